@@ -0,0 +1,380 @@
+package add
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/calypr/git-drs/internal/drsfilter"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/hashing"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recursive bool
+	jobs      int
+	dryRun    bool
+	verbose   bool
+	useMmap   bool
+)
+
+var (
+	gitTopLevel   = gitrepo.GitTopLevel
+	filterTracked = lfs.FilterTrackedPaths
+	lfsRootDir    = func(ctx context.Context) (string, error) {
+		_, root, err := lfs.GetGitRootDirectories(ctx)
+		return root, err
+	}
+	hashFiles    = hashing.HashFiles
+	storeFile    = drsfilter.StoreFile
+	stageInIndex = defaultStageInIndex
+
+	// progressOut receives hashing progress; nil disables it.
+	progressOut io.Writer = os.Stderr
+)
+
+// cleaned is one file converted to a pointer.
+type cleaned struct {
+	Path    string
+	OID     string
+	Size    int64
+	Mode    string
+	Pointer []byte
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "add <path>...",
+	Short: "Hash, store, and stage tracked files as DRS pointers",
+	Long: "Description:" +
+		"\n  Convert files matching the repository's tracking patterns into LFS" +
+		"\n  pointers and stage them, hashing in parallel. Content is stored in the" +
+		"\n  local object cache and a local DRS object is recorded for the next push." +
+		"\n  Use --recursive to import a directory tree; files that do not match a" +
+		"\n  tracking pattern are skipped and reported (see 'git drs track').",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires at least 1 argument (path), received 0\n\nUsage: %s\n\nSee 'git drs add --help' for more details", cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		// The root -v flag also lists each added file.
+		verbose = drslog.Verbosity() > 0
+		return run(ctx, cmd.OutOrStdout(), args)
+	},
+}
+
+func init() {
+	Cmd.Flags().BoolVarP(&recursive, "recursive", "R", false, "descend into directories")
+	Cmd.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "number of files to hash in parallel")
+	Cmd.Flags().BoolVar(&useMmap, "mmap", false, "memory-map files while hashing")
+	Cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "list files that would be added without writing")
+}
+
+func run(ctx context.Context, out io.Writer, args []string) error {
+	logger := drslog.GetLogger()
+	if jobs < 1 {
+		return fmt.Errorf("--jobs must be at least 1")
+	}
+	root, err := gitTopLevel()
+	if err != nil {
+		return err
+	}
+	candidates, err := collectPaths(root, args, recursive)
+	if err != nil {
+		return err
+	}
+	tracked, err := filterTracked(ctx, root, candidates)
+	if err != nil {
+		return err
+	}
+	skipped := difference(candidates, tracked)
+
+	if dryRun {
+		for _, p := range tracked {
+			fmt.Fprintf(out, "would add %s\n", p)
+		}
+		for _, p := range skipped {
+			fmt.Fprintf(out, "would skip %s (no tracking pattern)\n", p)
+		}
+		return nil
+	}
+
+	// Object paths are repo-relative, so clean and stage from the top level.
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(root); err != nil {
+		return err
+	}
+	defer os.Chdir(cwd)
+
+	lfsRoot, err := lfsRootDir(ctx)
+	if err != nil {
+		return fmt.Errorf("resolve LFS root: %w", err)
+	}
+	hashed, err := hashFiles(ctx, tracked, hashing.Options{Workers: jobs, Mmap: useMmap, Progress: progressOut})
+	if err != nil {
+		return err
+	}
+	files, err := storeAll(lfsRoot, hashed, jobs, logger)
+	if err != nil {
+		return err
+	}
+	if err := stageInIndex(ctx, root, files); err != nil {
+		return err
+	}
+	return writeSummary(out, files, skipped)
+}
+
+// collectPaths resolves arguments to sorted, slash-separated repo-relative
+// regular files. Directories require recursive; .git directories are skipped.
+func collectPaths(root string, args []string, recursive bool) ([]string, error) {
+	seen := make(map[string]struct{})
+	var paths []string
+	add := func(abs string) error {
+		rel, err := filepath.Rel(root, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s is outside the repository", abs)
+		}
+		rel = filepath.ToSlash(rel)
+		if _, ok := seen[rel]; !ok {
+			seen[rel] = struct{}{}
+			paths = append(paths, rel)
+		}
+		return nil
+	}
+	for _, arg := range args {
+		abs, err := filepath.Abs(arg)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Lstat(abs)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			if !info.Mode().IsRegular() {
+				return nil, fmt.Errorf("%s is not a regular file", arg)
+			}
+			if err := add(abs); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !recursive {
+			return nil, fmt.Errorf("%s is a directory (use --recursive)", arg)
+		}
+		err = filepath.WalkDir(abs, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			return add(p)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func difference(all, keep []string) []string {
+	kept := make(map[string]struct{}, len(keep))
+	for _, p := range keep {
+		kept[p] = struct{}{}
+	}
+	var out []string
+	for _, p := range all {
+		if _, ok := kept[p]; !ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// storeAll stores hashed files with n workers, keeping input order. Paths
+// sharing an oid are stored by the same worker so each object is copied once.
+func storeAll(lfsRoot string, hashed []hashing.Result, n int, logger *slog.Logger) ([]cleaned, error) {
+	groups := make(map[string][]int)
+	var order []string
+	for i, r := range hashed {
+		if _, ok := groups[r.OID]; !ok {
+			order = append(order, r.OID)
+		}
+		groups[r.OID] = append(groups[r.OID], i)
+	}
+
+	results := make([]cleaned, len(hashed))
+	errs := make([]error, len(hashed))
+	work := make(chan []int)
+	var wg sync.WaitGroup
+	for w := 0; w < n && w < len(order); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idxs := range work {
+				for _, i := range idxs {
+					results[i], errs[i] = storeOne(lfsRoot, hashed[i], logger)
+				}
+			}
+		}()
+	}
+	for _, oid := range order {
+		work <- groups[oid]
+	}
+	close(work)
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("add %s: %w", hashed[i].Path, err)
+		}
+	}
+	return results, nil
+}
+
+func storeOne(lfsRoot string, r hashing.Result, logger *slog.Logger) (cleaned, error) {
+	info, err := os.Stat(filepath.FromSlash(r.Path))
+	if err != nil {
+		return cleaned{}, err
+	}
+	pointer, err := storeFile(lfsRoot, r.Path, filepath.FromSlash(r.Path), r.OID, r.Size, logger)
+	if err != nil {
+		return cleaned{}, err
+	}
+	oid, size, ok := lfs.ParseLFSPointer(pointer)
+	if !ok {
+		return cleaned{}, fmt.Errorf("store produced an invalid pointer")
+	}
+	mode := "100644"
+	if info.Mode()&0o111 != 0 {
+		mode = "100755"
+	}
+	return cleaned{Path: r.Path, OID: oid, Size: size, Mode: mode, Pointer: pointer}, nil
+}
+
+// defaultStageInIndex writes each pointer as a blob and adds it to the index
+// without running the clean filter a second time.
+func defaultStageInIndex(ctx context.Context, root string, files []cleaned) error {
+	if len(files) == 0 {
+		return nil
+	}
+	tmpDir, err := os.MkdirTemp("", "git-drs-add-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var pointerPaths bytes.Buffer
+	for i, f := range files {
+		p := filepath.Join(tmpDir, fmt.Sprintf("%d", i))
+		if err := os.WriteFile(p, f.Pointer, 0o644); err != nil {
+			return err
+		}
+		pointerPaths.WriteString(p + "\n")
+	}
+	blobs, err := gitRun(ctx, root, &pointerPaths, "hash-object", "-w", "--no-filters", "--stdin-paths")
+	if err != nil {
+		return err
+	}
+	shas := strings.Fields(blobs)
+	if len(shas) != len(files) {
+		return fmt.Errorf("git hash-object returned %d blobs for %d files", len(shas), len(files))
+	}
+
+	var indexInfo bytes.Buffer
+	for i, f := range files {
+		fmt.Fprintf(&indexInfo, "%s %s\t%s\n", f.Mode, shas[i], f.Path)
+	}
+	_, err = gitRun(ctx, root, &indexInfo, "update-index", "--add", "--index-info")
+	return err
+}
+
+func gitRun(ctx context.Context, dir string, stdin io.Reader, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = stdin
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+func writeSummary(out io.Writer, files []cleaned, skipped []string) error {
+	var total, unique int64
+	oids := make(map[string]struct{}, len(files))
+	for _, f := range files {
+		total += f.Size
+		if _, ok := oids[f.OID]; !ok {
+			oids[f.OID] = struct{}{}
+			unique += f.Size
+		}
+		if verbose {
+			fmt.Fprintf(out, "add %s %s\n", shortOID(f.OID), f.Path)
+		}
+	}
+	fmt.Fprintf(out, "Added %d files (%s) as %d unique objects (%s)\n", len(files), humanBytes(total), len(oids), humanBytes(unique))
+	if len(skipped) > 0 {
+		fmt.Fprintf(out, "Skipped %d files that match no tracking pattern (see 'git drs track'):\n", len(skipped))
+		for _, p := range skipped {
+			fmt.Fprintf(out, "  %s\n", p)
+		}
+	}
+	return nil
+}
+
+func shortOID(oid string) string {
+	if len(oid) <= 10 {
+		return oid
+	}
+	return oid[:10]
+}
+
+func humanBytes(n int64) string {
+	const unit = int64(1024)
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := unit, 0
+	for q := n / unit; q >= unit; q /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,108 @@
+package add
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+func TestRunRecursiveAddsTrackedFilesAsPointers(t *testing.T) {
+	repo := t.TempDir()
+	runGitCmd(t, repo, "init")
+	if err := os.WriteFile(filepath.Join(repo, ".gitattributes"), []byte("*.bin filter=drs diff=drs merge=drs -text\n"), 0o644); err != nil {
+		t.Fatalf("write .gitattributes: %v", err)
+	}
+	files := map[string]string{
+		"dataset/a.bin":        "alpha",
+		"dataset/nested/b.bin": "beta",
+		"dataset/nested/c.bin": "alpha",
+		"dataset/README.txt":   "notes",
+	}
+	for rel, content := range files {
+		p := filepath.Join(repo, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	oldWD, _ := os.Getwd()
+	if err := os.Chdir(filepath.Join(repo, "dataset")); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldWD) })
+	oldTop := gitTopLevel
+	gitTopLevel = func() (string, error) { return repo, nil }
+	t.Cleanup(func() { gitTopLevel = oldTop })
+	oldProgress := progressOut
+	progressOut = nil
+	t.Cleanup(func() { progressOut = oldProgress })
+	recursive, jobs, dryRun, verbose, useMmap = true, 2, false, false, true
+	t.Cleanup(func() { recursive, jobs, useMmap = false, 1, false })
+
+	var out bytes.Buffer
+	if err := run(context.Background(), &out, []string{"."}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.Contains(out.String(), "Added 3 files (14 B) as 2 unique objects (9 B)") {
+		t.Fatalf("unexpected summary:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "dataset/README.txt") {
+		t.Fatalf("expected skipped README in summary:\n%s", out.String())
+	}
+
+	blob := gitOutput(t, repo, "cat-file", "-p", ":dataset/nested/b.bin")
+	oid, size, ok := lfs.ParseLFSPointer([]byte(blob))
+	if !ok || size != 4 {
+		t.Fatalf("expected staged pointer, got %q", blob)
+	}
+	cachePath, err := lfs.ObjectPath(filepath.Join(repo, common.LFS_OBJS_PATH), oid)
+	if err != nil {
+		t.Fatalf("object path: %v", err)
+	}
+	if data, err := os.ReadFile(cachePath); err != nil || string(data) != "beta" {
+		t.Fatalf("expected cached content, got %q (%v)", data, err)
+	}
+	if _, err := drsobject.ReadObject(filepath.Join(repo, common.DRS_OBJS_PATH), oid); err != nil {
+		t.Fatalf("expected local DRS object: %v", err)
+	}
+	if staged := gitOutput(t, repo, "ls-files", "dataset/README.txt"); staged != "" {
+		t.Fatalf("untracked-pattern file should not be staged: %q", staged)
+	}
+}
+
+func TestCollectPathsRequiresRecursiveForDirectories(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, "dir"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if _, err := collectPaths(repo, []string{filepath.Join(repo, "dir")}, false); err == nil || !strings.Contains(err.Error(), "--recursive") {
+		t.Fatalf("expected --recursive error, got %v", err)
+	}
+}
+
+func runGitCmd(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	gitOutput(t, dir, args...)
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, string(out))
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -8,7 +8,9 @@ import (
 
 	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsremote"
 	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	"github.com/spf13/cobra"
 )
 
@@ -16,8 +18,9 @@ var remote string
 var Cmd = &cobra.Command{
 	Use:   "add-ref <drs_uri> <dst path>",
 	Short: "Add a reference to an existing DRS object via URI",
-	Long:  "Add a reference to an existing DRS object via URI. Requires that the sha256 of the file is already in the cache",
-	Args:  cobra.ExactArgs(2),
+	Long: "Add a reference to an existing DRS object via URI. Requires that the sha256 of the file is already in the cache. " +
+		"On an AnVIL remote the URI is resolved through DRSHub, and objects without a sha256 are downloaded once to compute it",
+	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		drsUri := args[0]
 		dstPath := args[1]
@@ -42,7 +45,16 @@ var Cmd = &cobra.Command{
 			return err
 		}
 
-		obj, err := client.Client.DRS().GetObject(context.Background(), drsUri)
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		var obj *drsapi.DrsObject
+		if _, ok := cfg.GetRemote(remoteName).(*config.AnvilRemote); ok {
+			obj, err = resolveAnvil(ctx, client, drsUri)
+		} else {
+			obj, err = drsremote.ResolveObject(ctx, client, drsUri)
+		}
 		if err != nil {
 			return err
 		}
@@ -53,7 +65,7 @@ var Cmd = &cobra.Command{
 			os.MkdirAll(dirPath, os.ModePerm)
 		}
 
-		err = lfs.CreateLfsPointer(&obj, dstPath)
+		err = lfs.CreateLfsPointer(obj, dstPath)
 		return err
 	},
 }
@@ -0,0 +1,336 @@
+package addurl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	sycloud "github.com/calypr/syfon/client/cloud"
+	"github.com/spf13/cobra"
+)
+
+var AccessCmd = NewAccessCommand()
+
+// NewAccessCommand constructs the Cobra command for the `add-access`
+// subcommand.
+func NewAccessCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add-access <oid> <url>",
+		Short: "Add a mirror URL as an access method on an existing DRS record",
+		Long: "Description:" +
+			"\n  Append an s3://, gs://, az://, http(s):// or ftp:// URL as an access" +
+			"\n  method on the project record of an already registered object, for" +
+			"\n  example a mirrored copy at a partner site. The URL is checked first: it" +
+			"\n  must be reachable and report the record's size, and a sha256 it reports" +
+			"\n  must match the oid. A URL the record already lists is left alone.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				cmd.SilenceUsage = false
+				return fmt.Errorf("error: requires exactly 2 arguments (oid and URL), received %d\n\nUsage: %s\n\nSee 'git drs add-access --help' for more details", len(args), cmd.UseLine())
+			}
+			return nil
+		},
+		RunE: runAddAccess,
+	}
+	cmd.Flags().StringP("remote", "r", "", "remote whose project record is updated (default: default remote)")
+	return cmd
+}
+
+// runAddAccess is the Cobra RunE wrapper that delegates execution to the
+// service.
+func runAddAccess(cmd *cobra.Command, args []string) error {
+	return NewAddAccessService().Run(cmd, args)
+}
+
+// recordIndex is the subset of the Syfon index API add-access needs.
+type recordIndex interface {
+	Get(ctx context.Context, did string) (internalapi.InternalRecordResponse, error)
+	Update(ctx context.Context, did string, rec internalapi.InternalRecord) (internalapi.InternalRecordResponse, error)
+}
+
+// AddAccessService groups injectable dependencies used to implement the
+// add-access behavior.
+type AddAccessService struct {
+	loadConfig    func() (*config.Config, error)
+	newClient     func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error)
+	findRecords   func(ctx context.Context, drsCtx *config.GitContext, checksum string) ([]drsapi.DrsObject, error)
+	index         func(drsCtx *config.GitContext) recordIndex
+	inspectObject func(ctx context.Context, input sycloud.ObjectParameters, s3 config.S3Options) (*sycloud.ObjectInfo, error)
+	inspectWeb    func(ctx context.Context, rawURL, destinationPath string, computeSHA bool) (*sycloud.ObjectInfo, error)
+	inspectAzure  func(ctx context.Context, input sycloud.ObjectParameters, az config.AzureSettings) (*sycloud.ObjectInfo, error)
+	loadS3        func(remote string) (config.S3Settings, error)
+	loadAzure     func(remote string) (config.AzureSettings, error)
+	recordAudit   func(logger *slog.Logger, events ...audit.Event)
+}
+
+// NewAddAccessService constructs an AddAccessService populated with
+// production implementations of its dependencies.
+func NewAddAccessService() *AddAccessService {
+	return &AddAccessService{
+		loadConfig: config.LoadConfig,
+		newClient: func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+			return cfg.GetRemoteClient(remote, logger)
+		},
+		findRecords:   drsremote.ObjectsByHashForScope,
+		index:         func(drsCtx *config.GitContext) recordIndex { return drsCtx.Client.Index() },
+		inspectObject: inspectProviderObject,
+		inspectWeb:    inspectWebObject,
+		inspectAzure:  inspectAzureObject,
+		loadS3:        config.LoadS3Settings,
+		loadAzure:     config.LoadAzureSettings,
+		recordAudit:   audit.RecordOrWarn,
+	}
+}
+
+// addAccessResult is the `add-access --json` document.
+type addAccessResult struct {
+	Remote string `json:"remote"`
+	DRSID  string `json:"drs_id"`
+	OID    string `json:"oid"`
+	URL    string `json:"url"`
+	Type   string `json:"type"`
+	// Added is false when the record already listed the URL.
+	Added bool `json:"added"`
+}
+
+// Run checks the URL against the oid's project record and appends it as an
+// access method unless the record already lists it.
+func (s *AddAccessService) Run(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logger := drslog.GetLogger()
+
+	oid := strings.ToLower(drsobject.NormalizeOid(strings.TrimSpace(args[0])))
+	if !sha256Hex.MatchString(oid) {
+		return fmt.Errorf("invalid oid %q: expected a sha256 hex digest", args[0])
+	}
+	rawURL := strings.TrimSpace(args[1])
+	methodType, err := accessTypeForMirrorURL(rawURL)
+	if err != nil {
+		return err
+	}
+	remoteName, err := cmd.Flags().GetString("remote")
+	if err != nil {
+		return fmt.Errorf("read flag remote: %w", err)
+	}
+
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+	remote, err := cfg.GetRemoteOrDefault(remoteName)
+	if err != nil {
+		return err
+	}
+	drsCtx, err := s.newClient(cfg, remote, logger)
+	if err != nil {
+		return err
+	}
+	if err := drsCtx.RequireWrite(); err != nil {
+		return err
+	}
+
+	records, err := s.findRecords(ctx, drsCtx, oid)
+	if err != nil {
+		return fmt.Errorf("look up records for oid %s: %w", oid, err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no record for oid %s in %s; register the object before adding access methods", oid, scopeLabel(drsCtx))
+	}
+	record := records[0]
+	res := addAccessResult{Remote: string(remote), DRSID: record.Id, OID: oid, URL: redactRawURL(rawURL), Type: string(methodType)}
+
+	if !listsURL(record.AccessMethods, rawURL) {
+		if err := s.checkMirror(ctx, string(remote), rawURL, oid, record.Size); err != nil {
+			return err
+		}
+		added, err := appendAccessMethod(ctx, s.index(drsCtx), record.Id, drsapi.AccessMethod{
+			Type: methodType,
+			AccessUrl: &struct {
+				Headers *[]string `json:"headers,omitempty"`
+				Url     string    `json:"url"`
+			}{Url: rawURL},
+		})
+		if err != nil {
+			return fmt.Errorf("update record %s: %w", record.Id, err)
+		}
+		res.Added = added
+		if added {
+			s.recordAudit(logger, audit.Event{
+				Action:  audit.ActionUpdate,
+				Remote:  string(remote),
+				Project: drsCtx.ProjectId,
+				DRSID:   record.Id,
+				OID:     oid,
+				Detail:  "add-access " + redactRawURL(rawURL),
+			})
+		}
+	}
+	return writeAddAccessResult(cmd.OutOrStdout(), res)
+}
+
+// checkMirror confirms the URL is reachable and holds an object of the
+// record's size. A sha256 the source reports in its metadata must match the
+// oid; sources without one are accepted on size.
+func (s *AddAccessService) checkMirror(ctx context.Context, remote, rawURL, oid string, size int64) error {
+	var (
+		info *sycloud.ObjectInfo
+		err  error
+	)
+	if isWebSourceURL(rawURL) {
+		info, err = s.inspectWeb(ctx, rawURL, "", false)
+	} else if isAzureURL(rawURL) {
+		az, aerr := s.loadAzure(remote)
+		if aerr != nil {
+			return aerr
+		}
+		info, err = s.inspectAzure(ctx, buildObjectParameters(rawURL, "", oid), az)
+	} else {
+		s3Settings, serr := s.loadS3(remote)
+		if serr != nil {
+			return serr
+		}
+		var opts config.S3Options
+		if u, perr := url.Parse(rawURL); perr == nil {
+			opts = s3Settings.ForURL(u)
+		}
+		info, err = s.inspectObject(ctx, buildObjectParameters(rawURL, "", oid), opts)
+	}
+	if err != nil {
+		return fmt.Errorf("check %s: %w", redactRawURL(rawURL), err)
+	}
+	if info.SizeBytes != size {
+		return fmt.Errorf("size mismatch: %s holds %d bytes, the record for oid %s has %d", redactRawURL(rawURL), info.SizeBytes, oid, size)
+	}
+	if sha := strings.ToLower(drsobject.NormalizeChecksum(info.MetaSHA256)); sha != "" && sha != oid {
+		return fmt.Errorf("sha256 mismatch: %s reports %s, expected %s", redactRawURL(rawURL), sha, oid)
+	}
+	return nil
+}
+
+// appendAccessMethod adds method to the record unless an access method with
+// the same URL is already there, keeping every other field as stored. It
+// reports whether the record changed.
+func appendAccessMethod(ctx context.Context, idx recordIndex, did string, method drsapi.AccessMethod) (bool, error) {
+	cur, err := idx.Get(ctx, did)
+	if err != nil {
+		return false, err
+	}
+	if listsURL(cur.AccessMethods, method.AccessUrl.Url) {
+		return false, nil
+	}
+	var merged []drsapi.AccessMethod
+	if cur.AccessMethods != nil {
+		merged = append(merged, *cur.AccessMethods...)
+	}
+	merged = append(merged, method)
+	_, err = idx.Update(ctx, did, internalapi.InternalRecord{
+		Did:              cur.Did,
+		AccessMethods:    &merged,
+		ControlledAccess: cur.ControlledAccess,
+		Description:      cur.Description,
+		FileName:         cur.FileName,
+		Hashes:           cur.Hashes,
+		Organization:     cur.Organization,
+		Project:          cur.Project,
+		Size:             cur.Size,
+		Version:          cur.Version,
+	})
+	return err == nil, err
+}
+
+// accessTypeForMirrorURL returns the access method type recorded for a
+// mirror URL; gcs:// is treated as gs:// and Azure blobs as "az", as the
+// record builder does.
+func accessTypeForMirrorURL(raw string) (drsapi.AccessMethodType, error) {
+	if !looksLikeCloudURL(raw) {
+		return "", fmt.Errorf("unsupported URL %q: expected an s3://, gs://, az://, http(s):// or ftp:// URL with a host", raw)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		return "", fmt.Errorf("no object path in URL %s", redactURL(u))
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "s3":
+		return drsapi.AccessMethodTypeS3, nil
+	case "gs", "gcs":
+		return drsapi.AccessMethodTypeGs, nil
+	case "az", "azblob":
+		return drsapi.AccessMethodType("az"), nil
+	default:
+		return accessMethodTypeForURL(raw), nil
+	}
+}
+
+// listsURL reports whether methods already include raw. Scheme and host
+// compare case-insensitively; the rest of the URL must match exactly.
+func listsURL(methods *[]drsapi.AccessMethod, raw string) bool {
+	if methods == nil {
+		return false
+	}
+	want := canonicalURL(raw)
+	for _, m := range *methods {
+		if m.AccessUrl != nil && canonicalURL(m.AccessUrl.Url) == want {
+			return true
+		}
+	}
+	return false
+}
+
+func canonicalURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme == "gcs" {
+		u.Scheme = "gs"
+	}
+	u.Host = strings.ToLower(u.Host)
+	return u.String()
+}
+
+// redactRawURL is redactURL for a URL not yet parsed.
+func redactRawURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return redactURL(u)
+}
+
+func scopeLabel(drsCtx *config.GitContext) string {
+	if drsCtx.Organization == "" {
+		return "project " + drsCtx.ProjectId
+	}
+	return "project " + drsCtx.Organization + "/" + drsCtx.ProjectId
+}
+
+func writeAddAccessResult(w io.Writer, res addAccessResult) error {
+	if common.JSONOutput() {
+		return common.WriteJSON(w, res)
+	}
+	if !res.Added {
+		_, err := fmt.Fprintf(w, "Record %s already lists %s\n", res.DRSID, res.URL)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "Added %s access %s to record %s\n", res.Type, res.URL, res.DRSID)
+	return err
+}
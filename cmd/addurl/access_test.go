@@ -0,0 +1,151 @@
+package addurl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/config"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	sycloud "github.com/calypr/syfon/client/cloud"
+)
+
+type fakeRecordIndex struct {
+	rec     internalapi.InternalRecordResponse
+	updates []internalapi.InternalRecord
+}
+
+func (f *fakeRecordIndex) Get(context.Context, string) (internalapi.InternalRecordResponse, error) {
+	return f.rec, nil
+}
+
+func (f *fakeRecordIndex) Update(_ context.Context, _ string, rec internalapi.InternalRecord) (internalapi.InternalRecordResponse, error) {
+	f.updates = append(f.updates, rec)
+	return internalapi.InternalRecordResponse{Did: rec.Did, AccessMethods: rec.AccessMethods}, nil
+}
+
+func accessMethod(t drsapi.AccessMethodType, u string) drsapi.AccessMethod {
+	return drsapi.AccessMethod{Type: t, AccessUrl: &struct {
+		Headers *[]string `json:"headers,omitempty"`
+		Url     string    `json:"url"`
+	}{Url: u}}
+}
+
+func newTestAccessService(idx *fakeRecordIndex, record drsapi.DrsObject, info *sycloud.ObjectInfo, events *[]audit.Event) *AddAccessService {
+	return &AddAccessService{
+		loadConfig: func() (*config.Config, error) {
+			return &config.Config{DefaultRemote: "origin", Remotes: map[config.Remote]config.RemoteSelect{"origin": {Gen3: &config.Gen3Remote{}}}}, nil
+		},
+		newClient: func(*config.Config, config.Remote, *slog.Logger) (*config.GitContext, error) {
+			return &config.GitContext{RemoteName: "origin", Organization: "calypr", ProjectId: "proj"}, nil
+		},
+		findRecords: func(context.Context, *config.GitContext, string) ([]drsapi.DrsObject, error) {
+			return []drsapi.DrsObject{record}, nil
+		},
+		index: func(*config.GitContext) recordIndex { return idx },
+		inspectObject: func(context.Context, sycloud.ObjectParameters, config.S3Options) (*sycloud.ObjectInfo, error) {
+			return info, nil
+		},
+		inspectWeb: func(context.Context, string, string, bool) (*sycloud.ObjectInfo, error) {
+			return info, nil
+		},
+		inspectAzure: func(context.Context, sycloud.ObjectParameters, config.AzureSettings) (*sycloud.ObjectInfo, error) {
+			return info, nil
+		},
+		loadS3:      func(string) (config.S3Settings, error) { return config.S3Settings{}, nil },
+		loadAzure:   func(string) (config.AzureSettings, error) { return config.AzureSettings{}, nil },
+		recordAudit: func(_ *slog.Logger, ev ...audit.Event) { *events = append(*events, ev...) },
+	}
+}
+
+func TestAddAccessAppendsCheckedURL(t *testing.T) {
+	oid := strings.Repeat("a", 64)
+	existing := []drsapi.AccessMethod{accessMethod(drsapi.AccessMethodTypeS3, "s3://primary/data/a.bam")}
+	idx := &fakeRecordIndex{rec: internalapi.InternalRecordResponse{Did: "dg.1/a", AccessMethods: &existing}}
+	record := drsapi.DrsObject{Id: "dg.1/a", Size: 42, AccessMethods: &existing}
+	var events []audit.Event
+	svc := newTestAccessService(idx, record, &sycloud.ObjectInfo{SizeBytes: 42}, &events)
+
+	cmd := NewAccessCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := svc.Run(cmd, []string{oid, "https://mirror.example.org/data/a.bam"}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(idx.updates) != 1 {
+		t.Fatalf("expected one update, got %d", len(idx.updates))
+	}
+	methods := *idx.updates[0].AccessMethods
+	if len(methods) != 2 || methods[0].AccessUrl.Url != "s3://primary/data/a.bam" || methods[1].Type != drsapi.AccessMethodTypeHttps {
+		t.Fatalf("unexpected access methods %+v", methods)
+	}
+	if len(events) != 1 || events[0].Action != audit.ActionUpdate || events[0].DRSID != "dg.1/a" {
+		t.Fatalf("unexpected audit events %+v", events)
+	}
+	if !strings.Contains(out.String(), "Added https access") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	// A URL the record already lists is not written again or re-checked.
+	svc.inspectObject = func(context.Context, sycloud.ObjectParameters, config.S3Options) (*sycloud.ObjectInfo, error) {
+		return nil, errors.New("should not be inspected")
+	}
+	out.Reset()
+	if err := svc.Run(cmd, []string{oid, "S3://primary/data/a.bam"}); err != nil {
+		t.Fatalf("Run with listed URL: %v", err)
+	}
+	if len(idx.updates) != 1 || !strings.Contains(out.String(), "already lists") {
+		t.Fatalf("listed URL should be a no-op, got %d updates, %q", len(idx.updates), out.String())
+	}
+}
+
+func TestAddAccessRejectsMismatchedMirror(t *testing.T) {
+	oid := strings.Repeat("b", 64)
+	idx := &fakeRecordIndex{rec: internalapi.InternalRecordResponse{Did: "dg.1/b"}}
+	record := drsapi.DrsObject{Id: "dg.1/b", Size: 10}
+	var events []audit.Event
+
+	svc := newTestAccessService(idx, record, &sycloud.ObjectInfo{SizeBytes: 11}, &events)
+	err := svc.Run(NewAccessCommand(), []string{oid, "gs://mirror/b.bam"})
+	if err == nil || !strings.Contains(err.Error(), "size mismatch") {
+		t.Fatalf("expected size mismatch, got %v", err)
+	}
+
+	svc = newTestAccessService(idx, record, &sycloud.ObjectInfo{SizeBytes: 10, MetaSHA256: strings.Repeat("c", 64)}, &events)
+	err = svc.Run(NewAccessCommand(), []string{oid, "gs://mirror/b.bam"})
+	if err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+		t.Fatalf("expected sha256 mismatch, got %v", err)
+	}
+
+	if err := svc.Run(NewAccessCommand(), []string{oid, "file:///tmp/b.bam"}); err == nil {
+		t.Fatal("expected error for an unsupported scheme")
+	}
+	if len(idx.updates) != 0 || len(events) != 0 {
+		t.Fatalf("rejected mirrors must not update the record, got %d updates", len(idx.updates))
+	}
+}
+
+func TestAccessTypeForMirrorURL(t *testing.T) {
+	cases := map[string]drsapi.AccessMethodType{
+		"s3://bucket/key":                      drsapi.AccessMethodTypeS3,
+		"gcs://bucket/key":                     drsapi.AccessMethodTypeGs,
+		"azblob://account/container/key":       "az",
+		"az://container/key":                   "az",
+		"ftp://ftp.example.org/pub/key":        drsapi.AccessMethodTypeFtp,
+		"https://mirror.example.org/files/key": drsapi.AccessMethodTypeHttps,
+	}
+	for raw, want := range cases {
+		got, err := accessTypeForMirrorURL(raw)
+		if err != nil || got != want {
+			t.Errorf("%s: got %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, err := accessTypeForMirrorURL("https://mirror.example.org/"); err == nil {
+		t.Error("expected error for a URL without an object path")
+	}
+}
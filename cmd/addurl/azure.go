@@ -0,0 +1,83 @@
+package addurl
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsobject"
+	sycloud "github.com/calypr/syfon/client/cloud"
+	"gocloud.dev/blob/azureblob"
+)
+
+// isAzureURL reports whether raw names a blob in Azure storage: az://,
+// azblob://, or an https://<account>.blob.core.windows.net URL.
+func isAzureURL(raw string) bool {
+	_, ok := config.ParseAzureURL(raw)
+	return ok
+}
+
+// inspectAzureObject reads a blob's properties through the Azure SDK with
+// the remote's Azure account and credential.
+func inspectAzureObject(ctx context.Context, params sycloud.ObjectParameters, az config.AzureSettings) (*sycloud.ObjectInfo, error) {
+	obj, ok := config.ParseAzureURL(params.ObjectURL)
+	if !ok || obj.Key == "" {
+		return nil, fmt.Errorf("no blob name in URL: %s", params.ObjectURL)
+	}
+	client, err := config.NewAzureClient(az, obj.Account)
+	if err != nil {
+		return nil, err
+	}
+	props, err := client.ServiceClient().NewContainerClient(obj.Container).NewBlobClient(obj.Key).GetProperties(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("blob properties failed (container=%q blob=%q): %w", obj.Container, obj.Key, err)
+	}
+	md := make(map[string]string, len(props.Metadata))
+	for k, v := range props.Metadata {
+		if v != nil {
+			md[strings.ToLower(k)] = *v
+		}
+	}
+	metaSHA := metadataSHA256(md)
+	if expected := strings.ToLower(drsobject.NormalizeChecksum(params.SHA256)); expected != "" && metaSHA != "" && expected != metaSHA {
+		return nil, fmt.Errorf("sha256 mismatch: expected=%s blob.meta=%s", expected, metaSHA)
+	}
+	name := strings.TrimSpace(params.DestinationPath)
+	if name == "" {
+		name = path.Base(obj.Key)
+	}
+	info := &sycloud.ObjectInfo{
+		Bucket:     obj.Container,
+		Key:        obj.Key,
+		Path:       name,
+		MetaSHA256: metaSHA,
+	}
+	if props.ContentLength != nil {
+		info.SizeBytes = *props.ContentLength
+	}
+	if props.ETag != nil {
+		info.ETag = strings.Trim(string(*props.ETag), `"`)
+	}
+	if props.LastModified != nil {
+		info.LastModTime = *props.LastModified
+	}
+	return info, nil
+}
+
+// hashAzureObject is hashProviderObject for a blob read with the remote's
+// Azure account and credential.
+func hashAzureObject(ctx context.Context, params sycloud.ObjectParameters, info *sycloud.ObjectInfo, gitCommonDir string, az config.AzureSettings) (string, error) {
+	obj, _ := config.ParseAzureURL(params.ObjectURL)
+	client, err := config.NewAzureClient(az, obj.Account)
+	if err != nil {
+		return "", err
+	}
+	bucket, err := azureblob.OpenBucket(ctx, client.ServiceClient().NewContainerClient(info.Bucket), nil)
+	if err != nil {
+		return "", err
+	}
+	defer bucket.Close()
+	return hashBucketObject(ctx, bucket, params, info, gitCommonDir)
+}
@@ -14,8 +14,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/calypr/git-drs/internal/gitrepo"
 	"github.com/calypr/git-drs/internal/precommit_cache"
+	"github.com/calypr/git-drs/internal/projectdir"
 )
 
 // updatePrecommitCache updates the project's pre-commit cache with a mapping
@@ -114,37 +114,14 @@ func ensureCacheDirs(cache *precommit_cache.Cache, logger *slog.Logger) error {
 	return nil
 }
 
-// repoRelativePath converts a worktree path (absolute or relative) to a
-// repository-relative path. It resolves symlinks and ensures the path is
-// contained within the repository root.
+// repoRelativePath converts a worktree path, absolute or relative to the
+// working directory, to a repository-relative path. It resolves symlinks and
+// ensures the path is contained within the repository root.
 func repoRelativePath(pathArg string) (string, error) {
 	if pathArg == "" {
 		return "", errors.New("empty worktree path")
 	}
-	root, err := gitrepo.GitTopLevel()
-	if err != nil {
-		return "", err
-	}
-	root, err = filepath.EvalSymlinks(root)
-	if err != nil {
-		return "", err
-	}
-	clean := filepath.Clean(pathArg)
-	if filepath.IsAbs(clean) {
-		clean, err = filepath.EvalSymlinks(clean)
-		if err != nil {
-			return "", err
-		}
-		rel, err := filepath.Rel(root, clean)
-		if err != nil {
-			return "", err
-		}
-		if strings.HasPrefix(rel, "..") {
-			return "", fmt.Errorf("path %s is outside repo root %s", clean, root)
-		}
-		return filepath.ToSlash(rel), nil
-	}
-	return filepath.ToSlash(clean), nil
+	return projectdir.Rel(pathArg)
 }
 
 // cachePathEntryFile returns the filesystem path to the JSON path-entry file
@@ -0,0 +1,194 @@
+package addurl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+
+	sycloud "github.com/calypr/syfon/client/cloud"
+)
+
+// ftpConn is a minimal passive-mode FTP client: enough to log in, read a
+// file's size and modification time, and retrieve it.
+type ftpConn struct {
+	conn net.Conn
+	text *textproto.Conn
+	host string
+}
+
+func dialFTP(ctx context.Context, u *url.URL) (*ftpConn, error) {
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "21")
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("connect %s: %w", addr, err)
+	}
+	c := &ftpConn{conn: conn, text: textproto.NewConn(conn), host: u.Hostname()}
+	if _, _, err := c.text.ReadResponse(220); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("ftp greeting from %s: %w", addr, err)
+	}
+
+	user, pass := "anonymous", "anonymous@"
+	if u.User != nil {
+		user = u.User.Username()
+		if p, ok := u.User.Password(); ok {
+			pass = p
+		}
+	}
+	code, _, err := c.cmd(0, "USER %s", user)
+	if err == nil && code == 331 {
+		_, _, err = c.cmd(230, "PASS %s", pass)
+	} else if err == nil && code != 230 {
+		err = fmt.Errorf("unexpected reply %d to USER", code)
+	}
+	if err == nil {
+		_, _, err = c.cmd(200, "TYPE I")
+	}
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("ftp login to %s: %w", addr, err)
+	}
+	return c, nil
+}
+
+// cmd sends a command and reads its reply. expect 0 accepts any code.
+func (c *ftpConn) cmd(expect int, format string, args ...any) (int, string, error) {
+	if _, err := c.text.Cmd(format, args...); err != nil {
+		return 0, "", err
+	}
+	return c.text.ReadResponse(expect)
+}
+
+func (c *ftpConn) size(p string) (int64, error) {
+	_, msg, err := c.cmd(213, "SIZE %s", p)
+	if err != nil {
+		return -1, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(msg), 10, 64)
+}
+
+// dataConn opens a passive data connection, preferring EPSV.
+func (c *ftpConn) dataConn(ctx context.Context) (net.Conn, error) {
+	var addr string
+	if _, msg, err := c.cmd(229, "EPSV"); err == nil {
+		// 229 Entering Extended Passive Mode (|||port|)
+		start, end := strings.Index(msg, "(|||"), strings.LastIndex(msg, "|)")
+		if start < 0 || end <= start+4 {
+			return nil, fmt.Errorf("malformed EPSV reply %q", msg)
+		}
+		addr = net.JoinHostPort(c.host, msg[start+4:end])
+	} else {
+		_, msg, err := c.cmd(227, "PASV")
+		if err != nil {
+			return nil, err
+		}
+		// 227 Entering Passive Mode (h1,h2,h3,h4,p1,p2)
+		start, end := strings.Index(msg, "("), strings.LastIndex(msg, ")")
+		if start < 0 || end <= start {
+			return nil, fmt.Errorf("malformed PASV reply %q", msg)
+		}
+		parts := strings.Split(msg[start+1:end], ",")
+		if len(parts) != 6 {
+			return nil, fmt.Errorf("malformed PASV reply %q", msg)
+		}
+		hi, err1 := strconv.Atoi(strings.TrimSpace(parts[4]))
+		lo, err2 := strconv.Atoi(strings.TrimSpace(parts[5]))
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("malformed PASV reply %q", msg)
+		}
+		// Use the control host; servers behind NAT often advertise private IPs.
+		addr = net.JoinHostPort(c.host, strconv.Itoa(hi<<8|lo))
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", addr)
+}
+
+func (c *ftpConn) Close() error {
+	return c.conn.Close()
+}
+
+// ftpReader streams a RETR and checks the transfer-complete reply on Close.
+type ftpReader struct {
+	data   net.Conn
+	ctrl   *ftpConn
+	closed bool
+}
+
+func (r *ftpReader) Read(p []byte) (int, error) { return r.data.Read(p) }
+
+func (r *ftpReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.data.Close()
+	defer r.ctrl.Close()
+	_, _, err := r.ctrl.text.ReadResponse(226)
+	if err != nil {
+		return fmt.Errorf("ftp transfer: %w", err)
+	}
+	_, _ = r.ctrl.text.Cmd("QUIT")
+	return nil
+}
+
+func filePathFromURL(u *url.URL) string {
+	p := u.Path
+	if p == "" {
+		p = "/"
+	}
+	return p
+}
+
+// statFTP reads SIZE and MDTM. SizeBytes is -1 when the server lacks SIZE.
+func statFTP(ctx context.Context, u *url.URL) (*sycloud.ObjectInfo, error) {
+	c, err := dialFTP(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	p := filePathFromURL(u)
+	info := &sycloud.ObjectInfo{SizeBytes: -1}
+	if n, err := c.size(p); err == nil {
+		info.SizeBytes = n
+	}
+	if _, msg, err := c.cmd(213, "MDTM %s", p); err == nil {
+		if t, ok := parseFTPTime(msg); ok {
+			info.LastModTime = t
+		}
+	}
+	_, _ = c.text.Cmd("QUIT")
+	return info, nil
+}
+
+func openFTP(ctx context.Context, u *url.URL) (io.ReadCloser, error) {
+	c, err := dialFTP(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.dataConn(ctx)
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("ftp data connection to %s: %w", u.Host, err)
+	}
+	if _, err := c.text.Cmd("RETR %s", filePathFromURL(u)); err != nil {
+		data.Close()
+		c.Close()
+		return nil, err
+	}
+	code, msg, err := c.text.ReadResponse(1)
+	if err != nil {
+		data.Close()
+		c.Close()
+		return nil, fmt.Errorf("ftp RETR %s: %d %s", redactURL(u), code, msg)
+	}
+	return &ftpReader{data: data, ctrl: c}, nil
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/calypr/git-drs/internal/common"
 	sycloud "github.com/calypr/syfon/client/cloud"
 	"github.com/spf13/cobra"
 )
@@ -101,20 +102,11 @@ sha256 param  : %s
 }
 
 // writeJSONAtomic marshals `value` to JSON and writes it to `path` atomically
-// by writing to a temporary file in the same directory and renaming it. It
-// ensures parent directories exist.
+// with common.WriteFileAtomic. It ensures parent directories exist.
 func writeJSONAtomic(path string, value any) error {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return err
-	}
-	tmp := path + ".tmp"
 	data, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
-	if err := os.WriteFile(tmp, data, 0o644); err != nil {
-		return err
-	}
-	return os.Rename(tmp, path)
+	return common.WriteFileAtomic(path, data, 0o644)
 }
@@ -13,7 +13,7 @@ var Cmd = NewCommand()
 func NewCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "add-url <object-url-or-key> [path]",
-		Short: "Add a file from a provider URL or configured bucket object key",
+		Short: "Add a file from a provider, HTTP(S), or FTP URL, or a configured bucket object key",
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) < 1 || len(args) > 2 {
 				return errors.New("usage: add-url <object-url-or-key> [path]")
@@ -26,17 +26,23 @@ func NewCommand() *cobra.Command {
 	return cmd
 }
 
-// addFlags registers optional expected SHA256 checksum.
+// addFlags registers the optional expected SHA256 checksum, checksum
+// computation for web sources, and the object-key scheme.
 func addFlags(cmd *cobra.Command) {
 	cmd.Flags().String(
 		"sha256",
 		"",
 		"Expected SHA256 checksum (optional)",
 	)
+	cmd.Flags().Bool(
+		"compute-sha256",
+		false,
+		"Stream the source object once to compute its SHA256 (resumable for provider objects)",
+	)
 	cmd.Flags().String(
 		"scheme",
 		"",
-		"Storage scheme for object-key mode (for example: s3 or gs)",
+		"Storage scheme for object-key mode (s3, gs, or az)",
 	)
 }
 
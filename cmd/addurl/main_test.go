@@ -74,7 +74,7 @@ func TestRunAddURL_WritesPointerAndLFSObject(t *testing.T) {
 
 	service := NewAddURLService()
 	resetStubs := stubAddURLDeps(t, service,
-		func(ctx context.Context, in sycloud.ObjectParameters) (*sycloud.ObjectInfo, error) {
+		func(ctx context.Context, in sycloud.ObjectParameters, _ config.S3Options) (*sycloud.ObjectInfo, error) {
 			return &sycloud.ObjectInfo{
 				Bucket:      "bucket",
 				Key:         "path/to/file.bin",
@@ -235,6 +235,19 @@ func TestResolveObjectURL_UsesConfiguredBucketScopeForObjectKeyMode(t *testing.T
 	}
 }
 
+func TestResolveObjectURL_AzureObjectKeyMode(t *testing.T) {
+	input := addURLInput{sourceArg: "nested/file.bin", scheme: "az"}
+	for _, bucket := range []string{"reads", "az://reads"} {
+		got, err := resolveObjectURL(input, gitrepo.ResolvedBucketScope{Bucket: bucket, Prefix: "mapped"})
+		if err != nil {
+			t.Fatalf("resolveObjectURL: %v", err)
+		}
+		if got != "az://reads/mapped/nested/file.bin" {
+			t.Fatalf("bucket %s: unexpected object URL %s", bucket, got)
+		}
+	}
+}
+
 func TestResolveObjectURL_RejectsObjectKeyModeWithoutScheme(t *testing.T) {
 	_, err := resolveObjectURL(addURLInput{sourceArg: "nested/path/file.bin"}, gitrepo.ResolvedBucketScope{
 		Bucket: "mapped-bucket",
@@ -383,7 +396,7 @@ func TestUpdatePrecommitCacheContentChanged(t *testing.T) {
 func stubAddURLDeps(
 	t *testing.T,
 	service *AddURLService,
-	inspectFn func(context.Context, sycloud.ObjectParameters) (*sycloud.ObjectInfo, error),
+	inspectFn func(context.Context, sycloud.ObjectParameters, config.S3Options) (*sycloud.ObjectInfo, error),
 	isTrackedFn func(string) (bool, error),
 ) func() {
 	t.Helper()
@@ -0,0 +1,121 @@
+package addurl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/hashing"
+	"github.com/calypr/git-drs/internal/httpclient"
+	sycloud "github.com/calypr/syfon/client/cloud"
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/gcsblob"
+	"gocloud.dev/blob/s3blob"
+)
+
+// hashCheckpointDir holds resume state for interrupted --compute-sha256 runs,
+// relative to the git common dir.
+const hashCheckpointDir = "drs/hash-checkpoints"
+
+// hashProviderObject streams a provider object with parallel ranged reads and
+// returns its sha256. Progress is written to stderr; state is checkpointed
+// under gitCommonDir so a rerun resumes an interrupted hash.
+func hashProviderObject(ctx context.Context, params sycloud.ObjectParameters, info *sycloud.ObjectInfo, gitCommonDir string, s3opts config.S3Options) (string, error) {
+	bucket, err := openProviderBucket(ctx, params, info, s3opts)
+	if err != nil {
+		return "", err
+	}
+	defer bucket.Close()
+	return hashBucketObject(ctx, bucket, params, info, gitCommonDir)
+}
+
+// hashBucketObject hashes info's object in an opened bucket, checkpointing
+// under gitCommonDir.
+func hashBucketObject(ctx context.Context, bucket *blob.Bucket, params sycloud.ObjectParameters, info *sycloud.ObjectInfo, gitCommonDir string) (string, error) {
+	identity := fmt.Sprintf("%s|%s|%d", params.ObjectURL, info.ETag, info.SizeBytes)
+	opts := hashing.RangeOptions{Progress: os.Stderr, Identity: identity}
+	if gitCommonDir != "" {
+		sum := sha256.Sum256([]byte(params.ObjectURL))
+		opts.Checkpoint = filepath.Join(gitCommonDir, hashCheckpointDir, hex.EncodeToString(sum[:])+".json")
+	}
+	open := func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		return bucket.NewRangeReader(ctx, info.Key, offset, length, nil)
+	}
+	oid, err := hashing.HashRanges(ctx, info.SizeBytes, open, opts)
+	if err != nil {
+		return "", fmt.Errorf("compute sha256 of %s: %w", params.ObjectURL, err)
+	}
+	return oid, nil
+}
+
+// openProviderBucket opens the bucket holding an inspected object. S3 honors
+// the same region, endpoint, and credential hints as inspection, plus the
+// bucket's requester-pays and acceleration options. Azure blobs go through
+// hashAzureObject instead.
+func openProviderBucket(ctx context.Context, params sycloud.ObjectParameters, info *sycloud.ObjectInfo, opts config.S3Options) (*blob.Bucket, error) {
+	u, err := url.Parse(strings.TrimSpace(params.ObjectURL))
+	if err != nil {
+		return nil, err
+	}
+	host := strings.ToLower(u.Hostname())
+	switch strings.ToLower(u.Scheme) {
+	case "s3":
+		return openS3Bucket(ctx, info.Bucket, params, params.S3Endpoint, opts)
+	case "gs", "gcs":
+		return blob.OpenBucket(ctx, "gs://"+info.Bucket)
+	case "http", "https":
+		switch {
+		case host == "storage.googleapis.com" || strings.HasSuffix(host, ".storage.googleapis.com"):
+			return blob.OpenBucket(ctx, "gs://"+info.Bucket)
+		case strings.HasSuffix(host, ".amazonaws.com"):
+			return openS3Bucket(ctx, info.Bucket, params, params.S3Endpoint, opts)
+		case strings.Contains(host, "s3"):
+			return openS3Bucket(ctx, info.Bucket, params, u.Scheme+"://"+u.Host, opts)
+		}
+	}
+	return nil, fmt.Errorf("cannot read %s for hashing", params.ObjectURL)
+}
+
+func openS3Bucket(ctx context.Context, bucket string, params sycloud.ObjectParameters, endpoint string, opts config.S3Options) (*blob.Bucket, error) {
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	if region := strings.TrimSpace(params.S3Region); region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(region))
+	}
+	accessKey, secretKey := strings.TrimSpace(params.S3AccessKey), strings.TrimSpace(params.S3SecretKey)
+	if accessKey != "" || secretKey != "" {
+		if accessKey == "" || secretKey == "" {
+			return nil, errors.New("both AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required when either is set")
+		}
+		loadOpts = append(loadOpts, awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")))
+	}
+	awsHTTP, err := httpclient.AWSClient()
+	if err != nil {
+		return nil, err
+	}
+	loadOpts = append(loadOpts, awsconfig.WithHTTPClient(awsHTTP))
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+	var clientOpts []func(*s3.Options)
+	if endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/"); endpoint != "" {
+		clientOpts = append(clientOpts, func(o *s3.Options) {
+			o.UsePathStyle = true
+			o.BaseEndpoint = aws.String(endpoint)
+		})
+	}
+	clientOpts = append(clientOpts, opts.Apply)
+	return s3blob.OpenBucket(ctx, s3.NewFromConfig(awsCfg, clientOpts...), bucket, nil)
+}
@@ -7,6 +7,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/gitrepo"
 	sycloud "github.com/calypr/syfon/client/cloud"
 	"github.com/spf13/cobra"
@@ -16,9 +17,15 @@ import (
 type addURLInput struct {
 	sourceArg string
 	objectURL string
-	path      string
-	sha256    string
-	scheme    string
+	// path is where the pointer is written, relative to the working
+	// directory; repoPath is the same file relative to the repository root,
+	// which records and path scopes are keyed on.
+	path     string
+	repoPath string
+	sha256   string
+	scheme   string
+	// computeSHA streams HTTP(S) and FTP sources to compute their sha256.
+	computeSHA bool
 }
 
 // parseAddURLInput parses CLI args and flags into an addURLInput.
@@ -39,11 +46,17 @@ func parseAddURLInput(cmd *cobra.Command, args []string) (addURLInput, error) {
 		return addURLInput{}, fmt.Errorf("read flag scheme: %w", err)
 	}
 
+	computeSHA, err := cmd.Flags().GetBool("compute-sha256")
+	if err != nil {
+		return addURLInput{}, fmt.Errorf("read flag compute-sha256: %w", err)
+	}
+
 	return addURLInput{
-		sourceArg: sourceArg,
-		path:      pathArg,
-		sha256:    sha256Param,
-		scheme:    strings.ToLower(strings.TrimSpace(scheme)),
+		sourceArg:  sourceArg,
+		path:       pathArg,
+		sha256:     sha256Param,
+		scheme:     strings.ToLower(strings.TrimSpace(scheme)),
+		computeSHA: computeSHA,
 	}, nil
 }
 
@@ -84,7 +97,7 @@ func looksLikeCloudURL(raw string) bool {
 		return false
 	}
 	switch strings.ToLower(strings.TrimSpace(u.Scheme)) {
-	case "s3", "gs", "gcs", "azblob", "http", "https":
+	case "s3", "gs", "gcs", "az", "azblob", "http", "https", "ftp":
 		return strings.TrimSpace(u.Host) != ""
 	default:
 		return false
@@ -105,9 +118,14 @@ func resolveObjectURL(input addURLInput, scope gitrepo.ResolvedBucketScope) (str
 	case "gs", "gcs":
 		return fmt.Sprintf("gs://%s/%s", scope.Bucket, key), nil
 	case "azblob", "az":
-		return "", fmt.Errorf("object key mode for Azure requires a full azblob:// URL because the local mapping does not store account_name")
+		// The storage account comes from the remote's Azure settings.
+		container := scope.Bucket
+		if c, ok := config.AzureContainer(scope.Bucket); ok {
+			container = c
+		}
+		return fmt.Sprintf("az://%s/%s", container, key), nil
 	default:
-		return "", fmt.Errorf("unsupported --scheme %q (expected s3 or gs, or pass a full object URL)", input.scheme)
+		return "", fmt.Errorf("unsupported --scheme %q (expected s3, gs, or az, or pass a full object URL)", input.scheme)
 	}
 }
 
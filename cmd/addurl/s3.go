@@ -0,0 +1,79 @@
+package addurl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsobject"
+	sycloud "github.com/calypr/syfon/client/cloud"
+)
+
+var sha256Hex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// inspectProviderObject inspects a provider object. S3 objects whose bucket
+// is requester-pays or accelerated are read with a client carrying those
+// options; everything else goes through the provider inspector.
+func inspectProviderObject(ctx context.Context, params sycloud.ObjectParameters, opts config.S3Options) (*sycloud.ObjectInfo, error) {
+	if opts == (config.S3Options{}) {
+		return sycloud.InspectObject(ctx, params)
+	}
+	u, err := url.Parse(strings.TrimSpace(params.ObjectURL))
+	if err != nil {
+		return nil, err
+	}
+	bucketName, ok := config.S3BucketFromURL(u)
+	if !ok {
+		return sycloud.InspectObject(ctx, params)
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if !strings.EqualFold(u.Scheme, "s3") && !strings.HasPrefix(strings.ToLower(u.Hostname()), bucketName+".") {
+		// Path-style URL: the first segment is the bucket.
+		key = strings.TrimPrefix(key, bucketName+"/")
+	}
+	if key == "" {
+		return nil, fmt.Errorf("no object key in URL: %s", params.ObjectURL)
+	}
+	name := strings.TrimSpace(params.DestinationPath)
+	if name == "" {
+		name = path.Base(key)
+	}
+
+	bucket, err := openProviderBucket(ctx, params, &sycloud.ObjectInfo{Bucket: bucketName}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer bucket.Close()
+	attrs, err := bucket.Attributes(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("blob attributes failed (bucket=%q key=%q): %w", bucketName, key, err)
+	}
+	metaSHA := metadataSHA256(attrs.Metadata)
+	if expected := strings.ToLower(drsobject.NormalizeChecksum(params.SHA256)); expected != "" && metaSHA != "" && expected != metaSHA {
+		return nil, fmt.Errorf("sha256 mismatch: expected=%s head.meta=%s", expected, metaSHA)
+	}
+	return &sycloud.ObjectInfo{
+		Bucket:      bucketName,
+		Key:         key,
+		Path:        name,
+		SizeBytes:   attrs.Size,
+		MetaSHA256:  metaSHA,
+		ETag:        strings.Trim(strings.TrimSpace(attrs.ETag), `"`),
+		LastModTime: attrs.ModTime,
+	}, nil
+}
+
+// metadataSHA256 returns a sha256 recorded in object metadata under one of
+// the keys uploaders commonly use.
+func metadataSHA256(md map[string]string) string {
+	for _, k := range []string{"sha256", "checksum-sha256", "content-sha256", "oid-sha256", "git-lfs-sha256"} {
+		if v := strings.ToLower(drsobject.NormalizeChecksum(md[k])); sha256Hex.MatchString(v) {
+			return v
+		}
+	}
+	return ""
+}
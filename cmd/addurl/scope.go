@@ -7,9 +7,15 @@ import (
 	"github.com/calypr/git-drs/internal/gitrepo"
 )
 
-func resolveTargetScope(remoteConfig config.DRSRemote) (organization string, project string, scope gitrepo.ResolvedBucketScope, err error) {
+// resolveTargetScope returns the scope a record for path is registered in:
+// the path scope containing path, or the remote's own scope.
+func resolveTargetScope(remoteConfig config.DRSRemote, path string) (organization string, project string, scope gitrepo.ResolvedBucketScope, err error) {
 	organization = remoteConfig.GetOrganization()
 	project = remoteConfig.GetProjectId()
+	storagePrefix := remoteConfig.GetStoragePrefix()
+	if ps, ok := config.MatchPathScope(config.RemotePathScopes(remoteConfig), path); ok {
+		organization, project, storagePrefix = ps.Organization, ps.Project, ""
+	}
 	if project == "" {
 		return "", "", gitrepo.ResolvedBucketScope{}, fmt.Errorf("target project is required (set remote project)")
 	}
@@ -18,7 +24,7 @@ func resolveTargetScope(remoteConfig config.DRSRemote) (organization string, pro
 		organization,
 		project,
 		remoteConfig.GetBucketName(),
-		remoteConfig.GetStoragePrefix(),
+		storagePrefix,
 	)
 	if err != nil {
 		return "", "", gitrepo.ResolvedBucketScope{}, err
@@ -40,7 +40,7 @@ func TestResolveTargetScope_DefaultFallsBackToRemoteConfig(t *testing.T) {
 		bucket:       "remote-bucket",
 		prefix:       "remote/prefix",
 	}
-	org, project, scope, err := resolveTargetScope(remote)
+	org, project, scope, err := resolveTargetScope(remote, "data/file.bin")
 	if err != nil {
 		t.Fatalf("resolveTargetScope: %v", err)
 	}
@@ -75,7 +75,7 @@ func TestResolveTargetScope_UsesBucketMapping(t *testing.T) {
 		bucket:       "remote-bucket",
 		prefix:       "remote/prefix",
 	}
-	org, project, scope, err := resolveTargetScope(remote)
+	org, project, scope, err := resolveTargetScope(remote, "data/file.bin")
 	if err != nil {
 		t.Fatalf("resolveTargetScope: %v", err)
 	}
@@ -86,3 +86,23 @@ func TestResolveTargetScope_UsesBucketMapping(t *testing.T) {
 		t.Fatalf("unexpected bucket scope: %+v", scope)
 	}
 }
+
+func TestResolveTargetScope_UsesPathScope(t *testing.T) {
+	remote := &config.Gen3Remote{
+		ProjectID:     "main",
+		Organization:  "org-a",
+		Bucket:        "remote-bucket",
+		StoragePrefix: "remote/prefix",
+		PathScopes:    []config.PathScope{{Prefix: "raw", Organization: "org-a", Project: "rawdata"}},
+	}
+	org, project, scope, err := resolveTargetScope(remote, "raw/sample.bam")
+	if err != nil {
+		t.Fatalf("resolveTargetScope: %v", err)
+	}
+	if org != "org-a" || project != "rawdata" {
+		t.Fatalf("unexpected scope target: org=%s project=%s", org, project)
+	}
+	if scope.Bucket != "remote-bucket" || scope.Prefix != "" {
+		t.Fatalf("unexpected bucket scope: %+v", scope)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"strings"
 
 	"github.com/calypr/git-drs/internal/common"
@@ -15,7 +16,6 @@ import (
 	"github.com/calypr/git-drs/internal/lfs"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	sycloud "github.com/calypr/syfon/client/cloud"
-	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
@@ -23,11 +23,17 @@ import (
 // behavior (logger factory, object inspection, LFS helpers, config loader, etc.).
 type AddURLService struct {
 	newLogger     func(string, bool) (*slog.Logger, error)
-	inspectObject func(ctx context.Context, input sycloud.ObjectParameters) (*sycloud.ObjectInfo, error)
+	inspectObject func(ctx context.Context, input sycloud.ObjectParameters, s3 config.S3Options) (*sycloud.ObjectInfo, error)
+	inspectWeb    func(ctx context.Context, rawURL, destinationPath string, computeSHA bool) (*sycloud.ObjectInfo, error)
+	hashObject    func(ctx context.Context, params sycloud.ObjectParameters, info *sycloud.ObjectInfo, gitCommonDir string, s3 config.S3Options) (string, error)
+	inspectAzure  func(ctx context.Context, input sycloud.ObjectParameters, az config.AzureSettings) (*sycloud.ObjectInfo, error)
+	hashAzure     func(ctx context.Context, params sycloud.ObjectParameters, info *sycloud.ObjectInfo, gitCommonDir string, az config.AzureSettings) (string, error)
 	isLFSTracked  func(path string) (bool, error)
 	getGitRoots   func(ctx context.Context) (string, string, error)
 	gitLFSTrack   func(ctx context.Context, path string) (bool, error)
 	loadConfig    func() (*config.Config, error)
+	loadS3        func(remote string) (config.S3Settings, error)
+	loadAzure     func(remote string) (config.AzureSettings, error)
 }
 
 // NewAddURLService constructs an AddURLService populated with production
@@ -35,11 +41,17 @@ type AddURLService struct {
 func NewAddURLService() *AddURLService {
 	return &AddURLService{
 		newLogger:     drslog.NewLogger,
-		inspectObject: sycloud.InspectObject,
+		inspectObject: inspectProviderObject,
+		inspectWeb:    inspectWebObject,
+		hashObject:    hashProviderObject,
+		inspectAzure:  inspectAzureObject,
+		hashAzure:     hashAzureObject,
 		isLFSTracked:  lfs.IsLFSTracked,
 		getGitRoots:   lfs.GetGitRootDirectories,
 		gitLFSTrack:   drstrack.TrackReadOnly,
 		loadConfig:    config.LoadConfig,
+		loadS3:        config.LoadS3Settings,
+		loadAzure:     config.LoadAzureSettings,
 	}
 }
 
@@ -66,7 +78,7 @@ func (s *AddURLService) Run(cmd *cobra.Command, args []string) error {
 
 	cfg, err := s.loadConfig()
 	if err != nil {
-		return fmt.Errorf("error getting config: %v", err)
+		return fmt.Errorf("error getting config: %w", err)
 	}
 
 	remote, err := cfg.GetDefaultRemote()
@@ -79,24 +91,23 @@ func (s *AddURLService) Run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("error getting remote configuration for %s", remote)
 	}
 
-	org, project, scope, err := resolveTargetScope(remoteConfig)
+	input.repoPath, err = repoRelativePath(input.path)
 	if err != nil {
 		return err
 	}
 
-	input.objectURL, err = resolveObjectURL(input, scope)
+	org, project, scope, err := resolveTargetScope(remoteConfig, input.repoPath)
 	if err != nil {
 		return err
 	}
-
-	objectInfo, err := s.inspectObject(ctx, buildObjectParameters(input.objectURL, input.path, input.sha256))
+	ids, err := config.LoadIDMinter(string(remote))
 	if err != nil {
 		return err
 	}
 
-	isTracked, err := s.isLFSTracked(input.path)
+	input.objectURL, err = resolveObjectURL(input, scope)
 	if err != nil {
-		return fmt.Errorf("check LFS tracking for %s: %w", input.path, err)
+		return err
 	}
 
 	gitCommonDir, lfsRoot, err := s.getGitRoots(ctx)
@@ -104,6 +115,24 @@ func (s *AddURLService) Run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("get git root directories: %w", err)
 	}
 
+	s3Settings, err := s.loadS3(string(remote))
+	if err != nil {
+		return err
+	}
+	azSettings, err := s.loadAzure(string(remote))
+	if err != nil {
+		return err
+	}
+	objectInfo, err := s.inspectSource(ctx, &input, gitCommonDir, s3Settings, azSettings)
+	if err != nil {
+		return err
+	}
+
+	isTracked, err := s.isLFSTracked(input.path)
+	if err != nil {
+		return fmt.Errorf("check LFS tracking for %s: %w", input.path, err)
+	}
+
 	if err := printResolvedInfo(cmd, gitCommonDir, lfsRoot, objectInfo, input.path, isTracked, input.sha256); err != nil {
 		return err
 	}
@@ -128,9 +157,10 @@ func (s *AddURLService) Run(cmd *cobra.Command, args []string) error {
 	builder := drsobject.NewBuilder(scope.Bucket, project)
 	builder.Organization = org
 	builder.StoragePrefix = scope.Prefix
+	builder.IDs = ids
 
 	file := addURLDrsFile{
-		Name: input.path,
+		Name: input.repoPath,
 		Size: objectInfo.SizeBytes,
 		Oid:  oid,
 	}
@@ -141,6 +171,67 @@ func (s *AddURLService) Run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// inspectSource resolves object metadata through the provider inspector, or
+// directly for plain HTTP(S) and FTP sources. With --compute-sha256 the
+// object is streamed once and the computed sha256 becomes input.sha256. S3
+// sources are read with the options s3 configures for their bucket, and
+// Azure blobs with the account and credential in az.
+func (s *AddURLService) inspectSource(ctx context.Context, input *addURLInput, gitCommonDir string, s3 config.S3Settings, az config.AzureSettings) (*sycloud.ObjectInfo, error) {
+	var (
+		objectInfo *sycloud.ObjectInfo
+		computed   string
+		err        error
+	)
+	if isWebSourceURL(input.objectURL) {
+		objectInfo, err = s.inspectWeb(ctx, input.objectURL, input.path, input.computeSHA)
+		if err != nil {
+			return nil, err
+		}
+		computed = objectInfo.MetaSHA256
+	} else if isAzureURL(input.objectURL) {
+		params := buildObjectParameters(input.objectURL, input.path, input.sha256)
+		objectInfo, err = s.inspectAzure(ctx, params, az)
+		if err != nil {
+			return nil, err
+		}
+		if input.computeSHA {
+			computed, err = s.hashAzure(ctx, params, objectInfo, gitCommonDir, az)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		params := buildObjectParameters(input.objectURL, input.path, input.sha256)
+		var opts config.S3Options
+		if u, perr := url.Parse(strings.TrimSpace(input.objectURL)); perr == nil {
+			opts = s3.ForURL(u)
+		}
+		objectInfo, err = s.inspectObject(ctx, params, opts)
+		if err != nil {
+			return nil, err
+		}
+		if input.computeSHA {
+			computed, err = s.hashObject(ctx, params, objectInfo, gitCommonDir, opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if computed != "" {
+		if input.sha256 != "" && !strings.EqualFold(drsobject.NormalizeChecksum(input.sha256), computed) {
+			return nil, fmt.Errorf("sha256 mismatch: expected=%s computed=%s", input.sha256, computed)
+		}
+		input.sha256 = computed
+	}
+	if !isWebSourceURL(input.objectURL) {
+		return objectInfo, nil
+	}
+	if input.sha256 == "" && strings.TrimSpace(objectInfo.ETag) == "" {
+		return nil, fmt.Errorf("%s has no ETag to identify it; pass --sha256 or --compute-sha256", input.objectURL)
+	}
+	return objectInfo, nil
+}
+
 type addURLDrsFile struct {
 	Name string
 	Size int64
@@ -156,7 +247,7 @@ func writeAddURLDrsObject(builder drsobject.Builder, file addURLDrsFile, objectP
 		drsObj.Name = &name
 		drsObj.Size = file.Size
 	} else {
-		drsID := uuid.NewSHA1(drsobject.UUIDNamespace, []byte(fmt.Sprintf("%s:%s", builder.Project, drsobject.NormalizeOid(file.Oid)))).String()
+		drsID := builder.ID(file.Name, file.Oid)
 		drsObj, err = builder.Build(file.Name, file.Oid, file.Size, drsID)
 		if err != nil {
 			return nil, fmt.Errorf("error building DRS object for oid %s: %w", file.Oid, err)
@@ -164,15 +255,22 @@ func writeAddURLDrsObject(builder drsobject.Builder, file addURLDrsFile, objectP
 	}
 
 	if objectPath != "" {
+		methodType := accessMethodTypeForURL(objectPath)
 		if drsObj.AccessMethods != nil && len(*drsObj.AccessMethods) > 0 {
 			am := &(*drsObj.AccessMethods)[0]
 			am.AccessUrl = &struct {
 				Headers *[]string `json:"headers,omitempty"`
 				Url     string    `json:"url"`
 			}{Url: objectPath}
+			if methodType != "" {
+				am.Type = methodType
+			}
 		} else {
+			if methodType == "" {
+				methodType = drsapi.AccessMethodTypeS3
+			}
 			drsObj.AccessMethods = &[]drsapi.AccessMethod{{
-				Type: drsapi.AccessMethodTypeS3,
+				Type: methodType,
 				AccessUrl: &struct {
 					Headers *[]string `json:"headers,omitempty"`
 					Url     string    `json:"url"`
@@ -0,0 +1,228 @@
+package addurl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/httpclient"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	sycloud "github.com/calypr/syfon/client/cloud"
+)
+
+// webHTTPClient performs requests against plain HTTP(S) sources.
+var webHTTPClient = httpclient.New(0)
+
+// isWebSourceURL reports whether raw names a plain HTTP(S) or FTP source
+// rather than an object in provider storage.
+func isWebSourceURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || strings.TrimSpace(u.Host) == "" {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "ftp":
+		return true
+	case "http", "https":
+		return !isCloudStorageHost(u.Hostname())
+	default:
+		return false
+	}
+}
+
+// isCloudStorageHost matches the HTTP(S) hosts the provider inspector
+// resolves to a bucket: AWS, GCS, Azure blob, and S3-compatible endpoints.
+func isCloudStorageHost(host string) bool {
+	host = strings.ToLower(strings.TrimSpace(host))
+	return strings.HasSuffix(host, ".amazonaws.com") ||
+		host == "storage.googleapis.com" ||
+		strings.HasSuffix(host, ".storage.googleapis.com") ||
+		strings.HasSuffix(host, ".blob.core.windows.net") ||
+		strings.Contains(host, "s3")
+}
+
+// accessMethodTypeForURL returns the DRS access method type for a web source,
+// or "" for provider URLs whose type the builder already sets.
+func accessMethodTypeForURL(raw string) drsapi.AccessMethodType {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return drsapi.AccessMethodTypeHttps
+	case "ftp":
+		return drsapi.AccessMethodTypeFtp
+	default:
+		return ""
+	}
+}
+
+// inspectWebObject resolves size and identity for an HTTP(S) or FTP source.
+// When computeSHA is set the content is streamed once and its sha256 is
+// returned in MetaSHA256; nothing is written locally.
+func inspectWebObject(ctx context.Context, rawURL, destinationPath string, computeSHA bool) (*sycloud.ObjectInfo, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, err
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	name := strings.TrimSpace(destinationPath)
+	if name == "" {
+		name = path.Base(key)
+	}
+	if key == "" || name == "" || name == "." || name == "/" {
+		return nil, fmt.Errorf("no file path in URL: %s", redactURL(u))
+	}
+
+	var (
+		info *sycloud.ObjectInfo
+		open func() (io.ReadCloser, error)
+	)
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		info, err = statHTTP(ctx, u)
+		open = func() (io.ReadCloser, error) { return openHTTP(ctx, u) }
+	case "ftp":
+		info, err = statFTP(ctx, u)
+		open = func() (io.ReadCloser, error) { return openFTP(ctx, u) }
+	default:
+		return nil, fmt.Errorf("unsupported source scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	info.Bucket = u.Host
+	info.Key = key
+	info.Path = name
+
+	if computeSHA {
+		body, err := open()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		h := sha256.New()
+		n, err := io.Copy(h, body)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", redactURL(u), err)
+		}
+		if err := body.Close(); err != nil {
+			return nil, fmt.Errorf("read %s: %w", redactURL(u), err)
+		}
+		if info.SizeBytes >= 0 && n != info.SizeBytes {
+			return nil, fmt.Errorf("read %d bytes from %s, server reported %d", n, redactURL(u), info.SizeBytes)
+		}
+		info.SizeBytes = n
+		info.MetaSHA256 = hex.EncodeToString(h.Sum(nil))
+	}
+	if info.SizeBytes < 0 {
+		return nil, fmt.Errorf("%s did not report a size; pass --compute-sha256 to read it", redactURL(u))
+	}
+	return info, nil
+}
+
+// statHTTP reads size, ETag, and Last-Modified with a HEAD request, falling
+// back to a one-byte ranged GET for servers that reject HEAD or omit the
+// length. SizeBytes is -1 when neither reports it.
+func statHTTP(ctx context.Context, u *url.URL) (*sycloud.ObjectInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := webHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HEAD %s: %w", redactURL(u), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 == 2 && resp.ContentLength >= 0 {
+		return httpObjectInfo(resp, resp.ContentLength), nil
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	resp, err = webHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", redactURL(u), err)
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		return httpObjectInfo(resp, contentRangeTotal(resp.Header.Get("Content-Range"))), nil
+	case http.StatusOK:
+		return httpObjectInfo(resp, resp.ContentLength), nil
+	default:
+		return nil, fmt.Errorf("GET %s: %s", redactURL(u), resp.Status)
+	}
+}
+
+func httpObjectInfo(resp *http.Response, size int64) *sycloud.ObjectInfo {
+	info := &sycloud.ObjectInfo{
+		SizeBytes: size,
+		ETag:      strings.Trim(strings.TrimPrefix(strings.TrimSpace(resp.Header.Get("ETag")), "W/"), `"`),
+	}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModTime = t
+	}
+	return info
+}
+
+// contentRangeTotal parses the complete length from "bytes 0-0/1234", or
+// returns -1 when it is absent or "*".
+func contentRangeTotal(header string) int64 {
+	_, total, ok := strings.Cut(header, "/")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(total), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func openHTTP(ctx context.Context, u *url.URL) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := webHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", redactURL(u), err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", redactURL(u), resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// redactURL drops credentials so they never reach logs or errors.
+func redactURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	return c.String()
+}
+
+// ftpTimeFormat is the MDTM response layout (RFC 3659).
+const ftpTimeFormat = "20060102150405"
+
+func parseFTPTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		s = s[:i]
+	}
+	t, err := time.Parse(ftpTimeFormat, s)
+	return t, err == nil
+}
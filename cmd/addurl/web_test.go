@@ -0,0 +1,282 @@
+package addurl
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	sycloud "github.com/calypr/syfon/client/cloud"
+)
+
+func TestIsWebSourceURL(t *testing.T) {
+	cases := map[string]bool{
+		"https://example.org/data/reads.bam":                     true,
+		"http://example.org/reads.bam":                           true,
+		"ftp://ftp.example.org/pub/reads.bam":                    true,
+		"https://bucket.s3.amazonaws.com/reads.bam":              false,
+		"https://storage.googleapis.com/bucket/reads.bam":        false,
+		"https://acct.blob.core.windows.net/container/reads.bam": false,
+		"s3://bucket/reads.bam":                                  false,
+		"reads.bam":                                              false,
+	}
+	for raw, want := range cases {
+		if got := isWebSourceURL(raw); got != want {
+			t.Errorf("isWebSourceURL(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestInspectWebObject_HTTPHeadAndComputeSHA(t *testing.T) {
+	content := "hello from the web\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `W/"abc123"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(content))
+		}
+	}))
+	defer srv.Close()
+
+	info, err := inspectWebObject(context.Background(), srv.URL+"/data/hello.txt", "", false)
+	if err != nil {
+		t.Fatalf("inspectWebObject: %v", err)
+	}
+	if info.SizeBytes != int64(len(content)) || info.ETag != "abc123" || info.Path != "hello.txt" || info.MetaSHA256 != "" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if info.LastModTime.Year() != 2006 {
+		t.Fatalf("Last-Modified not parsed: %v", info.LastModTime)
+	}
+
+	info, err = inspectWebObject(context.Background(), srv.URL+"/data/hello.txt", "dest/hello.txt", true)
+	if err != nil {
+		t.Fatalf("inspectWebObject with sha: %v", err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	if info.MetaSHA256 != hex.EncodeToString(sum[:]) || info.Path != "dest/hello.txt" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+}
+
+func TestInspectWebObject_HTTPRangedGetFallback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Range") != "bytes=0-0" {
+			t.Errorf("expected ranged GET, got Range=%q", r.Header.Get("Range"))
+		}
+		w.Header().Set("ETag", `"etag-1"`)
+		w.Header().Set("Content-Range", "bytes 0-0/4096")
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write([]byte("x"))
+	}))
+	defer srv.Close()
+
+	info, err := inspectWebObject(context.Background(), srv.URL+"/big.bin", "", false)
+	if err != nil {
+		t.Fatalf("inspectWebObject: %v", err)
+	}
+	if info.SizeBytes != 4096 || info.ETag != "etag-1" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+}
+
+// serveFakeFTP answers one control session with a fixed file, enough for
+// statFTP and openFTP.
+func serveFakeFTP(t *testing.T, content string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go handleFakeFTP(conn, content)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func handleFakeFTP(conn net.Conn, content string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(format string, args ...any) { fmt.Fprintf(conn, format+"\r\n", args...) }
+	var data net.Listener
+	reply("220 fake ftp ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		verb, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch strings.ToUpper(verb) {
+		case "USER":
+			reply("331 password please")
+		case "PASS":
+			reply("230 logged in")
+		case "TYPE":
+			reply("200 binary")
+		case "SIZE":
+			reply("213 %d", len(content))
+		case "MDTM":
+			reply("213 20250102030405")
+		case "EPSV":
+			data, err = net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				reply("425 no data")
+				continue
+			}
+			reply("229 Entering Extended Passive Mode (|||%d|)", data.Addr().(*net.TCPAddr).Port)
+		case "RETR":
+			reply("150 opening data connection")
+			dc, err := data.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = dc.Write([]byte(content))
+			dc.Close()
+			data.Close()
+			reply("226 transfer complete")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestInspectWebObject_FTP(t *testing.T) {
+	content := "ftp payload"
+	addr := serveFakeFTP(t, content)
+
+	info, err := inspectWebObject(context.Background(), "ftp://"+addr+"/pub/payload.txt", "", true)
+	if err != nil {
+		t.Fatalf("inspectWebObject: %v", err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	if info.SizeBytes != int64(len(content)) || info.MetaSHA256 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if info.LastModTime.Year() != 2025 || info.Path != "payload.txt" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+}
+
+func TestInspectSource_RequiresIdentityForWebSources(t *testing.T) {
+	service := NewAddURLService()
+	service.inspectWeb = func(context.Context, string, string, bool) (*sycloud.ObjectInfo, error) {
+		return &sycloud.ObjectInfo{SizeBytes: 10}, nil
+	}
+	input := addURLInput{objectURL: "https://example.org/a.txt", path: "a.txt"}
+	if _, err := service.inspectSource(context.Background(), &input, "", config.S3Settings{}, config.AzureSettings{}); err == nil || !strings.Contains(err.Error(), "--compute-sha256") {
+		t.Fatalf("expected identity error, got %v", err)
+	}
+
+	service.inspectWeb = func(context.Context, string, string, bool) (*sycloud.ObjectInfo, error) {
+		return &sycloud.ObjectInfo{SizeBytes: 10, MetaSHA256: strings.Repeat("b", 64)}, nil
+	}
+	input = addURLInput{objectURL: "https://example.org/a.txt", path: "a.txt", sha256: strings.Repeat("a", 64)}
+	if _, err := service.inspectSource(context.Background(), &input, "", config.S3Settings{}, config.AzureSettings{}); err == nil || !strings.Contains(err.Error(), "mismatch") {
+		t.Fatalf("expected mismatch error, got %v", err)
+	}
+}
+
+func TestInspectSource_ComputesProviderSHAWhenRequested(t *testing.T) {
+	service := NewAddURLService()
+	service.inspectObject = func(context.Context, sycloud.ObjectParameters, config.S3Options) (*sycloud.ObjectInfo, error) {
+		return &sycloud.ObjectInfo{Bucket: "bucket", Key: "a.bin", SizeBytes: 3, ETag: "etag"}, nil
+	}
+	var hashedDir string
+	service.hashObject = func(_ context.Context, params sycloud.ObjectParameters, info *sycloud.ObjectInfo, gitCommonDir string, _ config.S3Options) (string, error) {
+		hashedDir = gitCommonDir
+		if params.ObjectURL != "s3://bucket/a.bin" || info.Key != "a.bin" {
+			t.Errorf("unexpected hash target: %+v %+v", params, info)
+		}
+		return strings.Repeat("c", 64), nil
+	}
+
+	input := addURLInput{objectURL: "s3://bucket/a.bin", path: "a.bin"}
+	if _, err := service.inspectSource(context.Background(), &input, "/repo/.git", config.S3Settings{}, config.AzureSettings{}); err != nil {
+		t.Fatalf("inspectSource: %v", err)
+	}
+	if hashedDir != "" || input.sha256 != "" {
+		t.Fatalf("hashed without --compute-sha256: dir=%q sha=%q", hashedDir, input.sha256)
+	}
+
+	input = addURLInput{objectURL: "s3://bucket/a.bin", path: "a.bin", computeSHA: true}
+	if _, err := service.inspectSource(context.Background(), &input, "/repo/.git", config.S3Settings{}, config.AzureSettings{}); err != nil {
+		t.Fatalf("inspectSource: %v", err)
+	}
+	if hashedDir != "/repo/.git" || input.sha256 != strings.Repeat("c", 64) {
+		t.Fatalf("unexpected result: dir=%q sha=%q", hashedDir, input.sha256)
+	}
+}
+
+func TestInspectSource_UsesBucketS3Options(t *testing.T) {
+	service := NewAddURLService()
+	var inspected, hashed config.S3Options
+	service.inspectObject = func(_ context.Context, _ sycloud.ObjectParameters, opts config.S3Options) (*sycloud.ObjectInfo, error) {
+		inspected = opts
+		return &sycloud.ObjectInfo{Bucket: "open-data", Key: "a.bin", SizeBytes: 3}, nil
+	}
+	service.hashObject = func(_ context.Context, _ sycloud.ObjectParameters, _ *sycloud.ObjectInfo, _ string, opts config.S3Options) (string, error) {
+		hashed = opts
+		return strings.Repeat("c", 64), nil
+	}
+	s3 := config.S3Settings{
+		Remote:  config.S3Options{Accelerate: true},
+		Buckets: map[string]config.S3Options{"open-data": {RequesterPays: true}},
+	}
+
+	input := addURLInput{objectURL: "https://open-data.s3.us-east-1.amazonaws.com/a.bin", path: "a.bin", computeSHA: true}
+	if _, err := service.inspectSource(context.Background(), &input, "", s3, config.AzureSettings{}); err != nil {
+		t.Fatalf("inspectSource: %v", err)
+	}
+	want := config.S3Options{RequesterPays: true}
+	if inspected != want || hashed != want {
+		t.Fatalf("expected bucket override %+v, got inspect=%+v hash=%+v", want, inspected, hashed)
+	}
+}
+
+func TestInspectSource_RoutesAzureBlobsToAzureSDK(t *testing.T) {
+	service := NewAddURLService()
+	service.inspectObject = func(context.Context, sycloud.ObjectParameters, config.S3Options) (*sycloud.ObjectInfo, error) {
+		t.Fatal("Azure blobs must not go through the provider inspector")
+		return nil, nil
+	}
+	az := config.AzureSettings{Account: "partner", Credential: config.AzureCredentialDefault}
+	var inspected, hashed config.AzureSettings
+	service.inspectAzure = func(_ context.Context, params sycloud.ObjectParameters, got config.AzureSettings) (*sycloud.ObjectInfo, error) {
+		inspected = got
+		return &sycloud.ObjectInfo{Bucket: "reads", Key: "a.bam", SizeBytes: 3}, nil
+	}
+	service.hashAzure = func(_ context.Context, _ sycloud.ObjectParameters, _ *sycloud.ObjectInfo, _ string, got config.AzureSettings) (string, error) {
+		hashed = got
+		return strings.Repeat("d", 64), nil
+	}
+
+	input := addURLInput{objectURL: "az://reads/a.bam", path: "a.bam", computeSHA: true}
+	if _, err := service.inspectSource(context.Background(), &input, "", config.S3Settings{}, az); err != nil {
+		t.Fatalf("inspectSource: %v", err)
+	}
+	if inspected != az || hashed != az || input.sha256 != strings.Repeat("d", 64) {
+		t.Fatalf("unexpected Azure inspection: inspect=%+v hash=%+v sha=%q", inspected, hashed, input.sha256)
+	}
+}
@@ -0,0 +1,213 @@
+package alias
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	loadInventory = lfs.GetTrackedLfsFiles
+	gitTopLevel   = gitrepo.GitTopLevel
+)
+
+// Entry is one tracked file and the aliases staged for it.
+type Entry struct {
+	Path    string   `json:"path"`
+	OID     string   `json:"oid"`
+	Aliases []string `json:"aliases"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Attach aliases such as accession numbers to tracked files",
+	Long: "Description:" +
+		"\n  Record aliases, such as accession numbers, on the local DRS object of a" +
+		"\n  tracked file. The aliases are sent when push registers the file's record," +
+		"\n  and query and download accept an alias wherever they accept a DRS ID.",
+}
+
+// AddCmd attaches aliases to a file.
+var AddCmd = &cobra.Command{
+	Use:   "add <path> <alias>...",
+	Short: "Attach aliases to a tracked file",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires at least 2 arguments (path and alias), received %d\n\nUsage: %s\n\nSee 'git drs alias add --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, a := range args[1:] {
+			if err := drsobject.ValidateAlias(a); err != nil {
+				return err
+			}
+		}
+		return edit(cmd.OutOrStdout(), args[0], func(obj *drsapi.DrsObject) bool {
+			return drsobject.AddAliases(obj, args[1:]...)
+		})
+	},
+}
+
+// RmCmd removes aliases from a file.
+var RmCmd = &cobra.Command{
+	Use:   "rm <path> <alias>...",
+	Short: "Remove aliases from a tracked file",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires at least 2 arguments (path and alias), received %d\n\nUsage: %s\n\nSee 'git drs alias rm --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return edit(cmd.OutOrStdout(), args[0], func(obj *drsapi.DrsObject) bool {
+			return drsobject.RemoveAliases(obj, args[1:]...)
+		})
+	},
+}
+
+// ListCmd lists the aliases staged for files.
+var ListCmd = &cobra.Command{
+	Use:   "list [path...]",
+	Short: "List the aliases of tracked files, optionally under the given paths",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := list(args, drslog.GetLogger())
+		if err != nil {
+			return err
+		}
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), entries)
+		}
+		for _, e := range entries {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", e.Path, strings.Join(e.Aliases, ", "))
+		}
+		return nil
+	},
+}
+
+func init() {
+	Cmd.AddCommand(AddCmd)
+	Cmd.AddCommand(RmCmd)
+	Cmd.AddCommand(ListCmd)
+}
+
+// edit applies change to the staged object of the tracked file at path,
+// staging a new object when the file has none yet.
+func edit(w io.Writer, path string, change func(*drsapi.DrsObject) bool) error {
+	inventory, root, err := inventoryAndRoot(drslog.GetLogger())
+	if err != nil {
+		return err
+	}
+	rel, err := repoRelative(root, path)
+	if err != nil {
+		return err
+	}
+	info, ok := inventory[rel]
+	if !ok {
+		return fmt.Errorf("%s is not a tracked DRS file", path)
+	}
+	oid := drsobject.NormalizeOid(info.Oid)
+	obj, err := drsobject.ReadObject(common.DRS_OBJS_PATH, oid)
+	if err != nil || obj == nil {
+		name := filepath.Base(rel)
+		obj = &drsapi.DrsObject{
+			Name:      &name,
+			Size:      info.Size,
+			Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: oid}},
+		}
+	}
+	if !change(obj) {
+		fmt.Fprintf(w, "%s\tunchanged\t%s\n", rel, strings.Join(drsobject.Aliases(*obj), ", "))
+		return nil
+	}
+	if err := drsobject.WriteObject(common.DRS_OBJS_PATH, obj, oid); err != nil {
+		return fmt.Errorf("write local DRS object for %s: %w", rel, err)
+	}
+	fmt.Fprintf(w, "%s\t%s\n", rel, strings.Join(drsobject.Aliases(*obj), ", "))
+	return nil
+}
+
+// list returns the tracked files under paths, or all of them, whose staged
+// objects carry aliases, sorted by path.
+func list(paths []string, logger *slog.Logger) ([]Entry, error) {
+	inventory, root, err := inventoryAndRoot(logger)
+	if err != nil {
+		return nil, err
+	}
+	var prefixes []string
+	for _, p := range paths {
+		rel, err := repoRelative(root, p)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, rel)
+	}
+
+	entries := []Entry{}
+	for path, info := range inventory {
+		if len(prefixes) > 0 && !underAny(path, prefixes) {
+			continue
+		}
+		oid := drsobject.NormalizeOid(info.Oid)
+		obj, err := drsobject.ReadObject(common.DRS_OBJS_PATH, oid)
+		if err != nil || obj == nil || len(drsobject.Aliases(*obj)) == 0 {
+			continue
+		}
+		entries = append(entries, Entry{Path: path, OID: oid, Aliases: drsobject.Aliases(*obj)})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+func underAny(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if p == "." || path == p || strings.HasPrefix(path, strings.TrimSuffix(p, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func inventoryAndRoot(logger *slog.Logger) (map[string]lfs.LfsFileInfo, string, error) {
+	inventory, err := loadInventory(logger)
+	if err != nil {
+		return nil, "", fmt.Errorf("error listing tracked files: %w", err)
+	}
+	root, err := gitTopLevel()
+	if err != nil {
+		return nil, "", err
+	}
+	return inventory, root, nil
+}
+
+func repoRelative(root, arg string) (string, error) {
+	abs, err := filepath.Abs(arg)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		abs = filepath.Join(resolved, filepath.Base(abs))
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is outside the repository", arg)
+	}
+	return filepath.ToSlash(rel), nil
+}
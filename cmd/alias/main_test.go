@@ -0,0 +1,81 @@
+package alias
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+func stubAlias(t *testing.T, root string) {
+	t.Helper()
+	origInv, origTop := loadInventory, gitTopLevel
+	t.Cleanup(func() {
+		loadInventory, gitTopLevel = origInv, origTop
+		common.SetJSONOutput(false)
+	})
+	loadInventory = func(*slog.Logger) (map[string]lfs.LfsFileInfo, error) {
+		return map[string]lfs.LfsFileInfo{
+			"data/a.bam":  {Oid: strings.Repeat("a", 64), Size: 1},
+			"other/c.txt": {Oid: strings.Repeat("c", 64), Size: 3},
+		}, nil
+	}
+	gitTopLevel = func() (string, error) { return root, nil }
+}
+
+func run(t *testing.T, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	Cmd.SetOut(&out)
+	Cmd.SetArgs(args)
+	if err := Cmd.Execute(); err != nil {
+		t.Fatalf("alias %v: %v", args, err)
+	}
+	return out.String()
+}
+
+func TestAliasAddStagesObjectAndListFilters(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	stubAlias(t, root)
+
+	run(t, "add", "data/a.bam", "ACC-1", "ACC-2")
+	run(t, "add", "other/c.txt", "ACC-3")
+	run(t, "rm", "data/a.bam", "ACC-2")
+
+	obj, err := drsobject.ReadObject(common.DRS_OBJS_PATH, strings.Repeat("a", 64))
+	if err != nil {
+		t.Fatalf("expected staged object: %v", err)
+	}
+	if got := drsobject.Aliases(*obj); len(got) != 1 || got[0] != "ACC-1" || obj.Size != 1 {
+		t.Fatalf("unexpected staged object %+v aliases %v", obj, got)
+	}
+
+	common.SetJSONOutput(true)
+	var entries []Entry
+	if err := json.Unmarshal([]byte(run(t, "list", "data")), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Path != "data/a.bam" || entries[0].Aliases[0] != "ACC-1" {
+		t.Fatalf("unexpected list %+v", entries)
+	}
+}
+
+func TestAliasAddRejectsReservedAndUntracked(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	stubAlias(t, root)
+
+	Cmd.SetOut(&bytes.Buffer{})
+	for _, args := range [][]string{{"add", "data/a.bam", "id:x"}, {"add", "missing.bam", "ACC-1"}} {
+		Cmd.SetArgs(args)
+		if err := Cmd.Execute(); err == nil {
+			t.Fatalf("expected alias %v to fail", args)
+		}
+	}
+}
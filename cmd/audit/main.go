@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/spf13/cobra"
+)
+
+// verifyResult is the --json document for audit verify. Events counts the
+// valid events before the first break in the chain.
+type verifyResult struct {
+	Path   string `json:"path"`
+	Events int    `json:"events"`
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+}
+
+// logPath is swapped in tests.
+var logPath = audit.Path
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the .drs/audit registration log",
+	Long: "Description:" +
+		"\n  git-drs appends every DRS register, update, and delete it performs to a" +
+		"\n  hash-chained log at .drs/audit/log.jsonl, recording the acting user, remote," +
+		"\n  DRS ID, and git commit. Commit the log to keep a history that is independent" +
+		"\n  of server-side logs.",
+}
+
+// LogCmd prints the audit log.
+var LogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Print audit log events",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := logPath()
+		if err != nil {
+			return err
+		}
+		events, err := audit.Read(path)
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		if common.JSONOutput() {
+			if events == nil {
+				events = []audit.Event{}
+			}
+			return common.WriteJSON(out, events)
+		}
+		for _, ev := range events {
+			fmt.Fprintf(out, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", ev.Seq, ev.Time, ev.Action, ev.User, ev.Remote, ev.DRSID, ev.Commit)
+		}
+		return nil
+	},
+}
+
+// VerifyCmd checks the hash chain.
+var VerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the audit log hash chain has not been altered",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := logPath()
+		if err != nil {
+			return err
+		}
+		n, err := audit.Verify(path)
+		if common.JSONOutput() {
+			res := verifyResult{Path: path, Events: n, OK: err == nil}
+			if err != nil {
+				res.Error = err.Error()
+			}
+			if werr := common.WriteJSON(cmd.OutOrStdout(), res); werr != nil {
+				return werr
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("audit log %s failed verification after %d valid events: %w", path, n, err)
+		}
+		if common.JSONOutput() {
+			return nil
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "audit log OK: %d events\n", n)
+		return nil
+	},
+}
+
+func init() {
+	Cmd.AddCommand(LogCmd)
+	Cmd.AddCommand(VerifyCmd)
+}
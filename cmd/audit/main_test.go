@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/common"
+)
+
+func useLog(t *testing.T, path string) {
+	t.Helper()
+	orig := logPath
+	t.Cleanup(func() {
+		logPath = orig
+		common.SetJSONOutput(false)
+	})
+	logPath = func() (string, error) { return path, nil }
+}
+
+func appendEvents(t *testing.T, path string, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if err := audit.Append(path, audit.Event{Action: audit.ActionRegister, Remote: "origin", DRSID: id}); err != nil {
+			t.Fatalf("Append error: %v", err)
+		}
+	}
+}
+
+func copyFile(t *testing.T, from, to string) {
+	t.Helper()
+	data, err := os.ReadFile(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(to, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func verify(t *testing.T) (verifyResult, error) {
+	t.Helper()
+	common.SetJSONOutput(true)
+	var out bytes.Buffer
+	VerifyCmd.SetOut(&out)
+	err := VerifyCmd.RunE(VerifyCmd, nil)
+	var res verifyResult
+	if jerr := json.Unmarshal(out.Bytes(), &res); jerr != nil {
+		t.Fatalf("decode verify output %q: %v", out.String(), jerr)
+	}
+	return res, err
+}
+
+func TestVerifyRejectsTamperedLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), audit.FileName)
+	appendEvents(t, path, "d1", "d2", "d3")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(data), `"drs_id":"d2"`, `"drs_id":"dX"`, 1)
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	useLog(t, path)
+
+	res, err := verify(t)
+	if err == nil || !strings.Contains(err.Error(), "after 1 valid events") {
+		t.Fatalf("expected verification failure, got %v", err)
+	}
+	if res.OK || res.Events != 1 || !strings.Contains(res.Error, "line 2") {
+		t.Fatalf("unexpected result: %#v", res)
+	}
+}
+
+func TestVerifyAcceptsMergedLog(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base")
+	ours := filepath.Join(dir, "ours")
+	theirs := filepath.Join(dir, "theirs")
+	appendEvents(t, base, "d1")
+	copyFile(t, base, ours)
+	copyFile(t, base, theirs)
+	appendEvents(t, ours, "ours-1", "ours-2")
+	appendEvents(t, theirs, "theirs-1")
+
+	// Concatenating the branches, as a plain text merge would, breaks the
+	// chain at theirs' first event.
+	oursData, _ := os.ReadFile(ours)
+	theirsData, _ := os.ReadFile(theirs)
+	naive := filepath.Join(dir, "naive")
+	lines := strings.SplitAfter(string(theirsData), "\n")
+	if err := os.WriteFile(naive, append(oursData, lines[1]...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	useLog(t, naive)
+	if res, err := verify(t); err == nil || res.OK {
+		t.Fatalf("expected naive merge to fail verification, got %#v", res)
+	}
+
+	if err := audit.MergeFiles(base, ours, theirs); err != nil {
+		t.Fatalf("MergeFiles error: %v", err)
+	}
+	useLog(t, ours)
+	res, err := verify(t)
+	if err != nil || !res.OK || res.Events != 4 {
+		t.Fatalf("merged log: %#v, %v", res, err)
+	}
+	events, err := audit.Read(ours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, ev := range events {
+		ids = append(ids, ev.DRSID)
+	}
+	if got := strings.Join(ids, ","); got != "d1,ours-1,ours-2,theirs-1" {
+		t.Fatalf("merged events = %s", got)
+	}
+}
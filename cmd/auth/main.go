@@ -0,0 +1,426 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/fenceauth"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	syconf "github.com/calypr/syfon/client/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	loadConfig        = config.LoadConfig
+	loadProfile       = config.LoadProfile
+	saveProfile       = config.SaveProfile
+	importCredentials = func(path string) (*syconf.Credential, error) {
+		return syconf.NewConfigure(drslog.GetLogger()).Import(path, "")
+	}
+	exchangeAPIKey = fenceauth.ExchangeAPIKey
+	currentUser    = fenceauth.CurrentUser
+	deviceLogin    = fenceauth.DeviceLogin
+	now            = time.Now
+)
+
+var (
+	credFile    string
+	useDevice   bool
+	clientID    string
+	profileName string
+)
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Log in to gen3 remotes and inspect, refresh, or switch their credentials",
+	Long: "Description:" +
+		"\n  Each gen3 remote loads a credential profile (an API key and the access" +
+		"\n  token exchanged for it) from ~/.gen3 or the OS keyring. These commands" +
+		"\n  create that profile, show whose it is and when it expires, refresh the" +
+		"\n  token, and choose which profile a remote uses.",
+}
+
+// LoginCmd exchanges an API key file or a device login for an access token.
+var LoginCmd = &cobra.Command{
+	Use:   "login [remote-name]",
+	Short: "Store credentials for a gen3 remote from an API key file or a device login",
+	Args:  maxOneRemote("login"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if (credFile == "") == !useDevice {
+			return errors.New("pass exactly one of --cred <file> or --device")
+		}
+		remote, gen3, err := resolveRemote(args)
+		if err != nil {
+			return err
+		}
+		ctx := commandContext(cmd)
+		profile := gen3.ProfileName(string(remote))
+		if strings.TrimSpace(profileName) != "" {
+			profile = strings.TrimSpace(profileName)
+		}
+		cred := &syconf.Credential{Profile: profile, APIEndpoint: gen3.Endpoint, UseShepherd: "false"}
+
+		if credFile != "" {
+			imported, err := importCredentials(credFile)
+			if err != nil {
+				return fmt.Errorf("failed to read credentials file %s: %w", credFile, err)
+			}
+			cred.APIKey, cred.KeyID = imported.APIKey, imported.KeyID
+			if cred.APIEndpoint == "" {
+				if cred.APIEndpoint, err = common.ParseAPIEndpointFromToken(cred.APIKey); err != nil {
+					return fmt.Errorf("failed to parse API endpoint from API key: %w", err)
+				}
+			}
+			if cred.AccessToken, err = exchangeAPIKey(ctx, cred.APIEndpoint, cred.APIKey); err != nil {
+				return fmt.Errorf("login to remote %q failed: %w", remote, err)
+			}
+		} else {
+			id := strings.TrimSpace(clientID)
+			if id == "" {
+				id, _ = gitrepo.GetGitConfigString("drs.auth.client-id")
+			}
+			if cred.AccessToken, err = deviceLogin(ctx, cred.APIEndpoint, strings.TrimSpace(id), devicePrompt(cmd.ErrOrStderr())); err != nil {
+				if errors.Is(err, fenceauth.ErrDeviceFlowUnsupported) {
+					return fmt.Errorf("%w at %s; log in with --cred <api-key-file> instead", err, cred.APIEndpoint)
+				}
+				return fmt.Errorf("login to remote %q failed: %w", remote, err)
+			}
+		}
+
+		if err := storeCredential(remote, gen3, cred); err != nil {
+			return err
+		}
+		if profile != gen3.ProfileName(string(remote)) {
+			if err := setRemoteProfile(remote, profile); err != nil {
+				return err
+			}
+		}
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "logged in to %s with profile %s\n", remote, profile)
+		if user, err := currentUser(ctx, cred.APIEndpoint, cred.AccessToken); err == nil {
+			fmt.Fprintf(out, "user: %s\n", user.Username)
+		}
+		if info, err := fenceauth.ParseToken(cred.AccessToken); err == nil && !info.Expires.IsZero() {
+			fmt.Fprintf(out, "token expires: %s\n", info.Expires.UTC().Format(time.RFC3339))
+		}
+		if cred.APIKey == "" {
+			fmt.Fprintln(out, "no API key was issued; run 'git drs auth login' again when the token expires")
+		}
+		return nil
+	},
+}
+
+// status is the `auth status --json` document.
+type status struct {
+	Remote          string     `json:"remote"`
+	Profile         string     `json:"profile"`
+	CredentialStore string     `json:"credential_store"`
+	Endpoint        string     `json:"endpoint"`
+	TokenExpires    *time.Time `json:"token_expires,omitempty"`
+	TokenExpired    bool       `json:"token_expired"`
+	APIKeyExpires   *time.Time `json:"api_key_expires,omitempty"`
+	APIKeyExpired   bool       `json:"api_key_expired"`
+	User            string     `json:"user,omitempty"`
+	Resources       []string   `json:"resources,omitempty"`
+	Problem         string     `json:"problem,omitempty"`
+}
+
+// StatusCmd shows whose credentials a remote uses and when they expire.
+var StatusCmd = &cobra.Command{
+	Use:   "status [remote-name]",
+	Short: "Show a gen3 remote's profile, token expiry, user, and accessible projects",
+	Args:  maxOneRemote("status"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remote, gen3, err := resolveRemote(args)
+		if err != nil {
+			return err
+		}
+		st := status{
+			Remote:          string(remote),
+			Profile:         gen3.ProfileName(string(remote)),
+			CredentialStore: credentialStoreName(gen3),
+			Endpoint:        gen3.Endpoint,
+		}
+		cred, err := loadProfile(gen3.CredentialStore, st.Profile, drslog.GetLogger())
+		if err != nil {
+			st.Problem = fmt.Sprintf("no credentials: %v; run 'git drs auth login %s'", err, remote)
+		} else {
+			describeCredential(commandContext(cmd), &st, cred)
+		}
+		if common.JSONOutput() {
+			if err := common.WriteJSON(cmd.OutOrStdout(), st); err != nil {
+				return err
+			}
+		} else {
+			writeStatus(cmd.OutOrStdout(), st)
+		}
+		if st.Problem != "" {
+			return fmt.Errorf("remote %q has no usable credentials", remote)
+		}
+		return nil
+	},
+}
+
+// RefreshCmd requests a new access token from the profile's API key.
+var RefreshCmd = &cobra.Command{
+	Use:   "refresh [remote-name]",
+	Short: "Exchange a gen3 remote's API key for a new access token now",
+	Args:  maxOneRemote("refresh"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remote, gen3, err := resolveRemote(args)
+		if err != nil {
+			return err
+		}
+		profile := gen3.ProfileName(string(remote))
+		cred, err := loadProfile(gen3.CredentialStore, profile, drslog.GetLogger())
+		if err != nil {
+			return fmt.Errorf("failed to load profile %q: %w; run 'git drs auth login %s'", profile, err, remote)
+		}
+		if strings.TrimSpace(cred.APIKey) == "" {
+			return fmt.Errorf("profile %q has no API key to refresh from; run 'git drs auth login %s'", profile, remote)
+		}
+		if key, err := fenceauth.ParseToken(cred.APIKey); err == nil && key.Expired(now()) {
+			return fmt.Errorf("the API key of profile %q expired at %s; download a new key and run 'git drs auth login %s --cred <file>'", profile, key.Expires.UTC().Format(time.RFC3339), remote)
+		}
+		endpoint := firstNonEmpty(cred.APIEndpoint, gen3.Endpoint)
+		token, err := exchangeAPIKey(commandContext(cmd), endpoint, cred.APIKey)
+		if err != nil {
+			return fmt.Errorf("refresh for remote %q failed: %w", remote, err)
+		}
+		cred.AccessToken = token
+		if err := storeCredential(remote, gen3, cred); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "refreshed access token for %s (profile %s)\n", remote, profile)
+		if info, err := fenceauth.ParseToken(token); err == nil && !info.Expires.IsZero() {
+			fmt.Fprintf(cmd.OutOrStdout(), "token expires: %s\n", info.Expires.UTC().Format(time.RFC3339))
+		}
+		return nil
+	},
+}
+
+// SwitchCmd points a remote at another stored profile.
+var SwitchCmd = &cobra.Command{
+	Use:   "switch <profile> [remote-name]",
+	Short: "Use another stored credential profile for a gen3 remote",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 || len(args) > 2 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: expected <profile> [remote-name], received %d arguments\n\nUsage: %s\n\nSee 'git drs auth switch --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile := strings.TrimSpace(args[0])
+		remote, gen3, err := resolveRemote(args[1:])
+		if err != nil {
+			return err
+		}
+		cred, err := loadProfile(gen3.CredentialStore, profile, drslog.GetLogger())
+		if err != nil {
+			return fmt.Errorf("profile %q is not available in the %s credential store: %w", profile, credentialStoreName(gen3), err)
+		}
+		if err := setRemoteProfile(remote, profile); err != nil {
+			return err
+		}
+		// The cached token belongs to the previous profile.
+		if !gen3.UsesKeyring() {
+			if token := strings.TrimSpace(cred.AccessToken); token != "" {
+				err = gitrepo.SetRemoteToken(string(remote), token)
+			} else {
+				err = gitrepo.UnsetGitConfigOptions([]string{fmt.Sprintf("drs.remote.%s.token", remote)})
+			}
+			if err != nil {
+				return fmt.Errorf("failed to update cached token for remote %s: %w", remote, err)
+			}
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "remote %s now uses profile %s\n", remote, profile)
+		return nil
+	},
+}
+
+func init() {
+	LoginCmd.Flags().StringVar(&credFile, "cred", "", "Gen3 API key file to exchange for an access token")
+	LoginCmd.Flags().BoolVar(&useDevice, "device", false, "Log in through the browser with an OAuth device code")
+	LoginCmd.Flags().StringVar(&clientID, "client-id", "", "OAuth client ID for --device; defaults to drs.auth.client-id")
+	LoginCmd.Flags().StringVar(&profileName, "profile", "", "Profile to store the credentials under and use for the remote")
+
+	Cmd.AddCommand(LoginCmd)
+	Cmd.AddCommand(StatusCmd)
+	Cmd.AddCommand(RefreshCmd)
+	Cmd.AddCommand(SwitchCmd)
+}
+
+func maxOneRemote(name string) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: accepts at most 1 argument (remote name), received %d\n\nUsage: %s\n\nSee 'git drs auth %s --help' for more details", len(args), cmd.UseLine(), name)
+		}
+		return nil
+	}
+}
+
+// resolveRemote returns the named or default remote, which must be a gen3
+// remote with credentials.
+func resolveRemote(args []string) (config.Remote, *config.Gen3Remote, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	var remoteArg string
+	if len(args) == 1 {
+		remoteArg = args[0]
+	}
+	remote, err := cfg.GetRemoteOrDefault(remoteArg)
+	if err != nil {
+		return "", nil, err
+	}
+	gen3, _ := cfg.GetRemote(remote).(*config.Gen3Remote)
+	if gen3 == nil {
+		return "", nil, fmt.Errorf("remote '%s' is not a configured gen3 remote", remote)
+	}
+	if gen3.Anonymous() {
+		return "", nil, fmt.Errorf("remote '%s' uses auth=none and has no credentials", remote)
+	}
+	return remote, gen3, nil
+}
+
+// storeCredential saves cred to the remote's store and, outside the keyring,
+// caches its token in git config for the credential helper.
+func storeCredential(remote config.Remote, gen3 *config.Gen3Remote, cred *syconf.Credential) error {
+	if err := saveProfile(gen3.CredentialStore, cred, drslog.GetLogger()); err != nil {
+		return fmt.Errorf("failed to save profile %q: %w", cred.Profile, err)
+	}
+	if gen3.UsesKeyring() || strings.TrimSpace(cred.AccessToken) == "" {
+		return nil
+	}
+	if err := gitrepo.SetRemoteToken(string(remote), strings.TrimSpace(cred.AccessToken)); err != nil {
+		return fmt.Errorf("failed to persist repo token for remote %s: %w", remote, err)
+	}
+	return nil
+}
+
+func setRemoteProfile(remote config.Remote, profile string) error {
+	if err := gitrepo.SetGitConfigOptions(map[string]string{
+		fmt.Sprintf("drs.remote.%s.profile", remote): profile,
+	}); err != nil {
+		return fmt.Errorf("failed to update remote config: %w", err)
+	}
+	return nil
+}
+
+// describeCredential fills st from cred, asking fence who the token belongs
+// to when it has not expired.
+func describeCredential(ctx context.Context, st *status, cred *syconf.Credential) {
+	at := now()
+	endpoint := firstNonEmpty(cred.APIEndpoint, st.Endpoint)
+	if key, err := fenceauth.ParseToken(cred.APIKey); err == nil {
+		st.APIKeyExpires, st.APIKeyExpired = timePtr(key.Expires), key.Expired(at)
+	}
+	token, err := fenceauth.ParseToken(cred.AccessToken)
+	if err != nil {
+		st.TokenExpired = true
+	} else {
+		st.TokenExpires, st.TokenExpired = timePtr(token.Expires), token.Expired(at)
+		st.User = token.Username
+	}
+	switch {
+	case st.TokenExpired && (cred.APIKey == "" || st.APIKeyExpired):
+		st.Problem = fmt.Sprintf("access token and API key are unusable; run 'git drs auth login %s'", st.Remote)
+		return
+	case st.TokenExpired:
+		// Commands refresh the token from the key on their own; status only reports it.
+		return
+	}
+	user, err := currentUser(ctx, endpoint, cred.AccessToken)
+	if err != nil {
+		st.Problem = fmt.Sprintf("fence rejected the access token: %v", err)
+		return
+	}
+	st.User = firstNonEmpty(user.Username, st.User)
+	st.Resources = user.Resources
+}
+
+func writeStatus(w io.Writer, st status) {
+	fmt.Fprintf(w, "remote: %s\n", st.Remote)
+	fmt.Fprintf(w, "profile: %s (%s)\n", st.Profile, st.CredentialStore)
+	fmt.Fprintf(w, "endpoint: %s\n", st.Endpoint)
+	if st.User != "" {
+		fmt.Fprintf(w, "user: %s\n", st.User)
+	}
+	fmt.Fprintf(w, "access token: %s\n", expiry(st.TokenExpires, st.TokenExpired))
+	fmt.Fprintf(w, "api key: %s\n", expiry(st.APIKeyExpires, st.APIKeyExpired))
+	if st.TokenExpired && st.Problem == "" {
+		fmt.Fprintf(w, "  the token is refreshed from the API key on the next command, or now with 'git drs auth refresh %s'\n", st.Remote)
+	}
+	if len(st.Resources) > 0 {
+		fmt.Fprintln(w, "access:")
+		for _, r := range st.Resources {
+			fmt.Fprintf(w, "  %s\n", r)
+		}
+	}
+	if st.Problem != "" {
+		fmt.Fprintf(w, "problem: %s\n", st.Problem)
+	}
+}
+
+func expiry(at *time.Time, expired bool) string {
+	switch {
+	case at == nil && expired:
+		return "missing"
+	case at == nil:
+		return "-"
+	case expired:
+		return "expired " + at.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprintf("expires %s (in %s)", at.UTC().Format(time.RFC3339), at.Sub(now()).Round(time.Minute))
+}
+
+func devicePrompt(w io.Writer) func(fenceauth.DeviceCode) {
+	return func(code fenceauth.DeviceCode) {
+		if code.VerificationURIComplete != "" {
+			fmt.Fprintf(w, "Open %s to approve this login (code %s).\n", code.VerificationURIComplete, code.UserCode)
+			return
+		}
+		fmt.Fprintf(w, "Open %s and enter the code %s to approve this login.\n", code.VerificationURI, code.UserCode)
+	}
+}
+
+func credentialStoreName(gen3 *config.Gen3Remote) string {
+	if gen3.UsesKeyring() {
+		return config.CredentialStoreKeyring
+	}
+	return config.CredentialStoreFile
+}
+
+func commandContext(cmd *cobra.Command) context.Context {
+	if cmd != nil && cmd.Context() != nil {
+		return cmd.Context()
+	}
+	return context.Background()
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
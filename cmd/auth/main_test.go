@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/fenceauth"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/testutils"
+	syconf "github.com/calypr/syfon/client/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testToken(t *testing.T, name string, exp time.Time) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp":     exp.Unix(),
+		"context": map[string]any{"user": map[string]any{"name": name}},
+	}).SignedString([]byte("test"))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+// fakeStore replaces the profile store and fence calls for one test.
+func fakeStore(t *testing.T) map[string]syconf.Credential {
+	t.Helper()
+	profiles := map[string]syconf.Credential{}
+	origLoad, origSave, origExchange, origUser := loadProfile, saveProfile, exchangeAPIKey, currentUser
+	loadProfile = func(store, profile string, _ *slog.Logger) (*syconf.Credential, error) {
+		cred, ok := profiles[profile]
+		if !ok {
+			return nil, syconf.ErrProfileNotFound
+		}
+		return &cred, nil
+	}
+	saveProfile = func(store string, cred *syconf.Credential, _ *slog.Logger) error {
+		profiles[cred.Profile] = *cred
+		return nil
+	}
+	currentUser = func(_ context.Context, endpoint, token string) (fenceauth.User, error) {
+		return fenceauth.User{Username: "alice", Resources: []string{"/programs/p/projects/a"}}, nil
+	}
+	t.Cleanup(func() {
+		loadProfile, saveProfile, exchangeAPIKey, currentUser = origLoad, origSave, origExchange, origUser
+		credFile, useDevice, clientID, profileName = "", false, "", ""
+	})
+	return profiles
+}
+
+func TestLoginWithCredentialsFile(t *testing.T) {
+	tmpDir := testutils.SetupTestGitRepo(t)
+	testutils.CreateDefaultTestConfig(t, tmpDir)
+	profiles := fakeStore(t)
+
+	apiKey := testToken(t, "alice", time.Now().Add(30*24*time.Hour))
+	accessToken := testToken(t, "alice", time.Now().Add(time.Hour))
+	origImport := importCredentials
+	importCredentials = func(path string) (*syconf.Credential, error) {
+		return &syconf.Credential{KeyID: "kid", APIKey: apiKey}, nil
+	}
+	t.Cleanup(func() { importCredentials = origImport })
+	exchangeAPIKey = func(_ context.Context, endpoint, key string) (string, error) {
+		if endpoint != "https://test.gen3.org" || key != apiKey {
+			t.Fatalf("unexpected exchange %s %s", endpoint, key)
+		}
+		return accessToken, nil
+	}
+
+	credFile, profileName = "credentials.json", "work"
+	out := &bytes.Buffer{}
+	LoginCmd.SetOut(out)
+	if err := LoginCmd.RunE(LoginCmd, nil); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	saved := profiles["work"]
+	if saved.APIKey != apiKey || saved.AccessToken != accessToken || saved.APIEndpoint != "https://test.gen3.org" {
+		t.Fatalf("unexpected saved profile: %+v", saved)
+	}
+	if got := config.RemoteProfile("origin"); got != "work" {
+		t.Fatalf("expected remote profile work, got %q", got)
+	}
+	if token, _ := gitrepo.GetRemoteToken("origin"); token != accessToken {
+		t.Fatalf("expected cached token, got %q", token)
+	}
+	if !strings.Contains(out.String(), "user: alice") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+}
+
+func TestLoginRequiresOneMethod(t *testing.T) {
+	fakeStore(t)
+	if err := LoginCmd.RunE(LoginCmd, nil); err == nil {
+		t.Fatal("expected error without --cred or --device")
+	}
+	credFile, useDevice = "credentials.json", true
+	if err := LoginCmd.RunE(LoginCmd, nil); err == nil {
+		t.Fatal("expected error with both --cred and --device")
+	}
+}
+
+func TestStatusAndRefresh(t *testing.T) {
+	tmpDir := testutils.SetupTestGitRepo(t)
+	testutils.CreateDefaultTestConfig(t, tmpDir)
+	profiles := fakeStore(t)
+
+	apiKey := testToken(t, "alice", time.Now().Add(30*24*time.Hour))
+	profiles["origin"] = syconf.Credential{
+		Profile:     "origin",
+		APIKey:      apiKey,
+		AccessToken: testToken(t, "alice", time.Now().Add(-time.Minute)),
+		APIEndpoint: "https://test.gen3.org",
+	}
+
+	out := &bytes.Buffer{}
+	StatusCmd.SetOut(out)
+	if err := StatusCmd.RunE(StatusCmd, nil); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !strings.Contains(out.String(), "access token: expired") || !strings.Contains(out.String(), "git drs auth refresh origin") {
+		t.Fatalf("expected expired token hint, got: %s", out.String())
+	}
+
+	fresh := testToken(t, "alice", time.Now().Add(time.Hour))
+	exchangeAPIKey = func(context.Context, string, string) (string, error) { return fresh, nil }
+	RefreshCmd.SetOut(&bytes.Buffer{})
+	if err := RefreshCmd.RunE(RefreshCmd, nil); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if profiles["origin"].AccessToken != fresh {
+		t.Fatal("refresh did not store the new token")
+	}
+
+	out.Reset()
+	if err := StatusCmd.RunE(StatusCmd, nil); err != nil {
+		t.Fatalf("status after refresh: %v", err)
+	}
+	if !strings.Contains(out.String(), "/programs/p/projects/a") || strings.Contains(out.String(), "problem:") {
+		t.Fatalf("unexpected status after refresh: %s", out.String())
+	}
+}
+
+func TestRefreshWithoutAPIKey(t *testing.T) {
+	tmpDir := testutils.SetupTestGitRepo(t)
+	testutils.CreateDefaultTestConfig(t, tmpDir)
+	profiles := fakeStore(t)
+	profiles["origin"] = syconf.Credential{Profile: "origin", AccessToken: "token"}
+
+	exchangeAPIKey = func(context.Context, string, string) (string, error) {
+		return "", errors.New("should not be called")
+	}
+	if err := RefreshCmd.RunE(RefreshCmd, nil); err == nil || !strings.Contains(err.Error(), "no API key") {
+		t.Fatalf("expected missing API key error, got %v", err)
+	}
+}
+
+func TestSwitchProfile(t *testing.T) {
+	tmpDir := testutils.SetupTestGitRepo(t)
+	testutils.CreateDefaultTestConfig(t, tmpDir)
+	profiles := fakeStore(t)
+	profiles["other"] = syconf.Credential{Profile: "other", AccessToken: "other-token"}
+
+	if err := SwitchCmd.RunE(SwitchCmd, []string{"missing"}); err == nil {
+		t.Fatal("expected error for unknown profile")
+	}
+	SwitchCmd.SetOut(&bytes.Buffer{})
+	if err := SwitchCmd.RunE(SwitchCmd, []string{"other", "origin"}); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	if got := config.RemoteProfile("origin"); got != "other" {
+		t.Fatalf("expected remote profile other, got %q", got)
+	}
+	if token, _ := gitrepo.GetRemoteToken("origin"); token != "other-token" {
+		t.Fatalf("expected cached token of new profile, got %q", token)
+	}
+}
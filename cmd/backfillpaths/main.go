@@ -0,0 +1,115 @@
+package backfillpaths
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pushsync"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remote string
+	dryRun bool
+)
+
+// Swapped in tests.
+var (
+	loadConfig    = config.LoadConfig
+	loadRegister  = config.RegisterSettings
+	loadInventory = func(remote string, logger *slog.Logger) (map[string]lfs.LfsFileInfo, error) {
+		return lfs.GetAllLfsFiles(remote, "", []string{"HEAD"}, logger)
+	}
+	backfill = pushsync.BackfillPathRecords
+)
+
+// Report is the JSON form of a backfill-paths run.
+type Report struct {
+	Remote string `json:"remote"`
+	DryRun bool   `json:"dry_run"`
+	pushsync.BackfillResult
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "backfill-paths",
+	Short: "Register a record for each path of content committed at several paths",
+	Long: "Description:" +
+		"\n  Content committed at several paths was registered once, under one path," +
+		"\n  before the remote used the path-hash ID strategy. For each such path in" +
+		"\n  HEAD that has no record of its own, register one named for the path" +
+		"\n  with its path-hash ID, copying the record already registered for the" +
+		"\n  content. Nothing is uploaded. Paths whose content was never pushed are" +
+		"\n  listed and left for the next push.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: accepts no arguments, received %d\n\nUsage: %s\n\nSee 'git drs backfill-paths --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := drslog.GetLogger()
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("error loading config: %w", err)
+		}
+		remoteName, err := cfg.GetRemoteOrDefault(remote)
+		if err != nil {
+			return err
+		}
+		cl, err := cfg.GetRemoteClient(remoteName, logger)
+		if err != nil {
+			return err
+		}
+		files, err := loadInventory(string(remoteName), logger)
+		if err != nil {
+			return fmt.Errorf("error listing LFS files: %w", err)
+		}
+		policy, err := loadRegister()
+		if err != nil {
+			return err
+		}
+		files, _ = policy.Filter(files)
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		result, err := backfill(cl, ctx, files, dryRun)
+		if err != nil {
+			return err
+		}
+		report := Report{Remote: string(remoteName), DryRun: dryRun, BackfillResult: result}
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), report)
+		}
+		writeReport(cmd.OutOrStdout(), report)
+		return nil
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote to register the records with (default: default remote)")
+	Cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list the records that would be registered without registering them")
+}
+
+func writeReport(w io.Writer, r Report) {
+	for _, rec := range r.Records {
+		fmt.Fprintf(w, "%s\t%s\n", rec.Path, rec.DRSID)
+	}
+	verb := "Registered"
+	if r.DryRun {
+		verb = "Would register"
+	}
+	fmt.Fprintf(w, "%s %d path records with remote %s\n", verb, len(r.Records), r.Remote)
+	if len(r.Unpushed) > 0 {
+		fmt.Fprintf(w, "Skipped %d paths whose content has no record yet; run 'git drs push' to register them\n", len(r.Unpushed))
+	}
+}
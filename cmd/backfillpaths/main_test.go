@@ -0,0 +1,31 @@
+package backfillpaths
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/pushsync"
+)
+
+func TestReport(t *testing.T) {
+	r := Report{Remote: "origin", DryRun: true, BackfillResult: pushsync.BackfillResult{
+		Records:  []pushsync.PathRecord{{Path: "b/x.bin", OID: "abc", DRSID: "id-b"}},
+		Unpushed: []string{"new.bin"},
+	}}
+	var out bytes.Buffer
+	writeReport(&out, r)
+	want := "b/x.bin\tid-b\nWould register 1 path records with remote origin\nSkipped 1 paths whose content has no record yet; run 'git drs push' to register them\n"
+	if out.String() != want {
+		t.Fatalf("report:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"records":[{"path":"b/x.bin","oid":"abc","drs_id":"id-b"}]`) || !strings.Contains(string(data), `"dry_run":true`) {
+		t.Fatalf("JSON report = %s; want the result fields inlined", data)
+	}
+}
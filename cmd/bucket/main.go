@@ -9,12 +9,13 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
 
 	"github.com/calypr/data-client/credentials"
 	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/drslog"
 	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/httpclient"
 	conf "github.com/calypr/syfon/client/config"
 	"github.com/spf13/cobra"
 )
@@ -34,8 +35,6 @@ var (
 	flagForce      bool
 )
 
-const defaultBucketAPITimeout = 30 * time.Second
-
 type putBucketPayload struct {
 	Bucket    string `json:"bucket"`
 	Region    string `json:"region,omitempty"`
@@ -246,11 +245,12 @@ func resolveEndpointAndToken(remoteName string) (string, string, error) {
 		}
 	}
 	if token == "" {
-		if prof, err := configure.Load(remoteName); err == nil {
+		store := config.RemoteCredentialStore(remoteName)
+		if prof, err := config.LoadProfile(store, remoteName, drslog.GetLogger()); err == nil {
 			token = strings.TrimSpace(prof.AccessToken)
 			if token == "" {
 				if ensureErr := credentials.EnsureValidCredential(context.Background(), prof, drslog.GetLogger()); ensureErr == nil {
-					_ = configure.Save(prof)
+					_ = config.SaveProfile(store, prof, drslog.GetLogger())
 					token = strings.TrimSpace(prof.AccessToken)
 				}
 			}
@@ -283,12 +283,6 @@ func resolveEndpointAndToken(remoteName string) (string, string, error) {
 }
 
 func upsertServerBucket(ctx context.Context, endpoint, token string, payload putBucketPayload) error {
-	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, defaultBucketAPITimeout)
-		defer cancel()
-	}
-
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to encode bucket request: %w", err)
@@ -301,7 +295,7 @@ func upsertServerBucket(ctx context.Context, endpoint, token string, payload put
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	client := &http.Client{Timeout: defaultBucketAPITimeout}
+	client := httpclient.NewAPI()
 	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("bucket credential upsert request failed: %w", err)
@@ -319,12 +313,6 @@ func upsertServerBucket(ctx context.Context, endpoint, token string, payload put
 }
 
 func addServerBucketScope(ctx context.Context, endpoint, token, bucket string, payload addBucketScopePayload) error {
-	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, defaultBucketAPITimeout)
-		defer cancel()
-	}
-
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to encode bucket scope request: %w", err)
@@ -337,7 +325,7 @@ func addServerBucketScope(ctx context.Context, endpoint, token, bucket string, p
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	client := &http.Client{Timeout: defaultBucketAPITimeout}
+	client := httpclient.NewAPI()
 	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("bucket scope request failed: %w", err)
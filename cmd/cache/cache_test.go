@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/precommit_cache"
+	"github.com/calypr/git-drs/internal/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// useTracked stubs the LFS inventory with files and records whether the
+// pointer index was cleared.
+func useTracked(t *testing.T, files map[string]lfs.LfsFileInfo) *bool {
+	t.Helper()
+	oldInventory, oldClear := loadLFSInventory, clearPointerIndex
+	t.Cleanup(func() {
+		loadLFSInventory, clearPointerIndex = oldInventory, oldClear
+		common.SetJSONOutput(false)
+	})
+	loadLFSInventory = func(*slog.Logger) (map[string]lfs.LfsFileInfo, error) { return files, nil }
+	cleared := false
+	clearPointerIndex = func(context.Context, string) error {
+		cleared = true
+		return nil
+	}
+	return &cleared
+}
+
+func status(t *testing.T) precommit_cache.Status {
+	t.Helper()
+	common.SetJSONOutput(true)
+	var out bytes.Buffer
+	StatusCmd.SetOut(&out)
+	if err := StatusCmd.RunE(StatusCmd, nil); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	var st precommit_cache.Status
+	if err := json.Unmarshal(out.Bytes(), &st); err != nil {
+		t.Fatalf("decode status %q: %v", out.String(), err)
+	}
+	return st
+}
+
+func TestRebuildThenClear(t *testing.T) {
+	testutils.SetupTestGitRepo(t)
+	cleared := useTracked(t, map[string]lfs.LfsFileInfo{
+		"data/a.bin": {Name: "data/a.bin", Oid: strings.Repeat("a", 64)},
+		"data/b.bin": {Name: "data/b.bin", Oid: strings.Repeat("b", 64)},
+		"data/c.bin": {Name: "data/c.bin"},
+	})
+
+	st := status(t)
+	assert.False(t, st.Exists)
+	assert.Equal(t, 2, st.Missing)
+
+	var out bytes.Buffer
+	RebuildCmd.SetOut(&out)
+	if err := RebuildCmd.RunE(RebuildCmd, nil); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	assert.Equal(t, "Rebuilt pre-commit cache with 2 paths\n", out.String())
+
+	st = status(t)
+	if !st.Exists || st.PathEntries != 2 || st.Valid != 2 || !st.Healthy() {
+		t.Fatalf("expected a consistent rebuilt cache, got %#v", st)
+	}
+
+	out.Reset()
+	ClearCmd.SetOut(&out)
+	if err := ClearCmd.RunE(ClearCmd, nil); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	assert.True(t, *cleared, "expected clear to drop the pointer index")
+	assert.Contains(t, out.String(), "Cleared the pointer index")
+	st = status(t)
+	assert.False(t, st.Exists)
+	assert.Equal(t, 2, st.Missing)
+}
+
+func TestStatusReportsMismatch(t *testing.T) {
+	testutils.SetupTestGitRepo(t)
+	files := map[string]lfs.LfsFileInfo{"data/a.bin": {Name: "data/a.bin", Oid: strings.Repeat("a", 64)}}
+	useTracked(t, files)
+	if err := RebuildCmd.RunE(RebuildCmd, nil); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	files["data/a.bin"] = lfs.LfsFileInfo{Name: "data/a.bin", Oid: strings.Repeat("c", 64)}
+
+	st := status(t)
+	assert.Equal(t, 1, st.Mismatched)
+	assert.False(t, st.Healthy())
+
+	common.SetJSONOutput(false)
+	var out bytes.Buffer
+	StatusCmd.SetOut(&out)
+	if err := StatusCmd.RunE(StatusCmd, nil); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	assert.Contains(t, out.String(), "Mismatched:   1")
+	assert.Contains(t, out.String(), "Run 'git drs cache rebuild' to repair.")
+}
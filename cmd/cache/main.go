@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/precommit_cache"
+	"github.com/spf13/cobra"
+)
+
+var (
+	openCache         = precommit_cache.Open
+	loadLFSInventory  = lfs.GetTrackedLfsFiles
+	gitTopLevel       = gitrepo.GitTopLevel
+	clearPointerIndex = lfs.ClearPointerIndex
+)
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect, clear, or rebuild the pre-commit cache",
+	Long: "Description:" +
+		"\n  The pre-commit hook records path -> oid entries under .git/drs/pre-commit" +
+		"\n  so pre-push can find LFS files without scanning history. The cache is" +
+		"\n  cleared automatically when .gitattributes changes; use these commands to" +
+		"\n  check it against the tracked files or repair it by hand. clear also" +
+		"\n  deletes the pointer index under .git/drs/index.",
+}
+
+// StatusCmd reports cache consistency.
+var StatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Compare the cache against tracked LFS files",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := commandContext(cmd)
+		c, err := openCache(ctx)
+		if err != nil {
+			return err
+		}
+		tracked, err := trackedOIDs()
+		if err != nil {
+			return err
+		}
+		st, err := c.Inspect(ctx, tracked, precommit_cache.DefaultMaxAge)
+		if err != nil {
+			return err
+		}
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), st)
+		}
+		return writeStatus(cmd.OutOrStdout(), st)
+	},
+}
+
+// ClearCmd deletes the cache.
+var ClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete all pre-commit cache entries and the pointer index",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := openCache(commandContext(cmd))
+		if err != nil {
+			return err
+		}
+		if err := c.Clear(); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Cleared %s\n", c.Root)
+		root, err := gitTopLevel()
+		if err != nil {
+			return err
+		}
+		if err := clearPointerIndex(commandContext(cmd), root); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Cleared the pointer index\n")
+		return nil
+	},
+}
+
+// RebuildCmd regenerates the cache from the tracked files.
+var RebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Regenerate the cache from the tracked LFS files",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := commandContext(cmd)
+		c, err := openCache(ctx)
+		if err != nil {
+			return err
+		}
+		tracked, err := trackedOIDs()
+		if err != nil {
+			return err
+		}
+		if err := c.Rebuild(ctx, tracked, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Rebuilt pre-commit cache with %d paths\n", len(tracked))
+		return nil
+	},
+}
+
+func init() {
+	Cmd.AddCommand(StatusCmd)
+	Cmd.AddCommand(ClearCmd)
+	Cmd.AddCommand(RebuildCmd)
+}
+
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+func trackedOIDs() (map[string]string, error) {
+	files, err := loadLFSInventory(drslog.GetLogger())
+	if err != nil {
+		return nil, fmt.Errorf("error listing tracked files: %w", err)
+	}
+	tracked := make(map[string]string, len(files))
+	for path, info := range files {
+		if info.Oid != "" {
+			tracked[path] = info.Oid
+		}
+	}
+	return tracked, nil
+}
+
+func writeStatus(w io.Writer, st precommit_cache.Status) error {
+	if !st.Exists {
+		_, err := fmt.Fprintf(w, "Pre-commit cache not found at %s (%d tracked paths uncached)\nRun 'git drs cache rebuild' to create it.\n", st.Root, st.Missing)
+		return err
+	}
+	fmt.Fprintf(w, "Cache:        %s\n", st.Root)
+	fmt.Fprintf(w, "Path entries: %d (%d oid entries)\n", st.PathEntries, st.OIDEntries)
+	fmt.Fprintf(w, "Valid:        %d\n", st.Valid)
+	fmt.Fprintf(w, "Stale:        %d\n", st.Stale)
+	fmt.Fprintf(w, "Mismatched:   %d\n", st.Mismatched)
+	fmt.Fprintf(w, "Orphaned:     %d\n", st.Orphaned)
+	fmt.Fprintf(w, "Missing:      %d\n", st.Missing)
+	fmt.Fprintf(w, "Corrupt:      %d\n", st.Corrupt)
+	if st.AttributesChanged {
+		fmt.Fprintln(w, "Tracking attributes changed since the cache was built.")
+	}
+	if st.Healthy() {
+		_, err := fmt.Fprintln(w, "Cache is consistent.")
+		return err
+	}
+	_, err := fmt.Fprintln(w, "Run 'git drs cache rebuild' to repair.")
+	return err
+}
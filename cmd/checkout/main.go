@@ -0,0 +1,67 @@
+package checkout
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/calypr/git-drs/cmd/pull"
+	"github.com/calypr/git-drs/internal/pathspec"
+	"github.com/spf13/cobra"
+)
+
+var (
+	data       bool
+	remoteName string
+	dryRun     bool
+)
+
+// runPull hydrates the selected pointers; swapped in tests.
+var runPull = pull.Run
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "checkout --data <path>...",
+	Short: "Download the content of selected pointer files in a pointer-only checkout",
+	Long: "Description:" +
+		"\n  Hydrate the named files, or every pointer under the named directories or" +
+		"\n  matching the named globs, in a checkout that was left pointer-only by" +
+		"\n  drs.skip-smudge, GIT_DRS_SKIP_SMUDGE or git drs clone --skip-smudge." +
+		"\n  Other pointers stay as they are. Paths are relative to the working" +
+		"\n  directory, as with git.",
+	Example: "  git drs checkout --data results/summary.tsv\n" +
+		"  git drs checkout --data 'data/*.bam' reference/",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if !data {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: --data is required; git drs checkout only hydrates pointer files\n\nUsage: %s", cmd.UseLine())
+		}
+		if len(args) == 0 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires at least 1 path\n\nUsage: %s\n\nSee 'git drs checkout --help' for more details", cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPull(cmd.OutOrStdout(), remoteName, patterns(args), dryRun)
+	},
+}
+
+func init() {
+	Cmd.Flags().BoolVar(&data, "data", false, "download the content of the named pointer files")
+	Cmd.Flags().StringVarP(&remoteName, "remote", "r", "", "DRS remote to download from (default: the default remote)")
+	Cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list the files that would be downloaded without downloading them")
+}
+
+// patterns roots args at the top level and lets a plain path also name every
+// file below it, so a directory selects its contents.
+func patterns(args []string) []string {
+	rooted := pathspec.Rooted(args)
+	out := make([]string, 0, 2*len(rooted))
+	for _, p := range rooted {
+		out = append(out, p)
+		if !strings.ContainsAny(p, "*?[") {
+			out = append(out, p+"/**")
+		}
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+package checkout
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestCheckoutPullsSelectedPaths(t *testing.T) {
+	t.Chdir(t.TempDir())
+	oldRunPull := runPull
+	t.Cleanup(func() {
+		runPull = oldRunPull
+		data, remoteName, dryRun = false, "", false
+	})
+
+	var got []string
+	var gotRemote string
+	runPull = func(_ io.Writer, remote string, patterns []string, _ bool) error {
+		gotRemote, got = remote, patterns
+		return nil
+	}
+	data, remoteName = true, "origin"
+	Cmd.SetOut(io.Discard)
+	if err := Cmd.Args(Cmd, []string{"data/a.bin", "ref/*.fa"}); err != nil {
+		t.Fatalf("args: %v", err)
+	}
+	if err := Cmd.RunE(Cmd, []string{"data/a.bin", "ref/*.fa"}); err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+	want := []string{"data/a.bin", "data/a.bin/**", "ref/*.fa"}
+	if gotRemote != "origin" || !reflect.DeepEqual(got, want) {
+		t.Fatalf("pull(%q, %v), want pull(origin, %v)", gotRemote, got, want)
+	}
+}
+
+func TestCheckoutRequiresData(t *testing.T) {
+	data = false
+	if err := Cmd.Args(Cmd, []string{"data/a.bin"}); err == nil {
+		t.Fatal("expected an error without --data")
+	}
+}
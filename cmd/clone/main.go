@@ -0,0 +1,125 @@
+package clone
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/calypr/git-drs/cmd/initialize"
+	"github.com/calypr/git-drs/cmd/pull"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsfilter"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	includePatterns []string
+	noData          bool
+	skipSmudge      bool
+	branch          string
+	remote          string
+)
+
+// runPull is swapped in tests.
+var runPull = pull.Run
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "clone <git-url> [directory]",
+	Short: "Clone a repository, set up git-drs, and pull DRS data",
+	Long: "Clone a git repository with pointer files left in place, apply git-drs " +
+		"repository setup (filters and hooks), then hydrate DRS data selected by --include. " +
+		"Use --no-data to stop after setup, or --skip-smudge to also keep later " +
+		"checkouts pointer-only, as CI jobs that never need the data should.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 || len(args) > 2 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires 1 or 2 arguments (git URL and optional directory), received %d\n\nUsage: %s\n\nSee 'git drs clone --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logg := drslog.GetLogger()
+
+		url := args[0]
+		dir := ""
+		if len(args) == 2 {
+			dir = args[1]
+		} else {
+			dir = defaultCloneDir(url)
+		}
+		if dir == "" {
+			return fmt.Errorf("unable to derive a directory name from %q; pass one explicitly", url)
+		}
+
+		cloneArgs := []string{"clone"}
+		if branch != "" {
+			cloneArgs = append(cloneArgs, "--branch", branch)
+		}
+		cloneArgs = append(cloneArgs, "--", url, dir)
+		gitClone := exec.Command("git", cloneArgs...)
+		gitClone.Stdout = cmd.OutOrStdout()
+		gitClone.Stderr = cmd.ErrOrStderr()
+		// Leave pointers in place during checkout; data is pulled selectively below.
+		gitClone.Env = append(os.Environ(), drsfilter.SkipSmudgeEnv+"=1")
+		if err := gitClone.Run(); err != nil {
+			return fmt.Errorf("git clone failed: %w", err)
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		if err := os.Chdir(dir); err != nil {
+			return fmt.Errorf("unable to enter cloned repository %s: %w", dir, err)
+		}
+		defer os.Chdir(cwd)
+
+		if err := initialize.InitializeRepo(logg); err != nil {
+			return err
+		}
+
+		if skipSmudge {
+			if err := gitrepo.SetGitConfigOptions(map[string]string{drsfilter.SkipSmudgeConfig: "true"}); err != nil {
+				return fmt.Errorf("unable to write git config: %w", err)
+			}
+		}
+		if noData || skipSmudge || drsfilter.SkipSmudge() {
+			logg.Debug("clone: --no-data or skip-smudge set; skipping DRS pull")
+			return nil
+		}
+
+		if err := runPull(cmd.OutOrStdout(), remote, includePatterns, false); err != nil {
+			if errors.Is(err, config.ErrNoDefaultRemote) {
+				fmt.Fprintf(cmd.ErrOrStderr(), "No DRS remote configured; files remain as pointers.\nAdd one with `git drs remote add ...` and run `git drs pull` inside %s.\n", dir)
+				return nil
+			}
+			return fmt.Errorf("clone succeeded but pulling DRS data failed: %w", err)
+		}
+		return nil
+	},
+}
+
+// defaultCloneDir mirrors git's "humanish" directory naming.
+func defaultCloneDir(url string) string {
+	trimmed := strings.TrimRight(strings.TrimSpace(url), "/")
+	trimmed = strings.TrimSuffix(trimmed, "/.git")
+	if i := strings.LastIndexAny(trimmed, "/:"); i >= 0 {
+		trimmed = trimmed[i+1:]
+	}
+	trimmed = strings.TrimSuffix(trimmed, ".git")
+	return filepath.Base(trimmed)
+}
+
+func init() {
+	Cmd.Flags().StringArrayVarP(&includePatterns, "include", "I", nil, "only pull DRS data for matching pathspec/glob pattern(s)")
+	Cmd.Flags().BoolVar(&noData, "no-data", false, "clone and set up git-drs without downloading DRS data")
+	Cmd.Flags().BoolVar(&skipSmudge, "skip-smudge", false, "like --no-data, and set drs.skip-smudge so later checkouts leave pointers too")
+	Cmd.Flags().StringVarP(&branch, "branch", "b", "", "branch to check out")
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote to pull from (default: default_remote)")
+}
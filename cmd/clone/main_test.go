@@ -0,0 +1,132 @@
+package clone
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+)
+
+func makeSourceRepo(t *testing.T) string {
+	t.Helper()
+	src := filepath.Join(t.TempDir(), "source")
+	for _, args := range [][]string{
+		{"init", src},
+		{"-C", src, "config", "user.email", "test@example.com"},
+		{"-C", src, "config", "user.name", "Test User"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(src, "README.md"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	for _, args := range [][]string{
+		{"-C", src, "add", "README.md"},
+		{"-C", src, "commit", "-m", "init"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	return src
+}
+
+func resetFlags(t *testing.T) {
+	t.Helper()
+	origPull := runPull
+	t.Cleanup(func() {
+		includePatterns = nil
+		noData = false
+		skipSmudge = false
+		branch = ""
+		remote = ""
+		runPull = origPull
+	})
+}
+
+func TestCloneNoDataInitializesRepo(t *testing.T) {
+	resetFlags(t)
+	src := makeSourceRepo(t)
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	noData = true
+	runPull = func(io.Writer, string, []string, bool) error {
+		t.Fatalf("pull should not run with --no-data")
+		return nil
+	}
+	Cmd.SetOut(io.Discard)
+	Cmd.SetErr(io.Discard)
+	if err := Cmd.RunE(Cmd, []string{src, dst}); err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dst, "README.md")); err != nil {
+		t.Fatalf("expected checkout: %v", err)
+	}
+	hook, err := os.ReadFile(filepath.Join(dst, ".git", "hooks", "pre-commit"))
+	if err != nil || !strings.Contains(string(hook), "git drs precommit") {
+		t.Fatalf("expected pre-commit hook installed, err=%v", err)
+	}
+}
+
+func TestCloneWithoutRemoteLeavesPointers(t *testing.T) {
+	resetFlags(t)
+	src := makeSourceRepo(t)
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	var gotPatterns []string
+	includePatterns = []string{"data/**"}
+	runPull = func(_ io.Writer, _ string, patterns []string, _ bool) error {
+		gotPatterns = patterns
+		return config.ErrNoDefaultRemote
+	}
+	Cmd.SetOut(io.Discard)
+	Cmd.SetErr(io.Discard)
+	if err := Cmd.RunE(Cmd, []string{src, dst}); err != nil {
+		t.Fatalf("clone should tolerate a missing remote: %v", err)
+	}
+	if len(gotPatterns) != 1 || gotPatterns[0] != "data/**" {
+		t.Fatalf("expected include patterns forwarded, got %v", gotPatterns)
+	}
+}
+
+func TestDefaultCloneDir(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/calypr/git-drs.git": "git-drs",
+		"git@github.com:calypr/data.git":        "data",
+		"/srv/repos/dataset/":                   "dataset",
+		"/srv/repos/dataset/.git":               "dataset",
+	}
+	for url, want := range tests {
+		if got := defaultCloneDir(url); got != want {
+			t.Fatalf("defaultCloneDir(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestCloneSkipSmudgeKeepsCheckoutsPointerOnly(t *testing.T) {
+	resetFlags(t)
+	src := makeSourceRepo(t)
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	skipSmudge = true
+	runPull = func(io.Writer, string, []string, bool) error {
+		t.Fatalf("pull should not run with --skip-smudge")
+		return nil
+	}
+	Cmd.SetOut(io.Discard)
+	Cmd.SetErr(io.Discard)
+	if err := Cmd.RunE(Cmd, []string{src, dst}); err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	out, err := exec.Command("git", "-C", dst, "config", "--get", "drs.skip-smudge").Output()
+	if err != nil || strings.TrimSpace(string(out)) != "true" {
+		t.Fatalf("drs.skip-smudge = %q, %v; want true", out, err)
+	}
+}
@@ -5,10 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"strings"
 
+	"github.com/calypr/git-drs/internal/audit"
 	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/projectmap"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
 	syservices "github.com/calypr/syfon/client/services"
@@ -16,7 +21,9 @@ import (
 )
 
 var (
-	batchSize int
+	batchSize       int
+	listConcurrency int
+	resume          bool
 )
 
 type copyStats struct {
@@ -25,6 +32,8 @@ type copyStats struct {
 	Updated    int
 	Unchanged  int
 	Written    int
+	// updatedDIDs marks records in a batch that already existed on the target.
+	updatedDIDs map[string]bool
 }
 
 type indexAPI interface {
@@ -87,8 +96,20 @@ var Cmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("error creating target client: %w", err)
 		}
+		if err := dstCtx.RequireWrite(); err != nil {
+			return err
+		}
+
+		cursorPath := ""
+		if resume {
+			cursorPath, err = copyCursorPath(srcRemoteName, org, proj)
+			if err != nil {
+				return fmt.Errorf("error resolving resume cursor: %w", err)
+			}
+		}
 
-		stats, err := copyProjectRecords(cmd.Context(), logger, srcCtx.Client.Index(), dstCtx.Client.Index(), org, proj, batchSize)
+		translate := scopeTranslator{src: srcCtx.ProjectMap, dst: dstCtx.ProjectMap}
+		stats, err := copyProjectRecords(cmd.Context(), logger, srcCtx.Client.Index(), dstCtx.Client.Index(), string(dstRemoteName), org, proj, batchSize, listConcurrency, cursorPath, translate)
 		if err != nil {
 			return err
 		}
@@ -110,6 +131,8 @@ var Cmd = &cobra.Command{
 
 func init() {
 	Cmd.Flags().IntVar(&batchSize, "batch-size", 250, "records per source page and target bulk write")
+	Cmd.Flags().IntVar(&listConcurrency, "list-concurrency", drsremote.DefaultListConcurrency, "source pages fetched concurrently")
+	Cmd.Flags().BoolVar(&resume, "resume", true, "persist a listing cursor under .git/drs/cursors and resume an interrupted copy")
 }
 
 func parseScopeArg(raw string) (string, string, error) {
@@ -129,35 +152,31 @@ func parseScopeArg(raw string) (string, string, error) {
 	return org, project, nil
 }
 
-func copyProjectRecords(ctx context.Context, logger *slog.Logger, src indexAPI, dst indexAPI, org, project string, batchSize int) (copyStats, error) {
+func copyProjectRecords(ctx context.Context, logger *slog.Logger, src indexAPI, dst indexAPI, targetRemote string, org, project string, batchSize int, concurrency int, cursorPath string, translate scopeTranslator) (copyStats, error) {
 	if batchSize <= 0 {
 		batchSize = 250
 	}
 
 	stats := copyStats{}
-	page := 1
-	for {
-		listResp, err := src.List(ctx, syservices.ListRecordsOptions{
-			Organization: org,
-			ProjectID:    project,
-			Limit:        batchSize,
-			Page:         page,
-		})
-		if err != nil {
-			return stats, fmt.Errorf("source list failed for %s/%s page %d: %w", org, project, page, err)
-		}
-		records := []internalapi.InternalRecord{}
-		if listResp.Records != nil {
-			records = *listResp.Records
-		}
-		if len(records) == 0 {
-			break
-		}
+	err := drsremote.ListRecordsParallel(ctx, src, drsremote.ListOptions{
+		Organization: org,
+		ProjectID:    project,
+		PageSize:     batchSize,
+		Concurrency:  concurrency,
+		CursorPath:   cursorPath,
+	}, func(page int, records []internalapi.InternalRecord) error {
 		stats.SourceSeen += len(records)
+		if translate.active() {
+			mapped := make([]internalapi.InternalRecord, len(records))
+			for i, rec := range records {
+				mapped[i] = translate.record(rec)
+			}
+			records = mapped
+		}
 
 		toWrite, batchStats, err := buildMergedBatch(ctx, dst, records)
 		if err != nil {
-			return stats, err
+			return err
 		}
 		stats.Created += batchStats.Created
 		stats.Updated += batchStats.Updated
@@ -166,13 +185,29 @@ func copyProjectRecords(ctx context.Context, logger *slog.Logger, src indexAPI,
 		if len(toWrite) > 0 {
 			resp, err := dst.CreateBulk(ctx, internalapi.BulkCreateRequest{Records: toWrite})
 			if err != nil {
-				return stats, fmt.Errorf("target bulk create failed on page %d: %w", page, err)
+				return fmt.Errorf("target bulk create failed on page %d: %w", page, err)
 			}
 			if resp.Records != nil {
 				stats.Written += len(*resp.Records)
 			} else {
 				stats.Written += len(toWrite)
 			}
+
+			events := make([]audit.Event, 0, len(toWrite))
+			for _, rec := range toWrite {
+				action := audit.ActionRegister
+				if batchStats.updatedDIDs[strings.TrimSpace(rec.Did)] {
+					action = audit.ActionUpdate
+				}
+				events = append(events, audit.Event{
+					Action:  action,
+					Remote:  targetRemote,
+					Project: translate.project(org, project),
+					DRSID:   rec.Did,
+					Detail:  "copy-records",
+				})
+			}
+			audit.RecordOrWarn(logger, events...)
 		}
 
 		if logger != nil {
@@ -187,14 +222,76 @@ func copyProjectRecords(ctx context.Context, logger *slog.Logger, src indexAPI,
 				"written", len(toWrite),
 			)
 		}
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("copy-records failed for %s/%s: %w", org, project, err)
+	}
+	return stats, nil
+}
+
+// copyCursorPath returns where an interrupted copy for this scope keeps its
+// resume cursor.
+func copyCursorPath(srcRemote config.Remote, org, project string) (string, error) {
+	drsDir, err := gitrepo.DrsTopLevel()
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s_%s_%s.json", srcRemote, org, project)
+	return filepath.Join(drsDir, "cursors", "copy-records", name), nil
+}
 
-		if len(records) < batchSize {
-			break
+// scopeTranslator moves records from the source remote's scope into the
+// target's, going through the repository's local scope. Either mapping may be
+// nil.
+type scopeTranslator struct {
+	src, dst *projectmap.Mapping
+}
+
+func (t scopeTranslator) active() bool {
+	return t.src != nil || t.dst != nil
+}
+
+func (t scopeTranslator) record(rec internalapi.InternalRecord) internalapi.InternalRecord {
+	oid := ""
+	if rec.Hashes != nil {
+		oid = (*rec.Hashes)["sha256"]
+	}
+	if oid != "" {
+		rec.Did = t.dst.ToRemoteID(t.src.ToLocalID(rec.Did, oid), oid)
+	}
+	if rec.ControlledAccess != nil {
+		resources := t.dst.ToRemoteResources(t.src.ToLocalResources(*rec.ControlledAccess))
+		rec.ControlledAccess = &resources
+	}
+	if rec.Project != nil {
+		org := ""
+		if rec.Organization != nil {
+			org = *rec.Organization
+		}
+		mappedOrg, mappedProject := t.scope(org, *rec.Project)
+		rec.Project = &mappedProject
+		if rec.Organization != nil {
+			rec.Organization = &mappedOrg
 		}
-		page++
 	}
+	return rec
+}
 
-	return stats, nil
+// scope maps an organization/project pair the same way records are mapped.
+func (t scopeTranslator) scope(org, project string) (string, string) {
+	if m := t.src; m != nil && org == m.RemoteOrganization && project == m.RemoteProject {
+		org, project = m.LocalOrganization, m.LocalProject
+	}
+	if m := t.dst; m != nil && org == m.LocalOrganization && project == m.LocalProject {
+		org, project = m.RemoteOrganization, m.RemoteProject
+	}
+	return org, project
+}
+
+func (t scopeTranslator) project(org, project string) string {
+	_, project = t.scope(org, project)
+	return project
 }
 
 func buildMergedBatch(ctx context.Context, dst indexAPI, source []internalapi.InternalRecord) ([]internalapi.InternalRecord, copyStats, error) {
@@ -232,6 +329,10 @@ func buildMergedBatch(ctx context.Context, dst indexAPI, source []internalapi.In
 			if changed {
 				out = append(out, merged)
 				stats.Updated++
+				if stats.updatedDIDs == nil {
+					stats.updatedDIDs = map[string]bool{}
+				}
+				stats.updatedDIDs[did] = true
 			} else {
 				stats.Unchanged++
 			}
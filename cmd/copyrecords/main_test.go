@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/projectmap"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
 	syservices "github.com/calypr/syfon/client/services"
@@ -137,3 +139,37 @@ func TestBuildMergedBatch_CreatesNewAndUpdatesExisting(t *testing.T) {
 		t.Fatalf("unexpected stats: %+v", stats)
 	}
 }
+
+func TestScopeTranslatorMapsSourceScopeToTarget(t *testing.T) {
+	oid := "95d536cc8df0a8e265832c6bd0422d69593f564d5ff0518e77535c45bc10bfde"
+	translate := scopeTranslator{dst: &projectmap.Mapping{
+		LocalOrganization:  "programA",
+		LocalProject:       "projectX",
+		RemoteOrganization: "programB",
+		RemoteProject:      "projectY",
+	}}
+	org, project := "programA", "projectX"
+	ca := []string{"/organization/programA/project/projectX"}
+	hashes := internalapi.HashInfo{"sha256": oid}
+	in := internalapi.InternalRecord{
+		Did:              drsobject.DeterministicID("projectX", oid),
+		Hashes:           &hashes,
+		ControlledAccess: &ca,
+		Organization:     &org,
+		Project:          &project,
+	}
+
+	out := translate.record(in)
+	if want := drsobject.DeterministicID("projectY", oid); out.Did != want {
+		t.Fatalf("did = %q, want %q", out.Did, want)
+	}
+	if out.ControlledAccess == nil || (*out.ControlledAccess)[0] != "/organization/programB/project/projectY" {
+		t.Fatalf("controlled access = %v", out.ControlledAccess)
+	}
+	if *out.Organization != "programB" || *out.Project != "projectY" {
+		t.Fatalf("scope = %s/%s", *out.Organization, *out.Project)
+	}
+	if in.Did == out.Did || (*in.ControlledAccess)[0] != "/organization/programA/project/projectX" {
+		t.Fatalf("translation must not modify the source record")
+	}
+}
@@ -10,9 +10,9 @@ import (
 	"strings"
 
 	"github.com/calypr/data-client/credentials"
+	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/drslog"
 	"github.com/calypr/git-drs/internal/gitrepo"
-	conf "github.com/calypr/syfon/client/config"
 	"github.com/spf13/cobra"
 )
 
@@ -60,22 +60,26 @@ var Cmd = &cobra.Command{
 		}
 
 		token := ""
+		// Keyring remotes never keep a token in git config.
+		store := config.RemoteCredentialStore(remoteName)
+		keyring := store == config.CredentialStoreKeyring
 		// Prefer repo-local token first to keep git-drs local and deterministic.
-		if repoToken, err := gitrepo.GetRemoteToken(remoteName); err == nil && strings.TrimSpace(repoToken) != "" {
-			token = strings.TrimSpace(repoToken)
+		if !keyring {
+			if repoToken, err := gitrepo.GetRemoteToken(remoteName); err == nil && strings.TrimSpace(repoToken) != "" {
+				token = strings.TrimSpace(repoToken)
+			}
 		}
 
 		// Try global profile to refresh/validate; fall back to repo token if unavailable.
-		manager := conf.NewConfigure(logg)
-		cred, err := manager.Load(remoteName)
+		cred, err := config.LoadProfile(store, config.RemoteProfile(remoteName), logg)
 		if err == nil {
 			if token != "" {
 				cred.AccessToken = token
 			}
 			if ensureErr := credentials.EnsureValidCredential(context.Background(), cred, logg); ensureErr == nil {
-				_ = manager.Save(cred)
+				_ = config.SaveProfile(store, cred, logg)
 				token = strings.TrimSpace(cred.AccessToken)
-				if token != "" {
+				if token != "" && !keyring {
 					_ = gitrepo.SetRemoteToken(remoteName, token)
 				}
 			}
@@ -0,0 +1,42 @@
+package dedupe
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// errReflinkUnsupported is returned where copy-on-write clones are not
+// available.
+var errReflinkUnsupported = errors.New("reflinks are not supported on this filesystem")
+
+// replace swaps dup for a link to keep, made under a temporary name and
+// renamed over dup so dup is never missing.
+func replace(mode, keep, dup string) error {
+	info, err := os.Stat(dup)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(filepath.Dir(dup), fmt.Sprintf(".%s.dedupe-%d", filepath.Base(dup), os.Getpid()))
+	_ = os.Remove(tmp)
+	switch mode {
+	case modeLink:
+		err = os.Link(keep, tmp)
+	case modeReflink:
+		if err = cloneFile(keep, tmp); err == nil {
+			err = os.Chmod(tmp, info.Mode().Perm())
+		}
+	default:
+		err = fmt.Errorf("unknown dedupe mode %q", mode)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dup); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package dedupe
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/hashing"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pathspec"
+	"github.com/calypr/git-drs/internal/progressui"
+	"github.com/calypr/git-drs/internal/projectdir"
+	"github.com/spf13/cobra"
+)
+
+var (
+	link    bool
+	reflink bool
+)
+
+var (
+	loadLFSInventory = lfs.GetTrackedLfsFiles
+	hashFile         = func(path string) (string, error) {
+		oid, _, err := hashing.HashFile(path, true)
+		return oid, err
+	}
+)
+
+// Group is one oid checked out at more than one path. Paths[0] is kept;
+// the others are the duplicates.
+type Group struct {
+	OID   string   `json:"oid"`
+	Size  int64    `json:"size"`
+	Paths []string `json:"paths"`
+	// Linked lists duplicates that already share Paths[0]'s storage.
+	Linked []string `json:"linked,omitempty"`
+	// Skipped maps duplicates left alone to the reason.
+	Skipped map[string]string `json:"skipped,omitempty"`
+}
+
+// Report lists duplicate groups and the space deduplication saves.
+type Report struct {
+	Groups []Group `json:"groups"`
+	// Reclaimable is the size of the duplicates that are not yet linked.
+	Reclaimable int64 `json:"reclaimable_bytes"`
+	// Saved is the size of the duplicates this run replaced.
+	Saved   int64  `json:"saved_bytes,omitempty"`
+	Mode    string `json:"mode,omitempty"`
+	Applied int    `json:"replaced,omitempty"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "dedupe [pathspec...]",
+	Short: "Report and link working tree files with identical content",
+	Long: "Description:" +
+		"\n  Find LFS files in the working tree that share an oid and report the space" +
+		"\n  their duplicates use. With --link, duplicates are replaced by hard links to" +
+		"\n  the first path; with --reflink, by copy-on-write clones on filesystems that" +
+		"\n  support them. Only files whose content hashes to their oid are touched.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if link && reflink {
+			return fmt.Errorf("--link and --reflink cannot be combined")
+		}
+		logger := drslog.GetLogger()
+		inventory, err := loadLFSInventory(logger)
+		if err != nil {
+			return fmt.Errorf("error listing tracked files: %w", err)
+		}
+		mode := ""
+		switch {
+		case link:
+			mode = modeLink
+		case reflink:
+			mode = modeReflink
+		}
+		report := run(inventory, pathspec.Rooted(args), mode, logger)
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), report)
+		}
+		return writeReport(cmd.OutOrStdout(), report)
+	},
+}
+
+func init() {
+	Cmd.Flags().BoolVar(&link, "link", false, "replace duplicates with hard links to the first path")
+	Cmd.Flags().BoolVar(&reflink, "reflink", false, "replace duplicates with copy-on-write clones (Linux btrfs and XFS)")
+}
+
+const (
+	modeLink    = "link"
+	modeReflink = "reflink"
+)
+
+// run groups the inventory by oid and, when mode is set, replaces each
+// verified duplicate with a link to its group's first path.
+func run(inventory map[string]lfs.LfsFileInfo, patterns []string, mode string, logger *slog.Logger) Report {
+	byOID := make(map[string][]string)
+	sizes := make(map[string]int64)
+	for p, info := range inventory {
+		if info.Oid == "" || !pathspec.MatchesAny(p, patterns) {
+			continue
+		}
+		byOID[info.Oid] = append(byOID[info.Oid], p)
+		sizes[info.Oid] = info.Size
+	}
+
+	report := Report{Mode: mode}
+	for oid, paths := range byOID {
+		if len(paths) < 2 {
+			continue
+		}
+		sort.Strings(paths)
+		g := checkGroup(oid, sizes[oid], paths)
+		if g == nil {
+			continue
+		}
+		for _, dup := range g.Paths[1:] {
+			if _, skipped := g.Skipped[dup]; skipped || contains(g.Linked, dup) {
+				continue
+			}
+			report.Reclaimable += g.Size
+			if mode == "" {
+				continue
+			}
+			if err := replace(mode, projectdir.Path(g.Paths[0]), projectdir.Path(dup)); err != nil {
+				logger.Warn("dedupe failed", "path", dup, "error", err)
+				g.skip(dup, err.Error())
+				continue
+			}
+			g.Linked = append(g.Linked, dup)
+			report.Saved += g.Size
+			report.Applied++
+		}
+		report.Groups = append(report.Groups, *g)
+	}
+	report.Reclaimable -= report.Saved
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if wa, wb := a.Size*int64(len(a.Paths)-1), b.Size*int64(len(b.Paths)-1); wa != wb {
+			return wa > wb
+		}
+		return a.OID < b.OID
+	})
+	return report
+}
+
+// checkGroup verifies the working tree copies of one oid. Paths that are not
+// checked out with exactly that content are skipped, and the first verified
+// path becomes the one duplicates link to. It returns nil when fewer than two
+// paths hold the content.
+func checkGroup(oid string, size int64, paths []string) *Group {
+	g := &Group{OID: oid, Size: size}
+	var keep os.FileInfo
+	for _, p := range paths {
+		info, reason := verify(projectdir.Path(p), oid, size)
+		if reason != "" {
+			g.skip(p, reason)
+			continue
+		}
+		if keep == nil {
+			keep = info
+			g.Paths = append(g.Paths, p)
+			continue
+		}
+		g.Paths = append(g.Paths, p)
+		if os.SameFile(keep, info) {
+			g.Linked = append(g.Linked, p)
+		}
+	}
+	if len(g.Paths) < 2 {
+		return nil
+	}
+	return g
+}
+
+// verify reports why path cannot take part in deduplication, or "" when it
+// is a regular file holding oid.
+func verify(path, oid string, size int64) (os.FileInfo, string) {
+	info, err := os.Lstat(path)
+	switch {
+	case err != nil:
+		return nil, "not in the working tree"
+	case !info.Mode().IsRegular():
+		return nil, "not a regular file"
+	case info.Size() != size:
+		return nil, "not checked out or modified"
+	}
+	got, err := hashFile(path)
+	if err != nil {
+		return nil, fmt.Sprintf("hash failed: %v", err)
+	}
+	if got != oid {
+		return nil, "modified in the working tree"
+	}
+	return info, ""
+}
+
+func (g *Group) skip(p, reason string) {
+	if g.Skipped == nil {
+		g.Skipped = make(map[string]string)
+	}
+	g.Skipped[p] = reason
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func writeReport(w io.Writer, r Report) error {
+	var b strings.Builder
+	if len(r.Groups) == 0 {
+		b.WriteString("No duplicate files in the working tree\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	for _, g := range r.Groups {
+		fmt.Fprintf(&b, "%s  %s x %d\n", g.OID[:12], progressui.FormatBinaryBytes(g.Size), len(g.Paths))
+		for i, p := range g.Paths {
+			mark := "  "
+			switch {
+			case i == 0:
+				mark = "* "
+			case contains(g.Linked, p):
+				mark = "= "
+			}
+			fmt.Fprintf(&b, "  %s%s\n", mark, p)
+		}
+		skipped := make([]string, 0, len(g.Skipped))
+		for p := range g.Skipped {
+			skipped = append(skipped, p)
+		}
+		sort.Strings(skipped)
+		for _, p := range skipped {
+			fmt.Fprintf(&b, "  ! %s (%s)\n", p, g.Skipped[p])
+		}
+	}
+	if r.Mode != "" {
+		fmt.Fprintf(&b, "\nReplaced %d duplicates with %ss, saving %s\n", r.Applied, r.Mode, progressui.FormatBinaryBytes(r.Saved))
+	}
+	fmt.Fprintf(&b, "Reclaimable: %s", progressui.FormatBinaryBytes(r.Reclaimable))
+	if r.Mode == "" && r.Reclaimable > 0 {
+		b.WriteString(" (run with --link or --reflink to reclaim)")
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,108 @@
+package dedupe
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+func writeTree(t *testing.T, files map[string]string) {
+	t.Helper()
+	for p, content := range files {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func oidOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestRunReportsAndLinksVerifiedDuplicates(t *testing.T) {
+	t.Chdir(t.TempDir())
+	calib := "calibration frame"
+	writeTree(t, map[string]string{
+		"a/calib.fits":    calib,
+		"b/calib.fits":    calib,
+		"c/calib.fits":    "calibration FRAME", // edited in place, same size
+		"d/calib.fits":    calib,
+		"unique/img.fits": "one of a kind",
+	})
+	oid := oidOf(calib)
+	size := int64(len(calib))
+	inventory := map[string]lfs.LfsFileInfo{
+		"a/calib.fits":    {Oid: oid, Size: size},
+		"b/calib.fits":    {Oid: oid, Size: size},
+		"c/calib.fits":    {Oid: oid, Size: size},
+		"d/calib.fits":    {Oid: oid, Size: size},
+		"e/calib.fits":    {Oid: oid, Size: size}, // not checked out
+		"unique/img.fits": {Oid: oidOf("one of a kind"), Size: 13},
+	}
+	logger := drslog.GetLogger()
+
+	report := run(inventory, nil, "", logger)
+	if len(report.Groups) != 1 || report.Reclaimable != 2*size || report.Saved != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	g := report.Groups[0]
+	if strings.Join(g.Paths, ",") != "a/calib.fits,b/calib.fits,d/calib.fits" {
+		t.Fatalf("unexpected paths: %v", g.Paths)
+	}
+	if g.Skipped["c/calib.fits"] != "modified in the working tree" || g.Skipped["e/calib.fits"] != "not in the working tree" {
+		t.Fatalf("unexpected skipped: %v", g.Skipped)
+	}
+
+	report = run(inventory, nil, modeLink, logger)
+	if report.Applied != 2 || report.Saved != 2*size || report.Reclaimable != 0 {
+		t.Fatalf("unexpected link report: %+v", report)
+	}
+	a, _ := os.Stat("a/calib.fits")
+	for _, p := range []string{"b/calib.fits", "d/calib.fits"} {
+		info, err := os.Stat(p)
+		if err != nil || !os.SameFile(a, info) {
+			t.Fatalf("%s was not linked to a/calib.fits: %v", p, err)
+		}
+	}
+	if got, _ := os.ReadFile("c/calib.fits"); string(got) != "calibration FRAME" {
+		t.Fatalf("modified file was touched: %q", got)
+	}
+
+	// A second run finds nothing left to reclaim.
+	report = run(inventory, nil, modeLink, logger)
+	if report.Applied != 0 || report.Reclaimable != 0 || len(report.Groups[0].Linked) != 2 {
+		t.Fatalf("unexpected rerun report: %+v", report)
+	}
+	var out bytes.Buffer
+	if err := writeReport(&out, report); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"* a/calib.fits", "= b/calib.fits", "! c/calib.fits (modified in the working tree)", "Reclaimable: 0 B"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("report missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunHonorsPathspec(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeTree(t, map[string]string{"x/f": "same", "y/f": "same"})
+	inventory := map[string]lfs.LfsFileInfo{
+		"x/f": {Oid: oidOf("same"), Size: 4},
+		"y/f": {Oid: oidOf("same"), Size: 4},
+	}
+	if report := run(inventory, []string{"x/**"}, "", drslog.GetLogger()); len(report.Groups) != 0 {
+		t.Fatalf("expected the pathspec to leave one copy and no group: %+v", report)
+	}
+}
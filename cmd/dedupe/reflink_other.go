@@ -0,0 +1,8 @@
+//go:build !linux
+
+package dedupe
+
+// cloneFile is unsupported here; use --link instead.
+func cloneFile(src, dst string) error {
+	return errReflinkUnsupported
+}
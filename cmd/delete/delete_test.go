@@ -1,9 +1,24 @@
 package delete
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsdelete"
+	"github.com/calypr/git-drs/internal/drslog"
 	"github.com/calypr/git-drs/internal/testutils"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	syclient "github.com/calypr/syfon/client"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,3 +47,69 @@ func TestDeleteRun_Error(t *testing.T) {
 	err = Cmd.RunE(Cmd, []string{"md5", "oid"})
 	assert.Error(t, err)
 }
+
+func TestRunPurgeKeepsSharedBucketObjects(t *testing.T) {
+	past := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	desc := drsdelete.SoftDeleteMarker + `{"deleted_at":"2025-12-01T00:00:00Z","purge_after":"2025-12-31T00:00:00Z"}`
+	methods := []drsapi.AccessMethod{{
+		Type: drsapi.AccessMethodTypeS3,
+		AccessUrl: &struct {
+			Headers *[]string `json:"headers,omitempty"`
+			Url     string    `json:"url"`
+		}{Url: "s3://bucket/shared"},
+	}}
+	records := []internalapi.InternalRecord{
+		{Did: "expired", Description: &desc, AccessMethods: &methods},
+		{Did: "live", AccessMethods: &methods},
+	}
+	deletes := map[string]bool{}
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := `{"records":[]}`
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/index" && (r.URL.Query().Get("url") != "" || r.URL.Query().Get("page") == "1"):
+			out, _ := json.Marshal(internalapi.ListRecordsResponse{Records: &records})
+			body = string(out)
+		case r.Method == http.MethodGet && r.URL.Path == "/index":
+		case strings.HasSuffix(r.URL.Path, "/delete"):
+			var req drsapi.DeleteObjectJSONRequestBody
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			deletes[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/ga4gh/drs/v1/objects/"), "/delete")] = req.DeleteStorageData != nil && *req.DeleteStorageData
+			return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Header: http.Header{}, Request: r}, nil
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Request:    r,
+		}, nil
+	})}
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("syclient.New: %v", err)
+	}
+	gc := &config.GitContext{Client: raw.(*syclient.Client), Organization: "org", ProjectId: "proj", BucketName: "bucket"}
+
+	oldNow, oldConfirm, oldAudit := now, confirmFlag, recordAudit
+	t.Cleanup(func() { now, confirmFlag, recordAudit = oldNow, oldConfirm, oldAudit })
+	now = func() time.Time { return past }
+	confirmFlag = true
+	recordAudit = func(*slog.Logger, ...audit.Event) {}
+
+	var out bytes.Buffer
+	if err := runPurge(context.Background(), &out, gc, "origin", drslog.GetLogger()); err != nil {
+		t.Fatalf("runPurge: %v", err)
+	}
+	if len(deletes) != 1 {
+		t.Fatalf("expected only the expired record to be purged, got %v", deletes)
+	}
+	if keepData, ok := deletes["expired"]; !ok || keepData {
+		t.Fatalf("expected the expired record purged without its shared bucket object, got %v", deletes)
+	}
+	assert.Contains(t, out.String(), "kept bucket object because s3://bucket/shared is also referenced by live")
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
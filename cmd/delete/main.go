@@ -3,42 +3,68 @@ package delete
 import (
 	"context"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"time"
 
+	"github.com/calypr/git-drs/internal/audit"
 	"github.com/calypr/git-drs/internal/common"
 	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsdelete"
 	"github.com/calypr/git-drs/internal/drslog"
 	"github.com/calypr/git-drs/internal/drsremote"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	"github.com/calypr/syfon/client/hash"
 	"github.com/spf13/cobra"
 )
 
 var (
-	remote      string
-	confirmFlag bool
+	remote       string
+	confirmFlag  bool
+	hardDelete   bool
+	retention    time.Duration
+	purgeExpired bool
+	withData     bool
+)
+
+var (
+	now         = time.Now
+	recordAudit = audit.RecordOrWarn
 )
 
-// Cmd line declaration
 // Cmd line declaration
 var Cmd = &cobra.Command{
-	Use:    "delete <hash-type> <oid>",
-	Short:  "Delete a file using hash and file object ID",
-	Long:   "Delete a file using file object ID. Use lfs ls-files to get oid",
+	Use:   "delete <hash-type> <oid>",
+	Short: "Delete a file using hash and file object ID",
+	Long: "Delete the DRS records for a file object ID. Use lfs ls-files to get oid." +
+		"\n\nBy default records are soft-deleted: they are flagged as deleted and can be" +
+		"\nbrought back with 'git drs restore <did>' until the retention window ends." +
+		"\nUse --hard to remove records immediately, and --purge-expired to remove" +
+		"\nsoft-deleted records whose retention has ended, with their bucket objects" +
+		"\nwhen no other record references them." +
+		"\nUse --with-data to hard delete the records together with their bucket objects" +
+		"\nwhen no other record references them.",
 	Hidden: true,
-	Args:   cobra.ExactArgs(2),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if purgeExpired {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		hashType, oid := args[0], args[1]
-
-		// check hash type is valid Checksum type and sha256
-		if hashType != hash.ChecksumTypeSHA256.String() {
-			return fmt.Errorf("only sha256 supported, you requested to remove: %s", hashType)
+		if !purgeExpired {
+			// check hash type is valid Checksum type and sha256
+			if hashType := args[0]; hashType != hash.ChecksumTypeSHA256.String() {
+				return fmt.Errorf("only sha256 supported, you requested to remove: %s", hashType)
+			}
 		}
 
 		logger := drslog.GetLogger()
 
 		cfg, err := config.LoadConfig()
 		if err != nil {
-			return fmt.Errorf("error loading config: %v", err)
+			return fmt.Errorf("error loading config: %w", err)
 		}
 
 		remoteName, err := cfg.GetRemoteOrDefault(remote)
@@ -51,53 +77,221 @@ var Cmd = &cobra.Command{
 			logger.Error(fmt.Sprintf("error creating DRS client: %s", err))
 			return err
 		}
+		if err := drsClient.RequireWrite(); err != nil {
+			return err
+		}
 
-		// Get record details before deletion for confirmation
-		records, err := drsremote.ObjectsByHashForScope(context.Background(), drsClient, oid)
-		if err != nil {
-			return fmt.Errorf("error getting records for OID %s: %v", oid, err)
-		}
-		if len(records) == 0 {
-			return fmt.Errorf("no records found for OID %s", oid)
-		}
-
-		// Show details and get confirmation unless --confirm flag is set
-		if !confirmFlag {
-			projectId := drsClient.ProjectId
-			common.DisplayWarningHeader(os.Stderr, "DELETE a DRS record")
-			common.DisplayField(os.Stderr, "Remote", string(remoteName))
-			common.DisplayField(os.Stderr, "Project", projectId)
-			common.DisplayField(os.Stderr, "OID", oid)
-			common.DisplayField(os.Stderr, "Hash Type", hashType)
-			common.DisplayField(os.Stderr, "Matched DIDs", fmt.Sprintf("%d", len(records)))
-			if len(records) > 0 {
-				common.DisplayField(os.Stderr, "Example DID", records[0].Id)
-			}
-			common.DisplayField(os.Stderr, "Warning", "This deletes all DIDs (pointers) resolved by this SHA256 in this backend")
-			common.DisplayFooter(os.Stderr)
-
-			if err := common.PromptForConfirmation(
-				os.Stderr,
-				"Type 'yes' to confirm deletion",
-				common.ConfirmationYes,
-				false,
-			); err != nil {
-				return err
-			}
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if purgeExpired {
+			return runPurge(ctx, cmd.OutOrStdout(), drsClient, string(remoteName), logger)
 		}
+		return runDelete(ctx, drsClient, string(remoteName), args[0], args[1], logger)
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "target remote DRS server (default: default_remote)")
+	Cmd.Flags().BoolVar(&confirmFlag, "confirm", false, "skip interactive confirmation prompt")
+	Cmd.Flags().BoolVar(&hardDelete, "hard", false, "delete records immediately instead of soft-deleting them")
+	Cmd.Flags().DurationVar(&retention, "retention", drsdelete.DefaultRetention, "how long soft-deleted records are kept before --purge-expired removes them")
+	Cmd.Flags().BoolVar(&purgeExpired, "purge-expired", false, "remove soft-deleted records whose retention has ended, and their bucket objects unless another record references them")
+	Cmd.Flags().BoolVar(&withData, "with-data", false, "hard delete records and their objects in the project's bucket unless another record references them")
+}
 
-		// Delete the matching record
-		err = drsClient.Client.DRS().DeleteRecordsByHash(context.Background(), oid)
+func runDelete(ctx context.Context, drsClient *config.GitContext, remoteName, hashType, oid string, logger *slog.Logger) error {
+	// Get record details before deletion for confirmation. Soft-deleted
+	// records are included so they can be hard deleted.
+	records, err := drsremote.ObjectsByHashForScope(drsremote.WithSoftDeleted(ctx), drsClient, oid)
+	if err != nil {
+		return fmt.Errorf("error getting records for OID %s: %v", oid, err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no records found for OID %s", oid)
+	}
+
+	var plans []drsdelete.DataPlan
+	if withData {
+		plans, err = drsdelete.PlanDataDeletion(ctx, drsClient.Client.Index(), records, drsClient.BucketName)
 		if err != nil {
-			return fmt.Errorf("error deleting file for OID %s: %v", oid, err)
+			return fmt.Errorf("error checking bucket objects for OID %s: %w", oid, err)
 		}
+	}
 
+	// Show details and get confirmation unless --confirm flag is set
+	if !confirmFlag {
+		mode := fmt.Sprintf("soft delete (restorable for %s)", retention)
+		if withData {
+			mode = "hard delete with bucket data (not recoverable)"
+		} else if hardDelete {
+			mode = "hard delete (not recoverable)"
+		}
+		common.DisplayWarningHeader(os.Stderr, "DELETE a DRS record")
+		common.DisplayField(os.Stderr, "Remote", remoteName)
+		common.DisplayField(os.Stderr, "Project", drsClient.ProjectId)
+		common.DisplayField(os.Stderr, "OID", oid)
+		common.DisplayField(os.Stderr, "Hash Type", hashType)
+		common.DisplayField(os.Stderr, "Mode", mode)
+		common.DisplayField(os.Stderr, "Matched DIDs", fmt.Sprintf("%d", len(records)))
+		common.DisplayField(os.Stderr, "Example DID", records[0].Id)
+		if withData {
+			deleted := countDataDeletes(plans)
+			common.DisplayField(os.Stderr, "Bucket Objects", fmt.Sprintf("%d deleted, %d kept", deleted, len(plans)-deleted))
+		}
+		common.DisplayField(os.Stderr, "Warning", "This deletes all DIDs (pointers) resolved by this SHA256 in this backend")
+		common.DisplayFooter(os.Stderr)
+
+		if err := common.PromptForConfirmation(
+			os.Stderr,
+			"Type 'yes' to confirm deletion",
+			common.ConfirmationYes,
+			false,
+		); err != nil {
+			return err
+		}
+	}
+
+	if withData {
+		return deleteWithData(ctx, drsClient, remoteName, oid, plans, logger)
+	}
+
+	events := make([]audit.Event, 0, len(records))
+	if hardDelete {
+		if err := drsClient.Client.DRS().DeleteRecordsByHash(ctx, oid); err != nil {
+			return fmt.Errorf("error deleting file for OID %s: %v", oid, err)
+		}
+		for _, rec := range records {
+			events = append(events, audit.Event{
+				Action:  audit.ActionDelete,
+				Remote:  remoteName,
+				Project: drsClient.ProjectId,
+				DRSID:   rec.Id,
+				OID:     oid,
+			})
+		}
+		recordAudit(logger, events...)
 		logger.Debug(fmt.Sprintf("Successfully deleted record for OID %s", oid))
 		return nil
-	},
+	}
+
+	by := audit.ActingUser()
+	for _, rec := range records {
+		sd, err := drsdelete.MarkDeleted(ctx, drsClient.Client.Index(), rec.Id, by, now(), retention)
+		if err != nil {
+			recordAudit(logger, events...)
+			return err
+		}
+		events = append(events, audit.Event{
+			Action:  audit.ActionDelete,
+			Remote:  remoteName,
+			Project: drsClient.ProjectId,
+			DRSID:   rec.Id,
+			OID:     oid,
+			Detail:  "soft delete; purge after " + sd.PurgeAfter,
+		})
+		fmt.Fprintf(os.Stderr, "Soft-deleted %s (restore with 'git drs restore %s' before %s)\n", rec.Id, rec.Id, sd.PurgeAfter)
+	}
+	recordAudit(logger, events...)
+	return nil
 }
 
-func init() {
-	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "target remote DRS server (default: default_remote)")
-	Cmd.Flags().BoolVar(&confirmFlag, "confirm", false, "skip interactive confirmation prompt")
+// deleteWithData deletes each record, asking the server to remove its bucket
+// object too when the plan allows it. Records whose object is kept are still
+// deleted; the reason is reported so the bytes can be cleaned up by hand.
+func deleteWithData(ctx context.Context, drsClient *config.GitContext, remoteName, oid string, plans []drsdelete.DataPlan, logger *slog.Logger) error {
+	events := make([]audit.Event, 0, len(plans))
+	defer func() { recordAudit(logger, events...) }()
+	for _, plan := range plans {
+		if err := drsClient.Client.DRS().DeleteObject(ctx, plan.DID, plan.DeleteData); err != nil {
+			return fmt.Errorf("error deleting %s: %w", plan.DID, err)
+		}
+		detail := "deleted record and bucket object"
+		if !plan.DeleteData {
+			detail = "deleted record; kept bucket object: " + plan.Reason
+			fmt.Fprintf(os.Stderr, "Deleted %s; kept bucket object because %s\n", plan.DID, plan.Reason)
+		} else {
+			fmt.Fprintf(os.Stderr, "Deleted %s and its bucket object\n", plan.DID)
+		}
+		events = append(events, audit.Event{
+			Action:  audit.ActionDelete,
+			Remote:  remoteName,
+			Project: drsClient.ProjectId,
+			DRSID:   plan.DID,
+			OID:     oid,
+			Detail:  detail,
+		})
+	}
+	return nil
+}
+
+func countDataDeletes(plans []drsdelete.DataPlan) int {
+	n := 0
+	for _, plan := range plans {
+		if plan.DeleteData {
+			n++
+		}
+	}
+	return n
+}
+
+func runPurge(ctx context.Context, out io.Writer, drsClient *config.GitContext, remoteName string, logger *slog.Logger) error {
+	expired, err := drsdelete.ExpiredSoftDeletes(ctx, drsClient.Client.Index(), drsClient.Organization, drsClient.ProjectId, now())
+	if err != nil {
+		return fmt.Errorf("error listing soft-deleted records: %w", err)
+	}
+	if len(expired) == 0 {
+		fmt.Fprintln(out, "No soft-deleted records past their retention window")
+		return nil
+	}
+	records := make([]drsapi.DrsObject, 0, len(expired))
+	oids := make(map[string]string, len(expired))
+	for _, rec := range expired {
+		records = append(records, drsapi.DrsObject{Id: rec.Did, AccessMethods: rec.AccessMethods})
+		if rec.Hashes != nil {
+			oids[rec.Did] = (*rec.Hashes)["sha256"]
+		}
+	}
+	plans, err := drsdelete.PlanDataDeletion(ctx, drsClient.Client.Index(), records, drsClient.BucketName)
+	if err != nil {
+		return fmt.Errorf("error checking bucket objects of soft-deleted records: %w", err)
+	}
+	if !confirmFlag {
+		deleted := countDataDeletes(plans)
+		common.DisplayWarningHeader(os.Stderr, "PURGE soft-deleted DRS records")
+		common.DisplayField(os.Stderr, "Remote", remoteName)
+		common.DisplayField(os.Stderr, "Project", drsClient.ProjectId)
+		common.DisplayField(os.Stderr, "Records", fmt.Sprintf("%d", len(expired)))
+		common.DisplayField(os.Stderr, "Bucket Objects", fmt.Sprintf("%d deleted, %d kept", deleted, len(plans)-deleted))
+		common.DisplayField(os.Stderr, "Warning", "Records and unshared bucket objects are removed permanently")
+		common.DisplayFooter(os.Stderr)
+		if err := common.PromptForConfirmation(os.Stderr, "Type 'yes' to confirm purge", common.ConfirmationYes, false); err != nil {
+			return err
+		}
+	}
+
+	events := make([]audit.Event, 0, len(plans))
+	defer func() { recordAudit(logger, events...) }()
+	for _, plan := range plans {
+		if err := drsClient.Client.DRS().DeleteObject(ctx, plan.DID, plan.DeleteData); err != nil {
+			return fmt.Errorf("error purging %s: %w", plan.DID, err)
+		}
+		detail := "purged soft-deleted record and bucket object"
+		if !plan.DeleteData {
+			detail = "purged soft-deleted record; kept bucket object: " + plan.Reason
+			fmt.Fprintf(out, "Purged %s; kept bucket object because %s\n", plan.DID, plan.Reason)
+		} else {
+			fmt.Fprintf(out, "Purged %s\n", plan.DID)
+		}
+		events = append(events, audit.Event{
+			Action:  audit.ActionDelete,
+			Remote:  remoteName,
+			Project: drsClient.ProjectId,
+			DRSID:   plan.DID,
+			OID:     oids[plan.DID],
+			Detail:  detail,
+		})
+	}
+	return nil
 }
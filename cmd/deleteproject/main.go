@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/calypr/git-drs/internal/audit"
 	"github.com/calypr/git-drs/internal/common"
 	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/drslog"
@@ -30,7 +31,7 @@ var Cmd = &cobra.Command{
 
 		cfg, err := config.LoadConfig()
 		if err != nil {
-			return fmt.Errorf("error loading config: %v", err)
+			return fmt.Errorf("error loading config: %w", err)
 		}
 
 		remoteName, err := cfg.GetRemoteOrDefault(remote)
@@ -43,6 +44,9 @@ var Cmd = &cobra.Command{
 			logger.Error(fmt.Sprintf("error creating DRS client: %s", err))
 			return err
 		}
+		if err := drsClient.RequireWrite(); err != nil {
+			return err
+		}
 
 		remoteConfig := cfg.GetRemote(remoteName)
 		organization := ""
@@ -50,8 +54,12 @@ var Cmd = &cobra.Command{
 			organization = remoteConfig.GetOrganization()
 		}
 
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
 		// Get a sample record to show the user what will be deleted
-		listResp, err := drsClient.Client.Index().List(context.Background(), syservices.ListRecordsOptions{
+		listResp, err := drsClient.Client.Index().List(ctx, syservices.ListRecordsOptions{
 			Organization: organization,
 			ProjectID:    projectId,
 			Limit:        1,
@@ -94,13 +102,20 @@ var Cmd = &cobra.Command{
 
 		// Delete the matching records
 		logger.Debug(fmt.Sprintf("Deleting all records for project %s...", projectId))
-		if _, err := drsClient.Client.Index().DeleteByQuery(context.Background(), syservices.DeleteByQueryOptions{
+		if _, err := drsClient.Client.Index().DeleteByQuery(ctx, syservices.DeleteByQueryOptions{
 			Organization: organization,
 			ProjectID:    projectId,
 		}); err != nil {
 			return fmt.Errorf("error deleting project %s: %v", projectId, err)
 		}
 
+		audit.RecordOrWarn(logger, audit.Event{
+			Action:  audit.ActionDelete,
+			Remote:  string(remoteName),
+			Project: projectId,
+			Detail:  "deleted all records in project",
+		})
+
 		logger.Debug(fmt.Sprintf("Successfully deleted all records for project %s", projectId))
 		return nil
 	},
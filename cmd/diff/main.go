@@ -0,0 +1,207 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/progressui"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remote  string
+	offline bool
+)
+
+var (
+	loadConfig      = config.LoadConfig
+	newRemoteClient = func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+		return cfg.GetRemoteClient(remote, logger)
+	}
+	diffPointers  = lfs.DiffPointers
+	lookupObjects = drsremote.ObjectsByHashesForScope
+)
+
+// Change is one tracked file whose content differs between the two commits,
+// with the DRS records registered for its old and new oids. The IDs are
+// empty when an oid has no record in the remote's project or the lookup was
+// skipped.
+type Change struct {
+	lfs.PointerChange
+	SizeDelta int64  `json:"size_delta"`
+	OldDRSID  string `json:"old_drs_id,omitempty"`
+	NewDRSID  string `json:"new_drs_id,omitempty"`
+}
+
+// Report is the JSON form of a diff.
+type Report struct {
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Added     int      `json:"added"`
+	Removed   int      `json:"removed"`
+	Modified  int      `json:"modified"`
+	SizeDelta int64    `json:"size_delta"`
+	Changes   []Change `json:"changes"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "diff <rev1> <rev2> [pathspec...]",
+	Short: "List tracked data files added, removed, or changed between two commits",
+	Long: "Description:" +
+		"\n  Compare the LFS pointers in two commits and list the tracked files whose" +
+		"\n  content was added, removed, or changed, with size deltas. Unless" +
+		"\n  --offline is set, each old and new oid is resolved to its DRS record in" +
+		"\n  the remote's project. Renames show as a removal and an addition.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires at least 2 arguments (rev1 rev2), received %d\n\nUsage: %s\n\nSee 'git drs diff --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := drslog.GetLogger()
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		pointers, err := diffPointers(ctx, args[0], args[1], args[2:])
+		if err != nil {
+			return err
+		}
+
+		var lookup func([]string) (map[string][]drsapi.DrsObject, error)
+		if !offline && len(pointers) > 0 {
+			cfg, err := loadConfig()
+			if err != nil {
+				return fmt.Errorf("error loading config: %w", err)
+			}
+			name, err := cfg.GetRemoteOrDefault(remote)
+			if err != nil {
+				return err
+			}
+			gc, err := newRemoteClient(cfg, name, logger)
+			if err != nil {
+				return err
+			}
+			lookup = func(oids []string) (map[string][]drsapi.DrsObject, error) {
+				return lookupObjects(ctx, gc, oids)
+			}
+		}
+
+		report, err := buildReport(args[0], args[1], pointers, lookup)
+		if err != nil {
+			return err
+		}
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), report)
+		}
+		return writeReport(cmd.OutOrStdout(), report, lookup != nil)
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote to resolve records from (default: default remote)")
+	Cmd.Flags().BoolVar(&offline, "offline", false, "compare pointers only, without DRS lookups")
+}
+
+// buildReport attaches DRS records and size deltas to pointer changes.
+// lookup may be nil for offline runs.
+func buildReport(from, to string, pointers []lfs.PointerChange, lookup func([]string) (map[string][]drsapi.DrsObject, error)) (Report, error) {
+	report := Report{From: from, To: to, Changes: make([]Change, 0, len(pointers))}
+
+	var records map[string][]drsapi.DrsObject
+	if lookup != nil {
+		seen := make(map[string]bool)
+		var oids []string
+		for _, p := range pointers {
+			for _, oid := range []string{p.OldOID, p.NewOID} {
+				if oid != "" && !seen[oid] {
+					seen[oid] = true
+					oids = append(oids, oid)
+				}
+			}
+		}
+		var err error
+		if records, err = lookup(oids); err != nil {
+			return Report{}, fmt.Errorf("error querying remote: %w", err)
+		}
+	}
+	drsID := func(oid string) string {
+		if recs := records[oid]; len(recs) > 0 {
+			return recs[0].Id
+		}
+		return ""
+	}
+
+	for _, p := range pointers {
+		c := Change{PointerChange: p, SizeDelta: p.NewSize - p.OldSize, OldDRSID: drsID(p.OldOID), NewDRSID: drsID(p.NewOID)}
+		switch p.Status {
+		case lfs.PointerAdded:
+			report.Added++
+		case lfs.PointerRemoved:
+			report.Removed++
+		case lfs.PointerModified:
+			report.Modified++
+		}
+		report.SizeDelta += c.SizeDelta
+		report.Changes = append(report.Changes, c)
+	}
+	return report, nil
+}
+
+func writeReport(w io.Writer, r Report, resolved bool) error {
+	for _, c := range r.Changes {
+		var status, oids string
+		var ids []string
+		switch c.Status {
+		case lfs.PointerAdded:
+			status, oids, ids = "A", shortHash(c.NewOID), []string{c.NewDRSID}
+		case lfs.PointerRemoved:
+			status, oids, ids = "D", shortHash(c.OldOID), []string{c.OldDRSID}
+		default:
+			status, oids, ids = "M", shortHash(c.OldOID)+" -> "+shortHash(c.NewOID), []string{c.OldDRSID, c.NewDRSID}
+		}
+		line := fmt.Sprintf("%s\t%s\t%s\t%s", status, c.Path, signedBytes(c.SizeDelta), oids)
+		if resolved {
+			for i, id := range ids {
+				if id == "" {
+					ids[i] = "(not registered)"
+				}
+			}
+			line += "\t" + strings.Join(ids, " -> ")
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d added, %d removed, %d modified; net %s\n", r.Added, r.Removed, r.Modified, signedBytes(r.SizeDelta))
+	return err
+}
+
+func signedBytes(n int64) string {
+	switch {
+	case n > 0:
+		return "+" + progressui.FormatBinaryBytes(n)
+	case n < 0:
+		return "-" + progressui.FormatBinaryBytes(-n)
+	}
+	return "0 B"
+}
+
+func shortHash(s string) string {
+	if len(s) > 10 {
+		return s[:10]
+	}
+	return s
+}
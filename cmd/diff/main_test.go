@@ -0,0 +1,85 @@
+package diff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestBuildReportResolvesOldAndNewRecords(t *testing.T) {
+	oidA := strings.Repeat("a", 64)
+	oidB := strings.Repeat("b", 64)
+	oidC := strings.Repeat("c", 64)
+	pointers := []lfs.PointerChange{
+		{Path: "data/changed.bam", Status: lfs.PointerModified, OldOID: oidA, NewOID: oidC, OldSize: 1024, NewSize: 3072},
+		{Path: "data/dropped.bam", Status: lfs.PointerRemoved, OldOID: oidB, OldSize: 512},
+		{Path: "data/new.bam", Status: lfs.PointerAdded, NewOID: oidB, NewSize: 512},
+	}
+
+	var queried []string
+	lookup := func(oids []string) (map[string][]drsapi.DrsObject, error) {
+		queried = oids
+		return map[string][]drsapi.DrsObject{
+			oidA: {{Id: "drs-a"}},
+			oidB: {{Id: "drs-b"}},
+		}, nil
+	}
+
+	report, err := buildReport("v1", "v2", pointers, lookup)
+	if err != nil {
+		t.Fatalf("buildReport: %v", err)
+	}
+	if len(queried) != 3 {
+		t.Fatalf("expected three distinct oids queried, got %v", queried)
+	}
+	if report.Added != 1 || report.Removed != 1 || report.Modified != 1 || report.SizeDelta != 2048 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+	changed := report.Changes[0]
+	if changed.OldDRSID != "drs-a" || changed.NewDRSID != "" || changed.SizeDelta != 2048 {
+		t.Fatalf("unexpected modified entry: %+v", changed)
+	}
+
+	var out bytes.Buffer
+	if err := writeReport(&out, report, true); err != nil {
+		t.Fatalf("writeReport: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := []string{
+		"M\tdata/changed.bam\t+2.0 KiB\taaaaaaaaaa -> cccccccccc\tdrs-a -> (not registered)",
+		"D\tdata/dropped.bam\t-512 B\tbbbbbbbbbb\tdrs-b",
+		"A\tdata/new.bam\t+512 B\tbbbbbbbbbb\tdrs-b",
+		"1 added, 1 removed, 1 modified; net +2.0 KiB",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestBuildReportOffline(t *testing.T) {
+	pointers := []lfs.PointerChange{{Path: "a.bin", Status: lfs.PointerAdded, NewOID: strings.Repeat("a", 64), NewSize: 10}}
+	report, err := buildReport("v1", "v2", pointers, nil)
+	if err != nil {
+		t.Fatalf("buildReport: %v", err)
+	}
+	var out bytes.Buffer
+	if err := writeReport(&out, report, false); err != nil {
+		t.Fatalf("writeReport: %v", err)
+	}
+	if strings.Contains(out.String(), "registered") {
+		t.Fatalf("offline output should not mention registration:\n%s", out.String())
+	}
+
+	empty, err := buildReport("v1", "v1", nil, nil)
+	if err != nil || empty.Changes == nil {
+		t.Fatalf("empty report = %+v, %v; want an empty changes array", empty, err)
+	}
+}
@@ -0,0 +1,338 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/hashing"
+	"github.com/calypr/git-drs/internal/progressui"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	manifestPath string
+	outputDir    string
+	remote       string
+	jobs         int
+	rangeSpec    string
+	headSpec     string
+)
+
+var (
+	loadCfg         = config.LoadConfig
+	resolveRemote   = func(cfg *config.Config, name string) (config.Remote, error) { return cfg.GetRemoteOrDefault(name) }
+	newRemoteClient = func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+		return cfg.GetRemoteClient(remote, logger)
+	}
+	resolveObject            = drsremote.ResolveObject
+	downloadObject           = drsremote.DownloadObjectToPath
+	stdin          io.Reader = os.Stdin
+)
+
+// File statuses in a Result.
+const (
+	statusDownloaded = "downloaded"
+	statusPresent    = "present"
+	statusFailed     = "failed"
+)
+
+// File is the outcome for one manifest entry.
+type File struct {
+	ID     string `json:"id"`
+	DRSID  string `json:"drs_id,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Size   int64  `json:"size,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Result summarizes one download run.
+type Result struct {
+	Remote     string `json:"remote"`
+	Output     string `json:"output"`
+	Objects    int    `json:"objects"`
+	Downloaded int    `json:"downloaded"`
+	Present    int    `json:"present"`
+	Failed     int    `json:"failed"`
+	Bytes      int64  `json:"bytes"`
+	Files      []File `json:"files"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "download --manifest <file>",
+	Short: "Download the DRS objects listed in a manifest into a directory",
+	Long: "Description:" +
+		"\n  Read DRS IDs or sha256 oids from a manifest (one per line, a JSON array," +
+		"\n  or a CSV file with an id column) and download every object into the" +
+		"\n  output directory with a pool of workers, then print a summary. Files" +
+		"\n  that already match the record's sha256 are skipped, and failed" +
+		"\n  objects do not stop the others. --range and --head fetch only part of" +
+		"\n  each object, for example to inspect the header of a large BAM file.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: accepts no arguments, received %d\n\nUsage: %s\n\nSee 'git drs download --help' for more details", len(args), cmd.UseLine())
+		}
+		if manifestPath == "" {
+			return fmt.Errorf("--manifest is required")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		res, err := Run(ctx, remote, manifestPath, outputDir)
+		if res != nil {
+			if werr := writeResult(cmd.OutOrStdout(), res); werr != nil {
+				return werr
+			}
+		}
+		return err
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&manifestPath, "manifest", "m", "", "file listing DRS IDs or oids: one per line, a JSON array, or CSV with an id column (- for stdin)")
+	Cmd.Flags().StringVarP(&outputDir, "output", "o", ".", "directory to download into")
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote to download from (default: default remote)")
+	Cmd.Flags().IntVarP(&jobs, "jobs", "j", 0, "concurrent downloads (default: drs.transfer.download-workers)")
+	Cmd.Flags().StringVar(&rangeSpec, "range", "", "download only bytes start-end (inclusive) of each object; start- reads to the end")
+	Cmd.Flags().StringVar(&headSpec, "head", "", "download only the first N bytes of each object (k, m, g suffixes allowed)")
+}
+
+// Run downloads every object listed in the manifest at path into outDir.
+// Failed objects are reported in the result; the returned error then says
+// how many failed.
+func Run(ctx context.Context, remoteName, path, outDir string) (*Result, error) {
+	logg := drslog.GetLogger()
+
+	span, err := parseRange(rangeSpec, headSpec)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := readManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := loadCfg()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+	remote, err := resolveRemote(cfg, remoteName)
+	if err != nil {
+		return nil, err
+	}
+	drsCtx, err := newRemoteClient(cfg, remote, logg)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create output directory: %w", err)
+	}
+
+	workers := jobs
+	if workers <= 0 {
+		workers = drsCtx.DownloadConcurrency
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	res := &Result{Remote: string(remote), Output: outDir, Objects: len(entries), Files: make([]File, len(entries))}
+	var (
+		mu      sync.Mutex
+		claimed = make(map[string]string, len(entries))
+	)
+	// claim reserves an output path so two entries never write the same file.
+	claim := func(rel, id string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if other, ok := claimed[rel]; ok {
+			return fmt.Errorf("output path %s is already used by %s", rel, other)
+		}
+		claimed[rel] = id
+		return nil
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(workers)
+	for i, e := range entries {
+		if gctx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			f := fetchEntry(gctx, drsCtx, outDir, e, span, claim)
+			if f.Status == statusFailed {
+				logg.Warn(fmt.Sprintf("download %s failed: %s", e.ID, f.Error))
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			res.Files[i] = f
+			switch f.Status {
+			case statusDownloaded:
+				res.Downloaded++
+				res.Bytes += f.Size
+			case statusPresent:
+				res.Present++
+			default:
+				res.Failed++
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return res, err
+	}
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	if res.Failed > 0 {
+		return res, drserrors.Partial(res.Failed, res.Objects, fmt.Errorf("%d of %d objects failed to download", res.Failed, res.Objects))
+	}
+	return res, nil
+}
+
+func readManifest(path string) ([]entry, error) {
+	if path == "-" {
+		return parseManifest("stdin", stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open manifest: %w", err)
+	}
+	defer f.Close()
+	return parseManifest(path, f)
+}
+
+// fetchEntry resolves and downloads one entry, or only span of it when span
+// is set. The object is written to a .part file and renamed into place, so an
+// interrupted run never leaves a truncated file under the final name.
+func fetchEntry(ctx context.Context, drsCtx *config.GitContext, outDir string, e entry, span *byteRange, claim func(rel, id string) error) File {
+	f := File{ID: e.ID, Status: statusFailed}
+	obj, err := resolveObject(ctx, drsCtx, e.ID)
+	if err != nil {
+		f.Error = err.Error()
+		return f
+	}
+	f.DRSID, f.Size = obj.Id, obj.Size
+	var offset int64
+	rel, err := outputName(e, obj)
+	if err == nil && span != nil {
+		offset, f.Size, err = span.bounds(obj.Id, obj.Size)
+		rel += rangeSuffix(offset, f.Size)
+	}
+	if err == nil {
+		err = claim(rel, e.ID)
+	}
+	if err != nil {
+		f.Error = err.Error()
+		return f
+	}
+	f.Path = rel
+	dst := filepath.Join(outDir, filepath.FromSlash(rel))
+	if present(dst, *obj, f.Size, span != nil) {
+		f.Status = statusPresent
+		return f
+	}
+	if span != nil {
+		if err := fetchRange(ctx, drsCtx, *obj, offset, f.Size, dst); err != nil {
+			f.Error = err.Error()
+			return f
+		}
+		f.Status = statusDownloaded
+		return f
+	}
+	part := dst + ".part"
+	if err := downloadObject(ctx, drsCtx, *obj, part); err != nil {
+		_ = os.Remove(part)
+		f.Error = err.Error()
+		return f
+	}
+	if err := os.Rename(part, dst); err != nil {
+		_ = os.Remove(part)
+		f.Error = err.Error()
+		return f
+	}
+	f.Status = statusDownloaded
+	return f
+}
+
+// present reports whether dst already holds the content. A whole object must
+// hash to the record's sha256, so a record without one is fetched again; a
+// range cannot be hashed and is matched by size alone.
+func present(dst string, obj drsapi.DrsObject, size int64, ranged bool) bool {
+	info, err := os.Stat(dst)
+	if err != nil || !info.Mode().IsRegular() || info.Size() != size {
+		return false
+	}
+	if ranged {
+		return true
+	}
+	want := strings.ToLower(drsobject.SHA256(obj))
+	if want == "" {
+		return false
+	}
+	got, _, err := hashing.HashFile(dst, true)
+	return err == nil && got == want
+}
+
+// outputName is the manifest's name for the entry, else the record's name,
+// else its DRS ID. Manifest names may contain directories but must stay
+// inside the output directory; record names are reduced to their base name.
+func outputName(e entry, obj *drsapi.DrsObject) (string, error) {
+	if e.Name != "" {
+		rel := filepath.ToSlash(filepath.Clean(filepath.FromSlash(e.Name)))
+		if !filepath.IsLocal(rel) {
+			return "", fmt.Errorf("name %q is outside the output directory", e.Name)
+		}
+		return rel, nil
+	}
+	if obj.Name != nil {
+		if name := filepath.Base(strings.ReplaceAll(*obj.Name, "\\", "/")); filepath.IsLocal(name) {
+			return name, nil
+		}
+	}
+	if filepath.IsLocal(obj.Id) && !strings.ContainsAny(obj.Id, `/\`) {
+		return obj.Id, nil
+	}
+	return "", fmt.Errorf("record %s has no usable file name; give one in the manifest", obj.Id)
+}
+
+func writeResult(w io.Writer, res *Result) error {
+	if common.JSONOutput() {
+		return common.WriteJSON(w, res)
+	}
+	failed := make([]File, 0, res.Failed)
+	for _, f := range res.Files {
+		if f.Status == statusFailed {
+			failed = append(failed, f)
+		}
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i].ID < failed[j].ID })
+	for _, f := range failed {
+		if _, err := fmt.Fprintf(w, "failed\t%s\t%s\n", f.ID, f.Error); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "Downloaded %d of %d objects to %s (%s, %d already present, %d failed)\n",
+		res.Downloaded, res.Objects, res.Output, progressui.FormatBinaryBytes(res.Bytes), res.Present, res.Failed)
+	return err
+}
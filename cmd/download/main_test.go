@@ -0,0 +1,220 @@
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestParseManifestFormats(t *testing.T) {
+	want := []entry{{ID: "dg.1/a"}, {ID: "dg.1/b", Name: "reads/b.bam"}}
+	cases := map[string]string{
+		"ids.json": `["dg.1/a", {"drs_id": "dg.1/b", "path": "reads/b.bam"}, "dg.1/a"]`,
+		"ids.csv":  "Name,ID\n,dg.1/a\nreads/b.bam,dg.1/b\n",
+		"ids.tsv":  "id\tname\ndg.1/a\t\ndg.1/b\treads/b.bam\n",
+	}
+	for name, body := range cases {
+		got, err := parseManifest(name, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v, want %+v", name, got, want)
+		}
+	}
+
+	got, err := parseManifest("ids.txt", strings.NewReader("# cohort A\ndg.1/a\n\n  dg.1/b  \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []entry{{ID: "dg.1/a"}, {ID: "dg.1/b"}}) {
+		t.Fatalf("list manifest = %+v", got)
+	}
+
+	if _, err := parseManifest("ids.csv", strings.NewReader("name,size\na.bam,1\n")); err == nil {
+		t.Fatal("expected error for CSV without an id column")
+	}
+	if _, err := parseManifest("ids.txt", strings.NewReader("# nothing\n")); err == nil {
+		t.Fatal("expected error for empty manifest")
+	}
+}
+
+func TestRunDownloadsManifestAndReportsFailures(t *testing.T) {
+	out := t.TempDir()
+	manifest := filepath.Join(t.TempDir(), "ids.csv")
+	body := "id,name\n" +
+		"dg.1/a,\n" +
+		"dg.1/b,sub/b.txt\n" +
+		"dg.1/missing,\n" +
+		"dg.1/present,\n" +
+		"dg.1/stale,\n" +
+		"dg.1/escape,../x\n"
+	if err := os.WriteFile(manifest, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(out, "present.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Same size as the record but different content, so it is fetched again.
+	if err := os.WriteFile(filepath.Join(out, "stale.txt"), []byte("12345"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("hello"))
+	checksums := []drsapi.Checksum{{Type: "sha256", Checksum: hex.EncodeToString(sum[:])}}
+
+	origLoad, origResolve, origClient, origObj, origDL := loadCfg, resolveRemote, newRemoteClient, resolveObject, downloadObject
+	t.Cleanup(func() {
+		loadCfg, resolveRemote, newRemoteClient, resolveObject, downloadObject = origLoad, origResolve, origClient, origObj, origDL
+	})
+	loadCfg = func() (*config.Config, error) { return &config.Config{}, nil }
+	resolveRemote = func(*config.Config, string) (config.Remote, error) { return "origin", nil }
+	newRemoteClient = func(*config.Config, config.Remote, *slog.Logger) (*config.GitContext, error) {
+		return &config.GitContext{UploadConcurrency: 3}, nil
+	}
+	resolveObject = func(_ context.Context, _ *config.GitContext, id string) (*drsapi.DrsObject, error) {
+		if id == "dg.1/missing" {
+			return nil, errors.New("object not found")
+		}
+		name := strings.TrimPrefix(id, "dg.1/") + ".txt"
+		return &drsapi.DrsObject{Id: id, Name: &name, Size: 5, Checksums: checksums}, nil
+	}
+	var (
+		mu         sync.Mutex
+		downloaded []string
+	)
+	downloadObject = func(_ context.Context, _ *config.GitContext, obj drsapi.DrsObject, dst string) error {
+		if !strings.HasSuffix(dst, ".part") {
+			t.Errorf("download should write a .part file, got %s", dst)
+		}
+		mu.Lock()
+		downloaded = append(downloaded, obj.Id)
+		mu.Unlock()
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(dst, []byte("hello"), 0o644)
+	}
+
+	res, err := Run(context.Background(), "", manifest, out)
+	if err == nil || !strings.Contains(err.Error(), "2 of 6 objects failed") {
+		t.Fatalf("expected 2 failures, got %v", err)
+	}
+	if !errors.Is(err, drserrors.ErrPartial) {
+		t.Fatalf("a run with some downloads should be a partial failure, got %v", err)
+	}
+	if res.Downloaded != 3 || res.Present != 1 || res.Failed != 2 || res.Bytes != 15 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	for _, rel := range []string{"a.txt", "sub/b.txt", "stale.txt"} {
+		if data, err := os.ReadFile(filepath.Join(out, rel)); err != nil || string(data) != "hello" {
+			t.Fatalf("%s: %q, %v", rel, data, err)
+		}
+	}
+	if res.Files[5].Status != statusFailed || !strings.Contains(res.Files[5].Error, "outside the output directory") {
+		t.Fatalf("escaping name should fail, got %+v", res.Files[5])
+	}
+	if len(downloaded) != 3 {
+		t.Fatalf("expected 3 downloads, got %v", downloaded)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	cases := []struct {
+		rangeFlag, headFlag string
+		want                *byteRange
+	}{
+		{"", "", nil},
+		{"0-1023", "", &byteRange{Start: 0, End: 1023}},
+		{"100-", "", &byteRange{Start: 100, End: -1}},
+		{"", "64k", &byteRange{Start: 0, End: 65535}},
+	}
+	for _, tc := range cases {
+		got, err := parseRange(tc.rangeFlag, tc.headFlag)
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseRange(%q, %q) = %+v, %v; want %+v", tc.rangeFlag, tc.headFlag, got, err, tc.want)
+		}
+	}
+	for _, tc := range [][2]string{{"10-5", ""}, {"x-1", ""}, {"-100", ""}, {"", "0"}, {"", "lots"}, {"0-1", "10"}} {
+		if _, err := parseRange(tc[0], tc[1]); err == nil {
+			t.Errorf("parseRange(%q, %q) succeeded, want error", tc[0], tc[1])
+		}
+	}
+}
+
+type fakeRange struct{ content string }
+
+func (f fakeRange) ReadRange(_ context.Context, offset, length int64) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(f.content[offset : offset+length])), nil
+}
+
+func TestRunDownloadsRanges(t *testing.T) {
+	out := t.TempDir()
+	manifest := filepath.Join(t.TempDir(), "ids.txt")
+	if err := os.WriteFile(manifest, []byte("dg.1/reads\ndg.1/tiny\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	const content = "BAM\x01header-and-reads"
+
+	origLoad, origResolve, origClient, origObj, origDL, origRange := loadCfg, resolveRemote, newRemoteClient, resolveObject, downloadObject, openRange
+	origSpec := headSpec
+	t.Cleanup(func() {
+		loadCfg, resolveRemote, newRemoteClient, resolveObject, downloadObject, openRange = origLoad, origResolve, origClient, origObj, origDL, origRange
+		headSpec = origSpec
+	})
+	loadCfg = func() (*config.Config, error) { return &config.Config{}, nil }
+	resolveRemote = func(*config.Config, string) (config.Remote, error) { return "origin", nil }
+	newRemoteClient = func(*config.Config, config.Remote, *slog.Logger) (*config.GitContext, error) {
+		return &config.GitContext{UploadConcurrency: 1}, nil
+	}
+	resolveObject = func(_ context.Context, _ *config.GitContext, id string) (*drsapi.DrsObject, error) {
+		name := strings.TrimPrefix(id, "dg.1/") + ".bam"
+		size := int64(len(content))
+		if id == "dg.1/tiny" {
+			size = 4
+		}
+		return &drsapi.DrsObject{Id: id, Name: &name, Size: size}, nil
+	}
+	downloadObject = func(context.Context, *config.GitContext, drsapi.DrsObject, string) error {
+		t.Fatal("a ranged run should not download whole objects")
+		return nil
+	}
+	openRange = func(_ *config.GitContext, obj drsapi.DrsObject) (rangeReader, error) {
+		return fakeRange{content: content[:obj.Size]}, nil
+	}
+
+	headSpec = "8"
+	res, err := Run(context.Background(), "", manifest, out)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Downloaded != 2 || res.Bytes != 12 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	for rel, want := range map[string]string{"reads.bam.bytes-0-7": "BAM\x01head", "tiny.bam.bytes-0-3": "BAM\x01"} {
+		if data, err := os.ReadFile(filepath.Join(out, rel)); err != nil || string(data) != want {
+			t.Fatalf("%s: %q, %v; want %q", rel, data, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "reads.bam")); !os.IsNotExist(err) {
+		t.Fatalf("a range must not be written under the object's own name: %v", err)
+	}
+
+	// A second run finds the ranges already present.
+	res, err = Run(context.Background(), "", manifest, out)
+	if err != nil || res.Present != 2 {
+		t.Fatalf("rerun = %+v, %v; want both present", res, err)
+	}
+}
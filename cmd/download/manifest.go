@@ -0,0 +1,164 @@
+package download
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// entry is one object listed in a manifest. Name, when set, is the path the
+// object is written to below the output directory.
+type entry struct {
+	ID   string
+	Name string
+}
+
+// idColumns and nameColumns are the CSV headers and JSON fields accepted for
+// an entry's ID and output name, in order of preference. They cover the
+// output of git drs share --manifest --json.
+var (
+	idColumns   = []string{"id", "drs_id", "object_id", "guid", "did", "oid"}
+	nameColumns = []string{"name", "file_name", "path"}
+)
+
+// parseManifest reads a JSON array, a CSV file with a header row, or a plain
+// list of one ID or oid per line. The format follows the file extension, and
+// content starting with [ is JSON whatever the name. Repeated IDs are
+// dropped.
+func parseManifest(name string, r io.Reader) ([]entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var entries []entry
+	ext := strings.ToLower(filepath.Ext(name))
+	switch {
+	case ext == ".json" || bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")):
+		entries, err = parseJSONManifest(data)
+	case ext == ".csv" || ext == ".tsv":
+		entries, err = parseCSVManifest(data, ext == ".tsv")
+	default:
+		entries, err = parseListManifest(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse manifest %s: %w", name, err)
+	}
+
+	seen := make(map[string]bool, len(entries))
+	out := entries[:0]
+	for _, e := range entries {
+		if seen[e.ID] {
+			continue
+		}
+		seen[e.ID] = true
+		out = append(out, e)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("manifest %s lists no objects", name)
+	}
+	return out, nil
+}
+
+func parseJSONManifest(data []byte) ([]entry, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	entries := make([]entry, 0, len(raw))
+	for i, item := range raw {
+		var id string
+		if err := json.Unmarshal(item, &id); err == nil {
+			if id = strings.TrimSpace(id); id == "" {
+				return nil, fmt.Errorf("item %d: empty ID", i+1)
+			}
+			entries = append(entries, entry{ID: id})
+			continue
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(item, &fields); err != nil {
+			return nil, fmt.Errorf("item %d: expected a string or an object", i+1)
+		}
+		lookup := func(key string) string {
+			s, _ := fields[key].(string)
+			return s
+		}
+		e := entry{ID: firstField(lookup, idColumns), Name: firstField(lookup, nameColumns)}
+		if e.ID == "" {
+			return nil, fmt.Errorf("item %d: no %s field", i+1, strings.Join(idColumns, ", "))
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func parseCSVManifest(data []byte, tabs bool) ([]entry, error) {
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+	if tabs {
+		cr.Comma = '\t'
+	}
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	columns := make(map[string]int, len(rows[0]))
+	for i, h := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	hasID := false
+	for _, key := range idColumns {
+		_, ok := columns[key]
+		hasID = hasID || ok
+	}
+	if !hasID {
+		return nil, fmt.Errorf("header has no %s column", strings.Join(idColumns, ", "))
+	}
+	entries := make([]entry, 0, len(rows)-1)
+	for n, row := range rows[1:] {
+		lookup := func(key string) string {
+			if i, ok := columns[key]; ok && i < len(row) {
+				return row[i]
+			}
+			return ""
+		}
+		e := entry{ID: firstField(lookup, idColumns), Name: firstField(lookup, nameColumns)}
+		if e.ID == "" {
+			return nil, fmt.Errorf("row %d: empty ID", n+2)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// parseListManifest reads one ID or oid per line; blank lines and lines
+// starting with # are skipped.
+func parseListManifest(data []byte) ([]entry, error) {
+	var entries []entry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, entry{ID: line})
+	}
+	return entries, sc.Err()
+}
+
+func firstField(lookup func(string) string, keys []string) string {
+	for _, key := range keys {
+		if v := strings.TrimSpace(lookup(key)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
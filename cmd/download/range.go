@@ -0,0 +1,136 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/guardrail"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// rangeReader reads byte ranges of one object.
+type rangeReader interface {
+	ReadRange(ctx context.Context, offset, length int64) (io.ReadCloser, error)
+}
+
+// openRange is swapped in tests.
+var openRange = func(drsCtx *config.GitContext, obj drsapi.DrsObject) (rangeReader, error) {
+	return drsremote.RangeForObject(drsCtx, obj)
+}
+
+// byteRange is the part of each object selected by --range or --head. End is
+// inclusive, as in an HTTP Range header; -1 means the end of the object.
+type byteRange struct {
+	Start, End int64
+}
+
+// parseRange reads --range start-end (or start-) and --head N, which take
+// the first N bytes and accept k, m, and g suffixes. It returns nil when
+// neither is set.
+func parseRange(rangeFlag, headFlag string) (*byteRange, error) {
+	rangeFlag, headFlag = strings.TrimSpace(rangeFlag), strings.TrimSpace(headFlag)
+	switch {
+	case rangeFlag != "" && headFlag != "":
+		return nil, fmt.Errorf("--range and --head cannot be used together")
+	case headFlag != "":
+		n, err := guardrail.ParseSize(headFlag)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid --head %q: expected a positive byte count such as 65536 or 64k", headFlag)
+		}
+		return &byteRange{Start: 0, End: n - 1}, nil
+	case rangeFlag != "":
+		start, end, ok := strings.Cut(rangeFlag, "-")
+		r := byteRange{End: -1}
+		var err error
+		if r.Start, err = strconv.ParseInt(strings.TrimSpace(start), 10, 64); err != nil || r.Start < 0 {
+			ok = false
+		}
+		if end = strings.TrimSpace(end); ok && end != "" {
+			if r.End, err = strconv.ParseInt(end, 10, 64); err != nil || r.End < r.Start {
+				ok = false
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid --range %q: expected start-end or start-, with inclusive byte offsets such as 0-1023", rangeFlag)
+		}
+		return &r, nil
+	}
+	return nil, nil
+}
+
+// bounds clips r to an object of size bytes and returns the offset and
+// length to read.
+func (r byteRange) bounds(id string, size int64) (int64, int64, error) {
+	if size == 0 {
+		return 0, 0, nil
+	}
+	if r.Start >= size {
+		return 0, 0, fmt.Errorf("range starts at byte %d, past the end of %s (%d bytes)", r.Start, id, size)
+	}
+	end := r.End
+	if end < 0 || end >= size {
+		end = size - 1
+	}
+	return r.Start, end - r.Start + 1, nil
+}
+
+// rangeSuffix names the file holding a range, so it is never mistaken for
+// the whole object. An empty object's range is the whole object.
+func rangeSuffix(offset, length int64) string {
+	if length == 0 {
+		return ""
+	}
+	return fmt.Sprintf(".bytes-%d-%d", offset, offset+length-1)
+}
+
+// fetchRange writes the selected range of obj to dst through a .part file.
+// Ranged content cannot be checked against the record's sha256.
+func fetchRange(ctx context.Context, drsCtx *config.GitContext, obj drsapi.DrsObject, offset, length int64, dst string) error {
+	rr, err := openRange(drsCtx, obj)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	part := dst + ".part"
+	if err := writeRange(ctx, rr, obj.Id, offset, length, part); err != nil {
+		_ = os.Remove(part)
+		return err
+	}
+	if err := os.Rename(part, dst); err != nil {
+		_ = os.Remove(part)
+		return err
+	}
+	return nil
+}
+
+func writeRange(ctx context.Context, rr rangeReader, id string, offset, length int64, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if length > 0 {
+		body, err := rr.ReadRange(ctx, offset, length)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		n, err := io.Copy(f, io.LimitReader(body, length))
+		if err != nil {
+			return err
+		}
+		if n != length {
+			return fmt.Errorf("ranged read of %s returned %d of %d bytes", id, n, length)
+		}
+	}
+	return f.Close()
+}
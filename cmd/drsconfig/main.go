@@ -0,0 +1,170 @@
+package drsconfig
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var listKeys bool
+
+// Setting is the JSON form of one key and its values.
+type Setting struct {
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+	Backup string   `json:"backup,omitempty"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "config",
+	Short: "Get and set git-drs settings in the repository's git config",
+	Long: "Description:" +
+		"\n  Read and change the drs.* settings git-drs keeps in the repository's git" +
+		"\n  config, checking each value against the setting's type before writing it." +
+		"\n  Keys may leave off the drs. prefix, and remotes.<name>.<field> names" +
+		"\n  drs.remote.<name>.<field>. Every change first copies the config file to" +
+		"\n  .git/drs/config-backups, keeping the latest 10 copies.",
+}
+
+// GetCmd prints the values of a key.
+var GetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the value of a setting, one line per value",
+	Args:  exactArgs(1, "key"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := config.CanonicalKey(args[0])
+		values, err := config.GetKey(key)
+		if err != nil {
+			return err
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("%s is not set", key)
+		}
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), Setting{Key: key, Values: values})
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), strings.Join(values, "\n"))
+		return nil
+	},
+}
+
+// SetCmd validates and stores the values of a key.
+var SetCmd = &cobra.Command{
+	Use:   "set <key> <value>...",
+	Short: "Validate and store a setting, replacing its previous value",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires a key and at least one value, received %d arguments\n\nUsage: %s\n\nSee 'git drs config set --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := config.CanonicalKey(args[0])
+		backup, err := config.SetKey(key, args[1:])
+		if err != nil {
+			return err
+		}
+		return write(cmd.OutOrStdout(), Setting{Key: key, Values: args[1:], Backup: backup})
+	},
+}
+
+// UnsetCmd removes a key.
+var UnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a setting from the repository's git config",
+	Args:  exactArgs(1, "key"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := config.CanonicalKey(args[0])
+		backup, err := config.UnsetKey(key)
+		if err != nil {
+			return err
+		}
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), Setting{Key: key, Values: []string{}, Backup: backup})
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "unset %s (previous config saved to %s)\n", key, backup)
+		return nil
+	},
+}
+
+// ListCmd lists the settings in effect, or the supported keys.
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the drs.* settings in effect, or with --keys the supported keys",
+	Args:  exactArgs(0, ""),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if listKeys {
+			names := make([]string, 0, len(config.Keys))
+			for _, k := range config.Keys {
+				names = append(names, k.Name)
+			}
+			if common.JSONOutput() {
+				return common.WriteJSON(cmd.OutOrStdout(), names)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), strings.Join(names, "\n"))
+			return nil
+		}
+		values, err := config.ListKeys()
+		if err != nil {
+			return err
+		}
+		settings := make([]Setting, 0, len(values))
+		for key, v := range values {
+			settings = append(settings, Setting{Key: key, Values: v})
+		}
+		sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), settings)
+		}
+		for _, s := range settings {
+			writeText(cmd.OutOrStdout(), s)
+		}
+		return nil
+	},
+}
+
+func init() {
+	ListCmd.Flags().BoolVar(&listKeys, "keys", false, "list the keys git drs config set accepts")
+	Cmd.AddCommand(GetCmd)
+	Cmd.AddCommand(SetCmd)
+	Cmd.AddCommand(UnsetCmd)
+	Cmd.AddCommand(ListCmd)
+}
+
+func exactArgs(n int, name string) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) != n {
+			cmd.SilenceUsage = false
+			if n == 0 {
+				return fmt.Errorf("error: accepts no arguments, received %d\n\nUsage: %s", len(args), cmd.UseLine())
+			}
+			return fmt.Errorf("error: requires exactly %d argument (%s), received %d\n\nUsage: %s", n, name, len(args), cmd.UseLine())
+		}
+		return nil
+	}
+}
+
+func write(w io.Writer, s Setting) error {
+	if common.JSONOutput() {
+		return common.WriteJSON(w, s)
+	}
+	writeText(w, s)
+	if s.Backup != "" {
+		fmt.Fprintf(w, "previous config saved to %s\n", s.Backup)
+	}
+	return nil
+}
+
+// writeText prints key=value lines, one per value, like git config --list.
+func writeText(w io.Writer, s Setting) {
+	for _, v := range s.Values {
+		fmt.Fprintf(w, "%s=%s\n", s.Key, v)
+	}
+}
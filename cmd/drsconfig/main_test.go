@@ -0,0 +1,37 @@
+package drsconfig
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestSetThenGet(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := exec.Command("git", "init", "-q").Run(); err != nil {
+		t.Fatalf("git init: %v", err)
+	}
+
+	var out bytes.Buffer
+	Cmd.SetOut(&out)
+	Cmd.SetErr(&out)
+	Cmd.SetArgs([]string{"set", "remotes.origin.bucket", "my-bucket"})
+	if err := Cmd.Execute(); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "drs.remote.origin.bucket=my-bucket\nprevious config saved to ") {
+		t.Fatalf("set output = %q", out.String())
+	}
+
+	out.Reset()
+	Cmd.SetArgs([]string{"get", "drs.remote.origin.bucket"})
+	if err := Cmd.Execute(); err != nil || out.String() != "my-bucket\n" {
+		t.Fatalf("get = %q, %v; want my-bucket", out.String(), err)
+	}
+
+	Cmd.SetArgs([]string{"set", "remotes.origin.read-only", "perhaps"})
+	if err := Cmd.Execute(); err == nil || !strings.Contains(err.Error(), "expected true or false") {
+		t.Fatalf("set of an invalid value = %v; want a validation error", err)
+	}
+}
@@ -0,0 +1,396 @@
+package exportdata
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/progressui"
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+// ManifestName is the manifest written at the top of the destination unless
+// --manifest names another file.
+const ManifestName = "drs-manifest.json"
+
+var (
+	remote       string
+	projectID    string
+	dest         string
+	manifestPath string
+	jobs         int
+)
+
+var (
+	loadCfg         = config.LoadStandaloneConfig
+	resolveRemote   = func(cfg *config.Config, name string) (config.Remote, error) { return cfg.GetRemoteOrDefault(name) }
+	newRemoteClient = func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+		return cfg.GetRemoteClient(remote, logger)
+	}
+	recordLister   = func(drsCtx *config.GitContext) drsremote.RecordLister { return drsCtx.Client.Index() }
+	resolveObject  = drsremote.ResolveObject
+	downloadObject = drsremote.DownloadObjectToPath
+)
+
+// File statuses in a Result. A planned file is pending until it is exported.
+const (
+	statusPending    = ""
+	statusDownloaded = "downloaded"
+	statusPresent    = "present"
+	statusFailed     = "failed"
+	statusSkipped    = "skipped"
+)
+
+// File is one record of the project and what happened to it. The manifest
+// lists these, and its drs_id and path fields can be fed back to
+// git drs download --manifest.
+type File struct {
+	DRSID  string `json:"drs_id"`
+	Path   string `json:"path,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	Size   int64  `json:"size"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Result summarizes one export run.
+type Result struct {
+	Remote       string `json:"remote"`
+	Organization string `json:"organization,omitempty"`
+	Project      string `json:"project"`
+	Destination  string `json:"destination"`
+	Manifest     string `json:"manifest"`
+	Records      int    `json:"records"`
+	Downloaded   int    `json:"downloaded"`
+	Present      int    `json:"present"`
+	Skipped      int    `json:"skipped"`
+	Failed       int    `json:"failed"`
+	Bytes        int64  `json:"bytes"`
+	Files        []File `json:"files"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "export-data --project <project> --dest <dir>",
+	Short: "Download every object of a project into a directory, without a git repository",
+	Long: "Description:" +
+		"\n  List the records registered in a project and download each object into" +
+		"\n  the destination directory at its file_name, then write a JSON manifest" +
+		"\n  of what was exported. It runs inside or outside a git repository; outside" +
+		"\n  one, the remote comes from the user-level config, GIT_DRS_* variables," +
+		"\n  and --config flags. Files already present with the record's size are" +
+		"\n  skipped, so an interrupted export can be rerun.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: accepts no arguments, received %d\n\nUsage: %s\n\nSee 'git drs export-data --help' for more details", len(args), cmd.UseLine())
+		}
+		if strings.TrimSpace(dest) == "" {
+			return fmt.Errorf("--dest is required")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		res, err := Run(ctx, remote, projectID, dest, manifestPath)
+		if res != nil {
+			if werr := writeResult(cmd.OutOrStdout(), res); werr != nil {
+				return werr
+			}
+		}
+		return err
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVar(&projectID, "project", "", "project to export, as <project> or <organization>/<project> (default: the remote's project)")
+	Cmd.Flags().StringVarP(&dest, "dest", "d", "", "directory to export into; created if missing")
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote to export from (default: default remote)")
+	Cmd.Flags().StringVar(&manifestPath, "manifest", "", "where to write the JSON manifest (default: <dest>/"+ManifestName+")")
+	Cmd.Flags().IntVarP(&jobs, "jobs", "j", 0, "concurrent downloads (default: drs.transfer.download-workers)")
+}
+
+// Run exports every record of the project into destDir and writes the
+// manifest. Failed objects are reported in the result; the returned error
+// then says how many failed.
+func Run(ctx context.Context, remoteName, project, destDir, manifest string) (*Result, error) {
+	logg := drslog.GetLogger()
+
+	cfg, err := loadCfg()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+	remote, err := resolveRemote(cfg, remoteName)
+	if err != nil {
+		return nil, err
+	}
+	drsCtx, err := newRemoteClient(cfg, remote, logg)
+	if err != nil {
+		return nil, err
+	}
+	org, project, err := parseProject(project, drsCtx.Organization, drsCtx.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	if manifest == "" {
+		manifest = filepath.Join(destDir, ManifestName)
+	}
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create destination directory: %w", err)
+	}
+
+	res := &Result{
+		Remote:       string(remote),
+		Organization: org,
+		Project:      project,
+		Destination:  destDir,
+		Manifest:     manifest,
+		Files:        []File{},
+	}
+	// The manifest's own name is reserved so no record overwrites it.
+	taken := map[string]bool{}
+	if rel, err := filepath.Rel(destDir, manifest); err == nil && filepath.IsLocal(rel) {
+		taken[filepath.ToSlash(rel)] = true
+	}
+	err = drsremote.ListRecordsParallel(ctx, recordLister(drsCtx), drsremote.ListOptions{
+		Organization: org,
+		ProjectID:    project,
+	}, func(_ int, records []internalapi.InternalRecord) error {
+		for _, rec := range records {
+			if drsobject.IsSoftDeleted(rec.Description) {
+				continue
+			}
+			res.Files = append(res.Files, planFile(rec, taken))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing records for project %s: %w", project, err)
+	}
+	res.Records = len(res.Files)
+
+	workers := jobs
+	if workers <= 0 {
+		workers = drsCtx.DownloadConcurrency
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(workers)
+	for i := range res.Files {
+		if gctx.Err() != nil {
+			break
+		}
+		if res.Files[i].Status == statusSkipped {
+			continue
+		}
+		planned := res.Files[i]
+		// Each worker writes only its own slot.
+		g.Go(func() error {
+			f := exportFile(gctx, drsCtx, destDir, planned)
+			if f.Status == statusFailed {
+				logg.Warn(fmt.Sprintf("export %s failed: %s", f.DRSID, f.Error))
+			}
+			res.Files[i] = f
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return res, err
+	}
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	for _, f := range res.Files {
+		switch f.Status {
+		case statusDownloaded:
+			res.Downloaded++
+			res.Bytes += f.Size
+		case statusPresent:
+			res.Present++
+		case statusSkipped:
+			res.Skipped++
+		default:
+			res.Failed++
+		}
+	}
+	if err := writeManifest(manifest, res.Files); err != nil {
+		return res, err
+	}
+	if res.Failed > 0 {
+		return res, drserrors.Partial(res.Failed, res.Records, fmt.Errorf("%d of %d objects failed to export", res.Failed, res.Records))
+	}
+	return res, nil
+}
+
+// planFile places a record at its file_name. Records without a usable name,
+// or whose name an earlier record already took, are skipped.
+func planFile(rec internalapi.InternalRecord, taken map[string]bool) File {
+	f := File{DRSID: rec.Did, Status: statusPending}
+	if rec.Size != nil {
+		f.Size = *rec.Size
+	}
+	if rec.Hashes != nil {
+		f.SHA256 = strings.ToLower(drsobject.NormalizeOid((*rec.Hashes)["sha256"]))
+	}
+	rel, ok := recordPath(rec)
+	switch {
+	case !ok:
+		f.Status, f.Error = statusSkipped, "missing or invalid file_name"
+	case taken[rel]:
+		f.Status, f.Error = statusSkipped, fmt.Sprintf("path %s is already used by another record", rel)
+	default:
+		taken[rel] = true
+	}
+	f.Path = rel
+	return f
+}
+
+// recordPath is the record's file_name as a path below the destination.
+// Names that are empty or climb out of it are rejected.
+func recordPath(rec internalapi.InternalRecord) (string, bool) {
+	if rec.FileName == nil {
+		return "", false
+	}
+	name := strings.TrimSpace(strings.ReplaceAll(*rec.FileName, "\\", "/"))
+	if name == "" {
+		return "", false
+	}
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+	if name == "." || !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", false
+	}
+	return name, true
+}
+
+// exportFile downloads one planned file. The object is written to a .part
+// file and renamed into place, so an interrupted export never leaves a
+// truncated file under the final name.
+func exportFile(ctx context.Context, drsCtx *config.GitContext, destDir string, f File) File {
+	dst := filepath.Join(destDir, filepath.FromSlash(f.Path))
+	if info, err := os.Stat(dst); err == nil && info.Mode().IsRegular() && info.Size() == f.Size {
+		f.Status = statusPresent
+		return f
+	}
+	f.Status = statusFailed
+	obj, err := resolveObject(ctx, drsCtx, f.DRSID)
+	if err != nil {
+		f.Error = err.Error()
+		return f
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		f.Error = err.Error()
+		return f
+	}
+	part := dst + ".part"
+	if err := downloadObject(ctx, drsCtx, *obj, part); err != nil {
+		_ = os.Remove(part)
+		f.Error = err.Error()
+		return f
+	}
+	if err := os.Rename(part, dst); err != nil {
+		_ = os.Remove(part)
+		f.Error = err.Error()
+		return f
+	}
+	f.Status = statusDownloaded
+	return f
+}
+
+// parseProject resolves --project against the remote's scope.
+func parseProject(raw, remoteOrg, remoteProject string) (string, string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		if strings.TrimSpace(remoteProject) == "" {
+			return "", "", fmt.Errorf("--project is required: the remote has no project configured")
+		}
+		return remoteOrg, remoteProject, nil
+	}
+	org, project, found := strings.Cut(raw, "/")
+	if !found {
+		return remoteOrg, raw, nil
+	}
+	org, project = strings.TrimSpace(org), strings.TrimSpace(project)
+	if org == "" || project == "" || strings.Contains(project, "/") {
+		return "", "", fmt.Errorf("invalid --project %q: expected <project> or <organization>/<project>", raw)
+	}
+	return org, project, nil
+}
+
+// writeManifest writes the exported files sorted by path, through a
+// temporary file so a reader never sees a partial manifest.
+func writeManifest(path string, files []File) error {
+	sorted := make([]File, len(files))
+	copy(sorted, files)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].DRSID < sorted[j].DRSID
+	})
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create manifest directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := common.WriteJSON(out, sorted); err != nil {
+		out.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
+
+func writeResult(w io.Writer, res *Result) error {
+	if common.JSONOutput() {
+		return common.WriteJSON(w, res)
+	}
+	var failed []File
+	for _, f := range res.Files {
+		if f.Status == statusFailed || f.Status == statusSkipped {
+			failed = append(failed, f)
+		}
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i].DRSID < failed[j].DRSID })
+	for _, f := range failed {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", f.Status, f.DRSID, f.Error); err != nil {
+			return err
+		}
+	}
+	scope := res.Project
+	if res.Organization != "" {
+		scope = res.Organization + "/" + res.Project
+	}
+	_, err := fmt.Fprintf(w, "Exported %d of %d records from %s to %s (%s, %d already present, %d skipped, %d failed)\nManifest: %s\n",
+		res.Downloaded, res.Records, scope, res.Destination, progressui.FormatBinaryBytes(res.Bytes), res.Present, res.Skipped, res.Failed, res.Manifest)
+	return err
+}
@@ -0,0 +1,141 @@
+package exportdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/drsremote"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	syservices "github.com/calypr/syfon/client/services"
+)
+
+type fakeLister struct {
+	records []internalapi.InternalRecord
+}
+
+func (f fakeLister) List(_ context.Context, opts syservices.ListRecordsOptions) (internalapi.ListRecordsResponse, error) {
+	start := (opts.Page - 1) * opts.Limit
+	if start >= len(f.records) {
+		return internalapi.ListRecordsResponse{Records: &[]internalapi.InternalRecord{}}, nil
+	}
+	end := min(start+opts.Limit, len(f.records))
+	page := f.records[start:end]
+	return internalapi.ListRecordsResponse{Records: &page}, nil
+}
+
+func record(did, name string, size int64) internalapi.InternalRecord {
+	rec := internalapi.InternalRecord{Did: did, Size: &size, Hashes: &internalapi.HashInfo{"sha256": strings.Repeat("a", 64)}}
+	if name != "" {
+		rec.FileName = &name
+	}
+	return rec
+}
+
+func stubRemote(t *testing.T, lister drsremote.RecordLister) {
+	t.Helper()
+	origLoad, origResolve, origClient, origLister, origObj, origDL := loadCfg, resolveRemote, newRemoteClient, recordLister, resolveObject, downloadObject
+	t.Cleanup(func() {
+		loadCfg, resolveRemote, newRemoteClient, recordLister, resolveObject, downloadObject = origLoad, origResolve, origClient, origLister, origObj, origDL
+	})
+	loadCfg = func() (*config.Config, error) { return &config.Config{}, nil }
+	resolveRemote = func(*config.Config, string) (config.Remote, error) { return "origin", nil }
+	newRemoteClient = func(*config.Config, config.Remote, *slog.Logger) (*config.GitContext, error) {
+		return &config.GitContext{Organization: "calypr", ProjectId: "default", DownloadConcurrency: 3}, nil
+	}
+	recordLister = func(*config.GitContext) drsremote.RecordLister { return lister }
+	resolveObject = func(_ context.Context, _ *config.GitContext, id string) (*drsapi.DrsObject, error) {
+		if id == "dg.1/missing" {
+			return nil, errors.New("object not found")
+		}
+		return &drsapi.DrsObject{Id: id, Size: 5}, nil
+	}
+	downloadObject = func(_ context.Context, _ *config.GitContext, obj drsapi.DrsObject, dst string) error {
+		if !strings.HasSuffix(dst, ".part") {
+			t.Errorf("export should write a .part file, got %s", dst)
+		}
+		return os.WriteFile(dst, []byte("hello"), 0o644)
+	}
+}
+
+func TestRunExportsProjectWithManifest(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "exports")
+	if err := os.MkdirAll(filepath.Join(dest, "reads"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dest, "reads", "present.bam"), []byte("12345"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stubRemote(t, fakeLister{records: []internalapi.InternalRecord{
+		record("dg.1/a", "reads/a.bam", 5),
+		record("dg.1/present", "/reads/present.bam", 5),
+		record("dg.1/dup", "reads/a.bam", 5),
+		record("dg.1/escape", "../outside.txt", 5),
+		record("dg.1/noname", "", 5),
+		record("dg.1/manifest", ManifestName, 5),
+		record("dg.1/missing", "missing.txt", 5),
+	}})
+
+	res, err := Run(context.Background(), "", "other-project", dest, "")
+	if err == nil || !strings.Contains(err.Error(), "1 of 7 objects failed") || !errors.Is(err, drserrors.ErrPartial) {
+		t.Fatalf("expected one partial failure, got %v", err)
+	}
+	if res.Organization != "calypr" || res.Project != "other-project" {
+		t.Fatalf("project should be scoped to the remote's organization, got %s/%s", res.Organization, res.Project)
+	}
+	if res.Records != 7 || res.Downloaded != 1 || res.Present != 1 || res.Skipped != 4 || res.Failed != 1 || res.Bytes != 5 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "reads", "a.bam")); err != nil || string(data) != "hello" {
+		t.Fatalf("reads/a.bam: %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "outside.txt")); !os.IsNotExist(err) {
+		t.Fatalf("escaping file_name must not be written, stat err %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dest, ManifestName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest []File
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("manifest is not JSON: %v", err)
+	}
+	if len(manifest) != 7 || manifest[0].Path != "" || manifest[len(manifest)-1].Path != "reads/present.bam" {
+		t.Fatalf("manifest should list every record sorted by path, got %+v", manifest)
+	}
+	byID := map[string]File{}
+	for _, f := range manifest {
+		byID[f.DRSID] = f
+	}
+	if f := byID["dg.1/a"]; f.Status != statusDownloaded || f.SHA256 != strings.Repeat("a", 64) {
+		t.Fatalf("dg.1/a = %+v", f)
+	}
+	if f := byID["dg.1/dup"]; f.Status != statusSkipped || !strings.Contains(f.Error, "already used") {
+		t.Fatalf("second record for a path should be skipped, got %+v", f)
+	}
+	if f := byID["dg.1/manifest"]; f.Status != statusSkipped {
+		t.Fatalf("a record must not overwrite the manifest, got %+v", f)
+	}
+}
+
+func TestParseProject(t *testing.T) {
+	if _, _, err := parseProject("", "calypr", ""); err == nil {
+		t.Fatal("expected error without --project or a remote project")
+	}
+	org, project, err := parseProject("program/proj", "calypr", "default")
+	if err != nil || org != "program" || project != "proj" {
+		t.Fatalf("got %s/%s, %v", org, project, err)
+	}
+	if _, _, err := parseProject("a/b/c", "", ""); err == nil {
+		t.Fatal("expected error for a nested project")
+	}
+}
@@ -0,0 +1,103 @@
+package fetch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+// ledger is the on-disk resume state for a fetch. It is keyed to the commit
+// being fetched so a ledger left behind by an older HEAD is discarded. The
+// LFS cache stays authoritative for what is present; the ledger records
+// progress and the objects that failed.
+type ledger struct {
+	Commit string            `json:"commit"`
+	Done   []string          `json:"done"`
+	Failed map[string]string `json:"failed,omitempty"`
+
+	mu   sync.Mutex
+	path string
+	done map[string]bool
+}
+
+func ledgerPath(remote string) (string, error) {
+	drsDir, err := gitrepo.DrsTopLevel()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(drsDir, "cursors", "fetch", remote+".json"), nil
+}
+
+// loadLedger reads the ledger at path, starting fresh when it is missing or
+// was written for a different commit.
+func loadLedger(path, commit string) (*ledger, bool, error) {
+	l := &ledger{Commit: commit, path: path, done: map[string]bool{}, Failed: map[string]string{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("read fetch ledger: %w", err)
+	}
+	var stored ledger
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, false, fmt.Errorf("parse fetch ledger %s: %w", path, err)
+	}
+	if stored.Commit != commit {
+		return l, false, nil
+	}
+	for _, oid := range stored.Done {
+		l.done[oid] = true
+	}
+	return l, len(l.done) > 0, nil
+}
+
+func (l *ledger) isDone(oid string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.done[oid]
+}
+
+// record marks oid done or failed and persists the ledger.
+func (l *ledger) record(oid string, err error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		l.Failed[oid] = err.Error()
+	} else {
+		l.done[oid] = true
+		delete(l.Failed, oid)
+	}
+	return l.saveLocked()
+}
+
+func (l *ledger) saveLocked() error {
+	l.Done = l.Done[:0]
+	for oid := range l.done {
+		l.Done = append(l.Done, oid)
+	}
+	sort.Strings(l.Done)
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	if err := common.WriteFileAtomic(l.path, data, 0o644); err != nil {
+		return fmt.Errorf("write fetch ledger: %w", err)
+	}
+	return nil
+}
+
+// remove deletes the ledger once every object has been fetched.
+func (l *ledger) remove() error {
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove fetch ledger: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pathspec"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	fetchAll        bool
+	includePatterns []string
+	jobs            int
+	rate            float64
+	restart         bool
+)
+
+var (
+	loadCfg         = config.LoadConfig
+	resolveRemote   = func(cfg *config.Config, name string) (config.Remote, error) { return cfg.GetRemoteOrDefault(name) }
+	newRemoteClient = func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+		return cfg.GetRemoteClient(remote, logger)
+	}
+	loadHeadInventory = func(logger *slog.Logger) (map[string]lfs.LfsFileInfo, error) {
+		return lfs.GetLfsFilesForRefs([]string{"HEAD"}, logger)
+	}
+	headCommit     = func() (string, error) { return gitOutput("rev-parse", "HEAD") }
+	downloadObject = drsremote.DownloadToCachePath
+	ledgerPathFn   = ledgerPath
+)
+
+// Result summarizes one fetch run.
+type Result struct {
+	Remote  string            `json:"remote"`
+	Commit  string            `json:"commit"`
+	Objects int               `json:"objects"`
+	Present int               `json:"present"`
+	Fetched int               `json:"fetched"`
+	Resumed bool              `json:"resumed"`
+	Failed  map[string]string `json:"failed,omitempty"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "fetch [remote-name]",
+	Short: "Download LFS objects for HEAD into the local cache without checking them out",
+	Long: "Description:" +
+		"\n  Enumerate the LFS objects referenced by HEAD, skip those already in" +
+		"\n  .git/lfs/objects, and download the rest with a pool of workers. Progress" +
+		"\n  is kept in a ledger under .git/drs/cursors/fetch, so an interrupted or" +
+		"\n  partly failed fetch resumes where it stopped when run again for the" +
+		"\n  same commit. Run git drs pull afterwards to check the files out.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: accepts at most 1 argument (remote name), received %d\n\nUsage: %s\n\nSee 'git drs fetch --help' for more details", len(args), cmd.UseLine())
+		}
+		if !fetchAll && len(includePatterns) == 0 {
+			return fmt.Errorf("specify --all or at least one --include pattern")
+		}
+		if fetchAll && len(includePatterns) > 0 {
+			return fmt.Errorf("--all and --include are mutually exclusive")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remoteName := ""
+		if len(args) > 0 {
+			remoteName = args[0]
+		}
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		res, err := Run(ctx, remoteName, pathspec.Rooted(includePatterns))
+		if res != nil {
+			if werr := writeResult(cmd.OutOrStdout(), res); werr != nil {
+				return werr
+			}
+		}
+		return err
+	},
+}
+
+func init() {
+	Cmd.Flags().BoolVar(&fetchAll, "all", false, "fetch every LFS object referenced by HEAD")
+	Cmd.Flags().StringArrayVarP(&includePatterns, "include", "I", nil, "fetch only objects for paths matching these pathspec/glob pattern(s)")
+	Cmd.Flags().IntVarP(&jobs, "jobs", "j", 0, "concurrent downloads (default: drs.transfer.download-workers)")
+	Cmd.Flags().Float64Var(&rate, "rate", 0, "maximum downloads started per second (0 = unlimited)")
+	Cmd.Flags().BoolVar(&restart, "restart", false, "discard the fetch ledger and start over")
+}
+
+// Run downloads every missing object for HEAD paths matching patterns (all
+// paths when patterns is empty). Failed objects are recorded in the ledger
+// and reported in the result; the returned error then says how many failed.
+func Run(ctx context.Context, remoteName string, patterns []string) (*Result, error) {
+	logg := drslog.GetLogger()
+
+	cfg, err := loadCfg()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+	remote, err := resolveRemote(cfg, remoteName)
+	if err != nil {
+		return nil, err
+	}
+	drsCtx, err := newRemoteClient(cfg, remote, logg)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := headCommit()
+	if err != nil {
+		return nil, err
+	}
+	inventory, err := loadHeadInventory(logg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list LFS objects in HEAD: %w", err)
+	}
+	oids := collectOIDs(inventory, patterns)
+
+	path, err := ledgerPathFn(string(remote))
+	if err != nil {
+		return nil, err
+	}
+	if restart {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("remove fetch ledger: %w", err)
+		}
+	}
+	led, resumed, err := loadLedger(path, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Remote: string(remote), Commit: commit, Objects: len(oids), Resumed: resumed}
+	pending := make([]string, 0, len(oids))
+	for _, oid := range oids {
+		cachePath, err := lfs.ObjectPath(common.LFS_OBJS_PATH, oid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve LFS object path for %s: %w", oid, err)
+		}
+		if _, err := os.Stat(cachePath); err == nil {
+			res.Present++
+			continue
+		}
+		pending = append(pending, oid)
+	}
+	if resumed {
+		logg.Info(fmt.Sprintf("resuming fetch of %s: %d of %d objects already done", shortCommit(commit), res.Present, res.Objects))
+	}
+
+	workers := jobs
+	if workers <= 0 {
+		workers = drsCtx.DownloadConcurrency
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+	var tick <-chan time.Time
+	if rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(workers)
+	for _, oid := range pending {
+		if tick != nil {
+			select {
+			case <-tick:
+			case <-gctx.Done():
+			}
+		}
+		if gctx.Err() != nil {
+			break
+		}
+		oid := oid
+		g.Go(func() error {
+			cachePath, err := lfs.ObjectPath(common.LFS_OBJS_PATH, oid)
+			if err != nil {
+				return err
+			}
+			dlErr := downloadObject(gctx, drsCtx, logg, oid, cachePath)
+			if dlErr != nil {
+				logg.Warn(fmt.Sprintf("fetch %s failed: %v", oid, dlErr))
+			}
+			if err := led.record(oid, dlErr); err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if dlErr == nil {
+				res.Fetched++
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return res, err
+	}
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+
+	if len(led.Failed) > 0 {
+		res.Failed = led.Failed
+		return res, drserrors.Partial(len(led.Failed), res.Objects, fmt.Errorf("%d of %d objects failed to download; run git drs fetch again to retry them", len(led.Failed), res.Objects))
+	}
+	if err := led.remove(); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func collectOIDs(inventory map[string]lfs.LfsFileInfo, patterns []string) []string {
+	seen := make(map[string]bool, len(inventory))
+	oids := make([]string, 0, len(inventory))
+	for path, info := range inventory {
+		if info.Oid == "" || seen[info.Oid] || !pathspec.MatchesAny(path, patterns) {
+			continue
+		}
+		seen[info.Oid] = true
+		oids = append(oids, info.Oid)
+	}
+	sort.Strings(oids)
+	return oids
+}
+
+func writeResult(w io.Writer, res *Result) error {
+	if common.JSONOutput() {
+		return common.WriteJSON(w, res)
+	}
+	_, err := fmt.Fprintf(w, "Fetched %d of %d objects for %s (%d already present, %d failed)\n",
+		res.Fetched, res.Objects, shortCommit(res.Commit), res.Present, len(res.Failed))
+	return err
+}
+
+func shortCommit(sha string) string {
+	if len(sha) > 10 {
+		return sha[:10]
+	}
+	return sha
+}
+
+func gitOutput(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,149 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+const (
+	oidA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	oidB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	oidC = "cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc"
+)
+
+// stubFetch runs in a temp dir with three objects in HEAD; oidA is already
+// cached. failing lists oids the downloader rejects.
+func stubFetch(t *testing.T, failing map[string]bool) *[]string {
+	t.Helper()
+	root := t.TempDir()
+	t.Chdir(root)
+
+	origLoad, origResolve, origClient, origInv, origHead, origDL, origLedger := loadCfg, resolveRemote, newRemoteClient, loadHeadInventory, headCommit, downloadObject, ledgerPathFn
+	t.Cleanup(func() {
+		loadCfg, resolveRemote, newRemoteClient, loadHeadInventory, headCommit, downloadObject, ledgerPathFn = origLoad, origResolve, origClient, origInv, origHead, origDL, origLedger
+		jobs, rate, restart = 0, 0, false
+	})
+
+	loadCfg = func() (*config.Config, error) { return &config.Config{}, nil }
+	resolveRemote = func(*config.Config, string) (config.Remote, error) { return "origin", nil }
+	newRemoteClient = func(*config.Config, config.Remote, *slog.Logger) (*config.GitContext, error) {
+		return &config.GitContext{UploadConcurrency: 2}, nil
+	}
+	loadHeadInventory = func(*slog.Logger) (map[string]lfs.LfsFileInfo, error) {
+		return map[string]lfs.LfsFileInfo{
+			"data/a.bam":      {Oid: oidA},
+			"data/b.bam":      {Oid: oidB},
+			"data/copy/b.bam": {Oid: oidB},
+			"other/c.bam":     {Oid: oidC},
+		}, nil
+	}
+	headCommit = func() (string, error) { return "commit-1", nil }
+	ledgerPathFn = func(remote string) (string, error) {
+		return filepath.Join(root, ".git", "drs", "cursors", "fetch", remote+".json"), nil
+	}
+
+	cached, err := lfs.ObjectPath(common.LFS_OBJS_PATH, oidA)
+	if err != nil {
+		t.Fatalf("ObjectPath: %v", err)
+	}
+	writeFile(t, cached)
+
+	var mu sync.Mutex
+	var downloaded []string
+	downloadObject = func(ctx context.Context, gc *config.GitContext, logger *slog.Logger, oid, cachePath string) error {
+		if failing[oid] {
+			return errors.New("signed URL expired")
+		}
+		writeFile(t, cachePath)
+		mu.Lock()
+		downloaded = append(downloaded, oid)
+		mu.Unlock()
+		return nil
+	}
+	return &downloaded
+}
+
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunFetchesMissingObjectsOnce(t *testing.T) {
+	downloaded := stubFetch(t, nil)
+
+	res, err := Run(context.Background(), "", nil)
+	if err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	if res.Objects != 3 || res.Present != 1 || res.Fetched != 2 || len(*downloaded) != 2 {
+		t.Fatalf("unexpected result %+v downloads %v", res, *downloaded)
+	}
+	path, _ := ledgerPathFn("origin")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected ledger removed after a complete fetch, stat err=%v", err)
+	}
+}
+
+func TestRunRespectsIncludePatterns(t *testing.T) {
+	downloaded := stubFetch(t, nil)
+
+	res, err := Run(context.Background(), "", []string{"other/**"})
+	if err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	if res.Objects != 1 || len(*downloaded) != 1 || (*downloaded)[0] != oidC {
+		t.Fatalf("unexpected result %+v downloads %v", res, *downloaded)
+	}
+}
+
+func TestRunResumesFromLedgerAfterFailure(t *testing.T) {
+	failing := map[string]bool{oidC: true}
+	downloaded := stubFetch(t, failing)
+
+	res, err := Run(context.Background(), "", nil)
+	if err == nil {
+		t.Fatalf("expected failure for %s", oidC)
+	}
+	if res.Fetched != 1 || res.Failed[oidC] == "" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	delete(failing, oidC)
+	*downloaded = nil
+
+	res, err = Run(context.Background(), "", nil)
+	if err != nil {
+		t.Fatalf("second Run error: %v", err)
+	}
+	if !res.Resumed || res.Fetched != 1 || len(*downloaded) != 1 || (*downloaded)[0] != oidC {
+		t.Fatalf("unexpected resumed result %+v downloads %v", res, *downloaded)
+	}
+}
+
+func TestRunIgnoresLedgerForOtherCommit(t *testing.T) {
+	stubFetch(t, map[string]bool{oidC: true})
+	if _, err := Run(context.Background(), "", nil); err == nil {
+		t.Fatal("expected failure on first run")
+	}
+
+	headCommit = func() (string, error) { return "commit-2", nil }
+	path, _ := ledgerPathFn("origin")
+	led, resumed, err := loadLedger(path, "commit-2")
+	if err != nil || resumed || led.isDone(oidB) {
+		t.Fatalf("expected a fresh ledger for a new commit, resumed=%v err=%v", resumed, err)
+	}
+}
@@ -2,7 +2,9 @@
 // (https://git-scm.com/docs/gitattributes#_long_running_filter_process) for
 // git-drs. It is configured as the filter.lfs.process handler and intercepts
 // smudge (checkout) and clean (stage) operations, wiring them directly to the
-// DRS transfer stack without spawning a separate transfer agent.
+// DRS transfer stack without spawning a separate transfer agent. When git
+// offers the delay capability, smudges of uncached objects are answered later
+// so downloads overlap with the rest of the checkout.
 //
 // The command is hidden and invoked automatically by git when
 //
@@ -54,7 +56,11 @@ func runFilter(cmd *cobra.Command, _ []string) error {
 	var drsCtx *config.GitContext
 
 	remote, err := cfg.GetDefaultRemote()
-	if err != nil {
+	if drsfilter.SkipSmudge() {
+		// Pointer-only checkouts never download, so they need no client or
+		// credentials.
+		logger.Debug("filter: smudge skipped; leaving pointers in place")
+	} else if err != nil {
 		logger.Info("filter: no default remote", "err", err)
 	} else {
 		drsCtx, err = cfg.GetRemoteClient(remote, logger)
@@ -72,6 +78,10 @@ func runFilter(cmd *cobra.Command, _ []string) error {
 	f := gitfilter.NewGitFilter(os.Stdin, os.Stdout, logger).
 		OnSmudge(makeSmudgeHandler(drsCtx, logger)).
 		OnClean(makeCleanHandler(lfsRoot, logger))
+	if drsCtx != nil {
+		// Let git keep checking out other files while objects download.
+		f.OnDelay(makeDelayHandler(drsCtx, logger), drsCtx.DownloadConcurrency)
+	}
 
 	return f.Run(ctx)
 }
@@ -93,6 +103,23 @@ func makeSmudgeHandler(drsCtx *config.GitContext, logger *slog.Logger) gitfilter
 	}
 }
 
+// makeDelayHandler defers smudges whose object is not cached yet; the
+// download runs in the background and the later smudge reads the cache.
+func makeDelayHandler(drsCtx *config.GitContext, logger *slog.Logger) gitfilter.DelayFunc {
+	return func(req gitfilter.FilterRequest, ptr []byte) func(context.Context) error {
+		oid, ok := drsfilter.PendingDownload(ptr)
+		if !ok {
+			return nil
+		}
+		logger.Debug("delaying smudge", "pathname", req.Pathname, "oid", oid)
+		return func(ctx context.Context) error {
+			return drsfilter.DownloadToCache(ctx, oid, func(callCtx context.Context, oid, cachePath string) error {
+				return drsremote.DownloadToCachePath(callCtx, drsCtx, logger, oid, cachePath)
+			})
+		}
+	}
+}
+
 // --------------------------------------------------------------------------
 // Clean handler — stage: real file content → LFS pointer
 // --------------------------------------------------------------------------
@@ -0,0 +1,104 @@
+package flush
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/pushsync"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remote string
+	dryRun bool
+)
+
+// Swapped in tests.
+var (
+	loadConfig = config.LoadConfig
+	outbox     = pushsync.Outbox
+	flush      = func(cl *config.GitContext, ctx context.Context) ([]pushsync.Intent, error) {
+		return pushsync.FlushOutbox(cl, ctx, nil)
+	}
+)
+
+// Report is the JSON form of a flush run.
+type Report struct {
+	Remote  string            `json:"remote"`
+	DryRun  bool              `json:"dry_run"`
+	Intents []pushsync.Intent `json:"intents"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Register and upload the files queued while the DRS server was unreachable",
+	Long: "Description:" +
+		"\n  Commits in drs.registration.mode=commit, and pushes with --queue-offline," +
+		"\n  queue the files they cannot register in the remote's outbox while the" +
+		"\n  DRS server is unreachable. Register and upload every queued file, as a" +
+		"\n  push would, and empty the outbox. The next git drs push does the same." +
+		"\n  Replaying an intent whose record is already registered does not register" +
+		"\n  it again.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: accepts no arguments, received %d\n\nUsage: %s\n\nSee 'git drs flush --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := drslog.GetLogger()
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("error loading config: %w", err)
+		}
+		remoteName, err := cfg.GetRemoteOrDefault(remote)
+		if err != nil {
+			return err
+		}
+		report := Report{Remote: string(remoteName), DryRun: dryRun, Intents: outbox(string(remoteName))}
+		if !dryRun && len(report.Intents) > 0 {
+			cl, err := cfg.GetRemoteClient(remoteName, logger)
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if report.Intents, err = flush(cl, ctx); err != nil {
+				return fmt.Errorf("flushing the outbox for remote %s (%d files stay queued): %w", remoteName, len(report.Intents), err)
+			}
+		}
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), report)
+		}
+		writeReport(cmd.OutOrStdout(), report)
+		return nil
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote whose outbox to flush (default: default remote)")
+	Cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list the queued files without registering them")
+}
+
+func writeReport(w io.Writer, r Report) {
+	if len(r.Intents) == 0 {
+		fmt.Fprintf(w, "Nothing queued for remote %s\n", r.Remote)
+		return
+	}
+	for _, in := range r.Intents {
+		fmt.Fprintf(w, "%s\t%s\tqueued by %s at %s\n", in.Path, in.OID, in.QueuedBy, in.QueuedAt.Format("2006-01-02T15:04:05Z"))
+	}
+	verb := "Flushed"
+	if r.DryRun {
+		verb = "Would flush"
+	}
+	fmt.Fprintf(w, "%s %d queued files to remote %s\n", verb, len(r.Intents), r.Remote)
+}
@@ -0,0 +1,27 @@
+package flush
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/calypr/git-drs/internal/pushsync"
+)
+
+func TestReport(t *testing.T) {
+	var out bytes.Buffer
+	writeReport(&out, Report{Remote: "origin", DryRun: true})
+	if out.String() != "Nothing queued for remote origin\n" {
+		t.Fatalf("empty report = %q", out.String())
+	}
+
+	out.Reset()
+	at := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	writeReport(&out, Report{Remote: "origin", Intents: []pushsync.Intent{
+		{Path: "data/a.bin", OID: "abc", QueuedBy: pushsync.QueuedByCommit, QueuedAt: at},
+	}})
+	want := "data/a.bin\tabc\tqueued by commit at 2026-03-04T05:06:07Z\nFlushed 1 queued files to remote origin\n"
+	if out.String() != want {
+		t.Fatalf("report:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,477 @@
+package fsck
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsfilter"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/hashing"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pathspec"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remote  string
+	offline bool
+	repair  bool
+)
+
+// Swapped in tests.
+var (
+	gitTopLevel     = gitrepo.GitTopLevel
+	loadConfig      = config.LoadConfig
+	newRemoteClient = func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+		return cfg.GetRemoteClient(remote, logger)
+	}
+	lookupObjects = drsremote.ObjectsByHashesForScope
+	lfsRootDir    = func(ctx context.Context) (string, error) {
+		_, root, err := lfs.GetGitRootDirectories(ctx)
+		return root, err
+	}
+	storeFile   = drsfilter.StoreFile
+	renormalize = defaultRenormalize
+)
+
+// Problem kinds.
+const (
+	kindMalformedPointer = "malformed-pointer"
+	kindUnfiltered       = "unfiltered-content"
+	kindHashMismatch     = "hash-mismatch"
+	kindMissingObject    = "missing-object"
+)
+
+// Repairs. A problem without one needs a person to decide.
+const (
+	repairRestorePointer = "restore-pointer"
+	repairRehash         = "rehash"
+	repairRestage        = "restage"
+)
+
+// pointerPrefix starts every LFS pointer file.
+const pointerPrefix = "version https://git-lfs.github.com/spec/"
+
+// maxPointerSize bounds the files read as possible pointers, as git-lfs does.
+const maxPointerSize = 1024
+
+// Problem is one broken tracked file.
+type Problem struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	OID    string `json:"oid,omitempty"`
+	Detail string `json:"detail"`
+	Repair string `json:"repair,omitempty"`
+	// Repaired is set once --repair has applied Repair; Error says why it
+	// could not.
+	Repaired bool   `json:"repaired,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	size int64
+	src  string
+}
+
+// Report is the outcome of one fsck run.
+type Report struct {
+	Checked       int       `json:"checked"`
+	RemoteChecked bool      `json:"remote_checked"`
+	Problems      []Problem `json:"problems"`
+	Repaired      int       `json:"repaired"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "fsck [pathspec...]",
+	Short: "Find and repair broken LFS pointers in the working tree",
+	Long: "Description:" +
+		"\n  Check every LFS-tracked file for problems left by tools that do not" +
+		"\n  know about LFS: malformed pointer files, raw content committed without" +
+		"\n  the clean filter, hydrated files whose content no longer matches their" +
+		"\n  pointer, and pointers with neither a staged DRS object nor a record on" +
+		"\n  the remote. Each problem names the repair that fixes it; --repair" +
+		"\n  applies them.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		logger := drslog.GetLogger()
+
+		var registered func([]string) (map[string]bool, error)
+		if !offline {
+			registered = func(oids []string) (map[string]bool, error) {
+				return remotePresence(ctx, logger, oids)
+			}
+		}
+		report, err := Check(ctx, pathspec.Rooted(args), registered)
+		if err != nil {
+			return err
+		}
+		if repair {
+			Repair(ctx, report, logger)
+		}
+		if common.JSONOutput() {
+			if err := common.WriteJSON(cmd.OutOrStdout(), report); err != nil {
+				return err
+			}
+		} else if err := writeReport(cmd.OutOrStdout(), report, repair); err != nil {
+			return err
+		}
+		if left := len(report.Problems) - report.Repaired; left > 0 {
+			return fmt.Errorf("%d of %d tracked files have problems", left, report.Checked)
+		}
+		return nil
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote to look up unstaged pointers on (default: default remote)")
+	Cmd.Flags().BoolVar(&offline, "offline", false, "skip the remote lookup; pointers without a staged DRS object are reported")
+	Cmd.Flags().BoolVar(&repair, "repair", false, "apply the suggested repairs")
+}
+
+func remotePresence(ctx context.Context, logger *slog.Logger, oids []string) (map[string]bool, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+	name, err := cfg.GetRemoteOrDefault(remote)
+	if err != nil {
+		return nil, err
+	}
+	gc, err := newRemoteClient(cfg, name, logger)
+	if err != nil {
+		return nil, err
+	}
+	results, err := lookupObjects(ctx, gc, oids)
+	if err != nil {
+		return nil, fmt.Errorf("error querying remote: %w", err)
+	}
+	out := make(map[string]bool, len(results))
+	for oid, objs := range results {
+		out[oid] = len(objs) > 0
+	}
+	return out, nil
+}
+
+// Check inspects the tracked files matching patterns. registered reports
+// which oids the remote holds; it may be nil for offline runs.
+func Check(ctx context.Context, patterns []string, registered func([]string) (map[string]bool, error)) (*Report, error) {
+	root, err := gitTopLevel()
+	if err != nil {
+		return nil, err
+	}
+	tracked, err := lfs.TrackedPaths(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, p := range tracked {
+		if pathspec.MatchesAny(p, patterns) {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	staged, err := lfs.ReadStagedBlobs(ctx, root, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Problems: []Problem{}, RemoteChecked: registered != nil}
+	var (
+		hydrated  []string // files whose content must be hashed
+		pointerOf = map[string]string{}
+		verified  = map[string]bool{} // hydrated files matching their pointer
+	)
+	for _, p := range paths {
+		sb, ok := staged[p]
+		if !ok {
+			continue
+		}
+		report.Checked++
+		file := filepath.Join(root, filepath.FromSlash(p))
+		head, size, readErr := readHead(file)
+		worktreePointer := readErr == nil && size <= maxPointerSize && bytes.HasPrefix(head, []byte(pointerPrefix))
+
+		if !sb.IsPointer {
+			pr := Problem{Path: p, Kind: kindUnfiltered, Detail: "the index holds raw content instead of an LFS pointer"}
+			if readErr == nil && !worktreePointer {
+				pr.Repair = repairRehash
+			}
+			report.Problems = append(report.Problems, pr)
+			continue
+		}
+		if worktreePointer {
+			if _, _, ok := lfs.ParseLFSPointer(head); !ok {
+				report.Problems = append(report.Problems, Problem{
+					Path: p, Kind: kindMalformedPointer, OID: sb.Oid, size: sb.ObjectSize, Repair: repairRestorePointer,
+					Detail: "the working tree file is a damaged LFS pointer",
+				})
+				continue
+			}
+			pointerOf[p] = sb.Oid
+			continue
+		}
+		if readErr != nil {
+			// Deleted or unreadable in the working tree; git status reports it.
+			pointerOf[p] = sb.Oid
+			continue
+		}
+		if size != sb.ObjectSize {
+			report.Problems = append(report.Problems, Problem{
+				Path: p, Kind: kindHashMismatch, OID: sb.Oid, Repair: repairRehash,
+				Detail: fmt.Sprintf("the file is %d bytes but its pointer records %d", size, sb.ObjectSize),
+			})
+			continue
+		}
+		hydrated = append(hydrated, p)
+	}
+
+	if len(hydrated) > 0 {
+		files := make([]string, len(hydrated))
+		for i, p := range hydrated {
+			files[i] = filepath.Join(root, filepath.FromSlash(p))
+		}
+		results, err := hashing.HashFiles(ctx, files, hashing.Options{})
+		if err != nil {
+			return nil, err
+		}
+		for i, p := range hydrated {
+			oid := staged[p].Oid
+			if results[i].OID != oid {
+				report.Problems = append(report.Problems, Problem{
+					Path: p, Kind: kindHashMismatch, OID: oid, Repair: repairRehash,
+					Detail: fmt.Sprintf("the file's sha256 is %s but its pointer records %s", results[i].OID, oid),
+				})
+				continue
+			}
+			pointerOf[p], verified[p] = oid, true
+		}
+	}
+
+	missing, err := unstagedPointers(root, pointerOf, verified, staged, registered)
+	if err != nil {
+		return nil, err
+	}
+	report.Problems = append(report.Problems, missing...)
+	sort.SliceStable(report.Problems, func(i, j int) bool { return report.Problems[i].Path < report.Problems[j].Path })
+	return report, nil
+}
+
+// unstagedPointers reports pointers whose oid has no staged DRS object and,
+// when registered is set, no record on the remote either. They can be
+// re-staged, and so registered by the next push, when their content is in
+// the local LFS store or verified in the working tree.
+func unstagedPointers(root string, pointerOf map[string]string, verified map[string]bool, staged map[string]lfs.StagedBlob, registered func([]string) (map[string]bool, error)) ([]Problem, error) {
+	paths := make([]string, 0, len(pointerOf))
+	var oids []string
+	seen := map[string]bool{}
+	for p, oid := range pointerOf {
+		if obj, err := drsobject.ReadObject(common.DRS_OBJS_PATH, oid); err == nil && obj != nil {
+			continue
+		}
+		paths = append(paths, p)
+		if !seen[oid] {
+			seen[oid] = true
+			oids = append(oids, oid)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	sort.Strings(paths)
+	sort.Strings(oids)
+	onRemote := map[string]bool{}
+	if registered != nil {
+		var err error
+		if onRemote, err = registered(oids); err != nil {
+			return nil, err
+		}
+	}
+
+	var out []Problem
+	for _, p := range paths {
+		oid := pointerOf[p]
+		if onRemote[oid] {
+			continue
+		}
+		pr := Problem{Path: p, Kind: kindMissingObject, OID: oid, size: staged[p].ObjectSize}
+		pr.Detail = "no staged DRS object and no record on the remote"
+		if registered == nil {
+			pr.Detail = "no staged DRS object (remote not checked)"
+		}
+		if cached, err := lfs.ObjectPath(common.LFS_OBJS_PATH, oid); err == nil && sizeIs(cached, pr.size) {
+			pr.src = cached
+		} else if verified[p] {
+			pr.src = filepath.Join(root, filepath.FromSlash(p))
+		}
+		if pr.src != "" {
+			pr.Repair = repairRestage
+		} else {
+			pr.Detail += "; the content is not in the local LFS store"
+		}
+		out = append(out, pr)
+	}
+	return out, nil
+}
+
+// Repair applies each problem's repair and records the outcome on it.
+func Repair(ctx context.Context, report *Report, logger *slog.Logger) {
+	root, err := gitTopLevel()
+	if err != nil {
+		markFailed(report, err)
+		return
+	}
+	var rehash []int
+	for i := range report.Problems {
+		pr := &report.Problems[i]
+		switch pr.Repair {
+		case repairRestorePointer:
+			pr.setResult(restorePointer(filepath.Join(root, filepath.FromSlash(pr.Path)), pr.OID, pr.size))
+		case repairRestage:
+			pr.setResult(restage(ctx, pr, logger))
+		case repairRehash:
+			rehash = append(rehash, i)
+		}
+	}
+	if len(rehash) > 0 {
+		paths := make([]string, len(rehash))
+		for i, idx := range rehash {
+			paths[i] = report.Problems[idx].Path
+		}
+		err := renormalize(ctx, root, paths)
+		for _, idx := range rehash {
+			report.Problems[idx].setResult(err)
+		}
+	}
+	for _, pr := range report.Problems {
+		if pr.Repaired {
+			report.Repaired++
+		}
+	}
+}
+
+func (pr *Problem) setResult(err error) {
+	if err != nil {
+		pr.Error = err.Error()
+		return
+	}
+	pr.Repaired = true
+}
+
+func markFailed(report *Report, err error) {
+	for i := range report.Problems {
+		if report.Problems[i].Repair != "" {
+			report.Problems[i].setResult(err)
+		}
+	}
+}
+
+// restorePointer rewrites a damaged pointer file from the index's pointer.
+func restorePointer(file, oid string, size int64) error {
+	pointer := fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", oid, size)
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(file); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return os.WriteFile(file, []byte(pointer), mode)
+}
+
+// restage writes the staged DRS object the clean filter would have written.
+func restage(ctx context.Context, pr *Problem, logger *slog.Logger) error {
+	lfsRoot, err := lfsRootDir(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = storeFile(lfsRoot, pr.Path, pr.src, pr.OID, pr.size, logger)
+	return err
+}
+
+// defaultRenormalize runs the clean filter over paths again, so their index
+// entries point at the working tree content even when git's stat cache
+// considers the files unchanged.
+func defaultRenormalize(ctx context.Context, root string, paths []string) error {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"add", "--renormalize", "--"}, paths...)...)
+	cmd.Dir = root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git add --renormalize: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// readHead returns up to maxPointerSize bytes of file and its size.
+func readHead(file string) ([]byte, int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, 0, fmt.Errorf("%s is not a regular file", file)
+	}
+	head := make([]byte, maxPointerSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, 0, err
+	}
+	return head[:n], info.Size(), nil
+}
+
+func sizeIs(file string, size int64) bool {
+	info, err := os.Stat(file)
+	return err == nil && info.Mode().IsRegular() && info.Size() == size
+}
+
+func writeReport(w io.Writer, report *Report, repairing bool) error {
+	for _, pr := range report.Problems {
+		action := "repair: " + pr.Repair
+		switch {
+		case pr.Repair == "":
+			action = "no automatic repair"
+		case pr.Repaired:
+			action = "repaired: " + pr.Repair
+		case pr.Error != "":
+			action = fmt.Sprintf("repair %s failed: %s", pr.Repair, pr.Error)
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s (%s)\n", pr.Kind, pr.Path, pr.Detail, action); err != nil {
+			return err
+		}
+	}
+	summary := fmt.Sprintf("Checked %d tracked files: %d problems", report.Checked, len(report.Problems))
+	if repairing {
+		summary += fmt.Sprintf(", %d repaired", report.Repaired)
+	} else if repairable(report) > 0 {
+		summary += fmt.Sprintf("; run 'git drs fsck --repair' to fix %d", repairable(report))
+	}
+	_, err := fmt.Fprintln(w, summary)
+	return err
+}
+
+func repairable(report *Report) int {
+	n := 0
+	for _, pr := range report.Problems {
+		if pr.Repair != "" {
+			n++
+		}
+	}
+	return n
+}
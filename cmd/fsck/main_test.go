@@ -0,0 +1,159 @@
+package fsck
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func git(t *testing.T, args ...string) {
+	t.Helper()
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+}
+
+func write(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func oidOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func pointer(content string) string {
+	return "version https://git-lfs.github.com/spec/v1\noid sha256:" + oidOf(content) + "\nsize " + strconv.Itoa(len(content)) + "\n"
+}
+
+func stage(t *testing.T, content string) {
+	t.Helper()
+	oid := oidOf(content)
+	if err := drsobject.WriteObject(common.DRS_OBJS_PATH, &drsapi.DrsObject{Id: "dg.1/" + oid[:8], Size: int64(len(content))}, oid); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func cache(t *testing.T, content string) {
+	t.Helper()
+	path, err := lfs.ObjectPath(common.LFS_OBJS_PATH, oidOf(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write(t, path, content)
+}
+
+func TestCheckAndRepair(t *testing.T) {
+	repo := t.TempDir()
+	t.Chdir(repo)
+	git(t, "init", "-q")
+	write(t, ".gitattributes", "*.bam filter=drs diff=drs merge=drs -text\n")
+
+	// ok.bam is a pointer with a staged object.
+	write(t, "ok.bam", pointer("ok content"))
+	stage(t, "ok content")
+	// damaged.bam had its pointer mangled after staging.
+	write(t, "damaged.bam", pointer("damaged content"))
+	stage(t, "damaged content")
+	// raw.bam was added without the clean filter.
+	write(t, "raw.bam", "raw content")
+	// edited.bam was hydrated, then rewritten in place by another tool.
+	write(t, "edited.bam", pointer("edited content"))
+	stage(t, "edited content")
+	// cached.bam lost its staged object but its content is in the LFS store.
+	write(t, "cached.bam", pointer("cached content"))
+	cache(t, "cached content")
+	// lost.bam has neither a staged object nor local content.
+	write(t, "lost.bam", pointer("lost content"))
+	git(t, "add", ".")
+	write(t, "damaged.bam", "version https://git-lfs.github.com/spec/v1\r\noid sha256:\r\n")
+	write(t, "edited.bam", "EDITED content")
+
+	report, err := Check(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	got := map[string][2]string{}
+	for _, pr := range report.Problems {
+		got[pr.Path] = [2]string{pr.Kind, pr.Repair}
+	}
+	want := map[string][2]string{
+		"damaged.bam": {kindMalformedPointer, repairRestorePointer},
+		"raw.bam":     {kindUnfiltered, repairRehash},
+		"edited.bam":  {kindHashMismatch, repairRehash},
+		"cached.bam":  {kindMissingObject, repairRestage},
+		"lost.bam":    {kindMissingObject, ""},
+	}
+	if report.Checked != 6 || !reflect.DeepEqual(got, want) {
+		t.Fatalf("Check = %d checked, %v; want 6, %v", report.Checked, got, want)
+	}
+
+	// A remote that holds lost.bam's object clears that problem.
+	online, err := Check(context.Background(), []string{"lost.bam"}, func(oids []string) (map[string]bool, error) {
+		return map[string]bool{oidOf("lost content"): true}, nil
+	})
+	if err != nil || online.Checked != 1 || len(online.Problems) != 0 || !online.RemoteChecked {
+		t.Fatalf("online Check = %+v, %v; want lost.bam registered", online, err)
+	}
+
+	origRoot, origRenormalize := lfsRootDir, renormalize
+	t.Cleanup(func() { lfsRootDir, renormalize = origRoot, origRenormalize })
+	lfsRootDir = func(context.Context) (string, error) { return filepath.Join(repo, ".git", "lfs"), nil }
+	var renormalized []string
+	renormalize = func(_ context.Context, _ string, paths []string) error {
+		renormalized = paths
+		return nil
+	}
+	Repair(context.Background(), report, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if report.Repaired != 4 {
+		t.Fatalf("Repaired = %d, want 4: %+v", report.Repaired, report.Problems)
+	}
+	if !reflect.DeepEqual(renormalized, []string{"edited.bam", "raw.bam"}) {
+		t.Fatalf("renormalized %v", renormalized)
+	}
+	if data, err := os.ReadFile("damaged.bam"); err != nil || string(data) != pointer("damaged content") {
+		t.Fatalf("damaged.bam = %q, %v; want its pointer restored", data, err)
+	}
+	if _, err := drsobject.ReadObject(common.DRS_OBJS_PATH, oidOf("cached content")); err != nil {
+		t.Fatalf("cached.bam was not re-staged: %v", err)
+	}
+
+	after, err := Check(context.Background(), []string{"damaged.bam", "cached.bam"}, nil)
+	if err != nil || len(after.Problems) != 0 {
+		t.Fatalf("after repair: %+v, %v", after, err)
+	}
+}
+
+func TestDefaultRenormalize(t *testing.T) {
+	repo := t.TempDir()
+	t.Chdir(repo)
+	git(t, "init", "-q")
+	write(t, "a.txt", "one\n")
+	git(t, "add", "a.txt")
+	if err := defaultRenormalize(context.Background(), repo, []string{"a.txt"}); err != nil {
+		t.Fatalf("defaultRenormalize: %v", err)
+	}
+	if err := defaultRenormalize(context.Background(), repo, []string{"missing.txt"}); err == nil {
+		t.Fatal("expected an error for a path git does not know")
+	}
+}
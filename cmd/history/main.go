@@ -0,0 +1,180 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remote  string
+	offline bool
+)
+
+var (
+	loadConfig      = config.LoadConfig
+	newRemoteClient = func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+		return cfg.GetRemoteClient(remote, logger)
+	}
+	pathHistory   = lfs.PathHistory
+	lookupObjects = drsremote.ObjectsByHashesForScope
+)
+
+// Entry is one commit in a path's history with the DRS record registered for
+// the pointer it introduced. DRSID is empty when the oid has no record in the
+// remote's project or the lookup was skipped.
+type Entry struct {
+	lfs.PathRevision
+	DRSID             string `json:"drs_id,omitempty"`
+	RecordVersion     string `json:"record_version,omitempty"`
+	RecordPredecessor string `json:"record_predecessor,omitempty"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "history <path>",
+	Short: "List the DRS versions of a tracked file across commits",
+	Long: "Description:" +
+		"\n  Walk the commits reachable from HEAD that changed <path> and show the LFS" +
+		"\n  pointer each one left, numbered as versions in the order they appeared." +
+		"\n  Unless --offline is set, each oid is resolved to its DRS record in the" +
+		"\n  remote's project, along with the version and predecessor stamped on the" +
+		"\n  record at push time. Renames are not followed.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires exactly 1 argument (path), received %d\n\nUsage: %s\n\nSee 'git drs history --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := drslog.GetLogger()
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		revs, err := pathHistory(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		if len(revs) == 0 {
+			return fmt.Errorf("no commits reachable from HEAD touch %s", args[0])
+		}
+
+		var lookup func([]string) (map[string][]drsapi.DrsObject, error)
+		if !offline {
+			cfg, err := loadConfig()
+			if err != nil {
+				return fmt.Errorf("error loading config: %w", err)
+			}
+			name, err := cfg.GetRemoteOrDefault(remote)
+			if err != nil {
+				return err
+			}
+			gc, err := newRemoteClient(cfg, name, logger)
+			if err != nil {
+				return err
+			}
+			lookup = func(oids []string) (map[string][]drsapi.DrsObject, error) {
+				return lookupObjects(ctx, gc, oids)
+			}
+		}
+
+		entries, err := buildEntries(revs, lookup)
+		if err != nil {
+			return err
+		}
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), entries)
+		}
+		return writeEntries(cmd.OutOrStdout(), entries, lookup != nil)
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote to resolve records from (default: default remote)")
+	Cmd.Flags().BoolVar(&offline, "offline", false, "list versions from git history only, without DRS lookups")
+}
+
+// buildEntries attaches DRS records to revisions. lookup may be nil for
+// offline runs.
+func buildEntries(revs []lfs.PathRevision, lookup func([]string) (map[string][]drsapi.DrsObject, error)) ([]Entry, error) {
+	var records map[string][]drsapi.DrsObject
+	if lookup != nil {
+		seen := make(map[string]bool)
+		var oids []string
+		for _, rev := range revs {
+			if rev.OID != "" && !seen[rev.OID] {
+				seen[rev.OID] = true
+				oids = append(oids, rev.OID)
+			}
+		}
+		if len(oids) > 0 {
+			var err error
+			if records, err = lookup(oids); err != nil {
+				return nil, fmt.Errorf("error querying remote: %w", err)
+			}
+		}
+	}
+
+	entries := make([]Entry, 0, len(revs))
+	for _, rev := range revs {
+		entry := Entry{PathRevision: rev}
+		if recs := records[rev.OID]; len(recs) > 0 {
+			rec := recs[0]
+			entry.DRSID = rec.Id
+			if rec.Version != nil {
+				entry.RecordVersion = *rec.Version
+			}
+			entry.RecordPredecessor = drsobject.Predecessor(rec)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func writeEntries(w io.Writer, entries []Entry, resolved bool) error {
+	for _, e := range entries {
+		date := e.Time
+		if len(date) > 10 {
+			date = date[:10]
+		}
+		var line string
+		switch {
+		case e.Deleted:
+			line = fmt.Sprintf("-\t%s\t%s\tdeleted", shortHash(e.Commit), date)
+		case e.OID == "":
+			line = fmt.Sprintf("-\t%s\t%s\tnot an LFS pointer", shortHash(e.Commit), date)
+		default:
+			line = fmt.Sprintf("v%d\t%s\t%s\t%s", e.Version, shortHash(e.Commit), date, shortHash(e.OID))
+			if resolved {
+				id := e.DRSID
+				if id == "" {
+					id = "(not registered)"
+				}
+				line += "\t" + id
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", line, e.Subject); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func shortHash(s string) string {
+	if len(s) > 10 {
+		return s[:10]
+	}
+	return s
+}
@@ -0,0 +1,75 @@
+package history
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestBuildEntriesResolvesRecordsPerOID(t *testing.T) {
+	oidA := strings.Repeat("a", 64)
+	oidB := strings.Repeat("b", 64)
+	revs := []lfs.PathRevision{
+		{Commit: strings.Repeat("3", 40), Time: "2026-03-01T10:00:00Z", Subject: "update", OID: oidB, Version: 2, Predecessor: oidA},
+		{Commit: strings.Repeat("2", 40), Time: "2026-02-01T10:00:00Z", Subject: "drop", Deleted: true},
+		{Commit: strings.Repeat("1", 40), Time: "2026-01-01T10:00:00Z", Subject: "add", OID: oidA, Version: 1},
+	}
+
+	version := "2"
+	aliases := []string{drsobject.PredecessorAliasPrefix + oidA}
+	var queried []string
+	lookup := func(oids []string) (map[string][]drsapi.DrsObject, error) {
+		queried = oids
+		return map[string][]drsapi.DrsObject{
+			oidB: {{Id: "drs-b", Version: &version, Aliases: &aliases}},
+		}, nil
+	}
+
+	entries, err := buildEntries(revs, lookup)
+	if err != nil {
+		t.Fatalf("buildEntries: %v", err)
+	}
+	if len(queried) != 2 {
+		t.Fatalf("expected two distinct oids queried, got %v", queried)
+	}
+	if entries[0].DRSID != "drs-b" || entries[0].RecordVersion != "2" || entries[0].RecordPredecessor != oidA {
+		t.Fatalf("unexpected resolved entry: %+v", entries[0])
+	}
+	if entries[2].DRSID != "" {
+		t.Fatalf("expected unregistered first version, got %+v", entries[2])
+	}
+
+	var out bytes.Buffer
+	if err := writeEntries(&out, entries, true); err != nil {
+		t.Fatalf("writeEntries: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[0], "v2\t3333333333\t2026-03-01\tbbbbbbbbbb\tdrs-b\tupdate") {
+		t.Fatalf("unexpected first line: %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "deleted") || !strings.Contains(lines[2], "(not registered)") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestBuildEntriesOffline(t *testing.T) {
+	revs := []lfs.PathRevision{{Commit: "c1", OID: strings.Repeat("a", 64), Version: 1, Subject: "add"}}
+	entries, err := buildEntries(revs, nil)
+	if err != nil {
+		t.Fatalf("buildEntries: %v", err)
+	}
+	var out bytes.Buffer
+	if err := writeEntries(&out, entries, false); err != nil {
+		t.Fatalf("writeEntries: %v", err)
+	}
+	if strings.Contains(out.String(), "registered") {
+		t.Fatalf("offline output should not mention registration:\n%s", out.String())
+	}
+}
@@ -0,0 +1,387 @@
+package importrecords
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/drstrack"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pathmap"
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remote    string
+	projectID string
+	prefix    string
+	dryRun    bool
+)
+
+var (
+	loadConfig            = config.LoadConfig
+	loadWorktreeInventory = lfs.GetWorktreeLfsFiles
+	gitTopLevel           = gitrepo.GitTopLevel
+	isLFSTracked          = lfs.IsLFSTracked
+	trackPatterns         = drstrack.TrackPatterns
+)
+
+var sha256Hex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "import",
+	Short: "Write LFS pointers for a project's existing DRS records",
+	Long: "Description:" +
+		"\n  List the records registered in a project and write an LFS pointer file for" +
+		"\n  each object the repository does not reference yet, so data that already" +
+		"\n  lives in Gen3 can be versioned with git-drs without uploading it again." +
+		"\n  Files are placed at the record's file_name, below --prefix.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: accepts no arguments, received %d\n\nUsage: %s\n\nSee 'git drs import --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logg := drslog.GetLogger()
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("error loading config: %w", err)
+		}
+		remoteName, err := cfg.GetRemoteOrDefault(remote)
+		if err != nil {
+			return err
+		}
+		cl, err := cfg.GetRemoteClient(remoteName, logg)
+		if err != nil {
+			return fmt.Errorf("error creating DRS client: %w", err)
+		}
+		org, project, err := parseProject(projectID, cl.Organization, cl.ProjectId)
+		if err != nil {
+			return err
+		}
+		dir, err := cleanPrefix(prefix)
+		if err != nil {
+			return err
+		}
+		root, err := gitTopLevel()
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		report, err := importRecords(ctx, logg, cl.Client.Index(), importOptions{
+			Root:         root,
+			Remote:       string(remoteName),
+			Organization: org,
+			Project:      project,
+			Prefix:       dir,
+			DryRun:       dryRun,
+		})
+		if err != nil {
+			return err
+		}
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), report)
+		}
+		return writeReport(cmd.OutOrStdout(), report)
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote to import from (default: default remote)")
+	Cmd.Flags().StringVar(&projectID, "project", "", "project to import, as <project> or <organization>/<project> (default: the remote's project)")
+	Cmd.Flags().StringVar(&prefix, "prefix", "", "repository directory to write the pointer files under")
+	Cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report what would be imported without writing anything")
+}
+
+type importOptions struct {
+	Root         string
+	Remote       string
+	Organization string
+	Project      string
+	Prefix       string
+	DryRun       bool
+}
+
+// importedFile is one pointer written (or, with --dry-run, to be written).
+type importedFile struct {
+	Path  string `json:"path"`
+	OID   string `json:"oid"`
+	DRSID string `json:"drs_id"`
+	Size  int64  `json:"size"`
+}
+
+// importReport is the `import --json` document.
+type importReport struct {
+	Remote       string         `json:"remote"`
+	Organization string         `json:"organization,omitempty"`
+	Project      string         `json:"project"`
+	DryRun       bool           `json:"dry_run"`
+	Records      int            `json:"records"`
+	Imported     []importedFile `json:"imported"`
+	// Referenced counts records whose object the repository already has.
+	Referenced int `json:"already_referenced"`
+	// PathExists counts records whose target path is already taken.
+	PathExists int `json:"path_exists"`
+	// NoChecksum counts records without a sha256 to build a pointer from.
+	NoChecksum int `json:"no_checksum"`
+	// InvalidName counts records whose file_name is empty or leaves the repo.
+	InvalidName int `json:"invalid_name"`
+	// Tracked lists the paths newly added to .gitattributes.
+	Tracked []string `json:"tracked,omitempty"`
+}
+
+// importRecords lists the project's records and writes a pointer, and a map
+// entry when .drs/map is enabled, for every object not referenced in the
+// worktree. Existing files are never overwritten.
+func importRecords(ctx context.Context, logger *slog.Logger, lister drsremote.RecordLister, opts importOptions) (importReport, error) {
+	report := importReport{
+		Remote:       opts.Remote,
+		Organization: opts.Organization,
+		Project:      opts.Project,
+		DryRun:       opts.DryRun,
+		Imported:     []importedFile{},
+	}
+	files, err := loadWorktreeInventory(logger)
+	if err != nil {
+		return report, fmt.Errorf("error listing LFS files: %w", err)
+	}
+	referenced := make(map[string]bool, len(files))
+	for _, info := range files {
+		referenced[drsobject.NormalizeOid(info.Oid)] = true
+	}
+	taken := map[string]bool{}
+
+	err = drsremote.ListRecordsParallel(ctx, lister, drsremote.ListOptions{
+		Organization: opts.Organization,
+		ProjectID:    opts.Project,
+	}, func(_ int, records []internalapi.InternalRecord) error {
+		for _, rec := range records {
+			report.Records++
+			oid := recordOid(rec)
+			if oid == "" {
+				report.NoChecksum++
+				logger.Debug("skipping record without sha256", "did", rec.Did)
+				continue
+			}
+			if referenced[oid] {
+				report.Referenced++
+				continue
+			}
+			repoPath, ok := recordPath(opts.Prefix, rec)
+			if !ok {
+				report.InvalidName++
+				logger.Warn("skipping record with unusable file_name", "did", rec.Did)
+				continue
+			}
+			if taken[repoPath] || pathExists(filepath.Join(opts.Root, filepath.FromSlash(repoPath))) {
+				report.PathExists++
+				logger.Warn("skipping record whose path already exists", "did", rec.Did, "path", repoPath)
+				continue
+			}
+			var size int64
+			if rec.Size != nil {
+				size = *rec.Size
+			}
+			referenced[oid] = true
+			taken[repoPath] = true
+			report.Imported = append(report.Imported, importedFile{Path: repoPath, OID: oid, DRSID: rec.Did, Size: size})
+		}
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("error listing records for project %s: %w", opts.Project, err)
+	}
+	sort.Slice(report.Imported, func(i, j int) bool { return report.Imported[i].Path < report.Imported[j].Path })
+	if opts.DryRun || len(report.Imported) == 0 {
+		return report, nil
+	}
+
+	for _, f := range report.Imported {
+		if err := writePointer(filepath.Join(opts.Root, filepath.FromSlash(f.Path)), f.OID, f.Size); err != nil {
+			return report, err
+		}
+	}
+	if report.Tracked, err = trackImported(ctx, opts.Root, report.Imported); err != nil {
+		return report, err
+	}
+	if pathmap.Enabled(opts.Root) {
+		entries := make(pathmap.Map, len(report.Imported))
+		for _, f := range report.Imported {
+			entries[f.Path] = pathmap.Entry{OID: f.OID, DRSID: f.DRSID, Remote: opts.Remote}
+		}
+		if _, err := pathmap.Update(opts.Root, entries, nil); err != nil {
+			return report, fmt.Errorf("error updating %s: %w", pathmap.Dir, err)
+		}
+	}
+	return report, nil
+}
+
+// parseProject resolves --project against the remote's scope.
+func parseProject(raw, remoteOrg, remoteProject string) (string, string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		if strings.TrimSpace(remoteProject) == "" {
+			return "", "", fmt.Errorf("--project is required: the remote has no project configured")
+		}
+		return remoteOrg, remoteProject, nil
+	}
+	org, project, found := strings.Cut(raw, "/")
+	if !found {
+		return remoteOrg, raw, nil
+	}
+	org, project = strings.TrimSpace(org), strings.TrimSpace(project)
+	if org == "" || project == "" || strings.Contains(project, "/") {
+		return "", "", fmt.Errorf("invalid --project %q: expected <project> or <organization>/<project>", raw)
+	}
+	return org, project, nil
+}
+
+// cleanPrefix normalizes --prefix to a repo-relative slash path.
+func cleanPrefix(raw string) (string, error) {
+	raw = strings.TrimSpace(filepath.ToSlash(raw))
+	if raw == "" {
+		return "", nil
+	}
+	clean := path.Clean(strings.TrimPrefix(raw, "/"))
+	if clean == "." {
+		return "", nil
+	}
+	if !safeRepoPath(clean) {
+		return "", fmt.Errorf("invalid --prefix %q: must be a directory inside the repository", raw)
+	}
+	return clean, nil
+}
+
+func recordOid(rec internalapi.InternalRecord) string {
+	if rec.Hashes == nil {
+		return ""
+	}
+	oid := strings.ToLower(drsobject.NormalizeOid((*rec.Hashes)["sha256"]))
+	if !sha256Hex.MatchString(oid) {
+		return ""
+	}
+	return oid
+}
+
+// recordPath places the record's file_name below prefix. Names that are
+// empty, climb out of the repository, or point into .git are rejected.
+func recordPath(prefix string, rec internalapi.InternalRecord) (string, bool) {
+	if rec.FileName == nil {
+		return "", false
+	}
+	name := strings.TrimSpace(filepath.ToSlash(*rec.FileName))
+	if name == "" {
+		return "", false
+	}
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+	if name == "." || !safeRepoPath(name) {
+		return "", false
+	}
+	return path.Join(prefix, name), true
+}
+
+func safeRepoPath(p string) bool {
+	if p == ".." || strings.HasPrefix(p, "../") {
+		return false
+	}
+	first, _, _ := strings.Cut(p, "/")
+	return first != ".git"
+}
+
+func pathExists(p string) bool {
+	_, err := os.Lstat(p)
+	return err == nil
+}
+
+func writePointer(dst, oid string, size int64) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", dst, err)
+	}
+	content := fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", oid, size)
+	if err := os.WriteFile(dst, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write LFS pointer file %s: %w", dst, err)
+	}
+	return nil
+}
+
+// trackImported adds the imported paths that no existing pattern covers to
+// .gitattributes, so `git add` stores them as pointers.
+func trackImported(ctx context.Context, root string, files []importedFile) ([]string, error) {
+	var untracked []string
+	for _, f := range files {
+		tracked, err := isLFSTracked(filepath.Join(root, filepath.FromSlash(f.Path)))
+		if err != nil {
+			return nil, fmt.Errorf("check LFS tracking for %s: %w", f.Path, err)
+		}
+		if !tracked {
+			untracked = append(untracked, f.Path)
+		}
+	}
+	if len(untracked) == 0 {
+		return nil, nil
+	}
+	patterns := make([]string, len(untracked))
+	for i, p := range untracked {
+		patterns[i] = "/" + p
+	}
+	if _, err := trackPatterns(ctx, patterns, false, false); err != nil {
+		return nil, err
+	}
+	return untracked, nil
+}
+
+func writeReport(w io.Writer, report importReport) error {
+	scope := report.Project
+	if report.Organization != "" {
+		scope = report.Organization + "/" + report.Project
+	}
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Would import"
+		for _, f := range report.Imported {
+			fmt.Fprintf(w, "  %s\t%s\n", f.Path, f.DRSID)
+		}
+	}
+	fmt.Fprintf(w, "%s %d of %d records from %s (remote %s)\n", verb, len(report.Imported), report.Records, scope, report.Remote)
+	for _, skip := range []struct {
+		n    int
+		what string
+	}{
+		{report.Referenced, "already referenced in the repository"},
+		{report.PathExists, "target path already exists"},
+		{report.NoChecksum, "no sha256 checksum"},
+		{report.InvalidName, "missing or invalid file_name"},
+	} {
+		if skip.n > 0 {
+			fmt.Fprintf(w, "  skipped %d: %s\n", skip.n, skip.what)
+		}
+	}
+	if len(report.Tracked) > 0 {
+		fmt.Fprintf(w, "Added %d paths to .gitattributes\n", len(report.Tracked))
+	}
+	if !report.DryRun && len(report.Imported) > 0 {
+		fmt.Fprintln(w, "Run `git add` on the new files (and .gitattributes) and commit them; the objects are already registered, so push uploads nothing for them.")
+	}
+	return nil
+}
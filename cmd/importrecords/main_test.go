@@ -0,0 +1,141 @@
+package importrecords
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pathmap"
+	"github.com/calypr/git-drs/internal/testutils"
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	syservices "github.com/calypr/syfon/client/services"
+)
+
+type fakeLister struct {
+	records []internalapi.InternalRecord
+}
+
+func (f fakeLister) List(_ context.Context, opts syservices.ListRecordsOptions) (internalapi.ListRecordsResponse, error) {
+	start := (opts.Page - 1) * opts.Limit
+	if start >= len(f.records) {
+		return internalapi.ListRecordsResponse{Records: &[]internalapi.InternalRecord{}}, nil
+	}
+	end := min(start+opts.Limit, len(f.records))
+	page := f.records[start:end]
+	return internalapi.ListRecordsResponse{Records: &page}, nil
+}
+
+func record(did, name, oid string, size int64) internalapi.InternalRecord {
+	rec := internalapi.InternalRecord{Did: did, Size: &size}
+	if name != "" {
+		rec.FileName = &name
+	}
+	if oid != "" {
+		rec.Hashes = &internalapi.HashInfo{"sha256": oid}
+	}
+	return rec
+}
+
+func TestImportRecords(t *testing.T) {
+	root := testutils.SetupTestGitRepo(t)
+	if err := os.MkdirAll(filepath.Join(root, pathmap.Dir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "data"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "data", "taken.txt"), []byte("local"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	existing := strings.Repeat("a", 64)
+	origInventory := loadWorktreeInventory
+	loadWorktreeInventory = func(*slog.Logger) (map[string]lfs.LfsFileInfo, error) {
+		return map[string]lfs.LfsFileInfo{"old.bam": {Name: "old.bam", Oid: existing}}, nil
+	}
+	t.Cleanup(func() { loadWorktreeInventory = origInventory })
+
+	newOid := strings.Repeat("b", 64)
+	lister := fakeLister{records: []internalapi.InternalRecord{
+		record("did-new", "reads/sample.bam", newOid, 42),
+		record("did-dup", "reads/copy.bam", newOid, 42),
+		record("did-old", "old-copy.bam", existing, 7),
+		record("did-taken", "taken.txt", strings.Repeat("c", 64), 5),
+		record("did-nohash", "nohash.txt", "", 1),
+		record("did-escape", "../outside.txt", strings.Repeat("d", 64), 1),
+	}}
+
+	report, err := importRecords(context.Background(), drslog.NewNoOpLogger(), lister, importOptions{
+		Root:    root,
+		Remote:  "origin",
+		Project: "test-project",
+		Prefix:  "data",
+	})
+	if err != nil {
+		t.Fatalf("importRecords: %v", err)
+	}
+	if report.Records != 6 || len(report.Imported) != 1 || report.Referenced != 2 || report.PathExists != 1 || report.NoChecksum != 1 || report.InvalidName != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	pointer, err := os.ReadFile(filepath.Join(root, "data", "reads", "sample.bam"))
+	if err != nil {
+		t.Fatalf("pointer not written: %v", err)
+	}
+	if !strings.Contains(string(pointer), "oid sha256:"+newOid) || !strings.Contains(string(pointer), "size 42") {
+		t.Fatalf("unexpected pointer: %s", pointer)
+	}
+	if got, _ := os.ReadFile(filepath.Join(root, "data", "taken.txt")); string(got) != "local" {
+		t.Fatalf("existing file was overwritten: %q", got)
+	}
+	tracked, err := lfs.IsLFSTracked(filepath.Join(root, "data", "reads", "sample.bam"))
+	if err != nil || !tracked {
+		t.Fatalf("imported path not tracked: %v %v", tracked, err)
+	}
+	entry, ok, err := pathmap.Lookup(root, "data/reads/sample.bam")
+	if err != nil || !ok || entry.DRSID != "did-new" || entry.OID != newOid {
+		t.Fatalf("unexpected map entry %+v ok=%v err=%v", entry, ok, err)
+	}
+}
+
+func TestImportRecordsDryRun(t *testing.T) {
+	root := testutils.SetupTestGitRepo(t)
+	origInventory := loadWorktreeInventory
+	loadWorktreeInventory = func(*slog.Logger) (map[string]lfs.LfsFileInfo, error) { return nil, nil }
+	t.Cleanup(func() { loadWorktreeInventory = origInventory })
+
+	lister := fakeLister{records: []internalapi.InternalRecord{record("did-1", "a.txt", strings.Repeat("e", 64), 3)}}
+	report, err := importRecords(context.Background(), drslog.NewNoOpLogger(), lister, importOptions{Root: root, Project: "p", DryRun: true})
+	if err != nil {
+		t.Fatalf("importRecords: %v", err)
+	}
+	if len(report.Imported) != 1 {
+		t.Fatalf("expected one planned import, got %+v", report)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("dry run wrote a file: %v", err)
+	}
+}
+
+func TestParseProjectAndPrefix(t *testing.T) {
+	if org, project, err := parseProject("", "calypr", "proj"); err != nil || org != "calypr" || project != "proj" {
+		t.Fatalf("default project = %q %q %v", org, project, err)
+	}
+	if org, project, err := parseProject("other/proj2", "calypr", "proj"); err != nil || org != "other" || project != "proj2" {
+		t.Fatalf("scoped project = %q %q %v", org, project, err)
+	}
+	if _, _, err := parseProject("a/b/c", "", ""); err == nil {
+		t.Fatal("expected error for nested project")
+	}
+	if _, err := cleanPrefix("../up"); err == nil {
+		t.Fatal("expected error for prefix outside the repository")
+	}
+	if got, err := cleanPrefix("/data/raw/"); err != nil || got != "data/raw" {
+		t.Fatalf("cleanPrefix = %q %v", got, err)
+	}
+}
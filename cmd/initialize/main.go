@@ -1,6 +1,7 @@
 package initialize
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -12,8 +13,11 @@ import (
 
 	"github.com/calypr/git-drs/internal/common"
 	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsfilter"
 	"github.com/calypr/git-drs/internal/drslog"
 	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/projectdir"
+	"github.com/calypr/git-drs/internal/repotemplate"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +26,9 @@ var (
 	upsert               bool
 	multiPartThreshold   = 5120
 	enableDataClientLogs bool
+	interactive          bool
+	skipSmudge           bool
+	profileRef           string
 )
 
 // Cmd line declaration
@@ -29,7 +36,12 @@ var Cmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize repo for git-drs",
 	Long: "Description:" +
-		"\n  Initialize repo for git-drs",
+		"\n  Initialize repo for git-drs" +
+		"\n\n  With --interactive, also prompt for a DRS server, probe it, and write" +
+		"\n  the remote config." +
+		"\n\n  With --profile, also apply a repository template: tracking patterns," +
+		"\n  ignore rules and git-drs settings for a kind of data. Built-in profiles" +
+		"\n  are " + strings.Join(repotemplate.Names(), ", ") + "; a URL or YAML file may be given instead.",
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(args) != 0 {
 			cmd.SilenceUsage = false
@@ -39,10 +51,40 @@ var Cmd = &cobra.Command{
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		logg := drslog.GetLogger()
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		var (
+			tmpl     repotemplate.Profile
+			settings map[string][]string
+		)
+		if profileRef != "" {
+			var err error
+			if tmpl, err = loadTemplate(ctx, profileRef); err != nil {
+				return err
+			}
+			if settings, err = profileConfig(tmpl); err != nil {
+				return err
+			}
+		}
 		if err := InitializeRepo(logg); err != nil {
 			return err
 		}
+		if profileRef != "" {
+			if err := applyProfile(ctx, cmd.OutOrStdout(), tmpl, settings); err != nil {
+				return err
+			}
+		}
+		if cmd.Flags().Changed("skip-smudge") {
+			if err := gitrepo.SetGitConfigOptions(map[string]string{drsfilter.SkipSmudgeConfig: strconv.FormatBool(skipSmudge)}); err != nil {
+				return fmt.Errorf("unable to write git config: %w", err)
+			}
+		}
 		logg.Debug(fmt.Sprintf("Using %d concurrent transfers", transfers))
+		if interactive {
+			return runWizard(ctx, cmd.InOrStdin(), cmd.OutOrStdout(), logg)
+		}
 		return nil
 	},
 }
@@ -53,7 +95,7 @@ func InitializeRepo(logg *slog.Logger) error {
 	// check if .git dir exists to ensure you're in a git repository
 	_, err := gitrepo.GitTopLevel()
 	if err != nil {
-		return fmt.Errorf("error: not in a git repository. Please run this command in the root of your git repository")
+		return fmt.Errorf("error: not in a git repository")
 	}
 
 	// create config file if it doesn't exist
@@ -70,8 +112,8 @@ func InitializeRepo(logg *slog.Logger) error {
 	}
 
 	// create drs directories
-	drsDir := common.DRS_DIR
-	drsLfsObjsDir := common.DRS_OBJS_PATH
+	drsDir := projectdir.Path(common.DRS_DIR)
+	drsLfsObjsDir := projectdir.Path(common.DRS_OBJS_PATH)
 	if err := os.MkdirAll(drsDir, 0755); err != nil {
 		return fmt.Errorf("error: unable to create drs directory: %v", err)
 	}
@@ -114,10 +156,10 @@ func EnsureInitialized(logg *slog.Logger) error {
 
 func isInitialized() (bool, error) {
 	if _, err := gitrepo.GitTopLevel(); err != nil {
-		return false, fmt.Errorf("error: not in a git repository. Please run this command in the root of your git repository")
+		return false, fmt.Errorf("error: not in a git repository")
 	}
 
-	if _, err := os.Stat(common.DRS_DIR); err != nil {
+	if _, err := os.Stat(projectdir.Path(common.DRS_DIR)); err != nil {
 		if os.IsNotExist(err) {
 			return false, nil
 		}
@@ -178,6 +220,9 @@ func initGitConfig() error {
 		"filter.drs.smudge":   "git-drs smudge -- %f",
 		"filter.drs.process":  "git-drs filter",
 		"filter.drs.required": "true",
+		// Semantic merges for the committed .drs/map shards and audit log.
+		"merge.drs.name":   "git-drs DRS map and audit log merge",
+		"merge.drs.driver": "git-drs merge-driver %O %A %B %P",
 		// Canonical git-drs config keys consumed by clients.
 		"drs.upsert":                  strconv.FormatBool(upsert),
 		"drs.multipart-threshold":     strconv.Itoa(multiPartThreshold),
@@ -195,6 +240,9 @@ func init() {
 	Cmd.Flags().BoolVarP(&upsert, "upsert", "u", false, "Enable upsert for DRS objects")
 	Cmd.Flags().IntVarP(&multiPartThreshold, "multipart-threshold", "m", 5120, "Multipart threshold in MB")
 	Cmd.Flags().BoolVar(&enableDataClientLogs, "enable-data-client-logs", false, "Enable data-client internal logs")
+	Cmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Prompt for a DRS server, probe it, and write the remote config")
+	Cmd.Flags().StringVar(&profileRef, "profile", "", "apply a repository template: "+strings.Join(repotemplate.Names(), ", ")+", or a profile URL or YAML file")
+	Cmd.Flags().BoolVar(&skipSmudge, "skip-smudge", false, "Leave LFS pointers in place on checkout instead of downloading their data (sets drs.skip-smudge); hydrate files later with git drs checkout --data")
 }
 
 func installPrePushHook(logger *slog.Logger) error {
@@ -1,6 +1,7 @@
 package initialize
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/calypr/git-drs/internal/common"
 	"github.com/calypr/git-drs/internal/drslog"
 	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/repotemplate"
 	"github.com/calypr/git-drs/internal/testutils"
 )
 
@@ -110,6 +112,7 @@ func TestInitConfigValues(t *testing.T) {
 	check("filter.drs.smudge", "git-drs smudge -- %f")
 	check("filter.drs.process", "git-drs filter")
 	check("filter.drs.required", "true")
+	check("merge.drs.driver", "git-drs merge-driver %O %A %B %P")
 }
 
 func TestEnsureInitialized(t *testing.T) {
@@ -141,3 +144,54 @@ func TestEnsureInitialized(t *testing.T) {
 		t.Fatalf("unexpected filter.drs.clean: %q", filterClean)
 	}
 }
+
+func TestBuiltinProfilesPassConfigSchema(t *testing.T) {
+	for _, name := range repotemplate.Names() {
+		p, err := repotemplate.Load(context.Background(), name)
+		if err != nil {
+			t.Fatalf("load %s: %v", name, err)
+		}
+		if _, err := profileConfig(p); err != nil {
+			t.Fatalf("profile %s: %v", name, err)
+		}
+	}
+	if _, err := profileConfig(repotemplate.Profile{Name: "bad", Config: map[string]repotemplate.Values{"limits.max-file-size": {"huge"}}}); err == nil {
+		t.Fatalf("expected an invalid setting to be rejected")
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	testutils.SetupTestGitRepo(t)
+	if err := os.WriteFile(".gitignore", []byte("*.tmp\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := repotemplate.Profile{
+		Name:   "lab",
+		Track:  []string{"*.bam"},
+		Ignore: []string{"*.tmp", "work/"},
+		Config: map[string]repotemplate.Values{"register.exclude": {"work/**"}},
+	}
+	settings, err := profileConfig(p)
+	if err != nil {
+		t.Fatalf("profileConfig: %v", err)
+	}
+	var out strings.Builder
+	if err := applyProfile(context.Background(), &out, p, settings); err != nil {
+		t.Fatalf("applyProfile: %v", err)
+	}
+	if got, _ := gitrepo.GetGitConfigString("drs.register.exclude"); got != "work/**" {
+		t.Fatalf("drs.register.exclude = %q", got)
+	}
+	attrs, _ := os.ReadFile(".gitattributes")
+	if !strings.Contains(string(attrs), "*.bam filter=drs diff=drs merge=drs -text") {
+		t.Fatalf("unexpected .gitattributes:\n%s", attrs)
+	}
+	if ignore, _ := os.ReadFile(".gitignore"); string(ignore) != "*.tmp\nwork/\n" {
+		t.Fatalf("unexpected .gitignore:\n%s", ignore)
+	}
+	for _, want := range []string{"Set drs.register.exclude", `Tracking "*.bam"`, `Ignoring "work/"`, "Applied profile lab"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package initialize
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drstrack"
+	"github.com/calypr/git-drs/internal/projectdir"
+	"github.com/calypr/git-drs/internal/repotemplate"
+)
+
+var (
+	loadTemplate  = repotemplate.Load
+	trackPatterns = drstrack.TrackPatterns
+	setConfigKeys = config.SetKeys
+)
+
+// profileConfig returns p's settings under their canonical keys, checked
+// against the config schema, so a bad profile fails before init changes
+// anything.
+func profileConfig(p repotemplate.Profile) (map[string][]string, error) {
+	out := make(map[string][]string, len(p.Config))
+	for name, values := range p.Config {
+		key := config.CanonicalKey(name)
+		k, err := config.LookupKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", p.Name, err)
+		}
+		if err := k.Check(key, values); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", p.Name, err)
+		}
+		out[key] = values
+	}
+	return out, nil
+}
+
+// applyProfile writes p's settings to git config, its tracking patterns to
+// .gitattributes and its ignore rules to .gitignore. Patterns and rules
+// already present are kept; settings replace the repository's values.
+func applyProfile(ctx context.Context, out io.Writer, p repotemplate.Profile, settings map[string][]string) error {
+	if _, err := setConfigKeys(settings); err != nil {
+		return fmt.Errorf("apply profile %s settings: %w", p.Name, err)
+	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(out, "Set %s = %v\n", key, settings[key])
+	}
+
+	if len(p.Track) > 0 {
+		tracked, err := trackPatterns(ctx, p.Track, false, false)
+		if err != nil {
+			return fmt.Errorf("apply profile %s tracking: %w", p.Name, err)
+		}
+		fmt.Fprint(out, tracked)
+	}
+
+	if len(p.Ignore) > 0 {
+		path := projectdir.Path(".gitignore")
+		existing, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("read .gitignore: %w", err)
+		}
+		merged, added := repotemplate.MergeIgnore(existing, p.Ignore)
+		if len(added) > 0 {
+			if err := os.WriteFile(path, merged, 0o644); err != nil {
+				return fmt.Errorf("write .gitignore: %w", err)
+			}
+		}
+		for _, line := range added {
+			fmt.Fprintf(out, "Ignoring %q\n", line)
+		}
+	}
+	fmt.Fprintf(out, "Applied profile %s; commit .gitattributes and .gitignore to share it\n", p.Name)
+	return nil
+}
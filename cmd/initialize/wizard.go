@@ -0,0 +1,269 @@
+package initialize
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/httpclient"
+	bucketapi "github.com/calypr/syfon/apigen/client/bucketapi"
+	syconf "github.com/calypr/syfon/client/config"
+)
+
+// wizardAnswers are the settings collected by `git drs init --interactive`.
+type wizardAnswers struct {
+	ServerType   config.RemoteType
+	Remote       string
+	Endpoint     string
+	Organization string
+	Project      string
+	Bucket       string
+	Profile      string
+}
+
+// probeResult records what the endpoint told us before the config is written.
+type probeResult struct {
+	IndexdVersion string
+	IndexdErr     error
+	Buckets       []string
+	BucketsErr    error
+}
+
+var (
+	probeClient = httpclient.New(10 * time.Second)
+	loadProfile = func(profile string, logg *slog.Logger) (*syconf.Credential, error) {
+		return syconf.NewConfigure(logg).Load(profile)
+	}
+	saveRemote = func(name config.Remote, remote config.RemoteSelect) error {
+		_, err := config.UpdateRemote(name, remote)
+		return err
+	}
+	configureRemoteGit = func(remote, endpoint string) error {
+		if err := gitrepo.SetRemoteLFSURL(remote, endpoint); err != nil {
+			return fmt.Errorf("failed to set lfs url for remote %s: %w", remote, err)
+		}
+		if err := gitrepo.ConfigureCredentialHelperForRepo(); err != nil {
+			return fmt.Errorf("failed to configure git credential helper: %w", err)
+		}
+		return nil
+	}
+)
+
+// prompter reads answers line by line, offering a default in brackets.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (p *prompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("error reading answer: %v", err)
+	}
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return def, nil
+	}
+	return line, nil
+}
+
+func (p *prompter) askRequired(question, def string) (string, error) {
+	for {
+		answer, err := p.ask(question, def)
+		if err != nil {
+			return "", err
+		}
+		if answer != "" {
+			return answer, nil
+		}
+		fmt.Fprintln(p.out, "  a value is required")
+	}
+}
+
+// runWizard prompts for a remote, probes it, writes the remote config, and
+// prints next steps. The repository must already be initialized.
+func runWizard(ctx context.Context, in io.Reader, out io.Writer, logg *slog.Logger) error {
+	p := &prompter{in: bufio.NewReader(in), out: out}
+	var a wizardAnswers
+
+	serverType, err := p.askRequired("Server type (gen3 or local)", string(config.Gen3ServerType))
+	if err != nil {
+		return err
+	}
+	a.ServerType = config.RemoteType(strings.ToLower(serverType))
+	if a.ServerType != config.Gen3ServerType && a.ServerType != config.LocalServerType {
+		return fmt.Errorf("unsupported server type %q: expected %s or %s", serverType, config.Gen3ServerType, config.LocalServerType)
+	}
+	if a.Remote, err = p.askRequired("Remote name", string(config.ORIGIN)); err != nil {
+		return err
+	}
+	endpoint, err := p.askRequired("Endpoint URL (e.g. https://gen3.example.org)", "")
+	if err != nil {
+		return err
+	}
+	a.Endpoint = strings.TrimRight(endpoint, "/")
+	if !strings.HasPrefix(a.Endpoint, "http://") && !strings.HasPrefix(a.Endpoint, "https://") {
+		return fmt.Errorf("endpoint %q must start with http:// or https://", endpoint)
+	}
+	scope, err := p.askRequired("Project (organization/project)", "")
+	if err != nil {
+		return err
+	}
+	if a.Organization, a.Project, err = splitScope(scope); err != nil {
+		return err
+	}
+
+	token := ""
+	if a.ServerType == config.Gen3ServerType {
+		if a.Profile, err = p.askRequired("Credential profile", a.Remote); err != nil {
+			return err
+		}
+		if cred, err := loadProfile(a.Profile, logg); err != nil {
+			fmt.Fprintf(out, "  profile %q not found; probing without credentials\n", a.Profile)
+		} else {
+			token = strings.TrimSpace(cred.AccessToken)
+		}
+	}
+
+	fmt.Fprintf(out, "Probing %s ...\n", a.Endpoint)
+	probe := probeEndpoint(ctx, a.Endpoint, token)
+	writeProbe(out, probe)
+
+	defBucket := ""
+	if len(probe.Buckets) == 1 {
+		defBucket = probe.Buckets[0]
+	}
+	if a.Bucket, err = p.askRequired("Bucket", defBucket); err != nil {
+		return err
+	}
+
+	if err := saveRemote(config.Remote(a.Remote), a.remoteSelect()); err != nil {
+		return fmt.Errorf("failed to update remote config: %w", err)
+	}
+	if err := configureRemoteGit(a.Remote, a.Endpoint); err != nil {
+		return err
+	}
+	writeNextSteps(out, a, token != "")
+	return nil
+}
+
+func (a wizardAnswers) remoteSelect() config.RemoteSelect {
+	if a.ServerType == config.LocalServerType {
+		return config.RemoteSelect{Local: &config.LocalRemote{
+			BaseURL:      a.Endpoint,
+			ProjectID:    a.Project,
+			Organization: a.Organization,
+			Bucket:       a.Bucket,
+		}}
+	}
+	gen3 := &config.Gen3Remote{
+		Endpoint:     a.Endpoint,
+		ProjectID:    a.Project,
+		Organization: a.Organization,
+		Bucket:       a.Bucket,
+	}
+	if a.Profile != a.Remote {
+		gen3.Profile = a.Profile
+	}
+	return config.RemoteSelect{Gen3: gen3}
+}
+
+func splitScope(raw string) (string, string, error) {
+	parts := strings.Split(strings.TrimSpace(raw), "/")
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+		return "", "", fmt.Errorf("invalid scope %q: expected organization/project", raw)
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+}
+
+// probeEndpoint asks indexd for its version and fence for the buckets the
+// caller can see. Failures are recorded, not returned: the wizard still
+// writes the config so it can be fixed up later.
+func probeEndpoint(ctx context.Context, endpoint, token string) probeResult {
+	var res probeResult
+
+	var version struct {
+		Version string `json:"version"`
+	}
+	if err := getJSON(ctx, endpoint+"/index/_version", token, &version); err != nil {
+		res.IndexdErr = err
+	} else {
+		res.IndexdVersion = version.Version
+	}
+
+	var buckets bucketapi.BucketsResponse
+	if err := getJSON(ctx, endpoint+"/user/data/buckets", token, &buckets); err != nil {
+		res.BucketsErr = err
+	} else {
+		for name := range buckets.S3BUCKETS {
+			res.Buckets = append(res.Buckets, name)
+		}
+		sort.Strings(res.Buckets)
+	}
+	return res
+}
+
+func getJSON(ctx context.Context, url, token string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode %s: %w", url, err)
+	}
+	return nil
+}
+
+func writeProbe(out io.Writer, probe probeResult) {
+	if probe.IndexdErr != nil {
+		fmt.Fprintf(out, "  indexd: unreachable (%v)\n", probe.IndexdErr)
+	} else {
+		fmt.Fprintf(out, "  indexd: ok (version %s)\n", probe.IndexdVersion)
+	}
+	switch {
+	case probe.BucketsErr != nil:
+		fmt.Fprintf(out, "  fence buckets: unavailable (%v)\n", probe.BucketsErr)
+	case len(probe.Buckets) == 0:
+		fmt.Fprintln(out, "  fence buckets: none visible")
+	default:
+		fmt.Fprintf(out, "  fence buckets: %s\n", strings.Join(probe.Buckets, ", "))
+	}
+}
+
+func writeNextSteps(out io.Writer, a wizardAnswers, haveCredential bool) {
+	fmt.Fprintf(out, "\nRemote %q configured for %s/%s at %s.\n\nNext steps:\n", a.Remote, a.Organization, a.Project, a.Endpoint)
+	step := 1
+	if a.ServerType == config.Gen3ServerType && !haveCredential {
+		fmt.Fprintf(out, "  %d. git drs remote add gen3 %s %s/%s --cred <credentials.json>\n", step, a.Remote, a.Organization, a.Project)
+		step++
+	}
+	fmt.Fprintf(out, "  %d. git drs ping %s\n", step, a.Remote)
+	fmt.Fprintf(out, "  %d. git drs track \"*.bam\"\n", step+1)
+	fmt.Fprintf(out, "  %d. git add .gitattributes <files> && git commit\n", step+2)
+	fmt.Fprintf(out, "  %d. git drs push %s\n", step+3, a.Remote)
+}
@@ -0,0 +1,107 @@
+package initialize
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	syconf "github.com/calypr/syfon/client/config"
+)
+
+func stubWizard(t *testing.T, cred *syconf.Credential) (*config.Remote, *config.RemoteSelect) {
+	t.Helper()
+	origLoad, origSave, origGit := loadProfile, saveRemote, configureRemoteGit
+	t.Cleanup(func() { loadProfile, saveRemote, configureRemoteGit = origLoad, origSave, origGit })
+
+	var savedName config.Remote
+	var saved config.RemoteSelect
+	loadProfile = func(string, *slog.Logger) (*syconf.Credential, error) {
+		if cred == nil {
+			return nil, errors.New("no profile")
+		}
+		return cred, nil
+	}
+	saveRemote = func(name config.Remote, remote config.RemoteSelect) error {
+		savedName, saved = name, remote
+		return nil
+	}
+	configureRemoteGit = func(string, string) error { return nil }
+	return &savedName, &saved
+}
+
+func TestRunWizardGen3ProbesAndWritesRemote(t *testing.T) {
+	var sawAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index/_version":
+			w.Write([]byte(`{"version":"2024.09"}`))
+		case "/user/data/buckets":
+			sawAuth = r.Header.Get("Authorization")
+			w.Write([]byte(`{"S3_BUCKETS":{"study-bucket":{"programs":["/programs/htan/projects/p1"]}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	name, saved := stubWizard(t, &syconf.Credential{AccessToken: "tok"})
+	in := strings.NewReader("\nprod\n" + srv.URL + "/\nhtan/p1\nci\n\n")
+	var out strings.Builder
+	if err := runWizard(context.Background(), in, &out, drslog.NewNoOpLogger()); err != nil {
+		t.Fatalf("runWizard error: %v\n%s", err, out.String())
+	}
+
+	if *name != "prod" || saved.Gen3 == nil {
+		t.Fatalf("unexpected remote %q: %+v", *name, saved)
+	}
+	g := saved.Gen3
+	if g.Endpoint != srv.URL || g.Organization != "htan" || g.ProjectID != "p1" || g.Bucket != "study-bucket" || g.Profile != "ci" {
+		t.Fatalf("unexpected gen3 remote: %+v", g)
+	}
+	if sawAuth != "Bearer tok" {
+		t.Fatalf("expected profile token on bucket probe, got %q", sawAuth)
+	}
+	for _, want := range []string{"indexd: ok (version 2024.09)", "fence buckets: study-bucket", "git drs push prod"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in output:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunWizardLocalWithUnreachableProbe(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	_, saved := stubWizard(t, nil)
+	in := strings.NewReader("local\n\n" + srv.URL + "\norg/proj\nmy-bucket\n")
+	var out strings.Builder
+	if err := runWizard(context.Background(), in, &out, drslog.NewNoOpLogger()); err != nil {
+		t.Fatalf("runWizard error: %v", err)
+	}
+	if saved.Local == nil || saved.Local.BaseURL != srv.URL || saved.Local.Bucket != "my-bucket" {
+		t.Fatalf("unexpected local remote: %+v", saved)
+	}
+	if !strings.Contains(out.String(), "indexd: unreachable") {
+		t.Fatalf("expected probe failure to be reported:\n%s", out.String())
+	}
+}
+
+func TestRunWizardRejectsBadInput(t *testing.T) {
+	stubWizard(t, nil)
+	for _, input := range []string{
+		"s3\n",
+		"gen3\norigin\nftp://example.org\n",
+		"gen3\norigin\nhttps://example.org\nnoslash\n",
+	} {
+		var out strings.Builder
+		if err := runWizard(context.Background(), strings.NewReader(input), &out, drslog.NewNoOpLogger()); err == nil {
+			t.Fatalf("expected error for input %q", input)
+		}
+	}
+}
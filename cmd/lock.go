@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/repolock"
+	"github.com/spf13/cobra"
+)
+
+// lockedCommands write .git/drs state (objects, caches, ledgers, pointers)
+// and run under the repository lock. Filters and read-only commands do not.
+var lockedCommands = map[string]bool{
+	"add":              true,
+	"add-access":       true,
+	"add-ref":          true,
+	"add-url":          true,
+	"alias add":        true,
+	"alias rm":         true,
+	"backfill-paths":   true,
+	"cache clear":      true,
+	"cache rebuild":    true,
+	"checkout":         true,
+	"delete":           true,
+	"fetch":            true,
+	"flush":            true,
+	"fsck":             true,
+	"import":           true,
+	"map rebuild":      true,
+	"pre-push-prepare": true,
+	"precommit":        true,
+	"pull":             true,
+	"push":             true,
+	"rekey":            true,
+	"replicate":        true,
+	"restore":          true,
+	"rm":               true,
+	"unstage":          true,
+}
+
+// lockWait makes a locked command wait for the repository lock instead of
+// failing when another git-drs command holds it.
+var lockWait bool
+
+var heldLock *repolock.Lock
+
+// commandName is the command path below the root, e.g. "map rebuild".
+func commandName(c *cobra.Command) string {
+	return strings.TrimSpace(strings.TrimPrefix(c.CommandPath(), c.Root().Name()))
+}
+
+// acquireRepoLock takes the repository lock for commands in lockedCommands.
+// Outside a repository it does nothing and leaves the error to the command.
+func acquireRepoLock(c *cobra.Command) error {
+	name := commandName(c)
+	if !lockedCommands[name] {
+		return nil
+	}
+	drsDir, err := gitrepo.DrsTopLevel()
+	if err != nil {
+		return nil
+	}
+	ctx := c.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	lock, err := repolock.Acquire(ctx, filepath.Join(drsDir, "lock"), repolock.Options{
+		Command: "git drs " + name,
+		Wait:    lockWait || gitrepo.GetGitConfigBool("drs.lock.wait", false),
+		OnWait: func(h repolock.Holder) {
+			fmt.Fprintf(os.Stderr, "Waiting for the repository lock held by %q (pid %d on %s)...\n", h.Command, h.PID, h.Host)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	heldLock = lock
+	// Hooks and other git-drs processes started by this command share it.
+	return os.Setenv(repolock.TokenEnv, lock.Token())
+}
+
+// ReleaseRepoLock releases the lock taken for the executed command, if any.
+func ReleaseRepoLock() {
+	if err := heldLock.Release(); err != nil {
+		drslog.GetLogger().Warn("failed to release repository lock", "error", err)
+	}
+	heldLock = nil
+}
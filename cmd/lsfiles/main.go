@@ -1,7 +1,6 @@
 package lsfiles
 
 import (
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
@@ -9,11 +8,13 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/calypr/git-drs/internal/common"
 	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/drslog"
 	"github.com/calypr/git-drs/internal/drsremote"
 	"github.com/calypr/git-drs/internal/lfs"
 	"github.com/calypr/git-drs/internal/pathspec"
+	"github.com/calypr/git-drs/internal/projectdir"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	"github.com/spf13/cobra"
 )
@@ -23,7 +24,6 @@ var drsRemote string
 var includePatterns []string
 var showLong bool
 var nameOnly bool
-var jsonOutput bool
 var drsStatus bool
 
 var (
@@ -245,10 +245,8 @@ func collectRows(cmd *cobra.Command, gitRemoteName, drsRemoteName string, patter
 }
 
 func printRows(cmd *cobra.Command, rows []fileRow) error {
-	if jsonOutput {
-		enc := json.NewEncoder(cmd.OutOrStdout())
-		enc.SetIndent("", "  ")
-		return enc.Encode(rows)
+	if common.JSONOutput() {
+		return common.WriteJSON(cmd.OutOrStdout(), rows)
 	}
 	for _, row := range rows {
 		switch {
@@ -289,7 +287,7 @@ func shortOID(oid string) string {
 }
 
 func isLocalized(path string) bool {
-	payload, err := os.ReadFile(path)
+	payload, err := os.ReadFile(projectdir.Path(path))
 	if err != nil {
 		return false
 	}
@@ -298,7 +296,7 @@ func isLocalized(path string) bool {
 }
 
 func validateOutputFlags() error {
-	if nameOnly && jsonOutput {
+	if nameOnly && common.JSONOutput() {
 		return fmt.Errorf("--name-only and --json are mutually exclusive")
 	}
 	if showLong && nameOnly {
@@ -317,7 +315,7 @@ var Cmd = &cobra.Command{
 			return err
 		}
 		patterns := append([]string{}, includePatterns...)
-		patterns = append(patterns, args...)
+		patterns = pathspec.Rooted(append(patterns, args...))
 		rows, err := collectRows(cmd, gitRemote, drsRemote, patterns, drsStatus)
 		if err != nil {
 			return err
@@ -332,6 +330,5 @@ func init() {
 	Cmd.Flags().StringArrayVarP(&includePatterns, "include", "I", nil, "include pathspec/glob pattern(s)")
 	Cmd.Flags().BoolVarP(&showLong, "long", "l", false, "show full object IDs")
 	Cmd.Flags().BoolVarP(&nameOnly, "name-only", "n", false, "show only file paths")
-	Cmd.Flags().BoolVar(&jsonOutput, "json", false, "emit JSON output")
 	Cmd.Flags().BoolVar(&drsStatus, "drs", false, "include DRS registration lookup details")
 }
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/calypr/git-drs/internal/common"
 	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/lfs"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
@@ -22,7 +23,7 @@ func resetFlagsForTest() {
 	includePatterns = nil
 	showLong = false
 	nameOnly = false
-	jsonOutput = false
+	common.SetJSONOutput(false)
 	drsStatus = false
 }
 
@@ -355,7 +356,7 @@ func TestValidateOutputFlags(t *testing.T) {
 	resetFlagsForTest()
 
 	nameOnly = true
-	jsonOutput = true
+	common.SetJSONOutput(true)
 	if err := validateOutputFlags(); err == nil {
 		t.Fatal("expected name-only/json conflict")
 	}
@@ -0,0 +1,76 @@
+package mergedriver
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/pathmap"
+	"github.com/spf13/cobra"
+)
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "merge-driver <base> <ours> <theirs> [path]",
+	Short: "git merge driver for .drs/map shards and the audit log",
+	Long: "Description:" +
+		"\n  Merge driver registered by git drs init as merge.drs.driver. It unions the" +
+		"\n  path-to-DRS entries of the three shard versions git passes (%O %A %B %P)," +
+		"\n  writes the result over <ours>, and exits non-zero when the same path was" +
+		"\n  changed incompatibly on both sides. For .drs/audit/log.jsonl it keeps the" +
+		"\n  events both branches appended and re-chains theirs after ours.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 3 || len(args) > 4 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires 3 or 4 arguments (base, ours, theirs, optional path), received %d\n\nUsage: %s\n\nSee 'git drs merge-driver --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[1]
+		if len(args) == 4 {
+			name = args[3]
+		}
+		if audit.IsLogPath(name) {
+			if err := audit.MergeFiles(args[0], args[1], args[2]); err != nil {
+				return fmt.Errorf("merge %s: %w", name, err)
+			}
+			return nil
+		}
+		conflicts, err := mergeFiles(args[0], args[1], args[2])
+		if err != nil {
+			return fmt.Errorf("merge %s: %w", name, err)
+		}
+		if len(conflicts) > 0 {
+			for _, c := range conflicts {
+				fmt.Fprintf(cmd.ErrOrStderr(), "CONFLICT (drs map) %s: %s\n", name, c)
+			}
+			return fmt.Errorf("%d DRS map conflict(s) in %s; resolve the data files, then run 'git drs map rebuild'", len(conflicts), name)
+		}
+		return nil
+	},
+}
+
+// mergeFiles merges the shard versions and writes the result to ours, as git
+// expects of a merge driver.
+func mergeFiles(basePath, oursPath, theirsPath string) ([]pathmap.Conflict, error) {
+	base, err := pathmap.ReadShard(basePath)
+	if err != nil {
+		return nil, err
+	}
+	ours, err := pathmap.ReadShard(oursPath)
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := pathmap.ReadShard(theirsPath)
+	if err != nil {
+		return nil, err
+	}
+	merged, conflicts := pathmap.Merge3(base, ours, theirs)
+	if len(merged) == 0 {
+		// Git reads the result back from ours, so leave an empty object rather
+		// than removing the file.
+		return conflicts, os.WriteFile(oursPath, []byte("{}\n"), 0o644)
+	}
+	return conflicts, pathmap.WriteShard(oursPath, merged)
+}
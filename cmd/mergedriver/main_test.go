@@ -0,0 +1,65 @@
+package mergedriver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/pathmap"
+)
+
+func TestMergeFilesWritesUnionToOurs(t *testing.T) {
+	dir := t.TempDir()
+	a := pathmap.Entry{OID: strings.Repeat("a", 64), DRSID: "id-a"}
+	b := pathmap.Entry{OID: strings.Repeat("b", 64), DRSID: "id-b"}
+
+	base := filepath.Join(dir, "base")
+	ours := filepath.Join(dir, "ours")
+	theirs := filepath.Join(dir, "theirs")
+	if err := os.WriteFile(base, []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := pathmap.WriteShard(ours, pathmap.Map{"a.bin": a}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pathmap.WriteShard(theirs, pathmap.Map{"b.bin": b}); err != nil {
+		t.Fatal(err)
+	}
+
+	conflicts, err := mergeFiles(base, ours, theirs)
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf("mergeFiles = %v, %v", conflicts, err)
+	}
+	merged, err := pathmap.ReadShard(ours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged["a.bin"] != a || merged["b.bin"] != b {
+		t.Fatalf("unexpected merged shard: %#v", merged)
+	}
+}
+
+func TestRunRoutesAuditLogToAuditMerge(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base")
+	ours := filepath.Join(dir, "ours")
+	theirs := filepath.Join(dir, "theirs")
+	if err := os.WriteFile(base, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := audit.Append(ours, audit.Event{Action: audit.ActionRegister, DRSID: "ours"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := audit.Append(theirs, audit.Event{Action: audit.ActionRegister, DRSID: "theirs"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Cmd.RunE(Cmd, []string{base, ours, theirs, ".drs/audit/log.jsonl"}); err != nil {
+		t.Fatalf("merge-driver error: %v", err)
+	}
+	if n, err := audit.Verify(ours); err != nil || n != 2 {
+		t.Fatalf("Verify = %d, %v", n, err)
+	}
+}
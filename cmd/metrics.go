@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+// FlushMetrics reports the metrics recorded while executed ran, as configured
+// by drs.metrics.*. Failures are logged and never change the exit status.
+func FlushMetrics(executed *cobra.Command) {
+	if executed == nil {
+		return
+	}
+	settings := config.MetricsSettings()
+	if !settings.Enabled() {
+		return
+	}
+	command := strings.ReplaceAll(commandName(executed), " ", "-")
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := metrics.Flush(ctx, settings, command, os.Stderr); err != nil {
+		drslog.GetLogger().Warn("metrics report failed", "error", err)
+	}
+}
@@ -0,0 +1,218 @@
+package mount
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/guardrail"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/mountfs"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/webdav"
+)
+
+var (
+	listenAddr string
+	ref        string
+	cacheSize  string
+	blockSize  string
+)
+
+// mountUser is the WebDAV basic auth user; the password is a per-run token.
+const mountUser = "git-drs"
+
+// discoveryFile is written under .git/drs while the server runs.
+const discoveryFile = "mount.json"
+
+type discovery struct {
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Ref      string `json:"ref"`
+	Files    int    `json:"files"`
+	PID      int    `json:"pid"`
+}
+
+var Cmd = &cobra.Command{
+	Use:   "mount [remote-name]",
+	Short: "Serve tracked files as a lazily hydrated read-only WebDAV share",
+	Long: "Serves the LFS files of a ref as a read-only WebDAV share on localhost that any WebDAV client " +
+		"(davfs2, gio, macOS Finder, Windows Explorer) can mount. Opening a file reads only the blocks " +
+		"it needs with ranged reads of signed URLs and keeps them in a local block cache, so large " +
+		"repositories can be browsed without pulling them.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: accepts at most 1 argument (remote name), received %d\n\nUsage: %s\n\nSee 'git drs mount --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := drslog.GetLogger()
+		if err := common.RequireLoopback(listenAddr); err != nil {
+			return err
+		}
+		maxCache, err := guardrail.ParseSize(cacheSize)
+		if err != nil {
+			return fmt.Errorf("--cache-size: %w", err)
+		}
+		block, err := guardrail.ParseSize(blockSize)
+		if err != nil || block <= 0 {
+			return fmt.Errorf("--block-size: invalid size %q", blockSize)
+		}
+		top, err := gitrepo.GitTopLevel()
+		if err != nil {
+			return err
+		}
+		// Object and cache paths are relative to the repository root.
+		if err := os.Chdir(top); err != nil {
+			return err
+		}
+		drsDir, err := gitrepo.DrsTopLevel()
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return err
+		}
+		remoteName := ""
+		if len(args) > 0 {
+			remoteName = args[0]
+		}
+		remote, err := cfg.GetRemoteOrDefault(remoteName)
+		if err != nil {
+			return err
+		}
+		drsCtx, err := cfg.GetRemoteClient(remote, logger)
+		if err != nil {
+			return err
+		}
+
+		files, err := lfs.GetLfsFilesForRefs([]string{ref}, logger)
+		if err != nil {
+			return fmt.Errorf("failed to list LFS files at %s: %w", ref, err)
+		}
+		cache, err := mountfs.OpenBlockCache(filepath.Join(drsDir, "mount-cache"), block, maxCache)
+		if err != nil {
+			return err
+		}
+		fsys := mountfs.New(files, mountfs.Options{
+			LFSObjects: common.LFS_OBJS_PATH,
+			Fetcher:    mountfs.NewRemoteFetcher(drsCtx),
+			Cache:      cache,
+			ModTime:    commitTime(ref),
+		})
+
+		password, err := common.RandomToken()
+		if err != nil {
+			return err
+		}
+		ln, err := net.Listen("tcp", listenAddr)
+		if err != nil {
+			return fmt.Errorf("listen on %s: %w", listenAddr, err)
+		}
+		info := discovery{
+			URL:      "http://" + ln.Addr().String() + "/",
+			Username: mountUser,
+			Password: password,
+			Ref:      ref,
+			Files:    len(files),
+			PID:      os.Getpid(),
+		}
+		discoveryPath := filepath.Join(drsDir, discoveryFile)
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			ln.Close()
+			return err
+		}
+		if err := common.WriteFileAtomic(discoveryPath, append(data, '\n'), 0o600); err != nil {
+			ln.Close()
+			return fmt.Errorf("write %s: %w", discoveryPath, err)
+		}
+		defer os.Remove(discoveryPath)
+
+		if common.JSONOutput() {
+			if err := common.WriteJSON(cmd.OutOrStdout(), info); err != nil {
+				return err
+			}
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Serving %d LFS files from %s at %s (user %s, password in %s)\n", len(files), ref, info.URL, mountUser, discoveryPath)
+			fmt.Fprintf(cmd.OutOrStdout(), "Mount with, for example:\n  sudo mount -t davfs %s /mnt/data\n  gio mount dav://%s@%s/\n", info.URL, mountUser, ln.Addr().String())
+		}
+
+		handler := &webdav.Handler{
+			FileSystem: fsys,
+			LockSystem: webdav.NewMemLS(),
+			Logger: func(r *http.Request, err error) {
+				if err != nil {
+					logger.Debug("mount request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+				}
+			},
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		httpSrv := &http.Server{Handler: basicAuth(password, handler), ReadHeaderTimeout: 10 * time.Second}
+		errCh := make(chan error, 1)
+		go func() { errCh <- httpSrv.Serve(ln) }()
+		select {
+		case err := <-errCh:
+			return err
+		case <-ctx.Done():
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return httpSrv.Shutdown(shutdownCtx)
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVar(&listenAddr, "listen", "127.0.0.1:0", "loopback address to listen on (port 0 picks a free port)")
+	Cmd.Flags().StringVar(&ref, "ref", "HEAD", "commit or branch whose LFS files are served")
+	Cmd.Flags().StringVar(&cacheSize, "cache-size", "20g", "maximum size of the block cache in .git/drs/mount-cache (0 for unlimited)")
+	Cmd.Flags().StringVar(&blockSize, "block-size", "4m", "size of each ranged read and cached block")
+}
+
+// basicAuth requires the per-run password, so other local users cannot read
+// data through the share with this user's credentials.
+func basicAuth(password string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != mountUser || subtle.ConstantTimeCompare([]byte(pass), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git-drs"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// commitTime is the committer time of ref, or now if it cannot be read.
+func commitTime(ref string) time.Time {
+	out, err := exec.Command("git", "show", "-s", "--format=%ct", ref).Output()
+	if err != nil {
+		return time.Now()
+	}
+	secs, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.Unix(secs, 0)
+}
@@ -0,0 +1,43 @@
+package mount
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMountCmdArgs(t *testing.T) {
+	if err := Cmd.Args(Cmd, []string{"origin"}); err != nil {
+		t.Fatalf("unexpected error with one arg: %v", err)
+	}
+	if err := Cmd.Args(Cmd, []string{"origin", "extra"}); err == nil {
+		t.Fatal("expected error for extra args")
+	}
+}
+
+func TestBasicAuthRequiresRunPassword(t *testing.T) {
+	h := basicAuth("pw", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	for _, tc := range []struct {
+		user, pass string
+		want       int
+	}{
+		{mountUser, "pw", http.StatusNoContent},
+		{mountUser, "wrong", http.StatusUnauthorized},
+		{"other", "pw", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest("PROPFIND", "/", nil)
+		req.SetBasicAuth(tc.user, tc.pass)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s/%s: got %d, want %d", tc.user, tc.pass, rec.Code, tc.want)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("expected a basic auth challenge, got %d", rec.Code)
+	}
+}
@@ -6,21 +6,29 @@ import (
 	"log/slog"
 	"strings"
 
+	"github.com/calypr/git-drs/internal/common"
 	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/drslog"
 	"github.com/spf13/cobra"
 )
 
 type statusInfo struct {
-	Remote        config.Remote
-	IsDefault     bool
-	RemoteType    string
-	Endpoint      string
-	Organization  string
-	Project       string
-	Bucket        string
-	StoragePrefix string
-	AuthMode      string
+	Remote        config.Remote `json:"remote"`
+	IsDefault     bool          `json:"default"`
+	RemoteType    string        `json:"type"`
+	Endpoint      string        `json:"endpoint"`
+	Organization  string        `json:"organization,omitempty"`
+	Project       string        `json:"project,omitempty"`
+	Bucket        string        `json:"bucket,omitempty"`
+	StoragePrefix string        `json:"storage_prefix,omitempty"`
+	AuthMode      string        `json:"auth"`
+}
+
+// pingResult is the --json document: the effective setup plus health.
+type pingResult struct {
+	statusInfo
+	Health string `json:"health"`
+	Error  string `json:"error,omitempty"`
 }
 
 var pingHealth = func(ctx context.Context, gc *config.GitContext) error {
@@ -43,6 +51,20 @@ var Cmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if common.JSONOutput() {
+			res := pingResult{statusInfo: status, Health: "ok"}
+			healthErr := pingHealth(cmd.Context(), gc)
+			if healthErr != nil {
+				res.Health, res.Error = "failed", healthErr.Error()
+			}
+			if err := common.WriteJSON(cmd.OutOrStdout(), res); err != nil {
+				return err
+			}
+			if healthErr != nil {
+				return fmt.Errorf("remote health check failed for %q (%s): %w", status.Remote, status.Endpoint, healthErr)
+			}
+			return nil
+		}
 		printStatus(status)
 
 		if err := pingHealth(cmd.Context(), gc); err != nil {
@@ -93,6 +115,10 @@ func resolveStatus(args []string, logger *slog.Logger) (statusInfo, *config.GitC
 		status.RemoteType = string(config.Gen3ServerType)
 	case *config.LocalRemote:
 		status.RemoteType = string(config.LocalServerType)
+	case *config.FilesystemRemote:
+		status.RemoteType = string(config.FilesystemServerType)
+	case *config.AnvilRemote:
+		status.RemoteType = string(config.AnvilServerType)
 	default:
 		status.RemoteType = "unknown"
 	}
@@ -0,0 +1,27 @@
+package precommit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/precommit_cache"
+)
+
+// invalidateOnAttributeChange clears the cache when staged tracking rules
+// differ from those it was built against, so stale path entries do not
+// survive a change to what is LFS-tracked.
+func invalidateOnAttributeChange(ctx context.Context) error {
+	cache, err := precommit_cache.Open(ctx)
+	if err != nil {
+		return err
+	}
+	invalidated, err := cache.EnsureFresh(ctx)
+	if err != nil {
+		return fmt.Errorf("validate pre-commit cache: %w", err)
+	}
+	if invalidated {
+		drslog.GetLogger().Info("pre-commit cache invalidated: tracking attributes changed")
+	}
+	return cache.EnsureLayout()
+}
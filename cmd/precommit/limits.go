@@ -0,0 +1,40 @@
+package precommit
+
+import (
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/guardrail"
+	"github.com/calypr/git-drs/internal/mimetype"
+)
+
+// loadLimits is swapped in tests.
+var loadLimits = config.LimitSettings
+
+// enforceLimits applies the per-file drs.limits.* to the LFS pointers being
+// committed, using the size recorded in each staged pointer. The push total
+// and project quota are checked at push time instead.
+func enforceLimits(staged stagedSet, changes []Change) error {
+	if guardrail.Overridden() {
+		return nil
+	}
+	policy, err := loadLimits()
+	if err != nil {
+		return err
+	}
+	if policy.MaxFileSize <= 0 && len(policy.AllowedExtensions) == 0 && len(policy.AllowedMimeTypes) == 0 {
+		return nil
+	}
+	var files []guardrail.File
+	for _, ch := range changes {
+		if ch.Kind == KindDelete {
+			continue
+		}
+		if f := staged[ch.NewPath]; f.LFSOID != "" {
+			file := guardrail.File{Path: ch.NewPath, Size: f.LFSSize}
+			if len(policy.AllowedMimeTypes) > 0 {
+				file.MimeType = mimetype.ForOID(ch.NewPath, f.LFSOID)
+			}
+			files = append(files, file)
+		}
+	}
+	return policy.CheckFiles(files)
+}
@@ -6,8 +6,9 @@
 //
 // This hook is intentionally:
 //   - LFS-only (non-LFS paths are ignored)
-//   - local-only (no network, no server index reads)
-//   - index-based (reads STAGED content via `git show :<path>`)
+//   - local-only (no network, no server index reads), unless
+//     drs.registration.mode is commit, when it registers the staged files
+//   - index-based (reads STAGED content of the staged paths only, in batches)
 //
 // Note: This is a reference implementation. Adjust logging/policy as desired.
 package precommit
@@ -26,16 +27,15 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/calypr/git-drs/internal/common"
 	"github.com/spf13/cobra"
 )
 
 const (
 	cacheVersionDir                     = "drs/pre-commit/v1"
-	lfsSpecLine                         = "version https://git-lfs.github.com/spec/v1"
 	defaultDirectCommitWarningThreshold = int64(10 * 1024 * 1024)
 )
 
@@ -119,6 +119,10 @@ func run(ctx context.Context) error {
 	}
 	_ = os.MkdirAll(tombsDir, 0o755) // optional
 
+	if err := invalidateOnAttributeChange(ctx); err != nil {
+		return err
+	}
+
 	changes, err := stagedChanges(ctx)
 	if err != nil {
 		return err
@@ -126,10 +130,14 @@ func run(ctx context.Context) error {
 	if len(changes) == 0 {
 		return nil
 	}
-	oversized, err := collectOversizedPlainGitStagedFiles(ctx, changes, directCommitWarningThresholdBytes)
+	staged, err := readStaged(ctx, changes)
 	if err != nil {
 		return err
 	}
+	if err := enforceLimits(staged, changes); err != nil {
+		return err
+	}
+	oversized := collectOversizedPlainGitStagedFiles(staged, changes, directCommitWarningThresholdBytes)
 	if len(oversized) > 0 {
 		allowed, err := confirmOversizedDirectGitCommit(oversized)
 		if err != nil {
@@ -151,11 +159,11 @@ func run(ctx context.Context) error {
 		// Only act if BOTH old and new are LFS in scope? Prefer:
 		// - If the new path is LFS, we migrate.
 		// - If it isn't LFS, we remove old path entry (out of scope).
-		newOID, newIsLFS, err := stagedLFSOID(ctx, ch.NewPath)
-		if err != nil {
+		if _, ok := staged[ch.NewPath]; !ok {
 			// If file doesn't exist in index due to weird staging, skip.
 			continue
 		}
+		newOID, newIsLFS := staged.lfsOID(ch.NewPath)
 
 		oldPathFile := pathEntryFile(pathsDir, ch.OldPath)
 		newPathFile := pathEntryFile(pathsDir, ch.NewPath)
@@ -189,12 +197,12 @@ func run(ctx context.Context) error {
 	for _, ch := range changes {
 		switch ch.Kind {
 		case KindAdd, KindModify:
-			if err := handleUpsert(ctx, pathsDir, oidsDir, ch.NewPath, now); err != nil {
+			if err := handleUpsert(staged, pathsDir, oidsDir, ch.NewPath, now); err != nil {
 				return err
 			}
 		case KindRename:
 			// Treat like upsert on NewPath to ensure OID/path consistency if content also changed.
-			if err := handleUpsert(ctx, pathsDir, oidsDir, ch.NewPath, now); err != nil {
+			if err := handleUpsert(staged, pathsDir, oidsDir, ch.NewPath, now); err != nil {
 				return err
 			}
 			// Optionally also remove old path from *other* OID entry if rename+content-change changed OID.
@@ -206,18 +214,21 @@ func run(ctx context.Context) error {
 		}
 	}
 
-	return nil
+	if err := updateRepoMap(ctx, staged, changes); err != nil {
+		return err
+	}
+	return registerStaged(ctx, staged, changes)
 }
 
-func handleUpsert(ctx context.Context, pathsDir, oidsDir, path, now string) error {
-	oid, isLFS, err := stagedLFSOID(ctx, path)
-	if err != nil {
+func handleUpsert(staged stagedSet, pathsDir, oidsDir, path, now string) error {
+	if _, ok := staged[path]; !ok {
 		// If file isn't in index, ignore.
 		return nil
 	}
+	oid, isLFS := staged.lfsOID(path)
 	if !isLFS {
-		// Out of scope.
-		return nil
+		// Out of scope; drop any entry left from when the path was LFS.
+		return dropPathEntry(pathsDir, oidsDir, path, now)
 	}
 
 	pathFile := pathEntryFile(pathsDir, path)
@@ -255,6 +266,24 @@ func handleUpsert(ctx context.Context, pathsDir, oidsDir, path, now string) erro
 	return nil
 }
 
+// dropPathEntry removes a path entry and its reference from the oid entry.
+func dropPathEntry(pathsDir, oidsDir, path, now string) error {
+	pathFile := pathEntryFile(pathsDir, path)
+	b, err := os.ReadFile(pathFile)
+	if err != nil {
+		return nil
+	}
+	var pe PathEntry
+	_ = json.Unmarshal(b, &pe)
+	if err := os.Remove(pathFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if pe.LFSOID != "" {
+		_ = oidRemovePath(oidsDir, pe.LFSOID, path, now)
+	}
+	return nil
+}
+
 func handleDelete(ctx context.Context, pathsDir, oidsDir, tombsDir, path, now string) error {
 	// Only consider deletion if it was previously an LFS entry (cache-driven).
 	pathFile := pathEntryFile(pathsDir, path)
@@ -330,65 +359,9 @@ func stagedChanges(ctx context.Context) ([]Change, error) {
 	return changes, nil
 }
 
-// stagedLFSOID returns (oid, isLFS, err) based on STAGED content.
-// isLFS is true only if the staged file is a valid LFS pointer with an oid sha256 line.
-func stagedLFSOID(ctx context.Context, path string) (string, bool, error) {
-	out, err := git(ctx, "show", ":"+path)
-	if err != nil {
-		// path may not exist in index (deleted/intent-to-add weirdness)
-		return "", false, err
-	}
-
-	// Fast parse: look for spec line and oid line near top.
-	// LFS pointer files are small; scanning full content is fine.
-	var hasSpec bool
-	var oid string
-
-	sc := bufio.NewScanner(bytes.NewReader(out))
-	for sc.Scan() {
-		line := sc.Text()
-		if line == lfsSpecLine {
-			hasSpec = true
-			continue
-		}
-		if strings.HasPrefix(line, "oid sha256:") {
-			hex := strings.TrimPrefix(line, "oid sha256:")
-			hex = strings.TrimSpace(hex)
-			if hex != "" {
-				oid = "sha256:" + hex
-			}
-			// keep scanning a bit in case spec is below (rare), but we can break once both are found.
-		}
-		// pointer usually has only a few lines; stop early after 10 lines
-		if hasSpec && oid != "" {
-			break
-		}
-	}
-	if err := sc.Err(); err != nil {
-		return "", false, err
-	}
-
-	if hasSpec && oid != "" {
-		return oid, true, nil
-	}
-	return "", false, nil
-}
-
-func stagedBlobSize(ctx context.Context, path string) (int64, error) {
-	out, err := git(ctx, "cat-file", "-s", ":"+path)
-	if err != nil {
-		return 0, err
-	}
-	size, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
-	if err != nil {
-		return 0, fmt.Errorf("parse staged blob size for %s: %w", path, err)
-	}
-	return size, nil
-}
-
-func collectOversizedPlainGitStagedFiles(ctx context.Context, changes []Change, thresholdBytes int64) ([]OversizedStagedFile, error) {
+func collectOversizedPlainGitStagedFiles(staged stagedSet, changes []Change, thresholdBytes int64) []OversizedStagedFile {
 	if thresholdBytes <= 0 {
-		return nil, nil
+		return nil
 	}
 	var oversized []OversizedStagedFile
 	seen := make(map[string]struct{})
@@ -405,25 +378,14 @@ func collectOversizedPlainGitStagedFiles(ctx context.Context, changes []Change,
 		}
 		seen[path] = struct{}{}
 
-		_, isLFS, err := stagedLFSOID(ctx, path)
-		if err != nil {
-			continue
-		}
-		if isLFS {
-			continue
-		}
-
-		size, err := stagedBlobSize(ctx, path)
-		if err != nil {
-			return nil, err
-		}
-		if size <= thresholdBytes {
+		f, ok := staged[path]
+		if !ok || f.LFSOID != "" || f.Size <= thresholdBytes {
 			continue
 		}
-		oversized = append(oversized, OversizedStagedFile{Path: path, Size: size})
+		oversized = append(oversized, OversizedStagedFile{Path: path, Size: f.Size})
 	}
 	sort.Slice(oversized, func(i, j int) bool { return oversized[i].Path < oversized[j].Path })
-	return oversized, nil
+	return oversized
 }
 
 func promptOversizedDirectGitCommit(files []OversizedStagedFile) (bool, error) {
@@ -592,36 +554,14 @@ func keysSorted(m map[string]struct{}) []string {
 	return out
 }
 
-// writeJSONAtomic writes JSON to a temp file then renames it into place.
-// This avoids partially written cache files if the process is interrupted.
+// writeJSONAtomic writes JSON with common.WriteFileAtomic. This avoids
+// partially written cache files if the process is interrupted.
 func writeJSONAtomic(path string, v any) error {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return err
-	}
-
-	tmp := path + ".tmp"
-	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	data, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		return err
 	}
-	defer func() { _ = f.Close() }()
-
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(v); err != nil {
-		_ = os.Remove(tmp)
-		return err
-	}
-	if err := f.Sync(); err != nil {
-		_ = os.Remove(tmp)
-		return err
-	}
-	if err := f.Close(); err != nil {
-		_ = os.Remove(tmp)
-		return err
-	}
-	return os.Rename(tmp, path)
+	return common.WriteFileAtomic(path, append(data, '\n'), 0o644)
 }
 
 func moveFileBestEffort(src, dst string) error {
@@ -3,12 +3,22 @@ package precommit
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/guardrail"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pathmap"
+	"github.com/calypr/git-drs/internal/registerpolicy"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
 )
 
 func TestHandleUpsertIgnoresNonLFSFile(t *testing.T) {
@@ -36,7 +46,7 @@ func TestHandleUpsertIgnoresNonLFSFile(t *testing.T) {
 	}
 
 	now := time.Now().UTC().Format(time.RFC3339)
-	if err := handleUpsert(context.Background(), pathsDir, oidsDir, "data/file.txt", now); err != nil {
+	if err := handleUpsert(stagedFor(t), pathsDir, oidsDir, "data/file.txt", now); err != nil {
 		t.Fatalf("handleUpsert: %v", err)
 	}
 
@@ -51,13 +61,15 @@ func TestHandleUpsertWritesLFSPointerCache(t *testing.T) {
 	oldwd := mustChdir(t, repo)
 	t.Cleanup(func() { _ = os.Chdir(oldwd) })
 
+	trackLFS(t, repo, "*.bin")
 	path := filepath.Join(repo, "data", "file.bin")
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		t.Fatalf("mkdir: %v", err)
 	}
+	oid := "sha256:" + strings.Repeat("de", 32)
 	lfsPointer := strings.Join([]string{
 		"version https://git-lfs.github.com/spec/v1",
-		"oid sha256:deadbeef",
+		"oid " + oid,
 		"size 12",
 		"",
 	}, "\n")
@@ -77,7 +89,7 @@ func TestHandleUpsertWritesLFSPointerCache(t *testing.T) {
 	}
 
 	now := time.Now().UTC().Format(time.RFC3339)
-	if err := handleUpsert(context.Background(), pathsDir, oidsDir, "data/file.bin", now); err != nil {
+	if err := handleUpsert(stagedFor(t), pathsDir, oidsDir, "data/file.bin", now); err != nil {
 		t.Fatalf("handleUpsert: %v", err)
 	}
 
@@ -93,11 +105,11 @@ func TestHandleUpsertWritesLFSPointerCache(t *testing.T) {
 	if pathCache.Path != "data/file.bin" {
 		t.Fatalf("expected path entry to be data/file.bin, got %q", pathCache.Path)
 	}
-	if pathCache.LFSOID != "sha256:deadbeef" {
-		t.Fatalf("expected lfs oid sha256:deadbeef, got %q", pathCache.LFSOID)
+	if pathCache.LFSOID != oid {
+		t.Fatalf("expected lfs oid %s, got %q", oid, pathCache.LFSOID)
 	}
 
-	oidEntry := oidEntryFile(oidsDir, "sha256:deadbeef")
+	oidEntry := oidEntryFile(oidsDir, oid)
 	oidData, err := os.ReadFile(oidEntry)
 	if err != nil {
 		t.Fatalf("read oid entry: %v", err)
@@ -106,8 +118,8 @@ func TestHandleUpsertWritesLFSPointerCache(t *testing.T) {
 	if err := json.Unmarshal(oidData, &oidCache); err != nil {
 		t.Fatalf("unmarshal oid entry: %v", err)
 	}
-	if oidCache.LFSOID != "sha256:deadbeef" {
-		t.Fatalf("expected oid entry sha256:deadbeef, got %q", oidCache.LFSOID)
+	if oidCache.LFSOID != oid {
+		t.Fatalf("expected oid entry %s, got %q", oid, oidCache.LFSOID)
 	}
 	if len(oidCache.Paths) != 1 || oidCache.Paths[0] != "data/file.bin" {
 		t.Fatalf("expected oid paths to include data/file.bin, got %v", oidCache.Paths)
@@ -119,6 +131,7 @@ func TestCollectOversizedPlainGitStagedFiles(t *testing.T) {
 	oldwd := mustChdir(t, repo)
 	t.Cleanup(func() { _ = os.Chdir(oldwd) })
 
+	trackLFS(t, repo, "*.bin")
 	plainPath := filepath.Join(repo, "data", "large.bin")
 	if err := os.MkdirAll(filepath.Dir(plainPath), 0o755); err != nil {
 		t.Fatalf("mkdir: %v", err)
@@ -131,7 +144,7 @@ func TestCollectOversizedPlainGitStagedFiles(t *testing.T) {
 	pointerPath := filepath.Join(repo, "data", "pointer.bin")
 	lfsPointer := strings.Join([]string{
 		"version https://git-lfs.github.com/spec/v1",
-		"oid sha256:deadbeef",
+		"oid sha256:" + strings.Repeat("de", 32),
 		"size 999",
 		"",
 	}, "\n")
@@ -144,10 +157,7 @@ func TestCollectOversizedPlainGitStagedFiles(t *testing.T) {
 	if err != nil {
 		t.Fatalf("stagedChanges: %v", err)
 	}
-	files, err := collectOversizedPlainGitStagedFiles(context.Background(), changes, 1)
-	if err != nil {
-		t.Fatalf("collectOversizedPlainGitStagedFiles: %v", err)
-	}
+	files := collectOversizedPlainGitStagedFiles(stagedFor(t), changes, 1)
 	if len(files) != 1 {
 		t.Fatalf("expected 1 oversized plain file, got %d: %+v", len(files), files)
 	}
@@ -202,6 +212,32 @@ func setupGitRepo(t *testing.T) string {
 	return dir
 }
 
+// trackLFS routes patterns through the LFS filter, as git lfs track would.
+func trackLFS(t *testing.T, repo string, patterns ...string) {
+	t.Helper()
+	var b strings.Builder
+	for _, p := range patterns {
+		b.WriteString(p + " filter=lfs diff=lfs merge=lfs -text\n")
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".gitattributes"), []byte(b.String()), 0o644); err != nil {
+		t.Fatalf("write .gitattributes: %v", err)
+	}
+}
+
+// stagedFor classifies the changes staged in the current repository.
+func stagedFor(t *testing.T) stagedSet {
+	t.Helper()
+	changes, err := stagedChanges(context.Background())
+	if err != nil {
+		t.Fatalf("stagedChanges: %v", err)
+	}
+	staged, err := readStaged(context.Background(), changes)
+	if err != nil {
+		t.Fatalf("readStaged: %v", err)
+	}
+	return staged
+}
+
 func mustChdir(t *testing.T, dir string) string {
 	t.Helper()
 	old, err := os.Getwd()
@@ -223,3 +259,301 @@ func gitCmd(t *testing.T, dir string, args ...string) {
 		t.Fatalf("git %s failed: %v (%s)", strings.Join(args, " "), err, string(out))
 	}
 }
+
+func TestRunUpdatesCommittedRepoMap(t *testing.T) {
+	repo := setupGitRepo(t)
+	oldwd := mustChdir(t, repo)
+	t.Cleanup(func() { _ = os.Chdir(oldwd) })
+
+	origLoad := loadMapConfig
+	t.Cleanup(func() { loadMapConfig = origLoad })
+	loadMapConfig = func() (*config.Config, error) {
+		return &config.Config{
+			DefaultRemote: config.Remote("origin"),
+			Remotes: map[config.Remote]config.RemoteSelect{
+				"origin": {Gen3: &config.Gen3Remote{ProjectID: "proj"}},
+			},
+		}, nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(repo, pathmap.Dir), 0o755); err != nil {
+		t.Fatalf("mkdir map: %v", err)
+	}
+	trackLFS(t, repo, "*.bin")
+	oid := strings.Repeat("ab", 32)
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 4\n"
+	if err := os.WriteFile(filepath.Join(repo, "file.bin"), []byte(pointer), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	gitCmd(t, repo, "add", "file.bin")
+
+	if err := run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	entry, ok, err := pathmap.Lookup(repo, "file.bin")
+	if err != nil || !ok {
+		t.Fatalf("expected map entry, ok=%v err=%v", ok, err)
+	}
+	if entry.OID != oid || entry.Remote != "origin" || entry.DRSID != drsobject.DeterministicID("proj", oid) {
+		t.Fatalf("unexpected entry: %#v", entry)
+	}
+	out, err := exec.Command("git", "-C", repo, "diff", "--cached", "--name-only").Output()
+	if err != nil {
+		t.Fatalf("git diff: %v", err)
+	}
+	if !strings.Contains(string(out), pathmap.Dir+"/"+pathmap.ShardName("file.bin")) {
+		t.Fatalf("expected map shard staged, got %q", out)
+	}
+}
+
+func TestRunLeavesExcludedPathsOutOfRepoMap(t *testing.T) {
+	repo := setupGitRepo(t)
+	oldwd := mustChdir(t, repo)
+	t.Cleanup(func() { _ = os.Chdir(oldwd) })
+
+	origLoad, origRegister := loadMapConfig, loadRegister
+	t.Cleanup(func() { loadMapConfig, loadRegister = origLoad, origRegister })
+	loadMapConfig = func() (*config.Config, error) {
+		return &config.Config{
+			DefaultRemote: config.Remote("origin"),
+			Remotes: map[config.Remote]config.RemoteSelect{
+				"origin": {Gen3: &config.Gen3Remote{ProjectID: "proj"}},
+			},
+		}, nil
+	}
+	loadRegister = func() (registerpolicy.Policy, error) {
+		return registerpolicy.Policy{Exclude: []string{"*.tmp"}}, nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(repo, pathmap.Dir), 0o755); err != nil {
+		t.Fatalf("mkdir map: %v", err)
+	}
+	trackLFS(t, repo, "*.bin", "*.tmp")
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:" + strings.Repeat("cd", 32) + "\nsize 4\n"
+	for _, name := range []string{"keep.bin", "scratch.tmp"} {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(pointer), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	gitCmd(t, repo, "add", "keep.bin", "scratch.tmp")
+
+	if err := run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if _, ok, err := pathmap.Lookup(repo, "keep.bin"); err != nil || !ok {
+		t.Fatalf("expected map entry for keep.bin, ok=%v err=%v", ok, err)
+	}
+	if _, ok, err := pathmap.Lookup(repo, "scratch.tmp"); err != nil || ok {
+		t.Fatalf("expected no map entry for excluded path, ok=%v err=%v", ok, err)
+	}
+}
+
+func TestEnforceLimitsRejectsOversizedPointer(t *testing.T) {
+	repo := setupGitRepo(t)
+	oldwd := mustChdir(t, repo)
+	t.Cleanup(func() { _ = os.Chdir(oldwd) })
+
+	origLoad := loadLimits
+	t.Cleanup(func() { loadLimits = origLoad })
+	loadLimits = func() (guardrail.Policy, error) {
+		return guardrail.Policy{MaxFileSize: 1 << 30}, nil
+	}
+
+	pointer := func(size string) []byte {
+		return []byte(strings.Join([]string{
+			"version https://git-lfs.github.com/spec/v1",
+			"oid sha256:" + strings.Repeat("ab", 32),
+			"size " + size,
+			"",
+		}, "\n"))
+	}
+	trackLFS(t, repo, "*.bam", "*.tar")
+	if err := os.WriteFile(filepath.Join(repo, "small.bam"), pointer("1024"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "scratch.tar"), pointer("2199023255552"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	gitCmd(t, repo, "add", "small.bam", "scratch.tar")
+
+	changes, err := stagedChanges(context.Background())
+	if err != nil {
+		t.Fatalf("stagedChanges: %v", err)
+	}
+	staged := stagedFor(t)
+	err = enforceLimits(staged, changes)
+	if err == nil || !strings.Contains(err.Error(), "scratch.tar (2.0 TiB)") || strings.Contains(err.Error(), "small.bam") {
+		t.Fatalf("enforceLimits error = %v, want only scratch.tar rejected", err)
+	}
+
+	t.Setenv(guardrail.OverrideEnv, "true")
+	if err := enforceLimits(staged, changes); err != nil {
+		t.Fatalf("override did not skip limits: %v", err)
+	}
+}
+
+func TestEnforceLimitsRejectsDisallowedMimeType(t *testing.T) {
+	repo := setupGitRepo(t)
+	oldwd := mustChdir(t, repo)
+	t.Cleanup(func() { _ = os.Chdir(oldwd) })
+
+	origLoad := loadLimits
+	t.Cleanup(func() { loadLimits = origLoad })
+	loadLimits = func() (guardrail.Policy, error) {
+		return guardrail.Policy{AllowedMimeTypes: []string{"image/*"}}, nil
+	}
+
+	oid := strings.Repeat("cd", 32)
+	mimeType := "application/x-tar"
+	if err := drsobject.WriteObject(common.DRS_OBJS_PATH, &drsapi.DrsObject{Id: "x", MimeType: &mimeType}, oid); err != nil {
+		t.Fatalf("WriteObject: %v", err)
+	}
+	trackLFS(t, repo, "*.tar")
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 10\n"
+	if err := os.WriteFile(filepath.Join(repo, "scratch.tar"), []byte(pointer), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	gitCmd(t, repo, "add", "scratch.tar")
+
+	changes, err := stagedChanges(context.Background())
+	if err != nil {
+		t.Fatalf("stagedChanges: %v", err)
+	}
+	err = enforceLimits(stagedFor(t), changes)
+	if err == nil || !strings.Contains(err.Error(), "scratch.tar (10 B): MIME type application/x-tar not in drs.limits.allowed-mime-types") {
+		t.Fatalf("enforceLimits error = %v, want scratch.tar rejected by type", err)
+	}
+}
+
+func TestRunCachesOnlyTrackedPointers(t *testing.T) {
+	repo := setupGitRepo(t)
+	oldwd := mustChdir(t, repo)
+	t.Cleanup(func() { _ = os.Chdir(oldwd) })
+
+	trackLFS(t, repo, "*.bin")
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:" + strings.Repeat("ef", 32) + "\nsize 4\n"
+	for _, name := range []string{"tracked.bin", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(pointer), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	gitCmd(t, repo, "add", "tracked.bin", "notes.txt")
+
+	if err := run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	pathsDir := filepath.Join(repo, ".git", cacheVersionDir, "paths")
+	if _, err := os.Stat(pathEntryFile(pathsDir, "tracked.bin")); err != nil {
+		t.Fatalf("expected cache entry for tracked pointer: %v", err)
+	}
+	if _, err := os.Stat(pathEntryFile(pathsDir, "notes.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected no cache entry for pointer outside tracked patterns, err=%v", err)
+	}
+}
+
+// BenchmarkRunScalesWithCommit commits one new file into repositories of
+// growing size. The hook only looks at staged paths, so what growth remains
+// comes from git reading the index, not from the tracked files themselves.
+func BenchmarkRunScalesWithCommit(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("tracked=%d", size), func(b *testing.B) {
+			repo := b.TempDir()
+			for _, args := range [][]string{
+				{"init", "-q"},
+				{"config", "user.email", "test@example.com"},
+				{"config", "user.name", "Test User"},
+			} {
+				benchGit(b, repo, args...)
+			}
+			attrs := "*.bin filter=lfs diff=lfs merge=lfs -text\n"
+			if err := os.WriteFile(filepath.Join(repo, ".gitattributes"), []byte(attrs), 0o644); err != nil {
+				b.Fatal(err)
+			}
+			pointer := func(i int) []byte {
+				return fmt.Appendf(nil, "version https://git-lfs.github.com/spec/v1\noid sha256:%064x\nsize %d\n", i, i+1)
+			}
+			for i := range size {
+				dir := filepath.Join(repo, "data", fmt.Sprintf("%03d", i%100))
+				if err := os.MkdirAll(dir, 0o755); err != nil {
+					b.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.bin", i)), pointer(i), 0o644); err != nil {
+					b.Fatal(err)
+				}
+			}
+			benchGit(b, repo, "add", ".")
+			benchGit(b, repo, "commit", "-q", "--no-verify", "-m", "seed")
+			if err := os.WriteFile(filepath.Join(repo, "new.bin"), pointer(size), 0o644); err != nil {
+				b.Fatal(err)
+			}
+			benchGit(b, repo, "add", "new.bin")
+			b.Chdir(repo)
+
+			for b.Loop() {
+				if err := run(context.Background()); err != nil {
+					b.Fatalf("run: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func benchGit(b *testing.B, dir string, args ...string) {
+	b.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		b.Fatalf("git %s failed: %v (%s)", strings.Join(args, " "), err, out)
+	}
+}
+
+func TestRunRegistersStagedFilesInCommitMode(t *testing.T) {
+	repo := setupGitRepo(t)
+	oldwd := mustChdir(t, repo)
+	t.Cleanup(func() { _ = os.Chdir(oldwd) })
+
+	origMode, origRegisterAt, origRegister := loadRegistrationMode, registerAtCommit, loadRegister
+	t.Cleanup(func() { loadRegistrationMode, registerAtCommit, loadRegister = origMode, origRegisterAt, origRegister })
+	loadRegister = func() (registerpolicy.Policy, error) {
+		return registerpolicy.Policy{Exclude: []string{"*.tmp"}}, nil
+	}
+	mode := config.RegisterAtPush
+	loadRegistrationMode = func() (config.RegistrationMode, error) { return mode, nil }
+	var got map[string]lfs.LfsFileInfo
+	registerAtCommit = func(_ context.Context, files map[string]lfs.LfsFileInfo) (int, error) {
+		got = files
+		return 0, nil
+	}
+
+	trackLFS(t, repo, "*.bin", "*.tmp")
+	oid := strings.Repeat("ef", 32)
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 4\n"
+	for _, name := range []string{"a.bin", "b.bin", "scratch.tmp"} {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(pointer), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	gitCmd(t, repo, "add", "a.bin", "b.bin", "scratch.tmp")
+
+	if err := run(context.Background()); err != nil || got != nil {
+		t.Fatalf("push mode: run = %v, registered %v; want nothing registered", err, got)
+	}
+
+	mode = config.RegisterAtCommit
+	if err := run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	// Identical files are registered at each of their paths.
+	if len(got) != 2 || got["a.bin"].Oid != oid || got["b.bin"].Oid != oid || got["a.bin"].Size != 4 {
+		t.Fatalf("registered %+v; want a.bin and b.bin without the excluded path", got)
+	}
+
+	registerAtCommit = func(context.Context, map[string]lfs.LfsFileInfo) (int, error) {
+		return 0, fmt.Errorf("401 unauthorized")
+	}
+	if err := run(context.Background()); err == nil || !strings.Contains(err.Error(), "drs.registration.mode=commit") {
+		t.Fatalf("run = %v; want the registration error to block the commit", err)
+	}
+}
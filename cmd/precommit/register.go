@@ -0,0 +1,81 @@
+package precommit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pushsync"
+)
+
+// Swapped in tests.
+var (
+	loadRegistrationMode = config.RegistrationSettings
+	registerAtCommit     = func(ctx context.Context, files map[string]lfs.LfsFileInfo) (int, error) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return 0, err
+		}
+		remote, err := cfg.GetDefaultRemote()
+		if err != nil {
+			if errors.Is(err, config.ErrNoDefaultRemote) {
+				return 0, nil
+			}
+			return 0, err
+		}
+		cl, err := cfg.GetRemoteClient(remote, drslog.GetLogger())
+		if err != nil {
+			return 0, err
+		}
+		if cl.ReadOnly || cl.Anonymous {
+			// Nothing is registered on a read-only remote.
+			return 0, nil
+		}
+		return pushsync.RegisterAtCommit(cl, ctx, files)
+	}
+)
+
+// registerStaged registers the staged LFS files with the default remote when
+// drs.registration.mode is commit; push registers them otherwise. Paths
+// excluded by drs.register.* are skipped. Files that cannot be registered
+// while offline are queued rather than blocking the commit.
+func registerStaged(ctx context.Context, staged stagedSet, changes []Change) error {
+	mode, err := loadRegistrationMode()
+	if err != nil || mode != config.RegisterAtCommit {
+		return err
+	}
+	policy, err := loadRegister()
+	if err != nil {
+		return err
+	}
+	files := map[string]lfs.LfsFileInfo{}
+	for _, ch := range changes {
+		if ch.Kind == KindDelete {
+			continue
+		}
+		f := staged[ch.NewPath]
+		if f.LFSOID == "" || !policy.Registers(ch.NewPath) {
+			continue
+		}
+		files[ch.NewPath] = lfs.LfsFileInfo{
+			Name:      ch.NewPath,
+			Size:      f.LFSSize,
+			IsPointer: true,
+			OidType:   "sha256",
+			Oid:       strings.TrimPrefix(f.LFSOID, "sha256:"),
+		}
+	}
+	queued, err := registerAtCommit(ctx, files)
+	if err != nil {
+		return fmt.Errorf("registering committed files (drs.registration.mode=commit): %w", err)
+	}
+	if queued > 0 {
+		fmt.Fprintf(os.Stderr, "git-drs: remote unreachable; %d files queued for registration by the next commit, push or 'git drs flush'\n", queued)
+	}
+	return nil
+}
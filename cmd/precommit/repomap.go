@@ -0,0 +1,83 @@
+package precommit
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/pathmap"
+)
+
+// Swapped in tests.
+var (
+	loadMapConfig = config.LoadConfig
+	loadRegister  = config.RegisterSettings
+)
+
+// updateRepoMap keeps the committed .drs/map shards in step with the staged
+// LFS changes and stages the rewritten shards into the same commit. It is a
+// no-op until the repository opts in by creating the map (git drs map rebuild).
+// Paths excluded by drs.register.* never get a DRS record, so they are kept
+// out of the map.
+func updateRepoMap(ctx context.Context, staged stagedSet, changes []Change) error {
+	out, err := git(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return err
+	}
+	root := strings.TrimSpace(string(out))
+	if !pathmap.Enabled(root) {
+		return nil
+	}
+
+	cfg, err := loadMapConfig()
+	if err != nil {
+		return err
+	}
+	remoteName, err := cfg.GetDefaultRemote()
+	if err != nil {
+		if errors.Is(err, config.ErrNoDefaultRemote) {
+			return nil
+		}
+		return err
+	}
+	drsRemote := cfg.GetRemote(remoteName)
+	if drsRemote == nil {
+		return nil
+	}
+	policy, err := loadRegister()
+	if err != nil {
+		return err
+	}
+	ids, err := config.LoadIDMinter(string(remoteName))
+	if err != nil {
+		return err
+	}
+
+	upserts := pathmap.Map{}
+	var deletes []string
+	for _, ch := range changes {
+		switch ch.Kind {
+		case KindRename:
+			deletes = append(deletes, ch.OldPath)
+		case KindDelete:
+			deletes = append(deletes, ch.NewPath)
+			continue
+		}
+		oid, isLFS := staged.lfsOID(ch.NewPath)
+		if !isLFS || !policy.Registers(ch.NewPath) {
+			deletes = append(deletes, ch.NewPath)
+			continue
+		}
+		upserts[ch.NewPath] = pathmap.NewEntry(string(remoteName), ids, config.LocalProjectIDForPath(drsRemote, ch.NewPath), ch.NewPath, oid)
+	}
+	if len(upserts) == 0 && len(deletes) == 0 {
+		return nil
+	}
+
+	if _, err := pathmap.Update(root, upserts, deletes); err != nil {
+		return err
+	}
+	_, err = git(ctx, "-C", root, "add", "-A", "--", pathmap.Dir)
+	return err
+}
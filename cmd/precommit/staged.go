@@ -0,0 +1,75 @@
+package precommit
+
+import (
+	"context"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+// stagedFile is what the hook knows about one staged path.
+type stagedFile struct {
+	Size    int64  // size of the staged blob
+	LFSOID  string // "sha256:<hex>" when the path is tracked and staged as a pointer
+	LFSSize int64  // object size recorded in that pointer
+}
+
+// stagedSet holds the staged paths of a commit, keyed by repo-relative path.
+// Paths missing from the index are absent.
+type stagedSet map[string]stagedFile
+
+// lfsOID reports the pointer oid of path when it is in scope for git-drs.
+func (s stagedSet) lfsOID(path string) (string, bool) {
+	f := s[path]
+	return f.LFSOID, f.LFSOID != ""
+}
+
+// readStaged classifies the paths added, modified or renamed by changes.
+// Only those paths are examined, tracking rules are checked for all of
+// them in one git check-attr call, and their staged blobs are read in
+// batches, so the cost follows the size of the commit rather than of the
+// repository.
+func readStaged(ctx context.Context, changes []Change) (stagedSet, error) {
+	var paths []string
+	seen := make(map[string]struct{})
+	for _, ch := range changes {
+		if ch.Kind == KindDelete || ch.NewPath == "" {
+			continue
+		}
+		if _, ok := seen[ch.NewPath]; ok {
+			continue
+		}
+		seen[ch.NewPath] = struct{}{}
+		paths = append(paths, ch.NewPath)
+	}
+	staged := stagedSet{}
+	if len(paths) == 0 {
+		return staged, nil
+	}
+
+	out, err := git(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(string(out))
+	tracked, err := lfs.FilterTrackedPaths(ctx, root, paths)
+	if err != nil {
+		return nil, err
+	}
+	isTracked := make(map[string]bool, len(tracked))
+	for _, p := range tracked {
+		isTracked[p] = true
+	}
+	blobs, err := lfs.ReadStagedBlobs(ctx, root, paths)
+	if err != nil {
+		return nil, err
+	}
+	for path, blob := range blobs {
+		f := stagedFile{Size: blob.Size}
+		if blob.IsPointer && isTracked[path] {
+			f.LFSOID, f.LFSSize = "sha256:"+blob.Oid, blob.ObjectSize
+		}
+		staged[path] = f
+	}
+	return staged, nil
+}
@@ -22,8 +22,13 @@ import (
 	"github.com/calypr/git-drs/internal/drsmap"
 	"github.com/calypr/git-drs/internal/drsobject"
 	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/guardrail"
+	"github.com/calypr/git-drs/internal/httpclient"
 	"github.com/calypr/git-drs/internal/lfs"
 	"github.com/calypr/git-drs/internal/precommit_cache"
+	"github.com/calypr/git-drs/internal/projectdir"
+	"github.com/calypr/git-drs/internal/pushsync"
+	"github.com/calypr/git-drs/internal/registerpolicy"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	"github.com/spf13/cobra"
 )
@@ -44,6 +49,10 @@ type PrePushService struct {
 	loadConfig      func() (*config.Config, error)
 	writeDrsObjects func(drsobject.Builder, map[string]lfs.LfsFileInfo, drsmap.WriteOptions) error
 	createTempFile  func(dir, pattern string) (*os.File, error)
+	loadLimits      func() (guardrail.Policy, error)
+	checkLimits     func(*config.GitContext, context.Context, map[string]lfs.LfsFileInfo, guardrail.Policy) (string, error)
+	scanObjects     func(string) (drsobject.ScanReport, error)
+	loadRegister    func() (registerpolicy.Policy, error)
 }
 
 func NewPrePushService() *PrePushService {
@@ -52,6 +61,10 @@ func NewPrePushService() *PrePushService {
 		loadConfig:      config.LoadConfig,
 		writeDrsObjects: drsmap.WriteObjectsForLFSFiles,
 		createTempFile:  os.CreateTemp,
+		loadLimits:      config.LimitSettings,
+		checkLimits:     pushsync.CheckLimits,
+		scanObjects:     drsobject.ScanObjects,
+		loadRegister:    config.RegisterSettings,
 	}
 }
 
@@ -66,7 +79,7 @@ func (s *PrePushService) Run(args []string, stdin io.Reader) error {
 
 	cfg, err := s.loadConfig()
 	if err != nil {
-		return fmt.Errorf("error getting config: %v", err)
+		return fmt.Errorf("error getting config: %w", err)
 	}
 
 	gitRemoteName, gitRemoteLocation := parseRemoteArgs(args)
@@ -89,6 +102,26 @@ func (s *PrePushService) Run(args []string, stdin io.Reader) error {
 	if err != nil {
 		return err
 	}
+	if drsClient.ReadOnly {
+		// Nothing is registered on a read-only remote; git-lfs pushes as usual.
+		myLogger.Debug("Skipping DRS preparation for read-only remote.", "remote", remote)
+		return nil
+	}
+	if drsClient.Anonymous {
+		fmt.Fprintf(os.Stderr, "Warning. Skipping DRS preparation. Remote %q is read-only (auth=none).\n", remote)
+		myLogger.Debug("Warning. Skipping DRS preparation for read-only remote.")
+		return nil
+	}
+	if _, ok := remoteConfig.(*config.FilesystemRemote); ok {
+		// A filesystem remote has no LFS server; git drs push registers
+		// and copies its objects itself.
+		myLogger.Debug("Skipping DRS preparation for filesystem remote.", "remote", remote)
+		return nil
+	}
+	if drsClient.Encryption.Enabled() {
+		// git-lfs would upload the plaintext after this hook returns.
+		return fmt.Errorf("remote %q encrypts content client-side; push with 'git drs push' (without --with-hooks) so objects are encrypted before upload", remote)
+	}
 
 	scope, err := gitrepo.ResolveBucketScope(
 		remoteConfig.GetOrganization(),
@@ -99,11 +132,10 @@ func (s *PrePushService) Run(args []string, stdin io.Reader) error {
 	if err != nil {
 		return err
 	}
-
-	builder := drsobject.NewBuilder(scope.Bucket, remoteConfig.GetProjectId())
-	builder.Organization = remoteConfig.GetOrganization()
-	builder.StoragePrefix = scope.Prefix
-	myLogger.Debug(fmt.Sprintf("Current server project: %s (org: %s)", builder.Project, builder.Organization))
+	if pm := drsClient.ProjectMap; pm != nil {
+		myLogger.Debug(fmt.Sprintf("Remote %s maps %s to %s", remote, pm.LocalScope(), pm.RemoteScope()))
+	}
+	myLogger.Debug(fmt.Sprintf("Current server project: %s (org: %s)", remoteConfig.GetProjectId(), remoteConfig.GetOrganization()))
 
 	tmp, err := bufferStdin(stdin, s.createTempFile)
 	if err != nil {
@@ -133,17 +165,35 @@ func (s *PrePushService) Run(args []string, stdin io.Reader) error {
 		return err
 	}
 
-	myLogger.Debug(fmt.Sprintf("Preparing DRS objects for push branches: %v (cache=%v)", branches, usedCache))
-	err = s.writeDrsObjects(builder, lfsFiles, drsmap.WriteOptions{
-		Cache:          cache,
-		PreferCacheURL: usedCache,
-		Logger:         myLogger,
-	})
+	lfsFiles, err = s.applyRegisterPolicy(lfsFiles, myLogger)
 	if err != nil {
-		myLogger.Error(fmt.Sprintf("WriteObjectsForLFSFiles failed: %v", err))
+		myLogger.Error(fmt.Sprintf("register policy failed: %v", err))
 		return err
 	}
 
+	groups := pushsync.GroupByPathScope(drsClient, lfsFiles)
+	for _, group := range groups {
+		if err := s.enforceLimits(ctx, group.Context, group.Files); err != nil {
+			myLogger.Error(fmt.Sprintf("push limits check failed: %v", err))
+			return err
+		}
+	}
+
+	s.validateStagedObjects(myLogger)
+
+	myLogger.Debug(fmt.Sprintf("Preparing DRS objects for push branches: %v (cache=%v)", branches, usedCache))
+	for _, group := range groups {
+		err = s.writeDrsObjects(scopeBuilder(drsClient, group.Context, scope), group.Files, drsmap.WriteOptions{
+			Cache:          cache,
+			PreferCacheURL: usedCache,
+			Logger:         myLogger,
+		})
+		if err != nil {
+			myLogger.Error(fmt.Sprintf("WriteObjectsForLFSFiles failed: %v", err))
+			return err
+		}
+	}
+
 	// Stage metadata in one packet; server consumes it at LFS verify-time.
 	myLogger.Info(fmt.Sprintf("Staging %d DRS metadata records for LFS verify", len(lfsFiles)))
 	if err := submitPendingLFSMeta(ctx, remote, remoteConfig.GetEndpoint(), lfsFiles, myLogger); err != nil {
@@ -155,6 +205,83 @@ func (s *PrePushService) Run(args []string, stdin io.Reader) error {
 	return nil
 }
 
+// scopeBuilder builds local objects for one scope group. Objects in the
+// remote's own scope use the resolved remote scope, or the local scope when
+// the remote maps one; path-scope groups use their own project and bucket.
+func scopeBuilder(remote, group *config.GitContext, remoteScope gitrepo.ResolvedBucketScope) drsobject.Builder {
+	if group != remote {
+		builder := drsobject.NewBuilder(group.BucketName, group.ProjectId)
+		builder.Organization = group.Organization
+		builder.StoragePrefix = group.StoragePrefix
+		builder.IDs = group.IDs
+		return builder
+	}
+	builder := drsobject.NewBuilder(remoteScope.Bucket, remote.ProjectId)
+	builder.Organization = remote.Organization
+	builder.StoragePrefix = remoteScope.Prefix
+	builder.IDs = remote.IDs
+	if pm := remote.ProjectMap; pm != nil {
+		// Local objects stay in the local scope; push translates them.
+		builder.Organization = pm.LocalOrganization
+		builder.Project = pm.LocalProject
+	}
+	return builder
+}
+
+// enforceLimits blocks the push when new objects break drs.limits.*, unless
+// guardrail.OverrideEnv is set. Quota warnings go to stderr either way.
+func (s *PrePushService) enforceLimits(ctx context.Context, drsClient *config.GitContext, lfsFiles map[string]lfs.LfsFileInfo) error {
+	policy, err := s.loadLimits()
+	if err != nil {
+		return err
+	}
+	if guardrail.Overridden() {
+		policy = policy.WarningsOnly()
+	}
+	warning, err := s.checkLimits(drsClient, ctx, lfsFiles, policy)
+	if warning != "" {
+		fmt.Fprintln(os.Stderr, "Warning:", warning)
+	}
+	return err
+}
+
+// applyRegisterPolicy drops paths excluded by drs.register.* so they get no
+// DRS object or staged metadata; git-lfs still uploads them as plain LFS
+// objects.
+func (s *PrePushService) applyRegisterPolicy(lfsFiles map[string]lfs.LfsFileInfo, logger *slog.Logger) (map[string]lfs.LfsFileInfo, error) {
+	policy, err := s.loadRegister()
+	if err != nil {
+		return nil, err
+	}
+	registered, excluded := policy.Filter(lfsFiles)
+	if len(excluded) > 0 {
+		logger.Info(fmt.Sprintf("Leaving %d LFS files excluded by drs.register.* in plain LFS storage", len(excluded)))
+	}
+	return registered, nil
+}
+
+// validateStagedObjects quarantines corrupt objects left in the local store by
+// an interrupted write so they are rebuilt below instead of being pushed.
+// Scan failures only warn: the write step reports anything that matters.
+func (s *PrePushService) validateStagedObjects(logger *slog.Logger) {
+	report, err := s.scanObjects(common.DRS_OBJS_PATH)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("staged DRS object scan failed: %v", err))
+		return
+	}
+	oids := make([]string, 0, len(report.Quarantined))
+	for oid := range report.Quarantined {
+		oids = append(oids, oid)
+	}
+	sort.Strings(oids)
+	for _, oid := range oids {
+		fmt.Fprintf(os.Stderr, "Warning: quarantined corrupt staged DRS object %s to %s; it will be rebuilt\n", oid, report.Quarantined[oid])
+	}
+	if report.TempFilesRemoved > 0 {
+		logger.Debug(fmt.Sprintf("removed %d interrupted staging writes", report.TempFilesRemoved))
+	}
+}
+
 type metadataSubmitRequest struct {
 	Candidates []metadataCandidate `json:"candidates"`
 	TTLSeconds int64               `json:"ttl_seconds,omitempty"`
@@ -332,6 +459,12 @@ func resolveRemoteAuthHeader(remoteName string) (string, bool) {
 			return "Bearer " + token, true
 		}
 	}
+	// Keyring remotes keep no token in git config.
+	if store := config.RemoteCredentialStore(remoteName); store == config.CredentialStoreKeyring {
+		if cred, err := config.LoadProfile(store, remoteName, drslog.GetLogger()); err == nil && strings.TrimSpace(cred.AccessToken) != "" {
+			return "Bearer " + strings.TrimSpace(cred.AccessToken), true
+		}
+	}
 	username, password, err := gitrepo.GetRemoteBasicAuth(remoteName)
 	if err != nil || username == "" || password == "" {
 		return "", false
@@ -368,6 +501,15 @@ func openCache(ctx context.Context, logger *slog.Logger) (*precommit_cache.Cache
 		}
 		return nil, false
 	}
+	invalidated, err := cache.EnsureFresh(ctx)
+	if err != nil {
+		logger.Debug(fmt.Sprintf("pre-commit cache validation failed: %v", err))
+		return nil, false
+	}
+	if invalidated {
+		logger.Info("pre-commit cache invalidated: tracking attributes changed")
+		return nil, false
+	}
 	return cache, true
 }
 
@@ -388,10 +530,10 @@ func collectLfsFiles(ctx context.Context, cache *precommit_cache.Cache, cacheRea
 	return lfsFiles, false, nil
 }
 
-const cacheMaxAge = 24 * time.Hour
+const cacheMaxAge = precommit_cache.DefaultMaxAge
 
 var pendingMetadataClientFactory = func() *http.Client {
-	return &http.Client{Timeout: 20 * time.Second}
+	return httpclient.NewAPI()
 }
 
 func normalizeCachedOID(oid string) string {
@@ -411,25 +553,34 @@ func lfsFilesFromCache(ctx context.Context, cache *precommit_cache.Cache, refs [
 		return nil, false, err
 	}
 	lfsFiles := make(map[string]lfs.LfsFileInfo, len(paths))
+	complete := true
+	defer func() {
+		hits, misses := cache.Stats()
+		logger.Info("pre-commit cache lookup", "paths", len(paths), "hits", hits, "misses", misses, "complete", complete)
+	}()
 	for _, path := range paths {
 		entry, ok, err := cache.ReadPathEntry(path)
 		if err != nil {
 			return nil, false, err
 		}
 		if !ok {
-			return nil, false, nil
+			complete = false
+			continue
 		}
 		oid := normalizeCachedOID(entry.LFSOID)
 		if oid == "" {
-			return nil, false, nil
+			complete = false
+			continue
 		}
 		if entry.UpdatedAt == "" || precommit_cache.StaleAfter(entry.UpdatedAt, cacheMaxAge) {
-			return nil, false, nil
+			complete = false
+			continue
 		}
-		stat, err := os.Stat(path)
+		stat, err := os.Stat(projectdir.Path(path))
 		if err != nil {
 			logger.Debug(fmt.Sprintf("cache path stat failed for %s: %v", path, err))
-			return nil, false, nil
+			complete = false
+			continue
 		}
 		lfsFiles[path] = lfs.LfsFileInfo{
 			Name:    path,
@@ -439,6 +590,9 @@ func lfsFilesFromCache(ctx context.Context, cache *precommit_cache.Cache, refs [
 			Version: "https://git-lfs.github.com/spec/v1",
 		}
 	}
+	if !complete {
+		return nil, false, nil
+	}
 	return lfsFiles, true, nil
 }
 
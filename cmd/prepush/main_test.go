@@ -18,6 +18,7 @@ import (
 	"github.com/calypr/git-drs/internal/drsobject"
 	"github.com/calypr/git-drs/internal/lfs"
 	"github.com/calypr/git-drs/internal/precommit_cache"
+	"github.com/calypr/git-drs/internal/registerpolicy"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 )
 
@@ -100,6 +101,24 @@ func TestLfsFilesFromCache(t *testing.T) {
 	}
 }
 
+func TestApplyRegisterPolicy(t *testing.T) {
+	svc := &PrePushService{loadRegister: func() (registerpolicy.Policy, error) {
+		return registerpolicy.Policy{Include: []string{"data/**"}, Exclude: []string{"*.tmp"}}, nil
+	}}
+	got, err := svc.applyRegisterPolicy(map[string]lfs.LfsFileInfo{
+		"data/a.bam":   {Oid: "a"},
+		"data/b.tmp":   {Oid: "b"},
+		"notes/c.pdf":  {Oid: "c"},
+		"data/sub/d.x": {Oid: "d"},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("applyRegisterPolicy error: %v", err)
+	}
+	if len(got) != 2 || got["data/a.bam"].Oid != "a" || got["data/sub/d.x"].Oid != "d" {
+		t.Fatalf("unexpected registered files: %+v", got)
+	}
+}
+
 func TestReadPushedRefsAndBranchesFromRefs(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -2,11 +2,13 @@ package pull
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -17,13 +19,16 @@ import (
 	"github.com/calypr/git-drs/internal/drsremote"
 	"github.com/calypr/git-drs/internal/lfs"
 	"github.com/calypr/git-drs/internal/pathspec"
+	"github.com/calypr/git-drs/internal/projectdir"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	sycommon "github.com/calypr/syfon/client/common"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 )
 
 var includePatterns []string
 var dryRun bool
+var recurseSubmodules bool
 
 var (
 	loadCfg         = config.LoadConfig
@@ -32,6 +37,7 @@ var (
 		return cfg.GetRemoteClient(remote, logger)
 	}
 	loadWorktreeInventory = lfs.GetWorktreeLfsFiles
+	refreshIndex          = gitRefreshIndex
 )
 
 var Cmd = &cobra.Command{
@@ -46,138 +52,228 @@ var Cmd = &cobra.Command{
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		logg := drslog.GetLogger()
+		remoteName := ""
+		if len(args) > 0 {
+			remoteName = args[0]
+		}
+		patterns := pathspec.Rooted(includePatterns)
+		err := Run(cmd.OutOrStdout(), remoteName, patterns, dryRun)
+		if !recurseSubmodules {
+			return err
+		}
+		// A superproject that only aggregates dataset submodules may have no
+		// DRS remote of its own.
+		if err != nil && !errors.Is(err, config.ErrNoDefaultRemote) {
+			return err
+		}
+		return pullSubmodules(cmd.OutOrStdout(), patterns, dryRun)
+	},
+}
+
+// Run hydrates pointer files in the current checkout that match patterns.
+// An empty remoteName selects the default remote. With dryRun, matching paths
+// are written to out instead of being downloaded.
+func Run(out io.Writer, remoteName string, patterns []string, dryRun bool) error {
+	logg := drslog.GetLogger()
+
+	cfg, err := loadCfg()
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
 
-		cfg, err := loadCfg()
+	var remote config.Remote
+	if remoteName != "" {
+		remote = config.Remote(remoteName)
+	} else {
+		remote, err = resolveRemote(cfg, "")
 		if err != nil {
-			return fmt.Errorf("error loading config: %v", err)
+			logg.Error(fmt.Sprintf("Error getting remote: %v", err))
+			return err
 		}
+	}
 
-		var remote config.Remote
-		if len(args) > 0 {
-			remote = config.Remote(args[0])
-		} else {
-			remote, err = resolveRemote(cfg, "")
-			if err != nil {
-				logg.Error(fmt.Sprintf("Error getting remote: %v", err))
+	drsCtx, err := newRemoteClient(cfg, remote, logg)
+	if err != nil {
+		logg.Error(fmt.Sprintf("error creating DRS client: %s", err))
+		return err
+	}
+	return Hydrate(out, drsCtx, patterns, dryRun)
+}
+
+// Hydrate is Run with an already configured remote client, for callers such
+// as git drs serve that keep clients across operations.
+func Hydrate(out io.Writer, drsCtx *config.GitContext, patterns []string, dryRun bool) error {
+	logg := drslog.GetLogger()
+	inventory, err := loadWorktreeInventory(logg)
+	if err != nil {
+		return fmt.Errorf("failed to discover pointer files in worktree: %w", err)
+	}
+	pointers := collectPointerFiles(inventory, patterns)
+	if len(pointers) == 0 {
+		logg.Debug("no matching pointer files to hydrate")
+		return nil
+	}
+
+	progress := newPullProgressRenderer(os.Stderr)
+	progress.OnPlan(pointers)
+	defer progress.Finish()
+
+	if dryRun {
+		for _, f := range pointers {
+			if _, err := fmt.Fprintln(out, f.Name); err != nil {
 				return err
 			}
 		}
+		return nil
+	}
 
-		drsCtx, err := newRemoteClient(cfg, remote, logg)
+	ctx := context.Background()
+	// The first pointer of each missing oid is downloaded; the others share
+	// its cached object.
+	missingOIDs := make([]string, 0, len(pointers))
+	firstPointer := make(map[string]pointerFile, len(pointers))
+	for _, f := range pointers {
+		cachePath, err := lfs.ObjectPath(common.LFS_OBJS_PATH, f.Oid)
 		if err != nil {
-			logg.Error(fmt.Sprintf("error creating DRS client: %s", err))
-			return err
+			return fmt.Errorf("failed to resolve LFS object path for %s: %w", f.Oid, err)
 		}
-
-		inventory, err := loadWorktreeInventory(logg)
-		if err != nil {
-			return fmt.Errorf("failed to discover pointer files in worktree: %w", err)
+		if _, err := os.Stat(cachePath); err == nil {
+			continue
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to stat cached object for %s: %w", f.Oid, err)
 		}
-		pointers := collectPointerFiles(inventory, includePatterns)
-		if len(pointers) == 0 {
-			logg.Debug("no matching pointer files to hydrate")
-			return nil
+		// Objects in the shared cache need no record or URL lookup.
+		if linked, err := lfs.LinkFromShared(drsCtx.SharedCache, f.Oid, cachePath); err != nil {
+			logg.Debug(fmt.Sprintf("shared cache link failed for %s: %v", f.Oid, err))
+		} else if linked {
+			continue
 		}
-
-		progress := newPullProgressRenderer(os.Stderr)
-		progress.OnPlan(pointers)
-		defer progress.Finish()
-
-		if dryRun {
-			for _, f := range pointers {
-				if _, err := fmt.Fprintln(cmd.OutOrStdout(), f.Name); err != nil {
-					return err
-				}
-			}
-			return nil
+		if _, seen := firstPointer[f.Oid]; seen {
+			continue
 		}
+		firstPointer[f.Oid] = f
+		missingOIDs = append(missingOIDs, f.Oid)
+	}
 
-		ctx := context.Background()
-		missingOIDs := make([]string, 0, len(pointers))
-		seenMissing := make(map[string]struct{}, len(pointers))
-		for _, f := range pointers {
-			cachePath, err := lfs.ObjectPath(common.LFS_OBJS_PATH, f.Oid)
-			if err != nil {
-				return fmt.Errorf("failed to resolve LFS object path for %s: %w", f.Oid, err)
-			}
-			if _, err := os.Stat(cachePath); err == nil {
-				continue
-			} else if !os.IsNotExist(err) {
-				return fmt.Errorf("failed to stat cached object for %s: %w", f.Oid, err)
-			}
-			if _, seen := seenMissing[f.Oid]; seen {
-				continue
-			}
-			seenMissing[f.Oid] = struct{}{}
-			missingOIDs = append(missingOIDs, f.Oid)
+	if len(missingOIDs) == 0 {
+		logg.Debug("no missing pointer objects to download")
+	}
+	for start := 0; start < len(missingOIDs); start += pullWindow {
+		window := missingOIDs[start:min(start+pullWindow, len(missingOIDs))]
+		if err := downloadWindow(ctx, drsCtx, progress, window, firstPointer); err != nil {
+			return err
 		}
+	}
 
-		if len(missingOIDs) > 0 {
-			prefetched := make(map[string]drsapi.DrsObject, len(missingOIDs))
-			for _, oid := range missingOIDs {
-				recs, err := drsremote.ObjectsByHashForScope(ctx, drsCtx, oid)
-				if err != nil || len(recs) == 0 {
-					continue
-				}
-				prefetched[oid] = recs[0]
-			}
-			if len(prefetched) > 0 {
-				logg.Debug(fmt.Sprintf("prefetched %d objects for pull", len(prefetched)))
-			} else {
-				logg.Debug("bulk prefetch found no scoped objects; continuing per-object")
-			}
+	if err := checkoutDownloadedFiles(pointers, progress); err != nil {
+		return err
+	}
+	// The index still records the pointer's size from checkout, so without a
+	// refresh git reports every hydrated file as modified.
+	if err := refreshIndex(pointers); err != nil {
+		logg.Debug(fmt.Sprintf("index refresh after pull failed: %v", err))
+	}
 
-			prefetchedAccess := make(map[string]drsapi.AccessURL, len(prefetched))
-			if len(prefetched) > 0 {
-				objects := make([]drsapi.DrsObject, 0, len(prefetched))
-				for _, obj := range prefetched {
-					objects = append(objects, obj)
-				}
-				if resolved, err := drsremote.BulkAccessURLsForObjects(ctx, drsCtx, objects); err == nil {
-					prefetchedAccess = resolved
-					logg.Debug(fmt.Sprintf("bulk access resolved %d URLs for pull", len(prefetchedAccess)))
-				} else {
-					logg.Debug(fmt.Sprintf("bulk access prefetch failed; continuing per-object: %v", err))
-				}
-			}
-			for _, f := range pointers {
-				dstPath, err := lfs.ObjectPath(common.LFS_OBJS_PATH, f.Oid)
-				if err != nil {
-					return fmt.Errorf("failed to resolve LFS object path for %s: %w", f.Oid, err)
-				}
-				if _, err := os.Stat(dstPath); err == nil {
-					continue
-				} else if !os.IsNotExist(err) {
-					return fmt.Errorf("failed to stat cache path %s: %w", dstPath, err)
-				}
-				progress.OnDownloadStart(f)
-				downloadCtx := progressContextForPointer(ctx, progress, f)
-				if obj, ok := prefetched[f.Oid]; ok {
-					if accessURL, ok := prefetchedAccess[obj.Id]; ok {
-						objCopy := obj
-						if err := drsremote.DownloadResolvedToCachePath(downloadCtx, drsCtx, f.Oid, dstPath, &objCopy, &accessURL); err != nil {
-							debugCtx := buildPullDownloadDebugContext(ctx, drsCtx, f.Oid)
-							return fmt.Errorf("failed to download oid %s to %s: %w\npull-debug: %s", f.Oid, dstPath, err, debugCtx)
-						}
-						continue
-					}
-				}
-				if err := drsremote.DownloadToCachePath(downloadCtx, drsCtx, logg, f.Oid, dstPath); err != nil {
-					debugCtx := buildPullDownloadDebugContext(ctx, drsCtx, f.Oid)
-					return fmt.Errorf("failed to download oid %s to %s: %w\npull-debug: %s", f.Oid, dstPath, err, debugCtx)
-				}
-			}
+	return nil
+}
+
+// pullWindow bounds the records and signed URLs a pull holds at once: they
+// are looked up, resolved and used for at most this many objects before the
+// next window starts, so memory stays flat however many objects are pulled.
+// Swapped in tests.
+var pullWindow = 500
+
+// downloadWindow downloads the objects for oids into the LFS cache,
+// resolving their records and access URLs in bulk first.
+func downloadWindow(ctx context.Context, drsCtx *config.GitContext, progress *pullProgressRenderer, oids []string, firstPointer map[string]pointerFile) error {
+	logg := drslog.GetLogger()
+	prefetched := make(map[string]drsapi.DrsObject, len(oids))
+	for _, oid := range oids {
+		recs, err := drsremote.ObjectsByHashForScope(ctx, drsCtx, oid)
+		if err != nil || len(recs) == 0 {
+			continue
+		}
+		prefetched[oid] = recs[0]
+	}
+	if len(prefetched) > 0 {
+		logg.Debug(fmt.Sprintf("prefetched %d objects for pull", len(prefetched)))
+		objects := make([]drsapi.DrsObject, 0, len(prefetched))
+		for _, obj := range prefetched {
+			objects = append(objects, obj)
+		}
+		// Resolved URLs land in the signed URL cache; downloads below reuse
+		// them while valid and re-resolve once they near expiry.
+		if resolved, err := drsremote.BulkAccessURLsForObjects(ctx, drsCtx, objects); err == nil {
+			logg.Debug(fmt.Sprintf("bulk access resolved %d URLs for pull", len(resolved)))
 		} else {
-			logg.Debug("no missing pointer objects to download")
+			logg.Debug(fmt.Sprintf("bulk access prefetch failed; continuing per-object: %v", err))
 		}
+	} else {
+		logg.Debug("bulk prefetch found no scoped objects; continuing per-object")
+	}
+	// Signed URLs are only needed within the window.
+	defer func() {
+		for _, obj := range prefetched {
+			drsremote.ForgetAccessURL(drsCtx, obj.Id)
+		}
+	}()
 
-		if err := checkoutDownloadedFiles(pointers, progress); err != nil {
-			return err
+	workers := drsCtx.DownloadConcurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(workers)
+	for _, oid := range oids {
+		if gctx.Err() != nil {
+			break
 		}
+		f := firstPointer[oid]
+		g.Go(func() error {
+			return downloadPointer(gctx, drsCtx, progress, f, prefetched)
+		})
+	}
+	return g.Wait()
+}
 
-		return nil
-	},
+// downloadPointer downloads one object into the LFS cache, using the access
+// URL prefetched for its window when one is still cached.
+func downloadPointer(ctx context.Context, drsCtx *config.GitContext, progress *pullProgressRenderer, f pointerFile, prefetched map[string]drsapi.DrsObject) error {
+	dstPath, err := lfs.ObjectPath(common.LFS_OBJS_PATH, f.Oid)
+	if err != nil {
+		return fmt.Errorf("failed to resolve LFS object path for %s: %w", f.Oid, err)
+	}
+	progress.OnDownloadStart(f)
+	downloadCtx := progressContextForPointer(ctx, progress, f)
+	download := func() error {
+		return drsremote.DownloadToCachePath(downloadCtx, drsCtx, drslog.GetLogger(), f.Oid, dstPath)
+	}
+	if obj, ok := prefetched[f.Oid]; ok {
+		if accessURL, ok := drsremote.CachedAccessURL(ctx, drsCtx, obj); ok {
+			download = func() error {
+				return drsremote.DownloadResolvedToCachePath(downloadCtx, drsCtx, f.Oid, dstPath, &obj, &accessURL)
+			}
+		}
+	}
+	if err := download(); err != nil {
+		debugCtx := buildPullDownloadDebugContext(ctx, drsCtx, f.Oid)
+		return fmt.Errorf("failed to download oid %s to %s: %w\npull-debug: %s", f.Oid, dstPath, err, debugCtx)
+	}
+	return nil
+}
+
+func gitRefreshIndex(files []pointerFile) error {
+	var paths strings.Builder
+	for _, f := range files {
+		paths.WriteString(f.Name + "\n")
+	}
+	cmd := exec.Command("git", "update-index", "-q", "--refresh", "--stdin")
+	cmd.Dir = projectdir.Path(".")
+	cmd.Stdin = strings.NewReader(paths.String())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git update-index --refresh: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
 }
 
 type pointerFile struct {
@@ -229,13 +325,12 @@ func checkoutDownloadedFiles(files []pointerFile, progress *pullProgressRenderer
 			return fmt.Errorf("failed to read cached object %s: %w", srcPath, err)
 		}
 		progress.OnCheckoutStart(f)
-		if dir := filepath.Dir(f.Name); dir != "." {
-			if err := os.MkdirAll(dir, 0o755); err != nil {
-				src.Close()
-				return fmt.Errorf("failed to create directory for %s: %w", f.Name, err)
-			}
+		dstPath := projectdir.Path(f.Name)
+		if err := os.MkdirAll(filepath.Dir(dstPath), 0o755); err != nil {
+			src.Close()
+			return fmt.Errorf("failed to create directory for %s: %w", f.Name, err)
 		}
-		dst, err := os.OpenFile(f.Name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+		dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
 		if err != nil {
 			src.Close()
 			return fmt.Errorf("failed to checkout %s: %w", f.Name, err)
@@ -295,4 +390,5 @@ func buildPullDownloadDebugContext(ctx context.Context, drsCtx *config.GitContex
 func init() {
 	Cmd.Flags().StringArrayVarP(&includePatterns, "include", "I", nil, "include pathspec/glob pattern(s)")
 	Cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list matching pointer files without downloading them")
+	Cmd.Flags().BoolVar(&recurseSubmodules, "recurse-submodules", false, "also hydrate checked-out submodules using each submodule's own DRS remote")
 }
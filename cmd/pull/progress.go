@@ -3,6 +3,7 @@ package pull
 import (
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/calypr/git-drs/internal/progressui"
 )
@@ -25,7 +26,10 @@ type pullFileProgress struct {
 	phase   pullProgressPhase
 }
 
+// pullProgressRenderer is safe for concurrent use; pull downloads several
+// objects at once.
 type pullProgressRenderer struct {
+	mu        sync.Mutex
 	base      *progressui.Renderer
 	planned   bool
 	files     map[string]*pullFileProgress
@@ -56,6 +60,8 @@ func (r *pullProgressRenderer) render(force bool) {
 }
 
 func (r *pullProgressRenderer) OnPlan(files []pointerFile) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.planned = len(files) > 0
 	r.files = make(map[string]*pullFileProgress, len(files))
 	r.fileOrder = r.fileOrder[:0]
@@ -73,6 +79,8 @@ func (r *pullProgressRenderer) OnPlan(files []pointerFile) {
 }
 
 func (r *pullProgressRenderer) OnDownloadStart(file pointerFile) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if !r.planned {
 		return
 	}
@@ -89,6 +97,8 @@ func (r *pullProgressRenderer) OnDownloadStart(file pointerFile) {
 }
 
 func (r *pullProgressRenderer) OnDownloadProgress(id string, bytesSoFar int64, total int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if !r.planned {
 		return
 	}
@@ -107,6 +117,8 @@ func (r *pullProgressRenderer) OnDownloadProgress(id string, bytesSoFar int64, t
 }
 
 func (r *pullProgressRenderer) OnCheckoutStart(file pointerFile) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if !r.planned {
 		return
 	}
@@ -122,6 +134,8 @@ func (r *pullProgressRenderer) OnCheckoutStart(file pointerFile) {
 }
 
 func (r *pullProgressRenderer) OnCompleted(file pointerFile) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if !r.planned {
 		return
 	}
@@ -140,6 +154,8 @@ func (r *pullProgressRenderer) OnCompleted(file pointerFile) {
 }
 
 func (r *pullProgressRenderer) Finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if !r.planned {
 		return
 	}
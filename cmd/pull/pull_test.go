@@ -12,6 +12,7 @@ import (
 func resetPullFlagsForTest() {
 	includePatterns = nil
 	dryRun = false
+	recurseSubmodules = false
 }
 
 func TestCollectPointerFilesFiltersAndSorts(t *testing.T) {
@@ -33,6 +34,8 @@ func TestCollectPointerFilesFiltersAndSorts(t *testing.T) {
 }
 
 func TestPullDryRunListsMatchingPaths(t *testing.T) {
+	// Include patterns are taken relative to the working directory.
+	t.Chdir(t.TempDir())
 	resetPullFlagsForTest()
 
 	oldLoadCfg := loadCfg
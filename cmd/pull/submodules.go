@@ -0,0 +1,100 @@
+package pull
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+// listSubmodules is swapped in tests.
+var listSubmodules = initializedSubmodules
+
+// initializedSubmodules returns the paths, relative to the superproject root,
+// of every checked-out submodule including nested ones.
+func initializedSubmodules(top string) ([]string, error) {
+	cmd := exec.Command("git", "-C", top, "submodule", "foreach", "--quiet", "--recursive", `printf '%s\n' "$displaypath"`)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git submodule foreach failed: %w", err)
+	}
+	var paths []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if p := strings.TrimSpace(line); p != "" {
+			paths = append(paths, filepath.ToSlash(p))
+		}
+	}
+	return paths, nil
+}
+
+// pullSubmodules hydrates each submodule using that submodule's own git-drs
+// remote configuration and credentials. Submodules without a DRS remote are
+// skipped with a warning.
+func pullSubmodules(out io.Writer, patterns []string, dryRun bool) error {
+	logg := drslog.GetLogger()
+	top, err := gitrepo.GitTopLevel()
+	if err != nil {
+		return err
+	}
+	subs, err := listSubmodules(top)
+	if err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	defer os.Chdir(cwd)
+
+	for _, sub := range subs {
+		subPatterns, ok := submodulePatterns(sub, patterns)
+		if !ok {
+			logg.Debug(fmt.Sprintf("pull: no include pattern selects submodule %s", sub))
+			continue
+		}
+		if err := os.Chdir(filepath.Join(top, filepath.FromSlash(sub))); err != nil {
+			return fmt.Errorf("enter submodule %s: %w", sub, err)
+		}
+		err := Run(out, "", subPatterns, dryRun)
+		if errors.Is(err, config.ErrNoDefaultRemote) {
+			logg.Warn(fmt.Sprintf("pull: submodule %s has no DRS remote configured; skipping", sub))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("pull submodule %s: %w", sub, err)
+		}
+	}
+	return nil
+}
+
+// submodulePatterns rewrites superproject-relative patterns for a submodule
+// rooted at sub. Patterns under sub are made relative to it, path-less
+// patterns (e.g. "*.bam") apply unchanged, and patterns for other paths are
+// dropped. ok is false when patterns were given but none apply.
+func submodulePatterns(sub string, patterns []string) ([]string, bool) {
+	if len(patterns) == 0 {
+		return nil, true
+	}
+	prefix := strings.TrimSuffix(sub, "/") + "/"
+	var out []string
+	for _, p := range patterns {
+		p = filepath.ToSlash(strings.TrimSpace(p))
+		switch {
+		case p == strings.TrimSuffix(prefix, "/"):
+			out = append(out, "**")
+		case strings.HasPrefix(p, prefix):
+			out = append(out, strings.TrimPrefix(p, prefix))
+		case !strings.Contains(p, "/"):
+			out = append(out, p)
+		}
+	}
+	return out, len(out) > 0
+}
@@ -0,0 +1,91 @@
+package pull
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/testutils"
+)
+
+func TestSubmodulePatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+		ok       bool
+	}{
+		{"no patterns", nil, nil, true},
+		{"scoped pattern", []string{"datasets/a/**/*.bam"}, []string{"**/*.bam"}, true},
+		{"whole submodule", []string{"datasets/a"}, []string{"**"}, true},
+		{"pathless pattern", []string{"*.vcf"}, []string{"*.vcf"}, true},
+		{"other path", []string{"datasets/b/**"}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := submodulePatterns("datasets/a", tt.patterns)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("submodulePatterns() = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPullSubmodulesRunsInEachSubmodule(t *testing.T) {
+	resetPullFlagsForTest()
+	top := testutils.SetupTestGitRepo(t)
+	for _, sub := range []string{"mods/a", "mods/b"} {
+		if err := os.MkdirAll(filepath.Join(top, sub), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+
+	oldList := listSubmodules
+	oldLoadCfg := loadCfg
+	oldResolveRemote := resolveRemote
+	oldNewRemoteClient := newRemoteClient
+	oldInventory := loadWorktreeInventory
+	t.Cleanup(func() {
+		listSubmodules = oldList
+		loadCfg = oldLoadCfg
+		resolveRemote = oldResolveRemote
+		newRemoteClient = oldNewRemoteClient
+		loadWorktreeInventory = oldInventory
+	})
+
+	listSubmodules = func(string) ([]string, error) { return []string{"mods/a", "mods/b"}, nil }
+	loadCfg = func() (*config.Config, error) { return &config.Config{}, nil }
+	resolveRemote = func(cfg *config.Config, name string) (config.Remote, error) {
+		wd, _ := os.Getwd()
+		if filepath.Base(wd) == "b" {
+			return "", config.ErrNoDefaultRemote
+		}
+		return config.Remote("origin"), nil
+	}
+	newRemoteClient = func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+		return &config.GitContext{}, nil
+	}
+	loadWorktreeInventory = func(_ *slog.Logger) (map[string]lfs.LfsFileInfo, error) {
+		wd, _ := os.Getwd()
+		name := filepath.Base(wd) + ".bin"
+		return map[string]lfs.LfsFileInfo{name: {Name: name, Oid: "aaaa", Size: 1}}, nil
+	}
+
+	var out bytes.Buffer
+	if err := pullSubmodules(&out, nil, true); err != nil {
+		t.Fatalf("pullSubmodules error: %v", err)
+	}
+	if got := out.String(); got != "a.bin\n" {
+		t.Fatalf("expected only submodule a hydrated, got %q", got)
+	}
+	if wd, _ := os.Getwd(); filepath.Clean(wd) != filepath.Clean(top) {
+		if resolved, _ := filepath.EvalSymlinks(top); filepath.Clean(wd) != filepath.Clean(resolved) {
+			t.Fatalf("expected cwd restored to %s, got %s", top, wd)
+		}
+	}
+}
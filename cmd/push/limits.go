@@ -0,0 +1,36 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/guardrail"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pushsync"
+)
+
+// Swapped in tests.
+var (
+	loadLimits  = config.LimitSettings
+	checkLimits = pushsync.CheckLimits
+)
+
+// checkPushLimits fails before anything is registered or uploaded when new
+// objects break drs.limits.*, unless --allow-oversize or the override
+// environment variable is set. Quota warnings are printed either way.
+func checkPushLimits(ctx context.Context, drsClient *config.GitContext, lfsFiles map[string]lfs.LfsFileInfo) error {
+	policy, err := loadLimits()
+	if err != nil {
+		return err
+	}
+	if pushAllowOversize || guardrail.Overridden() {
+		policy = policy.WarningsOnly()
+	}
+	warning, err := checkLimits(drsClient, ctx, lfsFiles, policy)
+	if warning != "" {
+		fmt.Fprintln(os.Stderr, "Warning:", warning)
+	}
+	return err
+}
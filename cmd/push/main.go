@@ -3,13 +3,16 @@ package push
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"strings"
 
+	"github.com/calypr/git-drs/internal/common"
 	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/drsdelete"
 	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsremote"
 	"github.com/calypr/git-drs/internal/lfs"
 	"github.com/calypr/git-drs/internal/pushsync"
 	"github.com/spf13/cobra"
@@ -17,6 +20,13 @@ import (
 
 var pushWithHooks bool
 var pushForceUpload bool
+var pushAllowOversize bool
+var pushVerify string
+var pushReport bool
+var pushQueueOffline bool
+
+// loadVerifyPolicy is swapped in tests.
+var loadVerifyPolicy = config.PushVerifyPolicy
 
 var runCommand = func(name string, args ...string) ([]byte, error) {
 	cmd := exec.Command(name, args...)
@@ -25,6 +35,15 @@ var runCommand = func(name string, args ...string) ([]byte, error) {
 
 var gitOutputFn = gitOutput
 
+// pushResult is the --json document for push.
+type pushResult struct {
+	Remote   string `json:"remote"`
+	Files    int    `json:"files"`
+	Uploaded bool   `json:"uploaded"`
+	Queued   int    `json:"queued,omitempty"`
+	MDSGUID  string `json:"mds_guid,omitempty"`
+}
+
 var Cmd = &cobra.Command{
 	Use:   "push [remote-name]",
 	Short: "Upload/register DRS objects and push Git refs",
@@ -60,31 +79,63 @@ var Cmd = &cobra.Command{
 			myLogger.Debug(fmt.Sprintf("Error creating DRS client: %s", err))
 			return err
 		}
+		if err := drsClient.RequireWrite(); err != nil {
+			return err
+		}
 		drsClient.ForceUpload = pushForceUpload
+		drsClient.Report = drsClient.Report || pushReport
+		drsClient.QueueOffline = drsClient.QueueOffline || pushQueueOffline
+		if drsClient.Verify, err = resolveVerifyPolicy(cmd.Flags().Changed("verify")); err != nil {
+			return err
+		}
+		if drsClient.Encryption.Enabled() && pushWithHooks {
+			return fmt.Errorf("remote %q encrypts content client-side; --with-hooks would let git-lfs upload plaintext", remote)
+		}
 		lfsFiles, err := lfs.GetAllLfsFiles(string(remote), "", []string{"HEAD"}, myLogger)
 		if err != nil {
 			return fmt.Errorf("failed to discover LFS files to push: %w", err)
 		}
 
-		ctx := context.Background()
-		deleteRefs, err := currentDeleteRefUpdates(ctx)
+		lfsFiles, excludedOIDs, err := splitByRegisterPolicy(lfsFiles)
 		if err != nil {
-			return fmt.Errorf("failed to resolve delete reconciliation base: %w", err)
+			return err
 		}
-		if _, err := drsdelete.ReconcileCommittedDeletes(ctx, drsClient, deleteRefs, myLogger); err != nil {
-			return fmt.Errorf("failed to reconcile deletes: %w", err)
+		if len(excludedOIDs) > 0 && drsClient.Encryption.Enabled() {
+			return fmt.Errorf("remote %q encrypts content client-side, but %d objects excluded by drs.register.* would be pushed to plain LFS unencrypted", remote, len(excludedOIDs))
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if err := pushsync.CheckWriteAccess(ctx, drsClient, lfsFiles); err != nil {
+			return err
 		}
 		progress := newUploadProgressRenderer(os.Stderr)
-		if err := pushsync.BatchSyncForPush(drsClient, ctx, lfsFiles, progress); err != nil {
-			progress.Finish()
-			return fmt.Errorf("failed batch register/upload workflow: %w", err)
+		queued := 0
+		if syncErr := syncDRS(ctx, drsClient, lfsFiles, progress, myLogger); syncErr != nil {
+			if !drsClient.QueueOffline || !pushsync.Deferrable(syncErr) {
+				return syncErr
+			}
+			// The git remote may still be reachable; push the refs and
+			// leave registration and upload to git drs flush.
+			if queued, err = pushsync.QueueForPush(drsClient, lfsFiles); err != nil {
+				return err
+			}
+			myLogger.Warn("DRS server unreachable; queued files in the outbox", "files", queued, "error", syncErr)
+		}
+		if err := pushExcludedObjects(remote, excludedOIDs); err != nil {
+			return err
 		}
-		progress.Finish()
-		switch {
-		case len(lfsFiles) == 0:
-			fmt.Fprintln(os.Stdout, "No git-drs tracked files found; pushing Git refs only.")
-		case !progress.HadUploads():
-			fmt.Fprintln(os.Stdout, "No DRS payload uploads needed; all tracked objects are already available remotely.")
+		if !common.JSONOutput() {
+			switch {
+			case queued > 0:
+				fmt.Fprintf(os.Stderr, "git-drs: DRS server unreachable; %d files queued in the outbox. Their content is not available to others until 'git drs flush' or the next 'git drs push' uploads it.\n", queued)
+			case len(lfsFiles) == 0:
+				fmt.Fprintln(os.Stdout, "No git-drs tracked files found; pushing Git refs only.")
+			case !progress.HadUploads():
+				fmt.Fprintln(os.Stdout, "No DRS payload uploads needed; all tracked objects are already available remotely.")
+			}
 		}
 
 		pushArgs := []string{"push"}
@@ -100,6 +151,25 @@ var Cmd = &cobra.Command{
 			}
 			return fmt.Errorf("git push failed for remote %q: %s", remote, msg)
 		}
+		// The refs are already pushed, so a metadata failure only warns.
+		var guid string
+		if queued == 0 {
+			if guid, err = updateDatasetRecord(ctx, drsClient, remote, lfsFiles); err != nil {
+				myLogger.Warn("dataset metadata update failed", "error", err)
+			}
+		}
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), pushResult{
+				Remote:   string(remote),
+				Files:    len(lfsFiles),
+				Uploaded: progress.HadUploads(),
+				Queued:   queued,
+				MDSGUID:  guid,
+			})
+		}
+		if guid != "" {
+			fmt.Fprintf(os.Stdout, "Updated dataset metadata record %s\n", guid)
+		}
 		return nil
 	},
 }
@@ -107,6 +177,51 @@ var Cmd = &cobra.Command{
 func init() {
 	Cmd.Flags().BoolVar(&pushWithHooks, "with-hooks", false, "Run git push with local hooks enabled (invokes pre-push)")
 	Cmd.Flags().BoolVar(&pushForceUpload, "force-upload", false, "Upload payload bytes even when a matching downloadable object already exists remotely")
+	Cmd.Flags().BoolVar(&pushAllowOversize, "allow-oversize", false, "Push even when new objects exceed the drs.limits.* size and extension limits")
+	Cmd.Flags().StringVar(&pushVerify, "verify", "", "After uploading, check that pushed objects are readable: all, a count, a percentage such as 10%, or off (default: drs.push.verify)")
+	Cmd.Flags().Lookup("verify").NoOptDefVal = "all"
+	Cmd.Flags().BoolVar(&pushReport, "report", false, "Write a per-object timing report to .drs/reports (default: drs.push.report)")
+	Cmd.Flags().BoolVar(&pushQueueOffline, "queue-offline", false, "When the DRS server is unreachable, queue files in the outbox and push Git refs anyway (default: drs.push.queue-offline)")
+}
+
+// resolveVerifyPolicy returns the --verify policy when the flag was given,
+// else drs.push.verify.
+func resolveVerifyPolicy(flagSet bool) (config.VerifyPolicy, error) {
+	if !flagSet {
+		return loadVerifyPolicy()
+	}
+	p, err := config.ParseVerifyPolicy(pushVerify)
+	if err != nil {
+		return config.VerifyPolicy{}, fmt.Errorf("--verify: %w", err)
+	}
+	return p, nil
+}
+
+// syncDRS checks the push limits, reconciles committed deletes and registers
+// and uploads files.
+func syncDRS(ctx context.Context, drsClient *config.GitContext, lfsFiles map[string]lfs.LfsFileInfo, progress *uploadProgressRenderer, logger *slog.Logger) error {
+	// The limit check and the sync look up the same oids; the cache
+	// lets the sync reuse what the check found.
+	lookupCtx := drsremote.WithHashCache(ctx)
+	if err := checkPushLimits(lookupCtx, drsClient, lfsFiles); err != nil {
+		return err
+	}
+	deleteRefs, err := currentDeleteRefUpdates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to resolve delete reconciliation base: %w", err)
+	}
+	deleted, err := drsdelete.ReconcileCommittedDeletes(ctx, drsClient, deleteRefs, logger)
+	if err != nil {
+		return fmt.Errorf("failed to reconcile deletes: %w", err)
+	}
+	if deleted.DeletedRecords > 0 || deleted.RemovedResources > 0 {
+		lookupCtx = drsremote.WithHashCache(ctx)
+	}
+	defer progress.Finish()
+	if err := pushsync.BatchSyncForPush(drsClient, lookupCtx, lfsFiles, progress); err != nil {
+		return fmt.Errorf("failed batch register/upload workflow: %w", err)
+	}
+	return nil
 }
 
 func currentDeleteRefUpdates(ctx context.Context) ([]drsdelete.RefUpdate, error) {
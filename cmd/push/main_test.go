@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/drsdelete"
 )
 
@@ -56,3 +57,21 @@ func TestCurrentDeleteRefUpdatesSkipsWhenUpstreamMissing(t *testing.T) {
 		t.Fatalf("expected nil delete refs when upstream is missing, got %+v", got)
 	}
 }
+
+func TestResolveVerifyPolicyFlagOverridesConfig(t *testing.T) {
+	oldLoad, oldFlag := loadVerifyPolicy, pushVerify
+	t.Cleanup(func() { loadVerifyPolicy, pushVerify = oldLoad, oldFlag })
+	loadVerifyPolicy = func() (config.VerifyPolicy, error) { return config.VerifyPolicy{Count: 5}, nil }
+
+	if got, err := resolveVerifyPolicy(false); err != nil || got != (config.VerifyPolicy{Count: 5}) {
+		t.Fatalf("config policy: got %+v, %v", got, err)
+	}
+	pushVerify = "off"
+	if got, err := resolveVerifyPolicy(true); err != nil || got.Enabled() {
+		t.Fatalf("--verify=off: got %+v, %v", got, err)
+	}
+	pushVerify = "often"
+	if _, err := resolveVerifyPolicy(true); err == nil {
+		t.Fatal("expected error for invalid --verify value")
+	}
+}
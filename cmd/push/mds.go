@@ -0,0 +1,85 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/mds"
+)
+
+var (
+	mdsSettings    = config.MDSSettings
+	lookupObjects  = drsremote.ObjectsByHashesForScope
+	publishDataset = mds.Publish
+)
+
+// updateDatasetRecord publishes the pushed commit and its DRS IDs to the
+// metadata service when drs.mds.enabled is set. It returns the record GUID,
+// or "" when publishing is disabled.
+func updateDatasetRecord(ctx context.Context, gc *config.GitContext, remote config.Remote, files map[string]lfs.LfsFileInfo) (string, error) {
+	settings := mdsSettings()
+	if !settings.Enabled {
+		return "", nil
+	}
+	if gc.Credential == nil || gc.Credential.APIEndpoint == "" {
+		return "", fmt.Errorf("remote %q has no API endpoint for the metadata service", remote)
+	}
+
+	oids := make([]string, 0, len(files))
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		if f.Oid != "" && !seen[f.Oid] {
+			seen[f.Oid] = true
+			oids = append(oids, f.Oid)
+		}
+	}
+	drsIDs := []string{}
+	if len(oids) > 0 {
+		objs, err := lookupObjects(ctx, gc, oids)
+		if err != nil {
+			return "", fmt.Errorf("resolve DRS IDs: %w", err)
+		}
+		ids := make(map[string]bool)
+		for _, records := range objs {
+			for _, obj := range records {
+				ids[obj.Id] = true
+			}
+		}
+		for id := range ids {
+			drsIDs = append(drsIDs, id)
+		}
+		sort.Strings(drsIDs)
+	}
+
+	commit, err := gitOutputFn(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	repoURL, err := gitOutputFn(ctx, "remote", "get-url", string(remote))
+	if err != nil {
+		return "", err
+	}
+	branch, _ := gitOutputFn(ctx, "rev-parse", "--abbrev-ref", "HEAD")
+	if branch == "HEAD" {
+		branch = ""
+	}
+
+	guid := settings.RecordGUID(gc.Organization, gc.ProjectId)
+	dataset := mds.Dataset{
+		RepoURL:      repoURL,
+		Commit:       commit,
+		Branch:       branch,
+		Remote:       string(remote),
+		Organization: gc.Organization,
+		Project:      gc.ProjectId,
+		DRSIDs:       drsIDs,
+	}
+	if err := publishDataset(ctx, gc.Credential.APIEndpoint, gc.Credential.AccessToken, settings, guid, dataset); err != nil {
+		return "", fmt.Errorf("update metadata record %s: %w", guid, err)
+	}
+	return guid, nil
+}
@@ -0,0 +1,87 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/mds"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	syconf "github.com/calypr/syfon/client/config"
+)
+
+func TestUpdateDatasetRecordDisabled(t *testing.T) {
+	oldSettings := mdsSettings
+	mdsSettings = func() mds.Settings { return mds.Settings{} }
+	t.Cleanup(func() { mdsSettings = oldSettings })
+
+	guid, err := updateDatasetRecord(context.Background(), &config.GitContext{}, "origin", nil)
+	if err != nil || guid != "" {
+		t.Fatalf("expected no-op, got guid=%q err=%v", guid, err)
+	}
+}
+
+func TestUpdateDatasetRecordPublishesCommitAndDRSIDs(t *testing.T) {
+	oldSettings, oldLookup, oldPublish, oldGit := mdsSettings, lookupObjects, publishDataset, gitOutputFn
+	t.Cleanup(func() {
+		mdsSettings, lookupObjects, publishDataset, gitOutputFn = oldSettings, oldLookup, oldPublish, oldGit
+	})
+
+	mdsSettings = func() mds.Settings { return mds.Settings{Enabled: true} }
+	lookupObjects = func(ctx context.Context, gc *config.GitContext, oids []string) (map[string][]drsapi.DrsObject, error) {
+		return map[string][]drsapi.DrsObject{
+			"oid-a": {{Id: "did-b"}},
+			"oid-b": {{Id: "did-a"}, {Id: "did-b"}},
+		}, nil
+	}
+	gitOutputFn = func(ctx context.Context, args ...string) (string, error) {
+		switch fmt.Sprint(args) {
+		case "[rev-parse HEAD]":
+			return "abc123", nil
+		case "[remote get-url origin]":
+			return "https://github.com/example/study.git", nil
+		case "[rev-parse --abbrev-ref HEAD]":
+			return "main", nil
+		}
+		return "", fmt.Errorf("unexpected git args: %v", args)
+	}
+	var gotEndpoint, gotGUID string
+	var got mds.Dataset
+	publishDataset = func(ctx context.Context, endpoint, token string, s mds.Settings, guid string, d mds.Dataset) error {
+		gotEndpoint, gotGUID, got = endpoint, guid, d
+		return nil
+	}
+
+	gc := &config.GitContext{
+		Organization: "htan",
+		ProjectId:    "p1",
+		Credential:   &syconf.Credential{APIEndpoint: "https://gen3.example", AccessToken: "tok"},
+	}
+	files := map[string]lfs.LfsFileInfo{
+		"a.bam":      {Oid: "oid-a"},
+		"copy/a.bam": {Oid: "oid-a"},
+		"b.bam":      {Oid: "oid-b"},
+	}
+	guid, err := updateDatasetRecord(context.Background(), gc, "origin", files)
+	if err != nil {
+		t.Fatalf("updateDatasetRecord error: %v", err)
+	}
+	if guid != "htan-p1" || gotGUID != "htan-p1" || gotEndpoint != "https://gen3.example" {
+		t.Fatalf("unexpected guid %q/%q endpoint %q", guid, gotGUID, gotEndpoint)
+	}
+	want := mds.Dataset{
+		RepoURL:      "https://github.com/example/study.git",
+		Commit:       "abc123",
+		Branch:       "main",
+		Remote:       "origin",
+		Organization: "htan",
+		Project:      "p1",
+		DRSIDs:       []string{"did-a", "did-b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected dataset:\n got %+v\nwant %+v", got, want)
+	}
+}
@@ -1,9 +1,12 @@
 package push
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/registerpolicy"
 	"github.com/calypr/git-drs/internal/testutils"
 	"github.com/stretchr/testify/assert"
 )
@@ -38,3 +41,38 @@ func TestPushRun_DefaultRemoteError(t *testing.T) {
 	err := Cmd.RunE(Cmd, []string{})
 	assert.Error(t, err)
 }
+
+func TestSplitByRegisterPolicyAndPushExcluded(t *testing.T) {
+	origLoad, origRun, origHooks := loadRegister, runCommand, pushWithHooks
+	t.Cleanup(func() { loadRegister, runCommand, pushWithHooks = origLoad, origRun, origHooks })
+	loadRegister = func() (registerpolicy.Policy, error) {
+		return registerpolicy.Policy{Exclude: []string{"*.tmp"}}, nil
+	}
+	shared := strings.Repeat("a", 64)
+	scratch := strings.Repeat("b", 64)
+
+	registered, excluded, err := splitByRegisterPolicy(map[string]lfs.LfsFileInfo{
+		"data/a.bam":  {Oid: shared},
+		"copy.tmp":    {Oid: shared},
+		"scratch.tmp": {Oid: "sha256:" + scratch},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, registered, 1)
+	assert.Contains(t, registered, "data/a.bam")
+	// An oid that a registered path also uses is uploaded by the DRS flow.
+	assert.Equal(t, []string{scratch}, excluded)
+
+	var calls [][]string
+	runCommand = func(name string, args ...string) ([]byte, error) {
+		calls = append(calls, append([]string{name}, args...))
+		return nil, nil
+	}
+	pushWithHooks = false
+	assert.NoError(t, pushExcludedObjects(config.Remote("origin"), excluded))
+	assert.Equal(t, [][]string{{"git", "lfs", "push", "origin", "--object-id", scratch}}, calls)
+
+	calls = nil
+	pushWithHooks = true
+	assert.NoError(t, pushExcludedObjects(config.Remote("origin"), excluded))
+	assert.Empty(t, calls)
+}
@@ -0,0 +1,64 @@
+package push
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+// loadRegister is swapped in tests.
+var loadRegister = config.RegisterSettings
+
+// splitByRegisterPolicy returns the files to register and the oids of files
+// excluded by drs.register.* that no registered path shares.
+func splitByRegisterPolicy(lfsFiles map[string]lfs.LfsFileInfo) (map[string]lfs.LfsFileInfo, []string, error) {
+	policy, err := loadRegister()
+	if err != nil {
+		return nil, nil, err
+	}
+	registered, excluded := policy.Filter(lfsFiles)
+	registeredOIDs := make(map[string]bool, len(registered))
+	for _, info := range registered {
+		registeredOIDs[drsobject.NormalizeOid(info.Oid)] = true
+	}
+	seen := make(map[string]bool)
+	var oids []string
+	for _, info := range excluded {
+		oid := drsobject.NormalizeOid(info.Oid)
+		if oid == "" || registeredOIDs[oid] || seen[oid] {
+			continue
+		}
+		seen[oid] = true
+		oids = append(oids, oid)
+	}
+	sort.Strings(oids)
+	return registered, oids, nil
+}
+
+// pushExcludedObjects uploads objects excluded from registration as plain LFS
+// objects. git push runs without hooks by default, so git-lfs would not
+// upload them on its own; with --with-hooks its pre-push hook does.
+func pushExcludedObjects(remote config.Remote, oids []string) error {
+	if len(oids) == 0 || pushWithHooks {
+		return nil
+	}
+	args := append([]string{"lfs", "push", string(remote), "--object-id"}, oids...)
+	out, err := runCommand("git", args...)
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("git lfs push of %d unregistered objects failed for remote %q: %s", len(oids), remote, msg)
+	}
+	if !common.JSONOutput() {
+		fmt.Fprintf(os.Stdout, "Pushed %d objects excluded by drs.register.* as plain LFS objects.\n", len(oids))
+	}
+	return nil
+}
@@ -18,6 +18,8 @@ var remote string
 var checksum = false
 var pretty = false
 
+var resolveObject = drsremote.ResolveObject
+
 func queryByChecksum(ctx context.Context, gc *config.GitContext, checksum string) ([]drsapi.DrsObject, error) {
 	hashType := checksumTypeForString(checksum)
 	if hashType != hash.ChecksumTypeSHA256.String() {
@@ -45,7 +47,10 @@ func checksumTypeForString(sum string) string {
 var Cmd = &cobra.Command{
 	Use:   "query <drs_id>",
 	Short: "Query DRS server by DRS ID",
-	Long:  "Query DRS server by DRS ID",
+	Long: "Description:" +
+		"\n  Query the DRS server by DRS ID. The ID may also be given as a DRS URI" +
+		"\n  (drs://host/id), a compact identifier (drs://prefix:accession), or an" +
+		"\n  alias such as an accession number.",
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(args) != 1 {
 			cmd.SilenceUsage = false
@@ -72,11 +77,21 @@ var Cmd = &cobra.Command{
 			return err
 		}
 
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
 		if checksum {
-			objs, err := queryByChecksum(context.Background(), gc, args[0])
+			objs, err := queryByChecksum(ctx, gc, args[0])
 			if err != nil {
 				return err
 			}
+			if common.JSONOutput() {
+				if objs == nil {
+					objs = []drsapi.DrsObject{}
+				}
+				return common.WriteJSON(cmd.OutOrStdout(), objs)
+			}
 			for _, drsObj := range objs {
 				if err := common.PrintDRSObject(drsObj, pretty); err != nil {
 					return err
@@ -85,11 +100,14 @@ var Cmd = &cobra.Command{
 			return nil
 		}
 
-		obj, err := gc.Client.DRS().GetObject(context.Background(), args[0])
+		obj, err := resolveObject(ctx, gc, args[0])
 		if err != nil {
 			return err
 		}
-		return common.PrintDRSObject(obj, pretty)
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), *obj)
+		}
+		return common.PrintDRSObject(*obj, pretty)
 	},
 }
 
@@ -0,0 +1,192 @@
+package rekey
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pathmap"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remote string
+	dryRun bool
+)
+
+var (
+	loadConfig    = config.LoadConfig
+	loadIDMinter  = config.LoadIDMinter
+	loadInventory = lfs.GetTrackedLfsFiles
+	gitTopLevel   = gitrepo.GitTopLevel
+)
+
+// Change is one staged object whose DRS ID differs from the one the
+// configured strategy mints.
+type Change struct {
+	Path  string `json:"path"`
+	OID   string `json:"oid"`
+	OldID string `json:"old_id"`
+	NewID string `json:"new_id"`
+}
+
+// Report is the JSON form of a rekey run.
+type Report struct {
+	Remote   string   `json:"remote"`
+	Strategy string   `json:"strategy"`
+	Prefix   string   `json:"prefix,omitempty"`
+	DryRun   bool     `json:"dry_run"`
+	Changes  []Change `json:"changes"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "Re-mint the DRS IDs of staged objects with the configured ID strategy",
+	Long: "Description:" +
+		"\n  Recompute the DRS ID of the staged object of every LFS file in the" +
+		"\n  worktree with the remote's drs.remote.<name>.id-strategy and id-prefix," +
+		"\n  and rewrite the objects whose ID differs. Run it after changing the" +
+		"\n  strategy so new and existing objects are keyed the same way. Under the" +
+		"\n  server strategy only locally minted IDs are cleared; IDs recorded from" +
+		"\n  the server are kept.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: accepts no arguments, received %d\n\nUsage: %s\n\nSee 'git drs rekey --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := drslog.GetLogger()
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("error loading config: %w", err)
+		}
+		remoteName, err := cfg.GetRemoteOrDefault(remote)
+		if err != nil {
+			return err
+		}
+		drsRemote := cfg.GetRemote(remoteName)
+		if drsRemote == nil {
+			return fmt.Errorf("remote %q is not configured", remoteName)
+		}
+		ids, err := loadIDMinter(string(remoteName))
+		if err != nil {
+			return err
+		}
+		files, err := loadInventory(logger)
+		if err != nil {
+			return fmt.Errorf("error listing LFS files: %w", err)
+		}
+
+		project := func(path string) string { return config.LocalProjectIDForPath(drsRemote, path) }
+		changes, err := rekey(common.DRS_OBJS_PATH, files, ids, project, dryRun)
+		if err != nil {
+			return err
+		}
+		report := Report{
+			Remote:   string(remoteName),
+			Strategy: string(ids.Strategy),
+			Prefix:   ids.Prefix,
+			DryRun:   dryRun,
+			Changes:  changes,
+		}
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), report)
+		}
+		writeReport(cmd.OutOrStdout(), report)
+		if len(changes) > 0 && !dryRun {
+			if root, err := gitTopLevel(); err == nil && pathmap.Enabled(root) {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Run 'git drs map rebuild' to update %s with the new IDs.\n", pathmap.Dir)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote whose ID strategy and project apply (default: default remote)")
+	Cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list the IDs that would change without rewriting objects")
+}
+
+// rekey re-mints the IDs of the objects staged under basePath for files.
+// An oid at several paths is keyed by the first path in sort order, since
+// one object is staged per oid. Files without a staged object are skipped.
+func rekey(basePath string, files map[string]lfs.LfsFileInfo, ids drsobject.Minter, project func(string) string, dryRun bool) ([]Change, error) {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	changes := []Change{}
+	seen := map[string]bool{}
+	for _, path := range paths {
+		oid := drsobject.NormalizeOid(files[path].Oid)
+		if oid == "" || seen[oid] {
+			continue
+		}
+		seen[oid] = true
+		obj, err := drsobject.ReadObject(basePath, oid)
+		if err != nil || obj == nil {
+			continue
+		}
+		p := project(path)
+		newID := ids.ID(p, path, oid)
+		if ids.Strategy == drsobject.IDServer && !mintedLocally(obj.Id, p, path, oid) {
+			newID = obj.Id
+		}
+		if obj.Id == newID {
+			continue
+		}
+		changes = append(changes, Change{Path: path, OID: oid, OldID: obj.Id, NewID: newID})
+		if dryRun {
+			continue
+		}
+		obj.Id = newID
+		obj.SelfUri = ""
+		if newID != "" {
+			obj.SelfUri = "drs://" + newID
+		}
+		if err := drsobject.WriteObject(basePath, obj, oid); err != nil {
+			return changes, fmt.Errorf("rewrite staged object for %s: %w", path, err)
+		}
+	}
+	return changes, nil
+}
+
+// mintedLocally reports whether id is one git-drs mints for oid, with any
+// prefix, rather than one recorded from the server.
+func mintedLocally(id, project, path, oid string) bool {
+	if id == "" {
+		return false
+	}
+	return strings.HasSuffix(id, drsobject.DeterministicID(project, oid)) ||
+		strings.HasSuffix(id, drsobject.Minter{Strategy: drsobject.IDPathHash}.ID(project, path, oid))
+}
+
+func writeReport(w io.Writer, r Report) {
+	for _, c := range r.Changes {
+		fmt.Fprintf(w, "%s\t%s -> %s\n", c.Path, displayID(c.OldID), displayID(c.NewID))
+	}
+	verb := "Re-keyed"
+	if r.DryRun {
+		verb = "Would re-key"
+	}
+	fmt.Fprintf(w, "%s %d staged objects for remote %s (strategy %s)\n", verb, len(r.Changes), r.Remote, r.Strategy)
+}
+
+func displayID(id string) string {
+	if id == "" {
+		return "(none)"
+	}
+	return id
+}
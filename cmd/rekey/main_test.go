@@ -0,0 +1,74 @@
+package rekey
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+func stage(t *testing.T, base, oid, id string) {
+	t.Helper()
+	obj, err := drsobject.Builder{Project: "proj"}.Build("f", oid, 1, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := drsobject.WriteObject(base, obj, oid); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func stagedID(t *testing.T, base, oid string) string {
+	t.Helper()
+	obj, err := drsobject.ReadObject(base, oid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return obj.Id
+}
+
+func TestRekey(t *testing.T) {
+	base := t.TempDir()
+	a, b, c := strings.Repeat("a", 64), strings.Repeat("b", 64), strings.Repeat("c", 64)
+	stage(t, base, a, drsobject.DeterministicID("proj", a))
+	stage(t, base, b, drsobject.DeterministicID("proj", b))
+	files := map[string]lfs.LfsFileInfo{
+		"z/a.bin":   {Oid: a},
+		"a/dup.bin": {Oid: a},
+		"b.bin":     {Oid: b},
+		"c.bin":     {Oid: c}, // not staged
+	}
+	project := func(string) string { return "proj" }
+	paths := drsobject.Minter{Strategy: drsobject.IDPathHash}
+
+	changes, err := rekey(base, files, paths, project, true)
+	if err != nil {
+		t.Fatalf("rekey dry run: %v", err)
+	}
+	if len(changes) != 2 || stagedID(t, base, a) != drsobject.DeterministicID("proj", a) {
+		t.Fatalf("dry run changes = %+v or rewrote objects", changes)
+	}
+
+	if _, err := rekey(base, files, paths, project, false); err != nil {
+		t.Fatalf("rekey: %v", err)
+	}
+	if got, want := stagedID(t, base, a), paths.ID("proj", "a/dup.bin", a); got != want {
+		t.Fatalf("a keyed %q, want the first path's ID %q", got, want)
+	}
+	if changes, _ := rekey(base, files, paths, project, false); len(changes) != 0 {
+		t.Fatalf("second run changed %+v", changes)
+	}
+
+	stage(t, base, b, "server-assigned-id")
+	changes, err = rekey(base, files, drsobject.Minter{Strategy: drsobject.IDServer}, project, false)
+	if err != nil {
+		t.Fatalf("rekey to server: %v", err)
+	}
+	if len(changes) != 1 || changes[0].OID != a || changes[0].NewID != "" {
+		t.Fatalf("server changes = %+v; want only the locally minted ID cleared", changes)
+	}
+	if stagedID(t, base, a) != "" || stagedID(t, base, b) != "server-assigned-id" {
+		t.Fatalf("staged IDs after server rekey: %q, %q", stagedID(t, base, a), stagedID(t, base, b))
+	}
+}
@@ -0,0 +1,432 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/release"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	syfoncommon "github.com/calypr/syfon/common"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remote string
+	ref    string
+	bundle bool
+	sign   bool
+)
+
+var (
+	loadConfig      = config.LoadConfig
+	newRemoteClient = func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+		return cfg.GetRemoteClient(remote, logger)
+	}
+	loadInventory = func(commit string, logger *slog.Logger) (map[string]lfs.LfsFileInfo, error) {
+		return lfs.GetLfsFilesForRefs([]string{commit}, logger)
+	}
+	lookupObjects  = drsremote.ObjectsByHashesForScope
+	resolveObject  = drsremote.ResolveObject
+	registerBundle = func(ctx context.Context, gc *config.GitContext, candidate drsapi.DrsObjectCandidate) (string, error) {
+		resp, err := gc.Client.DRS().RegisterObjects(ctx, drsapi.RegisterObjectsJSONRequestBody{
+			Candidates: []drsapi.DrsObjectCandidate{candidate},
+		})
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Objects) == 0 {
+			return "", fmt.Errorf("server registered no record")
+		}
+		return resp.Objects[0].Id, nil
+	}
+	now = time.Now
+)
+
+// verifyResult is the --json document for release verify.
+type verifyResult struct {
+	Name      string   `json:"name"`
+	Commit    string   `json:"commit"`
+	Objects   int      `json:"objects"`
+	Resolved  int      `json:"resolved"`
+	Missing   []string `json:"missing"`
+	Mismatch  []string `json:"mismatched"`
+	Bundle    string   `json:"bundle,omitempty"`
+	Signature string   `json:"signature"`
+	OK        bool     `json:"ok"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "release",
+	Short: "Freeze the DRS objects of a commit into a release manifest",
+	Long: "Description:" +
+		"\n  A release records the DRS ID of every tracked file in a commit in a" +
+		"\n  sealed manifest at .drs/releases/<name>.json, committed to the" +
+		"\n  repository. Publications can cite the release and check later that" +
+		"\n  every object it lists still resolves.",
+}
+
+// CreateCmd writes and commits a release manifest.
+var CreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Record the DRS objects referenced at a tag or commit",
+	Long: "Description:" +
+		"\n  Resolve every LFS pointer in the commit named by --ref (default: the tag" +
+		"\n  <name>) to its DRS record, write the manifest to .drs/releases/<name>.json," +
+		"\n  and commit it. Every object must already be registered. --sign signs the" +
+		"\n  manifest commit with the configured git signing key, and --bundle also" +
+		"\n  registers the release as a DRS bundle.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires exactly 1 argument (release name), received %d\n\nUsage: %s\n\nSee 'git drs release create --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := drslog.GetLogger()
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		name := args[0]
+		if err := release.ValidateName(name); err != nil {
+			return err
+		}
+		root, err := gitOutput("rev-parse", "--show-toplevel")
+		if err != nil {
+			return err
+		}
+		path := release.Path(root, name)
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("release %s already exists at %s; releases cannot be changed once created", name, path)
+		}
+		target := ref
+		if target == "" {
+			target = name
+		}
+		commit, err := gitOutput("rev-parse", "--verify", "--quiet", target+"^{commit}")
+		if err != nil {
+			return fmt.Errorf("unknown commit %q; tag the release or pass --ref", target)
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("error loading config: %w", err)
+		}
+		remoteName, err := cfg.GetRemoteOrDefault(remote)
+		if err != nil {
+			return err
+		}
+		gc, err := newRemoteClient(cfg, remoteName, logger)
+		if err != nil {
+			return err
+		}
+		if bundle {
+			if err := gc.RequireWrite(); err != nil {
+				return err
+			}
+		}
+		inventory, err := loadInventory(commit, logger)
+		if err != nil {
+			return fmt.Errorf("error listing tracked files: %w", err)
+		}
+		objects, err := resolveObjects(ctx, gc, inventory)
+		if err != nil {
+			return err
+		}
+
+		m := release.Manifest{
+			Name:         name,
+			Commit:       commit,
+			Created:      now().UTC().Format(time.RFC3339),
+			Remote:       string(remoteName),
+			Organization: gc.Organization,
+			Project:      gc.ProjectId,
+			Signed:       sign,
+			Objects:      objects,
+		}
+		if err := m.Seal(); err != nil {
+			return err
+		}
+		if bundle {
+			id, err := registerBundle(ctx, gc, bundleCandidate(m, gc))
+			if err != nil {
+				return fmt.Errorf("error registering release bundle: %w", err)
+			}
+			audit.RecordOrWarn(logger, audit.Event{
+				Action:  audit.ActionRegister,
+				Remote:  string(remoteName),
+				Project: gc.ProjectId,
+				DRSID:   id,
+				Detail:  "release bundle " + name,
+			})
+			m.BundleID = id
+			if err := m.Seal(); err != nil {
+				return err
+			}
+		}
+
+		if err := release.Write(path, m); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		commitArgs := []string{"commit", "-m", "Release " + name}
+		if sign {
+			commitArgs = append(commitArgs, "-S")
+		}
+		if _, err := gitOutput("add", "--", rel); err != nil {
+			return err
+		}
+		if _, err := gitOutput(append(commitArgs, "--", rel)...); err != nil {
+			return fmt.Errorf("manifest written to %s but not committed: %w", rel, err)
+		}
+
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), m)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "release %s: %d objects from %s recorded in %s\n", name, len(m.Objects), shortHash(commit), rel)
+		if m.BundleID != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "registered bundle %s\n", m.BundleID)
+		}
+		return nil
+	},
+}
+
+// VerifyCmd checks a release manifest against the remote.
+var VerifyCmd = &cobra.Command{
+	Use:   "verify <name>",
+	Short: "Check that every object in a release still resolves",
+	Long: "Description:" +
+		"\n  Check the manifest's digest, the signature on the commit that added it" +
+		"\n  when the release was signed, and that every DRS ID it lists (and its" +
+		"\n  bundle, if any) still resolves on the remote with the recorded checksum.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires exactly 1 argument (release name), received %d\n\nUsage: %s\n\nSee 'git drs release verify --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := drslog.GetLogger()
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		name := args[0]
+		if err := release.ValidateName(name); err != nil {
+			return err
+		}
+		root, err := gitOutput("rev-parse", "--show-toplevel")
+		if err != nil {
+			return err
+		}
+		path := release.Path(root, name)
+		m, err := release.Read(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no release %s in %s", name, release.Dir)
+		}
+		if err != nil {
+			return err
+		}
+
+		res, err := verify(ctx, root, path, m, logger)
+		if common.JSONOutput() {
+			res.OK = err == nil
+			if err != nil {
+				res.Error = err.Error()
+			}
+			if werr := common.WriteJSON(cmd.OutOrStdout(), res); werr != nil {
+				return werr
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("release %s failed verification: %w", name, err)
+		}
+		if common.JSONOutput() {
+			return nil
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "release %s OK: %d objects resolve (signature: %s)\n", name, res.Resolved, res.Signature)
+		return nil
+	},
+}
+
+func init() {
+	CreateCmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote to resolve records from (default: default remote)")
+	CreateCmd.Flags().StringVar(&ref, "ref", "", "commit to release (default: the tag named <name>)")
+	CreateCmd.Flags().BoolVar(&bundle, "bundle", false, "also register the release as a DRS bundle")
+	CreateCmd.Flags().BoolVarP(&sign, "sign", "S", false, "sign the manifest commit (git commit -S)")
+	VerifyCmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote to resolve records from (default: the release's remote)")
+	Cmd.AddCommand(CreateCmd)
+	Cmd.AddCommand(VerifyCmd)
+}
+
+// resolveObjects looks up the DRS record of every pointer in inventory and
+// fails when any is unregistered.
+func resolveObjects(ctx context.Context, gc *config.GitContext, inventory map[string]lfs.LfsFileInfo) ([]release.Object, error) {
+	seen := make(map[string]bool)
+	var oids []string
+	for _, info := range inventory {
+		if info.Oid != "" && !seen[info.Oid] {
+			seen[info.Oid] = true
+			oids = append(oids, info.Oid)
+		}
+	}
+	records := map[string][]drsapi.DrsObject{}
+	if len(oids) > 0 {
+		var err error
+		if records, err = lookupObjects(ctx, gc, oids); err != nil {
+			return nil, fmt.Errorf("error querying remote: %w", err)
+		}
+	}
+
+	objects := make([]release.Object, 0, len(inventory))
+	var unregistered []string
+	for path, info := range inventory {
+		if info.Oid == "" {
+			continue
+		}
+		recs := records[info.Oid]
+		if len(recs) == 0 {
+			unregistered = append(unregistered, path)
+			continue
+		}
+		objects = append(objects, release.Object{Path: path, OID: info.Oid, Size: info.Size, DRSID: recs[0].Id})
+	}
+	if len(unregistered) > 0 {
+		return nil, fmt.Errorf("%d files are not registered on the remote (push them first): %s", len(unregistered), summarize(unregistered))
+	}
+	return objects, nil
+}
+
+func bundleCandidate(m release.Manifest, gc *config.GitContext) drsapi.DrsObjectCandidate {
+	contents := make([]drsapi.ContentsObject, 0, len(m.Objects))
+	for _, o := range m.Objects {
+		id := o.DRSID
+		contents = append(contents, drsapi.ContentsObject{Name: o.Path, Id: &id})
+	}
+	name := m.Name
+	description := fmt.Sprintf("git-drs release %s of commit %s", m.Name, m.Commit)
+	size := m.TotalBytes
+	candidate := drsapi.DrsObjectCandidate{
+		Name:        &name,
+		Description: &description,
+		Version:     &name,
+		Size:        size,
+		Checksums:   []drsapi.Checksum{{Type: "sha256", Checksum: m.BundleChecksum()}},
+		Contents:    &contents,
+	}
+	if authzMap := syfoncommon.AuthzMapFromScope(gc.Organization, gc.ProjectId); authzMap != nil {
+		controlled := syfoncommon.AuthzMapToControlledAccess(authzMap)
+		candidate.ControlledAccess = &controlled
+	}
+	return candidate
+}
+
+func verify(ctx context.Context, root, path string, m release.Manifest, logger *slog.Logger) (verifyResult, error) {
+	res := verifyResult{Name: m.Name, Commit: m.Commit, Objects: len(m.Objects), Missing: []string{}, Mismatch: []string{}, Bundle: m.BundleID, Signature: "unsigned"}
+	if err := m.Check(); err != nil {
+		return res, err
+	}
+	if m.Signed {
+		rel, _ := filepath.Rel(root, path)
+		added, err := gitOutput("log", "--diff-filter=A", "--format=%H", "-1", "--", filepath.ToSlash(rel))
+		if err != nil || added == "" {
+			return res, fmt.Errorf("cannot find the commit that added %s", rel)
+		}
+		if _, err := gitOutput("verify-commit", added); err != nil {
+			res.Signature = "bad"
+			return res, fmt.Errorf("signature on %s does not verify: %w", shortHash(added), err)
+		}
+		res.Signature = "verified"
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return res, fmt.Errorf("error loading config: %w", err)
+	}
+	name := remote
+	if name == "" {
+		name = m.Remote
+	}
+	remoteName, err := cfg.GetRemoteOrDefault(name)
+	if err != nil {
+		return res, err
+	}
+	gc, err := newRemoteClient(cfg, remoteName, logger)
+	if err != nil {
+		return res, err
+	}
+
+	for _, o := range m.Objects {
+		obj, err := resolveObject(ctx, gc, o.DRSID)
+		switch {
+		case err != nil || obj == nil:
+			res.Missing = append(res.Missing, o.Path)
+		case drsobject.SHA256(*obj) != o.OID:
+			res.Mismatch = append(res.Mismatch, o.Path)
+		default:
+			res.Resolved++
+		}
+	}
+	if m.BundleID != "" {
+		if obj, err := resolveObject(ctx, gc, m.BundleID); err != nil || obj == nil {
+			res.Missing = append(res.Missing, "bundle "+m.BundleID)
+		}
+	}
+	var problems []string
+	if len(res.Missing) > 0 {
+		problems = append(problems, fmt.Sprintf("%d no longer resolve: %s", len(res.Missing), summarize(res.Missing)))
+	}
+	if len(res.Mismatch) > 0 {
+		problems = append(problems, fmt.Sprintf("%d resolve to different content: %s", len(res.Mismatch), summarize(res.Mismatch)))
+	}
+	if len(problems) > 0 {
+		return res, errors.New(strings.Join(problems, "; "))
+	}
+	return res, nil
+}
+
+// summarize lists the first few paths.
+func summarize(paths []string) string {
+	const max = 5
+	if len(paths) <= max {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s, and %d more", strings.Join(paths[:max], ", "), len(paths)-max)
+}
+
+var gitOutput = func(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func shortHash(s string) string {
+	if len(s) > 10 {
+		return s[:10]
+	}
+	return s
+}
@@ -0,0 +1,109 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/release"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func stubRemote(t *testing.T) {
+	t.Helper()
+	origLoad, origClient, origLookup, origResolve, origGit := loadConfig, newRemoteClient, lookupObjects, resolveObject, gitOutput
+	t.Cleanup(func() {
+		loadConfig, newRemoteClient, lookupObjects, resolveObject, gitOutput = origLoad, origClient, origLookup, origResolve, origGit
+		remote = ""
+	})
+	loadConfig = func() (*config.Config, error) {
+		return &config.Config{
+			DefaultRemote: "origin",
+			Remotes:       map[config.Remote]config.RemoteSelect{"origin": {Gen3: &config.Gen3Remote{ProjectID: "p"}}},
+		}, nil
+	}
+	newRemoteClient = func(*config.Config, config.Remote, *slog.Logger) (*config.GitContext, error) {
+		return &config.GitContext{}, nil
+	}
+}
+
+func TestResolveObjectsRequiresRegistration(t *testing.T) {
+	stubRemote(t)
+	oidA, oidB := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	lookupObjects = func(_ context.Context, _ *config.GitContext, oids []string) (map[string][]drsapi.DrsObject, error) {
+		return map[string][]drsapi.DrsObject{oidA: {{Id: "drs-a"}}}, nil
+	}
+	inventory := map[string]lfs.LfsFileInfo{
+		"a.bin":      {Oid: oidA, Size: 1},
+		"copy/a.bin": {Oid: oidA, Size: 1},
+	}
+	objects, err := resolveObjects(context.Background(), &config.GitContext{}, inventory)
+	if err != nil || len(objects) != 2 || objects[0].DRSID != "drs-a" {
+		t.Fatalf("resolveObjects = %+v, %v", objects, err)
+	}
+
+	inventory["b.bin"] = lfs.LfsFileInfo{Oid: oidB, Size: 2}
+	if _, err := resolveObjects(context.Background(), &config.GitContext{}, inventory); err == nil || !strings.Contains(err.Error(), "b.bin") {
+		t.Fatalf("unregistered file error = %v", err)
+	}
+}
+
+func TestVerifyReportsMissingAndChangedObjects(t *testing.T) {
+	stubRemote(t)
+	oidA, oidB, oidC := strings.Repeat("a", 64), strings.Repeat("b", 64), strings.Repeat("c", 64)
+	m := release.Manifest{Name: "v1", Commit: "c1", Remote: "origin", Objects: []release.Object{
+		{Path: "a.bin", OID: oidA, DRSID: "drs-a"},
+		{Path: "b.bin", OID: oidB, DRSID: "drs-b"},
+		{Path: "c.bin", OID: oidC, DRSID: "drs-c"},
+	}}
+	if err := m.Seal(); err != nil {
+		t.Fatal(err)
+	}
+	resolveObject = func(_ context.Context, _ *config.GitContext, id string) (*drsapi.DrsObject, error) {
+		switch id {
+		case "drs-a":
+			return &drsapi.DrsObject{Id: id, Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: oidA}}}, nil
+		case "drs-b":
+			return &drsapi.DrsObject{Id: id, Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: oidC}}}, nil
+		}
+		return nil, fmt.Errorf("object not found")
+	}
+
+	res, err := verify(context.Background(), "/repo", release.Path("/repo", "v1"), m, nil)
+	if err == nil {
+		t.Fatal("verify succeeded with a missing and a changed object")
+	}
+	if res.Resolved != 1 || len(res.Missing) != 1 || res.Missing[0] != "c.bin" || len(res.Mismatch) != 1 || res.Mismatch[0] != "b.bin" {
+		t.Fatalf("verify result = %+v", res)
+	}
+	if res.Signature != "unsigned" {
+		t.Fatalf("signature = %q", res.Signature)
+	}
+}
+
+func TestVerifyChecksSignatureOfSignedRelease(t *testing.T) {
+	stubRemote(t)
+	m := release.Manifest{Name: "v1", Commit: "c1", Remote: "origin", Signed: true}
+	if err := m.Seal(); err != nil {
+		t.Fatal(err)
+	}
+	var calls []string
+	gitOutput = func(args ...string) (string, error) {
+		calls = append(calls, strings.Join(args, " "))
+		if args[0] == "verify-commit" {
+			return "", fmt.Errorf("git verify-commit: no signature found")
+		}
+		return "abc123", nil
+	}
+	res, err := verify(context.Background(), "/repo", release.Path("/repo", "v1"), m, nil)
+	if err == nil || res.Signature != "bad" {
+		t.Fatalf("verify = %+v, %v; want a signature failure", res, err)
+	}
+	if len(calls) != 2 || calls[0] != "log --diff-filter=A --format=%H -1 -- .drs/releases/v1.json" || calls[1] != "verify-commit abc123" {
+		t.Fatalf("git calls = %q", calls)
+	}
+}
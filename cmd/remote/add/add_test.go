@@ -20,6 +20,17 @@ func TestGen3Cmd(t *testing.T) {
 	assert.Equal(t, "gen3 [remote-name] <organization/project>", Gen3Cmd.Use)
 }
 
+func TestFilesystemCmd(t *testing.T) {
+	assert.Equal(t, "filesystem <remote-name> <directory> <organization/project>", FilesystemCmd.Use)
+	assert.Error(t, FilesystemCmd.Args(FilesystemCmd, []string{"airgap", "/srv/drs"}))
+}
+
+func TestAnvilCmd(t *testing.T) {
+	assert.Equal(t, "anvil <remote-name> <organization/project>", AnvilCmd.Use)
+	assert.NotNil(t, AnvilCmd.Flags().Lookup("billing-project"))
+	assert.Error(t, AnvilCmd.Args(AnvilCmd, []string{"terra"}))
+}
+
 func TestParseScopeArg(t *testing.T) {
 	t.Run("splits org and project on slash", func(t *testing.T) {
 		org, project, err := parseScopeArg("HTAN_INT/BForePC")
@@ -0,0 +1,50 @@
+package add
+
+import (
+	"fmt"
+
+	"github.com/calypr/git-drs/cmd/initialize"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/spf13/cobra"
+)
+
+var AnvilCmd = &cobra.Command{
+	Use:   "anvil <remote-name> <organization/project>",
+	Short: "Add AnVIL/Terra as a read-only DRS remote",
+	Long: "Add AnVIL/Terra as a read-only DRS remote. DRS URIs are resolved through DRSHub with your Google credentials, " +
+		"and objects in requester-pays buckets are read with a SAM pet service account token billed to --billing-project. " +
+		"Reference objects with 'git drs add-ref --remote <remote-name> drs://...'; pull and fetch then download them. " +
+		"Credentials come from " + config.RemoteAuthEnv("<name>", "TOKEN") + ", drs.remote.<name>.token, " +
+		"or Application Default Credentials ('gcloud auth application-default login').",
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remoteName := args[0]
+
+		if err := initialize.EnsureInitialized(drslog.GetLogger()); err != nil {
+			return fmt.Errorf("failed to initialize repository: %w", err)
+		}
+		organization, project, err := parseScopeArg(args[1])
+		if err != nil {
+			return err
+		}
+
+		remoteSelect := config.RemoteSelect{
+			Anvil: &config.AnvilRemote{
+				Endpoint:       anvilEndpoint,
+				SAM:            anvilSAM,
+				DRSHost:        anvilDRSHost,
+				ProjectID:      project,
+				Organization:   organization,
+				BillingProject: anvilBillingProject,
+			},
+		}
+		newConfig, err := config.UpdateRemote(config.Remote(remoteName), remoteSelect)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Added remote '%s'. Config: %v\n", remoteName, newConfig.GetRemote(config.Remote(remoteName)))
+		return nil
+	},
+}
@@ -0,0 +1,61 @@
+package add
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/calypr/git-drs/cmd/initialize"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/fsremote"
+	"github.com/spf13/cobra"
+)
+
+var FilesystemCmd = &cobra.Command{
+	Use:   "filesystem <remote-name> <directory> <organization/project>",
+	Short: "Add a directory as a DRS remote for air-gapped deployments",
+	Long: "Add a directory, typically on a shared POSIX filesystem, as a DRS remote. " +
+		"Pushed objects are copied into a content-addressed tree under the directory and their records kept in a JSON index next to it; " +
+		"pull, query and delete read the same index. No server or credentials are needed. " +
+		"Copy the records and content to a Gen3 remote later with 'git drs replicate --from <remote-name> --to <gen3-remote>'.",
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remoteName := args[0]
+		dir := strings.TrimSpace(args[1])
+		scopeArg := args[2]
+
+		if err := initialize.EnsureInitialized(drslog.GetLogger()); err != nil {
+			return fmt.Errorf("failed to initialize repository: %w", err)
+		}
+		if dir == "" {
+			return fmt.Errorf("directory cannot be empty")
+		}
+		root, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		organization, project, err := parseScopeArg(scopeArg)
+		if err != nil {
+			return err
+		}
+		if _, err := fsremote.Open(root, organization, project); err != nil {
+			return err
+		}
+
+		remoteSelect := config.RemoteSelect{
+			Filesystem: &config.FilesystemRemote{
+				Root:         root,
+				ProjectID:    project,
+				Organization: organization,
+			},
+		}
+		newConfig, err := config.UpdateRemote(config.Remote(remoteName), remoteSelect)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Added remote '%s'. Config: %v\n", remoteName, newConfig.GetRemote(config.Remote(remoteName)))
+		return nil
+	},
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/drslog"
 	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/httpclient"
+	"github.com/calypr/git-drs/internal/projectmap"
 	bucketapi "github.com/calypr/syfon/apigen/client/bucketapi"
 	conf "github.com/calypr/syfon/client/config"
 	syfoncommon "github.com/calypr/syfon/common"
@@ -41,7 +43,12 @@ var Gen3Cmd = &cobra.Command{
 			scopeArg = args[1]
 		}
 
-		err := gen3Init(remoteName, credFile, fenceToken, scopeArg, logg)
+		var err error
+		if strings.TrimSpace(authMode) != "" {
+			err = gen3InitAnonymous(remoteName, authMode, gen3Endpoint, scopeArg, logg)
+		} else {
+			err = gen3Init(remoteName, credFile, fenceToken, scopeArg, logg)
+		}
 		if err != nil {
 			return fmt.Errorf("error configuring gen3 server: %v", err)
 		}
@@ -60,6 +67,17 @@ func gen3Init(remoteName, credFile, fenceToken, scopeArg string, logg *slog.Logg
 	if err != nil {
 		return err
 	}
+	if _, err := projectmap.Parse(localScope, organization, project); err != nil {
+		return err
+	}
+	if err := config.IsValidCredentialStore(credentialStore); err != nil {
+		return err
+	}
+	store := strings.TrimSpace(credentialStore)
+	if store == "" {
+		// Re-adding a remote keeps its store unless the flag says otherwise.
+		store = config.RemoteCredentialStore(remoteName)
+	}
 
 	var accessToken, apiKey, keyID, apiEndpoint string
 	configure := conf.NewConfigure(logg)
@@ -87,7 +105,7 @@ func gen3Init(remoteName, credFile, fenceToken, scopeArg string, logg *slog.Logg
 		}
 
 	default:
-		existing, err := configure.Load(remoteName)
+		existing, err := config.LoadProfile(store, remoteName, logg)
 		if err != nil {
 			return fmt.Errorf("failed to load %s config: %w", remoteName, err)
 		} else {
@@ -131,11 +149,13 @@ func gen3Init(remoteName, credFile, fenceToken, scopeArg string, logg *slog.Logg
 
 	remoteGen3 := config.RemoteSelect{
 		Gen3: &config.Gen3Remote{
-			Endpoint:      apiEndpoint,
-			ProjectID:     project,
-			Organization:  organization,
-			Bucket:        resolvedBucket,
-			StoragePrefix: resolvedStoragePrefix,
+			Endpoint:        apiEndpoint,
+			ProjectID:       project,
+			Organization:    organization,
+			Bucket:          resolvedBucket,
+			StoragePrefix:   resolvedStoragePrefix,
+			LocalScope:      strings.TrimSpace(localScope),
+			CredentialStore: store,
 		},
 	}
 
@@ -145,7 +165,7 @@ func gen3Init(remoteName, credFile, fenceToken, scopeArg string, logg *slog.Logg
 	}
 	logg.Debug(fmt.Sprintf("Remote added/updated: %s → %s (project: %s, bucket: %s, storage_prefix: %s)", remoteName, apiEndpoint, project, resolvedBucket, resolvedStoragePrefix))
 
-	if err := configure.Save(cred); err != nil {
+	if err := config.SaveProfile(store, cred, logg); err != nil {
 		return fmt.Errorf("failed to configure/update Gen3 profile: %w", err)
 	}
 	// Configure stock git credential plumbing for lfs + persist the refreshed token locally.
@@ -155,7 +175,8 @@ func gen3Init(remoteName, credFile, fenceToken, scopeArg string, logg *slog.Logg
 	if err := gitrepo.SetRemoteLFSURL(remoteName, apiEndpoint); err != nil {
 		return fmt.Errorf("failed to set lfs url for remote %s: %w", remoteName, err)
 	}
-	if strings.TrimSpace(cred.AccessToken) != "" {
+	// Keyring remotes keep the token out of git config too.
+	if store != config.CredentialStoreKeyring && strings.TrimSpace(cred.AccessToken) != "" {
 		if err := gitrepo.SetRemoteToken(remoteName, strings.TrimSpace(cred.AccessToken)); err != nil {
 			return fmt.Errorf("failed to persist repo token for remote %s: %w", remoteName, err)
 		}
@@ -165,6 +186,57 @@ func gen3Init(remoteName, credFile, fenceToken, scopeArg string, logg *slog.Logg
 	return nil
 }
 
+// gen3InitAnonymous configures an auth=none remote. No profile is created and
+// no credential is validated; a bucket mapping is optional because anonymous
+// remotes are read-only.
+func gen3InitAnonymous(remoteName, auth, endpoint, scopeArg string, logg *slog.Logger) error {
+	if remoteName == "" {
+		return fmt.Errorf("remote name is required")
+	}
+	if err := config.IsValidAuthMode(auth); err != nil {
+		return err
+	}
+	if credFile != "" || fenceToken != "" {
+		return fmt.Errorf("--auth none cannot be combined with --cred or --token")
+	}
+	endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	if endpoint == "" {
+		return fmt.Errorf("--endpoint is required with --auth none")
+	}
+	if err := initialize.EnsureInitialized(logg); err != nil {
+		return fmt.Errorf("failed to initialize repository: %w", err)
+	}
+	organization, project, err := parseScopeArg(scopeArg)
+	if err != nil {
+		return err
+	}
+	if _, err := projectmap.Parse(localScope, organization, project); err != nil {
+		return err
+	}
+
+	remoteGen3 := config.RemoteSelect{
+		Gen3: &config.Gen3Remote{
+			Endpoint:     endpoint,
+			ProjectID:    project,
+			Organization: organization,
+			Auth:         config.AuthNone,
+			LocalScope:   strings.TrimSpace(localScope),
+		},
+	}
+	if scope, err := gitrepo.ResolveBucketScope(organization, project, "", ""); err == nil {
+		remoteGen3.Gen3.Bucket = scope.Bucket
+		remoteGen3.Gen3.StoragePrefix = scope.Prefix
+	}
+	if _, err := config.UpdateRemote(config.Remote(remoteName), remoteGen3); err != nil {
+		return fmt.Errorf("failed to update remote config: %w", err)
+	}
+	if err := gitrepo.SetRemoteLFSURL(remoteName, endpoint); err != nil {
+		return fmt.Errorf("failed to set lfs url for remote %s: %w", remoteName, err)
+	}
+	logg.Debug(fmt.Sprintf("Anonymous remote added/updated: %s → %s (project: %s)", remoteName, endpoint, project))
+	return nil
+}
+
 func parseScopeArg(raw string) (string, string, error) {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
@@ -197,7 +269,7 @@ func resolveBucketScopeFromServer(ctx context.Context, endpoint, token, organiza
 	}
 	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(token))
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.NewAPI().Do(req)
 	if err != nil {
 		return gitrepo.ResolvedBucketScope{}, fmt.Errorf("request bucket list: %w", err)
 	}
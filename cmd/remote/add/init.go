@@ -1,12 +1,24 @@
 package add
 
-import "github.com/spf13/cobra"
+import (
+	"github.com/calypr/git-drs/internal/anvil"
+	"github.com/spf13/cobra"
+)
 
 var (
-	credFile      string
-	fenceToken    string
-	localPassword string
-	localUsername string
+	credFile        string
+	fenceToken      string
+	authMode        string
+	gen3Endpoint    string
+	localScope      string
+	credentialStore string
+	localPassword   string
+	localUsername   string
+
+	anvilEndpoint       string
+	anvilSAM            string
+	anvilDRSHost        string
+	anvilBillingProject string
 )
 
 // Cmd line declaration
@@ -18,9 +30,20 @@ var Cmd = &cobra.Command{
 func init() {
 	Gen3Cmd.Flags().StringVar(&credFile, "cred", "", "[gen3] Import a Gen3 credential file into this profile")
 	Gen3Cmd.Flags().StringVar(&fenceToken, "token", "", "[gen3] Use a temporary bearer token issued from fence")
+	Gen3Cmd.Flags().StringVar(&authMode, "auth", "", "[gen3] Set to \"none\" for a public server that needs no credentials (read-only)")
+	Gen3Cmd.Flags().StringVar(&gen3Endpoint, "endpoint", "", "[gen3] Server URL; required with --auth none")
+	Gen3Cmd.Flags().StringVar(&localScope, "local-scope", "", "[gen3] organization/project this repository uses locally; records are translated to the remote's scope on push and copy")
+
+	Gen3Cmd.Flags().StringVar(&credentialStore, "credential-store", "", "[gen3] Where to keep the profile: \"file\" (~/.gen3, the default) or \"keyring\" (the OS keyring)")
 
 	Cmd.AddCommand(Gen3Cmd)
 	LocalCmd.Flags().StringVar(&localUsername, "username", "", "Username for local DRS HTTP basic auth")
 	LocalCmd.Flags().StringVar(&localPassword, "password", "", "Password for local DRS HTTP basic auth")
 	Cmd.AddCommand(LocalCmd)
+	Cmd.AddCommand(FilesystemCmd)
+	AnvilCmd.Flags().StringVar(&anvilBillingProject, "billing-project", "", "Google project billed for requester-pays reads")
+	AnvilCmd.Flags().StringVar(&anvilEndpoint, "endpoint", "", "DRSHub URL, or a Martha function URL (default "+anvil.DefaultDRSHub+")")
+	AnvilCmd.Flags().StringVar(&anvilSAM, "sam-endpoint", "", "SAM URL pet service account tokens are requested from (default "+anvil.DefaultSAM+")")
+	AnvilCmd.Flags().StringVar(&anvilDRSHost, "drs-host", "", "DRS host bare object IDs resolve on (default "+anvil.DefaultDRSHost+")")
+	Cmd.AddCommand(AnvilCmd)
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/drslog"
 	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/httpclient"
 	bucketapi "github.com/calypr/syfon/apigen/client/bucketapi"
 	syfoncommon "github.com/calypr/syfon/common"
 	"github.com/spf13/cobra"
@@ -96,7 +97,7 @@ func resolveBucketScopeFromLocalServer(ctx context.Context, endpoint, username,
 		req.SetBasicAuth(username, password)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.NewAPI().Do(req)
 	if err != nil {
 		return gitrepo.ResolvedBucketScope{}, fmt.Errorf("request bucket list: %w", err)
 	}
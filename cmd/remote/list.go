@@ -2,8 +2,12 @@ package remote
 
 import (
 	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
 
 	"github.com/calypr/data-client/credentials"
+	"github.com/calypr/git-drs/internal/common"
 	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/drslog"
 	syconf "github.com/calypr/syfon/client/config"
@@ -12,12 +16,27 @@ import (
 
 var (
 	loadConfig            = config.LoadConfig
-	loadProfileCredential = func(profile string) (*syconf.Credential, error) {
-		return syconf.NewConfigure(drslog.GetLogger()).Load(profile)
+	loadProfileCredential = func(store, profile string) (*syconf.Credential, error) {
+		return config.LoadProfile(store, profile, drslog.GetLogger())
 	}
 	ensureValidCredential = credentials.EnsureValidCredential
 )
 
+// remoteEntry is one element of the `remote list --json` document.
+type remoteEntry struct {
+	Name          string `json:"name"`
+	Default       bool   `json:"default"`
+	Type          string `json:"type"`
+	Endpoint      string `json:"endpoint,omitempty"`
+	Organization  string `json:"organization,omitempty"`
+	Project       string `json:"project,omitempty"`
+	LocalScope    string `json:"local_scope,omitempty"`
+	Anonymous     bool   `json:"anonymous,omitempty"`
+	ReadOnly      bool   `json:"read_only,omitempty"`
+	CredStore     string `json:"credential_store,omitempty"`
+	CredentialErr string `json:"credential_error,omitempty"`
+}
+
 var ListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List DRS repos",
@@ -36,7 +55,16 @@ var ListCmd = &cobra.Command{
 			return err
 		}
 
-		for name, remoteSelect := range cfg.Remotes {
+		names := make([]string, 0, len(cfg.Remotes))
+		for name := range cfg.Remotes {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+		entries := make([]remoteEntry, 0, len(names))
+
+		for _, n := range names {
+			name := config.Remote(n)
+			remoteSelect := cfg.Remotes[name]
 			// Determine if this is the default
 			isDefault := name == cfg.DefaultRemote
 			marker := " "
@@ -53,6 +81,12 @@ var ListCmd = &cobra.Command{
 			} else if remoteSelect.Local != nil {
 				remoteType = string(config.LocalServerType)
 				remote = remoteSelect.Local
+			} else if remoteSelect.Filesystem != nil {
+				remoteType = string(config.FilesystemServerType)
+				remote = remoteSelect.Filesystem
+			} else if remoteSelect.Anvil != nil {
+				remoteType = string(config.AnvilServerType)
+				remote = remoteSelect.Anvil
 			} else {
 				remoteType = "unknown"
 			}
@@ -62,9 +96,41 @@ var ListCmd = &cobra.Command{
 				endpoint = remote.GetEndpoint()
 			}
 
+			if common.JSONOutput() {
+				entry := remoteEntry{Name: n, Default: isDefault, Type: remoteType, ReadOnly: remoteSelect.ReadOnly()}
+				if remote != nil {
+					entry.Endpoint = endpoint
+					entry.Organization = remote.GetOrganization()
+					entry.Project = remote.GetProjectId()
+				}
+				if remoteSelect.Gen3 != nil {
+					entry.LocalScope = strings.TrimSpace(remoteSelect.Gen3.LocalScope)
+					entry.Anonymous = remoteSelect.Gen3.Anonymous()
+					if !entry.Anonymous {
+						entry.CredStore = credentialStoreName(remoteSelect.Gen3)
+						entry.CredentialErr = credentialProblem(cmd, name, remoteSelect.Gen3, logg)
+					}
+				}
+				entries = append(entries, entry)
+				continue
+			}
+
 			fmt.Printf("%s %-10s %-8s %s\n", marker, name, remoteType, endpoint)
+			if remoteSelect.Gen3 != nil && strings.TrimSpace(remoteSelect.Gen3.LocalScope) != "" {
+				fmt.Printf("  %-10s %-8s maps %s -> %s/%s\n", "", "", remoteSelect.Gen3.LocalScope, remoteSelect.Gen3.Organization, remoteSelect.Gen3.ProjectID)
+			}
+			if remoteSelect.ReadOnly() {
+				fmt.Printf("  %-10s %-8s read-only\n", "", "")
+			}
+			if remoteSelect.Gen3 != nil && remoteSelect.Gen3.Anonymous() {
+				fmt.Printf("  %-10s %-8s auth=none (read-only)\n", "", "")
+				continue
+			}
+			if remoteSelect.Gen3 != nil && remoteSelect.Gen3.UsesKeyring() {
+				fmt.Printf("  %-10s %-8s credentials in OS keyring\n", "", "")
+			}
 			if remoteSelect.Gen3 != nil {
-				cred, err := loadProfileCredential(string(name))
+				cred, err := loadProfileCredential(remoteSelect.Gen3.CredentialStore, remoteSelect.Gen3.ProfileName(string(name)))
 				if err != nil {
 					logg.Warn(fmt.Sprintf("remote %s credential check skipped: %v", name, err))
 					continue
@@ -74,6 +140,31 @@ var ListCmd = &cobra.Command{
 				}
 			}
 		}
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), entries)
+		}
 		return nil
 	},
 }
+
+// credentialProblem returns a description of why the remote's stored
+// credential is unusable, or "" when it validates.
+func credentialProblem(cmd *cobra.Command, name config.Remote, gen3 *config.Gen3Remote, logg *slog.Logger) string {
+	cred, err := loadProfileCredential(gen3.CredentialStore, gen3.ProfileName(string(name)))
+	if err != nil {
+		return err.Error()
+	}
+	if err := ensureValidCredential(cmd.Context(), cred, logg); err != nil {
+		return config.WrapCredentialValidationError(string(name), err).Error()
+	}
+	return ""
+}
+
+// credentialStoreName is the store the remote's profile lives in, with the
+// default spelled out.
+func credentialStoreName(gen3 *config.Gen3Remote) string {
+	if gen3.UsesKeyring() {
+		return config.CredentialStoreKeyring
+	}
+	return config.CredentialStoreFile
+}
@@ -0,0 +1,66 @@
+package remote
+
+import (
+	"fmt"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/spf13/cobra"
+)
+
+var migrateProfile = config.MigrateProfileToKeyring
+
+var MigrateCredentialsCmd = &cobra.Command{
+	Use:   "migrate-credentials [remote-name]",
+	Short: "Move a gen3 remote's credentials into the OS keyring",
+	Long: "Copy the remote's profile from ~/.gen3/gen3_client_config.ini into the OS keyring, " +
+		"remove it from the file, drop the token cached in git config, and switch the remote to credential-store=keyring. " +
+		"Uses the default remote when none is given.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: accepts at most 1 argument (remote name), received %d\n\nUsage: %s\n\nSee 'git drs remote migrate-credentials --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := drslog.GetLogger()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		remoteName := cfg.DefaultRemote
+		if len(args) == 1 {
+			remoteName = config.Remote(args[0])
+		}
+		gen3 := cfg.Remotes[remoteName].Gen3
+		if gen3 == nil {
+			return fmt.Errorf("remote '%s' is not a configured gen3 remote", remoteName)
+		}
+		if gen3.Anonymous() {
+			return fmt.Errorf("remote '%s' uses auth=none and has no credentials to migrate", remoteName)
+		}
+		if gen3.UsesKeyring() {
+			fmt.Fprintf(cmd.OutOrStdout(), "remote %s already keeps its credentials in the OS keyring\n", remoteName)
+			return nil
+		}
+
+		profile := gen3.ProfileName(string(remoteName))
+		if err := migrateProfile(profile, logger); err != nil {
+			return fmt.Errorf("failed to migrate profile %q: %w", profile, err)
+		}
+		if err := gitrepo.SetGitConfigOptions(map[string]string{
+			fmt.Sprintf("drs.remote.%s.credential-store", remoteName): config.CredentialStoreKeyring,
+		}); err != nil {
+			return fmt.Errorf("failed to update remote config: %w", err)
+		}
+		if err := gitrepo.UnsetGitConfigOptions([]string{fmt.Sprintf("drs.remote.%s.token", remoteName)}); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "moved profile %s for remote %s into the OS keyring\n", profile, remoteName)
+		return nil
+	},
+}
@@ -33,7 +33,7 @@ func TestRemoteListRun(t *testing.T) {
 		ensureValidCredential = oldEnsureValidCredential
 	})
 
-	loadProfileCredential = func(profile string) (*syconf.Credential, error) {
+	loadProfileCredential = func(_, profile string) (*syconf.Credential, error) {
 		return &syconf.Credential{Profile: profile, AccessToken: "token", APIEndpoint: "https://example.test"}, nil
 	}
 	called := false
@@ -152,3 +152,36 @@ func TestRemoteRemoveRunClearsDefaultWhenLastRemoteRemoved(t *testing.T) {
 	assert.Empty(t, string(val))
 	assert.Error(t, err)
 }
+
+func TestRemoteMigrateCredentials(t *testing.T) {
+	tmpDir := testutils.SetupTestGitRepo(t)
+	testutils.CreateDefaultTestConfig(t, tmpDir)
+	cmd := exec.Command("git", "config", "drs.remote.origin.token", "token")
+	cmd.Dir = tmpDir
+	assert.NoError(t, cmd.Run())
+
+	oldMigrate := migrateProfile
+	t.Cleanup(func() { migrateProfile = oldMigrate })
+	migrated := ""
+	migrateProfile = func(profile string, _ *slog.Logger) error {
+		migrated = profile
+		return nil
+	}
+
+	err := MigrateCredentialsCmd.RunE(MigrateCredentialsCmd, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "origin", migrated)
+
+	cfg, err := config.LoadConfig()
+	assert.NoError(t, err)
+	assert.True(t, cfg.Remotes["origin"].Gen3.UsesKeyring())
+	val, err := exec.Command("git", "config", "--get", "drs.remote.origin.token").CombinedOutput()
+	assert.Empty(t, string(val))
+	assert.Error(t, err)
+
+	// Already migrated: nothing to do.
+	migrated = ""
+	err = MigrateCredentialsCmd.RunE(MigrateCredentialsCmd, []string{"origin"})
+	assert.NoError(t, err)
+	assert.Empty(t, migrated)
+}
@@ -16,4 +16,5 @@ func init() {
 	Cmd.AddCommand(ListCmd)
 	Cmd.AddCommand(RemoveCmd)
 	Cmd.AddCommand(SetCmd)
+	Cmd.AddCommand(MigrateCredentialsCmd)
 }
@@ -0,0 +1,485 @@
+package replicate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/drslog"
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pushsync"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	fromRemote string
+	toRemote   string
+	jobs       int
+)
+
+var (
+	loadCfg         = config.LoadConfig
+	newRemoteClient = func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+		return cfg.GetRemoteClient(remote, logger)
+	}
+	loadHeadInventory = func(logger *slog.Logger) (map[string]lfs.LfsFileInfo, error) {
+		return lfs.GetLfsFilesForRefs([]string{"HEAD"}, logger)
+	}
+	lookupObjects  = drsremote.ObjectsByHashesForScope
+	downloadObject = drsremote.DownloadToCachePath
+	uploadObject   = func(ctx context.Context, gc *config.GitContext, obj *drsapi.DrsObject, path string) error {
+		return pushsync.UploadObject(gc, ctx, obj, path)
+	}
+	objectStored = func(ctx context.Context, gc *config.GitContext, obj *drsapi.DrsObject) bool {
+		return pushsync.ObjectDownloadable(gc, ctx, obj)
+	}
+	registerObject    = registerRecord
+	addAccessMethods  = updateAccessMethods
+	recordAudit       = audit.RecordOrWarn
+	cachePathForOID   = func(oid string) (string, error) { return lfs.ObjectPath(common.LFS_OBJS_PATH, oid) }
+	cachedObjectReady = func(path string) bool {
+		st, err := os.Stat(path)
+		return err == nil && !st.IsDir()
+	}
+)
+
+// Outcomes of replicating one object.
+const (
+	outcomePresent = "present"
+	outcomeLinked  = "linked"
+	outcomeCopied  = "copied"
+)
+
+// Result summarizes one replicate run.
+type Result struct {
+	From         string            `json:"from"`
+	To           string            `json:"to"`
+	Objects      int               `json:"objects"`
+	Unregistered int               `json:"unregistered"`
+	Present      int               `json:"present"`
+	Linked       int               `json:"linked"`
+	Copied       int               `json:"copied"`
+	Created      int               `json:"created"`
+	Updated      int               `json:"updated"`
+	Failed       map[string]string `json:"failed,omitempty"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "replicate --to <remote>",
+	Short: "Copy the objects referenced by HEAD and their records to a mirror remote",
+	Long: "Description:" +
+		"\n  For each LFS object referenced by HEAD that has a record on the source" +
+		"\n  remote, make sure the mirror remote has a record in its project and a" +
+		"\n  copy of the content in its bucket. When the source record already points" +
+		"\n  into the mirror's bucket the mirror record reuses that location and no" +
+		"\n  bytes move; otherwise the object is downloaded from the source (reusing" +
+		"\n  the local LFS cache) and uploaded to the mirror. Mirror records that" +
+		"\n  exist without a location in the mirror's bucket gain one. Re-running" +
+		"\n  skips objects the mirror already serves.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: accepts no arguments, received %d\n\nUsage: %s\n\nSee 'git drs replicate --help' for more details", len(args), cmd.UseLine())
+		}
+		if strings.TrimSpace(toRemote) == "" {
+			return fmt.Errorf("--to is required")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		res, err := Run(ctx, fromRemote, toRemote)
+		if res != nil {
+			if werr := writeResult(cmd.OutOrStdout(), res); werr != nil {
+				return werr
+			}
+		}
+		return err
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVar(&fromRemote, "from", "", "source DRS remote (default: default remote)")
+	Cmd.Flags().StringVar(&toRemote, "to", "", "mirror DRS remote to copy objects and records to")
+	Cmd.Flags().IntVarP(&jobs, "jobs", "j", 0, "objects replicated concurrently (default: mirror upload concurrency)")
+}
+
+// Run replicates every registered object referenced by HEAD from the from
+// remote (the default remote when empty) to the to remote. Objects that fail
+// are reported in the result and the returned error says how many failed.
+func Run(ctx context.Context, from, to string) (*Result, error) {
+	logger := drslog.GetLogger()
+
+	cfg, err := loadCfg()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+	srcName, err := cfg.GetRemoteOrDefault(from)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving source remote: %w", err)
+	}
+	dstName := config.Remote(strings.TrimSpace(to))
+	if srcName == dstName {
+		return nil, fmt.Errorf("source and mirror remotes must be different")
+	}
+	src, err := newRemoteClient(cfg, srcName, logger)
+	if err != nil {
+		return nil, fmt.Errorf("error creating source client: %w", err)
+	}
+	dst, err := newRemoteClient(cfg, dstName, logger)
+	if err != nil {
+		return nil, fmt.Errorf("error creating mirror client: %w", err)
+	}
+	if err := dst.RequireWrite(); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(dst.BucketName) == "" {
+		return nil, fmt.Errorf("mirror remote %q has no bucket configured", dstName)
+	}
+
+	inventory, err := loadHeadInventory(logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list LFS objects in HEAD: %w", err)
+	}
+	files := filesByOID(inventory)
+	oids := make([]string, 0, len(files))
+	for oid := range files {
+		oids = append(oids, oid)
+	}
+	sort.Strings(oids)
+
+	res := &Result{From: string(srcName), To: string(dstName), Objects: len(oids)}
+	if len(oids) == 0 {
+		return res, nil
+	}
+	srcRecords, err := lookupObjects(ctx, src, oids)
+	if err != nil {
+		return res, fmt.Errorf("error querying source remote: %w", err)
+	}
+	dstRecords, err := lookupObjects(ctx, dst, oids)
+	if err != nil {
+		return res, fmt.Errorf("error querying mirror remote: %w", err)
+	}
+
+	r := &replicator{src: src, dst: dst, logger: logger}
+	workers := jobs
+	if workers <= 0 {
+		workers = dst.UploadConcurrency
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(workers)
+	for _, oid := range oids {
+		recs := srcRecords[oid]
+		if len(recs) == 0 {
+			res.Unregistered++
+			continue
+		}
+		if gctx.Err() != nil {
+			break
+		}
+		oid, source := oid, recs[0]
+		var existing *drsapi.DrsObject
+		if mirror := dstRecords[oid]; len(mirror) > 0 {
+			existing = &mirror[0]
+		}
+		g.Go(func() error {
+			out, err := r.replicateObject(gctx, files[oid], source, existing)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				logger.Warn(fmt.Sprintf("replicate %s failed: %v", oid, err))
+				if res.Failed == nil {
+					res.Failed = make(map[string]string)
+				}
+				res.Failed[oid] = err.Error()
+				return nil
+			}
+			res.add(out)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return res, err
+	}
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	if len(res.Failed) > 0 {
+		return res, drserrors.Partial(len(res.Failed), res.Objects, fmt.Errorf("%d of %d objects failed to replicate; run git drs replicate again to retry them", len(res.Failed), res.Objects))
+	}
+	return res, nil
+}
+
+// objectOutcome is what replicating one object did.
+type objectOutcome struct {
+	result  string
+	created bool
+	updated bool
+}
+
+func (res *Result) add(out objectOutcome) {
+	switch out.result {
+	case outcomePresent:
+		res.Present++
+	case outcomeLinked:
+		res.Linked++
+	case outcomeCopied:
+		res.Copied++
+	}
+	if out.created {
+		res.Created++
+	}
+	if out.updated {
+		res.Updated++
+	}
+}
+
+type replicator struct {
+	src, dst *config.GitContext
+	logger   *slog.Logger
+}
+
+// replicateObject makes the mirror serve one object. existing is the mirror's
+// record for the oid in its project, or nil.
+func (r *replicator) replicateObject(ctx context.Context, file lfs.LfsFileInfo, source drsapi.DrsObject, existing *drsapi.DrsObject) (objectOutcome, error) {
+	oid := localdrsobject.NormalizeOid(file.Oid)
+	bucket := r.dst.BucketName
+
+	if existing != nil && len(methodsInBucket(existing, bucket)) > 0 {
+		// The record is in place; only the content may be missing, for
+		// example after an interrupted run.
+		if objectStored(ctx, r.dst, existing) {
+			return objectOutcome{result: outcomePresent}, nil
+		}
+		if err := r.copyContent(ctx, oid, existing); err != nil {
+			return objectOutcome{}, err
+		}
+		return objectOutcome{result: outcomeCopied}, nil
+	}
+
+	linked := methodsInBucket(&source, bucket)
+	obj, err := mirrorObject(r.dst, file, source, linked)
+	if err != nil {
+		return objectOutcome{}, err
+	}
+
+	out := objectOutcome{result: outcomeCopied}
+	if len(linked) > 0 {
+		out.result = outcomeLinked
+	}
+	event := audit.Event{Remote: r.dst.RemoteName, Project: r.dst.ProjectId, OID: oid, Detail: "replicate"}
+	if existing != nil {
+		if err := addAccessMethods(ctx, r.dst, existing.Id, *obj.AccessMethods); err != nil {
+			return objectOutcome{}, fmt.Errorf("update mirror record %s: %w", existing.Id, err)
+		}
+		obj.Id = existing.Id
+		out.updated = true
+		event.Action = audit.ActionUpdate
+	} else {
+		registered, err := registerObject(ctx, r.dst, obj)
+		if err != nil {
+			return objectOutcome{}, fmt.Errorf("register mirror record: %w", err)
+		}
+		obj = registered
+		out.created = true
+		event.Action = audit.ActionRegister
+	}
+	event.DRSID = obj.Id
+	recordAudit(r.logger, event)
+
+	if out.result == outcomeLinked {
+		return out, nil
+	}
+	if err := r.copyContent(ctx, oid, obj); err != nil {
+		return objectOutcome{}, err
+	}
+	return out, nil
+}
+
+// copyContent uploads the object to the mirror's bucket for record obj,
+// downloading it from the source first unless the LFS cache has it.
+func (r *replicator) copyContent(ctx context.Context, oid string, obj *drsapi.DrsObject) error {
+	cachePath, err := cachePathForOID(oid)
+	if err != nil {
+		return fmt.Errorf("resolve LFS object path: %w", err)
+	}
+	if !cachedObjectReady(cachePath) {
+		if err := downloadObject(ctx, r.src, r.logger, oid, cachePath); err != nil {
+			return fmt.Errorf("download from %s: %w", r.src.RemoteName, err)
+		}
+	}
+	if err := uploadObject(ctx, r.dst, obj, cachePath); err != nil {
+		return fmt.Errorf("upload to %s: %w", r.dst.RemoteName, err)
+	}
+	return nil
+}
+
+// mirrorObject builds the mirror's record for source. When linked is empty
+// the record points at the mirror's own storage location for the object;
+// otherwise it reuses the linked locations. Version, aliases, and descriptive
+// fields carry over so the version chain survives replication.
+func mirrorObject(dst *config.GitContext, file lfs.LfsFileInfo, source drsapi.DrsObject, linked []drsapi.AccessMethod) (*drsapi.DrsObject, error) {
+	oid := localdrsobject.NormalizeOid(file.Oid)
+	name := filepath.Base(file.Name)
+	if source.Name != nil && *source.Name != "" {
+		name = *source.Name
+	}
+	size := source.Size
+	if size <= 0 {
+		size = file.Size
+	}
+	obj, err := localdrsobject.BuildWithPrefix(name, oid, size, dst.IDs.ID(dst.ProjectId, file.Name, oid), dst.BucketName, dst.Organization, dst.ProjectId, dst.StoragePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("build mirror record for %s: %w", oid, err)
+	}
+	if len(linked) > 0 {
+		obj.AccessMethods = &linked
+	}
+	obj.Aliases = source.Aliases
+	obj.Description = source.Description
+	obj.MimeType = source.MimeType
+	obj.Version = source.Version
+	return obj, nil
+}
+
+// methodsInBucket returns obj's object-store access methods located in
+// bucket. An az:// bucket names an Azure container.
+func methodsInBucket(obj *drsapi.DrsObject, bucket string) []drsapi.AccessMethod {
+	bucket = strings.TrimSpace(bucket)
+	if container, ok := config.AzureContainer(bucket); ok {
+		bucket = container
+	}
+	if obj == nil || obj.AccessMethods == nil || bucket == "" {
+		return nil
+	}
+	var out []drsapi.AccessMethod
+	for _, m := range *obj.AccessMethods {
+		if m.AccessUrl == nil {
+			continue
+		}
+		u, err := url.Parse(strings.TrimSpace(m.AccessUrl.Url))
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "s3", "gs", "az", "azblob":
+			if strings.EqualFold(u.Host, bucket) {
+				out = append(out, m)
+			}
+		}
+	}
+	return out
+}
+
+func registerRecord(ctx context.Context, gc *config.GitContext, obj *drsapi.DrsObject) (*drsapi.DrsObject, error) {
+	resp, err := gc.Client.DRS().RegisterObjects(ctx, drsapi.RegisterObjectsJSONRequestBody{
+		Candidates: []drsapi.DrsObjectCandidate{localdrsobject.ConvertToCandidate(obj)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Objects) == 0 {
+		return nil, fmt.Errorf("server registered no record")
+	}
+	return &resp.Objects[0], nil
+}
+
+// updateAccessMethods appends methods the record does not already list.
+func updateAccessMethods(ctx context.Context, gc *config.GitContext, did string, methods []drsapi.AccessMethod) error {
+	cur, err := gc.Client.Index().Get(ctx, did)
+	if err != nil {
+		return err
+	}
+	merged := make([]drsapi.AccessMethod, 0)
+	seen := make(map[string]bool)
+	for _, list := range []*[]drsapi.AccessMethod{cur.AccessMethods, &methods} {
+		if list == nil {
+			continue
+		}
+		for _, m := range *list {
+			key := string(m.Type)
+			if m.AccessUrl != nil {
+				key += "|" + m.AccessUrl.Url
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, m)
+		}
+	}
+	_, err = gc.Client.Index().Update(ctx, did, internalapi.InternalRecord{
+		Did:              cur.Did,
+		AccessMethods:    &merged,
+		ControlledAccess: cur.ControlledAccess,
+		Description:      cur.Description,
+		FileName:         cur.FileName,
+		Hashes:           cur.Hashes,
+		Organization:     cur.Organization,
+		Project:          cur.Project,
+		Size:             cur.Size,
+		Version:          cur.Version,
+	})
+	return err
+}
+
+// filesByOID keys the inventory by oid, keeping the first path in sorted
+// order for each.
+func filesByOID(inventory map[string]lfs.LfsFileInfo) map[string]lfs.LfsFileInfo {
+	paths := make([]string, 0, len(inventory))
+	for path := range inventory {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	out := make(map[string]lfs.LfsFileInfo, len(paths))
+	for _, path := range paths {
+		info := inventory[path]
+		oid := localdrsobject.NormalizeOid(info.Oid)
+		if oid == "" {
+			continue
+		}
+		if _, ok := out[oid]; ok {
+			continue
+		}
+		info.Oid = oid
+		if info.Name == "" {
+			info.Name = path
+		}
+		out[oid] = info
+	}
+	return out
+}
+
+func writeResult(w io.Writer, res *Result) error {
+	if common.JSONOutput() {
+		return common.WriteJSON(w, res)
+	}
+	_, err := fmt.Fprintf(w, "Replicated %d objects from %s to %s: %d copied, %d linked, %d already present, %d not registered, %d failed (%d records created, %d updated)\n",
+		res.Objects, res.From, res.To, res.Copied, res.Linked, res.Present, res.Unregistered, len(res.Failed), res.Created, res.Updated)
+	return err
+}
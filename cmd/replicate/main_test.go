@@ -0,0 +1,204 @@
+package replicate
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+const oidA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+type calls struct {
+	downloaded []string
+	uploaded   []string
+	registered []*drsapi.DrsObject
+	updated    map[string][]drsapi.AccessMethod
+}
+
+// stubTransfers swaps every network seam; cached reports whether the LFS
+// cache already holds the object and stored whether the mirror serves it.
+func stubTransfers(t *testing.T, cached, stored bool) *calls {
+	t.Helper()
+	origDL, origUL, origStored, origReg, origUpd, origAudit, origPath, origReady := downloadObject, uploadObject, objectStored, registerObject, addAccessMethods, recordAudit, cachePathForOID, cachedObjectReady
+	t.Cleanup(func() {
+		downloadObject, uploadObject, objectStored, registerObject, addAccessMethods, recordAudit, cachePathForOID, cachedObjectReady = origDL, origUL, origStored, origReg, origUpd, origAudit, origPath, origReady
+	})
+
+	c := &calls{updated: map[string][]drsapi.AccessMethod{}}
+	downloadObject = func(ctx context.Context, gc *config.GitContext, logger *slog.Logger, oid, cachePath string) error {
+		c.downloaded = append(c.downloaded, gc.RemoteName+":"+oid)
+		return nil
+	}
+	uploadObject = func(ctx context.Context, gc *config.GitContext, obj *drsapi.DrsObject, path string) error {
+		c.uploaded = append(c.uploaded, gc.RemoteName+":"+obj.Id)
+		return nil
+	}
+	objectStored = func(context.Context, *config.GitContext, *drsapi.DrsObject) bool { return stored }
+	registerObject = func(ctx context.Context, gc *config.GitContext, obj *drsapi.DrsObject) (*drsapi.DrsObject, error) {
+		c.registered = append(c.registered, obj)
+		out := *obj
+		out.Id = "minted-id"
+		return &out, nil
+	}
+	addAccessMethods = func(ctx context.Context, gc *config.GitContext, did string, methods []drsapi.AccessMethod) error {
+		c.updated[did] = methods
+		return nil
+	}
+	recordAudit = func(*slog.Logger, ...audit.Event) {}
+	cachePathForOID = func(oid string) (string, error) { return "/cache/" + oid, nil }
+	cachedObjectReady = func(string) bool { return cached }
+	return c
+}
+
+func newReplicator() *replicator {
+	return &replicator{
+		src:    &config.GitContext{RemoteName: "origin", Organization: "org", ProjectId: "proj", BucketName: "primary"},
+		dst:    &config.GitContext{RemoteName: "mirror", Organization: "org", ProjectId: "proj", BucketName: "backup"},
+		logger: slog.Default(),
+	}
+}
+
+func sourceRecord(url string) drsapi.DrsObject {
+	name := "a.bam"
+	version := "2"
+	aliases := []string{"git-drs:predecessor:sha256:" + oidA}
+	return drsapi.DrsObject{
+		Id:      "src-id",
+		Name:    &name,
+		Size:    42,
+		Version: &version,
+		Aliases: &aliases,
+		AccessMethods: &[]drsapi.AccessMethod{{
+			Type: drsapi.AccessMethodTypeS3,
+			AccessUrl: &struct {
+				Headers *[]string `json:"headers,omitempty"`
+				Url     string    `json:"url"`
+			}{Url: url},
+		}},
+	}
+}
+
+func TestReplicateObject_CopiesAndRegisters(t *testing.T) {
+	c := stubTransfers(t, false, false)
+	file := lfs.LfsFileInfo{Name: "data/a.bam", Oid: oidA, Size: 42}
+
+	out, err := newReplicator().replicateObject(context.Background(), file, sourceRecord("s3://primary/"+oidA), nil)
+	if err != nil {
+		t.Fatalf("replicateObject: %v", err)
+	}
+	if out.result != outcomeCopied || !out.created {
+		t.Fatalf("outcome = %+v, want copied and created", out)
+	}
+	if len(c.registered) != 1 {
+		t.Fatalf("registered %d records, want 1", len(c.registered))
+	}
+	reg := c.registered[0]
+	if got := methodsInBucket(reg, "backup"); len(got) != 1 {
+		t.Fatalf("mirror record access methods = %+v, want one in bucket backup", *reg.AccessMethods)
+	}
+	if reg.Version == nil || *reg.Version != "2" || reg.Aliases == nil || len(*reg.Aliases) != 1 {
+		t.Fatalf("version chain not carried over: version=%v aliases=%v", reg.Version, reg.Aliases)
+	}
+	if len(c.downloaded) != 1 || c.downloaded[0] != "origin:"+oidA {
+		t.Fatalf("downloaded = %v, want the object from origin", c.downloaded)
+	}
+	if len(c.uploaded) != 1 || c.uploaded[0] != "mirror:minted-id" {
+		t.Fatalf("uploaded = %v, want the registered mirror record", c.uploaded)
+	}
+}
+
+func TestReplicateObject_LinksWhenSourceIsInMirrorBucket(t *testing.T) {
+	c := stubTransfers(t, false, false)
+	file := lfs.LfsFileInfo{Name: "data/a.bam", Oid: oidA}
+
+	out, err := newReplicator().replicateObject(context.Background(), file, sourceRecord("s3://backup/shared/"+oidA), nil)
+	if err != nil {
+		t.Fatalf("replicateObject: %v", err)
+	}
+	if out.result != outcomeLinked || !out.created {
+		t.Fatalf("outcome = %+v, want linked and created", out)
+	}
+	if got := (*c.registered[0].AccessMethods)[0].AccessUrl.Url; got != "s3://backup/shared/"+oidA {
+		t.Fatalf("mirror record url = %q, want the source location", got)
+	}
+	if len(c.downloaded) != 0 || len(c.uploaded) != 0 {
+		t.Fatalf("linked object moved bytes: downloaded=%v uploaded=%v", c.downloaded, c.uploaded)
+	}
+}
+
+func TestReplicateObject_UpdatesExistingRecordAndUsesCache(t *testing.T) {
+	c := stubTransfers(t, true, false)
+	file := lfs.LfsFileInfo{Name: "data/a.bam", Oid: oidA}
+	existing := sourceRecord("s3://primary/" + oidA)
+	existing.Id = "mirror-id"
+
+	out, err := newReplicator().replicateObject(context.Background(), file, sourceRecord("s3://primary/"+oidA), &existing)
+	if err != nil {
+		t.Fatalf("replicateObject: %v", err)
+	}
+	if out.result != outcomeCopied || !out.updated || out.created {
+		t.Fatalf("outcome = %+v, want copied and updated", out)
+	}
+	if len(methodsInBucket(&drsapi.DrsObject{AccessMethods: ptr(c.updated["mirror-id"])}, "backup")) != 1 {
+		t.Fatalf("update added %+v, want a method in bucket backup", c.updated["mirror-id"])
+	}
+	if len(c.downloaded) != 0 {
+		t.Fatalf("downloaded %v although the object was cached", c.downloaded)
+	}
+	if len(c.uploaded) != 1 || c.uploaded[0] != "mirror:mirror-id" {
+		t.Fatalf("uploaded = %v", c.uploaded)
+	}
+}
+
+func TestReplicateObject_PresentAndRepair(t *testing.T) {
+	file := lfs.LfsFileInfo{Name: "data/a.bam", Oid: oidA}
+	existing := sourceRecord("s3://backup/" + oidA)
+	existing.Id = "mirror-id"
+
+	c := stubTransfers(t, true, true)
+	out, err := newReplicator().replicateObject(context.Background(), file, sourceRecord("s3://primary/"+oidA), &existing)
+	if err != nil || out.result != outcomePresent {
+		t.Fatalf("stored object: outcome=%+v err=%v, want present", out, err)
+	}
+	if len(c.uploaded) != 0 || len(c.registered) != 0 {
+		t.Fatalf("present object was rewritten: %+v", c)
+	}
+
+	c = stubTransfers(t, true, false)
+	out, err = newReplicator().replicateObject(context.Background(), file, sourceRecord("s3://primary/"+oidA), &existing)
+	if err != nil || out.result != outcomeCopied || out.created || out.updated {
+		t.Fatalf("unstored object: outcome=%+v err=%v, want content copy only", out, err)
+	}
+	if len(c.uploaded) != 1 {
+		t.Fatalf("uploaded = %v, want the missing content", c.uploaded)
+	}
+}
+
+func TestReplicateObject_ReportsUploadFailure(t *testing.T) {
+	stubTransfers(t, true, false)
+	uploadObject = func(context.Context, *config.GitContext, *drsapi.DrsObject, string) error {
+		return errors.New("403 Forbidden")
+	}
+	_, err := newReplicator().replicateObject(context.Background(), lfs.LfsFileInfo{Oid: oidA}, sourceRecord("s3://primary/"+oidA), nil)
+	if err == nil {
+		t.Fatal("expected upload error")
+	}
+}
+
+func TestFilesByOID_KeepsFirstPath(t *testing.T) {
+	files := filesByOID(map[string]lfs.LfsFileInfo{
+		"z/copy.bam": {Oid: "sha256:" + oidA},
+		"a/orig.bam": {Oid: oidA},
+	})
+	if len(files) != 1 || files[oidA].Name != "a/orig.bam" {
+		t.Fatalf("filesByOID = %+v", files)
+	}
+}
+
+func ptr(m []drsapi.AccessMethod) *[]drsapi.AccessMethod { return &m }
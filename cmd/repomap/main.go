@@ -0,0 +1,115 @@
+package repomap
+
+import (
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pathmap"
+	"github.com/spf13/cobra"
+)
+
+var remote string
+
+var (
+	loadConfig            = config.LoadConfig
+	loadWorktreeInventory = lfs.GetWorktreeLfsFiles
+	gitTopLevel           = gitrepo.GitTopLevel
+	stageMapDir           = defaultStageMapDir
+)
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "map",
+	Short: "Manage the committed path-to-DRS map",
+	Long: "Manage the committed .drs/map shards that record, for every LFS-tracked path, " +
+		"its oid, DRS ID, and remote so clones can resolve DRS IDs offline. Once the map " +
+		"exists the pre-commit hook keeps it up to date.",
+}
+
+// RebuildCmd regenerates the map from the current worktree.
+var RebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Regenerate .drs/map from the LFS files in the worktree",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: accepts no arguments, received %d\n\nUsage: %s\n\nSee 'git drs map rebuild --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logg := drslog.GetLogger()
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("error loading config: %w", err)
+		}
+		remoteName, err := cfg.GetRemoteOrDefault(remote)
+		if err != nil {
+			return err
+		}
+		m, err := buildMap(cfg, remoteName, logg)
+		if err != nil {
+			return err
+		}
+
+		root, err := gitTopLevel()
+		if err != nil {
+			return err
+		}
+		if err := pathmap.Rebuild(root, m); err != nil {
+			return fmt.Errorf("error writing %s: %w", pathmap.Dir, err)
+		}
+		if err := stageMapDir(root); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d entries to %s\n", len(m), pathmap.Dir)
+		return nil
+	},
+}
+
+func init() {
+	RebuildCmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote whose project scopes the DRS IDs (default: default remote)")
+	Cmd.AddCommand(RebuildCmd)
+}
+
+func buildMap(cfg *config.Config, remoteName config.Remote, logger *slog.Logger) (pathmap.Map, error) {
+	drsRemote := cfg.GetRemote(remoteName)
+	if drsRemote == nil {
+		return nil, fmt.Errorf("remote %q is not configured", remoteName)
+	}
+	project := config.LocalProjectID(drsRemote)
+	if project == "" {
+		return nil, fmt.Errorf("remote %q has no project configured", remoteName)
+	}
+
+	ids, err := config.LoadIDMinter(string(remoteName))
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := loadWorktreeInventory(logger)
+	if err != nil {
+		return nil, fmt.Errorf("error listing LFS files: %w", err)
+	}
+	m := pathmap.Map{}
+	for path, info := range files {
+		path = filepath.ToSlash(path)
+		m[path] = pathmap.NewEntry(string(remoteName), ids, config.LocalProjectIDForPath(drsRemote, path), path, info.Oid)
+	}
+	return m, nil
+}
+
+// defaultStageMapDir stages additions and removals under the map directory.
+func defaultStageMapDir(root string) error {
+	out, err := exec.Command("git", "-C", root, "add", "-A", "--", pathmap.Dir).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git add %s: %s", pathmap.Dir, out)
+	}
+	return nil
+}
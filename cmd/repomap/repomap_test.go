@@ -0,0 +1,88 @@
+package repomap
+
+import (
+	"bytes"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pathmap"
+	"github.com/calypr/git-drs/internal/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebuildCmdArgs(t *testing.T) {
+	assert.NoError(t, RebuildCmd.Args(RebuildCmd, nil))
+	assert.Error(t, RebuildCmd.Args(RebuildCmd, []string{"extra"}))
+}
+
+// useWorktree stubs the worktree inventory with files.
+func useWorktree(t *testing.T, files map[string]lfs.LfsFileInfo) {
+	t.Helper()
+	old := loadWorktreeInventory
+	t.Cleanup(func() { loadWorktreeInventory = old })
+	loadWorktreeInventory = func(*slog.Logger) (map[string]lfs.LfsFileInfo, error) { return files, nil }
+}
+
+func TestRebuildWritesAndStagesMap(t *testing.T) {
+	tmpDir := testutils.SetupTestGitRepo(t)
+	testutils.CreateDefaultTestConfig(t, tmpDir)
+	oidA, oidB := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	files := map[string]lfs.LfsFileInfo{
+		"data/a.bin": {Name: "data/a.bin", Oid: oidA},
+		"data/b.bin": {Name: "data/b.bin", Oid: oidB},
+	}
+	useWorktree(t, files)
+
+	var out bytes.Buffer
+	RebuildCmd.SetOut(&out)
+	if err := RebuildCmd.RunE(RebuildCmd, nil); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	assert.Equal(t, "Wrote 2 entries to "+pathmap.Dir+"\n", out.String())
+
+	m, err := pathmap.Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if assert.Len(t, m, 2) {
+		for path, oid := range map[string]string{"data/a.bin": oidA, "data/b.bin": oidB} {
+			assert.Equal(t, oid, m[path].OID)
+			assert.Equal(t, config.ORIGIN, m[path].Remote)
+			assert.NotEmpty(t, m[path].DRSID)
+		}
+	}
+	staged, err := exec.Command("git", "-C", tmpDir, "diff", "--cached", "--name-only").Output()
+	if err != nil {
+		t.Fatalf("git diff: %v", err)
+	}
+	assert.Contains(t, string(staged), pathmap.Dir+"/"+pathmap.ShardName("data/a.bin"))
+
+	// A path that left the worktree drops out of the map on the next rebuild.
+	delete(files, "data/b.bin")
+	if err := RebuildCmd.RunE(RebuildCmd, nil); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	m, err = pathmap.Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	assert.Len(t, m, 1)
+	assert.Contains(t, m, "data/a.bin")
+}
+
+func TestRebuildRequiresConfiguredRemote(t *testing.T) {
+	tmpDir := testutils.SetupTestGitRepo(t)
+	testutils.CreateDefaultTestConfig(t, tmpDir)
+	useWorktree(t, nil)
+	old := remote
+	t.Cleanup(func() { remote = old })
+	remote = "missing"
+
+	err := RebuildCmd.RunE(RebuildCmd, nil)
+	assert.Error(t, err)
+	assert.False(t, pathmap.Enabled(tmpDir), "expected no map written for an unknown remote")
+}
@@ -0,0 +1,86 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsdelete"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/spf13/cobra"
+)
+
+var remote string
+
+var recordAudit = audit.RecordOrWarn
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "restore <drs-id>",
+	Short: "Restore a soft-deleted DRS record",
+	Long: "Description:" +
+		"\n  Clear the deletion flag set by 'git drs delete' so the record resolves" +
+		"\n  again. Records can be restored until 'git drs delete --purge-expired'" +
+		"\n  removes them after their retention window.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires exactly 1 argument (DRS ID), received %d\n\nUsage: %s\n\nSee 'git drs restore --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := drslog.GetLogger()
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("error loading config: %w", err)
+		}
+		remoteName, err := cfg.GetRemoteOrDefault(remote)
+		if err != nil {
+			return fmt.Errorf("error getting default remote: %v", err)
+		}
+		drsClient, err := cfg.GetRemoteClient(remoteName, logger)
+		if err != nil {
+			return err
+		}
+		if err := drsClient.RequireWrite(); err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return runRestore(ctx, cmd.OutOrStdout(), drsClient, string(remoteName), args[0], logger)
+	},
+}
+
+// runRestore clears the deletion flag on the server record did. It changes
+// only the record; the local object store is left as it is.
+func runRestore(ctx context.Context, out io.Writer, drsClient *config.GitContext, remoteName, did string, logger *slog.Logger) error {
+	rec, err := drsdelete.Restore(ctx, drsClient.Client.Index(), did)
+	if err != nil {
+		return err
+	}
+	oid := ""
+	if rec.Hashes != nil {
+		oid = (*rec.Hashes)["sha256"]
+	}
+	recordAudit(logger, audit.Event{
+		Action:  audit.ActionRestore,
+		Remote:  remoteName,
+		Project: drsClient.ProjectId,
+		DRSID:   did,
+		OID:     oid,
+	})
+	fmt.Fprintf(out, "Restored %s\n", did)
+	return nil
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "target remote DRS server (default: default_remote)")
+}
@@ -0,0 +1,133 @@
+package restore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsdelete"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/testutils"
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	syclient "github.com/calypr/syfon/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreCmdArgs(t *testing.T) {
+	assert.NoError(t, Cmd.Args(Cmd, []string{"did-1"}))
+	assert.Error(t, Cmd.Args(Cmd, nil))
+	assert.Error(t, Cmd.Args(Cmd, []string{"did-1", "extra"}))
+}
+
+// fakeIndex serves a single record and records the body of any update.
+func fakeIndex(t *testing.T, rec internalapi.InternalRecordResponse, updated *internalapi.InternalRecord) *config.GitContext {
+	t.Helper()
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/index/"+rec.Did {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			if err := json.NewDecoder(r.Body).Decode(updated); err != nil {
+				t.Fatalf("decode update: %v", err)
+			}
+			rec.Description = updated.Description
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		out, _ := json.Marshal(rec)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(out)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Request:    r,
+		}, nil
+	})}
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("syclient.New: %v", err)
+	}
+	return &config.GitContext{Client: raw.(*syclient.Client), Organization: "org", ProjectId: "proj"}
+}
+
+func captureAudit(t *testing.T) *[]audit.Event {
+	t.Helper()
+	var events []audit.Event
+	old := recordAudit
+	t.Cleanup(func() { recordAudit = old })
+	recordAudit = func(_ *slog.Logger, evs ...audit.Event) { events = append(events, evs...) }
+	return &events
+}
+
+func TestRunRestoreClearsDeletionAndLeavesLocalStore(t *testing.T) {
+	tmpDir := testutils.SetupTestGitRepo(t)
+	oid := strings.Repeat("a", 64)
+	objPath := filepath.Join(tmpDir, common.DRS_OBJS_PATH, oid[:2], oid[2:4], oid)
+	if err := os.MkdirAll(filepath.Dir(objPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(objPath, []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	desc := drsdelete.SoftDeleteMarker + `{"deleted_at":"2026-01-01T00:00:00Z","purge_after":"2026-01-31T00:00:00Z","description":"original"}`
+	hashes := internalapi.HashInfo{"sha256": oid}
+	var updated internalapi.InternalRecord
+	gc := fakeIndex(t, internalapi.InternalRecordResponse{Did: "did-1", Description: &desc, Hashes: &hashes}, &updated)
+	events := captureAudit(t)
+
+	var out bytes.Buffer
+	if err := runRestore(context.Background(), &out, gc, "origin", "did-1", drslog.GetLogger()); err != nil {
+		t.Fatalf("runRestore: %v", err)
+	}
+	if updated.Description == nil || *updated.Description != "original" {
+		t.Fatalf("expected the original description restored, got %v", updated.Description)
+	}
+	assert.Equal(t, "Restored did-1\n", out.String())
+	if assert.Len(t, *events, 1) {
+		ev := (*events)[0]
+		assert.Equal(t, audit.ActionRestore, ev.Action)
+		assert.Equal(t, "origin", ev.Remote)
+		assert.Equal(t, "proj", ev.Project)
+		assert.Equal(t, "did-1", ev.DRSID)
+		assert.Equal(t, oid, ev.OID)
+	}
+
+	data, err := os.ReadFile(objPath)
+	if err != nil || string(data) != "content" {
+		t.Fatalf("expected the local object left in place, got %q, %v", data, err)
+	}
+}
+
+func TestRunRestoreRejectsLiveRecord(t *testing.T) {
+	testutils.SetupTestGitRepo(t)
+	desc := "live"
+	var updated internalapi.InternalRecord
+	gc := fakeIndex(t, internalapi.InternalRecordResponse{Did: "did-1", Description: &desc}, &updated)
+	events := captureAudit(t)
+
+	var out bytes.Buffer
+	err := runRestore(context.Background(), &out, gc, "origin", "did-1", drslog.GetLogger())
+	if !errors.Is(err, drsdelete.ErrNotSoftDeleted) {
+		t.Fatalf("expected ErrNotSoftDeleted, got %v", err)
+	}
+	assert.Nil(t, updated.Description)
+	assert.Empty(t, *events)
+	assert.Empty(t, out.String())
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,306 @@
+package restorearchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remote string
+	days   int32
+	tier   string
+)
+
+var (
+	loadConfig      = config.LoadConfig
+	newRemoteClient = func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+		return cfg.GetRemoteClient(remote, logger)
+	}
+	loadLFSInventory = lfs.GetTrackedLfsFiles
+	gitTopLevel      = gitrepo.GitTopLevel
+	lookupRecords    = drsremote.ObjectsByHashForScope
+	restoreObject    = defaultRestoreObject
+)
+
+var sha256Hex = regexp.MustCompile(`^(sha256:)?[0-9a-fA-F]{64}$`)
+
+// Restore request outcomes.
+const (
+	statusRequested   = "requested"
+	statusInProgress  = "in-progress"
+	statusNotArchived = "not-archived"
+)
+
+// result is the outcome for one object.
+type result struct {
+	Path   string `json:"path,omitempty"`
+	OID    string `json:"oid"`
+	DRSID  string `json:"drs_id"`
+	URL    string `json:"url"`
+	Status string `json:"status"`
+}
+
+// target is a tracked path or bare oid to restore.
+type target struct {
+	Path string
+	OID  string
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "restore-archive <path-or-oid>...",
+	Short: "Request restores of objects archived to S3 Glacier",
+	Long: "Description:" +
+		"\n  Ask S3 to restore a temporary copy of each object stored in an archive" +
+		"\n  class (GLACIER or DEEP_ARCHIVE), so git drs pull can download it once" +
+		"\n  the restore completes, which takes minutes to hours depending on the" +
+		"\n  tier. Directories select every tracked file under them. The request is" +
+		"\n  signed with AWS credentials from the default chain, or the static keys" +
+		"\n  when drs.upload.credential-source is static.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires at least 1 argument (path or oid), received 0\n\nUsage: %s\n\nSee 'git drs restore-archive --help' for more details", cmd.UseLine())
+		}
+		if days <= 0 {
+			return fmt.Errorf("--days must be positive")
+		}
+		if _, err := parseTier(tier); err != nil {
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := drslog.GetLogger()
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("error loading config: %w", err)
+		}
+		remoteName, err := cfg.GetRemoteOrDefault(remote)
+		if err != nil {
+			return err
+		}
+		gc, err := newRemoteClient(cfg, remoteName, logger)
+		if err != nil {
+			return err
+		}
+		targets, err := collectTargets(args, logger)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		glacierTier, _ := parseTier(tier)
+		results := make([]result, 0, len(targets))
+		for _, t := range targets {
+			res, err := restore(ctx, gc, t, days, glacierTier)
+			if err != nil {
+				name := t.Path
+				if name == "" {
+					name = t.OID
+				}
+				return fmt.Errorf("error restoring %s: %w", name, err)
+			}
+			results = append(results, res)
+		}
+		return writeResults(cmd.OutOrStdout(), results, common.JSONOutput())
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote whose records name the objects (default: default remote)")
+	Cmd.Flags().Int32Var(&days, "days", 7, "days the restored copy stays readable")
+	Cmd.Flags().StringVar(&tier, "tier", "standard", "retrieval tier: expedited, standard, or bulk")
+}
+
+func parseTier(raw string) (types.Tier, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "expedited":
+		return types.TierExpedited, nil
+	case "", "standard":
+		return types.TierStandard, nil
+	case "bulk":
+		return types.TierBulk, nil
+	}
+	return "", fmt.Errorf("invalid --tier %q: expected expedited, standard, or bulk", raw)
+}
+
+// collectTargets maps arguments to tracked files. Bare oids pass through;
+// directories select every tracked file under them.
+func collectTargets(args []string, logger *slog.Logger) ([]target, error) {
+	var (
+		inventory map[string]lfs.LfsFileInfo
+		root      string
+		targets   []target
+	)
+	for _, arg := range args {
+		if sha256Hex.MatchString(arg) {
+			targets = append(targets, target{OID: drsobject.NormalizeOid(strings.ToLower(arg))})
+			continue
+		}
+		if inventory == nil {
+			var err error
+			if inventory, err = loadLFSInventory(logger); err != nil {
+				return nil, fmt.Errorf("error listing tracked files: %w", err)
+			}
+			if root, err = gitTopLevel(); err != nil {
+				return nil, err
+			}
+		}
+		rel, err := repoRelative(root, arg)
+		if err != nil {
+			return nil, err
+		}
+		if info, ok := inventory[rel]; ok {
+			targets = append(targets, target{Path: rel, OID: info.Oid})
+			continue
+		}
+		prefix := strings.TrimSuffix(rel, "/") + "/"
+		if rel == "." {
+			prefix = ""
+		}
+		var matched []string
+		for p := range inventory {
+			if strings.HasPrefix(p, prefix) {
+				matched = append(matched, p)
+			}
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("%s is not a tracked DRS file or a directory containing one", arg)
+		}
+		sort.Strings(matched)
+		for _, p := range matched {
+			targets = append(targets, target{Path: p, OID: inventory[p].Oid})
+		}
+	}
+	return targets, nil
+}
+
+func repoRelative(root, arg string) (string, error) {
+	abs, err := filepath.Abs(arg)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		abs = filepath.Join(resolved, filepath.Base(abs))
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is outside the repository", arg)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// restore finds t's record on gc and requests a restore of its S3 copy.
+func restore(ctx context.Context, gc *config.GitContext, t target, days int32, glacierTier types.Tier) (result, error) {
+	records, err := lookupRecords(ctx, gc, t.OID)
+	if err != nil {
+		return result{}, err
+	}
+	if len(records) == 0 {
+		return result{}, fmt.Errorf("no record for oid %s on remote %s", t.OID, gc.RemoteName)
+	}
+	obj := records[0]
+	bucket, key, rawURL, ok := s3Location(&obj)
+	if !ok {
+		return result{}, fmt.Errorf("record %s has no S3 location to restore", obj.Id)
+	}
+	res := result{Path: t.Path, OID: t.OID, DRSID: obj.Id, URL: rawURL, Status: statusRequested}
+	err = restoreObject(ctx, gc, bucket, key, days, glacierTier)
+	var apiErr smithy.APIError
+	switch {
+	case err == nil:
+	case errors.As(err, &apiErr) && apiErr.ErrorCode() == "RestoreAlreadyInProgress":
+		res.Status = statusInProgress
+	case errors.As(err, &apiErr) && (apiErr.ErrorCode() == "InvalidObjectState" || apiErr.ErrorCode() == "ObjectAlreadyInActiveTierError"):
+		res.Status = statusNotArchived
+	default:
+		return result{}, err
+	}
+	return res, nil
+}
+
+// s3Location returns the bucket and key of obj's first S3 access method.
+func s3Location(obj *drsapi.DrsObject) (bucket, key, rawURL string, ok bool) {
+	if obj.AccessMethods == nil {
+		return "", "", "", false
+	}
+	for _, am := range *obj.AccessMethods {
+		if am.AccessUrl == nil {
+			continue
+		}
+		u, err := url.Parse(strings.TrimSpace(am.AccessUrl.Url))
+		if err != nil {
+			continue
+		}
+		bucket, ok := config.S3BucketFromURL(u)
+		if !ok {
+			continue
+		}
+		key := strings.TrimPrefix(u.Path, "/")
+		if !strings.EqualFold(u.Scheme, "s3") && strings.HasPrefix(key, bucket+"/") {
+			// Path-style HTTP URL: the bucket is the first path segment.
+			key = strings.TrimPrefix(key, bucket+"/")
+		}
+		if key != "" {
+			return bucket, key, u.String(), true
+		}
+	}
+	return "", "", "", false
+}
+
+func defaultRestoreObject(ctx context.Context, gc *config.GitContext, bucket, key string, days int32, glacierTier types.Tier) error {
+	client, err := config.NewS3Client(ctx, gc.RemoteName, gc.Upload, bucket)
+	if err != nil {
+		return err
+	}
+	_, err = client.RestoreObject(ctx, &s3.RestoreObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+		RestoreRequest: &types.RestoreRequest{
+			Days:                 &days,
+			GlacierJobParameters: &types.GlacierJobParameters{Tier: glacierTier},
+		},
+	})
+	return err
+}
+
+func writeResults(w io.Writer, results []result, asJSON bool) error {
+	if asJSON {
+		return common.WriteJSON(w, results)
+	}
+	for _, r := range results {
+		name := r.Path
+		if name == "" {
+			name = r.OID
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, r.Status, r.DRSID)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package restorearchive
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+type restoreCall struct {
+	bucket, key string
+	days        int32
+	tier        types.Tier
+}
+
+func stubRestore(t *testing.T, root string, codes map[string]string) *[]restoreCall {
+	t.Helper()
+	origLoad, origClient, origInv, origTop, origLookup, origRestore := loadConfig, newRemoteClient, loadLFSInventory, gitTopLevel, lookupRecords, restoreObject
+	t.Cleanup(func() {
+		loadConfig, newRemoteClient, loadLFSInventory, gitTopLevel, lookupRecords, restoreObject = origLoad, origClient, origInv, origTop, origLookup, origRestore
+		remote, days, tier = "", 7, "standard"
+	})
+	loadConfig = func() (*config.Config, error) {
+		return &config.Config{
+			DefaultRemote: "origin",
+			Remotes:       map[config.Remote]config.RemoteSelect{"origin": {Gen3: &config.Gen3Remote{ProjectID: "p"}}},
+		}, nil
+	}
+	newRemoteClient = func(*config.Config, config.Remote, *slog.Logger) (*config.GitContext, error) {
+		return &config.GitContext{RemoteName: "origin"}, nil
+	}
+	loadLFSInventory = func(*slog.Logger) (map[string]lfs.LfsFileInfo, error) {
+		return map[string]lfs.LfsFileInfo{
+			"raw/a.bam":     {Oid: strings.Repeat("a", 64)},
+			"raw/sub/b.bam": {Oid: strings.Repeat("b", 64)},
+		}, nil
+	}
+	gitTopLevel = func() (string, error) { return root, nil }
+	lookupRecords = func(_ context.Context, _ *config.GitContext, oid string) ([]drsapi.DrsObject, error) {
+		return []drsapi.DrsObject{{
+			Id: "id-" + oid[:1],
+			AccessMethods: &[]drsapi.AccessMethod{{
+				Type: drsapi.AccessMethodTypeS3,
+				AccessUrl: &struct {
+					Headers *[]string `json:"headers,omitempty"`
+					Url     string    `json:"url"`
+				}{Url: "s3://archive-bucket/p/" + oid},
+			}},
+		}}, nil
+	}
+	var calls []restoreCall
+	restoreObject = func(_ context.Context, _ *config.GitContext, bucket, key string, d int32, tr types.Tier) error {
+		calls = append(calls, restoreCall{bucket, key, d, tr})
+		if code := codes[key]; code != "" {
+			return &smithy.GenericAPIError{Code: code}
+		}
+		return nil
+	}
+	return &calls
+}
+
+func TestRestoreArchiveRequestsRestoreForDirectory(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	a, b := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	calls := stubRestore(t, root, map[string]string{"p/" + b: "RestoreAlreadyInProgress"})
+
+	var out bytes.Buffer
+	Cmd.SetOut(&out)
+	Cmd.SetArgs([]string{"raw", "--days", "3", "--tier", "bulk"})
+	if err := Cmd.Execute(); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	want := []restoreCall{{"archive-bucket", "p/" + a, 3, types.TierBulk}, {"archive-bucket", "p/" + b, 3, types.TierBulk}}
+	if len(*calls) != 2 || (*calls)[0] != want[0] || (*calls)[1] != want[1] {
+		t.Fatalf("unexpected restore calls %+v", *calls)
+	}
+	got := out.String()
+	if !strings.Contains(got, "raw/a.bam\trequested\tid-a") || !strings.Contains(got, "raw/sub/b.bam\tin-progress\tid-b") {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
+
+func TestRestoreArchiveReportsObjectsNotArchived(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	a := strings.Repeat("a", 64)
+	stubRestore(t, root, map[string]string{"p/" + a: "InvalidObjectState"})
+
+	var out bytes.Buffer
+	Cmd.SetOut(&out)
+	Cmd.SetArgs([]string{a})
+	if err := Cmd.Execute(); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != a+"\tnot-archived\tid-a" {
+		t.Fatalf("unexpected output %q", got)
+	}
+
+	Cmd.SetArgs([]string{a, "--tier", "fast"})
+	if err := Cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--tier") {
+		t.Fatalf("expected invalid tier error, got %v", err)
+	}
+}
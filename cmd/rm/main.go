@@ -9,6 +9,7 @@ import (
 
 	"github.com/calypr/git-drs/internal/drslog"
 	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/projectdir"
 	"github.com/spf13/cobra"
 )
 
@@ -38,11 +39,16 @@ func run(ctx context.Context, args []string) error {
 	}
 	planned := make([]removal, 0, len(args))
 	for _, raw := range args {
-		path := filepath.ToSlash(filepath.Clean(raw))
-		info, ok := tracked[path]
+		// Inventory paths are repo-relative; git rm takes them from here.
+		rel, err := projectdir.Rel(raw)
+		if err != nil {
+			return err
+		}
+		info, ok := tracked[rel]
 		if !ok || strings.TrimSpace(info.Oid) == "" {
 			return fmt.Errorf("%s is not a tracked git-drs/LFS file", raw)
 		}
+		path := filepath.ToSlash(filepath.Clean(raw))
 		planned = append(planned, removal{path: path, oid: "sha256:" + strings.TrimPrefix(strings.TrimSpace(info.Oid), "sha256:")})
 	}
 
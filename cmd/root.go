@@ -1,29 +1,72 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/cmd/add"
 	"github.com/calypr/git-drs/cmd/addref"
 	"github.com/calypr/git-drs/cmd/addurl"
+	"github.com/calypr/git-drs/cmd/alias"
+	"github.com/calypr/git-drs/cmd/audit"
+	"github.com/calypr/git-drs/cmd/auth"
+	"github.com/calypr/git-drs/cmd/backfillpaths"
 	"github.com/calypr/git-drs/cmd/bucket"
+	"github.com/calypr/git-drs/cmd/cache"
+	"github.com/calypr/git-drs/cmd/checkout"
 	"github.com/calypr/git-drs/cmd/clean"
+	"github.com/calypr/git-drs/cmd/clone"
 	"github.com/calypr/git-drs/cmd/copyrecords"
+	"github.com/calypr/git-drs/cmd/dedupe"
 	deleteCmd "github.com/calypr/git-drs/cmd/delete"
 	"github.com/calypr/git-drs/cmd/deleteproject"
+	"github.com/calypr/git-drs/cmd/diff"
+	"github.com/calypr/git-drs/cmd/download"
+	"github.com/calypr/git-drs/cmd/drsconfig"
+	"github.com/calypr/git-drs/cmd/exportdata"
+	"github.com/calypr/git-drs/cmd/fetch"
 	"github.com/calypr/git-drs/cmd/filter"
+	"github.com/calypr/git-drs/cmd/flush"
+	"github.com/calypr/git-drs/cmd/fsck"
+	"github.com/calypr/git-drs/cmd/history"
+	"github.com/calypr/git-drs/cmd/importrecords"
 	"github.com/calypr/git-drs/cmd/initialize"
 	"github.com/calypr/git-drs/cmd/install"
 	"github.com/calypr/git-drs/cmd/lsfiles"
+	"github.com/calypr/git-drs/cmd/mergedriver"
+	"github.com/calypr/git-drs/cmd/mount"
 	"github.com/calypr/git-drs/cmd/ping"
 	"github.com/calypr/git-drs/cmd/precommit"
 	"github.com/calypr/git-drs/cmd/prepush"
 	"github.com/calypr/git-drs/cmd/pull"
 	"github.com/calypr/git-drs/cmd/push"
 	"github.com/calypr/git-drs/cmd/query"
+	"github.com/calypr/git-drs/cmd/rekey"
+	"github.com/calypr/git-drs/cmd/release"
 	"github.com/calypr/git-drs/cmd/remote"
+	"github.com/calypr/git-drs/cmd/replicate"
+	"github.com/calypr/git-drs/cmd/repomap"
+	"github.com/calypr/git-drs/cmd/restore"
+	"github.com/calypr/git-drs/cmd/restorearchive"
 	"github.com/calypr/git-drs/cmd/rm"
+	"github.com/calypr/git-drs/cmd/serve"
+	"github.com/calypr/git-drs/cmd/share"
 	"github.com/calypr/git-drs/cmd/smudge"
+	"github.com/calypr/git-drs/cmd/stats"
 	"github.com/calypr/git-drs/cmd/track"
+	"github.com/calypr/git-drs/cmd/unstage"
 	"github.com/calypr/git-drs/cmd/untrack"
+	"github.com/calypr/git-drs/cmd/verify"
 	"github.com/calypr/git-drs/cmd/version"
+	"github.com/calypr/git-drs/cmd/whereis"
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/httpclient"
+	"github.com/calypr/git-drs/internal/progressui"
 	"github.com/spf13/cobra"
 )
 
@@ -32,8 +75,54 @@ var RootCmd = &cobra.Command{
 	Use:   "git-drs",
 	Short: "Git DRS - Git-LFS file management for DRS servers",
 	Long:  "Git DRS provides the benefits of Git-LFS file management using DRS for seamless integration with Gen3 servers",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if quiet && verbose > 0 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("--quiet and --verbose cannot be used together")
+		}
+		overrides, err := config.ParseOverrideAssignments(configOverrides)
+		if err != nil {
+			return drserrors.WithKind(drserrors.ErrConfig, err)
+		}
+		config.SetInvocationOverrides(overrides)
+		if cmd.Flags().Changed("timeout") {
+			if apiTimeout < 0 {
+				cmd.SilenceUsage = false
+				return fmt.Errorf("--timeout must not be negative")
+			}
+			httpclient.SetAPITimeout(apiTimeout)
+		}
+		common.SetJSONOutput(jsonOutput)
+		if quiet {
+			drslog.SetVerbosity(-1)
+		} else {
+			drslog.SetVerbosity(verbose)
+		}
+		progressui.SetQuiet(quiet)
+		applyTokenScope(cmd)
+		return acquireRepoLock(cmd)
+	},
 }
 
+// configOverrides holds --config key=value pairs that take precedence over
+// GIT_DRS_* environment variables and the stored repo configuration.
+var configOverrides []string
+
+// jsonOutput makes commands emit a JSON document on stdout in place of their
+// human-formatted output.
+var jsonOutput bool
+
+// apiTimeout bounds each remote API call; set only when --timeout is given,
+// so drs.http.api-timeout applies otherwise.
+var apiTimeout time.Duration
+
+// quiet limits stderr to errors and hides progress; verbose counts -v flags
+// (-v for debug logs, -vv for trace logs including each HTTP request).
+var (
+	quiet   bool
+	verbose int
+)
+
 func init() {
 	// Hide internal commands
 	precommit.Cmd.Hidden = true
@@ -45,25 +134,83 @@ func init() {
 	RootCmd.AddCommand(ping.Cmd)
 	RootCmd.AddCommand(filter.Cmd)
 	RootCmd.AddCommand(clean.Cmd)
+	RootCmd.AddCommand(clone.Cmd)
 	RootCmd.AddCommand(copyrecords.Cmd)
+	RootCmd.AddCommand(replicate.Cmd)
 	RootCmd.AddCommand(smudge.Cmd)
 	RootCmd.AddCommand(remote.Cmd)
+	RootCmd.AddCommand(drsconfig.Cmd)
+	RootCmd.AddCommand(auth.Cmd)
+	RootCmd.AddCommand(repomap.Cmd)
+	RootCmd.AddCommand(rekey.Cmd)
+	RootCmd.AddCommand(backfillpaths.Cmd)
+	RootCmd.AddCommand(flush.Cmd)
 	RootCmd.AddCommand(rm.Cmd)
+	RootCmd.AddCommand(unstage.Cmd)
+	RootCmd.AddCommand(serve.Cmd)
+	RootCmd.AddCommand(mount.Cmd)
+	RootCmd.AddCommand(share.Cmd)
+	RootCmd.AddCommand(alias.Cmd)
+	RootCmd.AddCommand(stats.Cmd)
+	RootCmd.AddCommand(dedupe.Cmd)
 	RootCmd.AddCommand(pull.Cmd)
+	RootCmd.AddCommand(checkout.Cmd)
+	RootCmd.AddCommand(fetch.Cmd)
+	RootCmd.AddCommand(download.Cmd)
+	RootCmd.AddCommand(exportdata.Cmd)
 	RootCmd.AddCommand(push.Cmd)
+	RootCmd.AddCommand(history.Cmd)
+	RootCmd.AddCommand(whereis.Cmd)
+	RootCmd.AddCommand(importrecords.Cmd)
+	RootCmd.AddCommand(diff.Cmd)
+	RootCmd.AddCommand(release.Cmd)
 	RootCmd.AddCommand(precommit.Cmd)
 	RootCmd.AddCommand(prepush.Cmd)
+	RootCmd.AddCommand(add.Cmd)
 	RootCmd.AddCommand(addref.Cmd)
 	RootCmd.AddCommand(addurl.Cmd)
+	RootCmd.AddCommand(addurl.AccessCmd)
+	RootCmd.AddCommand(audit.Cmd)
+	RootCmd.AddCommand(cache.Cmd)
 	RootCmd.AddCommand(deleteCmd.Cmd)
 	RootCmd.AddCommand(deleteproject.Cmd)
+	RootCmd.AddCommand(restore.Cmd)
+	RootCmd.AddCommand(restorearchive.Cmd)
 	RootCmd.AddCommand(query.Cmd)
 	RootCmd.AddCommand(bucket.Cmd)
 	RootCmd.AddCommand(track.Cmd)
 	RootCmd.AddCommand(untrack.Cmd)
 	RootCmd.AddCommand(lsfiles.Cmd)
+	RootCmd.AddCommand(fsck.Cmd)
+	RootCmd.AddCommand(verify.Cmd)
+	RootCmd.AddCommand(mergedriver.Cmd)
 	RootCmd.AddCommand(install.Cmd)
 
+	RootCmd.PersistentFlags().StringArrayVar(&configOverrides, "config", nil, "override a remote config field for this invocation (key=value; keys: remote, type, endpoint, organization, project, bucket, storage_prefix, profile)")
+
+	RootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "emit machine-readable JSON on stdout (logs and progress stay on stderr)")
+	RootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "log errors only and hide progress output")
+	RootCmd.PersistentFlags().CountVarP(&verbose, "verbose", "v", "log more detail: -v for debug, -vv for trace including HTTP requests")
+	RootCmd.PersistentFlags().DurationVar(&apiTimeout, "timeout", 0, "deadline for each DRS, fence, and metadata API call, e.g. 30s or 5m; 0 disables (default drs.http.api-timeout, or 2m; object transfers are not cut off)")
+	RootCmd.PersistentFlags().BoolVar(&lockWait, "wait", false, "wait for the repository lock when another git-drs command holds it, instead of failing")
+
 	RootCmd.CompletionOptions.HiddenDefaultCmd = true
 	RootCmd.SilenceUsage = true
+	// PrintError prints errors instead, so common server failures are
+	// explained rather than shown as raw response bodies.
+	RootCmd.SilenceErrors = true
+}
+
+// PrintError writes the error of executed, the command that failed, to w as
+// cobra would, with common Gen3 server failures translated into actionable
+// messages. The untranslated error is logged.
+func PrintError(w io.Writer, executed *cobra.Command, err error) {
+	if err == nil {
+		return
+	}
+	drslog.GetLogger().Debug("command failed", "error", err)
+	fmt.Fprintln(w, "Error:", drserrors.Translate(err))
+	if executed != nil && strings.HasPrefix(err.Error(), "unknown command ") {
+		fmt.Fprintf(w, "Run '%v --help' for usage.\n", executed.CommandPath())
+	}
 }
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// readOnlyCommands only read from remotes. Their clients use a token
+// narrowed to drs.remote.<name>.read-scopes when the remote sets it, so a
+// leaked token from a shared notebook cannot write.
+var readOnlyCommands = map[string]bool{
+	"add-ref":        true,
+	"checkout":       true,
+	"clone":          true,
+	"diff":           true,
+	"download":       true,
+	"export-data":    true,
+	"fetch":          true,
+	"filter":         true,
+	"fsck":           true,
+	"history":        true,
+	"import":         true,
+	"ls-files":       true,
+	"mount":          true,
+	"pull":           true,
+	"query":          true,
+	"release verify": true,
+	"share":          true,
+	"smudge":         true,
+	"stats":          true,
+	"verify":         true,
+	"whereis":        true,
+}
+
+// applyTokenScope tells the config package whether the command only reads.
+func applyTokenScope(c *cobra.Command) {
+	config.SetReadOnlyCommand(readOnlyCommands[commandName(c)])
+}
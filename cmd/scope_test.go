@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// otherCommands write to a remote, manage credentials or configuration, or
+// never contact a remote, so they keep the full token.
+var otherCommands = map[string]bool{
+	"add":                        true,
+	"add-access":                 true,
+	"add-url":                    true,
+	"alias add":                  true,
+	"alias list":                 true,
+	"alias rm":                   true,
+	"audit log":                  true,
+	"audit verify":               true,
+	"auth login":                 true,
+	"auth refresh":               true,
+	"auth status":                true,
+	"auth switch":                true,
+	"backfill-paths":             true,
+	"bucket add":                 true,
+	"bucket add-organization":    true,
+	"bucket add-project":         true,
+	"cache clear":                true,
+	"cache rebuild":              true,
+	"cache status":               true,
+	"clean":                      true,
+	"config get":                 true,
+	"config list":                true,
+	"config set":                 true,
+	"config unset":               true,
+	"copy-records":               true,
+	"dedupe":                     true,
+	"delete":                     true,
+	"delete-project":             true,
+	"flush":                      true,
+	"init":                       true,
+	"install":                    true,
+	"map rebuild":                true,
+	"merge-driver":               true,
+	"ping":                       true,
+	"pre-push-prepare":           true,
+	"precommit":                  true,
+	"push":                       true,
+	"rekey":                      true,
+	"release create":             true,
+	"remote add anvil":           true,
+	"remote add filesystem":      true,
+	"remote add gen3":            true,
+	"remote add local":           true,
+	"remote list":                true,
+	"remote migrate-credentials": true,
+	"remote remove":              true,
+	"remote set":                 true,
+	"replicate":                  true,
+	"restore":                    true,
+	"restore-archive":            true,
+	"rm":                         true,
+	"serve":                      true,
+	"track":                      true,
+	"unstage":                    true,
+	"untrack":                    true,
+	"version":                    true,
+}
+
+// TestEveryLeafCommandHasATokenScope makes each new command decide whether
+// it only reads from remotes and may run with a narrowed token.
+func TestEveryLeafCommandHasATokenScope(t *testing.T) {
+	seen := map[string]bool{}
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		if !c.HasSubCommands() {
+			name := commandName(c)
+			seen[name] = true
+			switch {
+			case readOnlyCommands[name] && otherCommands[name]:
+				t.Errorf("%q is listed as both read-only and not", name)
+			case !readOnlyCommands[name] && !otherCommands[name]:
+				t.Errorf("%q is not classified: add it to readOnlyCommands if it only reads from remotes, otherwise to otherCommands", name)
+			}
+		}
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(RootCmd)
+	for name := range readOnlyCommands {
+		if !seen[name] {
+			t.Errorf("readOnlyCommands lists %q, which is not a command", name)
+		}
+	}
+}
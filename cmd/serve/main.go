@@ -0,0 +1,114 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/spf13/cobra"
+)
+
+var listenAddr string
+
+// discoveryFile is written under .git/drs while the daemon runs so clients
+// can find its address and token.
+const discoveryFile = "serve.json"
+
+// discovery is the content of the discovery file and of --json output.
+type discovery struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+	PID   int    `json:"pid"`
+}
+
+var Cmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run a local REST API for IDEs, notebooks, and GUI tools",
+	Long: "Runs a long-lived daemon bound to localhost that keeps the config and authenticated remote clients loaded " +
+		"and serves register, query, and download requests over HTTP. The address and bearer token are written to " +
+		".git/drs/serve.json for clients to read.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := drslog.GetLogger()
+		if err := common.RequireLoopback(listenAddr); err != nil {
+			return err
+		}
+		top, err := gitrepo.GitTopLevel()
+		if err != nil {
+			return err
+		}
+		// Object and cache paths are relative to the repository root.
+		if err := os.Chdir(top); err != nil {
+			return err
+		}
+		drsDir, err := gitrepo.DrsTopLevel()
+		if err != nil {
+			return err
+		}
+
+		token, err := common.RandomToken()
+		if err != nil {
+			return err
+		}
+		srv := newServer(logger, token)
+		// Warm the default remote so the first request does not pay for it.
+		if _, err := srv.client(""); err != nil && !errors.Is(err, config.ErrNoDefaultRemote) {
+			return err
+		}
+
+		ln, err := net.Listen("tcp", listenAddr)
+		if err != nil {
+			return fmt.Errorf("listen on %s: %w", listenAddr, err)
+		}
+		info := discovery{URL: "http://" + ln.Addr().String(), Token: token, PID: os.Getpid()}
+		discoveryPath := filepath.Join(drsDir, discoveryFile)
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			ln.Close()
+			return err
+		}
+		if err := common.WriteFileAtomic(discoveryPath, append(data, '\n'), 0o600); err != nil {
+			ln.Close()
+			return fmt.Errorf("write %s: %w", discoveryPath, err)
+		}
+		defer os.Remove(discoveryPath)
+
+		if common.JSONOutput() {
+			if err := common.WriteJSON(cmd.OutOrStdout(), info); err != nil {
+				return err
+			}
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Serving git-drs API on %s (token in %s)\n", info.URL, discoveryPath)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		httpSrv := &http.Server{Handler: srv.routes(), ReadHeaderTimeout: 10 * time.Second}
+		errCh := make(chan error, 1)
+		go func() { errCh <- httpSrv.Serve(ln) }()
+		select {
+		case err := <-errCh:
+			return err
+		case <-ctx.Done():
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return httpSrv.Shutdown(shutdownCtx)
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVar(&listenAddr, "listen", "127.0.0.1:0", "loopback address to listen on (port 0 picks a free port)")
+}
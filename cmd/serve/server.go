@@ -0,0 +1,483 @@
+package serve
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/calypr/git-drs/cmd/pull"
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pathspec"
+	"github.com/calypr/git-drs/internal/pushsync"
+	"github.com/calypr/git-drs/internal/repolock"
+	"github.com/calypr/git-drs/internal/version"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// Swapped in tests.
+var (
+	loadCfg         = config.LoadConfig
+	newRemoteClient = func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+		return cfg.GetRemoteClient(remote, logger)
+	}
+	getObject = func(ctx context.Context, gc *config.GitContext, id string) (drsapi.DrsObject, error) {
+		obj, err := drsremote.ResolveObject(ctx, gc, id)
+		if err != nil {
+			return drsapi.DrsObject{}, err
+		}
+		return *obj, nil
+	}
+	objectsByHash       = drsremote.ObjectsByHashForScope
+	loadTrackedFiles    = lfs.GetTrackedLfsFiles
+	loadWorktreeFiles   = lfs.GetWorktreeLfsFiles
+	loadRegister        = config.RegisterSettings
+	loadLimits          = config.LimitSettings
+	checkLimits         = pushsync.CheckLimits
+	registerFiles       = pushsync.BatchSyncForPush
+	hydrate             = pull.Hydrate
+	acquireLock         = acquireRepoLock
+	lfsObjectsPath      = common.LFS_OBJS_PATH
+	maxRequestBodyBytes = int64(1 << 20)
+)
+
+// server is the state git drs serve keeps between requests: the loaded
+// config, one client per remote (so auth tokens stay warm), and download
+// jobs.
+type server struct {
+	logger  *slog.Logger
+	token   string
+	started time.Time
+
+	mu      sync.Mutex
+	cfg     *config.Config
+	clients map[config.Remote]*config.GitContext
+	jobs    map[string]*downloadJob
+	nextJob int
+	// jobsDone, when set by tests, receives the id of each finished job.
+	jobsDone chan string
+}
+
+func newServer(logger *slog.Logger, token string) *server {
+	return &server{
+		logger:  logger,
+		token:   token,
+		started: time.Now().UTC(),
+		clients: make(map[config.Remote]*config.GitContext),
+		jobs:    make(map[string]*downloadJob),
+	}
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", s.handleStatus)
+	mux.HandleFunc("POST /v1/reload", s.handleReload)
+	mux.HandleFunc("GET /v1/objects", s.handleObjectsByChecksum)
+	mux.HandleFunc("GET /v1/objects/{id}", s.handleObject)
+	mux.HandleFunc("POST /v1/register", s.handleRegister)
+	mux.HandleFunc("POST /v1/downloads", s.handleStartDownload)
+	mux.HandleFunc("GET /v1/downloads", s.handleListDownloads)
+	mux.HandleFunc("GET /v1/downloads/{id}", s.handleDownload)
+	return s.authorize(mux)
+}
+
+// authorize requires the bearer token from the discovery file on every
+// request, so other local users and web pages cannot drive the daemon.
+func (s *server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// client returns the cached client for name, or for the default remote when
+// name is empty, creating it on first use.
+func (s *server) client(name string) (*config.GitContext, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cfg == nil {
+		cfg, err := loadCfg()
+		if err != nil {
+			return nil, fmt.Errorf("error loading config: %w", err)
+		}
+		s.cfg = cfg
+	}
+	remote, err := s.cfg.GetRemoteOrDefault(name)
+	if err != nil {
+		return nil, err
+	}
+	if gc, ok := s.clients[remote]; ok {
+		return gc, nil
+	}
+	gc, err := newRemoteClient(s.cfg, remote, s.logger)
+	if err != nil {
+		return nil, err
+	}
+	s.clients[remote] = gc
+	return gc, nil
+}
+
+type statusResponse struct {
+	Version       string   `json:"version"`
+	PID           int      `json:"pid"`
+	StartedAt     string   `json:"started_at"`
+	DefaultRemote string   `json:"default_remote,omitempty"`
+	Remotes       []string `json:"remotes"`
+	WarmRemotes   []string `json:"warm_remotes"`
+	Downloads     int      `json:"downloads_running"`
+}
+
+func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.client(""); err != nil && !errors.Is(err, config.ErrNoDefaultRemote) {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.mu.Lock()
+	res := statusResponse{
+		Version:       version.Version,
+		PID:           os.Getpid(),
+		StartedAt:     s.started.Format(time.RFC3339),
+		DefaultRemote: string(s.cfg.DefaultRemote),
+		Remotes:       []string{},
+		WarmRemotes:   []string{},
+	}
+	for name := range s.cfg.Remotes {
+		res.Remotes = append(res.Remotes, string(name))
+	}
+	for name := range s.clients {
+		res.WarmRemotes = append(res.WarmRemotes, string(name))
+	}
+	for _, job := range s.jobs {
+		if job.State == jobRunning {
+			res.Downloads++
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(res.Remotes)
+	sort.Strings(res.WarmRemotes)
+	writeJSON(w, http.StatusOK, res)
+}
+
+// handleReload drops the cached config and clients so the next request picks
+// up remote changes made with git drs remote.
+func (s *server) handleReload(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.cfg = nil
+	s.clients = make(map[config.Remote]*config.GitContext)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]bool{"reloaded": true})
+}
+
+func (s *server) handleObject(w http.ResponseWriter, r *http.Request) {
+	gc, err := s.client(r.URL.Query().Get("remote"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	obj, err := getObject(r.Context(), gc, r.PathValue("id"))
+	if err != nil {
+		writeError(w, drserrors.HTTPStatus(err, http.StatusBadGateway), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, obj)
+}
+
+func (s *server) handleObjectsByChecksum(w http.ResponseWriter, r *http.Request) {
+	sum := drsobject.NormalizeOid(r.URL.Query().Get("checksum"))
+	if len(sum) != 64 {
+		writeError(w, http.StatusBadRequest, errors.New("checksum must be a sha256 hex digest"))
+		return
+	}
+	gc, err := s.client(r.URL.Query().Get("remote"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	objs, err := objectsByHash(r.Context(), gc, sum)
+	if err != nil {
+		writeError(w, drserrors.HTTPStatus(err, http.StatusBadGateway), err)
+		return
+	}
+	if objs == nil {
+		objs = []drsapi.DrsObject{}
+	}
+	writeJSON(w, http.StatusOK, objs)
+}
+
+// pathsRequest is the body of register and download requests. Paths are
+// pathspecs relative to the repository root; an empty list selects every
+// LFS file.
+type pathsRequest struct {
+	Remote string   `json:"remote"`
+	Paths  []string `json:"paths"`
+}
+
+type registerResponse struct {
+	Remote     string   `json:"remote"`
+	Registered []string `json:"registered"`
+	Excluded   []string `json:"excluded"`
+	Warning    string   `json:"warning,omitempty"`
+}
+
+// handleRegister registers and uploads the selected LFS files the way git
+// drs push does, without pushing Git refs.
+func (s *server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var req pathsRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	gc, err := s.client(req.Remote)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	lock, err := acquireLock(r.Context(), "git drs serve register")
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	defer lock.Release()
+
+	tracked, err := loadTrackedFiles(s.logger)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to discover LFS files: %w", err))
+		return
+	}
+	selected := make(map[string]lfs.LfsFileInfo)
+	for path, info := range tracked {
+		if pathspec.MatchesAny(path, req.Paths) {
+			selected[path] = info
+		}
+	}
+	policy, err := loadRegister()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	registered, excluded := policy.Filter(selected)
+	limits, err := loadLimits()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	warning, err := checkLimits(gc, r.Context(), registered, limits)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err := registerFiles(gc, r.Context(), registered, nil); err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed batch register/upload workflow: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, registerResponse{
+		Remote:     gc.RemoteName,
+		Registered: sortedKeys(registered),
+		Excluded:   sortedKeys(excluded),
+		Warning:    warning,
+	})
+}
+
+const (
+	jobRunning   = "running"
+	jobSucceeded = "succeeded"
+	jobFailed    = "failed"
+)
+
+// downloadJob hydrates pointer files in the background. Per-file progress is
+// read from the LFS object cache, so a status poll reflects every object
+// downloaded so far.
+type downloadJob struct {
+	ID         string         `json:"id"`
+	Remote     string         `json:"remote"`
+	State      string         `json:"state"`
+	Error      string         `json:"error,omitempty"`
+	StartedAt  string         `json:"started_at"`
+	FinishedAt string         `json:"finished_at,omitempty"`
+	Files      []downloadFile `json:"files"`
+}
+
+type downloadFile struct {
+	Path       string `json:"path"`
+	Oid        string `json:"oid"`
+	Size       int64  `json:"size"`
+	Downloaded bool   `json:"downloaded"`
+}
+
+type downloadStatus struct {
+	downloadJob
+	FilesTotal      int `json:"files_total"`
+	FilesDownloaded int `json:"files_downloaded"`
+}
+
+func (s *server) handleStartDownload(w http.ResponseWriter, r *http.Request) {
+	var req pathsRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	gc, err := s.client(req.Remote)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	inventory, err := loadWorktreeFiles(s.logger)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to discover pointer files in worktree: %w", err))
+		return
+	}
+	job := &downloadJob{
+		Remote:    gc.RemoteName,
+		State:     jobRunning,
+		StartedAt: time.Now().UTC().Format(time.RFC3339),
+		Files:     []downloadFile{},
+	}
+	for _, path := range sortedKeys(inventory) {
+		if pathspec.MatchesAny(path, req.Paths) {
+			info := inventory[path]
+			job.Files = append(job.Files, downloadFile{Path: path, Oid: info.Oid, Size: info.Size})
+		}
+	}
+
+	s.mu.Lock()
+	s.nextJob++
+	job.ID = strconv.Itoa(s.nextJob)
+	s.jobs[job.ID] = job
+	snapshot := s.statusLocked(job)
+	s.mu.Unlock()
+
+	go s.runDownload(job, gc, req.Paths)
+	writeJSON(w, http.StatusAccepted, snapshot)
+}
+
+func (s *server) runDownload(job *downloadJob, gc *config.GitContext, patterns []string) {
+	err := func() error {
+		// Queue behind other git-drs commands rather than failing the job.
+		lock, err := acquireLock(context.Background(), "git drs serve download")
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+		return hydrate(io.Discard, gc, patterns, false)
+	}()
+
+	s.mu.Lock()
+	job.State = jobSucceeded
+	if err != nil {
+		job.State, job.Error = jobFailed, err.Error()
+		s.logger.Warn("serve download failed", "job", job.ID, "error", err)
+	}
+	job.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+	done := s.jobsDone
+	s.mu.Unlock()
+	if done != nil {
+		done <- job.ID
+	}
+}
+
+func (s *server) handleListDownloads(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	out := make([]downloadStatus, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		out = append(out, s.statusLocked(job))
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		a, _ := strconv.Atoi(out[i].ID)
+		b, _ := strconv.Atoi(out[j].ID)
+		return a < b
+	})
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	job, ok := s.jobs[r.PathValue("id")]
+	var res downloadStatus
+	if ok {
+		res = s.statusLocked(job)
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no download job %q", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// statusLocked copies job with per-file progress filled in. s.mu must be
+// held.
+func (s *server) statusLocked(job *downloadJob) downloadStatus {
+	res := downloadStatus{downloadJob: *job, FilesTotal: len(job.Files)}
+	res.Files = make([]downloadFile, len(job.Files))
+	for i, f := range job.Files {
+		if path, err := lfs.ObjectPath(lfsObjectsPath, f.Oid); err == nil {
+			if _, err := os.Stat(path); err == nil {
+				f.Downloaded = true
+				res.FilesDownloaded++
+			}
+		}
+		res.Files[i] = f
+	}
+	return res
+}
+
+// acquireRepoLock takes the repository lock for one daemon operation, waiting
+// for other git-drs commands to finish. The daemon never holds it between
+// requests.
+func acquireRepoLock(ctx context.Context, command string) (*repolock.Lock, error) {
+	drsDir, err := gitrepo.DrsTopLevel()
+	if err != nil {
+		return nil, err
+	}
+	return repolock.Acquire(ctx, filepath.Join(drsDir, "lock"), repolock.Options{Command: command, Wait: true})
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]lfs.LfsFileInfo) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = common.WriteJSON(w, v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
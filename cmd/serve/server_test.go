@@ -0,0 +1,217 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/guardrail"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pushsync"
+	"github.com/calypr/git-drs/internal/registerpolicy"
+	"github.com/calypr/git-drs/internal/repolock"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+const testToken = "secret"
+
+var (
+	oidA = strings.Repeat("a", 64)
+	oidB = strings.Repeat("b", 64)
+)
+
+// stubServer swaps every seam and returns a running test server plus the
+// number of remote clients created so far.
+func stubServer(t *testing.T) (*httptest.Server, *server, *int) {
+	t.Helper()
+	origCfg, origClient, origGet, origLock, origObjects := loadCfg, newRemoteClient, getObject, acquireLock, lfsObjectsPath
+	t.Cleanup(func() {
+		loadCfg, newRemoteClient, getObject, acquireLock, lfsObjectsPath = origCfg, origClient, origGet, origLock, origObjects
+	})
+	loadCfg = func() (*config.Config, error) {
+		return &config.Config{
+			DefaultRemote: "origin",
+			Remotes:       map[config.Remote]config.RemoteSelect{"origin": {}, "mirror": {}},
+		}, nil
+	}
+	clients := 0
+	newRemoteClient = func(_ *config.Config, remote config.Remote, _ *slog.Logger) (*config.GitContext, error) {
+		clients++
+		return &config.GitContext{RemoteName: string(remote)}, nil
+	}
+	getObject = func(_ context.Context, gc *config.GitContext, id string) (drsapi.DrsObject, error) {
+		return drsapi.DrsObject{Id: id, Name: &gc.RemoteName}, nil
+	}
+	acquireLock = func(context.Context, string) (*repolock.Lock, error) { return nil, nil }
+	lfsObjectsPath = t.TempDir()
+
+	srv := newServer(drslog.NewNoOpLogger(), testToken)
+	ts := httptest.NewServer(srv.routes())
+	t.Cleanup(ts.Close)
+	return ts, srv, &clients
+}
+
+func do(t *testing.T, ts *httptest.Server, method, path, body string, out any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			t.Fatalf("decode %s %s response %q: %v", method, path, data, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestServeRequiresToken(t *testing.T) {
+	ts, _, _ := stubServer(t)
+	resp, err := http.Get(ts.URL + "/v1/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", resp.StatusCode)
+	}
+}
+
+func TestServeReusesRemoteClients(t *testing.T) {
+	ts, _, clients := stubServer(t)
+
+	var status statusResponse
+	if code := do(t, ts, http.MethodGet, "/v1/status", "", &status); code != http.StatusOK {
+		t.Fatalf("status returned %d", code)
+	}
+	if status.DefaultRemote != "origin" || len(status.Remotes) != 2 || len(status.WarmRemotes) != 1 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	for _, path := range []string{"/v1/objects/did-1", "/v1/objects/did-2", "/v1/objects/did-3?remote=mirror"} {
+		var obj drsapi.DrsObject
+		if code := do(t, ts, http.MethodGet, path, "", &obj); code != http.StatusOK {
+			t.Fatalf("GET %s returned %d", path, code)
+		}
+	}
+	if *clients != 2 {
+		t.Fatalf("expected one client per remote, created %d", *clients)
+	}
+
+	do(t, ts, http.MethodPost, "/v1/reload", "", nil)
+	do(t, ts, http.MethodGet, "/v1/objects/did-4", "", nil)
+	if *clients != 3 {
+		t.Fatalf("expected reload to drop cached clients, created %d", *clients)
+	}
+
+	if code := do(t, ts, http.MethodGet, "/v1/objects?checksum=abc", "", nil); code != http.StatusBadRequest {
+		t.Fatalf("expected short checksum to be rejected, got %d", code)
+	}
+}
+
+func TestServeRegisterAppliesPolicyAndLimits(t *testing.T) {
+	ts, _, _ := stubServer(t)
+	origTracked, origRegister, origLimits, origCheck, origSync := loadTrackedFiles, loadRegister, loadLimits, checkLimits, registerFiles
+	t.Cleanup(func() {
+		loadTrackedFiles, loadRegister, loadLimits, checkLimits, registerFiles = origTracked, origRegister, origLimits, origCheck, origSync
+	})
+	loadTrackedFiles = func(*slog.Logger) (map[string]lfs.LfsFileInfo, error) {
+		return map[string]lfs.LfsFileInfo{
+			"data/a.bam":     {Name: "data/a.bam", Oid: oidA},
+			"data/notes.tmp": {Name: "data/notes.tmp", Oid: oidB},
+			"other/c.bam":    {Name: "other/c.bam", Oid: oidB},
+		}, nil
+	}
+	loadRegister = func() (registerpolicy.Policy, error) {
+		return registerpolicy.Policy{Exclude: []string{"*.tmp"}}, nil
+	}
+	loadLimits = func() (guardrail.Policy, error) { return guardrail.Policy{}, nil }
+	checkLimits = func(*config.GitContext, context.Context, map[string]lfs.LfsFileInfo, guardrail.Policy) (string, error) {
+		return "near quota", nil
+	}
+	var synced map[string]lfs.LfsFileInfo
+	registerFiles = func(_ *config.GitContext, _ context.Context, files map[string]lfs.LfsFileInfo, _ pushsync.UploadProgressReporter) error {
+		synced = files
+		return nil
+	}
+
+	var res registerResponse
+	if code := do(t, ts, http.MethodPost, "/v1/register", `{"paths":["data/*"]}`, &res); code != http.StatusOK {
+		t.Fatalf("register returned %d", code)
+	}
+	if len(synced) != 1 || synced["data/a.bam"].Oid != oidA {
+		t.Fatalf("unexpected files registered: %v", synced)
+	}
+	if res.Remote != "origin" || len(res.Excluded) != 1 || res.Excluded[0] != "data/notes.tmp" || res.Warning != "near quota" {
+		t.Fatalf("unexpected register response: %+v", res)
+	}
+
+	if code := do(t, ts, http.MethodPost, "/v1/register", `{"path":"typo"}`, nil); code != http.StatusBadRequest {
+		t.Fatalf("expected unknown field to be rejected, got %d", code)
+	}
+}
+
+func TestServeDownloadJobReportsProgress(t *testing.T) {
+	ts, srv, _ := stubServer(t)
+	origWorktree, origHydrate := loadWorktreeFiles, hydrate
+	t.Cleanup(func() { loadWorktreeFiles, hydrate = origWorktree, origHydrate })
+	loadWorktreeFiles = func(*slog.Logger) (map[string]lfs.LfsFileInfo, error) {
+		return map[string]lfs.LfsFileInfo{
+			"data/a.bam": {Name: "data/a.bam", Oid: oidA, Size: 3},
+			"data/b.bam": {Name: "data/b.bam", Oid: oidB, Size: 4},
+		}, nil
+	}
+	release := make(chan struct{})
+	hydrate = func(_ io.Writer, gc *config.GitContext, patterns []string, _ bool) error {
+		// The first object lands in the cache before the job finishes.
+		path, _ := lfs.ObjectPath(lfsObjectsPath, oidA)
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		_ = os.WriteFile(path, []byte("abc"), 0o644)
+		<-release
+		return io.ErrUnexpectedEOF
+	}
+	srv.jobsDone = make(chan string, 1)
+
+	var started downloadStatus
+	if code := do(t, ts, http.MethodPost, "/v1/downloads", `{"paths":["data/**"]}`, &started); code != http.StatusAccepted {
+		t.Fatalf("start download returned %d", code)
+	}
+	if started.State != jobRunning || started.FilesTotal != 2 {
+		t.Fatalf("unexpected job: %+v", started)
+	}
+
+	close(release)
+	<-srv.jobsDone
+
+	var status downloadStatus
+	if code := do(t, ts, http.MethodGet, "/v1/downloads/"+started.ID, "", &status); code != http.StatusOK {
+		t.Fatalf("download status returned %d", code)
+	}
+	if status.State != jobFailed || status.Error == "" || status.FilesDownloaded != 1 || !status.Files[0].Downloaded {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	var all []downloadStatus
+	do(t, ts, http.MethodGet, "/v1/downloads", "", &all)
+	if len(all) != 1 {
+		t.Fatalf("expected one job listed, got %d", len(all))
+	}
+	if code := do(t, ts, http.MethodGet, "/v1/downloads/99", "", nil); code != http.StatusNotFound {
+		t.Fatalf("expected unknown job to 404, got %d", code)
+	}
+}
@@ -0,0 +1,252 @@
+package share
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/signedurl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remote   string
+	ttl      time.Duration
+	manifest bool
+)
+
+var (
+	loadConfig      = config.LoadConfig
+	newRemoteClient = func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+		return cfg.GetRemoteClient(remote, logger)
+	}
+	loadLFSInventory = lfs.GetTrackedLfsFiles
+	gitTopLevel      = gitrepo.GitTopLevel
+	resolveLink      = defaultResolveLink
+)
+
+var sha256Hex = regexp.MustCompile(`^(sha256:)?[0-9a-fA-F]{64}$`)
+
+// link is one shareable download URL.
+type link struct {
+	Path      string `json:"path,omitempty"`
+	OID       string `json:"oid"`
+	DRSID     string `json:"drs_id"`
+	Size      int64  `json:"size,omitempty"`
+	URL       string `json:"url"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// target is a path or bare OID to resolve.
+type target struct {
+	Path string
+	OID  string
+	Size int64
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "share <path-or-oid>...",
+	Short: "Print time-limited download links for tracked files",
+	Long: "Description:" +
+		"\n  Resolve a signed download URL from the DRS remote for each path or oid so the" +
+		"\n  file can be handed to a collaborator without granting project access. Use" +
+		"\n  --manifest to list links for every tracked file under a directory, and --ttl" +
+		"\n  to request a lifetime where the server supports it.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires at least 1 argument (path or oid), received 0\n\nUsage: %s\n\nSee 'git drs share --help' for more details", cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := drslog.GetLogger()
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("error loading config: %w", err)
+		}
+		remoteName, err := cfg.GetRemoteOrDefault(remote)
+		if err != nil {
+			return err
+		}
+		gc, err := newRemoteClient(cfg, remoteName, logger)
+		if err != nil {
+			return err
+		}
+
+		targets, err := collectTargets(args, manifest, logger)
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		links := make([]link, 0, len(targets))
+		for _, t := range targets {
+			l, err := resolveLink(ctx, gc, t, ttl)
+			if err != nil {
+				name := t.Path
+				if name == "" {
+					name = t.OID
+				}
+				return fmt.Errorf("error sharing %s: %w", name, err)
+			}
+			links = append(links, l)
+		}
+		return writeLinks(cmd.OutOrStdout(), links, manifest, common.JSONOutput())
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote to resolve links from (default: default remote)")
+	Cmd.Flags().DurationVar(&ttl, "ttl", 0, "requested link lifetime, e.g. 30m or 24h (server default when unset or unsupported)")
+	Cmd.Flags().BoolVar(&manifest, "manifest", false, "list links for every tracked file under the given paths")
+}
+
+// collectTargets maps arguments to tracked files. Bare oids pass through;
+// paths must be tracked files, or directories when manifest is set.
+func collectTargets(args []string, manifest bool, logger *slog.Logger) ([]target, error) {
+	var (
+		inventory map[string]lfs.LfsFileInfo
+		root      string
+	)
+	var targets []target
+	for _, arg := range args {
+		if sha256Hex.MatchString(arg) {
+			if _, err := os.Stat(arg); err != nil {
+				targets = append(targets, target{OID: drsobject.NormalizeOid(strings.ToLower(arg))})
+				continue
+			}
+		}
+		if inventory == nil {
+			var err error
+			if inventory, err = loadLFSInventory(logger); err != nil {
+				return nil, fmt.Errorf("error listing tracked files: %w", err)
+			}
+			if root, err = gitTopLevel(); err != nil {
+				return nil, err
+			}
+		}
+		rel, err := repoRelative(root, arg)
+		if err != nil {
+			return nil, err
+		}
+		if info, ok := inventory[rel]; ok {
+			targets = append(targets, target{Path: rel, OID: info.Oid, Size: info.Size})
+			continue
+		}
+		if !manifest {
+			return nil, fmt.Errorf("%s is not a tracked DRS file (use --manifest for directories)", arg)
+		}
+		prefix := strings.TrimSuffix(rel, "/") + "/"
+		if rel == "." {
+			prefix = ""
+		}
+		var matched []string
+		for p := range inventory {
+			if strings.HasPrefix(p, prefix) {
+				matched = append(matched, p)
+			}
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("no tracked DRS files under %s", arg)
+		}
+		sort.Strings(matched)
+		for _, p := range matched {
+			info := inventory[p]
+			targets = append(targets, target{Path: p, OID: info.Oid, Size: info.Size})
+		}
+	}
+	return targets, nil
+}
+
+func repoRelative(root, arg string) (string, error) {
+	abs, err := filepath.Abs(arg)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		abs = filepath.Join(resolved, filepath.Base(abs))
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is outside the repository", arg)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// defaultResolveLink asks the server for a link with the requested lifetime
+// and falls back to the standard DRS access URL when the server cannot honor
+// a custom TTL. Each object is signed once.
+func defaultResolveLink(ctx context.Context, gc *config.GitContext, t target, ttl time.Duration) (link, error) {
+	obj, err := drsremote.ResolveObject(ctx, gc, t.OID)
+	if err != nil {
+		return link{}, err
+	}
+	url := ""
+	if ttl > 0 {
+		signed, err := gc.Client.Data().DownloadURL(ctx, obj.Id, int(ttl.Seconds()), false)
+		if err == nil && signed.Url != nil && *signed.Url != "" {
+			url = *signed.Url
+		} else if gc.Logger != nil {
+			gc.Logger.Warn("server did not honor --ttl; using its default link lifetime", "drs_id", obj.Id, "error", err)
+		}
+	}
+	if url == "" {
+		access, err := drsremote.AccessURLForObject(ctx, gc, *obj)
+		if err != nil {
+			return link{}, err
+		}
+		url = access.Url
+	}
+	l := link{Path: t.Path, OID: t.OID, DRSID: obj.Id, Size: t.Size, URL: url}
+	if l.Size == 0 {
+		l.Size = obj.Size
+	}
+	if exp, ok := signedurl.Expiry(url, time.Now()); ok {
+		l.ExpiresAt = exp.UTC().Format(time.RFC3339)
+	}
+	return l, nil
+}
+
+func writeLinks(w io.Writer, links []link, manifest, asJSON bool) error {
+	if asJSON {
+		return common.WriteJSON(w, links)
+	}
+	for _, l := range links {
+		if !manifest {
+			fmt.Fprintln(w, l.URL)
+			continue
+		}
+		name := l.Path
+		if name == "" {
+			name = l.OID
+		}
+		expires := l.ExpiresAt
+		if expires == "" {
+			expires = "-"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", name, l.Size, expires, l.URL)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package share
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	syclient "github.com/calypr/syfon/client"
+)
+
+func stubShare(t *testing.T, root string) *[]time.Duration {
+	t.Helper()
+	origLoad, origClient, origInv, origTop, origResolve := loadConfig, newRemoteClient, loadLFSInventory, gitTopLevel, resolveLink
+	t.Cleanup(func() {
+		loadConfig, newRemoteClient, loadLFSInventory, gitTopLevel, resolveLink = origLoad, origClient, origInv, origTop, origResolve
+		remote, ttl, manifest = "", 0, false
+		common.SetJSONOutput(false)
+	})
+
+	loadConfig = func() (*config.Config, error) {
+		return &config.Config{
+			DefaultRemote: "origin",
+			Remotes:       map[config.Remote]config.RemoteSelect{"origin": {Gen3: &config.Gen3Remote{ProjectID: "p"}}},
+		}, nil
+	}
+	newRemoteClient = func(*config.Config, config.Remote, *slog.Logger) (*config.GitContext, error) {
+		return &config.GitContext{}, nil
+	}
+	loadLFSInventory = func(*slog.Logger) (map[string]lfs.LfsFileInfo, error) {
+		return map[string]lfs.LfsFileInfo{
+			"data/a.bam":     {Oid: strings.Repeat("a", 64), Size: 1},
+			"data/sub/b.bam": {Oid: strings.Repeat("b", 64), Size: 2},
+			"other/c.txt":    {Oid: strings.Repeat("c", 64), Size: 3},
+		}, nil
+	}
+	gitTopLevel = func() (string, error) { return root, nil }
+	var ttls []time.Duration
+	resolveLink = func(_ context.Context, _ *config.GitContext, tg target, d time.Duration) (link, error) {
+		ttls = append(ttls, d)
+		return link{Path: tg.Path, OID: tg.OID, DRSID: "id-" + tg.OID[:1], Size: tg.Size, URL: "https://signed.example/" + tg.OID[:1]}, nil
+	}
+	return &ttls
+}
+
+func TestShareSinglePathPrintsURL(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	ttls := stubShare(t, root)
+
+	var out bytes.Buffer
+	Cmd.SetOut(&out)
+	Cmd.SetArgs([]string{"data/a.bam", "--ttl", "30m"})
+	if err := Cmd.Execute(); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "https://signed.example/a" {
+		t.Fatalf("unexpected output %q", got)
+	}
+	if len(*ttls) != 1 || (*ttls)[0] != 30*time.Minute {
+		t.Fatalf("expected ttl passed through, got %v", *ttls)
+	}
+}
+
+func TestShareManifestListsDirectory(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	stubShare(t, root)
+
+	var out bytes.Buffer
+	Cmd.SetOut(&out)
+	common.SetJSONOutput(true)
+	Cmd.SetArgs([]string{"data", "--manifest"})
+	if err := Cmd.Execute(); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	var links []link
+	if err := json.Unmarshal(out.Bytes(), &links); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	if len(links) != 2 || links[0].Path != "data/a.bam" || links[1].Path != "data/sub/b.bam" {
+		t.Fatalf("unexpected manifest: %+v", links)
+	}
+}
+
+func TestCollectTargetsRejectsUntrackedPathWithoutManifest(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	stubShare(t, root)
+
+	if _, err := collectTargets([]string{"data"}, false, drslog.NewNoOpLogger()); err == nil {
+		t.Fatalf("expected error for directory without --manifest")
+	}
+	targets, err := collectTargets([]string{"sha256:" + strings.Repeat("D", 64)}, false, drslog.NewNoOpLogger())
+	if err != nil || len(targets) != 1 || targets[0].OID != strings.Repeat("d", 64) {
+		t.Fatalf("expected bare oid target, got %+v, %v", targets, err)
+	}
+}
+
+func TestDefaultResolveLinkSignsOnce(t *testing.T) {
+	oid := strings.Repeat("a", 64)
+	accessID := "s3"
+	methods := []drsapi.AccessMethod{{Type: drsapi.AccessMethodTypeS3, AccessId: &accessID}}
+	controlled := []string{"/organization/org1/project/proj1"}
+	body, err := json.Marshal(drsapi.N200OkDrsObjects{ResolvedDrsObject: &[]drsapi.DrsObject{{
+		Id: "obj-1", Size: 7, ControlledAccess: &controlled, AccessMethods: &methods,
+		Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: oid}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name        string
+		honorTTL    bool
+		wantURL     string
+		wantSigns   int
+		wantAccess  int
+		wantExpires bool
+	}{
+		{name: "custom ttl", honorTTL: true, wantURL: "https://signed.example/ttl?X-Amz-Date=20260101T000000Z&X-Amz-Expires=1800", wantSigns: 1, wantExpires: true},
+		{name: "server default", wantURL: "https://signed.example/default", wantSigns: 1, wantAccess: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var signs, access int
+			httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				status, out := http.StatusOK, ""
+				switch {
+				case r.URL.Path == "/ga4gh/drs/v1/objects/checksum/"+oid:
+					out = string(body)
+				case r.URL.Path == "/data/download/obj-1":
+					signs++
+					if r.URL.Query().Get("expires_in") != "1800" {
+						t.Errorf("expected the requested ttl, got %s", r.URL.RawQuery)
+					}
+					out = `{"url":"https://signed.example/ttl?X-Amz-Date=20260101T000000Z&X-Amz-Expires=1800"}`
+					if !tc.honorTTL {
+						status, out = http.StatusNotImplemented, `{}`
+					}
+				case r.URL.Path == "/ga4gh/drs/v1/objects/obj-1/access/s3":
+					access++
+					out = `{"url":"https://signed.example/default"}`
+				default:
+					t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+				}
+				return &http.Response{
+					StatusCode: status,
+					Body:       io.NopCloser(strings.NewReader(out)),
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Request:    r,
+				}, nil
+			})}
+			raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+			if err != nil {
+				t.Fatalf("syclient.New: %v", err)
+			}
+			gc := &config.GitContext{Client: raw.(*syclient.Client), Organization: "org1", ProjectId: "proj1"}
+
+			l, err := defaultResolveLink(context.Background(), gc, target{Path: "data/a.bam", OID: oid}, 30*time.Minute)
+			if err != nil {
+				t.Fatalf("defaultResolveLink: %v", err)
+			}
+			if l.URL != tc.wantURL || l.DRSID != "obj-1" || l.Size != 7 {
+				t.Fatalf("unexpected link %+v", l)
+			}
+			if signs != tc.wantSigns || access != tc.wantAccess {
+				t.Fatalf("expected %d ttl and %d default signings, got %d and %d", tc.wantSigns, tc.wantAccess, signs, access)
+			}
+			if (l.ExpiresAt != "") != tc.wantExpires {
+				t.Fatalf("unexpected expiry %q", l.ExpiresAt)
+			}
+		})
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
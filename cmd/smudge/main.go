@@ -47,6 +47,11 @@ func runSmudge(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("smudge: load config: %w", err)
 	}
 
+	if drsfilter.SkipSmudge() {
+		logger.Debug("smudge: skipped; passing through pointer", "pathname", pathname)
+		return drsfilter.SmudgeContent(ctx, pathname, os.Stdin, os.Stdout, logger, nil)
+	}
+
 	remote, err := cfg.GetDefaultRemote()
 	if err != nil {
 		if errors.Is(err, config.ErrNoDefaultRemote) {
@@ -0,0 +1,294 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pathspec"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remote  string
+	offline bool
+	depth   int
+)
+
+var (
+	loadConfig      = config.LoadConfig
+	newRemoteClient = func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+		return cfg.GetRemoteClient(remote, logger)
+	}
+	loadLFSInventory = lfs.GetTrackedLfsFiles
+	lookupObjects    = drsremote.ObjectsByHashesForScope
+)
+
+// Report summarizes tracked LFS content and, unless offline, how much of it
+// the DRS remote already holds.
+type Report struct {
+	Files          int         `json:"files"`
+	TotalBytes     int64       `json:"total_bytes"`
+	UniqueOIDs     int         `json:"unique_oids"`
+	UniqueBytes    int64       `json:"unique_bytes"`
+	DuplicateBytes int64       `json:"duplicate_bytes"`
+	Duplicates     []Duplicate `json:"duplicates,omitempty"`
+	Remote         string      `json:"remote,omitempty"`
+	RemoteChecked  bool        `json:"remote_checked"`
+	PresentOIDs    int         `json:"present_oids,omitempty"`
+	PresentBytes   int64       `json:"present_bytes,omitempty"`
+	PendingOIDs    int         `json:"pending_oids,omitempty"`
+	PendingBytes   int64       `json:"pending_bytes,omitempty"`
+	Directories    []DirStats  `json:"directories"`
+}
+
+// Duplicate is one oid tracked at more than one path.
+type Duplicate struct {
+	OID   string   `json:"oid"`
+	Size  int64    `json:"size"`
+	Paths []string `json:"paths"`
+}
+
+// DirStats is the per-directory breakdown. Pending bytes count each oid once
+// per directory.
+type DirStats struct {
+	Dir          string `json:"dir"`
+	Files        int    `json:"files"`
+	Bytes        int64  `json:"bytes"`
+	PendingBytes int64  `json:"pending_bytes,omitempty"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "stats [pathspec...]",
+	Short: "Summarize tracked LFS content and pending upload size",
+	Long: "Description:" +
+		"\n  Report total LFS bytes, unique oids, files that share an oid, and a" +
+		"\n  per-directory breakdown. Unless --offline is set, the DRS remote is" +
+		"\n  queried to split unique content into bytes already registered and bytes" +
+		"\n  the next push would upload.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if depth < 1 {
+			return fmt.Errorf("--depth must be at least 1")
+		}
+		logger := drslog.GetLogger()
+		inventory, err := loadLFSInventory(logger)
+		if err != nil {
+			return fmt.Errorf("error listing tracked files: %w", err)
+		}
+
+		var present func([]string) (map[string]bool, error)
+		remoteName := ""
+		if !offline {
+			cfg, err := loadConfig()
+			if err != nil {
+				return fmt.Errorf("error loading config: %w", err)
+			}
+			name, err := cfg.GetRemoteOrDefault(remote)
+			if err != nil {
+				return err
+			}
+			gc, err := newRemoteClient(cfg, name, logger)
+			if err != nil {
+				return err
+			}
+			remoteName = string(name)
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			present = func(oids []string) (map[string]bool, error) {
+				return remotePresence(ctx, gc, oids)
+			}
+		}
+
+		report, err := buildReport(inventory, pathspec.Rooted(args), depth, present)
+		if err != nil {
+			return err
+		}
+		report.Remote = remoteName
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), report)
+		}
+		return writeReport(cmd.OutOrStdout(), report)
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote to compare against (default: default remote)")
+	Cmd.Flags().BoolVar(&offline, "offline", false, "skip the remote lookup and report local totals only")
+	Cmd.Flags().IntVar(&depth, "depth", 1, "directory depth for the per-directory breakdown")
+}
+
+func remotePresence(ctx context.Context, gc *config.GitContext, oids []string) (map[string]bool, error) {
+	results, err := lookupObjects(ctx, gc, oids)
+	if err != nil {
+		return nil, fmt.Errorf("error querying remote: %w", err)
+	}
+	out := make(map[string]bool, len(results))
+	for oid, objs := range results {
+		out[oid] = len(objs) > 0
+	}
+	return out, nil
+}
+
+// buildReport aggregates the inventory. present may be nil for offline runs.
+func buildReport(inventory map[string]lfs.LfsFileInfo, patterns []string, depth int, present func([]string) (map[string]bool, error)) (Report, error) {
+	paths := make([]string, 0, len(inventory))
+	for p, info := range inventory {
+		if info.Oid == "" || !pathspec.MatchesAny(p, patterns) {
+			continue
+		}
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var report Report
+	sizes := make(map[string]int64)
+	byOID := make(map[string][]string)
+	var oids []string
+	for _, p := range paths {
+		info := inventory[p]
+		report.Files++
+		report.TotalBytes += info.Size
+		if _, ok := sizes[info.Oid]; !ok {
+			sizes[info.Oid] = info.Size
+			oids = append(oids, info.Oid)
+		}
+		byOID[info.Oid] = append(byOID[info.Oid], p)
+	}
+	report.UniqueOIDs = len(oids)
+	for _, oid := range oids {
+		report.UniqueBytes += sizes[oid]
+		if ps := byOID[oid]; len(ps) > 1 {
+			report.Duplicates = append(report.Duplicates, Duplicate{OID: oid, Size: sizes[oid], Paths: ps})
+		}
+	}
+	report.DuplicateBytes = report.TotalBytes - report.UniqueBytes
+	sort.SliceStable(report.Duplicates, func(i, j int) bool {
+		return report.Duplicates[i].Size*int64(len(report.Duplicates[i].Paths)) > report.Duplicates[j].Size*int64(len(report.Duplicates[j].Paths))
+	})
+
+	var onRemote map[string]bool
+	if present != nil && len(oids) > 0 {
+		var err error
+		if onRemote, err = present(oids); err != nil {
+			return Report{}, err
+		}
+	}
+	if present != nil {
+		report.RemoteChecked = true
+		for _, oid := range oids {
+			if onRemote[oid] {
+				report.PresentOIDs++
+				report.PresentBytes += sizes[oid]
+			} else {
+				report.PendingOIDs++
+				report.PendingBytes += sizes[oid]
+			}
+		}
+	}
+
+	dirs := make(map[string]*DirStats)
+	dirPending := make(map[string]map[string]struct{})
+	for _, p := range paths {
+		info := inventory[p]
+		d := dirKey(p, depth)
+		ds, ok := dirs[d]
+		if !ok {
+			ds = &DirStats{Dir: d}
+			dirs[d] = ds
+			dirPending[d] = make(map[string]struct{})
+		}
+		ds.Files++
+		ds.Bytes += info.Size
+		if report.RemoteChecked && !onRemote[info.Oid] {
+			if _, seen := dirPending[d][info.Oid]; !seen {
+				dirPending[d][info.Oid] = struct{}{}
+				ds.PendingBytes += info.Size
+			}
+		}
+	}
+	report.Directories = make([]DirStats, 0, len(dirs))
+	for _, ds := range dirs {
+		report.Directories = append(report.Directories, *ds)
+	}
+	sort.Slice(report.Directories, func(i, j int) bool {
+		return report.Directories[i].Dir < report.Directories[j].Dir
+	})
+	return report, nil
+}
+
+// dirKey truncates the parent directory of p to depth components.
+func dirKey(p string, depth int) string {
+	dir := path.Dir(p)
+	if dir == "." {
+		return "."
+	}
+	parts := strings.Split(dir, "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/")
+}
+
+func writeReport(w io.Writer, r Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Tracked files:     %d (%s)\n", r.Files, humanBytes(r.TotalBytes))
+	fmt.Fprintf(&b, "Unique objects:    %d (%s)\n", r.UniqueOIDs, humanBytes(r.UniqueBytes))
+	fmt.Fprintf(&b, "Duplicate content: %d oids at multiple paths (%s deduplicated)\n", len(r.Duplicates), humanBytes(r.DuplicateBytes))
+	if r.RemoteChecked {
+		fmt.Fprintf(&b, "On remote %s:  %d objects (%s)\n", r.Remote, r.PresentOIDs, humanBytes(r.PresentBytes))
+		fmt.Fprintf(&b, "Pending upload:    %d objects (%s)\n", r.PendingOIDs, humanBytes(r.PendingBytes))
+	}
+	if len(r.Directories) > 0 {
+		b.WriteString("\nBy directory:\n")
+		for _, d := range r.Directories {
+			fmt.Fprintf(&b, "  %-30s %6d files  %10s", d.Dir, d.Files, humanBytes(d.Bytes))
+			if r.RemoteChecked {
+				fmt.Fprintf(&b, "  %10s pending", humanBytes(d.PendingBytes))
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(r.Duplicates) > 0 {
+		b.WriteString("\nDuplicates:\n")
+		for _, d := range r.Duplicates {
+			fmt.Fprintf(&b, "  %s (%s) x%d\n", shortOID(d.OID), humanBytes(d.Size), len(d.Paths))
+			for _, p := range d.Paths {
+				fmt.Fprintf(&b, "    %s\n", p)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func shortOID(oid string) string {
+	if len(oid) <= 10 {
+		return oid
+	}
+	return oid[:10]
+}
+
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,81 @@
+package stats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+func TestBuildReportCountsDuplicatesAndPending(t *testing.T) {
+	oidA := strings.Repeat("a", 64)
+	oidB := strings.Repeat("b", 64)
+	oidC := strings.Repeat("c", 64)
+	inventory := map[string]lfs.LfsFileInfo{
+		"data/raw/a.bin":  {Oid: oidA, Size: 100},
+		"data/copy/a.bin": {Oid: oidA, Size: 100},
+		"data/b.bin":      {Oid: oidB, Size: 40},
+		"top.bin":         {Oid: oidC, Size: 7},
+		"docs/skip.bin":   {Oid: oidB, Size: 40},
+	}
+	var queried []string
+	present := func(oids []string) (map[string]bool, error) {
+		queried = oids
+		return map[string]bool{oidB: true}, nil
+	}
+
+	report, err := buildReport(inventory, []string{"data/**", "top.bin"}, 1, present)
+	if err != nil {
+		t.Fatalf("buildReport: %v", err)
+	}
+	if len(queried) != 3 {
+		t.Fatalf("expected 3 unique oids queried, got %v", queried)
+	}
+	if report.Files != 4 || report.TotalBytes != 247 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+	if report.UniqueOIDs != 3 || report.UniqueBytes != 147 || report.DuplicateBytes != 100 {
+		t.Fatalf("unexpected unique totals: %+v", report)
+	}
+	if len(report.Duplicates) != 1 || report.Duplicates[0].OID != oidA || len(report.Duplicates[0].Paths) != 2 {
+		t.Fatalf("unexpected duplicates: %+v", report.Duplicates)
+	}
+	if report.PresentOIDs != 1 || report.PresentBytes != 40 || report.PendingOIDs != 2 || report.PendingBytes != 107 {
+		t.Fatalf("unexpected remote split: %+v", report)
+	}
+	if len(report.Directories) != 2 {
+		t.Fatalf("unexpected directories: %+v", report.Directories)
+	}
+	root, data := report.Directories[0], report.Directories[1]
+	if root.Dir != "." || root.Files != 1 || root.PendingBytes != 7 {
+		t.Fatalf("unexpected root dir stats: %+v", root)
+	}
+	if data.Dir != "data" || data.Files != 3 || data.Bytes != 240 || data.PendingBytes != 100 {
+		t.Fatalf("unexpected data dir stats: %+v", data)
+	}
+}
+
+func TestBuildReportOfflineSkipsRemote(t *testing.T) {
+	inventory := map[string]lfs.LfsFileInfo{
+		"a/b/c/file.bin": {Oid: strings.Repeat("d", 64), Size: 2048},
+	}
+	report, err := buildReport(inventory, nil, 2, nil)
+	if err != nil {
+		t.Fatalf("buildReport: %v", err)
+	}
+	if report.RemoteChecked || report.PendingBytes != 0 {
+		t.Fatalf("expected no remote data, got %+v", report)
+	}
+	if len(report.Directories) != 1 || report.Directories[0].Dir != "a/b" {
+		t.Fatalf("unexpected directories: %+v", report.Directories)
+	}
+
+	var out bytes.Buffer
+	if err := writeReport(&out, report); err != nil {
+		t.Fatalf("writeReport: %v", err)
+	}
+	if !strings.Contains(out.String(), "2.0 KiB") || strings.Contains(out.String(), "Pending upload") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+}
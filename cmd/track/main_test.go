@@ -4,8 +4,18 @@ import (
 	"bytes"
 	"context"
 	"testing"
+
+	"github.com/calypr/git-drs/internal/drslog"
 )
 
+// setVerbose sets the verbosity the global -v flag would for the test.
+func setVerbose(t *testing.T) {
+	t.Helper()
+	prev := drslog.Verbosity()
+	drslog.SetVerbosity(1)
+	t.Cleanup(func() { drslog.SetVerbosity(prev) })
+}
+
 func TestRunTrack_TrackPatternsWithFlags(t *testing.T) {
 	origTrack := gitLFSTrackPatterns
 	origList := gitLFSListPatterns
@@ -33,10 +43,14 @@ func TestRunTrack_TrackPatternsWithFlags(t *testing.T) {
 		return "", nil
 	}
 
+	setVerbose(t)
 	cmd := NewCommand()
+	if cmd.Flags().Lookup("verbose") != nil {
+		t.Fatalf("a local --verbose flag would shadow the global -v/--verbose")
+	}
 	var out bytes.Buffer
 	cmd.SetOut(&out)
-	cmd.SetArgs([]string{"--verbose", "--dry-run", "*.bam", "data/**"})
+	cmd.SetArgs([]string{"--dry-run", "*.bam", "data/**"})
 
 	if err := cmd.Execute(); err != nil {
 		t.Fatalf("execute: %v", err)
@@ -68,10 +82,11 @@ func TestRunTrack_ListWhenNoArgs(t *testing.T) {
 		return "listing output\n", nil
 	}
 
+	setVerbose(t)
 	cmd := NewCommand()
 	var out bytes.Buffer
 	cmd.SetOut(&out)
-	cmd.SetArgs([]string{"--verbose"})
+	cmd.SetArgs(nil)
 
 	if err := cmd.Execute(); err != nil {
 		t.Fatalf("execute: %v", err)
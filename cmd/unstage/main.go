@@ -0,0 +1,382 @@
+package unstage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pathspec"
+	"github.com/calypr/git-drs/internal/pushsync"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remote string
+	all    bool
+	undo   bool
+	dryRun bool
+)
+
+// Swapped in tests.
+var (
+	loadConfig = config.LoadConfig
+	indexFiles = func() (map[string]lfs.LfsFileInfo, error) {
+		return lfs.GetTrackedLfsFiles(drslog.GetLogger())
+	}
+	pushedOIDs = upstreamOIDs
+	outbox     = pushsync.Outbox
+	unqueue    = pushsync.Unqueue
+)
+
+// Entry is one file selected by unstage. Queued is set for files waiting in
+// the remote's outbox.
+type Entry struct {
+	Path   string `json:"path,omitempty"`
+	OID    string `json:"oid"`
+	Size   int64  `json:"size,omitempty"`
+	Queued bool   `json:"queued,omitempty"`
+}
+
+// Report is the JSON form of an unstage run.
+type Report struct {
+	Remote  string  `json:"remote"`
+	DryRun  bool    `json:"dry_run"`
+	Undo    bool    `json:"undo"`
+	Objects []Entry `json:"objects"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "unstage [<path>|<pattern>|<oid>...]",
+	Short: "Take back DRS objects staged by mistake before a push registers them",
+	Long: "Description:" +
+		"\n  Move the staged DRS objects of files that are not yet registered to" +
+		"\n  .git/drs/trash, and drop them from the remote's outbox, so a file added" +
+		"\n  or committed by mistake is not registered by the next push or flush." +
+		"\n  Files are pending when they are in the index but not in the upstream" +
+		"\n  branch, or queued in the outbox. Arguments are paths, glob patterns, or" +
+		"\n  oids (or unique oid prefixes of at least 7 characters); --all selects" +
+		"\n  every pending file." +
+		"\n" +
+		"\n  Unstaging does not touch git: while an unstaged file is still committed" +
+		"\n  or in the index, git drs push refuses to register it. Remove it from the" +
+		"\n  commit, add it again, or run git drs unstage --undo to stage it again.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && !all {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: name the files to unstage, or pass --all\n\nUsage: %s\n\nSee 'git drs unstage --help' for more details", cmd.UseLine())
+		}
+		if len(args) > 0 && all {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: --all takes no arguments, received %d\n\nUsage: %s\n\nSee 'git drs unstage --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("error loading config: %w", err)
+		}
+		remoteName, err := cfg.GetRemoteOrDefault(remote)
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		report, err := run(ctx, string(remoteName), args)
+		if err != nil {
+			return err
+		}
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), report)
+		}
+		writeReport(cmd.OutOrStdout(), report)
+		return nil
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote whose outbox to update (default: default remote)")
+	Cmd.Flags().BoolVarP(&all, "all", "a", false, "select every pending file (with --undo, every unstaged file)")
+	Cmd.Flags().BoolVar(&undo, "undo", false, "stage previously unstaged files again")
+	Cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list the files that would be selected without changing anything")
+}
+
+func run(ctx context.Context, remoteName string, args []string) (Report, error) {
+	report := Report{Remote: remoteName, DryRun: dryRun, Undo: undo}
+	var candidates []Entry
+	var err error
+	if undo {
+		candidates, err = unstagedObjects()
+	} else {
+		candidates, err = pendingObjects(ctx, remoteName)
+	}
+	if err != nil {
+		return report, err
+	}
+	kind := "pending"
+	if undo {
+		kind = "unstaged"
+	}
+	if report.Objects, err = selectEntries(candidates, args, all, kind); err != nil {
+		return report, err
+	}
+	if dryRun || len(report.Objects) == 0 {
+		return report, nil
+	}
+	if undo {
+		return report, restore(report.Objects)
+	}
+	return report, trash(remoteName, report.Objects)
+}
+
+// pendingObjects lists the files a push could still register: LFS pointers
+// in the index whose oid is not in the upstream branch, and files queued in
+// the outbox.
+func pendingObjects(ctx context.Context, remoteName string) ([]Entry, error) {
+	files, err := indexFiles()
+	if err != nil {
+		return nil, err
+	}
+	pushed, err := pushedOIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byKey := map[string]*Entry{}
+	for p, f := range files {
+		oid := drsobject.NormalizeOid(f.Oid)
+		if oid == "" || pushed[oid] {
+			continue
+		}
+		byKey[p+"\x00"+oid] = &Entry{Path: p, OID: oid, Size: f.Size}
+	}
+	for _, in := range outbox(remoteName) {
+		key := in.Path + "\x00" + in.OID
+		if e, ok := byKey[key]; ok {
+			e.Queued = true
+			continue
+		}
+		byKey[key] = &Entry{Path: in.Path, OID: in.OID, Size: in.Size, Queued: true}
+	}
+	return sortedEntries(byKey), nil
+}
+
+// unstagedObjects lists the trashed oids, at each index path that still
+// points at them.
+func unstagedObjects() ([]Entry, error) {
+	oids, err := drsobject.TrashedObjects(common.DRS_OBJS_PATH)
+	if err != nil || len(oids) == 0 {
+		return nil, err
+	}
+	files, err := indexFiles()
+	if err != nil {
+		return nil, err
+	}
+	unstaged := make(map[string]bool, len(oids))
+	for _, oid := range oids {
+		unstaged[oid] = true
+	}
+	byKey := map[string]*Entry{}
+	inIndex := map[string]bool{}
+	for p, f := range files {
+		oid := drsobject.NormalizeOid(f.Oid)
+		if !unstaged[oid] {
+			continue
+		}
+		inIndex[oid] = true
+		byKey[p+"\x00"+oid] = &Entry{Path: p, OID: oid, Size: f.Size}
+	}
+	for _, oid := range oids {
+		if !inIndex[oid] {
+			byKey["\x00"+oid] = &Entry{OID: oid}
+		}
+	}
+	return sortedEntries(byKey), nil
+}
+
+// selectEntries returns the candidates named by args, or all of them. An
+// argument that is a unique prefix of a candidate's oid selects it by oid;
+// any other argument is a path or pattern and must match a candidate. kind
+// names the candidates in errors.
+func selectEntries(candidates []Entry, args []string, all bool, kind string) ([]Entry, error) {
+	if all {
+		return candidates, nil
+	}
+	picked := map[int]bool{}
+	for _, arg := range args {
+		matched, err := matchOID(candidates, arg)
+		if err != nil {
+			return nil, err
+		}
+		if matched == nil {
+			patterns := pathspec.Rooted([]string{arg})
+			for i, e := range candidates {
+				if e.Path != "" && pathspec.MatchesAny(e.Path, patterns) {
+					matched = append(matched, i)
+				}
+			}
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("%s matches no %s file", arg, kind)
+		}
+		for _, i := range matched {
+			picked[i] = true
+		}
+	}
+	var out []Entry
+	for i, e := range candidates {
+		if picked[i] {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// matchOID returns the candidates whose oid starts with arg. It returns nil
+// when arg is not an oid prefix of any candidate, and an error when the
+// prefix matches more than one oid.
+func matchOID(candidates []Entry, arg string) ([]int, error) {
+	prefix := strings.ToLower(drsobject.NormalizeOid(arg))
+	if len(prefix) < 7 || strings.Trim(prefix, "0123456789abcdef") != "" {
+		return nil, nil
+	}
+	var matched []int
+	oids := map[string]bool{}
+	for i, e := range candidates {
+		if strings.HasPrefix(e.OID, prefix) {
+			matched = append(matched, i)
+			oids[e.OID] = true
+		}
+	}
+	if len(oids) > 1 {
+		return nil, fmt.Errorf("oid prefix %s is ambiguous: it matches %d objects", arg, len(oids))
+	}
+	return matched, nil
+}
+
+// trash moves the selected objects to the trash and drops the queued ones
+// from the outbox.
+func trash(remoteName string, entries []Entry) error {
+	var queued []pushsync.Intent
+	done := map[string]bool{}
+	for _, e := range entries {
+		if e.Queued {
+			queued = append(queued, pushsync.Intent{Key: pushsync.IdempotencyKey(remoteName, e.Path, e.OID)})
+		}
+		if done[e.OID] {
+			continue
+		}
+		done[e.OID] = true
+		if err := drsobject.TrashObject(common.DRS_OBJS_PATH, e.OID, fallbackObject(e)); err != nil {
+			return err
+		}
+	}
+	if len(queued) > 0 {
+		if _, err := unqueue(remoteName, queued); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func restore(entries []Entry) error {
+	done := map[string]bool{}
+	for _, e := range entries {
+		if done[e.OID] {
+			continue
+		}
+		done[e.OID] = true
+		if err := drsobject.RestoreObject(common.DRS_OBJS_PATH, e.OID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fallbackObject is the trash entry for a file with no staged object, as
+// the clean filter would have staged it.
+func fallbackObject(e Entry) *drsapi.DrsObject {
+	name := path.Base(e.Path)
+	return &drsapi.DrsObject{
+		Name:      &name,
+		Size:      e.Size,
+		Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: e.OID}},
+	}
+}
+
+// upstreamOIDs returns the oids of the LFS pointers in the upstream branch,
+// or none when the branch has no upstream.
+func upstreamOIDs(ctx context.Context) (map[string]bool, error) {
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", "@{upstream}").Output()
+	if err != nil {
+		return map[string]bool{}, nil
+	}
+	files, err := lfs.GetLfsFilesForRefs([]string{strings.TrimSpace(string(out))}, drslog.GetLogger())
+	if err != nil {
+		return nil, err
+	}
+	oids := make(map[string]bool, len(files))
+	for _, f := range files {
+		oids[drsobject.NormalizeOid(f.Oid)] = true
+	}
+	return oids, nil
+}
+
+func sortedEntries(byKey map[string]*Entry) []Entry {
+	out := make([]Entry, 0, len(byKey))
+	for _, e := range byKey {
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].OID < out[j].OID
+	})
+	return out
+}
+
+func writeReport(w io.Writer, r Report) {
+	if len(r.Objects) == 0 {
+		if r.Undo {
+			fmt.Fprintln(w, "Nothing unstaged")
+		} else {
+			fmt.Fprintln(w, "Nothing pending registration")
+		}
+		return
+	}
+	for _, e := range r.Objects {
+		p := e.Path
+		if p == "" {
+			p = "(not in the index)"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", p, e.OID)
+	}
+	var verb string
+	switch {
+	case r.Undo && r.DryRun:
+		verb = "Would restore"
+	case r.Undo:
+		verb = "Restored"
+	case r.DryRun:
+		verb = "Would unstage"
+	default:
+		verb = "Unstaged"
+	}
+	fmt.Fprintf(w, "%s %d files\n", verb, len(r.Objects))
+	if !r.Undo && !r.DryRun {
+		fmt.Fprintln(w, "git drs push refuses to register them while they are committed or in the index; remove them there, or run 'git drs unstage --undo'")
+	}
+}
@@ -0,0 +1,111 @@
+package unstage
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pushsync"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+var (
+	oidA = strings.Repeat("a", 64)
+	oidB = strings.Repeat("b", 64)
+	oidC = strings.Repeat("c", 64)
+)
+
+func stubUnstage(t *testing.T) *[]pushsync.Intent {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	origIndex, origPushed, origOutbox, origUnqueue := indexFiles, pushedOIDs, outbox, unqueue
+	t.Cleanup(func() {
+		indexFiles, pushedOIDs, outbox, unqueue = origIndex, origPushed, origOutbox, origUnqueue
+		all, undo, dryRun = false, false, false
+	})
+	indexFiles = func() (map[string]lfs.LfsFileInfo, error) {
+		return map[string]lfs.LfsFileInfo{
+			"data/a.bam":   {Name: "data/a.bam", Oid: oidA, Size: 1},
+			"data/b.bam":   {Name: "data/b.bam", Oid: oidB, Size: 2},
+			"pushed/c.bam": {Name: "pushed/c.bam", Oid: oidC, Size: 3},
+		}, nil
+	}
+	pushedOIDs = func(context.Context) (map[string]bool, error) { return map[string]bool{oidC: true}, nil }
+	outbox = func(string) []pushsync.Intent {
+		return []pushsync.Intent{{Path: "data/b.bam", OID: oidB, Size: 2}}
+	}
+	var unqueued []pushsync.Intent
+	unqueue = func(_ string, intents []pushsync.Intent) (int, error) {
+		unqueued = append(unqueued, intents...)
+		return len(intents), nil
+	}
+	name := "a.bam"
+	if err := drsobject.WriteObject(common.DRS_OBJS_PATH, &drsapi.DrsObject{Id: "did-a", Name: &name}, oidA); err != nil {
+		t.Fatal(err)
+	}
+	return &unqueued
+}
+
+func TestUnstageSelectsPendingFilesOnly(t *testing.T) {
+	stubUnstage(t)
+	dryRun = true
+
+	report, err := run(context.Background(), "origin", []string{"data/*.bam"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Objects) != 2 || report.Objects[1].Path != "data/b.bam" || !report.Objects[1].Queued {
+		t.Fatalf("objects = %+v", report.Objects)
+	}
+	if !drsobject.Staged(common.DRS_OBJS_PATH, oidA) {
+		t.Fatal("dry run unstaged an object")
+	}
+
+	if _, err := run(context.Background(), "origin", []string{"pushed/c.bam"}); err == nil || !strings.Contains(err.Error(), "matches no pending file") {
+		t.Fatalf("expected pushed file to be rejected, got %v", err)
+	}
+	if _, err := run(context.Background(), "origin", []string{"sha256:" + oidB[:8]}); err != nil {
+		t.Fatalf("oid prefix: %v", err)
+	}
+}
+
+func TestUnstageAndUndo(t *testing.T) {
+	unqueued := stubUnstage(t)
+
+	report, err := run(context.Background(), "origin", []string{oidA[:7], "data/b.bam"})
+	if err != nil || len(report.Objects) != 2 {
+		t.Fatalf("run = %+v, %v", report, err)
+	}
+	if drsobject.Staged(common.DRS_OBJS_PATH, oidA) || !drsobject.Trashed(common.DRS_OBJS_PATH, oidA) || !drsobject.Trashed(common.DRS_OBJS_PATH, oidB) {
+		t.Fatal("expected both objects in the trash")
+	}
+	if len(*unqueued) != 1 || (*unqueued)[0].Key != pushsync.IdempotencyKey("origin", "data/b.bam", oidB) {
+		t.Fatalf("unqueued = %+v", *unqueued)
+	}
+
+	undo = true
+	report, err = run(context.Background(), "origin", []string{"data/a.bam"})
+	if err != nil || len(report.Objects) != 1 {
+		t.Fatalf("undo = %+v, %v", report, err)
+	}
+	obj, err := drsobject.ReadObject(common.DRS_OBJS_PATH, oidA)
+	if err != nil || obj.Id != "did-a" {
+		t.Fatalf("restored object = %+v, %v", obj, err)
+	}
+	if oids, _ := drsobject.TrashedObjects(common.DRS_OBJS_PATH); len(oids) != 1 || oids[0] != oidB {
+		t.Fatalf("trash = %v; want b only", oids)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	var out bytes.Buffer
+	writeReport(&out, Report{Remote: "origin", DryRun: true, Objects: []Entry{{Path: "a.bin", OID: "aa"}, {OID: "bb"}}})
+	want := "a.bin\taa\n(not in the index)\tbb\nWould unstage 2 files\n"
+	if out.String() != want {
+		t.Fatalf("report:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
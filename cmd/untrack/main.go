@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/calypr/git-drs/internal/drslog"
 	"github.com/calypr/git-drs/internal/drstrack"
 	"github.com/spf13/cobra"
 )
@@ -21,17 +22,14 @@ func NewCommand() *cobra.Command {
 		RunE:  runUntrack,
 	}
 
-	cmd.Flags().Bool("verbose", false, "show detailed output")
 	cmd.Flags().Bool("dry-run", false, "show what would change without writing")
 
 	return cmd
 }
 
 func runUntrack(cmd *cobra.Command, args []string) error {
-	verbose, err := cmd.Flags().GetBool("verbose")
-	if err != nil {
-		return fmt.Errorf("read flag verbose: %w", err)
-	}
+	// Detail follows the global -v/--verbose flag.
+	verbose := drslog.Verbosity() > 0
 	dryRun, err := cmd.Flags().GetBool("dry-run")
 	if err != nil {
 		return fmt.Errorf("read flag dry-run: %w", err)
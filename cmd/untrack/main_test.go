@@ -5,8 +5,18 @@ import (
 	"context"
 	"strings"
 	"testing"
+
+	"github.com/calypr/git-drs/internal/drslog"
 )
 
+// setVerbose sets the verbosity the global -v flag would for the test.
+func setVerbose(t *testing.T) {
+	t.Helper()
+	prev := drslog.Verbosity()
+	drslog.SetVerbosity(1)
+	t.Cleanup(func() { drslog.SetVerbosity(prev) })
+}
+
 func TestRunUntrack_RemovesPatternsWithFlags(t *testing.T) {
 	origUntrack := gitLFSUntrackPatterns
 	t.Cleanup(func() {
@@ -28,10 +38,14 @@ func TestRunUntrack_RemovesPatternsWithFlags(t *testing.T) {
 		return "untracking output\n", nil
 	}
 
+	setVerbose(t)
 	cmd := NewCommand()
+	if cmd.Flags().Lookup("verbose") != nil {
+		t.Fatalf("a local --verbose flag would shadow the global -v/--verbose")
+	}
 	var out bytes.Buffer
 	cmd.SetOut(&out)
-	cmd.SetArgs([]string{"--verbose", "--dry-run", "*.bam"})
+	cmd.SetArgs([]string{"--dry-run", "*.bam"})
 
 	if err := cmd.Execute(); err != nil {
 		t.Fatalf("execute: %v", err)
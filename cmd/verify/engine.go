@@ -0,0 +1,169 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/calypr/git-drs/internal/config"
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/provenance"
+	"github.com/calypr/git-drs/internal/pushsync"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"golang.org/x/sync/errgroup"
+)
+
+// Swapped in tests.
+var (
+	checkReadable = pushsync.CheckReadable
+	now           = time.Now
+)
+
+// batchSize is how many files are looked up on the remote together.
+const batchSize = 256
+
+// Options selects the checks Check runs and how it runs them.
+type Options struct {
+	// Verifier checks provenance attestations; nil skips that check.
+	Verifier provenance.Verifier
+	// Readable signs a download URL for each record and reads a byte.
+	Readable bool
+	// Jobs is how many files are checked at once.
+	Jobs int
+	// Budget stops Check from starting further files once it has elapsed;
+	// zero means no limit.
+	Budget time.Duration
+}
+
+// target is a file's record and the scope it was looked up in.
+type target struct {
+	scope *config.GitContext
+	obj   *drsapi.DrsObject
+}
+
+// Check verifies the record of each file, in the order given, looking
+// records up in the project each file registers under. Files are looked up
+// in batches and checked opts.Jobs at a time. Once opts.Budget has elapsed
+// no further file is started; checks already running finish, and the rest
+// are counted in Report.Skipped.
+func Check(ctx context.Context, cl *config.GitContext, files []lfs.LfsFileInfo, opts Options) (Report, error) {
+	report := Report{Results: []Result{}}
+	start := now()
+	overBudget := func() bool { return opts.Budget > 0 && now().Sub(start) >= opts.Budget }
+	for i := 0; i < len(files); i += batchSize {
+		if overBudget() {
+			report.Skipped += len(files) - i
+			break
+		}
+		batch := files[i:min(i+batchSize, len(files))]
+		targets, err := lookupBatch(ctx, cl, batch)
+		if err != nil {
+			return report, err
+		}
+		results := make([]*Result, len(batch))
+		eg, egCtx := errgroup.WithContext(ctx)
+		eg.SetLimit(max(opts.Jobs, 1))
+		for j, f := range batch {
+			if overBudget() {
+				break
+			}
+			eg.Go(func() error {
+				// Waiting for a free job may have used up the budget.
+				if overBudget() {
+					return nil
+				}
+				res, err := checkFile(egCtx, f, targets[f.Name], opts)
+				if err != nil {
+					return err
+				}
+				results[j] = &res
+				return nil
+			})
+		}
+		if err := eg.Wait(); err != nil {
+			return report, err
+		}
+		for _, res := range results {
+			if res == nil {
+				report.Skipped++
+				continue
+			}
+			report.Results = append(report.Results, *res)
+		}
+	}
+	sort.Slice(report.Results, func(i, j int) bool { return report.Results[i].Path < report.Results[j].Path })
+	for _, r := range report.Results {
+		if r.Status != StatusVerified {
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+// lookupBatch finds the record of each file in batch, by path.
+func lookupBatch(ctx context.Context, cl *config.GitContext, batch []lfs.LfsFileInfo) (map[string]target, error) {
+	byName := make(map[string]lfs.LfsFileInfo, len(batch))
+	for _, f := range batch {
+		byName[f.Name] = f
+	}
+	targets := make(map[string]target, len(batch))
+	for _, group := range pushsync.GroupByPathScope(cl, byName) {
+		oids := make([]string, 0, len(group.Files))
+		for _, f := range group.Files {
+			oids = append(oids, localdrsobject.NormalizeOid(f.Oid))
+		}
+		records, err := lookupObjects(ctx, group.Context, oids)
+		if err != nil {
+			return nil, fmt.Errorf("error querying remote: %w", err)
+		}
+		for _, f := range group.Files {
+			oid := localdrsobject.NormalizeOid(f.Oid)
+			targets[f.Name] = target{scope: group.Context, obj: recordFor(group.Context, f.Name, oid, records[oid])}
+		}
+	}
+	return targets, nil
+}
+
+// checkFile runs the checks opts selects on the record of f. An error is
+// returned only when the check itself could not run.
+func checkFile(ctx context.Context, f lfs.LfsFileInfo, t target, opts Options) (Result, error) {
+	oid := localdrsobject.NormalizeOid(f.Oid)
+	res := Result{Path: f.Name, OID: oid}
+	if t.obj == nil {
+		res.Status, res.Detail = StatusUnregistered, "no record in the remote's project"
+		return res, nil
+	}
+	res.DRSID = t.obj.Id
+	if opts.Verifier != nil {
+		env, ok := provenance.FromObject(t.obj)
+		if !ok {
+			res.Status, res.Detail = StatusUnsigned, "record has no provenance attestation"
+			return res, nil
+		}
+		st, err := provenance.Verify(ctx, opts.Verifier, env, oid)
+		if err != nil {
+			res.Status, res.Detail = StatusInvalid, err.Error()
+			if !errors.Is(err, provenance.ErrInvalid) {
+				return res, err
+			}
+			return res, nil
+		}
+		res.Commit = st.Commit()
+		res.SignedAt = st.Predicate.RunDetails.Metadata.StartedOn.Format("2006-01-02T15:04:05Z")
+		res.Builder = st.Predicate.RunDetails.Builder.Version["git-drs"]
+	}
+	if opts.Readable {
+		if err := checkReadable(ctx, t.scope, t.obj); err != nil {
+			if ctx.Err() != nil {
+				return res, ctx.Err()
+			}
+			res.Status, res.Detail = StatusUnreadable, err.Error()
+			return res, nil
+		}
+	}
+	res.Status = StatusVerified
+	return res, nil
+}
@@ -0,0 +1,227 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/drslog"
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/pathspec"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remote         string
+	withProvenance bool
+	withReadable   bool
+	sampleFlag     string
+	strategy       string
+	seed           uint64
+	budget         time.Duration
+	jobs           int
+)
+
+// Swapped in tests.
+var (
+	loadConfig    = config.LoadConfig
+	loadInventory = func(remote string, logger *slog.Logger) (map[string]lfs.LfsFileInfo, error) {
+		return lfs.GetAllLfsFiles(remote, "", []string{"HEAD"}, logger)
+	}
+	lookupObjects = drsremote.ObjectsByHashesForScope
+	lastChanged   = lfs.LastChanged
+)
+
+// Outcomes of checking one file.
+const (
+	StatusVerified     = "verified"
+	StatusUnsigned     = "unsigned"
+	StatusInvalid      = "invalid"
+	StatusUnregistered = "unregistered"
+	StatusUnreadable   = "unreadable"
+)
+
+// Result is the check of one tracked file.
+type Result struct {
+	Path     string `json:"path"`
+	OID      string `json:"oid"`
+	DRSID    string `json:"drs_id,omitempty"`
+	Status   string `json:"status"`
+	Commit   string `json:"commit,omitempty"`
+	SignedAt string `json:"signed_at,omitempty"`
+	Builder  string `json:"builder,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// Report is the outcome of one verify run. Total counts the files matching
+// the pathspecs; Results holds the sampled files that were checked, and
+// Skipped the sampled files the budget left unchecked.
+type Report struct {
+	Remote   string   `json:"remote"`
+	Strategy string   `json:"strategy,omitempty"`
+	Seed     uint64   `json:"seed"`
+	Total    int      `json:"total"`
+	Results  []Result `json:"results"`
+	Skipped  int      `json:"skipped"`
+	Failed   int      `json:"failed"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "verify --provenance|--readable [pathspec...]",
+	Short: "Verify the records of tracked files on the remote",
+	Long: "Description:" +
+		"\n  For each LFS file in HEAD matching the pathspecs, look up its record on" +
+		"\n  the remote and check it. --provenance checks the attestation push signed" +
+		"\n  onto it (drs.provenance.*): the signature, with" +
+		"\n  drs.provenance.public-key-file, key-file or verify-command, and that it" +
+		"\n  attests the file's content hash. --readable signs a download URL for the" +
+		"\n  record and reads its first byte. Files without a record, or whose record" +
+		"\n  fails a check, fail the command." +
+		"\n\n  --sample checks a count or percentage of the files, picked by --strategy:" +
+		"\n  random, newest (most recently changed first) or size-weighted. --seed" +
+		"\n  repeats an earlier pick; each report prints the seed it used. --budget" +
+		"\n  stops starting checks after a duration, leaving the rest of the sample" +
+		"\n  unchecked. --jobs sets how many files are checked at once.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !withProvenance && !withReadable {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: nothing to verify; pass --provenance or --readable\n\nUsage: %s", cmd.UseLine())
+		}
+		policy, err := config.ParseVerifyPolicy(sampleFlag)
+		if err != nil || !policy.Enabled() {
+			return drserrors.WithKind(drserrors.ErrConfig, fmt.Errorf("invalid --sample %q: expected all, a count, or a percentage", sampleFlag))
+		}
+		if !cmd.Flags().Changed("seed") {
+			seed = rand.Uint64()
+		}
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		logger := drslog.GetLogger()
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("error loading config: %w", err)
+		}
+		remoteName, err := cfg.GetRemoteOrDefault(remote)
+		if err != nil {
+			return err
+		}
+		cl, err := cfg.GetRemoteClient(remoteName, logger)
+		if err != nil {
+			return err
+		}
+		opts := Options{Readable: withReadable, Jobs: jobs, Budget: budget}
+		if withProvenance {
+			if opts.Verifier, err = cl.Provenance.Verifier(); err != nil {
+				return err
+			}
+		}
+		files, err := loadInventory(string(remoteName), logger)
+		if err != nil {
+			return fmt.Errorf("error listing LFS files: %w", err)
+		}
+		patterns := pathspec.Rooted(args)
+		for name, f := range files {
+			if !pathspec.MatchesAny(f.Name, patterns) {
+				delete(files, name)
+			}
+		}
+		sample := Sample{Policy: policy, Strategy: strategy, Seed: seed}
+		if strategy == StrategyNewest {
+			if sample.Changed, err = lastChanged(ctx); err != nil {
+				return err
+			}
+		}
+		picked, err := sample.Pick(files)
+		if err != nil {
+			return drserrors.WithKind(drserrors.ErrConfig, err)
+		}
+		report, err := Check(ctx, cl, picked, opts)
+		if err != nil {
+			return err
+		}
+		report.Remote = string(remoteName)
+		report.Strategy, report.Seed, report.Total = strategy, seed, len(files)
+		if common.JSONOutput() {
+			if err := common.WriteJSON(cmd.OutOrStdout(), report); err != nil {
+				return err
+			}
+		} else {
+			writeReport(cmd.OutOrStdout(), report)
+		}
+		if report.Failed > 0 {
+			return fmt.Errorf("%d of %d files failed verification", report.Failed, len(report.Results))
+		}
+		return nil
+	},
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "DRS remote to look the records up on (default: default remote)")
+	Cmd.Flags().BoolVar(&withProvenance, "provenance", false, "check each record's signed provenance attestation")
+	Cmd.Flags().BoolVar(&withReadable, "readable", false, "check each record's content can be downloaded")
+	Cmd.Flags().StringVar(&sampleFlag, "sample", "all", "files to check: all, a count such as 500, or a percentage such as 5%")
+	Cmd.Flags().StringVar(&strategy, "strategy", StrategyRandom, "how --sample picks files, and the order they are checked in: random, newest, or size-weighted")
+	Cmd.Flags().Uint64Var(&seed, "seed", 0, "seed for the random and size-weighted strategies (default: a new seed, printed in the report)")
+	Cmd.Flags().DurationVar(&budget, "budget", 0, "stop starting checks after this long, such as 10m (default: no limit)")
+	Cmd.Flags().IntVar(&jobs, "jobs", 8, "files to check at once")
+}
+
+// recordFor picks the record of path: the one with its path-hash ID when the
+// remote mints those, else the record of the content in the project.
+func recordFor(cl *config.GitContext, path, oid string, records []drsapi.DrsObject) *drsapi.DrsObject {
+	if cl.IDs.Strategy == localdrsobject.IDPathHash {
+		id := cl.IDs.ID(cl.ProjectId, path, oid)
+		for i := range records {
+			if records[i].Id == id {
+				return &records[i]
+			}
+		}
+	}
+	match, err := drsremote.FindMatchingRecord(records, cl.Organization, cl.ProjectId)
+	if err != nil {
+		return nil
+	}
+	return match
+}
+
+func writeReport(w io.Writer, r Report) {
+	for _, res := range r.Results {
+		switch {
+		case res.Status == StatusVerified && res.SignedAt != "":
+			fmt.Fprintf(w, "ok\t%s\tcommit %s, signed %s\n", res.Path, shortCommit(res.Commit), res.SignedAt)
+		case res.Status == StatusVerified:
+			fmt.Fprintf(w, "ok\t%s\treadable\n", res.Path)
+		default:
+			fmt.Fprintf(w, "%s\t%s\t%s\n", res.Status, res.Path, res.Detail)
+		}
+	}
+	if sampled := len(r.Results) + r.Skipped; sampled < r.Total {
+		fmt.Fprintf(w, "Sampled %d of %d files (%s, seed %d)\n", sampled, r.Total, r.Strategy, r.Seed)
+	}
+	if r.Skipped > 0 {
+		fmt.Fprintf(w, "Budget ran out with %d sampled files unchecked\n", r.Skipped)
+	}
+	fmt.Fprintf(w, "Verified %d of %d files against remote %s\n", len(r.Results)-r.Failed, len(r.Results), r.Remote)
+}
+
+func shortCommit(c string) string {
+	if c == "" {
+		return "unknown"
+	}
+	if len(c) > 12 {
+		return c[:12]
+	}
+	return c
+}
@@ -0,0 +1,188 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/provenance"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestCheck(t *testing.T) {
+	orig := lookupObjects
+	t.Cleanup(func() { lookupObjects = orig })
+
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(priv)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	settings := provenance.Settings{KeyFile: keyFile}
+	signer, _ := settings.Signer()
+	verifier, _ := settings.Verifier()
+
+	signed, unsigned, copied, missing := strings.Repeat("a", 64), strings.Repeat("b", 64), strings.Repeat("c", 64), strings.Repeat("d", 64)
+	record := func(id, oid, attested string) drsapi.DrsObject {
+		obj := drsapi.DrsObject{Id: id, Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: oid}}, ControlledAccess: &[]string{"/organization/syfon/project/e2e"}}
+		if attested != "" {
+			env, err := provenance.Sign(context.Background(), signer, provenance.New(provenance.Source{OID: attested, Path: "x", Commit: "0123456789abcdef", Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			provenance.SetOnObject(&obj, env)
+		}
+		return obj
+	}
+	lookupObjects = func(_ context.Context, _ *config.GitContext, oids []string) (map[string][]drsapi.DrsObject, error) {
+		return map[string][]drsapi.DrsObject{
+			signed:   {record("id-a", signed, signed)},
+			unsigned: {record("id-b", unsigned, "")},
+			// An attestation copied from another record does not verify.
+			copied: {record("id-c", copied, signed)},
+		}, nil
+	}
+	cl := &config.GitContext{RemoteName: "origin", Organization: "syfon", ProjectId: "e2e"}
+	files := []lfs.LfsFileInfo{
+		{Name: "d.bin", Oid: missing},
+		{Name: "c.bin", Oid: copied},
+		{Name: "b.bin", Oid: unsigned},
+		{Name: "a.bin", Oid: signed},
+	}
+	report, err := Check(context.Background(), cl, files, Options{Verifier: verifier, Jobs: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{StatusVerified, StatusUnsigned, StatusInvalid, StatusUnregistered}
+	for i, res := range report.Results {
+		if res.Status != want[i] {
+			t.Fatalf("%s: status %s (%s); want %s", res.Path, res.Status, res.Detail, want[i])
+		}
+	}
+	if report.Failed != 3 || report.Results[0].Commit != "0123456789abcdef" || report.Results[0].DRSID != "id-a" {
+		t.Fatalf("report = %+v", report)
+	}
+
+	report.Remote = "origin"
+	var out bytes.Buffer
+	writeReport(&out, report)
+	if !strings.HasPrefix(out.String(), "ok\ta.bin\tcommit 0123456789ab, signed 2026-01-02T03:04:05Z\nunsigned\tb.bin\t") || !strings.HasSuffix(out.String(), "Verified 1 of 4 files against remote origin\n") {
+		t.Fatalf("report:\n%s", out.String())
+	}
+}
+
+func TestSamplePick(t *testing.T) {
+	files := map[string]lfs.LfsFileInfo{}
+	for i, size := range []int64{1, 10, 100, 1000, 1 << 30} {
+		name := fmt.Sprintf("f%d.bin", i)
+		files[name] = lfs.LfsFileInfo{Name: name, Size: size}
+	}
+	names := func(picked []lfs.LfsFileInfo) string {
+		var out []string
+		for _, f := range picked {
+			out = append(out, f.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	random := Sample{Policy: config.VerifyPolicy{Percent: 40}, Strategy: StrategyRandom, Seed: 7}
+	first, err := random.Pick(files)
+	if err != nil || len(first) != 2 {
+		t.Fatalf("Pick = %v, %v; want 2 files", first, err)
+	}
+	if again, _ := random.Pick(files); names(again) != names(first) {
+		t.Fatalf("same seed picked %s then %s", names(first), names(again))
+	}
+
+	newest := Sample{Policy: config.VerifyPolicy{Count: 2}, Strategy: StrategyNewest, Changed: map[string]time.Time{
+		"f3.bin": time.Unix(300, 0), "f1.bin": time.Unix(200, 0), "f0.bin": time.Unix(100, 0),
+	}}
+	if picked, _ := newest.Pick(files); names(picked) != "f3.bin,f1.bin" {
+		t.Fatalf("newest picked %s", names(picked))
+	}
+
+	// The 1 GiB file outweighs the rest together, so it is picked first
+	// whatever the seed.
+	for seed := range uint64(20) {
+		weighted := Sample{Policy: config.VerifyPolicy{Count: 1}, Strategy: StrategySizeWeighted, Seed: seed}
+		if picked, _ := weighted.Pick(files); names(picked) != "f4.bin" {
+			t.Fatalf("seed %d: size-weighted picked %s", seed, names(picked))
+		}
+	}
+
+	if _, err := (Sample{Policy: config.VerifyPolicy{All: true}, Strategy: "oldest"}).Pick(files); err == nil {
+		t.Fatal("expected error for unknown strategy")
+	}
+}
+
+func TestCheckReadableWithinBudget(t *testing.T) {
+	origLookup, origReadable, origNow := lookupObjects, checkReadable, now
+	t.Cleanup(func() { lookupObjects, checkReadable, now = origLookup, origReadable, origNow })
+
+	lookupObjects = func(_ context.Context, _ *config.GitContext, oids []string) (map[string][]drsapi.DrsObject, error) {
+		out := map[string][]drsapi.DrsObject{}
+		for _, oid := range oids {
+			out[oid] = []drsapi.DrsObject{{Id: "id-" + oid[:1], Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: oid}}, ControlledAccess: &[]string{"/organization/syfon/project/e2e"}}}
+		}
+		return out, nil
+	}
+	// Each check takes a minute of the fake clock.
+	var mu sync.Mutex
+	clock := time.Unix(0, 0)
+	now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return clock
+	}
+	checkReadable = func(_ context.Context, _ *config.GitContext, obj *drsapi.DrsObject) error {
+		mu.Lock()
+		clock = clock.Add(time.Minute)
+		mu.Unlock()
+		if obj.Id == "id-b" {
+			return errors.New("download probe failed with status 403")
+		}
+		return nil
+	}
+
+	cl := &config.GitContext{RemoteName: "origin", Organization: "syfon", ProjectId: "e2e"}
+	files := []lfs.LfsFileInfo{
+		{Name: "a.bin", Oid: strings.Repeat("a", 64)},
+		{Name: "b.bin", Oid: strings.Repeat("b", 64)},
+		{Name: "c.bin", Oid: strings.Repeat("c", 64)},
+	}
+	report, err := Check(context.Background(), cl, files, Options{Readable: true, Jobs: 1, Budget: 2 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Results) != 2 || report.Skipped != 1 || report.Failed != 1 {
+		t.Fatalf("report = %+v; want a and b checked, c skipped", report)
+	}
+	if report.Results[0].Status != StatusVerified || report.Results[1].Status != StatusUnreadable {
+		t.Fatalf("results = %+v", report.Results)
+	}
+
+	report.Remote, report.Total, report.Strategy, report.Seed = "origin", 10, StrategyRandom, 42
+	var out bytes.Buffer
+	writeReport(&out, report)
+	for _, want := range []string{"ok\ta.bin\treadable\n", "unreadable\tb.bin\t", "Sampled 3 of 10 files (random, seed 42)", "Budget ran out with 1 sampled files unchecked"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("report missing %q:\n%s", want, out.String())
+		}
+	}
+}
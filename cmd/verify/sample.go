@@ -0,0 +1,62 @@
+package verify
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+// Sampling strategies for --strategy.
+const (
+	StrategyRandom       = "random"
+	StrategyNewest       = "newest"
+	StrategySizeWeighted = "size-weighted"
+)
+
+// Sample picks the files to check and the order to check them in, which is
+// also the order --budget cuts short.
+type Sample struct {
+	Policy   config.VerifyPolicy
+	Strategy string
+	// Seed drives the random and size-weighted strategies; the same seed
+	// over the same files picks the same sample.
+	Seed uint64
+	// Changed is when each path last changed, for the newest strategy.
+	Changed map[string]time.Time
+}
+
+// Pick returns the sampled files in check order. Files are sorted by path
+// first, so the pick depends only on the files, the strategy and the seed.
+func (s Sample) Pick(files map[string]lfs.LfsFileInfo) ([]lfs.LfsFileInfo, error) {
+	all := make([]lfs.LfsFileInfo, 0, len(files))
+	for _, f := range files {
+		all = append(all, f)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+
+	rng := rand.New(rand.NewPCG(s.Seed, 0))
+	switch s.Strategy {
+	case "", StrategyRandom:
+		rng.Shuffle(len(all), func(i, j int) { all[i], all[j] = all[j], all[i] })
+	case StrategyNewest:
+		sort.SliceStable(all, func(i, j int) bool { return s.Changed[all[i].Name].After(s.Changed[all[j].Name]) })
+	case StrategySizeWeighted:
+		// Weighted sampling without replacement (Efraimidis-Spirakis): each
+		// file draws u^(1/weight) and the largest keys win, so a file twice
+		// the size is about twice as likely to be picked. Keys are compared
+		// as logarithms, which stay distinct for large weights.
+		keys := make(map[string]float64, len(all))
+		for _, f := range all {
+			keys[f.Name] = math.Log(rng.Float64()) / float64(max(f.Size, 0)+1)
+		}
+		sort.SliceStable(all, func(i, j int) bool { return keys[all[i].Name] > keys[all[j].Name] })
+	default:
+		return nil, fmt.Errorf("invalid sampling strategy %q: want %s, %s or %s", s.Strategy, StrategyRandom, StrategyNewest, StrategySizeWeighted)
+	}
+	return all[:s.Policy.SampleSize(len(all))], nil
+}
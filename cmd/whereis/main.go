@@ -0,0 +1,122 @@
+package whereis
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/origin"
+	"github.com/spf13/cobra"
+)
+
+var remote string
+
+var (
+	loadConfig      = config.LoadConfig
+	newRemoteClient = func(cfg *config.Config, remote config.Remote, logger *slog.Logger) (*config.GitContext, error) {
+		return cfg.GetRemoteClient(remote, logger)
+	}
+	resolveObject = drsremote.ResolveObject
+	// hasCommit reports whether the current repository has commit; swapped
+	// in tests.
+	hasCommit = func(ctx context.Context, commit string) bool {
+		return exec.CommandContext(ctx, "git", "cat-file", "-e", commit+"^{commit}").Run() == nil
+	}
+)
+
+// Location is where a record was registered from.
+type Location struct {
+	DRSID string `json:"drs_id"`
+	origin.Origin
+	// InClone reports whether the commit is present in the current
+	// repository.
+	InClone bool `json:"in_clone"`
+}
+
+// Cmd line declaration
+var Cmd = &cobra.Command{
+	Use:   "whereis <drs-id>",
+	Short: "Show the repository, branch, commit and path a record was registered from",
+	Long: "Description:" +
+		"\n  Look up a DRS record and print the Git coordinates recorded on it at" +
+		"\n  registration: the repository URL, branch, commit and repo-relative path." +
+		"\n  Records carry these only when the pushing repository set drs.origin.fields." +
+		"\n  The ID may also be given as a DRS URI or an alias.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("error: requires exactly 1 argument (DRS ID), received %d\n\nUsage: %s\n\nSee 'git drs whereis --help' for more details", len(args), cmd.UseLine())
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := drslog.GetLogger()
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("error loading config: %w", err)
+		}
+		name, err := cfg.GetRemoteOrDefault(remote)
+		if err != nil {
+			return err
+		}
+		gc, err := newRemoteClient(cfg, name, logger)
+		if err != nil {
+			return err
+		}
+		loc, err := locate(ctx, gc, args[0])
+		if err != nil {
+			return err
+		}
+		if common.JSONOutput() {
+			return common.WriteJSON(cmd.OutOrStdout(), loc)
+		}
+		return printLocation(cmd.OutOrStdout(), loc)
+	},
+}
+
+func locate(ctx context.Context, gc *config.GitContext, id string) (Location, error) {
+	obj, err := resolveObject(ctx, gc, id)
+	if err != nil {
+		return Location{}, err
+	}
+	o, ok := origin.FromObject(obj)
+	if !ok {
+		return Location{}, fmt.Errorf("record %s carries no repository origin; it was registered without drs.origin.fields", obj.Id)
+	}
+	return Location{DRSID: obj.Id, Origin: o, InClone: o.Commit != "" && hasCommit(ctx, o.Commit)}, nil
+}
+
+func printLocation(w io.Writer, loc Location) error {
+	fmt.Fprintf(w, "drs id: %s\n", loc.DRSID)
+	for _, field := range []struct{ name, value string }{
+		{"repo", loc.Repo},
+		{"branch", loc.Branch},
+		{"commit", loc.Commit},
+		{"path", loc.Path},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if field.name == "commit" && loc.InClone {
+			field.value += " (present in this clone)"
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s\n", field.name, field.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&remote, "remote", "r", "", "remote to look the record up on (default: default_remote)")
+}
@@ -0,0 +1,41 @@
+package whereis
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/origin"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestLocateReadsRecordOrigin(t *testing.T) {
+	origResolve, origHas := resolveObject, hasCommit
+	t.Cleanup(func() { resolveObject, hasCommit = origResolve, origHas })
+	records := map[string]*drsapi.DrsObject{"did-1": {Id: "did-1"}, "did-2": {Id: "did-2"}}
+	origin.SetOnObject(records["did-1"], origin.Origin{Repo: "https://github.com/org/repo.git", Branch: "main", Commit: "abc123", Path: "data/x.bam"})
+	resolveObject = func(_ context.Context, _ *config.GitContext, id string) (*drsapi.DrsObject, error) {
+		return records[id], nil
+	}
+	hasCommit = func(_ context.Context, commit string) bool { return commit == "abc123" }
+
+	loc, err := locate(context.Background(), &config.GitContext{}, "did-1")
+	if err != nil {
+		t.Fatalf("locate: %v", err)
+	}
+	var out bytes.Buffer
+	if err := printLocation(&out, loc); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"repo: https://github.com/org/repo.git", "branch: main", "commit: abc123 (present in this clone)", "path: data/x.bam"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	if _, err := locate(context.Background(), &config.GitContext{}, "did-2"); err == nil || !strings.Contains(err.Error(), "drs.origin.fields") {
+		t.Fatalf("locate without origin = %v, want error naming drs.origin.fields", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/calypr/git-drs/cmd"
 	"github.com/calypr/git-drs/cmd/credentialhelper"
+	"github.com/calypr/git-drs/internal/drserrors"
 	"github.com/calypr/git-drs/internal/drslog"
 )
 
@@ -28,8 +29,12 @@ func main() {
 		return
 	}
 
-	if err := cmd.RootCmd.Execute(); err != nil {
+	executed, err := cmd.RootCmd.ExecuteC()
+	cmd.ReleaseRepoLock()
+	cmd.FlushMetrics(executed)
+	if err != nil {
+		cmd.PrintError(os.Stderr, executed, err)
 		drslog.Close() // closes log file if there was one
-		os.Exit(1)
+		os.Exit(drserrors.ExitCode(err))
 	}
 }
@@ -0,0 +1,126 @@
+// Package accesspolicy orders the access methods of a DRS object so that
+// downloads try the preferred (closest or cheapest) replica first.
+package accesspolicy
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// Preference matches access methods on one attribute. Key is one of type,
+// region, cloud, or host; host values may use path.Match globs.
+type Preference struct {
+	Key   string
+	Value string
+}
+
+// Policy ranks access methods: unavailable methods go last, then methods
+// matching an earlier preference go first, then (when Probe is set) lower
+// measured latency wins. Ties keep the record's order.
+type Policy struct {
+	Prefer []Preference
+	Probe  bool
+}
+
+var preferenceKeys = map[string]bool{"type": true, "region": true, "cloud": true, "host": true}
+
+// ParsePreferences parses a comma-separated list of key=value entries, for
+// example "region=us-west-2,type=s3,host=*.example.org".
+func ParsePreferences(raw string) ([]Preference, error) {
+	var prefs []Preference
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(item, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if !ok || value == "" || !preferenceKeys[key] {
+			return nil, fmt.Errorf("invalid access preference %q: expected type=, region=, cloud=, or host=<value>", item)
+		}
+		prefs = append(prefs, Preference{Key: key, Value: value})
+	}
+	return prefs, nil
+}
+
+// Matches reports whether m satisfies the preference.
+func (p Preference) Matches(m drsapi.AccessMethod) bool {
+	switch p.Key {
+	case "type":
+		return strings.EqualFold(string(m.Type), p.Value)
+	case "region":
+		return m.Region != nil && strings.EqualFold(*m.Region, p.Value)
+	case "cloud":
+		return m.Cloud != nil && strings.EqualFold(*m.Cloud, p.Value)
+	case "host":
+		host := Host(m)
+		ok, err := path.Match(strings.ToLower(p.Value), host)
+		return host != "" && err == nil && ok
+	}
+	return false
+}
+
+// Order returns methods ranked by the policy. latency reports a probed
+// round-trip for a method and is only consulted when Probe is set; it may be
+// nil.
+func (p Policy) Order(methods []drsapi.AccessMethod, latency func(drsapi.AccessMethod) (time.Duration, bool)) []drsapi.AccessMethod {
+	type ranked struct {
+		method  drsapi.AccessMethod
+		offline bool
+		pref    int
+		rtt     time.Duration
+		probed  bool
+	}
+	items := make([]ranked, len(methods))
+	for i, m := range methods {
+		r := ranked{method: m, offline: m.Available != nil && !*m.Available, pref: len(p.Prefer)}
+		for j, pref := range p.Prefer {
+			if pref.Matches(m) {
+				r.pref = j
+				break
+			}
+		}
+		if p.Probe && latency != nil {
+			r.rtt, r.probed = latency(m)
+		}
+		items[i] = r
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.offline != b.offline {
+			return !a.offline
+		}
+		if a.pref != b.pref {
+			return a.pref < b.pref
+		}
+		if a.probed != b.probed {
+			return a.probed
+		}
+		return a.probed && a.rtt < b.rtt
+	})
+	out := make([]drsapi.AccessMethod, len(items))
+	for i, r := range items {
+		out[i] = r.method
+	}
+	return out
+}
+
+// Host returns the lower-cased host of the method's access URL: the server
+// for http(s) URLs and the bucket for object-store URLs such as s3://.
+func Host(m drsapi.AccessMethod) string {
+	if m.AccessUrl == nil {
+		return ""
+	}
+	u, err := url.Parse(strings.TrimSpace(m.AccessUrl.Url))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
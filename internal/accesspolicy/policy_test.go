@@ -0,0 +1,114 @@
+package accesspolicy
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func method(typ, rawURL, region string) drsapi.AccessMethod {
+	m := drsapi.AccessMethod{Type: drsapi.AccessMethodType(typ)}
+	if rawURL != "" {
+		m.AccessUrl = &struct {
+			Headers *[]string `json:"headers,omitempty"`
+			Url     string    `json:"url"`
+		}{Url: rawURL}
+	}
+	if region != "" {
+		m.Region = &region
+	}
+	return m
+}
+
+func TestParsePreferences(t *testing.T) {
+	prefs, err := ParsePreferences(" region=us-west-2, TYPE=s3 ,host=*.example.org,")
+	if err != nil {
+		t.Fatalf("ParsePreferences: %v", err)
+	}
+	want := []Preference{{"region", "us-west-2"}, {"type", "s3"}, {"host", "*.example.org"}}
+	if len(prefs) != len(want) {
+		t.Fatalf("got %+v, want %+v", prefs, want)
+	}
+	for i := range want {
+		if prefs[i] != want[i] {
+			t.Fatalf("got %+v, want %+v", prefs, want)
+		}
+	}
+	for _, bad := range []string{"zone=a", "region", "region="} {
+		if _, err := ParsePreferences(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestOrderRanksPreferenceThenLatency(t *testing.T) {
+	unavailable := false
+	east := method("s3", "s3://east-bucket/key", "us-east-1")
+	west := method("s3", "s3://west-bucket/key", "us-west-2")
+	mirror := method("https", "https://mirror.example.org/key", "")
+	cold := method("s3", "s3://archive/key", "us-west-2")
+	cold.Available = &unavailable
+	methods := []drsapi.AccessMethod{cold, east, mirror, west}
+
+	policy := Policy{Prefer: []Preference{{"region", "us-west-2"}}}
+	got := policy.Order(methods, nil)
+	if Host(got[0]) != "west-bucket" || Host(got[3]) != "archive" {
+		t.Fatalf("unexpected order: %v", hosts(got))
+	}
+	if Host(got[1]) != "east-bucket" || Host(got[2]) != "mirror.example.org" {
+		t.Fatalf("ties should keep record order: %v", hosts(got))
+	}
+
+	policy = Policy{Prefer: []Preference{{"host", "*.example.org"}}, Probe: true}
+	rtt := map[string]time.Duration{"east-bucket": 80 * time.Millisecond, "west-bucket": 20 * time.Millisecond}
+	got = policy.Order(methods, func(m drsapi.AccessMethod) (time.Duration, bool) {
+		d, ok := rtt[Host(m)]
+		return d, ok
+	})
+	if want := []string{"mirror.example.org", "west-bucket", "east-bucket", "archive"}; !slices.Equal(hosts(got), want) {
+		t.Fatalf("got %v, want %v", hosts(got), want)
+	}
+}
+
+func TestProberCachesByEndpoint(t *testing.T) {
+	orig := dial
+	t.Cleanup(func() { dial = orig })
+	var dialed []string
+	dial = func(_ context.Context, address string) error {
+		dialed = append(dialed, address)
+		if address == "down.example.org:443" {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+
+	p := NewProber()
+	ctx := context.Background()
+	if _, ok := p.Latency(ctx, method("s3", "s3://bucket/key", "us-west-2")); !ok {
+		t.Fatal("expected regional s3 endpoint to be probed")
+	}
+	if _, ok := p.Latency(ctx, method("s3", "s3://other/key", "us-west-2")); !ok {
+		t.Fatal("expected cached probe result")
+	}
+	if _, ok := p.Latency(ctx, method("https", "https://down.example.org/key", "")); ok {
+		t.Fatal("expected failed probe")
+	}
+	if _, ok := p.Latency(ctx, method("gs", "gs://bucket/key", "")); ok {
+		t.Fatal("expected gs URL to be unprobed")
+	}
+	if want := []string{"s3.us-west-2.amazonaws.com:443", "down.example.org:443"}; !slices.Equal(dialed, want) {
+		t.Fatalf("dialed %v, want %v", dialed, want)
+	}
+}
+
+func hosts(methods []drsapi.AccessMethod) []string {
+	out := make([]string, len(methods))
+	for i, m := range methods {
+		out[i] = Host(m)
+	}
+	return out
+}
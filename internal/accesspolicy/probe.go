@@ -0,0 +1,100 @@
+package accesspolicy
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// ProbeTimeout bounds each latency probe.
+const ProbeTimeout = 2 * time.Second
+
+// dial is swapped in tests.
+var dial = func(ctx context.Context, address string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// Prober measures TCP connect time to the endpoint behind an access method
+// and remembers the result for the life of the process, so each endpoint is
+// probed at most once per command.
+type Prober struct {
+	mu    sync.Mutex
+	cache map[string]probeResult
+}
+
+type probeResult struct {
+	rtt time.Duration
+	ok  bool
+}
+
+// NewProber returns an empty Prober.
+func NewProber() *Prober {
+	return &Prober{cache: make(map[string]probeResult)}
+}
+
+// Latency returns the connect time to m's endpoint, or false when the
+// endpoint cannot be derived or did not answer within ProbeTimeout.
+func (p *Prober) Latency(ctx context.Context, m drsapi.AccessMethod) (time.Duration, bool) {
+	address := probeAddress(m)
+	if address == "" {
+		return 0, false
+	}
+	p.mu.Lock()
+	if res, ok := p.cache[address]; ok {
+		p.mu.Unlock()
+		return res.rtt, res.ok
+	}
+	p.mu.Unlock()
+
+	probeCtx, cancel := context.WithTimeout(ctx, ProbeTimeout)
+	defer cancel()
+	start := time.Now()
+	err := dial(probeCtx, address)
+	res := probeResult{rtt: time.Since(start), ok: err == nil}
+
+	p.mu.Lock()
+	p.cache[address] = res
+	p.mu.Unlock()
+	return res.rtt, res.ok
+}
+
+// probeAddress maps an access method to host:port. http(s) URLs are probed
+// directly; s3 URLs with a region are probed at that region's S3 endpoint.
+// Other object-store URLs carry no location and are not probed.
+func probeAddress(m drsapi.AccessMethod) string {
+	if m.AccessUrl == nil {
+		return ""
+	}
+	u, err := url.Parse(strings.TrimSpace(m.AccessUrl.Url))
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return net.JoinHostPort(u.Hostname(), portOr(u, "443"))
+	case "http":
+		return net.JoinHostPort(u.Hostname(), portOr(u, "80"))
+	case "s3":
+		if m.Region != nil && strings.TrimSpace(*m.Region) != "" {
+			return net.JoinHostPort("s3."+strings.TrimSpace(*m.Region)+".amazonaws.com", "443")
+		}
+	}
+	return ""
+}
+
+func portOr(u *url.URL, def string) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	return def
+}
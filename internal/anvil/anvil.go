@@ -0,0 +1,393 @@
+// Package anvil resolves AnVIL and Terra DRS objects without external
+// tools. DRSHub (or Martha, its predecessor) resolves DRS URIs to metadata
+// and access URLs; SAM exchanges the user's Google token for a pet service
+// account token that reads requester-pays buckets on the configured billing
+// project; and objects are read from signed URLs or straight from GCS.
+//
+// A Client answers the syfon DRS endpoints git-drs calls through a
+// RoundTripper, so pull, fetch, download, query and add-ref run unchanged
+// against an AnVIL remote. AnVIL does not accept registrations, so every
+// write is refused.
+package anvil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	syfoncommon "github.com/calypr/syfon/common"
+)
+
+// BaseURL is the API endpoint clients of a Client are built with. Nothing
+// resolves it: the Client's RoundTripper answers every request to it.
+const BaseURL = "http://anvil.invalid"
+
+const (
+	// DefaultDRSHub, DefaultSAM and DefaultDRSHost are Terra's production
+	// services and the Terra Data Repository's DRS host.
+	DefaultDRSHub  = "https://drshub.dsde-prod.broadinstitute.org"
+	DefaultSAM     = "https://sam.dsde-prod.broadinstitute.org"
+	DefaultDRSHost = "jade.datarepo-prod.broadinstitute.org"
+	// DefaultGCS is the Cloud Storage JSON API gs:// URLs are read from.
+	DefaultGCS = "https://storage.googleapis.com"
+)
+
+// AccessType is the access method type of the records a Client returns.
+const AccessType drsapi.AccessMethodType = "https"
+
+const (
+	drsPrefix = "/ga4gh/drs/v1/objects/"
+	// gcsPrefix and signedPrefix are the URLs a Client hands out for gs://
+	// objects and for signed URLs that need extra request headers; it
+	// fetches them itself with the right credentials.
+	gcsPrefix    = "/gcs/"
+	signedPrefix = "/signed/"
+)
+
+// TokenSource returns the user's Google access token.
+type TokenSource func(ctx context.Context) (string, error)
+
+// IDLookup returns the DRS ID recorded for a sha256 oid. DRSHub cannot look
+// objects up by checksum, so a pointer is only resolvable once its DRS ID
+// is known locally.
+type IDLookup func(oid string) (string, bool)
+
+// Client resolves AnVIL DRS objects for one git-drs remote. Records it
+// returns are scoped to Organization and Project so the remote's scope
+// filters keep them.
+type Client struct {
+	// DRSHub is the resolver's base URL, or the full URL of a Martha
+	// function, which takes the same request.
+	DRSHub string
+	SAM    string
+	GCS    string
+	// DRSHost completes bare IDs, such as v1_<uuid>_<uuid>, into DRS URIs.
+	DRSHost string
+	// BillingProject pays for requester-pays reads; when empty, objects are
+	// read with the user's own token.
+	BillingProject string
+	Organization   string
+	Project        string
+	Token          TokenSource
+	Lookup         IDLookup
+	// Base sends every request that leaves the process.
+	Base http.RoundTripper
+
+	pet    petToken
+	signed sync.Map // key -> signedURL
+}
+
+// signedURL is a signed URL DRSHub returned with headers it must be fetched
+// with.
+type signedURL struct {
+	url     string
+	headers map[string]string
+}
+
+// RoundTrip answers requests to BaseURL and sends the rest, such as signed
+// URLs, through Base.
+func (c *Client) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme+"://"+req.URL.Host != BaseURL {
+		return c.Base.RoundTrip(req)
+	}
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	path := req.URL.Path
+	switch {
+	case path == "/healthz" && req.Method == http.MethodGet:
+		return c.status(req)
+	case strings.HasPrefix(path, gcsPrefix) && (req.Method == http.MethodGet || req.Method == http.MethodHead):
+		return c.readGCS(req, strings.TrimPrefix(path, gcsPrefix))
+	case strings.HasPrefix(path, signedPrefix) && (req.Method == http.MethodGet || req.Method == http.MethodHead):
+		return c.readSigned(req, strings.TrimPrefix(path, signedPrefix))
+	case strings.HasPrefix(path, drsPrefix) && req.Method == http.MethodGet:
+		return c.serveDRS(req, strings.TrimPrefix(path, drsPrefix))
+	case path == strings.TrimSuffix(drsPrefix, "/")+"/access" && req.Method == http.MethodPost:
+		return c.bulkAccess(req)
+	}
+	return errorResponse(req, http.StatusMethodNotAllowed, "AnVIL remotes are read-only and only resolve existing DRS objects; "+req.Method+" "+path+" is not supported"), nil
+}
+
+func (c *Client) serveDRS(req *http.Request, rest string) (*http.Response, error) {
+	ctx := req.Context()
+	if sum, ok := strings.CutPrefix(rest, "checksum/"); ok {
+		objs := []drsapi.DrsObject{}
+		sum = strings.ToLower(strings.TrimPrefix(sum, "sha256:"))
+		if id, found := c.lookup(sum); found {
+			obj, err := c.object(ctx, id)
+			if err != nil {
+				return resolveErrorResponse(req, err), nil
+			}
+			if !hasChecksum(obj, "sha256") {
+				obj.Checksums = append(obj.Checksums, drsapi.Checksum{Type: "sha256", Checksum: sum})
+			}
+			objs = append(objs, obj)
+		}
+		return jsonResponse(req, http.StatusOK, drsapi.N200OkDrsObjects{ResolvedDrsObject: &objs}), nil
+	}
+	if id, _, ok := strings.Cut(rest, "/access/"); ok {
+		u, err := c.accessURL(ctx, id)
+		if err != nil {
+			return resolveErrorResponse(req, err), nil
+		}
+		return jsonResponse(req, http.StatusOK, drsapi.AccessURL{Url: u}), nil
+	}
+	obj, err := c.object(ctx, rest)
+	if err != nil {
+		return resolveErrorResponse(req, err), nil
+	}
+	return jsonResponse(req, http.StatusOK, obj), nil
+}
+
+func (c *Client) bulkAccess(req *http.Request) (*http.Response, error) {
+	var body drsapi.BulkObjectAccessId
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		return errorResponse(req, http.StatusBadRequest, err.Error()), nil
+	}
+	resolved := []drsapi.BulkAccessURL{}
+	if body.BulkObjectAccessIds != nil {
+		for _, item := range *body.BulkObjectAccessIds {
+			if item.BulkObjectId == nil {
+				continue
+			}
+			u, err := c.accessURL(req.Context(), *item.BulkObjectId)
+			if err != nil {
+				return resolveErrorResponse(req, err), nil
+			}
+			resolved = append(resolved, drsapi.BulkAccessURL{DrsObjectId: item.BulkObjectId, Url: u})
+		}
+	}
+	return jsonResponse(req, http.StatusOK, drsapi.N200OkAccesses{ResolvedDrsObjectAccessUrls: &resolved}), nil
+}
+
+func (c *Client) lookup(oid string) (string, bool) {
+	if c.Lookup == nil {
+		return "", false
+	}
+	return c.Lookup(oid)
+}
+
+// object resolves id's metadata into a record in the Client's scope.
+func (c *Client) object(ctx context.Context, id string) (drsapi.DrsObject, error) {
+	uri := c.URI(id)
+	res, err := c.resolve(ctx, uri, metadataFields)
+	if err != nil {
+		return drsapi.DrsObject{}, err
+	}
+	obj := drsapi.DrsObject{
+		Id:       uri,
+		SelfUri:  uri,
+		Name:     res.FileName,
+		MimeType: res.ContentType,
+		AccessMethods: &[]drsapi.AccessMethod{{
+			Type:     AccessType,
+			AccessId: ptr(string(AccessType)),
+		}},
+		ControlledAccess: ptr(syfoncommon.AuthzMapToControlledAccess(syfoncommon.AuthzMapFromScope(c.Organization, c.Project))),
+	}
+	if res.Size != nil {
+		obj.Size = *res.Size
+	}
+	for typ, sum := range res.Hashes {
+		if sum != "" {
+			obj.Checksums = append(obj.Checksums, drsapi.Checksum{Type: strings.ToLower(typ), Checksum: sum})
+		}
+	}
+	sortChecksums(obj.Checksums)
+	if res.TimeCreated != nil {
+		if t, err := time.Parse(time.RFC3339, *res.TimeCreated); err == nil {
+			obj.CreatedTime = t
+		}
+	}
+	return obj, nil
+}
+
+// accessURL returns a URL id can be downloaded from: DRSHub's signed URL
+// when it is usable as is, otherwise one of the Client's own URLs, which
+// it fetches with the headers or token the object needs.
+func (c *Client) accessURL(ctx context.Context, id string) (string, error) {
+	res, err := c.resolve(ctx, c.URI(id), accessFields)
+	if err != nil {
+		return "", err
+	}
+	if res.AccessURL != nil && strings.TrimSpace(res.AccessURL.URL) != "" {
+		if len(res.AccessURL.Headers) == 0 {
+			return res.AccessURL.URL, nil
+		}
+		key, err := randomKey()
+		if err != nil {
+			return "", err
+		}
+		c.signed.Store(key, signedURL{url: res.AccessURL.URL, headers: res.AccessURL.Headers})
+		return BaseURL + signedPrefix + key, nil
+	}
+	if res.GSURI != nil {
+		bucket, object, ok := strings.Cut(strings.TrimPrefix(*res.GSURI, "gs://"), "/")
+		if ok && bucket != "" && object != "" && strings.HasPrefix(*res.GSURI, "gs://") {
+			return BaseURL + gcsPrefix + bucket + "/" + object, nil
+		}
+	}
+	return "", &resolveError{status: http.StatusNotFound, msg: fmt.Sprintf("DRSHub returned no access URL for %s", c.URI(id))}
+}
+
+// readGCS reads gs://bucket/object through the JSON API, billing the
+// configured project and forwarding Range so ranged downloads work.
+func (c *Client) readGCS(req *http.Request, rest string) (*http.Response, error) {
+	bucket, object, ok := strings.Cut(rest, "/")
+	if !ok || bucket == "" || object == "" {
+		return errorResponse(req, http.StatusNotFound, "invalid gs:// object "+rest), nil
+	}
+	token, err := c.readToken(req.Context())
+	if err != nil {
+		return resolveErrorResponse(req, err), nil
+	}
+	q := url.Values{"alt": {"media"}}
+	if c.BillingProject != "" {
+		q.Set("userProject", c.BillingProject)
+	}
+	u := strings.TrimRight(c.gcs(), "/") + "/storage/v1/b/" + url.PathEscape(bucket) + "/o/" + url.PathEscape(object) + "?" + q.Encode()
+	out, err := http.NewRequestWithContext(req.Context(), req.Method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	out.Header.Set("Authorization", "Bearer "+token)
+	copyHeader(out.Header, req.Header, "Range")
+	return c.forward(req, out)
+}
+
+func (c *Client) readSigned(req *http.Request, key string) (*http.Response, error) {
+	v, ok := c.signed.Load(key)
+	if !ok {
+		return errorResponse(req, http.StatusNotFound, "unknown signed URL; resolve the object again"), nil
+	}
+	s := v.(signedURL)
+	out, err := http.NewRequestWithContext(req.Context(), req.Method, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range s.headers {
+		out.Header.Set(k, v)
+	}
+	copyHeader(out.Header, req.Header, "Range")
+	return c.forward(req, out)
+}
+
+// forward sends out and hands its response back as the answer to req.
+func (c *Client) forward(req, out *http.Request) (*http.Response, error) {
+	resp, err := c.Base.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	resp.Request = req
+	return resp, nil
+}
+
+// status answers the health check with DRSHub's own.
+func (c *Client) status(req *http.Request) (*http.Response, error) {
+	out, err := http.NewRequestWithContext(req.Context(), http.MethodGet, c.drsHubOrigin()+"/status", nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.forward(req, out)
+}
+
+// URI turns id into the DRS URI DRSHub resolves: URIs are kept, compact
+// identifiers in either form (drs.anv0:v2_x or dg.ANV0/x) get the drs://
+// compact syntax, and bare IDs are hosted on DRSHost.
+func (c *Client) URI(id string) string {
+	id = strings.TrimSpace(id)
+	switch {
+	case strings.HasPrefix(id, "drs://"):
+		return id
+	case strings.Contains(id, "/"):
+		prefix, accession, _ := strings.Cut(id, "/")
+		return "drs://" + prefix + ":" + accession
+	case strings.Contains(id, ":"):
+		return "drs://" + id
+	}
+	host := c.DRSHost
+	if host == "" {
+		host = DefaultDRSHost
+	}
+	return "drs://" + host + "/" + id
+}
+
+func (c *Client) gcs() string {
+	if c.GCS != "" {
+		return c.GCS
+	}
+	return DefaultGCS
+}
+
+func hasChecksum(obj drsapi.DrsObject, typ string) bool {
+	for _, sum := range obj.Checksums {
+		if strings.EqualFold(sum.Type, typ) && sum.Checksum != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func sortChecksums(sums []drsapi.Checksum) {
+	for i := 1; i < len(sums); i++ {
+		for j := i; j > 0 && sums[j].Type < sums[j-1].Type; j-- {
+			sums[j], sums[j-1] = sums[j-1], sums[j]
+		}
+	}
+}
+
+func copyHeader(dst, src http.Header, name string) {
+	if v := src.Get(name); v != "" {
+		dst.Set(name, v)
+	}
+}
+
+func randomKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// resolveErrorResponse reports err with the status DRSHub or SAM answered,
+// so callers classify it as an auth, not-found or server error.
+func resolveErrorResponse(req *http.Request, err error) *http.Response {
+	var re *resolveError
+	if errors.As(err, &re) {
+		return errorResponse(req, re.status, re.msg)
+	}
+	return errorResponse(req, http.StatusBadGateway, err.Error())
+}
+
+func errorResponse(req *http.Request, status int, msg string) *http.Response {
+	return jsonResponse(req, status, map[string]string{"msg": msg})
+}
+
+func jsonResponse(req *http.Request, status int, v any) *http.Response {
+	body, _ := json.Marshal(v)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(string(body))),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
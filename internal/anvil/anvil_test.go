@@ -0,0 +1,252 @@
+package anvil
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	syclient "github.com/calypr/syfon/client"
+)
+
+const (
+	testURI     = "drs://drs.anv0:v2_1234"
+	userToken   = "user-token"
+	petTokenVal = "pet-token"
+)
+
+// terra fakes DRSHub, SAM and GCS on one server.
+type terra struct {
+	t       *testing.T
+	content []byte
+	// resolved is the DRSHub answer for testURI's access fields.
+	resolved map[string]any
+	// userProjects collects the billing project each request named.
+	userProjects []string
+}
+
+func (f *terra) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/api/v4/drs/resolve":
+		if got := r.Header.Get("Authorization"); got != "Bearer "+userToken {
+			http.Error(w, `{"message":"not authorized"}`, http.StatusUnauthorized)
+			return
+		}
+		f.userProjects = append(f.userProjects, r.Header.Get("x-user-project"))
+		var body struct {
+			URL    string   `json:"url"`
+			Fields []string `json:"fields"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			f.t.Fatalf("decode resolve request: %v", err)
+		}
+		if body.URL != testURI {
+			http.Error(w, `{"message":"no such object"}`, http.StatusNotFound)
+			return
+		}
+		if body.Fields[0] == "accessUrl" {
+			_ = json.NewEncoder(w).Encode(f.resolved)
+			return
+		}
+		md5 := "0123456789abcdef0123456789abcdef"
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"fileName":    "reads.bam",
+			"size":        len(f.content),
+			"hashes":      map[string]string{"md5": md5},
+			"timeCreated": "2024-01-02T03:04:05Z",
+		})
+	case r.URL.Path == "/api/google/v1/user/petServiceAccount/billing-1/token":
+		if got := r.Header.Get("Authorization"); got != "Bearer "+userToken {
+			http.Error(w, "not authorized", http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(petTokenVal)
+	case strings.HasPrefix(r.URL.Path, "/storage/v1/b/anvil-bucket/o/"):
+		if got := r.Header.Get("Authorization"); got != "Bearer "+petTokenVal {
+			http.Error(w, "wrong token "+got, http.StatusForbidden)
+			return
+		}
+		f.userProjects = append(f.userProjects, r.URL.Query().Get("userProject"))
+		if obj := strings.TrimPrefix(r.URL.EscapedPath(), "/storage/v1/b/anvil-bucket/o/"); obj != "dir%2Freads.bam" {
+			http.Error(w, "no such object "+obj, http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(f.content)
+	case r.URL.Path == "/signed/reads.bam":
+		if got := r.Header.Get("x-signed-by"); got != "drshub" {
+			http.Error(w, "missing signed URL header", http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write(f.content)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestClient(t *testing.T, f *terra) (*Client, *syclient.Client) {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	sum := sha256.Sum256(f.content)
+	oid := hex.EncodeToString(sum[:])
+	c := &Client{
+		DRSHub:         srv.URL,
+		SAM:            srv.URL,
+		GCS:            srv.URL,
+		BillingProject: "billing-1",
+		Organization:   "anvil",
+		Project:        "reads",
+		Token:          func(context.Context) (string, error) { return userToken, nil },
+		Lookup: func(got string) (string, bool) {
+			return testURI, got == oid
+		},
+		Base: http.DefaultTransport,
+	}
+	raw, err := syclient.New(BaseURL, syclient.WithHTTPClient(&http.Client{Transport: c}))
+	if err != nil {
+		t.Fatalf("syclient.New: %v", err)
+	}
+	return c, raw.(*syclient.Client)
+}
+
+func download(t *testing.T, cl *syclient.Client, u string) []byte {
+	t.Helper()
+	resp, err := cl.Data().Download(context.Background(), u, nil, nil)
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read download: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("download status %d: %s", resp.StatusCode, data)
+	}
+	return data
+}
+
+func TestResolveByChecksumAndReadRequesterPaysBucket(t *testing.T) {
+	f := &terra{t: t, content: []byte("anvil object content"), resolved: map[string]any{"gsUri": "gs://anvil-bucket/dir/reads.bam"}}
+	_, cl := newTestClient(t, f)
+	ctx := context.Background()
+	sum := sha256.Sum256(f.content)
+	oid := hex.EncodeToString(sum[:])
+
+	page, err := cl.DRS().BatchGetObjectsByHash(ctx, []string{oid})
+	if err != nil {
+		t.Fatalf("BatchGetObjectsByHash: %v", err)
+	}
+	if len(page.DrsObjects) != 1 {
+		t.Fatalf("expected one record, got %d", len(page.DrsObjects))
+	}
+	rec := page.DrsObjects[0]
+	if rec.Id != testURI || rec.Size != int64(len(f.content)) || rec.Name == nil || *rec.Name != "reads.bam" {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+	if !hasChecksum(rec, "sha256") || !hasChecksum(rec, "md5") {
+		t.Fatalf("expected md5 and sha256 checksums, got %+v", rec.Checksums)
+	}
+	if rec.ControlledAccess == nil || len(*rec.ControlledAccess) == 0 {
+		t.Fatalf("expected the record to be scoped to the remote's project")
+	}
+
+	access, err := cl.DRS().GetAccessURL(ctx, rec.Id, string(AccessType))
+	if err != nil {
+		t.Fatalf("GetAccessURL: %v", err)
+	}
+	if !strings.HasPrefix(access.Url, BaseURL+gcsPrefix) {
+		t.Fatalf("expected a gs:// object to be read through the client, got %s", access.Url)
+	}
+	if got := download(t, cl, access.Url); string(got) != string(f.content) {
+		t.Fatalf("downloaded %q, want %q", got, f.content)
+	}
+	for _, p := range f.userProjects {
+		if p != "billing-1" {
+			t.Fatalf("expected every request billed to billing-1, got %q", f.userProjects)
+		}
+	}
+
+	page, err = cl.DRS().BatchGetObjectsByHash(ctx, []string{strings.Repeat("0", 64)})
+	if err != nil {
+		t.Fatalf("BatchGetObjectsByHash for unknown oid: %v", err)
+	}
+	if len(page.DrsObjects) != 0 {
+		t.Fatalf("expected no record for an oid without a recorded DRS ID, got %+v", page.DrsObjects)
+	}
+}
+
+func TestSignedURLHeadersAreSent(t *testing.T) {
+	f := &terra{t: t, content: []byte("signed content")}
+	// The signed URL points at the fake server, which is only known once
+	// the client is built.
+	c, cl := newTestClient(t, f)
+	srvURL := c.DRSHub
+	f.resolved = map[string]any{"accessUrl": map[string]any{
+		"url":     srvURL + "/signed/reads.bam",
+		"headers": map[string]string{"x-signed-by": "drshub"},
+	}}
+
+	access, err := cl.DRS().GetAccessURL(context.Background(), testURI, string(AccessType))
+	if err != nil {
+		t.Fatalf("GetAccessURL: %v", err)
+	}
+	if !strings.HasPrefix(access.Url, BaseURL+signedPrefix) {
+		t.Fatalf("expected a signed URL with headers to be fetched by the client, got %s", access.Url)
+	}
+	if got := download(t, cl, access.Url); string(got) != string(f.content) {
+		t.Fatalf("downloaded %q, want %q", got, f.content)
+	}
+
+	f.resolved = map[string]any{"accessUrl": map[string]any{"url": srvURL + "/plain"}}
+	access, err = cl.DRS().GetAccessURL(context.Background(), testURI, string(AccessType))
+	if err != nil {
+		t.Fatalf("GetAccessURL: %v", err)
+	}
+	if access.Url != srvURL+"/plain" {
+		t.Fatalf("expected a signed URL without headers to be returned as is, got %s", access.Url)
+	}
+}
+
+func TestResolveErrorsKeepStatus(t *testing.T) {
+	f := &terra{t: t}
+	c, cl := newTestClient(t, f)
+	if _, err := cl.DRS().GetObject(context.Background(), "drs.anv0/v2_missing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected a 404 for an unknown object, got %v", err)
+	}
+	c.Token = func(context.Context) (string, error) { return "expired", nil }
+	if _, err := cl.DRS().GetObject(context.Background(), testURI); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected DRSHub's 401 to be passed through, got %v", err)
+	}
+}
+
+func TestWritesAreRefused(t *testing.T) {
+	_, cl := newTestClient(t, &terra{t: t})
+	name := "x"
+	_, err := cl.DRS().RegisterObjects(context.Background(), drsapi.RegisterObjectsJSONRequestBody{
+		Candidates: []drsapi.DrsObjectCandidate{{Name: &name, Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: strings.Repeat("a", 64)}}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "405") {
+		t.Fatalf("expected registration to be refused, got %v", err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	c := &Client{}
+	for in, want := range map[string]string{
+		"drs://jade.datarepo-prod.broadinstitute.org/v1_a_b": "drs://jade.datarepo-prod.broadinstitute.org/v1_a_b",
+		"drs.anv0/v2_1234": "drs://drs.anv0:v2_1234",
+		"dg.ANV0:1234":     "drs://dg.ANV0:1234",
+		"v1_a_b":           "drs://" + DefaultDRSHost + "/v1_a_b",
+	} {
+		if got := c.URI(in); got != want {
+			t.Errorf("URI(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
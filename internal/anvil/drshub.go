@@ -0,0 +1,216 @@
+package anvil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// resolvePath is DRSHub's resolve endpoint. Martha endpoints are configured
+// with their full path and used as is.
+const resolvePath = "/api/v4/drs/resolve"
+
+var (
+	metadataFields = []string{"fileName", "size", "hashes", "contentType", "timeCreated"}
+	accessFields   = []string{"accessUrl", "gsUri"}
+)
+
+// petScopes are the scopes of the pet service account token objects in
+// requester-pays buckets are read with.
+var petScopes = []string{
+	"https://www.googleapis.com/auth/userinfo.email",
+	"https://www.googleapis.com/auth/userinfo.profile",
+	"https://www.googleapis.com/auth/devstorage.read_only",
+}
+
+// petTTL is how long a pet token is reused. SAM issues them for an hour.
+const petTTL = 50 * time.Minute
+
+// resolution is the subset of DRSHub's (and Martha's) response git-drs
+// uses. DRSHub fetches linked-account (Bond) credentials itself when a
+// provider needs them, so signed URLs for Gen3-hosted AnVIL data arrive
+// ready to use.
+type resolution struct {
+	FileName    *string           `json:"fileName"`
+	Size        *int64            `json:"size"`
+	Hashes      map[string]string `json:"hashes"`
+	ContentType *string           `json:"contentType"`
+	TimeCreated *string           `json:"timeCreated"`
+	GSURI       *string           `json:"gsUri"`
+	AccessURL   *struct {
+		URL     string            `json:"url"`
+		Headers map[string]string `json:"headers"`
+	} `json:"accessUrl"`
+}
+
+// resolveError carries the status a Terra service answered with.
+type resolveError struct {
+	status int
+	msg    string
+}
+
+func (e *resolveError) Error() string { return e.msg }
+
+// resolve asks DRSHub for fields of uri.
+func (c *Client) resolve(ctx context.Context, uri string, fields []string) (resolution, error) {
+	token, err := c.userToken(ctx)
+	if err != nil {
+		return resolution{}, err
+	}
+	body, err := json.Marshal(map[string]any{"url": uri, "fields": fields})
+	if err != nil {
+		return resolution{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.resolveURL(), bytes.NewReader(body))
+	if err != nil {
+		return resolution{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if c.BillingProject != "" {
+		req.Header.Set("x-user-project", c.BillingProject)
+	}
+	var res resolution
+	if err := c.call(req, "DRSHub", &res); err != nil {
+		return resolution{}, fmt.Errorf("resolve %s: %w", uri, err)
+	}
+	return res, nil
+}
+
+// readToken returns the token GCS objects are read with: a pet service
+// account token on the billing project when one is configured, so
+// requester-pays reads are billed there, otherwise the user's own.
+func (c *Client) readToken(ctx context.Context) (string, error) {
+	if c.BillingProject == "" {
+		return c.userToken(ctx)
+	}
+	return c.pet.get(ctx, func(ctx context.Context) (string, error) {
+		user, err := c.userToken(ctx)
+		if err != nil {
+			return "", err
+		}
+		body, _ := json.Marshal(petScopes)
+		u := strings.TrimRight(c.samBase(), "/") + "/api/google/v1/user/petServiceAccount/" + url.PathEscape(c.BillingProject) + "/token"
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+user)
+		var token string
+		if err := c.call(req, "SAM", &token); err != nil {
+			return "", fmt.Errorf("get pet service account token for billing project %s: %w", c.BillingProject, err)
+		}
+		return token, nil
+	})
+}
+
+func (c *Client) userToken(ctx context.Context) (string, error) {
+	if c.Token == nil {
+		return "", &resolveError{status: http.StatusUnauthorized, msg: "no Google credentials for the AnVIL remote"}
+	}
+	token, err := c.Token(ctx)
+	if err != nil {
+		return "", &resolveError{status: http.StatusUnauthorized, msg: "get Google access token: " + err.Error()}
+	}
+	return token, nil
+}
+
+// call sends req and decodes a successful JSON answer into out, keeping
+// the service's status on failure.
+func (c *Client) call(req *http.Request, service string, out any) error {
+	resp, err := c.Base.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &resolveError{status: resp.StatusCode, msg: fmt.Sprintf("%s returned %s: %s", service, resp.Status, errorMessage(data))}
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode %s response: %w", service, err)
+	}
+	return nil
+}
+
+// errorMessage pulls the message out of a Terra error body.
+func errorMessage(data []byte) string {
+	var body struct {
+		Message string `json:"message"`
+		Msg     string `json:"msg"`
+	}
+	if json.Unmarshal(data, &body) == nil {
+		if body.Message != "" {
+			return body.Message
+		}
+		if body.Msg != "" {
+			return body.Msg
+		}
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func (c *Client) resolveURL() string {
+	base := c.drsHubBase()
+	if u, err := url.Parse(base); err == nil && strings.Trim(u.Path, "/") != "" {
+		return base
+	}
+	return strings.TrimRight(base, "/") + resolvePath
+}
+
+// drsHubBase is the resolver's origin plus any configured path.
+func (c *Client) drsHubBase() string {
+	if c.DRSHub != "" {
+		return c.DRSHub
+	}
+	return DefaultDRSHub
+}
+
+// drsHubOrigin drops a Martha function path, leaving the host whose
+// /status reports the service's health.
+func (c *Client) drsHubOrigin() string {
+	base := c.drsHubBase()
+	if u, err := url.Parse(base); err == nil && u.Host != "" {
+		return u.Scheme + "://" + u.Host
+	}
+	return strings.TrimRight(base, "/")
+}
+
+func (c *Client) samBase() string {
+	if c.SAM != "" {
+		return c.SAM
+	}
+	return DefaultSAM
+}
+
+// petToken caches one pet service account token.
+type petToken struct {
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (p *petToken) get(ctx context.Context, fetch func(context.Context) (string, error)) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Now().Before(p.expires) {
+		return p.token, nil
+	}
+	token, err := fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	p.token, p.expires = token, time.Now().Add(petTTL)
+	return token, nil
+}
@@ -0,0 +1,44 @@
+package anvil
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/pathmap"
+	"github.com/calypr/git-drs/internal/projectdir"
+)
+
+// RecordedIDs looks up the DRS IDs this repository recorded for remote's
+// objects: the object add-ref staged for the oid, or, in a clone that never
+// staged it, the .drs/map entry committed for remote.
+func RecordedIDs(remote string) IDLookup {
+	var (
+		once   sync.Once
+		mapped map[string]string
+	)
+	return func(oid string) (string, bool) {
+		oid = drsobject.NormalizeOid(oid)
+		if obj, err := drsobject.ReadObject(common.DRS_OBJS_PATH, oid); err == nil && obj != nil && strings.HasPrefix(obj.Id, "drs://") {
+			return obj.Id, true
+		}
+		once.Do(func() {
+			mapped = map[string]string{}
+			root, err := projectdir.Root()
+			if err != nil {
+				return
+			}
+			_ = pathmap.Walk(root, func(shard pathmap.Map) error {
+				for _, entry := range shard {
+					if entry.Remote == remote && entry.DRSID != "" {
+						mapped[entry.OID] = entry.DRSID
+					}
+				}
+				return nil
+			})
+		})
+		id, ok := mapped[oid]
+		return id, ok
+	}
+}
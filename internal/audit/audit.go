@@ -0,0 +1,279 @@
+// Package audit keeps an append-only, hash-chained record of every DRS
+// registration, update, and deletion git-drs performs, under .drs/audit in
+// the repository so it can be committed and reviewed independently of server
+// logs.
+//
+// Each line of the log is a JSON Event. Hash is the sha256 of the event with
+// Hash cleared, and PrevHash is the Hash of the preceding line, so editing or
+// removing any line breaks the chain from that point on (see Verify). When
+// two branches both append, git drs merge-driver re-chains the other
+// branch's events after ours (see Merge).
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dir is the repo-relative directory holding the audit log.
+const Dir = ".drs/audit"
+
+// FileName is the log file inside Dir.
+const FileName = "log.jsonl"
+
+// Actions recorded in the log.
+const (
+	ActionRegister = "register"
+	ActionUpdate   = "update"
+	ActionDelete   = "delete"
+	ActionRestore  = "restore"
+)
+
+// Event is one audit log line.
+type Event struct {
+	Seq      int64  `json:"seq"`
+	Time     string `json:"time"`
+	Action   string `json:"action"`
+	User     string `json:"user"`
+	Remote   string `json:"remote,omitempty"`
+	Project  string `json:"project,omitempty"`
+	DRSID    string `json:"drs_id,omitempty"`
+	OID      string `json:"oid,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Commit   string `json:"commit,omitempty"`
+	Branch   string `json:"branch,omitempty"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+var (
+	mu sync.Mutex
+	// now and gitOutput are swapped in tests.
+	now       = time.Now
+	gitOutput = func(args ...string) (string, error) {
+		out, err := exec.Command("git", args...).Output()
+		return strings.TrimSpace(string(out)), err
+	}
+)
+
+// Path returns the audit log path for the current repository.
+func Path() (string, error) {
+	root, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil || root == "" {
+		return "", fmt.Errorf("audit: not in a git repository")
+	}
+	return filepath.Join(root, Dir, FileName), nil
+}
+
+// Record appends events to the current repository's audit log, filling in
+// the acting user, git commit context, time, and chain hashes.
+func Record(events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	return Append(path, events...)
+}
+
+// RecordOrWarn records events and logs, rather than returns, any failure.
+// The server-side change has already happened by the time it is audited, so
+// failing the command would only hide that it succeeded.
+func RecordOrWarn(logger *slog.Logger, events ...Event) {
+	if err := Record(events...); err != nil && logger != nil {
+		logger.Warn("failed to write audit log", "error", err)
+	}
+}
+
+// Append chains events onto the log at path.
+func Append(path string, events ...Event) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	last, err := lastEvent(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("audit: mkdir: %w", err)
+	}
+	if err := ensureAttributes(filepath.Dir(path)); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("audit: open log: %w", err)
+	}
+	defer f.Close()
+
+	actor := ActingUser()
+	commit, _ := gitOutput("rev-parse", "HEAD")
+	branch, _ := gitOutput("rev-parse", "--abbrev-ref", "HEAD")
+	for _, ev := range events {
+		ev.Seq = last.Seq + 1
+		ev.PrevHash = last.Hash
+		if ev.Time == "" {
+			ev.Time = now().UTC().Format(time.RFC3339Nano)
+		}
+		if ev.User == "" {
+			ev.User = actor
+		}
+		if ev.Commit == "" {
+			ev.Commit = commit
+		}
+		if ev.Branch == "" {
+			ev.Branch = branch
+		}
+		ev.Hash, err = eventHash(ev)
+		if err != nil {
+			return err
+		}
+		line, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("audit: write log: %w", err)
+		}
+		last = ev
+	}
+	return f.Sync()
+}
+
+// Verify walks the log at path and checks every hash and link. It returns
+// the number of valid events and an error naming the first broken line.
+func Verify(path string) (int, error) {
+	var (
+		count int
+		prev  Event
+	)
+	err := scan(path, func(lineNo int, ev Event) error {
+		if err := checkLink(lineNo, prev, ev); err != nil {
+			return err
+		}
+		prev = ev
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// verifyChain is Verify for events already in memory, numbering them as
+// lines of the log.
+func verifyChain(events []Event) (int, error) {
+	var prev Event
+	for i, ev := range events {
+		if err := checkLink(i+1, prev, ev); err != nil {
+			return i, err
+		}
+		prev = ev
+	}
+	return len(events), nil
+}
+
+func checkLink(lineNo int, prev, ev Event) error {
+	want, err := eventHash(ev)
+	if err != nil {
+		return err
+	}
+	if ev.Hash != want {
+		return fmt.Errorf("line %d: hash mismatch (event was modified)", lineNo)
+	}
+	if ev.PrevHash != prev.Hash || ev.Seq != prev.Seq+1 {
+		return fmt.Errorf("line %d: chain broken (event inserted, removed, or reordered)", lineNo)
+	}
+	return nil
+}
+
+// Read returns every event in the log at path without verifying it.
+func Read(path string) ([]Event, error) {
+	var events []Event
+	err := scan(path, func(_ int, ev Event) error {
+		events = append(events, ev)
+		return nil
+	})
+	return events, err
+}
+
+func lastEvent(path string) (Event, error) {
+	var last Event
+	err := scan(path, func(_ int, ev Event) error {
+		last = ev
+		return nil
+	})
+	return last, err
+}
+
+func scan(path string, fn func(lineNo int, ev Event) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("audit: open log: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal(line, &ev); err != nil {
+			return fmt.Errorf("line %d: parse: %w", lineNo, err)
+		}
+		if err := fn(lineNo, ev); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+func eventHash(ev Event) (string, error) {
+	ev.Hash = ""
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ActingUser returns the identity recorded for an action, preferring the
+// git identity and falling back to the OS account.
+func ActingUser() string {
+	name, _ := gitOutput("config", "user.name")
+	email, _ := gitOutput("config", "user.email")
+	switch {
+	case name != "" && email != "":
+		return fmt.Sprintf("%s <%s>", name, email)
+	case email != "":
+		return email
+	case name != "":
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "unknown"
+}
@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func stubGit(t *testing.T) {
+	t.Helper()
+	origGit, origNow := gitOutput, now
+	t.Cleanup(func() { gitOutput, now = origGit, origNow })
+	gitOutput = func(args ...string) (string, error) {
+		switch strings.Join(args, " ") {
+		case "config user.name":
+			return "Ada", nil
+		case "config user.email":
+			return "ada@example.org", nil
+		case "rev-parse HEAD":
+			return "abc123", nil
+		case "rev-parse --abbrev-ref HEAD":
+			return "main", nil
+		}
+		return "", nil
+	}
+	now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+}
+
+func TestAppendChainsAndVerifies(t *testing.T) {
+	stubGit(t)
+	path := filepath.Join(t.TempDir(), Dir, FileName)
+
+	if err := Append(path, Event{Action: ActionRegister, Remote: "origin", DRSID: "d1", OID: "o1"}); err != nil {
+		t.Fatalf("Append error: %v", err)
+	}
+	if err := Append(path, Event{Action: ActionDelete, Remote: "origin", DRSID: "d1"}); err != nil {
+		t.Fatalf("Append error: %v", err)
+	}
+
+	events, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].User != "Ada <ada@example.org>" || events[0].Commit != "abc123" || events[0].Branch != "main" {
+		t.Fatalf("unexpected context: %#v", events[0])
+	}
+	if events[1].PrevHash != events[0].Hash || events[1].Seq != 2 {
+		t.Fatalf("events not chained: %#v", events)
+	}
+	if n, err := Verify(path); err != nil || n != 2 {
+		t.Fatalf("Verify = %d, %v", n, err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	stubGit(t)
+	path := filepath.Join(t.TempDir(), FileName)
+	for _, id := range []string{"d1", "d2", "d3"} {
+		if err := Append(path, Event{Action: ActionRegister, DRSID: id}); err != nil {
+			t.Fatalf("Append error: %v", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited := strings.Replace(string(data), `"drs_id":"d2"`, `"drs_id":"dX"`, 1)
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := Verify(path); err == nil || n != 1 {
+		t.Fatalf("expected modification detected at event 2, got n=%d err=%v", n, err)
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	removed := lines[0] + lines[2]
+	if err := os.WriteFile(path, []byte(removed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(path); err == nil || !strings.Contains(err.Error(), "chain broken") {
+		t.Fatalf("expected removal detected, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// attributes routes log merges through git drs merge-driver. It lives next to
+// the log so the setting travels with it.
+const attributes = FileName + " merge=drs\n"
+
+// IsLogPath reports whether the repo-relative path names the audit log, so
+// the merge driver can tell it apart from map shards.
+func IsLogPath(path string) bool {
+	return filepath.ToSlash(filepath.Clean(path)) == Dir+"/"+FileName
+}
+
+// Merge joins two branches of the log that share base. Events each side
+// added after base are kept, ours first, and theirs are re-chained onto the
+// end so the result verifies. Both sides must verify and extend base;
+// re-chaining an edited log would otherwise launder the edit. An event added
+// on both sides, such as a cherry-picked commit's, is kept once.
+func Merge(base, ours, theirs []Event) ([]Event, error) {
+	for _, side := range []struct {
+		name   string
+		events []Event
+	}{{"base", base}, {"ours", ours}, {"theirs", theirs}} {
+		if _, err := verifyChain(side.events); err != nil {
+			return nil, fmt.Errorf("%s: %w", side.name, err)
+		}
+	}
+	if !hasPrefix(ours, base) || !hasPrefix(theirs, base) {
+		return nil, fmt.Errorf("audit: log history diverged before the merge base")
+	}
+
+	merged := append([]Event(nil), ours...)
+	seen := map[string]bool{}
+	for _, ev := range ours[len(base):] {
+		key, err := contentKey(ev)
+		if err != nil {
+			return nil, err
+		}
+		seen[key] = true
+	}
+	last := Event{}
+	if len(merged) > 0 {
+		last = merged[len(merged)-1]
+	}
+	for _, ev := range theirs[len(base):] {
+		key, err := contentKey(ev)
+		if err != nil {
+			return nil, err
+		}
+		if seen[key] {
+			continue
+		}
+		ev.Seq = last.Seq + 1
+		ev.PrevHash = last.Hash
+		if ev.Hash, err = eventHash(ev); err != nil {
+			return nil, err
+		}
+		merged = append(merged, ev)
+		last = ev
+	}
+	return merged, nil
+}
+
+// MergeFiles merges the three log versions git passes a merge driver and
+// writes the result over ours.
+func MergeFiles(basePath, oursPath, theirsPath string) error {
+	var sides [3][]Event
+	for i, path := range []string{basePath, oursPath, theirsPath} {
+		events, err := Read(path)
+		if err != nil {
+			return err
+		}
+		sides[i] = events
+	}
+	merged, err := Merge(sides[0], sides[1], sides[2])
+	if err != nil {
+		return err
+	}
+	return writeLog(oursPath, merged)
+}
+
+func writeLog(path string, events []Event) error {
+	var data []byte
+	for _, ev := range events {
+		line, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("audit: write log: %w", err)
+	}
+	return nil
+}
+
+// ensureAttributes writes the .gitattributes beside the log once.
+func ensureAttributes(dir string) error {
+	path := filepath.Join(dir, ".gitattributes")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(attributes), 0o644); err != nil {
+		return fmt.Errorf("audit: write attributes: %w", err)
+	}
+	return nil
+}
+
+func hasPrefix(events, prefix []Event) bool {
+	if len(prefix) > len(events) {
+		return false
+	}
+	for i := range prefix {
+		if events[i].Hash != prefix[i].Hash {
+			return false
+		}
+	}
+	return true
+}
+
+// contentKey identifies an event by what it records, ignoring its position
+// in the chain.
+func contentKey(ev Event) (string, error) {
+	ev.Seq, ev.PrevHash = 0, ""
+	return eventHash(ev)
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeRefusesTamperedSide(t *testing.T) {
+	stubGit(t)
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := Append(path, Event{Action: ActionRegister, DRSID: "d1"}, Event{Action: ActionRegister, DRSID: "d2"}); err != nil {
+		t.Fatal(err)
+	}
+	events, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := events[:1]
+	tampered := append([]Event(nil), events...)
+	tampered[1].DRSID = "dX"
+
+	if _, err := Merge(base, events, tampered); err == nil || !strings.Contains(err.Error(), "theirs") {
+		t.Fatalf("expected tampered theirs to be refused, got %v", err)
+	}
+	if _, err := Merge(events, events[:1], events); err == nil {
+		t.Fatal("expected a side that does not extend base to be refused")
+	}
+}
+
+func TestMergeKeepsSharedEventOnce(t *testing.T) {
+	stubGit(t)
+	dir := t.TempDir()
+	ours := filepath.Join(dir, "ours")
+	theirs := filepath.Join(dir, "theirs")
+	shared := Event{Action: ActionRegister, DRSID: "picked"}
+	if err := Append(ours, shared, Event{Action: ActionDelete, DRSID: "d1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Append(theirs, shared); err != nil {
+		t.Fatal(err)
+	}
+	oursEvents, _ := Read(ours)
+	theirsEvents, _ := Read(theirs)
+
+	merged, err := Merge(nil, oursEvents, theirsEvents)
+	if err != nil {
+		t.Fatalf("Merge error: %v", err)
+	}
+	if len(merged) != 2 {
+		t.Fatalf("expected shared event once, got %#v", merged)
+	}
+	if n, err := verifyChain(merged); err != nil || n != 2 {
+		t.Fatalf("verifyChain = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AtomicTempPrefix starts the names of in-flight WriteFileAtomic temp files,
+// so scans of a directory can recognize and skip or clean them up.
+const AtomicTempPrefix = ".tmp-"
+
+// WriteFileAtomic writes data to path so that readers see either the old
+// content or the new content, never a partial file: the data goes to a
+// uniquely named temp file in the same directory, is fsynced, and is renamed
+// over path. Parent directories are created as needed.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create directory for %s: %w", path, err)
+	}
+	tmp, err := os.CreateTemp(dir, AtomicTempPrefix+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("create temp file for %s: %w", path, err)
+	}
+	tmpName := tmp.Name()
+	cleanup := func(err error) error {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return cleanup(err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	// Persist the rename itself. Directories cannot be synced everywhere
+	// (for example on Windows), so this is best effort.
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+	return nil
+}
+
+// IsAtomicTempFile reports whether name is a WriteFileAtomic temp file.
+func IsAtomicTempFile(name string) bool {
+	return strings.HasPrefix(filepath.Base(name), AtomicTempPrefix)
+}
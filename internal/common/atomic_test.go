@@ -0,0 +1,43 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a", "b", "state.json")
+	if err := WriteFileAtomic(path, []byte("one"), 0o600); err != nil {
+		t.Fatalf("WriteFileAtomic error: %v", err)
+	}
+	if err := WriteFileAtomic(path, []byte("two"), 0o600); err != nil {
+		t.Fatalf("WriteFileAtomic overwrite error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "two" {
+		t.Fatalf("expected overwritten content, got %q err=%v", data, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	for _, e := range entries {
+		if IsAtomicTempFile(e.Name()) {
+			t.Fatalf("temp file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestIsAtomicTempFile(t *testing.T) {
+	if !IsAtomicTempFile("/x/.tmp-state.json-123") {
+		t.Fatal("expected temp file to be recognized")
+	}
+	if IsAtomicTempFile("/x/state.json") {
+		t.Fatal("expected regular file not to match")
+	}
+}
@@ -54,3 +54,13 @@ func TestCalculateFileSHA256(t *testing.T) {
 		}
 	})
 }
+
+func TestWriteJSON(t *testing.T) {
+	var out strings.Builder
+	if err := WriteJSON(&out, map[string]int{"files": 2}); err != nil {
+		t.Fatalf("WriteJSON error: %v", err)
+	}
+	if got := out.String(); got != "{\n  \"files\": 2\n}\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
@@ -0,0 +1,24 @@
+package common
+
+import (
+	"io"
+	"sync"
+)
+
+// copyBufferSize matches the buffer io.Copy allocates for itself.
+const copyBufferSize = 32 * 1024
+
+var copyBuffers = sync.Pool{
+	New: func() any {
+		buf := make([]byte, copyBufferSize)
+		return &buf
+	},
+}
+
+// Copy is io.Copy with a pooled buffer, so transfers of many objects reuse a
+// few buffers instead of allocating one per object.
+func Copy(dst io.Writer, src io.Reader) (int64, error) {
+	buf := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(buf)
+	return io.CopyBuffer(dst, src, *buf)
+}
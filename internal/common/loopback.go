@@ -0,0 +1,35 @@
+package common
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+)
+
+// RequireLoopback rejects listen addresses reachable from other hosts. Local
+// servers such as git drs serve and git drs mount act with the user's
+// credentials and must only be reachable from this machine.
+func RequireLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid --listen address %q: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("--listen address %q is not a loopback address", addr)
+}
+
+// RandomToken returns 32 random bytes, hex encoded, for use as a local
+// server credential.
+func RandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
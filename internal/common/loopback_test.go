@@ -0,0 +1,16 @@
+package common
+
+import "testing"
+
+func TestRequireLoopback(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:0", "localhost:8080", "[::1]:9000"} {
+		if err := RequireLoopback(addr); err != nil {
+			t.Errorf("RequireLoopback(%q) = %v", addr, err)
+		}
+	}
+	for _, addr := range []string{"0.0.0.0:8080", ":8080", "192.168.1.5:80", "nonsense"} {
+		if err := RequireLoopback(addr); err == nil {
+			t.Errorf("RequireLoopback(%q) accepted a non-loopback address", addr)
+		}
+	}
+}
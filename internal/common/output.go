@@ -0,0 +1,28 @@
+package common
+
+import (
+	"encoding/json"
+	"io"
+	"sync/atomic"
+)
+
+// jsonOutput is set from the root --json flag before any command runs.
+var jsonOutput atomic.Bool
+
+// SetJSONOutput switches commands between human-formatted and JSON output.
+func SetJSONOutput(enabled bool) {
+	jsonOutput.Store(enabled)
+}
+
+// JSONOutput reports whether commands should write a JSON document to stdout
+// instead of human-formatted text. Logs and progress still go to stderr.
+func JSONOutput() bool {
+	return jsonOutput.Load()
+}
+
+// WriteJSON writes v to w as a single indented JSON document.
+func WriteJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
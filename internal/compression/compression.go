@@ -0,0 +1,214 @@
+// Package compression stores chosen file types compressed in the bucket and
+// restores them on download.
+//
+// The DRS record keeps the original sha256 and size, so lookups, dedup, and
+// the LFS oid are unchanged; a metadata block on the record names the
+// encoding and the hash and size of the stored bytes. Compression is
+// deterministic for a given encoding and compressor version; a re-upload
+// whose bytes differ is checked to decompress to the original content, and
+// the block is updated to describe the new bytes.
+package compression
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content encodings, named as in HTTP Content-Encoding.
+const (
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+// Settings selects which files are compressed before upload and how.
+type Settings struct {
+	// Encoding is Gzip or Zstd.
+	Encoding string
+	// Extensions lists lower-case file name suffixes, such as ".vcf".
+	Extensions []string
+}
+
+// Enabled reports whether any file type is compressed.
+func (s Settings) Enabled() bool {
+	return len(s.Extensions) > 0
+}
+
+// Applies reports whether a file at the repo-relative name is compressed.
+func (s Settings) Applies(name string) bool {
+	base := strings.ToLower(path.Base(name))
+	for _, ext := range s.Extensions {
+		if strings.HasSuffix(base, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseEncoding checks a drs.compress.encoding value.
+func ParseEncoding(raw string) (string, error) {
+	switch enc := strings.ToLower(strings.TrimSpace(raw)); enc {
+	case "", Gzip:
+		return Gzip, nil
+	case Zstd:
+		return Zstd, nil
+	default:
+		return "", fmt.Errorf("invalid content encoding %q: expected %s or %s", raw, Gzip, Zstd)
+	}
+}
+
+// ParseExtensions splits a comma- or space-separated list of suffixes,
+// adding the leading dot when it is missing.
+func ParseExtensions(raw string) []string {
+	var out []string
+	for _, ext := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if ext != "." {
+			out = append(out, ext)
+		}
+	}
+	return out
+}
+
+// Metadata is the content-encoding block stored on a DRS record.
+type Metadata struct {
+	Encoding     string `json:"encoding"`
+	StoredSHA256 string `json:"stored_sha256"`
+	StoredSize   int64  `json:"stored_size"`
+}
+
+// Compress reads content from r and writes it to w in encoding.
+func Compress(w io.Writer, r io.Reader, encoding string) (Metadata, error) {
+	sum := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(w, sum)}
+	var (
+		enc io.WriteCloser
+		err error
+	)
+	switch encoding {
+	case Gzip:
+		// A zero header, with no name or modification time, keeps the
+		// output the same for the same content.
+		enc, err = gzip.NewWriterLevel(counter, gzip.DefaultCompression)
+	case Zstd:
+		enc, err = zstd.NewWriter(counter, zstd.WithEncoderConcurrency(1))
+	default:
+		err = fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+	if err != nil {
+		return Metadata{}, err
+	}
+	if _, err := io.Copy(enc, r); err != nil {
+		_ = enc.Close()
+		return Metadata{}, err
+	}
+	if err := enc.Close(); err != nil {
+		return Metadata{}, err
+	}
+	return Metadata{Encoding: encoding, StoredSHA256: hex.EncodeToString(sum.Sum(nil)), StoredSize: counter.n}, nil
+}
+
+// Decompress reads stored bytes described by meta from r and writes the
+// original content to w. A stored hash mismatch is an error; content already
+// written to w must then be discarded by the caller.
+func Decompress(w io.Writer, r io.Reader, meta Metadata) error {
+	sum := sha256.New()
+	tee := io.TeeReader(r, sum)
+	var dec io.Reader
+	switch meta.Encoding {
+	case Gzip:
+		gz, err := gzip.NewReader(tee)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		dec = gz
+	case Zstd:
+		zr, err := zstd.NewReader(tee, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		dec = zr
+	default:
+		return fmt.Errorf("unsupported content encoding %q", meta.Encoding)
+	}
+	if _, err := io.Copy(w, dec); err != nil {
+		return fmt.Errorf("decompress %s: %w", meta.Encoding, err)
+	}
+	// Hash any trailing bytes the decoder left unread.
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(sum.Sum(nil)); meta.StoredSHA256 != "" && got != meta.StoredSHA256 {
+		return fmt.Errorf("stored sha256 %s does not match metadata %s", got, meta.StoredSHA256)
+	}
+	return nil
+}
+
+// CompressFile compresses src into dst, replacing dst.
+func CompressFile(encoding, src, dst string) (Metadata, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return Metadata{}, err
+	}
+	meta, err := Compress(out, in, encoding)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(dst)
+		return Metadata{}, fmt.Errorf("compress %s: %w", src, err)
+	}
+	return meta, nil
+}
+
+// DecompressFile decompresses src into dst, replacing dst, and returns the
+// sha256 of the original content. dst is removed if decompression fails.
+func DecompressFile(meta Metadata, src, dst string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.New()
+	err = Decompress(io.MultiWriter(out, sum), in, meta)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(dst)
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
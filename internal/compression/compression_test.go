@@ -0,0 +1,102 @@
+package compression
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"testing"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestCompressDecompressRoundTrip(t *testing.T) {
+	plain := []byte(strings.Repeat("chr1\t10177\t.\tA\tAC\t100\tPASS\t.\n", 500))
+	for _, encoding := range []string{Gzip, Zstd} {
+		var stored bytes.Buffer
+		meta, err := Compress(&stored, bytes.NewReader(plain), encoding)
+		if err != nil {
+			t.Fatalf("%s: Compress error: %v", encoding, err)
+		}
+		sum := sha256.Sum256(stored.Bytes())
+		if meta.Encoding != encoding || meta.StoredSize != int64(stored.Len()) || meta.StoredSHA256 != hex.EncodeToString(sum[:]) {
+			t.Fatalf("%s: metadata %+v does not describe stored bytes", encoding, meta)
+		}
+		if meta.StoredSize >= int64(len(plain)) {
+			t.Fatalf("%s: stored %d bytes for %d of text", encoding, meta.StoredSize, len(plain))
+		}
+
+		var again bytes.Buffer
+		if second, err := Compress(&again, bytes.NewReader(plain), encoding); err != nil || second != meta {
+			t.Fatalf("%s: compression is not deterministic: %+v vs %+v (err %v)", encoding, second, meta, err)
+		}
+
+		var out bytes.Buffer
+		if err := Decompress(&out, bytes.NewReader(stored.Bytes()), meta); err != nil {
+			t.Fatalf("%s: Decompress error: %v", encoding, err)
+		}
+		if !bytes.Equal(out.Bytes(), plain) {
+			t.Fatalf("%s: content mismatch", encoding)
+		}
+
+		meta.StoredSHA256 = strings.Repeat("0", 64)
+		if err := Decompress(&bytes.Buffer{}, bytes.NewReader(stored.Bytes()), meta); err == nil {
+			t.Fatalf("%s: expected stored hash mismatch to fail", encoding)
+		}
+	}
+}
+
+func TestSettingsApplies(t *testing.T) {
+	s := Settings{Encoding: Gzip, Extensions: ParseExtensions("vcf, .BED .vcf.gz,")}
+	if !slices.Equal(s.Extensions, []string{".vcf", ".bed", ".vcf.gz"}) {
+		t.Fatalf("unexpected extensions %v", s.Extensions)
+	}
+	for name, want := range map[string]bool{
+		"data/calls.VCF":  true,
+		"regions.bed":     true,
+		"calls.vcf.gz":    true,
+		"reads.bam":       false,
+		"vcf/readme.txt":  false,
+		"data/bed":        false,
+		"notes.vcf.bak":   false,
+		"sub/dir/x.bed":   true,
+		"plainvcf":        false,
+		"calls.vcf.gz.md": false,
+	} {
+		if got := s.Applies(name); got != want {
+			t.Errorf("Applies(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if (Settings{}).Enabled() {
+		t.Fatal("expected empty settings to be disabled")
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	for raw, want := range map[string]string{"": Gzip, "gzip": Gzip, " ZSTD ": Zstd} {
+		if got, err := ParseEncoding(raw); err != nil || got != want {
+			t.Fatalf("ParseEncoding(%q) = %q, %v", raw, got, err)
+		}
+	}
+	if _, err := ParseEncoding("brotli"); err == nil {
+		t.Fatal("expected unsupported encoding to be rejected")
+	}
+}
+
+func TestObjectMetadataRoundTrip(t *testing.T) {
+	aliases := []string{"sample-1"}
+	obj := &drsapi.DrsObject{Aliases: &aliases}
+	if _, ok := FromObject(obj); ok {
+		t.Fatal("expected no block on a plain record")
+	}
+	SetOnObject(obj, Metadata{Encoding: Gzip, StoredSHA256: "aa", StoredSize: 1})
+	SetOnObject(obj, Metadata{Encoding: Zstd, StoredSHA256: "bb", StoredSize: 2})
+	meta, ok := FromObject(obj)
+	if !ok || meta != (Metadata{Encoding: Zstd, StoredSHA256: "bb", StoredSize: 2}) {
+		t.Fatalf("unexpected block %+v (ok %v)", meta, ok)
+	}
+	if len(*obj.Aliases) != 2 || !slices.Contains(*obj.Aliases, "sample-1") {
+		t.Fatalf("expected one block and the user alias, got %v", *obj.Aliases)
+	}
+}
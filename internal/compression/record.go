@@ -0,0 +1,20 @@
+package compression
+
+import (
+	"github.com/calypr/git-drs/internal/drsobject"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// AliasPrefix marks the alias that carries an object's Metadata as JSON
+// (see drsobject.AliasMetadata).
+const AliasPrefix = "git-drs-content-encoding:"
+
+// FromObject returns the content-encoding block on obj, if it has one.
+func FromObject(obj *drsapi.DrsObject) (Metadata, bool) {
+	return drsobject.AliasMetadata(obj, AliasPrefix, func(meta Metadata) bool { return meta.Encoding != "" })
+}
+
+// SetOnObject records meta on obj, replacing any earlier block.
+func SetOnObject(obj *drsapi.DrsObject, meta Metadata) {
+	drsobject.SetAliasMetadata(obj, AliasPrefix, meta)
+}
@@ -0,0 +1,20 @@
+package config
+
+import (
+	"github.com/calypr/git-drs/internal/accesspolicy"
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+// AccessPolicySettings reads drs.access.* from git config. An unparsable
+// preference list is ignored so downloads keep working in record order.
+func AccessPolicySettings() accesspolicy.Policy {
+	raw, _ := gitrepo.GetGitConfigString("drs.access.prefer")
+	prefs, err := accesspolicy.ParsePreferences(raw)
+	if err != nil {
+		prefs = nil
+	}
+	return accesspolicy.Policy{
+		Prefer: prefs,
+		Probe:  gitrepo.GetGitConfigBool("drs.access.probe", false),
+	}
+}
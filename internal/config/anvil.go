@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/calypr/git-drs/internal/anvil"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/httpclient"
+	"github.com/calypr/git-drs/internal/signedurl"
+	syclient "github.com/calypr/syfon/client"
+	syconf "github.com/calypr/syfon/client/config"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// anvilScopes are requested for Application Default Credentials; DRSHub
+// and SAM identify the user by email.
+var anvilScopes = []string{
+	"openid",
+	"https://www.googleapis.com/auth/userinfo.email",
+	"https://www.googleapis.com/auth/userinfo.profile",
+	"https://www.googleapis.com/auth/devstorage.read_only",
+}
+
+// AnvilRemote resolves AnVIL/Terra DRS URIs through DRSHub (or a Martha
+// endpoint), stored as the remote's endpoint. It reads only: objects are
+// referenced with git drs add-ref and downloaded by pull and fetch. Records
+// are scoped to Organization/ProjectID.
+type AnvilRemote struct {
+	Endpoint     string
+	SAM          string
+	DRSHost      string
+	ProjectID    string
+	Organization string
+	// BillingProject is the Google project requester-pays reads are
+	// billed to.
+	BillingProject string
+}
+
+func (a AnvilRemote) GetProjectId() string     { return a.ProjectID }
+func (a AnvilRemote) GetOrganization() string  { return a.Organization }
+func (a AnvilRemote) GetEndpoint() string      { return firstNonEmpty(a.Endpoint, anvil.DefaultDRSHub) }
+func (a AnvilRemote) GetBucketName() string    { return "" }
+func (a AnvilRemote) GetStoragePrefix() string { return "" }
+
+// GetClient returns a client whose DRS requests are resolved through
+// DRSHub with the user's Google credentials.
+func (a AnvilRemote) GetClient(remoteName string, logger *slog.Logger) (*GitContext, error) {
+	if a.ProjectID == "" || a.Organization == "" {
+		return nil, fmt.Errorf("anvil remote %q needs an organization and project", remoteName)
+	}
+	transport, err := httpclient.ForRemote(remoteName+"\x00"+a.GetEndpoint(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var base http.RoundTripper = transport
+	if drslog.TraceEnabled() {
+		base = drslog.Transport(base)
+	}
+	resolver := &anvil.Client{
+		DRSHub:         a.Endpoint,
+		SAM:            a.SAM,
+		DRSHost:        a.DRSHost,
+		BillingProject: a.BillingProject,
+		Organization:   a.Organization,
+		Project:        a.ProjectID,
+		Token:          anvilToken(remoteName),
+		Lookup:         anvil.RecordedIDs(remoteName),
+		Base:           base,
+	}
+	raw, err := syclient.New(anvil.BaseURL, syclient.WithHTTPClient(&http.Client{Transport: resolver}))
+	if err != nil {
+		return nil, err
+	}
+	client, ok := raw.(*syclient.Client)
+	if !ok {
+		return nil, fmt.Errorf("unexpected syfon client type %T", raw)
+	}
+
+	tuning := loadTransferTuning()
+	return &GitContext{
+		Client:              client,
+		SignedURLs:          signedurl.NewCache(),
+		Organization:        a.Organization,
+		ProjectId:           a.ProjectID,
+		MultiPartThreshold:  tuning.MultiPartThreshold,
+		UploadConcurrency:   tuning.UploadConcurrency,
+		DownloadConcurrency: tuning.DownloadConcurrency,
+		Logger:              logger,
+		Credential:          &syconf.Credential{APIEndpoint: anvil.BaseURL},
+		AccessPolicy:        AccessPolicySettings(),
+	}, nil
+}
+
+// anvilToken returns the user's Google access token: a static token from
+// GIT_DRS_REMOTE_<NAME>_TOKEN or drs.remote.<name>.token, as printed by
+// 'gcloud auth print-access-token', or else one from Application Default
+// Credentials, refreshed as it expires.
+func anvilToken(remoteName string) anvil.TokenSource {
+	if token := strings.TrimSpace(os.Getenv(RemoteAuthEnv(remoteName, "TOKEN"))); token != "" {
+		return func(context.Context) (string, error) { return token, nil }
+	}
+	if token, err := gitrepo.GetRemoteToken(remoteName); err == nil && strings.TrimSpace(token) != "" {
+		token = strings.TrimSpace(token)
+		return func(context.Context) (string, error) { return token, nil }
+	}
+	var (
+		mu  sync.Mutex
+		src oauth2.TokenSource
+	)
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if src == nil {
+			s, err := google.DefaultTokenSource(ctx, anvilScopes...)
+			if err != nil {
+				return "", fmt.Errorf("%w; run 'gcloud auth application-default login' or set %s", err, RemoteAuthEnv(remoteName, "TOKEN"))
+			}
+			src = s
+		}
+		t, err := src.Token()
+		if err != nil {
+			return "", err
+		}
+		return t.AccessToken, nil
+	}
+}
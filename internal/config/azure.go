@@ -0,0 +1,173 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/httpclient"
+	"github.com/calypr/git-drs/internal/throttle"
+)
+
+// Credential modes accepted by drs.remote.<name>.azure-credential.
+const (
+	// AzureCredentialDefault authenticates with the Azure default chain:
+	// service principal environment variables, workload identity, managed
+	// identity, and the Azure CLI login.
+	AzureCredentialDefault = "default"
+	// AzureCredentialSharedKey signs requests with the storage account key
+	// in AZURE_STORAGE_KEY.
+	AzureCredentialSharedKey = "shared-key"
+	// AzureCredentialSAS appends the SAS token in AZURE_STORAGE_SAS_TOKEN to
+	// every request.
+	AzureCredentialSAS = "sas"
+)
+
+// AzureSettings are the per-remote options for Azure Blob Storage. Secrets
+// are read from the environment, never from git config.
+type AzureSettings struct {
+	// Account is the storage account that az:// URLs, which name only the
+	// container, resolve against.
+	Account string
+	// Endpoint replaces https://<account>.blob.core.windows.net, for
+	// sovereign clouds, private endpoints, and the Azurite emulator.
+	Endpoint   string
+	Credential string
+}
+
+// AzureObject is a blob located by an az://, azblob:// or
+// https://<account>.blob.core.windows.net URL.
+type AzureObject struct {
+	// Account is empty for az:// URLs, which leave it to the remote's
+	// settings.
+	Account   string
+	Container string
+	Key       string
+}
+
+// LoadAzureSettings reads drs.remote.<remote>.azure-account, azure-endpoint
+// and azure-credential from git config. The account falls back to
+// AZURE_STORAGE_ACCOUNT and the credential defaults to the Azure default
+// chain.
+func LoadAzureSettings(remote string) (AzureSettings, error) {
+	account, _ := gitrepo.GetGitConfigString(fmt.Sprintf("drs.remote.%s.azure-account", remote))
+	endpoint, _ := gitrepo.GetGitConfigString(fmt.Sprintf("drs.remote.%s.azure-endpoint", remote))
+	credential, _ := gitrepo.GetGitConfigString(fmt.Sprintf("drs.remote.%s.azure-credential", remote))
+	s := AzureSettings{
+		Account:    firstNonEmpty(account, os.Getenv("AZURE_STORAGE_ACCOUNT")),
+		Endpoint:   strings.TrimRight(strings.TrimSpace(endpoint), "/"),
+		Credential: strings.ToLower(firstNonEmpty(credential, AzureCredentialDefault)),
+	}
+	switch s.Credential {
+	case AzureCredentialDefault, AzureCredentialSharedKey, AzureCredentialSAS:
+		return s, nil
+	}
+	return AzureSettings{}, fmt.Errorf("invalid drs.remote.%s.azure-credential %q: expected %s, %s, or %s",
+		remote, s.Credential, AzureCredentialDefault, AzureCredentialSharedKey, AzureCredentialSAS)
+}
+
+// ParseAzureURL locates the blob raw names. az:// and azblob:// URLs are
+// az://<container>/<key>; azblob:// may name the account in an account_name
+// query parameter, as gocloud URLs do. The key may be empty.
+func ParseAzureURL(raw string) (AzureObject, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return AzureObject{}, false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "az", "azblob":
+		return AzureObject{
+			Account:   strings.TrimSpace(u.Query().Get("account_name")),
+			Container: u.Host,
+			Key:       strings.TrimPrefix(u.Path, "/"),
+		}, true
+	case "https", "http":
+		host := strings.ToLower(u.Hostname())
+		if !strings.HasSuffix(host, ".blob.core.windows.net") {
+			return AzureObject{}, false
+		}
+		container, key, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+		if container == "" {
+			return AzureObject{}, false
+		}
+		return AzureObject{
+			Account:   strings.TrimSuffix(host, ".blob.core.windows.net"),
+			Container: container,
+			Key:       key,
+		}, true
+	}
+	return AzureObject{}, false
+}
+
+// AzureContainer returns the container a remote's bucket names when it is an
+// az:// or azblob:// URL, which marks the remote's storage as Azure.
+func AzureContainer(bucket string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(bucket))
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "az", "azblob":
+		return u.Host, true
+	}
+	return "", false
+}
+
+// ServiceURL returns the blob service URL for account, or for the
+// configured account when account is empty.
+func (s AzureSettings) ServiceURL(account string) (string, error) {
+	if s.Endpoint != "" {
+		return s.Endpoint + "/", nil
+	}
+	if account = firstNonEmpty(account, s.Account); account == "" {
+		return "", errors.New("no Azure storage account: set drs.remote.<remote>.azure-account or AZURE_STORAGE_ACCOUNT")
+	}
+	return fmt.Sprintf("https://%s.blob.core.windows.net/", account), nil
+}
+
+// NewAzureClient builds a blob service client for account, or for the
+// remote's account when account is empty, authenticated as s.Credential
+// selects. Requests use the drs.http settings and the transfer limiter.
+func NewAzureClient(s AzureSettings, account string) (*azblob.Client, error) {
+	serviceURL, err := s.ServiceURL(account)
+	if err != nil {
+		return nil, err
+	}
+	limiter, err := TransferLimiter()
+	if err != nil {
+		return nil, err
+	}
+	opts := &azblob.ClientOptions{ClientOptions: azcore.ClientOptions{
+		Transport: throttle.Client(httpclient.New(0), limiter),
+	}}
+	switch s.Credential {
+	case AzureCredentialSharedKey:
+		key := strings.TrimSpace(os.Getenv("AZURE_STORAGE_KEY"))
+		if key == "" {
+			return nil, errors.New("azure-credential shared-key requires AZURE_STORAGE_KEY")
+		}
+		cred, err := azblob.NewSharedKeyCredential(firstNonEmpty(account, s.Account), key)
+		if err != nil {
+			return nil, fmt.Errorf("azure shared key: %w", err)
+		}
+		return azblob.NewClientWithSharedKeyCredential(serviceURL, cred, opts)
+	case AzureCredentialSAS:
+		sas := strings.TrimPrefix(strings.TrimSpace(os.Getenv("AZURE_STORAGE_SAS_TOKEN")), "?")
+		if sas == "" {
+			return nil, errors.New("azure-credential sas requires AZURE_STORAGE_SAS_TOKEN")
+		}
+		return azblob.NewClientWithNoCredential(serviceURL+"?"+sas, opts)
+	default:
+		cred, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, fmt.Errorf("azure default credential: %w", err)
+		}
+		return azblob.NewClient(serviceURL, cred, opts)
+	}
+}
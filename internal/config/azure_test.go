@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestLoadAzureSettings(t *testing.T) {
+	setupTestRepo(t)
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "envaccount")
+
+	s, err := LoadAzureSettings("origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != (AzureSettings{Account: "envaccount", Credential: AzureCredentialDefault}) {
+		t.Fatalf("defaults = %+v", s)
+	}
+	if u, err := s.ServiceURL(""); err != nil || u != "https://envaccount.blob.core.windows.net/" {
+		t.Fatalf("service URL = %q, %v", u, err)
+	}
+
+	for _, kv := range [][2]string{
+		{"drs.remote.origin.azure-account", "partner"},
+		{"drs.remote.origin.azure-endpoint", "http://127.0.0.1:10000/devstoreaccount1/"},
+		{"drs.remote.origin.azure-credential", "SAS"},
+	} {
+		if out, err := exec.Command("git", "config", kv[0], kv[1]).CombinedOutput(); err != nil {
+			t.Fatalf("git config %s: %v: %s", kv[0], err, out)
+		}
+	}
+	s, err = LoadAzureSettings("origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Account != "partner" || s.Credential != AzureCredentialSAS {
+		t.Fatalf("configured = %+v", s)
+	}
+	if u, _ := s.ServiceURL("other"); u != "http://127.0.0.1:10000/devstoreaccount1/" {
+		t.Fatalf("endpoint should replace the account URL, got %q", u)
+	}
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "")
+	if _, err := NewAzureClient(s, ""); err == nil {
+		t.Fatal("expected an error without AZURE_STORAGE_SAS_TOKEN")
+	}
+
+	if out, err := exec.Command("git", "config", "drs.remote.origin.azure-credential", "password").CombinedOutput(); err != nil {
+		t.Fatalf("git config: %v: %s", err, out)
+	}
+	if _, err := LoadAzureSettings("origin"); err == nil {
+		t.Fatal("expected an error for an unknown credential mode")
+	}
+}
+
+func TestParseAzureURL(t *testing.T) {
+	cases := map[string]AzureObject{
+		"az://reads/run1/a.bam":                                  {Container: "reads", Key: "run1/a.bam"},
+		"azblob://reads/a.bam?account_name=partner":              {Account: "partner", Container: "reads", Key: "a.bam"},
+		"https://partner.blob.core.windows.net/reads/run1/a.bam": {Account: "partner", Container: "reads", Key: "run1/a.bam"},
+	}
+	for raw, want := range cases {
+		if got, ok := ParseAzureURL(raw); !ok || got != want {
+			t.Errorf("%s: got %+v, %v; want %+v", raw, got, ok, want)
+		}
+	}
+	for _, raw := range []string{"s3://bucket/key", "https://example.org/reads/a.bam", "https://partner.blob.core.windows.net/"} {
+		if _, ok := ParseAzureURL(raw); ok {
+			t.Errorf("%s is not an Azure blob URL", raw)
+		}
+	}
+	if c, ok := AzureContainer("az://reads"); !ok || c != "reads" {
+		t.Errorf("AzureContainer(az://reads) = %q, %v", c, ok)
+	}
+	if _, ok := AzureContainer("reads"); ok {
+		t.Error("a plain bucket name is not an Azure container")
+	}
+}
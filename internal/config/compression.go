@@ -0,0 +1,24 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/calypr/git-drs/internal/compression"
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+// CompressionSettings reads drs.compress.extensions and drs.compress.encoding
+// from git config. An invalid encoding is an error rather than falling back
+// to gzip, so a typo does not silently change what is stored.
+func CompressionSettings() (compression.Settings, error) {
+	extensions, _ := gitrepo.GetGitConfigString("drs.compress.extensions")
+	rawEncoding, _ := gitrepo.GetGitConfigString("drs.compress.encoding")
+	encoding, err := compression.ParseEncoding(rawEncoding)
+	if err != nil {
+		return compression.Settings{}, fmt.Errorf("drs.compress.encoding: %w", err)
+	}
+	return compression.Settings{
+		Encoding:   encoding,
+		Extensions: compression.ParseExtensions(extensions),
+	}, nil
+}
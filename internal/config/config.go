@@ -6,11 +6,14 @@ import (
 	"log/slog"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/drserrors"
 	"github.com/calypr/git-drs/internal/gitrepo"
 	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/plumbing/format/config"
 )
 
 // RemoteType represents the type of server being initialized
@@ -20,8 +23,10 @@ type Remote string
 const (
 	ORIGIN = "origin"
 
-	Gen3ServerType  RemoteType = "gen3"
-	LocalServerType RemoteType = "local"
+	Gen3ServerType       RemoteType = "gen3"
+	LocalServerType      RemoteType = "local"
+	FilesystemServerType RemoteType = "filesystem"
+	AnvilServerType      RemoteType = "anvil"
 
 	configSection          = "drs"
 	remoteSubsectionPrefix = "remote."
@@ -30,7 +35,7 @@ const (
 var ErrNoDefaultRemote = errors.New("no default remote configured")
 
 func AllRemoteTypes() []RemoteType {
-	return []RemoteType{Gen3ServerType, LocalServerType}
+	return []RemoteType{Gen3ServerType, LocalServerType, FilesystemServerType, AnvilServerType}
 }
 
 func IsValidRemoteType(mode string) error {
@@ -52,30 +57,81 @@ func IsValidRemoteType(mode string) error {
 type Config struct {
 	DefaultRemote Remote
 	Remotes       map[Remote]RemoteSelect
+
+	// overrides are the invocation's GIT_DRS_* and flag overrides, layered
+	// onto each remote as it is resolved.
+	overrides Overrides
 }
 
-func (c Config) GetRemoteClient(remote Remote, logger *slog.Logger) (*GitContext, error) {
+// remoteSelect returns the configuration of remote with the invocation's
+// overrides applied.
+func (c Config) remoteSelect(remote Remote) (RemoteSelect, bool) {
 	x, ok := c.Remotes[remote]
-	if !ok {
-		return nil, fmt.Errorf("GetRemoteClient no remote configuration found for current remote: %s", remote)
-	}
-	if x.Local != nil {
-		return x.Local.GetClient(string(remote), logger)
+	if c.overrides.IsEmpty() {
+		return x, ok
 	}
-	if x.Gen3 != nil {
-		username, password, err := gitrepo.GetRemoteBasicAuth(string(remote))
-		if err == nil && strings.TrimSpace(username) != "" && strings.TrimSpace(password) != "" {
+	// Apply already validated the overrides.
+	x, ok, _ = c.overrides.layer(x, ok)
+	return x, ok
+}
+
+func (c Config) GetRemoteClient(remote Remote, logger *slog.Logger) (*GitContext, error) {
+	x, ok := c.remoteSelect(remote)
+	if !ok {
+		return nil, drserrors.WithKind(drserrors.ErrConfig, fmt.Errorf("GetRemoteClient no remote configuration found for current remote: %s", remote))
+	}
+	var (
+		gc  *GitContext
+		err error
+	)
+	switch {
+	case x.Filesystem != nil:
+		gc, err = x.Filesystem.GetClient(string(remote), logger)
+	case x.Anvil != nil:
+		gc, err = x.Anvil.GetClient(string(remote), logger)
+	case x.Local != nil:
+		gc, err = x.Local.GetClient(string(remote), logger)
+	case x.Gen3 != nil:
+		username, password, authErr := gitrepo.GetRemoteBasicAuth(string(remote))
+		if authErr == nil && strings.TrimSpace(username) != "" && strings.TrimSpace(password) != "" {
 			// If repo-local basic auth is configured, prefer the local/basic-auth client
 			// path even when the remote entry was parsed as Gen3.
-			return localRemoteFromGen3(x.Gen3, username, password).GetClient(string(remote), logger)
+			gc, err = localRemoteFromGen3(x.Gen3, username, password).GetClient(string(remote), logger)
+		} else {
+			gc, err = x.Gen3.GetClient(string(remote), logger)
 		}
-		return x.Gen3.GetClient(string(remote), logger)
+	default:
+		return nil, drserrors.WithKind(drserrors.ErrConfig, fmt.Errorf("no valid remote configuration found for current remote: %s", remote))
+	}
+	if err != nil {
+		return nil, err
+	}
+	gc.RemoteName = string(remote)
+	gc.ReadOnly = x.ReadOnly()
+	gc.Encryption = EncryptionSettings(string(remote))
+	if gc.Compression, err = CompressionSettings(); err != nil {
+		return nil, drserrors.WithKind(drserrors.ErrConfig, err)
+	}
+	gc.Provenance = ProvenanceSettings()
+	gc.Usage = UsageSettings()
+	gc.SharedCache = SharedCacheDir()
+	if gc.Origin, err = OriginFields(); err != nil {
+		return nil, drserrors.WithKind(drserrors.ErrConfig, err)
+	}
+	if gc.Upload, err = LoadUploadSettings(string(remote)); err != nil {
+		return nil, drserrors.WithKind(drserrors.ErrConfig, err)
 	}
-	return nil, fmt.Errorf("no valid remote configuration found for current remote: %s", remote)
+	if gc.IDs, err = LoadIDMinter(string(remote)); err != nil {
+		return nil, drserrors.WithKind(drserrors.ErrConfig, err)
+	}
+	gc.Report = gitrepo.GetGitConfigBool("drs.push.report", false)
+	gc.QueueOffline = gitrepo.GetGitConfigBool("drs.push.queue-offline", false)
+	gc.CheckAuthz = gitrepo.GetGitConfigBool("drs.push.check-authz", true)
+	return gc, nil
 }
 
 func (c Config) GetRemote(remote Remote) DRSRemote {
-	x, ok := c.Remotes[remote]
+	x, ok := c.remoteSelect(remote)
 	if !ok {
 		return nil
 	}
@@ -83,6 +139,10 @@ func (c Config) GetRemote(remote Remote) DRSRemote {
 		return x.Gen3
 	} else if x.Local != nil {
 		return x.Local
+	} else if x.Filesystem != nil {
+		return x.Filesystem
+	} else if x.Anvil != nil {
+		return x.Anvil
 	}
 	return nil
 }
@@ -90,7 +150,7 @@ func (c Config) GetRemote(remote Remote) DRSRemote {
 // GetDefaultRemote returns the configured default remote with validation
 func (c Config) GetDefaultRemote() (Remote, error) {
 	if c.DefaultRemote == "" {
-		return "", fmt.Errorf(
+		return "", drserrors.WithKind(drserrors.ErrConfig, fmt.Errorf(
 			"%w.\n"+
 				"Set one with: git drs remote set <name>\n"+
 				"Available remotes: %v\n"+
@@ -98,16 +158,16 @@ func (c Config) GetDefaultRemote() (Remote, error) {
 			ErrNoDefaultRemote,
 			c.listRemoteNames(),
 			c,
-		)
+		))
 	}
 
 	if _, ok := c.Remotes[c.DefaultRemote]; !ok {
-		return "", fmt.Errorf(
+		return "", drserrors.WithKind(drserrors.ErrConfig, fmt.Errorf(
 			"default remote '%s' not found in configuration.\n"+
 				"Available remotes: %v",
 			c.DefaultRemote,
 			c.listRemoteNames(),
-		)
+		))
 	}
 
 	return c.DefaultRemote, nil
@@ -171,6 +231,20 @@ func UpdateRemote(name Remote, remote RemoteSelect) (*Config, error) {
 		if remote.Gen3.StoragePrefix != "" {
 			remoteSubsection.SetOption("storage_prefix", remote.Gen3.StoragePrefix)
 		}
+		if remote.Gen3.Profile != "" {
+			remoteSubsection.SetOption("profile", remote.Gen3.Profile)
+		}
+		if remote.Gen3.Auth != "" {
+			remoteSubsection.SetOption("auth", remote.Gen3.Auth)
+		}
+		if remote.Gen3.CredentialStore != "" {
+			remoteSubsection.SetOption("credential-store", remote.Gen3.CredentialStore)
+		}
+		if remote.Gen3.LocalScope != "" {
+			remoteSubsection.SetOption("local-scope", remote.Gen3.LocalScope)
+		}
+		setPathScopes(remoteSubsection, remote.Gen3.PathScopes)
+		setReadOnly(remoteSubsection, remote.Gen3.ReadOnly)
 	} else if remote.Local != nil {
 		remoteSubsection.SetOption("type", "local")
 		remoteSubsection.SetOption("endpoint", remote.Local.BaseURL)
@@ -186,6 +260,29 @@ func UpdateRemote(name Remote, remote RemoteSelect) (*Config, error) {
 		if remote.Local.StoragePrefix != "" {
 			remoteSubsection.SetOption("storage_prefix", remote.Local.StoragePrefix)
 		}
+		setPathScopes(remoteSubsection, remote.Local.PathScopes)
+		setReadOnly(remoteSubsection, remote.Local.ReadOnly)
+	} else if remote.Filesystem != nil {
+		remoteSubsection.SetOption("type", string(FilesystemServerType))
+		remoteSubsection.SetOption("endpoint", remote.Filesystem.Root)
+		remoteSubsection.SetOption("project", remote.Filesystem.ProjectID)
+		remoteSubsection.SetOption("organization", remote.Filesystem.Organization)
+		setPathScopes(remoteSubsection, remote.Filesystem.PathScopes)
+		setReadOnly(remoteSubsection, remote.Filesystem.ReadOnly)
+	} else if remote.Anvil != nil {
+		remoteSubsection.SetOption("type", string(AnvilServerType))
+		remoteSubsection.SetOption("endpoint", remote.Anvil.GetEndpoint())
+		remoteSubsection.SetOption("project", remote.Anvil.ProjectID)
+		remoteSubsection.SetOption("organization", remote.Anvil.Organization)
+		if remote.Anvil.SAM != "" {
+			remoteSubsection.SetOption("sam-endpoint", remote.Anvil.SAM)
+		}
+		if remote.Anvil.DRSHost != "" {
+			remoteSubsection.SetOption("drs-host", remote.Anvil.DRSHost)
+		}
+		if remote.Anvil.BillingProject != "" {
+			remoteSubsection.SetOption("billing-project", remote.Anvil.BillingProject)
+		}
 	}
 
 	// Set default remote if not set
@@ -203,7 +300,27 @@ func UpdateRemote(name Remote, remote RemoteSelect) (*Config, error) {
 	return LoadConfig()
 }
 
-func parseAndAddRemote(cfg *Config, subsectionName string, remoteType string, endpoint string, project string, bucket string, organization string, storagePrefix string) {
+// setPathScopes replaces the subsection's path-scope values; an empty list
+// leaves them alone.
+func setPathScopes(sub *gitconfig.Subsection, scopes []PathScope) {
+	if len(scopes) == 0 {
+		return
+	}
+	sub.RemoveOption("path-scope")
+	for _, s := range scopes {
+		sub.AddOption("path-scope", s.String())
+	}
+}
+
+// setReadOnly records a read-only remote; a writable one leaves any existing
+// value alone.
+func setReadOnly(sub *gitconfig.Subsection, readOnly bool) {
+	if readOnly {
+		sub.SetOption("read-only", "true")
+	}
+}
+
+func parseAndAddRemote(cfg *Config, subsectionName string, remoteType string, endpoint string, project string, bucket string, organization string, storagePrefix string, profile string, auth string, localScope string, credentialStore string) {
 	if !strings.HasPrefix(subsectionName, remoteSubsectionPrefix) {
 		return
 	}
@@ -213,11 +330,15 @@ func parseAndAddRemote(cfg *Config, subsectionName string, remoteType string, en
 
 	if remoteType == "gen3" || remoteType == "" {
 		rs.Gen3 = &Gen3Remote{
-			Endpoint:      endpoint,
-			ProjectID:     project,
-			Bucket:        bucket,
-			Organization:  organization,
-			StoragePrefix: storagePrefix,
+			Endpoint:        endpoint,
+			ProjectID:       project,
+			Bucket:          bucket,
+			Organization:    organization,
+			StoragePrefix:   storagePrefix,
+			Profile:         profile,
+			Auth:            auth,
+			LocalScope:      localScope,
+			CredentialStore: credentialStore,
 		}
 	} else if remoteType == "local" {
 		rs.Local = &LocalRemote{
@@ -227,13 +348,64 @@ func parseAndAddRemote(cfg *Config, subsectionName string, remoteType string, en
 			Organization:  organization,
 			StoragePrefix: storagePrefix,
 		}
+	} else if remoteType == string(FilesystemServerType) {
+		rs.Filesystem = &FilesystemRemote{
+			Root:         endpoint,
+			ProjectID:    project,
+			Organization: organization,
+		}
+	} else if remoteType == string(AnvilServerType) {
+		rs.Anvil = &AnvilRemote{
+			Endpoint:     endpoint,
+			ProjectID:    project,
+			Organization: organization,
+		}
 	}
 
 	cfg.Remotes[remoteName] = rs
 }
 
-// LoadConfig loads configuration using go-git
+// LoadConfig loads configuration using go-git, inherits remotes from the
+// user-level config, and layers GIT_DRS_* environment variables and CLI flag
+// overrides on top. Its errors are marked as configuration errors.
 func LoadConfig() (*Config, error) {
+	cfg, err := loadStoredConfig()
+	if err != nil {
+		return nil, drserrors.WithKind(drserrors.ErrConfig, err)
+	}
+	user, err := loadUserConfig()
+	if err != nil {
+		return nil, drserrors.WithKind(drserrors.ErrConfig, err)
+	}
+	inheritUserConfig(cfg, user)
+	if err := EffectiveOverrides().Apply(cfg); err != nil {
+		return nil, drserrors.WithKind(drserrors.ErrConfig, err)
+	}
+	return cfg, nil
+}
+
+// LoadStandaloneConfig is LoadConfig for commands that also run outside a
+// git repository. There the remotes come only from the user config file and
+// GIT_DRS_* overrides.
+func LoadStandaloneConfig() (*Config, error) {
+	if _, err := getRepo(); !errors.Is(err, git.ErrRepositoryNotExists) {
+		return LoadConfig()
+	}
+	user, err := loadUserConfig()
+	if err != nil {
+		return nil, drserrors.WithKind(drserrors.ErrConfig, err)
+	}
+	cfg := &Config{Remotes: make(map[Remote]RemoteSelect)}
+	inheritUserConfig(cfg, user)
+	if err := EffectiveOverrides().Apply(cfg); err != nil {
+		return nil, drserrors.WithKind(drserrors.ErrConfig, err)
+	}
+	return cfg, nil
+}
+
+// loadStoredConfig loads only what is persisted in git config, without
+// overrides. Use it before writing config back so overrides never leak to disk.
+func loadStoredConfig() (*Config, error) {
 	repo, err := getRepo()
 	if err != nil {
 		return nil, err
@@ -273,7 +445,48 @@ func LoadConfig() (*Config, error) {
 				subsection.Option("bucket"),
 				subsection.Option("organization"),
 				subsection.Option("storage_prefix"),
+				subsection.Option("profile"),
+				subsection.Option("auth"),
+				subsection.Option("local-scope"),
+				subsection.Option("credential-store"),
 			)
+			if rs := cfg.Remotes[Remote(strings.TrimPrefix(subsection.Name, remoteSubsectionPrefix))]; rs.Anvil != nil {
+				rs.Anvil.SAM = subsection.Option("sam-endpoint")
+				rs.Anvil.DRSHost = subsection.Option("drs-host")
+				rs.Anvil.BillingProject = subsection.Option("billing-project")
+			}
+			if raw := subsection.OptionAll("path-scope"); len(raw) > 0 {
+				scopes, err := ParsePathScopes(raw)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", subsection.Name, err)
+				}
+				rs := cfg.Remotes[Remote(strings.TrimPrefix(subsection.Name, remoteSubsectionPrefix))]
+				if rs.Gen3 != nil {
+					rs.Gen3.PathScopes = scopes
+				}
+				if rs.Local != nil {
+					rs.Local.PathScopes = scopes
+				}
+				if rs.Filesystem != nil {
+					rs.Filesystem.PathScopes = scopes
+				}
+			}
+			if raw := subsection.Option("read-only"); raw != "" {
+				readOnly, err := strconv.ParseBool(raw)
+				if err != nil {
+					return nil, fmt.Errorf("%s: invalid read-only value %q", subsection.Name, raw)
+				}
+				rs := cfg.Remotes[Remote(strings.TrimPrefix(subsection.Name, remoteSubsectionPrefix))]
+				if rs.Gen3 != nil {
+					rs.Gen3.ReadOnly = readOnly
+				}
+				if rs.Local != nil {
+					rs.Local.ReadOnly = readOnly
+				}
+				if rs.Filesystem != nil {
+					rs.Filesystem.ReadOnly = readOnly
+				}
+			}
 		}
 	}
 
@@ -291,11 +504,11 @@ func CreateEmptyConfig() error {
 func GetProjectId(remote Remote) (string, error) {
 	cfg, err := LoadConfig()
 	if err != nil {
-		return "", fmt.Errorf("error loading config: %v", err)
+		return "", fmt.Errorf("error loading config: %w", err)
 	}
 	rmt := cfg.GetRemote(remote)
 	if rmt == nil {
-		return "", fmt.Errorf("no remote configuration found for current remote: %s", remote)
+		return "", drserrors.WithKind(drserrors.ErrConfig, fmt.Errorf("no remote configuration found for current remote: %s", remote))
 	}
 	return rmt.GetProjectId(), nil
 }
@@ -320,7 +533,7 @@ func SaveConfig(cfg *Config) error {
 }
 
 func RemoveRemote(name Remote) (*Config, error) {
-	cfg, err := LoadConfig()
+	cfg, err := loadStoredConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -338,9 +551,26 @@ func RemoveRemote(name Remote) (*Config, error) {
 		fmt.Sprintf("drs.remote.%s.bucket", name),
 		fmt.Sprintf("drs.remote.%s.organization", name),
 		fmt.Sprintf("drs.remote.%s.storage_prefix", name),
+		fmt.Sprintf("drs.remote.%s.profile", name),
+		fmt.Sprintf("drs.remote.%s.auth", name),
+		fmt.Sprintf("drs.remote.%s.local-scope", name),
+		fmt.Sprintf("drs.remote.%s.credential-store", name),
+		fmt.Sprintf("drs.remote.%s.path-scope", name),
 		fmt.Sprintf("drs.remote.%s.token", name),
 		fmt.Sprintf("drs.remote.%s.username", name),
 		fmt.Sprintf("drs.remote.%s.password", name),
+		fmt.Sprintf("drs.remote.%s.encryption-key-file", name),
+		fmt.Sprintf("drs.remote.%s.encryption-key-command", name),
+		fmt.Sprintf("drs.remote.%s.s3-requester-pays", name),
+		fmt.Sprintf("drs.remote.%s.s3-accelerate", name),
+		fmt.Sprintf("drs.remote.%s.s3-path-style", name),
+		fmt.Sprintf("drs.remote.%s.s3-ca-bundle", name),
+		fmt.Sprintf("drs.remote.%s.s3-insecure", name),
+		fmt.Sprintf("drs.remote.%s.s3-signature-version", name),
+		fmt.Sprintf("drs.remote.%s.id-strategy", name),
+		fmt.Sprintf("drs.remote.%s.id-prefix", name),
+		fmt.Sprintf("drs.remote.%s.upload-method", name),
+		fmt.Sprintf("drs.remote.%s.read-only", name),
 		fmt.Sprintf("remote.%s.lfsurl", name),
 	}
 	if err := gitrepo.UnsetGitConfigOptions(keys); err != nil {
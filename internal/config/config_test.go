@@ -1,8 +1,11 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/calypr/git-drs/internal/drslog"
@@ -405,7 +408,7 @@ func TestNewGitContextReadsLFSConcurrentTransfers(t *testing.T) {
 		Bucket:       "bucket1",
 	}
 
-	gitCtx, err := newGitContext(cred, remote, drslog.GetLogger())
+	gitCtx, err := newGitContext("origin", cred, remote, drslog.GetLogger())
 	if err != nil {
 		t.Fatalf("newGitContext failed: %v", err)
 	}
@@ -413,3 +416,139 @@ func TestNewGitContextReadsLFSConcurrentTransfers(t *testing.T) {
 		t.Fatalf("UploadConcurrency = %d, want 7", gitCtx.UploadConcurrency)
 	}
 }
+
+func TestLocalRemoteClientSharesTransferSettings(t *testing.T) {
+	setupTestRepo(t)
+	t.Setenv("NETRC", filepath.Join(t.TempDir(), "none"))
+
+	if err := gitrepo.SetGitConfigOptions(map[string]string{
+		"lfs.concurrenttransfers": "7",
+		"drs.upsert":              "true",
+		"drs.multipart-threshold": "64",
+	}); err != nil {
+		t.Fatalf("SetGitConfigOptions failed: %v", err)
+	}
+
+	gitCtx, err := LocalRemote{BaseURL: "https://example.test", ProjectID: "proj1"}.GetClient("origin", drslog.GetLogger())
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+	if gitCtx.UploadConcurrency != 7 || !gitCtx.Upsert || gitCtx.MultiPartThreshold != 64*1024*1024 {
+		t.Fatalf("local context = concurrency %d, upsert %v, threshold %d; want the gen3 settings", gitCtx.UploadConcurrency, gitCtx.Upsert, gitCtx.MultiPartThreshold)
+	}
+}
+
+func TestAnonymousRemote_RoundTripAndReadOnlyClient(t *testing.T) {
+	setupTestRepo(t)
+
+	if _, err := UpdateRemote(Remote("public"), RemoteSelect{
+		Gen3: &Gen3Remote{Endpoint: "https://public.example", Organization: "org", ProjectID: "proj", Auth: AuthNone},
+	}); err != nil {
+		t.Fatalf("UpdateRemote error: %v", err)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	g := cfg.Remotes[Remote("public")].Gen3
+	if g == nil || !g.Anonymous() {
+		t.Fatalf("expected anonymous gen3 remote, got %#v", g)
+	}
+
+	// No credential profile exists; an anonymous remote must not need one.
+	gc, err := cfg.GetRemoteClient(Remote("public"), drslog.NewNoOpLogger())
+	if err != nil {
+		t.Fatalf("GetRemoteClient error: %v", err)
+	}
+	if !gc.Anonymous || gc.RemoteName != "public" || gc.ProjectId != "proj" {
+		t.Fatalf("unexpected anonymous context: %#v", gc)
+	}
+	if err := gc.RequireWrite(); !errors.Is(err, ErrReadOnlyRemote) {
+		t.Fatalf("expected ErrReadOnlyRemote, got %v", err)
+	}
+	if err := IsValidAuthMode("token"); err == nil {
+		t.Fatalf("expected invalid auth mode error")
+	}
+}
+
+func TestLocalScopeRoundTripBuildsProjectMap(t *testing.T) {
+	setupTestRepo(t)
+
+	if _, err := UpdateRemote(Remote("mirror"), RemoteSelect{
+		Gen3: &Gen3Remote{Endpoint: "https://prod.example", Organization: "programB", ProjectID: "projectY", Auth: AuthNone, LocalScope: "programA/projectX"},
+	}); err != nil {
+		t.Fatalf("UpdateRemote error: %v", err)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	g := cfg.Remotes[Remote("mirror")].Gen3
+	if g == nil || g.LocalScope != "programA/projectX" {
+		t.Fatalf("expected local scope to round-trip, got %#v", g)
+	}
+	if got := LocalProjectID(g); got != "projectX" {
+		t.Fatalf("LocalProjectID = %q, want projectX", got)
+	}
+
+	gc, err := cfg.GetRemoteClient(Remote("mirror"), drslog.NewNoOpLogger())
+	if err != nil {
+		t.Fatalf("GetRemoteClient error: %v", err)
+	}
+	if gc.ProjectMap == nil || gc.ProjectMap.LocalScope() != "programA/projectX" || gc.ProjectMap.RemoteScope() != "programB/projectY" {
+		t.Fatalf("unexpected project map: %#v", gc.ProjectMap)
+	}
+}
+
+func TestReadOnlyRemote_RefusesWritesAndInheritsFromUserConfig(t *testing.T) {
+	setupTestRepo(t)
+	writeUserConfig(t, `remotes:
+  anvil:
+    type: local
+    endpoint: https://anvil.example
+    read_only: true
+`)
+
+	if _, err := UpdateRemote(Remote("mirror"), RemoteSelect{
+		Local: &LocalRemote{BaseURL: "https://mirror.example", ProjectID: "proj", ReadOnly: true},
+	}); err != nil {
+		t.Fatalf("UpdateRemote error: %v", err)
+	}
+	if _, err := UpdateRemote(Remote("origin"), RemoteSelect{
+		Local: &LocalRemote{BaseURL: "https://origin.example", ProjectID: "proj"},
+	}); err != nil {
+		t.Fatalf("UpdateRemote error: %v", err)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	for name, want := range map[Remote]bool{"mirror": true, "anvil": true, "origin": false} {
+		if got := cfg.Remotes[name].ReadOnly(); got != want {
+			t.Fatalf("%s: ReadOnly() = %v, want %v", name, got, want)
+		}
+	}
+
+	gc, err := cfg.GetRemoteClient(Remote("mirror"), drslog.NewNoOpLogger())
+	if err != nil {
+		t.Fatalf("GetRemoteClient error: %v", err)
+	}
+	err = gc.RequireWrite()
+	if !errors.Is(err, ErrReadOnlyRemote) || !strings.Contains(err.Error(), `remote "mirror" is marked read-only`) {
+		t.Fatalf("expected a read-only error naming the remote, got %v", err)
+	}
+	gc, err = cfg.GetRemoteClient(Remote("origin"), drslog.NewNoOpLogger())
+	if err != nil {
+		t.Fatalf("GetRemoteClient error: %v", err)
+	}
+	if err := gc.RequireWrite(); err != nil {
+		t.Fatalf("expected writable origin, got %v", err)
+	}
+
+	if out, err := exec.Command("git", "config", "drs.remote.origin.read-only", "maybe").CombinedOutput(); err != nil {
+		t.Fatalf("git config: %v: %s", err, out)
+	}
+	if _, err := LoadConfig(); err == nil {
+		t.Fatal("expected an invalid read-only value to be rejected")
+	}
+}
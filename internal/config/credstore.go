@@ -0,0 +1,139 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/keyring"
+	syconf "github.com/calypr/syfon/client/config"
+	"gopkg.in/ini.v1"
+)
+
+// Credential stores for gen3 profiles, set per remote with
+// drs.remote.<name>.credential-store.
+const (
+	// CredentialStoreFile keeps the profile in ~/.gen3/gen3_client_config.ini,
+	// shared with the gen3 data client. It is the default.
+	CredentialStoreFile = "file"
+	// CredentialStoreKeyring keeps the profile in the OS keyring, so no token
+	// is written to the home directory or to git config.
+	CredentialStoreKeyring = "keyring"
+)
+
+// keyringService is the keyring service profiles are stored under; the
+// account is the profile name.
+const keyringService = "git-drs"
+
+// IsValidCredentialStore checks a drs.remote.<name>.credential-store value.
+func IsValidCredentialStore(store string) error {
+	switch strings.TrimSpace(store) {
+	case "", CredentialStoreFile, CredentialStoreKeyring:
+		return nil
+	}
+	return fmt.Errorf("invalid credential store %q: expected %q or %q", store, CredentialStoreFile, CredentialStoreKeyring)
+}
+
+// UsesKeyring reports whether the remote keeps its profile in the OS keyring.
+func (s Gen3Remote) UsesKeyring() bool {
+	return strings.TrimSpace(s.CredentialStore) == CredentialStoreKeyring
+}
+
+// LoadProfile loads the named gen3 profile from store.
+func LoadProfile(store, profile string, logger *slog.Logger) (*syconf.Credential, error) {
+	if strings.TrimSpace(store) != CredentialStoreKeyring {
+		return syconf.NewConfigure(logger).Load(profile)
+	}
+	raw, err := keyring.Get(keyringService, profile)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, fmt.Errorf("%w: profile %q is not in the OS keyring", syconf.ErrProfileNotFound, profile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read profile %q from the OS keyring: %w", profile, err)
+	}
+	var cred syconf.Credential
+	if err := json.Unmarshal([]byte(raw), &cred); err != nil {
+		return nil, fmt.Errorf("parse profile %q from the OS keyring: %w", profile, err)
+	}
+	cred.Profile = profile
+	return &cred, nil
+}
+
+// SaveProfile writes cred to store under cred.Profile.
+func SaveProfile(store string, cred *syconf.Credential, logger *slog.Logger) error {
+	if strings.TrimSpace(store) != CredentialStoreKeyring {
+		return syconf.NewConfigure(logger).Save(cred)
+	}
+	raw, err := json.Marshal(cred)
+	if err != nil {
+		return err
+	}
+	if err := keyring.Set(keyringService, cred.Profile, string(raw)); err != nil {
+		return fmt.Errorf("write profile %q to the OS keyring: %w", cred.Profile, err)
+	}
+	return nil
+}
+
+// RemoteCredentialStore returns the credential store configured for
+// remoteName, for callers that have only the remote's name.
+func RemoteCredentialStore(remoteName string) string {
+	store, _ := gitrepo.GetGitConfigString(fmt.Sprintf("drs.remote.%s.credential-store", remoteName))
+	return strings.TrimSpace(store)
+}
+
+// RemoteProfile returns the credential profile configured for remoteName,
+// defaulting to the remote name, for callers that have only the remote's
+// name.
+func RemoteProfile(remoteName string) string {
+	profile, _ := gitrepo.GetGitConfigString(fmt.Sprintf("drs.remote.%s.profile", remoteName))
+	return firstNonEmpty(strings.TrimSpace(profile), remoteName)
+}
+
+// gen3ConfigPath is the data client's profile file.
+func gen3ConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".gen3", "gen3_client_config.ini"), nil
+}
+
+// MigrateProfileToKeyring copies profile from ~/.gen3 into the OS keyring,
+// checks it reads back, and then removes its section from the file. Other
+// profiles in the file are left alone. A profile already moved by another
+// repository is only checked for in the keyring.
+func MigrateProfileToKeyring(profile string, logger *slog.Logger) error {
+	cred, err := LoadProfile(CredentialStoreFile, profile, logger)
+	if errors.Is(err, syconf.ErrProfileNotFound) {
+		if _, kerr := LoadProfile(CredentialStoreKeyring, profile, logger); kerr == nil {
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err := SaveProfile(CredentialStoreKeyring, cred, logger); err != nil {
+		return err
+	}
+	if back, err := LoadProfile(CredentialStoreKeyring, profile, logger); err != nil || back.APIKey != cred.APIKey || back.AccessToken != cred.AccessToken {
+		return fmt.Errorf("profile %q did not read back from the OS keyring; leaving ~/.gen3 unchanged: %v", profile, err)
+	}
+	path, err := gen3ConfigPath()
+	if err != nil {
+		return err
+	}
+	file, err := ini.Load(path)
+	if err != nil {
+		return fmt.Errorf("load %s: %w", path, err)
+	}
+	file.DeleteSection(profile)
+	if err := file.SaveTo(path); err != nil {
+		return fmt.Errorf("remove profile %q from %s: %w", profile, path, err)
+	}
+	return nil
+}
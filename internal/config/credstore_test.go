@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/keyring"
+	syconf "github.com/calypr/syfon/client/config"
+)
+
+func writeGen3Config(t *testing.T, content string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".gen3")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "gen3_client_config.ini")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestKeyringProfileRoundTrip(t *testing.T) {
+	t.Cleanup(keyring.SetBackend(&keyring.Memory{}))
+
+	_, err := LoadProfile(CredentialStoreKeyring, "prod", nil)
+	if !errors.Is(err, syconf.ErrProfileNotFound) {
+		t.Fatalf("missing profile error = %v, want ErrProfileNotFound", err)
+	}
+
+	cred := &syconf.Credential{Profile: "prod", APIKey: "key", AccessToken: "tok", APIEndpoint: "https://prod.example"}
+	if err := SaveProfile(CredentialStoreKeyring, cred, nil); err != nil {
+		t.Fatalf("SaveProfile: %v", err)
+	}
+	got, err := LoadProfile(CredentialStoreKeyring, "prod", nil)
+	if err != nil {
+		t.Fatalf("LoadProfile: %v", err)
+	}
+	if got.APIKey != "key" || got.AccessToken != "tok" || got.APIEndpoint != "https://prod.example" {
+		t.Fatalf("loaded %+v", got)
+	}
+}
+
+func TestMigrateProfileToKeyring(t *testing.T) {
+	mem := &keyring.Memory{}
+	t.Cleanup(keyring.SetBackend(mem))
+	path := writeGen3Config(t, `[prod]
+key_id=kid
+api_key=key
+access_token=tok
+api_endpoint=https://prod.example
+
+[staging]
+key_id=kid2
+api_key=key2
+access_token=tok2
+api_endpoint=https://staging.example
+`)
+
+	if err := MigrateProfileToKeyring("prod", nil); err != nil {
+		t.Fatalf("MigrateProfileToKeyring: %v", err)
+	}
+
+	got, err := LoadProfile(CredentialStoreKeyring, "prod", nil)
+	if err != nil || got.AccessToken != "tok" || got.KeyID != "kid" {
+		t.Fatalf("keyring profile = %+v, %v", got, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "[prod]") || strings.Contains(string(data), "access_token=tok\n") {
+		t.Fatalf("prod profile left in %s:\n%s", path, data)
+	}
+	if !strings.Contains(string(data), "[staging]") {
+		t.Fatalf("staging profile removed from %s:\n%s", path, data)
+	}
+
+	// A second repository sharing the profile finds it already moved.
+	if err := MigrateProfileToKeyring("prod", nil); err != nil {
+		t.Fatalf("second migration: %v", err)
+	}
+}
+
+type failingBackend struct{ keyring.Memory }
+
+func (*failingBackend) Set(string, string, string) error { return keyring.ErrUnavailable }
+
+func TestMigrateProfileKeepsFileWhenKeyringFails(t *testing.T) {
+	t.Cleanup(keyring.SetBackend(&failingBackend{}))
+	path := writeGen3Config(t, "[prod]\napi_key=key\naccess_token=tok\napi_endpoint=https://prod.example\n")
+
+	err := MigrateProfileToKeyring("prod", nil)
+	if !errors.Is(err, keyring.ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "[prod]") {
+		t.Fatalf("profile removed although the keyring write failed:\n%s", data)
+	}
+}
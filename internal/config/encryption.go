@@ -0,0 +1,21 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/encryption"
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+// EncryptionSettings reads drs.remote.<name>.encryption-key-file and
+// encryption-key-command from git config.
+func EncryptionSettings(remote string) encryption.Settings {
+	keyFile, _ := gitrepo.GetGitConfigString(fmt.Sprintf("drs.remote.%s.encryption-key-file", remote))
+	keyCommand, _ := gitrepo.GetGitConfigString(fmt.Sprintf("drs.remote.%s.encryption-key-command", remote))
+	return encryption.Settings{
+		Remote:     remote,
+		KeyFile:    strings.TrimSpace(keyFile),
+		KeyCommand: strings.TrimSpace(keyCommand),
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/calypr/git-drs/internal/fsremote"
+	"github.com/calypr/git-drs/internal/signedurl"
+	syclient "github.com/calypr/syfon/client"
+	syconf "github.com/calypr/syfon/client/config"
+)
+
+// FilesystemRemote keeps records and object content in a directory, for
+// air-gapped enclaves with no DRS server. Root is stored as the remote's
+// endpoint. It has no bucket and no credentials; records are scoped to
+// Organization/ProjectID.
+type FilesystemRemote struct {
+	Root         string
+	ProjectID    string
+	Organization string
+	// PathScopes register files under some directories in other projects.
+	PathScopes []PathScope
+	// ReadOnly refuses registration, upload, and delete on this remote.
+	ReadOnly bool
+}
+
+func (f FilesystemRemote) GetProjectId() string     { return f.ProjectID }
+func (f FilesystemRemote) GetOrganization() string  { return f.Organization }
+func (f FilesystemRemote) GetEndpoint() string      { return f.Root }
+func (f FilesystemRemote) GetBucketName() string    { return "" }
+func (f FilesystemRemote) GetStoragePrefix() string { return "" }
+
+// GetClient opens the store at Root and returns a client whose requests it
+// answers in-process. Path scopes keep their organization and project; there
+// is no bucket to resolve them to.
+func (f FilesystemRemote) GetClient(remoteName string, logger *slog.Logger) (*GitContext, error) {
+	if f.ProjectID == "" || f.Organization == "" {
+		return nil, fmt.Errorf("filesystem remote %q needs an organization and project", remoteName)
+	}
+	store, err := fsremote.Open(f.Root, f.Organization, f.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := syclient.New(fsremote.BaseURL, syclient.WithHTTPClient(&http.Client{Transport: store}))
+	if err != nil {
+		return nil, err
+	}
+	client, ok := raw.(*syclient.Client)
+	if !ok {
+		return nil, fmt.Errorf("unexpected syfon client type %T", raw)
+	}
+
+	tuning := loadTransferTuning()
+	return &GitContext{
+		Client:              client,
+		SignedURLs:          signedurl.NewCache(),
+		Organization:        f.Organization,
+		ProjectId:           f.ProjectID,
+		Upsert:              tuning.Upsert,
+		MultiPartThreshold:  tuning.MultiPartThreshold,
+		UploadConcurrency:   tuning.UploadConcurrency,
+		DownloadConcurrency: tuning.DownloadConcurrency,
+		Logger:              logger,
+		Credential:          &syconf.Credential{APIEndpoint: fsremote.BaseURL},
+		AccessPolicy:        AccessPolicySettings(),
+		PathScopes:          f.PathScopes,
+	}, nil
+}
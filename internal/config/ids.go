@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+// LoadIDMinter reads drs.remote.<name>.id-strategy and id-prefix, which
+// select how DRS IDs are minted for objects staged for remote, falling back
+// to drs.id-strategy and drs.id-prefix.
+func LoadIDMinter(remote string) (drsobject.Minter, error) {
+	strategy, _ := gitrepo.GetGitConfigString(fmt.Sprintf("drs.remote.%s.id-strategy", remote))
+	prefix, _ := gitrepo.GetGitConfigString(fmt.Sprintf("drs.remote.%s.id-prefix", remote))
+	defaultStrategy, _ := gitrepo.GetGitConfigString("drs.id-strategy")
+	defaultPrefix, _ := gitrepo.GetGitConfigString("drs.id-prefix")
+
+	parsed, err := drsobject.ParseIDStrategy(firstNonEmpty(strategy, defaultStrategy))
+	if err != nil {
+		return drsobject.Minter{}, err
+	}
+	m := drsobject.Minter{Strategy: parsed, Prefix: firstNonEmpty(prefix, defaultPrefix)}
+	if err := drsobject.ValidatePrefix(m.Prefix); err != nil {
+		return drsobject.Minter{}, err
+	}
+	return m, nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+func TestLoadIDMinter(t *testing.T) {
+	setupTestRepo(t)
+	if m, err := LoadIDMinter("origin"); err != nil || m != (drsobject.Minter{Strategy: drsobject.IDProjectHash}) {
+		t.Fatalf("unset minter = %+v, %v; want project-hash", m, err)
+	}
+
+	if err := gitrepo.SetGitConfigOptions(map[string]string{
+		"drs.id-strategy":               "path-hash",
+		"drs.remote.origin.id-strategy": "server",
+		"drs.id-prefix":                 "dg.TEST/",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadIDMinter("origin")
+	if err != nil {
+		t.Fatalf("LoadIDMinter: %v", err)
+	}
+	if m.Strategy != drsobject.IDServer || m.Prefix != "dg.TEST/" {
+		t.Fatalf("origin minter = %+v", m)
+	}
+	if m, _ := LoadIDMinter("mirror"); m.Strategy != drsobject.IDPathHash {
+		t.Fatalf("mirror minter = %+v; want the drs.id-strategy default", m)
+	}
+
+	if err := gitrepo.SetGitConfigOptions(map[string]string{"drs.remote.origin.id-strategy": "random"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadIDMinter("origin"); err == nil {
+		t.Fatal("invalid strategy accepted")
+	}
+}
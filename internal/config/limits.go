@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/guardrail"
+)
+
+// LimitSettings reads drs.limits.* from git config. Unlike other settings, an
+// unparsable size is an error: silently dropping a limit would defeat it.
+func LimitSettings() (guardrail.Policy, error) {
+	var p guardrail.Policy
+	sizes := []struct {
+		key string
+		dst *int64
+	}{
+		{"drs.limits.max-file-size", &p.MaxFileSize},
+		{"drs.limits.max-push-size", &p.MaxPushSize},
+		{"drs.limits.project-quota", &p.ProjectQuota},
+	}
+	for _, s := range sizes {
+		raw, _ := gitrepo.GetGitConfigString(s.key)
+		n, err := guardrail.ParseSize(raw)
+		if err != nil {
+			return guardrail.Policy{}, drserrors.WithKind(drserrors.ErrConfig, fmt.Errorf("%s: %w", s.key, err))
+		}
+		*s.dst = n
+	}
+	raw, _ := gitrepo.GetGitConfigString("drs.limits.allowed-extensions")
+	p.AllowedExtensions = guardrail.ParseExtensions(raw)
+	raw, _ = gitrepo.GetGitConfigString("drs.limits.allowed-mime-types")
+	types, err := guardrail.ParseMimeTypes(raw)
+	if err != nil {
+		return guardrail.Policy{}, drserrors.WithKind(drserrors.ErrConfig, fmt.Errorf("drs.limits.allowed-mime-types: %w", err))
+	}
+	p.AllowedMimeTypes = types
+	return p, nil
+}
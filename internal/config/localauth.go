@@ -0,0 +1,202 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+// LocalAuth is how a local (plain HTTPS) remote authenticates: HTTP basic
+// auth, or a static bearer token, which wins when both are set.
+type LocalAuth struct {
+	Username string
+	Password string
+	Token    string
+	// Source names where the credentials came from, for logs.
+	Source string
+}
+
+func (a LocalAuth) empty() bool {
+	return a.Token == "" && (a.Username == "" || a.Password == "")
+}
+
+// AuthHandler finds credentials for a local remote. It reports false when it
+// has none, so the next handler is asked.
+type AuthHandler interface {
+	Credentials(remoteName, baseURL string) (LocalAuth, bool, error)
+}
+
+// localAuthHandlers are asked in order: per-remote environment variables,
+// then the repository's git config, then .netrc; swapped in tests.
+var localAuthHandlers = []AuthHandler{envAuth{}, gitConfigAuth{}, netrcAuth{}}
+
+// ResolveLocalAuth returns the first credentials a handler finds for
+// remoteName at baseURL, or empty credentials when none does.
+func ResolveLocalAuth(remoteName, baseURL string) (LocalAuth, error) {
+	for _, h := range localAuthHandlers {
+		auth, ok, err := h.Credentials(remoteName, baseURL)
+		if err != nil {
+			return LocalAuth{}, err
+		}
+		if ok {
+			return auth, nil
+		}
+	}
+	return LocalAuth{}, nil
+}
+
+// RemoteAuthEnv names the environment variable holding field (USERNAME,
+// PASSWORD or TOKEN) for remoteName, e.g. GIT_DRS_REMOTE_MY_SERVER_TOKEN for
+// remote my-server.
+func RemoteAuthEnv(remoteName, field string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, remoteName)
+	return "GIT_DRS_REMOTE_" + name + "_" + field
+}
+
+type envAuth struct{}
+
+func (envAuth) Credentials(remoteName, _ string) (LocalAuth, bool, error) {
+	auth := LocalAuth{
+		Username: strings.TrimSpace(os.Getenv(RemoteAuthEnv(remoteName, "USERNAME"))),
+		Password: strings.TrimSpace(os.Getenv(RemoteAuthEnv(remoteName, "PASSWORD"))),
+		Token:    strings.TrimSpace(os.Getenv(RemoteAuthEnv(remoteName, "TOKEN"))),
+		Source:   "environment",
+	}
+	return auth, !auth.empty(), nil
+}
+
+// gitConfigAuth reads drs.remote.<name>.username/password, as set by
+// git drs remote add local --username/--password, or else
+// drs.remote.<name>.token. Basic auth is preferred here because a gen3 remote
+// with basic auth also keeps its gen3 access token under .token.
+type gitConfigAuth struct{}
+
+func (gitConfigAuth) Credentials(remoteName, _ string) (LocalAuth, bool, error) {
+	auth := LocalAuth{Source: "git config"}
+	if username, password, err := gitrepo.GetRemoteBasicAuth(remoteName); err == nil && username != "" && password != "" {
+		auth.Username, auth.Password = username, password
+		return auth, true, nil
+	}
+	if token, err := gitrepo.GetRemoteToken(remoteName); err == nil {
+		auth.Token = strings.TrimSpace(token)
+	}
+	return auth, !auth.empty(), nil
+}
+
+// netrcAuth reads the login and password for the endpoint's host from the
+// file named by NETRC, or ~/.netrc, as curl and git do.
+type netrcAuth struct{}
+
+func (netrcAuth) Credentials(_, baseURL string) (LocalAuth, bool, error) {
+	u, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil || u.Hostname() == "" {
+		return LocalAuth{}, false, nil
+	}
+	path := netrcPath()
+	if path == "" {
+		return LocalAuth{}, false, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return LocalAuth{}, false, nil
+	}
+	if err != nil {
+		return LocalAuth{}, false, fmt.Errorf("read %s: %w", path, err)
+	}
+	defer f.Close()
+	login, password, ok, err := parseNetrc(bufio.NewScanner(f), u.Hostname())
+	if err != nil {
+		return LocalAuth{}, false, fmt.Errorf("parse %s: %w", path, err)
+	}
+	auth := LocalAuth{Username: login, Password: password, Source: path}
+	return auth, ok && !auth.empty(), nil
+}
+
+func netrcPath() string {
+	if p := strings.TrimSpace(os.Getenv("NETRC")); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// parseNetrc returns the login and password of the entry for host, or of the
+// default entry when no machine entry matches. Macro definitions are skipped.
+func parseNetrc(sc *bufio.Scanner, host string) (login, password string, ok bool, err error) {
+	type entry struct{ login, password string }
+	var (
+		match, fallback *entry
+		cur             *entry
+		inMacro         bool
+	)
+	for sc.Scan() {
+		line := sc.Text()
+		if inMacro {
+			if strings.TrimSpace(line) == "" {
+				inMacro = false
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			value := func() string {
+				if i+1 < len(fields) {
+					i++
+					return fields[i]
+				}
+				return ""
+			}
+			switch fields[i] {
+			case "machine":
+				cur = nil
+				if value() == host && match == nil {
+					match = &entry{}
+					cur = match
+				}
+			case "default":
+				cur = nil
+				if fallback == nil {
+					fallback = &entry{}
+					cur = fallback
+				}
+			case "login":
+				if v := value(); cur != nil {
+					cur.login = v
+				}
+			case "password":
+				if v := value(); cur != nil {
+					cur.password = v
+				}
+			case "account":
+				value()
+			case "macdef":
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", "", false, err
+	}
+	if match == nil {
+		match = fallback
+	}
+	if match == nil {
+		return "", "", false, nil
+	}
+	return match.login, match.password, true, nil
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+func TestParseNetrc(t *testing.T) {
+	const netrc = `machine other.example login nobody password wrong
+machine drs.example.org
+  login alice
+  password s3cret
+macdef init
+  machine drs.example.org login macro password macro
+
+default login anon password guest
+`
+	cases := []struct {
+		host, login, password string
+		ok                    bool
+	}{
+		{"drs.example.org", "alice", "s3cret", true},
+		{"unknown.example", "anon", "guest", true},
+	}
+	for _, c := range cases {
+		login, password, ok, err := parseNetrc(bufio.NewScanner(strings.NewReader(netrc)), c.host)
+		if err != nil || ok != c.ok || login != c.login || password != c.password {
+			t.Fatalf("parseNetrc(%s) = %q, %q, %v, %v; want %q, %q, %v", c.host, login, password, ok, err, c.login, c.password, c.ok)
+		}
+	}
+	if _, _, ok, _ := parseNetrc(bufio.NewScanner(strings.NewReader("machine a login b password c\n")), "z"); ok {
+		t.Fatal("expected no entry without a match or default")
+	}
+}
+
+func TestResolveLocalAuthOrder(t *testing.T) {
+	setupTestRepo(t)
+	netrc := filepath.Join(t.TempDir(), "netrc")
+	if err := os.WriteFile(netrc, []byte("machine drs.example.org login netrc-user password netrc-pass\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("NETRC", netrc)
+	const endpoint = "https://drs.example.org:8443/ga4gh"
+
+	auth, err := ResolveLocalAuth("my-server", endpoint)
+	if err != nil || auth.Username != "netrc-user" || auth.Password != "netrc-pass" || auth.Source != netrc {
+		t.Fatalf("netrc auth = %+v, %v", auth, err)
+	}
+
+	if err := gitrepo.SetRemoteBasicAuth("my-server", "config-user", "config-pass"); err != nil {
+		t.Fatal(err)
+	}
+	auth, err = ResolveLocalAuth("my-server", endpoint)
+	if err != nil || auth.Username != "config-user" || auth.Source != "git config" {
+		t.Fatalf("git config auth = %+v, %v", auth, err)
+	}
+
+	t.Setenv(RemoteAuthEnv("my-server", "TOKEN"), "static-token")
+	auth, err = ResolveLocalAuth("my-server", endpoint)
+	if err != nil || auth.Token != "static-token" || auth.Username != "" || auth.Source != "environment" {
+		t.Fatalf("env auth = %+v, %v", auth, err)
+	}
+}
+
+func TestRemoteAuthEnv(t *testing.T) {
+	if got := RemoteAuthEnv("my-server.1", "PASSWORD"); got != "GIT_DRS_REMOTE_MY_SERVER_1_PASSWORD" {
+		t.Fatalf("RemoteAuthEnv = %q", got)
+	}
+}
@@ -0,0 +1,17 @@
+package config
+
+import (
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/mds"
+)
+
+// MDSSettings reads drs.mds.* from git config.
+func MDSSettings() mds.Settings {
+	guid, _ := gitrepo.GetGitConfigString("drs.mds.guid")
+	guidType, _ := gitrepo.GetGitConfigString("drs.mds.guid-type")
+	return mds.Settings{
+		Enabled:  gitrepo.GetGitConfigBool("drs.mds.enabled", false),
+		GUID:     guid,
+		GUIDType: guidType,
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/metrics"
+)
+
+// MetricsSettings reads drs.metrics.* from git config, falling back to the
+// metrics section of the user config.
+func MetricsSettings() metrics.Settings {
+	user := userMetricsDefaults()
+	defaultSummary := false
+	if user.Summary != nil {
+		defaultSummary = *user.Summary
+	}
+	pushgateway, _ := gitrepo.GetGitConfigString("drs.metrics.pushgateway")
+	otlp, _ := gitrepo.GetGitConfigString("drs.metrics.otlp-endpoint")
+	job, _ := gitrepo.GetGitConfigString("drs.metrics.job")
+	return metrics.Settings{
+		Summary:      gitrepo.GetGitConfigBool("drs.metrics.summary", defaultSummary),
+		Pushgateway:  firstNonEmpty(pushgateway, user.Pushgateway),
+		OTLPEndpoint: firstNonEmpty(otlp, user.OTLPEndpoint),
+		Job:          firstNonEmpty(job, user.Job),
+	}
+}
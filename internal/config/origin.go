@@ -0,0 +1,12 @@
+package config
+
+import (
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/origin"
+)
+
+// OriginFields reads drs.origin.fields from git config.
+func OriginFields() (origin.Fields, error) {
+	v, _ := gitrepo.GetGitConfigString("drs.origin.fields")
+	return origin.ParseFields(v)
+}
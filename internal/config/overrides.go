@@ -0,0 +1,236 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Environment variables that override the stored remote configuration.
+const (
+	EnvRemote        = "GIT_DRS_REMOTE"
+	EnvRemoteType    = "GIT_DRS_REMOTE_TYPE"
+	EnvEndpoint      = "GIT_DRS_ENDPOINT"
+	EnvOrganization  = "GIT_DRS_ORGANIZATION"
+	EnvProject       = "GIT_DRS_PROJECT"
+	EnvBucket        = "GIT_DRS_BUCKET"
+	EnvStoragePrefix = "GIT_DRS_STORAGE_PREFIX"
+	EnvProfile       = "GIT_DRS_PROFILE"
+	EnvAuth          = "GIT_DRS_AUTH"
+)
+
+// Environment variables that override the transfer worker count for one
+// direction; see loadTransferTuning.
+const (
+	EnvUploadWorkers   = "GIT_DRS_UPLOAD_WORKERS"
+	EnvDownloadWorkers = "GIT_DRS_DOWNLOAD_WORKERS"
+)
+
+// Overrides holds per-invocation values layered over the stored config.
+// Empty fields leave the underlying value untouched.
+type Overrides struct {
+	Remote        string
+	RemoteType    string
+	Endpoint      string
+	Organization  string
+	Project       string
+	Bucket        string
+	StoragePrefix string
+	Profile       string
+	Auth          string
+}
+
+// invocationOverrides is populated from root command flags and wins over
+// environment variables.
+var invocationOverrides Overrides
+
+// SetInvocationOverrides records CLI flag overrides for the current process.
+func SetInvocationOverrides(o Overrides) {
+	invocationOverrides = o
+}
+
+// EnvOverrides reads GIT_DRS_* environment variables.
+func EnvOverrides() Overrides {
+	return Overrides{
+		Remote:        strings.TrimSpace(os.Getenv(EnvRemote)),
+		RemoteType:    strings.TrimSpace(os.Getenv(EnvRemoteType)),
+		Endpoint:      strings.TrimSpace(os.Getenv(EnvEndpoint)),
+		Organization:  strings.TrimSpace(os.Getenv(EnvOrganization)),
+		Project:       strings.TrimSpace(os.Getenv(EnvProject)),
+		Bucket:        strings.TrimSpace(os.Getenv(EnvBucket)),
+		StoragePrefix: strings.TrimSpace(os.Getenv(EnvStoragePrefix)),
+		Profile:       strings.TrimSpace(os.Getenv(EnvProfile)),
+		Auth:          strings.TrimSpace(os.Getenv(EnvAuth)),
+	}
+}
+
+// EffectiveOverrides merges CLI flag overrides over environment overrides.
+func EffectiveOverrides() Overrides {
+	return invocationOverrides.Merge(EnvOverrides())
+}
+
+// Merge returns o with any empty fields filled from lower.
+func (o Overrides) Merge(lower Overrides) Overrides {
+	return Overrides{
+		Remote:        firstNonEmpty(o.Remote, lower.Remote),
+		RemoteType:    firstNonEmpty(o.RemoteType, lower.RemoteType),
+		Endpoint:      firstNonEmpty(o.Endpoint, lower.Endpoint),
+		Organization:  firstNonEmpty(o.Organization, lower.Organization),
+		Project:       firstNonEmpty(o.Project, lower.Project),
+		Bucket:        firstNonEmpty(o.Bucket, lower.Bucket),
+		StoragePrefix: firstNonEmpty(o.StoragePrefix, lower.StoragePrefix),
+		Profile:       firstNonEmpty(o.Profile, lower.Profile),
+		Auth:          firstNonEmpty(o.Auth, lower.Auth),
+	}
+}
+
+// IsEmpty reports whether no override is set.
+func (o Overrides) IsEmpty() bool {
+	return o == Overrides{}
+}
+
+// Apply layers the overrides onto cfg. The remote override selects the
+// default remote, and the field overrides apply to it here and, through
+// Config.GetRemote and Config.GetRemoteClient, to whichever remote a command
+// resolves, so --remote picks the remote they change. When the selected
+// remote does not exist and an endpoint is given, a remote is synthesized so
+// CI jobs can run without any stored configuration.
+func (o Overrides) Apply(cfg *Config) error {
+	if cfg == nil || o.IsEmpty() {
+		return nil
+	}
+	if err := IsValidAuthMode(o.Auth); err != nil {
+		return err
+	}
+	if o.RemoteType != "" {
+		if err := IsValidRemoteType(o.RemoteType); err != nil {
+			return err
+		}
+	}
+	if cfg.Remotes == nil {
+		cfg.Remotes = make(map[Remote]RemoteSelect)
+	}
+	if o.Remote != "" {
+		cfg.DefaultRemote = Remote(o.Remote)
+	}
+	target := cfg.DefaultRemote
+	if target == "" {
+		target = Remote(ORIGIN)
+	}
+	cfg.overrides = o
+
+	rs, ok := cfg.Remotes[target]
+	rs, ok, err := o.layer(rs, ok)
+	if err != nil || !ok {
+		return err
+	}
+	cfg.DefaultRemote = target
+	cfg.Remotes[target] = rs
+	return nil
+}
+
+// layer applies the field overrides to one remote. exists reports whether
+// the remote is configured; a missing one is synthesized from the endpoint
+// override, and stays missing without one.
+func (o Overrides) layer(rs RemoteSelect, exists bool) (RemoteSelect, bool, error) {
+	if !exists {
+		if o.Endpoint == "" {
+			return rs, false, nil
+		}
+		remoteType := firstNonEmpty(o.RemoteType, string(Gen3ServerType))
+		if err := IsValidRemoteType(remoteType); err != nil {
+			return rs, false, err
+		}
+		switch RemoteType(remoteType) {
+		case LocalServerType:
+			rs.Local = &LocalRemote{}
+		case FilesystemServerType:
+			rs.Filesystem = &FilesystemRemote{}
+		case AnvilServerType:
+			rs.Anvil = &AnvilRemote{}
+		default:
+			rs.Gen3 = &Gen3Remote{}
+		}
+	}
+
+	if rs.Gen3 != nil {
+		g := *rs.Gen3
+		g.Endpoint = firstNonEmpty(o.Endpoint, g.Endpoint)
+		g.Organization = firstNonEmpty(o.Organization, g.Organization)
+		g.ProjectID = firstNonEmpty(o.Project, g.ProjectID)
+		g.Bucket = firstNonEmpty(o.Bucket, g.Bucket)
+		g.StoragePrefix = firstNonEmpty(o.StoragePrefix, g.StoragePrefix)
+		g.Profile = firstNonEmpty(o.Profile, g.Profile)
+		g.Auth = firstNonEmpty(o.Auth, g.Auth)
+		rs.Gen3 = &g
+	}
+	if rs.Local != nil {
+		l := *rs.Local
+		l.BaseURL = firstNonEmpty(o.Endpoint, l.BaseURL)
+		l.Organization = firstNonEmpty(o.Organization, l.Organization)
+		l.ProjectID = firstNonEmpty(o.Project, l.ProjectID)
+		l.Bucket = firstNonEmpty(o.Bucket, l.Bucket)
+		l.StoragePrefix = firstNonEmpty(o.StoragePrefix, l.StoragePrefix)
+		rs.Local = &l
+	}
+	if rs.Filesystem != nil {
+		f := *rs.Filesystem
+		f.Root = firstNonEmpty(o.Endpoint, f.Root)
+		f.Organization = firstNonEmpty(o.Organization, f.Organization)
+		f.ProjectID = firstNonEmpty(o.Project, f.ProjectID)
+		rs.Filesystem = &f
+	}
+	if rs.Anvil != nil {
+		a := *rs.Anvil
+		a.Endpoint = firstNonEmpty(o.Endpoint, a.Endpoint)
+		a.Organization = firstNonEmpty(o.Organization, a.Organization)
+		a.ProjectID = firstNonEmpty(o.Project, a.ProjectID)
+		rs.Anvil = &a
+	}
+	return rs, true, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// ParseOverrideAssignments parses key=value pairs as passed to the root
+// --config flag into Overrides.
+func ParseOverrideAssignments(pairs []string) (Overrides, error) {
+	var o Overrides
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return Overrides{}, fmt.Errorf("invalid config override %q: expected key=value", pair)
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "remote":
+			o.Remote = value
+		case "type":
+			o.RemoteType = value
+		case "endpoint":
+			o.Endpoint = value
+		case "organization":
+			o.Organization = value
+		case "project":
+			o.Project = value
+		case "bucket":
+			o.Bucket = value
+		case "storage_prefix", "storage-prefix":
+			o.StoragePrefix = value
+		case "profile":
+			o.Profile = value
+		case "auth":
+			o.Auth = value
+		default:
+			return Overrides{}, fmt.Errorf("unknown config override key %q", key)
+		}
+	}
+	return o, nil
+}
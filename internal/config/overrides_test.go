@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestLoadConfig_EnvOverridesStoredRemote(t *testing.T) {
+	setupTestRepo(t)
+
+	if _, err := UpdateRemote(Remote("origin"), RemoteSelect{
+		Gen3: &Gen3Remote{Endpoint: "https://stored.example", ProjectID: "stored-proj", Bucket: "stored-bucket"},
+	}); err != nil {
+		t.Fatalf("UpdateRemote error: %v", err)
+	}
+
+	t.Setenv(EnvEndpoint, "https://env.example")
+	t.Setenv(EnvBucket, "env-bucket")
+	t.Setenv(EnvProfile, "ci-profile")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	g := cfg.Remotes[Remote("origin")].Gen3
+	if g == nil {
+		t.Fatalf("expected gen3 remote")
+	}
+	if g.Endpoint != "https://env.example" || g.Bucket != "env-bucket" || g.ProjectID != "stored-proj" {
+		t.Fatalf("unexpected overridden remote: %#v", g)
+	}
+	if got := g.ProfileName("origin"); got != "ci-profile" {
+		t.Fatalf("ProfileName = %q, want ci-profile", got)
+	}
+
+	stored, err := loadStoredConfig()
+	if err != nil {
+		t.Fatalf("loadStoredConfig error: %v", err)
+	}
+	if stored.Remotes[Remote("origin")].Gen3.Endpoint != "https://stored.example" {
+		t.Fatalf("overrides leaked into stored config")
+	}
+}
+
+func TestLoadConfig_FlagOverridesWinOverEnv(t *testing.T) {
+	setupTestRepo(t)
+	t.Cleanup(func() { SetInvocationOverrides(Overrides{}) })
+
+	t.Setenv(EnvEndpoint, "https://env.example")
+	t.Setenv(EnvProject, "env-proj")
+	SetInvocationOverrides(Overrides{Project: "flag-proj"})
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.DefaultRemote != Remote(ORIGIN) {
+		t.Fatalf("expected synthesized default remote, got %q", cfg.DefaultRemote)
+	}
+	g := cfg.Remotes[Remote(ORIGIN)].Gen3
+	if g == nil || g.Endpoint != "https://env.example" || g.ProjectID != "flag-proj" {
+		t.Fatalf("unexpected synthesized remote: %#v", g)
+	}
+}
+
+func TestLoadConfig_OverridesApplyToResolvedRemote(t *testing.T) {
+	tmpDir := setupTestRepo(t)
+	for _, name := range []string{"origin", "staging"} {
+		if _, err := UpdateRemote(Remote(name), RemoteSelect{
+			Gen3: &Gen3Remote{Endpoint: "https://" + name + ".example", ProjectID: name + "-proj", Bucket: name + "-bucket"},
+		}); err != nil {
+			t.Fatalf("UpdateRemote error: %v", err)
+		}
+	}
+	cmd := exec.Command("git", "config", "drs.default-remote", "origin")
+	cmd.Dir = tmpDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git config failed: %v: %s", err, out)
+	}
+	t.Setenv(EnvProject, "ci-proj")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	// As with `git drs push --remote staging`.
+	remote, err := cfg.GetRemoteOrDefault("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, _ := cfg.GetRemote(remote).(*Gen3Remote)
+	if g == nil || g.ProjectID != "ci-proj" || g.Bucket != "staging-bucket" || g.Endpoint != "https://staging.example" {
+		t.Fatalf("override not applied to the resolved remote: %#v", g)
+	}
+	if cfg.Remotes[Remote("staging")].Gen3.ProjectID != "staging-proj" {
+		t.Fatal("resolving a remote must not change the loaded configuration")
+	}
+
+	// GIT_DRS_REMOTE selects the remote the overrides apply to when no
+	// --remote is given.
+	t.Setenv(EnvRemote, "staging")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	remote, err = cfg.GetRemoteOrDefault("")
+	if err != nil || remote != Remote("staging") {
+		t.Fatalf("GetRemoteOrDefault = %q, %v", remote, err)
+	}
+	if g, _ := cfg.GetRemote(remote).(*Gen3Remote); g == nil || g.ProjectID != "ci-proj" {
+		t.Fatalf("override not applied to GIT_DRS_REMOTE: %#v", g)
+	}
+}
+
+func TestParseOverrideAssignments(t *testing.T) {
+	o, err := ParseOverrideAssignments([]string{"remote=ci", "type=local", "storage-prefix=data/"})
+	if err != nil {
+		t.Fatalf("ParseOverrideAssignments error: %v", err)
+	}
+	if o.Remote != "ci" || o.RemoteType != "local" || o.StoragePrefix != "data/" {
+		t.Fatalf("unexpected overrides: %#v", o)
+	}
+	if _, err := ParseOverrideAssignments([]string{"nope"}); err == nil {
+		t.Fatalf("expected error for missing '='")
+	}
+	if _, err := ParseOverrideAssignments([]string{"color=blue"}); err == nil {
+		t.Fatalf("expected error for unknown key")
+	}
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+// PathScope registers the files under Prefix in Organization/Project instead
+// of the remote's own scope, so one repository can span several projects on
+// the same server. It is set with repeated drs.remote.<name>.path-scope
+// values of the form "<prefix>=<organization>/<project>".
+type PathScope struct {
+	Prefix       string `yaml:"prefix"`
+	Organization string `yaml:"organization"`
+	Project      string `yaml:"project"`
+	// Bucket and StoragePrefix are resolved from the bucket mapping when a
+	// client is built.
+	Bucket        string `yaml:"-"`
+	StoragePrefix string `yaml:"-"`
+}
+
+// ParsePathScope parses one drs.remote.<name>.path-scope value.
+func ParsePathScope(raw string) (PathScope, error) {
+	prefix, scope, ok := strings.Cut(strings.TrimSpace(raw), "=")
+	if !ok {
+		return PathScope{}, fmt.Errorf("invalid path scope %q: expected <prefix>=<organization>/<project>", raw)
+	}
+	prefix = cleanScopePath(prefix)
+	if prefix == "" || prefix == "." || strings.HasPrefix(prefix, "../") {
+		return PathScope{}, fmt.Errorf("invalid path scope %q: prefix must be a directory inside the repository", raw)
+	}
+	org, project, ok := strings.Cut(strings.TrimSpace(scope), "/")
+	org, project = strings.TrimSpace(org), strings.TrimSpace(project)
+	if !ok || org == "" || project == "" || strings.Contains(project, "/") {
+		return PathScope{}, fmt.Errorf("invalid path scope %q: expected <prefix>=<organization>/<project>", raw)
+	}
+	return PathScope{Prefix: prefix, Organization: org, Project: project}, nil
+}
+
+// ParsePathScopes parses every path-scope value and rejects a prefix listed
+// twice.
+func ParsePathScopes(raw []string) ([]PathScope, error) {
+	var scopes []PathScope
+	seen := map[string]bool{}
+	for _, r := range raw {
+		if strings.TrimSpace(r) == "" {
+			continue
+		}
+		s, err := ParsePathScope(r)
+		if err != nil {
+			return nil, err
+		}
+		if seen[s.Prefix] {
+			return nil, fmt.Errorf("path scope prefix %q is listed more than once", s.Prefix)
+		}
+		seen[s.Prefix] = true
+		scopes = append(scopes, s)
+	}
+	return scopes, nil
+}
+
+// String formats s as a drs.remote.<name>.path-scope value.
+func (s PathScope) String() string {
+	return s.Prefix + "/=" + s.Organization + "/" + s.Project
+}
+
+// Contains reports whether the repo-relative file is under the prefix.
+func (s PathScope) Contains(file string) bool {
+	file = cleanScopePath(file)
+	return file == s.Prefix || strings.HasPrefix(file, s.Prefix+"/")
+}
+
+// MatchPathScope returns the scope with the longest prefix containing file.
+func MatchPathScope(scopes []PathScope, file string) (PathScope, bool) {
+	var best PathScope
+	found := false
+	for _, s := range scopes {
+		if s.Contains(file) && (!found || len(s.Prefix) > len(best.Prefix)) {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
+
+func cleanScopePath(p string) string {
+	p = strings.TrimSpace(strings.ReplaceAll(p, "\\", "/"))
+	if p == "" {
+		return ""
+	}
+	return strings.TrimPrefix(path.Clean(strings.TrimPrefix(p, "/")), "./")
+}
+
+// RemotePathScopes returns the path scopes configured for remote.
+func RemotePathScopes(remote DRSRemote) []PathScope {
+	switch r := remote.(type) {
+	case *Gen3Remote:
+		if r != nil {
+			return r.PathScopes
+		}
+	case *LocalRemote:
+		if r != nil {
+			return r.PathScopes
+		}
+	}
+	return nil
+}
+
+// resolvePathScopes fills in each scope's bucket and storage prefix from the
+// bucket mapping, falling back to the remote's bucket.
+func resolvePathScopes(scopes []PathScope, bucket string) ([]PathScope, error) {
+	out := make([]PathScope, len(scopes))
+	for i, s := range scopes {
+		resolved, err := gitrepo.ResolveBucketScope(s.Organization, s.Project, bucket, "")
+		if err != nil {
+			return nil, fmt.Errorf("path scope %s: %w", s, err)
+		}
+		s.Bucket, s.StoragePrefix = resolved.Bucket, resolved.Prefix
+		out[i] = s
+	}
+	return out, nil
+}
+
+// ForPath returns the context to register file under: a copy scoped to the
+// matching path scope, or g itself. Path scopes are never translated by a
+// local-scope mapping.
+func (g *GitContext) ForPath(file string) *GitContext {
+	if g == nil {
+		return nil
+	}
+	s, ok := MatchPathScope(g.PathScopes, file)
+	if !ok {
+		return g
+	}
+	scoped := *g
+	scoped.Organization = s.Organization
+	scoped.ProjectId = s.Project
+	scoped.BucketName = s.Bucket
+	scoped.StoragePrefix = s.StoragePrefix
+	scoped.ProjectMap = nil
+	return &scoped
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+func TestParsePathScope(t *testing.T) {
+	s, err := ParsePathScope(" ./raw/ = program/rawdata ")
+	if err != nil {
+		t.Fatalf("ParsePathScope: %v", err)
+	}
+	if s.Prefix != "raw" || s.Organization != "program" || s.Project != "rawdata" {
+		t.Fatalf("parsed %+v", s)
+	}
+	if s.String() != "raw/=program/rawdata" {
+		t.Fatalf("String() = %q", s.String())
+	}
+
+	for _, bad := range []string{"raw", "raw/=rawdata", "=program/rawdata", "../up=program/rawdata", "raw/=program/a/b", "raw/=/rawdata"} {
+		if _, err := ParsePathScope(bad); err == nil {
+			t.Errorf("ParsePathScope(%q) succeeded, want an error", bad)
+		}
+	}
+	if _, err := ParsePathScopes([]string{"raw=p/a", "raw/=p/b"}); err == nil {
+		t.Fatal("duplicate prefix accepted")
+	}
+}
+
+func TestMatchPathScope(t *testing.T) {
+	scopes := []PathScope{
+		{Prefix: "raw", Organization: "program", Project: "rawdata"},
+		{Prefix: "raw/imaging", Organization: "program", Project: "imaging"},
+	}
+	tests := []struct {
+		file, want string
+	}{
+		{"raw/a.bam", "rawdata"},
+		{"raw/imaging/scan.tif", "imaging"},
+		{"./raw/imaging", "imaging"},
+		{"rawdata/a.bam", ""},
+		{"derived/a.bam", ""},
+	}
+	for _, tt := range tests {
+		s, ok := MatchPathScope(scopes, tt.file)
+		if got := s.Project; ok != (tt.want != "") || got != tt.want {
+			t.Errorf("MatchPathScope(%q) = %q, %v; want %q", tt.file, got, ok, tt.want)
+		}
+	}
+}
+
+func TestPathScopesRoundTripAndScopeClient(t *testing.T) {
+	setupTestRepo(t)
+	if err := gitrepo.SetBucketMapping("program", "rawdata", "raw-bucket", "raw-prefix"); err != nil {
+		t.Fatalf("SetBucketMapping: %v", err)
+	}
+
+	if _, err := UpdateRemote(Remote("origin"), RemoteSelect{
+		Local: &LocalRemote{
+			BaseURL:      "http://localhost:8080",
+			Organization: "program",
+			ProjectID:    "main",
+			Bucket:       "main-bucket",
+			PathScopes: []PathScope{
+				{Prefix: "raw", Organization: "program", Project: "rawdata"},
+				{Prefix: "derived", Organization: "program", Project: "derived"},
+			},
+		},
+	}); err != nil {
+		t.Fatalf("UpdateRemote: %v", err)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	remote := cfg.GetRemote(Remote("origin"))
+	if got := LocalProjectIDForPath(remote, "raw/x.bam"); got != "rawdata" {
+		t.Fatalf("LocalProjectIDForPath(raw) = %q", got)
+	}
+	if got := LocalProjectIDForPath(remote, "notes/x.bam"); got != "main" {
+		t.Fatalf("LocalProjectIDForPath(notes) = %q", got)
+	}
+
+	gc, err := cfg.GetRemoteClient(Remote("origin"), drslog.NewNoOpLogger())
+	if err != nil {
+		t.Fatalf("GetRemoteClient: %v", err)
+	}
+	raw := gc.ForPath("raw/x.bam")
+	if raw.ProjectId != "rawdata" || raw.BucketName != "raw-bucket" || raw.StoragePrefix != "raw-prefix" {
+		t.Fatalf("raw scope = %s/%s in %s/%s", raw.Organization, raw.ProjectId, raw.BucketName, raw.StoragePrefix)
+	}
+	// No bucket mapping: the remote's bucket, without its prefix.
+	derived := gc.ForPath("derived/y.bam")
+	if derived.ProjectId != "derived" || derived.BucketName != "main-bucket" || derived.StoragePrefix != "" {
+		t.Fatalf("derived scope = %s/%s in %s/%s", derived.Organization, derived.ProjectId, derived.BucketName, derived.StoragePrefix)
+	}
+	if gc.ForPath("notes/x.bam") != gc {
+		t.Fatal("unscoped path did not use the remote's own context")
+	}
+}
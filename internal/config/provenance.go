@@ -0,0 +1,24 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/provenance"
+)
+
+// ProvenanceSettings reads the drs.provenance.* keys from git config. The
+// signing key belongs to whoever pushes, not to a remote, so the keys are
+// not per remote.
+func ProvenanceSettings() provenance.Settings {
+	get := func(key string) string {
+		v, _ := gitrepo.GetGitConfigString("drs.provenance." + key)
+		return strings.TrimSpace(v)
+	}
+	return provenance.Settings{
+		KeyFile:       get("key-file"),
+		PublicKeyFile: get("public-key-file"),
+		SignCommand:   get("sign-command"),
+		VerifyCommand: get("verify-command"),
+	}
+}
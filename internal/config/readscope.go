@@ -0,0 +1,65 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/fenceauth"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	syconf "github.com/calypr/syfon/client/config"
+)
+
+// readOnlyCommand is set for commands that only read from remotes; their
+// clients use a token narrowed to drs.remote.<name>.read-scopes.
+var readOnlyCommand bool
+
+// SetReadOnlyCommand records whether the current command only reads.
+func SetReadOnlyCommand(readOnly bool) {
+	readOnlyCommand = readOnly
+}
+
+// exchangeScopedAPIKey requests the narrowed token; swapped in tests.
+var exchangeScopedAPIKey = fenceauth.ExchangeScopedAPIKey
+
+// ParseScopes splits a read-scopes value on spaces and commas.
+func ParseScopes(raw string) ([]string, error) {
+	scopes := strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	if len(scopes) == 0 {
+		return nil, errors.New("expected one or more fence scopes, e.g. \"openid data\"")
+	}
+	return scopes, nil
+}
+
+// ReadScopes reads drs.remote.<name>.read-scopes from git config; nil when
+// unset.
+func ReadScopes(remote string) ([]string, error) {
+	raw, _ := gitrepo.GetGitConfigString(fmt.Sprintf("drs.remote.%s.read-scopes", remote))
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	scopes, err := ParseScopes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("drs.remote.%s.read-scopes: %w", remote, err)
+	}
+	return scopes, nil
+}
+
+// downscope returns a copy of cred whose access token fence issued for
+// scopes only. The API key is left out of the copy, so nothing later in the
+// command can trade it for a full-power token.
+func downscope(ctx context.Context, remoteName string, cred *syconf.Credential, scopes []string) (*syconf.Credential, error) {
+	if strings.TrimSpace(cred.APIKey) == "" {
+		return nil, fmt.Errorf("remote %q sets read-scopes but its profile has no API key to exchange; log in with `git drs auth login` or unset drs.remote.%s.read-scopes", remoteName, remoteName)
+	}
+	token, err := exchangeScopedAPIKey(ctx, cred.APIEndpoint, cred.APIKey, scopes)
+	if err != nil {
+		return nil, WrapCredentialValidationError(remoteName, err)
+	}
+	scoped := *cred
+	scoped.AccessToken = token
+	scoped.APIKey = ""
+	scoped.KeyID = ""
+	return &scoped, nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	syconf "github.com/calypr/syfon/client/config"
+)
+
+func TestReadScopes(t *testing.T) {
+	dir := setupTestRepo(t)
+	if scopes, err := ReadScopes("origin"); err != nil || scopes != nil {
+		t.Fatalf("unset read-scopes = %v, %v", scopes, err)
+	}
+	cmd := exec.Command("git", "config", "drs.remote.origin.read-scopes", "openid, data")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git config: %v: %s", err, out)
+	}
+	scopes, err := ReadScopes("origin")
+	if err != nil || !reflect.DeepEqual(scopes, []string{"openid", "data"}) {
+		t.Fatalf("ReadScopes = %v, %v", scopes, err)
+	}
+	if _, err := ParseScopes(" , "); err == nil {
+		t.Fatal("expected error for a value without scopes")
+	}
+}
+
+func TestDownscope(t *testing.T) {
+	orig := exchangeScopedAPIKey
+	t.Cleanup(func() { exchangeScopedAPIKey = orig })
+	exchangeScopedAPIKey = func(_ context.Context, endpoint, apiKey string, scopes []string) (string, error) {
+		if endpoint != "https://gen3.example" || apiKey != "key" || !reflect.DeepEqual(scopes, []string{"data"}) {
+			return "", errors.New("unexpected exchange")
+		}
+		return "scoped-token", nil
+	}
+
+	cred := &syconf.Credential{APIEndpoint: "https://gen3.example", KeyID: "id", APIKey: "key", AccessToken: "full-token"}
+	scoped, err := downscope(context.Background(), "origin", cred, []string{"data"})
+	if err != nil {
+		t.Fatalf("downscope: %v", err)
+	}
+	if scoped.AccessToken != "scoped-token" || scoped.APIKey != "" || scoped.KeyID != "" {
+		t.Fatalf("scoped credential should carry only the narrowed token, got %+v", scoped)
+	}
+	if cred.AccessToken != "full-token" || cred.APIKey != "key" {
+		t.Fatalf("stored credential must not change, got %+v", cred)
+	}
+	if _, err := downscope(context.Background(), "origin", &syconf.Credential{AccessToken: "t"}, []string{"data"}); err == nil {
+		t.Fatal("expected error for a profile without an API key")
+	}
+
+	gc := &GitContext{RemoteName: "origin", Scopes: []string{"data"}}
+	if err := gc.RequireWrite(); !errors.Is(err, ErrReadOnlyRemote) {
+		t.Fatalf("a down-scoped context should refuse writes, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/registerpolicy"
+)
+
+// RegisterSettings reads drs.register.include and drs.register.exclude from
+// git config. A malformed pattern is an error rather than being dropped, since
+// dropping an exclude would register files the user meant to keep out.
+func RegisterSettings() (registerpolicy.Policy, error) {
+	var p registerpolicy.Policy
+	for _, s := range []struct {
+		key string
+		dst *[]string
+	}{
+		{"drs.register.include", &p.Include},
+		{"drs.register.exclude", &p.Exclude},
+	} {
+		raw, _ := gitrepo.GetGitConfigString(s.key)
+		patterns, err := registerpolicy.ParsePatterns(raw)
+		if err != nil {
+			return registerpolicy.Policy{}, fmt.Errorf("%s: %w", s.key, err)
+		}
+		*s.dst = patterns
+	}
+	return p, nil
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+// RegistrationMode selects when git-drs registers records for new files.
+type RegistrationMode string
+
+const (
+	// RegisterAtPush registers records during push, with the upload. It is
+	// the default.
+	RegisterAtPush RegistrationMode = "push"
+	// RegisterAtCommit registers records from the pre-commit hook, so a
+	// commit's files have records before anything is uploaded. Files the
+	// hook cannot register, such as while offline, are queued and
+	// registered by the next commit or push.
+	RegisterAtCommit RegistrationMode = "commit"
+)
+
+// ParseRegistrationMode parses commit or push; "" is RegisterAtPush.
+func ParseRegistrationMode(raw string) (RegistrationMode, error) {
+	switch m := RegistrationMode(strings.ToLower(strings.TrimSpace(raw))); m {
+	case "":
+		return RegisterAtPush, nil
+	case RegisterAtPush, RegisterAtCommit:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid registration mode %q: use commit or push", raw)
+	}
+}
+
+// RegistrationSettings reads drs.registration.mode from git config.
+func RegistrationSettings() (RegistrationMode, error) {
+	raw, _ := gitrepo.GetGitConfigString("drs.registration.mode")
+	m, err := ParseRegistrationMode(raw)
+	if err != nil {
+		return "", drserrors.WithKind(drserrors.ErrConfig, fmt.Errorf("drs.registration.mode: %w", err))
+	}
+	return m, nil
+}
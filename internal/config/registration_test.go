@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+func TestRegistrationSettings(t *testing.T) {
+	setupTestRepo(t)
+	if m, err := RegistrationSettings(); err != nil || m != RegisterAtPush {
+		t.Fatalf("unset mode = %q, %v; want push", m, err)
+	}
+	if err := gitrepo.SetGitConfigOptions(map[string]string{"drs.registration.mode": " Commit "}); err != nil {
+		t.Fatal(err)
+	}
+	if m, err := RegistrationSettings(); err != nil || m != RegisterAtCommit {
+		t.Fatalf("mode = %q, %v; want commit", m, err)
+	}
+	if err := gitrepo.SetGitConfigOptions(map[string]string{"drs.registration.mode": "checkout"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RegistrationSettings(); err == nil {
+		t.Fatal("invalid mode accepted")
+	}
+}
@@ -2,13 +2,31 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/calypr/data-client/credentials"
+	"github.com/calypr/git-drs/internal/accesspolicy"
+	"github.com/calypr/git-drs/internal/compression"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/encryption"
 	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/httpclient"
+	"github.com/calypr/git-drs/internal/metrics"
+	"github.com/calypr/git-drs/internal/origin"
+	"github.com/calypr/git-drs/internal/projectmap"
+	"github.com/calypr/git-drs/internal/provenance"
+	"github.com/calypr/git-drs/internal/signedurl"
+	"github.com/calypr/git-drs/internal/throttle"
+	"github.com/calypr/git-drs/internal/usage"
 	syclient "github.com/calypr/syfon/client"
 	syconf "github.com/calypr/syfon/client/config"
 )
@@ -26,6 +44,7 @@ type DRSRemote interface {
 
 type GitContext struct {
 	Client             *syclient.Client
+	RemoteName         string
 	Organization       string
 	ProjectId          string
 	BucketName         string
@@ -34,13 +53,98 @@ type GitContext struct {
 	ForceUpload        bool
 	MultiPartThreshold int64
 	UploadConcurrency  int
-	Logger             *slog.Logger
-	Credential         *syconf.Credential
+	// DownloadConcurrency is how many objects fetch, pull and download
+	// transfer at once.
+	DownloadConcurrency int
+	Logger              *slog.Logger
+	Credential          *syconf.Credential
+	// Anonymous is set for auth=none remotes; such contexts are read-only.
+	Anonymous bool
+	// Scopes are the fence scopes the token was narrowed to for a read-only
+	// command (drs.remote.<name>.read-scopes); such contexts are read-only.
+	Scopes []string
+	// ReadOnly is set for remotes flagged read-only, such as mirrors of
+	// another platform's data; writes are refused before any request.
+	ReadOnly bool
+	// ProjectMap translates IDs and authz between the repository's local
+	// scope and this remote's; nil when the remote uses the local scope.
+	ProjectMap *projectmap.Mapping
+	// AccessPolicy orders a record's access methods for downloads.
+	AccessPolicy accesspolicy.Policy
+	// Encryption, when enabled, encrypts content before upload and decrypts
+	// records carrying an encryption block on download.
+	Encryption encryption.Settings
+	// Compression stores matching file types compressed and restores
+	// records carrying a content-encoding block on download.
+	Compression compression.Settings
+	// Provenance, when enabled, signs an attestation onto each record
+	// registered.
+	Provenance provenance.Settings
+	// Usage records each verified download to a ledger or webhook.
+	Usage usage.Settings
+	// SharedCache is a machine-wide object directory downloads are linked
+	// from and added to; empty when unset.
+	SharedCache string
+	// CheckAuthz checks the token's fence permissions on the target
+	// projects before a push uploads anything.
+	CheckAuthz bool
+	// Origin selects the repository coordinates recorded on registered
+	// records.
+	Origin origin.Fields
+	// Upload selects the credentials object content is uploaded with.
+	Upload UploadSettings
+	// Verify selects the objects a push checks for readability after
+	// registering and uploading them.
+	Verify VerifyPolicy
+	// PathScopes register files under some directories in other projects;
+	// see ForPath.
+	PathScopes []PathScope
+	// IDs mints the DRS IDs of objects staged for this remote.
+	IDs drsobject.Minter
+	// Report writes each push's per-object timing report under
+	// .drs/reports (drs.push.report); the summary is always logged.
+	Report bool
+	// QueueOffline lets a push that cannot reach the DRS server queue its
+	// files in the outbox and push the refs anyway
+	// (drs.push.queue-offline).
+	QueueOffline bool
+	// SignedURLs caches the access URLs resolved through this context, keyed
+	// by remote, object, and access method.
+	SignedURLs *signedurl.Cache
+}
+
+// ErrReadOnlyRemote is returned when a write is attempted on an auth=none
+// or read-only remote.
+var ErrReadOnlyRemote = errors.New("remote is read-only")
+
+// RequireWrite fails for anonymous and read-only contexts, before any
+// request is sent.
+func (g *GitContext) RequireWrite() error {
+	switch {
+	case g == nil:
+		return nil
+	case g.ReadOnly:
+		return fmt.Errorf("%w: remote %q is marked read-only and does not accept registration, upload, or delete; unset drs.remote.%s.read-only to write to it", ErrReadOnlyRemote, g.RemoteName, g.RemoteName)
+	case len(g.Scopes) > 0:
+		return fmt.Errorf("%w: remote %q is using a token narrowed to scopes [%s] for a read-only command", ErrReadOnlyRemote, g.RemoteName, strings.Join(g.Scopes, " "))
+	case g.Anonymous:
+		return fmt.Errorf("%w: remote %q is configured with auth=none; configure credentials for it to register, upload, or delete", ErrReadOnlyRemote, g.RemoteName)
+	}
+	return nil
 }
 
 type RemoteSelect struct {
-	Gen3  *Gen3Remote
-	Local *LocalRemote
+	Gen3       *Gen3Remote
+	Local      *LocalRemote
+	Filesystem *FilesystemRemote
+	Anvil      *AnvilRemote
+}
+
+// ReadOnly reports whether the selected remote is flagged read-only. AnVIL
+// remotes always are.
+func (r RemoteSelect) ReadOnly() bool {
+	return (r.Gen3 != nil && r.Gen3.ReadOnly) || (r.Local != nil && r.Local.ReadOnly) ||
+		(r.Filesystem != nil && r.Filesystem.ReadOnly) || r.Anvil != nil
 }
 
 type Gen3Remote struct {
@@ -49,6 +153,38 @@ type Gen3Remote struct {
 	Bucket        string `yaml:"bucket"`
 	Organization  string `yaml:"organization"`
 	StoragePrefix string `yaml:"storage_prefix"`
+	// Profile names the credential profile; it defaults to the remote name.
+	Profile string `yaml:"profile"`
+	// Auth is AuthNone for public servers that need no credentials.
+	Auth string `yaml:"auth"`
+	// CredentialStore is where the profile is kept: CredentialStoreFile
+	// (the default) or CredentialStoreKeyring.
+	CredentialStore string `yaml:"credential_store"`
+	// LocalScope is the organization/project the repository uses for records
+	// this remote stores under Organization/ProjectID.
+	LocalScope string `yaml:"local_scope"`
+	// PathScopes register files under some directories in other projects.
+	PathScopes []PathScope `yaml:"path_scopes"`
+	// ReadOnly refuses registration, upload, and delete on this remote.
+	ReadOnly bool `yaml:"read_only"`
+}
+
+// AuthNone marks a remote as anonymous: no profile is loaded and requests
+// carry no Authorization header.
+const AuthNone = "none"
+
+// IsValidAuthMode checks a drs.remote.<name>.auth value.
+func IsValidAuthMode(mode string) error {
+	switch strings.TrimSpace(mode) {
+	case "", AuthNone:
+		return nil
+	}
+	return fmt.Errorf("invalid auth mode %q: expected %q or empty", mode, AuthNone)
+}
+
+// Anonymous reports whether the remote is configured with auth=none.
+func (s Gen3Remote) Anonymous() bool {
+	return strings.TrimSpace(s.Auth) == AuthNone
 }
 
 func (s Gen3Remote) GetProjectId() string     { return s.ProjectID }
@@ -57,16 +193,68 @@ func (s Gen3Remote) GetEndpoint() string      { return s.Endpoint }
 func (s Gen3Remote) GetBucketName() string    { return s.Bucket }
 func (s Gen3Remote) GetStoragePrefix() string { return s.StoragePrefix }
 
+// LocalProjectID returns the project the repository uses for records on
+// remote: the mapped local project when LocalScope is set, otherwise the
+// remote's own project.
+func LocalProjectID(remote DRSRemote) string {
+	if g, ok := remote.(*Gen3Remote); ok && g != nil {
+		if m, err := projectmap.Parse(g.LocalScope, g.Organization, g.ProjectID); err == nil && m != nil {
+			return m.LocalProject
+		}
+	}
+	return remote.GetProjectId()
+}
+
+// LocalProjectIDForPath is LocalProjectID for one repo-relative file: the
+// project of the path scope containing it, if any.
+func LocalProjectIDForPath(remote DRSRemote, file string) string {
+	if s, ok := MatchPathScope(RemotePathScopes(remote), file); ok {
+		return s.Project
+	}
+	return LocalProjectID(remote)
+}
+
+// ProfileName returns the credential profile to load for remoteName.
+func (s Gen3Remote) ProfileName(remoteName string) string {
+	if strings.TrimSpace(s.Profile) != "" {
+		return strings.TrimSpace(s.Profile)
+	}
+	return remoteName
+}
+
 func (s Gen3Remote) GetClient(remoteName string, logger *slog.Logger) (*GitContext, error) {
-	manager := syconf.NewConfigure(logger)
-	cred, err := manager.Load(remoteName)
+	if s.Anonymous() {
+		return newGitContext(remoteName, syconf.Credential{APIEndpoint: s.Endpoint}, s, logger)
+	}
+	cred, err := LoadProfile(s.CredentialStore, s.ProfileName(remoteName), logger)
 	if err != nil {
 		return nil, err
 	}
 	if err := credentials.EnsureValidCredential(context.Background(), cred, logger); err != nil {
 		return nil, WrapCredentialValidationError(remoteName, err)
 	}
-	return newGitContext(*cred, s, logger)
+	if !readOnlyCommand {
+		return newGitContext(remoteName, *cred, s, logger)
+	}
+	scopes, err := ReadScopes(remoteName)
+	if err != nil {
+		return nil, drserrors.WithKind(drserrors.ErrConfig, err)
+	}
+	if len(scopes) == 0 {
+		return newGitContext(remoteName, *cred, s, logger)
+	}
+	if cred, err = downscope(context.Background(), remoteName, cred, scopes); err != nil {
+		return nil, err
+	}
+	if logger != nil {
+		logger.Debug("using a down-scoped token", "remote", remoteName, "scopes", scopes)
+	}
+	gc, err := newGitContext(remoteName, *cred, s, logger)
+	if err != nil {
+		return nil, err
+	}
+	gc.Scopes = scopes
+	return gc, nil
 }
 
 type LocalRemote struct {
@@ -77,6 +265,10 @@ type LocalRemote struct {
 	StoragePrefix string
 	BasicUsername string
 	BasicPassword string
+	// PathScopes register files under some directories in other projects.
+	PathScopes []PathScope
+	// ReadOnly refuses registration, upload, and delete on this remote.
+	ReadOnly bool
 }
 
 func (l LocalRemote) GetProjectId() string {
@@ -92,9 +284,15 @@ func (l LocalRemote) GetBucketName() string    { return l.Bucket }
 func (l LocalRemote) GetStoragePrefix() string { return l.StoragePrefix }
 
 func (l LocalRemote) GetClient(remoteName string, logger *slog.Logger) (*GitContext, error) {
-	if username, password, err := gitrepo.GetRemoteBasicAuth(remoteName); err == nil && username != "" && password != "" {
-		l.BasicUsername = username
-		l.BasicPassword = password
+	auth, err := ResolveLocalAuth(remoteName, l.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if auth.Source != "" {
+		l.BasicUsername, l.BasicPassword = auth.Username, auth.Password
+		if logger != nil {
+			logger.Debug("local remote credentials", "remote", remoteName, "source", auth.Source, "bearer", auth.Token != "")
+		}
 	}
 	projectID := l.GetProjectId()
 	bucketName := l.GetBucketName()
@@ -113,13 +311,52 @@ func (l LocalRemote) GetClient(remoteName string, logger *slog.Logger) (*GitCont
 		storagePrefix = scope.Prefix
 	}
 
-	cred := &syconf.Credential{APIEndpoint: l.BaseURL}
+	pathScopes, err := resolvePathScopes(l.PathScopes, l.GetBucketName())
+	if err != nil {
+		return nil, err
+	}
+
+	cred := &syconf.Credential{APIEndpoint: l.BaseURL, AccessToken: auth.Token}
 	if l.BasicUsername != "" || l.BasicPassword != "" {
 		cred.KeyID = l.BasicUsername
 		cred.APIKey = l.BasicPassword
 	}
 
-	raw, err := syclient.New(l.BaseURL, syclient.WithBasicAuth(cred.KeyID, cred.APIKey))
+	// The client sends the bearer token instead of basic auth when both are set.
+	client, err := newSyfonClient(l.BaseURL, remoteName, syclient.WithBasicAuth(cred.KeyID, cred.APIKey), syclient.WithBearerToken(cred.AccessToken))
+	if err != nil {
+		return nil, err
+	}
+
+	tuning := loadTransferTuning()
+	return &GitContext{
+		Client:              client,
+		SignedURLs:          signedurl.NewCache(),
+		Organization:        l.GetOrganization(),
+		ProjectId:           projectID,
+		BucketName:          bucketName,
+		StoragePrefix:       storagePrefix,
+		Upsert:              tuning.Upsert,
+		MultiPartThreshold:  tuning.MultiPartThreshold,
+		UploadConcurrency:   tuning.UploadConcurrency,
+		DownloadConcurrency: tuning.DownloadConcurrency,
+		Logger:              logger,
+		Credential:          cred,
+		AccessPolicy:        AccessPolicySettings(),
+		PathScopes:          pathScopes,
+	}, nil
+}
+
+// newSyfonClient builds the DRS client for a remote at baseURL. Every remote
+// type with a server goes through here, so all of them get the same
+// transport stack from httpClientOptions and differ only in the auth options
+// passed.
+func newSyfonClient(baseURL, remoteName string, auth ...syclient.Option) (*syclient.Client, error) {
+	opts, err := httpClientOptions(baseURL, remoteName)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := syclient.New(baseURL, append(opts, auth...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -127,19 +364,121 @@ func (l LocalRemote) GetClient(remoteName string, logger *slog.Logger) (*GitCont
 	if !ok {
 		return nil, fmt.Errorf("unexpected syfon client type %T", raw)
 	}
+	return client, nil
+}
 
-	return &GitContext{
-		Client:        client,
-		Organization:  l.GetOrganization(),
-		ProjectId:     projectID,
-		BucketName:    bucketName,
-		StoragePrefix: storagePrefix,
-		Logger:        logger,
-		Credential:    cred,
-	}, nil
+// transferTuning holds the transfer settings shared by every remote type.
+type transferTuning struct {
+	Upsert              bool
+	MultiPartThreshold  int64
+	UploadConcurrency   int
+	DownloadConcurrency int
+}
+
+// loadTransferTuning reads drs.upsert, drs.multipart-threshold and the
+// worker counts, falling back to the user-level transfer defaults.
+func loadTransferTuning() transferTuning {
+	transfer := userTransferDefaults()
+	defaultConcurrency := int64(4)
+	if transfer.Concurrency > 0 {
+		defaultConcurrency = int64(transfer.Concurrency)
+	}
+	defaultThresholdMB := int64(5120)
+	if transfer.MultipartThresholdMB > 0 {
+		defaultThresholdMB = int64(transfer.MultipartThresholdMB)
+	}
+	defaultUpsert := false
+	if transfer.Upsert != nil {
+		defaultUpsert = *transfer.Upsert
+	}
+
+	shared := int(gitrepo.GetGitConfigInt("lfs.concurrenttransfers", defaultConcurrency))
+	return transferTuning{
+		Upsert:              gitrepo.GetGitConfigBool("drs.upsert", defaultUpsert),
+		MultiPartThreshold:  gitrepo.GetGitConfigInt("drs.multipart-threshold", defaultThresholdMB) * 1024 * 1024,
+		UploadConcurrency:   transferWorkers(EnvUploadWorkers, "drs.transfer.upload-workers", transfer.UploadWorkers, shared),
+		DownloadConcurrency: transferWorkers(EnvDownloadWorkers, "drs.transfer.download-workers", transfer.DownloadWorkers, shared),
+	}
+}
+
+// transferWorkers resolves the worker count for one direction: the
+// environment variable env, then the git config key, then the user-level
+// setting, then shared (lfs.concurrenttransfers or the user-level
+// concurrency). A setting for one direction wins over the shared one at any
+// level, since git drs init always writes lfs.concurrenttransfers. Unset,
+// unparsable and non-positive values are skipped.
+func transferWorkers(env, key string, user, shared int) int {
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(env))); err == nil && n > 0 {
+		return n
+	}
+	if n := int(gitrepo.GetGitConfigInt(key, 0)); n > 0 {
+		return n
+	}
+	if user > 0 {
+		return user
+	}
+	return max(shared, 1)
+}
+
+// httpClientOptions builds the HTTP client for a remote at baseURL. DRS and
+// data API requests are retried on transient failures, and every request,
+// including those to signed storage URLs, uses the drs.http proxy, CA, and
+// connection settings, shares the remote's pooled connections, and goes
+// through the metrics transport
+// when metrics reporting is enabled, through the request-logging transport
+// when trace logging is enabled, and through the S3 request options of
+// remoteName when any are set. The remote's S3 TLS options apply to storage
+// requests only, never to the DRS server. Requests that move object bytes share the
+// process-wide transfer limiter when bandwidth or schedule limits are set.
+func httpClientOptions(baseURL, remoteName string) ([]syclient.Option, error) {
+	limiter, err := TransferLimiter()
+	if err != nil {
+		return nil, err
+	}
+	s3, err := LoadS3Settings(remoteName)
+	if err != nil {
+		return nil, err
+	}
+	httpSettings, err := httpclient.Current()
+	if err != nil {
+		return nil, err
+	}
+	// Clients for the same remote share its transports, and with them
+	// their connections, however often the remote's client is built.
+	key := remoteName + "\x00" + baseURL
+	base, err := httpclient.ForRemote(key, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rt http.RoundTripper = base
+	if s3.Remote.tls != nil {
+		storage, err := httpclient.ForRemote(key+"\x00storage", func(t *http.Transport) {
+			t.TLSClientConfig = s3.Remote.tls.Clone()
+		})
+		if err != nil {
+			return nil, err
+		}
+		rt = &storageTLSTransport{api: base, storage: storage, isStorage: storageRequest(baseURL)}
+	}
+	rt = throttle.Transport(rt, limiter, storageRequest(baseURL))
+	if s3.requesterPays() {
+		rt = &s3Transport{base: rt, settings: s3}
+	}
+	if drslog.TraceEnabled() {
+		rt = drslog.Transport(rt)
+	}
+	if MetricsSettings().Enabled() {
+		rt = metrics.Transport(rt)
+	}
+	// No overall client timeout: it would cut off large downloads. API
+	// calls get their own deadline in the retry transport, and the response
+	// header timeout bounds an unresponsive storage server.
+	return []syclient.Option{syclient.WithHTTPClient(&http.Client{
+		Transport: newRetryTransport(baseURL, rt, httpSettings.APITimeout),
+	})}, nil
 }
 
-func newGitContext(profileConfig syconf.Credential, remote Gen3Remote, logger *slog.Logger) (*GitContext, error) {
+func newGitContext(remoteName string, profileConfig syconf.Credential, remote Gen3Remote, logger *slog.Logger) (*GitContext, error) {
 	if _, err := url.Parse(profileConfig.APIEndpoint); err != nil {
 		return nil, err
 	}
@@ -147,6 +486,10 @@ func newGitContext(profileConfig syconf.Credential, remote Gen3Remote, logger *s
 	if projectID == "" {
 		return nil, fmt.Errorf("no gen3 project specified")
 	}
+	projectMap, err := projectmap.Parse(remote.LocalScope, remote.GetOrganization(), projectID)
+	if err != nil {
+		return nil, err
+	}
 
 	scope, err := gitrepo.ResolveBucketScope(
 		remote.GetOrganization(),
@@ -155,34 +498,42 @@ func newGitContext(profileConfig syconf.Credential, remote Gen3Remote, logger *s
 		remote.GetStoragePrefix(),
 	)
 	if err != nil {
-		return nil, err
+		if !remote.Anonymous() {
+			return nil, err
+		}
+		// Anonymous remotes are read-only, so no bucket mapping is needed.
+		scope = gitrepo.ResolvedBucketScope{Bucket: remote.GetBucketName(), Prefix: remote.GetStoragePrefix()}
+	}
+	pathScopes := remote.PathScopes
+	if !remote.Anonymous() {
+		if pathScopes, err = resolvePathScopes(remote.PathScopes, remote.GetBucketName()); err != nil {
+			return nil, err
+		}
 	}
 
-	raw, err := syclient.New(profileConfig.APIEndpoint, syclient.WithBearerToken(profileConfig.AccessToken))
+	client, err := newSyfonClient(profileConfig.APIEndpoint, remoteName, syclient.WithBearerToken(profileConfig.AccessToken))
 	if err != nil {
 		return nil, err
 	}
-	client, ok := raw.(*syclient.Client)
-	if !ok {
-		return nil, fmt.Errorf("unexpected syfon client type %T", raw)
-	}
-
-	uploadConcurrency := int(gitrepo.GetGitConfigInt("lfs.concurrenttransfers", 4))
-	if uploadConcurrency < 1 {
-		uploadConcurrency = 1
-	}
 
+	tuning := loadTransferTuning()
 	return &GitContext{
-		Client:             client,
-		ProjectId:          projectID,
-		BucketName:         scope.Bucket,
-		Organization:       remote.GetOrganization(),
-		StoragePrefix:      scope.Prefix,
-		Upsert:             gitrepo.GetGitConfigBool("drs.upsert", false),
-		MultiPartThreshold: int64(gitrepo.GetGitConfigInt("drs.multipart-threshold", 5120)) * 1024 * 1024,
-		UploadConcurrency:  uploadConcurrency,
-		Logger:             logger,
-		Credential:         &profileConfig,
+		Client:              client,
+		SignedURLs:          signedurl.NewCache(),
+		ProjectId:           projectID,
+		BucketName:          scope.Bucket,
+		Organization:        remote.GetOrganization(),
+		StoragePrefix:       scope.Prefix,
+		Upsert:              tuning.Upsert,
+		MultiPartThreshold:  tuning.MultiPartThreshold,
+		UploadConcurrency:   tuning.UploadConcurrency,
+		DownloadConcurrency: tuning.DownloadConcurrency,
+		Logger:              logger,
+		Credential:          &profileConfig,
+		Anonymous:           remote.Anonymous(),
+		ProjectMap:          projectMap,
+		AccessPolicy:        AccessPolicySettings(),
+		PathScopes:          pathScopes,
 	}, nil
 }
 
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/httpclient"
+	"github.com/calypr/git-drs/internal/metrics"
+)
+
+// maxAPIAttempts bounds how often a DRS or data API request is sent.
+const maxAPIAttempts = 4
+
+// maxRetryAfter caps how long a Retry-After header can make a request wait.
+const maxRetryAfter = 30 * time.Second
+
+// apiRetryBackoff is the wait before retry n (from 1); swapped in tests.
+var apiRetryBackoff = func(n int) time.Duration {
+	return time.Duration(1<<(n-1)) * 500 * time.Millisecond
+}
+
+// retryTransport retries DRS and data API requests to the remote's server
+// and bounds each attempt by timeout. The syfon client retries its
+// signed-URL and index requests itself, but its generated API clients do
+// not, so without this a single 503 from the server failed a whole push or
+// pull, and a server that stopped responding mid-body hung it. Requests to
+// other hosts and paths, such as signed storage URLs, pass through untouched
+// so they are not retried twice or cut off mid-transfer.
+type retryTransport struct {
+	base     http.RoundTripper
+	host     string
+	basePath string
+	timeout  time.Duration
+}
+
+func newRetryTransport(baseURL string, base http.RoundTripper, timeout time.Duration) http.RoundTripper {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return base
+	}
+	return &retryTransport{base: base, host: u.Host, basePath: strings.TrimRight(u.Path, "/"), timeout: timeout}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.api(req) {
+		return t.base.RoundTrip(req)
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return httpclient.RoundTripWithin(t.base, req, t.timeout)
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := httpclient.RoundTripWithin(t.base, req, t.timeout)
+		if attempt == maxAPIAttempts || !retryable(req, resp, err) {
+			return resp, err
+		}
+		metrics.CountRetry(req.Context())
+		wait := apiRetryBackoff(attempt)
+		if resp != nil {
+			if d, ok := retryAfter(resp); ok {
+				wait = d
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if req.GetBody != nil {
+			body, gerr := req.GetBody()
+			if gerr != nil {
+				return nil, gerr
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// api reports whether req is a DRS or data API request to the remote.
+func (t *retryTransport) api(req *http.Request) bool {
+	if req.URL.Host != t.host || signedURL(req.URL) {
+		return false
+	}
+	path := strings.TrimPrefix(req.URL.Path, t.basePath)
+	return strings.HasPrefix(path, "/ga4gh/drs/") || strings.HasPrefix(path, "/data/")
+}
+
+// retryable reports whether a failed attempt is worth repeating. 429 and 503
+// mean the server did not act on the request, so any method is retried;
+// other server errors, transport failures, and attempts that timed out are
+// retried only for methods that are safe to repeat.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		var timeout *httpclient.TimeoutError
+		if errors.As(err, &timeout) {
+			return idempotent(req.Method)
+		}
+		return idempotent(req.Method) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent(req.Method)
+	}
+	return false
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// retryAfter reads a Retry-After header given in seconds.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	secs, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Retry-After")))
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	return min(time.Duration(secs)*time.Second, maxRetryAfter), true
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/calypr/git-drs/internal/httpclient"
+	"github.com/calypr/git-drs/internal/metrics"
+)
+
+func TestRetryTransport(t *testing.T) {
+	orig := apiRetryBackoff
+	t.Cleanup(func() { apiRetryBackoff = orig })
+	apiRetryBackoff = func(int) time.Duration { return 0 }
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		status    int
+		failures  int32
+		wantCalls int32
+		wantCode  int
+	}{
+		{"get retried through 503s", http.MethodGet, "/api/ga4gh/drs/v1/objects/x", http.StatusServiceUnavailable, 2, 3, http.StatusOK},
+		{"post retried on 503", http.MethodPost, "/api/ga4gh/drs/v1/objects/register", http.StatusServiceUnavailable, 1, 2, http.StatusOK},
+		{"post not retried on 500", http.MethodPost, "/api/ga4gh/drs/v1/objects/register", http.StatusInternalServerError, 1, 1, http.StatusInternalServerError},
+		{"data api retried on 502", http.MethodGet, "/api/data/upload/x", http.StatusBadGateway, 1, 2, http.StatusOK},
+		{"attempts are bounded", http.MethodGet, "/api/data/buckets", http.StatusServiceUnavailable, 10, maxAPIAttempts, http.StatusServiceUnavailable},
+		{"client errors not retried", http.MethodGet, "/api/ga4gh/drs/v1/objects/x", http.StatusNotFound, 1, 1, http.StatusNotFound},
+		{"other paths left to their client", http.MethodGet, "/api/index", http.StatusServiceUnavailable, 1, 1, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if r.Method == http.MethodPost && string(body) != "payload" {
+					t.Errorf("attempt %d body = %q, want payload", calls.Load()+1, body)
+				}
+				if calls.Add(1) <= tt.failures {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer srv.Close()
+
+			client := &http.Client{Transport: newRetryTransport(srv.URL+"/api", http.DefaultTransport, 0)}
+			var retries atomic.Int64
+			ctx := metrics.WithRetryCount(context.Background(), &retries)
+			req, err := http.NewRequestWithContext(ctx, tt.method, srv.URL+tt.path, strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantCode || calls.Load() != tt.wantCalls {
+				t.Fatalf("status %d after %d calls, want %d after %d", resp.StatusCode, calls.Load(), tt.wantCode, tt.wantCalls)
+			}
+			if got := retries.Load(); got != int64(tt.wantCalls-1) {
+				t.Fatalf("counted %d retries, want %d", got, tt.wantCalls-1)
+			}
+		})
+	}
+}
+
+func TestRetryAfterIsCapped(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"3600"}}}
+	if d, ok := retryAfter(resp); !ok || d != maxRetryAfter {
+		t.Fatalf("retryAfter = %v, %v; want %v", d, ok, maxRetryAfter)
+	}
+}
+
+func TestRetryTransportTimesOutStalledCalls(t *testing.T) {
+	orig := apiRetryBackoff
+	t.Cleanup(func() { apiRetryBackoff = orig })
+	apiRetryBackoff = func(int) time.Duration { return 0 }
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		// The server notices a closed connection only once the body is read.
+		_, _ = io.ReadAll(r.Body)
+		if r.URL.Query().Has("X-Amz-Signature") {
+			time.Sleep(100 * time.Millisecond)
+		} else if n == 1 || r.Method == http.MethodPost {
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: newRetryTransport(srv.URL, http.DefaultTransport, 20*time.Millisecond)}
+
+	resp, err := client.Get(srv.URL + "/ga4gh/drs/v1/objects/x")
+	if err != nil {
+		t.Fatalf("stalled GET was not retried: %v", err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 2 {
+		t.Fatalf("GET took %d calls, want 2", calls.Load())
+	}
+
+	calls.Store(0)
+	_, err = client.Post(srv.URL+"/ga4gh/drs/v1/objects/register", "application/json", strings.NewReader("{}"))
+	var timeout *httpclient.TimeoutError
+	if !errors.As(err, &timeout) || calls.Load() != 1 {
+		t.Fatalf("stalled POST: err=%v after %d calls, want a TimeoutError after 1", err, calls.Load())
+	}
+
+	resp, err = client.Get(srv.URL + "/data/download/x?X-Amz-Signature=abc")
+	if err != nil {
+		t.Fatalf("signed storage request was bounded by the API timeout: %v", err)
+	}
+	resp.Body.Close()
+}
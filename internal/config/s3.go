@@ -0,0 +1,312 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/httpclient"
+)
+
+// RequestPayerHeader acknowledges that the requester is billed for a
+// request to a requester-pays bucket.
+const RequestPayerHeader = "X-Amz-Request-Payer"
+
+// Signature versions accepted by drs.remote.<name>.s3-signature-version.
+const (
+	S3SignatureV4 = "v4"
+	S3SignatureV2 = "v2"
+)
+
+// S3Options are request options for one S3 bucket.
+type S3Options struct {
+	// RequesterPays sends the request-payer header so reads of a
+	// requester-pays bucket are billed to the caller instead of refused.
+	RequesterPays bool
+	// Accelerate sends requests through the bucket's transfer acceleration
+	// endpoint. It only applies to requests git-drs signs itself; signed URLs
+	// issued by the DRS server keep the host they were signed for.
+	Accelerate bool
+	// PathStyle, when set, chooses path-style (true) or virtual-hosted
+	// (false) addressing. Unset, clients with a custom endpoint use path
+	// style and AWS clients use virtual-hosted style.
+	PathStyle *bool
+	// CABundle is a PEM file of extra CAs trusted for storage requests.
+	CABundle string
+	// Insecure skips TLS verification of storage requests. For development
+	// object stores with self-signed certificates only.
+	Insecure bool
+	// SignatureVersion is empty for SigV4, or S3SignatureV2 for older
+	// S3-compatible stores. V2 always uses path-style addressing.
+	SignatureVersion string
+
+	tls *tls.Config
+}
+
+// Apply configures an S3 client for these options. Acceleration is skipped
+// when the client has a custom endpoint, which has no acceleration host.
+func (opts S3Options) Apply(o *s3.Options) {
+	if opts.Accelerate && o.BaseEndpoint == nil {
+		o.UseAccelerate = true
+	}
+	if opts.RequesterPays {
+		o.APIOptions = append(o.APIOptions, smithyhttp.AddHeaderValue(RequestPayerHeader, "requester"))
+	}
+	if opts.PathStyle != nil {
+		o.UsePathStyle = *opts.PathStyle
+	}
+	if opts.SignatureVersion == S3SignatureV2 {
+		o.UsePathStyle = true
+		o.HTTPSignerV4 = sigV2Signer{}
+		// Trailing checksums are a SigV4 streaming feature.
+		o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+	}
+	if opts.tls != nil {
+		// A client that is not the SDK's own was customized by the caller,
+		// which then owns its TLS settings.
+		switch client := o.HTTPClient.(type) {
+		case nil:
+			o.HTTPClient = awshttp.NewBuildableClient().WithTransportOptions(opts.applyTLS)
+		case *awshttp.BuildableClient:
+			o.HTTPClient = client.WithTransportOptions(opts.applyTLS)
+		}
+	}
+}
+
+func (opts S3Options) applyTLS(t *http.Transport) {
+	t.TLSClientConfig = opts.tls.Clone()
+}
+
+// S3Settings holds a remote's S3 options and per-bucket overrides.
+type S3Settings struct {
+	Remote  S3Options
+	Buckets map[string]S3Options
+}
+
+// s3BucketKeyRE matches drs.s3.<bucket>.<option> keys.
+var s3BucketKeyRE = regexp.MustCompile(`^drs\.s3\.(.+)\.(requester-pays|accelerate)$`)
+
+// LoadS3Settings reads drs.remote.<name>.s3-requester-pays and s3-accelerate
+// from git config, plus drs.s3.<bucket>.requester-pays and accelerate, which
+// override the remote's options for that bucket only. The remote's
+// s3-path-style, s3-ca-bundle, s3-insecure, and s3-signature-version
+// describe its object store and apply to every bucket.
+func LoadS3Settings(remote string) (S3Settings, error) {
+	key := func(name string) string { return fmt.Sprintf("drs.remote.%s.%s", remote, name) }
+	s := S3Settings{
+		Remote: S3Options{
+			RequesterPays: gitrepo.GetGitConfigBool(key("s3-requester-pays"), false),
+			Accelerate:    gitrepo.GetGitConfigBool(key("s3-accelerate"), false),
+			Insecure:      gitrepo.GetGitConfigBool(key("s3-insecure"), false),
+		},
+		Buckets: map[string]S3Options{},
+	}
+	if raw, _ := gitrepo.GetGitConfigString(key("s3-path-style")); strings.TrimSpace(raw) != "" {
+		pathStyle, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return s, fmt.Errorf("invalid %s %q: expected true or false", key("s3-path-style"), raw)
+		}
+		s.Remote.PathStyle = &pathStyle
+	}
+	version, _ := gitrepo.GetGitConfigString(key("s3-signature-version"))
+	switch v := strings.ToLower(strings.TrimSpace(version)); v {
+	case "", S3SignatureV4, "s3v4":
+	case S3SignatureV2, "s3":
+		s.Remote.SignatureVersion = S3SignatureV2
+	default:
+		return s, fmt.Errorf("invalid %s %q: expected v4 or v2", key("s3-signature-version"), version)
+	}
+	bundle, _ := gitrepo.GetGitConfigString(key("s3-ca-bundle"))
+	s.Remote.CABundle = strings.TrimSpace(bundle)
+	if s.Remote.CABundle != "" || s.Remote.Insecure {
+		cfg, err := storageTLSConfig(s.Remote.CABundle, s.Remote.Insecure)
+		if err != nil {
+			return s, fmt.Errorf("invalid %s: %w", key("s3-ca-bundle"), err)
+		}
+		s.Remote.tls = cfg
+	}
+	for key, raw := range gitrepo.GetGitConfigRegexp(`^drs\.s3\..*\.(requester-pays|accelerate)$`) {
+		m := s3BucketKeyRE.FindStringSubmatch(key)
+		if m == nil {
+			continue
+		}
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			continue
+		}
+		opts, ok := s.Buckets[m[1]]
+		if !ok {
+			opts = s.Remote
+		}
+		if m[2] == "requester-pays" {
+			opts.RequesterPays = enabled
+		} else {
+			opts.Accelerate = enabled
+		}
+		s.Buckets[m[1]] = opts
+	}
+	return s, nil
+}
+
+// storageTLSConfig trusts the system roots, the drs.http.ca-bundle CAs, and
+// the CAs in bundle, or skips verification entirely when insecure is set.
+func storageTLSConfig(bundle string, insecure bool) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if insecure {
+		cfg.InsecureSkipVerify = true
+		return cfg, nil
+	}
+	pem, err := os.ReadFile(bundle)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := httpclient.Current()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := shared.CertPool()
+	if err != nil {
+		return nil, err
+	}
+	if pool == nil {
+		if pool, err = x509.SystemCertPool(); err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s contains no PEM certificates", bundle)
+	}
+	cfg.RootCAs = pool
+	return cfg, nil
+}
+
+// ForBucket returns the options for bucket.
+func (s S3Settings) ForBucket(bucket string) S3Options {
+	if opts, ok := s.Buckets[bucket]; ok {
+		return opts
+	}
+	return s.Remote
+}
+
+// ForURL returns the options for the bucket an S3 URL addresses. URLs that
+// are not S3 get no options, so the headers never reach other providers.
+func (s S3Settings) ForURL(u *url.URL) S3Options {
+	bucket, ok := S3BucketFromURL(u)
+	if !ok {
+		return S3Options{}
+	}
+	return s.ForBucket(bucket)
+}
+
+// virtualHostedS3RE matches bucket.s3.amazonaws.com, bucket.s3-accelerate...
+// and regional bucket.s3.<region>.amazonaws.com hosts.
+var virtualHostedS3RE = regexp.MustCompile(`^(.+?)\.s3(?:[.-]|$)`)
+
+// S3BucketFromURL returns the bucket of an s3:// URL or of a virtual-hosted
+// or path-style S3 HTTP(S) URL.
+func S3BucketFromURL(u *url.URL) (string, bool) {
+	if u == nil {
+		return "", false
+	}
+	host := strings.ToLower(u.Hostname())
+	switch strings.ToLower(u.Scheme) {
+	case "s3":
+		return u.Host, u.Host != ""
+	case "http", "https":
+		if m := virtualHostedS3RE.FindStringSubmatch(host); m != nil {
+			return m[1], true
+		}
+		if host == "s3" || strings.HasPrefix(host, "s3.") || strings.HasPrefix(host, "s3-") {
+			bucket, _, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+			return bucket, bucket != ""
+		}
+	}
+	return "", false
+}
+
+// s3Transport adds the request-payer header to requests for buckets that
+// need it, which covers signed-URL uploads, downloads, and ranged reads.
+type s3Transport struct {
+	base     http.RoundTripper
+	settings S3Settings
+}
+
+func (t *s3Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.settings.ForURL(req.URL).RequesterPays || !requestPayerHeaderAllowed(req.URL) {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(RequestPayerHeader, "requester")
+	return t.base.RoundTrip(req)
+}
+
+// requestPayerHeaderAllowed reports whether the request-payer header may be
+// added to a request for u. S3 rejects a presigned request carrying x-amz-
+// headers its signature does not cover, so a presigned URL only gets the
+// header when it was signed with it. One that carries the payer in its query
+// string already has it, and one signed without it cannot be fixed here: the
+// DRS server has to sign requester-pays URLs with the payer included.
+func requestPayerHeaderAllowed(u *url.URL) bool {
+	q := caseInsensitiveQuery(u.Query())
+	switch {
+	case q["x-amz-request-payer"] != "":
+		return false
+	case q["x-amz-signature"] != "":
+		return slices.Contains(strings.Split(strings.ToLower(q["x-amz-signedheaders"]), ";"), strings.ToLower(RequestPayerHeader))
+	case q["signature"] != "" && q["awsaccesskeyid"] != "":
+		// SigV2 query signatures cover x-amz- headers in the string to
+		// sign, which was computed without this one.
+		return false
+	}
+	return true
+}
+
+func caseInsensitiveQuery(values url.Values) map[string]string {
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		if len(v) > 0 {
+			out[strings.ToLower(k)] = v[0]
+		}
+	}
+	return out
+}
+
+// storageTLSTransport sends storage requests through a transport with the
+// remote's S3 TLS settings and everything else through the default one.
+type storageTLSTransport struct {
+	api       http.RoundTripper
+	storage   http.RoundTripper
+	isStorage func(*http.Request) bool
+}
+
+func (t *storageTLSTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.isStorage(req) {
+		return t.storage.RoundTrip(req)
+	}
+	return t.api.RoundTrip(req)
+}
+
+// requesterPays reports whether any bucket of the remote is requester-pays.
+func (s S3Settings) requesterPays() bool {
+	if s.Remote.RequesterPays {
+		return true
+	}
+	for _, opts := range s.Buckets {
+		if opts.RequesterPays {
+			return true
+		}
+	}
+	return false
+}
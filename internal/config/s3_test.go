@@ -0,0 +1,242 @@
+package config
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestLoadS3SettingsAppliesBucketOverrides(t *testing.T) {
+	setupTestRepo(t)
+	for _, kv := range [][2]string{
+		{"drs.remote.origin.s3-accelerate", "true"},
+		{"drs.s3.open-data.requester-pays", "true"},
+		{"drs.s3.slow.bucket.accelerate", "false"},
+	} {
+		if out, err := exec.Command("git", "config", kv[0], kv[1]).CombinedOutput(); err != nil {
+			t.Fatalf("git config %s: %v: %s", kv[0], err, out)
+		}
+	}
+
+	s, err := LoadS3Settings("origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.ForBucket("other"); got != (S3Options{Accelerate: true}) {
+		t.Fatalf("remote options = %+v", got)
+	}
+	if got := s.ForBucket("open-data"); got != (S3Options{Accelerate: true, RequesterPays: true}) {
+		t.Fatalf("open-data options = %+v", got)
+	}
+	if got := s.ForBucket("slow.bucket"); got != (S3Options{}) {
+		t.Fatalf("slow.bucket options = %+v", got)
+	}
+}
+
+func TestS3BucketFromURL(t *testing.T) {
+	cases := map[string]string{
+		"s3://open-data/reads/a.bam":                                 "open-data",
+		"https://open-data.s3.amazonaws.com/reads/a.bam":             "open-data",
+		"https://open-data.s3.us-west-2.amazonaws.com/a.bam?X-Amz=1": "open-data",
+		"https://open-data.s3-accelerate.amazonaws.com/a.bam":        "open-data",
+		"https://s3.us-west-2.amazonaws.com/open-data/reads/a.bam":   "open-data",
+		"https://storage.googleapis.com/open-data/a.bam":             "",
+		"https://drs.example.org/ga4gh/drs/v1/objects/abc":           "",
+	}
+	for raw, want := range cases {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := S3BucketFromURL(u)
+		if got != want || ok != (want != "") {
+			t.Errorf("S3BucketFromURL(%q) = %q, %v; want %q", raw, got, ok, want)
+		}
+	}
+}
+
+func TestS3TransportSendsRequestPayerOnlyToRequesterPaysBuckets(t *testing.T) {
+	var header string
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		header = req.Header.Get(RequestPayerHeader)
+		return httptest.NewRecorder().Result(), nil
+	})
+	rt := &s3Transport{base: base, settings: S3Settings{
+		Buckets: map[string]S3Options{"open-data": {RequesterPays: true}},
+	}}
+
+	for raw, want := range map[string]string{
+		"https://open-data.s3.amazonaws.com/a.bam": "requester",
+		"https://open-data.s3.amazonaws.com/a.bam?X-Amz-Signature=x&X-Amz-SignedHeaders=host%3Bx-amz-request-payer": "requester",
+		"https://private.s3.amazonaws.com/a.bam?X-Amz-Signature=x":                                                  "",
+		"https://drs.example.org/ga4gh/drs/v1/objects/abc":                                                          "",
+	} {
+		req := httptest.NewRequest(http.MethodGet, raw, nil)
+		req.Header.Set("Range", "bytes=0-9")
+		if _, err := rt.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+		if header != want {
+			t.Errorf("%s: request payer = %q, want %q", raw, header, want)
+		}
+		if req.Header.Get(RequestPayerHeader) != "" {
+			t.Fatalf("%s: transport modified the caller's request", raw)
+		}
+	}
+}
+
+func TestS3TransportLeavesPresignedURLsSignedWithoutPayerAlone(t *testing.T) {
+	var header string
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		header = req.Header.Get(RequestPayerHeader)
+		return httptest.NewRecorder().Result(), nil
+	})
+	rt := &s3Transport{base: base, settings: S3Settings{Remote: S3Options{RequesterPays: true}}}
+
+	for _, raw := range []string{
+		// SigV4 presign that did not sign the header: adding it makes S3
+		// refuse the request for carrying an unsigned x-amz- header.
+		"https://open-data.s3.amazonaws.com/a.bam?X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Date=20240102T030405Z&X-Amz-Expires=900&X-Amz-SignedHeaders=host&X-Amz-Signature=abc",
+		// The payer is already part of the signed query.
+		"https://open-data.s3.amazonaws.com/a.bam?x-amz-request-payer=requester&X-Amz-SignedHeaders=host&X-Amz-Signature=abc",
+		// SigV2 query-string signature.
+		"https://open-data.s3.amazonaws.com/a.bam?AWSAccessKeyId=AKID&Expires=1704164645&Signature=abc",
+	} {
+		req := httptest.NewRequest(http.MethodGet, raw, nil)
+		if _, err := rt.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+		if header != "" {
+			t.Errorf("%s: request payer header %q added to a presigned request", raw, header)
+		}
+	}
+}
+
+func TestS3OptionsSignRequestPayerIntoPresignedURLs(t *testing.T) {
+	client := s3.New(s3.Options{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+	}, S3Options{RequesterPays: true}.Apply)
+	signed, err := s3.NewPresignClient(client).PresignGetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String("open-data"),
+		Key:    aws.String("a.bam"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(signed.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The SDK signs the payer into the query string of a presigned URL.
+	if u.Query().Get("x-amz-request-payer") != "requester" {
+		t.Fatalf("presigned URL %s does not carry the request payer", signed.URL)
+	}
+
+	var header string
+	rt := &s3Transport{
+		base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			header = req.Header.Get(RequestPayerHeader)
+			return httptest.NewRecorder().Result(), nil
+		}),
+		settings: S3Settings{Remote: S3Options{RequesterPays: true}},
+	}
+	if _, err := rt.RoundTrip(httptest.NewRequest(signed.Method, signed.URL, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if header != "" {
+		t.Fatalf("request payer header %q added to a URL already signed with it", header)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestLoadS3SettingsObjectStoreOptions(t *testing.T) {
+	setupTestRepo(t)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(bundle, certPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitConfig := func(key, value string) {
+		t.Helper()
+		if out, err := exec.Command("git", "config", key, value).CombinedOutput(); err != nil {
+			t.Fatalf("git config %s: %v: %s", key, err, out)
+		}
+	}
+	gitConfig("drs.remote.origin.s3-path-style", "false")
+	gitConfig("drs.remote.origin.s3-signature-version", "v2")
+	gitConfig("drs.remote.origin.s3-ca-bundle", bundle)
+
+	s, err := LoadS3Settings("origin")
+	if err != nil {
+		t.Fatalf("LoadS3Settings: %v", err)
+	}
+	opts := s.ForBucket("minio-bucket")
+	if opts.PathStyle == nil || *opts.PathStyle || opts.SignatureVersion != S3SignatureV2 || opts.CABundle != bundle {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+
+	var o s3.Options
+	opts.Apply(&o)
+	if !o.UsePathStyle {
+		t.Fatal("signature v2 should force path-style addressing")
+	}
+	if _, ok := o.HTTPSignerV4.(sigV2Signer); !ok {
+		t.Fatalf("expected the v2 signer, got %T", o.HTTPSignerV4)
+	}
+
+	// The bundle's CA is trusted for storage requests.
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: opts.tls}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request with CA bundle failed: %v", err)
+	}
+	resp.Body.Close()
+
+	gitConfig("drs.remote.origin.s3-signature-version", "v3")
+	if _, err := LoadS3Settings("origin"); err == nil {
+		t.Fatal("expected error for unknown signature version")
+	}
+	gitConfig("drs.remote.origin.s3-signature-version", "v4")
+	gitConfig("drs.remote.origin.s3-ca-bundle", filepath.Join(t.TempDir(), "missing.pem"))
+	if _, err := LoadS3Settings("origin"); err == nil {
+		t.Fatal("expected error for missing CA bundle")
+	}
+}
+
+func TestSigV2StringToSign(t *testing.T) {
+	// The GET example from the S3 Signature Version 2 documentation.
+	req := httptest.NewRequest(http.MethodGet, "https://s3.amazonaws.com/johnsmith/photos/puppy.jpg", nil)
+	req.Header.Set("Date", "Tue, 27 Mar 2007 19:36:42 +0000")
+	mac := hmac.New(sha1.New, []byte("wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"))
+	mac.Write([]byte(sigV2StringToSign(req)))
+	if got := base64.StdEncoding.EncodeToString(mac.Sum(nil)); got != "bWq2s1WEIj+Ydj0vQ697zp+IXMU=" {
+		t.Fatalf("signature = %s", got)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "https://minio.local/bucket/key?partNumber=2&uploadId=abc&x-id=UploadPart", nil)
+	req.Header.Set("Date", "Tue, 27 Mar 2007 19:36:42 +0000")
+	req.Header.Set("X-Amz-Meta-Sha256", "aa")
+	want := "PUT\n\n\nTue, 27 Mar 2007 19:36:42 +0000\nx-amz-meta-sha256:aa\n/bucket/key?partNumber=2&uploadId=abc"
+	if got := sigV2StringToSign(req); got != want {
+		t.Fatalf("string to sign = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/calypr/git-drs/internal/httpclient"
+	"github.com/calypr/git-drs/internal/throttle"
+)
+
+// NewS3Client builds a client for requests git-drs signs itself against
+// bucket. Static uploads use AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+// only; every other credential source uses the AWS default chain. The
+// region and endpoint come from upload, and remote's and bucket's S3
+// options from LoadS3Settings apply. opts run before the transfer limiter
+// wraps the HTTP client.
+func NewS3Client(ctx context.Context, remote string, upload UploadSettings, bucket string, opts ...func(*s3.Options)) (*s3.Client, error) {
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	if upload.Region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(upload.Region))
+	}
+	if upload.CredentialSource == CredentialSourceStatic {
+		accessKey, secretKey := strings.TrimSpace(os.Getenv("AWS_ACCESS_KEY_ID")), strings.TrimSpace(os.Getenv("AWS_SECRET_ACCESS_KEY"))
+		if accessKey == "" || secretKey == "" {
+			return nil, errors.New("credential source static requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		loadOpts = append(loadOpts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(accessKey, secretKey, os.Getenv("AWS_SESSION_TOKEN"))))
+	}
+	awsHTTP, err := httpclient.AWSClient()
+	if err != nil {
+		return nil, err
+	}
+	loadOpts = append(loadOpts, awsconfig.WithHTTPClient(awsHTTP))
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+	limiter, err := TransferLimiter()
+	if err != nil {
+		return nil, err
+	}
+	clientOpts := []func(*s3.Options){}
+	if endpoint := strings.TrimRight(upload.Endpoint, "/"); endpoint != "" {
+		clientOpts = append(clientOpts, func(o *s3.Options) {
+			o.UsePathStyle = true
+			o.BaseEndpoint = aws.String(endpoint)
+		})
+	}
+	s3Settings, err := LoadS3Settings(remote)
+	if err != nil {
+		return nil, err
+	}
+	clientOpts = append(clientOpts, s3Settings.ForBucket(bucket).Apply)
+	clientOpts = append(clientOpts, opts...)
+	// Wrap last, so the limiter sees the client the S3 options configured.
+	if limiter != nil {
+		clientOpts = append(clientOpts, func(o *s3.Options) {
+			o.HTTPClient = throttle.Client(o.HTTPClient, limiter)
+		})
+	}
+	return s3.NewFromConfig(awsCfg, clientOpts...), nil
+}
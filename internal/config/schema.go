@@ -0,0 +1,477 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/accesspolicy"
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/compression"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/guardrail"
+	"github.com/calypr/git-drs/internal/hooks"
+	"github.com/calypr/git-drs/internal/origin"
+	"github.com/calypr/git-drs/internal/registerpolicy"
+	"github.com/calypr/git-drs/internal/storageclass"
+	"github.com/calypr/git-drs/internal/throttle"
+)
+
+// Key describes a drs.* git config setting that git drs config may change.
+// Name is the full key; a <remote>, <bucket> or <event> segment stands for
+// the subsection of a remote, an S3 bucket or a hook event.
+type Key struct {
+	Name string
+	// Multi keys hold a list of values, such as a remote's path scopes.
+	Multi    bool
+	Validate func(string) error
+}
+
+// Keys is the schema of settings git drs config accepts. Settings managed by
+// their own commands, such as bucket mappings and credentials, are absent.
+var Keys = []Key{
+	{Name: "drs.default-remote", Validate: nonEmpty},
+	{Name: "drs.loglevel", Validate: oneOf("debug", "info", "warn", "warning", "error")},
+	{Name: "drs.upsert", Validate: boolean},
+	{Name: "drs.multipart-threshold", Validate: nonNegativeInt},
+	{Name: "drs.lock.wait", Validate: boolean},
+	{Name: "drs.skip-smudge", Validate: boolean},
+	{Name: "drs.id-strategy", Validate: idStrategy},
+	{Name: "drs.id-prefix", Validate: nonEmpty},
+	{Name: "drs.registration.mode", Validate: func(v string) error { _, err := ParseRegistrationMode(v); return err }},
+	{Name: "drs.register.include", Validate: patterns},
+	{Name: "drs.register.exclude", Validate: patterns},
+	{Name: "drs.push.verify", Validate: func(v string) error { _, err := ParseVerifyPolicy(v); return err }},
+	{Name: "drs.push.report", Validate: boolean},
+	{Name: "drs.push.queue-offline", Validate: boolean},
+	{Name: "drs.push.check-authz", Validate: boolean},
+	{Name: "drs.limits.max-file-size", Validate: size},
+	{Name: "drs.limits.max-push-size", Validate: size},
+	{Name: "drs.limits.project-quota", Validate: size},
+	{Name: "drs.limits.allowed-extensions", Validate: nonEmpty},
+	{Name: "drs.limits.allowed-mime-types", Validate: func(v string) error { _, err := guardrail.ParseMimeTypes(v); return err }},
+	{Name: "drs.access.prefer", Validate: func(v string) error { _, err := accesspolicy.ParsePreferences(v); return err }},
+	{Name: "drs.access.probe", Validate: boolean},
+	{Name: "drs.transfer.max-bandwidth", Validate: func(v string) error { _, err := throttle.ParseBandwidth(v); return err }},
+	{Name: "drs.transfer.schedule", Validate: func(v string) error { _, err := throttle.ParseSchedule(v); return err }},
+	{Name: "drs.transfer.upload-workers", Validate: positiveInt},
+	{Name: "drs.transfer.download-workers", Validate: positiveInt},
+	{Name: "drs.http.proxy", Validate: nonEmpty},
+	{Name: "drs.http.no-proxy", Validate: nonEmpty},
+	{Name: "drs.http.ca-bundle", Validate: existingFile},
+	{Name: "drs.http.api-timeout", Validate: duration},
+	{Name: "drs.http.response-header-timeout", Validate: duration},
+	{Name: "drs.http.idle-conn-timeout", Validate: duration},
+	{Name: "drs.http.max-idle-conns-per-host", Validate: nonNegativeInt},
+	{Name: "drs.upload.credential-source", Validate: oneOf(CredentialSourceFence, CredentialSourceAmbient, CredentialSourceStatic)},
+	{Name: "drs.upload.method", Validate: oneOf(UploadMethodMultipart, UploadMethodPresigned)},
+	{Name: "drs.upload.verify-parts", Validate: boolean},
+	{Name: "drs.storage.class", Multi: true, Validate: func(v string) error { _, err := storageclass.ParseClassRule(v); return err }},
+	{Name: "drs.storage.tag", Multi: true, Validate: func(v string) error { _, err := storageclass.ParseTagRule(v); return err }},
+	{Name: "drs.compress.extensions", Validate: nonEmpty},
+	{Name: "drs.compress.encoding", Validate: func(v string) error { _, err := compression.ParseEncoding(v); return err }},
+	{Name: "drs.upload.region", Validate: nonEmpty},
+	{Name: "drs.upload.endpoint", Validate: httpURL},
+	{Name: "drs.metrics.summary", Validate: boolean},
+	{Name: "drs.metrics.pushgateway", Validate: httpURL},
+	{Name: "drs.metrics.otlp-endpoint", Validate: httpURL},
+	{Name: "drs.metrics.job", Validate: nonEmpty},
+	{Name: "drs.usage.ledger", Validate: boolean},
+	{Name: "drs.usage.webhook", Validate: httpURL},
+	{Name: "drs.cache.shared-dir", Validate: absolutePath},
+	{Name: "drs.origin.fields", Validate: func(v string) error { _, err := origin.ParseFields(v); return err }},
+	{Name: "drs.mds.enabled", Validate: boolean},
+	{Name: "drs.mds.guid", Validate: nonEmpty},
+	{Name: "drs.mds.guid-type", Validate: nonEmpty},
+	{Name: "drs.provenance.key-file", Validate: existingFile},
+	{Name: "drs.provenance.public-key-file", Validate: existingFile},
+	{Name: "drs.provenance.sign-command", Validate: nonEmpty},
+	{Name: "drs.provenance.verify-command", Validate: nonEmpty},
+	{Name: "drs.auth.client-id", Validate: nonEmpty},
+	{Name: "drs.hook.<event>.command", Validate: nonEmpty},
+	{Name: "drs.hook.<event>.timeout", Validate: duration},
+	{Name: "drs.hook.<event>.on-failure", Validate: oneOf(hooks.FailurePolicyFail, hooks.FailurePolicyWarn, hooks.FailurePolicyIgnore)},
+	{Name: "drs.remote.<remote>.type", Validate: func(v string) error { return IsValidRemoteType(v) }},
+	{Name: "drs.remote.<remote>.endpoint", Validate: httpURL},
+	{Name: "drs.remote.<remote>.organization", Validate: nonEmpty},
+	{Name: "drs.remote.<remote>.project", Validate: nonEmpty},
+	{Name: "drs.remote.<remote>.bucket", Validate: nonEmpty},
+	{Name: "drs.remote.<remote>.storage_prefix", Validate: nonEmpty},
+	{Name: "drs.remote.<remote>.profile", Validate: nonEmpty},
+	{Name: "drs.remote.<remote>.auth", Validate: IsValidAuthMode},
+	{Name: "drs.remote.<remote>.username", Validate: nonEmpty},
+	{Name: "drs.remote.<remote>.password", Validate: nonEmpty},
+	{Name: "drs.remote.<remote>.token", Validate: nonEmpty},
+	{Name: "drs.remote.<remote>.credential-store", Validate: oneOf(CredentialStoreFile, CredentialStoreKeyring)},
+	{Name: "drs.remote.<remote>.local-scope", Validate: nonEmpty},
+	{Name: "drs.remote.<remote>.path-scope", Multi: true, Validate: func(v string) error { _, err := ParsePathScope(v); return err }},
+	{Name: "drs.remote.<remote>.read-only", Validate: boolean},
+	{Name: "drs.remote.<remote>.read-scopes", Validate: func(v string) error { _, err := ParseScopes(v); return err }},
+	{Name: "drs.remote.<remote>.id-strategy", Validate: idStrategy},
+	{Name: "drs.remote.<remote>.id-prefix", Validate: nonEmpty},
+	{Name: "drs.remote.<remote>.upload-method", Validate: oneOf(UploadMethodMultipart, UploadMethodPresigned)},
+	{Name: "drs.remote.<remote>.encryption-key-file", Validate: existingFile},
+	{Name: "drs.remote.<remote>.encryption-key-command", Validate: nonEmpty},
+	{Name: "drs.remote.<remote>.s3-requester-pays", Validate: boolean},
+	{Name: "drs.remote.<remote>.s3-accelerate", Validate: boolean},
+	{Name: "drs.remote.<remote>.s3-path-style", Validate: boolean},
+	{Name: "drs.remote.<remote>.s3-insecure", Validate: boolean},
+	{Name: "drs.remote.<remote>.s3-ca-bundle", Validate: existingFile},
+	{Name: "drs.remote.<remote>.s3-signature-version", Validate: oneOf(S3SignatureV4, S3SignatureV2)},
+	{Name: "drs.remote.<remote>.azure-account", Validate: nonEmpty},
+	{Name: "drs.remote.<remote>.azure-endpoint", Validate: httpURL},
+	{Name: "drs.remote.<remote>.azure-credential", Validate: oneOf(AzureCredentialDefault, AzureCredentialSharedKey, AzureCredentialSAS)},
+	{Name: "drs.s3.<bucket>.requester-pays", Validate: boolean},
+	{Name: "drs.s3.<bucket>.accelerate", Validate: boolean},
+}
+
+// hookEvents are the values a <event> segment may take.
+var hookEvents = []string{hooks.PreRegister, hooks.PostRegister, hooks.PreUpload, hooks.PostDownload}
+
+// ErrUnknownKey is returned for keys outside the schema.
+var ErrUnknownKey = errors.New("unknown config key")
+
+// CanonicalKey returns the git config key for name. The drs. prefix may be
+// left off, and remotes.<name>.<field> is accepted for drs.remote.<name>.<field>.
+func CanonicalKey(name string) string {
+	name = strings.TrimSpace(name)
+	if rest, ok := strings.CutPrefix(name, "remotes."); ok {
+		return "drs.remote." + rest
+	}
+	if !strings.HasPrefix(name, configSection+".") {
+		return configSection + "." + name
+	}
+	return name
+}
+
+// LookupKey returns the schema entry for the canonical key.
+func LookupKey(key string) (Key, error) {
+	for _, k := range Keys {
+		if k.matches(key) {
+			return k, nil
+		}
+	}
+	return Key{}, drserrors.WithKind(drserrors.ErrConfig, fmt.Errorf("%w %q; run 'git drs config list --keys' for the supported keys", ErrUnknownKey, key))
+}
+
+// matches reports whether key is k.Name with any placeholder filled in. A
+// placeholder fills the subsection, which may itself contain dots, as S3
+// bucket names do.
+func (k Key) matches(key string) bool {
+	open := strings.Index(k.Name, "<")
+	if open < 0 {
+		return key == k.Name
+	}
+	end := strings.Index(k.Name, ">")
+	prefix, suffix := k.Name[:open], k.Name[end+1:]
+	if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, suffix) || len(key) <= len(prefix)+len(suffix) {
+		return false
+	}
+	sub := key[len(prefix) : len(key)-len(suffix)]
+	if k.Name[open:end+1] == "<event>" {
+		return oneOf(hookEvents...)(sub) == nil
+	}
+	return true
+}
+
+// Check validates values for the canonical key.
+func (k Key) Check(key string, values []string) error {
+	if len(values) == 0 {
+		return fmt.Errorf("%s: no value given", key)
+	}
+	if len(values) > 1 && !k.Multi {
+		return fmt.Errorf("%s takes one value, got %d", key, len(values))
+	}
+	for _, v := range values {
+		if err := k.Validate(v); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", key, v, err)
+		}
+	}
+	return nil
+}
+
+// GetKey returns the values of key from every git config scope, as git-drs
+// reads them.
+func GetKey(key string) ([]string, error) {
+	out, err := exec.Command("git", "config", "--get-all", key).Output()
+	if err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && exit.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("git config --get-all %s: %w", key, err)
+	}
+	return strings.Split(strings.TrimRight(string(out), "\n"), "\n"), nil
+}
+
+// SetKey validates values against the schema, backs up the repository's
+// git config and replaces key's values in it. It returns the backup path.
+func SetKey(key string, values []string) (string, error) {
+	k, err := LookupKey(key)
+	if err != nil {
+		return "", err
+	}
+	if err := k.Check(key, values); err != nil {
+		return "", drserrors.WithKind(drserrors.ErrConfig, err)
+	}
+	backup, err := BackupGitConfig()
+	if err != nil {
+		return "", err
+	}
+	if err := gitConfigLocal("--unset-all", key); err != nil && !missingKey(err) {
+		return backup, err
+	}
+	for _, v := range values {
+		if err := gitConfigLocal("--add", key, v); err != nil {
+			return backup, err
+		}
+	}
+	return backup, nil
+}
+
+// SetKeys is SetKey for several keys: every entry is validated before any
+// is written, and the git config is backed up once. It returns the backup
+// path.
+func SetKeys(values map[string][]string) (string, error) {
+	keys := make([]string, 0, len(values))
+	for key, vals := range values {
+		k, err := LookupKey(key)
+		if err != nil {
+			return "", err
+		}
+		if err := k.Check(key, vals); err != nil {
+			return "", drserrors.WithKind(drserrors.ErrConfig, err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return "", nil
+	}
+	sort.Strings(keys)
+	backup, err := BackupGitConfig()
+	if err != nil {
+		return "", err
+	}
+	for _, key := range keys {
+		if err := gitConfigLocal("--unset-all", key); err != nil && !missingKey(err) {
+			return backup, err
+		}
+		for _, v := range values[key] {
+			if err := gitConfigLocal("--add", key, v); err != nil {
+				return backup, err
+			}
+		}
+	}
+	return backup, nil
+}
+
+// UnsetKey backs up the repository's git config and removes key from it. It
+// returns the backup path, or an error when the repository does not set key.
+func UnsetKey(key string) (string, error) {
+	if _, err := LookupKey(key); err != nil {
+		return "", err
+	}
+	if err := exec.Command("git", "config", "--local", "--get-all", key).Run(); err != nil {
+		return "", fmt.Errorf("%s is not set in this repository's config", key)
+	}
+	backup, err := BackupGitConfig()
+	if err != nil {
+		return "", err
+	}
+	return backup, gitConfigLocal("--unset-all", key)
+}
+
+// ListKeys returns every drs.* key set in any git config scope and its
+// values, by key.
+func ListKeys() (map[string][]string, error) {
+	out, err := exec.Command("git", "config", "--get-regexp", `^drs\.`).Output()
+	values := map[string][]string{}
+	if err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && exit.ExitCode() == 1 {
+			return values, nil
+		}
+		return nil, fmt.Errorf("git config --get-regexp: %w", err)
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		values[key] = append(values[key], value)
+	}
+	return values, nil
+}
+
+// maxConfigBackups is how many backups BackupGitConfig keeps.
+const maxConfigBackups = 10
+
+// configBackupDir holds backups of the repository's git config; swapped in
+// tests.
+var configBackupDir = func() (string, error) {
+	dir, err := gitrepo.DrsTopLevel()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config-backups"), nil
+}
+
+// BackupGitConfig copies the repository's git config file aside before
+// git drs config changes it, keeping the latest few copies, and returns the
+// copy's path.
+func BackupGitConfig() (string, error) {
+	out, err := exec.Command("git", "rev-parse", "--git-path", "config").Output()
+	if err != nil {
+		return "", fmt.Errorf("locate git config: %w", err)
+	}
+	data, err := os.ReadFile(strings.TrimSpace(string(out)))
+	if err != nil {
+		return "", fmt.Errorf("read git config for backup: %w", err)
+	}
+	dir, err := configBackupDir()
+	if err != nil {
+		return "", err
+	}
+	backup := filepath.Join(dir, "config-"+time.Now().UTC().Format("20060102T150405.000000000Z"))
+	if err := common.WriteFileAtomic(backup, data, 0o600); err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return backup, nil
+	}
+	var names []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "config-") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	for len(names) > maxConfigBackups {
+		_ = os.Remove(filepath.Join(dir, names[0]))
+		names = names[1:]
+	}
+	return backup, nil
+}
+
+func gitConfigLocal(args ...string) error {
+	cmd := exec.Command("git", append([]string{"config", "--local"}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return &gitConfigError{args: args, err: err, out: strings.TrimSpace(string(out))}
+	}
+	return nil
+}
+
+type gitConfigError struct {
+	args []string
+	err  error
+	out  string
+}
+
+func (e *gitConfigError) Error() string {
+	return fmt.Sprintf("git config %s: %v %s", strings.Join(e.args, " "), e.err, e.out)
+}
+
+func (e *gitConfigError) Unwrap() error { return e.err }
+
+// missingKey reports whether git config failed because the key is unset.
+func missingKey(err error) bool {
+	var exit *exec.ExitError
+	return errors.As(err, &exit) && exit.ExitCode() == 5
+}
+
+func nonEmpty(v string) error {
+	if strings.TrimSpace(v) == "" {
+		return errors.New("empty value")
+	}
+	return nil
+}
+
+func oneOf(allowed ...string) func(string) error {
+	return func(v string) error {
+		for _, a := range allowed {
+			if strings.EqualFold(strings.TrimSpace(v), a) {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+func boolean(v string) error {
+	if _, err := strconv.ParseBool(strings.TrimSpace(v)); err != nil {
+		return errors.New("expected true or false")
+	}
+	return nil
+}
+
+func nonNegativeInt(v string) error {
+	if n, err := strconv.Atoi(strings.TrimSpace(v)); err != nil || n < 0 {
+		return errors.New("expected a non-negative integer")
+	}
+	return nil
+}
+
+func positiveInt(v string) error {
+	if n, err := strconv.Atoi(strings.TrimSpace(v)); err != nil || n < 1 {
+		return errors.New("expected a positive integer")
+	}
+	return nil
+}
+
+func duration(v string) error {
+	if d, err := time.ParseDuration(strings.TrimSpace(v)); err != nil || d < 0 {
+		return errors.New("expected a duration such as 30s")
+	}
+	return nil
+}
+
+func size(v string) error {
+	if err := nonEmpty(v); err != nil {
+		return err
+	}
+	_, err := guardrail.ParseSize(v)
+	return err
+}
+
+func patterns(v string) error {
+	_, err := registerpolicy.ParsePatterns(v)
+	return err
+}
+
+func idStrategy(v string) error {
+	_, err := drsobject.ParseIDStrategy(v)
+	return err
+}
+
+func httpURL(v string) error {
+	u, err := url.Parse(strings.TrimSpace(v))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("expected an http or https URL")
+	}
+	return nil
+}
+
+func absolutePath(v string) error {
+	if !filepath.IsAbs(strings.TrimSpace(v)) {
+		return errors.New("expected an absolute path")
+	}
+	return nil
+}
+
+func existingFile(v string) error {
+	info, err := os.Stat(v)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return errors.New("is a directory")
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalKeyAndLookup(t *testing.T) {
+	for in, want := range map[string]string{
+		"remotes.origin.bucket":   "drs.remote.origin.bucket",
+		"http.api-timeout":        "drs.http.api-timeout",
+		"drs.registration.mode":   "drs.registration.mode",
+		"s3.my.bucket.accelerate": "drs.s3.my.bucket.accelerate",
+	} {
+		key := CanonicalKey(in)
+		if key != want {
+			t.Fatalf("CanonicalKey(%q) = %q; want %q", in, key, want)
+		}
+		if _, err := LookupKey(key); err != nil {
+			t.Fatalf("LookupKey(%q): %v", key, err)
+		}
+	}
+	for _, key := range []string{"drs.nonsense", "drs.hook.pre-lunch.command", "drs.remote..bucket", "drs.remote.origin.colour"} {
+		if _, err := LookupKey(key); !errors.Is(err, ErrUnknownKey) {
+			t.Fatalf("LookupKey(%q) = %v; want ErrUnknownKey", key, err)
+		}
+	}
+}
+
+func TestSetAndUnsetKeyValidateAndBackUp(t *testing.T) {
+	setupTestRepo(t)
+	backups := t.TempDir()
+	orig := configBackupDir
+	t.Cleanup(func() { configBackupDir = orig })
+	configBackupDir = func() (string, error) { return backups, nil }
+
+	for key, values := range map[string][]string{
+		"drs.http.api-timeout":         {"soon"},
+		"drs.remote.origin.read-only":  {"maybe"},
+		"drs.registration.mode":        {"sometimes"},
+		"drs.remote.origin.bucket":     {"a", "b"},
+		"drs.remote.origin.path-scope": {"no-equals-sign"},
+	} {
+		if _, err := SetKey(key, values); err == nil {
+			t.Fatalf("SetKey(%s, %q) succeeded; want a validation error", key, values)
+		}
+	}
+	if entries, _ := os.ReadDir(backups); len(entries) != 0 {
+		t.Fatalf("rejected values made %d backups", len(entries))
+	}
+
+	if _, err := SetKey("drs.remote.origin.bucket", []string{"first"}); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := SetKey("drs.remote.origin.bucket", []string{"second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetKey("drs.remote.origin.bucket"); !slices.Equal(got, []string{"second"}) {
+		t.Fatalf("bucket = %q; want second", got)
+	}
+	data, err := os.ReadFile(backup)
+	if err != nil || !slices.Contains(splitLines(string(data)), "\tbucket = first") {
+		t.Fatalf("backup %s = %q, %v; want the config with the first bucket", backup, data, err)
+	}
+	if filepath.Dir(backup) != backups {
+		t.Fatalf("backup at %s; want under %s", backup, backups)
+	}
+
+	if _, err := SetKey("drs.remote.origin.path-scope", []string{"data/=org/a", "raw/=org/b"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetKey("drs.remote.origin.path-scope"); len(got) != 2 {
+		t.Fatalf("path-scope = %q; want both scopes", got)
+	}
+
+	if _, err := UnsetKey("drs.remote.origin.bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetKey("drs.remote.origin.bucket"); len(got) != 0 {
+		t.Fatalf("bucket after unset = %q", got)
+	}
+	if _, err := UnsetKey("drs.remote.origin.bucket"); err == nil {
+		t.Fatal("unsetting an unset key succeeded")
+	}
+}
+
+func TestBackupGitConfigKeepsLatest(t *testing.T) {
+	setupTestRepo(t)
+	backups := t.TempDir()
+	orig := configBackupDir
+	t.Cleanup(func() { configBackupDir = orig })
+	configBackupDir = func() (string, error) { return backups, nil }
+
+	for range maxConfigBackups + 3 {
+		if _, err := BackupGitConfig(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if entries, _ := os.ReadDir(backups); len(entries) != maxConfigBackups {
+		t.Fatalf("kept %d backups; want %d", len(entries), maxConfigBackups)
+	}
+}
+
+func splitLines(s string) []string {
+	var out []string
+	for line := range strings.Lines(s) {
+		out = append(out, strings.TrimRight(line, "\n"))
+	}
+	return out
+}
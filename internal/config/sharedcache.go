@@ -0,0 +1,20 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+// SharedCacheDir reads drs.cache.shared-dir, usually set in the global or
+// system git config so every clone on a machine shares it. Relative values
+// are ignored: they would name a different directory in each clone.
+func SharedCacheDir() string {
+	dir, _ := gitrepo.GetGitConfigString("drs.cache.shared-dir")
+	dir = strings.TrimSpace(dir)
+	if !filepath.IsAbs(dir) {
+		return ""
+	}
+	return filepath.Clean(dir)
+}
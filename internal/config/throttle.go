@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/throttle"
+)
+
+// TransferLimits reads drs.transfer.max-bandwidth and drs.transfer.schedule
+// from git config, falling back to the transfer section of the user config.
+func TransferLimits() (throttle.Settings, error) {
+	user := userTransferDefaults()
+	bandwidth, _ := gitrepo.GetGitConfigString("drs.transfer.max-bandwidth")
+	schedule, _ := gitrepo.GetGitConfigString("drs.transfer.schedule")
+
+	var s throttle.Settings
+	var err error
+	if s.BytesPerSecond, err = throttle.ParseBandwidth(firstNonEmpty(bandwidth, user.MaxBandwidth)); err != nil {
+		return throttle.Settings{}, fmt.Errorf("drs.transfer.max-bandwidth: %w", err)
+	}
+	if s.Schedule, err = throttle.ParseSchedule(firstNonEmpty(schedule, user.Schedule)); err != nil {
+		return throttle.Settings{}, fmt.Errorf("drs.transfer.schedule: %w", err)
+	}
+	return s, nil
+}
+
+// TransferLimiter returns the limiter shared by every transfer in the
+// process, or nil when no limits are configured.
+var TransferLimiter = sync.OnceValues(func() (*throttle.Limiter, error) {
+	s, err := TransferLimits()
+	if err != nil {
+		return nil, err
+	}
+	return throttle.New(s, drslog.GetLogger()), nil
+})
+
+// storageRequest reports whether req moves object bytes rather than talking
+// to the DRS API: it goes to another host than the remote's, or to a signed
+// URL on the remote's own host.
+func storageRequest(baseURL string) func(*http.Request) bool {
+	u, err := url.Parse(baseURL)
+	host := ""
+	if err == nil {
+		host = u.Host
+	}
+	return func(req *http.Request) bool {
+		return req.URL.Host != host || signedURL(req.URL)
+	}
+}
+
+func signedURL(u *url.URL) bool {
+	for key := range u.Query() {
+		switch strings.ToLower(key) {
+		case "x-amz-signature", "x-goog-signature", "signature", "sig":
+			return true
+		}
+	}
+	return false
+}
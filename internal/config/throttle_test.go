@@ -0,0 +1,53 @@
+package config
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+func TestTransferLimits(t *testing.T) {
+	setupTestRepo(t)
+	if s, err := TransferLimits(); err != nil || s.Enabled() {
+		t.Fatalf("unset limits = %+v, %v; want none", s, err)
+	}
+
+	if err := gitrepo.SetGitConfigOptions(map[string]string{
+		"drs.transfer.max-bandwidth": "20MB/s",
+		"drs.transfer.schedule":      "Mon-Fri 19:00-07:00; Sat-Sun",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := TransferLimits()
+	if err != nil {
+		t.Fatalf("TransferLimits: %v", err)
+	}
+	if s.BytesPerSecond != 20_000_000 || len(s.Schedule) != 2 {
+		t.Fatalf("limits = %+v", s)
+	}
+
+	if err := gitrepo.SetGitConfigOptions(map[string]string{"drs.transfer.schedule": "weekends"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TransferLimits(); err == nil {
+		t.Fatal("invalid schedule accepted")
+	}
+}
+
+func TestStorageRequest(t *testing.T) {
+	storage := storageRequest("https://drs.example/api")
+	tests := map[string]bool{
+		"https://drs.example/api/ga4gh/drs/v1/objects/x":      false,
+		"https://drs.example/api/data/upload/x":               false,
+		"https://bucket.s3.amazonaws.com/key?X-Amz-Signature": true,
+		"https://drs.example/blobs/key?signature=abc":         true,
+		"https://storage.googleapis.com/b/key":                true,
+	}
+	for raw, want := range tests {
+		req, _ := http.NewRequest(http.MethodGet, raw, nil)
+		if got := storage(req); got != want {
+			t.Errorf("storageRequest(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
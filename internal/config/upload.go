@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/storageclass"
+)
+
+// Credential sources for uploads.
+const (
+	// CredentialSourceFence uploads through URLs signed by the DRS server
+	// with the remote's credentials.
+	CredentialSourceFence = "fence"
+	// CredentialSourceAmbient uploads straight to the bucket with the AWS
+	// default credential chain: environment, shared config, web identity
+	// (IRSA), and the container or instance profile.
+	CredentialSourceAmbient = "ambient"
+	// CredentialSourceStatic uploads straight to the bucket with keys from
+	// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY only.
+	CredentialSourceStatic = "static"
+)
+
+// Upload methods for fence uploads.
+const (
+	// UploadMethodMultipart sends files below the multipart threshold with
+	// one signed PUT and larger files through the multipart endpoints.
+	UploadMethodMultipart = "multipart"
+	// UploadMethodPresigned sends every file up to the single-PUT limit with
+	// one signed PUT from /data/upload, using multipart only above it.
+	UploadMethodPresigned = "presigned"
+)
+
+// UploadSettings selects how object content reaches the bucket. Records are
+// registered with the DRS server whichever source is used.
+type UploadSettings struct {
+	CredentialSource string
+	// Region and Endpoint configure the S3 client for direct uploads. An
+	// endpoint selects path-style requests, as MinIO and Ceph expect.
+	Region   string
+	Endpoint string
+	// Method is UploadMethodMultipart or UploadMethodPresigned. It applies
+	// to fence uploads only.
+	Method string
+	// VerifyParts checks each multipart part against the MD5 ETag storage
+	// returns for it, and the completed object against the ETag its parts
+	// compose to.
+	VerifyParts bool
+	// Storage picks the storage class and object tags for each file from
+	// drs.storage.class and drs.storage.tag.
+	Storage storageclass.Rules
+}
+
+// Direct reports whether uploads bypass the DRS server's signed URLs.
+func (s UploadSettings) Direct() bool {
+	return s.CredentialSource == CredentialSourceAmbient || s.CredentialSource == CredentialSourceStatic
+}
+
+// LoadUploadSettings reads drs.upload.credential-source, region, and
+// endpoint from git config, falling back to the upload section of the user
+// config. The credential source defaults to fence. The upload method is read
+// from drs.remote.<remote>.upload-method, then drs.upload.method, then the
+// user config, and defaults to multipart. Part verification is read from
+// drs.upload.verify-parts and is on by default, and storage class and tag
+// rules from the multi-valued drs.storage.class and drs.storage.tag.
+func LoadUploadSettings(remote string) (UploadSettings, error) {
+	user := userUploadDefaults()
+	source, _ := gitrepo.GetGitConfigString("drs.upload.credential-source")
+	region, _ := gitrepo.GetGitConfigString("drs.upload.region")
+	endpoint, _ := gitrepo.GetGitConfigString("drs.upload.endpoint")
+	remoteMethod, _ := gitrepo.GetGitConfigString(fmt.Sprintf("drs.remote.%s.upload-method", remote))
+	method, _ := gitrepo.GetGitConfigString("drs.upload.method")
+	s := UploadSettings{
+		CredentialSource: strings.ToLower(firstNonEmpty(source, user.CredentialSource, CredentialSourceFence)),
+		Region:           firstNonEmpty(region, user.Region),
+		Endpoint:         firstNonEmpty(endpoint, user.Endpoint),
+		Method:           strings.ToLower(firstNonEmpty(remoteMethod, method, user.Method, UploadMethodMultipart)),
+		VerifyParts:      gitrepo.GetGitConfigBool("drs.upload.verify-parts", true),
+	}
+	classes, err := GetKey("drs.storage.class")
+	if err != nil {
+		return UploadSettings{}, err
+	}
+	tags, err := GetKey("drs.storage.tag")
+	if err != nil {
+		return UploadSettings{}, err
+	}
+	if s.Storage, err = storageclass.ParseRules(classes, tags); err != nil {
+		return UploadSettings{}, err
+	}
+	switch s.CredentialSource {
+	case CredentialSourceFence, CredentialSourceAmbient, CredentialSourceStatic:
+	default:
+		return UploadSettings{}, fmt.Errorf("invalid drs.upload.credential-source %q: expected %s, %s, or %s",
+			s.CredentialSource, CredentialSourceAmbient, CredentialSourceFence, CredentialSourceStatic)
+	}
+	switch s.Method {
+	case UploadMethodMultipart, UploadMethodPresigned:
+		return s, nil
+	}
+	return UploadSettings{}, fmt.Errorf("invalid upload method %q for remote %s: expected %s or %s",
+		s.Method, remote, UploadMethodMultipart, UploadMethodPresigned)
+}
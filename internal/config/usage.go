@@ -0,0 +1,15 @@
+package config
+
+import (
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/usage"
+)
+
+// UsageSettings reads drs.usage.* from git config.
+func UsageSettings() usage.Settings {
+	webhook, _ := gitrepo.GetGitConfigString("drs.usage.webhook")
+	return usage.Settings{
+		Ledger:  gitrepo.GetGitConfigBool("drs.usage.ledger", false),
+		Webhook: webhook,
+	}
+}
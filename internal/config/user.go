@@ -0,0 +1,100 @@
+package config
+
+import (
+	"github.com/calypr/git-drs/internal/userconfig"
+)
+
+// loadUserConfig is swapped in tests.
+var loadUserConfig = userconfig.Load
+
+// inheritUserConfig fills cfg with remotes and the default remote from the
+// user-level config. Repo-local values always win; user values only fill
+// remotes or fields the repo leaves unset. A remote flagged read-only in
+// either config stays read-only.
+func inheritUserConfig(cfg *Config, user *userconfig.Config) {
+	if cfg == nil || user == nil {
+		return
+	}
+	if cfg.Remotes == nil {
+		cfg.Remotes = make(map[Remote]RemoteSelect)
+	}
+	for name, ur := range user.Remotes {
+		rs, ok := cfg.Remotes[Remote(name)]
+		if !ok {
+			parseAndAddRemote(cfg, remoteSubsectionPrefix+name, ur.Type, ur.Endpoint, ur.Project, ur.Bucket, ur.Organization, ur.StoragePrefix, ur.Profile, ur.Auth, "", ur.CredentialStore)
+			rs = cfg.Remotes[Remote(name)]
+		}
+		if rs.Gen3 != nil {
+			g := *rs.Gen3
+			g.Endpoint = firstNonEmpty(g.Endpoint, ur.Endpoint)
+			g.Organization = firstNonEmpty(g.Organization, ur.Organization)
+			g.ProjectID = firstNonEmpty(g.ProjectID, ur.Project)
+			g.Bucket = firstNonEmpty(g.Bucket, ur.Bucket)
+			g.StoragePrefix = firstNonEmpty(g.StoragePrefix, ur.StoragePrefix)
+			g.Profile = firstNonEmpty(g.Profile, ur.Profile)
+			g.Auth = firstNonEmpty(g.Auth, ur.Auth)
+			g.CredentialStore = firstNonEmpty(g.CredentialStore, ur.CredentialStore)
+			g.ReadOnly = g.ReadOnly || ur.ReadOnly
+			rs.Gen3 = &g
+		}
+		if rs.Local != nil {
+			l := *rs.Local
+			l.BaseURL = firstNonEmpty(l.BaseURL, ur.Endpoint)
+			l.Organization = firstNonEmpty(l.Organization, ur.Organization)
+			l.ProjectID = firstNonEmpty(l.ProjectID, ur.Project)
+			l.Bucket = firstNonEmpty(l.Bucket, ur.Bucket)
+			l.StoragePrefix = firstNonEmpty(l.StoragePrefix, ur.StoragePrefix)
+			l.ReadOnly = l.ReadOnly || ur.ReadOnly
+			rs.Local = &l
+		}
+		if rs.Filesystem != nil {
+			f := *rs.Filesystem
+			f.Root = firstNonEmpty(f.Root, ur.Endpoint)
+			f.Organization = firstNonEmpty(f.Organization, ur.Organization)
+			f.ProjectID = firstNonEmpty(f.ProjectID, ur.Project)
+			f.ReadOnly = f.ReadOnly || ur.ReadOnly
+			rs.Filesystem = &f
+		}
+		if rs.Anvil != nil {
+			a := *rs.Anvil
+			a.Endpoint = firstNonEmpty(a.Endpoint, ur.Endpoint)
+			a.Organization = firstNonEmpty(a.Organization, ur.Organization)
+			a.ProjectID = firstNonEmpty(a.ProjectID, ur.Project)
+			rs.Anvil = &a
+		}
+		cfg.Remotes[Remote(name)] = rs
+	}
+	if cfg.DefaultRemote == "" && user.DefaultRemote != "" {
+		cfg.DefaultRemote = Remote(user.DefaultRemote)
+	}
+}
+
+// userTransferDefaults returns the user-level transfer settings, or zero
+// values when no user config is present or it cannot be read.
+func userTransferDefaults() userconfig.Transfer {
+	user, err := loadUserConfig()
+	if err != nil || user == nil {
+		return userconfig.Transfer{}
+	}
+	return user.Transfer
+}
+
+// userMetricsDefaults returns the user-level metrics settings, or zero values
+// when no user config is present or it cannot be read.
+func userMetricsDefaults() userconfig.Metrics {
+	user, err := loadUserConfig()
+	if err != nil || user == nil {
+		return userconfig.Metrics{}
+	}
+	return user.Metrics
+}
+
+// userUploadDefaults returns the user-level upload settings, or zero values
+// when no user config is present or it cannot be read.
+func userUploadDefaults() userconfig.Upload {
+	user, err := loadUserConfig()
+	if err != nil || user == nil {
+		return userconfig.Upload{}
+	}
+	return user.Upload
+}
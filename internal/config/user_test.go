@@ -0,0 +1,221 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func writeUserConfig(t *testing.T, body string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("write user config: %v", err)
+	}
+	t.Setenv("GIT_DRS_GLOBAL_CONFIG", path)
+}
+
+func TestLoadConfig_InheritsUserRemotes(t *testing.T) {
+	setupTestRepo(t)
+	writeUserConfig(t, `default_remote: shared
+remotes:
+  shared:
+    endpoint: https://shared.example
+    project: shared-proj
+    bucket: shared-bucket
+  origin:
+    endpoint: https://user-origin.example
+    bucket: user-bucket
+`)
+
+	if _, err := UpdateRemote(Remote("origin"), RemoteSelect{
+		Gen3: &Gen3Remote{Endpoint: "https://repo.example", ProjectID: "repo-proj"},
+	}); err != nil {
+		t.Fatalf("UpdateRemote error: %v", err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.DefaultRemote != Remote("origin") {
+		t.Fatalf("repo default should win, got %q", cfg.DefaultRemote)
+	}
+	shared := cfg.Remotes[Remote("shared")].Gen3
+	if shared == nil || shared.Endpoint != "https://shared.example" {
+		t.Fatalf("expected shared remote inherited, got %#v", shared)
+	}
+	origin := cfg.Remotes[Remote("origin")].Gen3
+	if origin.Endpoint != "https://repo.example" || origin.Bucket != "user-bucket" {
+		t.Fatalf("expected repo endpoint with inherited bucket, got %#v", origin)
+	}
+}
+
+func TestLoadConfig_UserDefaultRemoteWhenRepoUnset(t *testing.T) {
+	setupTestRepo(t)
+	writeUserConfig(t, `default_remote: shared
+remotes:
+  shared:
+    type: local
+    endpoint: http://localhost:8080
+`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	remote, err := cfg.GetDefaultRemote()
+	if err != nil || remote != Remote("shared") {
+		t.Fatalf("expected inherited default remote, got %q (%v)", remote, err)
+	}
+	if cfg.Remotes[remote].Local == nil {
+		t.Fatalf("expected local remote type inherited")
+	}
+}
+
+func TestMetricsSettings_RepoKeysOverrideUserConfig(t *testing.T) {
+	setupTestRepo(t)
+	writeUserConfig(t, `metrics:
+  summary: true
+  pushgateway: http://user-gateway:9091
+  job: shared-job
+`)
+
+	got := MetricsSettings()
+	if !got.Summary || got.Pushgateway != "http://user-gateway:9091" || got.Job != "shared-job" {
+		t.Fatalf("expected user metrics defaults, got %+v", got)
+	}
+
+	for key, value := range map[string]string{
+		"drs.metrics.summary":       "false",
+		"drs.metrics.pushgateway":   "http://repo-gateway:9091",
+		"drs.metrics.otlp-endpoint": "http://collector:4318",
+	} {
+		if out, err := exec.Command("git", "config", key, value).CombinedOutput(); err != nil {
+			t.Fatalf("git config %s: %v: %s", key, err, out)
+		}
+	}
+	got = MetricsSettings()
+	if got.Summary || got.Pushgateway != "http://repo-gateway:9091" || got.OTLPEndpoint != "http://collector:4318" || got.Job != "shared-job" {
+		t.Fatalf("expected repo metrics keys to win, got %+v", got)
+	}
+}
+
+func TestLoadUploadSettings_RepoKeyOverridesUserConfig(t *testing.T) {
+	setupTestRepo(t)
+	writeUserConfig(t, `upload:
+  credential_source: ambient
+  region: us-west-2
+`)
+
+	got, err := LoadUploadSettings("origin")
+	if err != nil || got.CredentialSource != CredentialSourceAmbient || got.Region != "us-west-2" || !got.Direct() {
+		t.Fatalf("expected user upload defaults, got %+v %v", got, err)
+	}
+
+	if out, err := exec.Command("git", "config", "drs.upload.credential-source", "Fence").CombinedOutput(); err != nil {
+		t.Fatalf("git config: %v: %s", err, out)
+	}
+	got, err = LoadUploadSettings("origin")
+	if err != nil || got.CredentialSource != CredentialSourceFence || got.Direct() {
+		t.Fatalf("expected repo key to win, got %+v %v", got, err)
+	}
+
+	if out, err := exec.Command("git", "config", "drs.upload.credential-source", "iam").CombinedOutput(); err != nil {
+		t.Fatalf("git config: %v: %s", err, out)
+	}
+	if _, err := LoadUploadSettings("origin"); err == nil {
+		t.Fatal("expected an invalid credential source to be rejected")
+	}
+}
+
+func TestLoadUploadSettings_MethodPrecedence(t *testing.T) {
+	setupTestRepo(t)
+	writeUserConfig(t, `upload:
+  method: presigned
+`)
+
+	got, err := LoadUploadSettings("origin")
+	if err != nil || got.Method != UploadMethodPresigned {
+		t.Fatalf("expected user upload method, got %+v %v", got, err)
+	}
+
+	for _, kv := range [][2]string{{"drs.upload.method", "multipart"}, {"drs.remote.other.upload-method", "Presigned"}} {
+		if out, err := exec.Command("git", "config", kv[0], kv[1]).CombinedOutput(); err != nil {
+			t.Fatalf("git config: %v: %s", err, out)
+		}
+	}
+	if got, err = LoadUploadSettings("origin"); err != nil || got.Method != UploadMethodMultipart {
+		t.Fatalf("expected repo key to win for origin, got %+v %v", got, err)
+	}
+	if got, err = LoadUploadSettings("other"); err != nil || got.Method != UploadMethodPresigned {
+		t.Fatalf("expected remote key to win for other, got %+v %v", got, err)
+	}
+
+	if out, err := exec.Command("git", "config", "drs.upload.method", "chunked").CombinedOutput(); err != nil {
+		t.Fatalf("git config: %v: %s", err, out)
+	}
+	if _, err := LoadUploadSettings("origin"); err == nil {
+		t.Fatal("expected an invalid upload method to be rejected")
+	}
+}
+
+func TestLoadTransferTuning_WorkerPrecedence(t *testing.T) {
+	setupTestRepo(t)
+	writeUserConfig(t, `transfer:
+  concurrency: 3
+  download_workers: 6
+`)
+
+	got := loadTransferTuning()
+	if got.UploadConcurrency != 3 || got.DownloadConcurrency != 6 {
+		t.Fatalf("expected user defaults, got %+v", got)
+	}
+
+	for key, value := range map[string]string{
+		"lfs.concurrenttransfers":     "5",
+		"drs.transfer.upload-workers": "9",
+	} {
+		if out, err := exec.Command("git", "config", key, value).CombinedOutput(); err != nil {
+			t.Fatalf("git config %s: %v: %s", key, err, out)
+		}
+	}
+	got = loadTransferTuning()
+	if got.UploadConcurrency != 9 || got.DownloadConcurrency != 6 {
+		t.Fatalf("expected per-direction settings to win over lfs.concurrenttransfers, got %+v", got)
+	}
+
+	t.Setenv(EnvUploadWorkers, "2")
+	t.Setenv(EnvDownloadWorkers, "0")
+	got = loadTransferTuning()
+	if got.UploadConcurrency != 2 || got.DownloadConcurrency != 6 {
+		t.Fatalf("expected env override for uploads only, got %+v", got)
+	}
+}
+
+func TestLoadStandaloneConfig_OutsideRepository(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeUserConfig(t, `default_remote: shared
+remotes:
+  shared:
+    endpoint: https://shared.example
+    project: shared-proj
+`)
+	t.Setenv(EnvProject, "hpc-proj")
+
+	cfg, err := LoadStandaloneConfig()
+	if err != nil {
+		t.Fatalf("LoadStandaloneConfig error: %v", err)
+	}
+	shared := cfg.Remotes[Remote("shared")].Gen3
+	if cfg.DefaultRemote != Remote("shared") || shared == nil {
+		t.Fatalf("expected user remote as default, got %#v", cfg)
+	}
+	if shared.Endpoint != "https://shared.example" || shared.ProjectID != "hpc-proj" {
+		t.Fatalf("expected env override on user remote, got %#v", shared)
+	}
+	if _, err := LoadConfig(); err == nil {
+		t.Fatal("LoadConfig should still require a repository")
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+// VerifyPolicy selects how many of the objects a push registered are checked
+// for readability before the push completes. The zero value checks none.
+type VerifyPolicy struct {
+	All bool
+	// Count checks at most this many objects.
+	Count int
+	// Percent checks this share of the objects, rounded up.
+	Percent float64
+}
+
+// Enabled reports whether the policy checks any object.
+func (p VerifyPolicy) Enabled() bool {
+	return p.All || p.Count > 0 || p.Percent > 0
+}
+
+// SampleSize is the number of objects to check out of n.
+func (p VerifyPolicy) SampleSize(n int) int {
+	k := 0
+	switch {
+	case p.All:
+		k = n
+	case p.Count > 0:
+		k = p.Count
+	case p.Percent > 0:
+		k = int(math.Ceil(float64(n) * p.Percent / 100))
+	}
+	return min(k, n)
+}
+
+// ParseVerifyPolicy parses off, all, a count such as 20, or a percentage
+// such as 10%. An empty value is off.
+func ParseVerifyPolicy(raw string) (VerifyPolicy, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	switch raw {
+	case "", "off", "false", "none", "0":
+		return VerifyPolicy{}, nil
+	case "all", "true":
+		return VerifyPolicy{All: true}, nil
+	}
+	if pct, ok := strings.CutSuffix(raw, "%"); ok {
+		v, err := strconv.ParseFloat(strings.TrimSpace(pct), 64)
+		if err != nil || v <= 0 || v > 100 {
+			return VerifyPolicy{}, fmt.Errorf("invalid verify percentage %q: expected a value in (0, 100]", raw)
+		}
+		if v == 100 {
+			return VerifyPolicy{All: true}, nil
+		}
+		return VerifyPolicy{Percent: v}, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return VerifyPolicy{}, fmt.Errorf("invalid verify policy %q: expected off, all, a count, or a percentage", raw)
+	}
+	return VerifyPolicy{Count: n}, nil
+}
+
+// PushVerifyPolicy reads drs.push.verify from git config. Like the limits, an
+// unparsable value is an error rather than silently skipping verification.
+func PushVerifyPolicy() (VerifyPolicy, error) {
+	raw, _ := gitrepo.GetGitConfigString("drs.push.verify")
+	p, err := ParseVerifyPolicy(raw)
+	if err != nil {
+		return VerifyPolicy{}, drserrors.WithKind(drserrors.ErrConfig, fmt.Errorf("drs.push.verify: %w", err))
+	}
+	return p, nil
+}
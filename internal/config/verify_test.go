@@ -0,0 +1,33 @@
+package config
+
+import "testing"
+
+func TestParseVerifyPolicy(t *testing.T) {
+	cases := []struct {
+		raw    string
+		n      int
+		sample int
+	}{
+		{"", 10, 0},
+		{"off", 10, 0},
+		{"all", 10, 10},
+		{"3", 10, 3},
+		{"30", 10, 10},
+		{"25%", 10, 3},
+		{"100%", 7, 7},
+	}
+	for _, c := range cases {
+		p, err := ParseVerifyPolicy(c.raw)
+		if err != nil {
+			t.Fatalf("%q: %v", c.raw, err)
+		}
+		if got := p.SampleSize(c.n); got != c.sample {
+			t.Errorf("%q: SampleSize(%d) = %d, want %d", c.raw, c.n, got, c.sample)
+		}
+	}
+	for _, raw := range []string{"some", "-1", "0%", "150%"} {
+		if _, err := ParseVerifyPolicy(raw); err == nil {
+			t.Errorf("%q: expected error", raw)
+		}
+	}
+}
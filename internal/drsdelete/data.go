@@ -0,0 +1,107 @@
+package drsdelete
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/config"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	syservices "github.com/calypr/syfon/client/services"
+)
+
+// DataPlan says whether deleting a record may also delete its bucket
+// object, and why not when it may not.
+type DataPlan struct {
+	DID        string
+	URLs       []string
+	DeleteData bool
+	// Reason explains why the bucket object is kept.
+	Reason string
+}
+
+// PlanDataDeletion decides, for each record about to be deleted, whether its
+// bucket object goes with it. An object is only deleted when every URL of the
+// record points into bucket, the project's managed bucket, and no record
+// outside records references the same URL; otherwise the bytes stay so other
+// records, or data the project does not own, are not broken.
+func PlanDataDeletion(ctx context.Context, idx IndexAPI, records []drsapi.DrsObject, bucket string) ([]DataPlan, error) {
+	deleting := make(map[string]bool, len(records))
+	for _, rec := range records {
+		deleting[rec.Id] = true
+	}
+	plans := make([]DataPlan, 0, len(records))
+	for _, rec := range records {
+		plan := DataPlan{DID: rec.Id, URLs: recordURLs(rec)}
+		if err := plan.check(ctx, idx, bucket, deleting); err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+func (p *DataPlan) check(ctx context.Context, idx IndexAPI, bucket string, deleting map[string]bool) error {
+	if strings.TrimSpace(bucket) == "" {
+		p.Reason = "no managed bucket is configured for the project"
+		return nil
+	}
+	if len(p.URLs) == 0 {
+		p.Reason = "record has no storage URL"
+		return nil
+	}
+	for _, raw := range p.URLs {
+		if !inBucket(raw, bucket) {
+			p.Reason = fmt.Sprintf("%s is outside the project's bucket %s", raw, bucket)
+			return nil
+		}
+		// One more record than is being deleted is enough to find any
+		// other reference.
+		resp, err := idx.List(ctx, syservices.ListRecordsOptions{URL: raw, Limit: len(deleting) + 1, Page: 1})
+		if err != nil {
+			return fmt.Errorf("list records referencing %s: %w", raw, err)
+		}
+		if resp.Records == nil {
+			continue
+		}
+		for _, other := range *resp.Records {
+			if !deleting[other.Did] {
+				p.Reason = fmt.Sprintf("%s is also referenced by %s", raw, other.Did)
+				return nil
+			}
+		}
+	}
+	p.DeleteData = true
+	return nil
+}
+
+func recordURLs(rec drsapi.DrsObject) []string {
+	if rec.AccessMethods == nil {
+		return nil
+	}
+	var urls []string
+	for _, am := range *rec.AccessMethods {
+		if am.AccessUrl != nil && strings.TrimSpace(am.AccessUrl.Url) != "" {
+			urls = append(urls, strings.TrimSpace(am.AccessUrl.Url))
+		}
+	}
+	return urls
+}
+
+// inBucket reports whether raw is an object URL (s3://, gs://, az://,
+// azblob://) in bucket. An az:// bucket names an Azure container.
+func inBucket(raw, bucket string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	if container, ok := config.AzureContainer(bucket); ok {
+		bucket = container
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "s3", "gs", "az", "azblob":
+		return strings.EqualFold(u.Host, strings.TrimSpace(bucket)) && strings.Trim(u.Path, "/") != ""
+	}
+	return false
+}
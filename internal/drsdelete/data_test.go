@@ -0,0 +1,105 @@
+package drsdelete
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	syservices "github.com/calypr/syfon/client/services"
+)
+
+// urlIndex answers List by the URL filter, the way the server does.
+type urlIndex struct {
+	fakeIndex
+}
+
+func (f *urlIndex) List(_ context.Context, opts syservices.ListRecordsOptions) (internalapi.ListRecordsResponse, error) {
+	var out []internalapi.InternalRecord
+	for _, rec := range f.records {
+		if rec.AccessMethods == nil {
+			continue
+		}
+		for _, am := range *rec.AccessMethods {
+			if am.AccessUrl != nil && am.AccessUrl.Url == opts.URL {
+				out = append(out, rec)
+				break
+			}
+		}
+	}
+	return internalapi.ListRecordsResponse{Records: &out}, nil
+}
+
+func accessMethods(urls ...string) *[]drsapi.AccessMethod {
+	out := make([]drsapi.AccessMethod, 0, len(urls))
+	for _, u := range urls {
+		out = append(out, drsapi.AccessMethod{
+			Type: drsapi.AccessMethodTypeS3,
+			AccessUrl: &struct {
+				Headers *[]string `json:"headers,omitempty"`
+				Url     string    `json:"url"`
+			}{Url: u},
+		})
+	}
+	return &out
+}
+
+func TestPlanDataDeletion(t *testing.T) {
+	shared := "s3://proj-bucket/org/proj/shared"
+	idx := &urlIndex{fakeIndex{records: map[string]internalapi.InternalRecord{
+		"did-own":    {Did: "did-own", AccessMethods: accessMethods("s3://proj-bucket/org/proj/own")},
+		"did-shared": {Did: "did-shared", AccessMethods: accessMethods(shared)},
+		"did-other":  {Did: "did-other", AccessMethods: accessMethods(shared)},
+		"did-pair-1": {Did: "did-pair-1", AccessMethods: accessMethods("s3://proj-bucket/org/proj/pair")},
+		"did-pair-2": {Did: "did-pair-2", AccessMethods: accessMethods("s3://proj-bucket/org/proj/pair")},
+	}}}
+	records := []drsapi.DrsObject{
+		{Id: "did-own", AccessMethods: accessMethods("s3://proj-bucket/org/proj/own")},
+		{Id: "did-shared", AccessMethods: accessMethods(shared)},
+		{Id: "did-foreign", AccessMethods: accessMethods("s3://someone-else/data")},
+		{Id: "did-https", AccessMethods: accessMethods("https://example.org/data")},
+		{Id: "did-pair-1", AccessMethods: accessMethods("s3://proj-bucket/org/proj/pair")},
+		{Id: "did-pair-2", AccessMethods: accessMethods("s3://proj-bucket/org/proj/pair")},
+		{Id: "did-empty"},
+	}
+
+	plans, err := PlanDataDeletion(context.Background(), idx, records, "proj-bucket")
+	if err != nil {
+		t.Fatalf("PlanDataDeletion: %v", err)
+	}
+	want := map[string]struct {
+		del    bool
+		reason string
+	}{
+		"did-own":     {del: true},
+		"did-shared":  {reason: "also referenced by did-other"},
+		"did-foreign": {reason: "outside the project's bucket"},
+		"did-https":   {reason: "outside the project's bucket"},
+		"did-pair-1":  {del: true},
+		"did-pair-2":  {del: true},
+		"did-empty":   {reason: "no storage URL"},
+	}
+	if len(plans) != len(want) {
+		t.Fatalf("got %d plans, want %d", len(plans), len(want))
+	}
+	for _, plan := range plans {
+		w := want[plan.DID]
+		if plan.DeleteData != w.del || !strings.Contains(plan.Reason, w.reason) {
+			t.Errorf("%s: DeleteData=%v Reason=%q, want %v and %q", plan.DID, plan.DeleteData, plan.Reason, w.del, w.reason)
+		}
+	}
+}
+
+func TestPlanDataDeletion_NoBucketKeepsData(t *testing.T) {
+	idx := &urlIndex{fakeIndex{records: map[string]internalapi.InternalRecord{}}}
+	plans, err := PlanDataDeletion(context.Background(), idx, []drsapi.DrsObject{
+		{Id: "did-1", AccessMethods: accessMethods("s3://proj-bucket/x")},
+	}, "")
+	if err != nil {
+		t.Fatalf("PlanDataDeletion: %v", err)
+	}
+	if plans[0].DeleteData {
+		t.Fatalf("expected data to be kept without a configured bucket: %+v", plans[0])
+	}
+}
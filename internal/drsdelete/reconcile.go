@@ -6,6 +6,7 @@ import (
 	"io"
 	"log/slog"
 
+	"github.com/calypr/git-drs/internal/audit"
 	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/drsremote"
 	sycommon "github.com/calypr/syfon/common"
@@ -34,6 +35,9 @@ func ReconcileCommittedDeletes(ctx context.Context, drsCtx *config.GitContext, r
 	if len(refs) == 0 {
 		return Summary{}, nil
 	}
+	if err := drsCtx.RequireWrite(); err != nil {
+		return Summary{}, err
+	}
 
 	deletedByOID, err := collectDeletedPointers(ctx, refs)
 	if err != nil {
@@ -90,6 +94,13 @@ func ReconcileCommittedDeletes(ctx context.Context, drsCtx *config.GitContext, r
 				return summary, err
 			}
 			summary.DeletedRecords++
+			audit.RecordOrWarn(logger, audit.Event{
+				Action:  audit.ActionDelete,
+				Remote:  drsCtx.RemoteName,
+				Project: drsCtx.ProjectId,
+				DRSID:   record.Id,
+				OID:     oid,
+			})
 			continue
 		}
 
@@ -100,6 +111,14 @@ func ReconcileCommittedDeletes(ctx context.Context, drsCtx *config.GitContext, r
 			return summary, err
 		}
 		summary.RemovedResources++
+		audit.RecordOrWarn(logger, audit.Event{
+			Action:  audit.ActionUpdate,
+			Remote:  drsCtx.RemoteName,
+			Project: drsCtx.ProjectId,
+			DRSID:   record.Id,
+			OID:     oid,
+			Detail:  "removed controlled-access resource " + resource,
+		})
 	}
 
 	if logger != nil && (summary.DeletedRecords > 0 || summary.RemovedResources > 0 || summary.ClearedLocalOnly > 0 || summary.PendingMissing > 0 || summary.PendingAmbiguous > 0) {
@@ -0,0 +1,150 @@
+package drsdelete
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	syservices "github.com/calypr/syfon/client/services"
+)
+
+// SoftDeleteMarker prefixes the description of a soft-deleted record. The
+// remainder of the description is a JSON SoftDelete. Record lookups in
+// drsremote skip records carrying it.
+const SoftDeleteMarker = drsobject.SoftDeleteMarker
+
+// DefaultRetention is how long a soft-deleted record is kept before
+// `git drs delete --purge-expired` removes it and its bucket object.
+const DefaultRetention = 30 * 24 * time.Hour
+
+// ErrNotSoftDeleted is returned by Restore for records without a marker.
+var ErrNotSoftDeleted = errors.New("record is not soft-deleted")
+
+// SoftDelete is the deletion flag stored on a record.
+type SoftDelete struct {
+	DeletedAt   string `json:"deleted_at"`
+	DeletedBy   string `json:"deleted_by,omitempty"`
+	PurgeAfter  string `json:"purge_after"`
+	Description string `json:"description,omitempty"`
+}
+
+// IndexAPI is the subset of the Syfon index client soft deletion needs.
+type IndexAPI interface {
+	Get(ctx context.Context, did string) (internalapi.InternalRecordResponse, error)
+	Update(ctx context.Context, did string, rec internalapi.InternalRecord) (internalapi.InternalRecordResponse, error)
+	List(ctx context.Context, opts syservices.ListRecordsOptions) (internalapi.ListRecordsResponse, error)
+}
+
+// ParseSoftDelete returns the deletion flag in description, if any.
+func ParseSoftDelete(description *string) (SoftDelete, bool) {
+	if description == nil || !strings.HasPrefix(*description, SoftDeleteMarker) {
+		return SoftDelete{}, false
+	}
+	var sd SoftDelete
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(*description, SoftDeleteMarker)), &sd); err != nil {
+		return SoftDelete{}, false
+	}
+	return sd, true
+}
+
+// MarkDeleted flags did as deleted without removing it. The original
+// description is kept in the flag so Restore can put it back. Marking an
+// already-deleted record is a no-op that returns its existing flag.
+func MarkDeleted(ctx context.Context, idx IndexAPI, did, by string, now time.Time, retention time.Duration) (SoftDelete, error) {
+	resp, err := idx.Get(ctx, did)
+	if err != nil {
+		return SoftDelete{}, fmt.Errorf("get record %s: %w", did, err)
+	}
+	if sd, ok := ParseSoftDelete(resp.Description); ok {
+		return sd, nil
+	}
+	sd := SoftDelete{
+		DeletedAt:  now.UTC().Format(time.RFC3339),
+		DeletedBy:  by,
+		PurgeAfter: now.Add(retention).UTC().Format(time.RFC3339),
+	}
+	if resp.Description != nil {
+		sd.Description = *resp.Description
+	}
+	payload, err := json.Marshal(sd)
+	if err != nil {
+		return SoftDelete{}, err
+	}
+	rec := recordFromResponse(resp)
+	desc := SoftDeleteMarker + string(payload)
+	rec.Description = &desc
+	if _, err := idx.Update(ctx, did, rec); err != nil {
+		return SoftDelete{}, fmt.Errorf("mark record %s deleted: %w", did, err)
+	}
+	return sd, nil
+}
+
+// Restore clears the deletion flag on did.
+func Restore(ctx context.Context, idx IndexAPI, did string) (internalapi.InternalRecordResponse, error) {
+	resp, err := idx.Get(ctx, did)
+	if err != nil {
+		return resp, fmt.Errorf("get record %s: %w", did, err)
+	}
+	sd, ok := ParseSoftDelete(resp.Description)
+	if !ok {
+		return resp, fmt.Errorf("%s: %w", did, ErrNotSoftDeleted)
+	}
+	rec := recordFromResponse(resp)
+	rec.Description = nil
+	if sd.Description != "" {
+		desc := sd.Description
+		rec.Description = &desc
+	}
+	out, err := idx.Update(ctx, did, rec)
+	if err != nil {
+		return resp, fmt.Errorf("restore record %s: %w", did, err)
+	}
+	return out, nil
+}
+
+// ExpiredSoftDeletes lists soft-deleted records in the project whose
+// retention window ended before now.
+func ExpiredSoftDeletes(ctx context.Context, idx IndexAPI, org, project string, now time.Time) ([]internalapi.InternalRecord, error) {
+	var expired []internalapi.InternalRecord
+	err := drsremote.ListRecordsParallel(ctx, idx, drsremote.ListOptions{
+		Organization: org,
+		ProjectID:    project,
+	}, func(_ int, records []internalapi.InternalRecord) error {
+		for _, rec := range records {
+			sd, ok := ParseSoftDelete(rec.Description)
+			if !ok {
+				continue
+			}
+			purgeAfter, err := time.Parse(time.RFC3339, sd.PurgeAfter)
+			if err != nil || purgeAfter.After(now) {
+				continue
+			}
+			expired = append(expired, rec)
+		}
+		return nil
+	})
+	return expired, err
+}
+
+func recordFromResponse(in internalapi.InternalRecordResponse) internalapi.InternalRecord {
+	return internalapi.InternalRecord{
+		Did:              in.Did,
+		AccessMethods:    in.AccessMethods,
+		ControlledAccess: in.ControlledAccess,
+		CreatedTime:      in.CreatedTime,
+		Description:      in.Description,
+		FileName:         in.FileName,
+		Hashes:           in.Hashes,
+		Organization:     in.Organization,
+		Project:          in.Project,
+		Size:             in.Size,
+		UpdatedTime:      in.UpdatedTime,
+		Version:          in.Version,
+	}
+}
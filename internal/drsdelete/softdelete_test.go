@@ -0,0 +1,102 @@
+package drsdelete
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	syservices "github.com/calypr/syfon/client/services"
+)
+
+type fakeIndex struct {
+	records map[string]internalapi.InternalRecord
+	updates int
+}
+
+func (f *fakeIndex) Get(_ context.Context, did string) (internalapi.InternalRecordResponse, error) {
+	rec, ok := f.records[did]
+	if !ok {
+		return internalapi.InternalRecordResponse{}, errors.New("not found")
+	}
+	return internalapi.InternalRecordResponse{Did: rec.Did, Description: rec.Description, Hashes: rec.Hashes}, nil
+}
+
+func (f *fakeIndex) Update(_ context.Context, did string, rec internalapi.InternalRecord) (internalapi.InternalRecordResponse, error) {
+	f.updates++
+	f.records[did] = rec
+	return internalapi.InternalRecordResponse{Did: rec.Did, Description: rec.Description, Hashes: rec.Hashes}, nil
+}
+
+func (f *fakeIndex) List(_ context.Context, opts syservices.ListRecordsOptions) (internalapi.ListRecordsResponse, error) {
+	var out []internalapi.InternalRecord
+	if opts.Page <= 1 {
+		for _, rec := range f.records {
+			out = append(out, rec)
+		}
+	}
+	return internalapi.ListRecordsResponse{Records: &out}, nil
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestMarkDeletedAndRestoreRoundTrip(t *testing.T) {
+	idx := &fakeIndex{records: map[string]internalapi.InternalRecord{
+		"did-1": {Did: "did-1", Description: strPtr("raw reads")},
+	}}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	sd, err := MarkDeleted(context.Background(), idx, "did-1", "alice", now, 48*time.Hour)
+	if err != nil {
+		t.Fatalf("MarkDeleted: %v", err)
+	}
+	if sd.PurgeAfter != "2026-01-03T00:00:00Z" || sd.DeletedBy != "alice" || sd.Description != "raw reads" {
+		t.Fatalf("unexpected flag: %+v", sd)
+	}
+	if _, ok := ParseSoftDelete(idx.records["did-1"].Description); !ok {
+		t.Fatalf("record not flagged: %v", *idx.records["did-1"].Description)
+	}
+
+	// Marking again keeps the original flag.
+	again, err := MarkDeleted(context.Background(), idx, "did-1", "bob", now.Add(time.Hour), time.Hour)
+	if err != nil {
+		t.Fatalf("MarkDeleted again: %v", err)
+	}
+	if again != sd || idx.updates != 1 {
+		t.Fatalf("expected idempotent mark, got %+v after %d updates", again, idx.updates)
+	}
+
+	if _, err := Restore(context.Background(), idx, "did-1"); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got := idx.records["did-1"].Description; got == nil || *got != "raw reads" {
+		t.Fatalf("description not restored: %v", got)
+	}
+	if _, err := Restore(context.Background(), idx, "did-1"); !errors.Is(err, ErrNotSoftDeleted) {
+		t.Fatalf("expected ErrNotSoftDeleted, got %v", err)
+	}
+}
+
+func TestExpiredSoftDeletes(t *testing.T) {
+	idx := &fakeIndex{records: map[string]internalapi.InternalRecord{
+		"live":    {Did: "live"},
+		"expired": {Did: "expired"},
+		"pending": {Did: "pending"},
+	}}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := MarkDeleted(context.Background(), idx, "expired", "", start, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MarkDeleted(context.Background(), idx, "pending", "", start, 72*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ExpiredSoftDeletes(context.Background(), idx, "org", "proj", start.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("ExpiredSoftDeletes: %v", err)
+	}
+	if len(got) != 1 || got[0].Did != "expired" {
+		t.Fatalf("expected only the expired record, got %+v", got)
+	}
+}
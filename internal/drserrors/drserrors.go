@@ -0,0 +1,191 @@
+// Package drserrors classifies failures from DRS servers, storage, and the
+// network, so callers can tell a missing object from expired credentials or
+// an unreachable server, and the CLI can exit with a code per class.
+package drserrors
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/calypr/syfon/client/request"
+)
+
+// Error classes. Test with errors.Is; errors returned by drsremote and the
+// commands built on it wrap one of these when the class is known.
+var (
+	// ErrNotFound: the server has no such object or record.
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized: credentials are missing, expired, or lack access.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrConflict: the server refused a write that conflicts with its state.
+	ErrConflict = errors.New("conflict")
+	// ErrChecksumMismatch: downloaded content does not hash to the expected
+	// sha256.
+	ErrChecksumMismatch = errors.New("downloaded object checksum mismatch")
+	// ErrNetwork: the server could not be reached or the connection failed.
+	ErrNetwork = errors.New("network error")
+	// ErrConfig: git-drs is not configured, or its configuration is invalid.
+	ErrConfig = errors.New("configuration error")
+	// ErrPartial: a batch operation failed for some of its objects and
+	// succeeded for the rest.
+	ErrPartial = errors.New("partial failure")
+)
+
+// Exit codes for each class; other failures, including a batch operation
+// in which every object failed, exit with 1.
+const (
+	ExitConfig           = 2
+	ExitNotFound         = 3
+	ExitUnauthorized     = 4
+	ExitConflict         = 5
+	ExitChecksumMismatch = 6
+	ExitNetwork          = 7
+	ExitPartial          = 8
+)
+
+// Error attaches a class, and the HTTP status it came from if any, to err.
+type Error struct {
+	Kind   error
+	Status int
+	Err    error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() []error { return []error{e.Kind, e.Err} }
+
+// WithKind marks err as belonging to kind. A nil err stays nil.
+func WithKind(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// Partial marks err as a partial failure when some but not all of total
+// objects failed. A batch in which every object failed is left unmarked, so
+// it exits like any other failure.
+func Partial(failed, total int, err error) error {
+	if failed <= 0 || failed >= total {
+		return err
+	}
+	return WithKind(ErrPartial, err)
+}
+
+// Classify returns err wrapped with its class when one can be determined
+// from an HTTP status or a network failure, and err unchanged otherwise.
+// Errors that already carry a class are returned as is.
+func Classify(err error) error {
+	if err == nil || Kind(err) != nil {
+		return err
+	}
+	if status, ok := Status(err); ok {
+		if kind := kindForStatus(status); kind != nil {
+			return &Error{Kind: kind, Status: status, Err: err}
+		}
+		return err
+	}
+	if isNetwork(err) {
+		return &Error{Kind: ErrNetwork, Err: err}
+	}
+	return err
+}
+
+// Kind returns the class err belongs to, or nil. Configuration errors and
+// partial failures take precedence over the class of the underlying cause.
+func Kind(err error) error {
+	for _, kind := range []error{ErrConfig, ErrPartial, ErrNotFound, ErrUnauthorized, ErrConflict, ErrChecksumMismatch, ErrNetwork} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return nil
+}
+
+// statusRE matches the status codes the syfon client and git-drs probes put
+// in error text when they do not return a *request.ResponseError.
+var statusRE = regexp.MustCompile(`(?:unexpected response|status(?: code)?):? (\d{3})\b`)
+
+// Status returns the HTTP status behind err, if it records one.
+func Status(err error) (int, bool) {
+	var classified *Error
+	if errors.As(err, &classified) && classified.Status != 0 {
+		return classified.Status, true
+	}
+	var respErr *request.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.Status, true
+	}
+	if m := statusRE.FindStringSubmatch(err.Error()); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		return status, true
+	}
+	return 0, false
+}
+
+func kindForStatus(status int) error {
+	switch status {
+	case http.StatusNotFound, http.StatusGone:
+		return ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return ErrConflict
+	}
+	return nil
+}
+
+func isNetwork(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// ExitCode is the process exit code for err: 0 for nil, the class's code
+// when err has one, and 1 otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	switch Kind(Classify(err)) {
+	case ErrConfig:
+		return ExitConfig
+	case ErrPartial:
+		return ExitPartial
+	case ErrNotFound:
+		return ExitNotFound
+	case ErrUnauthorized:
+		return ExitUnauthorized
+	case ErrConflict:
+		return ExitConflict
+	case ErrChecksumMismatch:
+		return ExitChecksumMismatch
+	case ErrNetwork:
+		return ExitNetwork
+	}
+	return 1
+}
+
+// HTTPStatus is the status an API serving err to its own clients should
+// answer with: 404 and 409 pass through, and other failures of the upstream
+// DRS server or storage use fallback.
+func HTTPStatus(err error, fallback int) int {
+	switch Kind(Classify(err)) {
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrConflict:
+		return http.StatusConflict
+	}
+	return fallback
+}
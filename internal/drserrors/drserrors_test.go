@@ -0,0 +1,73 @@
+package drserrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/calypr/syfon/client/request"
+)
+
+func TestClassify(t *testing.T) {
+	dnsErr := &url.Error{Op: "Get", URL: "https://drs.example", Err: &net.DNSError{Err: "no such host", Name: "drs.example"}}
+	cases := []struct {
+		name string
+		err  error
+		kind error
+		code int
+	}{
+		{"response error 404", fmt.Errorf("get object: %w", &request.ResponseError{Status: http.StatusNotFound}), ErrNotFound, ExitNotFound},
+		{"generated client 401", fmt.Errorf("unexpected response: %d", http.StatusUnauthorized), ErrUnauthorized, ExitUnauthorized},
+		{"probe 403", errors.New("download probe failed with status 403"), ErrUnauthorized, ExitUnauthorized},
+		{"register 409", fmt.Errorf("bulk register failed: %w", errors.New("unexpected response: 409")), ErrConflict, ExitConflict},
+		{"checksum", fmt.Errorf("pull: %w", ErrChecksumMismatch), ErrChecksumMismatch, ExitChecksumMismatch},
+		{"dns", dnsErr, ErrNetwork, ExitNetwork},
+		{"server error", errors.New("unexpected response: 500"), nil, 1},
+		{"canceled", &url.Error{Op: "Get", URL: "https://drs.example", Err: context.Canceled}, nil, 1},
+		{"plain", errors.New("no default remote configured"), nil, 1},
+		{"config", WithKind(ErrConfig, errors.New("no default remote configured")), ErrConfig, ExitConfig},
+		{"partial", Partial(2, 5, errors.New("2 of 5 objects failed")), ErrPartial, ExitPartial},
+		{"partial over auth", Partial(1, 3, fmt.Errorf("1 of 3 failed: %w", errors.New("unexpected response: 401"))), ErrPartial, ExitPartial},
+		{"all failed", Partial(3, 3, errors.New("unexpected response: 401")), ErrUnauthorized, ExitUnauthorized},
+	}
+	for _, c := range cases {
+		got := Classify(c.err)
+		if Kind(got) != c.kind {
+			t.Errorf("%s: kind = %v, want %v", c.name, Kind(got), c.kind)
+		}
+		if c.kind != nil && !errors.Is(got, c.kind) {
+			t.Errorf("%s: errors.Is(%v) = false", c.name, c.kind)
+		}
+		if got.Error() != c.err.Error() {
+			t.Errorf("%s: message changed to %q", c.name, got.Error())
+		}
+		if code := ExitCode(c.err); code != c.code {
+			t.Errorf("%s: exit code = %d, want %d", c.name, code, c.code)
+		}
+	}
+	if ExitCode(nil) != 0 {
+		t.Fatal("nil error should exit 0")
+	}
+}
+
+func TestClassifiedErrorKeepsCauseAndStatus(t *testing.T) {
+	cause := &request.ResponseError{Status: http.StatusGone, Body: "deleted"}
+	err := Classify(cause)
+	var respErr *request.ResponseError
+	if !errors.As(err, &respErr) || respErr != cause {
+		t.Fatalf("cause not reachable from %v", err)
+	}
+	if status, ok := Status(err); !ok || status != http.StatusGone {
+		t.Fatalf("Status = %d, %v", status, ok)
+	}
+	if HTTPStatus(err, http.StatusBadGateway) != http.StatusNotFound {
+		t.Fatalf("HTTPStatus should pass 404 through")
+	}
+	if HTTPStatus(errors.New("unexpected response: 500"), http.StatusBadGateway) != http.StatusBadGateway {
+		t.Fatalf("HTTPStatus should fall back for server errors")
+	}
+}
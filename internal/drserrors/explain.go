@@ -0,0 +1,211 @@
+package drserrors
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/calypr/syfon/client/request"
+)
+
+// TroubleshootingURL is the page the remediation links in explanations point
+// into.
+const TroubleshootingURL = "https://github.com/calypr/git-drs/blob/main/docs/troubleshooting.md"
+
+// Explanation is a plain-language reading of a common server failure.
+type Explanation struct {
+	// Summary says what went wrong.
+	Summary string
+	// Hint says what to do about it.
+	Hint string
+	// Doc links to the remediation steps.
+	Doc string
+	// Detail is the server's own message, without the JSON around it.
+	Detail string
+}
+
+// Explained is an error with its explanation. It unwraps to the original
+// error, so its class and exit code are unchanged.
+type Explained struct {
+	Explanation
+	Err error
+}
+
+func (e *Explained) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Summary)
+	if e.Hint != "" {
+		b.WriteString("\n  hint: " + e.Hint)
+	}
+	if e.Doc != "" {
+		b.WriteString("\n  see: " + e.Doc)
+	}
+	if e.Detail != "" {
+		b.WriteString("\n  server said: " + e.Detail)
+	}
+	return b.String()
+}
+
+func (e *Explained) Unwrap() error { return e.Err }
+
+// Translate returns err as an *Explained error when it matches a known
+// server response, and err unchanged otherwise.
+func Translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	var explained *Explained
+	if errors.As(err, &explained) {
+		return err
+	}
+	if ex, ok := Explain(err); ok {
+		return &Explained{Explanation: ex, Err: err}
+	}
+	return err
+}
+
+var (
+	resourcePathRE = regexp.MustCompile(`/programs/[^\s"',;)\]}]+`)
+	sha256RE       = regexp.MustCompile(`(?i)\b[0-9a-f]{64}\b`)
+)
+
+// explainRule recognizes one kind of failure from its status and the
+// lowercased error text, which includes the response body. explain is given
+// the text as it was, to quote from.
+type explainRule struct {
+	match   func(status int, text string) bool
+	explain func(raw string) Explanation
+}
+
+// explainRules are tried in order; the first match wins.
+var explainRules = []explainRule{
+	{
+		match: func(status int, text string) bool {
+			return isAuthStatus(status) && containsAny(text, "expired", "exp claim")
+		},
+		explain: func(string) Explanation {
+			return Explanation{
+				Summary: "the access token for this remote has expired",
+				Hint:    "run 'git drs auth refresh'; if the API key has expired too, download a new one and run 'git drs auth login --cred <file>'",
+				Doc:     TroubleshootingURL + "#my-credentials-expired",
+			}
+		},
+	},
+	{
+		match: func(status int, text string) bool {
+			return strings.Contains(text, "bucket") &&
+				containsAny(text, "not linked", "not configured", "not mapped", "no bucket", "no mapping", "not associated", "bucket not found", "no credential")
+		},
+		explain: func(string) Explanation {
+			return Explanation{
+				Summary: "the server has no bucket linked to this project",
+				Hint:    "ask a data steward to run 'git drs bucket add-project --organization <org> --project <project> --path s3://<bucket>/<prefix>'",
+				Doc:     TroubleshootingURL + "#bucket-not-linked-to-the-project",
+			}
+		},
+	},
+	{
+		match: func(status int, text string) bool {
+			return strings.Contains(text, "size") && containsAny(text, "sha256", "checksum", "hash") &&
+				containsAny(text, "mismatch", "differ", "does not match", "conflict")
+		},
+		explain: func(raw string) Explanation {
+			summary := "the server already has a record with this sha256 but a different size"
+			hint := "the local file may be truncated, or the record was registered from other content; run 'git drs fsck' to re-hash local files and 'git drs query --checksum <sha256>' to inspect the record"
+			if oid := strings.ToLower(sha256RE.FindString(raw)); oid != "" {
+				summary = "the server already has a record for sha256 " + oid + " with a different size"
+				hint = strings.Replace(hint, "<sha256>", oid, 1)
+			}
+			return Explanation{Summary: summary, Hint: hint, Doc: TroubleshootingURL + "#same-sha256-with-a-different-size"}
+		},
+	},
+	{
+		match: func(status int, text string) bool {
+			return status == http.StatusForbidden ||
+				isAuthStatus(status) && (resourcePathRE.MatchString(text) || containsAny(text, "authz", "permission", "not authorized", "access denied"))
+		},
+		explain: func(raw string) Explanation {
+			summary := "your account does not have access to this project"
+			if path := resourcePathRE.FindString(raw); path != "" {
+				summary = "your account does not have access to " + path
+			}
+			return Explanation{
+				Summary: summary,
+				Hint:    "ask a data commons administrator to grant your account access to the project; 'git drs auth status' shows which account this remote uses",
+				Doc:     TroubleshootingURL + "#no-access-to-a-resource-path",
+			}
+		},
+	},
+}
+
+// Explain matches err against the server failures users most often hit: an
+// expired token, missing access to a resource path, a project without a
+// bucket, and a sha256 registered with a different size.
+func Explain(err error) (Explanation, bool) {
+	if err == nil {
+		return Explanation{}, false
+	}
+	status, _ := Status(err)
+	if status == 0 && errors.Is(err, ErrUnauthorized) {
+		status = http.StatusUnauthorized
+	}
+	raw := err.Error()
+	text := strings.ToLower(raw)
+	for _, rule := range explainRules {
+		if rule.match(status, text) {
+			ex := rule.explain(raw)
+			ex.Detail = serverMessage(err)
+			return ex, true
+		}
+	}
+	return Explanation{}, false
+}
+
+func isAuthStatus(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+func containsAny(text string, subs ...string) bool {
+	for _, s := range subs {
+		if strings.Contains(text, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// serverMessage returns the message in the response body behind err, read
+// from the error, message, or detail field that Gen3 services put it in, or
+// the body itself when it is not JSON.
+func serverMessage(err error) string {
+	var respErr *request.ResponseError
+	if !errors.As(err, &respErr) {
+		return ""
+	}
+	body := strings.TrimSpace(respErr.Body)
+	var fields map[string]any
+	if json.Unmarshal([]byte(body), &fields) == nil {
+		for _, key := range []string{"error", "message", "detail", "msg", "description"} {
+			if msg := messageField(fields[key]); msg != "" {
+				return msg
+			}
+		}
+	}
+	return body
+}
+
+func messageField(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]any:
+		for _, key := range []string{"message", "detail", "msg"} {
+			if s, ok := v[key].(string); ok && strings.TrimSpace(s) != "" {
+				return strings.TrimSpace(s)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package drserrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/calypr/syfon/client/request"
+)
+
+func TestTranslate(t *testing.T) {
+	oid := strings.Repeat("ab", 32)
+	cases := []struct {
+		name    string
+		err     error
+		summary string
+		anchor  string
+		detail  string
+	}{
+		{
+			"expired token",
+			fmt.Errorf("bulk register: %w", &request.ResponseError{Status: http.StatusUnauthorized, Body: `{"error":"Authentication Error: Signature has expired"}`}),
+			"the access token for this remote has expired", "#my-credentials-expired", "Authentication Error: Signature has expired",
+		},
+		{
+			"missing authz",
+			&request.ResponseError{Status: http.StatusForbidden, Body: `{"error":{"message":"user does not have access to /programs/HTAN/projects/BForePC","code":403}}`},
+			"your account does not have access to /programs/HTAN/projects/BForePC", "#no-access-to-a-resource-path", "user does not have access to /programs/HTAN/projects/BForePC",
+		},
+		{
+			"bucket not linked",
+			fmt.Errorf("upload: %w", &request.ResponseError{Status: http.StatusBadRequest, Body: `{"detail":"no bucket configured for organization HTAN project BForePC"}`}),
+			"the server has no bucket linked to this project", "#bucket-not-linked-to-the-project", "no bucket configured for organization HTAN project BForePC",
+		},
+		{
+			"hash collision",
+			&request.ResponseError{Status: http.StatusConflict, Body: "record with sha256 " + oid + " exists with a different size"},
+			"the server already has a record for sha256 " + oid + " with a different size", "#same-sha256-with-a-different-size", "record with sha256 " + oid + " exists with a different size",
+		},
+	}
+	for _, c := range cases {
+		got := Translate(c.err)
+		var ex *Explained
+		if !errors.As(got, &ex) {
+			t.Errorf("%s: not translated: %v", c.name, got)
+			continue
+		}
+		if ex.Summary != c.summary || !strings.HasSuffix(ex.Doc, c.anchor) || ex.Detail != c.detail {
+			t.Errorf("%s: explanation = %+v", c.name, ex.Explanation)
+		}
+		if !errors.Is(got, c.err) || ExitCode(got) != ExitCode(c.err) {
+			t.Errorf("%s: translation lost the cause or exit code", c.name)
+		}
+		if !strings.HasPrefix(got.Error(), c.summary+"\n  hint: ") {
+			t.Errorf("%s: message = %q", c.name, got.Error())
+		}
+	}
+}
+
+func TestTranslateLeavesOtherErrors(t *testing.T) {
+	for _, err := range []error{
+		errors.New("no default remote configured"),
+		&request.ResponseError{Status: http.StatusInternalServerError, Body: "boom"},
+		fmt.Errorf("unexpected response: %d", http.StatusNotFound),
+	} {
+		if got := Translate(err); got != err {
+			t.Errorf("Translate(%v) = %v; want it unchanged", err, got)
+		}
+	}
+	if Translate(nil) != nil {
+		t.Fatal("nil error translated")
+	}
+}
@@ -2,8 +2,6 @@ package drsfilter
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"io"
 	"log/slog"
@@ -12,17 +10,22 @@ import (
 
 	"github.com/calypr/git-drs/internal/common"
 	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/hashing"
 	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/mimetype"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 )
 
 // writeDrsMap records a local DRS object entry in .git/drs/lfs/objects so that
-// the pre-push workflow can discover and upload the file.
-func writeDrsMap(pathname string, oid string, size int64) error {
+// the pre-push workflow can discover and upload the file. content is where
+// the file's bytes can be read to detect its MIME type.
+func writeDrsMap(pathname string, oid string, size int64, content string) error {
 	name := filepath.Base(pathname)
+	mimeType := mimetype.DetectFile(name, content)
 	drsObj := &drsapi.DrsObject{
-		Name: &name,
-		Size: size,
+		Name:     &name,
+		MimeType: &mimeType,
+		Size:     size,
 		Checksums: []drsapi.Checksum{
 			{Type: "sha256", Checksum: oid},
 		},
@@ -30,6 +33,7 @@ func writeDrsMap(pathname string, oid string, size int64) error {
 	if existing, err := drsobject.ReadObject(common.DRS_OBJS_PATH, oid); err == nil && existing != nil {
 		drsObj = existing
 		drsObj.Name = &name
+		drsObj.MimeType = &mimeType
 		drsObj.Size = size
 		drsObj.Checksums = []drsapi.Checksum{
 			{Type: "sha256", Checksum: oid},
@@ -38,22 +42,20 @@ func writeDrsMap(pathname string, oid string, size int64) error {
 	return drsobject.WriteObject(common.DRS_OBJS_PATH, drsObj, oid)
 }
 
-// CleanContent reads raw file content from content, hashes it with SHA-256,
-// stores the content in the git-lfs local object cache under lfsRoot, and
+// CleanContent spools raw file content from content, hashes the spooled copy
+// with the shared hashing engine, stores the content in the git-lfs local object cache under lfsRoot, and
 // writes an LFS pointer to dst. It also records a DRS map entry so that
 // `git drs push` can discover the file.
 //
 // pathname is the repo-relative path of the file being cleaned; it is used
 // only for the DRS map entry name and log messages.
 func CleanContent(ctx context.Context, lfsRoot, pathname string, content io.Reader, dst io.Writer, logger *slog.Logger) error {
-	_ = ctx // reserved for future cancellation propagation
-
 	objDir := filepath.Join(lfsRoot, "objects")
 	if err := os.MkdirAll(objDir, 0o755); err != nil {
 		return fmt.Errorf("clean: mkdir LFS objects: %w", err)
 	}
 
-	// Buffer the content into a temp file while computing its SHA-256.
+	// Buffer the content into a temp file, then hash it.
 	tmp, err := os.CreateTemp(objDir, "git-drs-clean-*")
 	if err != nil {
 		return fmt.Errorf("clean: create temp file: %w", err)
@@ -65,17 +67,18 @@ func CleanContent(ctx context.Context, lfsRoot, pathname string, content io.Read
 		}
 	}()
 
-	h := sha256.New()
-	written, err := io.Copy(tmp, io.TeeReader(content, h))
-	if err != nil {
+	if _, err := common.Copy(tmp, content); err != nil {
 		tmp.Close()
 		return fmt.Errorf("clean: write temp file: %w", err)
 	}
 	if err := tmp.Close(); err != nil {
 		return fmt.Errorf("clean: close temp file: %w", err)
 	}
-	size := written
-	oid := hex.EncodeToString(h.Sum(nil))
+	hashed, err := hashing.HashFiles(ctx, []string{tmpPath}, hashing.Options{Mmap: true})
+	if err != nil {
+		return fmt.Errorf("clean: %w", err)
+	}
+	oid, size := hashed[0].OID, hashed[0].Size
 
 	if size > 0 && size < 2048 {
 		if data, readErr := os.ReadFile(tmpPath); readErr == nil {
@@ -83,7 +86,7 @@ func CleanContent(ctx context.Context, lfsRoot, pathname string, content io.Read
 				if _, err := dst.Write(data); err != nil {
 					return fmt.Errorf("clean: write existing pointer: %w", err)
 				}
-				if mapErr := writeDrsMap(pathname, pointerOID, pointerSize); mapErr != nil {
+				if mapErr := writeDrsMap(pathname, pointerOID, pointerSize, cachedObjectPath(pointerOID)); mapErr != nil {
 					logger.Warn("clean: failed to write DRS map entry for existing pointer", "pathname", pathname, "error", mapErr)
 				}
 				logger.Debug("clean: passed through existing LFS pointer", "pathname", pathname, "oid", pointerOID, "size", pointerSize)
@@ -107,18 +110,90 @@ func CleanContent(ctx context.Context, lfsRoot, pathname string, content io.Read
 	logger.Debug("clean: stored LFS object", "pathname", pathname, "oid", oid, "size", size)
 
 	// Write the LFS pointer to dst.
-	pointer := fmt.Sprintf(
-		"version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n",
-		oid, size,
-	)
-	if _, err := io.WriteString(dst, pointer); err != nil {
+	if _, err := dst.Write(pointerFor(oid, size)); err != nil {
 		return fmt.Errorf("clean: write pointer: %w", err)
 	}
 
 	// Record a DRS map entry so `git drs push` can find the file.
-	if mapErr := writeDrsMap(pathname, oid, size); mapErr != nil {
+	if mapErr := writeDrsMap(pathname, oid, size, cachePath); mapErr != nil {
 		logger.Warn("clean: failed to write DRS map entry", "pathname", pathname, "error", mapErr)
 	}
 
 	return nil
 }
+
+// StoreFile records a file whose oid was computed by the caller, as
+// CleanContent would: the content is copied into the LFS object cache unless
+// already present, a DRS map entry is written, and the pointer is returned.
+// Files that are already LFS pointers pass through unchanged.
+func StoreFile(lfsRoot, pathname, src, oid string, size int64, logger *slog.Logger) ([]byte, error) {
+	if size > 0 && size < 2048 {
+		if data, err := os.ReadFile(src); err == nil {
+			if pointerOID, pointerSize, ok := lfs.ParseLFSPointer(data); ok {
+				if mapErr := writeDrsMap(pathname, pointerOID, pointerSize, cachedObjectPath(pointerOID)); mapErr != nil {
+					logger.Warn("store: failed to write DRS map entry for existing pointer", "pathname", pathname, "error", mapErr)
+				}
+				return data, nil
+			}
+		}
+	}
+
+	cachePath, err := lfs.ObjectPath(common.LFS_OBJS_PATH, oid)
+	if err != nil {
+		return nil, fmt.Errorf("store: resolve cache path: %w", err)
+	}
+	if info, err := os.Stat(cachePath); err != nil || info.Size() != size {
+		if err := copyIntoCache(lfsRoot, src, cachePath); err != nil {
+			return nil, err
+		}
+		logger.Debug("store: stored LFS object", "pathname", pathname, "oid", oid, "size", size)
+	}
+
+	if mapErr := writeDrsMap(pathname, oid, size, cachePath); mapErr != nil {
+		logger.Warn("store: failed to write DRS map entry", "pathname", pathname, "error", mapErr)
+	}
+	return pointerFor(oid, size), nil
+}
+
+func copyIntoCache(lfsRoot, src, cachePath string) error {
+	objDir := filepath.Join(lfsRoot, "objects")
+	if err := os.MkdirAll(objDir, 0o755); err != nil {
+		return fmt.Errorf("store: mkdir LFS objects: %w", err)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(objDir, "git-drs-store-*")
+	if err != nil {
+		return fmt.Errorf("store: create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	if _, err := common.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return fmt.Errorf("store: write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("store: close temp file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
+		return fmt.Errorf("store: mkdir for cache path: %w", err)
+	}
+	if err := os.Rename(tmpPath, cachePath); err != nil {
+		return fmt.Errorf("store: move to cache: %w", err)
+	}
+	return nil
+}
+
+// cachedObjectPath is where the LFS store keeps oid's content, or "" for an
+// invalid oid.
+func cachedObjectPath(oid string) string {
+	p, _ := lfs.ObjectPath(common.LFS_OBJS_PATH, oid)
+	return p
+}
+
+func pointerFor(oid string, size int64) []byte {
+	return []byte(fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", oid, size))
+}
@@ -74,3 +74,40 @@ func TestCleanContentPassesThroughExistingPointer(t *testing.T) {
 		}
 	}
 }
+
+func TestStoreFileCopiesContentAndReturnsPointer(t *testing.T) {
+	repo := t.TempDir()
+	orig, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer os.Chdir(orig)
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	content := []byte("dataset payload")
+	if err := os.WriteFile("payload.bin", content, 0o644); err != nil {
+		t.Fatalf("write payload: %v", err)
+	}
+	sum := sha256.Sum256(content)
+	oid := hex.EncodeToString(sum[:])
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	pointer, err := StoreFile(filepath.Join(repo, ".git", "lfs"), "data/payload.bin", "payload.bin", oid, int64(len(content)), logger)
+	if err != nil {
+		t.Fatalf("StoreFile returned error: %v", err)
+	}
+	if gotOID, gotSize, ok := lfs.ParseLFSPointer(pointer); !ok || gotOID != oid || gotSize != int64(len(content)) {
+		t.Fatalf("unexpected pointer %q", pointer)
+	}
+	cachePath, err := lfs.ObjectPath(common.LFS_OBJS_PATH, oid)
+	if err != nil {
+		t.Fatalf("ObjectPath: %v", err)
+	}
+	if cached, err := os.ReadFile(cachePath); err != nil || !bytes.Equal(cached, content) {
+		t.Fatalf("expected cached content, got %q (%v)", cached, err)
+	}
+	if _, err := drsobject.ReadObject(common.DRS_OBJS_PATH, oid); err != nil {
+		t.Fatalf("expected DRS map entry: %v", err)
+	}
+}
@@ -9,14 +9,42 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/gitrepo"
 	"github.com/calypr/git-drs/internal/lfs"
 )
 
 // SmudgeDownloadFunc downloads the object identified by oid into cachePath.
 type SmudgeDownloadFunc func(ctx context.Context, oid, cachePath string) error
 
+// SkipSmudgeEnv, when set to a true value, makes smudge write pointers for
+// objects missing from the local cache instead of downloading them. It
+// mirrors GIT_LFS_SKIP_SMUDGE and is used by `git drs clone`.
+const SkipSmudgeEnv = "GIT_DRS_SKIP_SMUDGE"
+
+// SkipSmudgeConfig does the same for every checkout of a repository, such as
+// a CI clone that only needs the code; SkipSmudgeEnv overrides it either way.
+const SkipSmudgeConfig = "drs.skip-smudge"
+
+// skipSmudgeConfigured reads SkipSmudgeConfig once per process, since the
+// filter asks for every file; swapped in tests.
+var skipSmudgeConfigured = sync.OnceValue(func() bool {
+	return gitrepo.GetGitConfigBool(SkipSmudgeConfig, false)
+})
+
+// SkipSmudge reports whether SkipSmudgeEnv, or else SkipSmudgeConfig, is
+// enabled.
+func SkipSmudge() bool {
+	if skip, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(SkipSmudgeEnv))); err == nil {
+		return skip
+	}
+	return skipSmudgeConfigured()
+}
+
 // SmudgeContent reads pointer content from ptr and writes smudged content to dst.
 // If the payload is not an LFS pointer, it passes data through unchanged.
 func SmudgeContent(ctx context.Context, pathname string, ptr io.Reader, dst io.Writer, logger *slog.Logger, download SmudgeDownloadFunc) error {
@@ -51,7 +79,7 @@ func SmudgeContent(ctx context.Context, pathname string, ptr io.Reader, dst io.W
 		return fmt.Errorf("smudge: read cache: %w", err)
 	}
 
-	if download == nil {
+	if download == nil || SkipSmudge() {
 		// No remote configured — write the pointer back to the working tree
 		// unchanged, matching git-lfs --skip-smudge behaviour.
 		if logger != nil {
@@ -61,12 +89,8 @@ func SmudgeContent(ctx context.Context, pathname string, ptr io.Reader, dst io.W
 		return err
 	}
 
-	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
-		return fmt.Errorf("smudge: mkdir for cache path: %w", err)
-	}
-
-	if err := download(ctx, oid, cachePath); err != nil {
-		return fmt.Errorf("smudge: download oid %s: %w", oid, err)
+	if err := DownloadToCache(ctx, oid, download); err != nil {
+		return err
 	}
 
 	if err := copyObjectToWriter(cachePath, dst); err != nil {
@@ -76,6 +100,38 @@ func SmudgeContent(ctx context.Context, pathname string, ptr io.Reader, dst io.W
 	return nil
 }
 
+// PendingDownload reports the oid of ptr when it is an LFS pointer whose
+// object is missing from the local cache and smudge would download it.
+func PendingDownload(ptr []byte) (string, bool) {
+	oid, _, ok := lfs.ParseLFSPointer(ptr)
+	if !ok || SkipSmudge() {
+		return "", false
+	}
+	cachePath, err := lfs.ObjectPath(common.LFS_OBJS_PATH, oid)
+	if err != nil {
+		return "", false
+	}
+	if _, err := os.Stat(cachePath); !errors.Is(err, fs.ErrNotExist) {
+		return "", false
+	}
+	return oid, true
+}
+
+// DownloadToCache fetches oid into the local LFS object cache.
+func DownloadToCache(ctx context.Context, oid string, download SmudgeDownloadFunc) error {
+	cachePath, err := lfs.ObjectPath(common.LFS_OBJS_PATH, oid)
+	if err != nil {
+		return fmt.Errorf("smudge: resolve cache path: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
+		return fmt.Errorf("smudge: mkdir for cache path: %w", err)
+	}
+	if err := download(ctx, oid, cachePath); err != nil {
+		return fmt.Errorf("smudge: download oid %s: %w", oid, err)
+	}
+	return nil
+}
+
 func copyObjectToWriter(path string, dst io.Writer) error {
 	f, err := os.Open(path)
 	if err != nil {
@@ -83,6 +139,6 @@ func copyObjectToWriter(path string, dst io.Writer) error {
 	}
 	defer f.Close()
 
-	_, err = io.Copy(dst, f)
+	_, err = common.Copy(dst, f)
 	return err
 }
@@ -157,3 +157,53 @@ func mustObjectPath(t *testing.T, oid string) string {
 func pointerForOID(oid string, size int64) string {
 	return fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", oid, size)
 }
+
+func TestPendingDownloadOnlyForUncachedPointers(t *testing.T) {
+	setupSmudgeTestRepo(t)
+	cached := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	missing := "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	cachePath := mustObjectPath(t, cached)
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
+		t.Fatalf("mkdir cache dir: %v", err)
+	}
+	if err := os.WriteFile(cachePath, []byte("cached"), 0o644); err != nil {
+		t.Fatalf("write cache file: %v", err)
+	}
+
+	if _, ok := PendingDownload([]byte(pointerForOID(cached, 6))); ok {
+		t.Fatal("cached object should not need a download")
+	}
+	if _, ok := PendingDownload([]byte("plain-bytes\n")); ok {
+		t.Fatal("non-pointer content should not need a download")
+	}
+	oid, ok := PendingDownload([]byte(pointerForOID(missing, 6)))
+	if !ok || oid != missing {
+		t.Fatalf("PendingDownload = %q, %v; want %q", oid, ok, missing)
+	}
+
+	t.Setenv(SkipSmudgeEnv, "true")
+	if _, ok := PendingDownload([]byte(pointerForOID(missing, 6))); ok {
+		t.Fatal("skip-smudge should never download")
+	}
+}
+
+func TestSkipSmudgeConfigAndEnv(t *testing.T) {
+	orig := skipSmudgeConfigured
+	t.Cleanup(func() { skipSmudgeConfigured = orig })
+	configured := true
+	skipSmudgeConfigured = func() bool { return configured }
+
+	t.Setenv(SkipSmudgeEnv, "")
+	if !SkipSmudge() {
+		t.Fatal("drs.skip-smudge=true should skip smudge")
+	}
+	t.Setenv(SkipSmudgeEnv, "0")
+	if SkipSmudge() {
+		t.Fatal("GIT_DRS_SKIP_SMUDGE=0 should override drs.skip-smudge")
+	}
+	configured = false
+	t.Setenv(SkipSmudgeEnv, "1")
+	if !SkipSmudge() {
+		t.Fatal("GIT_DRS_SKIP_SMUDGE=1 should skip smudge")
+	}
+}
@@ -13,6 +13,8 @@ import (
 
 	"github.com/calypr/git-drs/internal/common"
 	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/projectdir"
+	"github.com/calypr/git-drs/internal/userconfig"
 
 	"github.com/calypr/syfon/client/logs"
 )
@@ -27,6 +29,14 @@ var modulePathSuffixValue string
 var repoRootOnce sync.Once
 var repoRootValue string
 
+// levelVar is the level of the global logger. NewLogger sets it from config
+// and SetVerbosity adjusts it from the -q/-v flags.
+var levelVar slog.LevelVar
+var verbosity int
+
+// LevelTrace is below slog.LevelDebug and is enabled by -vv.
+const LevelTrace = slog.LevelDebug - 4
+
 const (
 	levelDebugStr   = "DEBUG"
 	levelInfoStr    = "INFO"
@@ -66,21 +76,55 @@ func TraceEnabled() bool {
 	return GIT_TRANSFER_TRACE == 1
 }
 
+// SetVerbosity adjusts the global log level from the root -q/-v flags.
+//
+// Behavior:
+// - v < 0 (-q) logs errors only.
+// - v == 0 keeps the level from drs.loglevel, logging.level, or GIT_TRANSFER_TRACE.
+// - v == 1 (-v) logs at debug.
+// - v >= 2 (-vv) logs at LevelTrace and enables transfer tracing, as GIT_TRANSFER_TRACE=1 does.
+// Typical callers:
+// - the root command's PersistentPreRunE, after flags are parsed.
+func SetVerbosity(v int) {
+	verbosity = v
+	switch {
+	case v < 0:
+		levelVar.Set(slog.LevelError)
+	case v == 1:
+		levelVar.Set(slog.LevelDebug)
+	case v >= 2:
+		levelVar.Set(LevelTrace)
+		GIT_TRANSFER_TRACE = 1
+	}
+}
+
+// Verbosity returns the value last passed to SetVerbosity: negative for -q,
+// and the number of -v flags otherwise.
+//
+// Typical callers:
+// - commands that print extra detail in verbose mode.
+func Verbosity() int {
+	return verbosity
+}
+
 // NewLogger creates and installs a global slog.Logger that writes to the specified file
 // and optionally to stderr. It is safe to call multiple times; the first successful call
 // establishes the global logger.
 //
 // Documented calls inside:
-//   - projectdir.DRS_DIR usage
-//     Uses projectdir.DRS_DIR to create log directory.
-//   - os.MkdirAll(projectdir.DRS_DIR, 0755)
+//   - projectdir.Path(common.DRS_DIR)
+//     Resolves the log directory against the repository root, so a command
+//     run from a subdirectory logs to the same file.
+//   - os.MkdirAll(drsDir, 0755)
 //     Ensures the directory exists; returns error on failure.
-//   - filepath.Join(projectdir.DRS_DIR, "git-drs.log")
+//   - filepath.Join(drsDir, "git-drs.log")
 //     Constructs default filename when none provided.
 //   - os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 //     Opens/creates the log file (returns *os.File).
 //   - io.MultiWriter(writers...)
 //     Combines file and optionally os.Stderr into a single Writer.
+//   - levelVar.Set(resolveLogLevel())
+//     Resets the shared level, which SetVerbosity may later adjust.
 //   - slog.NewTextHandler(multiWriter, &slog.HandlerOptions{...})
 //     Creates the text handler for slog that writes to the combined writer.
 //   - slog.New(handler).With("pid", os.Getpid())
@@ -97,11 +141,12 @@ func NewLogger(filename string, logToStderr bool) (*slog.Logger, error) {
 
 	if filename == "" {
 		// create drs dir if it doesn't exist
-		if err := os.MkdirAll(common.DRS_DIR, 0755); err != nil {
+		drsDir := projectdir.Path(common.DRS_DIR)
+		if err := os.MkdirAll(drsDir, 0755); err != nil {
 			return nil, err
 		}
 
-		filename = filepath.Join(common.DRS_DIR, "git-drs.log")
+		filename = filepath.Join(drsDir, "git-drs.log")
 	}
 
 	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
@@ -115,10 +160,11 @@ func NewLogger(filename string, logToStderr bool) (*slog.Logger, error) {
 	}
 
 	multiWriter := io.MultiWriter(writers...)
+	levelVar.Set(resolveLogLevel())
 
 	handler := slog.NewTextHandler(multiWriter, &slog.HandlerOptions{
 		AddSource:   true,
-		Level:       resolveLogLevel(),
+		Level:       &levelVar,
 		ReplaceAttr: replaceSourceAttr,
 	})
 	core := slog.New(logs.NewProgressHandler(handler)).With("pid", os.Getpid())
@@ -199,6 +245,8 @@ func NewNoOpLogger() *slog.Logger {
 //     If trace is enabled, returns Debug level immediately.
 //   - readLogLevelFromGitConfig()
 //     Attempts to read configured level from git config; returns level and ok.
+//   - readLogLevelFromUserConfig()
+//     Falls back to logging.level in the user-level config file.
 //   - defaults to slog.LevelInfo when nothing else matches.
 //
 // Typical callers:
@@ -213,6 +261,10 @@ func resolveLogLevel() slog.Level {
 		return level
 	}
 
+	if level, ok := readLogLevelFromUserConfig(); ok {
+		return level
+	}
+
 	return slog.LevelInfo
 }
 
@@ -245,6 +297,24 @@ func readLogLevelFromGitConfig() (slog.Level, bool) {
 	return parsed, true
 }
 
+// readLogLevelFromUserConfig reads logging.level from the user-level config.
+//
+// Behavior:
+// - A missing or unreadable file, or an unknown level, returns (slog.LevelInfo, false).
+// Typical callers:
+// - resolveLogLevel when git config does not set drs.loglevel.
+func readLogLevelFromUserConfig() (slog.Level, bool) {
+	cfg, err := userconfig.Load()
+	if err != nil || cfg.Logging.Level == "" {
+		return slog.LevelInfo, false
+	}
+	parsed, ok := parseLogLevel(cfg.Logging.Level)
+	if !ok {
+		return slog.LevelInfo, false
+	}
+	return parsed, true
+}
+
 // parseLogLevel maps textual level names to slog.Level.
 //
 // Documented calls inside:
@@ -271,7 +341,8 @@ func parseLogLevel(value string) (slog.Level, bool) {
 	}
 }
 
-// replaceSourceAttr rewrites the slog.Source attr to a shorter path suitable for logs.
+// replaceSourceAttr rewrites the slog.Source attr to a shorter path suitable for logs,
+// and names LevelTrace TRACE instead of DEBUG-4.
 //
 // Documented calls inside:
 //   - attr.Key comparison with slog.SourceKey
@@ -286,6 +357,12 @@ func parseLogLevel(value string) (slog.Level, bool) {
 // Typical callers:
 // - passed as ReplaceAttr to slog.HandlerOptions in NewLogger.
 func replaceSourceAttr(_ []string, attr slog.Attr) slog.Attr {
+	if attr.Key == slog.LevelKey {
+		if level, ok := attr.Value.Any().(slog.Level); ok && level <= LevelTrace {
+			attr.Value = slog.StringValue("TRACE")
+		}
+		return attr
+	}
 	if attr.Key != slog.SourceKey {
 		return attr
 	}
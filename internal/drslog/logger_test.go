@@ -177,3 +177,41 @@ func TestLogger_MultipleMessages(t *testing.T) {
 		t.Error("Expected message 3")
 	}
 }
+
+func TestSetVerbosityAdjustsGlobalLevel(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "test.log")
+	origTrace := GIT_TRANSFER_TRACE
+	t.Cleanup(func() {
+		GIT_TRANSFER_TRACE = origTrace
+		SetVerbosity(0)
+		_ = Close()
+	})
+
+	logger, err := NewLogger(logFile, false)
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+	SetVerbosity(-1)
+	logger.Warn("quiet warning")
+	logger.Error("quiet error")
+	SetVerbosity(2)
+	logger.Log(t.Context(), LevelTrace, "trace detail")
+	if !TraceEnabled() || Verbosity() != 2 {
+		t.Fatalf("-vv should enable trace, got trace=%v verbosity=%d", TraceEnabled(), Verbosity())
+	}
+	_ = Close()
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if bytes.Contains(content, []byte("quiet warning")) {
+		t.Error("-q should drop warnings")
+	}
+	if !bytes.Contains(content, []byte("quiet error")) {
+		t.Error("-q should keep errors")
+	}
+	if !bytes.Contains(content, []byte("level=TRACE")) || !bytes.Contains(content, []byte("trace detail")) {
+		t.Errorf("-vv should log trace messages, got:\n%s", content)
+	}
+}
@@ -0,0 +1,39 @@
+package drslog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Transport wraps base so every request is logged at LevelTrace with its
+// method, host, path, status, and duration. The query string is left out
+// because signed URLs carry credentials there. A nil base uses
+// http.DefaultTransport.
+//
+// Typical callers:
+// - config.httpClientOptions when trace logging is enabled (-vv or GIT_TRANSFER_TRACE=1).
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return traceTransport{base: base}
+}
+
+type traceTransport struct {
+	base http.RoundTripper
+}
+
+func (t traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	status := "error"
+	if resp != nil {
+		status = fmt.Sprint(resp.StatusCode)
+	}
+	GetLogger().Log(context.Background(), LevelTrace, "http request",
+		"method", req.Method, "host", req.URL.Host, "path", req.URL.Path,
+		"status", status, "duration", time.Since(start).Round(time.Millisecond))
+	return resp, err
+}
@@ -7,10 +7,10 @@ import (
 	"github.com/calypr/git-drs/internal/common"
 	"github.com/calypr/git-drs/internal/drsobject"
 	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/mimetype"
 	"github.com/calypr/git-drs/internal/precommit_cache"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	syfoncommon "github.com/calypr/syfon/common"
-	"github.com/google/uuid"
 )
 
 type WriteOptions struct {
@@ -42,7 +42,7 @@ func WriteObjectsForLFSFiles(builder drsobject.Builder, lfsFiles map[string]lfs.
 			authoritativeObj.Size = file.Size
 			ensureControlledAccess(authoritativeObj, builder.Organization, builder.Project)
 		} else {
-			drsID := uuid.NewSHA1(drsobject.UUIDNamespace, []byte(fmt.Sprintf("%s:%s", builder.Project, drsobject.NormalizeOid(file.Oid)))).String()
+			drsID := builder.ID(file.Name, file.Oid)
 			authoritativeObj, err = builder.Build(file.Name, file.Oid, file.Size, drsID)
 			if err != nil {
 				opts.Logger.Error(fmt.Sprintf("Could not build DRS object for %s OID %s %v", file.Name, file.Oid, err))
@@ -50,6 +50,11 @@ func WriteObjectsForLFSFiles(builder drsobject.Builder, lfsFiles map[string]lfs.
 			}
 		}
 
+		if authoritativeObj.MimeType == nil || *authoritativeObj.MimeType == "" {
+			mimeType := mimetype.ForOID(file.Name, file.Oid)
+			authoritativeObj.MimeType = &mimeType
+		}
+
 		authoritativeURL := ""
 		if authoritativeObj.AccessMethods != nil && len(*authoritativeObj.AccessMethods) > 0 && (*authoritativeObj.AccessMethods)[0].AccessUrl != nil {
 			authoritativeURL = (*authoritativeObj.AccessMethods)[0].AccessUrl.Url
@@ -0,0 +1,129 @@
+package drsobject
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/projectdir"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// internalAliasPrefix starts the aliases git-drs uses to carry predecessor
+// links and record metadata (see AliasMetadata).
+const internalAliasPrefix = "git-drs"
+
+// reservedAliasPrefixes are the internal prefix and the one a server reads
+// as a requested ID.
+var reservedAliasPrefixes = []string{"id:", internalAliasPrefix}
+
+// ValidateAlias rejects aliases that are empty, contain whitespace, or use
+// a reserved prefix.
+func ValidateAlias(alias string) error {
+	if alias == "" || strings.ContainsAny(alias, " \t\r\n") {
+		return fmt.Errorf("invalid alias %q: aliases must be non-empty and contain no whitespace", alias)
+	}
+	for _, prefix := range reservedAliasPrefixes {
+		if strings.HasPrefix(alias, prefix) {
+			return fmt.Errorf("invalid alias %q: the %q prefix is reserved", alias, prefix)
+		}
+	}
+	return nil
+}
+
+// Aliases returns the record's user aliases, leaving out the ones git-drs
+// uses to carry version and encryption metadata and the requested ID.
+func Aliases(obj drsapi.DrsObject) []string {
+	if obj.Aliases == nil {
+		return nil
+	}
+	var aliases []string
+	for _, a := range *obj.Aliases {
+		if !reservedAlias(a) {
+			aliases = append(aliases, a)
+		}
+	}
+	return aliases
+}
+
+func reservedAlias(alias string) bool {
+	return slices.ContainsFunc(reservedAliasPrefixes, func(p string) bool { return strings.HasPrefix(alias, p) })
+}
+
+// RequestID asks the server to register obj under obj.Id rather than the ID
+// it would mint from the checksum, which is the same for every path of the
+// content. It replaces any ID requested earlier.
+func RequestID(obj *drsapi.DrsObject) {
+	kept := slices.DeleteFunc(slices.Clone(allAliases(*obj)), func(a string) bool { return strings.HasPrefix(a, "id:") })
+	if obj.Id != "" {
+		kept = append(kept, "id:"+obj.Id)
+	}
+	obj.Aliases = &kept
+}
+
+func allAliases(obj drsapi.DrsObject) []string {
+	if obj.Aliases == nil {
+		return nil
+	}
+	return *obj.Aliases
+}
+
+// AddAliases appends the aliases obj does not already carry and reports
+// whether any were added.
+func AddAliases(obj *drsapi.DrsObject, aliases ...string) bool {
+	current := slices.Clone(allAliases(*obj))
+	added := false
+	for _, a := range aliases {
+		if !slices.Contains(current, a) {
+			current = append(current, a)
+			added = true
+		}
+	}
+	if added {
+		obj.Aliases = &current
+	}
+	return added
+}
+
+// RemoveAliases drops aliases from obj and reports whether any were removed.
+func RemoveAliases(obj *drsapi.DrsObject, aliases ...string) bool {
+	current := allAliases(*obj)
+	kept := slices.DeleteFunc(slices.Clone(current), func(a string) bool { return slices.Contains(aliases, a) })
+	if len(kept) == len(current) {
+		return false
+	}
+	if len(kept) == 0 {
+		obj.Aliases = nil
+	} else {
+		obj.Aliases = &kept
+	}
+	return true
+}
+
+// FindByAlias returns the oids of the objects staged under basePath that
+// carry alias, sorted. Unreadable objects are skipped.
+func FindByAlias(basePath, alias string) ([]string, error) {
+	staged, err := ListObjects(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("search staged DRS objects: %w", err)
+	}
+	root := projectdir.Path(basePath)
+	var oids []string
+	for _, oid := range staged {
+		data, err := os.ReadFile(shardPath(root, oid))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("search staged DRS objects: %w", err)
+		}
+		obj, err := parseObject(data, oid)
+		if err == nil && slices.Contains(Aliases(*obj), alias) {
+			oids = append(oids, oid)
+		}
+	}
+	return oids, nil
+}
@@ -0,0 +1,100 @@
+package drsobject
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestValidateAlias(t *testing.T) {
+	for _, alias := range []string{"ACC-1", "phs000123.v1", "doi:10.1/x"} {
+		if err := ValidateAlias(alias); err != nil {
+			t.Fatalf("ValidateAlias(%q) = %v", alias, err)
+		}
+	}
+	for _, alias := range []string{"", "has space", "id:abc", "git-drs:predecessor:sha256:x"} {
+		if err := ValidateAlias(alias); err == nil {
+			t.Fatalf("expected ValidateAlias(%q) to fail", alias)
+		}
+	}
+}
+
+func TestAddRemoveAliasesKeepInternalAliases(t *testing.T) {
+	obj := &drsapi.DrsObject{}
+	SetVersion(obj, 2, strings.Repeat("a", 64))
+
+	if !AddAliases(obj, "ACC-1", "ACC-2") || AddAliases(obj, "ACC-1") {
+		t.Fatal("expected only new aliases to count as added")
+	}
+	if got := Aliases(*obj); !reflect.DeepEqual(got, []string{"ACC-1", "ACC-2"}) {
+		t.Fatalf("unexpected user aliases %v", got)
+	}
+	if !RemoveAliases(obj, "ACC-1") || RemoveAliases(obj, "missing") {
+		t.Fatal("expected only carried aliases to count as removed")
+	}
+	if got := Aliases(*obj); !reflect.DeepEqual(got, []string{"ACC-2"}) {
+		t.Fatalf("unexpected user aliases after remove %v", got)
+	}
+	if Predecessor(*obj) != strings.Repeat("a", 64) {
+		t.Fatal("expected predecessor alias to survive alias edits")
+	}
+}
+
+func TestFindByAlias(t *testing.T) {
+	base := t.TempDir()
+	a, b := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	for _, oid := range []string{a, b} {
+		obj := &drsapi.DrsObject{Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: oid}}}
+		AddAliases(obj, "shared", "only-"+oid[:1])
+		if err := WriteObject(base, obj, oid); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, err := FindByAlias(base, "only-b"); err != nil || !reflect.DeepEqual(got, []string{b}) {
+		t.Fatalf("FindByAlias(only-b) = %v, %v", got, err)
+	}
+	if got, _ := FindByAlias(base, "shared"); !reflect.DeepEqual(got, []string{a, b}) {
+		t.Fatalf("FindByAlias(shared) = %v", got)
+	}
+	if got, err := FindByAlias(base+"/missing", "shared"); err != nil || len(got) != 0 {
+		t.Fatalf("expected empty result for missing store, got %v, %v", got, err)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	obj := &drsapi.DrsObject{Id: "new-id", Aliases: &[]string{"ACC-1", "id:old-id"}}
+	RequestID(obj)
+	if !reflect.DeepEqual(*obj.Aliases, []string{"ACC-1", "id:new-id"}) {
+		t.Fatalf("aliases = %v; want the requested ID replaced", *obj.Aliases)
+	}
+	if got := Aliases(*obj); !reflect.DeepEqual(got, []string{"ACC-1"}) {
+		t.Fatalf("user aliases = %v; want the requested ID left out", got)
+	}
+}
+
+func TestAliasMetadataRoundTripKeepsOtherAliases(t *testing.T) {
+	type block struct {
+		Name string `json:"name"`
+	}
+	valid := func(b block) bool { return b.Name != "" }
+	const prefix = "git-drs-test:"
+	obj := &drsapi.DrsObject{Aliases: &[]string{"ACC-1", prefix + `{"name":""}`}}
+
+	if _, ok := AliasMetadata(obj, prefix, valid); ok {
+		t.Fatal("expected an empty block to be skipped")
+	}
+	SetAliasMetadata(obj, prefix, block{Name: "first"})
+	SetAliasMetadata(obj, prefix, block{Name: "second"})
+	if got, ok := AliasMetadata(obj, prefix, valid); !ok || got.Name != "second" {
+		t.Fatalf("AliasMetadata = %#v, %v", got, ok)
+	}
+	if want := []string{prefix + `{"name":"second"}`, "ACC-1"}; !reflect.DeepEqual(*obj.Aliases, want) {
+		t.Fatalf("aliases = %v, want %v", *obj.Aliases, want)
+	}
+	if _, ok := AliasMetadata[block](nil, prefix, valid); ok {
+		t.Fatal("expected nothing on a nil object")
+	}
+}
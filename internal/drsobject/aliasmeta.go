@@ -0,0 +1,51 @@
+package drsobject
+
+import (
+	"encoding/json"
+	"strings"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// git-drs carries the metadata it attaches to a record (encryption and
+// content-encoding blocks, storage hints, origin, provenance) as one alias
+// per kind: a fixed "git-drs..." prefix followed by the metadata as JSON.
+// Aliases travel with a record through registration, copy-records, and
+// replicate, so the metadata stays with the bytes it describes, and the
+// reserved prefix keeps users from setting or listing them as their own.
+
+// AliasMetadata decodes the block stored under prefix on obj. Blocks that do
+// not decode, or that valid rejects as empty, are skipped.
+func AliasMetadata[T any](obj *drsapi.DrsObject, prefix string, valid func(T) bool) (T, bool) {
+	var zero T
+	if obj == nil || obj.Aliases == nil {
+		return zero, false
+	}
+	for _, alias := range *obj.Aliases {
+		raw, ok := strings.CutPrefix(alias, prefix)
+		if !ok {
+			continue
+		}
+		var v T
+		if err := json.Unmarshal([]byte(raw), &v); err != nil || !valid(v) {
+			continue
+		}
+		return v, true
+	}
+	return zero, false
+}
+
+// SetAliasMetadata records v under prefix on obj, replacing any earlier
+// block with that prefix.
+func SetAliasMetadata[T any](obj *drsapi.DrsObject, prefix string, v T) {
+	raw, _ := json.Marshal(v)
+	aliases := []string{prefix + string(raw)}
+	if obj.Aliases != nil {
+		for _, alias := range *obj.Aliases {
+			if !strings.HasPrefix(alias, prefix) {
+				aliases = append(aliases, alias)
+			}
+		}
+	}
+	obj.Aliases = &aliases
+}
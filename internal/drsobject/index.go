@@ -0,0 +1,263 @@
+package drsobject
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/projectdir"
+)
+
+// The store keeps an index of its oids, one per line, next to the fanout
+// directories so listing it does not walk every shard. Writers append to the
+// index under the store lock; ListObjects compacts it once most lines are
+// duplicates or refer to removed objects. A store without an index, such as
+// one written by an older git-drs with the flat <base>/<oid> layout, is
+// migrated and indexed on first listing.
+const (
+	indexName = "index"
+	lockName  = "index.lock"
+)
+
+// Swapped in tests.
+var (
+	lockRetry      = 10 * time.Millisecond
+	lockTimeout    = 30 * time.Second
+	staleLockAfter = 2 * time.Minute
+)
+
+// lockStore takes the store's advisory lock, an O_EXCL file like the
+// repository lock. Holders only write, trash or restore single objects and
+// append to or rewrite the index, so a lock older than staleLockAfter
+// belongs to a process that died and is removed.
+func lockStore(root string) (func(), error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(root, lockName)
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("lock DRS object store: %w", err)
+		}
+		if info, serr := os.Stat(path); serr == nil && time.Since(info.ModTime()) > staleLockAfter {
+			_ = os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("lock DRS object store: %s is held by another process; remove it if no git-drs command is running", path)
+		}
+		time.Sleep(lockRetry)
+	}
+}
+
+// indexObject records oid in the index of the store at root. Without an
+// index nothing is recorded: the next ListObjects builds it from the shards.
+// The caller holds the store lock.
+func indexObject(root, oid string) error {
+	f, err := os.OpenFile(filepath.Join(root, indexName), os.O_APPEND|os.O_WRONLY, 0o644)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(oid + "\n"); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ListObjects returns the sorted oids staged in the store at basePath.
+func ListObjects(basePath string) ([]string, error) {
+	root := projectdir.Path(basePath)
+	if _, err := os.Stat(root); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	unlock, err := lockStore(root)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	lines, err := readIndex(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		oids, err := rebuildIndex(root)
+		if err != nil {
+			return nil, fmt.Errorf("index staged DRS objects: %w", err)
+		}
+		return oids, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read DRS object index: %w", err)
+	}
+	seen := make(map[string]bool, len(lines))
+	oids := make([]string, 0, len(lines))
+	for _, oid := range lines {
+		if seen[oid] {
+			continue
+		}
+		seen[oid] = true
+		if _, err := os.Stat(shardPath(root, oid)); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+	sort.Strings(oids)
+	if len(lines) > 2*len(oids)+1024 {
+		if err := writeIndex(root, oids); err != nil {
+			return nil, fmt.Errorf("compact DRS object index: %w", err)
+		}
+	}
+	return oids, nil
+}
+
+// refreshIndex rewrites the index as the objects it lists that still exist
+// plus found, the valid objects a walk of the store saw. Keeping indexed
+// objects means one written during the walk is not dropped.
+func refreshIndex(root string, found []string) error {
+	unlock, err := lockStore(root)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	indexed, err := readIndex(root)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	seen := make(map[string]bool, len(found)+len(indexed))
+	oids := make([]string, 0, len(found))
+	for _, oid := range found {
+		if isOid(oid) && !seen[oid] {
+			seen[oid] = true
+			oids = append(oids, oid)
+		}
+	}
+	for _, oid := range indexed {
+		if seen[oid] {
+			continue
+		}
+		seen[oid] = true
+		if _, err := os.Stat(shardPath(root, oid)); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+	sort.Strings(oids)
+	return writeIndex(root, oids)
+}
+
+// migrateStore moves legacy flat objects into their shards under the lock.
+func migrateStore(root string) error {
+	unlock, err := lockStore(root)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return migrateFlatLayout(root)
+}
+
+// rebuildIndex moves legacy flat objects into their shards, then indexes
+// every object in the store. The caller holds the store lock.
+func rebuildIndex(root string) ([]string, error) {
+	if err := migrateFlatLayout(root); err != nil {
+		return nil, err
+	}
+	var oids []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && isOid(d.Name()) {
+			oids = append(oids, d.Name())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(oids)
+	return oids, writeIndex(root, oids)
+}
+
+// migrateFlatLayout moves objects stored directly under root into the fanout
+// layout. Where both copies exist the sharded one is newer and kept.
+func migrateFlatLayout(root string) error {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !isOid(e.Name()) {
+			continue
+		}
+		if err := migrateFlatObject(root, e.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateFlatObject(root, oid string) error {
+	legacy := filepath.Join(root, oid)
+	dst := shardPath(root, oid)
+	if _, err := os.Stat(dst); err == nil {
+		return os.Remove(legacy)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(legacy, dst)
+}
+
+func readIndex(root string) ([]string, error) {
+	f, err := os.Open(filepath.Join(root, indexName))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var oids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if oid := strings.TrimSpace(scanner.Text()); isOid(oid) {
+			oids = append(oids, oid)
+		}
+	}
+	return oids, scanner.Err()
+}
+
+func writeIndex(root string, oids []string) error {
+	var b strings.Builder
+	for _, oid := range oids {
+		b.WriteString(oid)
+		b.WriteByte('\n')
+	}
+	return common.WriteFileAtomic(filepath.Join(root, indexName), []byte(b.String()), 0o644)
+}
+
+func shardPath(root, oid string) string {
+	return filepath.Join(root, oid[:2], oid[2:4], oid)
+}
+
+func isOid(name string) bool {
+	if len(name) != 64 {
+		return false
+	}
+	for _, c := range name {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
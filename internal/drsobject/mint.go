@@ -0,0 +1,69 @@
+package drsobject
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// IDStrategy selects how git-drs mints the DRS ID of a new local object.
+type IDStrategy string
+
+const (
+	// IDProjectHash derives the ID from the project and content hash, so a
+	// file has one ID per project wherever it lives. It is the default and
+	// matches DeterministicID.
+	IDProjectHash IDStrategy = "project-hash"
+	// IDPathHash derives the ID from the project, repo path, and content
+	// hash, so the same content at two paths gets two IDs.
+	IDPathHash IDStrategy = "path-hash"
+	// IDServer mints no local ID: the object carries none until push
+	// records the ID the server assigned when registering it.
+	IDServer IDStrategy = "server"
+)
+
+// ParseIDStrategy parses a strategy name; "" is IDProjectHash.
+func ParseIDStrategy(raw string) (IDStrategy, error) {
+	switch s := IDStrategy(strings.ToLower(strings.TrimSpace(raw))); s {
+	case "":
+		return IDProjectHash, nil
+	case IDProjectHash, IDPathHash, IDServer:
+		return s, nil
+	default:
+		return "", fmt.Errorf("invalid DRS ID strategy %q: use project-hash, path-hash, or server", raw)
+	}
+}
+
+// Minter mints DRS IDs for new local objects. The zero value mints
+// project-hash IDs without a prefix.
+type Minter struct {
+	Strategy IDStrategy
+	// Prefix is prepended to minted IDs, such as a "dg.XXXX/" namespace.
+	Prefix string
+}
+
+// ValidatePrefix rejects prefixes that cannot start a DRS ID.
+func ValidatePrefix(prefix string) error {
+	if strings.ContainsAny(prefix, " \t\r\n") || strings.HasPrefix(prefix, "/") {
+		return fmt.Errorf("invalid DRS ID prefix %q: expected a namespace such as dg.XXXX/", prefix)
+	}
+	return nil
+}
+
+// ID returns the ID for oid stored at repo path in project, or "" under
+// IDServer.
+func (m Minter) ID(project, path, oid string) string {
+	var id string
+	switch m.Strategy {
+	case IDServer:
+		return ""
+	case IDPathHash:
+		path = strings.TrimPrefix(filepath.ToSlash(path), "./")
+		id = uuid.NewSHA1(UUIDNamespace, []byte(fmt.Sprintf("%s:%s:%s", project, path, NormalizeOid(oid)))).String()
+	default:
+		id = DeterministicID(project, oid)
+	}
+	return m.Prefix + id
+}
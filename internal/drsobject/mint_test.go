@@ -0,0 +1,51 @@
+package drsobject
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMinterID(t *testing.T) {
+	oid := strings.Repeat("ab", 32)
+
+	legacy := Minter{}.ID("proj", "data/a.bin", "sha256:"+oid)
+	if legacy != DeterministicID("proj", oid) {
+		t.Fatalf("zero Minter = %q, want the project-hash ID", legacy)
+	}
+	if got := (Minter{}).ID("proj", "data/b.bin", oid); got != legacy {
+		t.Fatalf("project-hash ID depends on path: %q vs %q", got, legacy)
+	}
+
+	paths := Minter{Strategy: IDPathHash}
+	a, b := paths.ID("proj", "data/a.bin", oid), paths.ID("proj", "data/b.bin", oid)
+	if a == b || a == legacy {
+		t.Fatalf("path-hash IDs = %q, %q; want distinct per path", a, b)
+	}
+	if got := paths.ID("proj", "./data/a.bin", oid); got != a {
+		t.Fatalf("path-hash ID for ./data/a.bin = %q, want %q", got, a)
+	}
+
+	if got := (Minter{Strategy: IDPathHash, Prefix: "dg.TEST/"}).ID("proj", "data/a.bin", oid); got != "dg.TEST/"+a {
+		t.Fatalf("prefixed ID = %q", got)
+	}
+	if got := (Minter{Strategy: IDServer, Prefix: "dg.TEST/"}).ID("proj", "data/a.bin", oid); got != "" {
+		t.Fatalf("server strategy minted %q", got)
+	}
+}
+
+func TestParseIDStrategy(t *testing.T) {
+	for raw, want := range map[string]IDStrategy{"": IDProjectHash, "Path-Hash": IDPathHash, " server ": IDServer} {
+		if got, err := ParseIDStrategy(raw); err != nil || got != want {
+			t.Errorf("ParseIDStrategy(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, err := ParseIDStrategy("uuid4"); err == nil {
+		t.Error("ParseIDStrategy accepted uuid4")
+	}
+	if err := ValidatePrefix("dg.TEST/"); err != nil {
+		t.Errorf("ValidatePrefix(dg.TEST/) = %v", err)
+	}
+	if err := ValidatePrefix("dg TEST/"); err == nil {
+		t.Error("ValidatePrefix accepted a space")
+	}
+}
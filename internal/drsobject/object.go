@@ -16,6 +16,11 @@ import (
 // with this exact namespace. Do not change it without a DRS ID migration plan.
 var UUIDNamespace = uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
 
+// DeterministicID derives the DRS ID git-drs mints for an object in project.
+func DeterministicID(project, oid string) string {
+	return uuid.NewSHA1(UUIDNamespace, []byte(fmt.Sprintf("%s:%s", project, NormalizeOid(oid)))).String()
+}
+
 func NormalizeChecksum(raw string) string {
 	raw = strings.TrimSpace(raw)
 	raw = strings.TrimPrefix(raw, "sha256:")
@@ -26,6 +31,16 @@ func NormalizeOid(raw string) string {
 	return NormalizeChecksum(raw)
 }
 
+// SHA256 returns the record's sha256 checksum, or "" when it has none.
+func SHA256(obj drsapi.DrsObject) string {
+	for _, c := range obj.Checksums {
+		if strings.EqualFold(strings.ReplaceAll(c.Type, "-", ""), "sha256") {
+			return NormalizeChecksum(c.Checksum)
+		}
+	}
+	return ""
+}
+
 type Builder struct {
 	Bucket        string
 	Project       string
@@ -33,12 +48,19 @@ type Builder struct {
 	StoragePrefix string
 	Provider      string
 	AccessScheme  string
+	// IDs mints the IDs of objects the builder creates.
+	IDs Minter
 }
 
 func NewBuilder(bucket, project string) Builder {
 	return Builder{Bucket: bucket, Project: project}
 }
 
+// ID mints the DRS ID for oid stored at repo path in the builder's project.
+func (b Builder) ID(path, oid string) string {
+	return b.IDs.ID(b.Project, path, oid)
+}
+
 func (b Builder) Build(fileName string, checksum string, size int64, drsID string) (*drsapi.DrsObject, error) {
 	prefix := strings.Trim(strings.TrimSpace(b.StoragePrefix), "/")
 	return BuildWithPrefix(fileName, checksum, size, drsID, b.Bucket, b.Organization, b.Project, prefix)
@@ -87,14 +109,16 @@ func BuildWithOptions(fileName string, checksum string, size int64, drsID string
 	}
 
 	obj := &drsapi.DrsObject{
-		Id:      drsID,
-		SelfUri: "drs://" + drsID,
-		Size:    size,
-		Name:    &fileName,
+		Id:   drsID,
+		Size: size,
+		Name: &fileName,
 		Checksums: []drsapi.Checksum{
 			{Type: "sha256", Checksum: checksum},
 		},
 	}
+	if drsID != "" {
+		obj.SelfUri = "drs://" + drsID
+	}
 
 	if opts.Bucket == "" {
 		return obj, nil
@@ -0,0 +1,38 @@
+package drsobject
+
+import (
+	"encoding/json"
+	"strings"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// SoftDeleteMarker prefixes the description of a soft-deleted record. The
+// remainder of the description is the JSON deletion flag written by
+// drsdelete.MarkDeleted.
+const SoftDeleteMarker = "git-drs:deleted "
+
+// IsSoftDeleted reports whether description carries a soft-delete flag.
+func IsSoftDeleted(description *string) bool {
+	if description == nil || !strings.HasPrefix(*description, SoftDeleteMarker) {
+		return false
+	}
+	return json.Valid([]byte(strings.TrimPrefix(*description, SoftDeleteMarker)))
+}
+
+// WithoutSoftDeleted returns the records in objs that are not soft-deleted.
+// objs is returned as is when none are.
+func WithoutSoftDeleted(objs []drsapi.DrsObject) []drsapi.DrsObject {
+	for i := range objs {
+		if IsSoftDeleted(objs[i].Description) {
+			live := make([]drsapi.DrsObject, 0, len(objs)-1)
+			for _, obj := range objs {
+				if !IsSoftDeleted(obj.Description) {
+					live = append(live, obj)
+				}
+			}
+			return live
+		}
+	}
+	return objs
+}
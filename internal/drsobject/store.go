@@ -1,25 +1,58 @@
 package drsobject
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/bytedance/sonic"
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/projectdir"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 )
 
+// ErrCorruptObject marks a staged object that could not be parsed. ReadObject
+// moves such files to QuarantineDir so they are rebuilt on the next write.
+var ErrCorruptObject = errors.New("corrupt staged DRS object")
+
+// objectPath returns the fanout path for oid in the store at basePath. A
+// relative basePath, such as common.DRS_OBJS_PATH, is taken from the
+// repository root so the store is the same from any subdirectory.
 func objectPath(basePath string, oid string) (string, error) {
 	oid = strings.TrimPrefix(oid, "sha256:")
 	if len(oid) != 64 {
 		return "", fmt.Errorf("error: %s is not a valid sha256 hash", oid)
 	}
-	return filepath.Join(basePath, oid[:2], oid[2:4], oid), nil
+	return shardPath(projectdir.Path(basePath), oid), nil
+}
+
+// QuarantineDir is where corrupt objects from the store at basePath are kept
+// for inspection.
+func QuarantineDir(basePath string) string {
+	return filepath.Join(filepath.Dir(projectdir.Path(basePath)), "quarantine")
 }
 
+// WriteObject stages drsObj for oid. The JSON is written with sorted map keys
+// so identical objects produce identical files, and it is written atomically
+// so a crash never leaves a truncated object behind. The oid is then added to
+// the store's index, both under the store lock.
 func WriteObject(basePath string, drsObj *drsapi.DrsObject, oid string) error {
-	drsObjBytes, err := sonic.ConfigFastest.Marshal(drsObj)
+	unlock, err := lockStore(projectdir.Path(basePath))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return writeObject(basePath, drsObj, oid)
+}
+
+// writeObject is WriteObject for a caller holding the store lock.
+func writeObject(basePath string, drsObj *drsapi.DrsObject, oid string) error {
+	drsObjBytes, err := sonic.ConfigStd.Marshal(drsObj)
 	if err != nil {
 		return fmt.Errorf("error marshalling DRS object for oid %s: %v", oid, err)
 	}
@@ -28,16 +61,17 @@ func WriteObject(basePath string, drsObj *drsapi.DrsObject, oid string) error {
 	if err != nil {
 		return err
 	}
-	if err := os.MkdirAll(filepath.Dir(drsObjPath), 0o755); err != nil {
-		return fmt.Errorf("error creating directory for %s: %v", drsObjPath, err)
+	if err := common.WriteFileAtomic(drsObjPath, drsObjBytes, 0o644); err != nil {
+		return fmt.Errorf("error writing DRS object for oid %s: %v", oid, err)
 	}
-
-	if err := os.WriteFile(drsObjPath, drsObjBytes, 0o644); err != nil {
-		return fmt.Errorf("error writing %s: %v", drsObjPath, err)
+	if err := indexObject(projectdir.Path(basePath), filepath.Base(drsObjPath)); err != nil {
+		return fmt.Errorf("error indexing DRS object for oid %s: %v", oid, err)
 	}
 	return nil
 }
 
+// ReadObject returns the object staged for oid. An object still in the
+// legacy flat layout is moved into its shard first.
 func ReadObject(basePath string, oid string) (*drsapi.DrsObject, error) {
 	path, err := objectPath(basePath, oid)
 	if err != nil {
@@ -45,14 +79,129 @@ func ReadObject(basePath string, oid string) (*drsapi.DrsObject, error) {
 	}
 
 	drsObjBytes, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		root := projectdir.Path(basePath)
+		name := filepath.Base(path)
+		if _, serr := os.Stat(filepath.Join(root, name)); serr == nil {
+			if merr := migrateFlatObject(root, name); merr != nil {
+				return nil, fmt.Errorf("error migrating DRS object for oid %s: %v", oid, merr)
+			}
+			drsObjBytes, err = os.ReadFile(path)
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error reading DRS object for oid %s: %v", oid, err)
 	}
 
-	var drsObject drsapi.DrsObject
-	if err := sonic.ConfigFastest.Unmarshal(drsObjBytes, &drsObject); err != nil {
-		return nil, fmt.Errorf("error unmarshaling DRS object for oid %s: %v", oid, err)
+	drsObject, err := parseObject(drsObjBytes, oid)
+	if err != nil {
+		dst, qerr := quarantine(basePath, path)
+		if qerr != nil {
+			return nil, fmt.Errorf("%w for oid %s: %v (quarantine failed: %v)", ErrCorruptObject, oid, err, qerr)
+		}
+		return nil, fmt.Errorf("%w for oid %s: %v; moved to %s", ErrCorruptObject, oid, err, dst)
+	}
+	return drsObject, nil
+}
+
+// ScanReport summarizes ScanObjects.
+type ScanReport struct {
+	Valid int
+	// Quarantined maps each corrupt object's oid to its quarantine path.
+	Quarantined map[string]string
+	// TempFilesRemoved counts temp files left by interrupted writes.
+	TempFilesRemoved int
+}
+
+// ScanObjects validates every object staged under basePath, moving corrupt
+// ones to QuarantineDir and deleting temp files left by interrupted writes,
+// so a push never sends or trips over a truncated object. Legacy flat objects
+// are moved into their shards first, and objects the index lacks are added
+// to it. A missing store is empty, not an error.
+func ScanObjects(basePath string) (ScanReport, error) {
+	basePath = projectdir.Path(basePath)
+	report := ScanReport{Quarantined: map[string]string{}}
+	if _, err := os.Stat(basePath); errors.Is(err, fs.ErrNotExist) {
+		return report, nil
+	}
+	if err := migrateStore(basePath); err != nil {
+		return report, fmt.Errorf("migrate staged DRS objects: %w", err)
+	}
+	var valid []string
+	err := filepath.WalkDir(basePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == basePath {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		// A temp index belongs to a writer holding the store lock.
+		if common.IsAtomicTempFile(name) && !strings.HasPrefix(name, common.AtomicTempPrefix+indexName) {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			report.TempFilesRemoved++
+			return nil
+		}
+		if common.IsAtomicTempFile(name) || len(name) != 64 {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if _, perr := parseObject(data, name); perr == nil {
+			report.Valid++
+			valid = append(valid, name)
+			return nil
+		}
+		dst, err := quarantine(basePath, path)
+		if err != nil {
+			return err
+		}
+		report.Quarantined[name] = dst
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("scan staged DRS objects: %w", err)
+	}
+	if err := refreshIndex(basePath, valid); err != nil {
+		return report, fmt.Errorf("index staged DRS objects: %w", err)
 	}
+	return report, nil
+}
 
+// parseObject decodes a staged object and checks that any sha256 checksum it
+// carries matches the oid it is stored under.
+func parseObject(data []byte, oid string) (*drsapi.DrsObject, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("empty file")
+	}
+	var drsObject drsapi.DrsObject
+	if err := sonic.ConfigFastest.Unmarshal(data, &drsObject); err != nil {
+		return nil, fmt.Errorf("error unmarshaling DRS object: %v", err)
+	}
+	want := NormalizeOid(oid)
+	for _, c := range drsObject.Checksums {
+		if strings.EqualFold(c.Type, "sha256") && !strings.EqualFold(NormalizeChecksum(c.Checksum), want) {
+			return nil, fmt.Errorf("sha256 checksum %s does not match oid", c.Checksum)
+		}
+	}
 	return &drsObject, nil
 }
+
+func quarantine(basePath, path string) (string, error) {
+	dir := QuarantineDir(basePath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	dst := filepath.Join(dir, fmt.Sprintf("%s.%s.json", filepath.Base(path), time.Now().UTC().Format("20060102T150405.000000000")))
+	if err := os.Rename(path, dst); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
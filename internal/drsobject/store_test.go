@@ -1,8 +1,14 @@
 package drsobject
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 )
@@ -35,4 +41,235 @@ func TestWriteObjectBasePath(t *testing.T) {
 	}
 }
 
+func TestWriteObjectIsDeterministic(t *testing.T) {
+	basePath := filepath.Join(t.TempDir(), "objects")
+	oid := "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	obj := &drsapi.DrsObject{
+		Id:        "did-2",
+		Name:      ptrString("file.txt"),
+		Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: oid}},
+	}
+	path, _ := objectPath(basePath, oid)
+	var first []byte
+	for i := 0; i < 3; i++ {
+		if err := WriteObject(basePath, obj, oid); err != nil {
+			t.Fatalf("WriteObject error: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read object: %v", err)
+		}
+		if first == nil {
+			first = data
+		} else if !bytes.Equal(first, data) {
+			t.Fatalf("object bytes changed between writes:\n%s\n%s", first, data)
+		}
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("expected only the object file, got %d entries", len(entries))
+	}
+}
+
+func TestReadObjectQuarantinesCorruptEntry(t *testing.T) {
+	basePath := filepath.Join(t.TempDir(), "objects")
+	oid := "cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc"
+	path, _ := objectPath(basePath, oid)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"id":"did-3","checks`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ReadObject(basePath, oid)
+	if !errors.Is(err, ErrCorruptObject) {
+		t.Fatalf("expected ErrCorruptObject, got %v", err)
+	}
+	if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+		t.Fatalf("expected corrupt object to be moved, stat err=%v", statErr)
+	}
+	quarantined, _ := os.ReadDir(QuarantineDir(basePath))
+	if len(quarantined) != 1 {
+		t.Fatalf("expected one quarantined file, got %d", len(quarantined))
+	}
+}
+
+func TestScanObjects(t *testing.T) {
+	basePath := filepath.Join(t.TempDir(), "objects")
+	good := "dddddddddddddddddddddddddddddddddddddddddddddddddddddddddddddddd"
+	empty := "eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+	mismatched := "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"
+
+	if err := WriteObject(basePath, &drsapi.DrsObject{
+		Id:        "did-good",
+		Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: good}},
+	}, good); err != nil {
+		t.Fatal(err)
+	}
+	// A checksum that disagrees with the path the object is stored under.
+	if err := WriteObject(basePath, &drsapi.DrsObject{
+		Id:        "did-bad",
+		Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: good}},
+	}, mismatched); err != nil {
+		t.Fatal(err)
+	}
+	emptyPath, _ := objectPath(basePath, empty)
+	if err := os.MkdirAll(filepath.Dir(emptyPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(emptyPath, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmpFile := filepath.Join(filepath.Dir(emptyPath), ".tmp-"+empty+"-123")
+	if err := os.WriteFile(tmpFile, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := ScanObjects(basePath)
+	if err != nil {
+		t.Fatalf("ScanObjects error: %v", err)
+	}
+	if report.Valid != 1 || report.TempFilesRemoved != 1 || len(report.Quarantined) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if _, ok := report.Quarantined[empty]; !ok {
+		t.Fatalf("expected empty object quarantined: %+v", report.Quarantined)
+	}
+	if _, ok := report.Quarantined[mismatched]; !ok {
+		t.Fatalf("expected mismatched object quarantined: %+v", report.Quarantined)
+	}
+	if _, err := os.Stat(tmpFile); !os.IsNotExist(err) {
+		t.Fatalf("expected temp file removed, stat err=%v", err)
+	}
+	if _, err := ReadObject(basePath, good); err != nil {
+		t.Fatalf("valid object should remain readable: %v", err)
+	}
+}
+
+func TestScanObjectsMissingStore(t *testing.T) {
+	report, err := ScanObjects(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || report.Valid != 0 || len(report.Quarantined) != 0 {
+		t.Fatalf("expected empty report, got %+v err=%v", report, err)
+	}
+}
+
 func ptrString(s string) *string { return &s }
+
+func TestListObjectsMigratesFlatLayoutAndIndexes(t *testing.T) {
+	basePath := filepath.Join(t.TempDir(), "objects")
+	legacy := "1111111111111111111111111111111111111111111111111111111111111111"
+	other := "2222222222222222222222222222222222222222222222222222222222222222"
+	if err := os.MkdirAll(basePath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"id":"did-legacy","checksums":[{"type":"sha256","checksum":"` + legacy + `"}]}`)
+	if err := os.WriteFile(filepath.Join(basePath, legacy), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Objects written before the index exists are found by the rebuild.
+	if err := WriteObject(basePath, &drsapi.DrsObject{Id: "did-other"}, other); err != nil {
+		t.Fatal(err)
+	}
+
+	oids, err := ListObjects(basePath)
+	if err != nil {
+		t.Fatalf("ListObjects error: %v", err)
+	}
+	if len(oids) != 2 || oids[0] != legacy || oids[1] != other {
+		t.Fatalf("unexpected oids: %v", oids)
+	}
+	if _, err := os.Stat(filepath.Join(basePath, legacy)); !os.IsNotExist(err) {
+		t.Fatalf("expected legacy object moved into its shard, stat err=%v", err)
+	}
+	if obj, err := ReadObject(basePath, legacy); err != nil || obj.Id != "did-legacy" {
+		t.Fatalf("ReadObject after migration = %+v, %v", obj, err)
+	}
+	if _, err := os.Stat(filepath.Join(basePath, indexName)); err != nil {
+		t.Fatalf("expected index file: %v", err)
+	}
+}
+
+func TestReadObjectMigratesFlatObject(t *testing.T) {
+	basePath := filepath.Join(t.TempDir(), "objects")
+	oid := "3333333333333333333333333333333333333333333333333333333333333333"
+	if err := os.MkdirAll(basePath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(basePath, oid), []byte(`{"id":"did-flat"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := ReadObject(basePath, oid)
+	if err != nil || obj.Id != "did-flat" {
+		t.Fatalf("ReadObject = %+v, %v", obj, err)
+	}
+	path, _ := objectPath(basePath, oid)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected object in its shard: %v", err)
+	}
+}
+
+func TestConcurrentWritesAreIndexed(t *testing.T) {
+	basePath := filepath.Join(t.TempDir(), "objects")
+	if _, err := ListObjects(basePath); err != nil {
+		t.Fatal(err)
+	}
+	// Create the index so writers append to it.
+	if err := WriteObject(basePath, &drsapi.DrsObject{Id: "seed"}, fmt.Sprintf("%064x", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ListObjects(basePath); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := 1; i <= 64; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- WriteObject(basePath, &drsapi.DrsObject{Id: fmt.Sprintf("did-%d", i)}, fmt.Sprintf("%064x", i))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("WriteObject error: %v", err)
+		}
+	}
+	oids, err := ListObjects(basePath)
+	if err != nil {
+		t.Fatalf("ListObjects error: %v", err)
+	}
+	if len(oids) != 65 {
+		t.Fatalf("expected 65 indexed objects, got %d", len(oids))
+	}
+}
+
+func TestLockStoreTakesOverStaleLock(t *testing.T) {
+	root := t.TempDir()
+	lock := filepath.Join(root, lockName)
+	if err := os.WriteFile(lock, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	origTimeout := lockTimeout
+	lockTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { lockTimeout = origTimeout })
+
+	if _, err := lockStore(root); err == nil {
+		t.Fatal("expected a fresh lock to be respected")
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(lock, old, old); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := lockStore(root)
+	if err != nil {
+		t.Fatalf("expected stale lock taken over: %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(lock); !os.IsNotExist(err) {
+		t.Fatalf("expected lock removed on unlock, stat err=%v", err)
+	}
+}
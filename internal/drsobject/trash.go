@@ -0,0 +1,149 @@
+package drsobject
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/projectdir"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// TrashDir is where objects unstaged from the store at basePath are kept
+// until they are restored or staged again.
+func TrashDir(basePath string) string {
+	return filepath.Join(filepath.Dir(projectdir.Path(basePath)), "trash")
+}
+
+func trashPath(basePath, oid string) (string, error) {
+	oid = NormalizeOid(oid)
+	if !isOid(oid) {
+		return "", fmt.Errorf("error: %s is not a valid sha256 hash", oid)
+	}
+	return filepath.Join(TrashDir(basePath), oid+".json"), nil
+}
+
+// TrashObject moves the object staged for oid to TrashDir. When nothing is
+// staged, fallback is written there instead, so every unstaged oid has an
+// entry that RestoreObject can stage again. It runs under the store lock, so
+// an object staged for oid at the same time is either trashed or kept whole.
+func TrashObject(basePath, oid string, fallback *drsapi.DrsObject) error {
+	dst, err := trashPath(basePath, oid)
+	if err != nil {
+		return err
+	}
+	src, err := objectPath(basePath, oid)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockStore(projectdir.Path(basePath))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	// ReadObject moves a legacy flat object into its shard first.
+	if _, err := ReadObject(basePath, oid); err == nil {
+		if err := os.Rename(src, dst); err != nil {
+			return fmt.Errorf("error unstaging DRS object for oid %s: %v", oid, err)
+		}
+		return nil
+	} else if errors.Is(err, ErrCorruptObject) {
+		return err
+	}
+	data, err := sonic.ConfigStd.Marshal(fallback)
+	if err != nil {
+		return fmt.Errorf("error marshalling DRS object for oid %s: %v", oid, err)
+	}
+	if err := common.WriteFileAtomic(dst, data, 0o644); err != nil {
+		return fmt.Errorf("error unstaging DRS object for oid %s: %v", oid, err)
+	}
+	return nil
+}
+
+// RestoreObject stages the trashed object for oid again. An object staged
+// since it was trashed, say by adding the file again, is kept and the trash
+// entry dropped. Like TrashObject it runs under the store lock.
+func RestoreObject(basePath, oid string) error {
+	src, err := trashPath(basePath, oid)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockStore(projectdir.Path(basePath))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("error reading unstaged DRS object for oid %s: %v", oid, err)
+	}
+	if _, err := ReadObject(basePath, oid); err != nil {
+		obj, perr := parseObject(data, oid)
+		if perr != nil {
+			return fmt.Errorf("%w for oid %s: %v", ErrCorruptObject, oid, perr)
+		}
+		if err := writeObject(basePath, obj, oid); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(src); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// TrashedObjects returns the sorted oids in TrashDir that have not been
+// staged again. A missing trash is empty, not an error.
+func TrashedObjects(basePath string) ([]string, error) {
+	entries, err := os.ReadDir(TrashDir(basePath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var oids []string
+	for _, e := range entries {
+		oid, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || !isOid(oid) || Staged(basePath, oid) {
+			continue
+		}
+		oids = append(oids, oid)
+	}
+	sort.Strings(oids)
+	return oids, nil
+}
+
+// Trashed reports whether oid was unstaged and has not been staged again.
+func Trashed(basePath, oid string) bool {
+	path, err := trashPath(basePath, oid)
+	if err != nil {
+		return false
+	}
+	if _, err := os.Stat(path); err != nil {
+		return false
+	}
+	return !Staged(basePath, oid)
+}
+
+// Staged reports whether an object is staged for oid.
+func Staged(basePath, oid string) bool {
+	path, err := objectPath(basePath, NormalizeOid(oid))
+	if err != nil {
+		return false
+	}
+	if _, err := os.Stat(path); err == nil {
+		return true
+	}
+	_, err = os.Stat(filepath.Join(projectdir.Path(basePath), filepath.Base(path)))
+	return err == nil
+}
@@ -0,0 +1,93 @@
+package drsobject
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestTrashAndRestoreObject(t *testing.T) {
+	basePath := filepath.Join(t.TempDir(), "drs", "lfs", "objects")
+	staged := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	missing := "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	obj := &drsapi.DrsObject{
+		Id:        "did-1",
+		Name:      ptrString("a.bin"),
+		Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: staged}},
+	}
+	if err := WriteObject(basePath, obj, staged); err != nil {
+		t.Fatal(err)
+	}
+
+	fallback := &drsapi.DrsObject{Name: ptrString("b.bin"), Size: 3, Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: missing}}}
+	for _, oid := range []string{staged, "sha256:" + missing} {
+		if err := TrashObject(basePath, oid, fallback); err != nil {
+			t.Fatalf("TrashObject(%s): %v", oid, err)
+		}
+	}
+	if Staged(basePath, staged) || !Trashed(basePath, staged) || !Trashed(basePath, missing) {
+		t.Fatal("expected both oids trashed and nothing staged")
+	}
+	if oids, err := ListObjects(basePath); err != nil || len(oids) != 0 {
+		t.Fatalf("ListObjects = %v, %v; want none", oids, err)
+	}
+	if oids, err := TrashedObjects(basePath); err != nil || len(oids) != 2 {
+		t.Fatalf("TrashedObjects = %v, %v", oids, err)
+	}
+
+	if err := RestoreObject(basePath, staged); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ReadObject(basePath, staged); err != nil || got.Id != "did-1" {
+		t.Fatalf("restored object = %+v, %v", got, err)
+	}
+
+	// Staging the file again lifts the trash without a restore.
+	if err := WriteObject(basePath, fallback, missing); err != nil {
+		t.Fatal(err)
+	}
+	if Trashed(basePath, missing) {
+		t.Fatal("restaged oid still reported as trashed")
+	}
+	if oids, err := TrashedObjects(basePath); err != nil || len(oids) != 0 {
+		t.Fatalf("TrashedObjects = %v, %v; want none", oids, err)
+	}
+}
+
+func TestTrashAndRestoreWaitForStoreLock(t *testing.T) {
+	basePath := filepath.Join(t.TempDir(), "drs", "lfs", "objects")
+	oid := "cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc"
+	obj := &drsapi.DrsObject{Id: "did-1", Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: oid}}}
+	if err := WriteObject(basePath, obj, oid); err != nil {
+		t.Fatal(err)
+	}
+	origTimeout := lockTimeout
+	lockTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { lockTimeout = origTimeout })
+
+	// Another process, say a clean filter staging the file again, holds the
+	// store lock.
+	lock := filepath.Join(basePath, lockName)
+	if err := os.WriteFile(lock, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := TrashObject(basePath, oid, obj); err == nil || !Staged(basePath, oid) {
+		t.Fatalf("TrashObject under a held lock = %v; want a lock error and the object left staged", err)
+	}
+	if err := os.Remove(lock); err != nil {
+		t.Fatal(err)
+	}
+	if err := TrashObject(basePath, oid, obj); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(lock, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreObject(basePath, oid); err == nil || Staged(basePath, oid) || !Trashed(basePath, oid) {
+		t.Fatalf("RestoreObject under a held lock = %v; want a lock error and the object left trashed", err)
+	}
+}
@@ -0,0 +1,54 @@
+package drsobject
+
+import (
+	"strconv"
+	"strings"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// PredecessorAliasPrefix marks the alias that links a versioned record to the
+// checksum of the version it replaced at the same path. The link names the
+// checksum rather than a DRS ID because the server mints IDs at registration,
+// and a predecessor pushed in the same batch has none yet.
+const PredecessorAliasPrefix = "git-drs:predecessor:sha256:"
+
+// SetVersion stamps obj with version and, when predecessorOID is non-empty, a
+// predecessor alias. Any earlier predecessor alias is replaced.
+func SetVersion(obj *drsapi.DrsObject, version int, predecessorOID string) {
+	if obj == nil || version <= 0 {
+		return
+	}
+	v := strconv.Itoa(version)
+	obj.Version = &v
+
+	var aliases []string
+	if obj.Aliases != nil {
+		for _, alias := range *obj.Aliases {
+			if !strings.HasPrefix(alias, PredecessorAliasPrefix) {
+				aliases = append(aliases, alias)
+			}
+		}
+	}
+	if oid := NormalizeOid(predecessorOID); oid != "" {
+		aliases = append(aliases, PredecessorAliasPrefix+oid)
+	}
+	if aliases == nil {
+		obj.Aliases = nil
+		return
+	}
+	obj.Aliases = &aliases
+}
+
+// Predecessor returns the checksum obj's predecessor alias points at, or "".
+func Predecessor(obj drsapi.DrsObject) string {
+	if obj.Aliases == nil {
+		return ""
+	}
+	for _, alias := range *obj.Aliases {
+		if oid, ok := strings.CutPrefix(alias, PredecessorAliasPrefix); ok {
+			return oid
+		}
+	}
+	return ""
+}
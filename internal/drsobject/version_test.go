@@ -0,0 +1,32 @@
+package drsobject
+
+import (
+	"testing"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestSetVersionReplacesPredecessorAlias(t *testing.T) {
+	aliases := []string{"sample.bam", PredecessorAliasPrefix + "old"}
+	obj := &drsapi.DrsObject{Aliases: &aliases}
+
+	SetVersion(obj, 3, "sha256:bbbb")
+	if obj.Version == nil || *obj.Version != "3" {
+		t.Fatalf("unexpected version: %v", obj.Version)
+	}
+	if got := *obj.Aliases; len(got) != 2 || got[0] != "sample.bam" || got[1] != PredecessorAliasPrefix+"bbbb" {
+		t.Fatalf("unexpected aliases: %v", got)
+	}
+	if got := Predecessor(*obj); got != "bbbb" {
+		t.Fatalf("Predecessor = %q, want bbbb", got)
+	}
+
+	first := &drsapi.DrsObject{}
+	SetVersion(first, 1, "")
+	if first.Version == nil || *first.Version != "1" || first.Aliases != nil {
+		t.Fatalf("unexpected first version: %+v", first)
+	}
+	if got := Predecessor(*first); got != "" {
+		t.Fatalf("Predecessor = %q, want empty", got)
+	}
+}
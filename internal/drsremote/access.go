@@ -0,0 +1,24 @@
+package drsremote
+
+import (
+	"context"
+	"time"
+
+	"github.com/calypr/git-drs/internal/accesspolicy"
+	"github.com/calypr/git-drs/internal/config"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// prober is shared by every download in the process so each endpoint is
+// probed once.
+var prober = accesspolicy.NewProber()
+
+// orderedAccessMethods ranks obj's access methods by the remote's policy.
+func orderedAccessMethods(ctx context.Context, drsCtx *config.GitContext, obj drsapi.DrsObject) []drsapi.AccessMethod {
+	if obj.AccessMethods == nil {
+		return nil
+	}
+	return drsCtx.AccessPolicy.Order(*obj.AccessMethods, func(m drsapi.AccessMethod) (time.Duration, bool) {
+		return prober.Latency(ctx, m)
+	})
+}
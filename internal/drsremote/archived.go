@@ -0,0 +1,35 @@
+package drsremote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrArchived is returned when storage refuses to serve an object because it
+// sits in an archive storage class, such as S3 Glacier Flexible Retrieval or
+// Deep Archive, and has not been restored.
+var ErrArchived = errors.New("object is archived; restore required")
+
+// archivedErrorCode is the S3 error code for a GET of an archived object.
+const archivedErrorCode = "<Code>InvalidObjectState</Code>"
+
+// checkArchived reports ErrArchived when resp is S3's refusal to read an
+// archived object. Any other response is left readable as it was.
+func checkArchived(resp *http.Response, oid string) error {
+	if resp == nil || resp.StatusCode != http.StatusForbidden || resp.Body == nil {
+		return nil
+	}
+	head, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err == nil && bytes.Contains(head, []byte(archivedErrorCode)) {
+		resp.Body.Close()
+		return fmt.Errorf("%w: %s must be restored before it can be downloaded; run `git drs restore-archive %s` and retry once the restore completes", ErrArchived, oid, oid)
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+	return nil
+}
@@ -0,0 +1,114 @@
+package drsremote
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// AuthorizationRequiredError reports that an object on an anonymous remote
+// cannot be read without credentials, along with the authorization schemes
+// and issuers the server advertises for it.
+type AuthorizationRequiredError struct {
+	ObjectID            string
+	SupportedTypes      []string
+	BearerAuthIssuers   []string
+	PassportAuthIssuers []string
+	Err                 error
+}
+
+func (e *AuthorizationRequiredError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "DRS object %s requires authorization", e.ObjectID)
+	if len(e.SupportedTypes) > 0 {
+		fmt.Fprintf(&b, " (supported: %s)", strings.Join(e.SupportedTypes, ", "))
+	}
+	if len(e.BearerAuthIssuers) > 0 {
+		fmt.Fprintf(&b, "; bearer token issuers: %s", strings.Join(e.BearerAuthIssuers, ", "))
+	}
+	if len(e.PassportAuthIssuers) > 0 {
+		fmt.Fprintf(&b, "; passport visa issuers: %s", strings.Join(e.PassportAuthIssuers, ", "))
+	}
+	b.WriteString("; configure credentials for this remote to download it")
+	if e.Err != nil {
+		fmt.Fprintf(&b, ": %v", e.Err)
+	}
+	return b.String()
+}
+
+func (e *AuthorizationRequiredError) Unwrap() error { return e.Err }
+
+// Is makes the error match drserrors.ErrUnauthorized.
+func (e *AuthorizationRequiredError) Is(target error) bool {
+	return target == drserrors.ErrUnauthorized
+}
+
+// authorizationError builds an AuthorizationRequiredError for obj from the
+// access method metadata, refined by the server's OPTIONS response when
+// available. It returns nil when the object advertises anonymous access, so
+// the original error is not misattributed.
+func authorizationError(ctx context.Context, drsCtx *config.GitContext, obj drsapi.DrsObject, cause error) error {
+	authErr := &AuthorizationRequiredError{ObjectID: obj.Id, Err: cause}
+	if obj.AccessMethods != nil {
+		for _, am := range *obj.AccessMethods {
+			if am.Authorizations == nil {
+				continue
+			}
+			if am.Authorizations.SupportedTypes != nil {
+				for _, t := range *am.Authorizations.SupportedTypes {
+					authErr.SupportedTypes = appendUnique(authErr.SupportedTypes, string(t))
+				}
+			}
+			if am.Authorizations.BearerAuthIssuers != nil {
+				authErr.BearerAuthIssuers = appendUnique(authErr.BearerAuthIssuers, *am.Authorizations.BearerAuthIssuers...)
+			}
+			if am.Authorizations.PassportAuthIssuers != nil {
+				authErr.PassportAuthIssuers = appendUnique(authErr.PassportAuthIssuers, *am.Authorizations.PassportAuthIssuers...)
+			}
+		}
+	}
+	if drsCtx != nil && drsCtx.Client != nil {
+		if resp, err := drsCtx.Client.DRSAPI().OptionsObjectWithResponse(ctx, drsapi.ObjectId(obj.Id)); err == nil && resp.JSON200 != nil {
+			a := resp.JSON200
+			if a.SupportedTypes != nil {
+				for _, t := range *a.SupportedTypes {
+					authErr.SupportedTypes = appendUnique(authErr.SupportedTypes, string(t))
+				}
+			}
+			if a.BearerAuthIssuers != nil {
+				authErr.BearerAuthIssuers = appendUnique(authErr.BearerAuthIssuers, *a.BearerAuthIssuers...)
+			}
+			if a.PassportAuthIssuers != nil {
+				authErr.PassportAuthIssuers = appendUnique(authErr.PassportAuthIssuers, *a.PassportAuthIssuers...)
+			}
+		}
+	}
+	if len(authErr.SupportedTypes) == 1 && authErr.SupportedTypes[0] == string(drsapi.AuthorizationsSupportedTypesNone) {
+		return nil
+	}
+	return authErr
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		found := false
+		for _, existing := range list {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,48 @@
+package drsremote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/calypr/git-drs/internal/compression"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/metrics"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	sydownload "github.com/calypr/syfon/client/transfer/download"
+)
+
+// downloadCompressed fetches the stored bytes of a compressed record next to
+// dstPath, checks them against the record's content-encoding block, and
+// decompresses them into dstPath.
+func downloadCompressed(ctx context.Context, drsCtx *config.GitContext, oid, dstPath string, obj *drsapi.DrsObject, accessURL *drsapi.AccessURL, opts sydownload.DownloadOptions, meta compression.Metadata) error {
+	src := &resolvedSource{
+		requestor:    drsCtx.Client.Requestor(),
+		accessURL:    accessURL.Url,
+		expectedSize: meta.StoredSize,
+	}
+	storedPath := dstPath + ".stored"
+	defer os.Remove(storedPath)
+
+	start := time.Now()
+	err := sydownload.DownloadToPathWithOptions(ctx, src, oid, storedPath, opts)
+	metrics.RecordTransfer(metrics.Download, meta.StoredSize, time.Since(start), err)
+	if err != nil {
+		return err
+	}
+	if meta.StoredSHA256 != "" {
+		if err := verifyDownloadedSHA256(storedPath, meta.StoredSHA256, src.streamedDigest()); err != nil {
+			ForgetAccessURL(drsCtx, obj.Id)
+			return err
+		}
+	}
+	plainSHA256, err := compression.DecompressFile(meta, storedPath, dstPath)
+	if err != nil {
+		return fmt.Errorf("decompress object %s: %w", obj.Id, err)
+	}
+	if expected, ok := expectedSHA256(oid); ok {
+		return verifyDownloadedSHA256(dstPath, expected, plainSHA256)
+	}
+	return nil
+}
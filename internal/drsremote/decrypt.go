@@ -0,0 +1,53 @@
+package drsremote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/encryption"
+	"github.com/calypr/git-drs/internal/metrics"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	sydownload "github.com/calypr/syfon/client/transfer/download"
+)
+
+// downloadEncrypted fetches the ciphertext of an encrypted record next to
+// dstPath, checks it against the record's encryption block, and decrypts it
+// into dstPath. The key is loaded first so a missing key fails before any
+// bytes are transferred.
+func downloadEncrypted(ctx context.Context, drsCtx *config.GitContext, oid, dstPath string, obj *drsapi.DrsObject, accessURL *drsapi.AccessURL, opts sydownload.DownloadOptions, meta encryption.Metadata) error {
+	key, err := drsCtx.Encryption.LoadKey(ctx)
+	if err != nil {
+		return fmt.Errorf("object %s is encrypted: %w", obj.Id, err)
+	}
+	src := &resolvedSource{
+		requestor:    drsCtx.Client.Requestor(),
+		accessURL:    accessURL.Url,
+		expectedSize: meta.CiphertextSize,
+	}
+	sealedPath := dstPath + ".sealed"
+	defer os.Remove(sealedPath)
+
+	start := time.Now()
+	err = sydownload.DownloadToPathWithOptions(ctx, src, oid, sealedPath, opts)
+	metrics.RecordTransfer(metrics.Download, meta.CiphertextSize, time.Since(start), err)
+	if err != nil {
+		return err
+	}
+	if meta.CiphertextSHA256 != "" {
+		if err := verifyDownloadedSHA256(sealedPath, meta.CiphertextSHA256, src.streamedDigest()); err != nil {
+			ForgetAccessURL(drsCtx, obj.Id)
+			return err
+		}
+	}
+	plainSHA256, err := encryption.DecryptFile(key, oid, meta, sealedPath, dstPath)
+	if err != nil {
+		return fmt.Errorf("decrypt object %s: %w", obj.Id, err)
+	}
+	if expected, ok := expectedSHA256(oid); ok {
+		return verifyDownloadedSHA256(dstPath, expected, plainSHA256)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package drsremote
+
+import (
+	"context"
+	"sync"
+
+	"github.com/calypr/git-drs/internal/drsobject"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+type hashCacheKey struct{}
+
+// hashCache remembers the records found for each checksum, per client, so
+// the steps of one push that look up the same oids query the server once.
+type hashCache struct {
+	mu      sync.Mutex
+	records map[hashCacheEntry][]drsapi.DrsObject
+}
+
+type hashCacheEntry struct {
+	client   any
+	checksum string
+}
+
+// WithHashCache returns a context under which ObjectsByHashes reuses earlier
+// lookups made with the same context. Use it only for a span of work that
+// sees its own writes through ForgetHashes; it is not a general cache.
+func WithHashCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, hashCacheKey{}, &hashCache{records: map[hashCacheEntry][]drsapi.DrsObject{}})
+}
+
+// ForgetHashes drops cached lookups for checksums after their records
+// change, such as after registering them.
+func ForgetHashes(ctx context.Context, checksums []string) {
+	c := hashCacheFrom(ctx)
+	if c == nil {
+		return
+	}
+	forget := make(map[string]bool, len(checksums))
+	for _, checksum := range checksums {
+		forget[drsobject.NormalizeChecksum(checksum)] = true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.records {
+		if forget[key.checksum] {
+			delete(c.records, key)
+		}
+	}
+}
+
+func hashCacheFrom(ctx context.Context) *hashCache {
+	c, _ := ctx.Value(hashCacheKey{}).(*hashCache)
+	return c
+}
+
+// get returns the cached records for checksums and the checksums not yet
+// looked up. A nil cache has nothing cached.
+func (c *hashCache) get(client any, checksums []string) (map[string][]drsapi.DrsObject, []string) {
+	found := make(map[string][]drsapi.DrsObject, len(checksums))
+	if c == nil {
+		return found, checksums
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var missing []string
+	for _, checksum := range checksums {
+		if records, ok := c.records[hashCacheEntry{client, checksum}]; ok {
+			found[checksum] = records
+		} else {
+			missing = append(missing, checksum)
+		}
+	}
+	return found, missing
+}
+
+func (c *hashCache) put(client any, records map[string][]drsapi.DrsObject) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for checksum, objs := range records {
+		c.records[hashCacheEntry{client, checksum}] = objs
+	}
+}
@@ -0,0 +1,152 @@
+package drsremote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/calypr/git-drs/internal/common"
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	syservices "github.com/calypr/syfon/client/services"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	DefaultListPageSize    = 500
+	DefaultListConcurrency = 4
+)
+
+// RecordLister is the subset of the Syfon index API needed to page records.
+type RecordLister interface {
+	List(ctx context.Context, opts syservices.ListRecordsOptions) (internalapi.ListRecordsResponse, error)
+}
+
+// ListOptions controls ListRecordsParallel.
+type ListOptions struct {
+	Organization string
+	ProjectID    string
+	PageSize     int
+	Concurrency  int
+	// CursorPath, when set, persists the next page to fetch after each page is
+	// handled so an interrupted listing resumes where it stopped.
+	CursorPath string
+}
+
+// ListCursor is the on-disk resume state for a listing.
+type ListCursor struct {
+	Organization string `json:"organization"`
+	ProjectID    string `json:"project_id"`
+	PageSize     int    `json:"page_size"`
+	NextPage     int    `json:"next_page"`
+}
+
+// ListRecordsParallel fetches pages concurrently and hands them to fn in page
+// order. Listing stops at the first short page. The cursor file is removed
+// once the listing completes.
+func ListRecordsParallel(ctx context.Context, lister RecordLister, opts ListOptions, fn func(page int, records []internalapi.InternalRecord) error) error {
+	if lister == nil {
+		return fmt.Errorf("record lister unavailable")
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultListPageSize
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultListConcurrency
+	}
+
+	page := 1
+	if opts.CursorPath != "" {
+		cursor, err := LoadListCursor(opts.CursorPath)
+		if err != nil {
+			return err
+		}
+		if cursor != nil && cursor.Organization == opts.Organization && cursor.ProjectID == opts.ProjectID && cursor.PageSize == opts.PageSize && cursor.NextPage > 1 {
+			page = cursor.NextPage
+		}
+	}
+
+	for {
+		window := make([][]internalapi.InternalRecord, opts.Concurrency)
+		g, gctx := errgroup.WithContext(ctx)
+		for i := range window {
+			i := i
+			p := page + i
+			g.Go(func() error {
+				resp, err := lister.List(gctx, syservices.ListRecordsOptions{
+					Organization: opts.Organization,
+					ProjectID:    opts.ProjectID,
+					Limit:        opts.PageSize,
+					Page:         p,
+				})
+				if err != nil {
+					return fmt.Errorf("list page %d: %w", p, err)
+				}
+				if resp.Records != nil {
+					window[i] = *resp.Records
+				}
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return err
+		}
+
+		for i, records := range window {
+			p := page + i
+			if len(records) > 0 {
+				if err := fn(p, records); err != nil {
+					return err
+				}
+			}
+			if opts.CursorPath != "" {
+				if err := SaveListCursor(opts.CursorPath, ListCursor{
+					Organization: opts.Organization,
+					ProjectID:    opts.ProjectID,
+					PageSize:     opts.PageSize,
+					NextPage:     p + 1,
+				}); err != nil {
+					return err
+				}
+			}
+			if len(records) < opts.PageSize {
+				if opts.CursorPath != "" {
+					if err := os.Remove(opts.CursorPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+						return fmt.Errorf("remove list cursor: %w", err)
+					}
+				}
+				return nil
+			}
+		}
+		page += opts.Concurrency
+	}
+}
+
+// LoadListCursor reads a cursor file. A missing file returns nil, nil.
+func LoadListCursor(path string) (*ListCursor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read list cursor: %w", err)
+	}
+	var cursor ListCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("parse list cursor %s: %w", path, err)
+	}
+	return &cursor, nil
+}
+
+// SaveListCursor writes a cursor file atomically.
+func SaveListCursor(path string, cursor ListCursor) error {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	if err := common.WriteFileAtomic(path, data, 0o644); err != nil {
+		return fmt.Errorf("write list cursor: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package drsremote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	syservices "github.com/calypr/syfon/client/services"
+)
+
+type pagedLister struct {
+	mu      sync.Mutex
+	total   int
+	failAt  int
+	fetched []int
+}
+
+func (l *pagedLister) List(ctx context.Context, opts syservices.ListRecordsOptions) (internalapi.ListRecordsResponse, error) {
+	l.mu.Lock()
+	l.fetched = append(l.fetched, opts.Page)
+	l.mu.Unlock()
+	if l.failAt > 0 && opts.Page == l.failAt {
+		return internalapi.ListRecordsResponse{}, errors.New("boom")
+	}
+	start := (opts.Page - 1) * opts.Limit
+	records := []internalapi.InternalRecord{}
+	for i := start; i < start+opts.Limit && i < l.total; i++ {
+		records = append(records, internalapi.InternalRecord{Did: fmt.Sprintf("did-%03d", i)})
+	}
+	return internalapi.ListRecordsResponse{Records: &records}, nil
+}
+
+func TestListRecordsParallel_OrderedMerge(t *testing.T) {
+	lister := &pagedLister{total: 11}
+	var dids []string
+	err := ListRecordsParallel(context.Background(), lister, ListOptions{PageSize: 2, Concurrency: 3}, func(page int, records []internalapi.InternalRecord) error {
+		for _, r := range records {
+			dids = append(dids, r.Did)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ListRecordsParallel error: %v", err)
+	}
+	if len(dids) != 11 {
+		t.Fatalf("expected 11 records, got %d", len(dids))
+	}
+	for i, did := range dids {
+		if did != fmt.Sprintf("did-%03d", i) {
+			t.Fatalf("records out of order at %d: %s", i, did)
+		}
+	}
+}
+
+func TestListRecordsParallel_ResumesFromCursor(t *testing.T) {
+	cursorPath := filepath.Join(t.TempDir(), "cursor.json")
+	opts := ListOptions{ProjectID: "p", PageSize: 2, Concurrency: 2, CursorPath: cursorPath}
+
+	failing := &pagedLister{total: 10, failAt: 4}
+	seen := 0
+	err := ListRecordsParallel(context.Background(), failing, opts, func(page int, records []internalapi.InternalRecord) error {
+		seen += len(records)
+		return nil
+	})
+	if err == nil {
+		t.Fatalf("expected listing to fail")
+	}
+	cursor, err := LoadListCursor(cursorPath)
+	if err != nil || cursor == nil || cursor.NextPage != 3 {
+		t.Fatalf("expected cursor at page 3, got %+v (%v)", cursor, err)
+	}
+
+	resumed := &pagedLister{total: 10}
+	err = ListRecordsParallel(context.Background(), resumed, opts, func(page int, records []internalapi.InternalRecord) error {
+		if page < 3 {
+			t.Fatalf("resumed listing re-read page %d", page)
+		}
+		seen += len(records)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("resumed listing error: %v", err)
+	}
+	if seen != 10 {
+		t.Fatalf("expected 10 records across runs, got %d", seen)
+	}
+	if _, err := os.Stat(cursorPath); !os.IsNotExist(err) {
+		t.Fatalf("expected cursor removed after completion, stat err=%v", err)
+	}
+}
@@ -2,20 +2,36 @@ package drsremote
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/compression"
 	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
 	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/encryption"
+	"github.com/calypr/git-drs/internal/hooks"
+	"github.com/calypr/git-drs/internal/metrics"
+	"github.com/calypr/git-drs/internal/usage"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	syhash "github.com/calypr/syfon/client/hash"
 	"github.com/calypr/syfon/client/request"
 	"github.com/calypr/syfon/client/transfer"
 	sydownload "github.com/calypr/syfon/client/transfer/download"
+	"golang.org/x/sync/errgroup"
 )
 
 func ObjectsByHash(ctx context.Context, drsCtx *config.GitContext, checksum string) ([]drsapi.DrsObject, error) {
@@ -28,11 +44,15 @@ func ObjectsByHash(ctx context.Context, drsCtx *config.GitContext, checksum stri
 	}
 	page, err := drsCtx.Client.DRS().BatchGetObjectsByHash(ctx, []string{checksum})
 	if err != nil {
-		return nil, err
+		return nil, drserrors.Classify(err)
 	}
-	return page.DrsObjects, nil
+	return liveRecords(ctx, page.DrsObjects), nil
 }
 
+// ObjectsByHashes looks up the records for every checksum, keyed by both the
+// checksum as given and its normalized form. Lookups run in parallel chunks,
+// and under WithHashCache a checksum already looked up is not queried again.
+// Soft-deleted records are left out unless ctx comes from WithSoftDeleted.
 func ObjectsByHashes(ctx context.Context, drsCtx *config.GitContext, checksums []string) (map[string][]drsapi.DrsObject, error) {
 	if drsCtx == nil || drsCtx.Client == nil {
 		return nil, fmt.Errorf("DRS client unavailable")
@@ -54,36 +74,72 @@ func ObjectsByHashes(ctx context.Context, drsCtx *config.GitContext, checksums [
 		return map[string][]drsapi.DrsObject{}, nil
 	}
 
-	page, err := drsCtx.Client.DRS().BatchGetObjectsByHash(ctx, queryChecksums)
-	if err != nil {
-		return nil, err
+	cache := hashCacheFrom(ctx)
+	found, missing := cache.get(drsCtx.Client, queryChecksums)
+	if len(missing) > 0 {
+		fetched, err := fetchObjectsByHashes(ctx, drsCtx, missing)
+		if err != nil {
+			return nil, err
+		}
+		cache.put(drsCtx.Client, fetched)
+		maps.Copy(found, fetched)
 	}
 
 	results := make(map[string][]drsapi.DrsObject, len(normalizedToOriginal))
 	for normalized, original := range normalizedToOriginal {
-		results[original] = nil
-		results[normalized] = nil
+		live := liveRecords(ctx, found[normalized])
+		results[original] = live
+		results[normalized] = live
 	}
-	for _, obj := range page.DrsObjects {
-		for _, checksum := range obj.Checksums {
-			if checksum.Type == "" || checksum.Checksum == "" {
-				continue
-			}
-			normalized := drsobject.NormalizeChecksum(fmt.Sprintf("%s:%s", checksum.Type, checksum.Checksum))
-			if normalized == "" {
-				continue
+	return results, nil
+}
+
+// The server answers one checksum per request, so lookups for many oids
+// run hashLookupChunk checksums at a time, hashLookupConcurrency chunks at
+// once.
+const (
+	hashLookupChunk       = 16
+	hashLookupConcurrency = 8
+)
+
+// fetchObjectsByHashes queries normalized checksums and returns the records
+// found for each; checksums without records map to nil.
+func fetchObjectsByHashes(ctx context.Context, drsCtx *config.GitContext, checksums []string) (map[string][]drsapi.DrsObject, error) {
+	var mu sync.Mutex
+	results := make(map[string][]drsapi.DrsObject, len(checksums))
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(hashLookupConcurrency)
+	for start := 0; start < len(checksums); start += hashLookupChunk {
+		chunk := checksums[start:min(start+hashLookupChunk, len(checksums))]
+		eg.Go(func() error {
+			page, err := drsCtx.Client.DRS().BatchGetObjectsByHash(egCtx, chunk)
+			if err != nil {
+				return drserrors.Classify(err)
 			}
-			original, ok := normalizedToOriginal[normalized]
-			if !ok {
-				continue
+			inChunk := make(map[string][]drsapi.DrsObject, len(chunk))
+			for _, checksum := range chunk {
+				inChunk[checksum] = nil
 			}
-			results[original] = append(results[original], obj)
-			if original != normalized {
-				results[normalized] = append(results[normalized], obj)
+			for _, obj := range page.DrsObjects {
+				for _, checksum := range obj.Checksums {
+					if checksum.Type == "" || checksum.Checksum == "" {
+						continue
+					}
+					normalized := drsobject.NormalizeChecksum(fmt.Sprintf("%s:%s", checksum.Type, checksum.Checksum))
+					if _, ok := inChunk[normalized]; ok {
+						inChunk[normalized] = append(inChunk[normalized], obj)
+					}
+				}
 			}
-		}
+			mu.Lock()
+			maps.Copy(results, inChunk)
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
 	}
-
 	return results, nil
 }
 
@@ -94,7 +150,7 @@ func ObjectsByHashForScope(ctx context.Context, drsCtx *config.GitContext, check
 	}
 	result := make([]drsapi.DrsObject, 0, len(objects))
 	for _, obj := range objects {
-		if MatchesScope(&obj, drsCtx.Organization, drsCtx.ProjectId) {
+		if matchesContextScope(&obj, drsCtx) {
 			result = append(result, obj)
 		}
 	}
@@ -110,7 +166,7 @@ func ObjectsByHashesForScope(ctx context.Context, drsCtx *config.GitContext, che
 	for checksum, objects := range objectsByChecksum {
 		filtered := make([]drsapi.DrsObject, 0, len(objects))
 		for _, obj := range objects {
-			if MatchesScope(&obj, drsCtx.Organization, drsCtx.ProjectId) {
+			if matchesContextScope(&obj, drsCtx) {
 				filtered = append(filtered, obj)
 			}
 		}
@@ -119,44 +175,125 @@ func ObjectsByHashesForScope(ctx context.Context, drsCtx *config.GitContext, che
 	return results, nil
 }
 
+// AccessURLForHashScope resolves a signed URL for the scoped record of
+// checksum, trying the record's access methods in policy order until one
+// signs.
 func AccessURLForHashScope(ctx context.Context, drsCtx *config.GitContext, checksum string) (*drsapi.AccessURL, *drsapi.DrsObject, error) {
-	records, err := ObjectsByHashForScope(ctx, drsCtx, checksum)
+	match, err := scopedRecordForHash(ctx, drsCtx, checksum)
+	if err != nil {
+		return nil, nil, err
+	}
+	accessURL, err := AccessURLForObject(ctx, drsCtx, *match)
 	if err != nil {
 		return nil, nil, err
 	}
+	return accessURL, match, nil
+}
+
+// AccessURLForObject signs a URL for an already resolved record, trying its
+// access methods in preference order.
+func AccessURLForObject(ctx context.Context, drsCtx *config.GitContext, obj drsapi.DrsObject) (*drsapi.AccessURL, error) {
+	var lastErr error
+	for i, method := range orderedAccessMethods(ctx, drsCtx, obj) {
+		accessURL, err := resolveAccessURL(ctx, drsCtx, obj, method, i == 0)
+		if err == nil {
+			return accessURL, nil
+		}
+		if stopFallback(ctx, err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func scopedRecordForHash(ctx context.Context, drsCtx *config.GitContext, checksum string) (*drsapi.DrsObject, error) {
+	records, err := ObjectsByHashForScope(ctx, drsCtx, checksum)
+	if err != nil {
+		return nil, err
+	}
 	if len(records) == 0 {
-		return nil, nil, fmt.Errorf("no matching DRS record found for oid %s", drsobject.NormalizeChecksum(checksum))
+		return nil, drserrors.WithKind(drserrors.ErrNotFound, fmt.Errorf("no matching DRS record found for oid %s", drsobject.NormalizeChecksum(checksum)))
 	}
 	match := records[0]
 	if match.AccessMethods == nil || len(*match.AccessMethods) == 0 {
-		return nil, nil, fmt.Errorf("no access methods available for DRS object %s", match.Id)
+		return nil, fmt.Errorf("no access methods available for DRS object %s", match.Id)
 	}
-	accessType := (*match.AccessMethods)[0].Type
-	if accessType == "" {
-		return nil, nil, fmt.Errorf("no access type found in access method for DRS object %s", match.Id)
+	return &match, nil
+}
+
+// resolveAccessURL signs one access method. The context's URL cache only
+// answers for the first-ranked method; a fallback always asks the server.
+func resolveAccessURL(ctx context.Context, drsCtx *config.GitContext, obj drsapi.DrsObject, method drsapi.AccessMethod, first bool) (*drsapi.AccessURL, error) {
+	accessID := accessIDForMethod(method)
+	if accessID == "" {
+		return nil, fmt.Errorf("no access type found in access method for DRS object %s", obj.Id)
+	}
+	if first {
+		if cached, ok := drsCtx.SignedURLs.Get(accessURLKey(drsCtx, obj.Id, accessID)); ok {
+			return &cached, nil
+		}
 	}
-	accessURL, err := drsCtx.Client.DRS().GetAccessURL(ctx, match.Id, string(accessType))
+	accessURL, err := drsCtx.Client.DRS().GetAccessURL(ctx, obj.Id, accessID)
 	if err != nil {
-		return nil, nil, err
+		if drsCtx.Anonymous {
+			if authErr := authorizationError(ctx, drsCtx, obj, err); authErr != nil {
+				return nil, authErr
+			}
+		}
+		return nil, drserrors.Classify(err)
+	}
+	drsCtx.SignedURLs.Put(accessURLKey(drsCtx, obj.Id, accessID), accessURL)
+	return &accessURL, nil
+}
+
+// accessIDForMethod returns the id passed to /access for method. Syfon signs
+// by access type, so the type wins; access_id is only used for typeless
+// methods.
+func accessIDForMethod(method drsapi.AccessMethod) string {
+	if method.Type != "" {
+		return string(method.Type)
 	}
-	return &accessURL, &match, nil
+	if method.AccessId != nil {
+		return strings.TrimSpace(*method.AccessId)
+	}
+	return ""
+}
+
+// stopFallback reports errors no other access method can fix.
+func stopFallback(ctx context.Context, err error) bool {
+	var authErr *AuthorizationRequiredError
+	// Every replica holds the same ciphertext, so a missing or wrong key
+	// fails the same way everywhere.
+	return ctx.Err() != nil || errors.As(err, &authErr) ||
+		errors.Is(err, encryption.ErrKeyUnavailable) || errors.Is(err, encryption.ErrWrongKey)
 }
 
 func BulkAccessURLsForObjects(ctx context.Context, drsCtx *config.GitContext, objects []drsapi.DrsObject) (map[string]drsapi.AccessURL, error) {
 	if drsCtx == nil || drsCtx.Client == nil {
 		return nil, fmt.Errorf("DRS client unavailable")
 	}
-	req, ok := bulkAccessRequest(objects)
+	ordered := make([]drsapi.DrsObject, len(objects))
+	requested := make(map[string]string, len(objects))
+	for i, obj := range objects {
+		if obj.AccessMethods != nil {
+			methods := orderedAccessMethods(ctx, drsCtx, obj)
+			obj.AccessMethods = &methods
+		}
+		ordered[i] = obj
+		requested[strings.TrimSpace(obj.Id)] = accessIDForBulkRequest(obj)
+	}
+	req, ok := bulkAccessRequest(ordered)
 	if !ok {
 		return map[string]drsapi.AccessURL{}, nil
 	}
 
 	resp, err := drsCtx.Client.DRSAPI().GetBulkAccessURLWithResponse(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, drserrors.Classify(err)
 	}
 	if resp.JSON200 == nil {
-		return nil, fmt.Errorf("unexpected response: %d", resp.StatusCode())
+		return nil, drserrors.Classify(fmt.Errorf("unexpected response: %d", resp.StatusCode()))
 	}
 
 	out := map[string]drsapi.AccessURL{}
@@ -171,24 +308,120 @@ func BulkAccessURLsForObjects(ctx context.Context, drsCtx *config.GitContext, ob
 		if strings.TrimSpace(objectID) == "" || strings.TrimSpace(resolved.Url) == "" {
 			continue
 		}
-		out[strings.TrimSpace(objectID)] = drsapi.AccessURL{Headers: resolved.Headers, Url: resolved.Url}
+		access := drsapi.AccessURL{Headers: resolved.Headers, Url: resolved.Url}
+		out[strings.TrimSpace(objectID)] = access
+		accessID := requested[strings.TrimSpace(objectID)]
+		if resolved.DrsAccessId != nil && strings.TrimSpace(*resolved.DrsAccessId) != "" {
+			accessID = strings.TrimSpace(*resolved.DrsAccessId)
+		}
+		drsCtx.SignedURLs.Put(accessURLKey(drsCtx, objectID, accessID), access)
 	}
 	return out, nil
 }
 
 // DownloadToCachePath downloads the DRS object identified by oid to cachePath
-// using the project-scoped URL resolution preferred by git-drs.
+// using the project-scoped URL resolution preferred by git-drs. An object in
+// the shared cache is linked instead of downloaded.
 func DownloadToCachePath(ctx context.Context, drsCtx *config.GitContext, logger *slog.Logger, oid, cachePath string) error {
 	_ = logger
 	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
 		return fmt.Errorf("mkdir for cache path: %w", err)
 	}
+	if fromSharedCache(drsCtx, oid, cachePath) {
+		return postDownload(ctx, drsCtx, sharedRecord(oid, cachePath), oid, cachePath)
+	}
 
-	accessURL, match, err := AccessURLForHashScope(ctx, drsCtx, oid)
+	match, err := scopedRecordForHash(ctx, drsCtx, oid)
 	if err != nil {
 		return err
 	}
-	return downloadResolved(ctx, drsCtx, oid, cachePath, match, accessURL)
+	err = writeIntoPlace(cachePath, func(tmpPath string) error {
+		return downloadWithFallback(ctx, drsCtx, oid, tmpPath, *match, nil)
+	})
+	if err != nil {
+		return drserrors.Classify(err)
+	}
+	toSharedCache(drsCtx, oid, cachePath)
+	return postDownload(ctx, drsCtx, match, oid, cachePath)
+}
+
+// ResolveObject looks up an object by DRS ID, or by sha256 oid within the
+// remote's scope when id is one. DRS URIs and compact identifiers are
+// accepted, and an ID the server does not know is tried as the alias of a
+// staged object. A soft-deleted record is reported as not found.
+func ResolveObject(ctx context.Context, drsCtx *config.GitContext, id string) (*drsapi.DrsObject, error) {
+	id = ParseIdentifier(id)
+	if _, ok := expectedSHA256(id); ok {
+		return scopedRecordForHash(ctx, drsCtx, id)
+	}
+	if drsCtx == nil || drsCtx.Client == nil {
+		return nil, fmt.Errorf("DRS client unavailable")
+	}
+	obj, err := drsCtx.Client.DRS().GetObject(ctx, id)
+	if err != nil {
+		err = drserrors.Classify(err)
+		if errors.Is(err, drserrors.ErrNotFound) {
+			if rec, aliasErr := resolveStagedAlias(ctx, drsCtx, id); rec != nil || aliasErr != nil {
+				return rec, aliasErr
+			}
+		}
+		return nil, err
+	}
+	if len(liveRecords(ctx, []drsapi.DrsObject{obj})) == 0 {
+		return nil, drserrors.WithKind(drserrors.ErrNotFound, fmt.Errorf("DRS object %s is deleted; restore it with 'git drs restore %s'", id, id))
+	}
+	return &obj, nil
+}
+
+// ParseIdentifier strips DRS URI syntax from raw: "drs://host/id" yields id,
+// and the compact form "drs://prefix:accession" yields "prefix/accession",
+// the ID a Gen3 commons registers under a dg.XXXX prefix. Anything else is
+// returned trimmed.
+func ParseIdentifier(raw string) string {
+	raw = strings.TrimSpace(raw)
+	rest, ok := strings.CutPrefix(raw, "drs://")
+	if !ok {
+		return raw
+	}
+	if _, id, hosted := strings.Cut(rest, "/"); hosted {
+		return id
+	}
+	if prefix, accession, compact := strings.Cut(rest, ":"); compact {
+		return prefix + "/" + accession
+	}
+	return rest
+}
+
+// resolveStagedAlias resolves an alias carried by a staged object to the
+// scope's record for that object. It returns nil, nil when no staged object
+// carries the alias; aliases attached in other clones are only found when
+// the server resolves them itself.
+func resolveStagedAlias(ctx context.Context, drsCtx *config.GitContext, alias string) (*drsapi.DrsObject, error) {
+	oids, err := drsobject.FindByAlias(common.DRS_OBJS_PATH, alias)
+	if err != nil || len(oids) == 0 {
+		return nil, err
+	}
+	if len(oids) > 1 {
+		return nil, fmt.Errorf("alias %q names %d staged objects: %s", alias, len(oids), strings.Join(oids, ", "))
+	}
+	return scopedRecordForHash(ctx, drsCtx, oids[0])
+}
+
+// DownloadObjectToPath downloads obj to dstPath, trying its access methods in
+// policy order. Content is verified against the record's sha256 when it has
+// one.
+func DownloadObjectToPath(ctx context.Context, drsCtx *config.GitContext, obj drsapi.DrsObject, dstPath string) error {
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0o755); err != nil {
+		return fmt.Errorf("mkdir for download path: %w", err)
+	}
+	oid := obj.Id
+	if sum, ok := expectedSHA256(syhash.ConvertDrsChecksumsToHashInfo(obj.Checksums).SHA256); ok {
+		oid = sum
+	}
+	if err := downloadWithFallback(ctx, drsCtx, oid, dstPath, obj, nil); err != nil {
+		return drserrors.Classify(err)
+	}
+	return postDownload(ctx, drsCtx, &obj, oid, dstPath)
 }
 
 func DownloadResolvedToCachePath(ctx context.Context, drsCtx *config.GitContext, oid, cachePath string, obj *drsapi.DrsObject, accessURL *drsapi.AccessURL) error {
@@ -198,7 +431,117 @@ func DownloadResolvedToCachePath(ctx context.Context, drsCtx *config.GitContext,
 	if obj == nil || accessURL == nil || accessURL.Url == "" {
 		return DownloadToCachePath(ctx, drsCtx, nil, oid, cachePath)
 	}
-	return downloadResolved(ctx, drsCtx, oid, cachePath, obj, accessURL)
+	if fromSharedCache(drsCtx, oid, cachePath) {
+		return postDownload(ctx, drsCtx, obj, oid, cachePath)
+	}
+	err := writeIntoPlace(cachePath, func(tmpPath string) error {
+		return downloadWithFallback(ctx, drsCtx, oid, tmpPath, *obj, accessURL)
+	})
+	if err != nil {
+		return drserrors.Classify(err)
+	}
+	toSharedCache(drsCtx, oid, cachePath)
+	return postDownload(ctx, drsCtx, obj, oid, cachePath)
+}
+
+// runHook and recordUsage are swapped in tests.
+var (
+	runHook     = hooks.Run
+	recordUsage = usage.Record
+)
+
+// postDownload records the download of obj to path for usage reporting, then
+// runs the post-download hook.
+func postDownload(ctx context.Context, drsCtx *config.GitContext, obj *drsapi.DrsObject, oid, path string) error {
+	if drsCtx != nil && drsCtx.Usage.Enabled() {
+		d := usage.Download{
+			Remote:       drsCtx.RemoteName,
+			Organization: drsCtx.Organization,
+			Project:      drsCtx.ProjectId,
+			DRSID:        obj.Id,
+			OID:          oid,
+			Bytes:        obj.Size,
+		}
+		// The download already succeeded; a reporting failure must not
+		// undo it.
+		if err := recordUsage(ctx, drsCtx.Usage, d); err != nil && drsCtx.Logger != nil {
+			drsCtx.Logger.Warn("failed to record download usage", "oid", oid, "error", err)
+		}
+	}
+	o := hooks.ObjectFor(obj, oid, "")
+	o.File = path
+	p := hooks.Payload{Event: hooks.PostDownload, Objects: []hooks.Object{o}}
+	if drsCtx != nil {
+		p.Remote, p.Organization, p.Project = drsCtx.RemoteName, drsCtx.Organization, drsCtx.ProjectId
+	}
+	_, err := runHook(ctx, p)
+	return err
+}
+
+// writeIntoPlace runs write against a temporary file next to path and renames
+// it into place on success. Several git-drs processes may share the LFS cache,
+// for example filter processes in linked worktrees alongside a pull, so each
+// download gets its own file and readers never see a partial object under its
+// final name. The last of two concurrent downloads of an oid wins the rename;
+// both wrote the same verified content.
+func writeIntoPlace(path string, write func(tmpPath string) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file for %s: %w", filepath.Base(path), err)
+	}
+	tmpPath := tmp.Name()
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := write(tmpPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("move download into place: %w", err)
+	}
+	return nil
+}
+
+// downloadWithFallback downloads from resolved when given, then from each of
+// obj's access methods in policy order, until one succeeds. The last error is
+// returned when every replica fails.
+func downloadWithFallback(ctx context.Context, drsCtx *config.GitContext, oid, cachePath string, obj drsapi.DrsObject, resolved *drsapi.AccessURL) error {
+	var lastErr error
+	if resolved != nil {
+		if lastErr = downloadResolved(ctx, drsCtx, oid, cachePath, &obj, resolved); lastErr == nil || ctx.Err() != nil {
+			return lastErr
+		}
+		ForgetAccessURL(drsCtx, obj.Id)
+	}
+	for i, method := range orderedAccessMethods(ctx, drsCtx, obj) {
+		accessURL, err := resolveAccessURL(ctx, drsCtx, obj, method, i == 0 && resolved == nil)
+		if err == nil {
+			err = downloadResolved(ctx, drsCtx, oid, cachePath, &obj, accessURL)
+			if err == nil {
+				return nil
+			}
+			ForgetAccessURL(drsCtx, obj.Id)
+		}
+		if stopFallback(ctx, err) {
+			return err
+		}
+		if drsCtx.Logger != nil {
+			drsCtx.Logger.Debug("access method failed; trying next", "oid", oid, "type", method.Type, "error", err)
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no access methods available for DRS object %s", obj.Id)
+	}
+	return lastErr
 }
 
 func DownloadResolvedToPath(ctx context.Context, drsCtx *config.GitContext, oid, dstPath string, obj *drsapi.DrsObject, accessURL *drsapi.AccessURL, opts sydownload.DownloadOptions) error {
@@ -208,12 +551,30 @@ func DownloadResolvedToPath(ctx context.Context, drsCtx *config.GitContext, oid,
 	if obj == nil || accessURL == nil || strings.TrimSpace(accessURL.Url) == "" {
 		return fmt.Errorf("resolved DRS object and access URL are required")
 	}
+	if meta, ok := encryption.FromObject(obj); ok {
+		return downloadEncrypted(ctx, drsCtx, oid, dstPath, obj, accessURL, opts, meta)
+	}
+	if meta, ok := compression.FromObject(obj); ok {
+		return downloadCompressed(ctx, drsCtx, oid, dstPath, obj, accessURL, opts, meta)
+	}
 	src := &resolvedSource{
 		requestor:    drsCtx.Client.Requestor(),
 		accessURL:    strings.TrimSpace(accessURL.Url),
 		expectedSize: obj.Size,
 	}
-	return sydownload.DownloadToPathWithOptions(ctx, src, oid, dstPath, opts)
+	start := time.Now()
+	err := sydownload.DownloadToPathWithOptions(ctx, src, oid, dstPath, opts)
+	metrics.RecordTransfer(metrics.Download, obj.Size, time.Since(start), err)
+	if err != nil {
+		return err
+	}
+	if expected, ok := expectedSHA256(oid); ok {
+		if err := verifyDownloadedSHA256(dstPath, expected, src.streamedDigest()); err != nil {
+			ForgetAccessURL(drsCtx, obj.Id)
+			return err
+		}
+	}
+	return nil
 }
 
 func downloadResolved(ctx context.Context, drsCtx *config.GitContext, oid, cachePath string, obj *drsapi.DrsObject, accessURL *drsapi.AccessURL) error {
@@ -228,6 +589,22 @@ type resolvedSource struct {
 	requestor    request.Requester
 	accessURL    string
 	expectedSize int64
+
+	// mu guards the streaming digest. It is only meaningful when the whole
+	// object arrived through a single GetReader stream; any ranged read means
+	// the file must be re-hashed from disk.
+	mu     sync.Mutex
+	hasher hash.Hash
+	ranged bool
+}
+
+func (s *resolvedSource) streamedDigest() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ranged || s.hasher == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.hasher.Sum(nil))
 }
 
 func (s *resolvedSource) Name() string {
@@ -247,22 +624,36 @@ func (s *resolvedSource) Stat(ctx context.Context, guid string) (*transfer.Objec
 }
 
 func (s *resolvedSource) GetReader(ctx context.Context, guid string) (io.ReadCloser, error) {
-	return s.download(ctx, nil, nil)
+	body, err := s.download(ctx, guid, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	s.mu.Lock()
+	s.hasher = h
+	s.mu.Unlock()
+	return &hashingReadCloser{ReadCloser: body, w: h}, nil
 }
 
 func (s *resolvedSource) GetRangeReader(ctx context.Context, guid string, offset, length int64) (io.ReadCloser, error) {
+	s.mu.Lock()
+	s.ranged = true
+	s.mu.Unlock()
 	if length <= 0 {
-		return s.download(ctx, nil, nil)
+		return s.download(ctx, guid, nil, nil)
 	}
 	end := offset + length - 1
-	return s.download(ctx, &offset, &end)
+	return s.download(ctx, guid, &offset, &end)
 }
 
-func (s *resolvedSource) download(ctx context.Context, start, end *int64) (io.ReadCloser, error) {
-	resp, err := transfer.GenericDownload(ctx, s.requestor, s.accessURL, start, end)
+func (s *resolvedSource) download(ctx context.Context, guid string, start, end *int64) (io.ReadCloser, error) {
+	resp, err := downloadSignedURL(ctx, s.requestor, s.accessURL, start, end)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkArchived(resp, guid); err != nil {
+		return nil, err
+	}
 	if start != nil && resp.StatusCode == http.StatusOK {
 		resp.Body.Close()
 		return nil, transfer.ErrRangeIgnored
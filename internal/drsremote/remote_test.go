@@ -1,16 +1,31 @@
 package drsremote
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 
+	"github.com/calypr/git-drs/internal/accesspolicy"
+	"github.com/calypr/git-drs/internal/compression"
 	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/encryption"
+	"github.com/calypr/git-drs/internal/signedurl"
+	"github.com/calypr/git-drs/internal/usage"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	syclient "github.com/calypr/syfon/client"
 	sydownload "github.com/calypr/syfon/client/transfer/download"
@@ -259,6 +274,68 @@ func TestObjectsByHashesForScope_FiltersByScope(t *testing.T) {
 	}
 }
 
+func TestObjectsByHashes_CachesLookupsUnderHashCache(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int64
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		sum := path.Base(r.URL.Path)
+		body := fmt.Sprintf(`{"resolved_drs_object":[{"id":"obj-%s","checksums":[{"type":"sha256","checksum":%q}]}]}`, sum, sum)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Request:    r,
+		}, nil
+	})}
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("syclient.New: %v", err)
+	}
+	drsCtx := &config.GitContext{Client: raw.(*syclient.Client)}
+
+	sums := make([]string, 40)
+	for i := range sums {
+		sums[i] = fmt.Sprintf("%064x", i)
+	}
+	ctx := WithHashCache(context.Background())
+	got, err := ObjectsByHashes(ctx, drsCtx, sums)
+	if err != nil {
+		t.Fatalf("ObjectsByHashes: %v", err)
+	}
+	for _, sum := range sums {
+		if len(got[sum]) != 1 || got[sum][0].Id != "obj-"+sum {
+			t.Fatalf("records for %s = %+v", sum, got[sum])
+		}
+	}
+	if n := requests.Load(); n != int64(len(sums)) {
+		t.Fatalf("requests = %d, want %d", n, len(sums))
+	}
+
+	if _, err := ObjectsByHashes(ctx, drsCtx, sums); err != nil {
+		t.Fatalf("ObjectsByHashes (cached): %v", err)
+	}
+	if n := requests.Load(); n != int64(len(sums)) {
+		t.Fatalf("cached lookup sent %d more requests", n-int64(len(sums)))
+	}
+
+	ForgetHashes(ctx, sums[:1])
+	if _, err := ObjectsByHashes(ctx, drsCtx, sums); err != nil {
+		t.Fatalf("ObjectsByHashes (after forget): %v", err)
+	}
+	if n := requests.Load(); n != int64(len(sums))+1 {
+		t.Fatalf("requests after forget = %d, want %d", n, len(sums)+1)
+	}
+
+	if _, err := ObjectsByHashes(context.Background(), drsCtx, sums[:2]); err != nil {
+		t.Fatalf("ObjectsByHashes (uncached): %v", err)
+	}
+	if n := requests.Load(); n != int64(len(sums))+3 {
+		t.Fatalf("uncached lookup: requests = %d, want %d", n, len(sums)+3)
+	}
+}
+
 func TestDownloadResolvedToPath_RangeIgnoredRestartsDownload(t *testing.T) {
 	t.Parallel()
 
@@ -317,3 +394,547 @@ func TestDownloadResolvedToPath_RangeIgnoredRestartsDownload(t *testing.T) {
 		t.Fatal("expected downloader to attempt a range request before restarting")
 	}
 }
+
+func newPayloadGitContext(t *testing.T, payload []byte) *config.GitContext {
+	t.Helper()
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(string(payload))),
+			Header:     make(http.Header),
+			Request:    r,
+		}, nil
+	})}
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("syclient.New: %v", err)
+	}
+	return &config.GitContext{Client: raw.(*syclient.Client)}
+}
+
+func TestDownloadResolvedToPath_VerifiesSHA256(t *testing.T) {
+	payload := []byte("verified payload")
+	sum := sha256.Sum256(payload)
+	oid := hex.EncodeToString(sum[:])
+
+	dstPath := filepath.Join(t.TempDir(), "object.bin")
+	obj := &drsapi.DrsObject{Id: "obj-ok", Size: int64(len(payload))}
+	accessURL := &drsapi.AccessURL{Url: "https://signed.example/download/object.bin"}
+	opts := sydownload.DownloadOptions{MultipartThreshold: 1 << 20, Concurrency: 1, ChunkSize: 1 << 20}
+
+	if err := DownloadResolvedToPath(context.Background(), newPayloadGitContext(t, payload), oid, dstPath, obj, accessURL, opts); err != nil {
+		t.Fatalf("DownloadResolvedToPath returned error: %v", err)
+	}
+	if _, err := os.Stat(dstPath); err != nil {
+		t.Fatalf("expected verified file to remain: %v", err)
+	}
+}
+
+func TestDownloadResolvedToPath_RejectsCorruptedObject(t *testing.T) {
+	sum := sha256.Sum256([]byte("expected payload"))
+	oid := hex.EncodeToString(sum[:])
+
+	dstPath := filepath.Join(t.TempDir(), "object.bin")
+	obj := &drsapi.DrsObject{Id: "obj-bad", Size: int64(len("corrupt payload!"))}
+	accessURL := &drsapi.AccessURL{Url: "https://signed.example/download/object.bin"}
+	opts := sydownload.DownloadOptions{MultipartThreshold: 1 << 20, Concurrency: 1, ChunkSize: 1 << 20}
+
+	err := DownloadResolvedToPath(context.Background(), newPayloadGitContext(t, []byte("corrupt payload!")), oid, dstPath, obj, accessURL, opts)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch, got %v", err)
+	}
+	if _, statErr := os.Stat(dstPath); !os.IsNotExist(statErr) {
+		t.Fatalf("expected corrupted file to be removed, stat err=%v", statErr)
+	}
+}
+
+func TestDownloadResolvedToPath_ReportsArchivedObject(t *testing.T) {
+	sum := sha256.Sum256([]byte("archived payload"))
+	oid := hex.EncodeToString(sum[:])
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusForbidden,
+			Body:       io.NopCloser(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>InvalidObjectState</Code><Message>The operation is not valid for the object's storage class</Message><StorageClass>GLACIER</StorageClass></Error>`)),
+			Header:     make(http.Header),
+			Request:    r,
+		}, nil
+	})}
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("syclient.New: %v", err)
+	}
+	obj := &drsapi.DrsObject{Id: "obj-glacier", Size: 16}
+	accessURL := &drsapi.AccessURL{Url: "https://signed.example/download/object.bin"}
+	opts := sydownload.DownloadOptions{MultipartThreshold: 1 << 20, Concurrency: 1, ChunkSize: 1 << 20}
+	err = DownloadResolvedToPath(context.Background(), &config.GitContext{Client: raw.(*syclient.Client)}, oid, filepath.Join(t.TempDir(), "object.bin"), obj, accessURL, opts)
+	if !errors.Is(err, ErrArchived) || !strings.Contains(err.Error(), "git drs restore-archive "+oid) {
+		t.Fatalf("expected ErrArchived naming the restore command, got %v", err)
+	}
+}
+
+func TestDownloadResolvedToCachePath_ConcurrentDownloadsShareNoTempFile(t *testing.T) {
+	payload := []byte("shared cache payload")
+	sum := sha256.Sum256(payload)
+	oid := hex.EncodeToString(sum[:])
+	drsCtx := newPayloadGitContext(t, payload)
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, oid)
+	obj := &drsapi.DrsObject{Id: "obj-shared", Size: int64(len(payload))}
+	accessURL := &drsapi.AccessURL{Url: "https://signed.example/download/object.bin"}
+
+	errs := make(chan error, 4)
+	for range 4 {
+		go func() {
+			errs <- DownloadResolvedToCachePath(context.Background(), drsCtx, oid, cachePath, obj, accessURL)
+		}()
+	}
+	for range 4 {
+		if err := <-errs; err != nil {
+			t.Fatalf("DownloadResolvedToCachePath returned error: %v", err)
+		}
+	}
+	if got, err := os.ReadFile(cachePath); err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("unexpected cached payload %q: %v", got, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected only the cached object, found %d entries", len(entries))
+	}
+}
+
+func TestDownloadResolvedToCachePath_RecordsUsage(t *testing.T) {
+	payload := []byte("usage payload")
+	sum := sha256.Sum256(payload)
+	oid := hex.EncodeToString(sum[:])
+	drsCtx := newPayloadGitContext(t, payload)
+	drsCtx.RemoteName, drsCtx.Organization, drsCtx.ProjectId = "origin", "org", "proj"
+	drsCtx.Usage = usage.Settings{Ledger: true}
+
+	var got []usage.Download
+	orig := recordUsage
+	recordUsage = func(_ context.Context, s usage.Settings, d usage.Download) error {
+		got = append(got, d)
+		return errors.New("webhook down")
+	}
+	t.Cleanup(func() { recordUsage = orig })
+
+	obj := &drsapi.DrsObject{Id: "obj-usage", Size: int64(len(payload))}
+	accessURL := &drsapi.AccessURL{Url: "https://signed.example/download/object.bin"}
+	if err := DownloadResolvedToCachePath(context.Background(), drsCtx, oid, filepath.Join(t.TempDir(), oid), obj, accessURL); err != nil {
+		t.Fatalf("a usage reporting failure must not fail the download: %v", err)
+	}
+	want := usage.Download{Remote: "origin", Organization: "org", Project: "proj", DRSID: "obj-usage", OID: oid, Bytes: int64(len(payload))}
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("recorded %+v, want %+v", got, want)
+	}
+}
+
+func TestDownloadResolvedToCachePath_SharesObjectsThroughSharedCache(t *testing.T) {
+	payload := []byte("shared across clones")
+	sum := sha256.Sum256(payload)
+	oid := hex.EncodeToString(sum[:])
+	drsCtx := newPayloadGitContext(t, payload)
+	drsCtx.SharedCache = filepath.Join(t.TempDir(), "shared")
+	obj := &drsapi.DrsObject{Id: "obj-shared-cache", Size: int64(len(payload))}
+	accessURL := &drsapi.AccessURL{Url: "https://signed.example/download/object.bin"}
+
+	first := filepath.Join(t.TempDir(), oid)
+	if err := DownloadResolvedToCachePath(context.Background(), drsCtx, oid, first, obj, accessURL); err != nil {
+		t.Fatalf("DownloadResolvedToCachePath: %v", err)
+	}
+
+	// A second clone links the object without reaching the server, and
+	// still records the download.
+	var got []usage.Download
+	orig := recordUsage
+	recordUsage = func(_ context.Context, s usage.Settings, d usage.Download) error {
+		got = append(got, d)
+		return nil
+	}
+	t.Cleanup(func() { recordUsage = orig })
+	offline := &config.GitContext{SharedCache: drsCtx.SharedCache, RemoteName: "origin", Usage: usage.Settings{Ledger: true}}
+	second := filepath.Join(t.TempDir(), oid)
+	if err := DownloadToCachePath(context.Background(), offline, nil, oid, second); err != nil {
+		t.Fatalf("DownloadToCachePath from shared cache: %v", err)
+	}
+	a, _ := os.Stat(first)
+	b, _ := os.Stat(second)
+	if !os.SameFile(a, b) {
+		t.Fatalf("expected both clones to link the shared object")
+	}
+	if len(got) != 1 || got[0].OID != oid || got[0].Bytes != int64(len(payload)) || got[0].Remote != "origin" {
+		t.Fatalf("expected the shared-cache hit to be recorded, got %+v", got)
+	}
+}
+
+func TestResolveObject_ClassifiesNotFound(t *testing.T) {
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       io.NopCloser(strings.NewReader(`{"msg":"not found"}`)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Request:    r,
+		}, nil
+	})}
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("syclient.New: %v", err)
+	}
+	_, err = ResolveObject(context.Background(), &config.GitContext{Client: raw.(*syclient.Client)}, "dg.1/missing")
+	if !errors.Is(err, drserrors.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSoftDeletedRecordIsNotDownloadable(t *testing.T) {
+	payload := []byte("soft-deleted payload")
+	sum := sha256.Sum256(payload)
+	oid := hex.EncodeToString(sum[:])
+	controlled := []string{"/organization/org1/project/proj1"}
+	methods := []drsapi.AccessMethod{{Type: drsapi.AccessMethodTypeS3}}
+	desc := drsobject.SoftDeleteMarker + `{"deleted_at":"2026-01-01T00:00:00Z","purge_after":"2026-01-31T00:00:00Z"}`
+	obj := drsapi.DrsObject{
+		Id:               "obj-deleted",
+		ControlledAccess: &controlled,
+		Checksums:        []drsapi.Checksum{{Type: "sha256", Checksum: oid}},
+		AccessMethods:    &methods,
+		Description:      &desc,
+	}
+	var signed atomic.Int32
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		var body []byte
+		switch {
+		case r.URL.Path == "/ga4gh/drs/v1/objects/checksum/"+oid:
+			body, _ = json.Marshal(drsapi.N200OkDrsObjects{ResolvedDrsObject: &[]drsapi.DrsObject{obj}})
+		case r.URL.Path == "/ga4gh/drs/v1/objects/obj-deleted":
+			body, _ = json.Marshal(obj)
+		default:
+			signed.Add(1)
+			body = []byte(`{"url":"https://signed.example/deleted"}`)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Request:    r,
+		}, nil
+	})}
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("syclient.New: %v", err)
+	}
+	gc := &config.GitContext{Client: raw.(*syclient.Client), Organization: "org1", ProjectId: "proj1"}
+	ctx := context.Background()
+
+	err = DownloadToCachePath(ctx, gc, nil, oid, filepath.Join(t.TempDir(), oid))
+	if !errors.Is(err, drserrors.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound downloading a soft-deleted record, got %v", err)
+	}
+	if _, err := ResolveObject(ctx, gc, "obj-deleted"); !errors.Is(err, drserrors.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound resolving a soft-deleted record, got %v", err)
+	}
+	if byHash, err := ObjectsByHashesForScope(ctx, gc, []string{oid}); err != nil || len(byHash[oid]) != 0 {
+		t.Fatalf("expected no live records, got %+v, %v", byHash[oid], err)
+	}
+	if match, err := FindMatchingRecord([]drsapi.DrsObject{obj}, "org1", "proj1"); err != nil || match != nil {
+		t.Fatalf("expected no matching record, got %+v, %v", match, err)
+	}
+	if signed.Load() != 0 {
+		t.Fatalf("expected no access URL to be signed, got %d requests", signed.Load())
+	}
+
+	recs, err := ObjectsByHashForScope(WithSoftDeleted(ctx), gc, oid)
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("expected WithSoftDeleted to return the record, got %+v, %v", recs, err)
+	}
+}
+
+func TestDownloadResolvedToPath_DecryptsEncryptedObject(t *testing.T) {
+	payload := []byte("protected payload")
+	sum := sha256.Sum256(payload)
+	oid := hex.EncodeToString(sum[:])
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte(strings.Repeat("ab", 32)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	settings := encryption.Settings{Remote: "origin", KeyFile: keyFile}
+	key, err := settings.LoadKey(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sealed bytes.Buffer
+	meta, err := encryption.Encrypt(&sealed, bytes.NewReader(payload), key, oid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := &drsapi.DrsObject{Id: "obj-enc", Size: int64(len(payload))}
+	encryption.SetOnObject(obj, meta)
+	accessURL := &drsapi.AccessURL{Url: "https://signed.example/download/object.bin"}
+	opts := sydownload.DownloadOptions{MultipartThreshold: 1 << 20, Concurrency: 1, ChunkSize: 1 << 20}
+
+	drsCtx := newPayloadGitContext(t, sealed.Bytes())
+	drsCtx.Encryption = settings
+	dstPath := filepath.Join(dir, "object.bin")
+	if err := DownloadResolvedToPath(context.Background(), drsCtx, oid, dstPath, obj, accessURL, opts); err != nil {
+		t.Fatalf("DownloadResolvedToPath returned error: %v", err)
+	}
+	got, err := os.ReadFile(dstPath)
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("expected decrypted payload, got %q err=%v", got, err)
+	}
+	if _, err := os.Stat(dstPath + ".sealed"); !os.IsNotExist(err) {
+		t.Fatalf("expected ciphertext to be removed, stat err=%v", err)
+	}
+
+	noKey := newPayloadGitContext(t, sealed.Bytes())
+	noKey.Encryption = encryption.Settings{Remote: "origin"}
+	err = DownloadResolvedToPath(context.Background(), noKey, oid, filepath.Join(dir, "other.bin"), obj, accessURL, opts)
+	if !errors.Is(err, encryption.ErrKeyUnavailable) {
+		t.Fatalf("expected ErrKeyUnavailable, got %v", err)
+	}
+}
+
+func TestDownloadResolvedToPath_DecompressesCompressedObject(t *testing.T) {
+	payload := bytes.Repeat([]byte("chr1\t10177\t.\tA\tAC\t100\tPASS\t.\n"), 100)
+	sum := sha256.Sum256(payload)
+	oid := hex.EncodeToString(sum[:])
+
+	var stored bytes.Buffer
+	meta, err := compression.Compress(&stored, bytes.NewReader(payload), compression.Zstd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := &drsapi.DrsObject{Id: "obj-zst", Size: int64(len(payload))}
+	compression.SetOnObject(obj, meta)
+	accessURL := &drsapi.AccessURL{Url: "https://signed.example/download/calls.vcf"}
+	opts := sydownload.DownloadOptions{MultipartThreshold: 1 << 20, Concurrency: 1, ChunkSize: 1 << 20}
+
+	dir := t.TempDir()
+	dstPath := filepath.Join(dir, "calls.vcf")
+	if err := DownloadResolvedToPath(context.Background(), newPayloadGitContext(t, stored.Bytes()), oid, dstPath, obj, accessURL, opts); err != nil {
+		t.Fatalf("DownloadResolvedToPath returned error: %v", err)
+	}
+	got, err := os.ReadFile(dstPath)
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("expected decompressed payload, got %d bytes err=%v", len(got), err)
+	}
+	if _, err := os.Stat(dstPath + ".stored"); !os.IsNotExist(err) {
+		t.Fatalf("expected stored bytes to be removed, stat err=%v", err)
+	}
+
+	if _, err := RangeForObject(newPayloadGitContext(t, stored.Bytes()), *obj); !errors.Is(err, ErrRangeUnsupported) {
+		t.Fatalf("expected ErrRangeUnsupported, got %v", err)
+	}
+}
+
+func TestAccessURLForHashScope_AnonymousSurfacesAuthorizations(t *testing.T) {
+	t.Parallel()
+
+	methods := []drsapi.AccessMethod{{Type: drsapi.AccessMethodTypeS3}}
+	controlled := []string{"/organization/org1/project/proj1"}
+	checksumBody, err := json.Marshal(drsapi.N200OkDrsObjects{ResolvedDrsObject: &[]drsapi.DrsObject{
+		{Id: "obj-private", ControlledAccess: &controlled, Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: "abc"}}, AccessMethods: &methods},
+	}})
+	if err != nil {
+		t.Fatalf("marshal checksum response: %v", err)
+	}
+
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Fatalf("anonymous request sent Authorization header %q", got)
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/ga4gh/drs/v1/objects/checksum/abc":
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(string(checksumBody))),
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Request:    r,
+			}, nil
+		case r.Method == http.MethodGet && r.URL.Path == "/ga4gh/drs/v1/objects/obj-private/access/s3":
+			return &http.Response{
+				StatusCode: http.StatusUnauthorized,
+				Body:       io.NopCloser(strings.NewReader(`{"msg":"unauthorized"}`)),
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Request:    r,
+			}, nil
+		case r.Method == http.MethodOptions && r.URL.Path == "/ga4gh/drs/v1/objects/obj-private":
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"supported_types":["BearerAuth"],"bearer_auth_issuers":["https://issuer.example"]}`)),
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Request:    r,
+			}, nil
+		default:
+			return nil, io.EOF
+		}
+	})}
+
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("syclient.New: %v", err)
+	}
+	ctx := &config.GitContext{Client: raw.(*syclient.Client), Organization: "org1", ProjectId: "proj1", Anonymous: true}
+
+	_, _, err = AccessURLForHashScope(context.Background(), ctx, "sha256:abc")
+	var authErr *AuthorizationRequiredError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("expected AuthorizationRequiredError, got %v", err)
+	}
+	if authErr.ObjectID != "obj-private" || len(authErr.SupportedTypes) != 1 || authErr.SupportedTypes[0] != "BearerAuth" {
+		t.Fatalf("unexpected authorization metadata: %+v", authErr)
+	}
+	if len(authErr.BearerAuthIssuers) != 1 || authErr.BearerAuthIssuers[0] != "https://issuer.example" {
+		t.Fatalf("unexpected bearer issuers: %+v", authErr.BearerAuthIssuers)
+	}
+}
+
+func TestDownloadToCachePath_FallsBackToNextPreferredMethod(t *testing.T) {
+	payload := []byte("replicated payload")
+	sum := sha256.Sum256(payload)
+	oid := hex.EncodeToString(sum[:])
+
+	east, west := "us-east-1", "us-west-2"
+	methods := []drsapi.AccessMethod{
+		{Type: drsapi.AccessMethodTypeS3, Region: &east},
+		{Type: drsapi.AccessMethodTypeHttps, Region: &west},
+	}
+	controlled := []string{"/organization/org1/project/proj1"}
+	checksumBody, err := json.Marshal(drsapi.N200OkDrsObjects{ResolvedDrsObject: &[]drsapi.DrsObject{
+		{Id: "obj-multi", Size: int64(len(payload)), ControlledAccess: &controlled, Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: oid}}, AccessMethods: &methods},
+	}})
+	if err != nil {
+		t.Fatalf("marshal checksum response: %v", err)
+	}
+
+	var signed []string
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		respond := func(status int, body string) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: status,
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Request:    r,
+			}, nil
+		}
+		switch r.URL.Path {
+		case "/ga4gh/drs/v1/objects/checksum/" + oid:
+			return respond(http.StatusOK, string(checksumBody))
+		case "/ga4gh/drs/v1/objects/obj-multi/access/https":
+			signed = append(signed, "https")
+			return respond(http.StatusOK, `{"url":"https://signed.example/west/object.bin"}`)
+		case "/ga4gh/drs/v1/objects/obj-multi/access/s3":
+			signed = append(signed, "s3")
+			return respond(http.StatusOK, `{"url":"https://signed.example/east/object.bin"}`)
+		case "/west/object.bin":
+			return respond(http.StatusForbidden, "denied")
+		case "/east/object.bin":
+			return respond(http.StatusOK, string(payload))
+		default:
+			return nil, io.EOF
+		}
+	})}
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("syclient.New: %v", err)
+	}
+	drsCtx := &config.GitContext{
+		Client:       raw.(*syclient.Client),
+		Organization: "org1",
+		ProjectId:    "proj1",
+		AccessPolicy: accesspolicy.Policy{Prefer: []accesspolicy.Preference{{Key: "region", Value: west}}},
+	}
+
+	dstPath := filepath.Join(t.TempDir(), "object.bin")
+	if err := DownloadToCachePath(context.Background(), drsCtx, nil, oid, dstPath); err != nil {
+		t.Fatalf("DownloadToCachePath returned error: %v", err)
+	}
+	if got, err := os.ReadFile(dstPath); err != nil || string(got) != string(payload) {
+		t.Fatalf("unexpected payload %q: %v", got, err)
+	}
+	if !slices.Equal(signed, []string{"https", "s3"}) {
+		t.Fatalf("expected preferred region first then fallback, got %v", signed)
+	}
+}
+
+func TestObjectRange_ReadsRangeAndResignsExpiredURL(t *testing.T) {
+	payload := []byte("0123456789abcdef")
+	sum := sha256.Sum256(payload)
+	oid := hex.EncodeToString(sum[:])
+	methods := []drsapi.AccessMethod{{Type: drsapi.AccessMethodTypeS3}}
+	controlled := []string{"/organization/org1/project/proj1"}
+	checksumBody, err := json.Marshal(drsapi.N200OkDrsObjects{ResolvedDrsObject: &[]drsapi.DrsObject{
+		{Id: "obj-range", Size: int64(len(payload)), ControlledAccess: &controlled, Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: oid}}, AccessMethods: &methods},
+	}})
+	if err != nil {
+		t.Fatalf("marshal checksum response: %v", err)
+	}
+
+	signs := 0
+	var ranges []string
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		respond := func(status int, body string) (*http.Response, error) {
+			return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{"Content-Type": []string{"application/json"}}, Request: r}, nil
+		}
+		switch r.URL.Path {
+		case "/ga4gh/drs/v1/objects/checksum/" + oid:
+			return respond(http.StatusOK, string(checksumBody))
+		case "/ga4gh/drs/v1/objects/obj-range/access/s3":
+			signs++
+			return respond(http.StatusOK, fmt.Sprintf(`{"url":"https://signed.example/v%d/object.bin"}`, signs))
+		case "/v1/object.bin":
+			return respond(http.StatusForbidden, "expired")
+		case "/v2/object.bin":
+			ranges = append(ranges, r.Header.Get("Range"))
+			return respond(http.StatusPartialContent, string(payload[4:8]))
+		default:
+			return nil, io.EOF
+		}
+	})}
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("syclient.New: %v", err)
+	}
+	drsCtx := &config.GitContext{Client: raw.(*syclient.Client), Organization: "org1", ProjectId: "proj1", SignedURLs: signedurl.NewCache()}
+
+	obj, err := OpenObjectRange(context.Background(), drsCtx, oid)
+	if err != nil {
+		t.Fatalf("OpenObjectRange returned error: %v", err)
+	}
+	if obj.Size() != int64(len(payload)) {
+		t.Fatalf("unexpected size %d", obj.Size())
+	}
+	// The first signed URL fails; a second call re-signs instead of reusing it.
+	if _, err := obj.ReadRange(context.Background(), 4, 4); err == nil {
+		t.Fatal("expected expired URL to fail")
+	}
+	body, err := obj.ReadRange(context.Background(), 4, 4)
+	if err != nil {
+		t.Fatalf("ReadRange returned error: %v", err)
+	}
+	got, _ := io.ReadAll(body)
+	body.Close()
+	if string(got) != "4567" || !slices.Equal(ranges, []string{"bytes=4-7"}) || signs != 2 {
+		t.Fatalf("unexpected range read %q ranges=%v signs=%d", got, ranges, signs)
+	}
+}
+
+func TestParseIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"plain-id":                      "plain-id",
+		" plain-id\n":                   "plain-id",
+		"drs://drs.example.org/abc-123": "abc-123",
+		"drs://dg.4503:abc-123":         "dg.4503/abc-123",
+		"drs://abc-123":                 "abc-123",
+	}
+	for raw, want := range tests {
+		if got := ParseIdentifier(raw); got != want {
+			t.Fatalf("ParseIdentifier(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	drscommon "github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsobject"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	syfoncommon "github.com/calypr/syfon/common"
 )
@@ -13,6 +15,20 @@ func MatchesScope(obj *drsapi.DrsObject, organization, project string) bool {
 	return syfoncommon.DrsObjectMatchesScope(obj, organization, project)
 }
 
+// matchesContextScope reports whether obj is in the context's own scope or in
+// one of its path scopes.
+func matchesContextScope(obj *drsapi.DrsObject, drsCtx *config.GitContext) bool {
+	if MatchesScope(obj, drsCtx.Organization, drsCtx.ProjectId) {
+		return true
+	}
+	for _, s := range drsCtx.PathScopes {
+		if MatchesScope(obj, s.Organization, s.Project) {
+			return true
+		}
+	}
+	return false
+}
+
 func FindMatchingRecord(records []drsapi.DrsObject, organization, projectID string) (*drsapi.DrsObject, error) {
 	if len(records) == 0 {
 		return nil, nil
@@ -23,7 +39,7 @@ func FindMatchingRecord(records []drsapi.DrsObject, organization, projectID stri
 		return nil, fmt.Errorf("could not determine organization from inputs org=%q project=%q", organization, projectID)
 	}
 
-	for _, record := range records {
+	for _, record := range drsobject.WithoutSoftDeleted(records) {
 		if MatchesScope(&record, org, project) {
 			return &record, nil
 		}
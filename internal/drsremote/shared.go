@@ -0,0 +1,50 @@
+package drsremote
+
+import (
+	"os"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// fromSharedCache links oid from the machine-wide shared cache into
+// cachePath, reporting whether it was there. A failure is logged and the
+// caller downloads instead.
+func fromSharedCache(drsCtx *config.GitContext, oid, cachePath string) bool {
+	if drsCtx == nil || drsCtx.SharedCache == "" {
+		return false
+	}
+	ok, err := lfs.LinkFromShared(drsCtx.SharedCache, oid, cachePath)
+	if err != nil && drsCtx.Logger != nil {
+		drsCtx.Logger.Warn("shared cache unavailable; downloading", "oid", oid, "error", err)
+	}
+	return ok
+}
+
+// sharedRecord describes an object linked from the shared cache for usage
+// reporting and hooks without asking the server: the staged record when
+// there is one, otherwise the oid and size of the linked file.
+func sharedRecord(oid, path string) *drsapi.DrsObject {
+	if obj, err := drsobject.ReadObject(common.DRS_OBJS_PATH, oid); err == nil && obj != nil {
+		return obj
+	}
+	obj := &drsapi.DrsObject{Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: oid}}}
+	if info, err := os.Stat(path); err == nil {
+		obj.Size = info.Size()
+	}
+	return obj
+}
+
+// toSharedCache adds a verified download to the shared cache. The download
+// already succeeded, so a failure is only logged.
+func toSharedCache(drsCtx *config.GitContext, oid, cachePath string) {
+	if drsCtx == nil || drsCtx.SharedCache == "" {
+		return
+	}
+	if err := lfs.StoreInShared(drsCtx.SharedCache, oid, cachePath); err != nil && drsCtx.Logger != nil {
+		drsCtx.Logger.Warn("failed to add object to shared cache", "oid", oid, "error", err)
+	}
+}
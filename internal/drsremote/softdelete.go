@@ -0,0 +1,26 @@
+package drsremote
+
+import (
+	"context"
+
+	"github.com/calypr/git-drs/internal/drsobject"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+type softDeletedKey struct{}
+
+// WithSoftDeleted returns a context under which record lookups also return
+// soft-deleted records. Only commands that act on deleted records
+// themselves, such as delete, should use it; everything else treats a
+// soft-deleted record as gone.
+func WithSoftDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, softDeletedKey{}, true)
+}
+
+// liveRecords drops soft-deleted records from objs unless ctx asks for them.
+func liveRecords(ctx context.Context, objs []drsapi.DrsObject) []drsapi.DrsObject {
+	if include, _ := ctx.Value(softDeletedKey{}).(bool); include {
+		return objs
+	}
+	return drsobject.WithoutSoftDeleted(objs)
+}
@@ -0,0 +1,33 @@
+package drsremote
+
+import (
+	"context"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/signedurl"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// accessURLKey names the URL drsCtx's remote signed for one access method of
+// an object.
+func accessURLKey(drsCtx *config.GitContext, objectID, accessID string) signedurl.Key {
+	return signedurl.Key{Remote: drsCtx.RemoteName, ObjectID: strings.TrimSpace(objectID), AccessID: accessID}
+}
+
+// CachedAccessURL returns a still-valid URL for obj that a bulk prefetch
+// through drsCtx resolved for its preferred access method.
+func CachedAccessURL(ctx context.Context, drsCtx *config.GitContext, obj drsapi.DrsObject) (drsapi.AccessURL, bool) {
+	if obj.AccessMethods != nil {
+		methods := orderedAccessMethods(ctx, drsCtx, obj)
+		obj.AccessMethods = &methods
+	}
+	return drsCtx.SignedURLs.Get(accessURLKey(drsCtx, obj.Id, accessIDForBulkRequest(obj)))
+}
+
+// ForgetAccessURL drops every URL cached for an object on drsCtx's remote once
+// it is no longer needed, so long transfers do not keep every URL they
+// resolved.
+func ForgetAccessURL(drsCtx *config.GitContext, objectID string) {
+	drsCtx.SignedURLs.Forget(drsCtx.RemoteName, strings.TrimSpace(objectID))
+}
@@ -0,0 +1,50 @@
+package drsremote
+
+import (
+	"context"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/signedurl"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestIsAzureSASURL(t *testing.T) {
+	if !isAzureSASURL("https://acct.blob.core.windows.net/c/k?sv=2022-11-02&se=2024-01-02T04%3A04%3A05Z&sr=b&sp=r&sig=abc") {
+		t.Fatal("expected an Azure SAS URL")
+	}
+	for _, raw := range []string{
+		"https://b.s3.amazonaws.com/k?X-Amz-Signature=abc&X-Amz-Date=20240102T030405Z",
+		"https://acct.blob.core.windows.net/c/k",
+		"https://drs.example/k?sig=abc",
+	} {
+		if isAzureSASURL(raw) {
+			t.Errorf("%s is not an Azure SAS URL", raw)
+		}
+	}
+}
+
+func TestCachedAccessURLIsScopedToRemoteAndAccessMethod(t *testing.T) {
+	accessID := "s3-east"
+	obj := drsapi.DrsObject{Id: "shared-id", AccessMethods: &[]drsapi.AccessMethod{{AccessId: &accessID}}}
+	cache := signedurl.NewCache()
+	origin := &config.GitContext{RemoteName: "origin", SignedURLs: cache}
+	mirror := &config.GitContext{RemoteName: "mirror", SignedURLs: cache}
+
+	origin.SignedURLs.Put(accessURLKey(origin, obj.Id, accessID), drsapi.AccessURL{Url: "https://origin.example/k"})
+	if got, ok := CachedAccessURL(context.Background(), origin, obj); !ok || got.Url != "https://origin.example/k" {
+		t.Fatalf("CachedAccessURL(origin) = %v, %v", got, ok)
+	}
+	if _, ok := CachedAccessURL(context.Background(), mirror, obj); ok {
+		t.Fatal("a URL signed by origin must not answer for the same object ID on mirror")
+	}
+	origin.SignedURLs.Put(accessURLKey(origin, obj.Id, "gs"), drsapi.AccessURL{Url: "https://origin.example/gs"})
+	if got, _ := CachedAccessURL(context.Background(), origin, obj); got.Url != "https://origin.example/k" {
+		t.Fatalf("expected the URL of the preferred access method, got %s", got.Url)
+	}
+
+	ForgetAccessURL(origin, obj.Id)
+	if _, ok := CachedAccessURL(context.Background(), origin, obj); ok {
+		t.Fatal("expected ForgetAccessURL to drop the object's URLs")
+	}
+}
@@ -0,0 +1,63 @@
+package drsremote
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/hashing"
+)
+
+// ErrChecksumMismatch is returned when downloaded content does not hash to
+// the expected sha256. The corrupted file is removed before returning.
+var ErrChecksumMismatch = drserrors.ErrChecksumMismatch
+
+var sha256HexPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// expectedSHA256 returns the sha256 an oid promises, if it is one.
+func expectedSHA256(oid string) (string, bool) {
+	normalized := strings.ToLower(drsobject.NormalizeChecksum(oid))
+	if !sha256HexPattern.MatchString(normalized) {
+		return "", false
+	}
+	return normalized, true
+}
+
+// verifyDownloadedSHA256 checks path against expected. streamed is the digest
+// computed while the bytes were received; when empty (ranged or resumed
+// downloads) the file is hashed from disk instead.
+func verifyDownloadedSHA256(path, expected, streamed string) error {
+	actual := streamed
+	if actual == "" {
+		var err error
+		actual, _, err = hashing.HashFile(path, true)
+		if err != nil {
+			return err
+		}
+	}
+	if actual == expected {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("%w for %s: expected sha256 %s, got %s (cleanup failed: %v)", ErrChecksumMismatch, path, expected, actual, err)
+	}
+	return fmt.Errorf("%w for %s: expected sha256 %s, got %s", ErrChecksumMismatch, path, expected, actual)
+}
+
+// hashingReadCloser feeds every byte read into w.
+type hashingReadCloser struct {
+	io.ReadCloser
+	w io.Writer
+}
+
+func (r *hashingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		_, _ = r.w.Write(p[:n])
+	}
+	return n, err
+}
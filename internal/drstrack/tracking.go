@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/projectdir"
 )
 
 func TrackPatterns(ctx context.Context, patterns []string, verbose bool, dryRun bool) (string, error) {
@@ -26,7 +27,7 @@ func TrackPatterns(ctx context.Context, patterns []string, verbose bool, dryRun
 	knownPatterns := parseKnownLFSPatterns(attribContents)
 
 	for _, unsanitizedPattern := range patterns {
-		pattern := trimCurrentPrefix(cleanRootPath(unsanitizedPattern))
+		pattern := rootPattern(unsanitizedPattern)
 		encodedArg := escapeAttrPattern(pattern)
 
 		if knownLine, ok := knownPatterns[pattern]; ok {
@@ -106,7 +107,7 @@ func UntrackPatterns(ctx context.Context, patterns []string, verbose bool, dryRu
 
 	removeSet := make(map[string]struct{}, len(patterns))
 	for _, p := range patterns {
-		escaped := escapeAttrPattern(trimCurrentPrefix(p))
+		escaped := escapeAttrPattern(rootPattern(p))
 		removeSet[escaped] = struct{}{}
 	}
 
@@ -143,7 +144,7 @@ func UntrackPatterns(ctx context.Context, patterns []string, verbose bool, dryRu
 		if content != "" {
 			content += "\n"
 		}
-		if err := os.WriteFile(".gitattributes", []byte(content), 0o644); err != nil {
+		if err := os.WriteFile(attributesPath(), []byte(content), 0o644); err != nil {
 			return "", fmt.Errorf("git lfs untrack failed: write .gitattributes: %w", err)
 		}
 	}
@@ -152,7 +153,7 @@ func UntrackPatterns(ctx context.Context, patterns []string, verbose bool, dryRu
 }
 
 func readLocalGitAttributes() ([]byte, error) {
-	data, err := os.ReadFile(".gitattributes")
+	data, err := os.ReadFile(attributesPath())
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
@@ -213,12 +214,29 @@ func writeMergedGitAttributes(existing []byte, changed map[string]string, dryRun
 	if content != "" {
 		content += "\n"
 	}
-	if err := os.WriteFile(".gitattributes", []byte(content), 0o644); err != nil {
+	if err := os.WriteFile(attributesPath(), []byte(content), 0o644); err != nil {
 		return fmt.Errorf("write .gitattributes: %w", err)
 	}
 	return nil
 }
 
+// attributesPath is the .gitattributes file at the repository root, which
+// track and untrack edit from any subdirectory.
+func attributesPath() string {
+	return projectdir.Path(".gitattributes")
+}
+
+// rootPattern makes pattern, given relative to the working directory,
+// relative to the repository root, as git lfs track does: "*.bam" run from
+// data/ tracks "data/*.bam". A leading "/" anchors pattern at the root.
+func rootPattern(pattern string) string {
+	if strings.HasPrefix(pattern, "/") {
+		return cleanRootPath(pattern)
+	}
+	prefix, _ := projectdir.Prefix()
+	return prefix + trimCurrentPrefix(pattern)
+}
+
 func cleanRootPath(pattern string) string {
 	return strings.TrimPrefix(pattern, "/")
 }
@@ -272,7 +290,7 @@ func TrackReadOnly(ctx context.Context, path string) (bool, error) {
 	}
 
 	attrPath := filepath.Join(repoRoot, ".gitattributes")
-	changed, err := UpsertDRSRouteLines(attrPath, "ro", []string{path})
+	changed, err := UpsertDRSRouteLines(attrPath, "ro", []string{rootPattern(path)})
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,258 @@
+// Package encryption encrypts object content on the client before upload so
+// shared buckets only ever hold ciphertext.
+//
+// Content is sealed with AES-256-GCM in fixed-size chunks (a STREAM-style
+// construction): each chunk has its own nonce, built from a counter and a
+// final-chunk flag, so chunks cannot be reordered, dropped, or truncated
+// without failing authentication. The chunk key is derived from the
+// configured key and the object's oid, which makes encryption deterministic:
+// the same content under the same key always yields the same ciphertext, so
+// retried uploads and mirrors agree on the ciphertext hash recorded in the
+// object's metadata block.
+package encryption
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/drsobject"
+)
+
+// Algorithm names the ciphertext format in metadata blocks.
+const Algorithm = "aes-256-gcm-stream-v1"
+
+// DefaultChunkSize is the plaintext size of each sealed chunk.
+const DefaultChunkSize = 1 << 20
+
+const magic = "GDRSENC1"
+
+// ErrWrongKey is returned when an object was encrypted under a different key
+// than the one configured.
+var ErrWrongKey = errors.New("object was encrypted with a different key")
+
+// Key is a 256-bit content encryption key.
+type Key struct {
+	secret [32]byte
+}
+
+// NewKey wraps 32 raw key bytes.
+func NewKey(b []byte) (*Key, error) {
+	if len(b) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(b))
+	}
+	k := &Key{}
+	copy(k.secret[:], b)
+	return k, nil
+}
+
+// ID is a short fingerprint of the key, stored with each object so downloads
+// can tell which key they need without revealing it.
+func (k *Key) ID() string {
+	mac := hmac.New(sha256.New, k.secret[:])
+	mac.Write([]byte("git-drs key id"))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// objectAEAD derives the per-object AEAD for oid.
+func (k *Key) objectAEAD(oid string) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, k.secret[:])
+	mac.Write([]byte("git-drs object key\x00" + strings.ToLower(drsobject.NormalizeOid(oid))))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Metadata is the encryption block stored on a DRS record.
+type Metadata struct {
+	Algorithm        string `json:"alg"`
+	KeyID            string `json:"key_id"`
+	ChunkSize        int    `json:"chunk_size"`
+	CiphertextSHA256 string `json:"ciphertext_sha256"`
+	CiphertextSize   int64  `json:"ciphertext_size"`
+}
+
+func header(chunkSize int) []byte {
+	h := make([]byte, len(magic)+4)
+	copy(h, magic)
+	binary.BigEndian.PutUint32(h[len(magic):], uint32(chunkSize))
+	return h
+}
+
+func nonce(size int, counter uint64, final bool) []byte {
+	n := make([]byte, size)
+	if final {
+		n[0] = 1
+	}
+	binary.BigEndian.PutUint64(n[size-8:], counter)
+	return n
+}
+
+// Encrypt reads plaintext from r and writes ciphertext to w, returning the
+// metadata block describing it.
+func Encrypt(w io.Writer, r io.Reader, key *Key, oid string) (Metadata, error) {
+	aead, err := key.objectAEAD(oid)
+	if err != nil {
+		return Metadata{}, err
+	}
+	sum := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(w, sum)}
+	hdr := header(DefaultChunkSize)
+	if _, err := counter.Write(hdr); err != nil {
+		return Metadata{}, err
+	}
+
+	br := bufio.NewReaderSize(r, DefaultChunkSize)
+	buf := make([]byte, DefaultChunkSize)
+	sealed := make([]byte, 0, DefaultChunkSize+aead.Overhead())
+	for i := uint64(0); ; i++ {
+		n, err := io.ReadFull(br, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return Metadata{}, err
+		}
+		final := n < len(buf)
+		if !final {
+			if _, peekErr := br.Peek(1); errors.Is(peekErr, io.EOF) {
+				final = true
+			}
+		}
+		sealed = aead.Seal(sealed[:0], nonce(aead.NonceSize(), i, final), buf[:n], hdr)
+		if _, err := counter.Write(sealed); err != nil {
+			return Metadata{}, err
+		}
+		if final {
+			break
+		}
+	}
+	return Metadata{
+		Algorithm:        Algorithm,
+		KeyID:            key.ID(),
+		ChunkSize:        DefaultChunkSize,
+		CiphertextSHA256: hex.EncodeToString(sum.Sum(nil)),
+		CiphertextSize:   counter.n,
+	}, nil
+}
+
+// Decrypt reads ciphertext described by meta from r and writes plaintext to
+// w. Any tampering, truncation, or ciphertext hash mismatch is an error;
+// plaintext already written to w must then be discarded by the caller.
+func Decrypt(w io.Writer, r io.Reader, key *Key, oid string, meta Metadata) error {
+	if meta.Algorithm != Algorithm {
+		return fmt.Errorf("unsupported encryption algorithm %q", meta.Algorithm)
+	}
+	if meta.KeyID != key.ID() {
+		return fmt.Errorf("%w: object key id %s, configured key id %s", ErrWrongKey, meta.KeyID, key.ID())
+	}
+	aead, err := key.objectAEAD(oid)
+	if err != nil {
+		return err
+	}
+	sum := sha256.New()
+	br := bufio.NewReaderSize(io.TeeReader(r, sum), meta.ChunkSize+aead.Overhead())
+
+	hdr := make([]byte, len(magic)+4)
+	if _, err := io.ReadFull(br, hdr); err != nil || string(hdr[:len(magic)]) != magic {
+		return fmt.Errorf("not a git-drs encrypted object")
+	}
+	chunkSize := int(binary.BigEndian.Uint32(hdr[len(magic):]))
+	if chunkSize <= 0 || chunkSize != meta.ChunkSize {
+		return fmt.Errorf("encrypted object chunk size %d does not match metadata (%d)", chunkSize, meta.ChunkSize)
+	}
+
+	buf := make([]byte, chunkSize+aead.Overhead())
+	plain := make([]byte, 0, chunkSize)
+	for i := uint64(0); ; i++ {
+		n, err := io.ReadFull(br, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+		final := n < len(buf)
+		if !final {
+			if _, peekErr := br.Peek(1); errors.Is(peekErr, io.EOF) {
+				final = true
+			}
+		}
+		plain, err = aead.Open(plain[:0], nonce(aead.NonceSize(), i, final), buf[:n], hdr)
+		if err != nil {
+			return fmt.Errorf("decrypt chunk %d: authentication failed", i)
+		}
+		if _, err := w.Write(plain); err != nil {
+			return err
+		}
+		if final {
+			break
+		}
+	}
+	if got := hex.EncodeToString(sum.Sum(nil)); meta.CiphertextSHA256 != "" && got != meta.CiphertextSHA256 {
+		return fmt.Errorf("ciphertext sha256 %s does not match metadata %s", got, meta.CiphertextSHA256)
+	}
+	return nil
+}
+
+// EncryptFile encrypts src into dst, replacing dst.
+func EncryptFile(key *Key, oid, src, dst string) (Metadata, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return Metadata{}, err
+	}
+	meta, err := Encrypt(out, in, key, oid)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(dst)
+		return Metadata{}, fmt.Errorf("encrypt %s: %w", src, err)
+	}
+	return meta, nil
+}
+
+// DecryptFile decrypts src into dst, replacing dst, and returns the sha256 of
+// the plaintext. dst is removed if decryption fails.
+func DecryptFile(key *Key, oid string, meta Metadata, src, dst string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.New()
+	err = Decrypt(io.MultiWriter(out, sum), in, key, oid, meta)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(dst)
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,175 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func testKey(t *testing.T, fill byte) *Key {
+	t.Helper()
+	k, err := NewKey(bytes.Repeat([]byte{fill}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	key := testKey(t, 1)
+	oid := strings.Repeat("a", 64)
+	for _, size := range []int{0, 1, DefaultChunkSize - 1, DefaultChunkSize, DefaultChunkSize + 1, 2 * DefaultChunkSize} {
+		plain := bytes.Repeat([]byte{0x5a}, size)
+		var sealed bytes.Buffer
+		meta, err := Encrypt(&sealed, bytes.NewReader(plain), key, oid)
+		if err != nil {
+			t.Fatalf("size %d: Encrypt error: %v", size, err)
+		}
+		if meta.CiphertextSize != int64(sealed.Len()) {
+			t.Fatalf("size %d: ciphertext size %d, metadata %d", size, sealed.Len(), meta.CiphertextSize)
+		}
+		sum := sha256.Sum256(sealed.Bytes())
+		if meta.CiphertextSHA256 != hex.EncodeToString(sum[:]) {
+			t.Fatalf("size %d: ciphertext hash mismatch", size)
+		}
+		if size > 0 && bytes.Contains(sealed.Bytes(), plain[:1+size/2]) {
+			t.Fatalf("size %d: ciphertext contains plaintext", size)
+		}
+
+		var out bytes.Buffer
+		if err := Decrypt(&out, bytes.NewReader(sealed.Bytes()), key, oid, meta); err != nil {
+			t.Fatalf("size %d: Decrypt error: %v", size, err)
+		}
+		if !bytes.Equal(out.Bytes(), plain) {
+			t.Fatalf("size %d: plaintext mismatch", size)
+		}
+	}
+}
+
+func TestEncryptIsDeterministicPerKeyAndOID(t *testing.T) {
+	plain := []byte("phi payload")
+	oid := strings.Repeat("b", 64)
+	var a, b, c bytes.Buffer
+	metaA, _ := Encrypt(&a, bytes.NewReader(plain), testKey(t, 1), oid)
+	metaB, _ := Encrypt(&b, bytes.NewReader(plain), testKey(t, 1), oid)
+	metaC, _ := Encrypt(&c, bytes.NewReader(plain), testKey(t, 2), oid)
+	if metaA.CiphertextSHA256 != metaB.CiphertextSHA256 {
+		t.Fatal("same key and oid should produce the same ciphertext")
+	}
+	if metaA.CiphertextSHA256 == metaC.CiphertextSHA256 || metaA.KeyID == metaC.KeyID {
+		t.Fatal("different keys should produce different ciphertext and key ids")
+	}
+}
+
+func TestDecryptRejectsTamperingAndWrongKey(t *testing.T) {
+	key := testKey(t, 1)
+	oid := strings.Repeat("c", 64)
+	plain := bytes.Repeat([]byte{7}, DefaultChunkSize+10)
+	var sealed bytes.Buffer
+	meta, err := Encrypt(&sealed, bytes.NewReader(plain), key, oid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flipped := append([]byte(nil), sealed.Bytes()...)
+	flipped[len(flipped)/2] ^= 0xff
+	if err := Decrypt(&bytes.Buffer{}, bytes.NewReader(flipped), key, oid, meta); err == nil {
+		t.Fatal("expected tampered ciphertext to fail")
+	}
+
+	// Dropping the final chunk leaves a full chunk that was not sealed as
+	// final, so truncation at a chunk boundary is detected.
+	truncated := sealed.Bytes()[:12+DefaultChunkSize+16]
+	if err := Decrypt(&bytes.Buffer{}, bytes.NewReader(truncated), key, oid, Metadata{Algorithm: Algorithm, KeyID: key.ID(), ChunkSize: DefaultChunkSize}); err == nil {
+		t.Fatal("expected truncated ciphertext to fail")
+	}
+
+	err = Decrypt(&bytes.Buffer{}, bytes.NewReader(sealed.Bytes()), testKey(t, 2), oid, meta)
+	if !errors.Is(err, ErrWrongKey) {
+		t.Fatalf("expected ErrWrongKey, got %v", err)
+	}
+
+	if err := Decrypt(&bytes.Buffer{}, bytes.NewReader(sealed.Bytes()), key, strings.Repeat("d", 64), meta); err == nil {
+		t.Fatal("expected ciphertext to be bound to its oid")
+	}
+}
+
+func TestEncryptDecryptFile(t *testing.T) {
+	dir := t.TempDir()
+	key := testKey(t, 3)
+	oid := strings.Repeat("e", 64)
+	src := filepath.Join(dir, "plain")
+	if err := os.WriteFile(src, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := EncryptFile(key, oid, src, filepath.Join(dir, "sealed"))
+	if err != nil {
+		t.Fatalf("EncryptFile error: %v", err)
+	}
+	sum, err := DecryptFile(key, oid, meta, filepath.Join(dir, "sealed"), filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatalf("DecryptFile error: %v", err)
+	}
+	want := sha256.Sum256([]byte("hello"))
+	if sum != hex.EncodeToString(want[:]) {
+		t.Fatalf("unexpected plaintext sha256 %s", sum)
+	}
+}
+
+func TestObjectMetadataAlias(t *testing.T) {
+	obj := &drsapi.DrsObject{Aliases: &[]string{"other", AliasPrefix + `{"alg":"old"}`}}
+	meta := Metadata{Algorithm: Algorithm, KeyID: "abc", ChunkSize: 16, CiphertextSHA256: "ff", CiphertextSize: 44}
+	SetOnObject(obj, meta)
+	if len(*obj.Aliases) != 2 {
+		t.Fatalf("expected block replaced, got %v", *obj.Aliases)
+	}
+	got, ok := FromObject(obj)
+	if !ok || got != meta {
+		t.Fatalf("unexpected block %+v ok=%v", got, ok)
+	}
+	if _, ok := FromObject(&drsapi.DrsObject{}); ok {
+		t.Fatal("expected no block on plain object")
+	}
+}
+
+func TestParseKeyAndLoadKey(t *testing.T) {
+	raw := bytes.Repeat([]byte{9}, 32)
+	for _, in := range [][]byte{raw, []byte(hex.EncodeToString(raw) + "\n"), []byte(base64.StdEncoding.EncodeToString(raw))} {
+		k, err := ParseKey(in)
+		if err != nil || k.secret != [32]byte(raw) {
+			t.Fatalf("ParseKey(%q) = %v", in, err)
+		}
+	}
+	if _, err := ParseKey([]byte("short")); err == nil {
+		t.Fatal("expected short key to be rejected")
+	}
+
+	orig := runKeyCommand
+	t.Cleanup(func() { runKeyCommand = orig })
+	calls := 0
+	runKeyCommand = func(ctx context.Context, command string) ([]byte, error) {
+		calls++
+		return []byte(hex.EncodeToString(raw)), nil
+	}
+	s := Settings{Remote: "origin", KeyCommand: "kms-decrypt"}
+	for i := 0; i < 2; i++ {
+		if _, err := s.LoadKey(context.Background()); err != nil {
+			t.Fatalf("LoadKey error: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected key command to run once, ran %d times", calls)
+	}
+	if _, err := (Settings{Remote: "origin"}).LoadKey(context.Background()); err == nil {
+		t.Fatal("expected error without a configured key")
+	}
+}
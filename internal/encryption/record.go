@@ -0,0 +1,20 @@
+package encryption
+
+import (
+	"github.com/calypr/git-drs/internal/drsobject"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// AliasPrefix marks the alias that carries an object's Metadata as JSON
+// (see drsobject.AliasMetadata).
+const AliasPrefix = "git-drs-encryption:"
+
+// FromObject returns the encryption block on obj, if it has one.
+func FromObject(obj *drsapi.DrsObject) (Metadata, bool) {
+	return drsobject.AliasMetadata(obj, AliasPrefix, func(meta Metadata) bool { return meta.Algorithm != "" })
+}
+
+// SetOnObject records meta on obj, replacing any earlier block.
+func SetOnObject(obj *drsapi.DrsObject, meta Metadata) {
+	drsobject.SetAliasMetadata(obj, AliasPrefix, meta)
+}
@@ -0,0 +1,96 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// Settings configures client-side encryption for one remote. KeyFile holds
+// the key; KeyCommand instead runs a shell command that prints it, which is
+// how a KMS or secrets manager is wired in (for example a command that
+// decrypts a wrapped data key). Either form holds 32 bytes as raw bytes, hex,
+// or base64.
+type Settings struct {
+	Remote     string
+	KeyFile    string
+	KeyCommand string
+}
+
+// Enabled reports whether the remote encrypts content.
+func (s Settings) Enabled() bool {
+	return s.KeyFile != "" || s.KeyCommand != ""
+}
+
+// ErrKeyUnavailable wraps every LoadKey failure, so callers can tell a missing
+// or unreadable key apart from a transfer error.
+var ErrKeyUnavailable = errors.New("encryption key unavailable")
+
+// runKeyCommand is swapped in tests.
+var runKeyCommand = func(ctx context.Context, command string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stderr = os.Stderr
+	return cmd.Output()
+}
+
+var (
+	keyMu    sync.Mutex
+	keyCache = map[Settings]*Key{}
+)
+
+// LoadKey reads the configured key. Keys are cached for the life of the
+// process so a key command runs once per command, not once per object.
+func (s Settings) LoadKey(ctx context.Context) (*Key, error) {
+	if !s.Enabled() {
+		return nil, fmt.Errorf("%w: no key configured for remote %q; set drs.remote.%s.encryption-key-file or encryption-key-command", ErrKeyUnavailable, s.Remote, s.Remote)
+	}
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	if k, ok := keyCache[s]; ok {
+		return k, nil
+	}
+	var (
+		raw    []byte
+		err    error
+		source string
+	)
+	if s.KeyFile != "" {
+		source = s.KeyFile
+		raw, err = os.ReadFile(s.KeyFile)
+	} else {
+		source = "encryption-key-command"
+		raw, err = runKeyCommand(ctx, s.KeyCommand)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: read key for remote %q from %s: %v", ErrKeyUnavailable, s.Remote, source, err)
+	}
+	k, err := ParseKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key for remote %q from %s: %v", ErrKeyUnavailable, s.Remote, source, err)
+	}
+	keyCache[s] = k
+	return k, nil
+}
+
+// ParseKey accepts 32 raw bytes, 64 hex characters, or base64 of 32 bytes.
+func ParseKey(raw []byte) (*Key, error) {
+	if len(raw) == 32 {
+		return NewKey(raw)
+	}
+	text := string(bytes.TrimSpace(raw))
+	if b, err := hex.DecodeString(text); err == nil && len(b) == 32 {
+		return NewKey(b)
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if b, err := enc.DecodeString(text); err == nil && len(b) == 32 {
+			return NewKey(b)
+		}
+	}
+	return nil, fmt.Errorf("expected 32 bytes as raw bytes, hex, or base64")
+}
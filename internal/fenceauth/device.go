@@ -0,0 +1,150 @@
+package fenceauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const discoveryPath = "/user/.well-known/openid-configuration"
+
+// ErrDeviceFlowUnsupported is returned when the server does not advertise an
+// OAuth device authorization endpoint.
+var ErrDeviceFlowUnsupported = errors.New("server does not support the device authorization flow")
+
+// DeviceCode is what the user needs to approve a device login.
+type DeviceCode struct {
+	UserCode                string
+	VerificationURI         string
+	VerificationURIComplete string
+	ExpiresIn               time.Duration
+}
+
+// wait pauses between token polls; swapped in tests.
+var wait = func(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// DeviceLogin runs the OAuth 2.0 device authorization flow (RFC 8628)
+// against the fence OIDC provider at endpoint. prompt is called once with
+// the code to show the user; DeviceLogin then polls until the login is
+// approved, denied, or expires, and returns the access token.
+func DeviceLogin(ctx context.Context, endpoint, clientID string, prompt func(DeviceCode)) (string, error) {
+	if strings.TrimSpace(clientID) == "" {
+		return "", errors.New("a client ID is required for device login")
+	}
+	var discovery struct {
+		DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+		TokenEndpoint               string `json:"token_endpoint"`
+	}
+	if err := do(ctx, http.MethodGet, base(endpoint)+discoveryPath, "", nil, &discovery); err != nil {
+		return "", fmt.Errorf("read OpenID configuration: %w", err)
+	}
+	if discovery.DeviceAuthorizationEndpoint == "" || discovery.TokenEndpoint == "" {
+		return "", ErrDeviceFlowUnsupported
+	}
+
+	var code struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+	}
+	status, err := postForm(ctx, discovery.DeviceAuthorizationEndpoint, url.Values{
+		"client_id": {clientID},
+		"scope":     {"openid user data"},
+	}, &code)
+	if err != nil {
+		return "", fmt.Errorf("start device login: %w", err)
+	}
+	if status/100 != 2 || code.DeviceCode == "" {
+		return "", fmt.Errorf("start device login: %s returned status %d", discovery.DeviceAuthorizationEndpoint, status)
+	}
+	prompt(DeviceCode{
+		UserCode:                code.UserCode,
+		VerificationURI:         code.VerificationURI,
+		VerificationURIComplete: code.VerificationURIComplete,
+		ExpiresIn:               time.Duration(code.ExpiresIn) * time.Second,
+	})
+
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for {
+		if err := wait(ctx, interval); err != nil {
+			return "", err
+		}
+		var tok struct {
+			AccessToken string `json:"access_token"`
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if _, err := postForm(ctx, discovery.TokenEndpoint, url.Values{
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+			"device_code": {code.DeviceCode},
+			"client_id":   {clientID},
+		}, &tok); err != nil {
+			return "", fmt.Errorf("poll device login: %w", err)
+		}
+		switch tok.Error {
+		case "":
+			if tok.AccessToken == "" {
+				return "", errors.New("device login: token response has no access_token")
+			}
+			return tok.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "access_denied":
+			return "", errors.New("device login was denied")
+		case "expired_token":
+			return "", errors.New("device login expired before it was approved")
+		default:
+			return "", fmt.Errorf("device login failed: %s %s", tok.Error, tok.Description)
+		}
+		if code.ExpiresIn > 0 && time.Now().After(deadline) {
+			return "", errors.New("device login expired before it was approved")
+		}
+	}
+}
+
+// postForm posts a form and decodes the JSON response, whatever its status:
+// OAuth endpoints report pending and failed logins as 400 responses.
+func postForm(ctx context.Context, target string, form url.Values, out any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("%s returned status %d: %s", target, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return resp.StatusCode, nil
+}
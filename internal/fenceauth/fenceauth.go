@@ -0,0 +1,275 @@
+// Package fenceauth talks to a Gen3 fence server on behalf of `git drs auth`:
+// it exchanges API keys for access tokens, runs the OAuth device
+// authorization flow, and reads who a token belongs to.
+package fenceauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/httpclient"
+	syfoncommon "github.com/calypr/syfon/common"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	accessTokenPath = "/user/credentials/api/access_token"
+	userPath        = "/user/user"
+)
+
+// httpClient sends fence requests; swapped in tests.
+var httpClient = httpclient.NewAPI()
+
+// TokenInfo is what a JWT says about itself. The signature is not checked;
+// fence does that on every request.
+type TokenInfo struct {
+	Subject  string
+	Username string
+	Issued   time.Time
+	Expires  time.Time
+	// Scopes are the OAuth scopes the token was issued with, if it says.
+	Scopes []string
+}
+
+// Expired reports whether the token has expired at now.
+func (t TokenInfo) Expired(now time.Time) bool {
+	return !t.Expires.IsZero() && !now.Before(t.Expires)
+}
+
+// ParseToken reads the claims of a fence access token or API key.
+func ParseToken(token string) (TokenInfo, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return TokenInfo{}, errors.New("token is empty")
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil {
+		return TokenInfo{}, fmt.Errorf("invalid token format: %w", err)
+	}
+	info := TokenInfo{}
+	info.Subject, _ = claims["sub"].(string)
+	if ctx, ok := claims["context"].(map[string]any); ok {
+		if user, ok := ctx["user"].(map[string]any); ok {
+			info.Username, _ = user["name"].(string)
+		}
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		info.Expires = exp.Time
+	}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		info.Issued = iat.Time
+	}
+	switch scope := claims["scope"].(type) {
+	case string:
+		info.Scopes = strings.Fields(scope)
+	case []any:
+		for _, s := range scope {
+			if name, ok := s.(string); ok {
+				info.Scopes = append(info.Scopes, name)
+			}
+		}
+	}
+	return info, nil
+}
+
+// Within reports whether the token carries scopes and all of them are in
+// allowed.
+func (t TokenInfo) Within(allowed []string) bool {
+	if len(t.Scopes) == 0 {
+		return false
+	}
+	for _, s := range t.Scopes {
+		if !slices.Contains(allowed, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// ExchangeAPIKey returns a new access token for apiKey.
+func ExchangeAPIKey(ctx context.Context, endpoint, apiKey string) (string, error) {
+	return exchange(ctx, endpoint, map[string]any{"api_key": apiKey})
+}
+
+// ExchangeScopedAPIKey returns a new access token for apiKey limited to
+// scopes. A token fence issues with any other scope, or without a scope
+// claim, is refused rather than used with more privilege than asked for.
+func ExchangeScopedAPIKey(ctx context.Context, endpoint, apiKey string, scopes []string) (string, error) {
+	if len(scopes) == 0 {
+		return "", errors.New("no scopes given for a scoped access token")
+	}
+	token, err := exchange(ctx, endpoint, map[string]any{"api_key": apiKey, "scope": scopes})
+	if err != nil {
+		return "", err
+	}
+	info, err := ParseToken(token)
+	if err != nil {
+		return "", fmt.Errorf("request scoped access token: %w", err)
+	}
+	if !info.Within(scopes) {
+		return "", fmt.Errorf("request scoped access token: fence issued scopes [%s], not within the requested [%s]", strings.Join(info.Scopes, " "), strings.Join(scopes, " "))
+	}
+	return token, nil
+}
+
+func exchange(ctx context.Context, endpoint string, request map[string]any) (string, error) {
+	if key, _ := request["api_key"].(string); strings.TrimSpace(key) == "" {
+		return "", errors.New("an API key is required to request an access token")
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := do(ctx, http.MethodPost, base(endpoint)+accessTokenPath, "", body, &out); err != nil {
+		return "", fmt.Errorf("request access token: %w", err)
+	}
+	if strings.TrimSpace(out.AccessToken) == "" {
+		return "", errors.New("request access token: response has no access_token")
+	}
+	return out.AccessToken, nil
+}
+
+// User is the account behind a token and the resources it can reach.
+type User struct {
+	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+	// Resources are the authz resource paths (or, for servers without
+	// arborist, the project names) the user holds any permission on.
+	Resources []string `json:"resources"`
+	// Permissions maps each resource to what the user may do on it.
+	Permissions map[string][]Permission `json:"-"`
+}
+
+// Permission is one arborist grant: a method such as "create", on a
+// service such as "indexd". Either may be "*".
+type Permission struct {
+	Method  string `json:"method"`
+	Service string `json:"service"`
+}
+
+// Missing returns the methods u is not granted on the project resource of
+// organization/project. A grant on the organization covers its projects,
+// and a "*" method covers every method. Servers without arborist list
+// project names instead of resource paths; those are matched by project
+// name or the Gen3 "<program>-<project>" form.
+func (u User) Missing(organization, project string, methods ...string) []string {
+	var missing []string
+	for _, method := range methods {
+		if !u.granted(organization, project, method) {
+			missing = append(missing, method)
+		}
+	}
+	return missing
+}
+
+func (u User) granted(organization, project, method string) bool {
+	for resource, perms := range u.Permissions {
+		if !covers(resource, organization, project) {
+			continue
+		}
+		for _, p := range perms {
+			if p.Method == method || p.Method == "*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func covers(resource, organization, project string) bool {
+	if org, proj, ok := syfoncommon.ResourceScope(resource); ok {
+		return org == organization && (proj == "" || proj == project)
+	}
+	return resource == project || resource == organization+"-"+project
+}
+
+// parsePermissions reads one authz entry: a list of arborist grants, or the
+// list of method names older fence servers return in project_access.
+func parsePermissions(raw json.RawMessage) []Permission {
+	var grants []Permission
+	if err := json.Unmarshal(raw, &grants); err == nil {
+		return grants
+	}
+	var names []string
+	if err := json.Unmarshal(raw, &names); err != nil {
+		return nil
+	}
+	for _, name := range names {
+		grants = append(grants, Permission{Method: name, Service: "*"})
+	}
+	return grants
+}
+
+// CurrentUser reads /user/user with token.
+func CurrentUser(ctx context.Context, endpoint, token string) (User, error) {
+	var out struct {
+		Username      string                     `json:"username"`
+		Name          string                     `json:"name"`
+		Email         string                     `json:"email"`
+		Authz         map[string]json.RawMessage `json:"authz"`
+		ProjectAccess map[string]json.RawMessage `json:"project_access"`
+	}
+	if err := do(ctx, http.MethodGet, base(endpoint)+userPath, token, nil, &out); err != nil {
+		return User{}, fmt.Errorf("read user info: %w", err)
+	}
+	u := User{Username: out.Username, Email: out.Email, Resources: []string{}}
+	if u.Username == "" {
+		u.Username = out.Name
+	}
+	access := out.Authz
+	if len(access) == 0 {
+		access = out.ProjectAccess
+	}
+	u.Permissions = make(map[string][]Permission, len(access))
+	for resource, raw := range access {
+		u.Resources = append(u.Resources, resource)
+		u.Permissions[resource] = parsePermissions(raw)
+	}
+	sort.Strings(u.Resources)
+	return u, nil
+}
+
+func base(endpoint string) string {
+	return strings.TrimRight(strings.TrimSpace(endpoint), "/")
+}
+
+// do sends a JSON request and decodes a 2xx JSON response into out.
+func do(ctx context.Context, method, target, token string, body []byte, out any) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s returned status %d: %s", method, target, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
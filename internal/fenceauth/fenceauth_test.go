@@ -0,0 +1,207 @@
+package fenceauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testToken(t *testing.T, name string, exp time.Time) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":     "42",
+		"exp":     exp.Unix(),
+		"iat":     exp.Add(-time.Hour).Unix(),
+		"context": map[string]any{"user": map[string]any{"name": name}},
+	}).SignedString([]byte("test"))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+func TestParseToken(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	info, err := ParseToken(testToken(t, "alice@example.org", exp))
+	if err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+	if info.Username != "alice@example.org" || info.Subject != "42" || !info.Expires.Equal(exp) {
+		t.Fatalf("unexpected token info: %+v", info)
+	}
+	if info.Expired(exp.Add(-time.Minute)) || !info.Expired(exp) {
+		t.Fatalf("unexpected expiry check for %v", info.Expires)
+	}
+	if _, err := ParseToken("not-a-jwt"); err == nil {
+		t.Fatal("expected error for malformed token")
+	}
+}
+
+func TestExchangeAPIKeyAndCurrentUser(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case accessTokenPath:
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["api_key"] != "key" {
+				http.Error(w, "bad key", http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "token"})
+		case userPath:
+			if r.Header.Get("Authorization") != "Bearer token" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"username": "alice",
+				"authz": map[string]any{
+					"/programs/p/projects/b": []any{},
+					"/programs/p/projects/a": []any{},
+				},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	token, err := ExchangeAPIKey(ctx, srv.URL+"/", "key")
+	if err != nil || token != "token" {
+		t.Fatalf("ExchangeAPIKey = %q, %v", token, err)
+	}
+	if _, err := ExchangeAPIKey(ctx, srv.URL, "wrong"); err == nil {
+		t.Fatal("expected error for rejected API key")
+	}
+	user, err := CurrentUser(ctx, srv.URL, token)
+	if err != nil {
+		t.Fatalf("CurrentUser: %v", err)
+	}
+	want := []string{"/programs/p/projects/a", "/programs/p/projects/b"}
+	if user.Username != "alice" || !reflect.DeepEqual(user.Resources, want) {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+}
+
+func TestExchangeScopedAPIKey(t *testing.T) {
+	issued := []any{"openid", "data"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			APIKey string   `json:"api_key"`
+			Scope  []string `json:"scope"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.APIKey != "key" || !reflect.DeepEqual(body.Scope, []string{"openid", "data"}) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"scope": issued}).SignedString([]byte("test"))
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": token})
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	token, err := ExchangeScopedAPIKey(ctx, srv.URL, "key", []string{"openid", "data"})
+	if err != nil {
+		t.Fatalf("ExchangeScopedAPIKey: %v", err)
+	}
+	if info, _ := ParseToken(token); !reflect.DeepEqual(info.Scopes, []string{"openid", "data"}) {
+		t.Fatalf("unexpected scopes %v", info.Scopes)
+	}
+
+	issued = []any{"openid", "data", "user"}
+	if _, err := ExchangeScopedAPIKey(ctx, srv.URL, "key", []string{"openid", "data"}); err == nil {
+		t.Fatal("a token broader than requested should be refused")
+	}
+	issued = nil
+	if _, err := ExchangeScopedAPIKey(ctx, srv.URL, "key", []string{"openid", "data"}); err == nil {
+		t.Fatal("a token without a scope claim should be refused")
+	}
+}
+
+func TestDeviceLogin(t *testing.T) {
+	polls := 0
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case discoveryPath:
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"device_authorization_endpoint": srv.URL + "/device",
+				"token_endpoint":                srv.URL + "/token",
+			})
+		case "/device":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"device_code": "dev", "user_code": "ABCD", "verification_uri": srv.URL + "/activate", "expires_in": 600, "interval": 1,
+			})
+		case "/token":
+			polls++
+			if r.FormValue("device_code") != "dev" {
+				http.Error(w, "bad code", http.StatusBadRequest)
+				return
+			}
+			if polls < 3 {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "authorization_pending"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "device-token"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	origWait := wait
+	wait = func(context.Context, time.Duration) error { return nil }
+	t.Cleanup(func() { wait = origWait })
+
+	var shown DeviceCode
+	token, err := DeviceLogin(context.Background(), srv.URL, "client", func(c DeviceCode) { shown = c })
+	if err != nil || token != "device-token" {
+		t.Fatalf("DeviceLogin = %q, %v", token, err)
+	}
+	if shown.UserCode != "ABCD" || polls != 3 {
+		t.Fatalf("unexpected prompt %+v after %d polls", shown, polls)
+	}
+}
+
+func TestDeviceLoginUnsupported(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"token_endpoint": "https://example.org/token"})
+	}))
+	defer srv.Close()
+
+	_, err := DeviceLogin(context.Background(), srv.URL, "client", func(DeviceCode) {})
+	if !errors.Is(err, ErrDeviceFlowUnsupported) {
+		t.Fatalf("expected ErrDeviceFlowUnsupported, got %v", err)
+	}
+}
+
+func TestUserMissing(t *testing.T) {
+	user := User{Permissions: map[string][]Permission{
+		"/programs/p/projects/a": parsePermissions(json.RawMessage(`[{"method":"read","service":"*"},{"method":"create","service":"indexd"}]`)),
+		"/organization/q":        parsePermissions(json.RawMessage(`[{"method":"*","service":"*"}]`)),
+		"p-legacy":               parsePermissions(json.RawMessage(`["read-storage","create"]`)),
+	}}
+
+	if got := user.Missing("p", "a", "create", "update"); !reflect.DeepEqual(got, []string{"update"}) {
+		t.Fatalf("Missing(p/a) = %v, want [update]", got)
+	}
+	if got := user.Missing("q", "any", "create", "update"); got != nil {
+		t.Fatalf("Missing(q/any) = %v, want organization grant to cover it", got)
+	}
+	if got := user.Missing("p", "legacy", "create"); got != nil {
+		t.Fatalf("Missing(p/legacy) = %v, want legacy project_access to match", got)
+	}
+	if got := user.Missing("p", "b", "create"); !reflect.DeepEqual(got, []string{"create"}) {
+		t.Fatalf("Missing(p/b) = %v, want [create]", got)
+	}
+}
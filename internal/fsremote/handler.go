@@ -0,0 +1,704 @@
+package fsremote
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+	syfoncommon "github.com/calypr/syfon/common"
+)
+
+const (
+	drsPrefix   = "/ga4gh/drs/v1/objects"
+	indexPrefix = "/index"
+	// blobPrefix, putPrefix and partPrefix are the "signed" URLs the Store
+	// hands out for downloads, single-PUT uploads and multipart parts.
+	blobPrefix = "/blob/"
+	putPrefix  = "/put/"
+	partPrefix = "/part/"
+
+	defaultListLimit = 1000
+	maxListLimit     = 1024
+)
+
+// errContentMismatch marks an upload whose bytes do not hash to the
+// record's checksum.
+var errContentMismatch = errors.New("uploaded content does not match the record's sha256 checksum")
+
+// ServeHTTP answers the DRS, index and data endpoints git-drs calls, and
+// the signed URLs the Store issues.
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	switch {
+	case strings.HasPrefix(path, blobPrefix) && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+		s.serveBlob(w, r, strings.TrimPrefix(path, blobPrefix))
+	case strings.HasPrefix(path, putPrefix) && r.Method == http.MethodPut:
+		s.putObject(w, r, strings.TrimPrefix(path, putPrefix))
+	case strings.HasPrefix(path, partPrefix) && r.Method == http.MethodPut:
+		s.putPart(w, r, strings.TrimPrefix(path, partPrefix))
+	case path == "/healthz" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	case strings.HasPrefix(path, drsPrefix+"/"):
+		s.serveDRS(w, r, strings.TrimPrefix(path, drsPrefix+"/"))
+	case path == indexPrefix || strings.HasPrefix(path, indexPrefix+"/"):
+		s.serveIndex(w, r, strings.TrimPrefix(strings.TrimPrefix(path, indexPrefix), "/"))
+	case strings.HasPrefix(path, "/data/"):
+		s.serveData(w, r, strings.TrimPrefix(path, "/data/"))
+	default:
+		writeError(w, http.StatusNotFound, "no route for "+r.Method+" "+path)
+	}
+}
+
+func (s *Store) serveDRS(w http.ResponseWriter, r *http.Request, rest string) {
+	switch {
+	case r.Method == http.MethodPost && rest == "register":
+		var body drsapi.RegisterObjectsBody
+		if !decode(w, r, &body) {
+			return
+		}
+		created, err := s.Register(body.Candidates)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, drsapi.N201ObjectsCreated{Objects: created})
+	case r.Method == http.MethodPost && rest == "access":
+		var body drsapi.BulkObjectAccessId
+		if !decode(w, r, &body) {
+			return
+		}
+		resolved := []drsapi.BulkAccessURL{}
+		if body.BulkObjectAccessIds != nil {
+			for _, item := range *body.BulkObjectAccessIds {
+				if item.BulkObjectId == nil {
+					continue
+				}
+				u, ok, err := s.signedURL(*item.BulkObjectId)
+				if err != nil {
+					writeError(w, http.StatusInternalServerError, err.Error())
+					return
+				}
+				if ok {
+					resolved = append(resolved, drsapi.BulkAccessURL{DrsObjectId: item.BulkObjectId, Url: u})
+				}
+			}
+		}
+		writeJSON(w, http.StatusOK, drsapi.N200OkAccesses{ResolvedDrsObjectAccessUrls: &resolved})
+	case r.Method == http.MethodGet && strings.HasPrefix(rest, "checksum/"):
+		matches, err := s.ByChecksum(strings.TrimPrefix(rest, "checksum/"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, drsapi.N200OkDrsObjects{ResolvedDrsObject: &matches})
+	case (r.Method == http.MethodPut || r.Method == http.MethodPost) && strings.HasSuffix(rest, "/delete"):
+		var body struct {
+			DeleteStorageData *bool `json:"delete_storage_data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		found, err := s.Delete(strings.TrimSuffix(rest, "/delete"), body.DeleteStorageData != nil && *body.DeleteStorageData)
+		switch {
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		case !found:
+			writeError(w, http.StatusNotFound, "object not found")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	case r.Method == http.MethodGet && strings.Contains(rest, "/access/"):
+		id, _, _ := strings.Cut(rest, "/access/")
+		s.answerSignedURL(w, id, func(u string) any { return drsapi.AccessURL{Url: u} })
+	case r.Method == http.MethodGet:
+		rec, ok, err := s.Object(rest)
+		switch {
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		case !ok:
+			writeError(w, http.StatusNotFound, "object not found")
+		default:
+			writeJSON(w, http.StatusOK, rec)
+		}
+	default:
+		writeError(w, http.StatusNotFound, "no route for "+r.Method+" "+r.URL.Path)
+	}
+}
+
+// signedURL returns the blob URL of record id, and false when there is no
+// such record or it names no stored object.
+func (s *Store) signedURL(id string) (string, bool, error) {
+	rec, ok, err := s.Object(id)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	key := storedKey(rec)
+	if key == "" {
+		return "", false, nil
+	}
+	return BaseURL + blobPrefix + strings.TrimPrefix(key, objectsDir+"/"), true, nil
+}
+
+// answerSignedURL writes the blob URL of record id in the body wrap builds.
+func (s *Store) answerSignedURL(w http.ResponseWriter, id string, wrap func(string) any) {
+	u, ok, err := s.signedURL(id)
+	switch {
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	case !ok:
+		writeError(w, http.StatusNotFound, "object not found")
+	default:
+		writeJSON(w, http.StatusOK, wrap(u))
+	}
+}
+
+func (s *Store) serveIndex(w http.ResponseWriter, r *http.Request, did string) {
+	switch {
+	case did == "" && r.Method == http.MethodGet:
+		s.listRecords(w, r.URL.Query())
+	case did == "" && r.Method == http.MethodDelete:
+		s.deleteByQuery(w, r.URL.Query())
+	case r.Method == http.MethodPost && strings.HasSuffix(did, "/controlled-access/remove"):
+		var body struct {
+			Resource string `json:"resource"`
+		}
+		if !decode(w, r, &body) {
+			return
+		}
+		s.editRecord(w, strings.TrimSuffix(did, "/controlled-access/remove"), func(rec *drsapi.DrsObject) {
+			if rec.ControlledAccess == nil {
+				return
+			}
+			drop := syfoncommon.NormalizeAccessResource(body.Resource)
+			kept := []string{}
+			for _, resource := range *rec.ControlledAccess {
+				if syfoncommon.NormalizeAccessResource(resource) != drop {
+					kept = append(kept, resource)
+				}
+			}
+			rec.ControlledAccess = &kept
+		})
+	case r.Method == http.MethodGet:
+		rec, ok, err := s.Object(did)
+		switch {
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		case !ok:
+			writeError(w, http.StatusNotFound, "record not found")
+		default:
+			writeJSON(w, http.StatusOK, toResponse(rec))
+		}
+	case r.Method == http.MethodPut:
+		var body internalapi.InternalRecord
+		if !decode(w, r, &body) {
+			return
+		}
+		s.editRecord(w, did, func(rec *drsapi.DrsObject) { applyInternal(rec, body) })
+	case r.Method == http.MethodDelete:
+		found, err := s.Delete(did, false)
+		switch {
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		case !found:
+			writeError(w, http.StatusNotFound, "record not found")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		writeError(w, http.StatusNotFound, "no route for "+r.Method+" "+r.URL.Path)
+	}
+}
+
+// editRecord applies edit to record did and answers with the result.
+func (s *Store) editRecord(w http.ResponseWriter, did string, edit func(*drsapi.DrsObject)) {
+	var (
+		out   drsapi.DrsObject
+		found bool
+	)
+	err := s.update(func(records map[string]drsapi.DrsObject) error {
+		rec, ok := records[did]
+		if !ok {
+			return nil
+		}
+		found = true
+		edit(&rec)
+		now := time.Now().UTC()
+		rec.UpdatedTime = &now
+		records[did] = rec
+		out = rec
+		return nil
+	})
+	switch {
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	case !found:
+		writeError(w, http.StatusNotFound, "record not found")
+	default:
+		writeJSON(w, http.StatusOK, toResponse(out))
+	}
+}
+
+// matchingRecords returns the records matching the index query q, ordered
+// by ID.
+func (s *Store) matchingRecords(q url.Values) ([]drsapi.DrsObject, error) {
+	records, err := s.Records()
+	if err != nil {
+		return nil, err
+	}
+	org, project := q.Get("organization"), q.Get("project")
+	hash := strings.TrimSpace(q.Get("hash"))
+	if typ, sum, ok := strings.Cut(hash, ":"); ok {
+		if !strings.EqualFold(strings.ReplaceAll(typ, "-", ""), "sha256") {
+			return nil, nil
+		}
+		hash = sum
+	}
+	rawURL := strings.TrimSpace(q.Get("url"))
+	var out []drsapi.DrsObject
+	for _, rec := range records {
+		if org != "" && !scopeMatches(rec, org, project) {
+			continue
+		}
+		if hash != "" && sha256Of(rec) != normalizeSum(hash) {
+			continue
+		}
+		if rawURL != "" && !hasAccessURL(rec, rawURL) {
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out, nil
+}
+
+// listRecords pages through the matching records. Pages are numbered from
+// 1, as git-drs requests them.
+func (s *Store) listRecords(w http.ResponseWriter, q url.Values) {
+	matches, err := s.matchingRecords(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	limit = min(limit, maxListLimit)
+	page, _ := strconv.Atoi(q.Get("page"))
+	start := (max(page, 1) - 1) * limit
+	records := []internalapi.InternalRecord{}
+	for i := start; i < len(matches) && i < start+limit; i++ {
+		records = append(records, toInternal(matches[i]))
+	}
+	writeJSON(w, http.StatusOK, internalapi.ListRecordsResponse{Records: &records})
+}
+
+func (s *Store) deleteByQuery(w http.ResponseWriter, q url.Values) {
+	if q.Get("organization") == "" && q.Get("hash") == "" {
+		writeError(w, http.StatusBadRequest, "organization or hash is required")
+		return
+	}
+	matches, err := s.matchingRecords(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	drop := make(map[string]bool, len(matches))
+	for _, rec := range matches {
+		drop[rec.Id] = true
+	}
+	deleted := 0
+	err = s.update(func(records map[string]drsapi.DrsObject) error {
+		for id := range drop {
+			if _, ok := records[id]; ok {
+				delete(records, id)
+				deleted++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, internalapi.DeleteByQueryResponse{Deleted: &deleted})
+}
+
+func (s *Store) serveData(w http.ResponseWriter, r *http.Request, rest string) {
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(rest, "upload/"):
+		did := strings.TrimPrefix(rest, "upload/")
+		if _, ok, err := s.Object(did); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		} else if !ok {
+			writeError(w, http.StatusNotFound, "object not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"url": BaseURL + putPrefix + url.PathEscape(did)})
+	case r.Method == http.MethodGet && strings.HasPrefix(rest, "download/"):
+		s.answerSignedURL(w, strings.TrimPrefix(rest, "download/"), func(u string) any { return map[string]string{"url": u} })
+	case r.Method == http.MethodPost && rest == "multipart/init":
+		var body internalapi.InternalMultipartInitRequest
+		if !decode(w, r, &body) {
+			return
+		}
+		s.initMultipart(w, body)
+	case r.Method == http.MethodPost && rest == "multipart/upload":
+		var body internalapi.InternalMultipartUploadRequest
+		if !decode(w, r, &body) {
+			return
+		}
+		if _, err := os.Stat(s.uploadDir(body.UploadId)); err != nil {
+			writeError(w, http.StatusNotFound, "unknown upload "+body.UploadId)
+			return
+		}
+		u := fmt.Sprintf("%s%s%s/%d", BaseURL, partPrefix, body.UploadId, body.PartNumber)
+		writeJSON(w, http.StatusOK, internalapi.InternalMultipartUploadOutput{PresignedUrl: &u})
+	case r.Method == http.MethodPost && rest == "multipart/complete":
+		var body internalapi.InternalMultipartCompleteRequest
+		if !decode(w, r, &body) {
+			return
+		}
+		s.completeMultipart(w, body)
+	default:
+		writeError(w, http.StatusNotFound, "no route for "+r.Method+" "+r.URL.Path)
+	}
+}
+
+func (s *Store) initMultipart(w http.ResponseWriter, body internalapi.InternalMultipartInitRequest) {
+	if body.Guid == nil || *body.Guid == "" {
+		writeError(w, http.StatusBadRequest, "guid is required")
+		return
+	}
+	if _, ok, err := s.Object(*body.Guid); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	} else if !ok {
+		writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+	id, err := newUploadID()
+	if err == nil {
+		err = os.MkdirAll(s.uploadDir(id), 0o755)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(s.uploadDir(id), "did"), []byte(*body.Guid), 0o644)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, internalapi.InternalMultipartInitOutput{Guid: body.Guid, UploadId: &id})
+}
+
+func (s *Store) completeMultipart(w http.ResponseWriter, body internalapi.InternalMultipartCompleteRequest) {
+	dir := s.uploadDir(body.UploadId)
+	did, err := os.ReadFile(filepath.Join(dir, "did"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "unknown upload "+body.UploadId)
+		return
+	}
+	parts := append([]internalapi.InternalMultipartPart(nil), body.Parts...)
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	readers := make([]io.Reader, 0, len(parts))
+	for _, p := range parts {
+		f, err := os.Open(filepath.Join(dir, strconv.Itoa(int(p.PartNumber))))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("part %d was not uploaded", p.PartNumber))
+			return
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+	if _, err := s.store(string(did), io.MultiReader(readers...)); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	_ = os.RemoveAll(dir)
+	writeJSON(w, http.StatusOK, map[string]string{"guid": string(did)})
+}
+
+func (s *Store) putObject(w http.ResponseWriter, r *http.Request, did string) {
+	did, _ = url.PathUnescape(did)
+	etag, err := s.store(did, r.Body)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.Header().Set("ETag", strconv.Quote(etag))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Store) putPart(w http.ResponseWriter, r *http.Request, rest string) {
+	uploadID, part, _ := strings.Cut(rest, "/")
+	n, err := strconv.Atoi(part)
+	if err != nil || n < 1 || !isUploadID(uploadID) {
+		writeError(w, http.StatusBadRequest, "invalid part URL")
+		return
+	}
+	dir := s.uploadDir(uploadID)
+	if _, err := os.Stat(dir); err != nil {
+		writeError(w, http.StatusNotFound, "unknown upload "+uploadID)
+		return
+	}
+	f, err := os.Create(filepath.Join(dir, strconv.Itoa(n)))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sum := md5.New()
+	_, err = io.Copy(io.MultiWriter(f, sum), r.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("ETag", strconv.Quote(hex.EncodeToString(sum.Sum(nil))))
+	w.WriteHeader(http.StatusOK)
+}
+
+// store writes the content of record did from body into the object tree
+// and returns its MD5. Plain content must hash to the record's checksum.
+// Encrypted or compressed content is stored under the hash of the bytes
+// written, and the record's access method is pointed at it.
+func (s *Store) store(did string, body io.Reader) (string, error) {
+	rec, ok, err := s.Object(did)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fs.ErrNotExist
+	}
+	tmp, err := s.tempFile()
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	sha, sum := sha256.New(), md5.New()
+	_, err = io.Copy(io.MultiWriter(tmp, sha, sum), body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	stored := hex.EncodeToString(sha.Sum(nil))
+	if !transformed(rec) && stored != sha256Of(rec) {
+		return "", errContentMismatch
+	}
+	key := objectKey(stored)
+	dst := s.objectPath(key)
+	if _, err := os.Stat(dst); errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return "", err
+		}
+		if err := os.Rename(tmp.Name(), dst); err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", err
+	}
+	if storedKey(rec) != key {
+		err := s.update(func(records map[string]drsapi.DrsObject) error {
+			if cur, ok := records[did]; ok {
+				s.setLocation(&cur, key)
+				records[did] = cur
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// serveBlob serves stored content, honouring Range headers.
+func (s *Store) serveBlob(w http.ResponseWriter, r *http.Request, rel string) {
+	if !validKey(rel) {
+		writeError(w, http.StatusNotFound, "no such object")
+		return
+	}
+	f, err := os.Open(s.objectPath(objectsDir + "/" + rel))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "no such object")
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+func (s *Store) uploadDir(id string) string {
+	return filepath.Join(s.Root, uploadsDir, id)
+}
+
+func isUploadID(id string) bool {
+	_, err := hex.DecodeString(id)
+	return err == nil && len(id) == 32
+}
+
+// scopeMatches reports whether rec is controlled by org and, when set,
+// project.
+func scopeMatches(rec drsapi.DrsObject, org, project string) bool {
+	if project != "" {
+		return syfoncommon.DrsObjectMatchesScope(&rec, org, project)
+	}
+	for _, resource := range derefStrings(rec.ControlledAccess) {
+		if o, _, ok := syfoncommon.ResourceScope(resource); ok && o == org {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAccessURL(rec drsapi.DrsObject, raw string) bool {
+	if rec.AccessMethods == nil {
+		return false
+	}
+	for _, am := range *rec.AccessMethods {
+		if am.AccessUrl != nil && am.AccessUrl.Url == raw {
+			return true
+		}
+	}
+	return false
+}
+
+// toInternal is rec as the index endpoints return it.
+func toInternal(rec drsapi.DrsObject) internalapi.InternalRecord {
+	hashes := internalapi.HashInfo{}
+	for _, c := range rec.Checksums {
+		hashes[strings.ToLower(strings.ReplaceAll(c.Type, "-", ""))] = c.Checksum
+	}
+	created := rec.CreatedTime.Format(time.RFC3339)
+	size := rec.Size
+	out := internalapi.InternalRecord{
+		Did:              rec.Id,
+		AccessMethods:    rec.AccessMethods,
+		ControlledAccess: rec.ControlledAccess,
+		CreatedTime:      &created,
+		Description:      rec.Description,
+		FileName:         rec.Name,
+		Hashes:           &hashes,
+		Size:             &size,
+		Version:          rec.Version,
+	}
+	if rec.UpdatedTime != nil {
+		updated := rec.UpdatedTime.Format(time.RFC3339)
+		out.UpdatedTime = &updated
+	}
+	if org, project := recordScope(rec); org != "" {
+		out.Organization = &org
+		if project != "" {
+			out.Project = &project
+		}
+	}
+	return out
+}
+
+func toResponse(rec drsapi.DrsObject) internalapi.InternalRecordResponse {
+	in := toInternal(rec)
+	return internalapi.InternalRecordResponse{
+		AccessMethods:    in.AccessMethods,
+		ControlledAccess: in.ControlledAccess,
+		CreatedTime:      in.CreatedTime,
+		Description:      in.Description,
+		Did:              in.Did,
+		FileName:         in.FileName,
+		Hashes:           in.Hashes,
+		Organization:     in.Organization,
+		Project:          in.Project,
+		Size:             in.Size,
+		UpdatedTime:      in.UpdatedTime,
+		Version:          in.Version,
+	}
+}
+
+// applyInternal copies the fields an index update sets onto rec.
+func applyInternal(rec *drsapi.DrsObject, in internalapi.InternalRecord) {
+	if in.AccessMethods != nil {
+		rec.AccessMethods = in.AccessMethods
+	}
+	if in.ControlledAccess != nil {
+		rec.ControlledAccess = in.ControlledAccess
+	}
+	if in.Description != nil {
+		rec.Description = in.Description
+	}
+	if in.FileName != nil {
+		rec.Name = in.FileName
+	}
+	if in.Size != nil {
+		rec.Size = *in.Size
+	}
+	if in.Version != nil {
+		rec.Version = in.Version
+	}
+	if in.Hashes != nil {
+		checksums := make([]drsapi.Checksum, 0, len(*in.Hashes))
+		for typ, sum := range *in.Hashes {
+			checksums = append(checksums, drsapi.Checksum{Type: typ, Checksum: sum})
+		}
+		sort.Slice(checksums, func(i, j int) bool { return checksums[i].Type < checksums[j].Type })
+		rec.Checksums = checksums
+	}
+}
+
+func derefStrings(p *[]string) []string {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		writeError(w, http.StatusNotFound, "object not found")
+	case errors.Is(err, errContentMismatch):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"msg": msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,430 @@
+// Package fsremote keeps DRS records and object content in a plain
+// directory, for enclaves with no DRS server and no object store. A Store
+// answers the syfon API endpoints git-drs uses in-process, through a
+// RoundTripper, so push, pull, query and delete run unchanged against it:
+// uploads land in a content-addressed tree under objects/ and records in a
+// JSON index next to it. git drs replicate later copies the records and
+// their content to a Gen3 remote.
+package fsremote
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/compression"
+	"github.com/calypr/git-drs/internal/encryption"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	syfoncommon "github.com/calypr/syfon/common"
+)
+
+// BaseURL is the API endpoint clients of a Store are built with. Nothing
+// resolves it: the Store's RoundTripper answers every request itself.
+const BaseURL = "http://filesystem.invalid"
+
+// AccessType is the access method type of records kept in a Store.
+const AccessType drsapi.AccessMethodType = "file"
+
+const (
+	indexName   = "index.json"
+	lockName    = "index.json.lock"
+	objectsDir  = "objects"
+	uploadsDir  = "uploads"
+	tmpDir      = "tmp"
+	lockRetry   = 10 * time.Millisecond
+	lockTimeout = 30 * time.Second
+	// staleLockAfter is how old a lock file must be before it is taken to
+	// belong to a process that died.
+	staleLockAfter = 2 * time.Minute
+)
+
+// Store is a DRS index and object tree under one directory, which may be
+// shared by several repositories and machines over a network filesystem.
+// Records registered without controlled access are scoped to Organization
+// and Project.
+type Store struct {
+	Root         string
+	Organization string
+	Project      string
+}
+
+// index is the on-disk form of the record index.
+type index struct {
+	Records []drsapi.DrsObject `json:"records"`
+}
+
+// Open returns the Store at root, creating its layout when root is new.
+func Open(root, organization, project string) (*Store, error) {
+	if strings.TrimSpace(root) == "" {
+		return nil, errors.New("filesystem remote directory is required")
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range []string{objectsDir, uploadsDir, tmpDir} {
+		if err := os.MkdirAll(filepath.Join(abs, dir), 0o755); err != nil {
+			return nil, fmt.Errorf("create filesystem remote: %w", err)
+		}
+	}
+	s := &Store{Root: abs, Organization: organization, Project: project}
+	if _, err := os.Stat(filepath.Join(abs, indexName)); errors.Is(err, fs.ErrNotExist) {
+		if err := s.update(func(map[string]drsapi.DrsObject) error { return nil }); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Records returns every record in the index, ordered by ID.
+func (s *Store) Records() ([]drsapi.DrsObject, error) {
+	data, err := os.ReadFile(filepath.Join(s.Root, indexName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var idx index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Join(s.Root, indexName), err)
+	}
+	return idx.Records, nil
+}
+
+// Object returns the record id.
+func (s *Store) Object(id string) (drsapi.DrsObject, bool, error) {
+	records, err := s.Records()
+	if err != nil {
+		return drsapi.DrsObject{}, false, err
+	}
+	for _, rec := range records {
+		if rec.Id == id {
+			return rec, true, nil
+		}
+	}
+	return drsapi.DrsObject{}, false, nil
+}
+
+// ByChecksum returns the records whose sha256 checksum is sum.
+func (s *Store) ByChecksum(sum string) ([]drsapi.DrsObject, error) {
+	sum = normalizeSum(sum)
+	records, err := s.Records()
+	if err != nil {
+		return nil, err
+	}
+	out := []drsapi.DrsObject{}
+	for _, rec := range records {
+		if sum != "" && sha256Of(rec) == sum {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+// Register adds candidates to the index, replacing records with the same
+// ID. A candidate's ID comes from its id: alias, or is minted from its
+// checksum and scope as syfon mints it. Each record gets a single file
+// access method naming where its content is, or will be, stored.
+func (s *Store) Register(candidates []drsapi.DrsObjectCandidate) ([]drsapi.DrsObject, error) {
+	out := make([]drsapi.DrsObject, 0, len(candidates))
+	err := s.update(func(records map[string]drsapi.DrsObject) error {
+		now := time.Now().UTC()
+		for _, c := range candidates {
+			rec, err := s.fromCandidate(c)
+			if err != nil {
+				return err
+			}
+			if prev, ok := records[rec.Id]; ok {
+				rec.CreatedTime = prev.CreatedTime
+				if transformed(prev) && sha256Of(prev) == sha256Of(rec) {
+					rec.AccessMethods = prev.AccessMethods
+				}
+			} else {
+				rec.CreatedTime = now
+			}
+			rec.UpdatedTime = &now
+			records[rec.Id] = rec
+			out = append(out, rec)
+		}
+		return nil
+	})
+	return out, err
+}
+
+func (s *Store) fromCandidate(c drsapi.DrsObjectCandidate) (drsapi.DrsObject, error) {
+	rec := drsapi.DrsObject{
+		Checksums:        c.Checksums,
+		Contents:         c.Contents,
+		ControlledAccess: c.ControlledAccess,
+		Description:      c.Description,
+		MimeType:         c.MimeType,
+		Name:             c.Name,
+		Size:             c.Size,
+		Version:          c.Version,
+	}
+	sum := sha256Of(rec)
+	if sum == "" {
+		return drsapi.DrsObject{}, errors.New("candidate has no sha256 checksum")
+	}
+	if rec.ControlledAccess == nil || len(*rec.ControlledAccess) == 0 {
+		access := syfoncommon.AuthzMapToControlledAccess(syfoncommon.AuthzMapFromScope(s.Organization, s.Project))
+		rec.ControlledAccess = &access
+	}
+	var aliases []string
+	if c.Aliases != nil {
+		for _, a := range *c.Aliases {
+			if id, ok := strings.CutPrefix(a, "id:"); ok {
+				rec.Id = strings.TrimSpace(id)
+				continue
+			}
+			aliases = append(aliases, a)
+		}
+	}
+	if len(aliases) > 0 {
+		rec.Aliases = &aliases
+	}
+	if rec.Id == "" {
+		org, project := recordScope(rec)
+		rec.Id = syfoncommon.DrsUUID(org, project, sum)
+		if rec.Id == "" {
+			return drsapi.DrsObject{}, fmt.Errorf("cannot mint an ID for %s without an organization and project", sum)
+		}
+	}
+	rec.SelfUri = "drs://" + rec.Id
+	s.setLocation(&rec, objectKey(sum))
+	return rec, nil
+}
+
+// setLocation points rec's access method at the stored object key.
+func (s *Store) setLocation(rec *drsapi.DrsObject, key string) {
+	accessID := string(AccessType)
+	methods := []drsapi.AccessMethod{{
+		Type:     AccessType,
+		AccessId: &accessID,
+		AccessUrl: &struct {
+			Headers *[]string `json:"headers,omitempty"`
+			Url     string    `json:"url"`
+		}{Url: "file://" + filepath.ToSlash(filepath.Join(s.Root, filepath.FromSlash(key)))},
+	}}
+	rec.AccessMethods = &methods
+}
+
+// Delete removes the record id. With storage set its content is removed
+// too, unless another record still names it.
+func (s *Store) Delete(id string, storage bool) (bool, error) {
+	found := false
+	var orphan string
+	err := s.update(func(records map[string]drsapi.DrsObject) error {
+		rec, ok := records[id]
+		if !ok {
+			return nil
+		}
+		found = true
+		delete(records, id)
+		key := storedKey(rec)
+		if !storage || key == "" {
+			return nil
+		}
+		for _, other := range records {
+			if storedKey(other) == key {
+				return nil
+			}
+		}
+		orphan = key
+		return nil
+	})
+	if err != nil || orphan == "" {
+		return found, err
+	}
+	if err := os.Remove(s.objectPath(orphan)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return found, err
+	}
+	return found, nil
+}
+
+// update applies fn to the index under the store lock and writes the
+// result back atomically, so concurrent readers never see a partial index.
+func (s *Store) update(fn func(map[string]drsapi.DrsObject) error) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	current, err := s.Records()
+	if err != nil {
+		return err
+	}
+	records := make(map[string]drsapi.DrsObject, len(current))
+	for _, rec := range current {
+		records[rec.Id] = rec
+	}
+	if err := fn(records); err != nil {
+		return err
+	}
+	idx := index{Records: make([]drsapi.DrsObject, 0, len(records))}
+	for _, rec := range records {
+		idx.Records = append(idx.Records, rec)
+	}
+	sort.Slice(idx.Records, func(i, j int) bool { return idx.Records[i].Id < idx.Records[j].Id })
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := s.tempFile()
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.Root, indexName)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// lock takes the index lock, an O_EXCL file like the repository lock.
+// Holders only rewrite the index, so a lock older than staleLockAfter
+// belongs to a process that died and is removed.
+func (s *Store) lock() (func(), error) {
+	path := filepath.Join(s.Root, lockName)
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("lock filesystem remote: %w", err)
+		}
+		if info, serr := os.Stat(path); serr == nil && time.Since(info.ModTime()) > staleLockAfter {
+			_ = os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("lock filesystem remote: %s is held by another process; remove it if no git-drs command is running", path)
+		}
+		time.Sleep(lockRetry)
+	}
+}
+
+// tempFile creates a file in the store's tmp directory, on the same
+// filesystem as the index and objects so it can be renamed into place.
+func (s *Store) tempFile() (*os.File, error) {
+	if err := os.MkdirAll(filepath.Join(s.Root, tmpDir), 0o755); err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(filepath.Join(s.Root, tmpDir), "write-*")
+}
+
+// objectPath is the file holding the object stored under key.
+func (s *Store) objectPath(key string) string {
+	return filepath.Join(s.Root, filepath.FromSlash(key))
+}
+
+// objectKey is the root-relative path content with sha256 sum is stored
+// at: objects/<aa>/<bb>/<sum>.
+func objectKey(sum string) string {
+	return objectsDir + "/" + sum[:2] + "/" + sum[2:4] + "/" + sum
+}
+
+// storedKey is the object key rec's file access method names. Keys are
+// read back relative to the store, so a store mounted at another path on
+// another machine still resolves them.
+func storedKey(rec drsapi.DrsObject) string {
+	if rec.AccessMethods == nil {
+		return ""
+	}
+	for _, am := range *rec.AccessMethods {
+		if am.Type != AccessType || am.AccessUrl == nil {
+			continue
+		}
+		if _, rel, ok := strings.Cut(am.AccessUrl.Url, "/"+objectsDir+"/"); ok && validKey(rel) {
+			return objectsDir + "/" + rel
+		}
+	}
+	return ""
+}
+
+// validKey reports whether rel is an <aa>/<bb>/<sha256> object path.
+func validKey(rel string) bool {
+	parts := strings.Split(rel, "/")
+	if len(parts) != 3 || !isSHA256(parts[2]) {
+		return false
+	}
+	return parts[0] == parts[2][:2] && parts[1] == parts[2][2:4]
+}
+
+// transformed reports whether rec's stored bytes are encrypted or
+// compressed, so they do not hash to its checksum.
+func transformed(rec drsapi.DrsObject) bool {
+	_, encrypted := encryption.FromObject(&rec)
+	_, compressed := compression.FromObject(&rec)
+	return encrypted || compressed
+}
+
+// recordScope is the organization and project of rec's first controlled
+// access resource.
+func recordScope(rec drsapi.DrsObject) (string, string) {
+	if rec.ControlledAccess != nil {
+		for _, resource := range *rec.ControlledAccess {
+			if org, project, ok := syfoncommon.ResourceScope(resource); ok {
+				return org, project
+			}
+		}
+	}
+	return "", ""
+}
+
+func sha256Of(rec drsapi.DrsObject) string {
+	for _, c := range rec.Checksums {
+		if strings.EqualFold(strings.ReplaceAll(c.Type, "-", ""), "sha256") {
+			return normalizeSum(c.Checksum)
+		}
+	}
+	return ""
+}
+
+func normalizeSum(sum string) string {
+	sum = strings.ToLower(strings.TrimSpace(sum))
+	sum = strings.TrimPrefix(sum, "sha256:")
+	if !isSHA256(sum) {
+		return ""
+	}
+	return sum
+}
+
+func isSHA256(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,181 @@
+package fsremote
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	syclient "github.com/calypr/syfon/client"
+	sycommon "github.com/calypr/syfon/client/common"
+	syservices "github.com/calypr/syfon/client/services"
+	"github.com/calypr/syfon/client/transfer"
+)
+
+func newTestClient(t *testing.T, root string) (*Store, *syclient.Client) {
+	t.Helper()
+	store, err := Open(root, "calypr", "airgap")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	raw, err := syclient.New(BaseURL, syclient.WithHTTPClient(&http.Client{Transport: store}))
+	if err != nil {
+		t.Fatalf("syclient.New: %v", err)
+	}
+	return store, raw.(*syclient.Client)
+}
+
+func candidate(content []byte, name string) drsapi.DrsObjectCandidate {
+	sum := sha256.Sum256(content)
+	return drsapi.DrsObjectCandidate{
+		Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: hex.EncodeToString(sum[:])}},
+		Name:      &name,
+		Size:      int64(len(content)),
+	}
+}
+
+func register(t *testing.T, cl *syclient.Client, c drsapi.DrsObjectCandidate) drsapi.DrsObject {
+	t.Helper()
+	created, err := cl.DRS().RegisterObjects(context.Background(), drsapi.RegisterObjectsJSONRequestBody{Candidates: []drsapi.DrsObjectCandidate{c}})
+	if err != nil {
+		t.Fatalf("RegisterObjects: %v", err)
+	}
+	if len(created.Objects) != 1 {
+		t.Fatalf("expected one created object, got %d", len(created.Objects))
+	}
+	return created.Objects[0]
+}
+
+func metadata() sycommon.FileMetadata {
+	return sycommon.FileMetadata{Authorizations: map[string][]string{"calypr": {"airgap"}}}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	_, cl := newTestClient(t, root)
+	content := []byte("air-gapped object content")
+
+	rec := register(t, cl, candidate(content, "a.txt"))
+	if rec.ControlledAccess == nil || len(*rec.ControlledAccess) != 1 || (*rec.ControlledAccess)[0] != "/organization/calypr/project/airgap" {
+		t.Fatalf("expected the store's scope as controlled access, got %+v", rec.ControlledAccess)
+	}
+	if rec.AccessMethods == nil || (*rec.AccessMethods)[0].Type != AccessType {
+		t.Fatalf("expected a file access method, got %+v", rec.AccessMethods)
+	}
+	sum := sha256Of(rec)
+	if want := "file://" + filepath.ToSlash(filepath.Join(root, "objects", sum[:2], sum[2:4], sum)); (*rec.AccessMethods)[0].AccessUrl.Url != want {
+		t.Fatalf("access URL = %q, want %q", (*rec.AccessMethods)[0].AccessUrl.Url, want)
+	}
+
+	// Content that does not hash to the record's checksum is refused.
+	putURL, err := cl.Data().ResolveUploadURL(ctx, rec.Id, sum, metadata(), "")
+	if err != nil {
+		t.Fatalf("ResolveUploadURL: %v", err)
+	}
+	if err := cl.Data().Upload(ctx, putURL, strings.NewReader("tampered"), 8); err == nil {
+		t.Fatal("expected an upload of mismatched content to fail")
+	}
+	if err := cl.Data().Upload(ctx, putURL, bytes.NewReader(content), int64(len(content))); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	matches, err := cl.DRS().BatchGetObjectsByHash(ctx, []string{"sha256:" + sum})
+	if err != nil || len(matches.DrsObjects) != 1 || matches.DrsObjects[0].Id != rec.Id {
+		t.Fatalf("checksum lookup = %+v, %v", matches.DrsObjects, err)
+	}
+
+	access, err := cl.DRS().GetAccessURL(ctx, rec.Id, string(AccessType))
+	if err != nil {
+		t.Fatalf("GetAccessURL: %v", err)
+	}
+	start, end := int64(4), int64(9)
+	resp, err := cl.Data().Download(ctx, access.Url, &start, &end)
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(got) != string(content[4:10]) {
+		t.Fatalf("ranged download = %q, want %q", got, content[4:10])
+	}
+
+	// A second store opened on the same directory sees the record.
+	_, other := newTestClient(t, root)
+	list, err := other.Index().List(ctx, syservices.ListRecordsOptions{Organization: "calypr", ProjectID: "airgap", Limit: 10, Page: 1})
+	if err != nil || list.Records == nil || len(*list.Records) != 1 || (*list.Records)[0].Did != rec.Id {
+		t.Fatalf("List = %+v, %v", list.Records, err)
+	}
+	if list, _ := other.Index().List(ctx, syservices.ListRecordsOptions{Organization: "calypr", ProjectID: "airgap", Limit: 10, Page: 2}); list.Records == nil || len(*list.Records) != 0 {
+		t.Fatalf("page 2 should be empty, got %+v", list.Records)
+	}
+
+	if err := other.DRS().DeleteObject(ctx, rec.Id, true); err != nil {
+		t.Fatalf("DeleteObject: %v", err)
+	}
+	if _, err := cl.DRS().GetObject(ctx, rec.Id); err == nil {
+		t.Fatal("expected the deleted record to be gone")
+	}
+	if _, err := os.Stat(filepath.Join(root, "objects", sum[:2], sum[2:4], sum)); !os.IsNotExist(err) {
+		t.Fatalf("expected the deleted object's content to be removed, got %v", err)
+	}
+}
+
+func TestStoreMultipartAndIDs(t *testing.T) {
+	ctx := context.Background()
+	_, cl := newTestClient(t, t.TempDir())
+	content := bytes.Repeat([]byte("0123456789"), 100)
+
+	c := candidate(content, "big.bin")
+	aliases := []string{"id:dg.TEST/fixed", "md5:abc"}
+	c.Aliases = &aliases
+	rec := register(t, cl, c)
+	if rec.Id != "dg.TEST/fixed" || rec.Aliases == nil || len(*rec.Aliases) != 1 {
+		t.Fatalf("expected the requested ID and the id: alias dropped, got %q %+v", rec.Id, rec.Aliases)
+	}
+	minted := register(t, cl, candidate([]byte("other"), "other.txt"))
+	if again := register(t, cl, candidate([]byte("other"), "other.txt")); again.Id != minted.Id || !again.CreatedTime.Equal(minted.CreatedTime) {
+		t.Fatalf("re-registering should keep the minted ID and creation time, got %+v and %+v", again, minted)
+	}
+
+	uploadID, err := cl.Data().MultipartInit(ctx, rec.Id)
+	if err != nil {
+		t.Fatalf("MultipartInit: %v", err)
+	}
+	var parts []transfer.MultipartPart
+	for i, chunk := range [][]byte{content[:600], content[600:]} {
+		n := i + 1
+		etag, err := cl.Data().MultipartPart(ctx, rec.Id, uploadID, n, bytes.NewReader(chunk))
+		if err != nil {
+			t.Fatalf("MultipartPart %d: %v", n, err)
+		}
+		parts = append(parts, transfer.MultipartPart{PartNumber: int32(n), ETag: etag})
+	}
+	if err := cl.Data().MultipartComplete(ctx, rec.Id, uploadID, parts); err != nil {
+		t.Fatalf("MultipartComplete: %v", err)
+	}
+
+	u, err := cl.Data().ResolveDownloadURL(ctx, rec.Id, "")
+	if err != nil {
+		t.Fatalf("ResolveDownloadURL: %v", err)
+	}
+	resp, err := cl.Data().Download(ctx, u, nil, nil)
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !bytes.Equal(got, content) {
+		t.Fatalf("downloaded %d bytes, want %d", len(got), len(content))
+	}
+	if err := cl.Health().Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package fsremote
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// RoundTrip answers req from the Store without a network. The handler runs
+// in its own goroutine and its body is streamed through a pipe, so large
+// downloads are never held in memory.
+func (s *Store) RoundTrip(req *http.Request) (*http.Response, error) {
+	pr, pw := io.Pipe()
+	rw := &pipeResponse{header: http.Header{}, req: req, body: pr, pw: pw, ready: make(chan *http.Response, 1)}
+	r := req.Clone(req.Context())
+	if r.Body == nil {
+		r.Body = http.NoBody
+	}
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				if !rw.sent {
+					rw.header = http.Header{}
+					rw.WriteHeader(http.StatusInternalServerError)
+				}
+				_ = pw.CloseWithError(fmt.Errorf("filesystem remote: %v", p))
+				return
+			}
+			rw.WriteHeader(http.StatusOK)
+			_ = pw.Close()
+		}()
+		defer r.Body.Close()
+		s.ServeHTTP(rw, r)
+	}()
+	select {
+	case resp := <-rw.ready:
+		return resp, nil
+	case <-req.Context().Done():
+		_ = pr.CloseWithError(req.Context().Err())
+		return nil, req.Context().Err()
+	}
+}
+
+// pipeResponse is the http.ResponseWriter RoundTrip hands the handler. The
+// response is released to the caller on the first WriteHeader or Write.
+type pipeResponse struct {
+	header http.Header
+	req    *http.Request
+	body   *io.PipeReader
+	pw     *io.PipeWriter
+	ready  chan *http.Response
+	once   sync.Once
+	sent   bool
+}
+
+func (p *pipeResponse) Header() http.Header { return p.header }
+
+func (p *pipeResponse) WriteHeader(status int) {
+	p.once.Do(func() {
+		p.sent = true
+		header := p.header.Clone()
+		length := int64(-1)
+		if n, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil {
+			length = n
+		}
+		var body io.ReadCloser = p.body
+		if p.req.Method == http.MethodHead || status == http.StatusNoContent || status == http.StatusNotModified {
+			if status != http.StatusOK && status != http.StatusPartialContent {
+				length = 0
+			}
+			body = http.NoBody
+			_ = p.body.Close()
+		}
+		p.ready <- &http.Response{
+			Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			StatusCode:    status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          body,
+			ContentLength: length,
+			Request:       p.req,
+		}
+	})
+}
+
+func (p *pipeResponse) Write(b []byte) (int, error) {
+	p.WriteHeader(http.StatusOK)
+	return p.pw.Write(b)
+}
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/git-lfs/pktline"
 )
@@ -24,12 +25,28 @@ type SmudgeFunc func(ctx context.Context, req FilterRequest, ptr io.Reader, dst
 // dst is where the LFS pointer must be written.
 type CleanFunc func(ctx context.Context, req FilterRequest, content io.Reader, dst io.Writer) error
 
+// DelayFunc is consulted for smudge requests git allows to be delayed. It
+// returns a fetch function when producing the content would block (typically
+// on a download), or nil to smudge immediately. Fetch runs in the background;
+// once it returns, git is told the path is available and re-sends the smudge
+// request, which is then answered from the pointer seen the first time.
+type DelayFunc func(req FilterRequest, ptr []byte) (fetch func(ctx context.Context) error)
+
 // FilterRequest describes a single filter request from git.
 type FilterRequest struct {
-	// Command is "smudge" or "clean".
+	// Command is "smudge", "clean", or "list_available_blobs".
 	Command string
 	// Pathname is the repo-relative file path being processed.
 	Pathname string
+	// CanDelay is set when git accepts a delayed answer to this smudge.
+	CanDelay bool
+}
+
+// delayedSmudge is a smudge answered with status=delayed. Err is set once the
+// background fetch has failed.
+type delayedSmudge struct {
+	ptr []byte
+	err error
 }
 
 // GitFilter implements the git long-running filter process protocol v2.
@@ -49,9 +66,25 @@ type GitFilter struct {
 	out    io.Writer
 	smudge SmudgeFunc
 	clean  CleanFunc
+	delay  DelayFunc
 	logger *slog.Logger
+
+	// capabilities agreed during the handshake.
+	caps map[string]bool
+
+	// delayed smudges, keyed by pathname. available receives each pathname
+	// whose fetch has finished; inflight counts fetches not yet listed.
+	mu        sync.Mutex
+	delayed   map[string]*delayedSmudge
+	available chan string
+	inflight  int
+	fetchSem  chan struct{}
 }
 
+// DefaultDelayConcurrency bounds the background fetches started for delayed
+// smudges.
+const DefaultDelayConcurrency = 8
+
 // NewGitFilter creates a GitFilter that reads from in and writes to out.
 func NewGitFilter(in io.Reader, out io.Writer, logger *slog.Logger) *GitFilter {
 	return &GitFilter{
@@ -73,6 +106,19 @@ func (f *GitFilter) OnClean(fn CleanFunc) *GitFilter {
 	return f
 }
 
+// OnDelay enables the delay capability. At most concurrency fetches run at
+// once; values below 1 use DefaultDelayConcurrency.
+func (f *GitFilter) OnDelay(fn DelayFunc, concurrency int) *GitFilter {
+	if concurrency < 1 {
+		concurrency = DefaultDelayConcurrency
+	}
+	f.delay = fn
+	f.delayed = make(map[string]*delayedSmudge)
+	f.available = make(chan string)
+	f.fetchSem = make(chan struct{}, concurrency)
+	return f
+}
+
 // Run performs the capability handshake and then processes filter requests
 // until the underlying reader is exhausted or the context is cancelled.
 func (f *GitFilter) Run(ctx context.Context) error {
@@ -105,14 +151,14 @@ func (f *GitFilter) Run(ctx context.Context) error {
 //	PKT-LINE("git-filter-client\n")
 //	PKT-LINE("version=2\n")
 //	flush-pkt
-//	PKT-LINE("capability=clean\n") + PKT-LINE("capability=smudge\n") + flush-pkt
+//	PKT-LINE("capability=clean\n") + PKT-LINE("capability=smudge\n") + [PKT-LINE("capability=delay\n")] + flush-pkt
 //
 // filter → git:
 //
 //	PKT-LINE("git-filter-server\n")
 //	PKT-LINE("version=2\n")
 //	flush-pkt
-//	PKT-LINE("capability=clean\n") + PKT-LINE("capability=smudge\n") + flush-pkt
+//	the subset of git's capabilities we support + flush-pkt
 func (f *GitFilter) handshake() error {
 	// --- version negotiation from git ---
 	initMsg, err := f.pl.ReadPacketText()
@@ -137,12 +183,25 @@ func (f *GitFilter) handshake() error {
 	}
 
 	// --- read capabilities from git ---
-	if _, err := f.pl.ReadPacketList(); err != nil {
+	offered, err := f.pl.ReadPacketList()
+	if err != nil {
 		return fmt.Errorf("reading capabilities: %w", err)
 	}
 
-	// --- advertise our capabilities ---
-	return f.pl.WritePacketList([]string{"capability=clean", "capability=smudge"})
+	// --- advertise the ones we share ---
+	supported := []string{"capability=clean", "capability=smudge"}
+	if f.delay != nil {
+		supported = append(supported, "capability=delay")
+	}
+	f.caps = make(map[string]bool, len(supported))
+	var agreed []string
+	for _, capability := range supported {
+		if slices.Contains(offered, capability) {
+			f.caps[strings.TrimPrefix(capability, "capability=")] = true
+			agreed = append(agreed, capability)
+		}
+	}
+	return f.pl.WritePacketList(agreed)
 }
 
 // --------------------------------------------------------------------------
@@ -157,12 +216,14 @@ func (f *GitFilter) processOne(ctx context.Context) error {
 		return err
 	}
 	f.logger.Debug("Received filter request", "command", req.Command, "pathname", req.Pathname)
-	// Read content (between the delimiter and the trailing flush).
-	content, err := f.readContent()
-	if err != nil {
-		return fmt.Errorf("reading content for %s %s: %w", req.Command, req.Pathname, err)
+	if req.Command == "list_available_blobs" {
+		return f.listAvailableBlobs(ctx)
 	}
 
+	// Content is streamed to the handler; whatever it leaves unread is
+	// drained before answering so the next request starts on a packet boundary.
+	content := pktline.NewPktlineReaderFromPktline(f.pl, pktline.MaxPacketLength)
+
 	var handlerErr error
 	switch req.Command {
 	case "smudge":
@@ -171,46 +232,147 @@ func (f *GitFilter) processOne(ctx context.Context) error {
 		handlerErr = f.handleClean(ctx, req, content)
 	default:
 		// Unknown command: respond with error status and empty content.
-		handlerErr = fmt.Errorf("unknown command %q", req.Command)
-	}
-
-	if handlerErr != nil {
-		// Send error status; git will use the pointer/raw bytes itself.
-		if err := f.pl.WritePacketList([]string{"status=error"}); err != nil {
-			return err
+		if _, err := io.Copy(io.Discard, content); err != nil {
+			return fmt.Errorf("reading content for %s %s: %w", req.Command, req.Pathname, err)
 		}
-		return nil
+		handlerErr = f.pl.WritePacketList([]string{"status=error"})
 	}
-	return nil
+	return handlerErr
 }
 
-func (f *GitFilter) handleSmudge(ctx context.Context, req FilterRequest, content []byte) error {
+func (f *GitFilter) handleSmudge(ctx context.Context, req FilterRequest, content io.Reader) error {
+	// Pointers are small; read the whole payload so it can be kept for a
+	// delayed answer.
+	ptr, err := io.ReadAll(content)
+	if err != nil {
+		return fmt.Errorf("reading content for smudge %s: %w", req.Pathname, err)
+	}
 	if f.smudge == nil {
-		return f.passthroughSmudge(content)
+		return f.writeSuccessResponse(ptr)
 	}
 
-	var dst bytes.Buffer
-	if err := f.smudge(ctx, req, bytes.NewReader(content), &dst); err != nil {
-		return err
+	if pending, ok := f.takeDelayed(req.Pathname); ok {
+		if pending.err != nil {
+			f.logger.Warn("delayed smudge failed", "pathname", req.Pathname, "err", pending.err)
+			return f.pl.WritePacketList([]string{"status=error"})
+		}
+		ptr = pending.ptr
+	} else if req.CanDelay && f.caps["delay"] && f.delay != nil {
+		if fetch := f.delay(req, ptr); fetch != nil {
+			f.startDelayed(ctx, req.Pathname, ptr, fetch)
+			return f.pl.WritePacketList([]string{"status=delayed"})
+		}
 	}
-	return f.writeSuccessResponse(dst.Bytes())
+
+	rw := f.newResponseWriter()
+	smudgeErr := f.smudge(ctx, req, bytes.NewReader(ptr), rw)
+	if smudgeErr != nil {
+		f.logger.Debug("smudge failed", "pathname", req.Pathname, "err", smudgeErr)
+	}
+	return rw.finish(smudgeErr)
 }
 
-func (f *GitFilter) handleClean(ctx context.Context, req FilterRequest, content []byte) error {
+func (f *GitFilter) handleClean(ctx context.Context, req FilterRequest, content io.Reader) error {
 	if f.clean == nil {
-		return f.passthroughClean(content)
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return fmt.Errorf("reading content for clean %s: %w", req.Pathname, err)
+		}
+		return f.passthroughClean(data)
 	}
 
 	var dst bytes.Buffer
-	if err := f.clean(ctx, req, bytes.NewReader(content), &dst); err != nil {
-		return err
+	cleanErr := f.clean(ctx, req, content, &dst)
+	if _, err := io.Copy(io.Discard, content); err != nil {
+		return fmt.Errorf("reading content for clean %s: %w", req.Pathname, err)
+	}
+	if cleanErr != nil {
+		f.logger.Debug("clean failed", "pathname", req.Pathname, "err", cleanErr)
+		// Send error status; git will keep the raw bytes itself.
+		return f.pl.WritePacketList([]string{"status=error"})
 	}
 	return f.writeSuccessResponse(dst.Bytes())
 }
 
-// passthroughSmudge sends content as-is (smudge no-op).
-func (f *GitFilter) passthroughSmudge(content []byte) error {
-	return f.writeSuccessResponse(content)
+// --------------------------------------------------------------------------
+// Delayed smudge
+// --------------------------------------------------------------------------
+
+func (f *GitFilter) startDelayed(ctx context.Context, pathname string, ptr []byte, fetch func(context.Context) error) {
+	pending := &delayedSmudge{ptr: ptr}
+	f.mu.Lock()
+	f.delayed[pathname] = pending
+	f.inflight++
+	f.mu.Unlock()
+
+	go func() {
+		f.fetchSem <- struct{}{}
+		err := fetch(ctx)
+		<-f.fetchSem
+		if err != nil {
+			f.mu.Lock()
+			pending.err = err
+			f.mu.Unlock()
+		}
+		select {
+		case f.available <- pathname:
+		case <-ctx.Done():
+		}
+	}()
+}
+
+func (f *GitFilter) takeDelayed(pathname string) (delayedSmudge, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pending, ok := f.delayed[pathname]
+	if !ok {
+		return delayedSmudge{}, false
+	}
+	delete(f.delayed, pathname)
+	return *pending, true
+}
+
+// listAvailableBlobs answers list_available_blobs. It blocks until at least
+// one delayed fetch has finished, then lists every finished pathname. An
+// empty list tells git nothing more is pending.
+//
+//	git → filter: PKT-LINE("command=list_available_blobs\n") + flush-pkt
+//	filter → git: PKT-LINE("pathname=<path>\n")... + flush-pkt
+//	              PKT-LINE("status=success\n") + flush-pkt
+func (f *GitFilter) listAvailableBlobs(ctx context.Context) error {
+	var paths []string
+	f.mu.Lock()
+	inflight := f.inflight
+	f.mu.Unlock()
+	if inflight > 0 {
+		select {
+		case p := <-f.available:
+			paths = append(paths, p)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	drain:
+		for len(paths) < inflight {
+			select {
+			case p := <-f.available:
+				paths = append(paths, p)
+			default:
+				break drain
+			}
+		}
+		f.mu.Lock()
+		f.inflight -= len(paths)
+		f.mu.Unlock()
+	}
+
+	list := make([]string, 0, len(paths))
+	for _, p := range paths {
+		list = append(list, "pathname="+p)
+	}
+	if err := f.pl.WritePacketList(list); err != nil {
+		return err
+	}
+	return f.pl.WritePacketList([]string{"status=success"})
 }
 
 // passthroughClean sends content as-is (clean no-op).
@@ -228,22 +390,56 @@ func (f *GitFilter) passthroughClean(content []byte) error {
 //	flush-pkt
 //	flush-pkt  (second flush signals end of command)
 func (f *GitFilter) writeSuccessResponse(data []byte) error {
-	if err := f.pl.WritePacketList([]string{"status=success"}); err != nil {
-		return err
+	rw := f.newResponseWriter()
+	if len(data) > 0 {
+		if _, err := rw.Write(data); err != nil {
+			return err
+		}
 	}
+	return rw.finish(nil)
+}
 
-	w := pktline.NewPktlineWriter(f.out, pktline.MaxPacketLength)
-	if len(data) > 0 {
-		if _, err := w.Write(data); err != nil {
+// responseWriter streams a response body, sending status=success before the
+// first byte. finish closes the response; a handler error becomes
+// status=error, sent before the content when nothing was written yet and in
+// the trailing list otherwise, as the protocol allows.
+type responseWriter struct {
+	w       *pktline.PktlineWriter
+	pl      *pktline.Pktline
+	started bool
+}
+
+func (f *GitFilter) newResponseWriter() *responseWriter {
+	return &responseWriter{w: pktline.NewPktlineWriterFromPktline(f.pl, pktline.MaxPacketLength), pl: f.pl}
+}
+
+func (r *responseWriter) Write(p []byte) (int, error) {
+	if !r.started {
+		if err := r.pl.WritePacketList([]string{"status=success"}); err != nil {
+			return 0, err
+		}
+		r.started = true
+	}
+	return r.w.Write(p)
+}
+
+func (r *responseWriter) finish(handlerErr error) error {
+	if !r.started {
+		if handlerErr != nil {
+			return r.pl.WritePacketList([]string{"status=error"})
+		}
+		if err := r.pl.WritePacketList([]string{"status=success"}); err != nil {
 			return err
 		}
 	}
-	if err := w.Flush(); err != nil {
+	if err := r.w.Flush(); err != nil {
 		return err
 	}
-
+	if handlerErr != nil {
+		return r.pl.WritePacketList([]string{"status=error"})
+	}
 	// Send trailing key=value list (empty) terminated with flush.
-	return f.pl.WritePacketList(nil)
+	return r.pl.WritePacketList(nil)
 }
 
 // --------------------------------------------------------------------------
@@ -266,14 +462,10 @@ func (f *GitFilter) readRequest() (FilterRequest, error) {
 				req.Command = kv[1]
 			case "pathname":
 				req.Pathname = kv[1]
+			case "can-delay":
+				req.CanDelay = kv[1] == "1"
 			}
 		}
 	}
 	return req, nil
 }
-
-// readContent reads pkt-line data packets until a flush packet, returning all
-// data concatenated. git sends content flush-terminated.
-func (f *GitFilter) readContent() ([]byte, error) {
-	return io.ReadAll(pktline.NewPktlineReaderFromPktline(f.pl, pktline.MaxPacketLength))
-}
@@ -108,3 +108,176 @@ func TestGitFilter_ProtocolSmudgeFraming(t *testing.T) {
 		t.Fatalf("expected empty trailing list, got %v", trailingList)
 	}
 }
+
+func writeClientHandshake(t *testing.T, pl *pktline.Pktline, caps ...string) {
+	t.Helper()
+	if err := pl.WritePacketText("git-filter-client"); err != nil {
+		t.Fatalf("write client welcome: %v", err)
+	}
+	if err := pl.WritePacketList([]string{"version=2"}); err != nil {
+		t.Fatalf("write versions: %v", err)
+	}
+	if err := pl.WritePacketList(caps); err != nil {
+		t.Fatalf("write capabilities: %v", err)
+	}
+}
+
+func writeClientRequest(t *testing.T, in io.Writer, pl *pktline.Pktline, headers []string, content string) {
+	t.Helper()
+	if err := pl.WritePacketList(headers); err != nil {
+		t.Fatalf("write request headers: %v", err)
+	}
+	w := pktline.NewPktlineWriter(in, pktline.MaxPacketLength)
+	if content != "" {
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("write request content: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("flush request content: %v", err)
+	}
+}
+
+func readList(t *testing.T, pl *pktline.Pktline) []string {
+	t.Helper()
+	list, err := pl.ReadPacketList()
+	if err != nil {
+		t.Fatalf("read packet list: %v", err)
+	}
+	return list
+}
+
+func TestGitFilter_DelayedSmudge(t *testing.T) {
+	var in bytes.Buffer
+	inPL := pktline.NewPktline(nil, &in)
+	writeClientHandshake(t, inPL, "capability=clean", "capability=smudge", "capability=delay")
+	writeClientRequest(t, &in, inPL, []string{"command=smudge", "pathname=big.bin", "can-delay=1"}, "pointer\n")
+	if err := inPL.WritePacketList([]string{"command=list_available_blobs"}); err != nil {
+		t.Fatalf("write list request: %v", err)
+	}
+	writeClientRequest(t, &in, inPL, []string{"command=smudge", "pathname=big.bin"}, "")
+	if err := inPL.WritePacketList([]string{"command=list_available_blobs"}); err != nil {
+		t.Fatalf("write list request: %v", err)
+	}
+
+	fetched := false
+	var smudged []string
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	f := NewGitFilter(&in, &out, logger).
+		OnSmudge(func(ctx context.Context, req FilterRequest, ptr io.Reader, dst io.Writer) error {
+			payload, _ := io.ReadAll(ptr)
+			smudged = append(smudged, string(payload))
+			_, err := dst.Write([]byte("content"))
+			return err
+		}).
+		OnDelay(func(req FilterRequest, ptr []byte) func(context.Context) error {
+			return func(context.Context) error {
+				fetched = true
+				return nil
+			}
+		}, 1)
+
+	if err := f.Run(context.Background()); err != nil {
+		t.Fatalf("filter run failed: %v", err)
+	}
+	if !fetched {
+		t.Fatal("expected background fetch to run")
+	}
+	if len(smudged) != 1 || smudged[0] != "pointer\n" {
+		t.Fatalf("expected one smudge of the remembered pointer, got %q", smudged)
+	}
+
+	outPL := pktline.NewPktline(&out, nil)
+	readList(t, outPL)
+	if caps := readList(t, outPL); !reflect.DeepEqual(caps, []string{"capability=clean", "capability=smudge", "capability=delay"}) {
+		t.Fatalf("unexpected capabilities: %v", caps)
+	}
+	if got := readList(t, outPL); !reflect.DeepEqual(got, []string{"status=delayed"}) {
+		t.Fatalf("expected delayed status, got %v", got)
+	}
+	if got := readList(t, outPL); !reflect.DeepEqual(got, []string{"pathname=big.bin"}) {
+		t.Fatalf("unexpected available blobs: %v", got)
+	}
+	if got := readList(t, outPL); !reflect.DeepEqual(got, []string{"status=success"}) {
+		t.Fatalf("unexpected list status: %v", got)
+	}
+	if got := readList(t, outPL); !reflect.DeepEqual(got, []string{"status=success"}) {
+		t.Fatalf("unexpected smudge status: %v", got)
+	}
+	content, err := io.ReadAll(pktline.NewPktlineReaderFromPktline(outPL, pktline.MaxPacketLength))
+	if err != nil || string(content) != "content" {
+		t.Fatalf("unexpected smudge content %q: %v", content, err)
+	}
+	if got := readList(t, outPL); len(got) != 0 {
+		t.Fatalf("expected empty trailing list, got %v", got)
+	}
+	if got := readList(t, outPL); len(got) != 0 {
+		t.Fatalf("expected empty final blob list, got %v", got)
+	}
+	if got := readList(t, outPL); !reflect.DeepEqual(got, []string{"status=success"}) {
+		t.Fatalf("unexpected final list status: %v", got)
+	}
+}
+
+func TestGitFilter_NoDelayWithoutGitCapability(t *testing.T) {
+	var in bytes.Buffer
+	inPL := pktline.NewPktline(nil, &in)
+	writeClientHandshake(t, inPL, "capability=clean", "capability=smudge")
+
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	f := NewGitFilter(&in, &out, logger).OnDelay(func(FilterRequest, []byte) func(context.Context) error {
+		t.Fatal("delay should not be consulted")
+		return nil
+	}, 0)
+	if err := f.Run(context.Background()); err != nil {
+		t.Fatalf("filter run failed: %v", err)
+	}
+	outPL := pktline.NewPktline(&out, nil)
+	readList(t, outPL)
+	if caps := readList(t, outPL); !reflect.DeepEqual(caps, []string{"capability=clean", "capability=smudge"}) {
+		t.Fatalf("unexpected capabilities: %v", caps)
+	}
+}
+
+func TestGitFilter_CleanErrorDrainsContent(t *testing.T) {
+	var in bytes.Buffer
+	inPL := pktline.NewPktline(nil, &in)
+	writeClientHandshake(t, inPL, "capability=clean", "capability=smudge")
+	writeClientRequest(t, &in, inPL, []string{"command=clean", "pathname=a.bin"}, "first file content")
+	writeClientRequest(t, &in, inPL, []string{"command=clean", "pathname=b.bin"}, "second")
+
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	f := NewGitFilter(&in, &out, logger).OnClean(func(ctx context.Context, req FilterRequest, content io.Reader, dst io.Writer) error {
+		if req.Pathname == "a.bin" {
+			// Fail after a partial read; the filter must skip the rest.
+			_, _ = content.Read(make([]byte, 3))
+			return io.ErrUnexpectedEOF
+		}
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return err
+		}
+		_, err = dst.Write([]byte("pointer:" + string(data)))
+		return err
+	})
+	if err := f.Run(context.Background()); err != nil {
+		t.Fatalf("filter run failed: %v", err)
+	}
+
+	outPL := pktline.NewPktline(&out, nil)
+	readList(t, outPL)
+	readList(t, outPL)
+	if got := readList(t, outPL); !reflect.DeepEqual(got, []string{"status=error"}) {
+		t.Fatalf("expected error status for a.bin, got %v", got)
+	}
+	if got := readList(t, outPL); !reflect.DeepEqual(got, []string{"status=success"}) {
+		t.Fatalf("expected success status for b.bin, got %v", got)
+	}
+	content, err := io.ReadAll(pktline.NewPktlineReaderFromPktline(outPL, pktline.MaxPacketLength))
+	if err != nil || string(content) != "pointer:second" {
+		t.Fatalf("unexpected clean output %q: %v", content, err)
+	}
+}
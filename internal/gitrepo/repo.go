@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/projectdir"
 	"github.com/go-git/go-git/v5"
 )
 
@@ -30,15 +31,7 @@ func GetRepo() (*git.Repository, error) {
 
 // GitTopLevel returns the absolute path of the git repository root
 func GitTopLevel() (string, error) {
-	repo, err := GetRepo()
-	if err != nil {
-		return "", err
-	}
-	wt, err := repo.Worktree()
-	if err != nil {
-		return "", err
-	}
-	return wt.Filesystem.Root(), nil
+	return projectdir.Root()
 }
 
 // GetGitConfigString reads a string value from git config using the git command
@@ -53,6 +46,24 @@ func GetGitConfigString(key string) (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
+// GetGitConfigRegexp reads every git config key matching pattern, from all
+// scopes. Keys set more than once keep their last value; no match yields an
+// empty map.
+func GetGitConfigRegexp(pattern string) map[string]string {
+	values := map[string]string{}
+	out, err := exec.Command("git", "config", "--get-regexp", pattern).Output()
+	if err != nil {
+		return values
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		if key != "" {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
 // GetGitConfigInt reads an integer value from git config
 func GetGitConfigInt(key string, defaultValue int64) int64 {
 	valStr, err := GetGitConfigString(key)
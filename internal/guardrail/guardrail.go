@@ -0,0 +1,248 @@
+// Package guardrail enforces size and file-type limits on LFS objects before
+// they are committed or pushed, so an accidental multi-terabyte scratch file
+// is caught locally instead of after an upload.
+package guardrail
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// OverrideEnv skips every limit for one command when set to a true value.
+const OverrideEnv = "GIT_DRS_ALLOW_OVERSIZE"
+
+// Policy holds the configured limits. Zero values disable a limit.
+type Policy struct {
+	MaxFileSize int64
+	MaxPushSize int64
+	// AllowedExtensions are lower-cased suffixes with a leading dot, such as
+	// ".bam" or ".vcf.gz". An empty list allows every file.
+	AllowedExtensions []string
+	// AllowedMimeTypes are lower-cased media types or patterns such as
+	// "image/*". An empty list allows every type.
+	AllowedMimeTypes []string
+	// ProjectQuota is the storage budget for the remote project; exceeding it
+	// only warns.
+	ProjectQuota int64
+}
+
+// File is one LFS object considered by a check.
+type File struct {
+	Path string
+	Size int64
+	// MimeType is the file's detected media type; it is only needed when
+	// AllowedMimeTypes is set, and "" counts as application/octet-stream.
+	MimeType string
+}
+
+// Violation is one file or push that breaks a limit.
+type Violation struct {
+	Path   string
+	Size   int64
+	Reason string
+}
+
+// Error lists every violation found by a check.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString("git-drs limits exceeded:")
+	for _, v := range e.Violations {
+		if v.Path != "" {
+			fmt.Fprintf(&b, "\n  - %s (%s): %s", v.Path, HumanBytes(v.Size), v.Reason)
+		} else {
+			fmt.Fprintf(&b, "\n  - %s", v.Reason)
+		}
+	}
+	fmt.Fprintf(&b, "\nUntrack or remove these files, raise the drs.limits.* settings, or set %s=1 to proceed anyway.", OverrideEnv)
+	return b.String()
+}
+
+// Overridden reports whether OverrideEnv is set to a true value.
+func Overridden() bool {
+	ok, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(OverrideEnv)))
+	return err == nil && ok
+}
+
+// Enabled reports whether any blocking limit is configured.
+func (p Policy) Enabled() bool {
+	return p.MaxFileSize > 0 || p.MaxPushSize > 0 || len(p.AllowedExtensions) > 0 || len(p.AllowedMimeTypes) > 0
+}
+
+// WarningsOnly returns p without its blocking limits, for overridden runs.
+func (p Policy) WarningsOnly() Policy {
+	return Policy{ProjectQuota: p.ProjectQuota}
+}
+
+// CheckFiles applies the per-file limits and returns an *Error, or nil.
+func (p Policy) CheckFiles(files []File) error {
+	if v := p.fileViolations(files); len(v) > 0 {
+		return &Error{Violations: v}
+	}
+	return nil
+}
+
+// CheckPush applies the per-file limits and the push total to files, the
+// objects a push would upload.
+func (p Policy) CheckPush(files []File) error {
+	violations := p.fileViolations(files)
+	if p.MaxPushSize > 0 {
+		var total int64
+		for _, f := range files {
+			total += f.Size
+		}
+		if total > p.MaxPushSize {
+			violations = append(violations, Violation{
+				Reason: fmt.Sprintf("push uploads %s in %d objects, over drs.limits.max-push-size (%s)", HumanBytes(total), len(files), HumanBytes(p.MaxPushSize)),
+			})
+		}
+	}
+	if len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+	return nil
+}
+
+func (p Policy) fileViolations(files []File) []Violation {
+	var out []Violation
+	for _, f := range files {
+		if p.MaxFileSize > 0 && f.Size > p.MaxFileSize {
+			out = append(out, Violation{Path: f.Path, Size: f.Size, Reason: fmt.Sprintf("larger than drs.limits.max-file-size (%s)", HumanBytes(p.MaxFileSize))})
+		}
+		if len(p.AllowedExtensions) > 0 && !p.extensionAllowed(f.Path) {
+			out = append(out, Violation{Path: f.Path, Size: f.Size, Reason: fmt.Sprintf("extension not in drs.limits.allowed-extensions (%s)", strings.Join(p.AllowedExtensions, ", "))})
+		}
+		if len(p.AllowedMimeTypes) > 0 {
+			mimeType := f.MimeType
+			if mimeType == "" {
+				mimeType = "application/octet-stream"
+			}
+			if !p.MimeTypeAllowed(mimeType) {
+				out = append(out, Violation{Path: f.Path, Size: f.Size, Reason: fmt.Sprintf("MIME type %s not in drs.limits.allowed-mime-types (%s)", mimeType, strings.Join(p.AllowedMimeTypes, ", "))})
+			}
+		}
+	}
+	return out
+}
+
+func (p Policy) extensionAllowed(file string) bool {
+	name := strings.ToLower(path.Base(file))
+	for _, ext := range p.AllowedExtensions {
+		if strings.HasSuffix(name, ext) && len(name) > len(ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// MimeTypeAllowed reports whether mimeType matches an AllowedMimeTypes entry.
+func (p Policy) MimeTypeAllowed(mimeType string) bool {
+	mimeType = strings.ToLower(mimeType)
+	for _, pattern := range p.AllowedMimeTypes {
+		if ok, _ := path.Match(pattern, mimeType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// QuotaWarning returns a warning when adding incoming bytes to used would go
+// over the project quota, or "" when it would not or no quota is set.
+func (p Policy) QuotaWarning(project string, used, incoming int64) string {
+	if p.ProjectQuota <= 0 || used+incoming <= p.ProjectQuota {
+		return ""
+	}
+	return fmt.Sprintf("project %s would use %s of its %s quota after this push (%s already stored, %s new)",
+		project, HumanBytes(used+incoming), HumanBytes(p.ProjectQuota), HumanBytes(used), HumanBytes(incoming))
+}
+
+// ParseSize parses a byte count with an optional k, m, g, or t suffix
+// (powers of 1024, as git config does), optionally followed by "b" or "ib":
+// "500m", "10GiB", "2T", "1048576".
+func ParseSize(raw string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	if s == "" {
+		return 0, nil
+	}
+	s = strings.TrimSuffix(s, "ib")
+	s = strings.TrimSuffix(s, "b")
+	mult := int64(1)
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'k':
+			mult = 1 << 10
+		case 'm':
+			mult = 1 << 20
+		case 'g':
+			mult = 1 << 30
+		case 't':
+			mult = 1 << 40
+		}
+		if mult > 1 {
+			s = s[:n-1]
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q: expected a byte count such as 500m or 10g", raw)
+	}
+	if n > (1<<63-1)/mult {
+		return 0, fmt.Errorf("invalid size %q: too large", raw)
+	}
+	return n * mult, nil
+}
+
+// ParseExtensions splits a comma- or space-separated extension list,
+// lower-casing entries and adding a leading dot where missing.
+func ParseExtensions(raw string) []string {
+	var out []string
+	for _, item := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' }) {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" || item == "." {
+			continue
+		}
+		if !strings.HasPrefix(item, ".") {
+			item = "." + item
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// ParseMimeTypes splits a comma- or space-separated list of media types or
+// patterns such as "image/*", lower-casing entries and rejecting malformed
+// ones.
+func ParseMimeTypes(raw string) ([]string, error) {
+	var out []string
+	for _, item := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' }) {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		if _, err := path.Match(item, ""); err != nil || strings.Count(item, "/") != 1 {
+			return nil, fmt.Errorf("invalid MIME type %q: expected a type such as text/csv or image/*", item)
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// HumanBytes formats n with a binary unit.
+func HumanBytes(n int64) string {
+	const unit = int64(1024)
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := unit, 0
+	for q := n / unit; q >= unit; q /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,121 @@
+package guardrail
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{
+		"":        0,
+		"1048576": 1 << 20,
+		"500m":    500 << 20,
+		"10GiB":   10 << 30,
+		"2T":      2 << 40,
+		"4kb":     4 << 10,
+	}
+	for in, want := range cases {
+		got, err := ParseSize(in)
+		if err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"ten", "-1g", "5x", "99999999999t"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestParseExtensions(t *testing.T) {
+	got := ParseExtensions("bam, .VCF.gz  cram,")
+	want := []string{".bam", ".vcf.gz", ".cram"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("ParseExtensions = %v, want %v", got, want)
+	}
+}
+
+func TestCheckFiles(t *testing.T) {
+	p := Policy{MaxFileSize: 1 << 30, AllowedExtensions: []string{".bam", ".vcf.gz"}}
+	err := p.CheckFiles([]File{
+		{Path: "data/ok.bam", Size: 10},
+		{Path: "data/calls.VCF.GZ", Size: 10},
+		{Path: "scratch/dump.tar", Size: 2 << 40},
+		{Path: "data/.bam", Size: 1},
+	})
+	var gerr *Error
+	if !errors.As(err, &gerr) {
+		t.Fatalf("CheckFiles error = %v, want *Error", err)
+	}
+	if len(gerr.Violations) != 3 {
+		t.Fatalf("violations = %+v, want size and extension for dump.tar and extension for .bam", gerr.Violations)
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "scratch/dump.tar (2.0 TiB): larger than drs.limits.max-file-size (1.0 GiB)") || !strings.Contains(msg, OverrideEnv) {
+		t.Fatalf("error message = %q", msg)
+	}
+}
+
+func TestParseMimeTypes(t *testing.T) {
+	got, err := ParseMimeTypes("text/CSV, image/*  application/pdf")
+	want := []string{"text/csv", "image/*", "application/pdf"}
+	if err != nil || strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("ParseMimeTypes = %v, %v; want %v", got, err, want)
+	}
+	for _, in := range []string{"csv", "image/[", "a/b/c"} {
+		if _, err := ParseMimeTypes(in); err == nil {
+			t.Errorf("ParseMimeTypes(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestCheckFilesMimeTypes(t *testing.T) {
+	p := Policy{AllowedMimeTypes: []string{"image/*", "text/csv"}}
+	err := p.CheckFiles([]File{
+		{Path: "fig.png", Size: 1, MimeType: "image/png"},
+		{Path: "table.csv", Size: 1, MimeType: "TEXT/CSV"},
+		{Path: "tool.exe", Size: 1},
+	})
+	if err == nil || !strings.Contains(err.Error(), "tool.exe (1 B): MIME type application/octet-stream not in drs.limits.allowed-mime-types (image/*, text/csv)") {
+		t.Fatalf("CheckFiles error = %v", err)
+	}
+	if strings.Contains(err.Error(), "fig.png") || strings.Contains(err.Error(), "table.csv") {
+		t.Fatalf("allowed types rejected: %v", err)
+	}
+}
+
+func TestCheckPushTotal(t *testing.T) {
+	p := Policy{MaxPushSize: 100}
+	if err := p.CheckPush([]File{{Path: "a", Size: 60}, {Path: "b", Size: 40}}); err != nil {
+		t.Fatalf("push at the limit: %v", err)
+	}
+	err := p.CheckPush([]File{{Path: "a", Size: 60}, {Path: "b", Size: 41}})
+	if err == nil || !strings.Contains(err.Error(), "push uploads 101 B in 2 objects") {
+		t.Fatalf("CheckPush error = %v", err)
+	}
+	if p.WarningsOnly().CheckPush([]File{{Path: "a", Size: 1 << 40}}) != nil {
+		t.Fatal("WarningsOnly policy blocked a push")
+	}
+}
+
+func TestQuotaWarning(t *testing.T) {
+	p := Policy{ProjectQuota: 1 << 30}
+	if w := p.QuotaWarning("org/proj", 512<<20, 256<<20); w != "" {
+		t.Fatalf("unexpected warning %q", w)
+	}
+	if w := p.QuotaWarning("org/proj", 900<<20, 200<<20); !strings.Contains(w, "org/proj would use 1.1 GiB of its 1.0 GiB quota") {
+		t.Fatalf("warning = %q", w)
+	}
+}
+
+func TestOverridden(t *testing.T) {
+	t.Setenv(OverrideEnv, "1")
+	if !Overridden() {
+		t.Fatal("Overridden() = false with env set")
+	}
+	t.Setenv(OverrideEnv, "no")
+	if Overridden() {
+		t.Fatal("Overridden() = true with env false")
+	}
+}
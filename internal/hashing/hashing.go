@@ -0,0 +1,190 @@
+// Package hashing computes sha256 LFS oids for local files using a worker
+// pool sized to the CPU count, with optional mmap reads and progress output,
+// and for remote objects through parallel, resumable ranged reads.
+package hashing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// chunkSize is the read size for buffered hashing and the progress
+// granularity for mmap hashing.
+const chunkSize = 4 << 20
+
+// Options controls HashFiles.
+type Options struct {
+	// Workers is the number of files hashed concurrently; zero or less uses
+	// runtime.NumCPU().
+	Workers int
+	// Mmap maps files into memory instead of reading them, where supported.
+	Mmap bool
+	// Progress receives a progress line while hashing; nil disables output.
+	Progress io.Writer
+}
+
+// Result is the oid and size of one hashed file.
+type Result struct {
+	Path string
+	OID  string
+	Size int64
+}
+
+// HashFiles hashes paths concurrently and returns results in input order.
+// The first error cancels the remaining work.
+func HashFiles(ctx context.Context, paths []string, opts Options) ([]Result, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	var total int64
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		total += info.Size()
+	}
+	progress := newReporter(opts.Progress, len(paths), total)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make([]Result, len(paths))
+	var (
+		firstErr error
+		errOnce  sync.Once
+	)
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(paths); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				oid, size, err := hashFile(ctx, paths[i], opts.Mmap, progress.add)
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = fmt.Errorf("hash %s: %w", paths[i], err)
+						cancel()
+					})
+					continue
+				}
+				results[i] = Result{Path: paths[i], OID: oid, Size: size}
+				progress.fileDone()
+			}
+		}()
+	}
+feed:
+	for i := range paths {
+		select {
+		case work <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+	progress.finish()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// HashFile returns the sha256 oid and size of a single file.
+func HashFile(path string, useMmap bool) (string, int64, error) {
+	return hashFile(context.Background(), path, useMmap, nil)
+}
+
+func hashFile(ctx context.Context, path string, useMmap bool, onRead func(int64)) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+	if useMmap && info.Size() > 0 {
+		data, unmap, err := mmapFile(f, info.Size())
+		if err == nil {
+			oid, faulted, err := hashMapped(ctx, data, onRead)
+			unmap()
+			if err != nil {
+				return "", 0, err
+			}
+			// A file truncated while mapped faults instead of reading short,
+			// and one that changed size may have been hashed partly before
+			// and partly after the change; both are re-read with read(2).
+			if after, err := f.Stat(); !faulted && err == nil && after.Size() == info.Size() {
+				return oid, info.Size(), nil
+			}
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return "", 0, err
+			}
+		}
+	}
+
+	h := sha256.New()
+	buf := make([]byte, chunkSize)
+	var size int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", 0, err
+		}
+		n, err := f.Read(buf)
+		if n > 0 {
+			h.Write(buf[:n])
+			size += int64(n)
+			if onRead != nil {
+				onRead(int64(n))
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", 0, err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// hashMapped hashes a mapped file. faulted reports that a page could not be
+// read, which happens when the file shrinks underneath the mapping; the
+// fault is recovered rather than crashing the process with SIGBUS.
+func hashMapped(ctx context.Context, data []byte, onRead func(int64)) (oid string, faulted bool, err error) {
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(interface{ Addr() uintptr }); !ok {
+				panic(r)
+			}
+			oid, faulted, err = "", true, nil
+		}
+	}()
+	h := sha256.New()
+	for off := 0; off < len(data); off += chunkSize {
+		if err := ctx.Err(); err != nil {
+			return "", false, err
+		}
+		end := min(off+chunkSize, len(data))
+		h.Write(data[off:end])
+		if onRead != nil {
+			onRead(int64(end - off))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), false, nil
+}
@@ -0,0 +1,126 @@
+package hashing
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHashFilesMatchesSha256InOrder(t *testing.T) {
+	dir := t.TempDir()
+	contents := []string{"alpha", "", strings.Repeat("x", chunkSize+17), "beta"}
+	paths := make([]string, len(contents))
+	for i, c := range contents {
+		paths[i] = filepath.Join(dir, string(rune('a'+i)))
+		if err := os.WriteFile(paths[i], []byte(c), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	for _, useMmap := range []bool{false, true} {
+		var progress bytes.Buffer
+		results, err := HashFiles(context.Background(), paths, Options{Workers: 3, Mmap: useMmap, Progress: &progress})
+		if err != nil {
+			t.Fatalf("HashFiles(mmap=%v): %v", useMmap, err)
+		}
+		for i, c := range contents {
+			sum := sha256.Sum256([]byte(c))
+			want := hex.EncodeToString(sum[:])
+			if results[i].Path != paths[i] || results[i].OID != want || results[i].Size != int64(len(c)) {
+				t.Fatalf("mmap=%v result %d = %+v, want oid %s size %d", useMmap, i, results[i], want, len(c))
+			}
+		}
+		if !strings.Contains(progress.String(), "(4/4 files)") {
+			t.Fatalf("expected final progress line, got %q", progress.String())
+		}
+	}
+}
+
+func TestHashFilesReportsMissingFile(t *testing.T) {
+	_, err := HashFiles(context.Background(), []string{filepath.Join(t.TempDir(), "missing")}, Options{})
+	if err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestHashRangesResumesFromCheckpoint(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789", 1000))
+	want := sha256.Sum256(data)
+	checkpoint := filepath.Join(t.TempDir(), "cp", "obj.json")
+
+	failAt := int64(4096)
+	open := func(_ context.Context, offset, length int64) (io.ReadCloser, error) {
+		if offset >= failAt {
+			return nil, errors.New("connection reset")
+		}
+		return io.NopCloser(bytes.NewReader(data[offset : offset+length])), nil
+	}
+	opts := RangeOptions{PartSize: 1024, Workers: 3, Checkpoint: checkpoint, Identity: "s3://b/k|etag"}
+	if _, err := HashRanges(context.Background(), int64(len(data)), open, opts); err == nil {
+		t.Fatal("expected interrupted hash to fail")
+	}
+	if _, err := os.Stat(checkpoint); err != nil {
+		t.Fatalf("expected checkpoint after interruption: %v", err)
+	}
+
+	var reads atomic.Int32
+	failAt = int64(len(data))
+	resumed := func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		if offset < 4096 {
+			t.Errorf("resumed run re-read offset %d", offset)
+		}
+		reads.Add(1)
+		return open(ctx, offset, length)
+	}
+	got, err := HashRanges(context.Background(), int64(len(data)), resumed, opts)
+	if err != nil {
+		t.Fatalf("HashRanges: %v", err)
+	}
+	if got != hex.EncodeToString(want[:]) {
+		t.Fatalf("sha mismatch: got %s", got)
+	}
+	if reads.Load() != 6 {
+		t.Fatalf("expected 6 remaining parts, read %d", reads.Load())
+	}
+	if _, err := os.Stat(checkpoint); !os.IsNotExist(err) {
+		t.Fatalf("checkpoint not removed: %v", err)
+	}
+
+	// A checkpoint for another identity is ignored.
+	opts.Identity = "s3://b/k|other"
+	if got, err := HashRanges(context.Background(), int64(len(data)), open, opts); err != nil || got != hex.EncodeToString(want[:]) {
+		t.Fatalf("fresh hash: %s %v", got, err)
+	}
+}
+
+func TestHashFileSurvivesTruncationWhileMapped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shrinking")
+	content := []byte(strings.Repeat("y", 3*chunkSize))
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	truncated := false
+	oid, size, err := hashFile(context.Background(), path, true, func(int64) {
+		if !truncated {
+			truncated = true
+			if err := os.Truncate(path, chunkSize); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatalf("hashFile error: %v", err)
+	}
+	sum := sha256.Sum256(content[:chunkSize])
+	if oid != hex.EncodeToString(sum[:]) || size != chunkSize {
+		t.Fatalf("expected the truncated content to be re-read, got %s size %d", oid, size)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package hashing
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapFile is unsupported here; callers fall back to buffered reads.
+func mmapFile(*os.File, int64) ([]byte, func() error, error) {
+	return nil, nil, errors.New("mmap not supported on this platform")
+}
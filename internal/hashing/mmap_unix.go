@@ -0,0 +1,19 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package hashing
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile maps f privately, so writes by other processes after the mapping
+// are not guaranteed to be seen. Pages past a concurrent truncation still
+// fault; hashMapped recovers from that and the caller re-reads the file.
+func mmapFile(f *os.File, size int64) ([]byte, func() error, error) {
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
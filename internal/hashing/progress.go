@@ -0,0 +1,63 @@
+package hashing
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/calypr/git-drs/internal/progressui"
+)
+
+// reporter renders aggregate hashing progress. A nil reporter is silent.
+type reporter struct {
+	mu        sync.Mutex
+	base      *progressui.Renderer
+	files     int
+	doneFiles int
+	total     int64
+	doneBytes int64
+}
+
+func newReporter(out io.Writer, files int, total int64) *reporter {
+	if out == nil || files == 0 {
+		return nil
+	}
+	return &reporter{base: progressui.NewRenderer(out), files: files, total: total}
+}
+
+func (r *reporter) add(n int64) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.doneBytes += n
+	r.base.Render(false, []string{r.line(false)})
+}
+
+func (r *reporter) fileDone() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.doneFiles++
+	r.base.Render(false, []string{r.line(false)})
+}
+
+func (r *reporter) finish() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.base.Finish([]string{r.line(r.doneFiles == r.files)})
+}
+
+func (r *reporter) line(completed bool) string {
+	return fmt.Sprintf("Hashing %s %s %s (%d/%d files)",
+		progressui.RenderProgressBar(r.doneBytes, r.total, 24),
+		progressui.RenderPercentCapped(r.doneBytes, r.total, completed),
+		progressui.RenderByteProgress(progressui.VisibleProgressBytes(r.doneBytes, r.total, completed), r.total, completed),
+		r.doneFiles, r.files)
+}
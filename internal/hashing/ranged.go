@@ -0,0 +1,193 @@
+package hashing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/calypr/git-drs/internal/common"
+)
+
+// DefaultPartSize is the ranged read size used when RangeOptions.PartSize is
+// unset.
+const DefaultPartSize = 16 << 20
+
+// defaultRangeWorkers is the number of parts fetched ahead of the hasher.
+const defaultRangeWorkers = 4
+
+// RangeReader opens length bytes of an object starting at offset.
+type RangeReader func(ctx context.Context, offset, length int64) (io.ReadCloser, error)
+
+// RangeOptions controls HashRanges.
+type RangeOptions struct {
+	// PartSize is the size of each ranged read; zero or less uses
+	// DefaultPartSize.
+	PartSize int64
+	// Workers is the number of parts fetched concurrently; zero or less uses
+	// four. Memory use is bounded by Workers*PartSize.
+	Workers int
+	// Progress receives a progress line while hashing; nil disables output.
+	Progress io.Writer
+	// Checkpoint, when set, is a file that records the hash state after each
+	// part so an interrupted run resumes where it stopped. It is removed on
+	// success.
+	Checkpoint string
+	// Identity distinguishes versions of the object (for example its URL and
+	// ETag). A checkpoint written for a different identity is discarded.
+	Identity string
+}
+
+// checkpoint is the on-disk resume state for HashRanges.
+type checkpoint struct {
+	Identity string `json:"identity"`
+	Size     int64  `json:"size"`
+	Offset   int64  `json:"offset"`
+	State    []byte `json:"state"`
+}
+
+type rangePart struct {
+	data []byte
+	err  error
+}
+
+// HashRanges computes the sha256 of a size-byte object by fetching parts in
+// parallel and hashing them in order.
+func HashRanges(ctx context.Context, size int64, open RangeReader, opts RangeOptions) (string, error) {
+	partSize := opts.PartSize
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultRangeWorkers
+	}
+
+	h := sha256.New()
+	offset := loadCheckpoint(opts, size, h)
+	progress := newReporter(opts.Progress, 1, size)
+	progress.add(offset)
+	defer progress.finish()
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	first := offset / partSize
+	parts := (size + partSize - 1) / partSize
+	results := make([]chan rangePart, parts)
+	for i := first; i < parts; i++ {
+		results[i] = make(chan rangePart, 1)
+	}
+	slots := make(chan struct{}, workers)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := first; i < parts; i++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			start := i * partSize
+			length := min(partSize, size-start)
+			wg.Add(1)
+			go func(out chan<- rangePart) {
+				defer wg.Done()
+				data, err := readRange(ctx, open, start, length)
+				out <- rangePart{data: data, err: err}
+			}(results[i])
+		}
+	}()
+
+	for i := first; i < parts; i++ {
+		var part rangePart
+		select {
+		case part = <-results[i]:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		<-slots
+		if part.err != nil {
+			return "", fmt.Errorf("read bytes %d-%d: %w", i*partSize, i*partSize+int64(len(part.data)), part.err)
+		}
+		h.Write(part.data)
+		offset += int64(len(part.data))
+		progress.add(int64(len(part.data)))
+		if err := saveCheckpoint(opts, size, offset, h); err != nil {
+			return "", err
+		}
+	}
+	progress.fileDone()
+
+	if opts.Checkpoint != "" {
+		_ = os.Remove(opts.Checkpoint)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func readRange(ctx context.Context, open RangeReader, offset, length int64) ([]byte, error) {
+	rc, err := open(ctx, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data := make([]byte, length)
+	n, err := io.ReadFull(rc, data)
+	if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			return data[:n], fmt.Errorf("short read: got %d of %d bytes", n, length)
+		}
+		return data[:n], err
+	}
+	return data, nil
+}
+
+// loadCheckpoint restores h from a matching checkpoint and returns the offset
+// to resume from. Unreadable or stale checkpoints start from zero.
+func loadCheckpoint(opts RangeOptions, size int64, h hash.Hash) int64 {
+	if opts.Checkpoint == "" {
+		return 0
+	}
+	raw, err := os.ReadFile(opts.Checkpoint)
+	if err != nil {
+		return 0
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(raw, &cp); err != nil || cp.Identity != opts.Identity || cp.Size != size || cp.Offset <= 0 || cp.Offset > size {
+		return 0
+	}
+	if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(cp.State); err != nil {
+		h.Reset()
+		return 0
+	}
+	return cp.Offset
+}
+
+func saveCheckpoint(opts RangeOptions, size, offset int64, h hash.Hash) error {
+	if opts.Checkpoint == "" || offset >= size {
+		return nil
+	}
+	state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(checkpoint{Identity: opts.Identity, Size: size, Offset: offset, State: state})
+	if err != nil {
+		return err
+	}
+	if err := common.WriteFileAtomic(opts.Checkpoint, raw, 0o644); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	return nil
+}
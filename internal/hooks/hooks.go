@@ -0,0 +1,247 @@
+// Package hooks runs site-defined scripts around git-drs lifecycle events,
+// so a site can stamp extra metadata on records or notify a LIMS without
+// forking git-drs.
+//
+// A hook is a shell command configured per event:
+//
+//	drs.hook.<event>.command      run with sh -c; the JSON Payload arrives on stdin
+//	drs.hook.<event>.timeout      how long the command may run (default 30s)
+//	drs.hook.<event>.on-failure   fail, warn, or ignore (default fail for pre-
+//	                              hooks, warn for post- hooks)
+//
+// A pre-register hook may print a Result on stdout to add a description,
+// MIME type, or aliases to the records about to be registered. Other hooks'
+// stdout is copied to stderr, since stdout may belong to a git filter.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// Events hooks can be attached to.
+const (
+	PreRegister  = "pre-register"
+	PostRegister = "post-register"
+	PreUpload    = "pre-upload"
+	PostDownload = "post-download"
+)
+
+// Failure policies.
+const (
+	FailurePolicyFail   = "fail"
+	FailurePolicyWarn   = "warn"
+	FailurePolicyIgnore = "ignore"
+)
+
+// DefaultTimeout bounds a hook without drs.hook.<event>.timeout.
+const DefaultTimeout = 30 * time.Second
+
+// Hook is the configuration of one event's script.
+type Hook struct {
+	Command   string
+	Timeout   time.Duration
+	OnFailure string
+}
+
+// Object describes one file in a Payload.
+type Object struct {
+	OID string `json:"oid"`
+	// Path is the file's repository path, when known.
+	Path string `json:"path,omitempty"`
+	// File is where a post-download hook finds the downloaded content.
+	File        string   `json:"file,omitempty"`
+	Size        int64    `json:"size"`
+	DRSID       string   `json:"drs_id,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	MimeType    string   `json:"mime_type,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+// ObjectFor describes obj, the record for the file at path. obj may be nil
+// when there is no record yet.
+func ObjectFor(obj *drsapi.DrsObject, oid, path string) Object {
+	o := Object{OID: oid, Path: path}
+	if obj == nil {
+		return o
+	}
+	o.Size, o.DRSID, o.Aliases = obj.Size, obj.Id, drsobject.Aliases(*obj)
+	if obj.Name != nil {
+		o.Name = *obj.Name
+	}
+	if obj.Description != nil {
+		o.Description = *obj.Description
+	}
+	if obj.MimeType != nil {
+		o.MimeType = *obj.MimeType
+	}
+	return o
+}
+
+// Payload is the JSON document a hook reads on stdin.
+type Payload struct {
+	Event        string   `json:"event"`
+	Remote       string   `json:"remote,omitempty"`
+	Organization string   `json:"organization,omitempty"`
+	Project      string   `json:"project,omitempty"`
+	Objects      []Object `json:"objects"`
+}
+
+// Stamp is metadata a pre-register hook adds to the record for OID.
+// Aliases are added to the record's own; empty fields are left unchanged.
+type Stamp struct {
+	OID         string   `json:"oid"`
+	Description string   `json:"description,omitempty"`
+	MimeType    string   `json:"mime_type,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+// Result is what a pre-register hook may print on stdout.
+type Result struct {
+	Objects []Stamp `json:"objects"`
+}
+
+// Load reads the hooks configured in git config, keyed by event.
+func Load() (map[string]Hook, error) {
+	hooks := map[string]Hook{}
+	for key, value := range gitrepo.GetGitConfigRegexp(`^drs\.hook\.`) {
+		rest := strings.TrimPrefix(key, "drs.hook.")
+		dot := strings.LastIndex(rest, ".")
+		if dot < 0 {
+			continue
+		}
+		event, field := rest[:dot], rest[dot+1:]
+		switch event {
+		case PreRegister, PostRegister, PreUpload, PostDownload:
+		default:
+			return nil, fmt.Errorf("%s: unknown hook event %q; expected %s, %s, %s, or %s", key, event, PreRegister, PostRegister, PreUpload, PostDownload)
+		}
+		h := hooks[event]
+		value = strings.TrimSpace(value)
+		switch field {
+		case "command":
+			h.Command = value
+		case "timeout":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid %s %q: expected a duration such as 30s", key, value)
+			}
+			h.Timeout = d
+		case "on-failure":
+			switch value {
+			case FailurePolicyFail, FailurePolicyWarn, FailurePolicyIgnore:
+				h.OnFailure = value
+			default:
+				return nil, fmt.Errorf("invalid %s %q: expected fail, warn, or ignore", key, value)
+			}
+		default:
+			return nil, fmt.Errorf("%s: unknown hook setting %q; expected command, timeout, or on-failure", key, field)
+		}
+		hooks[event] = h
+	}
+	for event, h := range hooks {
+		if h.Command == "" {
+			delete(hooks, event)
+			continue
+		}
+		if h.Timeout == 0 {
+			h.Timeout = DefaultTimeout
+		}
+		if h.OnFailure == "" {
+			h.OnFailure = FailurePolicyWarn
+			if strings.HasPrefix(event, "pre-") {
+				h.OnFailure = FailurePolicyFail
+			}
+		}
+		hooks[event] = h
+	}
+	return hooks, nil
+}
+
+// Configured returns the process's hooks, read from git config once.
+var Configured = sync.OnceValues(Load)
+
+// runCommand is swapped in tests.
+var runCommand = func(ctx context.Context, h Hook, event string, stdin []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Env = append(os.Environ(), "GIT_DRS_HOOK="+event)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stderr = os.Stderr
+	var stdout bytes.Buffer
+	if event == PreRegister {
+		cmd.Stdout = &stdout
+	} else {
+		cmd.Stdout = os.Stderr
+	}
+	// Do not wait forever on pipes held open by the hook's children.
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	return stdout.Bytes(), err
+}
+
+// Run runs the hook configured for p.Event, if any. A failing hook returns
+// an error only under the fail policy; otherwise it is logged or ignored and
+// Run returns an empty Result.
+func Run(ctx context.Context, p Payload) (Result, error) {
+	hooks, err := Configured()
+	if err != nil {
+		return Result{}, err
+	}
+	h, ok := hooks[p.Event]
+	if !ok {
+		return Result{}, nil
+	}
+	if p.Objects == nil {
+		p.Objects = []Object{}
+	}
+	res, err := run(ctx, h, p)
+	if err == nil {
+		return res, nil
+	}
+	err = fmt.Errorf("%s hook: %w", p.Event, err)
+	switch h.OnFailure {
+	case FailurePolicyFail:
+		return Result{}, err
+	case FailurePolicyWarn:
+		drslog.GetLogger().Warn("hook failed; continuing", "event", p.Event, "error", err)
+	}
+	return Result{}, nil
+}
+
+func run(ctx context.Context, h Hook, p Payload) (Result, error) {
+	stdin, err := json.Marshal(p)
+	if err != nil {
+		return Result{}, err
+	}
+	hookCtx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+	out, err := runCommand(hookCtx, h, p.Event, stdin)
+	if err != nil {
+		if errors.Is(hookCtx.Err(), context.DeadlineExceeded) {
+			return Result{}, fmt.Errorf("timed out after %s", h.Timeout)
+		}
+		return Result{}, err
+	}
+	var res Result
+	if len(bytes.TrimSpace(out)) == 0 {
+		return res, nil
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		return Result{}, fmt.Errorf("parse output: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,143 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupRepo(t *testing.T, config ...string) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	if err := exec.Command("git", "init").Run(); err != nil {
+		t.Fatalf("git init failed: %v", err)
+	}
+	for i := 0; i+1 < len(config); i += 2 {
+		if err := exec.Command("git", "config", config[i], config[i+1]).Run(); err != nil {
+			t.Fatalf("git config %s: %v", config[i], err)
+		}
+	}
+}
+
+func useHooks(t *testing.T, hooks map[string]Hook) {
+	t.Helper()
+	orig := Configured
+	t.Cleanup(func() { Configured = orig })
+	Configured = func() (map[string]Hook, error) { return hooks, nil }
+}
+
+func TestLoad(t *testing.T) {
+	setupRepo(t,
+		"drs.hook.pre-register.command", "./stamp.sh",
+		"drs.hook.post-download.command", "notify-lims",
+		"drs.hook.post-download.timeout", "5s",
+		"drs.hook.pre-upload.timeout", "5s",
+	)
+	hooks, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := map[string]Hook{
+		PreRegister:  {Command: "./stamp.sh", Timeout: DefaultTimeout, OnFailure: FailurePolicyFail},
+		PostDownload: {Command: "notify-lims", Timeout: 5 * time.Second, OnFailure: FailurePolicyWarn},
+	}
+	if len(hooks) != len(want) || hooks[PreRegister] != want[PreRegister] || hooks[PostDownload] != want[PostDownload] {
+		t.Fatalf("Load = %+v, want %+v", hooks, want)
+	}
+
+	for _, tc := range [][]string{
+		{"drs.hook.post-push.command", "x"},
+		{"drs.hook.pre-upload.timeout", "soon"},
+		{"drs.hook.pre-upload.on-failure", "retry"},
+	} {
+		setupRepo(t, tc...)
+		if _, err := Load(); err == nil {
+			t.Errorf("%s=%s: expected an error", tc[0], tc[1])
+		}
+	}
+}
+
+func TestRunPassesPayloadAndReadsStamps(t *testing.T) {
+	dir := t.TempDir()
+	seen := filepath.Join(dir, "payload.json")
+	useHooks(t, map[string]Hook{
+		PreRegister: {
+			Command:   `cat > ` + seen + ` && echo '{"objects":[{"oid":"abc","description":"from LIMS"}]}'`,
+			Timeout:   5 * time.Second,
+			OnFailure: FailurePolicyFail,
+		},
+	})
+
+	res, err := Run(context.Background(), Payload{Event: PreRegister, Remote: "origin", Objects: []Object{{OID: "abc", Path: "data/a.bam", Size: 3}}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(res.Objects) != 1 || res.Objects[0].OID != "abc" || res.Objects[0].Description != "from LIMS" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	raw, err := os.ReadFile(seen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Payload
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("payload is not JSON: %v: %s", err, raw)
+	}
+	if got.Event != PreRegister || got.Remote != "origin" || len(got.Objects) != 1 || got.Objects[0].Path != "data/a.bam" {
+		t.Fatalf("unexpected payload: %+v", got)
+	}
+
+	// Events without a hook do nothing.
+	if res, err := Run(context.Background(), Payload{Event: PostDownload}); err != nil || len(res.Objects) != 0 {
+		t.Fatalf("Run without a hook = %+v, %v", res, err)
+	}
+}
+
+func TestRunFailurePolicies(t *testing.T) {
+	for _, tc := range []struct {
+		policy  string
+		command string
+		wantErr string
+	}{
+		{FailurePolicyFail, "exit 3", "exit status 3"},
+		{FailurePolicyFail, "exec sleep 5", "timed out after"},
+		{FailurePolicyFail, "echo not-json", "parse output"},
+		{FailurePolicyWarn, "exit 3", ""},
+		{FailurePolicyIgnore, "exit 3", ""},
+	} {
+		useHooks(t, map[string]Hook{PreRegister: {Command: tc.command, Timeout: 100 * time.Millisecond, OnFailure: tc.policy}})
+		_, err := Run(context.Background(), Payload{Event: PreRegister})
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s %q: unexpected error %v", tc.policy, tc.command, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) || !strings.Contains(err.Error(), "pre-register hook") {
+			t.Errorf("%s %q: error %v, want one containing %q", tc.policy, tc.command, err, tc.wantErr)
+		}
+	}
+}
+
+func TestRunCommandSeamReceivesEvent(t *testing.T) {
+	orig := runCommand
+	t.Cleanup(func() { runCommand = orig })
+	var events []string
+	runCommand = func(_ context.Context, _ Hook, event string, _ []byte) ([]byte, error) {
+		events = append(events, event)
+		return nil, errors.New("boom")
+	}
+	useHooks(t, map[string]Hook{PostDownload: {Command: "x", Timeout: time.Second, OnFailure: FailurePolicyWarn}})
+	if _, err := Run(context.Background(), Payload{Event: PostDownload}); err != nil {
+		t.Fatalf("warn policy returned %v", err)
+	}
+	if len(events) != 1 || events[0] != PostDownload {
+		t.Fatalf("events = %v", events)
+	}
+}
@@ -0,0 +1,386 @@
+// Package httpclient builds the HTTP transports git-drs sends requests
+// through, so proxy, CA bundle, and connection settings apply the same way to
+// the DRS and fence APIs, signed storage URLs, and every other endpoint.
+//
+// Settings come from git config:
+//
+//	drs.http.proxy                     proxy URL; falls back to git's http.proxy, then HTTPS_PROXY/HTTP_PROXY
+//	drs.http.no-proxy                  hosts reached directly; falls back to NO_PROXY
+//	drs.http.ca-bundle                 PEM CAs trusted besides the system roots; falls back to git's http.sslCAInfo
+//	drs.http.api-timeout               deadline for each API call, including its response body (default 2m)
+//	drs.http.response-header-timeout   how long to wait for response headers (default 60s)
+//	drs.http.idle-conn-timeout         how long idle keep-alive connections stay open (default 90s)
+//	drs.http.max-idle-conns-per-host   idle keep-alive connections kept per host (default 100)
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Defaults for the connection settings.
+const (
+	DefaultAPITimeout            = 2 * time.Minute
+	DefaultResponseHeaderTimeout = 60 * time.Second
+	DefaultIdleConnTimeout       = 90 * time.Second
+	DefaultMaxIdleConnsPerHost   = 100
+)
+
+// Settings configure every transport the package builds.
+type Settings struct {
+	// Proxy is an explicit proxy URL. Empty uses HTTPS_PROXY and HTTP_PROXY.
+	Proxy string
+	// NoProxy lists hosts that bypass the proxy, in NO_PROXY syntax.
+	NoProxy string
+	// CABundle is a PEM file of CAs trusted besides the system roots.
+	CABundle string
+	// APITimeout bounds each DRS, fence, and metadata API call; zero
+	// disables it. Object transfers are bounded only by
+	// ResponseHeaderTimeout so large files are never cut off.
+	APITimeout            time.Duration
+	ResponseHeaderTimeout time.Duration
+	IdleConnTimeout       time.Duration
+	MaxIdleConnsPerHost   int
+}
+
+// LoadSettings reads the drs.http.* settings from git config.
+func LoadSettings() (Settings, error) {
+	s := Settings{
+		APITimeout:            DefaultAPITimeout,
+		ResponseHeaderTimeout: DefaultResponseHeaderTimeout,
+		IdleConnTimeout:       DefaultIdleConnTimeout,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+	}
+	s.Proxy = firstConfig("drs.http.proxy", "http.proxy")
+	if s.Proxy != "" {
+		if _, err := parseProxy(s.Proxy); err != nil {
+			return Settings{}, fmt.Errorf("invalid drs.http.proxy %q: %w", s.Proxy, err)
+		}
+	}
+	s.NoProxy = firstConfig("drs.http.no-proxy")
+	s.CABundle = firstConfig("drs.http.ca-bundle", "http.sslCAInfo")
+
+	var err error
+	if s.APITimeout, err = durationConfig("drs.http.api-timeout", s.APITimeout); err != nil {
+		return Settings{}, err
+	}
+	if apiTimeoutOverride != nil {
+		s.APITimeout = *apiTimeoutOverride
+	}
+	if s.ResponseHeaderTimeout, err = durationConfig("drs.http.response-header-timeout", s.ResponseHeaderTimeout); err != nil {
+		return Settings{}, err
+	}
+	if s.IdleConnTimeout, err = durationConfig("drs.http.idle-conn-timeout", s.IdleConnTimeout); err != nil {
+		return Settings{}, err
+	}
+	if raw := firstConfig("drs.http.max-idle-conns-per-host"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return Settings{}, fmt.Errorf("invalid drs.http.max-idle-conns-per-host %q: expected a non-negative integer", raw)
+		}
+		s.MaxIdleConnsPerHost = n
+	}
+	return s, nil
+}
+
+// CertPool returns the system roots plus the CAs in the configured bundle,
+// or nil when no bundle is configured.
+func (s Settings) CertPool() (*x509.CertPool, error) {
+	if s.CABundle == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(s.CABundle)
+	if err != nil {
+		return nil, fmt.Errorf("read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", s.CABundle)
+	}
+	return pool, nil
+}
+
+// Apply configures t with the settings.
+func (s Settings) Apply(t *http.Transport) error {
+	proxy, err := s.proxyFunc()
+	if err != nil {
+		return err
+	}
+	t.Proxy = proxy
+	pool, err := s.CertPool()
+	if err != nil {
+		return err
+	}
+	if pool != nil {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		} else {
+			t.TLSClientConfig = t.TLSClientConfig.Clone()
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	t.ResponseHeaderTimeout = s.ResponseHeaderTimeout
+	t.IdleConnTimeout = s.IdleConnTimeout
+	t.MaxIdleConnsPerHost = s.MaxIdleConnsPerHost
+	return nil
+}
+
+func (s Settings) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	if s.Proxy == "" && s.NoProxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	if s.Proxy != "" {
+		if _, err := parseProxy(s.Proxy); err != nil {
+			return nil, fmt.Errorf("invalid proxy %q: %w", s.Proxy, err)
+		}
+	}
+	cfg := httpproxy.FromEnvironment()
+	if s.Proxy != "" {
+		cfg.HTTPProxy, cfg.HTTPSProxy = s.Proxy, s.Proxy
+	}
+	if s.NoProxy != "" {
+		cfg.NoProxy = s.NoProxy
+	}
+	proxy := cfg.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) { return proxy(req.URL) }, nil
+}
+
+// NewTransport returns a new transport with the configured settings, for
+// callers that tune it further.
+func NewTransport() (*http.Transport, error) {
+	s, err := Current()
+	if err != nil {
+		return nil, err
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ForceAttemptHTTP2 = true
+	t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12, ClientSessionCache: tls.NewLRUClientSessionCache(0)}
+	if err := s.Apply(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// remotes holds the transport of each remote; see ForRemote.
+var remotes = struct {
+	sync.Mutex
+	m map[string]*http.Transport
+}{m: map[string]*http.Transport{}}
+
+// ForRemote returns the transport for the remote identified by key, built on
+// first use with NewTransport and then tune, if not nil. Every client built
+// for the remote in this process goes through it, so they share its idle
+// keep-alive connections, HTTP/2 streams and resumable TLS sessions instead
+// of each paying for new handshakes.
+func ForRemote(key string, tune func(*http.Transport)) (*http.Transport, error) {
+	remotes.Lock()
+	defer remotes.Unlock()
+	if t, ok := remotes.m[key]; ok {
+		return t, nil
+	}
+	t, err := NewTransport()
+	if err != nil {
+		return nil, err
+	}
+	if tune != nil {
+		tune(t)
+		if t.TLSClientConfig != nil && t.TLSClientConfig.ClientSessionCache == nil {
+			t.TLSClientConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+		}
+	}
+	remotes.m[key] = t
+	return t, nil
+}
+
+// Current returns the process's settings, read from git config once.
+var Current = sync.OnceValues(LoadSettings)
+
+// apiTimeoutOverride is the global --timeout flag, which takes precedence
+// over drs.http.api-timeout.
+var apiTimeoutOverride *time.Duration
+
+// SetAPITimeout overrides drs.http.api-timeout for this invocation. It must
+// be called before the settings are first read.
+func SetAPITimeout(d time.Duration) {
+	apiTimeoutOverride = &d
+}
+
+// shared is the transport behind clients built by New.
+var shared = sync.OnceValues(NewTransport)
+
+// Transport is a RoundTripper over the shared transport. Configuration
+// errors surface from the first request instead of at package init.
+var Transport http.RoundTripper = transport{}
+
+type transport struct{}
+
+func (transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t, err := shared()
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, fmt.Errorf("http client configuration: %w", err)
+	}
+	return t.RoundTrip(req)
+}
+
+// New returns a client over the shared transport. A zero timeout sets no
+// overall deadline.
+func New(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: Transport, Timeout: timeout}
+}
+
+// NewAPI returns a client for API calls over the shared transport. Each
+// request is bounded by the configured API timeout, read when it is sent.
+func NewAPI() *http.Client {
+	return &http.Client{Transport: apiTransport{}}
+}
+
+type apiTransport struct{}
+
+func (apiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s, err := Current()
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, fmt.Errorf("http client configuration: %w", err)
+	}
+	return RoundTripWithin(Transport, req, s.APITimeout)
+}
+
+// TimeoutError reports an API call that did not finish within its timeout.
+type TimeoutError struct {
+	Method  string
+	URL     string
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s %s did not complete within %s; raise it with --timeout or drs.http.api-timeout", e.Method, e.URL, e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() error { return context.DeadlineExceeded }
+
+// RoundTripWithin sends req through rt with a deadline of timeout covering
+// both the response and reading its body. A zero timeout sends req as is.
+// Cancellation of req's own context is reported unchanged.
+func RoundTripWithin(rt http.RoundTripper, req *http.Request, timeout time.Duration) (*http.Response, error) {
+	if timeout <= 0 {
+		return rt.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := rt.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && req.Context().Err() == nil {
+			return nil, &TimeoutError{Method: req.Method, URL: redact(req.URL), Timeout: timeout}
+		}
+		return nil, err
+	}
+	resp.Body = &deadlineBody{ReadCloser: resp.Body, ctx: ctx, parent: req.Context(), cancel: cancel, err: &TimeoutError{Method: req.Method, URL: redact(req.URL), Timeout: timeout}}
+	return resp, nil
+}
+
+// deadlineBody releases its request's deadline when closed and reports a
+// read cut off by the deadline as a TimeoutError.
+type deadlineBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	parent context.Context
+	cancel context.CancelFunc
+	err    *TimeoutError
+}
+
+func (b *deadlineBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && errors.Is(b.ctx.Err(), context.DeadlineExceeded) && b.parent.Err() == nil {
+		err = b.err
+	}
+	return n, err
+}
+
+func (b *deadlineBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// redact drops the query, which may carry signatures or tokens.
+func redact(u *url.URL) string {
+	c := *u
+	c.RawQuery = ""
+	c.User = nil
+	return c.String()
+}
+
+func firstConfig(keys ...string) string {
+	for _, key := range keys {
+		if v, _ := gitrepo.GetGitConfigString(key); strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func durationConfig(key string, def time.Duration) (time.Duration, error) {
+	raw := firstConfig(key)
+	if raw == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: expected a duration such as 30s", key, raw)
+	}
+	return d, nil
+}
+
+func parseProxy(raw string) (*url.URL, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("no host")
+	}
+	return u, nil
+}
+
+// AWSClient returns an AWS SDK HTTP client with the configured settings, for
+// awsconfig.WithHTTPClient.
+func AWSClient() (*awshttp.BuildableClient, error) {
+	configured, err := NewTransport()
+	if err != nil {
+		return nil, err
+	}
+	return awshttp.NewBuildableClient().WithTransportOptions(func(t *http.Transport) {
+		t.Proxy = configured.Proxy
+		if configured.TLSClientConfig != nil {
+			t.TLSClientConfig = configured.TLSClientConfig.Clone()
+		}
+		t.ResponseHeaderTimeout = configured.ResponseHeaderTimeout
+		t.IdleConnTimeout = configured.IdleConnTimeout
+		t.MaxIdleConnsPerHost = configured.MaxIdleConnsPerHost
+	}), nil
+}
@@ -0,0 +1,243 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setupRepo(t *testing.T, config ...string) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	if err := exec.Command("git", "init").Run(); err != nil {
+		t.Fatalf("git init failed: %v", err)
+	}
+	for i := 0; i+1 < len(config); i += 2 {
+		if err := exec.Command("git", "config", config[i], config[i+1]).Run(); err != nil {
+			t.Fatalf("git config %s: %v", config[i], err)
+		}
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	setupRepo(t)
+	s, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	if s.Proxy != "" || s.CABundle != "" || s.APITimeout != DefaultAPITimeout || s.ResponseHeaderTimeout != DefaultResponseHeaderTimeout ||
+		s.IdleConnTimeout != DefaultIdleConnTimeout || s.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost {
+		t.Fatalf("unexpected defaults: %+v", s)
+	}
+
+	setupRepo(t,
+		"http.proxy", "http://git-proxy:3128",
+		"http.sslCAInfo", "/etc/git-ca.pem",
+		"drs.http.ca-bundle", "/etc/drs-ca.pem",
+		"drs.http.no-proxy", "internal.example",
+		"drs.http.api-timeout", "45s",
+		"drs.http.response-header-timeout", "2m",
+		"drs.http.idle-conn-timeout", "15s",
+		"drs.http.max-idle-conns-per-host", "8",
+	)
+	s, err = LoadSettings()
+	if err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	want := Settings{
+		Proxy:                 "http://git-proxy:3128",
+		NoProxy:               "internal.example",
+		CABundle:              "/etc/drs-ca.pem",
+		APITimeout:            45 * time.Second,
+		ResponseHeaderTimeout: 2 * time.Minute,
+		IdleConnTimeout:       15 * time.Second,
+		MaxIdleConnsPerHost:   8,
+	}
+	if s != want {
+		t.Fatalf("LoadSettings = %+v, want %+v", s, want)
+	}
+}
+
+func TestSetAPITimeoutOverridesConfig(t *testing.T) {
+	setupRepo(t, "drs.http.api-timeout", "45s")
+	t.Cleanup(func() { apiTimeoutOverride = nil })
+	SetAPITimeout(0)
+	s, err := LoadSettings()
+	if err != nil || s.APITimeout != 0 {
+		t.Fatalf("APITimeout = %v, %v; want the --timeout override 0", s.APITimeout, err)
+	}
+}
+
+func TestRoundTripWithin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		if r.URL.Path == "/stall" {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/ok?token=secret", nil)
+	resp, err := RoundTripWithin(http.DefaultTransport, req, time.Second)
+	if err != nil {
+		t.Fatalf("RoundTripWithin: %v", err)
+	}
+	if body, err := io.ReadAll(resp.Body); err != nil || string(body) != "ok" {
+		t.Fatalf("body = %q, %v", body, err)
+	}
+	resp.Body.Close()
+
+	// Headers arrive in time but the body stalls past the deadline.
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/stall?token=secret", nil)
+	resp, err = RoundTripWithin(http.DefaultTransport, req, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("RoundTripWithin: %v", err)
+	}
+	_, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("stalled body read: %v, want a TimeoutError", err)
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Fatalf("timeout error leaks the query: %v", err)
+	}
+}
+
+func TestLoadSettingsRejectsInvalidValues(t *testing.T) {
+	for _, tc := range [][]string{
+		{"drs.http.proxy", "http://"},
+		{"drs.http.api-timeout", "soon"},
+		{"drs.http.response-header-timeout", "soon"},
+		{"drs.http.idle-conn-timeout", "-1s"},
+		{"drs.http.max-idle-conns-per-host", "many"},
+	} {
+		setupRepo(t, tc...)
+		if _, err := LoadSettings(); err == nil || !strings.Contains(err.Error(), tc[0]) {
+			t.Errorf("%s=%s: expected error naming the key, got %v", tc[0], tc[1], err)
+		}
+	}
+}
+
+func TestApplyRoutesThroughProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		_, _ = io.WriteString(w, "via proxy")
+	}))
+	defer proxy.Close()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if err := (Settings{Proxy: proxy.URL, NoProxy: "direct.example"}).Apply(transport); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get("http://drs.example/ga4gh/drs/v1/objects/1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "via proxy" || len(proxied) != 1 || proxied[0] != "http://drs.example/ga4gh/drs/v1/objects/1" {
+		t.Fatalf("request not proxied: body=%q proxied=%v", body, proxied)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://direct.example/", nil)
+	if u, err := transport.Proxy(req); err != nil || u != nil {
+		t.Fatalf("no-proxy host was proxied: %v %v", u, err)
+	}
+}
+
+func TestApplyTrustsCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	untrusted := &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+	if _, err := untrusted.Get(server.URL); err == nil {
+		t.Fatal("expected the test server's certificate to be untrusted by default")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if err := (Settings{CABundle: bundle}).Apply(transport); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get with CA bundle: %v", err)
+	}
+	resp.Body.Close()
+
+	if err := os.WriteFile(bundle, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Settings{CABundle: bundle}).Apply(http.DefaultTransport.(*http.Transport).Clone()); err == nil {
+		t.Fatal("expected error for a bundle without certificates")
+	}
+}
+
+func TestForRemoteSharesConnections(t *testing.T) {
+	setupRepo(t)
+	var opened atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	}))
+	server.EnableHTTP2 = true
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			opened.Add(1)
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+	trust := func(t *http.Transport) {
+		t.TLSClientConfig.RootCAs = x509.NewCertPool()
+		t.TLSClientConfig.RootCAs.AddCert(server.Certificate())
+	}
+
+	// Each client is built anew, as every command and scope builds its own.
+	for range 5 {
+		transport, err := ForRemote("test-shared", trust)
+		if err != nil {
+			t.Fatalf("ForRemote: %v", err)
+		}
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "HTTP/2.0" {
+			t.Fatalf("request used %s; want HTTP/2.0", body)
+		}
+	}
+	if n := opened.Load(); n != 1 {
+		t.Fatalf("opened %d connections for one remote; want 1", n)
+	}
+
+	a, _ := ForRemote("test-shared", nil)
+	b, err := ForRemote("test-other", trust)
+	if err != nil || a == b {
+		t.Fatalf("ForRemote for another remote = %p, %v; want its own transport", b, err)
+	}
+}
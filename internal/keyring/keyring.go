@@ -0,0 +1,82 @@
+// Package keyring keeps secrets in the operating system's credential store:
+// the login keychain on macOS, the Secret Service (through libsecret's
+// secret-tool) on Linux and other Unix systems, and the Windows Credential
+// Manager. Secrets are addressed by a service and an account name.
+package keyring
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrNotFound: no secret is stored for the service and account.
+	ErrNotFound = errors.New("secret not found in keyring")
+	// ErrUnavailable: this system has no usable keyring.
+	ErrUnavailable = errors.New("no OS keyring available")
+)
+
+// Backend is a credential store.
+type Backend interface {
+	Get(service, account string) (string, error)
+	Set(service, account, secret string) error
+	Delete(service, account string) error
+}
+
+// backend is the platform store; swapped with SetBackend in tests.
+var backend Backend = platformBackend{}
+
+// Get returns the secret stored for service and account.
+func Get(service, account string) (string, error) { return backend.Get(service, account) }
+
+// Set stores secret for service and account, replacing any existing one.
+func Set(service, account, secret string) error { return backend.Set(service, account, secret) }
+
+// Delete removes the secret for service and account. Deleting a missing
+// secret returns ErrNotFound.
+func Delete(service, account string) error { return backend.Delete(service, account) }
+
+// SetBackend replaces the store, for tests, and returns a func restoring
+// the previous one.
+func SetBackend(b Backend) (restore func()) {
+	prev := backend
+	backend = b
+	return func() { backend = prev }
+}
+
+// Memory is an in-process Backend for tests.
+type Memory struct {
+	mu      sync.Mutex
+	secrets map[[2]string]string
+}
+
+func (m *Memory) Get(service, account string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	secret, ok := m.secrets[[2]string{service, account}]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+func (m *Memory) Set(service, account, secret string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.secrets == nil {
+		m.secrets = map[[2]string]string{}
+	}
+	m.secrets[[2]string{service, account}] = secret
+	return nil
+}
+
+func (m *Memory) Delete(service, account string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := [2]string{service, account}
+	if _, ok := m.secrets[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.secrets, key)
+	return nil
+}
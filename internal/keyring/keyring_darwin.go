@@ -0,0 +1,63 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// errSecItemNotFound is the exit status security(1) uses for a missing item.
+const errSecItemNotFound = 44
+
+// platformBackend uses security(1) and the login keychain. Secrets are
+// passed on stdin in interactive mode, hex-encoded, so they never appear in
+// a process listing.
+type platformBackend struct{}
+
+func (platformBackend) Get(service, account string) (string, error) {
+	out, err := securityCmd(nil, "find-generic-password", "-s", service, "-a", account, "-w")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (platformBackend) Set(service, account, secret string) error {
+	line := fmt.Sprintf("add-generic-password -U -s %q -a %q -X %s\n", service, account, hex.EncodeToString([]byte(secret)))
+	_, err := securityCmd(strings.NewReader(line), "-i")
+	return err
+}
+
+func (platformBackend) Delete(service, account string) error {
+	_, err := securityCmd(nil, "delete-generic-password", "-s", service, "-a", account)
+	return err
+}
+
+func securityCmd(stdin *strings.Reader, args ...string) ([]byte, error) {
+	path, err := exec.LookPath("security")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	cmd := exec.Command(path, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == errSecItemNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("security %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	// Interactive mode exits 0 even when a command fails.
+	if msg := strings.TrimSpace(stderr.String()); stdin != nil && msg != "" {
+		return nil, fmt.Errorf("security: %s", msg)
+	}
+	return out, nil
+}
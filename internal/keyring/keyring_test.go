@@ -0,0 +1,32 @@
+package keyring
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMemoryBackend(t *testing.T) {
+	t.Cleanup(SetBackend(&Memory{}))
+
+	if _, err := Get("git-drs", "prod"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get on empty store = %v, want ErrNotFound", err)
+	}
+	if err := Set("git-drs", "prod", "one"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set("git-drs", "prod", "two"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Get("git-drs", "prod"); err != nil || got != "two" {
+		t.Fatalf("Get = %q, %v; want the replaced secret", got, err)
+	}
+	if _, err := Get("other", "prod"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("secrets leaked across services: %v", err)
+	}
+	if err := Delete("git-drs", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Delete("git-drs", "prod"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second Delete = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,66 @@
+//go:build !darwin && !windows
+
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// platformBackend uses secret-tool(1) from libsecret, which talks to the
+// Secret Service (GNOME Keyring, KWallet) over D-Bus. Secrets are passed on
+// stdin, never on the command line.
+type platformBackend struct{}
+
+func (platformBackend) Get(service, account string) (string, error) {
+	out, err := secretTool(nil, "lookup", "service", service, "account", account)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(out) == 0 {
+		// secret-tool exits 1 without output when nothing matches.
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (platformBackend) Set(service, account, secret string) error {
+	_, err := secretTool(strings.NewReader(secret), "store", "--label="+service+" "+account, "service", service, "account", account)
+	return err
+}
+
+func (b platformBackend) Delete(service, account string) error {
+	if _, err := b.Get(service, account); err != nil {
+		return err
+	}
+	_, err := secretTool(nil, "clear", "service", service, "account", account)
+	return err
+}
+
+func secretTool(stdin io.Reader, args ...string) ([]byte, error) {
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil, fmt.Errorf("%w: secret-tool not found; install libsecret-tools", ErrUnavailable)
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			// No D-Bus session, as on a headless HPC login node.
+			if strings.Contains(msg, "DBus") || strings.Contains(msg, "D-Bus") || strings.Contains(msg, "dbus") {
+				return nil, fmt.Errorf("%w: %s", ErrUnavailable, msg)
+			}
+			return out, fmt.Errorf("secret-tool %s: %w: %s", args[0], err, msg)
+		}
+		return out, fmt.Errorf("secret-tool %s: %w", args[0], err)
+	}
+	return out, nil
+}
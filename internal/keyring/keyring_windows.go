@@ -0,0 +1,160 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32      = syscall.NewLazyDLL("advapi32.dll")
+	procCredRead  = advapi32.NewProc("CredReadW")
+	procCredWrite = advapi32.NewProc("CredWriteW")
+	procCredDel   = advapi32.NewProc("CredDeleteW")
+	procCredFree  = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric        = 1
+	credPersistLocal       = 2
+	errorNotFound          = syscall.Errno(1168)
+	maxCredentialBlobBytes = 5 * 512
+)
+
+// credential mirrors CREDENTIALW.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// platformBackend uses the Windows Credential Manager. A credential blob is
+// limited to 2560 bytes, so longer secrets are split across targets
+// "<service>:<account>", "<service>:<account>#1", and so on.
+type platformBackend struct{}
+
+func target(service, account string, part int) string {
+	t := service + ":" + account
+	if part > 0 {
+		t += "#" + strconv.Itoa(part)
+	}
+	return t
+}
+
+func (platformBackend) Get(service, account string) (string, error) {
+	var secret []byte
+	for part := 0; ; part++ {
+		chunk, err := credRead(target(service, account, part))
+		if errors.Is(err, ErrNotFound) && part > 0 {
+			return string(secret), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		secret = append(secret, chunk...)
+		if len(chunk) < maxCredentialBlobBytes {
+			return string(secret), nil
+		}
+	}
+}
+
+func (platformBackend) Set(service, account, secret string) error {
+	data := []byte(secret)
+	part := 0
+	for {
+		n := min(len(data), maxCredentialBlobBytes)
+		if err := credWrite(target(service, account, part), account, data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+		part++
+		if n < maxCredentialBlobBytes {
+			break
+		}
+	}
+	// Drop parts left over from a longer secret.
+	for ; ; part++ {
+		if err := credDelete(target(service, account, part)); err != nil {
+			return nil
+		}
+	}
+}
+
+func (platformBackend) Delete(service, account string) error {
+	if err := credDelete(target(service, account, 0)); err != nil {
+		return err
+	}
+	for part := 1; credDelete(target(service, account, part)) == nil; part++ {
+	}
+	return nil
+}
+
+func credRead(name string) ([]byte, error) {
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	var cred *credential
+	r, _, callErr := procCredRead.Call(uintptr(unsafe.Pointer(namePtr)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if r == 0 {
+		return nil, credError("CredRead", callErr)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	return append([]byte(nil), unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)...), nil
+}
+
+func credWrite(name, account string, blob []byte) error {
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	userPtr, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return err
+	}
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         namePtr,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocal,
+		UserName:           userPtr,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+	r, _, callErr := procCredWrite.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if r == 0 {
+		return credError("CredWrite", callErr)
+	}
+	return nil
+}
+
+func credDelete(name string) error {
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	r, _, callErr := procCredDel.Call(uintptr(unsafe.Pointer(namePtr)), credTypeGeneric, 0)
+	if r == 0 {
+		return credError("CredDelete", callErr)
+	}
+	return nil
+}
+
+func credError(op string, err error) error {
+	if errors.Is(err, errorNotFound) {
+		return ErrNotFound
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
@@ -0,0 +1,188 @@
+package lfs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// PointerChange is one path whose LFS pointer differs between two commits.
+// Status is "added", "removed", or "modified"; a path that stopped or started
+// being an LFS pointer counts as removed or added.
+type PointerChange struct {
+	Path    string `json:"path"`
+	Status  string `json:"status"`
+	OldOID  string `json:"old_oid,omitempty"`
+	NewOID  string `json:"new_oid,omitempty"`
+	OldSize int64  `json:"old_size,omitempty"`
+	NewSize int64  `json:"new_size,omitempty"`
+}
+
+// Pointer change statuses.
+const (
+	PointerAdded    = "added"
+	PointerRemoved  = "removed"
+	PointerModified = "modified"
+)
+
+// DiffPointers lists the LFS pointers that differ between the commits from
+// and to, limited to pathspecs when any are given, sorted by path. Renames
+// show as a removal and an addition.
+func DiffPointers(ctx context.Context, from, to string, pathspecs []string) ([]PointerChange, error) {
+	repoDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return diffPointers(ctx, repoDir, from, to, pathspecs)
+}
+
+func diffPointers(ctx context.Context, repoDir, from, to string, pathspecs []string) ([]PointerChange, error) {
+	for _, rev := range []string{from, to} {
+		if _, err := runGitCommand(ctx, repoDir, "rev-parse", "--verify", "--quiet", rev+"^{commit}"); err != nil {
+			return nil, fmt.Errorf("unknown commit %q", rev)
+		}
+	}
+	args := append([]string{"diff", "--raw", "-z", "--no-renames", "--no-abbrev", from, to, "--"}, pathspecs...)
+	out, err := runGitCommand(ctx, repoDir, args...)
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s %s: %w", from, to, err)
+	}
+
+	// -z output alternates ":<old mode> <new mode> <old blob> <new blob> <status>"
+	// and the path.
+	type rawChange struct{ path, oldBlob, newBlob string }
+	var raw []rawChange
+	var blobs []string
+	fields := strings.Split(out, "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		meta := strings.Fields(fields[i])
+		if len(meta) != 5 || !strings.HasPrefix(meta[0], ":") {
+			continue
+		}
+		c := rawChange{path: fields[i+1]}
+		if !zeroBlob(meta[2]) {
+			c.oldBlob = meta[2]
+			blobs = append(blobs, c.oldBlob)
+		}
+		if !zeroBlob(meta[3]) {
+			c.newBlob = meta[3]
+			blobs = append(blobs, c.newBlob)
+		}
+		raw = append(raw, c)
+	}
+
+	pointers, err := readPointerBlobs(ctx, repoDir, blobs)
+	if err != nil {
+		return nil, err
+	}
+	var changes []PointerChange
+	for _, c := range raw {
+		before, hadPointer := pointers[c.oldBlob]
+		after, hasPointer := pointers[c.newBlob]
+		change := PointerChange{Path: c.path}
+		switch {
+		case hadPointer && hasPointer:
+			if before.Oid == after.Oid {
+				continue
+			}
+			change.Status = PointerModified
+		case hasPointer:
+			change.Status = PointerAdded
+		case hadPointer:
+			change.Status = PointerRemoved
+		default:
+			continue
+		}
+		if hadPointer {
+			change.OldOID, change.OldSize = before.Oid, before.Size
+		}
+		if hasPointer {
+			change.NewOID, change.NewSize = after.Oid, after.Size
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func zeroBlob(blob string) bool {
+	return strings.Trim(blob, "0") == ""
+}
+
+// maxPointerSize bounds the blobs read as candidate pointers; real pointers
+// are well under 200 bytes.
+const maxPointerSize = 1024
+
+// readPointerBlobs returns the LFS pointers among blobs, keyed by blob id.
+// Sizes are checked first so large files are never read.
+func readPointerBlobs(ctx context.Context, repoDir string, blobs []string) (map[string]lfsPointer, error) {
+	pointers := make(map[string]lfsPointer, len(blobs))
+	if len(blobs) == 0 {
+		return pointers, nil
+	}
+	out, err := catFileBatch(ctx, repoDir, "--batch-check", blobs)
+	if err != nil {
+		return nil, err
+	}
+	var small []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		// <blob> <type> <size>
+		parts := strings.Fields(line)
+		if len(parts) != 3 || parts[1] != "blob" {
+			continue
+		}
+		if size, err := strconv.ParseInt(parts[2], 10, 64); err == nil && size <= maxPointerSize {
+			small = append(small, parts[0])
+		}
+	}
+	if len(small) == 0 {
+		return pointers, nil
+	}
+
+	out, err = catFileBatch(ctx, repoDir, "--batch", small)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(bytes.NewReader(out))
+	for range small {
+		// <blob> <type> <size>\n<content>\n
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("git cat-file: %w", err)
+		}
+		parts := strings.Fields(header)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("unexpected git cat-file output %q", strings.TrimSpace(header))
+		}
+		size, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected git cat-file output %q", strings.TrimSpace(header))
+		}
+		content := make([]byte, size+1)
+		if _, err := io.ReadFull(r, content); err != nil {
+			return nil, fmt.Errorf("git cat-file: %w", err)
+		}
+		if pointer, ok := parseLFSPointer(string(content[:size])); ok {
+			pointers[parts[0]] = pointer
+		}
+	}
+	return pointers, nil
+}
+
+func catFileBatch(ctx context.Context, repoDir, mode string, ids []string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", "cat-file", mode)
+	cmd.Dir = repoDir
+	cmd.Stdin = strings.NewReader(strings.Join(ids, "\n") + "\n")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git cat-file %s: %w (%s)", mode, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
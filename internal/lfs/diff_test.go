@@ -0,0 +1,69 @@
+package lfs
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffPointers(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+	repo := t.TempDir()
+	runGitCmdTest(t, repo, "init")
+	runGitCmdTest(t, repo, "config", "user.email", "test@example.com")
+	runGitCmdTest(t, repo, "config", "user.name", "Test User")
+
+	oidA := strings.Repeat("a", 64)
+	oidB := strings.Repeat("b", 64)
+	oidC := strings.Repeat("c", 64)
+	writePointerFile(t, filepath.Join(repo, "data", "kept.bam"), oidA, "10")
+	writePointerFile(t, filepath.Join(repo, "data", "changed.bam"), oidA, "10")
+	writePointerFile(t, filepath.Join(repo, "data", "dropped.bam"), oidB, "20")
+	if err := os.WriteFile(filepath.Join(repo, "README"), []byte("v1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGitCmdTest(t, repo, "add", ".")
+	runGitCmdTest(t, repo, "commit", "-m", "release 1")
+	runGitCmdTest(t, repo, "tag", "v1")
+
+	writePointerFile(t, filepath.Join(repo, "data", "changed.bam"), oidC, "35")
+	writePointerFile(t, filepath.Join(repo, "data", "new.bam"), oidB, "20")
+	runGitCmdTest(t, repo, "rm", "-q", "data/dropped.bam")
+	if err := os.WriteFile(filepath.Join(repo, "README"), []byte("v2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGitCmdTest(t, repo, "add", ".")
+	runGitCmdTest(t, repo, "commit", "-m", "release 2")
+
+	t.Chdir(repo)
+	changes, err := DiffPointers(context.Background(), "v1", "HEAD", nil)
+	if err != nil {
+		t.Fatalf("DiffPointers: %v", err)
+	}
+	want := []PointerChange{
+		{Path: "data/changed.bam", Status: PointerModified, OldOID: oidA, NewOID: oidC, OldSize: 10, NewSize: 35},
+		{Path: "data/dropped.bam", Status: PointerRemoved, OldOID: oidB, OldSize: 20},
+		{Path: "data/new.bam", Status: PointerAdded, NewOID: oidB, NewSize: 20},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %+v", changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+
+	changes, err = DiffPointers(context.Background(), "v1", "HEAD", []string{"data/new.bam"})
+	if err != nil || len(changes) != 1 || changes[0].Path != "data/new.bam" {
+		t.Fatalf("pathspec-limited diff = %+v, %v", changes, err)
+	}
+	if _, err := DiffPointers(context.Background(), "v1", "no-such-rev", nil); err == nil || !strings.Contains(err.Error(), "no-such-rev") {
+		t.Fatalf("unknown revision error = %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package lfs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PathRevision is one commit that changed a path, with the LFS pointer it
+// left behind. Version counts distinct pointer oids from the oldest commit,
+// starting at 1; Predecessor is the oid of the previous version.
+type PathRevision struct {
+	Commit      string `json:"commit"`
+	Time        string `json:"time"`
+	Subject     string `json:"subject"`
+	OID         string `json:"oid,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	Deleted     bool   `json:"deleted,omitempty"`
+	Version     int    `json:"version,omitempty"`
+	Predecessor string `json:"predecessor,omitempty"`
+}
+
+// PathHistory lists the commits reachable from HEAD that changed path, newest
+// first. Revisions where path was deleted or was not an LFS pointer carry no
+// oid or version. Renames are not followed.
+func PathHistory(ctx context.Context, path string) ([]PathRevision, error) {
+	repoDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	out, err := runGitCommand(ctx, repoDir, "log", "--no-abbrev", "--raw", "--no-renames", "--format=%x00%H%x09%cI%x09%s", "HEAD", "--", path)
+	if err != nil {
+		return nil, fmt.Errorf("git log %s: %w", path, err)
+	}
+
+	var revs []PathRevision
+	var blobs []string
+	for _, record := range strings.Split(out, "\x00") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		header := strings.SplitN(lines[0], "\t", 3)
+		if len(header) < 2 {
+			continue
+		}
+		rev := PathRevision{Commit: header[0], Time: header[1]}
+		if len(header) == 3 {
+			rev.Subject = header[2]
+		}
+		blob := ""
+		for _, line := range lines[1:] {
+			// :<old mode> <new mode> <old blob> <new blob> <status>\t<path>
+			fields := strings.Fields(strings.SplitN(line, "\t", 2)[0])
+			if len(fields) != 5 || !strings.HasPrefix(fields[0], ":") {
+				continue
+			}
+			if fields[4] == "D" {
+				rev.Deleted = true
+			} else {
+				blob = fields[3]
+			}
+		}
+		revs = append(revs, rev)
+		blobs = append(blobs, blob)
+	}
+
+	for i := range revs {
+		if blobs[i] == "" {
+			continue
+		}
+		content, err := runGitCommand(ctx, repoDir, "cat-file", "-p", blobs[i])
+		if err != nil {
+			return nil, fmt.Errorf("git cat-file %s: %w", blobs[i], err)
+		}
+		if pointer, ok := parseLFSPointer(content); ok {
+			revs[i].OID = pointer.Oid
+			revs[i].Size = pointer.Size
+		}
+	}
+
+	version, last := 0, ""
+	for i := len(revs) - 1; i >= 0; i-- {
+		if revs[i].OID == "" {
+			continue
+		}
+		if revs[i].OID != last {
+			version++
+			revs[i].Predecessor = last
+			last = revs[i].OID
+		} else if i+1 < len(revs) {
+			revs[i].Predecessor = predecessorOf(revs[i+1:], last)
+		}
+		revs[i].Version = version
+	}
+	return revs, nil
+}
+
+// predecessorOf finds the predecessor recorded on the newest older revision
+// carrying oid, for commits that re-add an unchanged pointer.
+func predecessorOf(older []PathRevision, oid string) string {
+	for _, rev := range older {
+		if rev.OID == oid {
+			return rev.Predecessor
+		}
+	}
+	return ""
+}
+
+// VersionOf returns the newest revision carrying oid, or false when oid
+// never appears in the history.
+func VersionOf(revs []PathRevision, oid string) (PathRevision, bool) {
+	for _, rev := range revs {
+		if rev.OID == oid {
+			return rev, true
+		}
+	}
+	return PathRevision{}, false
+}
+
+// LastChanged returns, for each path changed by a commit reachable from
+// HEAD, the commit time of the newest such commit. Renames are not
+// followed, so a renamed file dates from its rename.
+func LastChanged(ctx context.Context) (map[string]time.Time, error) {
+	repoDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	out, err := runGitCommand(ctx, repoDir, "-c", "core.quotePath=false", "log", "--no-renames", "--name-only", "--format=%x00%ct", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	changed := map[string]time.Time{}
+	for _, record := range strings.Split(out, "\x00") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		secs, err := strconv.ParseInt(strings.TrimSpace(lines[0]), 10, 64)
+		if err != nil {
+			continue
+		}
+		for _, path := range lines[1:] {
+			if path = strings.TrimSpace(path); path == "" {
+				continue
+			}
+			if _, seen := changed[path]; !seen {
+				changed[path] = time.Unix(secs, 0)
+			}
+		}
+	}
+	return changed, nil
+}
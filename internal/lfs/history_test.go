@@ -0,0 +1,119 @@
+package lfs
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestPathHistoryChainsVersions(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+	repo := t.TempDir()
+	runGitCmdTest(t, repo, "init")
+	runGitCmdTest(t, repo, "config", "user.email", "test@example.com")
+	runGitCmdTest(t, repo, "config", "user.name", "Test User")
+
+	oidA := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	oidB := "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	path := filepath.Join(repo, "data", "sample.bam")
+
+	writePointerFile(t, path, oidA, "10")
+	runGitCmdTest(t, repo, "add", ".")
+	runGitCmdTest(t, repo, "commit", "-m", "add sample")
+
+	if err := os.WriteFile(filepath.Join(repo, "README"), []byte("unrelated\n"), 0o644); err != nil {
+		t.Fatalf("write README: %v", err)
+	}
+	runGitCmdTest(t, repo, "add", ".")
+	runGitCmdTest(t, repo, "commit", "-m", "unrelated")
+
+	writePointerFile(t, path, oidB, "20")
+	runGitCmdTest(t, repo, "add", ".")
+	runGitCmdTest(t, repo, "commit", "-m", "update sample")
+
+	runGitCmdTest(t, repo, "rm", "-q", "data/sample.bam")
+	runGitCmdTest(t, repo, "commit", "-m", "drop sample")
+
+	writePointerFile(t, path, oidB, "20")
+	runGitCmdTest(t, repo, "add", ".")
+	runGitCmdTest(t, repo, "commit", "-m", "restore sample")
+
+	t.Chdir(repo)
+	revs, err := PathHistory(context.Background(), "data/sample.bam")
+	if err != nil {
+		t.Fatalf("PathHistory: %v", err)
+	}
+	if len(revs) != 4 {
+		t.Fatalf("expected 4 revisions, got %d: %+v", len(revs), revs)
+	}
+
+	want := []struct {
+		subject     string
+		oid         string
+		deleted     bool
+		version     int
+		predecessor string
+	}{
+		{"restore sample", oidB, false, 2, oidA},
+		{"drop sample", "", true, 0, ""},
+		{"update sample", oidB, false, 2, oidA},
+		{"add sample", oidA, false, 1, ""},
+	}
+	for i, w := range want {
+		got := revs[i]
+		if got.Subject != w.subject || got.OID != w.oid || got.Deleted != w.deleted || got.Version != w.version || got.Predecessor != w.predecessor {
+			t.Fatalf("revision %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if revs[3].Size != 10 || revs[0].Size != 20 {
+		t.Fatalf("unexpected sizes: %+v", revs)
+	}
+
+	rev, ok := VersionOf(revs, oidB)
+	if !ok || rev.Subject != "restore sample" {
+		t.Fatalf("VersionOf(oidB) = %+v, %v", rev, ok)
+	}
+	if _, ok := VersionOf(revs, "cccc"); ok {
+		t.Fatal("expected unknown oid to be absent")
+	}
+}
+
+func TestLastChangedKeepsNewestCommitPerPath(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+	repo := t.TempDir()
+	runGitCmdTest(t, repo, "init")
+	runGitCmdTest(t, repo, "config", "user.email", "test@example.com")
+	runGitCmdTest(t, repo, "config", "user.name", "Test User")
+
+	commit := func(date string, files ...string) {
+		t.Helper()
+		for _, f := range files {
+			if err := os.WriteFile(filepath.Join(repo, f), []byte(date), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		runGitCmdTest(t, repo, "add", ".")
+		t.Setenv("GIT_COMMITTER_DATE", date)
+		runGitCmdTest(t, repo, "commit", "-m", date)
+	}
+	commit("2026-01-01T00:00:00Z", "old.bin", "new.bin")
+	commit("2026-02-01T00:00:00Z", "new.bin")
+
+	t.Chdir(repo)
+	changed, err := LastChanged(context.Background())
+	if err != nil {
+		t.Fatalf("LastChanged: %v", err)
+	}
+	if got := changed["old.bin"].UTC().Format("2006-01-02"); got != "2026-01-01" {
+		t.Fatalf("old.bin changed %s, want 2026-01-01", got)
+	}
+	if got := changed["new.bin"].UTC().Format("2006-01-02"); got != "2026-02-01" {
+		t.Fatalf("new.bin changed %s, want 2026-02-01", got)
+	}
+}
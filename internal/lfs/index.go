@@ -0,0 +1,217 @@
+package lfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PointerIndexDir holds the pointer index, relative to the git common dir.
+//
+// The index keeps, for each ref it has been asked about, the commit it last
+// saw and every LFS pointer in that commit's tree. Asking about the ref again
+// diffs the saved commit against the current one and reads only the changed
+// blobs, so listing the pointers of a branch costs time proportional to what
+// changed since the last push rather than to the size of the tree. The index
+// is a cache: deleting it only makes the next listing a full scan.
+const PointerIndexDir = "drs/index/v1"
+
+// pointerSnapshot is one ref's entry in the index.
+type pointerSnapshot struct {
+	Ref    string                    `json:"ref"`
+	Commit string                    `json:"commit"`
+	Files  map[string]indexedPointer `json:"files"`
+}
+
+type indexedPointer struct {
+	Oid  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+var commitID = regexp.MustCompile(`^(?i)[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// indexedPointers returns the LFS pointers in the tree of ref, keyed by path,
+// and updates the index. Snapshots are kept for names such as branches and
+// HEAD; a bare commit id is computed from HEAD's snapshot and not saved, since
+// nothing would look it up again.
+func indexedPointers(ctx context.Context, repoDir, ref string, logger *slog.Logger) (map[string]indexedPointer, error) {
+	out, err := runGitCommand(ctx, repoDir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("unknown commit %q", ref)
+	}
+	commit := strings.TrimSpace(out)
+
+	dir, err := pointerIndexPath(ctx, repoDir)
+	if err != nil {
+		return nil, err
+	}
+	key := ref
+	if commitID.MatchString(ref) {
+		key = ""
+	}
+	base := loadSnapshot(dir, key)
+	if base == nil && key != "HEAD" {
+		base = loadSnapshot(dir, "HEAD")
+	}
+
+	var files map[string]indexedPointer
+	switch {
+	case base != nil && base.Commit == commit:
+		return base.Files, nil
+	case base != nil:
+		files, err = applyPointerChanges(ctx, repoDir, base, commit)
+		if err != nil {
+			// The saved commit may have been garbage collected.
+			logger.Debug(fmt.Sprintf("pointer index: rescanning %s: %v", ref, err))
+			files = nil
+		}
+	}
+	if files == nil {
+		if files, err = scanTreePointers(ctx, repoDir, commit); err != nil {
+			return nil, err
+		}
+	}
+	if key != "" {
+		if err := saveSnapshot(dir, pointerSnapshot{Ref: key, Commit: commit, Files: files}); err != nil {
+			logger.Debug(fmt.Sprintf("pointer index: not saving %s: %v", ref, err))
+		}
+	}
+	return files, nil
+}
+
+func pointerIndexPath(ctx context.Context, repoDir string) (string, error) {
+	out, err := runGitCommand(ctx, repoDir, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse --git-common-dir failed: %w", err)
+	}
+	dir := strings.TrimSpace(out)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoDir, dir)
+	}
+	return filepath.Join(dir, filepath.FromSlash(PointerIndexDir)), nil
+}
+
+func snapshotFile(dir, ref string) string {
+	return filepath.Join(dir, url.PathEscape(ref)+".json")
+}
+
+// loadSnapshot returns nil when ref has no usable snapshot.
+func loadSnapshot(dir, ref string) *pointerSnapshot {
+	if ref == "" {
+		return nil
+	}
+	data, err := os.ReadFile(snapshotFile(dir, ref))
+	if err != nil {
+		return nil
+	}
+	var snap pointerSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil || snap.Ref != ref || !commitID.MatchString(snap.Commit) || snap.Files == nil {
+		return nil
+	}
+	return &snap
+}
+
+func saveSnapshot(dir string, snap pointerSnapshot) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".snapshot-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), snapshotFile(dir, snap.Ref))
+}
+
+// applyPointerChanges returns base's pointers updated to commit.
+func applyPointerChanges(ctx context.Context, repoDir string, base *pointerSnapshot, commit string) (map[string]indexedPointer, error) {
+	changes, err := diffPointers(ctx, repoDir, base.Commit, commit, nil)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]indexedPointer, len(base.Files)+len(changes))
+	for path, p := range base.Files {
+		files[path] = p
+	}
+	for _, c := range changes {
+		if c.Status == PointerRemoved {
+			delete(files, c.Path)
+			continue
+		}
+		files[c.Path] = indexedPointer{Oid: c.NewOID, Size: c.NewSize}
+	}
+	return files, nil
+}
+
+// scanTreePointers lists every LFS pointer in commit's tree with one git
+// ls-tree and one batch read of the blobs small enough to be pointers.
+func scanTreePointers(ctx context.Context, repoDir, commit string) (map[string]indexedPointer, error) {
+	out, err := runGitCommand(ctx, repoDir, "ls-tree", "-r", "-l", "-z", "--full-tree", commit)
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree %s: %w", commit, err)
+	}
+	blobOf := map[string]string{}
+	var blobs []string
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(out, "\x00") {
+		// <mode> blob <object> <size>\t<path>
+		meta, path, ok := strings.Cut(entry, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 4 || fields[1] != "blob" {
+			continue
+		}
+		if size, err := strconv.ParseInt(fields[3], 10, 64); err != nil || size > maxPointerSize {
+			continue
+		}
+		blobOf[path] = fields[2]
+		if !seen[fields[2]] {
+			seen[fields[2]] = true
+			blobs = append(blobs, fields[2])
+		}
+	}
+	pointers, err := readPointerBlobs(ctx, repoDir, blobs)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]indexedPointer, len(pointers))
+	for path, blob := range blobOf {
+		if p, ok := pointers[blob]; ok {
+			files[path] = indexedPointer{Oid: p.Oid, Size: p.Size}
+		}
+	}
+	return files, nil
+}
+
+// ClearPointerIndex removes the pointer index of the repository at repoDir.
+func ClearPointerIndex(ctx context.Context, repoDir string) error {
+	dir, err := pointerIndexPath(ctx, repoDir)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package lfs
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIndexedPointers(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+	repo := t.TempDir()
+	runGitCmdTest(t, repo, "init", "-b", "main")
+	runGitCmdTest(t, repo, "config", "user.email", "test@example.com")
+	runGitCmdTest(t, repo, "config", "user.name", "Test User")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	oidA := strings.Repeat("a", 64)
+	oidB := strings.Repeat("b", 64)
+	writePointerFile(t, filepath.Join(repo, "data", "a.bam"), oidA, "10")
+	writePointerFile(t, filepath.Join(repo, "data", "b.bam"), oidB, "20")
+	if err := os.WriteFile(filepath.Join(repo, "README"), []byte("not a pointer\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGitCmdTest(t, repo, "add", ".")
+	runGitCmdTest(t, repo, "commit", "-m", "first")
+
+	files, err := indexedPointers(ctx, repo, "main", logger)
+	if err != nil {
+		t.Fatalf("indexedPointers: %v", err)
+	}
+	want := map[string]indexedPointer{"data/a.bam": {oidA, 10}, "data/b.bam": {oidB, 20}}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("first scan = %+v, want %+v", files, want)
+	}
+	dir := filepath.Join(repo, ".git", filepath.FromSlash(PointerIndexDir))
+	if snap := loadSnapshot(dir, "main"); snap == nil || !reflect.DeepEqual(snap.Files, want) {
+		t.Fatalf("snapshot for main = %+v", snap)
+	}
+
+	// The next listing applies only the changes since the saved commit. A
+	// planted entry that a rescan would drop proves the snapshot was reused.
+	snap := loadSnapshot(dir, "main")
+	snap.Files["planted.bam"] = indexedPointer{oidA, 1}
+	if err := saveSnapshot(dir, *snap); err != nil {
+		t.Fatal(err)
+	}
+	oidC := strings.Repeat("c", 64)
+	writePointerFile(t, filepath.Join(repo, "data", "a.bam"), oidC, "30")
+	runGitCmdTest(t, repo, "rm", "-q", "data/b.bam")
+	runGitCmdTest(t, repo, "add", ".")
+	runGitCmdTest(t, repo, "commit", "-m", "second")
+
+	files, err = indexedPointers(ctx, repo, "main", logger)
+	if err != nil {
+		t.Fatalf("indexedPointers: %v", err)
+	}
+	want = map[string]indexedPointer{"data/a.bam": {oidC, 30}, "planted.bam": {oidA, 1}}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("incremental update = %+v, want %+v", files, want)
+	}
+
+	// A bare commit id is answered without saving a snapshot for it.
+	first, err := runGitCommand(ctx, repo, "rev-parse", "main~1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first = strings.TrimSpace(first)
+	files, err = indexedPointers(ctx, repo, first, logger)
+	if err != nil || len(files) != 2 || files["data/b.bam"].Oid != oidB {
+		t.Fatalf("pointers at %s = %+v, %v", first, files, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("expected only the main snapshot, got %v", entries)
+	}
+
+	// A snapshot whose commit is gone falls back to a full scan.
+	snap = loadSnapshot(dir, "main")
+	snap.Commit = strings.Repeat("d", 40)
+	if err := saveSnapshot(dir, *snap); err != nil {
+		t.Fatal(err)
+	}
+	files, err = indexedPointers(ctx, repo, "main", logger)
+	if err != nil || !reflect.DeepEqual(files, map[string]indexedPointer{"data/a.bam": {oidC, 30}}) {
+		t.Fatalf("rescan = %+v, %v", files, err)
+	}
+
+	if _, err := indexedPointers(ctx, repo, "no-such-branch", logger); err == nil {
+		t.Fatal("expected an error for an unknown ref")
+	}
+	if err := ClearPointerIndex(ctx, repo); err != nil {
+		t.Fatalf("ClearPointerIndex: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("index still present: %v", err)
+	}
+}
@@ -3,7 +3,6 @@ package lfs
 import (
 	"bytes"
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -13,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/calypr/git-drs/internal/projectdir"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	"github.com/calypr/syfon/client/hash"
 )
@@ -53,7 +53,7 @@ func GetAllLfsFiles(gitRemoteName, gitRemoteLocation string, branches []string,
 	if logger == nil {
 		return nil, fmt.Errorf("logger is required")
 	}
-	repoDir, err := os.Getwd()
+	repoDir, err := projectdir.Root()
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +87,7 @@ func GetLfsFilesForRefs(refs []string, logger *slog.Logger) (map[string]LfsFileI
 	if logger == nil {
 		return nil, fmt.Errorf("logger is required")
 	}
-	repoDir, err := os.Getwd()
+	repoDir, err := projectdir.Root()
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +118,7 @@ func GetWorktreeLfsFiles(logger *slog.Logger) (map[string]LfsFileInfo, error) {
 	if logger == nil {
 		return nil, fmt.Errorf("logger is required")
 	}
-	repoDir, err := os.Getwd()
+	repoDir, err := projectdir.Root()
 	if err != nil {
 		return nil, err
 	}
@@ -158,7 +158,7 @@ func GetTrackedLfsFiles(logger *slog.Logger) (map[string]LfsFileInfo, error) {
 	if logger == nil {
 		return nil, fmt.Errorf("logger is required")
 	}
-	repoDir, err := os.Getwd()
+	repoDir, err := projectdir.Root()
 	if err != nil {
 		return nil, err
 	}
@@ -186,32 +186,20 @@ func GetTrackedLfsFiles(logger *slog.Logger) (map[string]LfsFileInfo, error) {
 }
 
 func addFilesFromRef(ctx context.Context, repoDir, ref string, logger *slog.Logger, lfsFileMap map[string]LfsFileInfo) error {
-	paths, err := grepPointerPaths(ctx, repoDir, ref)
+	pointers, err := indexedPointers(ctx, repoDir, ref, logger)
 	if err != nil {
-		return fmt.Errorf("git grep failed for %s: %w", ref, err)
+		return fmt.Errorf("listing LFS pointers in %s: %w", ref, err)
 	}
-	for _, path := range paths {
-		blob, err := runGitCommand(ctx, repoDir, "show", fmt.Sprintf("%s:%s", ref, path))
-		if err != nil {
-			logger.Debug(fmt.Sprintf("skipping path %s in %s: unable to read blob", path, ref))
-			continue
-		}
-
-		pointer, ok := parseLFSPointer(blob)
-		if !ok {
-			continue
-		}
-
+	for path, p := range pointers {
 		lfsFileMap[path] = LfsFileInfo{
 			Name:      path,
-			Size:      pointer.Size,
+			Size:      p.Size,
 			IsPointer: true,
-			OidType:   pointer.OidType,
-			Oid:       pointer.Oid,
-			Version:   pointer.Version,
+			OidType:   "sha256",
+			Oid:       p.Oid,
+			Version:   "https://git-lfs.github.com/spec/v1",
 		}
 	}
-
 	return nil
 }
 
@@ -232,6 +220,22 @@ func listTrackedWorktreeFiles(ctx context.Context, repoDir string) ([]string, er
 	return paths, nil
 }
 
+// TrackedPaths returns the indexed paths whose filter attribute routes them
+// through git-drs or git-lfs.
+func TrackedPaths(ctx context.Context, repoDir string) ([]string, error) {
+	paths, err := listTrackedWorktreeFiles(ctx, repoDir)
+	if err != nil {
+		return nil, err
+	}
+	return filterLfsTrackedPaths(ctx, repoDir, paths)
+}
+
+// FilterTrackedPaths returns the repo-relative paths whose filter attribute
+// routes them through git-drs or git-lfs.
+func FilterTrackedPaths(ctx context.Context, repoDir string, paths []string) ([]string, error) {
+	return filterLfsTrackedPaths(ctx, repoDir, paths)
+}
+
 func filterLfsTrackedPaths(ctx context.Context, repoDir string, paths []string) ([]string, error) {
 	if len(paths) == 0 {
 		return nil, nil
@@ -314,42 +318,6 @@ func readIndexPointerInfo(ctx context.Context, repoDir, path string) (LfsFileInf
 	}, true
 }
 
-func grepPointerPaths(ctx context.Context, repoDir, ref string) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "git", "grep", "-z", "-l", "https://git-lfs.github.com/spec/v1", ref, "--")
-	cmd.Dir = repoDir
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	err := cmd.Run()
-	if err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
-			return nil, nil
-		}
-		msg := strings.TrimSpace(stderr.String())
-		if msg == "" {
-			msg = err.Error()
-		}
-		return nil, fmt.Errorf("%s", msg)
-	}
-
-	raw := strings.Split(stdout.String(), "\x00")
-	paths := make([]string, 0, len(raw))
-	prefix := ref + ":"
-	for _, entry := range raw {
-		entry = strings.TrimSpace(entry)
-		if entry == "" {
-			continue
-		}
-		path := entry
-		if strings.HasPrefix(path, prefix) {
-			path = strings.TrimPrefix(path, prefix)
-		}
-		paths = append(paths, path)
-	}
-	return paths, nil
-}
-
 func runGitCommand(ctx context.Context, repoDir string, args ...string) (string, error) {
 	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = repoDir
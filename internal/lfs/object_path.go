@@ -4,14 +4,18 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+
+	"github.com/calypr/git-drs/internal/projectdir"
 )
 
-// ObjectPath returns the Git LFS fanout path for a sha256 object ID.
+// ObjectPath returns the Git LFS fanout path for a sha256 object ID. A
+// relative basePath, such as common.LFS_OBJS_PATH, is taken from the
+// repository root.
 func ObjectPath(basePath string, oid string) (string, error) {
 	oid = strings.TrimPrefix(oid, "sha256:")
 	if len(oid) != 64 {
 		return "", fmt.Errorf("error: %s is not a valid sha256 hash", oid)
 	}
 
-	return filepath.Join(basePath, oid[:2], oid[2:4], oid), nil
+	return filepath.Join(projectdir.Path(basePath), oid[:2], oid[2:4], oid), nil
 }
@@ -0,0 +1,122 @@
+package lfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/common"
+)
+
+// LinkFromShared places oid from sharedDir, a machine-wide object cache with
+// the same fanout layout as the LFS object directory, at path. The object is
+// hard-linked so clones on one machine share a single copy; it is copied
+// when sharedDir is on another filesystem. It reports false when sharedDir
+// is unset or does not hold oid. Content that does not hash to oid, because
+// another user of the cache wrote or damaged it, is never linked; it is
+// removed from the cache when permissions allow and reported as an error so
+// the caller downloads the object instead.
+func LinkFromShared(sharedDir, oid, path string) (bool, error) {
+	if sharedDir == "" {
+		return false, nil
+	}
+	src, err := ObjectPath(sharedDir, oid)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(src); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, err
+	}
+	if err := linkOrCopy(src, path, 0o444); err != nil {
+		return false, fmt.Errorf("link %s from shared cache: %w", oid, err)
+	}
+	// Hash what was linked, not the cache entry before linking, so the
+	// entry cannot change in between.
+	sum, err := common.CalculateFileSHA256(path)
+	if err != nil {
+		_ = os.Remove(path)
+		return false, err
+	}
+	if sum != strings.TrimPrefix(oid, "sha256:") {
+		_ = os.Remove(path)
+		_ = os.Remove(src)
+		return false, fmt.Errorf("shared cache object %s is corrupt: content hashes to %s", oid, sum)
+	}
+	return true, nil
+}
+
+// StoreInShared adds the object at path to sharedDir so other clones can
+// link it. Only verified content should be stored. Directories are created
+// group-writable so users sharing the cache can all add to it. Objects are
+// read-only so no clone can change content the others link; a hard link
+// shares path's mode, so path is made read-only first.
+func StoreInShared(sharedDir, oid, path string) error {
+	if sharedDir == "" {
+		return nil
+	}
+	dst, err := ObjectPath(sharedDir, oid)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o775); err != nil {
+		return fmt.Errorf("create shared cache dir: %w", err)
+	}
+	if err := os.Chmod(path, 0o444); err != nil {
+		return fmt.Errorf("make %s read-only: %w", oid, err)
+	}
+	if err := linkOrCopy(path, dst, 0o444); err != nil {
+		return fmt.Errorf("store %s in shared cache: %w", oid, err)
+	}
+	return nil
+}
+
+// linkOrCopy hard-links src to dst, copying through a temporary file when a
+// link is not possible. An existing dst is left alone: both hold the same
+// object.
+func linkOrCopy(src, dst string, mode os.FileMode) error {
+	err := os.Link(src, dst)
+	if err == nil || errors.Is(err, fs.ErrExist) {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := io.Copy(tmp, in); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, dst); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package lfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSharedCacheStoreThenLink(t *testing.T) {
+	oid := sha256Hex("object")
+	shared := filepath.Join(t.TempDir(), "shared")
+	first := filepath.Join(t.TempDir(), "clone1", oid)
+	second := filepath.Join(t.TempDir(), "clone2", oid)
+
+	if linked, err := LinkFromShared(shared, oid, second); err != nil || linked {
+		t.Fatalf("expected a miss on an empty shared cache, got %v, %v", linked, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(first), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(first, []byte("object"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := StoreInShared(shared, oid, first); err != nil {
+		t.Fatalf("StoreInShared: %v", err)
+	}
+	// Storing again is a no-op.
+	if err := StoreInShared(shared, oid, first); err != nil {
+		t.Fatalf("StoreInShared again: %v", err)
+	}
+
+	linked, err := LinkFromShared(shared, oid, second)
+	if err != nil || !linked {
+		t.Fatalf("expected the object to be linked, got %v, %v", linked, err)
+	}
+	if got, err := os.ReadFile(second); err != nil || string(got) != "object" {
+		t.Fatalf("unexpected linked content %q: %v", got, err)
+	}
+	a, _ := os.Stat(first)
+	b, _ := os.Stat(second)
+	if !os.SameFile(a, b) {
+		t.Fatalf("expected clones on one filesystem to share a single copy")
+	}
+	if a.Mode().Perm()&0o222 != 0 {
+		t.Fatalf("expected the shared object to be read-only, got %v", a.Mode())
+	}
+}
+
+func TestSharedCacheRejectsCorruptObject(t *testing.T) {
+	oid := sha256Hex("object")
+	shared := filepath.Join(t.TempDir(), "shared")
+	src, err := ObjectPath(shared, oid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(src), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("tampered"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "clone", oid)
+	linked, err := LinkFromShared(shared, oid, path)
+	if err == nil || linked {
+		t.Fatalf("expected a corrupt shared object to be refused, got %v, %v", linked, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("corrupt object left in the clone: %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Fatalf("corrupt object left in the shared cache: %v", err)
+	}
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestSharedCacheUnset(t *testing.T) {
+	oid := strings.Repeat("ab", 32)
+	if linked, err := LinkFromShared("", oid, filepath.Join(t.TempDir(), oid)); err != nil || linked {
+		t.Fatalf("expected no shared cache, got %v, %v", linked, err)
+	}
+	if err := StoreInShared("", oid, "missing"); err != nil {
+		t.Fatalf("StoreInShared without a shared cache: %v", err)
+	}
+}
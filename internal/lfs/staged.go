@@ -0,0 +1,66 @@
+package lfs
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// StagedBlob describes the index entry of a path. When the staged content is
+// an LFS pointer, Oid and ObjectSize are the object it points to.
+type StagedBlob struct {
+	Size       int64
+	IsPointer  bool
+	Oid        string
+	ObjectSize int64
+}
+
+// ReadStagedBlobs describes the staged content of paths with a fixed number
+// of git cat-file calls, however many paths there are. Paths not in the
+// index are left out, and blobs too large to be pointers are never read.
+func ReadStagedBlobs(ctx context.Context, repoDir string, paths []string) (map[string]StagedBlob, error) {
+	staged := make(map[string]StagedBlob, len(paths))
+	if len(paths) == 0 {
+		return staged, nil
+	}
+	names := make([]string, len(paths))
+	for i, p := range paths {
+		names[i] = ":" + p
+	}
+	out, err := catFileBatch(ctx, repoDir, "--batch-check", names)
+	if err != nil {
+		return nil, err
+	}
+	blobOf := make(map[string]string, len(paths))
+	var blobs []string
+	for i, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+		if i >= len(paths) {
+			break
+		}
+		// <blob> blob <size>, or <name> missing
+		parts := strings.Fields(line)
+		if len(parts) != 3 || parts[1] != "blob" {
+			continue
+		}
+		size, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		staged[paths[i]] = StagedBlob{Size: size}
+		blobOf[paths[i]] = parts[0]
+		blobs = append(blobs, parts[0])
+	}
+
+	pointers, err := readPointerBlobs(ctx, repoDir, blobs)
+	if err != nil {
+		return nil, err
+	}
+	for path, blob := range blobOf {
+		if pointer, ok := pointers[blob]; ok {
+			entry := staged[path]
+			entry.IsPointer, entry.Oid, entry.ObjectSize = true, pointer.Oid, pointer.Size
+			staged[path] = entry
+		}
+	}
+	return staged, nil
+}
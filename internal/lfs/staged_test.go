@@ -0,0 +1,42 @@
+package lfs
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadStagedBlobs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+	repo := t.TempDir()
+	runGitCmdTest(t, repo, "init")
+
+	oid := strings.Repeat("a", 64)
+	writePointerFile(t, filepath.Join(repo, "data", "a.bam"), oid, "10")
+	writePointerFile(t, filepath.Join(repo, "data", "copy.bam"), oid, "10")
+	if err := os.WriteFile(filepath.Join(repo, "README"), []byte("plain\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGitCmdTest(t, repo, "add", ".")
+
+	staged, err := ReadStagedBlobs(context.Background(), repo, []string{"data/a.bam", "README", "missing.bam", "data/copy.bam"})
+	if err != nil {
+		t.Fatalf("ReadStagedBlobs: %v", err)
+	}
+	if len(staged) != 3 {
+		t.Fatalf("staged = %+v", staged)
+	}
+	for _, p := range []string{"data/a.bam", "data/copy.bam"} {
+		if b := staged[p]; !b.IsPointer || b.Oid != oid || b.ObjectSize != 10 {
+			t.Errorf("%s = %+v", p, b)
+		}
+	}
+	if b := staged["README"]; b.IsPointer || b.Size != 6 {
+		t.Errorf("README = %+v", b)
+	}
+}
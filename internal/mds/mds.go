@@ -0,0 +1,123 @@
+// Package mds publishes dataset-level records to the Gen3 metadata service so
+// file-level DRS records can be found from browsable study metadata.
+package mds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/httpclient"
+)
+
+// DefaultGUIDType is the _guid_type written when drs.mds.guid-type is unset;
+// the Gen3 discovery page lists records of this type.
+const DefaultGUIDType = "discovery_metadata"
+
+// Settings controls the post-push dataset record.
+type Settings struct {
+	// Enabled turns publishing on.
+	Enabled bool
+	// GUID is the metadata record key; empty uses "<organization>-<project>".
+	GUID string
+	// GUIDType is stored as _guid_type; empty uses DefaultGUIDType.
+	GUIDType string
+}
+
+// RecordGUID returns the configured GUID or the default for the scope.
+func (s Settings) RecordGUID(organization, project string) string {
+	if g := strings.TrimSpace(s.GUID); g != "" {
+		return g
+	}
+	if strings.TrimSpace(organization) == "" {
+		return project
+	}
+	return organization + "-" + project
+}
+
+func (s Settings) guidType() string {
+	if t := strings.TrimSpace(s.GUIDType); t != "" {
+		return t
+	}
+	return DefaultGUIDType
+}
+
+// Dataset is the git-drs block of the metadata record. It is stored under the
+// "git_drs" key so curated fields on the same record are left alone.
+type Dataset struct {
+	RepoURL      string   `json:"repo_url"`
+	Commit       string   `json:"commit"`
+	Branch       string   `json:"branch,omitempty"`
+	Remote       string   `json:"remote"`
+	Organization string   `json:"organization,omitempty"`
+	Project      string   `json:"project"`
+	DRSIDs       []string `json:"drs_ids"`
+	UpdatedAt    string   `json:"updated_at"`
+}
+
+// httpClient sends metadata requests; swapped in tests.
+var httpClient = httpclient.NewAPI()
+
+// Publish creates or updates the record guid at endpoint. The record is
+// merged into an existing one (PUT ?merge=true) and created with POST when
+// the service reports it missing.
+func Publish(ctx context.Context, endpoint, token string, s Settings, guid string, d Dataset) error {
+	if d.UpdatedAt == "" {
+		d.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	if d.DRSIDs == nil {
+		d.DRSIDs = []string{}
+	}
+	body, err := json.Marshal(map[string]any{
+		"_guid_type": s.guidType(),
+		"git_drs":    d,
+	})
+	if err != nil {
+		return err
+	}
+	base := strings.TrimRight(endpoint, "/") + "/mds/metadata/" + url.PathEscape(guid)
+
+	status, err := send(ctx, http.MethodPut, base+"?merge=true", token, body)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusNotFound {
+		return nil
+	}
+	status, err = send(ctx, http.MethodPost, base, token, body)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNotFound {
+		return fmt.Errorf("no metadata service at %s", strings.TrimRight(endpoint, "/")+"/mds")
+	}
+	return nil
+}
+
+// send returns the status for 2xx and 404 responses and an error otherwise.
+func send(ctx context.Context, method, target, token string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 || resp.StatusCode == http.StatusNotFound {
+		return resp.StatusCode, nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return resp.StatusCode, fmt.Errorf("%s %s returned status %d: %s", method, target, resp.StatusCode, strings.TrimSpace(string(msg)))
+}
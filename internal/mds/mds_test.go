@@ -0,0 +1,86 @@
+package mds
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPublishCreatesRecordWhenMissing(t *testing.T) {
+	var calls []string
+	var body map[string]json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.RequestURI())
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("missing bearer token")
+		}
+		if r.Method == http.MethodPut {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	d := Dataset{RepoURL: "https://example/repo.git", Commit: "abc", Remote: "origin", Project: "p1"}
+	if err := Publish(context.Background(), srv.URL+"/", "tok", Settings{Enabled: true}, "org-p1", d); err != nil {
+		t.Fatalf("Publish error: %v", err)
+	}
+	want := []string{"PUT /mds/metadata/org-p1?merge=true", "POST /mds/metadata/org-p1"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+	if string(body["_guid_type"]) != `"discovery_metadata"` {
+		t.Fatalf("unexpected guid type %s", body["_guid_type"])
+	}
+	var got Dataset
+	if err := json.Unmarshal(body["git_drs"], &got); err != nil {
+		t.Fatalf("decode git_drs: %v", err)
+	}
+	if got.Commit != "abc" || got.DRSIDs == nil || got.UpdatedAt == "" {
+		t.Fatalf("unexpected dataset %+v", got)
+	}
+}
+
+func TestPublishMergesExistingRecord(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Method != http.MethodPut || r.URL.Query().Get("merge") != "true" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer srv.Close()
+
+	if err := Publish(context.Background(), srv.URL, "", Settings{GUIDType: "dataset"}, "g", Dataset{}); err != nil {
+		t.Fatalf("Publish error: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single merge request, got %d", calls)
+	}
+}
+
+func TestPublishReportsServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	err := Publish(context.Background(), srv.URL, "", Settings{}, "g", Dataset{})
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected 403 error, got %v", err)
+	}
+}
+
+func TestRecordGUID(t *testing.T) {
+	if got := (Settings{}).RecordGUID("htan", "p1"); got != "htan-p1" {
+		t.Fatalf("unexpected default guid %q", got)
+	}
+	if got := (Settings{GUID: "study-1"}).RecordGUID("htan", "p1"); got != "study-1" {
+		t.Fatalf("unexpected configured guid %q", got)
+	}
+}
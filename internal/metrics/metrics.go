@@ -0,0 +1,215 @@
+// Package metrics records data-plane measurements for one git-drs invocation
+// (transfer durations and bytes, DRS server request latencies, and retried
+// requests) and reports them when the command exits: as a summary on stderr,
+// pushed to a Prometheus pushgateway, or sent to an OTLP/HTTP collector.
+//
+// Recording is always on and cheap; nothing is reported unless Settings
+// enables an output.
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transfer directions.
+const (
+	Upload   = "upload"
+	Download = "download"
+)
+
+// Settings selects where metrics are reported at exit.
+type Settings struct {
+	// Summary prints a human-readable summary to stderr.
+	Summary bool
+	// Pushgateway is a Prometheus pushgateway base URL.
+	Pushgateway string
+	// OTLPEndpoint is an OTLP/HTTP collector base URL; metrics are posted to
+	// <endpoint>/v1/metrics as JSON.
+	OTLPEndpoint string
+	// Job names the pushgateway job and the OTLP service; empty uses "git-drs".
+	Job string
+}
+
+// Enabled reports whether any output is configured.
+func (s Settings) Enabled() bool {
+	return s.Summary || strings.TrimSpace(s.Pushgateway) != "" || strings.TrimSpace(s.OTLPEndpoint) != ""
+}
+
+func (s Settings) job() string {
+	if j := strings.TrimSpace(s.Job); j != "" {
+		return j
+	}
+	return "git-drs"
+}
+
+// durations aggregates observed durations in seconds.
+type durations struct {
+	Count int64
+	Sum   float64
+	Max   float64
+}
+
+func (d *durations) observe(v time.Duration) {
+	s := v.Seconds()
+	d.Count++
+	d.Sum += s
+	if s > d.Max {
+		d.Max = s
+	}
+}
+
+// TransferStats aggregates transfers in one direction.
+type TransferStats struct {
+	Failures int64
+	Bytes    int64
+	Duration durations
+}
+
+// RequestStats aggregates requests to one DRS server API.
+type RequestStats struct {
+	Retries int64
+	Latency durations
+}
+
+// Snapshot is a copy of everything recorded so far.
+type Snapshot struct {
+	Start     time.Time
+	Transfers map[string]TransferStats
+	Requests  map[string]RequestStats
+}
+
+// Empty reports whether nothing has been recorded.
+func (s Snapshot) Empty() bool {
+	return len(s.Transfers) == 0 && len(s.Requests) == 0
+}
+
+type registry struct {
+	mu        sync.Mutex
+	start     time.Time
+	transfers map[string]*TransferStats
+	requests  map[string]*RequestStats
+}
+
+func newRegistry() *registry {
+	return &registry{
+		start:     time.Now(),
+		transfers: make(map[string]*TransferStats),
+		requests:  make(map[string]*RequestStats),
+	}
+}
+
+// std is the process-wide registry; Reset replaces it in tests.
+var std = newRegistry()
+
+// Reset discards everything recorded so far.
+func Reset() {
+	r := newRegistry()
+	std.mu.Lock()
+	std.start, std.transfers, std.requests = r.start, r.transfers, r.requests
+	std.mu.Unlock()
+}
+
+// RecordTransfer records one upload or download of n bytes that took d.
+// Failed transfers count toward Failures and are not added to Bytes.
+func RecordTransfer(direction string, n int64, d time.Duration, err error) {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	t := std.transfers[direction]
+	if t == nil {
+		t = &TransferStats{}
+		std.transfers[direction] = t
+	}
+	t.Duration.observe(d)
+	if err != nil {
+		t.Failures++
+		return
+	}
+	t.Bytes += n
+}
+
+// RecordRequest records one request attempt to a DRS server API. retryable
+// marks attempts the client retries (transport errors, 429, and 5xx).
+func RecordRequest(api string, d time.Duration, retryable bool) {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	r := std.requests[api]
+	if r == nil {
+		r = &RequestStats{}
+		std.requests[api] = r
+	}
+	r.Latency.observe(d)
+	if retryable {
+		r.Retries++
+	}
+}
+
+// Current returns a copy of everything recorded so far.
+func Current() Snapshot {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	s := Snapshot{
+		Start:     std.start,
+		Transfers: make(map[string]TransferStats, len(std.transfers)),
+		Requests:  make(map[string]RequestStats, len(std.requests)),
+	}
+	for k, v := range std.transfers {
+		s.Transfers[k] = *v
+	}
+	for k, v := range std.requests {
+		s.Requests[k] = *v
+	}
+	return s
+}
+
+// Transport wraps base so every request is timed and attributed to the DRS
+// server API its path belongs to. A nil base uses http.DefaultTransport.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripper{base: base}
+}
+
+type roundTripper struct {
+	base http.RoundTripper
+}
+
+func (t roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	retryable := err != nil
+	if resp != nil {
+		retryable = resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented)
+	}
+	RecordRequest(apiForPath(req.URL.Path), time.Since(start), retryable)
+	return resp, err
+}
+
+// apiForPath groups a request path by the server API it targets.
+func apiForPath(p string) string {
+	switch {
+	case strings.Contains(p, "/ga4gh/drs/"):
+		return "drs"
+	case strings.HasPrefix(p, "/index"):
+		return "indexd"
+	case strings.HasPrefix(p, "/data"):
+		return "data"
+	case strings.Contains(p, "/info/lfs"):
+		return "lfs"
+	default:
+		return "other"
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
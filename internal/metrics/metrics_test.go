@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndSummarize(t *testing.T) {
+	Reset()
+	RecordTransfer(Upload, 2048, 2*time.Second, nil)
+	RecordTransfer(Upload, 4096, time.Second, errors.New("boom"))
+	RecordRequest("indexd", 100*time.Millisecond, false)
+	RecordRequest("indexd", 300*time.Millisecond, true)
+
+	snap := Current()
+	up := snap.Transfers[Upload]
+	if up.Duration.Count != 2 || up.Failures != 1 || up.Bytes != 2048 {
+		t.Fatalf("unexpected upload stats: %+v", up)
+	}
+	idx := snap.Requests["indexd"]
+	if idx.Latency.Count != 2 || idx.Retries != 1 || idx.Latency.Max < 0.29 {
+		t.Fatalf("unexpected indexd stats: %+v", idx)
+	}
+
+	var out bytes.Buffer
+	WriteSummary(&out, snap, "push")
+	for _, want := range []string{"git drs push", "upload", "2 transfers", "1 failed", "indexd", "2 requests", "avg 200ms", "1 retried"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("summary missing %q:\n%s", want, out.String())
+		}
+	}
+
+	var prom bytes.Buffer
+	WritePrometheus(&prom, snap)
+	for _, want := range []string{
+		"# TYPE git_drs_transfer_bytes_total counter",
+		`git_drs_transfer_bytes_total{direction="upload"} 2048`,
+		`git_drs_request_retries_total{api="indexd"} 1`,
+		"# TYPE git_drs_request_duration_seconds_max gauge",
+	} {
+		if !strings.Contains(prom.String(), want) {
+			t.Fatalf("prometheus output missing %q:\n%s", want, prom.String())
+		}
+	}
+}
+
+func TestTransportAttributesRequestsByAPI(t *testing.T) {
+	Reset()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/index") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: Transport(nil)}
+	for _, p := range []string{"/index/abc", "/ga4gh/drs/v1/objects/abc", "/data/upload/abc"} {
+		resp, err := client.Get(srv.URL + p)
+		if err != nil {
+			t.Fatalf("GET %s: %v", p, err)
+		}
+		resp.Body.Close()
+	}
+
+	snap := Current()
+	if snap.Requests["indexd"].Retries != 1 || snap.Requests["drs"].Latency.Count != 1 || snap.Requests["data"].Retries != 0 {
+		t.Fatalf("unexpected request stats: %+v", snap.Requests)
+	}
+}
+
+func TestFlushPushesToGatewayAndCollector(t *testing.T) {
+	Reset()
+	RecordTransfer(Download, 10, time.Second, nil)
+
+	var gatewayPath, gatewayBody string
+	var otlp otlpRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/metrics/job/"):
+			gatewayPath, gatewayBody = r.URL.Path, string(body)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/metrics":
+			if err := json.Unmarshal(body, &otlp); err != nil {
+				t.Errorf("decode otlp: %v", err)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	s := Settings{Pushgateway: srv.URL, OTLPEndpoint: srv.URL, Job: "ci"}
+	if err := Flush(context.Background(), s, "pull", nil); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if gatewayPath != "/metrics/job/ci/command/pull" || !strings.Contains(gatewayBody, `git_drs_transfer_bytes_total{direction="download"} 10`) {
+		t.Fatalf("unexpected push %s:\n%s", gatewayPath, gatewayBody)
+	}
+	if len(otlp.ResourceMetrics) != 1 || otlp.ResourceMetrics[0].Resource.Attributes[0].Value.StringValue != "ci" {
+		t.Fatalf("unexpected otlp payload: %+v", otlp)
+	}
+	metrics := otlp.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) == 0 || metrics[0].Sum == nil || len(metrics[0].Sum.DataPoints) != 1 {
+		t.Fatalf("unexpected otlp metrics: %+v", metrics)
+	}
+
+	// Nothing is sent when no output is enabled.
+	gatewayPath = ""
+	if err := Flush(context.Background(), Settings{}, "pull", nil); err != nil || gatewayPath != "" {
+		t.Fatalf("disabled flush sent %q: %v", gatewayPath, err)
+	}
+}
@@ -0,0 +1,262 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/httpclient"
+	"github.com/calypr/git-drs/internal/progressui"
+)
+
+// httpClient sends pushgateway and OTLP requests; swapped in tests.
+var httpClient = httpclient.New(10 * time.Second)
+
+// Flush reports everything recorded to the outputs s enables. command labels
+// the series (for example "push"). Nothing is sent when nothing was recorded.
+func Flush(ctx context.Context, s Settings, command string, stderr io.Writer) error {
+	snap := Current()
+	if !s.Enabled() || snap.Empty() {
+		return nil
+	}
+	var errs []error
+	if s.Summary && stderr != nil {
+		WriteSummary(stderr, snap, command)
+	}
+	if gw := strings.TrimSpace(s.Pushgateway); gw != "" {
+		if err := push(ctx, gw, s.job(), command, snap); err != nil {
+			errs = append(errs, fmt.Errorf("pushgateway: %w", err))
+		}
+	}
+	if ep := strings.TrimSpace(s.OTLPEndpoint); ep != "" {
+		if err := sendOTLP(ctx, ep, s.job(), command, snap); err != nil {
+			errs = append(errs, fmt.Errorf("otlp: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WriteSummary prints one line per transfer direction and server API.
+func WriteSummary(w io.Writer, snap Snapshot, command string) {
+	fmt.Fprintf(w, "Metrics for git drs %s (%s):\n", command, time.Since(snap.Start).Round(time.Millisecond))
+	for _, dir := range sortedKeys(snap.Transfers) {
+		t := snap.Transfers[dir]
+		line := fmt.Sprintf("  %-9s %d transfers, %s in %s", dir, t.Duration.Count,
+			progressui.FormatBinaryBytes(t.Bytes), seconds(t.Duration.Sum))
+		if t.Failures > 0 {
+			line += fmt.Sprintf(", %d failed", t.Failures)
+		}
+		fmt.Fprintln(w, line)
+	}
+	for _, api := range sortedKeys(snap.Requests) {
+		r := snap.Requests[api]
+		avg := 0.0
+		if r.Latency.Count > 0 {
+			avg = r.Latency.Sum / float64(r.Latency.Count)
+		}
+		line := fmt.Sprintf("  %-9s %d requests, avg %s, max %s", api, r.Latency.Count, seconds(avg), seconds(r.Latency.Max))
+		if r.Retries > 0 {
+			line += fmt.Sprintf(", %d retried", r.Retries)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second)).Round(time.Millisecond)
+}
+
+// series is one exported value.
+type series struct {
+	name    string
+	help    string
+	counter bool
+	labels  [][2]string
+	value   float64
+}
+
+func (snap Snapshot) series() []series {
+	var out []series
+	for _, dir := range sortedKeys(snap.Transfers) {
+		t := snap.Transfers[dir]
+		l := [][2]string{{"direction", dir}}
+		out = append(out,
+			series{"git_drs_transfers_total", "Transfers attempted.", true, l, float64(t.Duration.Count)},
+			series{"git_drs_transfer_failures_total", "Transfers that failed.", true, l, float64(t.Failures)},
+			series{"git_drs_transfer_bytes_total", "Bytes moved by successful transfers.", true, l, float64(t.Bytes)},
+			series{"git_drs_transfer_duration_seconds_total", "Time spent in transfers.", true, l, t.Duration.Sum},
+			series{"git_drs_transfer_duration_seconds_max", "Longest transfer.", false, l, t.Duration.Max},
+		)
+	}
+	for _, api := range sortedKeys(snap.Requests) {
+		r := snap.Requests[api]
+		l := [][2]string{{"api", api}}
+		out = append(out,
+			series{"git_drs_requests_total", "DRS server request attempts.", true, l, float64(r.Latency.Count)},
+			series{"git_drs_request_retries_total", "Request attempts that failed with a retryable error.", true, l, float64(r.Retries)},
+			series{"git_drs_request_duration_seconds_total", "Time spent waiting on DRS server requests.", true, l, r.Latency.Sum},
+			series{"git_drs_request_duration_seconds_max", "Slowest DRS server request.", false, l, r.Latency.Max},
+		)
+	}
+	return out
+}
+
+// WritePrometheus writes snap in the Prometheus text exposition format.
+func WritePrometheus(w io.Writer, snap Snapshot) {
+	seen := make(map[string]bool)
+	for _, s := range snap.series() {
+		if !seen[s.name] {
+			seen[s.name] = true
+			kind := "gauge"
+			if s.counter {
+				kind = "counter"
+			}
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", s.name, s.help, s.name, kind)
+		}
+		parts := make([]string, len(s.labels))
+		for i, l := range s.labels {
+			parts[i] = fmt.Sprintf("%s=%q", l[0], l[1])
+		}
+		fmt.Fprintf(w, "%s{%s} %s\n", s.name, strings.Join(parts, ","), strconv.FormatFloat(s.value, 'g', -1, 64))
+	}
+}
+
+// push replaces the job/command group on a Prometheus pushgateway.
+func push(ctx context.Context, gateway, job, command string, snap Snapshot) error {
+	var body bytes.Buffer
+	WritePrometheus(&body, snap)
+	target := strings.TrimRight(gateway, "/") + "/metrics/job/" + url.PathEscape(job)
+	if command != "" {
+		target += "/command/" + url.PathEscape(command)
+	}
+	return send(ctx, http.MethodPut, target, "text/plain; version=0.0.4", body.Bytes())
+}
+
+// OTLP/HTTP JSON shapes, limited to what sendOTLP emits.
+type (
+	otlpRequest struct {
+		ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+	}
+	otlpResourceMetrics struct {
+		Resource     otlpResource       `json:"resource"`
+		ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeMetrics struct {
+		Scope   otlpScope    `json:"scope"`
+		Metrics []otlpMetric `json:"metrics"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpMetric struct {
+		Name        string     `json:"name"`
+		Description string     `json:"description,omitempty"`
+		Sum         *otlpSum   `json:"sum,omitempty"`
+		Gauge       *otlpGauge `json:"gauge,omitempty"`
+	}
+	otlpSum struct {
+		DataPoints             []otlpDataPoint `json:"dataPoints"`
+		AggregationTemporality int             `json:"aggregationTemporality"`
+		IsMonotonic            bool            `json:"isMonotonic"`
+	}
+	otlpGauge struct {
+		DataPoints []otlpDataPoint `json:"dataPoints"`
+	}
+	otlpDataPoint struct {
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		TimeUnixNano      string          `json:"timeUnixNano"`
+		AsDouble          float64         `json:"asDouble"`
+	}
+	otlpAttribute struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpAnyValue struct {
+		StringValue string `json:"stringValue"`
+	}
+)
+
+// otlpCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE.
+const otlpCumulative = 2
+
+func buildOTLP(job, command string, snap Snapshot, now time.Time) otlpRequest {
+	start := strconv.FormatInt(snap.Start.UnixNano(), 10)
+	end := strconv.FormatInt(now.UnixNano(), 10)
+	var metrics []otlpMetric
+	index := make(map[string]int)
+	for _, s := range snap.series() {
+		attrs := make([]otlpAttribute, 0, len(s.labels)+1)
+		for _, l := range s.labels {
+			attrs = append(attrs, otlpAttribute{Key: l[0], Value: otlpAnyValue{StringValue: l[1]}})
+		}
+		if command != "" {
+			attrs = append(attrs, otlpAttribute{Key: "command", Value: otlpAnyValue{StringValue: command}})
+		}
+		dp := otlpDataPoint{Attributes: attrs, StartTimeUnixNano: start, TimeUnixNano: end, AsDouble: s.value}
+		i, ok := index[s.name]
+		if !ok {
+			m := otlpMetric{Name: s.name, Description: s.help}
+			if s.counter {
+				m.Sum = &otlpSum{AggregationTemporality: otlpCumulative, IsMonotonic: true}
+			} else {
+				m.Gauge = &otlpGauge{}
+			}
+			i = len(metrics)
+			index[s.name] = i
+			metrics = append(metrics, m)
+		}
+		if metrics[i].Sum != nil {
+			metrics[i].Sum.DataPoints = append(metrics[i].Sum.DataPoints, dp)
+		} else {
+			metrics[i].Gauge.DataPoints = append(metrics[i].Gauge.DataPoints, dp)
+		}
+	}
+	return otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpAnyValue{StringValue: job}},
+		}},
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: "git-drs"}, Metrics: metrics}},
+	}}}
+}
+
+func sendOTLP(ctx context.Context, endpoint, job, command string, snap Snapshot) error {
+	body, err := json.Marshal(buildOTLP(job, command, snap, time.Now()))
+	if err != nil {
+		return err
+	}
+	target := strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(target, "/v1/metrics") {
+		target += "/v1/metrics"
+	}
+	return send(ctx, http.MethodPost, target, "application/json", body)
+}
+
+func send(ctx context.Context, method, target, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s %s", method, target, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
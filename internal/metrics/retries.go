@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type retryCountKey struct{}
+
+// WithRetryCount returns a context under which every retried DRS server
+// request is added to n, so a caller can attribute retries to one object.
+func WithRetryCount(ctx context.Context, n *atomic.Int64) context.Context {
+	return context.WithValue(ctx, retryCountKey{}, n)
+}
+
+// CountRetry adds one to the retry count carried by ctx, if any.
+func CountRetry(ctx context.Context) {
+	if n, ok := ctx.Value(retryCountKey{}).(*atomic.Int64); ok {
+		n.Add(1)
+	}
+}
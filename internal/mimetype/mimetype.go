@@ -0,0 +1,81 @@
+// Package mimetype detects the content type recorded in a DRS object's
+// mime_type, which portals use to preview files and to apply egress rules.
+package mimetype
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+// Unknown is the type of content neither its name nor its bytes identify.
+const Unknown = "application/octet-stream"
+
+// sniffLen is how much content http.DetectContentType considers.
+const sniffLen = 512
+
+// Detect returns the media type, without parameters, of a file named name
+// whose content begins with head. Magic bytes that identify a specific
+// format win, since they describe what the file is; otherwise a known
+// extension decides, which tells text/csv apart from text/plain.
+func Detect(name string, head []byte) string {
+	sniffed := ""
+	if len(head) > 0 {
+		sniffed = mediaType(http.DetectContentType(head))
+	}
+	if sniffed != "" && sniffed != Unknown && sniffed != "text/plain" {
+		return sniffed
+	}
+	if byExt := mediaType(mime.TypeByExtension(strings.ToLower(path.Ext(name)))); byExt != "" {
+		return byExt
+	}
+	if sniffed != "" {
+		return sniffed
+	}
+	return Unknown
+}
+
+// DetectFile is Detect for the content stored at file. When file cannot be
+// read the type comes from name alone.
+func DetectFile(name, file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return Detect(name, nil)
+	}
+	defer f.Close()
+	head := make([]byte, sniffLen)
+	n, _ := io.ReadFull(f, head)
+	return Detect(name, head[:n])
+}
+
+// ForOID returns the type of the LFS object oid committed as name: the one
+// recorded on its staged DRS object, or else one detected from the object in
+// the local LFS store.
+func ForOID(name, oid string) string {
+	if obj, err := drsobject.ReadObject(common.DRS_OBJS_PATH, oid); err == nil && obj != nil && obj.MimeType != nil && *obj.MimeType != "" {
+		return *obj.MimeType
+	}
+	file, err := lfs.ObjectPath(common.LFS_OBJS_PATH, oid)
+	if err != nil {
+		return Detect(name, nil)
+	}
+	return DetectFile(name, file)
+}
+
+func mediaType(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	mt, _, err := mime.ParseMediaType(raw)
+	if err != nil {
+		return ""
+	}
+	return mt
+}
@@ -0,0 +1,43 @@
+package mimetype
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	cases := []struct {
+		name string
+		head []byte
+		want string
+	}{
+		// Magic bytes win over a misleading extension.
+		{"figure.json", png, "image/png"},
+		{"calls.vcf.gz", []byte("\x1f\x8b\x08\x04\x00\x00\x00\x00"), "application/x-gzip"},
+		// Plain text takes its specific type from the extension.
+		{"meta.json", []byte(`{"sample": "S1"}`), "application/json"},
+		{"notes", []byte("hello\n"), "text/plain"},
+		{"report.PDF", nil, "application/pdf"},
+		{"reads.unknownext", nil, Unknown},
+	}
+	for _, tc := range cases {
+		if got := Detect(tc.name, tc.head); got != tc.want {
+			t.Errorf("Detect(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDetectFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "blob")
+	if err := os.WriteFile(file, []byte("%PDF-1.7\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := DetectFile("paper.unknownext", file); got != "application/pdf" {
+		t.Fatalf("DetectFile = %q, want application/pdf", got)
+	}
+	if got := DetectFile("figure.png", filepath.Join(t.TempDir(), "missing")); got != "image/png" {
+		t.Fatalf("DetectFile without content = %q, want image/png from the name", got)
+	}
+}
@@ -0,0 +1,178 @@
+package mountfs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/calypr/git-drs/internal/common"
+)
+
+// DefaultBlockSize is the size of each cached block and of each ranged read.
+const DefaultBlockSize = 4 << 20
+
+// blockSizeMarker records the block size the cache was filled with.
+const blockSizeMarker = "block-size"
+
+// BlockCache stores fixed-size blocks of object content on disk, keyed by oid
+// and block index, so re-reading a region never goes back to the network.
+// When the cache grows past its limit the least recently used blocks are
+// removed.
+type BlockCache struct {
+	dir       string
+	blockSize int64
+	maxBytes  int64
+
+	mu     sync.Mutex
+	used   int64
+	blocks map[string]cachedBlock
+}
+
+type cachedBlock struct {
+	size     int64
+	lastUsed time.Time
+}
+
+// OpenBlockCache opens the cache at dir, accounting for blocks left by
+// earlier runs. maxBytes of zero or less disables eviction.
+func OpenBlockCache(dir string, blockSize, maxBytes int64) (*BlockCache, error) {
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+	c := &BlockCache{dir: dir, blockSize: blockSize, maxBytes: maxBytes, blocks: map[string]cachedBlock{}}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == dir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() || d.Name() == blockSizeMarker {
+			return nil
+		}
+		if common.IsAtomicTempFile(d.Name()) {
+			return os.Remove(path)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		c.blocks[path] = cachedBlock{size: info.Size(), lastUsed: info.ModTime()}
+		c.used += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open block cache %s: %w", dir, err)
+	}
+	// Blocks cached with a different block size cannot be reused.
+	marker := filepath.Join(dir, blockSizeMarker)
+	want := strconv.FormatInt(blockSize, 10)
+	if got, err := os.ReadFile(marker); err == nil && string(got) != want {
+		if err := c.clear(); err != nil {
+			return nil, err
+		}
+	}
+	if err := common.WriteFileAtomic(marker, []byte(want), 0o644); err != nil {
+		return nil, err
+	}
+	c.evict()
+	return c, nil
+}
+
+// BlockSize is the cache's block size.
+func (c *BlockCache) BlockSize() int64 {
+	return c.blockSize
+}
+
+func (c *BlockCache) path(oid string, index int64) string {
+	return filepath.Join(c.dir, oid[:2], oid, strconv.FormatInt(index, 10))
+}
+
+// Get returns a cached block.
+func (c *BlockCache) Get(oid string, index int64) ([]byte, bool) {
+	path := c.path(oid, index)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	if b, ok := c.blocks[path]; ok {
+		b.lastUsed = time.Now()
+		c.blocks[path] = b
+	}
+	c.mu.Unlock()
+	return data, true
+}
+
+// Put stores a block, evicting older blocks if the cache is over its limit.
+func (c *BlockCache) Put(oid string, index int64, data []byte) error {
+	path := c.path(oid, index)
+	if err := common.WriteFileAtomic(path, data, 0o644); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.blocks[path]; ok {
+		c.used -= old.size
+	}
+	c.blocks[path] = cachedBlock{size: int64(len(data)), lastUsed: time.Now()}
+	c.used += int64(len(data))
+	c.evictLocked()
+	return nil
+}
+
+// Used is the number of bytes currently cached.
+func (c *BlockCache) Used() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.used
+}
+
+func (c *BlockCache) evict() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evictLocked()
+}
+
+// evictLocked removes least recently used blocks until the cache is at 90%
+// of its limit, so eviction does not run on every Put once the cache is full.
+func (c *BlockCache) evictLocked() {
+	if c.maxBytes <= 0 || c.used <= c.maxBytes {
+		return
+	}
+	paths := make([]string, 0, len(c.blocks))
+	for path := range c.blocks {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return c.blocks[paths[i]].lastUsed.Before(c.blocks[paths[j]].lastUsed)
+	})
+	target := c.maxBytes / 10 * 9
+	for _, path := range paths {
+		if c.used <= target {
+			break
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		c.used -= c.blocks[path].size
+		delete(c.blocks, path)
+	}
+}
+
+func (c *BlockCache) clear() error {
+	for path := range c.blocks {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	c.blocks = map[string]cachedBlock{}
+	c.used = 0
+	return nil
+}
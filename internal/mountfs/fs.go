@@ -0,0 +1,374 @@
+// Package mountfs presents LFS-tracked files as a read-only, lazily hydrated
+// file tree, which git drs mount serves over WebDAV. Nothing is downloaded
+// up front: a read fetches only the blocks it touches with signed-URL ranged
+// reads and keeps them in a BlockCache keyed by oid. Objects already in the
+// local LFS store are read from there.
+package mountfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/lfs"
+	"golang.org/x/net/webdav"
+)
+
+// Fetcher reads object content from the remote.
+type Fetcher interface {
+	// ReadRange opens length bytes of oid's content starting at offset. It
+	// returns drsremote.ErrRangeUnsupported for objects that must be
+	// downloaded whole.
+	ReadRange(ctx context.Context, oid string, offset, length int64) (io.ReadCloser, error)
+	// Download writes oid's verified content to path.
+	Download(ctx context.Context, oid, path string) error
+}
+
+// Options configures New.
+type Options struct {
+	// LFSObjects is the local LFS object store (.git/lfs/objects). Objects
+	// found there are served locally; objects that cannot be read in ranges
+	// are downloaded into it.
+	LFSObjects string
+	Fetcher    Fetcher
+	Cache      *BlockCache
+	// ModTime is reported for every file and directory, typically the
+	// commit time of the mounted ref.
+	ModTime time.Time
+}
+
+// FS is a read-only webdav.FileSystem over a set of LFS files.
+type FS struct {
+	opts  Options
+	files map[string]lfs.LfsFileInfo
+	dirs  map[string][]string
+
+	mu       sync.Mutex
+	inflight map[string]*fetchCall
+}
+
+type fetchCall struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+var _ webdav.FileSystem = (*FS)(nil)
+
+// New builds the tree for files, keyed by repository-relative path.
+func New(files map[string]lfs.LfsFileInfo, opts Options) *FS {
+	f := &FS{
+		opts:     opts,
+		files:    make(map[string]lfs.LfsFileInfo, len(files)),
+		dirs:     map[string][]string{"": nil},
+		inflight: map[string]*fetchCall{},
+	}
+	children := map[string]map[string]bool{"": {}}
+	for name, info := range files {
+		name = strings.Trim(path.Clean(filepath.ToSlash(name)), "/")
+		if name == "" || name == "." || strings.HasPrefix(name, "../") {
+			continue
+		}
+		f.files[name] = info
+		for child := name; child != ""; {
+			parent := path.Dir(child)
+			if parent == "." {
+				parent = ""
+			}
+			if children[parent] == nil {
+				children[parent] = map[string]bool{}
+			}
+			children[parent][path.Base(child)] = true
+			child = parent
+		}
+	}
+	for dir, names := range children {
+		list := make([]string, 0, len(names))
+		for name := range names {
+			list = append(list, name)
+		}
+		sort.Strings(list)
+		f.dirs[dir] = list
+	}
+	return f
+}
+
+func clean(name string) string {
+	return strings.Trim(path.Clean("/"+name), "/")
+}
+
+func (f *FS) stat(name string) (*fileInfo, error) {
+	name = clean(name)
+	if info, ok := f.files[name]; ok {
+		return &fileInfo{name: path.Base(name), size: info.Size, oid: info.Oid, modTime: f.opts.ModTime}, nil
+	}
+	if _, ok := f.dirs[name]; ok {
+		base := path.Base(name)
+		if name == "" {
+			base = "/"
+		}
+		return &fileInfo{name: base, dir: true, modTime: f.opts.ModTime}, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+// Stat implements webdav.FileSystem.
+func (f *FS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	info, err := f.stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// OpenFile implements webdav.FileSystem. Only read-only opens succeed.
+func (f *FS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return nil, os.ErrPermission
+	}
+	info, err := f.stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return &file{fs: f, ctx: ctx, path: clean(name), info: info}, nil
+}
+
+// Mkdir implements webdav.FileSystem; the tree is read-only.
+func (f *FS) Mkdir(context.Context, string, os.FileMode) error { return os.ErrPermission }
+
+// RemoveAll implements webdav.FileSystem; the tree is read-only.
+func (f *FS) RemoveAll(context.Context, string) error { return os.ErrPermission }
+
+// Rename implements webdav.FileSystem; the tree is read-only.
+func (f *FS) Rename(context.Context, string, string) error { return os.ErrPermission }
+
+// ReadAt reads len(p) bytes of oid's content at off, block by block.
+func (f *FS) ReadAt(ctx context.Context, oid string, size int64, p []byte, off int64) (int, error) {
+	if off >= size {
+		return 0, io.EOF
+	}
+	if local, err := f.localObject(oid); err == nil {
+		return readLocal(local, p, off)
+	}
+	bs := f.opts.Cache.BlockSize()
+	n := 0
+	for n < len(p) && off < size {
+		index := off / bs
+		start := index * bs
+		data, err := f.block(ctx, oid, index, min(bs, size-start))
+		if errors.Is(err, drsremote.ErrRangeUnsupported) {
+			local, derr := f.download(ctx, oid)
+			if derr != nil {
+				return n, derr
+			}
+			m, rerr := readLocal(local, p[n:], off)
+			return n + m, rerr
+		}
+		if err != nil {
+			return n, err
+		}
+		m := copy(p[n:], data[off-start:])
+		n += m
+		off += int64(m)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *FS) localObject(oid string) (string, error) {
+	p, err := lfs.ObjectPath(f.opts.LFSObjects, oid)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(p); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+func readLocal(path string, p []byte, off int64) (int, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer fh.Close()
+	return fh.ReadAt(p, off)
+}
+
+// block returns one block from the cache or the remote. Concurrent reads of
+// the same block share a single fetch.
+func (f *FS) block(ctx context.Context, oid string, index, length int64) ([]byte, error) {
+	if data, ok := f.opts.Cache.Get(oid, index); ok && int64(len(data)) == length {
+		return data, nil
+	}
+	key := fmt.Sprintf("%s/%d", oid, index)
+	data, err := f.once(key, func() ([]byte, error) {
+		body, err := f.opts.Fetcher.ReadRange(ctx, oid, index*f.opts.Cache.BlockSize(), length)
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		data := make([]byte, length)
+		if _, err := io.ReadFull(body, data); err != nil {
+			return nil, fmt.Errorf("read block %d of %s: %w", index, oid, err)
+		}
+		if err := f.opts.Cache.Put(oid, index, data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	})
+	return data, err
+}
+
+// download fetches oid whole into the LFS store.
+func (f *FS) download(ctx context.Context, oid string) (string, error) {
+	dst, err := lfs.ObjectPath(f.opts.LFSObjects, oid)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.once(oid, func() ([]byte, error) {
+		if _, err := os.Stat(dst); err == nil {
+			return nil, nil
+		}
+		return nil, f.opts.Fetcher.Download(ctx, oid, dst)
+	})
+	return dst, err
+}
+
+func (f *FS) once(key string, fn func() ([]byte, error)) ([]byte, error) {
+	f.mu.Lock()
+	if call, ok := f.inflight[key]; ok {
+		f.mu.Unlock()
+		<-call.done
+		return call.data, call.err
+	}
+	call := &fetchCall{done: make(chan struct{})}
+	f.inflight[key] = call
+	f.mu.Unlock()
+
+	call.data, call.err = fn()
+	f.mu.Lock()
+	delete(f.inflight, key)
+	f.mu.Unlock()
+	close(call.done)
+	return call.data, call.err
+}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	oid     string
+	dir     bool
+	modTime time.Time
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return i.dir }
+func (i *fileInfo) Sys() any           { return nil }
+
+func (i *fileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// ContentType answers from the extension so directory listings do not fetch
+// the start of every file to sniff it.
+func (i *fileInfo) ContentType(context.Context) (string, error) {
+	if t := mime.TypeByExtension(path.Ext(i.name)); t != "" {
+		return t, nil
+	}
+	return "application/octet-stream", nil
+}
+
+// ETag is the oid, which identifies the content exactly.
+func (i *fileInfo) ETag(context.Context) (string, error) {
+	if i.dir {
+		return "", webdav.ErrNotImplemented
+	}
+	return `"` + i.oid + `"`, nil
+}
+
+type file struct {
+	fs   *FS
+	ctx  context.Context
+	path string
+	info *fileInfo
+	off  int64
+	// listed is how many directory entries Readdir has returned.
+	listed int
+}
+
+func (f *file) Close() error { return nil }
+
+func (f *file) Read(p []byte) (int, error) {
+	if f.info.dir {
+		return 0, fmt.Errorf("%s is a directory", f.path)
+	}
+	n, err := f.fs.ReadAt(f.ctx, f.info.oid, f.info.size, p, f.off)
+	f.off += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += f.info.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative offset %d", offset)
+	}
+	f.off = offset
+	return offset, nil
+}
+
+func (f *file) Readdir(count int) ([]fs.FileInfo, error) {
+	if !f.info.dir {
+		return nil, fmt.Errorf("%s is not a directory", f.path)
+	}
+	names := f.fs.dirs[f.path][f.listed:]
+	if count > 0 && len(names) == 0 {
+		return nil, io.EOF
+	}
+	if count > 0 && len(names) > count {
+		names = names[:count]
+	}
+	out := make([]fs.FileInfo, 0, len(names))
+	for _, name := range names {
+		info, err := f.fs.stat(path.Join(f.path, name))
+		if err != nil {
+			return out, err
+		}
+		out = append(out, info)
+	}
+	f.listed += len(names)
+	return out, nil
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *file) Write([]byte) (int, error) { return 0, os.ErrPermission }
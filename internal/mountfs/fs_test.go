@@ -0,0 +1,183 @@
+package mountfs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/lfs"
+	"golang.org/x/net/webdav"
+)
+
+type fakeFetcher struct {
+	mu        sync.Mutex
+	content   map[string][]byte
+	ranged    map[string]bool
+	reads     []string
+	downloads int
+}
+
+func (f *fakeFetcher) ReadRange(_ context.Context, oid string, offset, length int64) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.ranged[oid] {
+		return nil, drsremote.ErrRangeUnsupported
+	}
+	f.reads = append(f.reads, oid[:1])
+	return io.NopCloser(bytes.NewReader(f.content[oid][offset : offset+length])), nil
+}
+
+func (f *fakeFetcher) Download(_ context.Context, oid, path string) error {
+	f.mu.Lock()
+	f.downloads++
+	f.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, f.content[oid], 0o644)
+}
+
+func newTestFS(t *testing.T) (*FS, *fakeFetcher, *BlockCache) {
+	t.Helper()
+	dir := t.TempDir()
+	big := bytes.Repeat([]byte("0123456789"), 5) // 50 bytes, 7 blocks of 8
+	oidA, oidB := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	fetcher := &fakeFetcher{
+		content: map[string][]byte{oidA: big, oidB: []byte("sealed")},
+		ranged:  map[string]bool{oidA: true},
+	}
+	cache, err := OpenBlockCache(filepath.Join(dir, "cache"), 8, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := New(map[string]lfs.LfsFileInfo{
+		"data/reads/big.bam": {Oid: oidA, Size: int64(len(big))},
+		"data/enc.txt":       {Oid: oidB, Size: 6},
+	}, Options{LFSObjects: filepath.Join(dir, "objects"), Fetcher: fetcher, Cache: cache, ModTime: time.Unix(0, 0)})
+	return fsys, fetcher, cache
+}
+
+func TestReadAtFetchesOnlyTouchedBlocksAndCaches(t *testing.T) {
+	fsys, fetcher, cache := newTestFS(t)
+	oid := strings.Repeat("a", 64)
+	buf := make([]byte, 10)
+	n, err := fsys.ReadAt(context.Background(), oid, 50, buf, 14)
+	if err != nil || n != 10 || string(buf) != "4567890123" {
+		t.Fatalf("ReadAt = %d %q %v", n, buf, err)
+	}
+	if len(fetcher.reads) != 2 {
+		t.Fatalf("expected two block reads, got %d", len(fetcher.reads))
+	}
+	if n, err := fsys.ReadAt(context.Background(), oid, 50, buf, 8); err != nil || string(buf) != "8901234567" {
+		t.Fatalf("cached ReadAt = %d %q %v", n, buf, err)
+	}
+	if len(fetcher.reads) != 2 || cache.Used() != 16 {
+		t.Fatalf("expected cached blocks to be reused: reads=%d used=%d", len(fetcher.reads), cache.Used())
+	}
+
+	// The final short block is read to the end of the object.
+	n, err = fsys.ReadAt(context.Background(), oid, 50, buf, 45)
+	if n != 5 || err != io.EOF || string(buf[:5]) != "56789" {
+		t.Fatalf("tail ReadAt = %d %q %v", n, buf[:5], err)
+	}
+}
+
+func TestReadAtDownloadsObjectsWithoutRangeSupport(t *testing.T) {
+	fsys, fetcher, _ := newTestFS(t)
+	oid := strings.Repeat("b", 64)
+	buf := make([]byte, 6)
+	for i := 0; i < 2; i++ {
+		if n, err := fsys.ReadAt(context.Background(), oid, 6, buf, 0); n != 6 || string(buf) != "sealed" {
+			t.Fatalf("ReadAt = %d %q %v", n, buf, err)
+		}
+	}
+	if fetcher.downloads != 1 {
+		t.Fatalf("expected one whole-object download, got %d", fetcher.downloads)
+	}
+}
+
+func TestBlockCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := OpenBlockCache(dir, 4, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oid := strings.Repeat("c", 64)
+	for i := int64(0); i < 3; i++ {
+		if err := cache.Put(oid, i, []byte("abcd")); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if cache.Used() > 9 {
+		t.Fatalf("expected eviction below the limit, used %d", cache.Used())
+	}
+	if _, ok := cache.Get(oid, 0); ok {
+		t.Fatal("expected oldest block evicted")
+	}
+	if _, ok := cache.Get(oid, 2); !ok {
+		t.Fatal("expected newest block kept")
+	}
+
+	reopened, err := OpenBlockCache(dir, 4, 10)
+	if err != nil || reopened.Used() != cache.Used() {
+		t.Fatalf("expected reopened cache to account existing blocks: %v", err)
+	}
+	if resized, err := OpenBlockCache(dir, 8, 10); err != nil || resized.Used() != 0 {
+		t.Fatalf("expected block size change to clear the cache: %v", err)
+	}
+}
+
+func TestWebDAVServesTreeReadOnly(t *testing.T) {
+	fsys, _, _ := newTestFS(t)
+	srv := httptest.NewServer(&webdav.Handler{FileSystem: fsys, LockSystem: webdav.NewMemLS()})
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/data/reads/big.bam", nil)
+	req.Header.Set("Range", "bytes=10-19")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent || string(body) != "0123456789" {
+		t.Fatalf("ranged GET = %d %q", resp.StatusCode, body)
+	}
+	if etag := resp.Header.Get("ETag"); etag != `"`+strings.Repeat("a", 64)+`"` {
+		t.Fatalf("unexpected ETag %q", etag)
+	}
+
+	req, _ = http.NewRequest("PROPFIND", srv.URL+"/data/", nil)
+	req.Header.Set("Depth", "1")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus || !strings.Contains(string(body), "enc.txt") || !strings.Contains(string(body), "reads") {
+		t.Fatalf("PROPFIND = %d %s", resp.StatusCode, body)
+	}
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete, "MKCOL"} {
+		req, _ = http.NewRequest(method, srv.URL+"/data/new.txt", strings.NewReader("x"))
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 400 {
+			t.Fatalf("%s succeeded on a read-only tree: %d", method, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package mountfs
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drsremote"
+)
+
+// RemoteFetcher reads objects from a DRS remote. Each object's record is
+// resolved once and reused for every block.
+type RemoteFetcher struct {
+	drsCtx *config.GitContext
+
+	mu      sync.Mutex
+	objects map[string]*drsremote.ObjectRange
+}
+
+// NewRemoteFetcher returns a Fetcher for drsCtx.
+func NewRemoteFetcher(drsCtx *config.GitContext) *RemoteFetcher {
+	return &RemoteFetcher{drsCtx: drsCtx, objects: map[string]*drsremote.ObjectRange{}}
+}
+
+// ReadRange implements Fetcher.
+func (r *RemoteFetcher) ReadRange(ctx context.Context, oid string, offset, length int64) (io.ReadCloser, error) {
+	r.mu.Lock()
+	obj, ok := r.objects[oid]
+	r.mu.Unlock()
+	if !ok {
+		var err error
+		obj, err = drsremote.OpenObjectRange(ctx, r.drsCtx, oid)
+		if err != nil {
+			return nil, err
+		}
+		r.mu.Lock()
+		r.objects[oid] = obj
+		r.mu.Unlock()
+	}
+	return obj.ReadRange(ctx, offset, length)
+}
+
+// Download implements Fetcher.
+func (r *RemoteFetcher) Download(ctx context.Context, oid, path string) error {
+	return drsremote.DownloadToCachePath(ctx, r.drsCtx, nil, oid, path)
+}
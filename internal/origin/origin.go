@@ -0,0 +1,135 @@
+// Package origin records where in a Git repository a DRS record was
+// registered from, so an object in a bucket can be traced back to the
+// commit, branch, repository and path that produced it.
+package origin
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/drsobject"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// AliasPrefix marks the alias that carries a record's Origin as JSON (see
+// drsobject.AliasMetadata).
+const AliasPrefix = "git-drs-origin:"
+
+// Origin is the repository coordinates a record was registered from.
+type Origin struct {
+	Repo   string `json:"repo,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	Commit string `json:"commit,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+// Empty reports whether o records nothing.
+func (o Origin) Empty() bool { return o == Origin{} }
+
+// Fields selects which coordinates registration records.
+type Fields struct {
+	Repo, Branch, Commit, Path bool
+}
+
+// Enabled reports whether any coordinate is recorded.
+func (f Fields) Enabled() bool { return f != Fields{} }
+
+// Select returns o with the coordinates f leaves out cleared.
+func (f Fields) Select(o Origin) Origin {
+	if !f.Repo {
+		o.Repo = ""
+	}
+	if !f.Branch {
+		o.Branch = ""
+	}
+	if !f.Commit {
+		o.Commit = ""
+	}
+	if !f.Path {
+		o.Path = ""
+	}
+	return o
+}
+
+// ParseFields parses drs.origin.fields: a comma-separated list of repo,
+// branch, commit and path, "all", or "none". An empty value means none.
+func ParseFields(v string) (Fields, error) {
+	var f Fields
+	for _, name := range strings.Split(v, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "", "none":
+		case "all":
+			f = Fields{Repo: true, Branch: true, Commit: true, Path: true}
+		case "repo":
+			f.Repo = true
+		case "branch":
+			f.Branch = true
+		case "commit":
+			f.Commit = true
+		case "path":
+			f.Path = true
+		default:
+			return Fields{}, fmt.Errorf("invalid origin field %q: want repo, branch, commit, path, all or none", name)
+		}
+	}
+	return f, nil
+}
+
+// gitOutput runs git and returns its trimmed output, or "" when it fails;
+// swapped in tests.
+var gitOutput = func(ctx context.Context, args ...string) string {
+	out, err := exec.CommandContext(ctx, "git", args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Current returns the repository, branch and commit of the working tree.
+// The repository is the URL of the current branch's upstream remote, or of
+// origin, with any credentials removed. A detached HEAD has no branch.
+func Current(ctx context.Context) Origin {
+	branch := gitOutput(ctx, "symbolic-ref", "--short", "-q", "HEAD")
+	remote := "origin"
+	if branch != "" {
+		if name := gitOutput(ctx, "config", "--get", "branch."+branch+".remote"); name != "" && name != "." {
+			remote = name
+		}
+	}
+	return Origin{
+		Repo:   redact(gitOutput(ctx, "config", "--get", "remote."+remote+".url")),
+		Branch: branch,
+		Commit: gitOutput(ctx, "rev-parse", "--verify", "--quiet", "HEAD"),
+	}
+}
+
+// redact drops the user info from URL-style remotes; scp-style remotes
+// such as git@host:org/repo.git carry no secret and are kept.
+func redact(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil || u.Host == "" {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
+
+// At returns o for the file at the repo-relative path.
+func (o Origin) At(path string) Origin {
+	o.Path = filepath.ToSlash(path)
+	return o
+}
+
+// FromObject returns the origin recorded on obj, if it has one.
+func FromObject(obj *drsapi.DrsObject) (Origin, bool) {
+	return drsobject.AliasMetadata(obj, AliasPrefix, func(o Origin) bool { return !o.Empty() })
+}
+
+// SetOnObject records o on obj, replacing any earlier origin.
+func SetOnObject(obj *drsapi.DrsObject, o Origin) {
+	drsobject.SetAliasMetadata(obj, AliasPrefix, o)
+}
@@ -0,0 +1,87 @@
+package pathmap
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+var sha256Hex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Conflict describes a path both sides changed incompatibly.
+type Conflict struct {
+	Path   string
+	Reason string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: %s", c.Path, c.Reason)
+}
+
+// Merge3 performs a semantic three-way merge of map shards. Paths changed on
+// only one side take that side's value, paths added on both sides with the
+// same object union cleanly, and entries whose oid is not a sha256 digest are
+// rejected. Conflicted paths keep our value in the result.
+func Merge3(base, ours, theirs Map) (Map, []Conflict) {
+	paths := map[string]struct{}{}
+	for _, m := range []Map{base, ours, theirs} {
+		for p := range m {
+			paths[p] = struct{}{}
+		}
+	}
+	keys := make([]string, 0, len(paths))
+	for p := range paths {
+		keys = append(keys, p)
+	}
+	sort.Strings(keys)
+
+	merged := Map{}
+	var conflicts []Conflict
+	for _, p := range keys {
+		b := lookup(base, p)
+		o := lookup(ours, p)
+		t := lookup(theirs, p)
+
+		var result side
+		switch {
+		case o == t:
+			result = o
+		case o == b:
+			result = t
+		case t == b:
+			result = o
+		default:
+			c := Conflict{Path: p, Reason: "changed on both sides"}
+			if o.ok {
+				merged[p] = o.entry
+			}
+			if o.ok && t.ok && o.entry.OID == t.entry.OID {
+				c.Reason = fmt.Sprintf("same oid registered with different DRS IDs (%s vs %s)", o.entry.DRSID, t.entry.DRSID)
+			} else if !o.ok || !t.ok {
+				c.Reason = "deleted on one side and changed on the other"
+			}
+			conflicts = append(conflicts, c)
+			continue
+		}
+		if !result.ok {
+			continue
+		}
+		if !sha256Hex.MatchString(result.entry.OID) {
+			conflicts = append(conflicts, Conflict{Path: p, Reason: fmt.Sprintf("invalid sha256 oid %q", result.entry.OID)})
+			continue
+		}
+		merged[p] = result.entry
+	}
+	return merged, conflicts
+}
+
+// side is one version of a path; ok is false when the path is absent.
+type side struct {
+	entry Entry
+	ok    bool
+}
+
+func lookup(m Map, path string) side {
+	e, ok := m[path]
+	return side{entry: e, ok: ok}
+}
@@ -0,0 +1,51 @@
+package pathmap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge3UnionsIndependentChanges(t *testing.T) {
+	a := Entry{OID: strings.Repeat("a", 64), DRSID: "id-a", Remote: "origin"}
+	b := Entry{OID: strings.Repeat("b", 64), DRSID: "id-b", Remote: "origin"}
+	c := Entry{OID: strings.Repeat("c", 64), DRSID: "id-c", Remote: "origin"}
+
+	base := Map{"keep.bin": a, "gone.bin": a}
+	ours := Map{"keep.bin": a, "ours.bin": b}
+	theirs := Map{"keep.bin": a, "gone.bin": a, "theirs.bin": c, "ours.bin": b}
+
+	merged, conflicts := Merge3(base, ours, theirs)
+	if len(conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	want := Map{"keep.bin": a, "ours.bin": b, "theirs.bin": c}
+	if len(merged) != len(want) {
+		t.Fatalf("merged = %#v, want %#v", merged, want)
+	}
+	for p, e := range want {
+		if merged[p] != e {
+			t.Fatalf("merged[%s] = %#v, want %#v", p, merged[p], e)
+		}
+	}
+}
+
+func TestMerge3ReportsConflicts(t *testing.T) {
+	a := Entry{OID: strings.Repeat("a", 64), DRSID: "id-a"}
+	b := Entry{OID: strings.Repeat("b", 64), DRSID: "id-b"}
+	a2 := Entry{OID: a.OID, DRSID: "other"}
+
+	merged, conflicts := Merge3(
+		Map{"edit.bin": a},
+		Map{"edit.bin": b, "same.bin": a, "bad.bin": {OID: "nothex"}},
+		Map{"same.bin": a2, "bad.bin": {OID: "nothex"}},
+	)
+	if len(conflicts) != 3 {
+		t.Fatalf("expected 3 conflicts, got %v", conflicts)
+	}
+	if merged["edit.bin"] != b {
+		t.Fatalf("expected conflicted path to keep ours, got %#v", merged["edit.bin"])
+	}
+	if _, ok := merged["bad.bin"]; ok {
+		t.Fatalf("expected invalid oid to be dropped")
+	}
+}
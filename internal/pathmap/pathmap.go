@@ -0,0 +1,237 @@
+// Package pathmap maintains the committed repo-path -> DRS mapping under
+// .drs/map so clones can resolve DRS IDs without contacting the server.
+//
+// The map is sharded by the first byte of sha256(path) into
+// .drs/map/<xx>.json. Each shard is a JSON object keyed by path with sorted
+// keys and one entry per line group, so concurrent edits to different paths
+// rarely touch the same shard and merge cleanly when they do.
+package pathmap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/drsobject"
+)
+
+// Dir is the repo-relative directory holding map shards.
+const Dir = ".drs/map"
+
+// attributes routes shard merges through git drs merge-driver. It lives next
+// to the shards so the setting travels with the map.
+const attributes = "*.json merge=drs\n"
+
+// Entry records how a repo path resolves to a DRS object.
+type Entry struct {
+	OID    string `json:"oid"`
+	DRSID  string `json:"drs_id"`
+	Remote string `json:"remote,omitempty"`
+}
+
+// Map is an in-memory view of every shard, keyed by repo path.
+type Map map[string]Entry
+
+// NewEntry builds the entry for oid at path under remote/project. The DRS ID
+// comes from the locally staged DRS object when one exists, otherwise it is
+// the ID ids would mint for the object.
+func NewEntry(remote string, ids drsobject.Minter, project, path, oid string) Entry {
+	oid = drsobject.NormalizeOid(oid)
+	drsID := ids.ID(project, path, oid)
+	if obj, err := drsobject.ReadObject(common.DRS_OBJS_PATH, oid); err == nil && obj != nil && obj.Id != "" {
+		drsID = obj.Id
+	}
+	return Entry{OID: oid, DRSID: drsID, Remote: remote}
+}
+
+// ShardName returns the shard file name for a repo path.
+func ShardName(path string) string {
+	sum := sha256.Sum256([]byte(filepath.ToSlash(path)))
+	return hex.EncodeToString(sum[:1]) + ".json"
+}
+
+// Enabled reports whether the repository at root has opted in to a
+// committed map, i.e. the map directory exists in the worktree.
+func Enabled(root string) bool {
+	info, err := os.Stat(filepath.Join(root, Dir))
+	return err == nil && info.IsDir()
+}
+
+// Load reads every shard under root.
+func Load(root string) (Map, error) {
+	out := Map{}
+	err := Walk(root, func(shard Map) error {
+		for path, entry := range shard {
+			out[path] = entry
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Walk calls fn with each shard under root in turn, holding only one shard in
+// memory at a time, so callers that scan the map need not load all of it.
+func Walk(root string, fn func(shard Map) error) error {
+	dir := filepath.Join(root, Dir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") || strings.HasPrefix(e.Name(), common.AtomicTempPrefix) {
+			continue
+		}
+		shard, err := ReadShard(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		if err := fn(shard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lookup returns the entry for path, if the map has one.
+func Lookup(root, path string) (Entry, bool, error) {
+	shard, err := ReadShard(filepath.Join(root, Dir, ShardName(path)))
+	if err != nil {
+		return Entry{}, false, err
+	}
+	entry, ok := shard[filepath.ToSlash(path)]
+	return entry, ok, nil
+}
+
+// Update applies upserts and deletes, rewriting only the affected shards.
+// It returns the repo-relative paths of shard files that changed.
+func Update(root string, upserts Map, deletes []string) ([]string, error) {
+	// Group the changes by shard up front so each shard is read and written
+	// once, however many paths it holds.
+	type change struct {
+		upserts Map
+		deletes []string
+	}
+	touched := map[string]*change{}
+	changeFor := func(path string) *change {
+		name := ShardName(path)
+		if touched[name] == nil {
+			touched[name] = &change{upserts: Map{}}
+		}
+		return touched[name]
+	}
+	for _, path := range deletes {
+		c := changeFor(path)
+		c.deletes = append(c.deletes, filepath.ToSlash(path))
+	}
+	for path, entry := range upserts {
+		changeFor(path).upserts[filepath.ToSlash(path)] = entry
+	}
+	names := make([]string, 0, len(touched))
+	for name := range touched {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var changed []string
+	for _, name := range names {
+		file := filepath.Join(root, Dir, name)
+		shard, err := ReadShard(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range touched[name].deletes {
+			delete(shard, path)
+		}
+		for path, entry := range touched[name].upserts {
+			shard[path] = entry
+		}
+		if err := WriteShard(file, shard); err != nil {
+			return nil, err
+		}
+		changed = append(changed, filepath.ToSlash(filepath.Join(Dir, name)))
+	}
+	return changed, nil
+}
+
+// Rebuild replaces the whole map with m, removing shards that no longer hold
+// any entry.
+func Rebuild(root string, m Map) error {
+	dir := filepath.Join(root, Dir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	shards := map[string]Map{}
+	for path, entry := range m {
+		name := ShardName(path)
+		if shards[name] == nil {
+			shards[name] = Map{}
+		}
+		shards[name][filepath.ToSlash(path)] = entry
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitattributes"), []byte(attributes), 0o644); err != nil {
+		return err
+	}
+	existing, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range existing {
+		if _, keep := shards[e.Name()]; !keep && strings.HasSuffix(e.Name(), ".json") {
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	for name, shard := range shards {
+		if err := WriteShard(filepath.Join(dir, name), shard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadShard parses a single shard file. A missing file is an empty shard.
+func ReadShard(file string) (Map, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Map{}, nil
+		}
+		return nil, err
+	}
+	shard := Map{}
+	if err := json.Unmarshal(data, &shard); err != nil {
+		return nil, fmt.Errorf("parse map shard %s: %w", file, err)
+	}
+	return shard, nil
+}
+
+// writeShard writes shard deterministically; an empty shard removes the file.
+func WriteShard(file string, shard Map) error {
+	if len(shard) == 0 {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	// encoding/json sorts map keys, which keeps output stable across runs.
+	data, err := json.MarshalIndent(shard, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	return common.WriteFileAtomic(file, data, 0o644)
+}
@@ -0,0 +1,117 @@
+package pathmap
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/drsobject"
+)
+
+func TestUpdateShardsAndDeletes(t *testing.T) {
+	root := t.TempDir()
+
+	upserts := Map{
+		"data/a.bin": {OID: "aa", DRSID: "id-a", Remote: "origin"},
+		"data/b.bin": {OID: "bb", DRSID: "id-b", Remote: "origin"},
+	}
+	changed, err := Update(root, upserts, nil)
+	if err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if len(changed) == 0 {
+		t.Fatalf("expected changed shard files")
+	}
+
+	got, ok, err := Lookup(root, "data/a.bin")
+	if err != nil || !ok || got.DRSID != "id-a" {
+		t.Fatalf("Lookup(a) = %#v, %v, %v", got, ok, err)
+	}
+
+	if _, err := Update(root, nil, []string{"data/a.bin"}); err != nil {
+		t.Fatalf("Update delete error: %v", err)
+	}
+	m, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if _, ok := m["data/a.bin"]; ok {
+		t.Fatalf("expected data/a.bin removed, got %#v", m)
+	}
+	if m["data/b.bin"].OID != "bb" {
+		t.Fatalf("expected data/b.bin kept, got %#v", m)
+	}
+}
+
+func TestRebuildRemovesStaleShards(t *testing.T) {
+	root := t.TempDir()
+	stale := filepath.Join(root, Dir, "zz.json")
+	if err := os.MkdirAll(filepath.Dir(stale), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, []byte(`{"old.bin":{"oid":"x","drs_id":"y"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Rebuild(root, Map{"new.bin": {OID: "n", DRSID: "d"}}); err != nil {
+		t.Fatalf("Rebuild error: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected stale shard removed, err=%v", err)
+	}
+	m, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if len(m) != 1 || m["new.bin"].DRSID != "d" {
+		t.Fatalf("unexpected map after rebuild: %#v", m)
+	}
+	if !Enabled(root) {
+		t.Fatalf("expected map to be enabled after rebuild")
+	}
+}
+
+func TestNewEntryUsesDeterministicID(t *testing.T) {
+	t.Chdir(t.TempDir())
+	oid := "sha256:" + strings.Repeat("ab", 32)
+	e1 := NewEntry("origin", drsobject.Minter{}, "proj", "data/a.bin", oid)
+	e2 := NewEntry("origin", drsobject.Minter{}, "proj", "data/a.bin", oid[len("sha256:"):])
+	if e1.DRSID == "" || e1.DRSID != e2.DRSID {
+		t.Fatalf("expected stable deterministic ID, got %q and %q", e1.DRSID, e2.DRSID)
+	}
+	if e1.OID != oid[len("sha256:"):] {
+		t.Fatalf("expected normalized oid, got %q", e1.OID)
+	}
+}
+
+func TestWalkVisitsEveryShard(t *testing.T) {
+	root := t.TempDir()
+	m := Map{}
+	for _, p := range []string{"a.bin", "dir/b.bin", "dir/sub/c.bin", "d.bin"} {
+		m[p] = Entry{OID: p}
+	}
+	if err := Rebuild(root, m); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := Map{}
+	shards := 0
+	err := Walk(root, func(shard Map) error {
+		shards++
+		for p, e := range shard {
+			seen[p] = e
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != len(m) || shards == 0 || shards > len(m) {
+		t.Fatalf("walked %d entries in %d shards; want %d", len(seen), shards, len(m))
+	}
+	if err := Walk(t.TempDir(), func(Map) error { return errors.New("called") }); err != nil {
+		t.Fatalf("Walk of a repo without a map = %v; want no shards", err)
+	}
+}
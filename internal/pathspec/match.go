@@ -1,11 +1,39 @@
 package pathspec
 
 import (
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/calypr/git-drs/internal/projectdir"
 )
 
+// Rooted rewrites patterns given relative to the working directory, as git
+// reads pathspecs, to be relative to the repository root, which is how
+// inventory paths are keyed. A ":/" prefix anchors a pattern at the root,
+// and "." selects everything below the directory it names.
+func Rooted(patterns []string) []string {
+	if len(patterns) == 0 {
+		return patterns
+	}
+	prefix, _ := projectdir.Prefix()
+	out := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		p = filepath.ToSlash(strings.TrimSpace(p))
+		base := prefix
+		if rest, ok := strings.CutPrefix(p, ":/"); ok {
+			p, base = rest, ""
+		}
+		if p == "" || p == "." {
+			out = append(out, base+"**")
+			continue
+		}
+		out = append(out, path.Join(base, p))
+	}
+	return out
+}
+
 func MatchesAny(path string, patterns []string) bool {
 	if len(patterns) == 0 {
 		return true
@@ -20,3 +20,14 @@ func TestMatches(t *testing.T) {
 		}
 	}
 }
+
+func TestRootedFromTopLevel(t *testing.T) {
+	t.Chdir(t.TempDir())
+	got := Rooted([]string{"data/*.bam", ":/other.bam", "."})
+	want := []string{"data/*.bam", "other.bam", "**"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Rooted = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package precommit_cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/hashing"
+)
+
+// DefaultMaxAge is how old a path entry may be before pre-push stops
+// trusting it.
+const DefaultMaxAge = 24 * time.Hour
+
+// State is persisted at Cache.StatePath and records what the cache was built
+// against, so later runs can tell when it no longer applies.
+type State struct {
+	AttributesFingerprint string `json:"attributes_fingerprint"`
+	UpdatedAt             string `json:"updated_at"`
+}
+
+// Status summarizes the cache against the currently tracked LFS paths.
+type Status struct {
+	Root              string `json:"root"`
+	Exists            bool   `json:"exists"`
+	PathEntries       int    `json:"path_entries"`
+	OIDEntries        int    `json:"oid_entries"`
+	Valid             int    `json:"valid"`
+	Stale             int    `json:"stale"`
+	Mismatched        int    `json:"mismatched"`
+	Orphaned          int    `json:"orphaned"`
+	Missing           int    `json:"missing"`
+	Corrupt           int    `json:"corrupt"`
+	AttributesChanged bool   `json:"attributes_changed"`
+	Hits              int64  `json:"hits"`
+	Misses            int64  `json:"misses"`
+}
+
+// Healthy reports whether every tracked path has a fresh, matching entry.
+func (s Status) Healthy() bool {
+	return s.Stale == 0 && s.Mismatched == 0 && s.Orphaned == 0 && s.Missing == 0 && s.Corrupt == 0 && !s.AttributesChanged
+}
+
+// Stats returns the number of entry lookups that found (hits) or did not find
+// (misses) a cache entry since the cache was opened.
+func (c *Cache) Stats() (hits, misses int64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+func (c *Cache) record(found bool) {
+	if found {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+}
+
+// ReadState returns the persisted state, or (nil, nil) when none exists.
+func (c *Cache) ReadState() (*State, error) {
+	b, err := os.ReadFile(c.StatePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var st State
+	if err := json.Unmarshal(b, &st); err != nil {
+		return nil, fmt.Errorf("parse cache state %q: %w", c.StatePath, err)
+	}
+	return &st, nil
+}
+
+// AttributesFingerprint hashes the staged .gitattributes blobs plus
+// .git/info/attributes. Any change to tracking rules changes the fingerprint.
+func (c *Cache) AttributesFingerprint(ctx context.Context) (string, error) {
+	out, err := git(ctx, "ls-files", "-s", "--", "*.gitattributes")
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write(out)
+	if oid, _, err := hashing.HashFile(filepath.Join(c.GitDir, "info", "attributes"), false); err == nil {
+		h.Write([]byte{0})
+		h.Write([]byte(oid))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// EnsureFresh clears the cache when the tracking rules changed since it was
+// last validated and records the current fingerprint. It reports whether the
+// cache was invalidated.
+func (c *Cache) EnsureFresh(ctx context.Context) (bool, error) {
+	fp, err := c.AttributesFingerprint(ctx)
+	if err != nil {
+		return false, err
+	}
+	st, err := c.ReadState()
+	if err != nil {
+		st = nil
+	}
+	if st != nil && st.AttributesFingerprint == fp {
+		return false, nil
+	}
+	invalidated := st != nil || err != nil
+	if invalidated {
+		if err := c.Clear(); err != nil {
+			return false, err
+		}
+	}
+	if err := c.writeState(fp); err != nil {
+		return invalidated, err
+	}
+	return invalidated, nil
+}
+
+// Clear removes every cache entry and the persisted state.
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.Root); err != nil {
+		return fmt.Errorf("clear pre-commit cache: %w", err)
+	}
+	return nil
+}
+
+// Inspect compares cache entries against tracked (path -> oid) and counts
+// entries older than maxAge as stale.
+func (c *Cache) Inspect(ctx context.Context, tracked map[string]string, maxAge time.Duration) (Status, error) {
+	st := Status{Root: c.Root}
+	st.Hits, st.Misses = c.Stats()
+	if _, err := os.Stat(c.Root); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			st.Missing = len(tracked)
+			return st, nil
+		}
+		return st, err
+	}
+	st.Exists = true
+
+	entries, corrupt, err := c.listPathEntries()
+	if err != nil {
+		return st, err
+	}
+	st.PathEntries = len(entries) + corrupt
+	st.Corrupt = corrupt
+	seen := make(map[string]struct{}, len(entries))
+	for _, pe := range entries {
+		seen[pe.Path] = struct{}{}
+		want, ok := tracked[pe.Path]
+		switch {
+		case !ok:
+			st.Orphaned++
+		case normalizeOID(pe.LFSOID) != normalizeOID(want):
+			st.Mismatched++
+		case pe.UpdatedAt == "" || StaleAfter(pe.UpdatedAt, maxAge):
+			st.Stale++
+		default:
+			st.Valid++
+		}
+	}
+	for path := range tracked {
+		if _, ok := seen[path]; !ok {
+			st.Missing++
+		}
+	}
+
+	if oids, err := os.ReadDir(c.OIDsDir); err == nil {
+		for _, e := range oids {
+			if strings.HasSuffix(e.Name(), ".json") {
+				st.OIDEntries++
+			}
+		}
+	}
+
+	if state, err := c.ReadState(); err != nil {
+		st.AttributesChanged = true
+	} else if state != nil {
+		fp, err := c.AttributesFingerprint(ctx)
+		if err != nil {
+			return st, err
+		}
+		st.AttributesChanged = state.AttributesFingerprint != fp
+	}
+	return st, nil
+}
+
+// Rebuild replaces the cache with entries for tracked (path -> oid), keeping
+// external URL hints recorded for oids that are still referenced.
+func (c *Cache) Rebuild(ctx context.Context, tracked map[string]string, now string) error {
+	hints := make(map[string]string)
+	if oids, err := os.ReadDir(c.OIDsDir); err == nil {
+		for _, e := range oids {
+			b, err := os.ReadFile(filepath.Join(c.OIDsDir, e.Name()))
+			if err != nil {
+				continue
+			}
+			var oe OIDEntry
+			if json.Unmarshal(b, &oe) == nil && strings.TrimSpace(oe.ExternalURL) != "" {
+				hints[normalizeOID(oe.LFSOID)] = oe.ExternalURL
+			}
+		}
+	}
+
+	if err := c.Clear(); err != nil {
+		return err
+	}
+	if err := c.EnsureLayout(); err != nil {
+		return err
+	}
+
+	// Entries are keyed by the bare hex oid, the form add-url writes and
+	// pre-push looks hints up by.
+	byOID := make(map[string][]string)
+	for path, oid := range tracked {
+		oid = normalizeOID(oid)
+		if err := c.UpsertPathEntry(PathEntry{Path: path, LFSOID: oid, UpdatedAt: now}); err != nil {
+			return err
+		}
+		byOID[oid] = append(byOID[oid], path)
+	}
+	for oid, paths := range byOID {
+		sort.Strings(paths)
+		entry := OIDEntry{
+			LFSOID:      oid,
+			Paths:       paths,
+			ExternalURL: hints[oid],
+			UpdatedAt:   now,
+		}
+		if err := writeJSONAtomic(c.oidEntryFile(oid), entry); err != nil {
+			return err
+		}
+	}
+
+	fp, err := c.AttributesFingerprint(ctx)
+	if err != nil {
+		return err
+	}
+	return c.writeState(fp)
+}
+
+func (c *Cache) writeState(fingerprint string) error {
+	if err := c.EnsureLayout(); err != nil {
+		return err
+	}
+	return writeJSONAtomic(c.StatePath, State{
+		AttributesFingerprint: fingerprint,
+		UpdatedAt:             time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// listPathEntries parses every path entry file, counting unparseable ones.
+func (c *Cache) listPathEntries() ([]PathEntry, int, error) {
+	files, err := os.ReadDir(c.PathsDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	var (
+		entries []PathEntry
+		corrupt int
+	)
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(c.PathsDir, f.Name()))
+		if err != nil {
+			return nil, 0, err
+		}
+		var pe PathEntry
+		if err := json.Unmarshal(b, &pe); err != nil || pe.Path == "" {
+			corrupt++
+			continue
+		}
+		entries = append(entries, pe)
+	}
+	return entries, corrupt, nil
+}
+
+func normalizeOID(oid string) string {
+	oid = strings.TrimSpace(oid)
+	if len(oid) >= len("sha256:") && strings.EqualFold(oid[:len("sha256:")], "sha256:") {
+		oid = oid[len("sha256:"):]
+	}
+	return strings.ToLower(strings.TrimSpace(oid))
+}
@@ -0,0 +1,91 @@
+package precommit_cache
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRebuildInspectAndInvalidate(t *testing.T) {
+	repo := t.TempDir()
+	runGit(t, repo, "init")
+	if err := os.WriteFile(filepath.Join(repo, ".gitattributes"), []byte("*.bin filter=drs\n"), 0o644); err != nil {
+		t.Fatalf("write attributes: %v", err)
+	}
+	runGit(t, repo, "add", ".gitattributes")
+	oldWD, _ := os.Getwd()
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldWD) })
+
+	ctx := context.Background()
+	c, err := Open(ctx)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	oidA := strings.Repeat("a", 64)
+	oidB := strings.Repeat("b", 64)
+	if err := c.AddOrReplaceOIDPath(oidA, "", "old.bin", "2020-01-01T00:00:00Z", false); err != nil {
+		t.Fatalf("seed oid entry: %v", err)
+	}
+	if err := writeJSONAtomic(c.oidEntryFile(oidA), OIDEntry{LFSOID: oidA, Paths: []string{"old.bin"}, ExternalURL: "s3://bucket/a"}); err != nil {
+		t.Fatalf("seed hint: %v", err)
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	tracked := map[string]string{"a.bin": oidA, "b.bin": "sha256:" + oidB}
+	if err := c.Rebuild(ctx, tracked, now); err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if url, ok, err := c.LookupExternalURLByOID(oidA); err != nil || !ok || url != "s3://bucket/a" {
+		t.Fatalf("expected hint to survive rebuild, got %q %v %v", url, ok, err)
+	}
+	if oid, ok, err := c.LookupOIDByPath("b.bin"); err != nil || !ok || oid != oidB {
+		t.Fatalf("expected rebuilt path entry, got %q %v %v", oid, ok, err)
+	}
+	if hits, misses := c.Stats(); hits != 2 || misses != 0 {
+		t.Fatalf("unexpected stats hits=%d misses=%d", hits, misses)
+	}
+
+	tracked["c.bin"] = oidA
+	delete(tracked, "b.bin")
+	st, err := c.Inspect(ctx, tracked, time.Hour)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if st.Valid != 1 || st.Orphaned != 1 || st.Missing != 1 || st.AttributesChanged || st.Healthy() {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+
+	if invalidated, err := c.EnsureFresh(ctx); err != nil || invalidated {
+		t.Fatalf("expected fresh cache, got invalidated=%v err=%v", invalidated, err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".gitattributes"), []byte("*.bin filter=drs\n*.dat filter=drs\n"), 0o644); err != nil {
+		t.Fatalf("rewrite attributes: %v", err)
+	}
+	runGit(t, repo, "add", ".gitattributes")
+	if st, err := c.Inspect(ctx, tracked, time.Hour); err != nil || !st.AttributesChanged {
+		t.Fatalf("expected attribute change in status, got %+v %v", st, err)
+	}
+	invalidated, err := c.EnsureFresh(ctx)
+	if err != nil || !invalidated {
+		t.Fatalf("expected invalidation, got invalidated=%v err=%v", invalidated, err)
+	}
+	if _, ok, _ := c.ReadPathEntry("a.bin"); ok {
+		t.Fatal("expected path entries to be cleared")
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
@@ -13,7 +13,10 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/calypr/git-drs/internal/common"
 )
 
 const (
@@ -43,7 +46,7 @@ type OIDEntry struct {
 	ContentChange bool     `json:"content_changed"`
 }
 
-// Cache provides read-only access to the `.git/drs/pre-commit` cache.
+// Cache provides access to the `.git/drs/pre-commit` cache.
 // Use Open to construct an instance with correct paths resolved.
 type Cache struct {
 	GitDir    string
@@ -51,6 +54,9 @@ type Cache struct {
 	PathsDir  string
 	OIDsDir   string
 	StatePath string
+
+	hits   atomic.Int64
+	misses atomic.Int64
 }
 
 // Open discovers the repository `.git` directory and returns a Cache
@@ -139,6 +145,7 @@ func (c *Cache) ReadPathEntry(path string) (*PathEntry, bool, error) {
 	b, err := os.ReadFile(f)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
+			c.record(false)
 			return nil, false, nil
 		}
 		return nil, false, fmt.Errorf("read path entry %q: %w", f, err)
@@ -147,6 +154,7 @@ func (c *Cache) ReadPathEntry(path string) (*PathEntry, bool, error) {
 	if err := json.Unmarshal(b, &pe); err != nil {
 		return nil, false, fmt.Errorf("parse path entry %q: %w", f, err)
 	}
+	c.record(true)
 	return &pe, true, nil
 }
 
@@ -158,6 +166,7 @@ func (c *Cache) ReadOIDEntry(oid string) (*OIDEntry, bool, error) {
 	b, err := os.ReadFile(f)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
+			c.record(false)
 			return nil, false, nil
 		}
 		return nil, false, fmt.Errorf("read oid entry %q: %w", f, err)
@@ -166,6 +175,7 @@ func (c *Cache) ReadOIDEntry(oid string) (*OIDEntry, bool, error) {
 	if err := json.Unmarshal(b, &oe); err != nil {
 		return nil, false, fmt.Errorf("parse oid entry %q: %w", f, err)
 	}
+	c.record(true)
 	return &oe, true, nil
 }
 
@@ -318,27 +328,11 @@ func git(ctx context.Context, args ...string) ([]byte, error) {
 }
 
 func writeJSONAtomic(path string, v any) error {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return err
-	}
-	tmp, err := os.CreateTemp(dir, ".tmp-*.json")
+	data, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		return err
 	}
-	tmpName := tmp.Name()
-	enc := json.NewEncoder(tmp)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(v); err != nil {
-		_ = tmp.Close()
-		_ = os.Remove(tmpName)
-		return err
-	}
-	if err := tmp.Close(); err != nil {
-		_ = os.Remove(tmpName)
-		return err
-	}
-	return os.Rename(tmpName, path)
+	return common.WriteFileAtomic(path, append(data, '\n'), 0o644)
 }
 
 func oidAddOrReplacePath(oidsDir, oid, oldPath, newPath, now string, contentChanged bool) error {
@@ -5,6 +5,7 @@ import (
 	"io"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mattn/go-isatty"
@@ -25,7 +26,18 @@ type Renderer struct {
 	spinnerIndex  int
 }
 
+// quiet is set from the root -q flag before any command runs.
+var quiet atomic.Bool
+
+// SetQuiet makes renderers created afterwards discard their output.
+func SetQuiet(enabled bool) {
+	quiet.Store(enabled)
+}
+
 func NewRenderer(out io.Writer) *Renderer {
+	if quiet.Load() {
+		out = io.Discard
+	}
 	return &Renderer{
 		out:   out,
 		isTTY: IsWriterTTY(out),
@@ -0,0 +1,102 @@
+// Package projectdir locates the repository a command runs in, so paths
+// kept relative to the repository root, such as .git/drs and the keys of
+// the LFS inventory, resolve the same from any subdirectory.
+package projectdir
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// showTopLevel runs `git rev-parse --show-toplevel` in dir.
+var showTopLevel = func(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("not inside a git repository: %s", dir)
+	}
+	return filepath.FromSlash(strings.TrimSpace(string(out))), nil
+}
+
+var (
+	mu    sync.Mutex
+	roots = map[string]string{}
+)
+
+// Root returns the top level of the repository containing the working
+// directory. Lookups are cached per working directory.
+func Root() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	mu.Lock()
+	root, ok := roots[cwd]
+	mu.Unlock()
+	if ok {
+		return root, nil
+	}
+	root, err = showTopLevel(cwd)
+	if err != nil {
+		return "", err
+	}
+	mu.Lock()
+	roots[cwd] = root
+	mu.Unlock()
+	return root, nil
+}
+
+// Path resolves rel, a path relative to the repository root such as
+// common.DRS_OBJS_PATH or an inventory key, against Root. Absolute paths,
+// and any path outside a repository, are returned unchanged.
+func Path(rel string) string {
+	if filepath.IsAbs(rel) {
+		return rel
+	}
+	root, err := Root()
+	if err != nil {
+		return rel
+	}
+	return filepath.Join(root, filepath.FromSlash(rel))
+}
+
+// Prefix returns the working directory relative to Root, slash-separated
+// with a trailing slash, or "" at the top level, like
+// `git rev-parse --show-prefix`.
+func Prefix() (string, error) {
+	rel, err := Rel(".")
+	if err != nil || rel == "." {
+		return "", err
+	}
+	return rel + "/", nil
+}
+
+// Rel returns arg, a path relative to the working directory or absolute,
+// as a slash-separated path relative to Root. Symlinks in its parent
+// directories are resolved, and paths outside the repository are rejected.
+func Rel(arg string) (string, error) {
+	root, err := Root()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(arg)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		abs = filepath.Join(resolved, filepath.Base(abs))
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the repository", arg)
+	}
+	return filepath.ToSlash(rel), nil
+}
@@ -0,0 +1,64 @@
+package projectdir
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func initRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "data", "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestResolvesFromSubdirectory(t *testing.T) {
+	root := initRepo(t)
+	t.Chdir(filepath.Join(root, "data", "sub"))
+
+	if got, err := Root(); err != nil || got != root {
+		t.Fatalf("Root() = %q, %v; want %q", got, err, root)
+	}
+	if got := Path(".git/drs"); got != filepath.Join(root, ".git", "drs") {
+		t.Fatalf("Path(.git/drs) = %q", got)
+	}
+	if got := Path("/abs/path"); got != "/abs/path" {
+		t.Fatalf("Path kept absolute path as %q", got)
+	}
+	if got, err := Prefix(); err != nil || got != "data/sub/" {
+		t.Fatalf("Prefix() = %q, %v", got, err)
+	}
+	for arg, want := range map[string]string{"a.bin": "data/sub/a.bin", "../b.bin": "data/b.bin", filepath.Join(root, "c.bin"): "c.bin"} {
+		if got, err := Rel(arg); err != nil || got != want {
+			t.Fatalf("Rel(%q) = %q, %v; want %q", arg, got, err, want)
+		}
+	}
+	if _, err := Rel("../../../outside"); err == nil {
+		t.Fatal("expected a path outside the repository to be rejected")
+	}
+}
+
+func TestOutsideRepositoryLeavesPathsRelative(t *testing.T) {
+	t.Chdir(t.TempDir())
+	orig := showTopLevel
+	t.Cleanup(func() { showTopLevel = orig })
+	showTopLevel = func(dir string) (string, error) { return "", os.ErrNotExist }
+
+	if got := Path(".git/drs"); got != ".git/drs" {
+		t.Fatalf("Path outside a repository = %q", got)
+	}
+	if got, err := Prefix(); err == nil || got != "" {
+		t.Fatalf("Prefix outside a repository = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,126 @@
+// Package projectmap translates DRS records between the organization/project
+// a repository uses locally and the one a mirror remote stores them under.
+package projectmap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/drsobject"
+	syfoncommon "github.com/calypr/syfon/common"
+)
+
+// Mapping pairs the local scope with a remote's scope. A nil *Mapping is the
+// identity mapping, so callers can use it without checking.
+type Mapping struct {
+	LocalOrganization  string
+	LocalProject       string
+	RemoteOrganization string
+	RemoteProject      string
+}
+
+// Parse builds the mapping for a remote whose records live under
+// remoteOrg/remoteProject while the repository uses localScope
+// ("organization/project"). It returns nil when localScope is empty or names
+// the remote's own scope.
+func Parse(localScope, remoteOrg, remoteProject string) (*Mapping, error) {
+	localScope = strings.TrimSpace(localScope)
+	if localScope == "" {
+		return nil, nil
+	}
+	parts := strings.Split(localScope, "/")
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+		return nil, fmt.Errorf("invalid local scope %q: expected organization/project", localScope)
+	}
+	m := &Mapping{
+		LocalOrganization:  strings.TrimSpace(parts[0]),
+		LocalProject:       strings.TrimSpace(parts[1]),
+		RemoteOrganization: strings.TrimSpace(remoteOrg),
+		RemoteProject:      strings.TrimSpace(remoteProject),
+	}
+	if m.RemoteProject == "" {
+		return nil, fmt.Errorf("local scope %q requires the remote to name a project", localScope)
+	}
+	if m.LocalOrganization == m.RemoteOrganization && m.LocalProject == m.RemoteProject {
+		return nil, nil
+	}
+	return m, nil
+}
+
+// LocalScope returns the local side as "organization/project".
+func (m *Mapping) LocalScope() string {
+	if m == nil {
+		return ""
+	}
+	return m.LocalOrganization + "/" + m.LocalProject
+}
+
+// RemoteScope returns the remote side as "organization/project".
+func (m *Mapping) RemoteScope() string {
+	if m == nil {
+		return ""
+	}
+	return m.RemoteOrganization + "/" + m.RemoteProject
+}
+
+// ToRemoteID rewrites a DRS ID git-drs minted for oid in the local project to
+// the ID it mints in the remote project. Other IDs are returned unchanged.
+func (m *Mapping) ToRemoteID(did, oid string) string {
+	if m == nil || did != drsobject.DeterministicID(m.LocalProject, oid) {
+		return did
+	}
+	return drsobject.DeterministicID(m.RemoteProject, oid)
+}
+
+// ToLocalID is the inverse of ToRemoteID.
+func (m *Mapping) ToLocalID(did, oid string) string {
+	if m == nil || did != drsobject.DeterministicID(m.RemoteProject, oid) {
+		return did
+	}
+	return drsobject.DeterministicID(m.LocalProject, oid)
+}
+
+// ToRemoteResources rewrites controlled_access resources for the local
+// project (and, when the organizations differ, the local organization) to the
+// remote's. Recognized resources are normalized; duplicates are dropped.
+func (m *Mapping) ToRemoteResources(resources []string) []string {
+	if m == nil {
+		return resources
+	}
+	return rewriteResources(resources, m.LocalOrganization, m.LocalProject, m.RemoteOrganization, m.RemoteProject)
+}
+
+// ToLocalResources is the inverse of ToRemoteResources.
+func (m *Mapping) ToLocalResources(resources []string) []string {
+	if m == nil {
+		return resources
+	}
+	return rewriteResources(resources, m.RemoteOrganization, m.RemoteProject, m.LocalOrganization, m.LocalProject)
+}
+
+func rewriteResources(resources []string, fromOrg, fromProject, toOrg, toProject string) []string {
+	fromProjectPath, _ := syfoncommon.ResourcePath(fromOrg, fromProject)
+	toProjectPath, _ := syfoncommon.ResourcePath(toOrg, toProject)
+	fromOrgPath, _ := syfoncommon.ResourcePath(fromOrg, "")
+	toOrgPath, _ := syfoncommon.ResourcePath(toOrg, "")
+
+	seen := make(map[string]struct{}, len(resources))
+	out := make([]string, 0, len(resources))
+	for _, raw := range resources {
+		res := syfoncommon.NormalizeAccessResource(raw)
+		switch {
+		case res == "":
+			res = strings.TrimSpace(raw)
+		case res == fromProjectPath && toProjectPath != "":
+			res = toProjectPath
+		case res == fromOrgPath && fromOrg != toOrg && toOrgPath != "":
+			res = toOrgPath
+		}
+		if _, ok := seen[res]; ok || res == "" {
+			continue
+		}
+		seen[res] = struct{}{}
+		out = append(out, res)
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package projectmap
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/drsobject"
+)
+
+const testOID = "95d536cc8df0a8e265832c6bd0422d69593f564d5ff0518e77535c45bc10bfde"
+
+func TestParse(t *testing.T) {
+	m, err := Parse("programA/projectX", "programB", "projectY")
+	if err != nil || m == nil {
+		t.Fatalf("Parse = %v, %v", m, err)
+	}
+	if m.LocalScope() != "programA/projectX" || m.RemoteScope() != "programB/projectY" {
+		t.Fatalf("unexpected mapping %+v", m)
+	}
+	if m, err := Parse("", "programB", "projectY"); m != nil || err != nil {
+		t.Fatalf("empty scope should be identity, got %v, %v", m, err)
+	}
+	if m, err := Parse("programB/projectY", "programB", "projectY"); m != nil || err != nil {
+		t.Fatalf("same scope should be identity, got %v, %v", m, err)
+	}
+	if _, err := Parse("projectX", "programB", "projectY"); err == nil {
+		t.Fatalf("expected error for malformed scope")
+	}
+}
+
+func TestIDRoundTrip(t *testing.T) {
+	m := &Mapping{LocalOrganization: "programA", LocalProject: "projectX", RemoteOrganization: "programB", RemoteProject: "projectY"}
+	local := drsobject.DeterministicID("projectX", testOID)
+	remote := drsobject.DeterministicID("projectY", testOID)
+
+	if got := m.ToRemoteID(local, testOID); got != remote {
+		t.Fatalf("ToRemoteID = %q, want %q", got, remote)
+	}
+	if got := m.ToLocalID(remote, testOID); got != local {
+		t.Fatalf("ToLocalID = %q, want %q", got, local)
+	}
+	if got := m.ToRemoteID("custom-id", testOID); got != "custom-id" {
+		t.Fatalf("non-deterministic IDs must be kept, got %q", got)
+	}
+	var identity *Mapping
+	if got := identity.ToRemoteID(local, testOID); got != local {
+		t.Fatalf("nil mapping changed id to %q", got)
+	}
+}
+
+func TestResources(t *testing.T) {
+	m := &Mapping{LocalOrganization: "programA", LocalProject: "projectX", RemoteOrganization: "programB", RemoteProject: "projectY"}
+	got := m.ToRemoteResources([]string{
+		"/programs/programA/projects/projectX",
+		"/organization/programB/project/projectY",
+		"/organization/programA",
+		"/organization/other/project/keep",
+	})
+	want := []string{
+		"/organization/programB/project/projectY",
+		"/organization/programB",
+		"/organization/other/project/keep",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ToRemoteResources = %v, want %v", got, want)
+	}
+	if back := m.ToLocalResources([]string{"/organization/programB/project/projectY"}); !reflect.DeepEqual(back, []string{"/organization/programA/project/projectX"}) {
+		t.Fatalf("ToLocalResources = %v", back)
+	}
+}
@@ -0,0 +1,201 @@
+package provenance
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Settings configures how attestations are signed and verified. KeyFile
+// holds a local Ed25519 private key; SignCommand instead runs a shell command
+// that signs, which is how sigstore keyless signing or a KMS is wired in.
+// Verification uses PublicKeyFile, the public half of KeyFile, or
+// VerifyCommand.
+type Settings struct {
+	KeyFile       string
+	PublicKeyFile string
+	SignCommand   string
+	VerifyCommand string
+}
+
+// Enabled reports whether registered objects are attested.
+func (s Settings) Enabled() bool {
+	return s.KeyFile != "" || s.SignCommand != ""
+}
+
+// Signer signs the pre-authentication encoding of a statement.
+type Signer interface {
+	KeyID() string
+	Sign(ctx context.Context, msg []byte) ([]byte, error)
+}
+
+// Verifier checks a signature made by a Signer.
+type Verifier interface {
+	Verify(ctx context.Context, msg, sig []byte) error
+}
+
+// ErrKeyUnavailable wraps every failure to set up signing or verification.
+var ErrKeyUnavailable = errors.New("provenance key unavailable")
+
+// runCommand is swapped in tests.
+var runCommand = func(ctx context.Context, command string, stdin []byte, env ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), env...)
+	return cmd.Output()
+}
+
+// Signer returns the configured signer.
+func (s Settings) Signer() (Signer, error) {
+	switch {
+	case s.KeyFile != "":
+		key, err := readPrivateKey(s.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519Signer{key: key, id: keyID(key.Public().(ed25519.PublicKey))}, nil
+	case s.SignCommand != "":
+		return commandSigner{command: s.SignCommand}, nil
+	}
+	return nil, fmt.Errorf("%w: set drs.provenance.key-file or drs.provenance.sign-command", ErrKeyUnavailable)
+}
+
+// Verifier returns the configured verifier.
+func (s Settings) Verifier() (Verifier, error) {
+	switch {
+	case s.PublicKeyFile != "":
+		key, err := readPublicKey(s.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519Verifier{key: key}, nil
+	case s.VerifyCommand != "":
+		return commandVerifier{command: s.VerifyCommand}, nil
+	case s.KeyFile != "":
+		key, err := readPrivateKey(s.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519Verifier{key: key.Public().(ed25519.PublicKey)}, nil
+	}
+	return nil, fmt.Errorf("%w: set drs.provenance.public-key-file, drs.provenance.key-file or drs.provenance.verify-command", ErrKeyUnavailable)
+}
+
+// keyID is the hex sha256 of the public key's PKIX encoding.
+func keyID(pub ed25519.PublicKey) string {
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// readPrivateKey reads a PEM PKCS #8 Ed25519 key, as written by
+// openssl genpkey -algorithm ed25519.
+func readPrivateKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrKeyUnavailable, path, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: not an Ed25519 key", ErrKeyUnavailable, path)
+	}
+	return key, nil
+}
+
+// readPublicKey reads a PEM PKIX Ed25519 public key, as written by
+// openssl pkey -pubout.
+func readPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrKeyUnavailable, path, err)
+	}
+	key, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: not an Ed25519 key", ErrKeyUnavailable, path)
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyUnavailable, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: %s: no PEM block", ErrKeyUnavailable, path)
+	}
+	return block, nil
+}
+
+type ed25519Signer struct {
+	key ed25519.PrivateKey
+	id  string
+}
+
+func (s ed25519Signer) KeyID() string { return s.id }
+
+func (s ed25519Signer) Sign(_ context.Context, msg []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, msg), nil
+}
+
+type ed25519Verifier struct {
+	key ed25519.PublicKey
+}
+
+func (v ed25519Verifier) Verify(_ context.Context, msg, sig []byte) error {
+	if !ed25519.Verify(v.key, msg, sig) {
+		return errors.New("signature does not match the public key")
+	}
+	return nil
+}
+
+// commandSigner runs a command with the message on stdin; its trimmed
+// stdout is the signature.
+type commandSigner struct {
+	command string
+}
+
+func (s commandSigner) KeyID() string { return "" }
+
+func (s commandSigner) Sign(ctx context.Context, msg []byte) ([]byte, error) {
+	out, err := runCommand(ctx, s.command, msg)
+	if err != nil {
+		return nil, fmt.Errorf("drs.provenance.sign-command: %w", err)
+	}
+	sig := bytes.TrimSpace(out)
+	if len(sig) == 0 {
+		return nil, errors.New("drs.provenance.sign-command printed no signature")
+	}
+	return sig, nil
+}
+
+// commandVerifier runs a command with the message on stdin and the
+// signature in GIT_DRS_PROVENANCE_SIGNATURE; exit status 0 accepts it.
+type commandVerifier struct {
+	command string
+}
+
+func (v commandVerifier) Verify(ctx context.Context, msg, sig []byte) error {
+	if _, err := runCommand(ctx, v.command, msg, "GIT_DRS_PROVENANCE_SIGNATURE="+string(sig)); err != nil {
+		return fmt.Errorf("drs.provenance.verify-command: %w", err)
+	}
+	return nil
+}
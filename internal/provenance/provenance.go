@@ -0,0 +1,241 @@
+// Package provenance builds, signs and verifies the in-toto attestations
+// git-drs attaches to the records it registers: a SLSA provenance statement
+// naming the git-drs build, the source commit and path, and the content
+// hash, in a signed DSSE envelope.
+package provenance
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/drsobject"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+const (
+	StatementType = "https://in-toto.io/Statement/v1"
+	PredicateType = "https://slsa.dev/provenance/v1"
+	PayloadType   = "application/vnd.in-toto+json"
+	BuildType     = "https://github.com/calypr/git-drs/register/v1"
+	BuilderID     = "https://github.com/calypr/git-drs"
+)
+
+// AliasPrefix marks the alias that carries a record's Envelope as JSON
+// (see drsobject.AliasMetadata).
+const AliasPrefix = "git-drs-provenance:"
+
+// When the record was registered. At commit time the commit being made does
+// not exist yet, so the statement names the commit it is made on.
+const (
+	StagePush   = "push"
+	StageCommit = "commit"
+)
+
+// Source is what a statement attests about one registered object.
+type Source struct {
+	OID          string
+	Path         string
+	Remote       string
+	Organization string
+	Project      string
+	Commit       string
+	Stage        string
+	Version      string
+	Time         time.Time
+}
+
+// Statement is an in-toto v1 statement with a SLSA v1 provenance predicate.
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type Predicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   Parameters           `json:"externalParameters"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+type Parameters struct {
+	Remote       string `json:"remote"`
+	Organization string `json:"organization,omitempty"`
+	Project      string `json:"project,omitempty"`
+	Path         string `json:"path"`
+	Stage        string `json:"stage"`
+}
+
+type ResourceDescriptor struct {
+	Name   string            `json:"name,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+type RunDetails struct {
+	Builder  Builder     `json:"builder"`
+	Metadata RunMetadata `json:"metadata"`
+}
+
+type Builder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+type RunMetadata struct {
+	StartedOn time.Time `json:"startedOn"`
+}
+
+// New builds the statement for src.
+func New(src Source) Statement {
+	st := Statement{
+		Type:          StatementType,
+		Subject:       []Subject{{Name: src.Path, Digest: map[string]string{"sha256": src.OID}}},
+		PredicateType: PredicateType,
+		Predicate: Predicate{
+			BuildDefinition: BuildDefinition{
+				BuildType: BuildType,
+				ExternalParameters: Parameters{
+					Remote:       src.Remote,
+					Organization: src.Organization,
+					Project:      src.Project,
+					Path:         src.Path,
+					Stage:        src.Stage,
+				},
+			},
+			RunDetails: RunDetails{
+				Builder:  Builder{ID: BuilderID},
+				Metadata: RunMetadata{StartedOn: src.Time.UTC().Truncate(time.Second)},
+			},
+		},
+	}
+	if src.Commit != "" {
+		st.Predicate.BuildDefinition.ResolvedDependencies = []ResourceDescriptor{{Name: "source", Digest: map[string]string{"gitCommit": src.Commit}}}
+	}
+	if src.Version != "" {
+		st.Predicate.RunDetails.Builder.Version = map[string]string{"git-drs": src.Version}
+	}
+	return st
+}
+
+// OID returns the sha256 digest of the statement's subject.
+func (st Statement) OID() string {
+	if len(st.Subject) == 0 {
+		return ""
+	}
+	return st.Subject[0].Digest["sha256"]
+}
+
+// Commit returns the source commit the statement names, if any.
+func (st Statement) Commit() string {
+	for _, dep := range st.Predicate.BuildDefinition.ResolvedDependencies {
+		if c := dep.Digest["gitCommit"]; c != "" {
+			return c
+		}
+	}
+	return ""
+}
+
+// Envelope is a DSSE envelope around a statement.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+type Signature struct {
+	KeyID string `json:"keyid,omitempty"`
+	Sig   string `json:"sig"`
+}
+
+// PAE is the DSSE pre-authentication encoding of a payload, the bytes that
+// are signed.
+func PAE(payloadType string, payload []byte) []byte {
+	return fmt.Appendf(nil, "DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)
+}
+
+// Sign serializes st and signs it with signer.
+func Sign(ctx context.Context, signer Signer, st Statement) (Envelope, error) {
+	payload, err := json.Marshal(st)
+	if err != nil {
+		return Envelope{}, err
+	}
+	sig, err := signer.Sign(ctx, PAE(PayloadType, payload))
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{
+		PayloadType: PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []Signature{{KeyID: signer.KeyID(), Sig: base64.StdEncoding.EncodeToString(sig)}},
+	}, nil
+}
+
+// ErrInvalid wraps every reason Verify rejects an envelope.
+var ErrInvalid = errors.New("invalid provenance")
+
+// Verify checks env's signature with verifier and that its statement is
+// about oid, and returns the statement.
+func Verify(ctx context.Context, verifier Verifier, env Envelope, oid string) (Statement, error) {
+	if env.PayloadType != PayloadType {
+		return Statement{}, fmt.Errorf("%w: payload type %q", ErrInvalid, env.PayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return Statement{}, fmt.Errorf("%w: payload: %v", ErrInvalid, err)
+	}
+	var errs []error
+	verified := false
+	for _, s := range env.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err == nil {
+			err = verifier.Verify(ctx, PAE(env.PayloadType, payload), sig)
+		}
+		if err == nil {
+			verified = true
+			break
+		}
+		errs = append(errs, err)
+	}
+	if !verified {
+		if len(errs) == 0 {
+			return Statement{}, fmt.Errorf("%w: unsigned", ErrInvalid)
+		}
+		return Statement{}, fmt.Errorf("%w: signature: %w", ErrInvalid, errors.Join(errs...))
+	}
+	var st Statement
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return Statement{}, fmt.Errorf("%w: statement: %v", ErrInvalid, err)
+	}
+	if st.Type != StatementType || st.PredicateType != PredicateType {
+		return Statement{}, fmt.Errorf("%w: statement type %q with predicate %q", ErrInvalid, st.Type, st.PredicateType)
+	}
+	if !strings.EqualFold(st.OID(), oid) {
+		return Statement{}, fmt.Errorf("%w: signed for sha256 %s, not %s", ErrInvalid, st.OID(), oid)
+	}
+	return st, nil
+}
+
+// FromObject returns the envelope on obj, if it has one.
+func FromObject(obj *drsapi.DrsObject) (Envelope, bool) {
+	return drsobject.AliasMetadata(obj, AliasPrefix, func(env Envelope) bool { return env.Payload != "" })
+}
+
+// SetOnObject records env on obj, replacing any earlier envelope.
+func SetOnObject(obj *drsapi.DrsObject, env Envelope) {
+	drsobject.SetAliasMetadata(obj, AliasPrefix, env)
+}
@@ -0,0 +1,130 @@
+package provenance
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func writeKeys(t *testing.T) (private, public string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	der, _ := x509.MarshalPKCS8PrivateKey(priv)
+	private = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(private, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	der, _ = x509.MarshalPKIXPublicKey(pub)
+	public = filepath.Join(dir, "key.pub")
+	if err := os.WriteFile(public, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return private, public
+}
+
+func TestSignAndVerify(t *testing.T) {
+	ctx := context.Background()
+	private, public := writeKeys(t)
+	oid := strings.Repeat("a", 64)
+	src := Source{OID: oid, Path: "data/a.bam", Remote: "origin", Project: "p", Commit: "c0ffee", Stage: StagePush, Version: "1.2.3", Time: time.Date(2026, 5, 6, 7, 8, 9, 500, time.UTC)}
+
+	signer, err := Settings{KeyFile: private}.Signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := Sign(ctx, signer, New(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := &drsapi.DrsObject{Aliases: &[]string{"user-alias"}}
+	SetOnObject(obj, env)
+	SetOnObject(obj, env)
+	if len(*obj.Aliases) != 2 || !slices.Contains(*obj.Aliases, "user-alias") {
+		t.Fatalf("aliases = %v; want one envelope and the user alias", *obj.Aliases)
+	}
+	got, ok := FromObject(obj)
+	if !ok {
+		t.Fatal("envelope not found on the record")
+	}
+
+	verifier, err := Settings{PublicKeyFile: public}.Verifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := Verify(ctx, verifier, got, oid)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if st.Commit() != "c0ffee" || st.Subject[0].Name != "data/a.bam" || st.Predicate.RunDetails.Builder.Version["git-drs"] != "1.2.3" {
+		t.Fatalf("statement = %+v", st)
+	}
+
+	if _, err := Verify(ctx, verifier, got, strings.Repeat("b", 64)); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("attestation accepted for other content: %v", err)
+	}
+	tampered := got
+	tampered.Payload = strings.Replace(tampered.Payload, "A", "B", 1)
+	if _, err := Verify(ctx, verifier, tampered, oid); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("tampered payload accepted: %v", err)
+	}
+	_, otherPublic := writeKeys(t)
+	other, _ := Settings{PublicKeyFile: otherPublic}.Verifier()
+	if _, err := Verify(ctx, other, got, oid); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("signature accepted under another key: %v", err)
+	}
+}
+
+func TestCommandSignerAndVerifier(t *testing.T) {
+	orig := runCommand
+	t.Cleanup(func() { runCommand = orig })
+	var verifyEnv []string
+	runCommand = func(_ context.Context, command string, stdin []byte, env ...string) ([]byte, error) {
+		if !strings.HasPrefix(string(stdin), "DSSEv1 ") {
+			t.Fatalf("%s got %q; want the DSSE encoding", command, stdin)
+		}
+		if command == "sign" {
+			return []byte("bundle\n"), nil
+		}
+		verifyEnv = env
+		return nil, nil
+	}
+	ctx := context.Background()
+	s := Settings{SignCommand: "sign", VerifyCommand: "check"}
+	signer, err := s.Signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oid := strings.Repeat("c", 64)
+	env, err := Sign(ctx, signer, New(Source{OID: oid, Path: "x"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := s.Verifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(ctx, verifier, env, oid); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !slices.Equal(verifyEnv, []string{"GIT_DRS_PROVENANCE_SIGNATURE=bundle"}) {
+		t.Fatalf("verify command env = %v", verifyEnv)
+	}
+
+	if _, err := (Settings{}).Signer(); !errors.Is(err, ErrKeyUnavailable) {
+		t.Fatalf("Signer without settings: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package pushsync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/fenceauth"
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+// currentUser is swapped in tests.
+var currentUser = fenceauth.CurrentUser
+
+// CheckWriteAccess asks fence which permissions the push's token holds and
+// fails before any upload when it lacks one the push needs on a project the
+// files register into: create, and update when upserting. Remotes without a
+// fence access token are not checked, and a lookup that fails is only
+// logged, so the check never blocks a push the server would accept.
+func CheckWriteAccess(ctx context.Context, gc *config.GitContext, files map[string]lfs.LfsFileInfo) error {
+	if gc == nil || !gc.CheckAuthz || gc.Credential == nil || strings.TrimSpace(gc.Credential.AccessToken) == "" || len(files) == 0 {
+		return nil
+	}
+	user, err := currentUser(ctx, gc.Credential.APIEndpoint, gc.Credential.AccessToken)
+	if err != nil {
+		if gc.Logger != nil {
+			gc.Logger.Debug("skipping permission check: user info unavailable", "error", err)
+		}
+		return nil
+	}
+	methods := []string{"create"}
+	if gc.Upsert {
+		methods = append(methods, "update")
+	}
+
+	scopes := map[string][2]string{}
+	for path := range files {
+		scoped := gc.ForPath(path)
+		scopes[scoped.Organization+"/"+scoped.ProjectId] = [2]string{scoped.Organization, scoped.ProjectId}
+	}
+	var problems []string
+	for _, scope := range scopes {
+		if missing := user.Missing(scope[0], scope[1], methods...); len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("  %s/%s: missing %s", scope[0], scope[1], strings.Join(missing, ", ")))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	name := user.Username
+	if name == "" {
+		name = "the current user"
+	}
+	return drserrors.WithKind(drserrors.ErrUnauthorized, fmt.Errorf(
+		"%s cannot push to remote %q:\n%s\nAsk a project administrator for these permissions, or set drs.push.check-authz false if the server grants them another way",
+		name, gc.RemoteName, strings.Join(problems, "\n")))
+}
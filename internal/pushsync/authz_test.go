@@ -0,0 +1,65 @@
+package pushsync
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/fenceauth"
+	"github.com/calypr/git-drs/internal/lfs"
+	syconf "github.com/calypr/syfon/client/config"
+)
+
+func TestCheckWriteAccess(t *testing.T) {
+	orig := currentUser
+	t.Cleanup(func() { currentUser = orig })
+	calls := 0
+	currentUser = func(ctx context.Context, endpoint, token string) (fenceauth.User, error) {
+		calls++
+		return fenceauth.User{Username: "alice", Permissions: map[string][]fenceauth.Permission{
+			"/programs/org/projects/proj": {{Method: "create", Service: "*"}},
+		}}, nil
+	}
+	gc := &config.GitContext{
+		RemoteName:   "origin",
+		Organization: "org",
+		ProjectId:    "proj",
+		CheckAuthz:   true,
+		Credential:   &syconf.Credential{APIEndpoint: "https://fence", AccessToken: "token"},
+	}
+	files := map[string]lfs.LfsFileInfo{"data/a.bam": {Name: "data/a.bam"}}
+
+	if err := CheckWriteAccess(context.Background(), gc, files); err != nil {
+		t.Fatalf("CheckWriteAccess with create granted: %v", err)
+	}
+
+	gc.Upsert = true
+	err := CheckWriteAccess(context.Background(), gc, files)
+	if !errors.Is(err, drserrors.ErrUnauthorized) {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "alice") || !strings.Contains(msg, "org/proj: missing update") {
+		t.Fatalf("error does not name the user and missing permission: %q", msg)
+	}
+
+	gc.CheckAuthz = false
+	calls = 0
+	if err := CheckWriteAccess(context.Background(), gc, files); err != nil || calls != 0 {
+		t.Fatalf("disabled check: err=%v calls=%d", err, calls)
+	}
+}
+
+func TestCheckWriteAccess_LookupFailureDoesNotBlock(t *testing.T) {
+	orig := currentUser
+	t.Cleanup(func() { currentUser = orig })
+	currentUser = func(context.Context, string, string) (fenceauth.User, error) {
+		return fenceauth.User{}, errors.New("no /user endpoint")
+	}
+	gc := &config.GitContext{CheckAuthz: true, Credential: &syconf.Credential{AccessToken: "token"}}
+	if err := CheckWriteAccess(context.Background(), gc, map[string]lfs.LfsFileInfo{"a": {}}); err != nil {
+		t.Fatalf("CheckWriteAccess: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package pushsync
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/config"
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/calypr/syfon/client/hash"
+)
+
+// PathRecord is a record BackfillPathRecords registered, or would register,
+// for one path of content tracked at several.
+type PathRecord struct {
+	Path  string `json:"path"`
+	OID   string `json:"oid"`
+	DRSID string `json:"drs_id"`
+}
+
+// BackfillResult is what BackfillPathRecords found.
+type BackfillResult struct {
+	Records []PathRecord `json:"records"`
+	// Unpushed lists the paths whose content has no record in the remote's
+	// project yet; the next push registers them.
+	Unpushed []string `json:"unpushed,omitempty"`
+}
+
+// BackfillPathRecords gives each path of content tracked at several paths its
+// own record, as push does for new files under the path-hash ID strategy, for
+// content pushed before that strategy was in place. The records copy the one
+// already registered for the content in the remote's project, so nothing is
+// uploaded. With dryRun the records are only listed.
+func BackfillPathRecords(cl *config.GitContext, ctx context.Context, files map[string]lfs.LfsFileInfo, dryRun bool) (BackfillResult, error) {
+	result := BackfillResult{Records: []PathRecord{}}
+	if err := cl.RequireWrite(); err != nil {
+		return result, err
+	}
+	if cl.IDs.Strategy != localdrsobject.IDPathHash {
+		return result, fmt.Errorf("remote %q uses the %s ID strategy; path records need path-hash (git config drs.remote.%s.id-strategy path-hash)", cl.RemoteName, cl.IDs.Strategy, cl.RemoteName)
+	}
+	for _, group := range GroupByPathScope(cl, files) {
+		if err := backfillScope(group.Context, ctx, group.Files, dryRun, &result); err != nil {
+			return result, err
+		}
+	}
+	sort.Slice(result.Records, func(i, j int) bool { return result.Records[i].Path < result.Records[j].Path })
+	sort.Strings(result.Unpushed)
+	return result, nil
+}
+
+func backfillScope(cl *config.GitContext, ctx context.Context, files map[string]lfs.LfsFileInfo, dryRun bool, result *BackfillResult) error {
+	s := newBatchSyncSession(cl, ctx, nil, nil, nil)
+	s.normalizeFiles(files)
+	if err := s.lookupMetadata(); err != nil {
+		return err
+	}
+	for _, oid := range s.oids {
+		if len(s.duplicates[oid]) == 0 {
+			continue
+		}
+		match, err := drsremote.FindMatchingRecord(s.existingByHash[oid], s.rt.Scope.Organization, s.rt.Scope.Project)
+		if err != nil || match == nil {
+			result.Unpushed = append(result.Unpushed, s.filesByOID[oid].Name)
+			for _, f := range s.duplicates[oid] {
+				result.Unpushed = append(result.Unpushed, f.Name)
+			}
+			continue
+		}
+		s.drsObjByOID[oid] = match
+	}
+
+	extra := s.duplicatePathRecords()
+	if len(extra) == 0 {
+		return nil
+	}
+	pathOf := s.pathsByID()
+	if dryRun {
+		for _, obj := range extra {
+			oid := localdrsobject.NormalizeOid(hash.ConvertDrsChecksumsToHashInfo(obj.Checksums).SHA256)
+			result.Records = append(result.Records, PathRecord{Path: pathOf[obj.Id], OID: oid, DRSID: obj.Id})
+		}
+		return nil
+	}
+
+	s.stampOrigin(nil, extra)
+	if err := s.attest(nil, extra); err != nil {
+		return err
+	}
+	candidates := make([]drsapi.DrsObjectCandidate, len(extra))
+	for i, obj := range extra {
+		candidates[i] = localdrsobject.ConvertToCandidate(obj)
+	}
+	s.rt.Logger.InfoContext(ctx, fmt.Sprintf("bulk registering %d path records", len(candidates)))
+	registered, err := s.rt.API.Client.DRS().RegisterObjects(ctx, drsapi.RegisterObjectsJSONRequestBody{Candidates: candidates})
+	if err != nil {
+		return fmt.Errorf("bulk register failed: %w", err)
+	}
+	events := make([]audit.Event, 0, len(registered.Objects))
+	for i := range registered.Objects {
+		obj := registered.Objects[i]
+		oid := localdrsobject.NormalizeOid(hash.ConvertDrsChecksumsToHashInfo(obj.Checksums).SHA256)
+		result.Records = append(result.Records, PathRecord{Path: pathOf[obj.Id], OID: oid, DRSID: obj.Id})
+		events = append(events, audit.Event{
+			Action:  audit.ActionRegister,
+			Remote:  s.rt.API.RemoteName,
+			Project: s.rt.Scope.Project,
+			DRSID:   obj.Id,
+			OID:     oid,
+		})
+	}
+	recordAudit(s.rt.Logger, events...)
+	drsremote.ForgetHashes(ctx, s.oids)
+	return nil
+}
+
+// pathsByID maps the path-hash ID of every path in the session to the path.
+func (s *batchSyncSession) pathsByID() map[string]string {
+	out := map[string]string{}
+	for _, oid := range s.oids {
+		for _, f := range append([]lfs.LfsFileInfo{s.filesByOID[oid]}, s.duplicates[oid]...) {
+			out[s.rt.API.IDs.ID(s.rt.Scope.Project, f.Name, oid)] = filepath.ToSlash(f.Name)
+		}
+	}
+	return out
+}
@@ -0,0 +1,129 @@
+package pushsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	syclient "github.com/calypr/syfon/client"
+)
+
+func TestBackfillPathRecords(t *testing.T) {
+	origAudit, origHistory := recordAudit, pathHistory
+	t.Cleanup(func() { recordAudit, pathHistory = origAudit, origHistory })
+	var audited []audit.Event
+	recordAudit = func(_ *slog.Logger, events ...audit.Event) { audited = append(audited, events...) }
+	pathHistory = func(context.Context, string) ([]lfs.PathRevision, error) { return nil, errors.New("no history") }
+
+	pushed, unpushed := strings.Repeat("e", 64), strings.Repeat("f", 64)
+	ids := localdrsobject.Minter{Strategy: localdrsobject.IDPathHash}
+	existing := drsapi.DrsObject{
+		Id:               localdrsobject.DeterministicID("e2e", pushed),
+		Name:             ptrString("x.bin"),
+		Size:             3,
+		Checksums:        []drsapi.Checksum{{Type: "sha256", Checksum: pushed}},
+		ControlledAccess: &[]string{"/organization/syfon/project/e2e"},
+		AccessMethods: &[]drsapi.AccessMethod{{
+			Type: drsapi.AccessMethodTypeS3,
+			AccessUrl: &struct {
+				Headers *[]string `json:"headers,omitempty"`
+				Url     string    `json:"url"`
+			}{Url: "s3://syfon-e2e-bucket/" + pushed},
+		}},
+	}
+	var registered []drsapi.DrsObjectCandidate
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		status, body := http.StatusOK, `{"resolved_drs_object":[]}`
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/objects/checksum/"+pushed):
+			data, _ := json.Marshal(map[string][]drsapi.DrsObject{"resolved_drs_object": {existing}})
+			body = string(data)
+		case strings.HasSuffix(r.URL.Path, "/objects/register"):
+			var req drsapi.RegisterObjectsJSONRequestBody
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatal(err)
+			}
+			registered = req.Candidates
+			objects := make([]drsapi.DrsObject, len(req.Candidates))
+			for i, c := range req.Candidates {
+				// Like the server, register under the requested ID.
+				objects[i] = drsapi.DrsObject{Id: "minted-from-checksum", Size: c.Size, Checksums: c.Checksums}
+				for _, a := range *c.Aliases {
+					if id, ok := strings.CutPrefix(a, "id:"); ok {
+						objects[i].Id = id
+					}
+				}
+			}
+			data, _ := json.Marshal(drsapi.N201ObjectsCreated{Objects: objects})
+			status, body = http.StatusCreated, string(data)
+		}
+		return &http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Request:    r,
+		}, nil
+	})}
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl := &config.GitContext{
+		Client:       raw.(*syclient.Client),
+		RemoteName:   "origin",
+		Organization: "syfon",
+		ProjectId:    "e2e",
+		BucketName:   "syfon-e2e-bucket",
+		Logger:       drslog.NewNoOpLogger(),
+	}
+	files := map[string]lfs.LfsFileInfo{
+		"a/x.bin":  {Name: "a/x.bin", Oid: pushed, Size: 3},
+		"b/x.bin":  {Name: "b/x.bin", Oid: pushed, Size: 3},
+		"solo.bin": {Name: "solo.bin", Oid: unpushed, Size: 3},
+		"c/new":    {Name: "c/new", Oid: unpushed, Size: 3},
+		"only.bin": {Name: "only.bin", Oid: strings.Repeat("9", 64), Size: 3},
+	}
+
+	if _, err := BackfillPathRecords(cl, context.Background(), files, false); err == nil {
+		t.Fatal("backfill ran under the project-hash strategy")
+	}
+	cl.IDs = ids
+
+	dry, err := BackfillPathRecords(cl, context.Background(), files, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if registered != nil || len(dry.Records) != 2 {
+		t.Fatalf("dry run registered %d, listed %+v", len(registered), dry.Records)
+	}
+
+	result, err := BackfillPathRecords(cl, context.Background(), files, false)
+	if err != nil {
+		t.Fatalf("BackfillPathRecords: %v", err)
+	}
+	want := []PathRecord{
+		{Path: "a/x.bin", OID: pushed, DRSID: ids.ID("e2e", "a/x.bin", pushed)},
+		{Path: "b/x.bin", OID: pushed, DRSID: ids.ID("e2e", "b/x.bin", pushed)},
+	}
+	if !reflect.DeepEqual(result.Records, want) || !reflect.DeepEqual(result.Unpushed, []string{"c/new", "solo.bin"}) {
+		t.Fatalf("result = %+v; want records %+v and c/new, solo.bin unpushed", result, want)
+	}
+	if len(registered) != 2 || registered[0].AccessMethods == nil || (*registered[0].AccessMethods)[0].AccessUrl.Url != "s3://syfon-e2e-bucket/"+pushed {
+		t.Fatalf("registered %+v; want copies of the existing record", registered)
+	}
+	if len(audited) != 2 {
+		t.Fatalf("audited %d events, want 2", len(audited))
+	}
+}
@@ -6,30 +6,53 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/calypr/git-drs/internal/audit"
 	localcommon "github.com/calypr/git-drs/internal/common"
 	"github.com/calypr/git-drs/internal/config"
 	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
 	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/hooks"
 	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/metrics"
+	"github.com/calypr/git-drs/internal/projectdir"
+	"github.com/calypr/git-drs/internal/provenance"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	sycommon "github.com/calypr/syfon/client/common"
 	"github.com/calypr/syfon/client/hash"
-	"github.com/google/uuid"
 	"golang.org/x/sync/errgroup"
 )
 
+// recordAudit and runHook are swapped in tests.
+var (
+	recordAudit = audit.RecordOrWarn
+	runHook     = hooks.Run
+)
+
 type batchSyncSession struct {
-	ctx            context.Context
-	rt             *pushRuntime
-	reporter       UploadProgressReporter
-	filesByOID     map[string]lfs.LfsFileInfo
-	oids           []string
+	ctx        context.Context
+	rt         *pushRuntime
+	reporter   UploadProgressReporter
+	filesByOID map[string]lfs.LfsFileInfo
+	oids       []string
+	// duplicates holds the further paths of each oid tracked at several.
+	duplicates     map[string][]lfs.LfsFileInfo
 	drsObjByOID    map[string]*drsapi.DrsObject
 	existingByHash map[string][]drsapi.DrsObject
 	uploadRequired map[string]bool
+	// sealed maps oids to ciphertext or compressed content staged for upload.
+	sealed map[string]string
+	// registered holds the oids whose records this push registered.
+	registered map[string]bool
+	pending    *pendingUploads
+	timings    *transferTimings
+	// stage is when the records are registered, for their attestations.
+	stage string
 }
 
 type uploadCandidate struct {
@@ -40,25 +63,119 @@ type uploadCandidate struct {
 	src  string
 }
 
-// BatchSyncForPush performs checksum-first push preparation.
+// BatchSyncForPush performs checksum-first push preparation. Files under a
+// path scope are registered in that scope's project, one scope at a time.
+// Intents queued in the remote's outbox are pushed along with files and
+// leave the outbox once they are.
 func BatchSyncForPush(cl *config.GitContext, ctx context.Context, files map[string]lfs.LfsFileInfo, reporter UploadProgressReporter) error {
-	session := &batchSyncSession{
+	if err := cl.RequireWrite(); err != nil {
+		return err
+	}
+	queue := loadOutbox(cl.RemoteName)
+	files = queue.merge(files)
+	if len(files) == 0 {
+		return nil
+	}
+	pending := loadPendingUploads(cl.RemoteName)
+	timings := newTransferTimings(cl.RemoteName)
+	defer timings.finish(cl.Logger, cl.Report)
+	for _, group := range GroupByPathScope(cl, files) {
+		if err := syncScope(group.Context, ctx, group.Files, reporter, pending, timings); err != nil {
+			return err
+		}
+	}
+	if len(queue.intents) == 0 {
+		return nil
+	}
+	_, err := updateOutbox(cl.RemoteName, func(o *outbox) { o.remove(files) })
+	return err
+}
+
+// ScopeGroup is the files a push registers under one scope.
+type ScopeGroup struct {
+	Context *config.GitContext
+	Files   map[string]lfs.LfsFileInfo
+}
+
+// GroupByPathScope splits files by the scope they register under. The
+// remote's own scope comes first, then path scopes by prefix; a remote
+// without path scopes yields a single group.
+func GroupByPathScope(cl *config.GitContext, files map[string]lfs.LfsFileInfo) []ScopeGroup {
+	if len(cl.PathScopes) == 0 {
+		return []ScopeGroup{{Context: cl, Files: files}}
+	}
+	byPrefix := map[string]*ScopeGroup{}
+	for key, f := range files {
+		prefix := ""
+		if s, ok := config.MatchPathScope(cl.PathScopes, f.Name); ok {
+			prefix = s.Prefix
+		}
+		g := byPrefix[prefix]
+		if g == nil {
+			g = &ScopeGroup{Context: cl.ForPath(f.Name), Files: map[string]lfs.LfsFileInfo{}}
+			byPrefix[prefix] = g
+		}
+		g.Files[key] = f
+	}
+	prefixes := make([]string, 0, len(byPrefix))
+	for p := range byPrefix {
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+	groups := make([]ScopeGroup, len(prefixes))
+	for i, p := range prefixes {
+		groups[i] = *byPrefix[p]
+	}
+	return groups
+}
+
+func newBatchSyncSession(cl *config.GitContext, ctx context.Context, reporter UploadProgressReporter, pending *pendingUploads, timings *transferTimings) *batchSyncSession {
+	return &batchSyncSession{
 		ctx:            ctx,
 		rt:             newPushRuntime(cl),
 		reporter:       reporter,
 		drsObjByOID:    make(map[string]*drsapi.DrsObject),
 		existingByHash: make(map[string][]drsapi.DrsObject),
 		uploadRequired: make(map[string]bool),
+		sealed:         make(map[string]string),
+		pending:        pending,
+		timings:        timings,
+		stage:          provenance.StagePush,
 	}
-	if len(files) == 0 {
-		return nil
-	}
+}
 
-	session.normalizeFiles(files)
-	if err := session.lookupMetadata(); err != nil {
+// register looks up files and registers records for those the server lacks.
+func (s *batchSyncSession) register(files map[string]lfs.LfsFileInfo) error {
+	s.normalizeFiles(files)
+	s.timings.considered(len(s.oids))
+	if err := s.lookupMetadata(); err != nil {
 		return err
 	}
-	if err := session.ensureMetadataRegistered(); err != nil {
+	if err := s.ensureMetadataRegistered(); err != nil {
+		return err
+	}
+	return s.recordServerIDs()
+}
+
+// syncScope registers and uploads the files of one scope, a window of oids
+// at a time.
+func syncScope(cl *config.GitContext, ctx context.Context, files map[string]lfs.LfsFileInfo, reporter UploadProgressReporter, pending *pendingUploads, timings *transferTimings) error {
+	for window := range windows(files, syncWindow) {
+		if err := syncWindowFiles(cl, ctx, window, reporter, pending, timings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func syncWindowFiles(cl *config.GitContext, ctx context.Context, files map[string]lfs.LfsFileInfo, reporter UploadProgressReporter, pending *pendingUploads, timings *transferTimings) error {
+	session := newBatchSyncSession(cl, ctx, reporter, pending, timings)
+	session.rt.Journal = loadUploadJournal(cl.RemoteName)
+	defer session.removeSealed()
+	// Lookups cached for the limit check are not needed past the window.
+	defer func() { drsremote.ForgetHashes(ctx, session.oids) }()
+
+	if err := session.register(files); err != nil {
 		return err
 	}
 
@@ -66,21 +183,43 @@ func BatchSyncForPush(cl *config.GitContext, ctx context.Context, files map[stri
 	if err != nil {
 		return err
 	}
-	if len(candidates) == 0 {
-		return nil
+	if len(candidates) > 0 {
+		if err := session.executeUploadPlan(candidates); err != nil {
+			return err
+		}
+		uploaded := make([]string, len(candidates))
+		for i, c := range candidates {
+			uploaded[i] = c.oid
+		}
+		if err := session.pending.done(uploaded); err != nil {
+			return err
+		}
+		if err := session.rt.Journal.forget(uploaded); err != nil {
+			return err
+		}
 	}
-
-	return session.executeUploadPlan(candidates)
+	return session.verifyReadable()
 }
 
+// normalizeFiles keys files by oid. Content tracked at several paths is
+// registered under the first path in sorted order; the rest are kept in
+// duplicates.
 func (s *batchSyncSession) normalizeFiles(files map[string]lfs.LfsFileInfo) {
 	s.filesByOID = make(map[string]lfs.LfsFileInfo, len(files))
+	s.duplicates = make(map[string][]lfs.LfsFileInfo)
+	sorted := make([]lfs.LfsFileInfo, 0, len(files))
 	for _, f := range files {
+		sorted = append(sorted, f)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for _, f := range sorted {
 		oid := localdrsobject.NormalizeOid(f.Oid)
 		if oid == "" {
 			continue
 		}
 		if _, exists := s.filesByOID[oid]; exists {
+			f.Oid = oid
+			s.duplicates[oid] = append(s.duplicates[oid], f)
 			continue
 		}
 		f.Oid = oid
@@ -92,12 +231,15 @@ func (s *batchSyncSession) normalizeFiles(files map[string]lfs.LfsFileInfo) {
 
 func (s *batchSyncSession) lookupMetadata() error {
 	s.existingByHash = make(map[string][]drsapi.DrsObject, len(s.oids))
+	if len(s.oids) == 0 {
+		return nil
+	}
+	byHash, err := drsremote.ObjectsByHashes(s.ctx, s.rt.API, s.oids)
+	if err != nil {
+		return fmt.Errorf("hash lookup failed: %w", err)
+	}
 	for _, oid := range s.oids {
-		objects, err := drsremote.ObjectsByHash(s.ctx, s.rt.API, oid)
-		if err != nil {
-			return fmt.Errorf("hash lookup failed for oid %s: %w", oid, err)
-		}
-		for _, obj := range objects {
+		for _, obj := range byHash[oid] {
 			objOID := localdrsobject.NormalizeOid(hash.ConvertDrsChecksumsToHashInfo(obj.Checksums).SHA256)
 			if objOID == "" {
 				continue
@@ -109,7 +251,7 @@ func (s *batchSyncSession) lookupMetadata() error {
 }
 
 func (s *batchSyncSession) ensureMetadataRegistered() error {
-	toRegister := make([]drsapi.DrsObjectCandidate, 0)
+	var toRegister, toUpload []string
 
 	for _, oid := range s.oids {
 		obj, err := s.getOrCreateDRSObjectCandidate(oid)
@@ -120,13 +262,24 @@ func (s *batchSyncSession) ensureMetadataRegistered() error {
 
 		recs := s.existingByHash[oid]
 		if len(recs) == 0 {
-			toRegister = append(toRegister, localdrsobject.ConvertToCandidate(obj))
+			if err := s.sealObject(oid, obj, nil); err != nil {
+				return err
+			}
+			s.chainVersion(oid, obj)
+			toRegister = append(toRegister, oid)
+			toUpload = append(toUpload, oid)
 			s.uploadRequired[oid] = true
 			continue
 		}
 		if match, err := drsremote.FindMatchingRecord(recs, s.rt.Scope.Organization, s.rt.Scope.Project); err == nil && match != nil {
 			s.drsObjByOID[oid] = match
-			if s.rt.Tuning.ForceUpload {
+			s.warnUnregisteredAliases(oid, obj, match)
+			// A record left by a push that failed before uploading is
+			// uploaded again, like a forced upload.
+			if s.rt.Tuning.ForceUpload || s.pending.has(oid) {
+				if err := s.sealObject(oid, obj, match); err != nil {
+					return err
+				}
 				s.uploadRequired[oid] = true
 			}
 			continue
@@ -138,32 +291,212 @@ func (s *batchSyncSession) ensureMetadataRegistered() error {
 			if err != nil {
 				return err
 			}
+			localdrsobject.AddAliases(reuseObj, localdrsobject.Aliases(*obj)...)
 			s.drsObjByOID[oid] = reuseObj
-			toRegister = append(toRegister, localdrsobject.ConvertToCandidate(reuseObj))
+			s.chainVersion(oid, reuseObj)
+			toRegister = append(toRegister, oid)
 			continue
 		}
 
-		toRegister = append(toRegister, localdrsobject.ConvertToCandidate(obj))
+		if err := s.sealObject(oid, obj, nil); err != nil {
+			return err
+		}
+		s.chainVersion(oid, obj)
+		toRegister = append(toRegister, oid)
+		toUpload = append(toUpload, oid)
 		s.uploadRequired[oid] = true
 	}
 
-	if len(toRegister) == 0 {
+	if err := s.refuseUnstaged(toRegister); err != nil {
+		return err
+	}
+	if len(toRegister) > 0 {
+		if err := s.stampFromHook(toRegister); err != nil {
+			return err
+		}
+	}
+	extra := s.duplicatePathRecords()
+	if len(toRegister) == 0 && len(extra) == 0 {
 		return nil
 	}
+	s.stampOrigin(toRegister, extra)
+	if err := s.attest(toRegister, extra); err != nil {
+		return err
+	}
+	candidates := make([]drsapi.DrsObjectCandidate, 0, len(toRegister)+len(extra))
+	for _, oid := range toRegister {
+		obj := s.drsObjByOID[oid]
+		if s.pathIDs() && s.mintedForPath(oid, obj.Id) {
+			localdrsobject.RequestID(obj)
+		}
+		candidates = append(candidates, localdrsobject.ConvertToCandidate(obj))
+	}
+	extraIDs := make(map[string]bool, len(extra))
+	for _, obj := range extra {
+		candidates = append(candidates, localdrsobject.ConvertToCandidate(obj))
+		extraIDs[obj.Id] = true
+	}
+	if err := s.pending.add(toUpload); err != nil {
+		return err
+	}
 
-	s.rt.Logger.InfoContext(s.ctx, fmt.Sprintf("bulk registering %d missing records", len(toRegister)))
+	s.rt.Logger.InfoContext(s.ctx, fmt.Sprintf("bulk registering %d missing records", len(candidates)))
+	start := time.Now()
 	registered, err := s.rt.API.Client.DRS().RegisterObjects(s.ctx, drsapi.RegisterObjectsJSONRequestBody{
-		Candidates: toRegister,
+		Candidates: candidates,
 	})
+	registerTime := time.Since(start)
 	if err != nil {
 		return fmt.Errorf("bulk register failed: %w", err)
 	}
+	if s.registered == nil {
+		s.registered = make(map[string]bool, len(registered.Objects))
+	}
+	events := make([]audit.Event, 0, len(registered.Objects))
+	timed := make(map[string]string, len(registered.Objects))
+	var registeredOIDs []string
 	for i := range registered.Objects {
 		obj := registered.Objects[i]
 		oid := localdrsobject.NormalizeOid(hash.ConvertDrsChecksumsToHashInfo(obj.Checksums).SHA256)
-		if oid != "" {
+		if oid != "" && !extraIDs[obj.Id] {
 			copyObj := obj
 			s.drsObjByOID[oid] = &copyObj
+			s.registered[oid] = true
+			timed[oid] = s.filesByOID[oid].Name
+			registeredOIDs = append(registeredOIDs, oid)
+		}
+		events = append(events, audit.Event{
+			Action:  audit.ActionRegister,
+			Remote:  s.rt.API.RemoteName,
+			Project: s.rt.Scope.Project,
+			DRSID:   obj.Id,
+			OID:     oid,
+		})
+	}
+	s.timings.registered(timed, registerTime)
+	recordAudit(s.rt.Logger, events...)
+	drsremote.ForgetHashes(s.ctx, s.oids)
+	if len(registeredOIDs) == 0 {
+		return nil
+	}
+	_, err = runHook(s.ctx, s.hookPayload(hooks.PostRegister, registeredOIDs))
+	return err
+}
+
+// pathIDs reports whether records are keyed by path. The server mints IDs
+// from the checksum, so records keyed by path request their ID with
+// localdrsobject.RequestID.
+func (s *batchSyncSession) pathIDs() bool {
+	return s.rt.API != nil && s.rt.API.IDs.Strategy == localdrsobject.IDPathHash
+}
+
+// mintedForPath reports whether id is the path-hash ID of one of oid's
+// paths. Other IDs, such as those of reused records, are left to the server.
+func (s *batchSyncSession) mintedForPath(oid, id string) bool {
+	for _, f := range append([]lfs.LfsFileInfo{s.filesByOID[oid]}, s.duplicates[oid]...) {
+		if id != "" && id == s.rt.API.IDs.ID(s.rt.Scope.Project, f.Name, oid) {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicatePathRecords builds a record for each further path of content
+// tracked at several paths. Only the path-hash ID strategy gives each path
+// its own ID, so only under it do n copies of a file yield n records; other
+// strategies register the content once. Each record is a copy of the first
+// path's, named and versioned for its own path, and paths the server already
+// has a record for are skipped.
+func (s *batchSyncSession) duplicatePathRecords() []*drsapi.DrsObject {
+	if !s.pathIDs() {
+		return nil
+	}
+	var out []*drsapi.DrsObject
+	for _, oid := range s.oids {
+		primary := s.drsObjByOID[oid]
+		if primary == nil || len(s.duplicates[oid]) == 0 {
+			continue
+		}
+		// The staged record's ID may have been minted for any of the paths.
+		paths := append([]lfs.LfsFileInfo{s.filesByOID[oid]}, s.duplicates[oid]...)
+		for _, f := range paths {
+			id := s.rt.API.IDs.ID(s.rt.Scope.Project, f.Name, oid)
+			if id == primary.Id || slices.ContainsFunc(s.existingByHash[oid], func(r drsapi.DrsObject) bool { return r.Id == id }) {
+				continue
+			}
+			obj := *primary
+			name := filepath.Base(f.Name)
+			obj.Id, obj.SelfUri, obj.Name, obj.Version = id, "drs://"+id, &name, nil
+			localdrsobject.RequestID(&obj)
+			s.chainVersionAt(f.Name, oid, &obj)
+			out = append(out, &obj)
+		}
+	}
+	return out
+}
+
+// stampFromHook runs the pre-register hook over the records about to be
+// registered and applies the metadata it returns to them.
+func (s *batchSyncSession) stampFromHook(oids []string) error {
+	res, err := runHook(s.ctx, s.hookPayload(hooks.PreRegister, oids))
+	if err != nil {
+		return err
+	}
+	for _, stamp := range res.Objects {
+		oid := localdrsobject.NormalizeOid(stamp.OID)
+		obj := s.drsObjByOID[oid]
+		if obj == nil || !slices.Contains(oids, oid) {
+			s.rt.Logger.WarnContext(s.ctx, "pre-register hook stamped an object not being registered; ignored", "oid", stamp.OID)
+			continue
+		}
+		if stamp.Description != "" {
+			obj.Description = &stamp.Description
+		}
+		if stamp.MimeType != "" {
+			obj.MimeType = &stamp.MimeType
+		}
+		localdrsobject.AddAliases(obj, stamp.Aliases...)
+	}
+	return nil
+}
+
+// hookPayload describes the objects with oids for a hook.
+func (s *batchSyncSession) hookPayload(event string, oids []string) hooks.Payload {
+	p := hooks.Payload{
+		Event:        event,
+		Organization: s.rt.Scope.Organization,
+		Project:      s.rt.Scope.Project,
+		Objects:      make([]hooks.Object, 0, len(oids)),
+	}
+	if s.rt.API != nil {
+		p.Remote = s.rt.API.RemoteName
+	}
+	for _, oid := range oids {
+		p.Objects = append(p.Objects, hooks.ObjectFor(s.drsObjByOID[oid], oid, s.filesByOID[oid].Name))
+	}
+	return p
+}
+
+// recordServerIDs stores the ID each record is registered under in staged
+// objects that have none, as under the server ID strategy, so later commands
+// resolve the file without asking the server.
+func (s *batchSyncSession) recordServerIDs() error {
+	if s.rt.API == nil || s.rt.API.IDs.Strategy != localdrsobject.IDServer {
+		return nil
+	}
+	for _, oid := range s.oids {
+		obj := s.drsObjByOID[oid]
+		if obj == nil || obj.Id == "" {
+			continue
+		}
+		local, err := localdrsobject.ReadObject(localcommon.DRS_OBJS_PATH, oid)
+		if err != nil || local == nil || local.Id != "" {
+			continue
+		}
+		local.Id = obj.Id
+		local.SelfUri = obj.SelfUri
+		if err := localdrsobject.WriteObject(localcommon.DRS_OBJS_PATH, local, oid); err != nil {
+			return fmt.Errorf("record DRS ID for oid %s: %w", oid, err)
 		}
 	}
 	return nil
@@ -194,9 +527,14 @@ func (s *batchSyncSession) buildReusableScopedObject(oid string, existing *drsap
 func (s *batchSyncSession) getOrCreateDRSObjectCandidate(oid string) (*drsapi.DrsObject, error) {
 	file := s.filesByOID[oid]
 	if localObj, err := localdrsobject.ReadObject(localcommon.DRS_OBJS_PATH, oid); err == nil && localObj != nil {
-		return scopedDRSObjectForPush(s.rt, oid, file.Name, file.Size, localObj)
+		obj, err := scopedDRSObjectForPush(s.rt, oid, file.Name, file.Size, localObj)
+		if err != nil {
+			return nil, err
+		}
+		setStorageHint(s.rt, obj, file.Name)
+		return obj, nil
 	}
-	stat, err := os.Stat(file.Name)
+	stat, err := os.Stat(projectdir.Path(file.Name))
 	if err != nil {
 		return nil, fmt.Errorf("failed to stat file %s for oid %s: %w", file.Name, oid, err)
 	}
@@ -204,9 +542,25 @@ func (s *batchSyncSession) getOrCreateDRSObjectCandidate(oid string) (*drsapi.Dr
 	if err != nil {
 		return nil, fmt.Errorf("failed to build drs object for oid %s: %w", oid, err)
 	}
+	setStorageHint(s.rt, obj, file.Name)
 	return obj, nil
 }
 
+// warnUnregisteredAliases reports staged aliases missing from an existing
+// record; records are registered once, so they cannot be added after the
+// fact.
+func (s *batchSyncSession) warnUnregisteredAliases(oid string, staged, rec *drsapi.DrsObject) {
+	var missing []string
+	for _, a := range localdrsobject.Aliases(*staged) {
+		if !slices.Contains(localdrsobject.Aliases(*rec), a) {
+			missing = append(missing, a)
+		}
+	}
+	if len(missing) > 0 {
+		s.rt.Logger.WarnContext(s.ctx, "record already registered without staged aliases; they were not added", "oid", oid, "did", rec.Id, "aliases", strings.Join(missing, ","))
+	}
+}
+
 func scopedDRSObjectForPush(rt *pushRuntime, oid string, path string, size int64, existing *drsapi.DrsObject) (*drsapi.DrsObject, error) {
 	if rt == nil {
 		return existing, nil
@@ -215,7 +569,7 @@ func scopedDRSObjectForPush(rt *pushRuntime, oid string, path string, size int64
 		size = existing.Size
 	}
 	if size <= 0 {
-		if stat, err := os.Stat(path); err == nil {
+		if stat, err := os.Stat(projectdir.Path(path)); err == nil {
 			size = stat.Size()
 		}
 	}
@@ -228,9 +582,17 @@ func scopedDRSObjectForPush(rt *pushRuntime, oid string, path string, size int64
 		name = oid
 	}
 
-	did := uuid.NewSHA1(localdrsobject.UUIDNamespace, []byte(fmt.Sprintf("%s:%s", rt.Scope.Project, localdrsobject.NormalizeOid(oid)))).String()
+	var ids localdrsobject.Minter
+	if rt.API != nil {
+		ids = rt.API.IDs
+	}
+	did := ids.ID(rt.Scope.Project, path, oid)
 	if existing != nil && existing.Id != "" {
 		did = existing.Id
+		if rt.API != nil {
+			// Local objects carry IDs minted for the local scope.
+			did = rt.API.ProjectMap.ToRemoteID(did, oid)
+		}
 	}
 
 	obj, err := localdrsobject.BuildWithPrefix(name, oid, size, did, rt.Scope.Bucket, rt.Scope.Organization, rt.Scope.Project, rt.Scope.StoragePref)
@@ -309,7 +671,7 @@ func parseStorageURL(raw string) (bucket string, key string, ok bool) {
 		return "", "", false
 	}
 	switch strings.ToLower(u.Scheme) {
-	case "s3", "gs", "azblob":
+	case "s3", "gs", "az", "azblob":
 		return u.Host, strings.Trim(u.Path, "/"), true
 	default:
 		return "", "", false
@@ -329,6 +691,9 @@ func (s *batchSyncSession) identifyUploadCandidates() ([]uploadCandidate, error)
 
 		file := s.filesByOID[oid]
 		srcPath, canUpload, err := resolveUploadSourcePath(oid, file.Name, file.IsPointer)
+		if sealed, ok := s.sealed[oid]; ok {
+			srcPath, canUpload, err = sealed, true, nil
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve upload source for oid %s: %w", oid, err)
 		}
@@ -397,19 +762,14 @@ func (s *batchSyncSession) executeUploadPlan(candidates []uploadCandidate) error
 		s.reporter.OnUploadPlan(buildUploadPlanSummary(candidates))
 	}
 
+	planned := time.Now()
 	if len(small) > 0 {
 		eg, egCtx := errgroup.WithContext(s.ctx)
 		eg.SetLimit(concurrency)
 		for _, c := range small {
 			c := c
 			eg.Go(func() error {
-				s.reportUploadStarted(c)
-				uploadCtx := s.progressContextForCandidate(egCtx, c)
-				if err := uploadFileForObject(s.rt, uploadCtx, c.obj, c.src, false); err != nil {
-					return err
-				}
-				s.reportUploadCompleted(c)
-				return nil
+				return s.uploadCandidate(egCtx, c, planned)
 			})
 		}
 		if err := eg.Wait(); err != nil {
@@ -418,16 +778,32 @@ func (s *batchSyncSession) executeUploadPlan(candidates []uploadCandidate) error
 	}
 
 	for _, c := range large {
-		s.reportUploadStarted(c)
-		uploadCtx := s.progressContextForCandidate(s.ctx, c)
-		if err := uploadFileForObject(s.rt, uploadCtx, c.obj, c.src, false); err != nil {
+		if err := s.uploadCandidate(s.ctx, c, planned); err != nil {
 			return err
 		}
-		s.reportUploadCompleted(c)
 	}
 	return nil
 }
 
+// uploadCandidate uploads c, reporting progress and recording how long it
+// waited since the plan started at planned and how long its upload took.
+func (s *batchSyncSession) uploadCandidate(ctx context.Context, c uploadCandidate, planned time.Time) error {
+	if _, err := runHook(ctx, s.hookPayload(hooks.PreUpload, []string{c.oid})); err != nil {
+		return err
+	}
+	start := time.Now()
+	s.reportUploadStarted(c)
+	var retries atomic.Int64
+	uploadCtx := metrics.WithRetryCount(s.progressContextForCandidate(ctx, c), &retries)
+	err := uploadFileForObject(s.rt, uploadCtx, c.obj, c.src, false)
+	s.timings.uploaded(c, start.Sub(planned), time.Since(start), retries.Load(), err)
+	if err != nil {
+		return err
+	}
+	s.reportUploadCompleted(c)
+	return nil
+}
+
 func buildUploadPlanSummary(candidates []uploadCandidate) UploadPlanSummary {
 	files := make([]UploadPlanFile, 0, len(candidates))
 	var totalBytes int64
@@ -4,17 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
 
+	"github.com/calypr/git-drs/internal/audit"
 	"github.com/calypr/git-drs/internal/config"
 	"github.com/calypr/git-drs/internal/drslog"
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/hooks"
 	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/projectmap"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	syclient "github.com/calypr/syfon/client"
 	sycommon "github.com/calypr/syfon/client/common"
@@ -43,6 +49,10 @@ type pushUploadBackendStub struct {
 
 	resolveFunc func(context.Context, string, string, sycommon.FileMetadata, string) (string, error)
 	uploadFunc  func(context.Context, string, io.Reader, int64) error
+	initFunc    func(context.Context, string) (string, error)
+
+	resolves int
+	inits    int
 
 	lastResolve struct {
 		guid     string
@@ -94,6 +104,7 @@ func (b *pushUploadBackendStub) ResolveUploadURL(ctx context.Context, guid strin
 	b.lastResolve.filename = filename
 	b.lastResolve.metadata = metadata
 	b.lastResolve.bucket = bucket
+	b.resolves++
 	b.mu.Unlock()
 
 	if b.resolveFunc != nil {
@@ -102,7 +113,14 @@ func (b *pushUploadBackendStub) ResolveUploadURL(ctx context.Context, guid strin
 	return "https://upload.example/" + filename, nil
 }
 
-func (b *pushUploadBackendStub) MultipartInit(context.Context, string) (string, error) {
+func (b *pushUploadBackendStub) MultipartInit(ctx context.Context, guid string) (string, error) {
+	b.mu.Lock()
+	b.inits++
+	b.mu.Unlock()
+
+	if b.initFunc != nil {
+		return b.initFunc(ctx, guid)
+	}
 	return "upload-id", nil
 }
 
@@ -179,6 +197,22 @@ func TestEnsureMetadataRegisteredReusesExistingDownloadableRecordWithoutUpload(t
 		t.Fatalf("write temp file: %v", err)
 	}
 
+	var audited []audit.Event
+	origAudit := recordAudit
+	t.Cleanup(func() { recordAudit = origAudit })
+	recordAudit = func(_ *slog.Logger, events ...audit.Event) { audited = append(audited, events...) }
+
+	var hookRuns []hooks.Payload
+	origHook := runHook
+	t.Cleanup(func() { runHook = origHook })
+	runHook = func(_ context.Context, p hooks.Payload) (hooks.Result, error) {
+		hookRuns = append(hookRuns, p)
+		if p.Event != hooks.PreRegister {
+			return hooks.Result{}, nil
+		}
+		return hooks.Result{Objects: []hooks.Stamp{{OID: oid, Description: "sequenced by core lab", Aliases: []string{"lims:S-42"}}}}, nil
+	}
+
 	reusableURL := "s3://existing-bucket/cas/" + oid
 	var registerReq drsapi.RegisterObjectsJSONRequestBody
 	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
@@ -292,6 +326,18 @@ func TestEnsureMetadataRegisteredReusesExistingDownloadableRecordWithoutUpload(t
 	if session.drsObjByOID[oid] == nil {
 		t.Fatalf("expected resolved scoped object after registration")
 	}
+	if len(audited) != 1 || audited[0].Action != audit.ActionRegister || audited[0].DRSID != "scoped-id" || audited[0].OID != oid {
+		t.Fatalf("expected one register audit event, got %+v", audited)
+	}
+	if c := registerReq.Candidates[0]; c.Description == nil || *c.Description != "sequenced by core lab" || c.Aliases == nil || !slices.Contains(*c.Aliases, "lims:S-42") {
+		t.Fatalf("pre-register hook stamp not applied: %+v", c)
+	}
+	if len(hookRuns) != 2 || hookRuns[0].Event != hooks.PreRegister || hookRuns[1].Event != hooks.PostRegister {
+		t.Fatalf("expected pre- and post-register hooks, got %+v", hookRuns)
+	}
+	if got := hookRuns[1].Objects; len(got) != 1 || got[0].OID != oid || got[0].DRSID != "scoped-id" {
+		t.Fatalf("unexpected post-register payload: %+v", got)
+	}
 	needsUpload, err := session.needsUpload(oid)
 	if err != nil {
 		t.Fatalf("needsUpload returned error: %v", err)
@@ -608,4 +654,47 @@ func TestScopedDRSObjectForPushPreservesExplicitAddURLAccessMethod(t *testing.T)
 	}
 }
 
+func TestScopedDRSObjectForPushRewritesMappedProjectID(t *testing.T) {
+	tmp := t.TempDir()
+	filePath := filepath.Join(tmp, "mirror.bin")
+	if err := os.WriteFile(filePath, []byte("payload"), 0o644); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+
+	oid := "95d536cc8df0a8e265832c6bd0422d69593f564d5ff0518e77535c45bc10bfde"
+	rt := &pushRuntime{
+		API: &config.GitContext{
+			Organization: "programB",
+			ProjectId:    "projectY",
+			BucketName:   "prod-bucket",
+			ProjectMap: &projectmap.Mapping{
+				LocalOrganization:  "programA",
+				LocalProject:       "projectX",
+				RemoteOrganization: "programB",
+				RemoteProject:      "projectY",
+			},
+		},
+		Scope: pushScope{
+			Organization: "programB",
+			Project:      "projectY",
+			Bucket:       "prod-bucket",
+		},
+	}
+	existing := &drsapi.DrsObject{
+		Id:   localdrsobject.DeterministicID("projectX", oid),
+		Name: ptrString("mirror.bin"),
+	}
+
+	obj, err := scopedDRSObjectForPush(rt, oid, filePath, 7, existing)
+	if err != nil {
+		t.Fatalf("scopedDRSObjectForPush returned error: %v", err)
+	}
+	if want := localdrsobject.DeterministicID("projectY", oid); obj.Id != want {
+		t.Fatalf("id = %q, want remote-scope id %q", obj.Id, want)
+	}
+	if obj.ControlledAccess == nil || len(*obj.ControlledAccess) != 1 || (*obj.ControlledAccess)[0] != "/organization/programB/project/projectY" {
+		t.Fatalf("controlled access = %+v, want remote scope", obj.ControlledAccess)
+	}
+}
+
 func ptrString(s string) *string { return &s }
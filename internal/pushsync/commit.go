@@ -0,0 +1,77 @@
+package pushsync
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/provenance"
+)
+
+// RegisterAtCommit registers records for files without uploading their
+// content, as the pre-commit hook does when drs.registration.mode is commit.
+// Intents left in the outbox by earlier commits are registered with them.
+// New content is added to the remote's pending-upload list first, so the
+// next push uploads it even though its record then already exists.
+//
+// When the server cannot be reached, or fails with a server error, the files
+// are queued in the remote's outbox instead and the number queued is returned
+// with a nil error; the next commit, push or flush registers them. Other errors, such as a rejected
+// credential, are returned so the commit does not go ahead on a broken setup.
+func RegisterAtCommit(cl *config.GitContext, ctx context.Context, files map[string]lfs.LfsFileInfo) (int, error) {
+	if err := cl.RequireWrite(); err != nil {
+		return 0, err
+	}
+	queue := loadOutbox(cl.RemoteName)
+	queue.add(files, QueuedByCommit)
+	if len(queue.intents) == 0 {
+		return 0, nil
+	}
+	queued := queue.files()
+	err := registerFiles(cl, ctx, queued)
+	if err == nil {
+		_, err := updateOutbox(cl.RemoteName, func(o *outbox) { o.remove(queued) })
+		return 0, err
+	}
+	if !Deferrable(err) {
+		return 0, err
+	}
+	cl.Logger.WarnContext(ctx, "queued files for registration", "files", len(queue.intents), "error", err)
+	queue, err = updateOutbox(cl.RemoteName, func(o *outbox) { o.add(files, QueuedByCommit) })
+	if err != nil {
+		return 0, err
+	}
+	return len(queue.intents), nil
+}
+
+// registerFiles registers files one scope, and one window of oids, at a time
+// without uploading them.
+func registerFiles(cl *config.GitContext, ctx context.Context, files map[string]lfs.LfsFileInfo) error {
+	pending := loadPendingUploads(cl.RemoteName)
+	for _, group := range GroupByPathScope(cl, files) {
+		for window := range windows(group.Files, syncWindow) {
+			session := newBatchSyncSession(group.Context, ctx, nil, pending, nil)
+			session.stage = provenance.StageCommit
+			err := session.register(window)
+			session.removeSealed()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Deferrable reports whether a registration failure is worth retrying
+// later: the server could not be reached or answered with a server error.
+func Deferrable(err error) bool {
+	err = drserrors.Classify(err)
+	if errors.Is(err, drserrors.ErrNetwork) {
+		return true
+	}
+	status, ok := drserrors.Status(err)
+	return ok && status >= http.StatusInternalServerError
+}
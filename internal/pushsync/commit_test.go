@@ -0,0 +1,148 @@
+package pushsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/hooks"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	syclient "github.com/calypr/syfon/client"
+)
+
+func TestDuplicatePathRecords(t *testing.T) {
+	orig := pathHistory
+	t.Cleanup(func() { pathHistory = orig })
+	pathHistory = func(context.Context, string) ([]lfs.PathRevision, error) { return nil, errors.New("no history") }
+
+	oid := "cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc"
+	ids := localdrsobject.Minter{Strategy: localdrsobject.IDPathHash}
+	rt := newPushRuntime(&config.GitContext{IDs: ids, Logger: drslog.NewNoOpLogger()})
+	setTestPushScope(rt)
+	session := newBatchSyncSession(nil, context.Background(), nil, nil, nil)
+	session.rt = rt
+	session.normalizeFiles(map[string]lfs.LfsFileInfo{
+		"b/x.bin": {Name: "b/x.bin", Oid: oid, Size: 3},
+		"a/x.bin": {Name: "a/x.bin", Oid: "sha256:" + oid, Size: 3},
+		"c/x.bin": {Name: "c/x.bin", Oid: oid, Size: 3},
+	})
+	if got := session.filesByOID[oid].Name; got != "a/x.bin" || len(session.duplicates[oid]) != 2 {
+		t.Fatalf("primary = %q with %d duplicates; want a/x.bin with 2", got, len(session.duplicates[oid]))
+	}
+
+	// The staged record was minted for b/x.bin; c/x.bin is already registered.
+	primary, err := localdrsobject.BuildWithPrefix("x.bin", oid, 3, ids.ID("e2e", "b/x.bin", oid), "syfon-e2e-bucket", "syfon", "e2e", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.drsObjByOID[oid] = primary
+	session.existingByHash[oid] = []drsapi.DrsObject{{Id: ids.ID("e2e", "c/x.bin", oid)}}
+
+	extra := session.duplicatePathRecords()
+	if len(extra) != 1 {
+		t.Fatalf("duplicatePathRecords = %+v; want one record for a/x.bin", extra)
+	}
+	want := ids.ID("e2e", "a/x.bin", oid)
+	if r := extra[0]; r.Id != want || r.SelfUri != "drs://"+want || *r.Name != "x.bin" || r.AccessMethods == nil || !slices.Contains(*r.Aliases, "id:"+want) {
+		t.Fatalf("record = %+v; want %s named x.bin with the shared access methods", r, want)
+	}
+
+	rt.API.IDs = localdrsobject.Minter{}
+	if extra := session.duplicatePathRecords(); len(extra) != 0 {
+		t.Fatalf("project-hash strategy registered %d duplicate records", len(extra))
+	}
+}
+
+func TestRegisterAtCommitQueuesWhileOffline(t *testing.T) {
+	usePendingDir(t)
+	queueDir := t.TempDir()
+	origQueue, origAudit, origHook, origHistory := outboxPath, recordAudit, runHook, pathHistory
+	t.Cleanup(func() {
+		outboxPath, recordAudit, runHook, pathHistory = origQueue, origAudit, origHook, origHistory
+	})
+	outboxPath = func(remote string) (string, error) { return filepath.Join(queueDir, remote+".json"), nil }
+	recordAudit = func(*slog.Logger, ...audit.Event) {}
+	runHook = func(context.Context, hooks.Payload) (hooks.Result, error) { return hooks.Result{}, nil }
+	pathHistory = func(context.Context, string) ([]lfs.PathRevision, error) { return nil, errors.New("no history") }
+
+	oid := "dddddddddddddddddddddddddddddddddddddddddddddddddddddddddddddddd"
+	file := filepath.Join(t.TempDir(), "sample.bin")
+	if err := os.WriteFile(file, []byte("abc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]lfs.LfsFileInfo{file: {Name: file, Oid: oid, Size: 3}}
+
+	offline := true
+	var registered int
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if offline {
+			return nil, errors.New("dial tcp: connection refused")
+		}
+		status, body := http.StatusOK, `{"resolved_drs_object":[]}`
+		if strings.HasSuffix(r.URL.Path, "/objects/register") {
+			var req drsapi.RegisterObjectsJSONRequestBody
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatal(err)
+			}
+			registered += len(req.Candidates)
+			objects := make([]drsapi.DrsObject, len(req.Candidates))
+			for i, c := range req.Candidates {
+				objects[i] = drsapi.DrsObject{Id: "did-" + oid[:8], Size: c.Size, Checksums: c.Checksums}
+			}
+			data, _ := json.Marshal(drsapi.N201ObjectsCreated{Objects: objects})
+			status, body = http.StatusCreated, string(data)
+		}
+		return &http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Request:    r,
+		}, nil
+	})}
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl := &config.GitContext{
+		Client:       raw.(*syclient.Client),
+		RemoteName:   "origin",
+		Organization: "syfon",
+		ProjectId:    "e2e",
+		BucketName:   "syfon-e2e-bucket",
+		Logger:       drslog.NewNoOpLogger(),
+	}
+
+	queued, err := RegisterAtCommit(cl, context.Background(), files)
+	if err != nil || queued != 1 {
+		t.Fatalf("offline RegisterAtCommit = %d, %v; want 1 queued", queued, err)
+	}
+	if got := Outbox("origin"); len(got) != 1 || got[0].Path != filepath.ToSlash(file) || got[0].OID != oid || got[0].QueuedBy != QueuedByCommit {
+		t.Fatalf("outbox = %+v; want %s queued by commit", got, file)
+	}
+
+	// The next commit registers the queued file along with its own.
+	offline = false
+	queued, err = RegisterAtCommit(cl, context.Background(), nil)
+	if err != nil || queued != 0 || registered != 1 {
+		t.Fatalf("online RegisterAtCommit = %d, %v with %d registered; want the queued file registered", queued, err, registered)
+	}
+	if _, err := os.Stat(filepath.Join(queueDir, "origin.json")); !os.IsNotExist(err) {
+		t.Fatalf("outbox not removed once drained: %v", err)
+	}
+	if !loadPendingUploads("origin").has(oid) {
+		t.Fatal("registered content not left for the next push to upload")
+	}
+}
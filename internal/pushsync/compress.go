@@ -0,0 +1,133 @@
+package pushsync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	localcommon "github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/compression"
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/projectdir"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/calypr/syfon/client/hash"
+)
+
+// compressedUploadDir holds compressed content staged for upload.
+var compressedUploadDir = filepath.Join(localcommon.DRS_DIR, "tmp", "compressed")
+
+// compressForUpload compresses src with encoding into compressedUploadDir.
+// It returns the compressed path, which the caller uploads and then removes.
+func compressForUpload(encoding, oid, src string) (string, compression.Metadata, error) {
+	dir := projectdir.Path(compressedUploadDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", compression.Metadata{}, fmt.Errorf("create compressed upload dir: %w", err)
+	}
+	dst := filepath.Join(dir, oid)
+	meta, err := compression.CompressFile(encoding, src, dst)
+	if err != nil {
+		return "", compression.Metadata{}, err
+	}
+	return dst, meta, nil
+}
+
+// compressObject compresses the local content for oid before its record is
+// registered when its file type is configured for compression, and records
+// the content-encoding block on obj. Content that does not shrink is stored
+// as is. An existing record decides for itself: one registered without a
+// block is uploaded uncompressed, and one with a block is compressed with its
+// encoding, its stored hash and size updated when the bytes come out
+// differently.
+func (s *batchSyncSession) compressObject(oid string, obj *drsapi.DrsObject, existing *drsapi.DrsObject) error {
+	settings := s.rt.Compression
+	file := s.filesByOID[oid]
+	encoding := settings.Encoding
+	var want compression.Metadata
+	if existing != nil {
+		current, ok := compression.FromObject(existing)
+		if !ok {
+			return nil
+		}
+		want, encoding = current, current.Encoding
+	} else if !settings.Enabled() || !settings.Applies(file.Name) {
+		return nil
+	}
+	src, ok, err := resolveUploadSourcePath(oid, file.Name, file.IsPointer)
+	if err != nil {
+		return fmt.Errorf("failed to resolve upload source for oid %s: %w", oid, err)
+	}
+	if !ok {
+		return nil
+	}
+	compressed, meta, err := compressForUpload(encoding, oid, src)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		s.sealed[oid] = compressed
+		if meta.StoredSHA256 != want.StoredSHA256 {
+			return restampCompressed(s.rt, s.ctx, existing, oid, compressed, meta)
+		}
+		return nil
+	}
+	if st, err := os.Stat(src); err == nil && meta.StoredSize >= st.Size() {
+		s.rt.Logger.DebugContext(s.ctx, "compressed content is not smaller; storing as is", "oid", oid, "path", file.Name, "encoding", encoding)
+		_ = os.Remove(compressed)
+		return nil
+	}
+	compression.SetOnObject(obj, meta)
+	s.sealed[oid] = compressed
+	return nil
+}
+
+// uploadCompressed uploads path to an object whose record carries a
+// content-encoding block, compressing it first. Compressors may change their
+// output between versions, so bytes that differ from the recorded stored hash
+// are accepted once they decompress to the record's content.
+func uploadCompressed(rt *pushRuntime, ctx context.Context, obj *drsapi.DrsObject, path string, want compression.Metadata) error {
+	oid := localdrsobject.NormalizeOid(hash.ConvertDrsChecksumsToHashInfo(obj.Checksums).SHA256)
+	compressed, meta, err := compressForUpload(want.Encoding, oid, path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(compressed)
+	if meta.StoredSHA256 != want.StoredSHA256 {
+		if err := restampCompressed(rt, ctx, obj, oid, compressed, meta); err != nil {
+			return err
+		}
+	}
+	return uploadFileForObject(rt, ctx, obj, compressed, false)
+}
+
+// restampCompressed re-registers obj with meta as its content-encoding block
+// once compressed, which is about to replace the stored bytes, is checked to
+// decompress to the record's sha256. The record keeps its ID; only the
+// stored hash and size change.
+func restampCompressed(rt *pushRuntime, ctx context.Context, obj *drsapi.DrsObject, oid, compressed string, meta compression.Metadata) error {
+	in, err := os.Open(compressed)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	sum := sha256.New()
+	if err := compression.Decompress(sum, in, meta); err != nil {
+		return fmt.Errorf("check %s content for record %s: %w", meta.Encoding, obj.Id, err)
+	}
+	if got := hex.EncodeToString(sum.Sum(nil)); got != oid {
+		return fmt.Errorf("record %s: %s content decompresses to sha256 %s, want %s", obj.Id, meta.Encoding, got, oid)
+	}
+	updated := *obj
+	compression.SetOnObject(&updated, meta)
+	localdrsobject.RequestID(&updated)
+	if _, err := rt.API.Client.DRS().RegisterObjects(ctx, drsapi.RegisterObjectsJSONRequestBody{
+		Candidates: []drsapi.DrsObjectCandidate{localdrsobject.ConvertToCandidate(&updated)},
+	}); err != nil {
+		return fmt.Errorf("update stored hash of record %s: %w", obj.Id, err)
+	}
+	rt.Logger.InfoContext(ctx, "compressed content differs from the stored bytes; updated the record's stored hash", "did", obj.Id, "oid", oid, "encoding", meta.Encoding, "stored_sha256", meta.StoredSHA256)
+	compression.SetOnObject(obj, meta)
+	return nil
+}
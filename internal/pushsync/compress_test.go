@@ -0,0 +1,192 @@
+package pushsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/compression"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/encryption"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	syclient "github.com/calypr/syfon/client"
+)
+
+func TestCompressObjectStoresConfiguredTypesCompressed(t *testing.T) {
+	tmp := t.TempDir()
+	origDir := compressedUploadDir
+	t.Cleanup(func() { compressedUploadDir = origDir })
+	compressedUploadDir = filepath.Join(tmp, "compressed")
+
+	write := func(name string, content []byte) (string, string) {
+		t.Helper()
+		path := filepath.Join(tmp, name)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(content)
+		return path, hex.EncodeToString(sum[:])
+	}
+	vcf := bytes.Repeat([]byte("chr1\t10177\t.\tA\tAC\t100\tPASS\t.\n"), 200)
+	vcfPath, vcfOID := write("calls.vcf", vcf)
+	bamPath, bamOID := write("reads.bam", append([]byte("BAM\x01"), vcf...))
+	tinyPath, tinyOID := write("tiny.bed", []byte("x"))
+
+	session := &batchSyncSession{
+		ctx: context.Background(),
+		rt: &pushRuntime{
+			Logger:      slog.New(slog.NewTextHandler(os.Stderr, nil)),
+			Compression: compression.Settings{Encoding: compression.Gzip, Extensions: []string{".vcf", ".bed"}},
+		},
+		filesByOID: map[string]lfs.LfsFileInfo{
+			vcfOID:  {Oid: vcfOID, Name: vcfPath, Size: int64(len(vcf))},
+			bamOID:  {Oid: bamOID, Name: bamPath, Size: int64(len(vcf))},
+			tinyOID: {Oid: tinyOID, Name: tinyPath, Size: 1},
+		},
+		sealed: map[string]string{},
+	}
+
+	obj := &drsapi.DrsObject{Id: "vcf-id", Size: int64(len(vcf))}
+	if err := session.sealObject(vcfOID, obj, nil); err != nil {
+		t.Fatalf("sealObject returned error: %v", err)
+	}
+	meta, ok := compression.FromObject(obj)
+	if !ok || meta.Encoding != compression.Gzip {
+		t.Fatalf("expected gzip block on record, got aliases %v", obj.Aliases)
+	}
+	stored, err := os.ReadFile(session.sealed[vcfOID])
+	if err != nil {
+		t.Fatalf("read compressed upload: %v", err)
+	}
+	if int64(len(stored)) != meta.StoredSize || meta.StoredSize >= int64(len(vcf)) {
+		t.Fatalf("compressed upload is not the recorded stored content")
+	}
+
+	// Types not configured, and content that does not shrink, are stored as is.
+	for _, oid := range []string{bamOID, tinyOID} {
+		plain := &drsapi.DrsObject{Id: oid}
+		if err := session.sealObject(oid, plain, nil); err != nil {
+			t.Fatalf("sealObject returned error: %v", err)
+		}
+		if _, ok := compression.FromObject(plain); ok {
+			t.Fatalf("expected %s stored uncompressed", session.filesByOID[oid].Name)
+		}
+		if _, ok := session.sealed[oid]; ok {
+			t.Fatalf("expected no staged upload for %s", session.filesByOID[oid].Name)
+		}
+	}
+
+	// An existing record keeps the encoding it was registered with.
+	uncompressed := &drsapi.DrsObject{Id: "existing-plain"}
+	delete(session.sealed, vcfOID)
+	if err := session.sealObject(vcfOID, &drsapi.DrsObject{}, uncompressed); err != nil {
+		t.Fatalf("sealObject returned error: %v", err)
+	}
+	if _, ok := session.sealed[vcfOID]; ok {
+		t.Fatal("expected record without a block to be uploaded uncompressed")
+	}
+
+	// A record whose stored bytes this client compresses differently is
+	// re-registered under its ID with the new stored hash and size.
+	var registered []drsapi.DrsObjectCandidate
+	session.rt.API = &config.GitContext{Client: registerRecorder(t, &registered)}
+	other := &drsapi.DrsObject{Id: "existing-other", Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: vcfOID}}}
+	compression.SetOnObject(other, compression.Metadata{Encoding: compression.Gzip, StoredSHA256: "00", StoredSize: 1})
+	if err := session.sealObject(vcfOID, &drsapi.DrsObject{}, other); err != nil {
+		t.Fatalf("sealObject returned error: %v", err)
+	}
+	if got, _ := compression.FromObject(other); got != meta {
+		t.Fatalf("expected the record's block updated to %+v, got %+v", meta, got)
+	}
+	if len(registered) != 1 || registered[0].Aliases == nil || !slices.Contains(*registered[0].Aliases, "id:existing-other") {
+		t.Fatalf("expected the record re-registered under its ID, got %+v", registered)
+	}
+	if got, _ := compression.FromObject(&drsapi.DrsObject{Aliases: registered[0].Aliases}); got != meta {
+		t.Fatalf("expected the new stored hash registered, got %+v", got)
+	}
+
+	// Encrypting remotes encrypt instead.
+	keyFile := filepath.Join(tmp, "key")
+	if err := os.WriteFile(keyFile, bytes.Repeat([]byte("ab"), 32), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	origSealed := sealedUploadDir
+	t.Cleanup(func() { sealedUploadDir = origSealed })
+	sealedUploadDir = filepath.Join(tmp, "sealed")
+	session.rt.Encryption = encryption.Settings{Remote: "origin", KeyFile: keyFile}
+	encrypted := &drsapi.DrsObject{Id: "vcf-enc"}
+	if err := session.sealObject(vcfOID, encrypted, nil); err != nil {
+		t.Fatalf("sealObject returned error: %v", err)
+	}
+	if _, ok := compression.FromObject(encrypted); ok {
+		t.Fatal("expected encrypted record to carry no content-encoding block")
+	}
+
+	session.removeSealed()
+}
+
+func TestUploadCompressedRefusesContentOfAnotherRecord(t *testing.T) {
+	tmp := t.TempDir()
+	origDir := compressedUploadDir
+	t.Cleanup(func() { compressedUploadDir = origDir })
+	compressedUploadDir = filepath.Join(tmp, "compressed")
+	path := filepath.Join(tmp, "calls.vcf")
+	if err := os.WriteFile(path, []byte("chr1\t10177\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var registered []drsapi.DrsObjectCandidate
+	rt := &pushRuntime{
+		API:    &config.GitContext{Client: registerRecorder(t, &registered)},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	obj := &drsapi.DrsObject{Id: "other-content", Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: strings.Repeat("a", 64)}}}
+	want := compression.Metadata{Encoding: compression.Gzip, StoredSHA256: "00"}
+	compression.SetOnObject(obj, want)
+	err := uploadCompressed(rt, context.Background(), obj, path, want)
+	if err == nil || !strings.Contains(err.Error(), "decompresses to sha256") {
+		t.Fatalf("expected content of another record to be refused, got %v", err)
+	}
+	if len(registered) != 0 {
+		t.Fatalf("expected the record left alone, got %+v", registered)
+	}
+}
+
+// registerRecorder returns a client whose registrations are appended to
+// registered and echoed back.
+func registerRecorder(t *testing.T, registered *[]drsapi.DrsObjectCandidate) *syclient.Client {
+	t.Helper()
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Method != http.MethodPost || r.URL.Path != "/ga4gh/drs/v1/objects/register" {
+			return nil, io.EOF
+		}
+		var req drsapi.RegisterObjectsJSONRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode register request: %v", err)
+		}
+		*registered = append(*registered, req.Candidates...)
+		body, _ := json.Marshal(drsapi.N201ObjectsCreated{})
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Request:    r,
+		}, nil
+	})}
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("syclient.New: %v", err)
+	}
+	return raw.(*syclient.Client)
+}
@@ -0,0 +1,70 @@
+package pushsync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/syfon/client/transfer"
+	azureprovider "github.com/calypr/syfon/client/transfer/providers/azure"
+	s3provider "github.com/calypr/syfon/client/transfer/providers/s3"
+)
+
+// uploadBackend returns the backend uploads go through for rt's credential
+// source. The direct backend is built once per runtime.
+func (rt *pushRuntime) uploadBackend(ctx context.Context) (transfer.MultipartBackend, error) {
+	if !rt.Upload.Direct() {
+		backend := uploadBackendForRuntime(rt)
+		if backend == nil {
+			return nil, fmt.Errorf("upload backend is required")
+		}
+		if strings.TrimSpace(rt.Scope.Organization) != "" && strings.TrimSpace(rt.Scope.Project) != "" {
+			backend = &scopedUploadURLBackend{MultipartBackend: backend, rt: rt}
+		}
+		return backend, nil
+	}
+	rt.directOnce.Do(func() {
+		rt.direct, rt.directErr = directUploadBackend(rt, ctx)
+	})
+	return rt.direct, rt.directErr
+}
+
+// directUploadBackend uploads straight to the remote's bucket with AWS
+// credentials instead of URLs signed by the DRS server, for the ambient and
+// static credential sources. A bucket given as an az:// URL is an Azure
+// container, written with the remote's Azure account and credential. The
+// object key is the one the record's access method already names, so
+// registration is unchanged.
+func directUploadBackend(rt *pushRuntime, ctx context.Context) (transfer.MultipartBackend, error) {
+	bucket := strings.TrimSpace(rt.Scope.Bucket)
+	if bucket == "" {
+		return nil, fmt.Errorf("credential source %q uploads directly to the bucket, but no bucket is configured", rt.Upload.CredentialSource)
+	}
+	var (
+		remote string
+		logger transfer.TransferLogger = transfer.NoOpLogger{}
+	)
+	if rt.API != nil {
+		remote = rt.API.RemoteName
+		if rt.API.Client != nil {
+			logger = rt.API.Client.Data().Logger()
+		}
+	}
+	if container, ok := config.AzureContainer(bucket); ok {
+		settings, err := config.LoadAzureSettings(remote)
+		if err != nil {
+			return nil, err
+		}
+		client, err := config.NewAzureClient(settings, "")
+		if err != nil {
+			return nil, err
+		}
+		return azureprovider.NewBackend(logger, client, container), nil
+	}
+	client, err := config.NewS3Client(ctx, remote, rt.Upload, bucket, applyStorageHint)
+	if err != nil {
+		return nil, err
+	}
+	return s3provider.NewBackend(logger, client, bucket), nil
+}
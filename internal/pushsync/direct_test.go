@@ -0,0 +1,88 @@
+package pushsync
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestUploadFileForObjectStaticCredentialsUploadsToBucket(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	var (
+		mu   sync.Mutex
+		puts = map[string]string{}
+		auth string
+	)
+	s3srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPut {
+			puts[r.URL.Path] = string(body)
+			auth = r.Header.Get("Authorization")
+		}
+		w.Header().Set("ETag", `"etag"`)
+	}))
+	defer s3srv.Close()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "reads.bam")
+	if err := os.WriteFile(src, []byte("direct upload"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rt := &pushRuntime{
+		Logger: drslog.NewNoOpLogger(),
+		Scope:  pushScope{Organization: "org", Project: "proj", Bucket: "data-bucket"},
+		Upload: config.UploadSettings{CredentialSource: config.CredentialSourceStatic, Region: "us-east-1", Endpoint: s3srv.URL},
+	}
+	obj := &drsapi.DrsObject{
+		Id:   "did-1",
+		Size: 13,
+		AccessMethods: &[]drsapi.AccessMethod{{
+			Type: drsapi.AccessMethodTypeS3,
+			AccessUrl: &struct {
+				Headers *[]string `json:"headers,omitempty"`
+				Url     string    `json:"url"`
+			}{Url: "s3://data-bucket/org/proj/" + strings.Repeat("a", 64)},
+		}},
+	}
+	if err := uploadFileForObject(rt, context.Background(), obj, src, false); err != nil {
+		t.Fatalf("uploadFileForObject: %v", err)
+	}
+	key := "/data-bucket/org/proj/" + strings.Repeat("a", 64)
+	if puts[key] != "direct upload" {
+		t.Fatalf("expected PUT to %s, got %v", key, puts)
+	}
+	if !strings.Contains(auth, "Credential=AKIDEXAMPLE/") {
+		t.Fatalf("expected request signed with static keys, got %q", auth)
+	}
+}
+
+func TestDirectUploadBackendRequiresStaticKeysAndBucket(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	rt := &pushRuntime{
+		Scope:  pushScope{Bucket: "data-bucket"},
+		Upload: config.UploadSettings{CredentialSource: config.CredentialSourceStatic, Region: "us-east-1"},
+	}
+	if _, err := rt.uploadBackend(context.Background()); err == nil || !strings.Contains(err.Error(), "AWS_ACCESS_KEY_ID") {
+		t.Fatalf("expected missing key error, got %v", err)
+	}
+
+	rt = &pushRuntime{Upload: config.UploadSettings{CredentialSource: config.CredentialSourceAmbient}}
+	if _, err := rt.uploadBackend(context.Background()); err == nil || !strings.Contains(err.Error(), "no bucket") {
+		t.Fatalf("expected missing bucket error, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package pushsync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	localcommon "github.com/calypr/git-drs/internal/common"
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/encryption"
+	"github.com/calypr/git-drs/internal/projectdir"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/calypr/syfon/client/hash"
+)
+
+// sealedUploadDir holds ciphertext staged for upload to encrypting remotes.
+var sealedUploadDir = filepath.Join(localcommon.DRS_DIR, "tmp", "encrypted")
+
+// sealForUpload encrypts src for obj under rt's key into sealedUploadDir and
+// records the encryption block on obj. It returns the ciphertext path, which
+// the caller uploads and then removes.
+func sealForUpload(rt *pushRuntime, ctx context.Context, obj *drsapi.DrsObject, oid, src string) (string, encryption.Metadata, error) {
+	key, err := rt.Encryption.LoadKey(ctx)
+	if err != nil {
+		return "", encryption.Metadata{}, err
+	}
+	dir := projectdir.Path(sealedUploadDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", encryption.Metadata{}, fmt.Errorf("create encrypted upload dir: %w", err)
+	}
+	dst := filepath.Join(dir, oid)
+	meta, err := encryption.EncryptFile(key, oid, src, dst)
+	if err != nil {
+		return "", encryption.Metadata{}, err
+	}
+	encryption.SetOnObject(obj, meta)
+	return dst, meta, nil
+}
+
+// sealObject encrypts the local content for oid before its record is
+// registered, so the record carries the ciphertext hash from the start. An
+// existing record is only re-uploaded when its block matches the new
+// ciphertext; otherwise the stored bytes would stop matching the record.
+// Remotes that do not encrypt compress configured file types instead;
+// ciphertext does not compress, so the two are never combined.
+func (s *batchSyncSession) sealObject(oid string, obj *drsapi.DrsObject, existing *drsapi.DrsObject) error {
+	if !s.rt.Encryption.Enabled() {
+		return s.compressObject(oid, obj, existing)
+	}
+	file := s.filesByOID[oid]
+	src, ok, err := resolveUploadSourcePath(oid, file.Name, file.IsPointer)
+	if err != nil {
+		return fmt.Errorf("failed to resolve upload source for oid %s: %w", oid, err)
+	}
+	if !ok {
+		return fmt.Errorf("remote %q encrypts content client-side, but %s (oid %s) has no local content to encrypt; run git drs pull first", s.rt.Encryption.Remote, file.Name, oid)
+	}
+	sealed, meta, err := sealForUpload(s.rt, s.ctx, obj, oid, src)
+	if err != nil {
+		return err
+	}
+	s.sealed[oid] = sealed
+	if existing != nil {
+		if current, ok := encryption.FromObject(existing); !ok || current.CiphertextSHA256 != meta.CiphertextSHA256 {
+			return fmt.Errorf("record %s for oid %s was not registered with this encryption key; delete it before re-uploading encrypted content", existing.Id, oid)
+		}
+	}
+	return nil
+}
+
+func (s *batchSyncSession) removeSealed() {
+	for _, path := range s.sealed {
+		_ = os.Remove(path)
+	}
+}
+
+// uploadSealed uploads path to an object whose record carries an encryption
+// block, encrypting it under cl's key first. Because encryption is
+// deterministic, the result must reproduce the recorded ciphertext hash.
+func uploadSealed(rt *pushRuntime, ctx context.Context, obj *drsapi.DrsObject, path string, want encryption.Metadata) error {
+	oid := localdrsobject.NormalizeOid(hash.ConvertDrsChecksumsToHashInfo(obj.Checksums).SHA256)
+	probe := *obj
+	sealed, meta, err := sealForUpload(rt, ctx, &probe, oid, path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(sealed)
+	if meta.CiphertextSHA256 != want.CiphertextSHA256 {
+		return fmt.Errorf("record %s was encrypted with key %s, but remote %q is configured with key %s", obj.Id, want.KeyID, rt.Encryption.Remote, meta.KeyID)
+	}
+	return uploadFileForObject(rt, ctx, obj, sealed, false)
+}
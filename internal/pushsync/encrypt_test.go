@@ -0,0 +1,69 @@
+package pushsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/encryption"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestSealObjectEncryptsBeforeRegistration(t *testing.T) {
+	tmp := t.TempDir()
+	origDir := sealedUploadDir
+	t.Cleanup(func() { sealedUploadDir = origDir })
+	sealedUploadDir = filepath.Join(tmp, "sealed")
+
+	plain := []byte("patient record")
+	sum := sha256.Sum256(plain)
+	oid := hex.EncodeToString(sum[:])
+	src := filepath.Join(tmp, "record.txt")
+	keyFile := filepath.Join(tmp, "key")
+	if err := os.WriteFile(src, plain, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, []byte(strings.Repeat("ab", 32)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	session := &batchSyncSession{
+		ctx:        context.Background(),
+		rt:         &pushRuntime{Encryption: encryption.Settings{Remote: "origin", KeyFile: keyFile}},
+		filesByOID: map[string]lfs.LfsFileInfo{oid: {Oid: oid, Name: src, Size: int64(len(plain))}},
+		sealed:     map[string]string{},
+	}
+	obj := &drsapi.DrsObject{Id: "new-id", Size: int64(len(plain))}
+	if err := session.sealObject(oid, obj, nil); err != nil {
+		t.Fatalf("sealObject returned error: %v", err)
+	}
+	meta, ok := encryption.FromObject(obj)
+	if !ok {
+		t.Fatalf("expected encryption block on record, got aliases %v", obj.Aliases)
+	}
+	sealed, err := os.ReadFile(session.sealed[oid])
+	if err != nil {
+		t.Fatalf("read sealed upload: %v", err)
+	}
+	if bytes.Contains(sealed, plain) || int64(len(sealed)) != meta.CiphertextSize {
+		t.Fatalf("sealed upload is not the recorded ciphertext")
+	}
+
+	// A record registered under another key cannot be overwritten in place.
+	other := &drsapi.DrsObject{Id: "existing-id"}
+	encryption.SetOnObject(other, encryption.Metadata{Algorithm: encryption.Algorithm, KeyID: "other", CiphertextSHA256: "00"})
+	if err := session.sealObject(oid, &drsapi.DrsObject{}, other); err == nil {
+		t.Fatal("expected mismatched existing record to be rejected")
+	}
+
+	session.removeSealed()
+	if _, err := os.Stat(session.sealed[oid]); !os.IsNotExist(err) {
+		t.Fatalf("expected sealed upload removed, stat err %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+package pushsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	sycommon "github.com/calypr/syfon/client/common"
+	"github.com/calypr/syfon/client/transfer"
+)
+
+// journalPath locates the upload journal for a remote; swapped in tests.
+var journalPath = func(remote string) (string, error) {
+	drsDir, err := gitrepo.DrsTopLevel()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(drsDir, "cursors", "upload", remote+".json"), nil
+}
+
+// journalMaxAge is how long an unfinished multipart upload is resumed.
+// Stores commonly abort incomplete uploads after a week, so older entries
+// start over rather than fail on an upload ID the store has forgotten.
+const journalMaxAge = 6 * 24 * time.Hour
+
+// journalEntry is the upload progress of one object, by oid. While a
+// multipart upload runs it holds the upload ID and the ETag of each part
+// sent; once the object is stored it is marked Done until the push that
+// uploaded it finishes.
+type journalEntry struct {
+	DID      string         `json:"did"`
+	Key      string         `json:"key,omitempty"`
+	Size     int64          `json:"size"`
+	UploadID string         `json:"upload_id,omitempty"`
+	Parts    map[int]string `json:"parts,omitempty"`
+	Done     bool           `json:"done,omitempty"`
+	Updated  time.Time      `json:"updated"`
+}
+
+// uploadJournal records uploads a push has started or finished but not yet
+// seen through, so a push interrupted part way does not send them again:
+// the next one skips objects already stored and resumes multipart uploads
+// at the first part not sent. The transfer engine keeps its own resume
+// checkpoint, but that is keyed by the source file's path, which for
+// encrypted and compressed content is a new temporary file on every push.
+type uploadJournal struct {
+	path string
+
+	mu      sync.Mutex
+	objects map[string]*journalEntry
+}
+
+// loadUploadJournal reads the journal for remote, dropping stale entries.
+// Outside a repository, or when the journal cannot be read, it starts
+// empty; without a remote name nothing is persisted.
+func loadUploadJournal(remote string) *uploadJournal {
+	j := &uploadJournal{objects: map[string]*journalEntry{}}
+	if remote == "" {
+		return j
+	}
+	path, err := journalPath(remote)
+	if err != nil {
+		return j
+	}
+	j.path = path
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return j
+	}
+	var objects map[string]*journalEntry
+	if json.Unmarshal(data, &objects) != nil {
+		return j
+	}
+	cutoff := time.Now().Add(-journalMaxAge)
+	for oid, e := range objects {
+		if e != nil && e.Updated.After(cutoff) {
+			j.objects[oid] = e
+		}
+	}
+	return j
+}
+
+// finished reports whether oid was stored as did with size bytes by a push
+// that did not get to record it as uploaded.
+func (j *uploadJournal) finished(oid, did string, size int64) bool {
+	if j == nil {
+		return false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e := j.objects[oid]
+	return e != nil && e.Done && e.DID == did && e.Size == size
+}
+
+// finish marks oid stored.
+func (j *uploadJournal) finish(oid, did string, size int64) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.objects[oid] = &journalEntry{DID: did, Size: size, Done: true, Updated: time.Now()}
+	return j.saveLocked()
+}
+
+// forget drops oids once the push that uploaded them has recorded them.
+func (j *uploadJournal) forget(oids []string) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	changed := false
+	for _, oid := range oids {
+		if _, ok := j.objects[oid]; ok {
+			delete(j.objects, oid)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return j.saveLocked()
+}
+
+func (j *uploadJournal) saveLocked() error {
+	if j.path == "" {
+		return nil
+	}
+	if len(j.objects) == 0 {
+		if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove upload journal: %w", err)
+		}
+		return nil
+	}
+	data, err := json.Marshal(j.objects)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o755); err != nil {
+		return fmt.Errorf("write upload journal: %w", err)
+	}
+	if err := common.WriteFileAtomic(j.path, data, 0o644); err != nil {
+		return fmt.Errorf("write upload journal: %w", err)
+	}
+	return nil
+}
+
+// wrap returns backend with its multipart uploads of oid journaled.
+func (j *uploadJournal) wrap(backend transfer.MultipartBackend, oid, did string, size int64) transfer.MultipartBackend {
+	if j == nil || backend == nil {
+		return backend
+	}
+	return &journaledBackend{MultipartBackend: backend, journal: j, oid: oid, did: did, size: size}
+}
+
+// journaledBackend resumes a multipart upload recorded in the journal: it
+// hands the transfer engine the recorded upload ID instead of starting a new
+// upload, and answers parts already sent with their recorded ETags.
+type journaledBackend struct {
+	transfer.MultipartBackend
+	journal *uploadJournal
+	oid     string
+	did     string
+	size    int64
+	resumed bool
+}
+
+// ResolveUploadURL passes through to the wrapped backend, which may sign
+// single-PUT URLs.
+func (b *journaledBackend) ResolveUploadURL(ctx context.Context, guid string, filename string, metadata sycommon.FileMetadata, bucket string) (string, error) {
+	resolver, ok := b.MultipartBackend.(interface {
+		ResolveUploadURL(context.Context, string, string, sycommon.FileMetadata, string) (string, error)
+	})
+	if !ok {
+		return filename, nil
+	}
+	return resolver.ResolveUploadURL(ctx, guid, filename, metadata, bucket)
+}
+
+func (b *journaledBackend) MultipartInit(ctx context.Context, key string) (string, error) {
+	j := b.journal
+	j.mu.Lock()
+	e := j.objects[b.oid]
+	if e != nil && !e.Done && e.UploadID != "" && e.DID == b.did && e.Key == key && e.Size == b.size {
+		b.resumed = true
+		uploadID := e.UploadID
+		j.mu.Unlock()
+		return uploadID, nil
+	}
+	j.mu.Unlock()
+
+	uploadID, err := b.MultipartBackend.MultipartInit(ctx, key)
+	if err != nil {
+		return uploadID, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.objects[b.oid] = &journalEntry{DID: b.did, Key: key, Size: b.size, UploadID: uploadID, Parts: map[int]string{}, Updated: time.Now()}
+	return uploadID, j.saveLocked()
+}
+
+// MultipartPart skips a part the journal has an ETag for. The part is
+// still read, so progress is reported and a part verifier wrapping this
+// backend can check it against the ETag.
+func (b *journaledBackend) MultipartPart(ctx context.Context, key string, uploadID string, partNum int, body io.Reader) (string, error) {
+	j := b.journal
+	j.mu.Lock()
+	e := j.objects[b.oid]
+	etag, sent := "", false
+	if e != nil && e.UploadID == uploadID {
+		etag, sent = e.Parts[partNum]
+	}
+	j.mu.Unlock()
+	if sent {
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			return "", err
+		}
+		return etag, nil
+	}
+
+	etag, err := b.MultipartBackend.MultipartPart(ctx, key, uploadID, partNum, body)
+	if err != nil {
+		if b.resumed && uploadGone(err) {
+			_ = j.forget([]string{b.oid})
+		}
+		return etag, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if e := j.objects[b.oid]; e != nil && e.UploadID == uploadID {
+		e.Parts[partNum] = etag
+		e.Updated = time.Now()
+		return etag, j.saveLocked()
+	}
+	return etag, nil
+}
+
+// MultipartComplete drops a resumed upload the store rejects, so the next
+// push starts the object over instead of failing on it again.
+func (b *journaledBackend) MultipartComplete(ctx context.Context, key string, uploadID string, parts []transfer.MultipartPart) error {
+	err := b.MultipartBackend.MultipartComplete(ctx, key, uploadID, parts)
+	if err != nil && b.resumed {
+		_ = b.journal.forget([]string{b.oid})
+	}
+	return err
+}
+
+// uploadGone reports whether err says the store no longer knows the
+// multipart upload, as when a lifecycle rule aborted it.
+func uploadGone(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "NoSuchUpload") || strings.Contains(msg, "status 404")
+}
@@ -0,0 +1,140 @@
+package pushsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/calypr/syfon/client/transfer"
+)
+
+func useJournalDir(t testing.TB) string {
+	t.Helper()
+	dir := t.TempDir()
+	orig := journalPath
+	t.Cleanup(func() { journalPath = orig })
+	journalPath = func(remote string) (string, error) { return filepath.Join(dir, remote+".json"), nil }
+	return dir
+}
+
+// countingMultipartBackend numbers uploads, records the parts it is sent,
+// and fails part failAt once.
+type countingMultipartBackend struct {
+	chunkedUploadBackend
+	inits    int
+	sent     []int
+	failAt   int
+	complete []transfer.MultipartPart
+}
+
+func (b *countingMultipartBackend) MultipartInit(context.Context, string) (string, error) {
+	b.inits++
+	return fmt.Sprintf("upload-%d", b.inits), nil
+}
+
+func (b *countingMultipartBackend) MultipartPart(_ context.Context, _ string, _ string, partNum int, body io.Reader) (string, error) {
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return "", err
+	}
+	if partNum == b.failAt {
+		b.failAt = 0
+		return "", errors.New("connection reset")
+	}
+	b.sent = append(b.sent, partNum)
+	return fmt.Sprintf(`"etag-%d"`, partNum), nil
+}
+
+func (b *countingMultipartBackend) MultipartComplete(_ context.Context, _ string, _ string, parts []transfer.MultipartPart) error {
+	b.complete = parts
+	return nil
+}
+
+func TestUploadJournalResumesMultipartUpload(t *testing.T) {
+	dir := useJournalDir(t)
+	ctx := context.Background()
+	oid := strings.Repeat("a", 64)
+	inner := &countingMultipartBackend{failAt: 2}
+
+	// The first push sends part 1 and is interrupted on part 2.
+	first := loadUploadJournal("origin").wrap(inner, oid, "did-1", 100)
+	uploadID, err := first.MultipartInit(ctx, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.MultipartPart(ctx, "key", uploadID, 1, strings.NewReader("one")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.MultipartPart(ctx, "key", uploadID, 2, strings.NewReader("two")); err == nil {
+		t.Fatal("expected part 2 to fail")
+	}
+
+	// The next push reuses the upload and only sends part 2.
+	journal := loadUploadJournal("origin")
+	second := journal.wrap(inner, oid, "did-1", 100)
+	resumedID, err := second.MultipartInit(ctx, "key")
+	if err != nil || resumedID != uploadID || inner.inits != 1 {
+		t.Fatalf("resumed upload ID = %q, %v after %d inits; want %q", resumedID, err, inner.inits, uploadID)
+	}
+	var parts []transfer.MultipartPart
+	for n, body := range []string{"one", "two"} {
+		etag, err := second.MultipartPart(ctx, "key", resumedID, n+1, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, transfer.MultipartPart{PartNumber: int32(n + 1), ETag: etag})
+	}
+	if fmt.Sprint(inner.sent) != "[1 2]" || parts[0].ETag != `"etag-1"` {
+		t.Fatalf("parts sent = %v, etags %v; want part 1 sent once and its ETag reused", inner.sent, parts)
+	}
+	if err := second.MultipartComplete(ctx, "key", resumedID, parts); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.finish(oid, "did-1", 100); err != nil {
+		t.Fatal(err)
+	}
+
+	// A different record or size for the oid starts over.
+	if other := loadUploadJournal("origin"); !other.finished(oid, "did-1", 100) || other.finished(oid, "did-2", 100) || other.finished(oid, "did-1", 99) {
+		t.Fatal("finished upload not matched by oid, record and size")
+	}
+	if err := journal.forget([]string{oid}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "origin.json")); !os.IsNotExist(err) {
+		t.Fatalf("journal not removed once empty: %v", err)
+	}
+}
+
+func TestUploadFileForObjectSkipsObjectFinishedByInterruptedPush(t *testing.T) {
+	useJournalDir(t)
+	oid := strings.Repeat("b", 64)
+	file := filepath.Join(t.TempDir(), "sample.bin")
+	if err := os.WriteFile(file, []byte("hello world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	journal := loadUploadJournal("origin")
+	if err := journal.finish(oid, "did-1", 11); err != nil {
+		t.Fatal(err)
+	}
+
+	// The runtime has no upload backend, so any upload attempt fails.
+	rt := newPushRuntime(&config.GitContext{Logger: drslog.NewNoOpLogger()})
+	rt.Journal = loadUploadJournal("origin")
+	obj := &drsapi.DrsObject{Id: "did-1", Size: 11, Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: oid}}}
+	if err := uploadFileForObject(rt, context.Background(), obj, file, false); err != nil {
+		t.Fatalf("uploadFileForObject: %v", err)
+	}
+
+	rt.Tuning.ForceUpload = true
+	if err := uploadFileForObject(rt, context.Background(), obj, file, false); err == nil {
+		t.Fatal("forced upload skipped the journaled object")
+	}
+}
@@ -0,0 +1,117 @@
+package pushsync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/calypr/git-drs/internal/config"
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/drsremote"
+	"github.com/calypr/git-drs/internal/guardrail"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/mimetype"
+	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
+)
+
+// Swapped in tests.
+var (
+	lookupScopedObjects = drsremote.ObjectsByHashesForScope
+	projectUsage        = storedProjectBytes
+)
+
+// CheckLimits applies policy to the objects in files that the remote does not
+// yet have in its project, since only those will be uploaded; objects already
+// stored were accepted by an earlier push. It returns a quota warning, if
+// any, and a *guardrail.Error when a limit is exceeded.
+func CheckLimits(cl *config.GitContext, ctx context.Context, files map[string]lfs.LfsFileInfo, policy guardrail.Policy) (string, error) {
+	if len(files) == 0 || (!policy.Enabled() && policy.ProjectQuota <= 0) {
+		return "", nil
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	byOID := make(map[string]guardrail.File, len(paths))
+	oids := make([]string, 0, len(paths))
+	for _, path := range paths {
+		info := files[path]
+		oid := localdrsobject.NormalizeOid(info.Oid)
+		if oid == "" {
+			continue
+		}
+		if _, ok := byOID[oid]; ok {
+			continue
+		}
+		name := info.Name
+		if name == "" {
+			name = path
+		}
+		file := guardrail.File{Path: name, Size: info.Size}
+		if len(policy.AllowedMimeTypes) > 0 {
+			file.MimeType = mimetype.ForOID(name, oid)
+		}
+		byOID[oid] = file
+		oids = append(oids, oid)
+	}
+
+	existing, err := lookupScopedObjects(ctx, cl, oids)
+	if err != nil {
+		return "", fmt.Errorf("limit check: hash lookup failed: %w", err)
+	}
+	var pending []guardrail.File
+	var incoming int64
+	for _, oid := range oids {
+		if len(existing[oid]) > 0 {
+			continue
+		}
+		pending = append(pending, byOID[oid])
+		incoming += byOID[oid].Size
+	}
+
+	warning := ""
+	if policy.ProjectQuota > 0 && incoming > 0 {
+		used, err := projectUsage(ctx, cl)
+		if err != nil {
+			if cl.Logger != nil {
+				cl.Logger.DebugContext(ctx, fmt.Sprintf("skipping project quota check: %v", err))
+			}
+		} else {
+			warning = policy.QuotaWarning(cl.Organization+"/"+cl.ProjectId, used, incoming)
+		}
+	}
+	return warning, policy.CheckPush(pending)
+}
+
+// storedProjectBytes sums the sizes of the distinct objects registered in the
+// remote's project.
+func storedProjectBytes(ctx context.Context, cl *config.GitContext) (int64, error) {
+	if cl == nil || cl.Client == nil {
+		return 0, fmt.Errorf("remote client unavailable")
+	}
+	seen := make(map[string]bool)
+	var total int64
+	err := drsremote.ListRecordsParallel(ctx, cl.Client.Index(), drsremote.ListOptions{
+		Organization: cl.Organization,
+		ProjectID:    cl.ProjectId,
+	}, func(_ int, records []internalapi.InternalRecord) error {
+		for _, rec := range records {
+			if rec.Size == nil {
+				continue
+			}
+			key := rec.Did
+			if rec.Hashes != nil && (*rec.Hashes)["sha256"] != "" {
+				key = (*rec.Hashes)["sha256"]
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			total += *rec.Size
+		}
+		return nil
+	})
+	return total, err
+}
@@ -0,0 +1,45 @@
+package pushsync
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/guardrail"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestCheckLimits_OnlyCountsObjectsNotYetStored(t *testing.T) {
+	oldOID := strings.Repeat("a", 64)
+	newOID := strings.Repeat("b", 64)
+	origLookup, origUsage := lookupScopedObjects, projectUsage
+	t.Cleanup(func() { lookupScopedObjects, projectUsage = origLookup, origUsage })
+	lookupScopedObjects = func(ctx context.Context, cl *config.GitContext, oids []string) (map[string][]drsapi.DrsObject, error) {
+		return map[string][]drsapi.DrsObject{oldOID: {{Id: "stored"}}}, nil
+	}
+	projectUsage = func(context.Context, *config.GitContext) (int64, error) { return 900, nil }
+
+	cl := &config.GitContext{Organization: "org", ProjectId: "proj"}
+	files := map[string]lfs.LfsFileInfo{
+		"big/old.bam":  {Name: "big/old.bam", Oid: oldOID, Size: 5000},
+		"data/new.bam": {Name: "data/new.bam", Oid: newOID, Size: 200},
+		"copy/new.bam": {Name: "copy/new.bam", Oid: newOID, Size: 200},
+	}
+
+	warning, err := CheckLimits(cl, context.Background(), files, guardrail.Policy{MaxFileSize: 1000, MaxPushSize: 250, ProjectQuota: 1000})
+	if err != nil {
+		t.Fatalf("CheckLimits: %v (stored or duplicate objects were counted)", err)
+	}
+	if !strings.Contains(warning, "org/proj would use 1.1 KiB") {
+		t.Fatalf("warning = %q, want quota warning for 900 + 200 bytes", warning)
+	}
+
+	_, err = CheckLimits(cl, context.Background(), files, guardrail.Policy{MaxPushSize: 100})
+	var gerr *guardrail.Error
+	if !errors.As(err, &gerr) {
+		t.Fatalf("CheckLimits error = %v, want push total violation", err)
+	}
+}
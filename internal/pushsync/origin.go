@@ -0,0 +1,31 @@
+package pushsync
+
+import (
+	"github.com/calypr/git-drs/internal/origin"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// currentOrigin is swapped in tests.
+var currentOrigin = origin.Current
+
+// stampOrigin records the repository coordinates drs.origin.fields selects
+// on each record about to be registered, the records for oids and the extra
+// path records, so the record can be traced back with git drs whereis.
+func (s *batchSyncSession) stampOrigin(oids []string, extra []*drsapi.DrsObject) {
+	if !s.rt.Origin.Enabled() {
+		return
+	}
+	base := currentOrigin(s.ctx)
+	pathOf := s.pathsByID()
+	for _, oid := range oids {
+		obj := s.drsObjByOID[oid]
+		path := s.filesByOID[oid].Name
+		if p, ok := pathOf[obj.Id]; ok && s.pathIDs() {
+			path = p
+		}
+		origin.SetOnObject(obj, s.rt.Origin.Select(base.At(path)))
+	}
+	for _, obj := range extra {
+		origin.SetOnObject(obj, s.rt.Origin.Select(base.At(pathOf[obj.Id])))
+	}
+}
@@ -0,0 +1,43 @@
+package pushsync
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/origin"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestStampOriginRecordsSelectedFields(t *testing.T) {
+	orig := currentOrigin
+	t.Cleanup(func() { currentOrigin = orig })
+	currentOrigin = func(context.Context) origin.Origin {
+		return origin.Origin{Repo: "https://github.com/org/repo.git", Branch: "main", Commit: "abc123"}
+	}
+
+	oid := strings.Repeat("e", 64)
+	fields := origin.Fields{Commit: true, Branch: true, Path: true}
+	session := newBatchSyncSession(&config.GitContext{RemoteName: "origin", ProjectId: "e2e", Origin: fields, Logger: drslog.NewNoOpLogger()}, context.Background(), nil, nil, nil)
+	session.normalizeFiles(map[string]lfs.LfsFileInfo{"data/x.bin": {Name: "data/x.bin", Oid: oid, Size: 3}})
+	obj := &drsapi.DrsObject{Id: "did-1"}
+	session.drsObjByOID[oid] = obj
+
+	session.stampOrigin([]string{oid}, nil)
+	got, ok := origin.FromObject(obj)
+	want := origin.Origin{Branch: "main", Commit: "abc123", Path: "data/x.bin"}
+	if !ok || got != want {
+		t.Fatalf("origin = %+v, %v; want %+v", got, ok, want)
+	}
+
+	// Unless drs.origin.fields selects something, records are registered as before.
+	session.rt.Origin = origin.Fields{}
+	plain := &drsapi.DrsObject{Id: "did-1"}
+	session.drsObjByOID[oid] = plain
+	if session.stampOrigin([]string{oid}, nil); plain.Aliases != nil {
+		t.Fatalf("aliases without origin fields = %v", *plain.Aliases)
+	}
+}
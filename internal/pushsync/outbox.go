@@ -0,0 +1,273 @@
+package pushsync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/config"
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/gitrepo"
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+// Who queued an intent.
+const (
+	QueuedByCommit = "commit"
+	QueuedByPush   = "push"
+)
+
+// Intent is a registration a commit or push could not make because the
+// remote was unreachable, kept in the remote's outbox until a later commit,
+// push or flush makes it.
+type Intent struct {
+	// Key identifies the intent by remote, path and oid. Queueing the same
+	// file again leaves one intent, and a replay that finds the key's
+	// record already registered (say the server applied the request but the
+	// answer was lost) does not register it twice.
+	Key      string    `json:"key"`
+	Path     string    `json:"path"`
+	OID      string    `json:"oid"`
+	Size     int64     `json:"size"`
+	QueuedBy string    `json:"queued_by"`
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// IdempotencyKey is the key of the intent to register oid at path with
+// remote.
+func IdempotencyKey(remote, path, oid string) string {
+	sum := sha256.Sum256([]byte(remote + "\x00" + filepath.ToSlash(path) + "\x00" + localdrsobject.NormalizeOid(oid)))
+	return hex.EncodeToString(sum[:])
+}
+
+// outboxPath locates the outbox for a remote; swapped in tests.
+var outboxPath = func(remote string) (string, error) {
+	drsDir, err := gitrepo.DrsTopLevel()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(drsDir, "outbox", remote+".json"), nil
+}
+
+// now is swapped in tests.
+var now = time.Now
+
+// Outbox returns the intents queued for remote, oldest first.
+func Outbox(remote string) []Intent {
+	return loadOutbox(remote).sorted()
+}
+
+// QueueForPush adds files a push could not register to the remote's outbox
+// and returns the number of intents queued.
+func QueueForPush(cl *config.GitContext, files map[string]lfs.LfsFileInfo) (int, error) {
+	queue, err := updateOutbox(cl.RemoteName, func(o *outbox) { o.add(files, QueuedByPush) })
+	if err != nil {
+		return 0, err
+	}
+	return len(queue.intents), nil
+}
+
+// FlushOutbox replays the remote's outbox: it registers and uploads every
+// queued file, as a push would, and removes them from the outbox. Intents
+// queued while the flush runs, say by a commit hook, stay queued. The
+// intents flushed are returned; on error the outbox is left as it was.
+func FlushOutbox(cl *config.GitContext, ctx context.Context, reporter UploadProgressReporter) ([]Intent, error) {
+	intents := Outbox(cl.RemoteName)
+	if len(intents) == 0 {
+		return intents, nil
+	}
+	if err := BatchSyncForPush(cl, ctx, nil, reporter); err != nil {
+		return nil, err
+	}
+	return intents, nil
+}
+
+// outbox is the intents, keyed by idempotency key, queued for a remote.
+type outbox struct {
+	remote  string
+	path    string
+	intents map[string]Intent
+}
+
+// loadOutbox reads the outbox for remote. Like the pending-upload list, it
+// starts empty when it cannot be read, and is not persisted without a remote
+// name.
+func loadOutbox(remote string) *outbox {
+	o := &outbox{remote: remote, intents: map[string]Intent{}}
+	if remote == "" {
+		return o
+	}
+	path, err := outboxPath(remote)
+	if err != nil {
+		return o
+	}
+	o.path = path
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return o
+	}
+	var intents []Intent
+	if json.Unmarshal(data, &intents) == nil {
+		for _, in := range intents {
+			o.intents[in.Key] = in
+		}
+	}
+	return o
+}
+
+// Swapped in tests.
+var (
+	outboxLockRetry   = 10 * time.Millisecond
+	outboxLockTimeout = 30 * time.Second
+	staleOutboxLock   = 2 * time.Minute
+)
+
+// updateOutbox reads the remote's outbox under its lock, applies change and
+// saves it. Commit hooks, pushes and flushes in other processes each change
+// the outbox this way, so none of them overwrites intents another queued or
+// removed after it first read the outbox.
+func updateOutbox(remote string, change func(*outbox)) (*outbox, error) {
+	o := loadOutbox(remote)
+	if o.path == "" {
+		change(o)
+		return o, nil
+	}
+	unlock, err := lockOutbox(o.path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	o = loadOutbox(remote)
+	change(o)
+	return o, o.save()
+}
+
+// lockOutbox takes the advisory lock of the outbox at path, an O_EXCL file
+// like the DRS object store's. Holders only read and rewrite the outbox, so
+// a lock older than staleOutboxLock belongs to a process that died and is
+// removed.
+func lockOutbox(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(outboxLockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("lock outbox: %w", err)
+		}
+		if info, serr := os.Stat(lockPath); serr == nil && time.Since(info.ModTime()) > staleOutboxLock {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("lock outbox: %s is held by another process; remove it if no git-drs command is running", lockPath)
+		}
+		time.Sleep(outboxLockRetry)
+	}
+}
+
+// add queues files, keeping the original queue time of any already queued.
+func (o *outbox) add(files map[string]lfs.LfsFileInfo, by string) {
+	for _, f := range files {
+		key := IdempotencyKey(o.remote, f.Name, f.Oid)
+		if _, ok := o.intents[key]; ok {
+			continue
+		}
+		o.intents[key] = Intent{
+			Key:      key,
+			Path:     filepath.ToSlash(f.Name),
+			OID:      localdrsobject.NormalizeOid(f.Oid),
+			Size:     f.Size,
+			QueuedBy: by,
+			QueuedAt: now().UTC(),
+		}
+	}
+}
+
+// files returns the queued files keyed by idempotency key, so two versions
+// of one path queued by successive commits are both registered.
+func (o *outbox) files() map[string]lfs.LfsFileInfo {
+	out := make(map[string]lfs.LfsFileInfo, len(o.intents))
+	for key, in := range o.intents {
+		out[key] = lfs.LfsFileInfo{Name: in.Path, Size: in.Size, IsPointer: true, OidType: "sha256", Oid: in.OID}
+	}
+	return out
+}
+
+// merge returns files plus the queued files they do not already cover.
+func (o *outbox) merge(files map[string]lfs.LfsFileInfo) map[string]lfs.LfsFileInfo {
+	if len(o.intents) == 0 {
+		return files
+	}
+	covered := make(map[string]bool, len(files))
+	for _, f := range files {
+		covered[IdempotencyKey(o.remote, f.Name, f.Oid)] = true
+	}
+	out := make(map[string]lfs.LfsFileInfo, len(files)+len(o.intents))
+	for k, f := range files {
+		out[k] = f
+	}
+	for key, f := range o.files() {
+		if !covered[key] {
+			out[key] = f
+		}
+	}
+	return out
+}
+
+// remove drops the intents for files that were registered, matching on path
+// and oid so a path committed again with new content stays queued.
+func (o *outbox) remove(registered map[string]lfs.LfsFileInfo) {
+	for _, f := range registered {
+		delete(o.intents, IdempotencyKey(o.remote, f.Name, f.Oid))
+	}
+}
+
+func (o *outbox) sorted() []Intent {
+	out := make([]Intent, 0, len(o.intents))
+	for _, in := range o.intents {
+		out = append(out, in)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].QueuedAt.Equal(out[j].QueuedAt) {
+			return out[i].QueuedAt.Before(out[j].QueuedAt)
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+func (o *outbox) save() error {
+	if o.path == "" {
+		return nil
+	}
+	if len(o.intents) == 0 {
+		if err := os.Remove(o.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove outbox: %w", err)
+		}
+		return nil
+	}
+	data, err := json.Marshal(o.sorted())
+	if err != nil {
+		return err
+	}
+	if err := common.WriteFileAtomic(o.path, data, 0o644); err != nil {
+		return fmt.Errorf("write outbox: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package pushsync
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+func TestOutboxKeepsEachVersionUntilPushed(t *testing.T) {
+	dir := t.TempDir()
+	origPath, origNow := outboxPath, now
+	t.Cleanup(func() { outboxPath, now = origPath, origNow })
+	outboxPath = func(remote string) (string, error) { return filepath.Join(dir, remote+".json"), nil }
+	clock := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	now = func() time.Time { clock = clock.Add(time.Second); return clock }
+
+	o := loadOutbox("origin")
+	o.add(map[string]lfs.LfsFileInfo{"a.bin": {Name: "a.bin", Oid: "aa"}, "b.bin": {Name: "b.bin", Oid: "bb"}}, QueuedByCommit)
+	// b.bin is committed again with new content, and a.bin queued again.
+	o.add(map[string]lfs.LfsFileInfo{"a.bin": {Name: "a.bin", Oid: "sha256:aa"}, "b.bin": {Name: "b.bin", Oid: "cc"}}, QueuedByPush)
+	if err := o.save(); err != nil {
+		t.Fatal(err)
+	}
+	intents := Outbox("origin")
+	if len(intents) != 3 || intents[2].OID != "cc" || intents[2].QueuedBy != QueuedByPush {
+		t.Fatalf("outbox = %+v; want a.bin once and both versions of b.bin", intents)
+	}
+	if k := IdempotencyKey("origin", "a.bin", "sha256:aa"); k != IdempotencyKey("origin", "a.bin", "aa") || k == IdempotencyKey("other", "a.bin", "aa") {
+		t.Fatal("idempotency key depends on the oid prefix or ignores the remote")
+	}
+
+	// A push of HEAD replays the old version of b.bin along with its files.
+	o = loadOutbox("origin")
+	pushed := o.merge(map[string]lfs.LfsFileInfo{"a.bin": {Name: "a.bin", Oid: "aa"}, "b.bin": {Name: "b.bin", Oid: "cc"}})
+	if len(pushed) != 3 {
+		t.Fatalf("merged %+v; want HEAD plus the queued b.bin bb", pushed)
+	}
+	o.remove(map[string]lfs.LfsFileInfo{"a.bin": {Name: "a.bin", Oid: "aa"}})
+	if err := o.save(); err != nil {
+		t.Fatal(err)
+	}
+	if intents := Outbox("origin"); len(intents) != 2 || intents[0].Path != "b.bin" {
+		t.Fatalf("outbox after push = %+v; want both versions of b.bin", intents)
+	}
+}
+
+func TestOutboxQueueAndFlushDoNotLoseIntents(t *testing.T) {
+	dir := t.TempDir()
+	origPath := outboxPath
+	t.Cleanup(func() { outboxPath = origPath })
+	outboxPath = func(remote string) (string, error) { return filepath.Join(dir, remote+".json"), nil }
+	cl := &config.GitContext{RemoteName: "origin"}
+
+	file := func(i int) map[string]lfs.LfsFileInfo {
+		name := fmt.Sprintf("f%02d.bin", i)
+		return map[string]lfs.LfsFileInfo{name: {Name: name, Oid: fmt.Sprintf("%064x", i)}}
+	}
+	// f00..f09 are queued before a flush that registers them; f10..f29 are
+	// queued by pushes and commit hooks while it runs.
+	flushed := map[string]lfs.LfsFileInfo{}
+	for i := range 10 {
+		if _, err := QueueForPush(cl, file(i)); err != nil {
+			t.Fatal(err)
+		}
+		for k, f := range file(i) {
+			flushed[k] = f
+		}
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 30)
+	for i := 10; i < 30; i++ {
+		wg.Go(func() {
+			_, err := QueueForPush(cl, file(i))
+			errs <- err
+		})
+	}
+	for k, f := range flushed {
+		wg.Go(func() {
+			_, err := updateOutbox("origin", func(o *outbox) { o.remove(map[string]lfs.LfsFileInfo{k: f}) })
+			errs <- err
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	intents := Outbox("origin")
+	if len(intents) != 20 {
+		t.Fatalf("outbox has %d intents; want f10..f29", len(intents))
+	}
+	for _, in := range intents {
+		if in.Path < "f10" {
+			t.Fatalf("flushed intent %s still queued", in.Path)
+		}
+	}
+}
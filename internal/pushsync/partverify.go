@@ -0,0 +1,215 @@
+package pushsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/calypr/git-drs/internal/httpclient"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	sycommon "github.com/calypr/syfon/client/common"
+	"github.com/calypr/syfon/client/transfer"
+	"github.com/calypr/syfon/client/transfer/engine"
+)
+
+// multipartETag matches the ETag S3 and S3-compatible stores give an object
+// assembled from parts: the MD5 of the parts' MD5s, a dash, and the count.
+var multipartETag = regexp.MustCompile(`^[0-9a-f]{32}-[0-9]+$`)
+
+// storedETag reads the ETag storage serves for a signed download URL;
+// swapped in tests.
+var storedETag = func(ctx context.Context, rt *pushRuntime, rawURL string) (string, error) {
+	httpClient := httpclient.New(0)
+	if rt.API != nil && rt.API.Client != nil && rt.API.Client.HTTPClient() != nil {
+		httpClient = rt.API.Client.HTTPClient()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("read stored object: status %d", resp.StatusCode)
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// partVerifier checks a multipart upload as it goes. Each part is hashed
+// while it is sent and compared with the ETag storage returns for it, so a
+// part corrupted on the way fails and is sent again. Parts completed by an
+// earlier, resumed run are hashed from the local file before the upload is
+// completed, and the ETag of the completed object is then checked against
+// the one its parts compose to.
+type partVerifier struct {
+	transfer.MultipartBackend
+	path string
+	did  string
+	size int64
+
+	mu   sync.Mutex
+	sums map[int][]byte
+	// composite is the ETag the completed object should have, set once the
+	// upload completes and every part's ETag was an MD5.
+	composite string
+}
+
+func newPartVerifier(backend transfer.MultipartBackend, path, did string, size int64) *partVerifier {
+	return &partVerifier{MultipartBackend: backend, path: path, did: did, size: size, sums: map[int][]byte{}}
+}
+
+// ResolveUploadURL passes through to the wrapped backend, which may sign
+// single-PUT URLs.
+func (v *partVerifier) ResolveUploadURL(ctx context.Context, guid string, filename string, metadata sycommon.FileMetadata, bucket string) (string, error) {
+	resolver, ok := v.MultipartBackend.(interface {
+		ResolveUploadURL(context.Context, string, string, sycommon.FileMetadata, string) (string, error)
+	})
+	if !ok {
+		return filename, nil
+	}
+	return resolver.ResolveUploadURL(ctx, guid, filename, metadata, bucket)
+}
+
+// MultipartPart sends the part and checks storage received what was read.
+// A mismatch is returned as an ordinary error, so the part is retried.
+func (v *partVerifier) MultipartPart(ctx context.Context, guid string, uploadID string, partNum int, body io.Reader) (string, error) {
+	h := md5.New()
+	etag, err := v.MultipartBackend.MultipartPart(ctx, guid, uploadID, partNum, &hashingReader{r: body, h: h})
+	if err != nil {
+		return etag, err
+	}
+	sum := h.Sum(nil)
+	if got, ok := md5ETag(etag); ok && !bytes.Equal(got, sum) {
+		return "", fmt.Errorf("part %d of %s: storage received content with MD5 %x, but %x was sent", partNum, v.did, got, sum)
+	}
+	v.mu.Lock()
+	v.sums[partNum] = sum
+	v.mu.Unlock()
+	return etag, nil
+}
+
+// MultipartComplete checks parts resumed from an earlier run against the
+// local file before completing the upload. When one does not match, the
+// resume checkpoint is dropped so the next attempt starts over.
+func (v *partVerifier) MultipartComplete(ctx context.Context, guid string, uploadID string, parts []transfer.MultipartPart) error {
+	sums := make([][]byte, 0, len(parts))
+	for _, p := range parts {
+		got, ok := md5ETag(p.ETag)
+		if !ok {
+			sums = nil
+			break
+		}
+		v.mu.Lock()
+		sum, sent := v.sums[int(p.PartNumber)]
+		v.mu.Unlock()
+		if !sent {
+			var err error
+			if sum, err = v.partFromFile(int(p.PartNumber)); err != nil {
+				return err
+			}
+			if !bytes.Equal(got, sum) {
+				if cp, err := engine.CheckpointPath(v.path, v.did); err == nil {
+					_ = os.Remove(cp)
+				}
+				return fmt.Errorf("part %d of %s, uploaded by an earlier run, does not match the local file (MD5 %x, want %x); the upload will restart", p.PartNumber, v.did, got, sum)
+			}
+		}
+		sums = append(sums, sum)
+	}
+	if err := v.MultipartBackend.MultipartComplete(ctx, guid, uploadID, parts); err != nil {
+		return err
+	}
+	if sums != nil {
+		v.composite = compositeETag(sums)
+	}
+	return nil
+}
+
+// partFromFile hashes part partNum of the local file, split the way the
+// transfer engine splits it.
+func (v *partVerifier) partFromFile(partNum int) ([]byte, error) {
+	chunk := engine.OptimalChunkSize(v.size)
+	f, err := os.Open(v.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, io.NewSectionReader(f, int64(partNum-1)*chunk, chunk)); err != nil {
+		return nil, fmt.Errorf("hash part %d of %s: %w", partNum, v.path, err)
+	}
+	return h.Sum(nil), nil
+}
+
+// verifyStored compares the ETag of the completed object with the one its
+// parts compose to. Stores that do not report a multipart ETag are not
+// checked.
+func (v *partVerifier) verifyStored(ctx context.Context, rt *pushRuntime, obj *drsapi.DrsObject) error {
+	if v.composite == "" || rt.API == nil || rt.API.Client == nil || obj.AccessMethods == nil || len(*obj.AccessMethods) == 0 {
+		return nil
+	}
+	accessType := (*obj.AccessMethods)[0].Type
+	res, err := rt.API.Client.DRS().GetAccessURL(ctx, obj.Id, string(accessType))
+	if err != nil {
+		return fmt.Errorf("sign %s access URL to verify upload of %s: %w", accessType, obj.Id, err)
+	}
+	etag, err := storedETag(ctx, rt, res.Url)
+	if err != nil {
+		return fmt.Errorf("verify upload of %s: %w", obj.Id, err)
+	}
+	etag = strings.ToLower(strings.Trim(strings.TrimSpace(etag), `"`))
+	if etag == v.composite {
+		return nil
+	}
+	if !multipartETag.MatchString(etag) {
+		rt.Logger.DebugContext(ctx, "storage does not report a multipart ETag; skipping composed ETag check", "did", obj.Id, "etag", etag)
+		return nil
+	}
+	return fmt.Errorf("uploaded object %s has ETag %s, but its parts compose to %s; the stored copy is corrupt and will be uploaded again by the next push", obj.Id, etag, v.composite)
+}
+
+// md5ETag decodes an ETag that is a plain MD5 hex digest. Other ETags, such
+// as Azure block IDs, report false.
+func md5ETag(etag string) ([]byte, bool) {
+	etag = strings.ToLower(strings.Trim(strings.TrimSpace(etag), `"`))
+	if len(etag) != 2*md5.Size {
+		return nil, false
+	}
+	sum, err := hex.DecodeString(etag)
+	return sum, err == nil
+}
+
+// compositeETag is the ETag of an object assembled from parts with the
+// given MD5s, in part order.
+func compositeETag(sums [][]byte) string {
+	h := md5.New()
+	for _, sum := range sums {
+		h.Write(sum)
+	}
+	return hex.EncodeToString(h.Sum(nil)) + "-" + strconv.Itoa(len(sums))
+}
+
+type hashingReader struct {
+	r io.Reader
+	h hash.Hash
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.h.Write(p[:n])
+	return n, err
+}
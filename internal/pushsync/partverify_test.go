@@ -0,0 +1,102 @@
+package pushsync
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/syfon/client/transfer"
+)
+
+// etagUploadBackend answers each part with the MD5 of what it read, after
+// corrupting the first corrupt parts it receives.
+type etagUploadBackend struct {
+	chunkedUploadBackend
+	corrupt   int
+	completed bool
+}
+
+func (b *etagUploadBackend) MultipartPart(_ context.Context, _ string, _ string, _ int, body io.Reader) (string, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	if b.corrupt > 0 {
+		b.corrupt--
+		data = append([]byte{'x'}, data[1:]...)
+	}
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`, nil
+}
+
+func (b *etagUploadBackend) MultipartComplete(context.Context, string, string, []transfer.MultipartPart) error {
+	b.completed = true
+	return nil
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestPartVerifierRejectsCorruptedPart(t *testing.T) {
+	backend := &etagUploadBackend{corrupt: 1}
+	v := newPartVerifier(backend, "unused", "did-1", 5)
+
+	if _, err := v.MultipartPart(context.Background(), "key", "upload", 1, strings.NewReader("hello")); err == nil || !strings.Contains(err.Error(), "part 1 of did-1") {
+		t.Fatalf("corrupted part error = %v", err)
+	}
+	etag, err := v.MultipartPart(context.Background(), "key", "upload", 1, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("retried part: %v", err)
+	}
+	if err := v.MultipartComplete(context.Background(), "key", "upload", []transfer.MultipartPart{{PartNumber: 1, ETag: etag}}); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	sum := md5.Sum([]byte("hello"))
+	want := md5Hex(string(sum[:])) + "-1"
+	if v.composite != want {
+		t.Fatalf("composite = %q, want %q", v.composite, want)
+	}
+}
+
+func TestPartVerifierChecksResumedPartsAgainstFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payload.bin")
+	if err := os.WriteFile(path, []byte("resumed payload"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := &etagUploadBackend{}
+	v := newPartVerifier(backend, path, "did-2", int64(len("resumed payload")))
+	good := []transfer.MultipartPart{{PartNumber: 1, ETag: md5Hex("resumed payload")}}
+	if err := v.MultipartComplete(context.Background(), "key", "upload", good); err != nil || !backend.completed {
+		t.Fatalf("complete with a matching resumed part: %v (completed %v)", err, backend.completed)
+	}
+
+	backend = &etagUploadBackend{}
+	v = newPartVerifier(backend, path, "did-2", int64(len("resumed payload")))
+	bad := []transfer.MultipartPart{{PartNumber: 1, ETag: md5Hex("something else")}}
+	err := v.MultipartComplete(context.Background(), "key", "upload", bad)
+	if err == nil || !strings.Contains(err.Error(), "earlier run") || backend.completed {
+		t.Fatalf("complete with a corrupt resumed part = %v (completed %v)", err, backend.completed)
+	}
+}
+
+func TestPartVerifierSkipsNonMD5ETags(t *testing.T) {
+	v := newPartVerifier(&chunkedUploadBackend{}, "unused", "did-3", 4)
+	etag, err := v.MultipartPart(context.Background(), "key", "upload", 1, strings.NewReader("data"))
+	if err != nil {
+		t.Fatalf("part: %v", err)
+	}
+	if err := v.MultipartComplete(context.Background(), "key", "upload", []transfer.MultipartPart{{PartNumber: 1, ETag: etag}}); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if v.composite != "" {
+		t.Fatalf("composite = %q, want none for block-ID ETags", v.composite)
+	}
+}
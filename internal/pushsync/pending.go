@@ -0,0 +1,107 @@
+package pushsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/gitrepo"
+)
+
+// pendingPath locates the pending-upload list for a remote; swapped in tests.
+var pendingPath = func(remote string) (string, error) {
+	drsDir, err := gitrepo.DrsTopLevel()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(drsDir, "cursors", "push", remote+".json"), nil
+}
+
+// pendingUploads lists the oids a push registered records for but has not
+// finished uploading. The server already answers a checksum lookup for
+// them, so without the list a push that failed between register and upload
+// would leave records pointing at missing content, and every later push
+// would skip them as already available.
+type pendingUploads struct {
+	path string
+	oids map[string]bool
+}
+
+// loadPendingUploads reads the list for remote. Outside a repository, or
+// when the list cannot be read, it starts empty; without a remote name
+// nothing is persisted.
+func loadPendingUploads(remote string) *pendingUploads {
+	p := &pendingUploads{oids: map[string]bool{}}
+	if remote == "" {
+		return p
+	}
+	path, err := pendingPath(remote)
+	if err != nil {
+		return p
+	}
+	p.path = path
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return p
+	}
+	var oids []string
+	if json.Unmarshal(data, &oids) == nil {
+		for _, oid := range oids {
+			p.oids[oid] = true
+		}
+	}
+	return p
+}
+
+func (p *pendingUploads) has(oid string) bool { return p != nil && p.oids[oid] }
+
+// add records oids before their records are registered.
+func (p *pendingUploads) add(oids []string) error {
+	if p == nil {
+		return nil
+	}
+	for _, oid := range oids {
+		p.oids[oid] = true
+	}
+	return p.save()
+}
+
+// done drops oids once their content is uploaded.
+func (p *pendingUploads) done(oids []string) error {
+	if p == nil {
+		return nil
+	}
+	for _, oid := range oids {
+		delete(p.oids, oid)
+	}
+	return p.save()
+}
+
+func (p *pendingUploads) save() error {
+	if p == nil || p.path == "" {
+		return nil
+	}
+	if len(p.oids) == 0 {
+		if err := os.Remove(p.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove pending upload list: %w", err)
+		}
+		return nil
+	}
+	oids := make([]string, 0, len(p.oids))
+	for oid := range p.oids {
+		oids = append(oids, oid)
+	}
+	sort.Strings(oids)
+	data, err := json.Marshal(oids)
+	if err != nil {
+		return err
+	}
+	if err := common.WriteFileAtomic(p.path, data, 0o644); err != nil {
+		return fmt.Errorf("write pending upload list: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package pushsync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func usePendingDir(t testing.TB) string {
+	t.Helper()
+	dir := t.TempDir()
+	orig := pendingPath
+	t.Cleanup(func() { pendingPath = orig })
+	pendingPath = func(remote string) (string, error) { return filepath.Join(dir, remote+".json"), nil }
+	return dir
+}
+
+func TestPendingUploadsPersistUntilDone(t *testing.T) {
+	dir := usePendingDir(t)
+
+	if err := loadPendingUploads("origin").add([]string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	p := loadPendingUploads("origin")
+	if !p.has("a") || !p.has("b") {
+		t.Fatalf("reloaded pending = %v, want a and b", p.oids)
+	}
+	if loadPendingUploads("other").has("a") {
+		t.Fatal("pending uploads leaked across remotes")
+	}
+
+	if err := p.done([]string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "origin.json")); !os.IsNotExist(err) {
+		t.Fatalf("pending list not removed once empty: %v", err)
+	}
+}
+
+func TestEnsureMetadataRegisteredUploadsPendingRecord(t *testing.T) {
+	usePendingDir(t)
+	oid := "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	if err := loadPendingUploads("origin").add([]string{oid}); err != nil {
+		t.Fatal(err)
+	}
+
+	rt := newPushRuntime(&config.GitContext{Logger: drslog.NewNoOpLogger()})
+	setTestPushScope(rt)
+	existing := drsapi.DrsObject{
+		Id:               "existing-id",
+		Checksums:        []drsapi.Checksum{{Type: "sha256", Checksum: oid}},
+		ControlledAccess: &[]string{"/organization/syfon/project/e2e"},
+	}
+	file := filepath.Join(t.TempDir(), "sample.bin")
+	if err := os.WriteFile(file, []byte("hello world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	session := &batchSyncSession{
+		ctx:            context.Background(),
+		rt:             rt,
+		filesByOID:     map[string]lfs.LfsFileInfo{oid: {Oid: oid, Name: file, Size: 11}},
+		oids:           []string{oid},
+		drsObjByOID:    map[string]*drsapi.DrsObject{},
+		existingByHash: map[string][]drsapi.DrsObject{oid: {existing}},
+		uploadRequired: map[string]bool{},
+		pending:        loadPendingUploads("origin"),
+	}
+
+	if err := session.ensureMetadataRegistered(); err != nil {
+		t.Fatalf("ensureMetadataRegistered: %v", err)
+	}
+	if !session.uploadRequired[oid] {
+		t.Fatal("record left by a failed upload was not marked for upload")
+	}
+}
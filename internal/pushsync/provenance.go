@@ -0,0 +1,78 @@
+package pushsync
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/provenance"
+	"github.com/calypr/git-drs/internal/version"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/calypr/syfon/client/hash"
+)
+
+// sourceCommit returns the commit HEAD names, or "" before the first commit;
+// swapped in tests.
+var sourceCommit = func(ctx context.Context) string {
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// attest signs a provenance statement onto each record about to be
+// registered, the records for oids and the extra path records, when
+// drs.provenance.* configures a signer. A signing failure fails the push
+// rather than registering records without the attestation asked for.
+func (s *batchSyncSession) attest(oids []string, extra []*drsapi.DrsObject) error {
+	if !s.rt.Provenance.Enabled() {
+		return nil
+	}
+	signer, err := s.rt.Provenance.Signer()
+	if err != nil {
+		return err
+	}
+	src := provenance.Source{
+		Organization: s.rt.Scope.Organization,
+		Project:      s.rt.Scope.Project,
+		Commit:       sourceCommit(s.ctx),
+		Stage:        s.stage,
+		Version:      version.Version,
+		Time:         time.Now(),
+	}
+	if s.rt.API != nil {
+		src.Remote = s.rt.API.RemoteName
+	}
+	pathOf := s.pathsByID()
+	sign := func(oid, path string, obj *drsapi.DrsObject) error {
+		src.OID, src.Path = oid, filepath.ToSlash(path)
+		env, err := provenance.Sign(s.ctx, signer, provenance.New(src))
+		if err != nil {
+			return fmt.Errorf("signing provenance for %s (oid %s): %w", path, oid, err)
+		}
+		provenance.SetOnObject(obj, env)
+		return nil
+	}
+	for _, oid := range oids {
+		obj := s.drsObjByOID[oid]
+		path := s.filesByOID[oid].Name
+		if p, ok := pathOf[obj.Id]; ok && s.pathIDs() {
+			path = p
+		}
+		if err := sign(oid, path, obj); err != nil {
+			return err
+		}
+	}
+	for _, obj := range extra {
+		oid := localdrsobject.NormalizeOid(hash.ConvertDrsChecksumsToHashInfo(obj.Checksums).SHA256)
+		if err := sign(oid, pathOf[obj.Id], obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package pushsync
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/provenance"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestAttestSignsRecordsAboutToBeRegistered(t *testing.T) {
+	orig := sourceCommit
+	t.Cleanup(func() { sourceCommit = orig })
+	sourceCommit = func(context.Context) string { return "abc123" }
+
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(priv)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	oid := strings.Repeat("e", 64)
+	settings := provenance.Settings{KeyFile: keyFile}
+	session := newBatchSyncSession(&config.GitContext{RemoteName: "origin", ProjectId: "e2e", Provenance: settings, Logger: drslog.NewNoOpLogger()}, context.Background(), nil, nil, nil)
+	session.normalizeFiles(map[string]lfs.LfsFileInfo{"data/x.bin": {Name: "data/x.bin", Oid: oid, Size: 3}})
+	obj := &drsapi.DrsObject{Id: "did-1"}
+	session.drsObjByOID[oid] = obj
+
+	if err := session.attest([]string{oid}, nil); err != nil {
+		t.Fatalf("attest: %v", err)
+	}
+	env, ok := provenance.FromObject(obj)
+	if !ok {
+		t.Fatal("record carries no attestation")
+	}
+	verifier, err := settings.Verifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := provenance.Verify(context.Background(), verifier, env, oid)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	params := st.Predicate.BuildDefinition.ExternalParameters
+	if st.Commit() != "abc123" || params.Path != "data/x.bin" || params.Remote != "origin" || params.Stage != provenance.StagePush {
+		t.Fatalf("statement = %+v", st)
+	}
+
+	// Without a signer configured, records are registered as before.
+	session.rt.Provenance = provenance.Settings{}
+	plain := &drsapi.DrsObject{Id: "did-1"}
+	session.drsObjByOID[oid] = plain
+	if err := session.attest([]string{oid}, nil); err != nil || plain.Aliases != nil {
+		t.Fatalf("attest without settings = %v, aliases %v", err, plain.Aliases)
+	}
+}
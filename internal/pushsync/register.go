@@ -8,11 +8,21 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	localcommon "github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/compression"
 	"github.com/calypr/git-drs/internal/config"
 	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/encryption"
+	"github.com/calypr/git-drs/internal/httpclient"
 	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/metrics"
+	"github.com/calypr/git-drs/internal/origin"
+	"github.com/calypr/git-drs/internal/projectdir"
+	"github.com/calypr/git-drs/internal/provenance"
 	drsapi "github.com/calypr/syfon/apigen/client/drs"
 	internalapi "github.com/calypr/syfon/apigen/client/internalapi"
 	sycommon "github.com/calypr/syfon/client/common"
@@ -54,12 +64,34 @@ type pushTuning struct {
 }
 
 type pushRuntime struct {
-	API        *config.GitContext
-	Credential *conf.Credential
-	Logger     *slog.Logger
-	Scope      pushScope
-	Tuning     pushTuning
-	ProbeURL   func(context.Context, string) error
+	API         *config.GitContext
+	Credential  *conf.Credential
+	Logger      *slog.Logger
+	Scope       pushScope
+	Tuning      pushTuning
+	ProbeURL    func(context.Context, string) error
+	Encryption  encryption.Settings
+	Compression compression.Settings
+	Provenance  provenance.Settings
+	Origin      origin.Fields
+	Upload      config.UploadSettings
+	Verify      config.VerifyPolicy
+	// Journal records uploads in progress so an interrupted push resumes
+	// them; nil outside a push session.
+	Journal *uploadJournal
+
+	directOnce sync.Once
+	direct     transfer.MultipartBackend
+	directErr  error
+
+	// singlePutUnsupported and multipartUnsupported record fence upload
+	// modes the server rejected, so later uploads go straight to the other.
+	singlePutUnsupported atomic.Bool
+	multipartUnsupported atomic.Bool
+
+	// storageHintOnce warns once that signed-URL uploads ignore storage
+	// hints.
+	storageHintOnce sync.Once
 }
 
 func newPushRuntime(cl *config.GitContext) *pushRuntime {
@@ -82,27 +114,43 @@ func newPushRuntime(cl *config.GitContext) *pushRuntime {
 			MultiPartThreshold: cl.MultiPartThreshold,
 			UploadConcurrency:  cl.UploadConcurrency,
 		},
-		ProbeURL: newDownloadProbe(cl),
+		ProbeURL:    newDownloadProbe(cl),
+		Encryption:  cl.Encryption,
+		Compression: cl.Compression,
+		Provenance:  cl.Provenance,
+		Origin:      cl.Origin,
+		Upload:      cl.Upload,
+		Verify:      cl.Verify,
 	}
 }
 
 // isFileDownloadable checks if a file is already available for download
 func isFileDownloadable(rt *pushRuntime, ctx context.Context, drsObject *drsapi.DrsObject) (bool, error) {
-	// Try to get a download URL - if successful, file is downloadable
+	// If we can't get a download URL, assume file is not downloadable
+	return checkDownloadable(rt, ctx, drsObject) == nil, nil
+}
+
+// CheckReadable signs a download URL for obj and reads its first byte, as
+// push verification does, returning why the object cannot be read.
+func CheckReadable(ctx context.Context, cl *config.GitContext, obj *drsapi.DrsObject) error {
+	return checkDownloadable(newPushRuntime(cl), ctx, obj)
+}
+
+// checkDownloadable signs drsObject's first access method and probes the URL,
+// returning why the object cannot be read.
+func checkDownloadable(rt *pushRuntime, ctx context.Context, drsObject *drsapi.DrsObject) error {
 	if drsObject.AccessMethods == nil || len(*drsObject.AccessMethods) == 0 {
-		return false, nil
+		return fmt.Errorf("record %s has no access methods", drsObject.Id)
 	}
 	accessType := (*drsObject.AccessMethods)[0].Type
 	res, err := rt.API.Client.DRS().GetAccessURL(ctx, drsObject.Id, string(accessType))
 	if err != nil {
-		// If we can't get a download URL, assume file is not downloadable
-		return false, nil
+		return fmt.Errorf("sign %s access URL: %w", accessType, err)
 	}
 	if rt.ProbeURL == nil {
 		rt.ProbeURL = newDownloadProbe(rt.API)
 	}
-	err = rt.ProbeURL(ctx, res.Url)
-	return err == nil, nil
+	return rt.ProbeURL(ctx, res.Url)
 }
 
 func uploadKeyFromObject(obj *drsapi.DrsObject, bucket string, storagePrefix string) string {
@@ -124,10 +172,13 @@ func uploadKeyFromObject(obj *drsapi.DrsObject, bucket string, storagePrefix str
 			raw = strings.TrimSpace((*obj.AccessMethods)[0].AccessUrl.Url)
 		}
 		if raw != "" {
-			if u, err := url.Parse(raw); err == nil && strings.EqualFold(u.Scheme, "s3") {
+			if u, err := url.Parse(raw); err == nil && isObjectStoreScheme(u.Scheme) {
 				// Preserve the full object key path from DRS metadata.
 				// Taking only filepath.Base(...) loses CAS/storage prefixes and causes 404 downloads.
 				key := strings.TrimSpace(strings.TrimPrefix(u.Path, "/"))
+				if container, ok := config.AzureContainer(bucket); ok {
+					bucket = container
+				}
 				if key != "" && (bucket == "" || strings.EqualFold(strings.TrimSpace(u.Host), strings.TrimSpace(bucket))) {
 					return key
 				}
@@ -140,6 +191,16 @@ func uploadKeyFromObject(obj *drsapi.DrsObject, bucket string, storagePrefix str
 	return ""
 }
 
+// isObjectStoreScheme reports whether an access URL with scheme names the
+// object key uploads write to: S3, or an Azure container.
+func isObjectStoreScheme(scheme string) bool {
+	switch strings.ToLower(scheme) {
+	case "s3", "az", "azblob":
+		return true
+	}
+	return false
+}
+
 func resolveUploadSourcePath(oid string, worktreePath string, isPointer bool) (string, bool, error) {
 	oid = localdrsobject.NormalizeOid(oid)
 	if oid == "" {
@@ -157,6 +218,7 @@ func resolveUploadSourcePath(oid string, worktreePath string, isPointer bool) (s
 		return "", false, nil
 	}
 
+	worktreePath = projectdir.Path(worktreePath)
 	st, statErr := os.Stat(worktreePath)
 	if statErr != nil {
 		return "", false, fmt.Errorf("stat worktree path %s: %w", worktreePath, statErr)
@@ -167,6 +229,32 @@ func resolveUploadSourcePath(oid string, worktreePath string, isPointer bool) (s
 	return worktreePath, true, nil
 }
 
+// UploadObject uploads the file at path to cl's bucket as the content of the
+// registered record obj. When obj carries an encryption block, path holds
+// plaintext and is encrypted under cl's key first; a record without one is
+// refused by a remote that encrypts, since its bytes would be stored in the
+// clear. A content-encoding block likewise has path compressed first.
+func UploadObject(cl *config.GitContext, ctx context.Context, obj *drsapi.DrsObject, path string) error {
+	rt := newPushRuntime(cl)
+	if meta, ok := encryption.FromObject(obj); ok {
+		return uploadSealed(rt, ctx, obj, path, meta)
+	}
+	if rt.Encryption.Enabled() {
+		return fmt.Errorf("remote %q encrypts content client-side, but record %s is not encrypted", rt.Encryption.Remote, obj.Id)
+	}
+	if meta, ok := compression.FromObject(obj); ok {
+		return uploadCompressed(rt, ctx, obj, path, meta)
+	}
+	return uploadFileForObject(rt, ctx, obj, path, false)
+}
+
+// ObjectDownloadable reports whether obj's first access method on cl resolves
+// to a URL that serves content.
+func ObjectDownloadable(cl *config.GitContext, ctx context.Context, obj *drsapi.DrsObject) bool {
+	ok, _ := isFileDownloadable(newPushRuntime(cl), ctx, obj)
+	return ok
+}
+
 func uploadFileForObject(rt *pushRuntime, ctx context.Context, drsObject *drsapi.DrsObject, filePath string, skipIfDownloadable bool) error {
 	hInfo := hash.ConvertDrsChecksumsToHashInfo(drsObject.Checksums)
 	if skipIfDownloadable {
@@ -192,7 +280,9 @@ func uploadFileForObject(rt *pushRuntime, ctx context.Context, drsObject *drsapi
 	}
 	fileSize := fileStat.Size()
 	drsSize := drsObject.Size
-	if drsSize != fileSize {
+	_, encrypted := encryption.FromObject(drsObject)
+	_, compressed := compression.FromObject(drsObject)
+	if drsSize != fileSize && !encrypted && !compressed {
 		rt.Logger.WarnContext(ctx, "drs metadata size differs from local source size; using local file size for upload mode decision",
 			"did", drsObject.Id,
 			"path", filePath,
@@ -201,36 +291,60 @@ func uploadFileForObject(rt *pushRuntime, ctx context.Context, drsObject *drsapi
 		)
 	}
 
+	oid := localdrsobject.NormalizeOid(hInfo.SHA256)
+	if !rt.Tuning.ForceUpload && rt.Journal.finished(oid, drsObject.Id, fileSize) {
+		rt.Logger.DebugContext(ctx, "object was uploaded by an interrupted push, skipping upload", "oid", oid, "did", drsObject.Id)
+		return nil
+	}
+
 	objectKey := uploadKeyFromObject(drsObject, rt.Scope.Bucket, rt.Scope.StoragePref)
 	rt.Logger.DebugContext(ctx, "uploading via data-client orchestrator",
 		"size", fileSize,
 		"path", filePath,
 		"threshold", multiPartThreshold,
 	)
-	forceMultipart := fileSize >= multiPartThreshold
-	rt.Logger.DebugContext(ctx, "uploading via syfon transfer engine",
-		"did", drsObject.Id,
-		"size", fileSize,
-		"threshold", multiPartThreshold,
-		"forceMultipart", forceMultipart,
-	)
-	backend := uploadBackendForRuntime(rt)
-	if backend == nil {
-		return fmt.Errorf("upload backend is required")
+	backend, err := rt.uploadBackend(ctx)
+	if err != nil {
+		return err
 	}
-	if strings.TrimSpace(rt.Scope.Organization) != "" && strings.TrimSpace(rt.Scope.Project) != "" {
-		backend = &scopedUploadURLBackend{MultipartBackend: backend, rt: rt}
+	if !rt.Tuning.ForceUpload {
+		backend = rt.Journal.wrap(backend, oid, drsObject.Id, fileSize)
 	}
-	if forceMultipart {
-		if err := syupload.Upload(ctx, backend, filePath, objectKey, drsObject.Id, rt.Scope.Bucket, scopedUploadMetadata(rt), false, true); err != nil {
-			return fmt.Errorf("upload error: %w", err)
-		}
-		return nil
+	var verifier *partVerifier
+	if rt.Upload.VerifyParts {
+		verifier = newPartVerifier(backend, filePath, drsObject.Id, fileSize)
+		backend = verifier
 	}
-	if err := syupload.Upload(ctx, backend, filePath, objectKey, drsObject.Id, rt.Scope.Bucket, scopedUploadMetadata(rt), false, false); err != nil {
+	ctx = rt.withStorageHint(ctx, drsObject)
+	start := time.Now()
+	if rt.Upload.Direct() {
+		forceMultipart := fileSize >= multiPartThreshold
+		rt.Logger.DebugContext(ctx, "uploading via syfon transfer engine",
+			"did", drsObject.Id,
+			"size", fileSize,
+			"threshold", multiPartThreshold,
+			"forceMultipart", forceMultipart,
+		)
+		err = syupload.Upload(ctx, backend, filePath, objectKey, drsObject.Id, rt.Scope.Bucket, scopedUploadMetadata(rt), false, forceMultipart)
+	} else {
+		mode := rt.uploadModeFor(fileSize, multiPartThreshold)
+		rt.Logger.DebugContext(ctx, "uploading via syfon transfer engine",
+			"did", drsObject.Id,
+			"size", fileSize,
+			"threshold", multiPartThreshold,
+			"method", rt.Upload.Method,
+			"mode", mode.String(),
+		)
+		err = rt.uploadWithFallback(ctx, backend, filePath, objectKey, drsObject.Id, fileSize, mode)
+	}
+	if err == nil && verifier != nil {
+		err = verifier.verifyStored(ctx, rt, drsObject)
+	}
+	metrics.RecordTransfer(metrics.Upload, fileSize, time.Since(start), err)
+	if err != nil {
 		return fmt.Errorf("upload error: %w", err)
 	}
-	return nil
+	return rt.Journal.finish(oid, drsObject.Id, fileSize)
 }
 
 func resolveScopedUploadURL(rt *pushRuntime, ctx context.Context, backend transfer.MultipartBackend, did, objectKey string) (string, error) {
@@ -283,7 +397,7 @@ func scopedUploadMetadata(rt *pushRuntime) sycommon.FileMetadata {
 }
 
 func newDownloadProbe(cl *config.GitContext) func(context.Context, string) error {
-	httpClient := http.DefaultClient
+	httpClient := httpclient.New(0)
 	if cl != nil && cl.Client != nil && cl.Client.HTTPClient() != nil {
 		httpClient = cl.Client.HTTPClient()
 	}
@@ -299,7 +413,7 @@ func probeDownloadURL(ctx context.Context, httpClient *http.Client, rawURL strin
 	}
 	req.Header.Set("Range", "bytes=0-0")
 	if httpClient == nil {
-		httpClient = http.DefaultClient
+		httpClient = httpclient.New(0)
 	}
 	resp, err := httpClient.Do(req)
 	if err != nil {
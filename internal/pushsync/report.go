@@ -0,0 +1,213 @@
+package pushsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/calypr/git-drs/internal/projectdir"
+)
+
+// ReportDir is the repo-relative directory push timing reports are written
+// to when drs.push.report is set.
+const ReportDir = ".drs/reports"
+
+// ObjectTiming is where one object's time went during a push. Register is
+// the duration of the bulk registration that included it; QueueWait is the
+// time between the upload plan starting and its upload starting.
+type ObjectTiming struct {
+	OID              string  `json:"oid"`
+	Path             string  `json:"path"`
+	Bytes            int64   `json:"bytes"`
+	Registered       bool    `json:"registered"`
+	Uploaded         bool    `json:"uploaded"`
+	QueueWaitSeconds float64 `json:"queue_wait_seconds"`
+	RegisterSeconds  float64 `json:"register_seconds"`
+	UploadSeconds    float64 `json:"upload_seconds"`
+	Retries          int64   `json:"retries"`
+	Error            string  `json:"error,omitempty"`
+}
+
+// ReportTotals sums the objects of a report.
+type ReportTotals struct {
+	Objects          int     `json:"objects"`
+	Registered       int     `json:"registered"`
+	Uploaded         int     `json:"uploaded"`
+	Bytes            int64   `json:"bytes"`
+	QueueWaitSeconds float64 `json:"queue_wait_seconds"`
+	RegisterSeconds  float64 `json:"register_seconds"`
+	UploadSeconds    float64 `json:"upload_seconds"`
+	Retries          int64   `json:"retries"`
+}
+
+// TransferReport is the per-object timing summary of one push. Objects lists
+// only those the push registered or uploaded; Totals.Objects counts every
+// object it considered.
+type TransferReport struct {
+	Remote         string         `json:"remote"`
+	Started        time.Time      `json:"started"`
+	ElapsedSeconds float64        `json:"elapsed_seconds"`
+	Objects        []ObjectTiming `json:"objects"`
+	Totals         ReportTotals   `json:"totals"`
+}
+
+// transferTimings collects ObjectTimings across the scopes of a push. A nil
+// *transferTimings records nothing.
+type transferTimings struct {
+	mu      sync.Mutex
+	remote  string
+	start   time.Time
+	seen    int
+	objects map[string]*ObjectTiming
+	// register is the time spent in bulk registration requests.
+	register time.Duration
+}
+
+func newTransferTimings(remote string) *transferTimings {
+	return &transferTimings{remote: remote, start: time.Now(), objects: map[string]*ObjectTiming{}}
+}
+
+func (t *transferTimings) object(oid, path string) *ObjectTiming {
+	o := t.objects[oid]
+	if o == nil {
+		o = &ObjectTiming{OID: oid, Path: path}
+		t.objects[oid] = o
+	}
+	return o
+}
+
+// considered counts objects a scope looked at, whether or not they needed work.
+func (t *transferTimings) considered(n int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.seen += n
+	t.mu.Unlock()
+}
+
+// registered records one bulk registration request that took d and
+// registered paths, keyed by oid.
+func (t *transferTimings) registered(paths map[string]string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.register += d
+	for oid, path := range paths {
+		o := t.object(oid, path)
+		o.Registered = true
+		o.RegisterSeconds = d.Seconds()
+	}
+}
+
+func (t *transferTimings) uploaded(c uploadCandidate, wait, d time.Duration, retries int64, err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	o := t.object(c.oid, c.file.Name)
+	o.Bytes = c.size
+	o.QueueWaitSeconds = wait.Seconds()
+	o.UploadSeconds = d.Seconds()
+	o.Retries = retries
+	o.Uploaded = err == nil
+	if err != nil {
+		o.Error = err.Error()
+	}
+}
+
+func (t *transferTimings) report() TransferReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := TransferReport{
+		Remote:         t.remote,
+		Started:        t.start.UTC(),
+		ElapsedSeconds: time.Since(t.start).Seconds(),
+		Objects:        make([]ObjectTiming, 0, len(t.objects)),
+		Totals:         ReportTotals{Objects: t.seen, RegisterSeconds: t.register.Seconds()},
+	}
+	for _, o := range t.objects {
+		r.Objects = append(r.Objects, *o)
+		if o.Registered {
+			r.Totals.Registered++
+		}
+		if o.Uploaded {
+			r.Totals.Uploaded++
+			r.Totals.Bytes += o.Bytes
+		}
+		r.Totals.QueueWaitSeconds += o.QueueWaitSeconds
+		r.Totals.UploadSeconds += o.UploadSeconds
+		r.Totals.Retries += o.Retries
+	}
+	sort.Slice(r.Objects, func(i, j int) bool { return r.Objects[i].Path < r.Objects[j].Path })
+	return r
+}
+
+// finish logs the report and, when write is set, saves it under ReportDir.
+// Pushes that registered and uploaded nothing are not reported.
+func (t *transferTimings) finish(logger *slog.Logger, write bool) {
+	if t == nil || logger == nil {
+		return
+	}
+	r := t.report()
+	if len(r.Objects) == 0 {
+		return
+	}
+	for _, o := range r.Objects {
+		logger.Info("push timing",
+			"oid", o.OID,
+			"path", o.Path,
+			"bytes", o.Bytes,
+			"queue_wait", seconds(o.QueueWaitSeconds),
+			"register", seconds(o.RegisterSeconds),
+			"upload", seconds(o.UploadSeconds),
+			"retries", o.Retries,
+		)
+	}
+	logger.Info("push timing totals",
+		"objects", r.Totals.Objects,
+		"registered", r.Totals.Registered,
+		"uploaded", r.Totals.Uploaded,
+		"bytes", r.Totals.Bytes,
+		"queue_wait", seconds(r.Totals.QueueWaitSeconds),
+		"register", seconds(r.Totals.RegisterSeconds),
+		"upload", seconds(r.Totals.UploadSeconds),
+		"retries", r.Totals.Retries,
+		"elapsed", seconds(r.ElapsedSeconds),
+	)
+	if !write {
+		return
+	}
+	path, err := writeReport(r)
+	if err != nil {
+		logger.Warn("failed to write push timing report", "error", err)
+		return
+	}
+	logger.Info("wrote push timing report", "path", path)
+}
+
+// writeReport saves r as ReportDir/push-<start time>.json.
+func writeReport(r TransferReport) (string, error) {
+	dir := projectdir.Path(ReportDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("push-%s.json", r.Started.Format("20060102T150405.000Z")))
+	return path, os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second)).Round(time.Millisecond)
+}
@@ -0,0 +1,120 @@
+package pushsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/lfs"
+	"github.com/calypr/git-drs/internal/metrics"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/calypr/syfon/client/transfer"
+)
+
+func TestExecuteUploadPlanRecordsTimings(t *testing.T) {
+	tmp := t.TempDir()
+	rt := newPushRuntime(nil)
+	setTestPushScope(rt)
+	rt.Logger = drslog.NewNoOpLogger()
+	rt.Tuning.MultiPartThreshold = 1024
+	rt.Tuning.UploadConcurrency = 1
+
+	backend := &pushUploadBackendStub{
+		uploadFunc: func(ctx context.Context, _ string, _ io.Reader, _ int64) error {
+			metrics.CountRetry(ctx)
+			return nil
+		},
+	}
+	oldBackend := uploadBackendForRuntime
+	uploadBackendForRuntime = func(*pushRuntime) transfer.MultipartBackend { return backend }
+	t.Cleanup(func() { uploadBackendForRuntime = oldBackend })
+
+	makeCandidate := func(oid string) uploadCandidate {
+		path := filepath.Join(tmp, oid)
+		if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return uploadCandidate{
+			oid:  oid,
+			obj:  &drsapi.DrsObject{Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: oid}}},
+			file: lfs.LfsFileInfo{Name: "data/" + oid},
+			size: 5,
+			src:  path,
+		}
+	}
+
+	timings := newTransferTimings("origin")
+	timings.considered(3)
+	timings.registered(map[string]string{"good-oid": "data/good-oid"}, 20*time.Millisecond)
+	session := &batchSyncSession{ctx: context.Background(), rt: rt, timings: timings}
+	bad := makeCandidate("bad-oid")
+	bad.src = filepath.Join(tmp, "missing")
+	if err := session.executeUploadPlan([]uploadCandidate{makeCandidate("good-oid"), bad}); err == nil {
+		t.Fatal("expected the failing upload to fail the plan")
+	}
+
+	r := timings.report()
+	if len(r.Objects) != 2 {
+		t.Fatalf("objects = %+v", r.Objects)
+	}
+	failed, good := r.Objects[0], r.Objects[1]
+	if !good.Registered || !good.Uploaded || good.Bytes != 5 || good.Retries != 1 || good.RegisterSeconds != 0.02 {
+		t.Fatalf("good = %+v", good)
+	}
+	if failed.Uploaded || failed.Registered || !strings.Contains(failed.Error, "missing") {
+		t.Fatalf("failed = %+v", failed)
+	}
+	if failed.QueueWaitSeconds < good.QueueWaitSeconds {
+		t.Fatalf("sequential upload waited %v, less than the one before it (%v)", failed.QueueWaitSeconds, good.QueueWaitSeconds)
+	}
+	want := ReportTotals{Objects: 3, Registered: 1, Uploaded: 1, Bytes: 5, Retries: 1, RegisterSeconds: 0.02}
+	got := r.Totals
+	got.QueueWaitSeconds, got.UploadSeconds = 0, 0
+	if got != want {
+		t.Fatalf("totals = %+v, want %+v", r.Totals, want)
+	}
+}
+
+func TestFinishLogsAndWritesReport(t *testing.T) {
+	repo := t.TempDir()
+	t.Chdir(repo)
+
+	timings := newTransferTimings("origin")
+	timings.registered(map[string]string{"abc": "data/a.bin"}, time.Second)
+	var logs bytes.Buffer
+	timings.finish(slog.New(slog.NewTextHandler(&logs, nil)), true)
+
+	if !strings.Contains(logs.String(), "push timing totals") || !strings.Contains(logs.String(), "path=data/a.bin") {
+		t.Fatalf("logs = %s", logs.String())
+	}
+	files, err := filepath.Glob(filepath.Join(repo, ReportDir, "push-*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("report files = %v, %v", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r TransferReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Remote != "origin" || len(r.Objects) != 1 || r.Objects[0].RegisterSeconds != 1 || r.Totals.Registered != 1 {
+		t.Fatalf("report = %+v", r)
+	}
+
+	quiet := newTransferTimings("origin")
+	logs.Reset()
+	quiet.finish(slog.New(slog.NewTextHandler(&logs, nil)), true)
+	if logs.Len() != 0 {
+		t.Fatalf("expected nothing reported for a push with no work, got %s", logs.String())
+	}
+}
@@ -0,0 +1,70 @@
+package pushsync
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/calypr/git-drs/internal/storageclass"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// applyStorageHint sets the storage class and tags carried by a request's
+// context on the uploads a direct S3 client starts, single PUTs and
+// multipart uploads alike.
+func applyStorageHint(o *s3.Options) {
+	o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("GitDRSStorageHint",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				if h, ok := storageclass.HintFrom(ctx); ok {
+					switch p := in.Parameters.(type) {
+					case *s3.PutObjectInput:
+						p.StorageClass, p.Tagging = storageHintFields(h)
+					case *s3.CreateMultipartUploadInput:
+						p.StorageClass, p.Tagging = storageHintFields(h)
+					}
+				}
+				return next.HandleInitialize(ctx, in)
+			}), middleware.Before)
+	})
+}
+
+func storageHintFields(h storageclass.Hint) (types.StorageClass, *string) {
+	var tagging *string
+	if len(h.Tags) > 0 {
+		t := h.Tagging()
+		tagging = &t
+	}
+	return types.StorageClass(h.Class), tagging
+}
+
+// withStorageHint returns ctx carrying obj's storage hint for a direct
+// upload. Uploads through URLs the DRS server signs cannot add headers to the
+// signature, so their objects get the bucket's defaults; that is logged once
+// per push, and the record still carries the hint for lifecycle rules.
+func (rt *pushRuntime) withStorageHint(ctx context.Context, obj *drsapi.DrsObject) context.Context {
+	h, ok := storageclass.FromObject(obj)
+	if !ok {
+		return ctx
+	}
+	if !rt.Upload.Direct() {
+		rt.storageHintOnce.Do(func() {
+			rt.Logger.WarnContext(ctx, "storage class and tags only apply to direct uploads (drs.upload.credential-source ambient or static); objects uploaded through signed URLs get the bucket defaults",
+				"did", obj.Id, "class", h.Class)
+		})
+		return ctx
+	}
+	return storageclass.WithHint(ctx, h)
+}
+
+// setStorageHint records the storage class and tags configured for path on a
+// record about to be registered.
+func setStorageHint(rt *pushRuntime, obj *drsapi.DrsObject, path string) {
+	if rt == nil {
+		return
+	}
+	if h := rt.Upload.Storage.HintFor(path); !h.Empty() {
+		storageclass.SetOnObject(obj, h)
+	}
+}
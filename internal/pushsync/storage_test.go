@@ -0,0 +1,73 @@
+package pushsync
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/storageclass"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestDirectUploadSendsStorageClassAndTags(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	var class, tagging string
+	s3srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			class, tagging = r.Header.Get("X-Amz-Storage-Class"), r.Header.Get("X-Amz-Tagging")
+		}
+		w.Header().Set("ETag", `"etag"`)
+	}))
+	defer s3srv.Close()
+
+	src := filepath.Join(t.TempDir(), "reads.bam")
+	if err := os.WriteFile(src, []byte("archive me"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := storageclass.ParseRules([]string{"*.bam=glacier_ir"}, []string{"*.bam=retention=7y", "*=team=genomics"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := &pushRuntime{
+		Logger: drslog.NewNoOpLogger(),
+		Scope:  pushScope{Organization: "org", Project: "proj", Bucket: "data-bucket"},
+		Upload: config.UploadSettings{CredentialSource: config.CredentialSourceStatic, Region: "us-east-1", Endpoint: s3srv.URL, Storage: rules},
+	}
+	obj := &drsapi.DrsObject{
+		Id:   "did-1",
+		Size: 10,
+		AccessMethods: &[]drsapi.AccessMethod{{
+			Type: drsapi.AccessMethodTypeS3,
+			AccessUrl: &struct {
+				Headers *[]string `json:"headers,omitempty"`
+				Url     string    `json:"url"`
+			}{Url: "s3://data-bucket/org/proj/" + strings.Repeat("a", 64)},
+		}},
+	}
+	setStorageHint(rt, obj, "data/reads.bam")
+	hint, ok := storageclass.FromObject(obj)
+	if !ok || hint.Class != "GLACIER_IR" || hint.Tags["retention"] != "7y" || hint.Tags["team"] != "genomics" {
+		t.Fatalf("expected storage hint on record, got %+v (ok %v)", hint, ok)
+	}
+	if err := uploadFileForObject(rt, context.Background(), obj, src, false); err != nil {
+		t.Fatalf("uploadFileForObject: %v", err)
+	}
+	if class != "GLACIER_IR" || tagging != "retention=7y&team=genomics" {
+		t.Fatalf("expected storage class and tags on PUT, got class %q tagging %q", class, tagging)
+	}
+
+	// Files no rule matches keep the bucket's defaults.
+	plain := &drsapi.DrsObject{Id: "did-2"}
+	setStorageHint(&pushRuntime{Upload: config.UploadSettings{Storage: storageclass.Rules{Classes: rules.Classes}}}, plain, "notes.txt")
+	if _, ok := storageclass.FromObject(plain); ok {
+		t.Fatal("expected no storage hint for an unmatched file")
+	}
+}
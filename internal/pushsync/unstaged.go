@@ -0,0 +1,52 @@
+package pushsync
+
+import (
+	"fmt"
+	"strings"
+
+	localcommon "github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/drserrors"
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+)
+
+// trashed reports whether oid was unstaged with git drs unstage; swapped in
+// tests.
+var trashed = func(oid string) bool {
+	return localdrsobject.Trashed(localcommon.DRS_OBJS_PATH, oid)
+}
+
+// refuseUnstaged fails when any of oids, about to get a new record, was
+// unstaged. The pointer is still committed or in the index, so registering
+// it would publish the file the user took back.
+func (s *batchSyncSession) refuseUnstaged(oids []string) error {
+	var lines []string
+	for _, oid := range oids {
+		if trashed(oid) {
+			lines = append(lines, fmt.Sprintf("  %s (%s)", s.filesByOID[oid].Name, oid))
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return drserrors.WithKind(drserrors.ErrConfig, fmt.Errorf(
+		"%d files were unstaged with git drs unstage but are still committed or in the index:\n%s\nremove them from the commit, or run git drs unstage --undo to register them",
+		len(lines), strings.Join(lines, "\n")))
+}
+
+// Unqueue removes intents from the remote's outbox so no later push or flush
+// registers them, and returns how many were removed.
+func Unqueue(remote string, intents []Intent) (int, error) {
+	removed := 0
+	_, err := updateOutbox(remote, func(o *outbox) {
+		for _, in := range intents {
+			if _, ok := o.intents[in.Key]; ok {
+				delete(o.intents, in.Key)
+				removed++
+			}
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
@@ -0,0 +1,51 @@
+package pushsync
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+func TestRefuseUnstaged(t *testing.T) {
+	orig := trashed
+	t.Cleanup(func() { trashed = orig })
+	trashed = func(oid string) bool { return oid == "bb" }
+
+	s := &batchSyncSession{filesByOID: map[string]lfs.LfsFileInfo{
+		"aa": {Name: "a.bin", Oid: "aa"},
+		"bb": {Name: "secret.bin", Oid: "bb"},
+	}}
+	if err := s.refuseUnstaged([]string{"aa"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := s.refuseUnstaged([]string{"aa", "bb"})
+	if !errors.Is(err, drserrors.ErrConfig) || !strings.Contains(err.Error(), "secret.bin (bb)") || strings.Contains(err.Error(), "a.bin") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestUnqueue(t *testing.T) {
+	dir := t.TempDir()
+	origPath := outboxPath
+	t.Cleanup(func() { outboxPath = origPath })
+	outboxPath = func(remote string) (string, error) { return filepath.Join(dir, remote+".json"), nil }
+
+	if _, err := QueueForPush(&config.GitContext{RemoteName: "origin"}, map[string]lfs.LfsFileInfo{
+		"a.bin": {Name: "a.bin", Oid: "aa"},
+		"b.bin": {Name: "b.bin", Oid: "bb"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	n, err := Unqueue("origin", []Intent{{Key: IdempotencyKey("origin", "b.bin", "bb")}, {Key: "unknown"}})
+	if err != nil || n != 1 {
+		t.Fatalf("Unqueue = %d, %v; want 1", n, err)
+	}
+	if intents := Outbox("origin"); len(intents) != 1 || intents[0].Path != "a.bin" {
+		t.Fatalf("outbox = %+v; want a.bin only", intents)
+	}
+}
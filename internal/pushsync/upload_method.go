@@ -0,0 +1,150 @@
+package pushsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drserrors"
+	sycommon "github.com/calypr/syfon/client/common"
+	"github.com/calypr/syfon/client/transfer"
+	syupload "github.com/calypr/syfon/client/transfer/upload"
+)
+
+// singlePutLimit is the largest object one signed PUT can carry.
+const singlePutLimit = int64(sycommon.FileSizeLimit)
+
+// uploadMode is how one fence upload sends its bytes.
+type uploadMode int
+
+const (
+	// uploadSinglePut resolves one URL from /data/upload and PUTs the file.
+	uploadSinglePut uploadMode = iota
+	// uploadMultipart goes through the /data/multipart endpoints.
+	uploadMultipart
+)
+
+func (m uploadMode) String() string {
+	if m == uploadMultipart {
+		return "multipart"
+	}
+	return "single-put"
+}
+
+func (m uploadMode) other() uploadMode {
+	if m == uploadMultipart {
+		return uploadSinglePut
+	}
+	return uploadMultipart
+}
+
+// uploadModeFor picks the mode to try first for a file of size bytes. The
+// multipart method keeps single PUTs for files below threshold; the
+// presigned method uses them up to singlePutLimit. A mode the server has
+// already rejected during this push is avoided when the other one fits.
+func (rt *pushRuntime) uploadModeFor(size, threshold int64) uploadMode {
+	mode := uploadSinglePut
+	switch {
+	case size >= singlePutLimit:
+		mode = uploadMultipart
+	case rt.Upload.Method != config.UploadMethodPresigned && size >= threshold:
+		mode = uploadMultipart
+	}
+	if mode == uploadSinglePut && rt.singlePutUnsupported.Load() {
+		return uploadMultipart
+	}
+	if mode == uploadMultipart && rt.multipartUnsupported.Load() && size < singlePutLimit {
+		return uploadSinglePut
+	}
+	return mode
+}
+
+func (rt *pushRuntime) markUnsupported(mode uploadMode) {
+	if mode == uploadMultipart {
+		rt.multipartUnsupported.Store(true)
+		return
+	}
+	rt.singlePutUnsupported.Store(true)
+}
+
+// uploadWithFallback uploads filePath with mode and, when the server answers
+// that mode's signing endpoint as unsupported, once more with the other mode
+// if the file fits it.
+func (rt *pushRuntime) uploadWithFallback(ctx context.Context, backend transfer.MultipartBackend, filePath, objectKey, did string, size int64, mode uploadMode) error {
+	err := rt.uploadAs(ctx, backend, filePath, objectKey, did, mode)
+	if !isUnsupportedUploadMode(err) {
+		return err
+	}
+	rt.markUnsupported(mode)
+	fallback := mode.other()
+	if fallback == uploadSinglePut && size >= singlePutLimit {
+		return err
+	}
+	rt.Logger.WarnContext(ctx, "upload mode not supported by server; falling back",
+		"did", did,
+		"mode", mode.String(),
+		"fallback", fallback.String(),
+		"error", err,
+	)
+	if fallbackErr := rt.uploadAs(ctx, backend, filePath, objectKey, did, fallback); fallbackErr != nil {
+		if isUnsupportedUploadMode(fallbackErr) {
+			rt.markUnsupported(fallback)
+		}
+		return fmt.Errorf("%s upload failed: %v; %s fallback failed: %w", mode, err, fallback, fallbackErr)
+	}
+	return nil
+}
+
+func (rt *pushRuntime) uploadAs(ctx context.Context, backend transfer.MultipartBackend, filePath, objectKey, did string, mode uploadMode) error {
+	return syupload.Upload(ctx, &signingProbeBackend{MultipartBackend: backend}, filePath, objectKey, did, rt.Scope.Bucket, scopedUploadMetadata(rt), false, mode == uploadMultipart)
+}
+
+// unsupportedUploadModeError marks a signing request the server rejected as
+// not found, not allowed, or not implemented.
+type unsupportedUploadModeError struct{ err error }
+
+func (e *unsupportedUploadModeError) Error() string { return e.err.Error() }
+func (e *unsupportedUploadModeError) Unwrap() error { return e.err }
+
+func isUnsupportedUploadMode(err error) bool {
+	var unsupported *unsupportedUploadModeError
+	return errors.As(err, &unsupported)
+}
+
+func markUnsupportedUploadMode(err error) error {
+	if err == nil {
+		return nil
+	}
+	if status, ok := drserrors.Status(err); ok {
+		switch status {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			return &unsupportedUploadModeError{err: err}
+		}
+	}
+	return err
+}
+
+// signingProbeBackend marks unsupported-endpoint failures of the requests
+// that start each mode, so a rejected PUT or part upload never triggers a
+// fallback.
+type signingProbeBackend struct {
+	transfer.MultipartBackend
+}
+
+func (b *signingProbeBackend) ResolveUploadURL(ctx context.Context, guid string, filename string, metadata sycommon.FileMetadata, bucket string) (string, error) {
+	resolver, ok := b.MultipartBackend.(interface {
+		ResolveUploadURL(context.Context, string, string, sycommon.FileMetadata, string) (string, error)
+	})
+	if !ok {
+		return filename, nil
+	}
+	url, err := resolver.ResolveUploadURL(ctx, guid, filename, metadata, bucket)
+	return url, markUnsupportedUploadMode(err)
+}
+
+func (b *signingProbeBackend) MultipartInit(ctx context.Context, guid string) (string, error) {
+	uploadID, err := b.MultipartBackend.MultipartInit(ctx, guid)
+	return uploadID, markUnsupportedUploadMode(err)
+}
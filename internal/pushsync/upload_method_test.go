@@ -0,0 +1,152 @@
+package pushsync
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	sycommon "github.com/calypr/syfon/client/common"
+	"github.com/calypr/syfon/client/transfer"
+)
+
+func TestUploadModeFor(t *testing.T) {
+	const threshold = 1024
+	cases := []struct {
+		name          string
+		method        string
+		size          int64
+		singleRejects bool
+		multiRejects  bool
+		want          uploadMode
+	}{
+		{name: "multipart below threshold", method: config.UploadMethodMultipart, size: 10, want: uploadSinglePut},
+		{name: "multipart at threshold", method: config.UploadMethodMultipart, size: threshold, want: uploadMultipart},
+		{name: "presigned above threshold", method: config.UploadMethodPresigned, size: 10 * threshold, want: uploadSinglePut},
+		{name: "presigned above single put limit", method: config.UploadMethodPresigned, size: singlePutLimit, want: uploadMultipart},
+		{name: "single put rejected earlier", method: config.UploadMethodPresigned, size: 10, singleRejects: true, want: uploadMultipart},
+		{name: "multipart rejected earlier", method: config.UploadMethodMultipart, size: threshold, multiRejects: true, want: uploadSinglePut},
+		{name: "multipart rejected but file too large", method: config.UploadMethodMultipart, size: singlePutLimit, multiRejects: true, want: uploadMultipart},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := &pushRuntime{Upload: config.UploadSettings{Method: tc.method}}
+			rt.singlePutUnsupported.Store(tc.singleRejects)
+			rt.multipartUnsupported.Store(tc.multiRejects)
+			if got := rt.uploadModeFor(tc.size, threshold); got != tc.want {
+				t.Fatalf("uploadModeFor(%d) = %s, want %s", tc.size, got, tc.want)
+			}
+		})
+	}
+}
+
+func newUploadMethodTest(t *testing.T, method string, backend *pushUploadBackendStub) (*pushRuntime, *drsapi.DrsObject, string) {
+	t.Helper()
+	t.Setenv("DATA_CLIENT_CACHE_DIR", t.TempDir())
+	filePath := filepath.Join(t.TempDir(), "payload.bin")
+	if err := os.WriteFile(filePath, []byte("method payload"), 0o644); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+	oldBackend := uploadBackendForRuntime
+	uploadBackendForRuntime = func(*pushRuntime) transfer.MultipartBackend { return backend }
+	t.Cleanup(func() { uploadBackendForRuntime = oldBackend })
+
+	rt := &pushRuntime{
+		Logger: drslog.NewNoOpLogger(),
+		Tuning: pushTuning{MultiPartThreshold: 4},
+		Upload: config.UploadSettings{CredentialSource: config.CredentialSourceFence, Method: method},
+	}
+	setTestPushScope(rt)
+	obj := &drsapi.DrsObject{
+		Id:        "6b4c2a4e-8d4f-5f39-9f7e-1c0f6a4a8b21",
+		Size:      14,
+		Checksums: []drsapi.Checksum{{Type: "sha256", Checksum: strings.Repeat("a", 64)}},
+	}
+	return rt, obj, filePath
+}
+
+func TestUploadFileForObjectPresignedFallsBackToMultipart(t *testing.T) {
+	backend := &pushUploadBackendStub{
+		resolveFunc: func(context.Context, string, string, sycommon.FileMetadata, string) (string, error) {
+			return "", errors.New("unexpected response: 405")
+		},
+	}
+	rt, obj, filePath := newUploadMethodTest(t, config.UploadMethodPresigned, backend)
+
+	if err := uploadFileForObject(rt, context.Background(), obj, filePath, false); err != nil {
+		t.Fatalf("uploadFileForObject returned error: %v", err)
+	}
+	if backend.resolves != 1 || backend.inits != 1 {
+		t.Fatalf("resolves=%d inits=%d, want one single-put attempt then multipart", backend.resolves, backend.inits)
+	}
+	if !rt.singlePutUnsupported.Load() {
+		t.Fatal("expected the rejected single put to be remembered")
+	}
+
+	if err := uploadFileForObject(rt, context.Background(), obj, filePath, false); err != nil {
+		t.Fatalf("second uploadFileForObject returned error: %v", err)
+	}
+	if backend.resolves != 1 || backend.inits != 2 {
+		t.Fatalf("resolves=%d inits=%d, want later uploads to skip the single put", backend.resolves, backend.inits)
+	}
+}
+
+func TestUploadFileForObjectMultipartFallsBackToSinglePut(t *testing.T) {
+	backend := &pushUploadBackendStub{
+		initFunc: func(context.Context, string) (string, error) {
+			return "", errors.New("unexpected response: 404")
+		},
+	}
+	rt, obj, filePath := newUploadMethodTest(t, config.UploadMethodMultipart, backend)
+
+	if err := uploadFileForObject(rt, context.Background(), obj, filePath, false); err != nil {
+		t.Fatalf("uploadFileForObject returned error: %v", err)
+	}
+	if backend.inits != 1 || backend.resolves != 1 {
+		t.Fatalf("inits=%d resolves=%d, want one multipart attempt then a single put", backend.inits, backend.resolves)
+	}
+	if backend.lastUpload.body != "method payload" {
+		t.Fatalf("uploaded body = %q, want method payload", backend.lastUpload.body)
+	}
+}
+
+func TestUploadFileForObjectDoesNotFallBackOnOtherErrors(t *testing.T) {
+	backend := &pushUploadBackendStub{
+		resolveFunc: func(context.Context, string, string, sycommon.FileMetadata, string) (string, error) {
+			return "", errors.New("unexpected response: 403")
+		},
+	}
+	rt, obj, filePath := newUploadMethodTest(t, config.UploadMethodPresigned, backend)
+
+	if err := uploadFileForObject(rt, context.Background(), obj, filePath, false); err == nil {
+		t.Fatal("expected a forbidden upload URL to fail the upload")
+	}
+	if backend.inits != 0 {
+		t.Fatalf("inits = %d, want no multipart fallback", backend.inits)
+	}
+}
+
+func TestUploadFileForObjectReportsBothFailedModes(t *testing.T) {
+	backend := &pushUploadBackendStub{
+		resolveFunc: func(context.Context, string, string, sycommon.FileMetadata, string) (string, error) {
+			return "", errors.New("unexpected response: 501")
+		},
+		initFunc: func(context.Context, string) (string, error) {
+			return "", errors.New("unexpected response: 405")
+		},
+	}
+	rt, obj, filePath := newUploadMethodTest(t, config.UploadMethodPresigned, backend)
+
+	err := uploadFileForObject(rt, context.Background(), obj, filePath, false)
+	if err == nil || !strings.Contains(err.Error(), "single-put upload failed") || !strings.Contains(err.Error(), "multipart fallback failed") {
+		t.Fatalf("expected both modes in the error, got %v", err)
+	}
+	if !rt.singlePutUnsupported.Load() || !rt.multipartUnsupported.Load() {
+		t.Fatal("expected both rejected modes to be remembered")
+	}
+}
@@ -0,0 +1,79 @@
+package pushsync
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// shuffleOIDs picks the verification sample; swapped in tests.
+var shuffleOIDs = func(oids []string) {
+	rand.Shuffle(len(oids), func(i, j int) { oids[i], oids[j] = oids[j], oids[i] })
+}
+
+// verifyReadable checks a sample of the objects this push registered or
+// uploaded, per rt.Verify, by signing a download URL for each and probing it.
+// Any unreadable object fails the push, so a misconfigured bucket or
+// authorization service surfaces now instead of at the first download.
+func (s *batchSyncSession) verifyReadable() error {
+	oids := make([]string, 0, len(s.registered)+len(s.uploadRequired))
+	for _, oid := range s.oids {
+		if s.registered[oid] || s.uploadRequired[oid] {
+			oids = append(oids, oid)
+		}
+	}
+	n := s.rt.Verify.SampleSize(len(oids))
+	if n == 0 {
+		return nil
+	}
+	if n < len(oids) {
+		shuffleOIDs(oids)
+		oids = oids[:n]
+		sort.Strings(oids)
+	}
+	s.rt.Logger.InfoContext(s.ctx, fmt.Sprintf("verifying %d pushed objects are readable", len(oids)))
+
+	concurrency := s.rt.Tuning.UploadConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var (
+		mu       sync.Mutex
+		failures = map[string]error{}
+	)
+	eg, egCtx := errgroup.WithContext(s.ctx)
+	eg.SetLimit(concurrency)
+	for _, oid := range oids {
+		eg.Go(func() error {
+			obj := s.drsObjByOID[oid]
+			if obj == nil {
+				return nil
+			}
+			if err := checkDownloadable(s.rt, egCtx, obj); err != nil {
+				mu.Lock()
+				failures[oid] = fmt.Errorf("%s (%s): %w", oid, obj.Id, err)
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	msgs := make([]string, 0, len(failures))
+	for _, err := range failures {
+		msgs = append(msgs, err.Error())
+	}
+	sort.Strings(msgs)
+	return fmt.Errorf("%d of %d verified objects are not readable from the remote:\n  %s", len(failures), len(oids), strings.Join(msgs, "\n  "))
+}
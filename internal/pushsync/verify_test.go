@@ -0,0 +1,80 @@
+package pushsync
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	syclient "github.com/calypr/syfon/client"
+)
+
+func TestVerifyReadableSamplesPushedObjectsAndFailsOnUnreadable(t *testing.T) {
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		did := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/ga4gh/drs/v1/objects/"), "/access/s3")
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"url":"https://signed.example/` + did + `"}`)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Request:    r,
+		}, nil
+	})}
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("syclient.New: %v", err)
+	}
+	rt := newPushRuntime(&config.GitContext{
+		Client: raw.(*syclient.Client),
+		Logger: drslog.NewNoOpLogger(),
+		Verify: config.VerifyPolicy{Count: 2},
+	})
+	var probed []string
+	rt.ProbeURL = func(_ context.Context, url string) error {
+		probed = append(probed, url)
+		if strings.HasSuffix(url, "/did-b") {
+			return errors.New("download probe failed with status 403")
+		}
+		return nil
+	}
+	rt.Tuning.UploadConcurrency = 1
+
+	origShuffle := shuffleOIDs
+	t.Cleanup(func() { shuffleOIDs = origShuffle })
+	shuffleOIDs = func(oids []string) { sort.Sort(sort.Reverse(sort.StringSlice(oids))) }
+
+	method := &[]drsapi.AccessMethod{{Type: drsapi.AccessMethodTypeS3}}
+	session := &batchSyncSession{
+		ctx:  context.Background(),
+		rt:   rt,
+		oids: []string{"a", "b", "c", "d"},
+		drsObjByOID: map[string]*drsapi.DrsObject{
+			"a": {Id: "did-a", AccessMethods: method},
+			"b": {Id: "did-b", AccessMethods: method},
+			"c": {Id: "did-c", AccessMethods: method},
+			"d": {Id: "did-d", AccessMethods: method},
+		},
+		registered:     map[string]bool{"b": true, "d": true},
+		uploadRequired: map[string]bool{"a": true},
+	}
+
+	err = session.verifyReadable()
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 verified objects are not readable") || !strings.Contains(err.Error(), "b (did-b): download probe failed") {
+		t.Fatalf("expected did-b to fail verification, got %v", err)
+	}
+	sort.Strings(probed)
+	if strings.Join(probed, ",") != "https://signed.example/did-b,https://signed.example/did-d" {
+		t.Fatalf("expected the two sampled pushed objects to be probed, got %v", probed)
+	}
+
+	rt.Verify = config.VerifyPolicy{}
+	probed = nil
+	if err := session.verifyReadable(); err != nil || len(probed) != 0 {
+		t.Fatalf("verification off should probe nothing: %v %v", err, probed)
+	}
+}
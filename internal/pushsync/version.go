@@ -0,0 +1,35 @@
+package pushsync
+
+import (
+	"fmt"
+
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// pathHistory is swapped in tests.
+var pathHistory = lfs.PathHistory
+
+// chainVersion stamps a record about to be registered with its version at
+// its path and a link to the checksum of the previous version. Versions come
+// from git history, so the chain needs no server support and replaces any
+// version copied from a reused record; history errors only skip versioning.
+func (s *batchSyncSession) chainVersion(oid string, obj *drsapi.DrsObject) {
+	s.chainVersionAt(s.filesByOID[oid].Name, oid, obj)
+}
+
+// chainVersionAt stamps obj with its version at path.
+func (s *batchSyncSession) chainVersionAt(path, oid string, obj *drsapi.DrsObject) {
+	if obj == nil {
+		return
+	}
+	revs, err := pathHistory(s.ctx, path)
+	if err != nil {
+		s.rt.Logger.DebugContext(s.ctx, fmt.Sprintf("skipping version chain for %s: %v", path, err))
+		return
+	}
+	if rev, ok := lfs.VersionOf(revs, oid); ok {
+		localdrsobject.SetVersion(obj, rev.Version, rev.Predecessor)
+	}
+}
@@ -0,0 +1,56 @@
+package pushsync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/drslog"
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestChainVersionStampsVersionAndPredecessor(t *testing.T) {
+	oldOID := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	newOID := "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	orig := pathHistory
+	t.Cleanup(func() { pathHistory = orig })
+	var asked string
+	pathHistory = func(_ context.Context, path string) ([]lfs.PathRevision, error) {
+		asked = path
+		return []lfs.PathRevision{
+			{Commit: "c2", OID: newOID, Version: 2, Predecessor: oldOID},
+			{Commit: "c1", OID: oldOID, Version: 1},
+		}, nil
+	}
+
+	session := &batchSyncSession{
+		ctx:        context.Background(),
+		rt:         &pushRuntime{Logger: drslog.NewNoOpLogger()},
+		filesByOID: map[string]lfs.LfsFileInfo{newOID: {Oid: newOID, Name: "data/sample.bam"}},
+	}
+	other := "7"
+	obj := &drsapi.DrsObject{Version: &other}
+	session.chainVersion(newOID, obj)
+
+	if asked != "data/sample.bam" {
+		t.Fatalf("history requested for %q", asked)
+	}
+	if obj.Version == nil || *obj.Version != "2" {
+		t.Fatalf("unexpected version: %v", obj.Version)
+	}
+	if got := localdrsobject.Predecessor(*obj); got != oldOID {
+		t.Fatalf("Predecessor = %q, want %q", got, oldOID)
+	}
+
+	pathHistory = func(context.Context, string) ([]lfs.PathRevision, error) {
+		return nil, errors.New("not a git repository")
+	}
+	plain := &drsapi.DrsObject{}
+	session.chainVersion(newOID, plain)
+	if plain.Version != nil || plain.Aliases != nil {
+		t.Fatalf("expected history failure to leave record unversioned: %+v", plain)
+	}
+}
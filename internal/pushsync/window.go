@@ -0,0 +1,53 @@
+package pushsync
+
+import (
+	"iter"
+	"sort"
+
+	localdrsobject "github.com/calypr/git-drs/internal/drsobject"
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+// syncWindow bounds the objects one session holds at once. A push of many
+// files runs one session per window of at most this many oids, so lookups,
+// records, registration requests and upload plans are released before the
+// next window starts and memory stays flat as the object count grows.
+// Swapped in tests and benchmarks.
+var syncWindow = 1000
+
+// windows yields files in groups of at most size oids in oid order, building
+// each group only when it is reached. Every path of an oid lands in the same
+// group, so duplicates are still seen together.
+func windows(files map[string]lfs.LfsFileInfo, size int) iter.Seq[map[string]lfs.LfsFileInfo] {
+	return func(yield func(map[string]lfs.LfsFileInfo) bool) {
+		if size <= 0 || len(files) <= size {
+			yield(files)
+			return
+		}
+		keysByOID := make(map[string][]string)
+		for key, f := range files {
+			oid := localdrsobject.NormalizeOid(f.Oid)
+			keysByOID[oid] = append(keysByOID[oid], key)
+		}
+		if len(keysByOID) <= size {
+			yield(files)
+			return
+		}
+		oids := make([]string, 0, len(keysByOID))
+		for oid := range keysByOID {
+			oids = append(oids, oid)
+		}
+		sort.Strings(oids)
+		for start := 0; start < len(oids); start += size {
+			window := map[string]lfs.LfsFileInfo{}
+			for _, oid := range oids[start:min(start+size, len(oids))] {
+				for _, key := range keysByOID[oid] {
+					window[key] = files[key]
+				}
+			}
+			if !yield(window) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package pushsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/config"
+	"github.com/calypr/git-drs/internal/drslog"
+	"github.com/calypr/git-drs/internal/hooks"
+	"github.com/calypr/git-drs/internal/lfs"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	syclient "github.com/calypr/syfon/client"
+)
+
+func TestWindowsKeepDuplicatePathsTogether(t *testing.T) {
+	files := map[string]lfs.LfsFileInfo{}
+	for i := range 5 {
+		oid := fmt.Sprintf("%064x", i)
+		files[fmt.Sprintf("a/%d.bin", i)] = lfs.LfsFileInfo{Name: fmt.Sprintf("a/%d.bin", i), Oid: oid}
+		files[fmt.Sprintf("b/%d.bin", i)] = lfs.LfsFileInfo{Name: fmt.Sprintf("b/%d.bin", i), Oid: "sha256:" + oid}
+	}
+
+	if got := slices.Collect(windows(files, 5)); len(got) != 1 || len(got[0]) != 10 {
+		t.Fatalf("windows of 5 oids = %d; want one window with every file", len(got))
+	}
+	got := slices.Collect(windows(files, 2))
+	if len(got) != 3 {
+		t.Fatalf("windows = %d; want 3 of at most 2 oids", len(got))
+	}
+	seen := 0
+	for _, window := range got {
+		oids := map[string]bool{}
+		for _, f := range window {
+			oids[strings.TrimPrefix(f.Oid, "sha256:")] = true
+		}
+		if len(oids) > 2 || len(window) != 2*len(oids) {
+			t.Fatalf("window %+v; want at most 2 oids with both paths of each", window)
+		}
+		seen += len(window)
+	}
+	if seen != len(files) {
+		t.Fatalf("windows hold %d files; want %d", seen, len(files))
+	}
+}
+
+// BenchmarkRegisterMemory registers n new objects at commit time against a
+// stub server and reports the peak heap above the starting point, with the
+// default window and with none. Windowed, what grows with n is the file list
+// and the oid index; the records, requests and responses stay bounded.
+func BenchmarkRegisterMemory(b *testing.B) {
+	usePendingDir(b)
+	origAudit, origHook, origHistory := recordAudit, runHook, pathHistory
+	b.Cleanup(func() { recordAudit, runHook, pathHistory = origAudit, origHook, origHistory })
+	pathHistory = func(context.Context, string) ([]lfs.PathRevision, error) { return nil, errors.New("no history") }
+	recordAudit = func(*slog.Logger, ...audit.Event) {}
+	runHook = func(context.Context, hooks.Payload) (hooks.Result, error) { return hooks.Result{}, nil }
+
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		status, body := http.StatusOK, `{"resolved_drs_object":[]}`
+		if strings.HasSuffix(r.URL.Path, "/objects/register") {
+			var req drsapi.RegisterObjectsJSONRequestBody
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				return nil, err
+			}
+			objects := make([]drsapi.DrsObject, len(req.Candidates))
+			for i, c := range req.Candidates {
+				objects[i] = drsapi.DrsObject{Id: fmt.Sprintf("did-%d", i), Size: c.Size, Checksums: c.Checksums}
+			}
+			data, _ := json.Marshal(drsapi.N201ObjectsCreated{Objects: objects})
+			status, body = http.StatusCreated, string(data)
+		}
+		return &http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Request:    r,
+		}, nil
+	})}
+	raw, err := syclient.New("http://example.test", syclient.WithHTTPClient(httpClient))
+	if err != nil {
+		b.Fatal(err)
+	}
+	cl := &config.GitContext{
+		Client:       raw.(*syclient.Client),
+		RemoteName:   "origin",
+		Organization: "syfon",
+		ProjectId:    "bench",
+		BucketName:   "bench-bucket",
+		Logger:       drslog.NewNoOpLogger(),
+	}
+
+	// Registration stats each file, so the worktree holds the largest set.
+	sizes := []int{1_000, 10_000, 50_000}
+	dir := b.TempDir()
+	for i := range sizes[len(sizes)-1] {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.bin", i)), []byte("abc"), 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	origWindow := syncWindow
+	b.Cleanup(func() { syncWindow = origWindow })
+	for _, window := range []int{origWindow, 0} {
+		for _, n := range sizes {
+			b.Run(fmt.Sprintf("window=%d/objects=%d", window, n), func(b *testing.B) {
+				syncWindow = window
+				for range b.N {
+					files := make(map[string]lfs.LfsFileInfo, n)
+					for i := range n {
+						name := filepath.Join(dir, fmt.Sprintf("%d.bin", i))
+						files[name] = lfs.LfsFileInfo{Name: name, Oid: fmt.Sprintf("%064x", i), Size: 3}
+					}
+					peak := trackPeakHeap()
+					if err := registerFiles(cl, context.Background(), files); err != nil {
+						b.Fatal(err)
+					}
+					b.ReportMetric(float64(peak())/(1<<20), "peak-MB")
+				}
+			})
+		}
+	}
+}
+
+// trackPeakHeap samples the live heap until the returned func is called,
+// which returns the peak seen above the heap at the start.
+func trackPeakHeap() func() uint64 {
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	base := m.HeapAlloc
+	var peak atomic.Uint64
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		var m runtime.MemStats
+		for {
+			runtime.ReadMemStats(&m)
+			if m.HeapAlloc > base && m.HeapAlloc-base > peak.Load() {
+				peak.Store(m.HeapAlloc - base)
+			}
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}()
+	return func() uint64 {
+		close(done)
+		<-stopped
+		return peak.Load()
+	}
+}
@@ -0,0 +1,140 @@
+// Package registerpolicy decides which LFS-tracked paths get DRS records.
+// Paths it excludes stay in plain LFS storage: pre-push stages no metadata
+// for them, the committed path map has no entry for them, and git drs push
+// hands them to git lfs push instead of registering them.
+package registerpolicy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+// Policy holds the configured patterns. A path is registered when it matches
+// no Exclude pattern and, if any Include patterns are set, at least one of
+// them. The zero Policy registers everything.
+//
+// Patterns follow .gitignore conventions: a pattern without a slash, such as
+// "*.tmp", matches a file or directory name at any depth; a pattern with a
+// slash, such as "data/**" or "/raw/*.bam", is matched from the repository
+// root; "**" matches any number of directories; a trailing slash matches
+// everything under a directory.
+type Policy struct {
+	Include []string
+	Exclude []string
+}
+
+// ParsePatterns splits a comma- or space-separated pattern list and rejects
+// malformed globs.
+func ParsePatterns(raw string) ([]string, error) {
+	var out []string
+	for _, item := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' }) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		for _, seg := range strings.Split(normalizePattern(item), "/") {
+			if _, err := path.Match(seg, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", item, err)
+			}
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// Enabled reports whether any pattern is configured.
+func (p Policy) Enabled() bool {
+	return len(p.Include) > 0 || len(p.Exclude) > 0
+}
+
+// Registers reports whether the repo-relative path should get a DRS record.
+func (p Policy) Registers(file string) bool {
+	file = strings.TrimPrefix(path.Clean(strings.ReplaceAll(file, "\\", "/")), "./")
+	for _, pattern := range p.Exclude {
+		if Match(pattern, file) {
+			return false
+		}
+	}
+	if len(p.Include) == 0 {
+		return true
+	}
+	for _, pattern := range p.Include {
+		if Match(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter partitions LFS files, keyed by repo path, into those to register and
+// those to leave in LFS.
+func (p Policy) Filter(files map[string]lfs.LfsFileInfo) (registered, excluded map[string]lfs.LfsFileInfo) {
+	if !p.Enabled() {
+		return files, map[string]lfs.LfsFileInfo{}
+	}
+	registered = make(map[string]lfs.LfsFileInfo, len(files))
+	excluded = make(map[string]lfs.LfsFileInfo)
+	for file, info := range files {
+		if p.Registers(file) {
+			registered[file] = info
+		} else {
+			excluded[file] = info
+		}
+	}
+	return registered, excluded
+}
+
+// Match reports whether the repo-relative path matches pattern.
+func Match(pattern, file string) bool {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	segs := strings.Split(normalizePattern(pattern), "/")
+	parts := strings.Split(file, "/")
+	if !anchored {
+		// A trailing slash only matches directories, never the file itself.
+		names := parts
+		if strings.HasSuffix(pattern, "/") {
+			names = parts[:len(parts)-1]
+		}
+		for _, part := range names {
+			if ok, _ := path.Match(segs[0], part); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return matchSegments(segs, parts)
+}
+
+// normalizePattern strips a leading "./" or "/" and turns a trailing slash
+// into "/**".
+func normalizePattern(pattern string) string {
+	pattern = strings.TrimPrefix(strings.TrimPrefix(pattern, "./"), "/")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return pattern
+}
+
+func matchSegments(segs, parts []string) bool {
+	for len(segs) > 0 {
+		if segs[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(segs[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(segs[0], parts[0]); !ok {
+			return false
+		}
+		segs, parts = segs[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
@@ -0,0 +1,72 @@
+package registerpolicy
+
+import (
+	"testing"
+
+	"github.com/calypr/git-drs/internal/lfs"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, file string
+		want          bool
+	}{
+		{"*.tmp", "scratch.tmp", true},
+		{"*.tmp", "a/b/scratch.tmp", true},
+		{"*.tmp", "a/b/scratch.bam", false},
+		{"scratch", "scratch/run1/out.bam", true},
+		{"scratch/", "scratch/run1/out.bam", true},
+		{"scratch/", "data/scratch", false},
+		{"data/**", "data/x.bam", true},
+		{"data/**", "data/sub/x.bam", true},
+		{"data/**", "other/data/x.bam", false},
+		{"/raw/*.bam", "raw/x.bam", true},
+		{"/raw/*.bam", "raw/sub/x.bam", false},
+		{"**/cache/*", "a/b/cache/x", true},
+		{"**/cache/*", "cache/x", true},
+		{"./data/*.vcf.gz", "data/calls.vcf.gz", true},
+	}
+	for _, tc := range cases {
+		if got := Match(tc.pattern, tc.file); got != tc.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tc.pattern, tc.file, got, tc.want)
+		}
+	}
+}
+
+func TestPolicyRegisters(t *testing.T) {
+	p := Policy{Include: []string{"data/**"}, Exclude: []string{"*.tmp"}}
+	if !p.Registers("data/a.bam") {
+		t.Fatal("expected included path to register")
+	}
+	if p.Registers("data/a.tmp") {
+		t.Fatal("exclude should win over include")
+	}
+	if p.Registers("docs/a.pdf") {
+		t.Fatal("expected path outside includes to be skipped")
+	}
+	if !(Policy{}).Registers("anything.bin") {
+		t.Fatal("zero policy should register everything")
+	}
+
+	registered, excluded := p.Filter(map[string]lfs.LfsFileInfo{
+		"data/a.bam": {Oid: "a"},
+		"notes.tmp":  {Oid: "b"},
+		"b.bam":      {Oid: "c"},
+	})
+	if len(registered) != 1 || registered["data/a.bam"].Oid != "a" || len(excluded) != 2 {
+		t.Fatalf("unexpected split: %v / %v", registered, excluded)
+	}
+}
+
+func TestParsePatterns(t *testing.T) {
+	got, err := ParsePatterns("*.tmp, data/** scratch/")
+	if err != nil {
+		t.Fatalf("ParsePatterns error: %v", err)
+	}
+	if len(got) != 3 || got[0] != "*.tmp" || got[1] != "data/**" || got[2] != "scratch/" {
+		t.Fatalf("unexpected patterns: %v", got)
+	}
+	if _, err := ParsePatterns("data/[a-"); err == nil {
+		t.Fatal("expected malformed glob to be rejected")
+	}
+}
@@ -0,0 +1,158 @@
+// Package release freezes the DRS objects a commit references into a
+// manifest under .drs/releases, so a publication can cite an exact set of
+// data that later pushes cannot change.
+//
+// A manifest is sealed: Digest is the sha256 of the manifest with Digest
+// cleared, so editing any field after creation is detected by Check.
+package release
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Dir is the repo-relative directory holding release manifests.
+const Dir = ".drs/releases"
+
+// Object is one tracked file in a release.
+type Object struct {
+	Path  string `json:"path"`
+	OID   string `json:"oid"`
+	Size  int64  `json:"size"`
+	DRSID string `json:"drs_id"`
+}
+
+// Manifest records the DRS objects referenced by Commit. BundleID is set
+// when the release was also registered as a DRS bundle, and Signed when the
+// commit adding the manifest was signed.
+type Manifest struct {
+	Name         string   `json:"name"`
+	Commit       string   `json:"commit"`
+	Created      string   `json:"created"`
+	Remote       string   `json:"remote"`
+	Organization string   `json:"organization,omitempty"`
+	Project      string   `json:"project,omitempty"`
+	BundleID     string   `json:"bundle_id,omitempty"`
+	Signed       bool     `json:"signed"`
+	TotalBytes   int64    `json:"total_bytes"`
+	Objects      []Object `json:"objects"`
+	Digest       string   `json:"digest"`
+}
+
+// ErrExists is returned by Write when the release already has a manifest.
+var ErrExists = errors.New("release already exists")
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidateName rejects names that cannot be used as a manifest file name.
+func ValidateName(name string) error {
+	if !validName.MatchString(name) || strings.Contains(name, "..") {
+		return fmt.Errorf("invalid release name %q: use letters, digits, '.', '_', and '-'", name)
+	}
+	return nil
+}
+
+// Path returns the manifest path for name in the repository at root.
+func Path(root, name string) string {
+	return filepath.Join(root, Dir, name+".json")
+}
+
+// Seal sorts the objects by path, totals their sizes, and sets Digest.
+func (m *Manifest) Seal() error {
+	sort.Slice(m.Objects, func(i, j int) bool { return m.Objects[i].Path < m.Objects[j].Path })
+	m.TotalBytes = 0
+	for _, o := range m.Objects {
+		m.TotalBytes += o.Size
+	}
+	digest, err := m.digest()
+	if err != nil {
+		return err
+	}
+	m.Digest = digest
+	return nil
+}
+
+// Check reports whether the manifest still matches its digest.
+func (m Manifest) Check() error {
+	if m.Digest == "" {
+		return errors.New("manifest has no digest")
+	}
+	digest, err := m.digest()
+	if err != nil {
+		return err
+	}
+	if digest != m.Digest {
+		return fmt.Errorf("manifest digest mismatch: recorded %s, computed %s", m.Digest, digest)
+	}
+	return nil
+}
+
+func (m Manifest) digest() (string, error) {
+	m.Digest = ""
+	if m.Objects == nil {
+		m.Objects = []Object{}
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// BundleChecksum is the DRS bundle checksum of the release: the sha256 of
+// the sorted, concatenated sha256 checksums of its objects.
+func (m Manifest) BundleChecksum() string {
+	sums := make([]string, 0, len(m.Objects))
+	for _, o := range m.Objects {
+		sums = append(sums, o.OID)
+	}
+	sort.Strings(sums)
+	sum := sha256.Sum256([]byte(strings.Join(sums, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Write creates the manifest at path and fails with ErrExists rather than
+// replace one: a release never changes once created.
+func Write(path string, m Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("release: mkdir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%s: %w", path, ErrExists)
+		}
+		return fmt.Errorf("release: create manifest: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("release: write manifest: %w", err)
+	}
+	return f.Close()
+}
+
+// Read loads the manifest at path.
+func Read(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, fmt.Errorf("release: parse %s: %w", path, err)
+	}
+	return m, nil
+}
@@ -0,0 +1,72 @@
+package release
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSealCheckAndWrite(t *testing.T) {
+	m := Manifest{
+		Name:   "v1.0",
+		Commit: strings.Repeat("1", 40),
+		Remote: "origin",
+		Objects: []Object{
+			{Path: "b.bin", OID: strings.Repeat("b", 64), Size: 20, DRSID: "drs-b"},
+			{Path: "a.bin", OID: strings.Repeat("a", 64), Size: 10, DRSID: "drs-a"},
+		},
+	}
+	if err := m.Seal(); err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if m.Objects[0].Path != "a.bin" || m.TotalBytes != 30 || m.Digest == "" {
+		t.Fatalf("sealed manifest = %+v", m)
+	}
+
+	path := Path(t.TempDir(), m.Name)
+	if err := Write(path, m); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := Write(path, m); !errors.Is(err, ErrExists) {
+		t.Fatalf("second Write = %v, want ErrExists", err)
+	}
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if err := got.Check(); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	got.Objects[1].DRSID = "drs-other"
+	if err := got.Check(); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("Check after edit = %v, want a digest mismatch", err)
+	}
+}
+
+func TestBundleChecksumIgnoresOrder(t *testing.T) {
+	a := Manifest{Objects: []Object{{OID: "aa"}, {OID: "bb"}}}
+	b := Manifest{Objects: []Object{{OID: "bb"}, {OID: "aa"}}}
+	// sha256("aabb")
+	const want = "486b34250bd4400c0aa90516fce9a9c0633a922eb40d0828cf299bc4e825acf4"
+	if a.BundleChecksum() != want || b.BundleChecksum() != want {
+		t.Fatalf("bundle checksums = %s, %s; want %s", a.BundleChecksum(), b.BundleChecksum(), want)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, ok := range []string{"v1.0", "2026-release", "rc_1"} {
+		if err := ValidateName(ok); err != nil {
+			t.Errorf("ValidateName(%q) = %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"", "../v1", "a/b", ".hidden", "v1..2"} {
+		if err := ValidateName(bad); err == nil {
+			t.Errorf("ValidateName(%q) succeeded, want an error", bad)
+		}
+	}
+	if filepath.Base(Path("/repo", "v1.0")) != "v1.0.json" {
+		t.Fatalf("Path = %s", Path("/repo", "v1.0"))
+	}
+}
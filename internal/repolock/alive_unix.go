@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package repolock
+
+import (
+	"errors"
+	"syscall"
+)
+
+// pidAlive reports whether a process with pid exists. EPERM means it exists
+// but belongs to another user.
+func pidAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,242 @@
+// Package repolock serializes git-drs commands that write repository state
+// under .git/drs, so two pushes, or a push and a pre-commit hook, cannot
+// interleave writes to the object store and caches.
+//
+// The lock is an advisory file created with O_EXCL. It records the holder's
+// pid, host, and command; a lock whose process is gone, or which is older
+// than StaleAfter, is taken over. Child processes started while the lock is
+// held (for example the pre-push hook run by git drs push --with-hooks)
+// inherit TokenEnv and share the parent's lock instead of waiting on it.
+package repolock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TokenEnv carries the held lock's token to child processes.
+const TokenEnv = "GIT_DRS_LOCK_TOKEN"
+
+// StaleAfter is how old a lock must be before it is taken over when its
+// holder cannot be checked, such as a holder on another host.
+const StaleAfter = 12 * time.Hour
+
+// pollInterval is how often a waiting Acquire retries.
+var pollInterval = 250 * time.Millisecond
+
+// Swapped in tests.
+var (
+	hostname     = os.Hostname
+	now          = time.Now
+	processAlive = pidAlive
+)
+
+// Holder describes the process that holds a lock.
+type Holder struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	Command   string    `json:"command"`
+	Token     string    `json:"token"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// HeldError is returned when another live process holds the lock.
+type HeldError struct {
+	Path   string
+	Holder Holder
+}
+
+func (e *HeldError) Error() string {
+	h := e.Holder
+	age := now().Sub(h.StartedAt).Round(time.Second)
+	return fmt.Sprintf("another git-drs operation holds the repository lock: %q (pid %d on %s, started %s ago)."+
+		"\nRerun with --wait to wait for it, or remove %s if that process is no longer running.",
+		h.Command, h.PID, h.Host, age, e.Path)
+}
+
+// Lock is a held repository lock.
+type Lock struct {
+	path   string
+	token  string
+	shared bool
+}
+
+// Options controls Acquire.
+type Options struct {
+	// Command names the operation in diagnostics shown to other processes.
+	Command string
+	// Wait blocks until the lock is free or ctx is done instead of failing
+	// with *HeldError.
+	Wait bool
+	// OnWait, when set, is called once if Acquire has to wait.
+	OnWait func(Holder)
+}
+
+// Acquire takes the lock file at path.
+func Acquire(ctx context.Context, path string, opts Options) (*Lock, error) {
+	if token := strings.TrimSpace(os.Getenv(TokenEnv)); token != "" {
+		if h, err := readHolder(path); err == nil && h.Token == token {
+			return &Lock{path: path, token: token, shared: true}, nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create lock directory: %w", err)
+	}
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	host, _ := hostname()
+	self := Holder{PID: os.Getpid(), Host: host, Command: opts.Command, Token: token}
+
+	waited := false
+	for {
+		self.StartedAt = now().UTC()
+		err := create(path, self)
+		if err == nil {
+			return &Lock{path: path, token: token}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("create lock %s: %w", path, err)
+		}
+
+		holder, isStale, readErr := inspect(path, host)
+		if errors.Is(readErr, os.ErrNotExist) {
+			continue
+		}
+		if isStale {
+			if err := takeOver(path, token, host); err != nil {
+				return nil, fmt.Errorf("take over stale lock %s: %w", path, err)
+			}
+			continue
+		}
+		if readErr != nil {
+			holder = Holder{Command: "unknown", StartedAt: now()}
+		}
+
+		if !opts.Wait {
+			return nil, &HeldError{Path: path, Holder: holder}
+		}
+		if !waited && opts.OnWait != nil {
+			opts.OnWait(holder)
+		}
+		waited = true
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for repository lock held by %q (pid %d): %w", holder.Command, holder.PID, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Token is the value child processes need in TokenEnv to share the lock.
+func (l *Lock) Token() string {
+	if l == nil {
+		return ""
+	}
+	return l.token
+}
+
+// Release removes the lock file unless it was shared from a parent process
+// or has since been taken over by another process.
+func (l *Lock) Release() error {
+	if l == nil || l.shared {
+		return nil
+	}
+	h, err := readHolder(l.path)
+	if err != nil || h.Token != l.token {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// inspect reads the lock file at path and reports whether it is stale. A
+// holder that crashed mid-write leaves an unreadable file; it is stale once
+// it is clearly not being written.
+func inspect(path, host string) (Holder, bool, error) {
+	h, err := readHolder(path)
+	if err == nil {
+		return h, stale(h, host), nil
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return h, false, err
+	}
+	info, statErr := os.Stat(path)
+	return h, statErr == nil && now().Sub(info.ModTime()) > time.Minute, err
+}
+
+// takeOver moves a stale lock file aside under a name only this process
+// uses and deletes it only if it is still stale there. Two processes that
+// both found the lock stale would otherwise each remove it, the second
+// deleting the lock the first had just created. A live lock moved aside by
+// mistake is linked back into place, which fails rather than overwrite a
+// lock created in the meantime.
+func takeOver(path, token, host string) error {
+	aside := path + ".stale-" + token
+	if err := os.Rename(path, aside); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer os.Remove(aside)
+	if _, isStale, err := inspect(aside, host); isStale || errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := os.Link(aside, path); err != nil && !errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("restore lock: %w", err)
+	}
+	return nil
+}
+
+func stale(h Holder, host string) bool {
+	if h.Host == host && h.PID > 0 {
+		return !processAlive(h.PID)
+	}
+	return now().Sub(h.StartedAt) > StaleAfter
+}
+
+func create(path string, h Holder) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	werr := json.NewEncoder(f).Encode(h)
+	cerr := f.Close()
+	if werr != nil || cerr != nil {
+		_ = os.Remove(path)
+		return errors.Join(werr, cerr)
+	}
+	return nil
+}
+
+func readHolder(path string) (Holder, error) {
+	var h Holder
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return h, err
+	}
+	if err := json.Unmarshal(b, &h); err != nil {
+		return h, fmt.Errorf("parse lock %s: %w", path, err)
+	}
+	return h, nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
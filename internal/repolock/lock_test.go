@@ -0,0 +1,182 @@
+package repolock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func lockPath(t *testing.T) string {
+	t.Helper()
+	return filepath.Join(t.TempDir(), "drs", "lock")
+}
+
+func writeHolder(t *testing.T, path string, h Holder) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(h)
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquireAndRelease(t *testing.T) {
+	t.Setenv(TokenEnv, "")
+	path := lockPath(t)
+	lock, err := Acquire(context.Background(), path, Options{Command: "git drs push"})
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	h, err := readHolder(path)
+	if err != nil || h.PID != os.Getpid() || h.Command != "git drs push" || h.Token != lock.Token() {
+		t.Fatalf("holder = %+v, %v", h, err)
+	}
+
+	_, err = Acquire(context.Background(), path, Options{Command: "git drs pull"})
+	var held *HeldError
+	if !errors.As(err, &held) || held.Holder.Command != "git drs push" {
+		t.Fatalf("second Acquire error = %v, want *HeldError naming push", err)
+	}
+	if !strings.Contains(err.Error(), "--wait") || !strings.Contains(err.Error(), path) {
+		t.Fatalf("diagnostic = %q", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("lock file still present after Release: %v", err)
+	}
+}
+
+func TestAcquireTakesOverStaleLocks(t *testing.T) {
+	t.Setenv(TokenEnv, "")
+	origAlive := processAlive
+	t.Cleanup(func() { processAlive = origAlive })
+	processAlive = func(pid int) bool { return pid != 999999 }
+	host, _ := hostname()
+
+	cases := map[string]Holder{
+		"dead process on this host": {PID: 999999, Host: host, Command: "git drs push", Token: "old", StartedAt: time.Now()},
+		"old lock on another host":  {PID: 1, Host: "elsewhere", Command: "git drs push", Token: "old", StartedAt: time.Now().Add(-2 * StaleAfter)},
+	}
+	for name, h := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := lockPath(t)
+			writeHolder(t, path, h)
+			lock, err := Acquire(context.Background(), path, Options{Command: "git drs pull"})
+			if err != nil {
+				t.Fatalf("Acquire: %v", err)
+			}
+			_ = lock.Release()
+		})
+	}
+
+	path := lockPath(t)
+	writeHolder(t, path, Holder{PID: 1, Host: "elsewhere", Command: "git drs push", StartedAt: time.Now()})
+	if _, err := Acquire(context.Background(), path, Options{}); err == nil {
+		t.Fatal("took over a fresh lock from another host")
+	}
+}
+
+func TestTakeOverKeepsLockCreatedSinceStaleCheck(t *testing.T) {
+	t.Setenv(TokenEnv, "")
+	origAlive := processAlive
+	t.Cleanup(func() { processAlive = origAlive })
+	processAlive = func(pid int) bool { return pid != 999999 }
+	host, _ := hostname()
+
+	path := lockPath(t)
+	writeHolder(t, path, Holder{PID: 999999, Host: host, Command: "git drs push", Token: "old", StartedAt: time.Now()})
+	first, err := Acquire(context.Background(), path, Options{Command: "git drs pull"})
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	// A second process that judged the dead holder's lock stale before the
+	// first took it over gets here only now.
+	if err := takeOver(path, "late", host); err != nil {
+		t.Fatalf("takeOver: %v", err)
+	}
+	h, err := readHolder(path)
+	if err != nil || h.Token != first.Token() {
+		t.Fatalf("holder after late takeover = %+v, %v; want the first acquirer's lock", h, err)
+	}
+	if _, err := os.Stat(path + ".stale-late"); !os.IsNotExist(err) {
+		t.Fatalf("aside file left behind: %v", err)
+	}
+	if err := first.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+}
+
+func TestAcquireWaitsForRelease(t *testing.T) {
+	t.Setenv(TokenEnv, "")
+	origPoll := pollInterval
+	t.Cleanup(func() { pollInterval = origPoll })
+	pollInterval = 5 * time.Millisecond
+
+	path := lockPath(t)
+	first, err := Acquire(context.Background(), path, Options{Command: "git drs push"})
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	waited := make(chan Holder, 1)
+	done := make(chan error, 1)
+	go func() {
+		lock, err := Acquire(context.Background(), path, Options{Wait: true, OnWait: func(h Holder) { waited <- h }})
+		if err == nil {
+			err = lock.Release()
+		}
+		done <- err
+	}()
+	if h := <-waited; h.Command != "git drs push" {
+		t.Fatalf("OnWait holder = %+v", h)
+	}
+	if err := first.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("waiting Acquire: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiting Acquire did not return after release")
+	}
+
+	held, _ := Acquire(context.Background(), path, Options{})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := Acquire(ctx, path, Options{Wait: true}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire with expired context = %v", err)
+	}
+	_ = held.Release()
+}
+
+func TestAcquireSharesLockWithChildProcess(t *testing.T) {
+	t.Setenv(TokenEnv, "")
+	path := lockPath(t)
+	parent, err := Acquire(context.Background(), path, Options{Command: "git drs push"})
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	t.Setenv(TokenEnv, parent.Token())
+	child, err := Acquire(context.Background(), path, Options{Command: "git drs pre-push-prepare"})
+	if err != nil {
+		t.Fatalf("child Acquire: %v", err)
+	}
+	if err := child.Release(); err != nil {
+		t.Fatalf("child Release: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("child Release removed the parent's lock: %v", err)
+	}
+	_ = parent.Release()
+}
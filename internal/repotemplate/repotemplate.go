@@ -0,0 +1,176 @@
+// Package repotemplate holds the repository profiles git drs init --profile
+// applies: curated LFS tracking patterns, ignore rules and git-drs settings
+// for a kind of data, embedded in the binary or loaded from a URL or file.
+package repotemplate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/calypr/git-drs/internal/httpclient"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed profiles/*.yaml
+var builtin embed.FS
+
+// maxProfileSize bounds a profile read from a URL or file.
+const maxProfileSize = 1 << 20
+
+// httpClient fetches profiles by URL; swapped in tests.
+var httpClient = httpclient.New(30 * time.Second)
+
+// Profile is one repository template.
+type Profile struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Track lists patterns stored through git-drs, as git drs track takes
+	// them.
+	Track []string `yaml:"track"`
+	// Ignore lists .gitignore lines.
+	Ignore []string `yaml:"ignore"`
+	// Config maps git config keys, with or without the drs. prefix, to a
+	// value or a list of values.
+	Config map[string]Values `yaml:"config"`
+}
+
+// Values is a config value that may be written as a scalar or a list.
+type Values []string
+
+// UnmarshalYAML accepts a scalar or a sequence of scalars.
+func (v *Values) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*v = Values{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*v = list
+	return nil
+}
+
+// Names returns the built-in profile names.
+func Names() []string {
+	entries, _ := builtin.ReadDir("profiles")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load returns the built-in profile named ref, or reads one from ref when it
+// is an http(s) URL or a path to a YAML file.
+func Load(ctx context.Context, ref string) (Profile, error) {
+	ref = strings.TrimSpace(ref)
+	var (
+		data []byte
+		err  error
+	)
+	switch {
+	case strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://"):
+		data, err = fetch(ctx, ref)
+	case strings.ContainsAny(ref, `/\`) || path.Ext(ref) == ".yaml" || path.Ext(ref) == ".yml":
+		data, err = readFile(ref)
+	default:
+		data, err = builtin.ReadFile("profiles/" + ref + ".yaml")
+		if err != nil {
+			return Profile{}, fmt.Errorf("unknown profile %q; built-in profiles are %s, or give a URL or YAML file", ref, strings.Join(Names(), ", "))
+		}
+	}
+	if err != nil {
+		return Profile{}, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes a profile, rejecting unknown fields so a typo does not
+// silently drop part of it.
+func Parse(data []byte) (Profile, error) {
+	var p Profile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && err != io.EOF {
+		return Profile{}, fmt.Errorf("invalid profile: %w", err)
+	}
+	for _, pattern := range p.Track {
+		if strings.TrimSpace(pattern) == "" {
+			return Profile{}, fmt.Errorf("invalid profile: empty track pattern")
+		}
+	}
+	return p, nil
+}
+
+func fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch profile: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("fetch profile %s: %s", url, resp.Status)
+	}
+	return readLimited(resp.Body, url)
+}
+
+func readFile(name string) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("read profile: %w", err)
+	}
+	defer f.Close()
+	return readLimited(f, name)
+}
+
+func readLimited(r io.Reader, name string) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxProfileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read profile %s: %w", name, err)
+	}
+	if len(data) > maxProfileSize {
+		return nil, fmt.Errorf("profile %s is larger than %d bytes", name, maxProfileSize)
+	}
+	return data, nil
+}
+
+// MergeIgnore returns existing .gitignore content with each of lines that
+// it does not already contain appended, and the lines that were added.
+func MergeIgnore(existing []byte, lines []string) ([]byte, []string) {
+	have := make(map[string]bool)
+	sc := bufio.NewScanner(bytes.NewReader(existing))
+	for sc.Scan() {
+		have[strings.TrimSpace(sc.Text())] = true
+	}
+	var added []string
+	out := bytes.NewBuffer(append([]byte(nil), existing...))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || have[line] {
+			continue
+		}
+		if len(added) == 0 && out.Len() > 0 && !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+			out.WriteByte('\n')
+		}
+		out.WriteString(line + "\n")
+		have[line] = true
+		added = append(added, line)
+	}
+	return out.Bytes(), added
+}
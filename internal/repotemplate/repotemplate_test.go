@@ -0,0 +1,82 @@
+package repotemplate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinProfilesLoad(t *testing.T) {
+	names := Names()
+	if !reflect.DeepEqual(names, []string{"genomics", "imaging", "minimal"}) {
+		t.Fatalf("unexpected built-in profiles: %v", names)
+	}
+	for _, name := range names {
+		p, err := Load(context.Background(), name)
+		if err != nil {
+			t.Fatalf("Load(%s): %v", name, err)
+		}
+		if p.Name != name || p.Description == "" {
+			t.Fatalf("profile %s has name %q and description %q", name, p.Name, p.Description)
+		}
+	}
+	if _, err := Load(context.Background(), "astronomy"); err == nil || !strings.Contains(err.Error(), "genomics, imaging, minimal") {
+		t.Fatalf("expected an unknown profile error listing the built-ins, got %v", err)
+	}
+}
+
+func TestParseAcceptsScalarAndListValues(t *testing.T) {
+	p, err := Parse([]byte("name: lab\nconfig:\n  push.verify: all\n  storage.class:\n    - \"*.bam=GLACIER\"\n    - \"*.vcf=STANDARD_IA\"\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !reflect.DeepEqual(p.Config["push.verify"], Values{"all"}) || len(p.Config["storage.class"]) != 2 {
+		t.Fatalf("unexpected config: %#v", p.Config)
+	}
+	if _, err := Parse([]byte("name: lab\ntracks:\n  - \"*.bam\"\n")); err == nil {
+		t.Fatalf("expected a misspelled field to be rejected")
+	}
+}
+
+func TestLoadFromURLAndFile(t *testing.T) {
+	body := "name: remote-lab\ntrack:\n  - \"*.raw\"\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/lab.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	p, err := Load(context.Background(), srv.URL+"/lab.yaml")
+	if err != nil || p.Name != "remote-lab" || len(p.Track) != 1 {
+		t.Fatalf("Load(url) = %+v, %v", p, err)
+	}
+	if _, err := Load(context.Background(), srv.URL+"/missing.yaml"); err == nil {
+		t.Fatalf("expected a 404 to fail")
+	}
+
+	file := filepath.Join(t.TempDir(), "lab.yaml")
+	if err := os.WriteFile(file, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := Load(context.Background(), file); err != nil || p.Name != "remote-lab" {
+		t.Fatalf("Load(file) = %+v, %v", p, err)
+	}
+}
+
+func TestMergeIgnore(t *testing.T) {
+	merged, added := MergeIgnore([]byte("build/\n*.tmp"), []string{"*.tmp", "work/", "", "work/"})
+	if string(merged) != "build/\n*.tmp\nwork/\n" || !reflect.DeepEqual(added, []string{"work/"}) {
+		t.Fatalf("MergeIgnore = %q, %v", merged, added)
+	}
+	if merged, added := MergeIgnore(nil, []string{".DS_Store"}); string(merged) != ".DS_Store\n" || len(added) != 1 {
+		t.Fatalf("MergeIgnore on empty = %q, %v", merged, added)
+	}
+}
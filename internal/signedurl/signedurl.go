@@ -0,0 +1,149 @@
+// Package signedurl caches signed DRS access URLs until shortly before they
+// expire and parses the expiry of presigned storage URLs.
+package signedurl
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// refreshMargin is how long before expiry a cached URL is
+	// considered stale, so long downloads never start on a dying URL.
+	refreshMargin = 2 * time.Minute
+	// defaultTTL bounds reuse of URLs whose expiry cannot be parsed.
+	defaultTTL = 5 * time.Minute
+)
+
+// Key identifies a signed URL by the remote that signed it, the DRS object,
+// and the access method it was signed for. The same object ID can name
+// different objects on different remotes, and each access method signs a
+// different replica.
+type Key struct {
+	Remote   string
+	ObjectID string
+	AccessID string
+}
+
+// Cache caches resolved access URLs until shortly before they expire. It is
+// safe for concurrent use by download workers, and a nil Cache caches
+// nothing.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[Key]cachedURL
+	now     func() time.Time
+}
+
+type cachedURL struct {
+	access    drsapi.AccessURL
+	expiresAt time.Time
+}
+
+// NewCache returns an empty cache.
+func NewCache() *Cache {
+	return &Cache{entries: map[Key]cachedURL{}, now: time.Now}
+}
+
+// Get returns a cached URL for key when it is not within the refresh margin
+// of its expiry.
+func (c *Cache) Get(key Key) (drsapi.AccessURL, bool) {
+	if c == nil {
+		return drsapi.AccessURL{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return drsapi.AccessURL{}, false
+	}
+	if !c.now().Add(refreshMargin).Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return drsapi.AccessURL{}, false
+	}
+	return entry.access, true
+}
+
+// Put stores access under key, deriving its expiry from the signed URL.
+func (c *Cache) Put(key Key, access drsapi.AccessURL) {
+	if c == nil || strings.TrimSpace(key.ObjectID) == "" || strings.TrimSpace(access.Url) == "" {
+		return
+	}
+	now := c.now()
+	expiresAt, ok := Expiry(access.Url, now)
+	if !ok {
+		expiresAt = now.Add(defaultTTL)
+	}
+	c.mu.Lock()
+	c.entries[key] = cachedURL{access: access, expiresAt: expiresAt}
+	c.mu.Unlock()
+}
+
+// Forget drops every URL cached for an object on remote, e.g. after a
+// download from one of them is rejected.
+func (c *Cache) Forget(remote, objectID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if key.Remote == remote && key.ObjectID == objectID {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Expiry derives the expiry of a presigned URL. It understands AWS
+// SigV4 (X-Amz-Date + X-Amz-Expires), GCS V4 (X-Goog-Date + X-Goog-Expires),
+// SigV2-style Expires epochs, Azure SAS "se", and JWT "token" query params.
+// issuedAt is used when a relative expiry has no signing date.
+func Expiry(rawURL string, issuedAt time.Time) (time.Time, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return time.Time{}, false
+	}
+	q := caseInsensitiveQuery(u.Query())
+
+	for _, prefix := range []string{"x-amz-", "x-goog-"} {
+		if secs, err := strconv.ParseInt(q[prefix+"expires"], 10, 64); err == nil {
+			signedAt := issuedAt
+			if d, err := time.Parse("20060102T150405Z", q[prefix+"date"]); err == nil {
+				signedAt = d
+			}
+			return signedAt.Add(time.Duration(secs) * time.Second), true
+		}
+	}
+	if epoch, err := strconv.ParseInt(q["expires"], 10, 64); err == nil {
+		return time.Unix(epoch, 0), true
+	}
+	if se := q["se"]; se != "" {
+		if t, err := time.Parse(time.RFC3339, se); err == nil {
+			return t, true
+		}
+	}
+	if token := q["token"]; token != "" {
+		claims := jwt.MapClaims{}
+		if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err == nil {
+			if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+				return exp.Time, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func caseInsensitiveQuery(values url.Values) map[string]string {
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		if len(v) > 0 {
+			out[strings.ToLower(k)] = v[0]
+		}
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package signedurl
+
+import (
+	"testing"
+	"time"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestExpiry(t *testing.T) {
+	issued := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": issued.Add(time.Hour).Unix()}).SignedString([]byte("k"))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		want time.Time
+		ok   bool
+	}{
+		{"sigv4", "https://b.s3.amazonaws.com/k?X-Amz-Date=20240102T030405Z&X-Amz-Expires=900", issued.Add(15 * time.Minute), true},
+		{"gcs v4", "https://storage.googleapis.com/b/k?X-Goog-Date=20240102T030405Z&X-Goog-Expires=60", issued.Add(time.Minute), true},
+		{"sigv2 epoch", "https://b.example/k?Expires=1704164645", time.Unix(1704164645, 0), true},
+		{"azure sas", "https://a.blob.core.windows.net/c/k?se=2024-01-02T04:04:05Z&sig=x", issued.Add(time.Hour), true},
+		{"jwt token", "https://drs.example/k?token=" + token, time.Unix(issued.Add(time.Hour).Unix(), 0), true},
+		{"unsigned", "https://drs.example/k", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Expiry(tt.url, issued)
+			if ok != tt.ok || !got.Equal(tt.want) {
+				t.Fatalf("Expiry() = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCacheRefreshesBeforeExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cache := NewCache()
+	cache.now = func() time.Time { return now }
+	key1 := Key{Remote: "origin", ObjectID: "obj-1", AccessID: "s3"}
+
+	cache.Put(key1, drsapi.AccessURL{Url: "https://b.example/k?X-Amz-Date=20240102T030405Z&X-Amz-Expires=600"})
+	if _, ok := cache.Get(key1); !ok {
+		t.Fatalf("expected fresh URL to be cached")
+	}
+
+	now = now.Add(9 * time.Minute)
+	if _, ok := cache.Get(key1); ok {
+		t.Fatalf("expected URL within refresh margin to be evicted")
+	}
+}
+
+func TestCacheDefaultTTLForUnsignedURLs(t *testing.T) {
+	now := time.Now()
+	cache := NewCache()
+	cache.now = func() time.Time { return now }
+
+	key2 := Key{Remote: "origin", ObjectID: "obj-2", AccessID: "s3"}
+
+	cache.Put(key2, drsapi.AccessURL{Url: "https://drs.example/plain"})
+	if _, ok := cache.Get(key2); !ok {
+		t.Fatalf("expected unsigned URL cached for default TTL")
+	}
+	cache.Forget("origin", "obj-2")
+	if _, ok := cache.Get(key2); ok {
+		t.Fatalf("expected forgotten URL to be gone")
+	}
+}
+
+func TestCacheSeparatesRemotesAndAccessMethods(t *testing.T) {
+	cache := NewCache()
+	origin := Key{Remote: "origin", ObjectID: "obj", AccessID: "s3"}
+	cache.Put(origin, drsapi.AccessURL{Url: "https://origin.example/k"})
+	cache.Put(Key{Remote: "origin", ObjectID: "obj", AccessID: "gs"}, drsapi.AccessURL{Url: "https://origin.example/gs"})
+
+	if _, ok := cache.Get(Key{Remote: "mirror", ObjectID: "obj", AccessID: "s3"}); ok {
+		t.Fatal("a URL signed by one remote must not answer for another")
+	}
+	if got, ok := cache.Get(origin); !ok || got.Url != "https://origin.example/k" {
+		t.Fatalf("Get(origin) = %v, %v", got, ok)
+	}
+	cache.Forget("mirror", "obj")
+	if _, ok := cache.Get(origin); !ok {
+		t.Fatal("forgetting another remote's object must keep origin's URL")
+	}
+	cache.Forget("origin", "obj")
+	if _, ok := cache.Get(Key{Remote: "origin", ObjectID: "obj", AccessID: "gs"}); ok {
+		t.Fatal("expected Forget to drop every access method of the object")
+	}
+
+	var none *Cache
+	none.Put(origin, drsapi.AccessURL{Url: "https://origin.example/k"})
+	if _, ok := none.Get(origin); ok {
+		t.Fatal("a nil cache caches nothing")
+	}
+}
@@ -0,0 +1,21 @@
+package storageclass
+
+import (
+	"github.com/calypr/git-drs/internal/drsobject"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+// AliasPrefix marks the alias that carries an object's Hint as JSON (see
+// drsobject.AliasMetadata), so the record says which class and tags its
+// content was uploaded with.
+const AliasPrefix = "git-drs-storage:"
+
+// FromObject returns the storage hint on obj, if it has one.
+func FromObject(obj *drsapi.DrsObject) (Hint, bool) {
+	return drsobject.AliasMetadata(obj, AliasPrefix, func(h Hint) bool { return !h.Empty() })
+}
+
+// SetOnObject records h on obj, replacing any earlier hint.
+func SetOnObject(obj *drsapi.DrsObject, h Hint) {
+	drsobject.SetAliasMetadata(obj, AliasPrefix, h)
+}
@@ -0,0 +1,179 @@
+// Package storageclass picks the S3 storage class and object tags an upload
+// is written with, from per-pattern rules:
+//
+//	drs.storage.class   <pattern>=<class>        e.g. *.bam=GLACIER_IR
+//	drs.storage.tag     <pattern>=<key>=<value>  e.g. raw/**=retention=7y
+//
+// Patterns follow the drs.register.* conventions. The first class rule that
+// matches a path wins; every matching tag rule applies, a later rule
+// overriding an earlier one's key.
+package storageclass
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/calypr/git-drs/internal/registerpolicy"
+)
+
+// Classes are the storage classes a rule may name.
+var Classes = []string{"STANDARD", "STANDARD_IA", "ONEZONE_IA", "INTELLIGENT_TIERING", "GLACIER_IR", "GLACIER", "DEEP_ARCHIVE"}
+
+// ClassRule stores files matching Pattern in Class.
+type ClassRule struct {
+	Pattern string
+	Class   string
+}
+
+// TagRule tags objects for files matching Pattern with Key=Value.
+type TagRule struct {
+	Pattern string
+	Key     string
+	Value   string
+}
+
+// Rules holds the configured class and tag rules.
+type Rules struct {
+	Classes []ClassRule
+	Tags    []TagRule
+}
+
+// ParseClassRule parses one drs.storage.class value.
+func ParseClassRule(raw string) (ClassRule, error) {
+	pattern, class, ok := strings.Cut(strings.TrimSpace(raw), "=")
+	class = strings.ToUpper(strings.TrimSpace(class))
+	if !ok || class == "" {
+		return ClassRule{}, fmt.Errorf("invalid storage class rule %q: expected <pattern>=<class>", raw)
+	}
+	pattern, err := parsePattern(raw, pattern)
+	if err != nil {
+		return ClassRule{}, err
+	}
+	if !slices.Contains(Classes, class) {
+		return ClassRule{}, fmt.Errorf("invalid storage class rule %q: unknown class %s, expected one of %s", raw, class, strings.Join(Classes, ", "))
+	}
+	return ClassRule{Pattern: pattern, Class: class}, nil
+}
+
+// ParseTagRule parses one drs.storage.tag value.
+func ParseTagRule(raw string) (TagRule, error) {
+	pattern, tag, ok := strings.Cut(strings.TrimSpace(raw), "=")
+	key, value, hasValue := strings.Cut(tag, "=")
+	key = strings.TrimSpace(key)
+	if !ok || !hasValue || key == "" {
+		return TagRule{}, fmt.Errorf("invalid storage tag rule %q: expected <pattern>=<key>=<value>", raw)
+	}
+	// Hints travel in record aliases, which may not contain whitespace.
+	if strings.ContainsAny(key+strings.TrimSpace(value), " \t\r\n") {
+		return TagRule{}, fmt.Errorf("invalid storage tag rule %q: tag keys and values may not contain whitespace", raw)
+	}
+	pattern, err := parsePattern(raw, pattern)
+	if err != nil {
+		return TagRule{}, err
+	}
+	return TagRule{Pattern: pattern, Key: key, Value: strings.TrimSpace(value)}, nil
+}
+
+func parsePattern(raw, pattern string) (string, error) {
+	patterns, err := registerpolicy.ParsePatterns(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid storage rule %q: %w", raw, err)
+	}
+	if len(patterns) != 1 {
+		return "", fmt.Errorf("invalid storage rule %q: expected a single pattern", raw)
+	}
+	return patterns[0], nil
+}
+
+// ParseRules parses every drs.storage.class and drs.storage.tag value.
+func ParseRules(classes, tags []string) (Rules, error) {
+	var rules Rules
+	for _, raw := range classes {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		r, err := ParseClassRule(raw)
+		if err != nil {
+			return Rules{}, err
+		}
+		rules.Classes = append(rules.Classes, r)
+	}
+	for _, raw := range tags {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		r, err := ParseTagRule(raw)
+		if err != nil {
+			return Rules{}, err
+		}
+		rules.Tags = append(rules.Tags, r)
+	}
+	return rules, nil
+}
+
+// Enabled reports whether any rule is configured.
+func (r Rules) Enabled() bool {
+	return len(r.Classes) > 0 || len(r.Tags) > 0
+}
+
+// HintFor returns the class and tags for the repo-relative path.
+func (r Rules) HintFor(file string) Hint {
+	var h Hint
+	for _, rule := range r.Classes {
+		if registerpolicy.Match(rule.Pattern, file) {
+			h.Class = rule.Class
+			break
+		}
+	}
+	for _, rule := range r.Tags {
+		if registerpolicy.Match(rule.Pattern, file) {
+			if h.Tags == nil {
+				h.Tags = map[string]string{}
+			}
+			h.Tags[rule.Key] = rule.Value
+		}
+	}
+	return h
+}
+
+// Hint is the storage class and tags chosen for one object.
+type Hint struct {
+	Class string            `json:"class,omitempty"`
+	Tags  map[string]string `json:"tags,omitempty"`
+}
+
+// Empty reports whether h asks for nothing beyond the bucket's defaults.
+func (h Hint) Empty() bool {
+	return h.Class == "" && len(h.Tags) == 0
+}
+
+// Tagging formats the tags as the URL query S3's x-amz-tagging takes.
+func (h Hint) Tagging() string {
+	keys := make([]string, 0, len(h.Tags))
+	for k := range h.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, url.QueryEscape(k)+"="+url.QueryEscape(h.Tags[k]))
+	}
+	return strings.Join(parts, "&")
+}
+
+type hintKey struct{}
+
+// WithHint returns ctx carrying h for the S3 requests an upload makes.
+func WithHint(ctx context.Context, h Hint) context.Context {
+	return context.WithValue(ctx, hintKey{}, h)
+}
+
+// HintFrom returns the hint WithHint stored on ctx.
+func HintFrom(ctx context.Context) (Hint, bool) {
+	h, ok := ctx.Value(hintKey{}).(Hint)
+	return h, ok && !h.Empty()
+}
@@ -0,0 +1,54 @@
+package storageclass
+
+import (
+	"context"
+	"testing"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]string{"raw/**=deep_archive", "*.bam=GLACIER_IR", ""}, []string{"*.bam=retention=7y", "raw/**=retention=30y"})
+	if err != nil {
+		t.Fatalf("ParseRules: %v", err)
+	}
+	if got := rules.HintFor("raw/run1/reads.bam"); got.Class != "DEEP_ARCHIVE" || got.Tags["retention"] != "30y" {
+		t.Fatalf("expected first class rule and last tag rule to win, got %+v", got)
+	}
+	if got := rules.HintFor("aligned/reads.bam"); got.Class != "GLACIER_IR" || got.Tagging() != "retention=7y" {
+		t.Fatalf("unexpected hint %+v", got)
+	}
+	if got := rules.HintFor("notes.txt"); !got.Empty() {
+		t.Fatalf("expected no hint, got %+v", got)
+	}
+
+	for _, raw := range []string{"*.bam", "*.bam=COLD", "=GLACIER", "[=GLACIER"} {
+		if _, err := ParseClassRule(raw); err == nil {
+			t.Errorf("ParseClassRule(%q): expected error", raw)
+		}
+	}
+	for _, raw := range []string{"*.bam=retention", "*.bam==7y", "*.bam=owner=a lab"} {
+		if _, err := ParseTagRule(raw); err == nil {
+			t.Errorf("ParseTagRule(%q): expected error", raw)
+		}
+	}
+}
+
+func TestHintTravelsOnRecordAndContext(t *testing.T) {
+	h := Hint{Class: "GLACIER", Tags: map[string]string{"a b": "c&d"}}
+	obj := &drsapi.DrsObject{}
+	SetOnObject(obj, h)
+	got, ok := FromObject(obj)
+	if !ok || got.Class != "GLACIER" || got.Tags["a b"] != "c&d" {
+		t.Fatalf("unexpected hint from record %+v (ok %v)", got, ok)
+	}
+	if got.Tagging() != "a+b=c%26d" {
+		t.Fatalf("unexpected tagging %q", got.Tagging())
+	}
+	if _, ok := HintFrom(context.Background()); ok {
+		t.Fatal("expected no hint on a bare context")
+	}
+	if got, ok := HintFrom(WithHint(context.Background(), h)); !ok || got.Class != "GLACIER" {
+		t.Fatalf("unexpected hint from context %+v", got)
+	}
+}
@@ -0,0 +1,231 @@
+// Package e2e runs the git-drs binary end to end: it builds git-drs once per
+// test binary, puts it on PATH in an isolated HOME, and drives real git and
+// git drs commands in scratch repositories that share a bare git remote and
+// an in-memory DRS server (see Server). Hooks and filters therefore run
+// exactly as they do for users.
+package e2e
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Organization and Project are the DRS scope every Env's repositories use.
+const (
+	Organization = "e2e"
+	Project      = "flow"
+)
+
+var (
+	buildOnce sync.Once
+	binDir    string
+	buildErr  error
+	buildOut  []byte
+)
+
+// buildBinary compiles git-drs into a temporary directory once per test
+// binary and returns that directory.
+func buildBinary(t *testing.T) string {
+	t.Helper()
+	buildOnce.Do(func() {
+		var root []byte
+		root, buildErr = exec.Command("go", "list", "-m", "-f", "{{.Dir}}").Output()
+		if buildErr != nil {
+			return
+		}
+		binDir, buildErr = os.MkdirTemp("", "git-drs-e2e-bin-")
+		if buildErr != nil {
+			return
+		}
+		cmd := exec.Command("go", "build", "-o", filepath.Join(binDir, "git-drs"), ".")
+		cmd.Dir = strings.TrimSpace(string(root))
+		buildOut, buildErr = cmd.CombinedOutput()
+	})
+	if buildErr != nil {
+		t.Fatalf("build git-drs: %v\n%s", buildErr, buildOut)
+	}
+	return binDir
+}
+
+// Env is one end-to-end scenario: a DRS server, a bare git remote, and an
+// isolated environment to run commands in.
+type Env struct {
+	t *testing.T
+	// Dir holds the remote and the repositories.
+	Dir string
+	// Remote is the path of the bare git repository used as origin.
+	Remote string
+	Server *Server
+	env    []string
+}
+
+// New sets up an Env. It skips the test in -short mode or when git is not
+// installed.
+func New(t *testing.T) *Env {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping end-to-end test in -short mode")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("skipping end-to-end test: git is not installed")
+	}
+	bin := buildBinary(t)
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home")
+	if err := os.MkdirAll(home, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	e := &Env{
+		t:      t,
+		Dir:    dir,
+		Remote: filepath.Join(dir, "remote.git"),
+		Server: NewServer(t, Organization, Project),
+	}
+	e.env = append(os.Environ(),
+		"PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"),
+		"HOME="+home,
+		"XDG_CONFIG_HOME="+filepath.Join(home, ".config"),
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_CONFIG_GLOBAL="+filepath.Join(home, ".gitconfig"),
+		"GIT_AUTHOR_NAME=git-drs e2e",
+		"GIT_AUTHOR_EMAIL=e2e@example.com",
+		"GIT_COMMITTER_NAME=git-drs e2e",
+		"GIT_COMMITTER_EMAIL=e2e@example.com",
+	)
+	e.run(dir, "git", "config", "--global", "init.defaultBranch", "main")
+	e.run(dir, "git", "config", "--global", "push.autoSetupRemote", "true")
+	e.run(dir, "git", "init", "--bare", e.Remote)
+	return e
+}
+
+// NewRepo creates a repository named name with origin pointing at the bare
+// remote, git-drs initialized, and the DRS remote configured.
+func (e *Env) NewRepo(name string) *Repo {
+	e.t.Helper()
+	r := &Repo{env: e, Dir: filepath.Join(e.Dir, name)}
+	e.run(e.Dir, "git", "init", r.Dir)
+	r.Git("remote", "add", "origin", e.Remote)
+	r.setupDRS()
+	return r
+}
+
+// Clone clones the bare remote into name and configures git-drs in it, as a
+// collaborator would. Tracked files are pointers until `git drs pull`.
+func (e *Env) Clone(name string) *Repo {
+	e.t.Helper()
+	r := &Repo{env: e, Dir: filepath.Join(e.Dir, name)}
+	e.run(e.Dir, "git", "clone", e.Remote, r.Dir)
+	r.setupDRS()
+	return r
+}
+
+func (e *Env) run(dir, name string, args ...string) string {
+	e.t.Helper()
+	out, err := e.exec(dir, name, args...)
+	if err != nil {
+		e.t.Fatalf("%s %s: %v\n%s", name, strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+func (e *Env) exec(dir, name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Env = e.env
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return stdout.String() + stderr.String(), err
+	}
+	return stdout.String(), nil
+}
+
+// Repo is a working repository inside an Env.
+type Repo struct {
+	env *Env
+	Dir string
+}
+
+func (r *Repo) setupDRS() {
+	r.env.t.Helper()
+	s := r.env.Server
+	r.Drs("init")
+	r.Drs("remote", "add", "local", "origin", s.URL, Organization+"/"+Project,
+		"--username", s.Username, "--password", s.Password)
+}
+
+// Git runs git in the repository and returns its stdout, failing the test
+// on error.
+func (r *Repo) Git(args ...string) string {
+	r.env.t.Helper()
+	return r.env.run(r.Dir, "git", args...)
+}
+
+// Drs runs `git drs` with args in the repository and returns its stdout,
+// failing the test on error.
+func (r *Repo) Drs(args ...string) string {
+	r.env.t.Helper()
+	return r.Git(append([]string{"drs"}, args...)...)
+}
+
+// TryDrs runs `git drs` with args in the repository and returns its output
+// and exit code, for commands expected to fail. Output includes stderr when
+// the command fails.
+func (r *Repo) TryDrs(args ...string) (string, int) {
+	r.env.t.Helper()
+	out, err := r.Run("git", append([]string{"drs"}, args...)...)
+	if err == nil {
+		return out, 0
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		r.env.t.Fatalf("git drs %s: %v", strings.Join(args, " "), err)
+	}
+	return out, exitErr.ExitCode()
+}
+
+// Run runs name with args in the repository and returns its stdout, or
+// stdout and stderr together when it fails.
+func (r *Repo) Run(name string, args ...string) (string, error) {
+	return r.env.exec(r.Dir, name, args...)
+}
+
+// WriteFile writes data to the repository-relative path rel.
+func (r *Repo) WriteFile(rel, data string) {
+	r.env.t.Helper()
+	path := filepath.Join(r.Dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		r.env.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		r.env.t.Fatal(err)
+	}
+}
+
+// ReadFile returns the worktree content of the repository-relative path rel.
+func (r *Repo) ReadFile(rel string) string {
+	r.env.t.Helper()
+	data, err := os.ReadFile(filepath.Join(r.Dir, filepath.FromSlash(rel)))
+	if err != nil {
+		r.env.t.Fatal(err)
+	}
+	return string(data)
+}
+
+// In returns the repository as seen from its subdirectory rel, so commands
+// run there as they would for a user who cd'd into it.
+func (r *Repo) In(rel string) *Repo {
+	r.env.t.Helper()
+	dir := filepath.Join(r.Dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		r.env.t.Fatal(err)
+	}
+	return &Repo{env: r.env, Dir: dir}
+}
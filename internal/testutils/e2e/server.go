@@ -0,0 +1,439 @@
+package e2e
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+const (
+	drsPrefix  = "/ga4gh/drs/v1/objects"
+	blobPrefix = "/blob/"
+)
+
+// Server is an in-memory stand-in for a syfon DRS server and its bucket. It
+// serves the DRS and data endpoints git-drs uses, and hands out
+// "signed" URLs under /blob/ that store and serve object bytes itself, so a
+// push uploads through the same code paths as against real storage.
+type Server struct {
+	URL      string
+	Bucket   string
+	Org      string
+	Project  string
+	Username string
+	Password string
+
+	mu       sync.Mutex
+	nextID   int
+	records  map[string]drsapi.DrsObject
+	blobs    map[string][]byte
+	requests []string
+	faults   Faults
+	rng      *rand.Rand
+}
+
+// Faults configures failures the Server injects, so tests can exercise
+// git-drs error paths. Counted faults apply to the next n matching requests
+// and are used up as they fire; rates apply to each matching request.
+type Faults struct {
+	// Only restricts every fault to requests whose path contains it, for
+	// example an oid to fault a single object's blob.
+	Only string
+	// Latency delays every response.
+	Latency time.Duration
+	// APIErrors answers the next n DRS and data API requests with 503 and
+	// Retry-After: 0, which the client retries without waiting.
+	APIErrors int
+	// APIErrorRate answers this fraction of DRS and data API requests the
+	// same way. The draw is seeded, so a test fails the same requests on
+	// every run.
+	APIErrorRate float64
+	// TruncatedBlobs sends the first half of the next n blob downloads and
+	// then drops the connection.
+	TruncatedBlobs int
+	// ExpiredURLs answers the next n blob requests with the 403 storage
+	// returns for an expired signed URL.
+	ExpiredURLs int
+	// RejectUploads answers every upload URL request with 403, as a server
+	// without write access to its bucket does. The failure comes after the
+	// push registered its records.
+	RejectUploads bool
+}
+
+// SetFaults replaces the faults the Server injects; the zero value turns
+// injection off.
+func (s *Server) SetFaults(f Faults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = f
+}
+
+// Faults returns the faults still configured, so tests can check that
+// counted faults fired.
+func (s *Server) Faults() Faults {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.faults
+}
+
+// NewServer starts a Server for organization/project, whose bucket is
+// mapped to that project by /data/buckets. It stops when the test ends.
+func NewServer(t *testing.T, org, project string) *Server {
+	t.Helper()
+	s := &Server{
+		Bucket:   "e2e-bucket",
+		Org:      org,
+		Project:  project,
+		Username: "drs-user",
+		Password: "drs-pass",
+		records:  map[string]drsapi.DrsObject{},
+		blobs:    map[string][]byte{},
+		rng:      rand.New(rand.NewPCG(1, 2)),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL
+	return s
+}
+
+// Requests returns "METHOD path" for every request served so far, for
+// failure messages.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Records returns the registered DRS objects, ordered by ID.
+func (s *Server) Records() []drsapi.DrsObject {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]drsapi.DrsObject, 0, len(s.records))
+	for _, rec := range s.records {
+		out = append(out, rec)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Id < out[j].Id })
+	return out
+}
+
+// RecordsFor returns the records whose sha256 checksum is oid.
+func (s *Server) RecordsFor(oid string) []drsapi.DrsObject {
+	var out []drsapi.DrsObject
+	for _, rec := range s.Records() {
+		if sha256Of(rec) == oid {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// Blob returns the bytes stored for a record's s3 access URL.
+func (s *Server) Blob(rec drsapi.DrsObject) ([]byte, bool) {
+	key := storageKey(rec)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.blobs[key]
+	return data, ok
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	s.mu.Unlock()
+
+	if s.injectFault(w, r) {
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, blobPrefix) {
+		s.serveBlob(w, r)
+		return
+	}
+	if user, pass, ok := r.BasicAuth(); !ok || user != s.Username || pass != s.Password {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"msg": "unauthorized"})
+		return
+	}
+
+	path := r.URL.Path
+	switch {
+	case r.Method == http.MethodGet && path == "/data/buckets":
+		writeJSON(w, http.StatusOK, map[string]any{"S3_BUCKETS": map[string]any{
+			s.Bucket: map[string]any{"programs": []string{"/organization/" + s.Org + "/project/" + s.Project}},
+		}})
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/data/upload/"):
+		s.signedURL(w, strings.TrimPrefix(path, "/data/upload/"))
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/data/download/"):
+		s.signedURL(w, strings.TrimPrefix(path, "/data/download/"))
+	case r.Method == http.MethodGet && strings.HasPrefix(path, drsPrefix+"/checksum/"):
+		s.getByChecksum(w, strings.TrimPrefix(path, drsPrefix+"/checksum/"))
+	case r.Method == http.MethodPost && path == drsPrefix+"/register":
+		s.register(w, r)
+	case r.Method == http.MethodPost && path == drsPrefix+"/access":
+		s.bulkAccessURLs(w, r)
+	case r.Method == http.MethodGet && strings.Contains(path, "/access/"):
+		id, _, _ := strings.Cut(strings.TrimPrefix(path, drsPrefix+"/"), "/access/")
+		s.accessURL(w, id)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, drsPrefix+"/"):
+		s.getObject(w, strings.TrimPrefix(path, drsPrefix+"/"))
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"msg": "no route for " + r.Method + " " + path})
+	}
+}
+
+// injectFault applies the configured faults to r and reports whether it
+// answered the request.
+func (s *Server) injectFault(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	f := &s.faults
+	if f.Only != "" && !strings.Contains(r.URL.Path, f.Only) {
+		s.mu.Unlock()
+		return false
+	}
+	latency := f.Latency
+	blob := strings.HasPrefix(r.URL.Path, blobPrefix)
+	var apiError, expired, truncate, reject bool
+	switch {
+	case !blob && f.RejectUploads && strings.HasPrefix(r.URL.Path, "/data/upload/"):
+		reject = true
+	case !blob:
+		apiError = f.APIErrors > 0 || (f.APIErrorRate > 0 && s.rng.Float64() < f.APIErrorRate)
+		if f.APIErrors > 0 {
+			f.APIErrors--
+		}
+	case f.ExpiredURLs > 0:
+		f.ExpiredURLs--
+		expired = true
+	case r.Method == http.MethodGet && f.TruncatedBlobs > 0:
+		f.TruncatedBlobs--
+		truncate = true
+	}
+	s.mu.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+	switch {
+	case apiError:
+		w.Header().Set("Retry-After", "0")
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"msg": "injected fault"})
+	case expired:
+		writeS3Error(w, "AccessDenied", "Request has expired")
+	case reject:
+		writeJSON(w, http.StatusForbidden, map[string]string{"msg": "no write access to bucket " + s.Bucket})
+	case truncate:
+		s.serveTruncated(w, strings.TrimPrefix(r.URL.Path, blobPrefix))
+	default:
+		return false
+	}
+	return true
+}
+
+// serveTruncated announces the full length of key's blob but sends only the
+// first half, so the server closes the connection mid-body.
+func (s *Server) serveTruncated(w http.ResponseWriter, key string) {
+	s.mu.Lock()
+	data, ok := s.blobs[key]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "no such key", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data[:len(data)/2])
+}
+
+func (s *Server) getByChecksum(w http.ResponseWriter, sum string) {
+	matches := []drsapi.DrsObject{}
+	for _, rec := range s.Records() {
+		if sha256Of(rec) == sum {
+			matches = append(matches, rec)
+		}
+	}
+	writeJSON(w, http.StatusOK, drsapi.N200OkDrsObjects{ResolvedDrsObject: &matches})
+}
+
+func (s *Server) getObject(w http.ResponseWriter, id string) {
+	id, _ = url.PathUnescape(id)
+	s.mu.Lock()
+	rec, ok := s.records[id]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"msg": "object not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+func (s *Server) register(w http.ResponseWriter, r *http.Request) {
+	var body drsapi.RegisterObjectsBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"msg": err.Error()})
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	created := make([]drsapi.DrsObject, 0, len(body.Candidates))
+	for _, c := range body.Candidates {
+		s.nextID++
+		id := fmt.Sprintf("00000000-0000-4000-8000-%012d", s.nextID)
+		rec := drsapi.DrsObject{
+			Id:               id,
+			SelfUri:          "drs://" + strings.TrimPrefix(s.URL, "http://") + "/" + id,
+			Checksums:        c.Checksums,
+			Contents:         c.Contents,
+			ControlledAccess: c.ControlledAccess,
+			Aliases:          c.Aliases,
+			Description:      c.Description,
+			MimeType:         c.MimeType,
+			Name:             c.Name,
+			Size:             c.Size,
+			Version:          c.Version,
+			CreatedTime:      now,
+			UpdatedTime:      &now,
+		}
+		if c.AccessMethods != nil {
+			methods := make([]drsapi.AccessMethod, len(*c.AccessMethods))
+			for i, am := range *c.AccessMethods {
+				if am.AccessId == nil {
+					accessID := string(am.Type)
+					am.AccessId = &accessID
+				}
+				methods[i] = am
+			}
+			rec.AccessMethods = &methods
+		}
+		s.records[id] = rec
+		created = append(created, rec)
+	}
+	writeJSON(w, http.StatusCreated, drsapi.N201ObjectsCreated{Objects: created})
+}
+
+func (s *Server) accessURL(w http.ResponseWriter, id string) {
+	if u, ok := s.blobURL(id); ok {
+		writeJSON(w, http.StatusOK, drsapi.AccessURL{Url: u})
+		return
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"msg": "object not found"})
+}
+
+func (s *Server) bulkAccessURLs(w http.ResponseWriter, r *http.Request) {
+	var body drsapi.BulkObjectAccessId
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"msg": err.Error()})
+		return
+	}
+	resolved := []drsapi.BulkAccessURL{}
+	if body.BulkObjectAccessIds != nil {
+		for _, item := range *body.BulkObjectAccessIds {
+			if item.BulkObjectId == nil {
+				continue
+			}
+			if u, ok := s.blobURL(*item.BulkObjectId); ok {
+				resolved = append(resolved, drsapi.BulkAccessURL{DrsObjectId: item.BulkObjectId, Url: u})
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, drsapi.N200OkAccesses{ResolvedDrsObjectAccessUrls: &resolved})
+}
+
+// signedURL answers the data service's upload and download URL requests.
+func (s *Server) signedURL(w http.ResponseWriter, id string) {
+	if u, ok := s.blobURL(id); ok {
+		writeJSON(w, http.StatusOK, map[string]string{"url": u})
+		return
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"msg": "object not found"})
+}
+
+// blobURL is the "signed" storage URL for the record id.
+func (s *Server) blobURL(id string) (string, bool) {
+	id, _ = url.PathUnescape(id)
+	s.mu.Lock()
+	rec, ok := s.records[id]
+	s.mu.Unlock()
+	if !ok {
+		return "", false
+	}
+	return s.URL + blobPrefix + storageKey(rec) + "?signature=e2e", true
+}
+
+// serveBlob stands in for presigned storage URLs: PUT stores the body and
+// GET serves it, honouring Range headers. Multipart uploads are not
+// implemented; end-to-end fixtures stay below the multipart threshold.
+func (s *Server) serveBlob(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, blobPrefix)
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.blobs[key] = data
+		s.mu.Unlock()
+		sum := sha256.Sum256(data)
+		w.Header().Set("ETag", strconv.Quote(hex.EncodeToString(sum[:])))
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		s.mu.Lock()
+		data, ok := s.blobs[key]
+		s.mu.Unlock()
+		if !ok {
+			http.Error(w, "no such key", http.StatusNotFound)
+			return
+		}
+		http.ServeContent(w, r, key, time.Time{}, bytes.NewReader(data))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func sha256Of(rec drsapi.DrsObject) string {
+	for _, c := range rec.Checksums {
+		if strings.EqualFold(strings.ReplaceAll(c.Type, "-", ""), "sha256") {
+			return c.Checksum
+		}
+	}
+	return ""
+}
+
+// storageKey is the bucket/key of a record's s3 access URL.
+func storageKey(rec drsapi.DrsObject) string {
+	if rec.AccessMethods != nil {
+		for _, am := range *rec.AccessMethods {
+			if am.AccessUrl != nil && strings.HasPrefix(am.AccessUrl.Url, "s3://") {
+				return strings.TrimPrefix(am.AccessUrl.Url, "s3://")
+			}
+		}
+	}
+	return rec.Id
+}
+
+func writeS3Error(w http.ResponseWriter, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusForbidden)
+	fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Error><Code>%s</Code><Message>%s</Message></Error>", code, message)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,162 @@
+package throttle
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule is a list of windows in local time during which transfers may
+// run. An empty Schedule allows transfers at any time.
+type Schedule []Window
+
+// Window allows transfers on Days between Start and End, given as minutes
+// after midnight. A window whose End is not after its Start runs past
+// midnight into the next day; one without times covers the whole day.
+type Window struct {
+	Days       [7]bool // indexed by time.Weekday
+	Start, End int
+	AllDay     bool
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseSchedule parses windows separated by ";", each an optional list of
+// days followed by an optional time range, for example
+// "Mon-Fri 18:00-08:00; Sat,Sun" or "22:00-06:00". A window without days
+// applies every day.
+func ParseSchedule(raw string) (Schedule, error) {
+	var s Schedule
+	for _, part := range strings.Split(raw, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		w, err := parseWindow(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", raw, err)
+		}
+		s = append(s, w)
+	}
+	return s, nil
+}
+
+func parseWindow(raw string) (Window, error) {
+	var w Window
+	fields := strings.Fields(raw)
+	if len(fields) > 2 {
+		return w, fmt.Errorf("window %q: expected [days] [HH:MM-HH:MM]", raw)
+	}
+	days, times := "", ""
+	for _, f := range fields {
+		if strings.Contains(f, ":") {
+			times = f
+		} else if days == "" && times == "" {
+			days = f
+		} else {
+			return w, fmt.Errorf("window %q: expected [days] [HH:MM-HH:MM]", raw)
+		}
+	}
+
+	if days == "" {
+		for d := range w.Days {
+			w.Days[d] = true
+		}
+	}
+	for _, item := range strings.Split(days, ",") {
+		if item == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(strings.ToLower(item), "-")
+		first, ok1 := weekdays[from]
+		last, ok2 := first, true
+		if isRange {
+			last, ok2 = weekdays[to]
+		}
+		if !ok1 || !ok2 {
+			return w, fmt.Errorf("window %q: unknown day %q; use Mon, Tue, ... Sun", raw, item)
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			w.Days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+
+	if times == "" {
+		w.AllDay = true
+		return w, nil
+	}
+	from, to, ok := strings.Cut(times, "-")
+	if !ok {
+		return w, fmt.Errorf("window %q: expected a time range such as 18:00-08:00", raw)
+	}
+	var err error
+	if w.Start, err = parseClock(from); err != nil {
+		return w, fmt.Errorf("window %q: %w", raw, err)
+	}
+	if w.End, err = parseClock(to); err != nil {
+		return w, fmt.Errorf("window %q: %w", raw, err)
+	}
+	if w.Start == w.End {
+		w.AllDay = true
+	}
+	return w, nil
+}
+
+func parseClock(raw string) (int, error) {
+	t, err := time.Parse("15:04", raw)
+	if err != nil {
+		if raw == "24:00" {
+			return 24 * 60, nil
+		}
+		return 0, fmt.Errorf("invalid time %q: expected HH:MM", raw)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Allows reports whether t falls in any window.
+func (s Schedule) Allows(t time.Time) bool {
+	if len(s) == 0 {
+		return true
+	}
+	day := t.Weekday()
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range s {
+		switch {
+		case w.AllDay:
+			if w.Days[day] {
+				return true
+			}
+		case w.Start < w.End:
+			if w.Days[day] && minute >= w.Start && minute < w.End {
+				return true
+			}
+		default:
+			if (w.Days[day] && minute >= w.Start) || (w.Days[(day+6)%7] && minute < w.End) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NextOpen returns the first minute at or after t that s allows, or t when
+// s allows t.
+func (s Schedule) NextOpen(t time.Time) time.Time {
+	if s.Allows(t) {
+		return t
+	}
+	next := t.Truncate(time.Minute)
+	for range 8 * 24 * 60 {
+		next = next.Add(time.Minute)
+		if s.Allows(next) {
+			return next
+		}
+	}
+	return t.Add(maxScheduleSleep)
+}
@@ -0,0 +1,264 @@
+// Package throttle caps the bandwidth of object transfers and holds them to
+// allowed time windows. One Limiter is shared by every upload and download in
+// the process, so concurrent transfers split the budget between them.
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Swapped in tests.
+var (
+	now   = time.Now
+	sleep = func(ctx context.Context, d time.Duration) error {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		}
+	}
+)
+
+// chunkSize is how many bytes a throttled read takes at a time, so one large
+// read does not hold the whole budget.
+const chunkSize = 32 * 1024
+
+// maxScheduleSleep bounds one wait for a window to open, so a clock change
+// or suspended laptop is noticed.
+const maxScheduleSleep = time.Minute
+
+// Settings configures a Limiter. The zero value limits nothing.
+type Settings struct {
+	// BytesPerSecond caps the combined transfer rate; 0 means unlimited.
+	BytesPerSecond int64
+	// Schedule lists when transfers may run; empty means always.
+	Schedule Schedule
+}
+
+// Enabled reports whether s limits anything.
+func (s Settings) Enabled() bool {
+	return s.BytesPerSecond > 0 || len(s.Schedule) > 0
+}
+
+// Limiter is a token bucket holding one second of transfer, gated by a
+// schedule. A nil Limiter limits nothing.
+type Limiter struct {
+	settings Settings
+	logger   *slog.Logger
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	// waiting is set while a transfer waits for a window, so the wait is
+	// logged once rather than by every stream.
+	waiting bool
+}
+
+// New returns a Limiter for s, or nil when s limits nothing. Waits for a
+// schedule window are logged to logger when it is non-nil.
+func New(s Settings, logger *slog.Logger) *Limiter {
+	if !s.Enabled() {
+		return nil
+	}
+	return &Limiter{settings: s, logger: logger, tokens: float64(s.BytesPerSecond), last: now()}
+}
+
+// WaitN blocks until n bytes may be transferred: first until the schedule
+// allows transfers, then until the bucket holds n tokens. Tokens are
+// reserved up front, so concurrent callers are served in arrival order.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+	if err := l.waitForWindow(ctx); err != nil {
+		return err
+	}
+	rate := float64(l.settings.BytesPerSecond)
+	if rate <= 0 || n <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	t := now()
+	l.tokens = math.Min(rate, l.tokens+t.Sub(l.last).Seconds()*rate)
+	l.last = t
+	l.tokens -= float64(n)
+	deficit := -l.tokens
+	l.mu.Unlock()
+	if deficit <= 0 {
+		return nil
+	}
+	return sleep(ctx, time.Duration(deficit/rate*float64(time.Second)))
+}
+
+func (l *Limiter) waitForWindow(ctx context.Context) error {
+	for {
+		t := now()
+		if l.settings.Schedule.Allows(t) {
+			return nil
+		}
+		next := l.settings.Schedule.NextOpen(t)
+		l.mu.Lock()
+		if !l.waiting && l.logger != nil {
+			l.logger.Info(fmt.Sprintf("transfers paused outside the configured schedule; resuming at %s", next.Format("Mon 15:04")))
+		}
+		l.waiting = true
+		l.mu.Unlock()
+		if err := sleep(ctx, min(next.Sub(t), maxScheduleSleep)); err != nil {
+			return err
+		}
+		l.mu.Lock()
+		l.waiting = false
+		l.mu.Unlock()
+	}
+}
+
+// Reader throttles reads from r.
+func (l *Limiter) Reader(ctx context.Context, r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	return &reader{ctx: ctx, r: r, l: l}
+}
+
+type reader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *Limiter
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) > chunkSize {
+		p = p[:chunkSize]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.l.WaitN(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (l *Limiter) readCloser(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	return readCloser{Reader: l.Reader(ctx, rc), Closer: rc}
+}
+
+// Transport throttles the request and response bodies of requests for which
+// limited returns true, and holds them until the schedule allows transfers.
+// Other requests pass through.
+func Transport(base http.RoundTripper, l *Limiter, limited func(*http.Request) bool) http.RoundTripper {
+	if l == nil {
+		return base
+	}
+	return &transport{base: base, l: l, limited: limited}
+}
+
+type transport struct {
+	base    http.RoundTripper
+	l       *Limiter
+	limited func(*http.Request) bool
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.limited != nil && !t.limited(req) {
+		return t.base.RoundTrip(req)
+	}
+	return t.l.do(req, t.base.RoundTrip)
+}
+
+// Doer is the HTTP client interface of the AWS SDK.
+type Doer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// Client throttles every request sent through d.
+func Client(d Doer, l *Limiter) Doer {
+	if l == nil || d == nil {
+		return d
+	}
+	return &client{d: d, l: l}
+}
+
+type client struct {
+	d Doer
+	l *Limiter
+}
+
+func (c *client) Do(req *http.Request) (*http.Response, error) {
+	return c.l.do(req, c.d.Do)
+}
+
+func (l *Limiter) do(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	ctx := req.Context()
+	if err := l.waitForWindow(ctx); err != nil {
+		return nil, err
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(ctx)
+		req.Body = l.readCloser(ctx, req.Body)
+		if getBody := req.GetBody; getBody != nil {
+			req.GetBody = func() (io.ReadCloser, error) {
+				body, err := getBody()
+				if err != nil {
+					return nil, err
+				}
+				return l.readCloser(ctx, body), nil
+			}
+		}
+	}
+	resp, err := send(req)
+	if resp != nil && resp.Body != nil {
+		resp.Body = l.readCloser(ctx, resp.Body)
+	}
+	return resp, err
+}
+
+// ParseBandwidth parses a rate such as "10MB/s", "500KiB", or "100Mbit/s"
+// into bytes per second. A bare number is bytes per second; "", "0", and
+// "unlimited" mean no limit.
+func ParseBandwidth(raw string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	if s == "" || s == "0" || s == "unlimited" {
+		return 0, nil
+	}
+	s = strings.TrimSuffix(s, "/s")
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	num, unit := s, ""
+	if i >= 0 {
+		num, unit = s[:i], strings.TrimSpace(s[i:])
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid bandwidth %q: expected a rate such as 10MB/s or 100Mbit/s", raw)
+	}
+	mult, ok := bandwidthUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid bandwidth %q: unknown unit %q", raw, unit)
+	}
+	return int64(v * mult), nil
+}
+
+var bandwidthUnits = map[string]float64{
+	"": 1, "b": 1,
+	"k": 1e3, "kb": 1e3, "kib": 1 << 10,
+	"m": 1e6, "mb": 1e6, "mib": 1 << 20,
+	"g": 1e9, "gb": 1e9, "gib": 1 << 30,
+	"kbit": 1e3 / 8, "mbit": 1e6 / 8, "gbit": 1e9 / 8,
+}
@@ -0,0 +1,157 @@
+package throttle
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock replaces now and sleep so waits advance a virtual clock.
+func fakeClock(t *testing.T, start time.Time) *time.Time {
+	t.Helper()
+	clock := start
+	origNow, origSleep := now, sleep
+	now = func() time.Time { return clock }
+	sleep = func(ctx context.Context, d time.Duration) error {
+		clock = clock.Add(d)
+		return ctx.Err()
+	}
+	t.Cleanup(func() { now, sleep = origNow, origSleep })
+	return &clock
+}
+
+func TestParseBandwidth(t *testing.T) {
+	tests := map[string]int64{
+		"":           0,
+		"unlimited":  0,
+		"2048":       2048,
+		"10MB/s":     10_000_000,
+		"500KiB":     500 * 1024,
+		"1.5 GB/s":   1_500_000_000,
+		"100Mbit/s":  12_500_000,
+		" 1 mib/s  ": 1 << 20,
+	}
+	for raw, want := range tests {
+		got, err := ParseBandwidth(raw)
+		if err != nil || got != want {
+			t.Errorf("ParseBandwidth(%q) = %d, %v; want %d", raw, got, err, want)
+		}
+	}
+	for _, bad := range []string{"fast", "10XB/s", "-5MB", "MB/s"} {
+		if _, err := ParseBandwidth(bad); err == nil {
+			t.Errorf("ParseBandwidth(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestScheduleAllows(t *testing.T) {
+	s, err := ParseSchedule("Mon-Fri 18:00-08:00; Sat,Sun")
+	if err != nil {
+		t.Fatalf("ParseSchedule: %v", err)
+	}
+	// 2026-10-12 is a Monday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, 12+day, hour, minute, 0, 0, time.Local)
+	}
+	tests := []struct {
+		when time.Time
+		want bool
+	}{
+		{at(0, 7, 59), false}, // Monday morning: Sunday's window ended at midnight
+		{at(0, 12, 0), false},
+		{at(0, 18, 0), true},
+		{at(1, 7, 59), true}, // Monday's window runs into Tuesday
+		{at(1, 8, 0), false},
+		{at(5, 12, 0), true}, // Saturday, all day
+		{at(5, 7, 0), true},  // Friday's window, then Saturday
+	}
+	for _, tt := range tests {
+		if got := s.Allows(tt.when); got != tt.want {
+			t.Errorf("Allows(%s) = %v, want %v", tt.when.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+	if next := s.NextOpen(at(2, 9, 30)); !next.Equal(at(2, 18, 0)) {
+		t.Errorf("NextOpen = %s, want Wed 18:00", next.Format("Mon 15:04"))
+	}
+
+	for _, bad := range []string{"Funday", "Mon 25:00-08:00", "Mon 18:00", "Mon Tue 18:00-08:00"} {
+		if _, err := ParseSchedule(bad); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want an error", bad)
+		}
+	}
+	if s, err := ParseSchedule("  "); err != nil || len(s) != 0 || !s.Allows(time.Now()) {
+		t.Errorf("empty schedule = %v, %v; want one that always allows", s, err)
+	}
+}
+
+func TestLimiterPacesReads(t *testing.T) {
+	clock := fakeClock(t, time.Date(2026, 10, 12, 12, 0, 0, 0, time.Local))
+	start := *clock
+	l := New(Settings{BytesPerSecond: 100_000}, nil)
+
+	// The bucket starts full, so 400 kB takes three more seconds.
+	n, err := io.Copy(io.Discard, l.Reader(context.Background(), bytes.NewReader(make([]byte, 400_000))))
+	if err != nil || n != 400_000 {
+		t.Fatalf("copy = %d, %v", n, err)
+	}
+	if got := clock.Sub(start); got < 3*time.Second-time.Millisecond || got > 3*time.Second+time.Millisecond {
+		t.Fatalf("copy took %s, want 3s", got)
+	}
+}
+
+func TestLimiterWaitsForScheduleWindow(t *testing.T) {
+	// Monday 12:00, with transfers allowed from 18:00.
+	clock := fakeClock(t, time.Date(2026, 10, 12, 12, 0, 0, 0, time.Local))
+	schedule, err := ParseSchedule("18:00-08:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(Settings{Schedule: schedule}, nil)
+
+	var sent time.Time
+	rt := Transport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = *clock
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	}), l, func(req *http.Request) bool { return req.URL.Host == "bucket.example" })
+
+	api, _ := http.NewRequest(http.MethodGet, "https://drs.example/ga4gh/drs/v1/objects/x", nil)
+	if _, err := rt.RoundTrip(api); err != nil || !sent.Equal(time.Date(2026, 10, 12, 12, 0, 0, 0, time.Local)) {
+		t.Fatalf("API request was held: sent at %s, %v", sent.Format("15:04"), err)
+	}
+	blob, _ := http.NewRequest(http.MethodGet, "https://bucket.example/key", nil)
+	if _, err := rt.RoundTrip(blob); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	if sent.Hour() != 18 || sent.Minute() != 0 {
+		t.Fatalf("storage request sent at %s, want 18:00", sent.Format("15:04"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	*clock = time.Date(2026, 10, 13, 12, 0, 0, 0, time.Local)
+	if err := l.WaitN(ctx, 1); err == nil {
+		t.Fatal("WaitN outside the window ignored a cancelled context")
+	}
+}
+
+func TestNilLimiterPassesThrough(t *testing.T) {
+	if New(Settings{}, nil) != nil {
+		t.Fatal("zero settings built a limiter")
+	}
+	var l *Limiter
+	r := strings.NewReader("x")
+	if l.Reader(context.Background(), r) != r {
+		t.Fatal("nil limiter wrapped the reader")
+	}
+	if Transport(http.DefaultTransport, nil, nil) != http.DefaultTransport {
+		t.Fatal("nil limiter wrapped the transport")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
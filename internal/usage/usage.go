@@ -0,0 +1,127 @@
+// Package usage records the downloads git-drs performs, so data stewards can
+// report on how datasets are used from repositories. Each verified download
+// can be appended to a ledger in the git directory and posted to a webhook;
+// both are off unless Settings enables them.
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/calypr/git-drs/internal/audit"
+	"github.com/calypr/git-drs/internal/common"
+	"github.com/calypr/git-drs/internal/httpclient"
+	"github.com/calypr/git-drs/internal/projectdir"
+)
+
+// LedgerFile is the repo-relative ledger path. It lives in the git directory
+// because downloads are per clone and should not be committed.
+var LedgerFile = filepath.Join(common.DRS_DIR, "usage", "downloads.jsonl")
+
+// Settings selects where downloads are recorded.
+type Settings struct {
+	// Ledger appends each download to LedgerFile.
+	Ledger bool
+	// Webhook is a URL each download is posted to as JSON.
+	Webhook string
+}
+
+// Enabled reports whether any output is configured.
+func (s Settings) Enabled() bool {
+	return s.Ledger || strings.TrimSpace(s.Webhook) != ""
+}
+
+// Download is one ledger line and webhook body.
+type Download struct {
+	Time         string `json:"time"`
+	User         string `json:"user"`
+	Remote       string `json:"remote,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Project      string `json:"project,omitempty"`
+	DRSID        string `json:"drs_id"`
+	OID          string `json:"oid"`
+	Bytes        int64  `json:"bytes"`
+}
+
+var (
+	mu sync.Mutex
+	// now, actingUser and httpClient are swapped in tests.
+	now        = time.Now
+	actingUser = sync.OnceValue(audit.ActingUser)
+	httpClient = httpclient.New(10 * time.Second)
+)
+
+// Record writes d to the outputs s enables, filling in the time and acting
+// user. Both outputs are attempted; their errors are joined.
+func Record(ctx context.Context, s Settings, d Download) error {
+	if !s.Enabled() {
+		return nil
+	}
+	if d.Time == "" {
+		d.Time = now().UTC().Format(time.RFC3339)
+	}
+	if d.User == "" {
+		d.User = actingUser()
+	}
+	line, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	if s.Ledger {
+		if err := appendLine(projectdir.Path(LedgerFile), line); err != nil {
+			errs = append(errs, fmt.Errorf("usage ledger: %w", err))
+		}
+	}
+	if hook := strings.TrimSpace(s.Webhook); hook != "" {
+		if err := post(ctx, hook, line); err != nil {
+			errs = append(errs, fmt.Errorf("usage webhook: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func appendLine(path string, line []byte) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func post(ctx context.Context, target string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("POST %s: %s %s", target, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordWritesLedgerAndPostsWebhook(t *testing.T) {
+	t.Chdir(t.TempDir())
+	LedgerFile = filepath.Join(t.TempDir(), "downloads.jsonl")
+	now = func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }
+	actingUser = func() string { return "Ada <ada@example.org>" }
+
+	var posted Download
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &posted); err != nil {
+			t.Errorf("decode webhook body: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	s := Settings{Ledger: true, Webhook: srv.URL}
+	d := Download{Remote: "origin", Project: "proj", DRSID: "did-1", OID: "abc", Bytes: 42}
+	for range 2 {
+		if err := Record(context.Background(), s, d); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	want := Download{Time: "2026-03-01T12:00:00Z", User: "Ada <ada@example.org>", Remote: "origin", Project: "proj", DRSID: "did-1", OID: "abc", Bytes: 42}
+	if posted != want {
+		t.Fatalf("posted %+v, want %+v", posted, want)
+	}
+	data, err := os.ReadFile(LedgerFile)
+	if err != nil {
+		t.Fatalf("read ledger: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 ledger lines, got %d:\n%s", len(lines), data)
+	}
+	var got Download
+	if err := json.Unmarshal([]byte(lines[1]), &got); err != nil || got != want {
+		t.Fatalf("ledger line %+v (%v), want %+v", got, err, want)
+	}
+}
+
+func TestRecordReportsWebhookFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	actingUser = func() string { return "ada" }
+
+	err := Record(context.Background(), Settings{Webhook: srv.URL}, Download{DRSID: "did-1"})
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("expected webhook error, got %v", err)
+	}
+	if err := Record(context.Background(), Settings{}, Download{}); err != nil {
+		t.Fatalf("disabled settings should record nothing: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+// Package userconfig loads the user-level git-drs configuration that every
+// repository inherits from, typically ~/.config/git-drs/config.yaml.
+package userconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EnvPath points at an explicit user config file, bypassing XDG lookup.
+	EnvPath  = "GIT_DRS_GLOBAL_CONFIG"
+	dirName  = "git-drs"
+	fileName = "config.yaml"
+)
+
+// Config is the on-disk shape of the user-level config file.
+type Config struct {
+	DefaultRemote string            `yaml:"default_remote"`
+	Remotes       map[string]Remote `yaml:"remotes"`
+	Logging       Logging           `yaml:"logging"`
+	Transfer      Transfer          `yaml:"transfer"`
+	Metrics       Metrics           `yaml:"metrics"`
+	Upload        Upload            `yaml:"upload"`
+}
+
+// Remote mirrors the repo-local drs.remote.<name>.* keys.
+type Remote struct {
+	Type          string `yaml:"type"`
+	Endpoint      string `yaml:"endpoint"`
+	Organization  string `yaml:"organization"`
+	Project       string `yaml:"project"`
+	Bucket        string `yaml:"bucket"`
+	StoragePrefix string `yaml:"storage_prefix"`
+	Profile       string `yaml:"profile"`
+	Auth          string `yaml:"auth"`
+	// CredentialStore is "file" (the default) or "keyring".
+	CredentialStore string `yaml:"credential_store"`
+	ReadOnly        bool   `yaml:"read_only"`
+}
+
+// Logging holds logger defaults.
+type Logging struct {
+	Level string `yaml:"level"`
+}
+
+// Transfer holds transfer defaults. Zero values mean "not set".
+type Transfer struct {
+	Concurrency int `yaml:"concurrency"`
+	// UploadWorkers and DownloadWorkers override Concurrency for one
+	// direction; see drs.transfer.upload-workers.
+	UploadWorkers        int   `yaml:"upload_workers"`
+	DownloadWorkers      int   `yaml:"download_workers"`
+	MultipartThresholdMB int   `yaml:"multipart_threshold_mb"`
+	Upsert               *bool `yaml:"upsert"`
+	// MaxBandwidth and Schedule limit object transfers; see
+	// drs.transfer.max-bandwidth and drs.transfer.schedule.
+	MaxBandwidth string `yaml:"max_bandwidth"`
+	Schedule     string `yaml:"schedule"`
+}
+
+// Metrics holds metrics reporting defaults. Zero values mean "not set".
+type Metrics struct {
+	Summary      *bool  `yaml:"summary"`
+	Pushgateway  string `yaml:"pushgateway"`
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+	Job          string `yaml:"job"`
+}
+
+// Upload holds upload defaults. Zero values mean "not set".
+type Upload struct {
+	CredentialSource string `yaml:"credential_source"`
+	Region           string `yaml:"region"`
+	Endpoint         string `yaml:"endpoint"`
+	Method           string `yaml:"method"`
+}
+
+// Path returns the user config file location. GIT_DRS_GLOBAL_CONFIG wins,
+// then $XDG_CONFIG_HOME/git-drs/config.yaml, then ~/.config/git-drs/config.yaml.
+func Path() (string, error) {
+	if p := strings.TrimSpace(os.Getenv(EnvPath)); p != "" {
+		return p, nil
+	}
+	if xdg := strings.TrimSpace(os.Getenv("XDG_CONFIG_HOME")); xdg != "" {
+		return filepath.Join(xdg, dirName, fileName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", dirName, fileName), nil
+}
+
+// Load reads the user config. A missing file yields an empty config.
+func Load() (*Config, error) {
+	path, err := Path()
+	if err != nil {
+		return &Config{}, nil
+	}
+	return LoadFile(path)
+}
+
+// LoadFile reads a user config from path. A missing file yields an empty config.
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("read user config %s: %w", path, err)
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse user config %s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,76 @@
+package userconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPathPrecedence(t *testing.T) {
+	t.Setenv(EnvPath, "")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	if got, _ := Path(); got != filepath.Join("/xdg", "git-drs", "config.yaml") {
+		t.Fatalf("Path() = %q, want XDG location", got)
+	}
+
+	t.Setenv(EnvPath, "/explicit/config.yaml")
+	if got, _ := Path(); got != "/explicit/config.yaml" {
+		t.Fatalf("Path() = %q, want explicit override", got)
+	}
+}
+
+func TestLoadFileMissingReturnsEmpty(t *testing.T) {
+	cfg, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFile error: %v", err)
+	}
+	if cfg.DefaultRemote != "" || len(cfg.Remotes) != 0 {
+		t.Fatalf("expected empty config, got %#v", cfg)
+	}
+}
+
+func TestLoadFileParsesSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `default_remote: prod
+remotes:
+  prod:
+    type: gen3
+    endpoint: https://prod.example
+    organization: org
+    project: proj
+    bucket: prod-bucket
+logging:
+  level: warn
+transfer:
+  concurrency: 8
+  multipart_threshold_mb: 256
+  upsert: true
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile error: %v", err)
+	}
+	if cfg.DefaultRemote != "prod" || cfg.Remotes["prod"].Bucket != "prod-bucket" {
+		t.Fatalf("unexpected remotes: %#v", cfg)
+	}
+	if cfg.Logging.Level != "warn" || cfg.Transfer.Concurrency != 8 || cfg.Transfer.MultipartThresholdMB != 256 {
+		t.Fatalf("unexpected settings: %#v", cfg)
+	}
+	if cfg.Transfer.Upsert == nil || !*cfg.Transfer.Upsert {
+		t.Fatalf("expected upsert=true")
+	}
+}
+
+func TestLoadFileRejectsInvalidYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("remotes: [unterminated"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
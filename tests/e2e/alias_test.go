@@ -0,0 +1,42 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/testutils/e2e"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func TestAliasesRegisterAndResolve(t *testing.T) {
+	env := e2e.New(t)
+
+	repo := env.NewRepo("repo")
+	repo.Drs("track", "*.bin")
+	content := "alias payload\n"
+	oid := oidOf(content)
+	repo.WriteFile("a.bin", content)
+	repo.Git("add", ".gitattributes", "a.bin")
+	repo.Git("commit", "-m", "add data")
+	repo.Drs("alias", "add", "a.bin", "ACC-1")
+	repo.Drs("push", "origin")
+
+	records := env.Server.RecordsFor(oid)
+	if len(records) != 1 || records[0].Aliases == nil || !slices.Contains(*records[0].Aliases, "ACC-1") {
+		t.Fatalf("expected registered record to carry ACC-1, got %+v", records)
+	}
+
+	for _, id := range []string{"ACC-1", "drs://drs.example.org/" + records[0].Id} {
+		var obj drsapi.DrsObject
+		if err := json.Unmarshal([]byte(repo.Drs("query", "--json", id)), &obj); err != nil {
+			t.Fatalf("query %s: %v", id, err)
+		}
+		if obj.Id != records[0].Id {
+			t.Fatalf("query %s resolved %q, want %q", id, obj.Id, records[0].Id)
+		}
+	}
+	if _, code := repo.TryDrs("alias", "add", "a.bin", "id:mine"); code == 0 {
+		t.Fatal("expected reserved alias to be rejected")
+	}
+}
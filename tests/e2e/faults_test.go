@@ -0,0 +1,123 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/drserrors"
+	"github.com/calypr/git-drs/internal/testutils/e2e"
+)
+
+// pushed sets up a repository whose files have been pushed, and a clone of
+// it that still holds pointers.
+func pushed(t *testing.T, files map[string]string) (*e2e.Env, *e2e.Repo) {
+	t.Helper()
+	env := e2e.New(t)
+	repo := env.NewRepo("repo")
+	repo.Drs("track", "*.bin")
+	repo.Git("add", ".gitattributes")
+	for name, content := range files {
+		repo.WriteFile(name, content)
+		repo.Git("add", name)
+	}
+	repo.Git("commit", "-m", "add data")
+	repo.Drs("push", "origin")
+	return env, env.Clone("clone")
+}
+
+func TestPushRetriesServerErrors(t *testing.T) {
+	env := e2e.New(t)
+	repo := env.NewRepo("repo")
+	repo.Drs("track", "*.bin")
+	repo.WriteFile("a.bin", "flaky server\n")
+	repo.Git("add", ".gitattributes", "a.bin")
+	repo.Git("commit", "-m", "add")
+
+	env.Server.SetFaults(e2e.Faults{APIErrors: 3, APIErrorRate: 0.2})
+	repo.Drs("push", "origin")
+
+	if left := env.Server.Faults().APIErrors; left != 0 {
+		t.Fatalf("%d injected API errors never fired", left)
+	}
+	assertStored(t, env, "flaky server\n")
+}
+
+func TestPullRecoversFromTruncatedDownload(t *testing.T) {
+	env, clone := pushed(t, map[string]string{"a.bin": "truncate me please\n"})
+
+	env.Server.SetFaults(e2e.Faults{TruncatedBlobs: 1})
+	clone.Drs("pull", "origin")
+
+	if left := env.Server.Faults().TruncatedBlobs; left != 0 {
+		t.Fatal("truncated download never fired")
+	}
+	if got := clone.ReadFile("a.bin"); got != "truncate me please\n" {
+		t.Fatalf("a.bin after pull = %q", got)
+	}
+}
+
+func TestPullReSignsExpiredURL(t *testing.T) {
+	env, clone := pushed(t, map[string]string{"a.bin": "expired url\n"})
+
+	env.Server.SetFaults(e2e.Faults{ExpiredURLs: 1})
+	clone.Drs("pull", "origin")
+
+	if left := env.Server.Faults().ExpiredURLs; left != 0 {
+		t.Fatal("expired URL never fired")
+	}
+	if got := clone.ReadFile("a.bin"); got != "expired url\n" {
+		t.Fatalf("a.bin after pull = %q", got)
+	}
+}
+
+func TestFetchResumesAfterPartialFailure(t *testing.T) {
+	env, clone := pushed(t, map[string]string{
+		"a.bin": "fetched first time\n",
+		"b.bin": "fetched on resume\n",
+	})
+
+	env.Server.SetFaults(e2e.Faults{Only: oidOf("fetched on resume\n"), ExpiredURLs: 10})
+	out, code := clone.TryDrs("fetch", "--all", "origin")
+	if code != drserrors.ExitPartial {
+		t.Fatalf("fetch exit code = %d, want %d; output:\n%s", code, drserrors.ExitPartial, out)
+	}
+
+	env.Server.SetFaults(e2e.Faults{})
+	var res struct {
+		Objects int  `json:"objects"`
+		Fetched int  `json:"fetched"`
+		Resumed bool `json:"resumed"`
+	}
+	if err := json.Unmarshal([]byte(clone.Drs("fetch", "--all", "--json", "origin")), &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.Resumed || res.Fetched != 1 || res.Objects != 2 {
+		t.Fatalf("resumed fetch = %+v, want 1 of 2 objects fetched on resume", res)
+	}
+	clone.Drs("pull", "origin")
+	if got := clone.ReadFile("b.bin"); got != "fetched on resume\n" {
+		t.Fatalf("b.bin after pull = %q", got)
+	}
+}
+
+func TestFailedUploadDoesNotPushRefs(t *testing.T) {
+	env := e2e.New(t)
+	repo := env.NewRepo("repo")
+	repo.Drs("track", "*.bin")
+	repo.WriteFile("a.bin", "never uploaded\n")
+	repo.Git("add", ".gitattributes", "a.bin")
+	repo.Git("commit", "-m", "add")
+
+	env.Server.SetFaults(e2e.Faults{RejectUploads: true})
+	if out, code := repo.TryDrs("push", "origin"); code == 0 {
+		t.Fatalf("push succeeded with uploads rejected:\n%s", out)
+	}
+	if refs, _ := repo.Run("git", "--git-dir", env.Remote, "for-each-ref"); strings.TrimSpace(refs) != "" {
+		t.Fatalf("remote has refs after a failed push:\n%s", refs)
+	}
+
+	env.Server.SetFaults(e2e.Faults{})
+	repo.Drs("push", "origin")
+	assertStored(t, env, "never uploaded\n")
+}
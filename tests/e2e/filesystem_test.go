@@ -0,0 +1,37 @@
+package e2e_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/testutils/e2e"
+)
+
+func TestFilesystemRemoteAndReplicate(t *testing.T) {
+	env := e2e.New(t)
+	store := filepath.Join(env.Dir, "airgap-store")
+	scope := e2e.Organization + "/" + e2e.Project
+
+	repo := env.NewRepo("repo")
+	repo.Drs("remote", "add", "filesystem", "airgap", store, scope)
+	repo.Git("remote", "add", "airgap", env.Remote)
+	repo.Drs("track", "*.bin")
+	repo.WriteFile("data/a.bin", "air-gapped\n")
+	repo.Git("add", ".gitattributes", "data/a.bin")
+	repo.Git("commit", "-m", "add data")
+	repo.Drs("push", "airgap")
+
+	if n := len(env.Server.Records()); n != 0 {
+		t.Fatalf("server records after filesystem push = %d, want 0", n)
+	}
+
+	clone := env.Clone("clone")
+	clone.Drs("remote", "add", "filesystem", "airgap", store, scope)
+	clone.Drs("pull", "airgap")
+	if got := clone.ReadFile("data/a.bin"); got != "air-gapped\n" {
+		t.Fatalf("a.bin after pull = %q", got)
+	}
+
+	repo.Drs("replicate", "--from", "airgap", "--to", "origin")
+	assertStored(t, env, "air-gapped\n")
+}
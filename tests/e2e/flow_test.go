@@ -0,0 +1,116 @@
+package e2e_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/testutils/e2e"
+)
+
+func oidOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// assertStored checks the server holds exactly one record for content and
+// that its blob matches.
+func assertStored(t *testing.T, env *e2e.Env, content string) {
+	t.Helper()
+	recs := env.Server.RecordsFor(oidOf(content))
+	if len(recs) != 1 {
+		t.Fatalf("records for %q = %d, want 1; requests:\n%s", content, len(recs), strings.Join(env.Server.Requests(), "\n"))
+	}
+	blob, ok := env.Server.Blob(recs[0])
+	if !ok {
+		t.Fatalf("no blob uploaded for record %s", recs[0].Id)
+	}
+	if string(blob) != content {
+		t.Fatalf("blob for record %s = %q, want %q", recs[0].Id, blob, content)
+	}
+}
+
+func TestPushCloneAndPull(t *testing.T) {
+	env := e2e.New(t)
+
+	repo := env.NewRepo("repo")
+	repo.Drs("track", "*.bin")
+	repo.WriteFile("data/a.bin", "hello e2e\n")
+	repo.WriteFile("README.md", "not tracked\n")
+	repo.Git("add", ".gitattributes", "data/a.bin", "README.md")
+	repo.Git("commit", "-m", "add data")
+
+	committed := repo.Git("show", "HEAD:data/a.bin")
+	if !strings.HasPrefix(committed, "version https://git-lfs.github.com/spec/v1") {
+		t.Fatalf("committed a.bin is not a pointer:\n%s", committed)
+	}
+
+	repo.Drs("push", "origin")
+	assertStored(t, env, "hello e2e\n")
+	if n := len(env.Server.Records()); n != 1 {
+		t.Fatalf("server records = %d, want 1", n)
+	}
+
+	clone := env.Clone("clone")
+	if got := clone.ReadFile("data/a.bin"); !strings.HasPrefix(got, "version https://git-lfs.github.com/spec/v1") {
+		t.Fatalf("a.bin before pull = %q, want a pointer", got)
+	}
+	clone.Drs("pull", "origin")
+	if got := clone.ReadFile("data/a.bin"); got != "hello e2e\n" {
+		t.Fatalf("a.bin after pull = %q", got)
+	}
+	if got := clone.ReadFile("README.md"); got != "not tracked\n" {
+		t.Fatalf("README.md after pull = %q", got)
+	}
+	if status := clone.Git("status", "--short"); status != "" {
+		t.Fatalf("clone is dirty after pull:\n%s", status)
+	}
+}
+
+func TestPushFromCloneAddsNewObjectsOnly(t *testing.T) {
+	env := e2e.New(t)
+
+	repo := env.NewRepo("repo")
+	repo.Drs("track", "*.bin")
+	repo.WriteFile("a.bin", "first\n")
+	repo.Git("add", ".gitattributes", "a.bin")
+	repo.Git("commit", "-m", "first")
+	repo.Drs("push", "origin")
+
+	clone := env.Clone("clone")
+	clone.Drs("pull", "origin")
+	clone.WriteFile("b.bin", "second\n")
+	clone.Git("add", "b.bin")
+	clone.Git("commit", "-m", "second")
+	clone.Drs("push", "origin")
+
+	assertStored(t, env, "first\n")
+	assertStored(t, env, "second\n")
+	if n := len(env.Server.Records()); n != 2 {
+		t.Fatalf("server records = %d, want 2", n)
+	}
+
+	repo.Git("pull", "origin", "main")
+	repo.Drs("pull", "origin")
+	if got := repo.ReadFile("b.bin"); got != "second\n" {
+		t.Fatalf("b.bin after pull = %q", got)
+	}
+}
+
+func TestPlainGitPushRunsPrePushHook(t *testing.T) {
+	env := e2e.New(t)
+
+	repo := env.NewRepo("repo")
+	repo.Drs("track", "*.bin")
+	repo.WriteFile("a.bin", "via hook\n")
+	repo.Git("add", ".gitattributes", "a.bin")
+	repo.Git("commit", "-m", "add")
+
+	// The pre-push hook installed by `git drs init` must accept a plain
+	// git push.
+	repo.Git("push", "origin", "main")
+	if got := strings.TrimSpace(repo.Git("--git-dir", env.Remote, "rev-parse", "main")); got != strings.TrimSpace(repo.Git("rev-parse", "HEAD")) {
+		t.Fatalf("remote main = %s, want HEAD", got)
+	}
+}
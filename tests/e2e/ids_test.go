@@ -0,0 +1,61 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/testutils/e2e"
+	drsapi "github.com/calypr/syfon/apigen/client/drs"
+)
+
+func stagedObject(t *testing.T, repo *e2e.Repo, oid string) drsapi.DrsObject {
+	t.Helper()
+	var obj drsapi.DrsObject
+	raw := repo.ReadFile(".git/drs/lfs/objects/" + oid[:2] + "/" + oid[2:4] + "/" + oid)
+	if err := json.Unmarshal([]byte(raw), &obj); err != nil {
+		t.Fatalf("parse staged object: %v\n%s", err, raw)
+	}
+	return obj
+}
+
+func TestIDStrategies(t *testing.T) {
+	env := e2e.New(t)
+
+	repo := env.NewRepo("repo")
+	repo.Git("config", "drs.remote.origin.id-strategy", "path-hash")
+	repo.Git("config", "drs.remote.origin.id-prefix", "dg.E2E/")
+	repo.Drs("track", "*.bin")
+	content := "id strategy payload\n"
+	oid := oidOf(content)
+	repo.WriteFile("a.bin", content)
+	repo.Git("add", ".gitattributes", "a.bin")
+	repo.Git("commit", "-m", "add data")
+	repo.Drs("push", "origin")
+	out := repo.Drs("rekey")
+	if !strings.Contains(out, "a.bin\t(none) -> dg.E2E/") {
+		t.Fatalf("rekey output:\n%s", out)
+	}
+	minted := stagedObject(t, repo, oid).Id
+	if !strings.HasPrefix(minted, "dg.E2E/") {
+		t.Fatalf("staged ID under path-hash = %q, want the dg.E2E/ prefix", minted)
+	}
+
+	repo.Git("config", "drs.remote.origin.id-strategy", "server")
+	out = repo.Drs("rekey")
+	if !strings.Contains(out, "a.bin\t"+minted+" -> (none)") {
+		t.Fatalf("rekey output:\n%s", out)
+	}
+	if id := stagedObject(t, repo, oid).Id; id != "" {
+		t.Fatalf("staged ID after rekey to server = %q, want none", id)
+	}
+
+	repo.Drs("push", "origin")
+	records := env.Server.RecordsFor(oid)
+	if len(records) == 0 {
+		t.Fatal("object not registered")
+	}
+	if id := stagedObject(t, repo, oid).Id; id != records[0].Id {
+		t.Fatalf("staged ID after push = %q, want the server's %q", id, records[0].Id)
+	}
+}
@@ -0,0 +1,37 @@
+package e2e_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/testutils/e2e"
+)
+
+func TestPathScopesRegisterUnderTheirProject(t *testing.T) {
+	env := e2e.New(t)
+
+	repo := env.NewRepo("repo")
+	repo.Git("config", "--add", "drs.remote.origin.path-scope", "raw/="+e2e.Organization+"/rawdata")
+	repo.Drs("track", "*.bin")
+	repo.WriteFile("raw/a.bin", "raw bytes\n")
+	repo.WriteFile("derived/b.bin", "derived bytes\n")
+	repo.Git("add", ".gitattributes", "raw/a.bin", "derived/b.bin")
+	repo.Git("commit", "-m", "add data")
+	repo.Drs("push", "origin")
+
+	for content, project := range map[string]string{"raw bytes\n": "rawdata", "derived bytes\n": e2e.Project} {
+		assertStored(t, env, content)
+		rec := env.Server.RecordsFor(oidOf(content))[0]
+		want := "/organization/" + e2e.Organization + "/project/" + project
+		if rec.ControlledAccess == nil || !strings.Contains(strings.Join(*rec.ControlledAccess, ","), want) {
+			t.Errorf("record for %q has controlled_access %v, want %s", content, rec.ControlledAccess, want)
+		}
+	}
+
+	clone := env.Clone("clone")
+	clone.Git("config", "--add", "drs.remote.origin.path-scope", "raw/="+e2e.Organization+"/rawdata")
+	clone.Drs("pull", "origin")
+	if got := clone.ReadFile("raw/a.bin"); got != "raw bytes\n" {
+		t.Fatalf("raw/a.bin after pull = %q", got)
+	}
+}
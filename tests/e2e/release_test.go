@@ -0,0 +1,83 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/testutils/e2e"
+)
+
+func TestReleaseCreateAndVerify(t *testing.T) {
+	env := e2e.New(t)
+
+	repo := env.NewRepo("repo")
+	repo.Drs("track", "*.bin")
+	repo.WriteFile("data/a.bin", "release a\n")
+	repo.WriteFile("data/b.bin", "release b\n")
+	repo.Git("add", ".gitattributes", "data/a.bin", "data/b.bin")
+	repo.Git("commit", "-m", "add data")
+	repo.Git("tag", "v1.0")
+
+	if out, code := repo.TryDrs("release", "create", "v1.0"); code == 0 || !strings.Contains(out, "not registered") {
+		t.Fatalf("release of unpushed files exited %d\n%s", code, out)
+	}
+	repo.Drs("push", "origin")
+	repo.Drs("release", "create", "v1.0", "--bundle")
+
+	if got := strings.TrimSpace(repo.Git("log", "-1", "--format=%s")); got != "Release v1.0" {
+		t.Fatalf("last commit = %q, want the release commit", got)
+	}
+	var manifest struct {
+		Commit   string `json:"commit"`
+		BundleID string `json:"bundle_id"`
+		Objects  []struct {
+			Path  string `json:"path"`
+			DRSID string `json:"drs_id"`
+		} `json:"objects"`
+	}
+	path := filepath.Join(repo.Dir, ".drs", "releases", "v1.0.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Objects) != 2 || manifest.Objects[0].Path != "data/a.bin" || manifest.Objects[0].DRSID != env.Server.RecordsFor(oidOf("release a\n"))[0].Id {
+		t.Fatalf("manifest objects = %+v", manifest.Objects)
+	}
+	if manifest.Commit != strings.TrimSpace(repo.Git("rev-parse", "v1.0")) {
+		t.Fatalf("manifest commit = %s, want v1.0", manifest.Commit)
+	}
+	var bundle *[]string
+	for _, rec := range env.Server.Records() {
+		if rec.Id == manifest.BundleID && rec.Contents != nil {
+			names := []string{}
+			for _, c := range *rec.Contents {
+				names = append(names, c.Name)
+			}
+			bundle = &names
+		}
+	}
+	if bundle == nil || len(*bundle) != 2 {
+		t.Fatalf("bundle %s not registered with two contents", manifest.BundleID)
+	}
+
+	if out := repo.Drs("release", "verify", "v1.0"); !strings.Contains(out, "2 objects resolve") {
+		t.Fatalf("verify output:\n%s", out)
+	}
+	if out, code := repo.TryDrs("release", "create", "v1.0"); code == 0 || !strings.Contains(out, "already exists") {
+		t.Fatalf("re-creating a release exited %d\n%s", code, out)
+	}
+
+	tampered := strings.Replace(string(data), "data/a.bin", "data/z.bin", 1)
+	if err := os.WriteFile(path, []byte(tampered), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if out, code := repo.TryDrs("release", "verify", "v1.0"); code == 0 || !strings.Contains(out, "digest mismatch") {
+		t.Fatalf("verify of an edited manifest exited %d\n%s", code, out)
+	}
+}
@@ -0,0 +1,52 @@
+package e2e_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/calypr/git-drs/internal/testutils/e2e"
+)
+
+func TestCommandsFromSubdirectory(t *testing.T) {
+	env := e2e.New(t)
+
+	repo := env.NewRepo("repo")
+	sub := repo.In("data/sub")
+	sub.Drs("track", "*.bin")
+	if attrs := repo.ReadFile(".gitattributes"); !strings.HasPrefix(attrs, "data/sub/*.bin filter=drs") {
+		t.Fatalf("track from a subdirectory wrote:\n%s", attrs)
+	}
+	contentA, contentB := "subdir payload a\n", "subdir payload b\n"
+	sub.WriteFile("a.bin", contentA)
+	sub.WriteFile("b.bin", contentB)
+	repo.Git("add", ".")
+	repo.Git("commit", "-m", "add data")
+	sub.Drs("alias", "add", "a.bin", "SUB-1")
+	sub.Drs("push", "origin")
+	assertStored(t, env, contentA)
+	assertStored(t, env, contentB)
+	if out, _ := repo.Run("git", "status", "--porcelain", "--ignored", "data"); out != "" {
+		t.Fatalf("commands left files in the subdirectory:\n%s", out)
+	}
+	if out := sub.Drs("query", "SUB-1"); !strings.Contains(out, oidOf(contentA)) {
+		t.Fatalf("query by alias from a subdirectory:\n%s", out)
+	}
+
+	clone := env.Clone("clone")
+	csub := clone.In("data/sub")
+	csub.Drs("pull", "-I", "a.bin")
+	if got := clone.ReadFile("data/sub/a.bin"); got != contentA {
+		t.Fatalf("pull -I a.bin hydrated %q", got)
+	}
+	if got := clone.ReadFile("data/sub/b.bin"); got == contentB {
+		t.Fatal("pull -I a.bin also hydrated b.bin")
+	}
+	out := csub.Drs("ls-files", "b.bin")
+	if !strings.Contains(out, "data/sub/b.bin") || strings.Contains(out, "a.bin") {
+		t.Fatalf("ls-files b.bin from a subdirectory:\n%s", out)
+	}
+	csub.Drs("rm", "b.bin")
+	if status := clone.Git("status", "--porcelain"); status != "D  data/sub/b.bin\n" {
+		t.Fatalf("rm from a subdirectory left status:\n%s", status)
+	}
+}
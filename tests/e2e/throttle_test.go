@@ -0,0 +1,34 @@
+package e2e_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/calypr/git-drs/internal/testutils/e2e"
+)
+
+func TestTransferBandwidthLimit(t *testing.T) {
+	env := e2e.New(t)
+
+	repo := env.NewRepo("repo")
+	repo.Git("config", "drs.transfer.max-bandwidth", "100KB/s")
+	repo.Drs("track", "*.bin")
+	content := strings.Repeat("0123456789", 30_000)
+	repo.WriteFile("big.bin", content)
+	repo.Git("add", ".gitattributes", "big.bin")
+	repo.Git("commit", "-m", "add data")
+
+	// 300 kB at 100 kB/s, less the one-second burst the limiter starts with.
+	start := time.Now()
+	repo.Drs("push", "origin")
+	if elapsed := time.Since(start); elapsed < 1500*time.Millisecond {
+		t.Errorf("push of 300 kB at 100KB/s took %s, want about 2s", elapsed)
+	}
+	assertStored(t, env, content)
+
+	repo.Git("config", "drs.transfer.schedule", "whenever")
+	if out, code := repo.TryDrs("push", "origin"); code == 0 || !strings.Contains(out, "drs.transfer.schedule") {
+		t.Fatalf("push with an invalid schedule exited %d\n%s", code, out)
+	}
+}